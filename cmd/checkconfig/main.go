@@ -46,11 +46,13 @@ import (
 	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
 	pluginsflagutil "sigs.k8s.io/prow/pkg/flagutil/plugins"
 	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/report"
 	_ "sigs.k8s.io/prow/pkg/hook/plugin-imports"
 	"sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/labels"
 	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/pjutil"
 	"sigs.k8s.io/prow/pkg/plank"
 	"sigs.k8s.io/prow/pkg/plugins"
 	"sigs.k8s.io/prow/pkg/plugins/approve"
@@ -82,6 +84,8 @@ type options struct {
 	expensive              bool
 	includeDefaultWarnings bool
 
+	effectivePluginsRepos flagutil.Strings
+
 	github  flagutil.GitHubOptions
 	storage flagutil.StorageClientOptions
 }
@@ -122,7 +126,10 @@ const (
 	validateGitHubAppInstallationWarning           = "validate-github-app-installation"
 	validateLabelWarning                           = "validate-label"
 	requiredJobAnnotationsWarning                  = "required-job-annotations"
+	jobOwnershipAnnotationsWarning                 = "job-ownership-annotations"
 	periodicDefaultCloneWarning                    = "periodic-default-clone-config"
+	labelPropagationWarning                        = "label-propagation"
+	gitHubReportTemplatesWarning                   = "validate-github-report-templates"
 
 	defaultHourlyTokens = 3000
 	defaultAllowedBurst = 100
@@ -147,7 +154,10 @@ var defaultWarnings = []string{
 	validateUnmanagedBranchConfigHasNoSubconfig,
 	validateLabelWarning,
 	requiredJobAnnotationsWarning,
+	jobOwnershipAnnotationsWarning,
 	periodicDefaultCloneWarning,
+	labelPropagationWarning,
+	gitHubReportTemplatesWarning,
 }
 
 var expensiveWarnings = []string{
@@ -184,6 +194,14 @@ func (o *options) DefaultAndValidate() error {
 	if o.prowYAMLPath != "" && o.prowYAMLRepoName == "" {
 		return errors.New("--prow-yaml-repo-path requires --prow-yaml-repo-name to be set")
 	}
+	if len(o.effectivePluginsRepos.Strings()) > 0 && o.pluginsConfig.PluginConfigPath == "" {
+		return errors.New("--print-effective-plugins requires --plugin-config to be set")
+	}
+	for _, orgRepo := range o.effectivePluginsRepos.Strings() {
+		if parts := strings.Split(orgRepo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("--print-effective-plugins value %q is not in org/repo format", orgRepo)
+		}
+	}
 	for _, warning := range o.warnings.Strings() {
 		found := false
 		for _, registeredWarning := range allWarnings {
@@ -218,6 +236,7 @@ func (o *options) gatherOptions(flag *flag.FlagSet, args []string) error {
 	flag.BoolVar(&o.expensive, "expensive-checks", false, "If set, additional expensive warnings will be enabled")
 	flag.BoolVar(&o.strict, "strict", false, "If set, consider all warnings as errors.")
 	flag.BoolVar(&o.includeDefaultWarnings, "include-default-warnings", false, "If set force inclusion of default warning set. Normally this is inferred based on a lack of '--warnings' flags.")
+	flag.Var(&o.effectivePluginsRepos, "print-effective-plugins", "Print the effective list of plugins enabled for the given org/repo and their approve, dco, lgtm and trigger settings after org-level inheritance, opt-outs and overrides. Use repeatedly to provide a list of repos")
 	o.github.AddCustomizedFlags(flag, throttlerDefaults)
 	o.github.AllowAnonymous = true
 	o.config.AddFlags(flag)
@@ -253,6 +272,38 @@ func main() {
 	}
 }
 
+// effectivePluginConfig is the plugin configuration of a repo after org-level
+// inheritance, opt-outs and overrides.
+type effectivePluginConfig struct {
+	Plugins []string         `json:"plugins"`
+	Approve *plugins.Approve `json:"approve"`
+	Dco     *plugins.Dco     `json:"dco"`
+	Lgtm    *plugins.Lgtm    `json:"lgtm"`
+	Trigger plugins.Trigger  `json:"trigger"`
+}
+
+// printEffectivePlugins writes the plugins that are effectively enabled for
+// each of the given org/repo strings, and their settings, as YAML.
+func printEffectivePlugins(pcfg *plugins.Configuration, orgRepos []string, w stdio.Writer) error {
+	effective := make(map[string]effectivePluginConfig, len(orgRepos))
+	for _, orgRepo := range orgRepos {
+		org, repo, _ := strings.Cut(orgRepo, "/")
+		effective[orgRepo] = effectivePluginConfig{
+			Plugins: pcfg.Plugins.EnabledPlugins(org, repo),
+			Approve: pcfg.ApproveFor(org, repo),
+			Dco:     pcfg.DcoFor(org, repo),
+			Lgtm:    pcfg.LgtmFor(org, repo),
+			Trigger: pcfg.TriggerFor(org, repo),
+		}
+	}
+	out, err := yaml.Marshal(effective)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
 func validate(o options) error {
 	// use all warnings by default
 	if len(o.warnings.Strings()) == 0 || o.includeDefaultWarnings {
@@ -287,6 +338,12 @@ func validate(o options) error {
 		pcfg = pluginAgent.Config()
 	}
 
+	if pcfg != nil && len(o.effectivePluginsRepos.Strings()) > 0 {
+		if err := printEffectivePlugins(pcfg, o.effectivePluginsRepos.Strings(), os.Stdout); err != nil {
+			return fmt.Errorf("error printing effective plugins: %w", err)
+		}
+	}
+
 	// the following checks are useful in finding user errors but their
 	// presence won't lead to strictly incorrect behavior, so we can
 	// detect them here but don't necessarily want to stop config re-load
@@ -449,6 +506,24 @@ func validate(o options) error {
 		}
 	}
 
+	if o.warningEnabled(jobOwnershipAnnotationsWarning) {
+		if err := validateJobOwnershipAnnotations(cfg.JobConfig); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if o.warningEnabled(labelPropagationWarning) {
+		if err := validateLabelPropagation(cfg.Plank.LabelPropagation, cfg.JobConfig); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if o.warningEnabled(gitHubReportTemplatesWarning) {
+		if err := report.ValidateTemplates(cfg.GitHubReporter); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	// validate rerun commands match presubmit job triggering regex
 	for _, presubmits := range cfg.JobConfig.PresubmitsStatic {
 		for _, p := range presubmits {
@@ -622,8 +697,8 @@ func validatePeriodicDefaultCloneConfig(cfg config.JobConfig) error {
 	var validationErrs []error
 	for _, job := range cfg.Periodics {
 		// Top level clone configs don't make sense for periodics jobs.
-		if job.CloneDepth != 0 || job.CloneURI != "" || job.PathAlias != "" {
-			validationErrs = append(validationErrs, fmt.Errorf("periodic jobs might clone 0, 1, or more repos, top level `clone_depth`, `clone_uri`, and `path_alias` don't have any effect. Name: %q", job.Name))
+		if job.CloneDepth != 0 || job.CloneURI != "" || job.PathAlias != "" || job.Filter != "" || job.SingleBranch {
+			validationErrs = append(validationErrs, fmt.Errorf("periodic jobs might clone 0, 1, or more repos, top level `clone_depth`, `clone_uri`, `path_alias`, `filter`, and `single_branch` don't have any effect. Name: %q", job.Name))
 		}
 	}
 	return utilerrors.NewAggregate(validationErrs)
@@ -1225,7 +1300,7 @@ func validateTideContextPolicy(cfg *config.Config) error {
 
 var agentsNotSupportingCluster = sets.New[string]("jenkins")
 
-func validateJobCluster(job config.JobBase, statuses map[string]plank.ClusterStatus) error {
+func validateJobCluster(job config.JobBase, statuses map[string]plank.ClusterStatus, architectures map[string][]string) error {
 	if job.Cluster != "" && job.Cluster != kube.DefaultClusterAlias && agentsNotSupportingCluster.Has(job.Agent) {
 		return fmt.Errorf("%s: cannot set cluster field if agent is %s", job.Name, job.Agent)
 	}
@@ -1238,6 +1313,15 @@ func validateJobCluster(job config.JobBase, statuses map[string]plank.ClusterSta
 			logrus.Warnf("Job configuration for %q specifies cluster %q which cannot be reached from Plank. Status: %q", job.Name, job.Cluster, status)
 		}
 	}
+	if job.Arch != "" && architectures != nil {
+		cluster := job.Cluster
+		if cluster == "" {
+			cluster = kube.DefaultClusterAlias
+		}
+		if !sets.New[string](architectures[cluster]...).Has(job.Arch) {
+			return fmt.Errorf("job configuration for %q specifies 'arch' value %q, but cluster %q only has nodes of architectures %v", job.Name, job.Arch, cluster, architectures[cluster])
+		}
+	}
 	return nil
 }
 
@@ -1265,20 +1349,20 @@ func validateCluster(cfg *config.Config, opener io.Opener) error {
 	var errs []error
 	for orgRepo, jobs := range cfg.PresubmitsStatic {
 		for _, job := range jobs {
-			if err := validateJobCluster(job.JobBase, statuses); err != nil {
+			if err := validateJobCluster(job.JobBase, statuses, cfg.Plank.ClusterArchitectures); err != nil {
 				errs = append(errs, fmt.Errorf("%s: %w", orgRepo, err))
 			}
 		}
 	}
 	for _, job := range cfg.Periodics {
-		if err := validateJobCluster(job.JobBase, statuses); err != nil {
+		if err := validateJobCluster(job.JobBase, statuses, cfg.Plank.ClusterArchitectures); err != nil {
 			errs = append(errs, fmt.Errorf("%s: %w", "invalid periodic job", err))
 		}
 
 	}
 	for orgRepo, jobs := range cfg.PostsubmitsStatic {
 		for _, job := range jobs {
-			if err := validateJobCluster(job.JobBase, statuses); err != nil {
+			if err := validateJobCluster(job.JobBase, statuses, cfg.Plank.ClusterArchitectures); err != nil {
 				errs = append(errs, fmt.Errorf("%s: %w", orgRepo, err))
 			}
 		}
@@ -1521,6 +1605,69 @@ func validateGitHubAppIsInstalled(client ghAppListingClient, allRepos sets.Set[s
 	return utilerrors.NewAggregate(errs)
 }
 
+// validateJobOwnershipAnnotations validates the owner, escalation and docs
+// annotations of all jobs that declare them.
+func validateJobOwnershipAnnotations(c config.JobConfig) error {
+	var errs []error
+	for _, presubmits := range c.PresubmitsStatic {
+		for _, presubmit := range presubmits {
+			if err := pjutil.ValidateOwnershipAnnotations(presubmit.Annotations); err != nil {
+				errs = append(errs, fmt.Errorf("job '%s' has invalid ownership annotations: %w", presubmit.Name, err))
+			}
+		}
+	}
+	for _, postsubmits := range c.PostsubmitsStatic {
+		for _, postsubmit := range postsubmits {
+			if err := pjutil.ValidateOwnershipAnnotations(postsubmit.Annotations); err != nil {
+				errs = append(errs, fmt.Errorf("job '%s' has invalid ownership annotations: %w", postsubmit.Name, err))
+			}
+		}
+	}
+	for _, periodic := range c.Periodics {
+		if err := pjutil.ValidateOwnershipAnnotations(periodic.Annotations); err != nil {
+			errs = append(errs, fmt.Errorf("job '%s' has invalid ownership annotations: %w", periodic.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateLabelPropagation checks that all jobs have the labels required by
+// the label propagation policy and that none of their labels is dropped from
+// their pods.
+func validateLabelPropagation(policy *config.LabelPropagationPolicy, c config.JobConfig) error {
+	validator := func(job config.JobBase) error {
+		var errs []error
+		if missing := policy.MissingRequiredLabels(job.Labels); len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("job '%s' is missing required labels: %s", job.Name, strings.Join(missing, ", ")))
+		}
+		if dropped := policy.DroppedPodLabels(job.Labels); len(dropped) > 0 {
+			errs = append(errs, fmt.Errorf("job '%s' has labels that are not propagated to its pods: %s", job.Name, strings.Join(dropped, ", ")))
+		}
+		return utilerrors.NewAggregate(errs)
+	}
+	var errs []error
+	for _, presubmits := range c.PresubmitsStatic {
+		for _, presubmit := range presubmits {
+			if err := validator(presubmit.JobBase); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, postsubmits := range c.PostsubmitsStatic {
+		for _, postsubmit := range postsubmits {
+			if err := validator(postsubmit.JobBase); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, periodic := range c.Periodics {
+		if err := validator(periodic.JobBase); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
 func validateRequiredJobAnnotations(a []string, c config.JobConfig) error {
 	validator := func(job config.JobBase, annotations []string) error {
 		var errs []error
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
@@ -1476,6 +1477,23 @@ func TestOptions(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "print-effective-plugins without plugin-config is invalid",
+			args: []string{
+				"--config-path=prow/config.yaml",
+				"--print-effective-plugins=org/repo",
+			},
+			expectedError: true,
+		},
+		{
+			name: "print-effective-plugins with invalid repo is invalid",
+			args: []string{
+				"--config-path=prow/config.yaml",
+				"--plugin-config=prow/plugins/plugin.yaml",
+				"--print-effective-plugins=org",
+			},
+			expectedError: true,
+		},
 		{
 			name: "prow-yaml-path without prow-yaml-repo-name is invalid",
 			args: []string{
@@ -1891,6 +1909,38 @@ func TestValidateClusterField(t *testing.T) {
 			clusterStatusFile: fmt.Sprintf(`{"default": %q, "build1": %q, "build2": %q}`, plank.ClusterStatusReachable, plank.ClusterStatusReachable, plank.ClusterStatusError),
 			expectedError:     "org1/repo1: job configuration for \"my-job\" specifies unknown 'cluster' value \"build3\"",
 		},
+		{
+			name: "arch declared for the cluster",
+			cfg: &config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{ClusterArchitectures: map[string][]string{"default": {"amd64"}, "arm": {"amd64", "arm64"}}},
+				},
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{
+						{JobBase: config.JobBase{Name: "my-job", Cluster: "arm", Arch: "arm64"}},
+						{JobBase: config.JobBase{Name: "other-job", Arch: "amd64"}},
+					}}},
+		},
+		{
+			name: "arch not declared for the cluster",
+			cfg: &config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{ClusterArchitectures: map[string][]string{"default": {"amd64"}, "arm": {"amd64", "arm64"}}},
+				},
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{
+						{JobBase: config.JobBase{Name: "my-job", Cluster: "default", Arch: "arm64"}},
+					}}},
+			expectedError: "invalid periodic job: job configuration for \"my-job\" specifies 'arch' value \"arm64\", but cluster \"default\" only has nodes of architectures [amd64]",
+		},
+		{
+			name: "arch is not validated without declared architectures",
+			cfg: &config.Config{
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{
+						{JobBase: config.JobBase{Name: "my-job", Arch: "arm64"}},
+					}}},
+		},
 		{
 			name: "cluster validation skipped if status file does not exist yet",
 			cfg: &config.Config{
@@ -2480,3 +2530,192 @@ func TestValidateRequiredJobAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateJobOwnershipAnnotations(t *testing.T) {
+	tc := []struct {
+		name        string
+		presubmits  []config.Presubmit
+		periodics   []config.Periodic
+		expectedErr bool
+	}{
+		{
+			name: "no ownership annotations, pass",
+			presubmits: []config.Presubmit{
+				{JobBase: config.JobBase{Annotations: map[string]string{"prow.k8s.io/cat": "meow"}}},
+			},
+		},
+		{
+			name: "valid ownership annotations, pass",
+			periodics: []config.Periodic{
+				{JobBase: config.JobBase{Annotations: map[string]string{
+					"prow.k8s.io/owner":      "sig-testing",
+					"prow.k8s.io/escalation": "#sig-testing",
+					"prow.k8s.io/docs":       "https://docs.example.com/jobs/periodic",
+				}}},
+			},
+		},
+		{
+			name: "empty owner, fail",
+			presubmits: []config.Presubmit{
+				{JobBase: config.JobBase{Annotations: map[string]string{"prow.k8s.io/owner": " "}}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "docs is not a URL, fail",
+			periodics: []config.Periodic{
+				{JobBase: config.JobBase{Annotations: map[string]string{"prow.k8s.io/docs": "see the wiki"}}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "escalation URL is not http, fail",
+			periodics: []config.Periodic{
+				{JobBase: config.JobBase{Annotations: map[string]string{"prow.k8s.io/escalation": "javascript://alert(1)"}}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			jcfg := config.JobConfig{
+				PresubmitsStatic: map[string][]config.Presubmit{"org/repo": c.presubmits},
+				Periodics:        c.periodics,
+			}
+			err := validateJobOwnershipAnnotations(jcfg)
+			if c.expectedErr && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !c.expectedErr && err != nil {
+				t.Errorf("Got error but didn't expect one: %v", err)
+			}
+		})
+	}
+}
+
+func TestPrintEffectivePlugins(t *testing.T) {
+	pcfg := &plugins.Configuration{
+		Plugins: plugins.Plugins{
+			"org":        {Plugins: []string{"cat", "dog"}, ExcludedRepos: []string{"excluded"}},
+			"org/repo":   {Plugins: []string{"yuks"}, DisabledPlugins: []string{"dog"}},
+			"org/plain":  {},
+			"org/second": {Plugins: []string{"pony"}},
+		},
+		Triggers: []plugins.Trigger{
+			{Repos: []string{"org"}, OnlyOrgMembers: &[]bool{true}[0]},
+			{Repos: []string{"org/repo"}, TrustedApps: []string{"app"}},
+		},
+	}
+	var out bytes.Buffer
+	if err := printEffectivePlugins(pcfg, []string{"org/repo", "org/excluded", "org/second", "other/repo"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `org/excluded:
+  approve:
+    commandHelpLink: https://go.k8s.io/bot-commands
+    pr_process_link: https://git.k8s.io/community/contributors/guide/owners.md#the-code-review-process
+  dco: {}
+  lgtm: {}
+  plugins: null
+  trigger:
+    only_org_members: true
+    repos:
+    - org
+org/repo:
+  approve:
+    commandHelpLink: https://go.k8s.io/bot-commands
+    pr_process_link: https://git.k8s.io/community/contributors/guide/owners.md#the-code-review-process
+  dco: {}
+  lgtm: {}
+  plugins:
+  - cat
+  - yuks
+  trigger:
+    only_org_members: true
+    repos:
+    - org/repo
+    trusted_apps:
+    - app
+org/second:
+  approve:
+    commandHelpLink: https://go.k8s.io/bot-commands
+    pr_process_link: https://git.k8s.io/community/contributors/guide/owners.md#the-code-review-process
+  dco: {}
+  lgtm: {}
+  plugins:
+  - cat
+  - dog
+  - pony
+  trigger:
+    only_org_members: true
+    repos:
+    - org
+other/repo:
+  approve:
+    commandHelpLink: https://go.k8s.io/bot-commands
+    pr_process_link: https://git.k8s.io/community/contributors/guide/owners.md#the-code-review-process
+  dco: {}
+  lgtm: {}
+  plugins: null
+  trigger: {}
+`
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidateLabelPropagation(t *testing.T) {
+	policy := &config.LabelPropagationPolicy{
+		Pods:           &config.PropagationRules{DeniedLabels: []string{"^internal-"}},
+		RequiredLabels: []config.RequiredLabel{{Key: "team"}, {Key: "cost-center", Default: "shared"}},
+	}
+	testCases := []struct {
+		name        string
+		policy      *config.LabelPropagationPolicy
+		presubmits  []config.Presubmit
+		postsubmits []config.Postsubmit
+		periodics   []config.Periodic
+		expectedErr string
+	}{
+		{
+			name:       "no policy, pass",
+			presubmits: []config.Presubmit{{JobBase: config.JobBase{Name: "pre", Labels: map[string]string{"internal-owner": "me"}}}},
+		},
+		{
+			name:        "jobs comply with the policy, pass",
+			policy:      policy,
+			presubmits:  []config.Presubmit{{JobBase: config.JobBase{Name: "pre", Labels: map[string]string{"team": "a"}}}},
+			postsubmits: []config.Postsubmit{{JobBase: config.JobBase{Name: "post", Labels: map[string]string{"team": "a", "cost-center": "ci"}}}},
+			periodics:   []config.Periodic{{JobBase: config.JobBase{Name: "periodic", Labels: map[string]string{"team": "a"}}}},
+		},
+		{
+			name:        "job is missing a required label, fail",
+			policy:      policy,
+			periodics:   []config.Periodic{{JobBase: config.JobBase{Name: "periodic"}}},
+			expectedErr: "job 'periodic' is missing required labels: team",
+		},
+		{
+			name:        "job has a dropped label, fail",
+			policy:      policy,
+			postsubmits: []config.Postsubmit{{JobBase: config.JobBase{Name: "post", Labels: map[string]string{"team": "a", "internal-owner": "me"}}}},
+			expectedErr: "job 'post' has labels that are not propagated to its pods: internal-owner",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jobConfig := config.JobConfig{
+				PresubmitsStatic:  map[string][]config.Presubmit{"org/repo": tc.presubmits},
+				PostsubmitsStatic: map[string][]config.Postsubmit{"org/repo": tc.postsubmits},
+				Periodics:         tc.periodics,
+			}
+			var errMsg string
+			if err := validateLabelPropagation(tc.policy, jobConfig); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+type client interface {
+	GetPullRequests(org, repo string) ([]github.PullRequest, error)
+	GetCombinedStatus(org, repo, ref string) (*github.CombinedStatus, error)
+	GetBranchProtection(org, repo, branch string) (*github.BranchProtection, error)
+	UpdateRequiredStatusChecks(org, repo, branch string, checks github.RequiredStatusChecks) error
+	CreateComment(org, repo string, number int, comment string) error
+}
+
+// contextReport describes a status context of a branch.
+type contextReport struct {
+	Context string `json:"context"`
+	// Required is true if the branch protection requires the context.
+	Required bool `json:"required"`
+	// Produced is true if a configured presubmit reports the context.
+	Produced bool `json:"produced"`
+	// Reported is the number of recent pull requests that have the context.
+	Reported int `json:"reported"`
+	// Stale is true if the branch protection requires the context although
+	// no configured job produces it and no recent pull request has it, so it
+	// blocks every pull request from merging.
+	Stale bool `json:"stale"`
+}
+
+// branchReport describes the status contexts of the recent pull requests
+// against a branch.
+type branchReport struct {
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+	// Pulls are the numbers of the recent pull requests.
+	Pulls    []int           `json:"pulls"`
+	Contexts []contextReport `json:"contexts"`
+
+	// blocked are the recent pull requests missing a stale context.
+	blocked []int
+	checks  *github.RequiredStatusChecks
+}
+
+// StaleContexts returns the stale contexts of the branch.
+func (r branchReport) StaleContexts() []string {
+	var stale []string
+	for _, c := range r.Contexts {
+		if c.Stale {
+			stale = append(stale, c.Context)
+		}
+	}
+	return stale
+}
+
+// auditRepo reports the contexts of the most recently updated open pull
+// requests of org/repo, by base branch.
+func auditRepo(gc client, cfg *config.Config, org, repo string, limit int) ([]branchReport, error) {
+	prs, err := gc.GetPullRequests(org, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests of %s/%s: %w", org, repo, err)
+	}
+	sort.SliceStable(prs, func(i, j int) bool {
+		return prs[i].UpdatedAt.After(prs[j].UpdatedAt)
+	})
+	if limit > 0 && len(prs) > limit {
+		prs = prs[:limit]
+	}
+
+	byBranch := map[string][]github.PullRequest{}
+	for _, pr := range prs {
+		byBranch[pr.Base.Ref] = append(byBranch[pr.Base.Ref], pr)
+	}
+	presubmits := cfg.GetPresubmitsStatic(org + "/" + repo)
+
+	var reports []branchReport
+	for _, branch := range sets.List(sets.KeySet(byBranch)) {
+		report, err := auditBranch(gc, presubmits, org, repo, branch, byBranch[branch])
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func auditBranch(gc client, presubmits []config.Presubmit, org, repo, branch string, prs []github.PullRequest) (branchReport, error) {
+	report := branchReport{Org: org, Repo: repo, Branch: branch}
+	contexts := map[string]*contextReport{}
+	get := func(context string) *contextReport {
+		if _, ok := contexts[context]; !ok {
+			contexts[context] = &contextReport{Context: context}
+		}
+		return contexts[context]
+	}
+
+	for _, ps := range presubmits {
+		if ps.Context != "" && ps.CouldRun(branch) {
+			get(ps.Context).Produced = true
+		}
+	}
+
+	reported := map[int]sets.Set[string]{}
+	for _, pr := range prs {
+		report.Pulls = append(report.Pulls, pr.Number)
+		status, err := gc.GetCombinedStatus(org, repo, pr.Head.SHA)
+		if err != nil {
+			return report, fmt.Errorf("failed to get the status of %s/%s#%d: %w", org, repo, pr.Number, err)
+		}
+		reported[pr.Number] = sets.New[string]()
+		if status == nil {
+			continue
+		}
+		for _, s := range status.Statuses {
+			if reported[pr.Number].Has(s.Context) {
+				continue
+			}
+			reported[pr.Number].Insert(s.Context)
+			get(s.Context).Reported++
+		}
+	}
+
+	protection, err := gc.GetBranchProtection(org, repo, branch)
+	if err != nil {
+		return report, fmt.Errorf("failed to get the branch protection of %s/%s=%s: %w", org, repo, branch, err)
+	}
+	if protection != nil && protection.RequiredStatusChecks != nil {
+		report.checks = protection.RequiredStatusChecks
+		for _, context := range protection.RequiredStatusChecks.Contexts {
+			c := get(context)
+			c.Required = true
+			c.Stale = !c.Produced && c.Reported == 0
+		}
+	}
+
+	for _, context := range sets.List(sets.KeySet(contexts)) {
+		report.Contexts = append(report.Contexts, *contexts[context])
+	}
+	if stale := report.StaleContexts(); len(stale) > 0 {
+		for _, pr := range prs {
+			if !reported[pr.Number].HasAll(stale...) {
+				report.blocked = append(report.blocked, pr.Number)
+			}
+		}
+	}
+	return report, nil
+}
+
+// overrideComment returns a comment that makes the override plugin override
+// the contexts.
+func overrideComment(contexts []string) string {
+	quoted := make([]string, 0, len(contexts))
+	for _, context := range contexts {
+		if strings.Contains(context, " ") {
+			context = `"` + context + `"`
+		}
+		quoted = append(quoted, context)
+	}
+	return fmt.Sprintf("/override %s\n\nNo configured job produces these required contexts anymore.", strings.Join(quoted, " "))
+}
+
+// overrideStale comments on the recent pull requests of the branch that are
+// blocked by stale contexts to override them.
+func overrideStale(gc client, report branchReport) error {
+	stale := report.StaleContexts()
+	if len(stale) == 0 {
+		return nil
+	}
+	comment := overrideComment(stale)
+	for _, number := range report.blocked {
+		if err := gc.CreateComment(report.Org, report.Repo, number, comment); err != nil {
+			return fmt.Errorf("failed to override the stale contexts of %s/%s#%d: %w", report.Org, report.Repo, number, err)
+		}
+	}
+	return nil
+}
+
+// unrequireStale removes the stale contexts from the required status checks
+// of the branch.
+func unrequireStale(gc client, report branchReport) error {
+	stale := sets.New[string](report.StaleContexts()...)
+	if stale.Len() == 0 || report.checks == nil {
+		return nil
+	}
+	checks := github.RequiredStatusChecks{Strict: report.checks.Strict, Contexts: []string{}}
+	for _, context := range report.checks.Contexts {
+		if !stale.Has(context) {
+			checks.Contexts = append(checks.Contexts, context)
+		}
+	}
+	if err := gc.UpdateRequiredStatusChecks(report.Org, report.Repo, report.Branch, checks); err != nil {
+		return fmt.Errorf("failed to update the required status checks of %s/%s=%s: %w", report.Org, report.Repo, report.Branch, err)
+	}
+	return nil
+}
+
+// writeReports writes a table of the contexts of each branch.
+func writeReports(out io.Writer, reports []branchReport) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tBRANCH\tCONTEXT\tREQUIRED\tPRODUCED\tREPORTED\tSTALE")
+	for _, report := range reports {
+		for _, c := range report.Contexts {
+			fmt.Fprintf(w, "%s/%s\t%s\t%s\t%t\t%t\t%d/%d\t%t\n", report.Org, report.Repo, report.Branch, c.Context, c.Required, c.Produced, c.Reported, len(report.Pulls), c.Stale)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+type fakeClient struct {
+	prs        []github.PullRequest
+	statuses   map[string][]github.Status
+	protection map[string]*github.BranchProtection
+
+	comments map[int][]string
+	checks   map[string]github.RequiredStatusChecks
+}
+
+func (c *fakeClient) GetPullRequests(org, repo string) ([]github.PullRequest, error) {
+	return c.prs, nil
+}
+
+func (c *fakeClient) GetCombinedStatus(org, repo, ref string) (*github.CombinedStatus, error) {
+	return &github.CombinedStatus{SHA: ref, Statuses: c.statuses[ref]}, nil
+}
+
+func (c *fakeClient) GetBranchProtection(org, repo, branch string) (*github.BranchProtection, error) {
+	return c.protection[branch], nil
+}
+
+func (c *fakeClient) UpdateRequiredStatusChecks(org, repo, branch string, checks github.RequiredStatusChecks) error {
+	if c.checks == nil {
+		c.checks = map[string]github.RequiredStatusChecks{}
+	}
+	c.checks[branch] = checks
+	return nil
+}
+
+func (c *fakeClient) CreateComment(org, repo string, number int, comment string) error {
+	if c.comments == nil {
+		c.comments = map[int][]string{}
+	}
+	c.comments[number] = append(c.comments[number], comment)
+	return nil
+}
+
+func pr(number int, branch string, updated time.Time) github.PullRequest {
+	return github.PullRequest{
+		Number:    number,
+		Base:      github.PullRequestBranch{Ref: branch},
+		Head:      github.PullRequestBranch{SHA: strings.Repeat(string(rune('a'+number)), 8)},
+		UpdatedAt: updated,
+	}
+}
+
+func TestAudit(t *testing.T) {
+	now := time.Now()
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}},
+		{JobBase: config.JobBase{Name: "e2e"}, Reporter: config.Reporter{Context: "e2e"}, Brancher: config.Brancher{Branches: []string{"main"}}},
+	}
+	if err := config.SetPresubmitRegexes(presubmits); err != nil {
+		t.Fatalf("failed to set presubmit regexes: %v", err)
+	}
+	cfg := &config.Config{
+		JobConfig: config.JobConfig{
+			PresubmitsStatic: map[string][]config.Presubmit{"org/repo": presubmits},
+		},
+	}
+	gc := &fakeClient{
+		prs: []github.PullRequest{
+			pr(1, "main", now.Add(-3*time.Hour)),
+			pr(2, "main", now.Add(-time.Hour)),
+			pr(3, "release", now.Add(-2*time.Hour)),
+			pr(4, "main", now.Add(-4*time.Hour)),
+		},
+		statuses: map[string][]github.Status{
+			"bbbbbbbb": {{Context: "unit", State: github.StatusSuccess}, {Context: "cla", State: github.StatusSuccess}, {Context: "removed", State: github.StatusFailure}},
+			"cccccccc": {{Context: "unit", State: github.StatusPending}, {Context: "unit", State: github.StatusFailure}},
+			"dddddddd": {{Context: "cla", State: github.StatusSuccess}},
+		},
+		protection: map[string]*github.BranchProtection{
+			"main":    {RequiredStatusChecks: &github.RequiredStatusChecks{Strict: true, Contexts: []string{"unit", "cla", "e2e", "gone"}}},
+			"release": {RequiredStatusChecks: &github.RequiredStatusChecks{Contexts: []string{"unit", "e2e"}}},
+		},
+	}
+
+	reports, err := auditRepo(gc, cfg, "org", "repo", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []branchReport{
+		{
+			Org: "org", Repo: "repo", Branch: "main", Pulls: []int{2, 1},
+			Contexts: []contextReport{
+				{Context: "cla", Required: true, Reported: 1},
+				{Context: "e2e", Required: true, Produced: true},
+				{Context: "gone", Required: true, Stale: true},
+				{Context: "removed", Reported: 1},
+				{Context: "unit", Required: true, Produced: true, Reported: 2},
+			},
+		},
+		{
+			Org: "org", Repo: "repo", Branch: "release", Pulls: []int{3},
+			Contexts: []contextReport{
+				{Context: "cla", Reported: 1},
+				{Context: "e2e", Required: true, Stale: true},
+				{Context: "unit", Required: true, Produced: true},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, reports, cmp.Comparer(func(a, b branchReport) bool {
+		return cmp.Equal(a.Org+a.Repo+a.Branch, b.Org+b.Repo+b.Branch) && cmp.Equal(a.Pulls, b.Pulls) && cmp.Equal(a.Contexts, b.Contexts)
+	})); diff != "" {
+		t.Fatalf("reports differ from expected (-want +got):\n%s", diff)
+	}
+
+	for _, report := range reports {
+		if err := overrideStale(gc, report); err != nil {
+			t.Fatalf("unexpected error overriding: %v", err)
+		}
+		if err := unrequireStale(gc, report); err != nil {
+			t.Fatalf("unexpected error updating branch protection: %v", err)
+		}
+	}
+	expectedComments := map[int][]string{
+		1: {overrideComment([]string{"gone"})},
+		2: {overrideComment([]string{"gone"})},
+		3: {overrideComment([]string{"e2e"})},
+	}
+	if diff := cmp.Diff(expectedComments, gc.comments); diff != "" {
+		t.Errorf("comments differ from expected (-want +got):\n%s", diff)
+	}
+	expectedChecks := map[string]github.RequiredStatusChecks{
+		"main":    {Strict: true, Contexts: []string{"unit", "cla", "e2e"}},
+		"release": {Contexts: []string{"unit"}},
+	}
+	if diff := cmp.Diff(expectedChecks, gc.checks); diff != "" {
+		t.Errorf("required status checks differ from expected (-want +got):\n%s", diff)
+	}
+}
+
+func TestOverrideComment(t *testing.T) {
+	expected := "/override unit \"pull-request-review (push)\"\n\nNo configured job produces these required contexts anymore."
+	if actual := overrideComment([]string{"unit", "pull-request-review (push)"}); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
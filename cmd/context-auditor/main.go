@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// context-auditor lists the status contexts reported on the recent pull
+// requests of each repo and flags the required contexts that no configured
+// job produces anymore, which block every pull request from merging.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+)
+
+const (
+	defaultTokens = 300
+	defaultBurst  = 100
+)
+
+type options struct {
+	config  configflagutil.ConfigOptions
+	github  flagutil.GitHubOptions
+	repos   flagutil.Strings
+	pulls   int
+	output  string
+	confirm bool
+
+	override               bool
+	updateBranchProtection bool
+}
+
+func (o *options) Validate() error {
+	if err := o.github.Validate(!o.confirm); err != nil {
+		return err
+	}
+	if err := o.config.Validate(!o.confirm); err != nil {
+		return err
+	}
+	for _, repo := range o.repos.Strings() {
+		if parts := strings.Split(repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("--repo=%s is not in the org/repo format", repo)
+		}
+	}
+	if o.pulls < 1 {
+		return errors.New("--pulls must be positive")
+	}
+	if o.output != "table" && o.output != "json" {
+		return fmt.Errorf("--output=%s is not one of table or json", o.output)
+	}
+	return nil
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	o := options{}
+	fs.Var(&o.repos, "repo", "Repo to audit in the org/repo format. Can be passed multiple times. Defaults to all the repos with jobs, Tide queries or branch protection in the config.")
+	fs.IntVar(&o.pulls, "pulls", 20, "Number of the most recently updated open pull requests of each repo to audit.")
+	fs.StringVar(&o.output, "output", "table", "Format of the report, one of table or json.")
+	fs.BoolVar(&o.override, "override", false, "Comment /override for the stale contexts on the audited pull requests that are blocked by them.")
+	fs.BoolVar(&o.updateBranchProtection, "update-branch-protection", false, "Remove the stale contexts from the required status checks of the branches.")
+	fs.BoolVar(&o.confirm, "confirm", false, "Mutate github if set, otherwise only report what --override and --update-branch-protection would do.")
+	o.config.AddFlags(fs)
+	o.github.AddCustomizedFlags(fs, flagutil.ThrottlerDefaults(defaultTokens, defaultBurst))
+	fs.Parse(args)
+	return o
+}
+
+// auditedRepos returns the repos to audit when none is specified.
+func auditedRepos(cfg *config.Config) []string {
+	repos := sets.New[string](sets.List(cfg.AllRepos)...)
+	for org, orgPolicy := range cfg.BranchProtection.Orgs {
+		for repo := range orgPolicy.Repos {
+			repos.Insert(org + "/" + repo)
+		}
+	}
+	return sets.List(repos)
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.Fatal(err)
+	}
+
+	ca, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to load --config-path=%s", o.config.ConfigPath)
+	}
+	cfg := ca.Config()
+
+	githubClient, err := o.github.GitHubClient(!o.confirm)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting GitHub client.")
+	}
+
+	repos := o.repos.Strings()
+	if len(repos) == 0 {
+		repos = auditedRepos(cfg)
+	}
+	var reports []branchReport
+	var errs []error
+	for _, orgRepo := range repos {
+		org, repo, _ := strings.Cut(orgRepo, "/")
+		repoReports, err := auditRepo(githubClient, cfg, org, repo, o.pulls)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		reports = append(reports, repoReports...)
+	}
+
+	for _, report := range reports {
+		stale := report.StaleContexts()
+		if len(stale) == 0 {
+			continue
+		}
+		log := logrus.WithFields(logrus.Fields{"org": report.Org, "repo": report.Repo, "branch": report.Branch, "contexts": stale})
+		if o.override {
+			log.WithField("pulls", report.blocked).Info("Overriding stale contexts.")
+			if err := overrideStale(githubClient, report); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if o.updateBranchProtection {
+			log.Info("Removing stale contexts from the required status checks.")
+			if err := unrequireStale(githubClient, report); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if o.output == "json" {
+		err = json.NewEncoder(os.Stdout).Encode(reports)
+	} else {
+		err = writeReports(os.Stdout, reports)
+	}
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to write the report.")
+	}
+	if n := len(errs); n > 0 {
+		for i, err := range errs {
+			logrus.WithError(err).Error(i)
+		}
+		logrus.Fatalf("Encountered %d errors auditing contexts", n)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/io"
+)
+
+// artifactDecryption decides whether viewers are shown the decrypted content
+// of encrypted artifacts, according to deck.spyglass.artifact_decryption.
+type artifactDecryption struct {
+	cfg config.Getter
+	// goa is set once GitHub OAuth is configured. Without it, only
+	// allow_anyone authorizes viewers.
+	goa *githuboauth.Agent
+	ghc githuboauth.AuthenticatedUserIdentifier
+	cli github.RerunClient
+	log *logrus.Entry
+}
+
+// keyURLs returns the keys that Deck may decrypt artifacts with.
+func (d *artifactDecryption) keyURLs() []string {
+	return d.cfg().Deck.Spyglass.ArtifactDecryption.GetKeyURLs()
+}
+
+// authorized returns whether the viewer of the request may see the decrypted
+// content of encrypted artifacts.
+func (d *artifactDecryption) authorized(r *http.Request) bool {
+	if d == nil {
+		return false
+	}
+	decryption := d.cfg().Deck.Spyglass.ArtifactDecryption
+	if decryption == nil {
+		return false
+	}
+	if decryption.Viewers.IsAllowAnyone() {
+		return true
+	}
+	if d.goa == nil {
+		return false
+	}
+	login, err := d.goa.GetLogin(r, d.ghc)
+	if err != nil {
+		// The viewer is not logged in.
+		return false
+	}
+	allowed, err := decryption.Viewers.IsAuthorized("", login, d.cli)
+	if err != nil {
+		d.log.WithError(err).WithField("user", login).Warn("Failed to check whether the user may see decrypted artifacts.")
+		return false
+	}
+	return allowed
+}
+
+// context returns the context to read artifacts in on behalf of the viewer of
+// the request.
+func (d *artifactDecryption) context(ctx context.Context, r *http.Request) context.Context {
+	if d.authorized(r) {
+		return io.WithDecryption(ctx)
+	}
+	return ctx
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestArtifactDecryptionAuthorized(t *testing.T) {
+	testCases := []struct {
+		name       string
+		decryption *config.ArtifactDecryption
+		expected   bool
+	}{
+		{
+			name: "decryption is not configured",
+		},
+		{
+			name:       "anyone may see decrypted artifacts",
+			decryption: &config.ArtifactDecryption{KeyURLs: []string{"base64key://"}, Viewers: prowapi.RerunAuthConfig{AllowAnyone: true}},
+			expected:   true,
+		},
+		{
+			name:       "viewers cannot log in without GitHub OAuth",
+			decryption: &config.ArtifactDecryption{KeyURLs: []string{"base64key://"}, Viewers: prowapi.RerunAuthConfig{GitHubUsers: []string{"viewer"}}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := func() *config.Config {
+				return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{ArtifactDecryption: tc.decryption}}}}
+			}
+			d := &artifactDecryption{cfg: cfg, log: logrus.WithField("test", t.Name())}
+			if actual := d.authorized(httptest.NewRequest("GET", "/spyglass/raw/gs/bucket/admin.kubeconfig", nil)); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass"
+)
+
+// handleArtifactSearch serves the matches of the q parameter in the
+// artifacts of the run given by the src parameter as JSON.
+func handleArtifactSearch(sg *spyglass.Spyglass, cfg config.Getter, decryption *artifactDecryption, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		if r.Method != http.MethodGet {
+			http.Error(w, fmt.Sprintf("method %s is not supported", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		src := strings.TrimSuffix(r.URL.Query().Get("src"), "/")
+		if src == "" {
+			http.Error(w, "missing src parameter", http.StatusBadRequest)
+			return
+		}
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			http.Error(w, "missing q parameter", http.StatusBadRequest)
+			return
+		}
+		if len(query) > spyglass.MaxSearchQueryLength {
+			http.Error(w, fmt.Sprintf("the query must not be longer than %d bytes", spyglass.MaxSearchQueryLength), http.StatusBadRequest)
+			return
+		}
+		if err := validateStoragePath(cfg, src); err != nil {
+			http.Error(w, fmt.Sprintf("failed to process request: %v", err), httpStatusForError(err))
+			return
+		}
+		l := log.WithField("src", src)
+
+		result, err := sg.SearchArtifacts(decryption.context(r.Context(), r), src, query)
+		if err != nil {
+			l.WithError(err).Debug("Failed to search artifacts.")
+			http.Error(w, fmt.Sprintf("failed to search artifacts: %v", err), httpStatusForError(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			l.WithError(err).Warn("Failed to write search result.")
+		}
+	}
+}
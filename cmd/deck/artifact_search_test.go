@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/spyglass"
+)
+
+func TestHandleArtifactSearch(t *testing.T) {
+	gcsServer := fakestorage.NewServer([]fakestorage.Object{
+		{
+			BucketName: "bucket",
+			Name:       "logs/ci-job/1/build-log.txt",
+			Content:    []byte("starting\nFAIL: TestFoo\n"),
+		},
+		{
+			BucketName: "bucket",
+			Name:       "logs/ci-job/1/artifacts/e2e.log",
+			Content:    []byte("TestFoo failed\n"),
+		},
+	})
+	defer gcsServer.Stop()
+
+	testCases := []struct {
+		name         string
+		method       string
+		query        url.Values
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "matches are served",
+			method:       http.MethodGet,
+			query:        url.Values{"src": {"gs/bucket/logs/ci-job/1"}, "q": {"fail"}},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"matches":[{"artifact":"build-log.txt","offset":9,"length":4,"line":2,"text":"FAIL: TestFoo"},{"artifact":"artifacts/e2e.log","offset":8,"length":4,"line":1,"text":"TestFoo failed"}],"links":{"artifacts/e2e.log":"https://storage.googleapis.com/bucket/logs/ci-job/1/artifacts/e2e.log","build-log.txt":"https://storage.googleapis.com/bucket/logs/ci-job/1/build-log.txt"},"searched":2}`,
+		},
+		{
+			name:         "src is required",
+			method:       http.MethodGet,
+			query:        url.Values{"q": {"fail"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "q is required",
+			method:       http.MethodGet,
+			query:        url.Values{"src": {"gs/bucket/logs/ci-job/1"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "q must not be too long",
+			method:       http.MethodGet,
+			query:        url.Values{"src": {"gs/bucket/logs/ci-job/1"}, "q": {strings.Repeat("a", spyglass.MaxSearchQueryLength+1)}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "buckets must be allowed",
+			method:       http.MethodGet,
+			query:        url.Values{"src": {"gs/other-bucket/logs/ci-job/1"}, "q": {"fail"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "other methods are not supported",
+			method:       http.MethodPost,
+			query:        url.Values{"src": {"gs/bucket/logs/ci-job/1"}, "q": {"fail"}},
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	skipValidation := false
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{
+			Spyglass:                  config.Spyglass{SizeLimit: 1000},
+			SkipStoragePathValidation: &skipValidation,
+			AllKnownStorageBuckets:    sets.New[string]("bucket"),
+		}}}
+	}
+	sg := spyglass.New(context.Background(), nil, cfg, io.NewGCSOpener(gcsServer.Client()), false)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := handleArtifactSearch(sg, cfg, nil, logrus.WithField("test", t.Name()))
+			req := httptest.NewRequest(tc.method, "/spyglass/search?"+tc.query.Encode(), nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.expectedBody != "" {
+				if actual := strings.TrimSpace(rr.Body.String()); actual != tc.expectedBody {
+					t.Errorf("expected body %s, got %s", tc.expectedBody, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/version"
+)
+
+// Health of a Prow component.
+const (
+	componentHealthy   = "healthy"
+	componentUnready   = "unready"
+	componentDown      = "down"
+	componentUnchecked = "unchecked"
+)
+
+// componentHealth is the last known health of a Prow component.
+type componentHealth struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Health    string    `json:"health"`
+	LastCheck time.Time `json:"last_check,omitempty"`
+	// ConfigHash is the hash of the config the component loaded, if it
+	// serves it.
+	ConfigHash string `json:"config_hash,omitempty"`
+	// ConfigMatches is whether the component loaded the same config as Deck.
+	ConfigMatches bool `json:"config_matches"`
+	// Version is the version of the component, if it serves it.
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// componentHealthData is served to the component health view.
+type componentHealthData struct {
+	// ConfigHash is the hash of the config Deck loaded.
+	ConfigHash string            `json:"config_hash"`
+	Components []componentHealth `json:"components"`
+	// Healthy is true if all components are healthy and agree on the config.
+	Healthy bool `json:"healthy"`
+}
+
+// componentHealthAggregator periodically checks the health endpoints of the
+// Prow components listed in deck.health_aggregation.
+type componentHealthAggregator struct {
+	log    *logrus.Entry
+	client *http.Client
+	cfg    config.Getter
+
+	lock    sync.Mutex
+	results map[string]componentHealth
+}
+
+func newComponentHealthAggregator(cfg config.Getter) *componentHealthAggregator {
+	return &componentHealthAggregator{
+		log:     logrus.WithField("agent", "component-health"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		cfg:     cfg,
+		results: map[string]componentHealth{},
+	}
+}
+
+func (ha *componentHealthAggregator) components() []config.HealthComponent {
+	if aggregation := ha.cfg().Deck.HealthAggregation; aggregation != nil {
+		return aggregation.Components
+	}
+	return nil
+}
+
+// period returns how often the components are checked.
+func (ha *componentHealthAggregator) period() time.Duration {
+	if aggregation := ha.cfg().Deck.HealthAggregation; aggregation != nil {
+		return aggregation.GetUpdatePeriod()
+	}
+	return time.Minute
+}
+
+func (ha *componentHealthAggregator) update() {
+	components := ha.components()
+	results := make(map[string]componentHealth, len(components))
+	var resultsLock sync.Mutex
+	var wg sync.WaitGroup
+	for _, component := range components {
+		wg.Add(1)
+		go func(component config.HealthComponent) {
+			defer wg.Done()
+			result := ha.check(component, time.Now())
+			resultsLock.Lock()
+			results[component.Name] = result
+			resultsLock.Unlock()
+		}(component)
+	}
+	wg.Wait()
+
+	ha.lock.Lock()
+	defer ha.lock.Unlock()
+	ha.results = results
+}
+
+// check fetches the liveness, readiness, config hash and version of the
+// component.
+func (ha *componentHealthAggregator) check(component config.HealthComponent, now time.Time) componentHealth {
+	base := strings.TrimSuffix(component.URL, "/")
+	result := componentHealth{Name: component.Name, URL: component.URL, LastCheck: now}
+	if _, err := ha.fetch(base + pjutil.LivenessPath); err != nil {
+		ha.log.WithError(err).WithField("component", component.Name).Warn("Component is not alive.")
+		result.Health = componentDown
+		result.Error = err.Error()
+		return result
+	}
+	result.Health = componentHealthy
+	if _, err := ha.fetch(base + pjutil.ReadinessPath); err != nil {
+		result.Health = componentUnready
+		result.Error = err.Error()
+	}
+	// Components that do not load the Prow config do not serve its hash.
+	if hash, err := ha.fetch(base + pjutil.ConfigHashPath); err == nil {
+		result.ConfigHash = strings.TrimSpace(hash)
+	}
+	if v, err := ha.fetch(base + pjutil.VersionPath); err == nil {
+		result.Version = strings.TrimSpace(v)
+	}
+	return result
+}
+
+func (ha *componentHealthAggregator) fetch(url string) (string, error) {
+	resp, err := ha.client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s responded with status code %d", url, resp.StatusCode)
+	}
+	return string(body), nil
+}
+
+// data returns the health of the configured components, in the order of the
+// config, compared against the config of Deck.
+func (ha *componentHealthAggregator) data() componentHealthData {
+	hash, err := ha.cfg().Hash()
+	if err != nil {
+		ha.log.WithError(err).Warn("Failed to hash the config.")
+	}
+	data := componentHealthData{ConfigHash: hash, Components: []componentHealth{}, Healthy: true}
+
+	ha.lock.Lock()
+	defer ha.lock.Unlock()
+	for _, component := range ha.components() {
+		result, ok := ha.results[component.Name]
+		if !ok || result.URL != component.URL {
+			result = componentHealth{Name: component.Name, URL: component.URL, Health: componentUnchecked}
+		}
+		result.ConfigMatches = result.ConfigHash == "" || result.ConfigHash == hash
+		if result.Health != componentHealthy || !result.ConfigMatches {
+			data.Healthy = false
+		}
+		data.Components = append(data.Components, result)
+	}
+	return data
+}
+
+func handleComponentHealth(ha *componentHealthAggregator, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		hd, err := json.Marshal(ha.data())
+		if err != nil {
+			log.WithError(err).Error("Error marshaling component health data.")
+			hd = []byte("{}")
+		}
+		writeJSONResponse(w, r, hd)
+	}
+}
+
+// componentVersions are the versions of the deployed Prow components, to
+// debug issues caused by version skew between them.
+type componentVersions struct {
+	// Deck is the version of Deck itself.
+	Deck string `json:"deck"`
+	// Components are the versions of the components of
+	// deck.health_aggregation, by name. Components that were not reached
+	// or do not serve their version are left out.
+	Components map[string]string `json:"components"`
+}
+
+func (ha *componentHealthAggregator) versions() componentVersions {
+	versions := componentVersions{Deck: version.Version, Components: map[string]string{}}
+	for _, component := range ha.data().Components {
+		if component.Version != "" {
+			versions.Components[component.Name] = component.Version
+		}
+	}
+	return versions
+}
+
+func handleComponentVersions(ha *componentHealthAggregator, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		vd, err := json.Marshal(ha.versions())
+		if err != nil {
+			log.WithError(err).Error("Error marshaling component versions.")
+			vd = []byte("{}")
+		}
+		writeJSONResponse(w, r, vd)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/version"
+)
+
+// fakeComponent serves the health endpoints of a component. A nil hash means
+// the component serves neither its config hash nor its version, like older
+// components.
+const fakeComponentVersion = "v20240101-abcdef"
+
+func fakeComponent(t *testing.T, ready bool, hash *string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pjutil.LivenessPath, func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "OK") })
+	mux.HandleFunc(pjutil.ReadinessPath, func(w http.ResponseWriter, r *http.Request) {
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	if hash != nil {
+		mux.HandleFunc(pjutil.ConfigHashPath, func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, *hash) })
+		mux.HandleFunc(pjutil.VersionPath, func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, fakeComponentVersion) })
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestComponentHealthAggregator(t *testing.T) {
+	var components []config.HealthComponent
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{HealthAggregation: &config.HealthAggregation{Components: components}}}}
+	}
+
+	// The components serve the hash of the config they loaded, which
+	// lists their URLs, so it is only known once they are all started.
+	var hash string
+	stale := "stale"
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	components = []config.HealthComponent{
+		{Name: "hook", URL: fakeComponent(t, true, &hash).URL},
+		{Name: "tide", URL: fakeComponent(t, false, &hash).URL + "/"},
+		{Name: "crier", URL: fakeComponent(t, true, &stale).URL},
+		{Name: "ghproxy", URL: fakeComponent(t, true, nil).URL},
+		{Name: "sinker", URL: down.URL},
+	}
+	hash, err := cfg().Hash()
+	if err != nil {
+		t.Fatalf("failed to hash config: %v", err)
+	}
+
+	ha := newComponentHealthAggregator(cfg)
+	ha.update()
+	// Components added since the last update are not checked yet, and the
+	// config of Deck changed along with them.
+	components = append(components, config.HealthComponent{Name: "horologium", URL: "http://horologium:8081"})
+	data := ha.data()
+
+	newHash, err := cfg().Hash()
+	if err != nil {
+		t.Fatalf("failed to hash config: %v", err)
+	}
+	expected := componentHealthData{
+		ConfigHash: newHash,
+		Components: []componentHealth{
+			{Name: "hook", URL: components[0].URL, Health: componentHealthy, ConfigHash: hash, Version: fakeComponentVersion},
+			{Name: "tide", URL: components[1].URL, Health: componentUnready, ConfigHash: hash, Version: fakeComponentVersion},
+			{Name: "crier", URL: components[2].URL, Health: componentHealthy, ConfigHash: "stale", Version: fakeComponentVersion},
+			{Name: "ghproxy", URL: components[3].URL, Health: componentHealthy, ConfigMatches: true},
+			{Name: "sinker", URL: components[4].URL, Health: componentDown, ConfigMatches: true},
+			{Name: "horologium", URL: "http://horologium:8081", Health: componentUnchecked, ConfigMatches: true},
+		},
+	}
+	if diff := cmp.Diff(expected, data, cmpopts.IgnoreFields(componentHealth{}, "LastCheck", "Error")); diff != "" {
+		t.Errorf("health differs from expected (-want +got):\n%s", diff)
+	}
+	for _, component := range data.Components {
+		if (component.Error != "") != (component.Health == componentUnready || component.Health == componentDown) {
+			t.Errorf("component %s is %s with error %q", component.Name, component.Health, component.Error)
+		}
+	}
+
+	// Once the healthy components load the new config, they agree with Deck.
+	components = []config.HealthComponent{components[0], components[3]}
+	hash, err = cfg().Hash()
+	if err != nil {
+		t.Fatalf("failed to hash config: %v", err)
+	}
+	ha.update()
+	if data := ha.data(); !data.Healthy {
+		t.Errorf("expected all components to be healthy, got %+v", data)
+	}
+	expectedVersions := componentVersions{Deck: version.Version, Components: map[string]string{"hook": fakeComponentVersion}}
+	if diff := cmp.Diff(expectedVersions, ha.versions()); diff != "" {
+		t.Errorf("versions differ from expected (-want +got):\n%s", diff)
+	}
+
+	rr := httptest.NewRecorder()
+	handleSimpleTemplate(options{templateFilesLocation: "template"}, cfg, "component-health.html", ha.data())(rr, httptest.NewRequest(http.MethodGet, "/component-health", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	for _, expected := range []string{"All components are healthy", "in sync", `href="/component-health">Component Health</a>`} {
+		if !strings.Contains(rr.Body.String(), expected) {
+			t.Errorf("expected the page to contain %q", expected)
+		}
+	}
+}
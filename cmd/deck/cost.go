@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/deck/jobs"
+	"sigs.k8s.io/prow/pkg/pjutil"
+)
+
+// noCostGroup is the repo of jobs without refs, and the SIG of jobs without
+// the SIG label, in cost reports.
+const noCostGroup = "(none)"
+
+// costRow is the estimated cost of the jobs of a repo or SIG.
+type costRow struct {
+	Name           string  `json:"name"`
+	Runs           int     `json:"runs"`
+	CPUCoreHours   float64 `json:"cpu_core_hours"`
+	MemoryGiBHours float64 `json:"memory_gib_hours"`
+	GPUHours       float64 `json:"gpu_hours"`
+	Total          float64 `json:"total"`
+}
+
+func (r *costRow) add(cost pjutil.JobCost) {
+	r.Runs++
+	r.CPUCoreHours += cost.CPUCoreHours
+	r.MemoryGiBHours += cost.MemoryGiBHours
+	r.GPUHours += cost.GPUHours
+	r.Total += cost.Total
+}
+
+// costReport is the estimated cost of the completed jobs known to Deck, by
+// repo and by SIG, most expensive first.
+type costReport struct {
+	Currency string    `json:"currency"`
+	Since    time.Time `json:"since"`
+	Total    costRow   `json:"total"`
+	Repos    []costRow `json:"repos"`
+	Sigs     []costRow `json:"sigs"`
+}
+
+// costTable is a table of the cost report page.
+type costTable struct {
+	Title  string
+	Column string
+	Rows   []costRow
+}
+
+// Tables returns the tables of the cost report page.
+func (r costReport) Tables() []costTable {
+	return []costTable{
+		{Title: "Total", Rows: []costRow{r.Total}},
+		{Title: "By Repository", Column: "Repository", Rows: r.Repos},
+		{Title: "By SIG", Column: "SIG", Rows: r.Sigs},
+	}
+}
+
+func newCostReport(pjs []prowapi.ProwJob, cfg config.CostAccounting) costReport {
+	report := costReport{Currency: cfg.GetCurrency(), Total: costRow{Name: "Total"}}
+	repos, sigs := map[string]*costRow{}, map[string]*costRow{}
+	for i := range pjs {
+		pj := &pjs[i]
+		cost, ok := pjutil.EstimateCost(pj, cfg)
+		if !ok {
+			continue
+		}
+		if report.Since.IsZero() || pj.Status.StartTime.Time.Before(report.Since) {
+			report.Since = pj.Status.StartTime.Time
+		}
+		report.Total.add(cost)
+		repo := noCostGroup
+		if pj.Spec.Refs != nil {
+			repo = pj.Spec.Refs.Org + "/" + pj.Spec.Refs.Repo
+		} else if len(pj.Spec.ExtraRefs) > 0 {
+			repo = pj.Spec.ExtraRefs[0].Org + "/" + pj.Spec.ExtraRefs[0].Repo
+		}
+		sig := pj.Labels[cfg.GetSigLabel()]
+		if sig == "" {
+			sig = noCostGroup
+		}
+		addCost(repos, repo, cost)
+		addCost(sigs, sig, cost)
+	}
+	report.Repos, report.Sigs = sortedCostRows(repos), sortedCostRows(sigs)
+	return report
+}
+
+func addCost(rows map[string]*costRow, name string, cost pjutil.JobCost) {
+	if rows[name] == nil {
+		rows[name] = &costRow{Name: name}
+	}
+	rows[name].add(cost)
+}
+
+func sortedCostRows(rows map[string]*costRow) []costRow {
+	sorted := make([]costRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, *row)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Total != sorted[j].Total {
+			return sorted[i].Total > sorted[j].Total
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func handleCost(ja *jobs.JobAgent, cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		cd, err := json.Marshal(newCostReport(ja.ProwJobs(), cfg().CostAccounting))
+		if err != nil {
+			log.WithError(err).Error("Error marshaling cost report.")
+			cd = []byte("{}")
+		}
+		writeJSONResponse(w, r, cd)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func costJob(refs *prowapi.Refs, sig string, cpu string, start time.Time, ran time.Duration) prowapi.ProwJob {
+	completion := metav1.NewTime(start.Add(ran))
+	pj := prowapi.ProwJob{
+		Spec: prowapi.ProwJobSpec{
+			Agent: prowapi.KubernetesAgent,
+			Refs:  refs,
+			PodSpec: &coreapi.PodSpec{Containers: []coreapi.Container{{Resources: coreapi.ResourceRequirements{
+				Requests: coreapi.ResourceList{coreapi.ResourceCPU: resource.MustParse(cpu)},
+			}}}},
+		},
+		Status: prowapi.ProwJobStatus{
+			StartTime:      metav1.NewTime(start),
+			CompletionTime: &completion,
+		},
+	}
+	if sig != "" {
+		pj.Labels = map[string]string{"team": sig}
+	}
+	return pj
+}
+
+func TestNewCostReport(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.CostAccounting{
+		Prices:   map[string]config.ResourcePrices{"*": {CPU: 1}},
+		Currency: "EUR",
+		SigLabel: "team",
+	}
+	prow := &prowapi.Refs{Org: "kubernetes-sigs", Repo: "prow"}
+	k8s := &prowapi.Refs{Org: "kubernetes", Repo: "kubernetes"}
+	running := costJob(k8s, "node", "8", start, time.Hour)
+	running.Status.CompletionTime = nil
+	pjs := []prowapi.ProwJob{
+		costJob(prow, "testing", "1", start.Add(time.Hour), time.Hour),
+		costJob(k8s, "node", "2", start.Add(2*time.Hour), time.Hour),
+		costJob(k8s, "testing", "1", start.Add(3*time.Hour), 2*time.Hour),
+		costJob(nil, "", "1", start.Add(4*time.Hour), 30*time.Minute),
+		running,
+	}
+	expected := costReport{
+		Currency: "EUR",
+		Since:    start.Add(time.Hour),
+		Total:    costRow{Name: "Total", Runs: 4, CPUCoreHours: 5.5, Total: 5.5},
+		Repos: []costRow{
+			{Name: "kubernetes/kubernetes", Runs: 2, CPUCoreHours: 4, Total: 4},
+			{Name: "kubernetes-sigs/prow", Runs: 1, CPUCoreHours: 1, Total: 1},
+			{Name: noCostGroup, Runs: 1, CPUCoreHours: 0.5, Total: 0.5},
+		},
+		Sigs: []costRow{
+			{Name: "testing", Runs: 2, CPUCoreHours: 3, Total: 3},
+			{Name: "node", Runs: 1, CPUCoreHours: 2, Total: 2},
+			{Name: noCostGroup, Runs: 1, CPUCoreHours: 0.5, Total: 0.5},
+		},
+	}
+	if diff := cmp.Diff(expected, newCostReport(pjs, cfg)); diff != "" {
+		t.Errorf("cost report differs from expected (-want +got):\n%s", diff)
+	}
+}
+
+func TestCostTemplate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{CostAccounting: config.CostAccounting{
+			Prices: map[string]config.ResourcePrices{"*": {CPU: 1}},
+		}}}
+	}
+	report := newCostReport([]prowapi.ProwJob{
+		costJob(&prowapi.Refs{Org: "kubernetes-sigs", Repo: "prow"}, "", "1", start, 90*time.Minute),
+	}, cfg().CostAccounting)
+	rr := httptest.NewRecorder()
+	handleSimpleTemplate(options{templateFilesLocation: "template"}, cfg, "cost.html", report)(rr, httptest.NewRequest(http.MethodGet, "/cost", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	for _, expected := range []string{"kubernetes-sigs/prow", "Cost (USD)", "<td>1.50</td>", `href="/cost">Job Cost</a>`} {
+		if !strings.Contains(rr.Body.String(), expected) {
+			t.Errorf("expected the page to contain %q", expected)
+		}
+	}
+}
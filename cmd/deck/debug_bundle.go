@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass"
+)
+
+// handleDebugBundle serves the debug bundle of the run given by the src
+// parameter as a gzipped tarball to download.
+func handleDebugBundle(sg *spyglass.Spyglass, cfg config.Getter, decryption *artifactDecryption, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		if r.Method != http.MethodGet {
+			http.Error(w, fmt.Sprintf("method %s is not supported", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		src := strings.TrimSuffix(r.URL.Query().Get("src"), "/")
+		if src == "" {
+			http.Error(w, "missing src parameter", http.StatusBadRequest)
+			return
+		}
+		if err := validateStoragePath(cfg, src); err != nil {
+			http.Error(w, fmt.Sprintf("failed to process request: %v", err), httpStatusForError(err))
+			return
+		}
+		src, err := sg.ResolveSymlink(src)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to resolve src: %v", err), httpStatusForError(err))
+			return
+		}
+		l := log.WithField("src", src)
+
+		bundle, err := sg.DebugBundle(decryption.context(r.Context(), r), src)
+		if err != nil {
+			l.WithError(err).Debug("Failed to collect debug bundle.")
+			http.Error(w, fmt.Sprintf("failed to collect debug bundle: %v", err), httpStatusForError(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bundle.Filename()))
+		if err := bundle.Write(w); err != nil {
+			l.WithError(err).Warn("Failed to write debug bundle.")
+		}
+	}
+}
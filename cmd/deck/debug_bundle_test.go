@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/spyglass"
+)
+
+func TestHandleDebugBundle(t *testing.T) {
+	gcsServer := fakestorage.NewServer([]fakestorage.Object{
+		{
+			BucketName: "bucket",
+			Name:       "logs/ci-job/1/build-log.txt",
+			Content:    []byte("starting\nFAIL: TestFoo\n"),
+		},
+		{
+			BucketName: "bucket",
+			Name:       "logs/ci-job/1/finished.json",
+			Content:    []byte(`{"passed":false}`),
+		},
+		{
+			BucketName: "bucket",
+			Name:       "logs/ci-job/1/artifacts/junit_01.xml",
+			Content:    []byte("<testsuites></testsuites>"),
+		},
+		{
+			BucketName: "bucket",
+			Name:       "logs/ci-job/1/artifacts/coverage.out",
+			Content:    []byte("mode: set\n"),
+		},
+	})
+	defer gcsServer.Stop()
+
+	testCases := []struct {
+		name          string
+		method        string
+		query         url.Values
+		expectedCode  int
+		expectedFiles []string
+	}{
+		{
+			name:         "bundle is served",
+			method:       http.MethodGet,
+			query:        url.Values{"src": {"gs/bucket/logs/ci-job/1"}},
+			expectedCode: http.StatusOK,
+			expectedFiles: []string{
+				"ci-job-1-debug-bundle/artifacts/artifacts/junit_01.xml",
+				"ci-job-1-debug-bundle/artifacts/build-log.txt",
+				"ci-job-1-debug-bundle/artifacts/finished.json",
+				"ci-job-1-debug-bundle/index.html",
+			},
+		},
+		{
+			name:         "src is required",
+			method:       http.MethodGet,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "buckets must be allowed",
+			method:       http.MethodGet,
+			query:        url.Values{"src": {"gs/other-bucket/logs/ci-job/1"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "other methods are not supported",
+			method:       http.MethodPost,
+			query:        url.Values{"src": {"gs/bucket/logs/ci-job/1"}},
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	skipValidation := false
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{
+			Spyglass:                  config.Spyglass{SizeLimit: 1000},
+			SkipStoragePathValidation: &skipValidation,
+			AllKnownStorageBuckets:    sets.New[string]("bucket"),
+		}}}
+	}
+	sg := spyglass.New(context.Background(), nil, cfg, pkgio.NewGCSOpener(gcsServer.Client()), false)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := handleDebugBundle(sg, cfg, nil, logrus.WithField("test", t.Name()))
+			req := httptest.NewRequest(tc.method, "/spyglass/debug-bundle?"+tc.query.Encode(), nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			if disposition := rr.Header().Get("Content-Disposition"); disposition != `attachment; filename="ci-job-1-debug-bundle.tar.gz"` {
+				t.Errorf("unexpected Content-Disposition %q", disposition)
+			}
+			gz, err := gzip.NewReader(rr.Body)
+			if err != nil {
+				t.Fatalf("failed to decompress bundle: %v", err)
+			}
+			var files []string
+			tr := tar.NewReader(gz)
+			for {
+				header, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("failed to read bundle: %v", err)
+				}
+				files = append(files, header.Name)
+			}
+			sort.Strings(files)
+			if diff := cmp.Diff(tc.expectedFiles, files); diff != "" {
+				t.Errorf("files differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
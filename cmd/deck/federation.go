@@ -0,0 +1,275 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/tide"
+)
+
+// Health of a federated Prow install.
+const (
+	federationHealthy     = "healthy"
+	federationStale       = "stale"
+	federationUnreachable = "unreachable"
+)
+
+const (
+	// federationMaxResponseSize bounds the jobs and Tide status read from a
+	// federated Prow install on every update.
+	federationMaxResponseSize = 256 << 20
+	// federationJobDisplayLimit bounds the jobs rendered by the federation
+	// view. All jobs are served by /federation.js.
+	federationJobDisplayLimit = 500
+)
+
+// federationSource is another Prow install whose Deck is aggregated.
+type federationSource struct {
+	Name string
+	URL  string
+}
+
+// parseFederationSource parses a "name=url" flag value.
+func parseFederationSource(value string) (federationSource, error) {
+	name, rawURL, ok := strings.Cut(value, "=")
+	if !ok || name == "" || rawURL == "" {
+		return federationSource{}, fmt.Errorf("federation source %q must be of the form name=url", value)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return federationSource{}, fmt.Errorf("federation source %q has an invalid URL %q", name, rawURL)
+	}
+	return federationSource{Name: name, URL: strings.TrimSuffix(rawURL, "/")}, nil
+}
+
+// federatedSourceStatus is the last known state of a federated Prow install.
+type federatedSourceStatus struct {
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	Health      string    `json:"health"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	// TideError is set if the install serves jobs but its Tide status could
+	// not be fetched, e.g. because it does not run Tide.
+	TideError string `json:"tide_error,omitempty"`
+	Jobs      int    `json:"jobs"`
+	Pools     int    `json:"pools"`
+}
+
+// federatedJob is a job of a federated Prow install.
+type federatedJob struct {
+	Source string          `json:"source"`
+	Job    prowapi.ProwJob `json:"job"`
+}
+
+// federatedPool is a Tide pool of a federated Prow install.
+type federatedPool struct {
+	Source string           `json:"source"`
+	Pool   tide.PoolForDeck `json:"pool"`
+}
+
+// federationData is served to the federation view.
+type federationData struct {
+	Sources []federatedSourceStatus `json:"sources"`
+	Jobs    []federatedJob          `json:"jobs"`
+	Pools   []federatedPool         `json:"pools"`
+}
+
+// federationPage is rendered by the federation view. It only holds the most
+// recent jobs.
+type federationPage struct {
+	federationData
+	TotalJobs int
+}
+
+type federatedSource struct {
+	status federatedSourceStatus
+	jobs   []prowapi.ProwJob
+	pools  []tide.PoolForDeck
+}
+
+// federationAgent periodically fetches the jobs and Tide status of other
+// Prow installs from their Deck.
+type federationAgent struct {
+	log    *logrus.Entry
+	client *http.Client
+	// maxResponseSize is the size above which responses are rejected.
+	maxResponseSize int64
+	sources         []federationSource
+	updatePeriod    time.Duration
+
+	lock  sync.Mutex
+	state map[string]*federatedSource
+}
+
+func newFederationAgent(sources []federationSource, updatePeriod time.Duration) *federationAgent {
+	state := make(map[string]*federatedSource, len(sources))
+	for _, source := range sources {
+		state[source.Name] = &federatedSource{status: federatedSourceStatus{Name: source.Name, URL: source.URL, Health: federationUnreachable}}
+	}
+	return &federationAgent{
+		log:             logrus.WithField("agent", "federation"),
+		client:          &http.Client{Timeout: time.Minute},
+		maxResponseSize: federationMaxResponseSize,
+		sources:         sources,
+		updatePeriod:    updatePeriod,
+		state:           state,
+	}
+}
+
+func (fa *federationAgent) start() {
+	fa.update()
+	go func() {
+		for range time.Tick(fa.updatePeriod) {
+			fa.update()
+		}
+	}()
+}
+
+func (fa *federationAgent) update() {
+	var wg sync.WaitGroup
+	for _, source := range fa.sources {
+		wg.Add(1)
+		go func(source federationSource) {
+			defer wg.Done()
+			fa.updateSource(source, time.Now())
+		}(source)
+	}
+	wg.Wait()
+}
+
+func (fa *federationAgent) updateSource(source federationSource, now time.Time) {
+	log := fa.log.WithField("source", source.Name)
+	var jobs struct {
+		Items []prowapi.ProwJob `json:"items"`
+	}
+	jobsErr := fa.fetch(source.URL+"/prowjobs.js?omit="+strings.Join([]string{Annotations, DecorationConfig, PodSpec}, ","), &jobs)
+	var pools tidePools
+	tideErr := fa.fetch(source.URL+"/tide.js", &pools)
+
+	fa.lock.Lock()
+	defer fa.lock.Unlock()
+	state := fa.state[source.Name]
+	if jobsErr != nil {
+		log.WithError(jobsErr).Warn("Failed to fetch the jobs of the federated Prow.")
+		state.status.Error = jobsErr.Error()
+		return
+	}
+	state.status.Error = ""
+	state.status.LastSuccess = now
+	state.jobs = jobs.Items
+	state.status.Jobs = len(jobs.Items)
+	if tideErr != nil {
+		state.status.TideError = tideErr.Error()
+		state.pools = nil
+	} else {
+		state.status.TideError = ""
+		state.pools = pools.Pools
+	}
+	state.status.Pools = len(state.pools)
+}
+
+func (fa *federationAgent) fetch(path string, data interface{}) error {
+	resp, err := fa.client.Get(path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("response has status code %d", resp.StatusCode)
+	}
+	body := &io.LimitedReader{R: resp.Body, N: fa.maxResponseSize + 1}
+	if err := json.NewDecoder(body).Decode(data); err != nil {
+		if body.N <= 0 {
+			return fmt.Errorf("response is larger than %d bytes", fa.maxResponseSize)
+		}
+		return err
+	}
+	return nil
+}
+
+// health determines the health of a source from its last successful update.
+// Sources that were not updated for three update periods are stale.
+func (fa *federationAgent) health(status federatedSourceStatus, now time.Time) string {
+	switch {
+	case status.LastSuccess.IsZero():
+		return federationUnreachable
+	case status.Error != "" || now.Sub(status.LastSuccess) > 3*fa.updatePeriod:
+		return federationStale
+	default:
+		return federationHealthy
+	}
+}
+
+// data returns the aggregated jobs and Tide pools, labeled with their
+// source. Jobs are sorted by start time, the most recent first.
+func (fa *federationAgent) data(now time.Time) federationData {
+	fa.lock.Lock()
+	defer fa.lock.Unlock()
+	data := federationData{Sources: []federatedSourceStatus{}, Jobs: []federatedJob{}, Pools: []federatedPool{}}
+	for _, source := range fa.sources {
+		state := fa.state[source.Name]
+		status := state.status
+		status.Health = fa.health(status, now)
+		data.Sources = append(data.Sources, status)
+		for _, job := range state.jobs {
+			data.Jobs = append(data.Jobs, federatedJob{Source: source.Name, Job: job})
+		}
+		for _, pool := range state.pools {
+			data.Pools = append(data.Pools, federatedPool{Source: source.Name, Pool: pool})
+		}
+	}
+	sort.SliceStable(data.Jobs, func(i, j int) bool {
+		return data.Jobs[i].Job.Status.StartTime.After(data.Jobs[j].Job.Status.StartTime.Time)
+	})
+	return data
+}
+
+// page returns the aggregated data with the federationJobDisplayLimit most
+// recent jobs, for the federation view.
+func (fa *federationAgent) page(now time.Time) federationPage {
+	data := fa.data(now)
+	page := federationPage{federationData: data, TotalJobs: len(data.Jobs)}
+	if len(page.Jobs) > federationJobDisplayLimit {
+		page.Jobs = page.Jobs[:federationJobDisplayLimit]
+	}
+	return page
+}
+
+func handleFederation(fa *federationAgent, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		fd, err := json.Marshal(fa.data(time.Now()))
+		if err != nil {
+			log.WithError(err).Error("Error marshaling federation data.")
+			fd = []byte("{}")
+		}
+		writeJSONResponse(w, r, fd)
+	}
+}
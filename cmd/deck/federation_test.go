@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/tide"
+)
+
+func TestParseFederationSource(t *testing.T) {
+	testCases := []struct {
+		value       string
+		expected    federationSource
+		expectedErr bool
+	}{
+		{value: "us=https://prow.us.example.com/", expected: federationSource{Name: "us", URL: "https://prow.us.example.com"}},
+		{value: "us=https://prow.example.com/us", expected: federationSource{Name: "us", URL: "https://prow.example.com/us"}},
+		{value: "https://prow.us.example.com", expectedErr: true},
+		{value: "=https://prow.us.example.com", expectedErr: true},
+		{value: "us=", expectedErr: true},
+		{value: "us=prow.us.example.com", expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			actual, err := parseFederationSource(tc.value)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected source (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func fakeDeck(t *testing.T, jobs []prowapi.ProwJob, pools []tide.PoolForDeck) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/prowjobs.js", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("omit") == "" {
+			t.Error("expected the federated Deck to be asked to omit fields")
+		}
+		json.NewEncoder(w).Encode(struct {
+			Items []prowapi.ProwJob `json:"items"`
+		}{jobs})
+	})
+	if pools != nil {
+		mux.HandleFunc("/tide.js", func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(tidePools{Pools: pools})
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func federatedProwJob(name string, started time.Time) prowapi.ProwJob {
+	return prowapi.ProwJob{
+		Spec:   prowapi.ProwJobSpec{Job: name, Type: prowapi.PeriodicJob},
+		Status: prowapi.ProwJobStatus{State: prowapi.SuccessState, StartTime: metav1.NewTime(started)},
+	}
+}
+
+func TestFederationAgent(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	us := fakeDeck(t,
+		[]prowapi.ProwJob{federatedProwJob("us-old", now.Add(-time.Hour)), federatedProwJob("us-new", now)},
+		[]tide.PoolForDeck{{Org: "org", Repo: "repo", Branch: "main"}},
+	)
+	eu := fakeDeck(t, []prowapi.ProwJob{federatedProwJob("eu", now.Add(-time.Minute))}, nil)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	fa := newFederationAgent([]federationSource{
+		{Name: "us", URL: us.URL},
+		{Name: "eu", URL: eu.URL},
+		{Name: "down", URL: down.URL},
+	}, time.Minute)
+	for _, source := range fa.sources {
+		fa.updateSource(source, now)
+	}
+
+	data := fa.data(now)
+	var health, jobs []string
+	for _, source := range data.Sources {
+		health = append(health, source.Name+": "+source.Health)
+	}
+	for _, job := range data.Jobs {
+		jobs = append(jobs, job.Source+": "+job.Job.Spec.Job)
+	}
+	if diff := cmp.Diff([]string{"us: healthy", "eu: healthy", "down: unreachable"}, health); diff != "" {
+		t.Errorf("unexpected health (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"us: us-new", "eu: eu", "us: us-old"}, jobs); diff != "" {
+		t.Errorf("unexpected jobs (-want +got):\n%s", diff)
+	}
+	if len(data.Pools) != 1 || data.Pools[0].Source != "us" || data.Pools[0].Pool.Repo != "repo" {
+		t.Errorf("expected the pool of us, got %+v", data.Pools)
+	}
+	if data.Sources[1].TideError == "" {
+		t.Error("expected a Tide error for the source without Tide")
+	}
+	if data.Sources[2].Error == "" {
+		t.Error("expected an error for the unreachable source")
+	}
+
+	// A source that fails after succeeding keeps its jobs but becomes stale.
+	fa.sources[0].URL = down.URL
+	fa.updateSource(fa.sources[0], now.Add(time.Minute))
+	data = fa.data(now.Add(time.Minute))
+	if data.Sources[0].Health != federationStale || data.Sources[0].Jobs != 2 {
+		t.Errorf("expected the failing source to be stale and keep its jobs, got %+v", data.Sources[0])
+	}
+	// So does a source that was not updated for three update periods.
+	if health := fa.data(now.Add(4 * time.Minute)).Sources[1].Health; health != federationStale {
+		t.Errorf("expected the source that was not updated to be stale, got %s", health)
+	}
+}
+
+func TestFederationAgentLimits(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	var jobs []prowapi.ProwJob
+	for i := 0; i < federationJobDisplayLimit+1; i++ {
+		jobs = append(jobs, federatedProwJob(fmt.Sprintf("job-%d", i), now.Add(-time.Duration(i)*time.Second)))
+	}
+	deck := fakeDeck(t, jobs, nil)
+
+	fa := newFederationAgent([]federationSource{{Name: "us", URL: deck.URL}}, time.Minute)
+	fa.updateSource(fa.sources[0], now)
+	page := fa.page(now)
+	if page.TotalJobs != len(jobs) || len(page.Jobs) != federationJobDisplayLimit {
+		t.Errorf("expected %d of %d jobs to be rendered, got %d of %d", federationJobDisplayLimit, len(jobs), len(page.Jobs), page.TotalJobs)
+	}
+	if page.Jobs[0].Job.Spec.Job != "job-0" {
+		t.Errorf("expected the most recent job to be rendered first, got %s", page.Jobs[0].Job.Spec.Job)
+	}
+
+	fa = newFederationAgent([]federationSource{{Name: "us", URL: deck.URL}}, time.Minute)
+	fa.maxResponseSize = 1024
+	fa.updateSource(fa.sources[0], now)
+	status := fa.data(now).Sources[0]
+	if !strings.Contains(status.Error, "response is larger than 1024 bytes") || status.Jobs != 0 {
+		t.Errorf("expected the oversized response to be rejected, got %+v", status)
+	}
+}
@@ -38,6 +38,7 @@ import (
 	pkgio "sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/io/providers"
 	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
+	"sigs.k8s.io/prow/pkg/spyglass"
 )
 
 const (
@@ -67,6 +68,11 @@ type buildData struct {
 	Result       string
 	commitHash   string
 	Refs         *prowv1.Refs
+	// Origin is the location the build was read from, if the history
+	// merges several locations.
+	Origin string `json:",omitempty"`
+	// TriageNotes are the notes users attached to the build.
+	TriageNotes []spyglass.TriageNote `json:",omitempty"`
 }
 
 // storageBucket is an abstraction for unit testing
@@ -102,6 +108,45 @@ type jobHistoryTemplate struct {
 	ResultsShown int
 	ResultsTotal int
 	Builds       []buildData
+	// Merged is true if the history merges the builds of several locations.
+	Merged bool
+	// TriageNotes is true if users may attach notes to builds.
+	TriageNotes bool
+}
+
+// historySource is a location holding results of the job.
+type historySource struct {
+	bucket blobStorageBucket
+	root   string
+}
+
+func (s historySource) location() string {
+	return fmt.Sprintf("%s://%s/%s", s.bucket.getStorageProvider(), s.bucket.getName(), s.root)
+}
+
+// historySources returns the source of the job history requested and the
+// other sources configured for the same job.
+func historySources(cfg *config.Config, opener pkgio.Opener, storageProvider, bucketName, root string) ([]historySource, error) {
+	bucket, err := newBlobStorageBucket(bucketName, storageProvider, cfg, opener)
+	if err != nil {
+		return nil, err
+	}
+	sources := []historySource{{bucket: bucket, root: root}}
+	for _, location := range cfg.Deck.JobHistoryLocations(sources[0].location())[1:] {
+		log := logrus.WithField("location", location)
+		storageProvider, bucketName, root, err := providers.ParseStoragePath(location)
+		if err != nil {
+			log.WithError(err).Warn("Skipping invalid job history source.")
+			continue
+		}
+		bucket, err := newBlobStorageBucket(bucketName, storageProvider, cfg, opener)
+		if err != nil {
+			log.WithError(err).Warn("Skipping invalid job history source.")
+			continue
+		}
+		sources = append(sources, historySource{bucket: bucket, root: root})
+	}
+	return sources, nil
 }
 
 func (bucket blobStorageBucket) readObject(ctx context.Context, key string) ([]byte, error) {
@@ -443,14 +488,28 @@ func getJobHistory(ctx context.Context, url *url.URL, cfg config.Getter, opener
 		bucketName = bucketAlias
 	}
 
-	bucket, err := newBlobStorageBucket(bucketName, storageProvider, cfg(), opener)
+	sources, err := historySources(cfg(), opener, storageProvider, bucketName, root)
 	if err != nil {
 		return tmpl, err
 	}
 	tmpl.Name = root
-	latest, err := readLatestBuild(ctx, bucket, root)
-	if err != nil {
-		return tmpl, fmt.Errorf("failed to locate build data: %w", err)
+	tmpl.TriageNotes = cfg().Deck.Spyglass.TriageNotes != nil
+	tmpl.Merged = len(sources) > 1
+	// The latest build of a merged history is the latest of any source.
+	var latest uint64
+	var latestErr error
+	for _, source := range sources {
+		sourceLatest, err := readLatestBuild(ctx, source.bucket, source.root)
+		if err != nil {
+			latestErr = err
+			continue
+		}
+		if sourceLatest > latest {
+			latest = sourceLatest
+		}
+	}
+	if latest == emptyID && latestErr != nil {
+		return tmpl, fmt.Errorf("failed to locate build data: %w", latestErr)
 	}
 	if top == emptyID || top > latest {
 		top = latest
@@ -462,9 +521,24 @@ func getJobHistory(ctx context.Context, url *url.URL, cfg config.Getter, opener
 	// Don't spend an unbound amount of time finding a potentially huge history
 	buildIDListCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	buildIDs, err := bucket.listBuildIDs(buildIDListCtx, root)
-	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
-		return tmpl, fmt.Errorf("failed to get build ids: %w", err)
+	// Builds found in several sources, e.g. uploaded to several clouds, are
+	// read from the first of them.
+	var buildIDs []uint64
+	sourceOf := map[uint64]historySource{}
+	for i, source := range sources {
+		sourceIDs, err := source.bucket.listBuildIDs(buildIDListCtx, source.root)
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			if i == 0 {
+				return tmpl, fmt.Errorf("failed to get build ids: %w", err)
+			}
+			logrus.WithError(err).WithField("location", source.location()).Warn("Failed to get build ids of job history source.")
+		}
+		for _, id := range sourceIDs {
+			if _, found := sourceOf[id]; !found {
+				sourceOf[id] = source
+				buildIDs = append(buildIDs, id)
+			}
+		}
 	}
 
 	sort.Sort(sort.Reverse(uint64slice(buildIDs)))
@@ -495,6 +569,8 @@ func getJobHistory(ctx context.Context, url *url.URL, cfg config.Getter, opener
 	for i, buildID := range shownIDs {
 		go func(i int, buildID uint64) {
 			id := strconv.FormatUint(buildID, 10)
+			source := sourceOf[buildID]
+			bucket, root := source.bucket, source.root
 			dir, err := bucket.getPath(ctx, root, id, "")
 			if err != nil {
 				if !pkgio.IsNotExist(err) {
@@ -513,6 +589,16 @@ func getJobHistory(ctx context.Context, url *url.URL, cfg config.Getter, opener
 			}
 			b.index = i
 			b.ID = id
+			if tmpl.Merged {
+				b.Origin = source.location()
+			}
+			if tmpl.TriageNotes {
+				var notes spyglass.TriageNotes
+				if err := readJSON(ctx, bucket, path.Join(dir, spyglass.TriageNotesName), &notes); err != nil && !pkgio.IsNotExist(err) {
+					logrus.WithError(err).WithField("build-id", buildID).Warning("Failed to read triage notes.")
+				}
+				b.TriageNotes = notes.Notes
+			}
 			b.SpyglassLink, err = bucket.spyglassLink(ctx, root, id)
 			if err != nil {
 				logrus.WithError(err).Errorf("failed to get spyglass link")
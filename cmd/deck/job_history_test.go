@@ -19,16 +19,20 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/google/go-cmp/cmp"
 
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/spyglass"
 )
 
 func TestJobHistURL(t *testing.T) {
@@ -410,6 +414,123 @@ func Test_getJobHistory(t *testing.T) {
 	}
 }
 
+func Test_getJobHistoryMerged(t *testing.T) {
+	build := func(bucket, id string, timestamp int) []fakestorage.Object {
+		return []fakestorage.Object{
+			{
+				BucketName: bucket,
+				Name:       "logs/ci-job/" + id + "/started.json",
+				Content:    []byte(fmt.Sprintf(`{"timestamp": %d}`, timestamp)),
+			},
+			{
+				BucketName: bucket,
+				Name:       "logs/ci-job/" + id + "/finished.json",
+				Content:    []byte(fmt.Sprintf(`{"timestamp": %d, "result": "SUCCESS"}`, timestamp+10)),
+			},
+		}
+	}
+	var objects []fakestorage.Object
+	objects = append(objects, fakestorage.Object{BucketName: "old-bucket", Name: "logs/ci-job/latest-build.txt", Content: []byte("200")})
+	objects = append(objects, build("old-bucket", "100", 1000)...)
+	objects = append(objects, build("old-bucket", "200", 2000)...)
+	objects = append(objects, fakestorage.Object{BucketName: "new-bucket", Name: "logs/team/ci-job/latest-build.txt", Content: []byte("300")})
+	for _, o := range append(build("new-bucket", "200", 2000), build("new-bucket", "300", 3000)...) {
+		o.Name = strings.Replace(o.Name, "logs/", "logs/team/", 1)
+		objects = append(objects, o)
+	}
+	gcsServer := fakestorage.NewServer(objects)
+	defer gcsServer.Stop()
+
+	boolTrue := true
+	ca := &config.Agent{}
+	ca.Set(&config.Config{
+		ProwConfig: config.ProwConfig{
+			Deck: config.Deck{
+				SkipStoragePathValidation: &boolTrue,
+				JobHistorySources: []config.JobHistorySources{
+					{Locations: []string{"gs://new-bucket/logs/team", "gs://old-bucket/logs/"}},
+				},
+			},
+		},
+	})
+
+	expectedBuild := func(bucket, root, id string, index, timestamp int) buildData {
+		return buildData{
+			index:        index,
+			SpyglassLink: fmt.Sprintf("/view/gs/%s/%s/%s", bucket, root, id),
+			ID:           id,
+			Started:      time.Unix(int64(timestamp), 0),
+			Duration:     10 * time.Second,
+			Result:       "SUCCESS",
+			commitHash:   "Unknown",
+			Origin:       fmt.Sprintf("gs://%s/%s", bucket, root),
+		}
+	}
+	expected := jobHistoryTemplate{
+		Name:         "logs/ci-job",
+		ResultsShown: 3,
+		ResultsTotal: 3,
+		Merged:       true,
+		Builds: []buildData{
+			expectedBuild("new-bucket", "logs/team/ci-job", "300", 0, 3000),
+			expectedBuild("old-bucket", "logs/ci-job", "200", 1, 2000),
+			expectedBuild("old-bucket", "logs/ci-job", "100", 2, 1000),
+		},
+	}
+	jobURL, _ := url.Parse("https://prow.k8s.io/job-history/gs/old-bucket/logs/ci-job")
+	got, err := getJobHistory(context.Background(), jobURL, ca.Config, io.NewGCSOpener(gcsServer.Client()))
+	if err != nil {
+		t.Fatalf("getJobHistory() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, got, cmp.AllowUnexported(buildData{})); diff != "" {
+		t.Errorf("getJobHistory() differs from expected (-want +got):\n%s", diff)
+	}
+}
+
+func Test_getJobHistoryTriageNotes(t *testing.T) {
+	objects := []fakestorage.Object{
+		{BucketName: "bucket", Name: "logs/ci-job/latest-build.txt", Content: []byte("2")},
+		{BucketName: "bucket", Name: "logs/ci-job/1/started.json", Content: []byte(`{"timestamp": 1000}`)},
+		{BucketName: "bucket", Name: "logs/ci-job/1/finished.json", Content: []byte(`{"timestamp": 1010, "result": "FAILURE"}`)},
+		{BucketName: "bucket", Name: "logs/ci-job/1/" + spyglass.TriageNotesName, Content: []byte(`{"notes":[{"author":"alice","text":"known infra issue, ignore","created":"2024-05-01T12:00:00Z"}]}`)},
+		{BucketName: "bucket", Name: "logs/ci-job/2/started.json", Content: []byte(`{"timestamp": 2000}`)},
+		{BucketName: "bucket", Name: "logs/ci-job/2/finished.json", Content: []byte(`{"timestamp": 2010, "result": "SUCCESS"}`)},
+	}
+	gcsServer := fakestorage.NewServer(objects)
+	defer gcsServer.Stop()
+
+	boolTrue := true
+	ca := &config.Agent{}
+	ca.Set(&config.Config{
+		ProwConfig: config.ProwConfig{
+			Deck: config.Deck{
+				SkipStoragePathValidation: &boolTrue,
+				Spyglass:                  config.Spyglass{TriageNotes: &config.TriageNotes{}},
+			},
+		},
+	})
+
+	jobURL, _ := url.Parse("https://prow.k8s.io/job-history/gs/bucket/logs/ci-job")
+	got, err := getJobHistory(context.Background(), jobURL, ca.Config, io.NewGCSOpener(gcsServer.Client()))
+	if err != nil {
+		t.Fatalf("getJobHistory() unexpected error: %v", err)
+	}
+	if !got.TriageNotes {
+		t.Error("expected triage notes to be shown")
+	}
+	expected := map[string][]spyglass.TriageNote{
+		"1": {{Author: "alice", Text: "known infra issue, ignore", Created: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}},
+		"2": nil,
+	}
+	actual := map[string][]spyglass.TriageNote{}
+	for _, build := range got.Builds {
+		actual[build.ID] = build.TriageNotes
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected triage notes (-want +got):\n%s", diff)
+	}
+}
+
 // TestListBuildIDsReturnsResultsOnError verifies that we get results even when there was an error,
 // mostly important so we can timeout it and still get some results.
 func TestListBuildIDsReturnsResultsOnError(t *testing.T) {
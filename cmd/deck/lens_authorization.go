@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+)
+
+// lensViewers decides who is shown the lenses that restrict their viewers
+// with the authorization of deck.spyglass.lenses.
+type lensViewers struct {
+	// goa is set once GitHub OAuth is configured. Without it, nobody is
+	// shown restricted lenses.
+	goa *githuboauth.Agent
+	ghc githuboauth.AuthenticatedUserIdentifier
+	cli github.RerunClient
+	log *logrus.Entry
+}
+
+// AuthorizeLens returns nil if the viewer of the request may be shown the
+// lens, and otherwise the HTTP status to deny the request with.
+func (v *lensViewers) AuthorizeLens(r *http.Request, lens config.LensFileConfig) (int, error) {
+	auth := lens.Authorization
+	if auth == nil {
+		return http.StatusOK, nil
+	}
+	if v.goa == nil {
+		return http.StatusForbidden, fmt.Errorf("the %s lens requires GitHub OAuth to be configured", lens.Lens.Name)
+	}
+	login, err := v.goa.GetLogin(r, v.ghc)
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("log in with GitHub to view the %s lens", lens.Lens.Name)
+	}
+	if auth.Viewers == nil {
+		return http.StatusOK, nil
+	}
+	allowed, err := auth.Viewers.IsAuthorized("", login, v.cli)
+	if err != nil {
+		v.log.WithError(err).WithField("user", login).WithField("lens", lens.Lens.Name).Warn("Failed to check whether the user may view the lens.")
+		return http.StatusInternalServerError, fmt.Errorf("could not verify whether %s may view the %s lens", login, lens.Lens.Name)
+	}
+	if !allowed {
+		return http.StatusForbidden, fmt.Errorf("%s may not view the %s lens", login, lens.Lens.Name)
+	}
+	return http.StatusOK, nil
+}
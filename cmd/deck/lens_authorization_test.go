@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestLensViewersAuthorizeLens(t *testing.T) {
+	testCases := []struct {
+		name          string
+		authorization *config.LensAuthorization
+		expected      int
+	}{
+		{
+			name:     "lens is not restricted",
+			expected: http.StatusOK,
+		},
+		{
+			name:          "viewers cannot log in without GitHub OAuth",
+			authorization: &config.LensAuthorization{RequireLogin: true},
+			expected:      http.StatusForbidden,
+		},
+		{
+			name:          "team members cannot log in without GitHub OAuth",
+			authorization: &config.LensAuthorization{Viewers: &prowapi.RerunAuthConfig{GitHubUsers: []string{"viewer"}}},
+			expected:      http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &lensViewers{log: logrus.WithField("test", t.Name())}
+			lens := config.LensFileConfig{Lens: config.LensConfig{Name: "configdump"}, Authorization: tc.authorization}
+			status, err := v.AuthorizeLens(httptest.NewRequest("POST", "/dynamic/configdump", nil), lens)
+			if status != tc.expected {
+				t.Errorf("expected status %d, got %d", tc.expected, status)
+			}
+			if allowed := err == nil; allowed != (tc.expected == http.StatusOK) {
+				t.Errorf("expected to be allowed: %t, got error: %v", tc.expected == http.StatusOK, err)
+			}
+		})
+	}
+}
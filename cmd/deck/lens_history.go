@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/prow/pkg/config"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
+	spyglassapi "sigs.k8s.io/prow/pkg/spyglass/api"
+)
+
+// lensHistorySources returns the sources of the n builds of the job of src
+// that precede it, most recent first. The builds are read from the job
+// history, so src must be in a storage bucket.
+func lensHistorySources(ctx context.Context, cfg *config.Config, opener pkgio.Opener, src string, n int) ([]string, error) {
+	parts := strings.SplitN(strings.Trim(src, "/"), "/", 3)
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("invalid src %s: expected <storageType>/<bucket>/<folders...>", src)
+	}
+	keyType, bucketName, buildPath := parts[0], parts[1], parts[2]
+	storageProvider := keyType
+	if keyType == spyglassapi.GCSKeyType {
+		storageProvider = providers.GS
+	}
+	if keyType == spyglassapi.ProwKeyType {
+		return nil, fmt.Errorf("src %s is not in a storage bucket", src)
+	}
+	// The sources keep the bucket name of src, even if it is an alias.
+	listedBucketName := bucketName
+	if bucketAlias, exists := cfg.Deck.Spyglass.BucketAliases[bucketName]; exists {
+		listedBucketName = bucketAlias
+	}
+	buildID, err := strconv.ParseUint(path.Base(buildPath), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid src %s: expected it to end with a build ID: %w", src, err)
+	}
+
+	// The builds of PR jobs are indexed by the directory of the job.
+	root := path.Dir(buildPath)
+	if !strings.HasPrefix(buildPath, logsPrefix+"/") {
+		root = path.Join(gcs.PRLogs, "directory", path.Base(root))
+	}
+	bucket, err := newBlobStorageBucket(listedBucketName, storageProvider, cfg, opener)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like the job history, don't spend an unbound amount of time listing
+	// the builds.
+	listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	buildIDs, err := bucket.listBuildIDs(listCtx, root)
+	if err != nil && len(buildIDs) == 0 {
+		return nil, fmt.Errorf("failed to get build ids: %w", err)
+	}
+	sort.Sort(sort.Reverse(uint64slice(buildIDs)))
+	var prior []uint64
+	for _, id := range buildIDs {
+		if id < buildID && len(prior) < n {
+			prior = append(prior, id)
+		}
+	}
+
+	dirs := make([]string, len(prior))
+	var wg sync.WaitGroup
+	for i, id := range prior {
+		wg.Add(1)
+		go func(i int, id uint64) {
+			defer wg.Done()
+			dir, err := bucket.getPath(ctx, root, strconv.FormatUint(id, 10), "")
+			if err == nil {
+				dirs[i] = dir
+			}
+		}(i, id)
+	}
+	wg.Wait()
+
+	var sources []string
+	for _, dir := range dirs {
+		if dir != "" {
+			sources = append(sources, path.Join(keyType, bucketName, dir))
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+)
+
+func TestLensHistorySources(t *testing.T) {
+	objects := []fakestorage.Object{
+		{BucketName: "bucket", Name: "logs/ci-job/1/started.json"},
+		{BucketName: "bucket", Name: "logs/ci-job/2/started.json"},
+		{BucketName: "bucket", Name: "logs/ci-job/3/started.json"},
+		{BucketName: "bucket", Name: "logs/ci-job/4/started.json"},
+		{BucketName: "bucket", Name: "logs/ci-job/5/started.json"},
+		{BucketName: "bucket", Name: "pr-logs/directory/pull-job/10.txt", Content: []byte("gs://bucket/pr-logs/pull/org_repo/1/pull-job/10")},
+		{BucketName: "bucket", Name: "pr-logs/directory/pull-job/11.txt", Content: []byte("gs://bucket/pr-logs/pull/org_repo/2/pull-job/11")},
+		{BucketName: "bucket", Name: "pr-logs/directory/pull-job/12.txt", Content: []byte("gs://bucket/pr-logs/pull/org_repo/1/pull-job/12")},
+	}
+	gcsServer := fakestorage.NewServer(objects)
+	defer gcsServer.Stop()
+
+	boolTrue := true
+	cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{SkipStoragePathValidation: &boolTrue}}}
+
+	cases := []struct {
+		name     string
+		src      string
+		n        int
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "prior builds of a periodic, most recent first",
+			src:      "gs/bucket/logs/ci-job/4",
+			n:        2,
+			expected: []string{"gs/bucket/logs/ci-job/3", "gs/bucket/logs/ci-job/2"},
+		},
+		{
+			name:     "fewer prior builds than requested",
+			src:      "gs/bucket/logs/ci-job/2",
+			n:        5,
+			expected: []string{"gs/bucket/logs/ci-job/1"},
+		},
+		{
+			name:     "prior builds of a presubmit, across pull requests",
+			src:      "gs/bucket/pr-logs/pull/org_repo/1/pull-job/12",
+			n:        5,
+			expected: []string{"gs/bucket/pr-logs/pull/org_repo/2/pull-job/11", "gs/bucket/pr-logs/pull/org_repo/1/pull-job/10"},
+		},
+		{
+			name:    "prowjob sources have no history",
+			src:     "prowjob/ci-job/4",
+			n:       5,
+			wantErr: true,
+		},
+		{
+			name:    "src without a build ID",
+			src:     "gs/bucket/logs/ci-job",
+			n:       5,
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := lensHistorySources(context.Background(), cfg, io.NewGCSOpener(gcsServer.Client()), tc.src, tc.n)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %t, got %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected sources (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
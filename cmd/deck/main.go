@@ -86,14 +86,23 @@ import (
 	// Import standard spyglass viewers
 
 	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/archive"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/buildlog"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/coverage"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/diff"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/html"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/htmlreport"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/junit"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/junithistory"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/k8sevents"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/links"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/metadata"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/podinfo"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/pprof"
 	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/restcoverage"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/sarif"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/tfplan"
+	_ "sigs.k8s.io/prow/pkg/spyglass/lenses/wasm"
 )
 
 // Omittable ProwJob fields.
@@ -140,6 +149,8 @@ type options struct {
 	controllerManager     prowflagutil.ControllerManagerOptions
 	dryRun                bool
 	tenantIDs             prowflagutil.Strings
+	federationSources     prowflagutil.Strings
+	federationPeriod      time.Duration
 }
 
 func (o *options) Validate() error {
@@ -161,6 +172,21 @@ func (o *options) Validate() error {
 	if (o.hiddenOnly && o.showHidden) || (o.tenantIDs.Strings() != nil && (o.hiddenOnly || o.showHidden)) {
 		return errors.New("'--hidden-only', '--tenant-id', and '--show-hidden' are mutually exclusive, 'hidden-only' shows only hidden job, '--tenant-id' shows all jobs with matching ID and 'show-hidden' shows both hidden and non-hidden jobs")
 	}
+
+	names := sets.New[string]()
+	for _, value := range o.federationSources.Strings() {
+		source, err := parseFederationSource(value)
+		if err != nil {
+			return err
+		}
+		if names.Has(source.Name) {
+			return fmt.Errorf("duplicate federation source %q", source.Name)
+		}
+		names.Insert(source.Name)
+	}
+	if names.Len() > 0 && o.federationPeriod <= 0 {
+		return errors.New("--federation-update-period must be positive")
+	}
 	return nil
 }
 
@@ -186,6 +212,8 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.BoolVar(&o.allowInsecure, "allow-insecure", false, "Allows insecure requests for CSRF and GitHub oauth.")
 	fs.BoolVar(&o.dryRun, "dry-run", false, "Whether or not to make mutating API calls to GitHub.")
 	fs.Var(&o.tenantIDs, "tenant-id", "The tenantID(s) used by the ProwJobs that should be displayed by this instance of Deck. This flag can be repeated.")
+	fs.Var(&o.federationSources, "federation-source", "Another Prow install to show the jobs and Tide status of in the federation view, as name=url with the URL of its Deck. This flag can be repeated.")
+	fs.DurationVar(&o.federationPeriod, "federation-update-period", time.Minute, "How often to fetch the jobs and Tide status of the federation sources.")
 	o.config.AddFlags(fs)
 	o.instrumentation.AddFlags(fs)
 	o.controllerManager.TimeoutListingProwJobsDefault = 30 * time.Second
@@ -222,9 +250,14 @@ var simplifier = simplifypath.NewSimplifier(l("", // shadow element mimicking th
 	l(""),
 	l("badge.svg"),
 	l("command-help"),
+	l("component-health"),
+	l("component-health.js"),
+	l("component-versions.js"),
 	l("config"),
 	l("data.js"),
 	l("favicon.ico"),
+	l("federation"),
+	l("federation.js"),
 	l("github-login",
 		l("redirect")),
 	l("github-link"),
@@ -448,20 +481,52 @@ func main() {
 	mux.Handle("/data.js", gziphandler.GzipHandler(handleData(ja, logrus.WithField("handler", "/data.js"))))
 	mux.Handle("/prowjobs.js", gziphandler.GzipHandler(handleProwJobs(ja, logrus.WithField("handler", "/prowjobs.js"))))
 	mux.Handle("/badge.svg", gziphandler.GzipHandler(handleBadge(ja)))
+	mux.Handle(widgetsScriptPath, gziphandler.GzipHandler(handleWidgetsScript()))
+	mux.Handle(widgetsPath+"job", gziphandler.GzipHandler(handleJobWidget(ja, logrus.WithField("handler", widgetsPath+"job"))))
+	mux.Handle(widgetsPath+"pr", gziphandler.GzipHandler(handlePRWidget(ja, logrus.WithField("handler", widgetsPath+"pr"))))
 	mux.Handle("/log", gziphandler.GzipHandler(handleLog(ja, logrus.WithField("handler", "/log"))))
-
+	ha := newComponentHealthAggregator(cfg)
+	interrupts.Tick(ha.update, ha.period)
+	mux.Handle("/component-health.js", gziphandler.GzipHandler(handleComponentHealth(ha, logrus.WithField("handler", "/component-health.js"))))
+	mux.Handle("/component-versions.js", gziphandler.GzipHandler(handleComponentVersions(ha, logrus.WithField("handler", "/component-versions.js"))))
+	mux.Handle("/component-health", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleSimpleTemplate(o, cfg, "component-health.html", ha.data())(w, r)
+	})))
+	mux.Handle("/cost.js", gziphandler.GzipHandler(handleCost(ja, cfg, logrus.WithField("handler", "/cost.js"))))
+	mux.Handle("/cost", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleSimpleTemplate(o, cfg, "cost.html", newCostReport(ja.ProwJobs(), cfg().CostAccounting))(w, r)
+	})))
+
+	decryption := &artifactDecryption{
+		cfg: cfg,
+		ghc: githuboauth.NewAuthenticatedUserIdentifier(&o.github),
+		cli: githubClient,
+		log: logrus.WithField("handler", "artifact-decryption"),
+	}
+	triageNoteAuthors := &triageNoteAuthors{
+		cfg: cfg,
+		ghc: githuboauth.NewAuthenticatedUserIdentifier(&o.github),
+		cli: githubClient,
+		log: logrus.WithField("handler", "/spyglass/triage-notes"),
+	}
+	lensViewers := &lensViewers{
+		ghc: githuboauth.NewAuthenticatedUserIdentifier(&o.github),
+		cli: githubClient,
+		log: logrus.WithField("handler", "lens-authorization"),
+	}
 	if o.spyglass {
-		initSpyglass(cfg, o, mux, ja, githubClient, gitClient)
+		initSpyglass(cfg, o, mux, ja, githubClient, gitClient, decryption, triageNoteAuthors, lensViewers)
 	}
 
 	if runLocal {
 		mux = localOnlyMain(cfg, o, mux)
 	} else {
-		mux = prodOnlyMain(cfg, pluginAgent, authCfgGetter, githubClient, o, mux)
+		mux = prodOnlyMain(cfg, pluginAgent, authCfgGetter, githubClient, decryption, triageNoteAuthors, lensViewers, o, mux)
 	}
 
 	// signal to the world that we're ready
 	health.ServeReady()
+	health.ServeConfigHash(cfg)
 
 	// cookie secret will be used for CSRF protection and should be exactly 32 bytes
 	// we sometimes accept different lengths to stay backwards compatible
@@ -553,7 +618,7 @@ func (fjc *fakePjListingClientWrapper) List(ctx context.Context, pjl *prowapi.Pr
 }
 
 // prodOnlyMain contains logic only used when running deployed, not locally
-func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGetter authCfgGetter, githubClient deckGitHubClient, o options, mux *http.ServeMux) *http.ServeMux {
+func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGetter authCfgGetter, githubClient deckGitHubClient, decryption *artifactDecryption, triageNoteAuthors *triageNoteAuthors, lensViewers *lensViewers, o options, mux *http.ServeMux) *http.ServeMux {
 	prowJobClient, err := o.kubernetes.ProwJobClient(cfg().ProwJobNamespace, false)
 	if err != nil {
 		logrus.WithError(err).Fatal("Error getting ProwJob client for infrastructure cluster.")
@@ -587,9 +652,25 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 			ta.start()
 			mux.Handle("/tide.js", gziphandler.GzipHandler(handleTidePools(cfg, ta, logrus.WithField("handler", "/tide.js"))))
 			mux.Handle("/tide-history.js", gziphandler.GzipHandler(handleTideHistory(ta, logrus.WithField("handler", "/tide-history.js"))))
+			mux.Handle(widgetsPath+"tide", gziphandler.GzipHandler(handleTideWidget(ta, logrus.WithField("handler", widgetsPath+"tide"))))
 		}()
 	}
 
+	if len(o.federationSources.Strings()) > 0 {
+		var sources []federationSource
+		for _, value := range o.federationSources.Strings() {
+			// Validated in options.Validate.
+			source, _ := parseFederationSource(value)
+			sources = append(sources, source)
+		}
+		fa := newFederationAgent(sources, o.federationPeriod)
+		go fa.start()
+		mux.Handle("/federation.js", gziphandler.GzipHandler(handleFederation(fa, logrus.WithField("handler", "/federation.js"))))
+		mux.Handle("/federation", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handleSimpleTemplate(o, cfg, "federation.html", fa.page(time.Now()))(w, r)
+		})))
+	}
+
 	secure := !o.allowInsecure
 
 	// Handles link to github
@@ -628,6 +709,9 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 		githubOAuthConfig.InitGitHubOAuthConfig(cookie)
 
 		goa = githuboauth.NewAgent(&githubOAuthConfig, logrus.WithField("client", "githuboauth"))
+		decryption.goa = goa
+		triageNoteAuthors.goa = goa
+		lensViewers.goa = goa
 		oauthClient := githuboauth.NewClient(&oauth2.Config{
 			ClientID:     githubOAuthConfig.ClientID,
 			ClientSecret: githubOAuthConfig.ClientSecret,
@@ -683,53 +767,76 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 	return mux
 }
 
-func initSpyglass(cfg config.Getter, o options, mux *http.ServeMux, ja *jobs.JobAgent, gitHubClient deckGitHubClient, gitClient git.ClientFactory) {
+func initSpyglass(cfg config.Getter, o options, mux *http.ServeMux, ja *jobs.JobAgent, gitHubClient deckGitHubClient, gitClient git.ClientFactory, decryption *artifactDecryption, triageNoteAuthors *triageNoteAuthors, lensViewers *lensViewers) {
 	ctx := context.TODO()
 	opener, err := io.NewOpener(ctx, o.storage.GCSCredentialsFile, o.storage.S3CredentialsFile)
 	if err != nil {
 		logrus.WithError(err).Fatal("Error creating opener")
 	}
+	opener = io.NewDecryptingOpener(opener, decryption.keyURLs)
 	sg := spyglass.New(ctx, ja, cfg, opener, o.gcsCookieAuth)
 	sg.Start()
 
 	mux.Handle("/spyglass/static/", http.StripPrefix("/spyglass/static", staticHandlerFromDir(o.spyglassFilesLocation)))
-	mux.Handle("/spyglass/lens/", gziphandler.GzipHandler(http.StripPrefix("/spyglass/lens/", handleArtifactView(o, sg, cfg))))
+	mux.Handle("/spyglass/lens/", gzipUnlessStream(http.StripPrefix("/spyglass/lens/", handleArtifactView(o, sg, cfg, opener, decryption))))
+	mux.Handle(lenses.RawArtifactViewerPath, gziphandler.GzipHandler(handleRawArtifact(o, cfg, opener, decryption, logrus.WithField("handler", lenses.RawArtifactViewerPath))))
+	mux.Handle(lenses.SandboxedArtifactPath, gziphandler.GzipHandler(handleSandboxedArtifact(cfg, opener, decryption, logrus.WithField("handler", lenses.SandboxedArtifactPath))))
 	mux.Handle("/view/", gziphandler.GzipHandler(handleRequestJobViews(sg, cfg, o, logrus.WithField("handler", "/view"))))
+	mux.Handle("/spyglass/triage-notes", gziphandler.GzipHandler(handleTriageNotes(sg, cfg, triageNoteAuthors, logrus.WithField("handler", "/spyglass/triage-notes"))))
+	mux.Handle("/spyglass/search", gziphandler.GzipHandler(handleArtifactSearch(sg, cfg, decryption, logrus.WithField("handler", "/spyglass/search"))))
+	mux.Handle("/spyglass/debug-bundle", handleDebugBundle(sg, cfg, decryption, logrus.WithField("handler", "/spyglass/debug-bundle")))
 	mux.Handle("/job-history/", gziphandler.GzipHandler(handleJobHistory(o, cfg, opener, logrus.WithField("handler", "/job-history"))))
 	mux.Handle("/pr-history/", gziphandler.GzipHandler(handlePRHistory(o, cfg, opener, gitHubClient, gitClient, logrus.WithField("handler", "/pr-history"))))
-	if err := initLocalLensHandler(cfg, o, sg); err != nil {
+	if err := initLocalLensHandler(cfg, o, sg, lensViewers); err != nil {
 		logrus.WithError(err).Fatal("Failed to initialize local lens handler")
 	}
 }
 
-func initLocalLensHandler(cfg config.Getter, o options, sg *spyglass.Spyglass) error {
+func initLocalLensHandler(cfg config.Getter, o options, sg *spyglass.Spyglass, authorizer common.LensAuthorizer) error {
+	lensServer, err := common.NewLensServer(spyglassLocalLensListenerAddr, sg.JobAgent, sg.StorageArtifactFetchers, sg.PodLogArtifactFetcher, cfg, authorizer, func(c *config.Config) ([]common.LensWithConfiguration, error) {
+		return localLenses(c, o.spyglassFilesLocation)
+	})
+	if err != nil {
+		return fmt.Errorf("constructing local lens server: %w", err)
+	}
+
+	interrupts.ListenAndServe(lensServer, 5*time.Second)
+	return nil
+}
+
+// localLenses returns the lenses of a config that the local lens server
+// serves, which it looks up again when the config changes.
+func localLenses(c *config.Config, spyglassFilesLocation string) ([]common.LensWithConfiguration, error) {
 	var localLenses []common.LensWithConfiguration
-	for _, lfc := range cfg().Deck.Spyglass.Lenses {
+	for _, lfc := range c.Deck.Spyglass.Lenses {
 		if !strings.HasPrefix(strings.TrimPrefix(lfc.RemoteConfig.Endpoint, "http://"), spyglassLocalLensListenerAddr) {
 			continue
 		}
 
-		lens, err := lenses.GetLens(lfc.Lens.Name)
+		lensOpt := common.LensOpt{
+			LensResourcesDir: lenses.ResourceDirForLens(spyglassFilesLocation, lfc.Lens.Name),
+			LensName:         lfc.Lens.Name,
+			LensTitle:        lfc.RemoteConfig.Title,
+		}
+		if lfc.RemoteConfig.GRPCEndpoint != "" {
+			client, err := common.LensV2ClientForEndpoint(lfc.RemoteConfig.GRPCEndpoint)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't connect to lens %q: %w", lfc.Lens.Name, err)
+			}
+			localLenses = append(localLenses, common.LensWithConfiguration{Config: lensOpt, Client: client})
+			continue
+		}
+
+		lens, err := lenses.GetLensV2(lfc.Lens.Name)
 		if err != nil {
-			return fmt.Errorf("couldn't find local lens %q: %w", lfc.Lens.Name, err)
+			return nil, fmt.Errorf("couldn't find local lens %q: %w", lfc.Lens.Name, err)
 		}
 		localLenses = append(localLenses, common.LensWithConfiguration{
-			Config: common.LensOpt{
-				LensResourcesDir: lenses.ResourceDirForLens(o.spyglassFilesLocation, lfc.Lens.Name),
-				LensName:         lfc.Lens.Name,
-				LensTitle:        lfc.RemoteConfig.Title,
-			},
-			Lens: lens,
+			Config: lensOpt,
+			LensV2: lens,
 		})
 	}
-
-	lensServer, err := common.NewLensServer(spyglassLocalLensListenerAddr, sg.JobAgent, sg.StorageArtifactFetcher, sg.PodLogArtifactFetcher, cfg, localLenses)
-	if err != nil {
-		return fmt.Errorf("constructing local lens server: %w", err)
-	}
-
-	interrupts.ListenAndServe(lensServer, 5*time.Second)
-	return nil
+	return localLenses, nil
 }
 
 func loadToken(file string) ([]byte, error) {
@@ -986,6 +1093,19 @@ func renderSpyglass(ctx context.Context, sg *spyglass.Spyglass, cfg config.Gette
 	var lensIndexes []int
 lensesLoop:
 	for i, lfc := range cfg().Deck.Spyglass.Lenses {
+		if lfc.RemoteConfig != nil && lfc.RemoteConfig.GRPCEndpoint != "" && len(lfc.RequiredFiles) == 0 && len(lfc.OptionalFiles) == 0 {
+			// The lens declares the files it is given.
+			client, err := common.LensV2ClientForEndpoint(lfc.RemoteConfig.GRPCEndpoint)
+			if err != nil {
+				log.WithError(err).WithField("lens", lfc.Lens.Name).Warn("Failed to connect to lens")
+				continue
+			}
+			if matches, ok := client.MatchArtifacts(ctx, artifactNames); ok {
+				lensCache[i] = matches
+				lensIndexes = append(lensIndexes, i)
+			}
+			continue
+		}
 		matches := sets.Set[string]{}
 		for _, re := range lfc.RequiredFiles {
 			found := false
@@ -1052,7 +1172,7 @@ lensesLoop:
 
 	artifactsLink := ""
 	bucket := ""
-	if jobPath != "" && (strings.HasPrefix(jobPath, providers.GS) || strings.HasPrefix(jobPath, providers.S3)) {
+	if jobPath != "" && providers.HasStorageProviderPrefix(jobPath) {
 		bucket = strings.Split(jobPath, "/")[1] // The provider (gs) will be in index 0, followed by the bucket name
 	}
 	gcswebPrefix := cfg().Deck.Spyglass.GetGCSBrowserPrefix(org, repo, bucket)
@@ -1073,9 +1193,13 @@ lensesLoop:
 	}
 
 	prLink := ""
+	var ownership pjutil.JobOwnership
 	j, err := sg.JobAgent.GetProwJob(jobName, buildID)
-	if err == nil && j.Spec.Refs != nil && len(j.Spec.Refs.Pulls) > 0 {
-		prLink = j.Spec.Refs.Pulls[0].Link
+	if err == nil {
+		if j.Spec.Refs != nil && len(j.Spec.Refs.Pulls) > 0 {
+			prLink = j.Spec.Refs.Pulls[0].Link
+		}
+		ownership = pjutil.OwnershipFor(j.Annotations)
 	}
 
 	announcement := ""
@@ -1113,6 +1237,14 @@ lensesLoop:
 		extraLinks = nil
 	}
 
+	var triageNotes []spyglass.TriageNote
+	if cfg().Deck.Spyglass.TriageNotes != nil {
+		triageNotes, err = sg.TriageNotes(ctx, src)
+		if err != nil {
+			log.WithError(err).WithField("page", src).Warn("Failed to read triage notes.")
+		}
+	}
+
 	var viewBuf bytes.Buffer
 	type spyglassTemplate struct {
 		Lenses          map[int]spyglass.LensConfig
@@ -1122,6 +1254,7 @@ lensesLoop:
 		JobHistLink     string
 		ProwJobLink     string
 		ArtifactsLink   string
+		DebugBundleLink string
 		PRHistLink      string
 		Announcement    template.HTML
 		TestgridLink    string
@@ -1133,26 +1266,34 @@ lensesLoop:
 		ProwJob         string
 		ProwJobName     string
 		ProwJobState    string
+		Ownership       pjutil.JobOwnership
+		TriageNotes     []spyglass.TriageNote
+		// TriageNotesConfig is nil if users may not attach notes to runs.
+		TriageNotesConfig *config.TriageNotes
 	}
 	sTmpl := spyglassTemplate{
-		Lenses:          ls,
-		LensIndexes:     lensIndexes,
-		Source:          src,
-		LensArtifacts:   lensCache,
-		JobHistLink:     jobHistLink,
-		ProwJobLink:     prowJobLink,
-		ArtifactsLink:   artifactsLink,
-		PRHistLink:      prHistLink,
-		Announcement:    template.HTML(announcement),
-		TestgridLink:    tgLink,
-		JobName:         jobName,
-		BuildID:         buildID,
-		PRLink:          prLink,
-		ExtraLinks:      extraLinks,
-		ReRunCreatesJob: o.rerunCreatesJob,
-		ProwJob:         prowJob,
-		ProwJobName:     prowJobName,
-		ProwJobState:    string(prowJobState),
+		Lenses:            ls,
+		LensIndexes:       lensIndexes,
+		Source:            src,
+		LensArtifacts:     lensCache,
+		JobHistLink:       jobHistLink,
+		ProwJobLink:       prowJobLink,
+		ArtifactsLink:     artifactsLink,
+		DebugBundleLink:   "/spyglass/debug-bundle?" + url.Values{"src": {src}}.Encode(),
+		PRHistLink:        prHistLink,
+		Announcement:      template.HTML(announcement),
+		TestgridLink:      tgLink,
+		JobName:           jobName,
+		BuildID:           buildID,
+		PRLink:            prLink,
+		ExtraLinks:        extraLinks,
+		ReRunCreatesJob:   o.rerunCreatesJob,
+		ProwJob:           prowJob,
+		ProwJobName:       prowJobName,
+		ProwJobState:      string(prowJobState),
+		Ownership:         ownership,
+		TriageNotes:       triageNotes,
+		TriageNotesConfig: cfg().Deck.Spyglass.TriageNotes,
 	}
 	t := template.New("spyglass.html")
 
@@ -1197,7 +1338,7 @@ func prHistLinkFromTemplate(prHistLinkTemplate, org, repo string, number int) (s
 // Query params:
 // - name: required, specifies the name of the viewer to load
 // - src: required, specifies the job source from which to fetch artifacts
-func handleArtifactView(o options, sg *spyglass.Spyglass, cfg config.Getter) http.HandlerFunc {
+func handleArtifactView(o options, sg *spyglass.Spyglass, cfg config.Getter, opener io.Opener, decryption *artifactDecryption) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		setHeadersNoCaching(w)
 		pathSegments := strings.Split(r.URL.Path, "/")
@@ -1234,11 +1375,43 @@ func handleArtifactView(o options, sg *spyglass.Spyglass, cfg config.Getter) htt
 			return
 		}
 
-		handleRemoteLens(*lens, w, r, resource, request)
+		if request.BaseSource != "" {
+			if err := validateStoragePath(cfg, request.BaseSource); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to process request: %v", err), httpStatusForError(err))
+				return
+			}
+		}
+
+		// Listing the builds of the job takes a while, so prior builds are
+		// only looked up for the requests that render the lens.
+		var historySources []string
+		if lens.HistoryBuilds > 0 && (resource == "iframe" || resource == "rerender") {
+			sources, err := lensHistorySources(r.Context(), cfg(), opener, request.Source, lens.HistoryBuilds)
+			if err != nil {
+				logrus.WithError(err).WithField("src", request.Source).Warn("Failed to find the prior builds of the job, rendering the lens without them.")
+			}
+			historySources = sources
+		}
+
+		handleRemoteLens(*lens, w, r, resource, request, historySources, decryption.authorized(r))
 	}
 }
 
-func handleRemoteLens(lens config.LensFileConfig, w http.ResponseWriter, r *http.Request, resource string, request spyglass.LensRequest) {
+// gzipUnlessStream compresses the responses of next, except for streams of
+// lens updates, which the compression would hold back until enough of them
+// piled up.
+func gzipUnlessStream(next http.Handler) http.Handler {
+	gzipped := gziphandler.GzipHandler(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/stream") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gzipped.ServeHTTP(w, r)
+	})
+}
+
+func handleRemoteLens(lens config.LensFileConfig, w http.ResponseWriter, r *http.Request, resource string, request spyglass.LensRequest, historySources []string, decrypt bool) {
 	var requestType spyglassapi.RequestAction
 	switch resource {
 	case "iframe":
@@ -1247,13 +1420,18 @@ func handleRemoteLens(lens config.LensFileConfig, w http.ResponseWriter, r *http
 		requestType = spyglassapi.RequestActionRerender
 	case "callback":
 		requestType = spyglassapi.RequestActionCallBack
+	case "stream":
+		requestType = spyglassapi.RequestActionStream
 	default:
 		http.NotFound(w, r)
 		return
 	}
 
 	var data string
-	if requestType != spyglassapi.RequestActionInitial {
+	if requestType == spyglassapi.RequestActionStream {
+		// Streams are opened with EventSource, which can only send GET requests.
+		data = r.URL.Query().Get("data")
+	} else if requestType != spyglassapi.RequestActionInitial {
 		dataBytes, err := stdio.ReadAll(r.Body)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to read body: %v", err), http.StatusInternalServerError)
@@ -1263,13 +1441,16 @@ func handleRemoteLens(lens config.LensFileConfig, w http.ResponseWriter, r *http
 	}
 
 	lensRequest := spyglassapi.LensRequest{
-		Action:         requestType,
-		Data:           data,
-		Config:         lens.Lens.Config,
-		ResourceRoot:   "/spyglass/static/" + lens.Lens.Name + "/",
-		Artifacts:      request.Artifacts,
-		ArtifactSource: request.Source,
-		LensIndex:      request.Index,
+		Action:                 requestType,
+		Data:                   data,
+		Config:                 lens.Lens.Config,
+		ResourceRoot:           "/spyglass/static/" + lens.Lens.Name + "/",
+		Artifacts:              request.Artifacts,
+		ArtifactSource:         request.Source,
+		BaseArtifactSource:     request.BaseSource,
+		HistoryArtifactSources: historySources,
+		LensIndex:              request.Index,
+		Decrypt:                decrypt,
 	}
 	serializedRequest, err := json.Marshal(lensRequest)
 	if err != nil {
@@ -1587,6 +1768,11 @@ func spglassConfigDefaulting(c *config.Config) error {
 			return fmt.Errorf("failed to parse url %q for remote lens %q: %w", c.Deck.Spyglass.Lenses[idx].RemoteConfig.Endpoint, c.Deck.Spyglass.Lenses[idx].Lens.Name, err)
 		}
 		c.Deck.Spyglass.Lenses[idx].RemoteConfig.ParsedEndpoint = parsedEndpoint
+		// Remote lenses are proxied to without going through the lens server,
+		// which is what denies the viewers of restricted lenses.
+		if c.Deck.Spyglass.Lenses[idx].Authorization != nil && parsedEndpoint.Host != spyglassLocalLensListenerAddr {
+			return fmt.Errorf("remote lens %q cannot restrict its viewers, only lenses served by Deck can", c.Deck.Spyglass.Lenses[idx].Lens.Name)
+		}
 	}
 
 	return nil
@@ -1599,7 +1785,16 @@ func defaultLensRemoteConfig(lfc *config.LensFileConfig) error {
 		return nil
 	}
 
-	lens, err := lenses.GetLens(lfc.Lens.Name)
+	if lfc.RemoteConfig != nil && lfc.RemoteConfig.GRPCEndpoint != "" {
+		// Lenses served over gRPC are served by the local lens server.
+		lfc.RemoteConfig.Endpoint = fmt.Sprintf("http://%s%s", spyglassLocalLensListenerAddr, common.DynamicPathForLens(lfc.Lens.Name))
+		if lfc.RemoteConfig.Title == "" {
+			lfc.RemoteConfig.Title = lfc.Lens.Name
+		}
+		return nil
+	}
+
+	lens, err := lenses.GetLensV2(lfc.Lens.Name)
 	if err != nil {
 		return fmt.Errorf("lens %q has no remote_config and could not get default: %w", lfc.Lens.Name, err)
 	}
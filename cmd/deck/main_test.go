@@ -79,6 +79,9 @@ func (ca fca) Config() *config.Config {
 func TestOptions_Validate(t *testing.T) {
 	setTenantIDs := flagutil.Strings{}
 	setTenantIDs.Set("Test")
+	federationSources := flagutil.NewStrings("us=https://prow.us.example.com", "eu=https://prow.eu.example.com/")
+	duplicateFederationSources := flagutil.NewStrings("us=https://prow.us.example.com", "us=https://prow.eu.example.com")
+	invalidFederationSources := flagutil.NewStrings("https://prow.us.example.com")
 	var testCases = []struct {
 		name        string
 		input       options
@@ -174,6 +177,53 @@ func TestOptions_Validate(t *testing.T) {
 			},
 			expectedErr: true,
 		},
+		{
+			name: "federation sources",
+			input: options{
+				config: configflagutil.ConfigOptions{ConfigPath: "test"},
+				controllerManager: flagutil.ControllerManagerOptions{
+					TimeoutListingProwJobsDefault: 30 * time.Second,
+				},
+				federationSources: federationSources,
+				federationPeriod:  time.Minute,
+			},
+			expectedErr: false,
+		},
+		{
+			name: "duplicate federation sources",
+			input: options{
+				config: configflagutil.ConfigOptions{ConfigPath: "test"},
+				controllerManager: flagutil.ControllerManagerOptions{
+					TimeoutListingProwJobsDefault: 30 * time.Second,
+				},
+				federationSources: duplicateFederationSources,
+				federationPeriod:  time.Minute,
+			},
+			expectedErr: true,
+		},
+		{
+			name: "federation source without name",
+			input: options{
+				config: configflagutil.ConfigOptions{ConfigPath: "test"},
+				controllerManager: flagutil.ControllerManagerOptions{
+					TimeoutListingProwJobsDefault: 30 * time.Second,
+				},
+				federationSources: invalidFederationSources,
+				federationPeriod:  time.Minute,
+			},
+			expectedErr: true,
+		},
+		{
+			name: "federation sources without update period",
+			input: options{
+				config: configflagutil.ConfigOptions{ConfigPath: "test"},
+				controllerManager: flagutil.ControllerManagerOptions{
+					TimeoutListingProwJobsDefault: 30 * time.Second,
+				},
+				federationSources: federationSources,
+			},
+			expectedErr: true,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -712,6 +762,7 @@ func Test_gatherOptions(t *testing.T) {
 				spyglassFilesLocation: "/lenses",
 				github:                ghoptions,
 				instrumentation:       flagutil.DefaultInstrumentationOptions(),
+				federationPeriod:      time.Minute,
 			}
 			if tc.expected != nil {
 				tc.expected(expected)
@@ -1039,6 +1090,23 @@ func TestSpyglassConfigDefaulting(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name: "remote lens restricting its viewers fails",
+			in: &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{
+				Lenses: []config.LensFileConfig{{
+					Lens:          config.LensConfig{Name: "remote"},
+					RemoteConfig:  &config.LensRemoteConfig{Endpoint: "http://lens.example.com/remote"},
+					Authorization: &config.LensAuthorization{RequireLogin: true},
+				}},
+			}}}},
+			verify: func(_ *config.Config, err error) error {
+				expectedErrMsg := `remote lens "remote" cannot restrict its viewers, only lenses served by Deck can`
+				if err == nil || err.Error() != expectedErrMsg {
+					return fmt.Errorf("expected err to be %q, was %w", expectedErrMsg, err)
+				}
+				return nil
+			},
+		},
 	}
 
 	for _, tc := range testCases {
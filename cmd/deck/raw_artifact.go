@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	stdio "io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/spyglass"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+// rawArtifactTemplate is the data used to render raw-artifact.html.
+type rawArtifactTemplate struct {
+	Name         string
+	Path         string
+	ContentType  string
+	Kind         spyglass.ArtifactKind
+	Size         int64
+	Truncated    bool
+	Content      template.HTML
+	DownloadLink string
+}
+
+// handleRawArtifact serves a single artifact from storage. Text, JSON and
+// YAML artifacts are pretty-printed and highlighted inline, everything else
+// is streamed as a download. The url must look like this:
+//
+// /spyglass/raw/<storage-provider>/<bucket-name>/<path-to-artifact>[?download]
+//
+// Example:
+// - /spyglass/raw/gs/kubernetes-jenkins/logs/ci-kubernetes-e2e-prow-canary/1234/artifacts/junit.xml
+func handleRawArtifact(o options, cfg config.Getter, opener io.Opener, decryption *artifactDecryption, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		viewer := cfg().Deck.Spyglass.RawArtifactViewer
+		ctx, cancel := context.WithTimeout(decryption.context(r.Context(), r), viewer.GetTimeout())
+		defer cancel()
+
+		artifactPath := strings.TrimPrefix(r.URL.Path, lenses.RawArtifactViewerPath)
+		storagePath, err := rawArtifactStoragePath(cfg, artifactPath)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to process request: %v", err), httpStatusForError(err))
+			return
+		}
+		attrs, err := opener.Attributes(ctx, storagePath)
+		if err != nil {
+			if io.IsNotExist(err) {
+				http.NotFound(w, r)
+				return
+			}
+			log.WithError(err).WithField("path", storagePath).Warn("Failed to get artifact attributes.")
+			http.Error(w, fmt.Sprintf("Failed to get artifact: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if attrs.Size > viewer.GetDownloadSizeLimit() {
+			http.Error(w, fmt.Sprintf("Artifact is %d bytes, which is over the limit of %d bytes.", attrs.Size, viewer.GetDownloadSizeLimit()), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		reader, err := opener.Reader(ctx, storagePath)
+		if err != nil {
+			log.WithError(err).WithField("path", storagePath).Warn("Failed to open artifact.")
+			http.Error(w, fmt.Sprintf("Failed to open artifact: %v", err), http.StatusInternalServerError)
+			return
+		}
+		defer reader.Close()
+		// The reader transparently decompresses artifacts stored with a
+		// content encoding, so the size reported by the storage provider
+		// is not the size of what we serve.
+		limited := stdio.LimitReader(reader, viewer.GetDownloadSizeLimit())
+		buffered := bufio.NewReaderSize(limited, spyglass.SniffLen)
+		head, err := buffered.Peek(spyglass.SniffLen)
+		if err != nil && !errors.Is(err, stdio.EOF) {
+			log.WithError(err).WithField("path", storagePath).Warn("Failed to read artifact.")
+			http.Error(w, fmt.Sprintf("Failed to read artifact: %v", err), http.StatusInternalServerError)
+			return
+		}
+		name := path.Base(storagePath)
+		contentType, kind := spyglass.DetectContentType(name, head)
+
+		_, download := r.URL.Query()["download"]
+		if download || kind == spyglass.ArtifactKindBinary || attrs.Size > viewer.GetRenderSizeLimit() {
+			if viewer != nil && viewer.SignedURLDownloads {
+				if link, ok := signedDownloadURL(r.Context(), opener, storagePath, name, viewer, decryption.authorized(r), log); ok {
+					http.Redirect(w, r, link, http.StatusFound)
+					return
+				}
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			if attrs.ContentEncoding == "" {
+				w.Header().Set("Content-Length", strconv.FormatInt(attrs.Size, 10))
+			}
+			if _, err := stdio.Copy(w, buffered); err != nil {
+				log.WithError(err).WithField("path", storagePath).Info("Failed to stream artifact.")
+			}
+			return
+		}
+
+		content, err := stdio.ReadAll(stdio.LimitReader(buffered, viewer.GetRenderSizeLimit()))
+		if err != nil {
+			log.WithError(err).WithField("path", storagePath).Warn("Failed to read artifact.")
+			http.Error(w, fmt.Sprintf("Failed to read artifact: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		handleSimpleTemplate(o, cfg, "raw-artifact.html", rawArtifactTemplate{
+			Name:         name,
+			Path:         storagePath,
+			ContentType:  contentType,
+			Kind:         kind,
+			Size:         int64(len(content)),
+			Truncated:    int64(len(content)) == viewer.GetRenderSizeLimit(),
+			Content:      spyglass.Highlight(kind, spyglass.PrettyPrint(kind, content)),
+			DownloadLink: r.URL.Path + "?download",
+		})(w, r)
+	}
+}
+
+// signedDownloadURL returns a short-lived signed URL to download an artifact
+// directly from storage. Artifacts Deck would decrypt for the viewer are not
+// redirected, as storage serves them encrypted.
+func signedDownloadURL(ctx context.Context, opener io.Opener, storagePath, name string, viewer *config.RawArtifactViewer, decrypt bool, log *logrus.Entry) (string, bool) {
+	if decrypt {
+		encrypted, err := io.IsEncrypted(ctx, opener, storagePath)
+		if err != nil {
+			log.WithError(err).WithField("path", storagePath).Warn("Failed to check whether the artifact is encrypted.")
+			return "", false
+		}
+		if encrypted {
+			return "", false
+		}
+	}
+	link, err := opener.SignedURL(ctx, storagePath, io.SignedURLOptions{
+		RequireSignature:   true,
+		Expiry:             viewer.GetSignedURLExpiry(),
+		ContentDisposition: mime.FormatMediaType("attachment", map[string]string{"filename": name}),
+	})
+	if err != nil {
+		log.WithError(err).WithField("path", storagePath).Warn("Failed to sign the artifact URL, streaming the artifact instead.")
+		return "", false
+	}
+	return link, true
+}
+
+// rawArtifactStoragePath validates the path of an artifact requested from
+// the raw artifact viewer and turns it into a storage path.
+func rawArtifactStoragePath(cfg config.Getter, artifactPath string) (string, error) {
+	if err := validateStoragePath(cfg, artifactPath); err != nil {
+		return "", err
+	}
+	parts := strings.SplitN(artifactPath, "/", 2)
+	if strings.HasSuffix(parts[1], "/") || strings.Contains("/"+parts[1]+"/", "/../") {
+		return "", httpError{
+			error:      fmt.Errorf("invalid artifact path: %s", artifactPath),
+			statusCode: http.StatusBadRequest,
+		}
+	}
+	provider := parts[0]
+	switch provider {
+	case "gcs":
+		provider = providers.GS
+	case providers.GS, providers.S3, providers.Azure:
+	default:
+		return "", httpError{
+			error:      fmt.Errorf("unsupported storage provider: %s", provider),
+			statusCode: http.StatusBadRequest,
+		}
+	}
+	return fmt.Sprintf("%s://%s", provider, parts[1]), nil
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+)
+
+func TestRawArtifactStoragePath(t *testing.T) {
+	boolTrue := true
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{SkipStoragePathValidation: &boolTrue}}}
+	}
+	testCases := []struct {
+		name         string
+		artifactPath string
+		expected     string
+		expectedCode int
+	}{
+		{
+			name:         "gs artifact",
+			artifactPath: "gs/bucket/logs/job/1/build-log.txt",
+			expected:     "gs://bucket/logs/job/1/build-log.txt",
+		},
+		{
+			name:         "gcs is an alias for gs",
+			artifactPath: "gcs/bucket/logs/job/1/build-log.txt",
+			expected:     "gs://bucket/logs/job/1/build-log.txt",
+		},
+		{
+			name:         "s3 artifact",
+			artifactPath: "s3/bucket/logs/job/1/build-log.txt",
+			expected:     "s3://bucket/logs/job/1/build-log.txt",
+		},
+		{
+			name:         "local files are rejected",
+			artifactPath: "file/etc/passwd",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "directories are rejected",
+			artifactPath: "gs/bucket/logs/job/1/",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "parent references are rejected",
+			artifactPath: "gs/bucket/logs/../other/1/build-log.txt",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "missing bucket",
+			artifactPath: "gs/bucket",
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := rawArtifactStoragePath(cfg, tc.artifactPath)
+			if tc.expectedCode != 0 {
+				if err == nil {
+					t.Fatalf("expected an error, got storage path %q", actual)
+				}
+				if code := httpStatusForError(err); code != tc.expectedCode {
+					t.Errorf("expected status %d, got %d", tc.expectedCode, code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected storage path %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHandleRawArtifact(t *testing.T) {
+	gcsServer := fakestorage.NewServer([]fakestorage.Object{
+		{
+			BucketName: "bucket",
+			Name:       "logs/job/1/finished.json",
+			Content:    []byte(`{"passed":true,"result":"SUCCESS"}`),
+		},
+		{
+			BucketName: "bucket",
+			Name:       "logs/job/1/artifacts/report.html",
+			Content:    []byte(`<html><script>alert(1)</script></html>`),
+		},
+		{
+			BucketName: "bucket",
+			Name:       "logs/job/1/artifacts/big.log",
+			Content:    []byte(strings.Repeat("x", 100)),
+		},
+	})
+	defer gcsServer.Stop()
+	opener := io.NewGCSOpener(gcsServer.Client())
+
+	boolTrue := true
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{
+			SkipStoragePathValidation: &boolTrue,
+			Spyglass: config.Spyglass{
+				RawArtifactViewer: &config.RawArtifactViewer{RenderSizeLimit: 50, DownloadSizeLimit: 1000},
+			},
+		}}}
+	}
+	o := options{templateFilesLocation: "template"}
+
+	testCases := []struct {
+		name                string
+		path                string
+		expectedCode        int
+		expectedContentType string
+		expectedAttachment  bool
+		expectedBody        []string
+	}{
+		{
+			name:                "json is rendered pretty-printed and highlighted",
+			path:                "/spyglass/raw/gs/bucket/logs/job/1/finished.json",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        []string{`<span class="hl-key">&#34;passed&#34;</span>: <span class="hl-literal">true</span>,`},
+		},
+		{
+			name:                "json is downloaded on request",
+			path:                "/spyglass/raw/gs/bucket/logs/job/1/finished.json?download",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedAttachment:  true,
+			expectedBody:        []string{`{"passed":true,"result":"SUCCESS"}`},
+		},
+		{
+			name:                "html is never rendered",
+			path:                "/spyglass/raw/gs/bucket/logs/job/1/artifacts/report.html",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedAttachment:  true,
+		},
+		{
+			name:                "artifacts over the render limit are downloaded",
+			path:                "/spyglass/raw/gs/bucket/logs/job/1/artifacts/big.log",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedAttachment:  true,
+		},
+		{
+			name:         "missing artifact",
+			path:         "/spyglass/raw/gs/bucket/logs/job/1/missing.txt",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unsupported provider",
+			path:         "/spyglass/raw/file/bucket/logs/job/1/finished.json",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+			handleRawArtifact(o, cfg, opener, nil, logrus.WithField("handler", "/spyglass/raw/"))(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			if contentType := rr.Header().Get("Content-Type"); contentType != tc.expectedContentType {
+				t.Errorf("expected content type %q, got %q", tc.expectedContentType, contentType)
+			}
+			if attachment := strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment"); attachment != tc.expectedAttachment {
+				t.Errorf("expected attachment to be %t, got %t", tc.expectedAttachment, attachment)
+			}
+			if nosniff := rr.Header().Get("X-Content-Type-Options"); nosniff != "nosniff" {
+				t.Errorf("expected X-Content-Type-Options to be nosniff, got %q", nosniff)
+			}
+			for _, expected := range tc.expectedBody {
+				if !strings.Contains(rr.Body.String(), expected) {
+					t.Errorf("expected body to contain %q, got:\n%s", expected, rr.Body.String())
+				}
+			}
+		})
+	}
+}
+
+// signingOpener signs URLs without credentials.
+type signingOpener struct {
+	io.Opener
+	opts io.SignedURLOptions
+}
+
+func (o *signingOpener) SignedURL(_ context.Context, p string, opts io.SignedURLOptions) (string, error) {
+	o.opts = opts
+	return "https://signed.example.com/" + strings.TrimPrefix(p, "gs://"), nil
+}
+
+func TestHandleRawArtifactSignedURLs(t *testing.T) {
+	gcsServer := fakestorage.NewServer([]fakestorage.Object{
+		{
+			BucketName: "bucket",
+			Name:       "logs/job/1/finished.json",
+			Content:    []byte(`{"passed":true,"result":"SUCCESS"}`),
+		},
+		{
+			BucketName: "bucket",
+			Name:       "logs/job/1/artifacts/report.tar",
+			Content:    []byte{0x1f, 0x8b, 0, 1, 2, 3},
+		},
+	})
+	defer gcsServer.Stop()
+	opener := &signingOpener{Opener: io.NewGCSOpener(gcsServer.Client())}
+
+	boolTrue := true
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{
+			SkipStoragePathValidation: &boolTrue,
+			Spyglass: config.Spyglass{
+				RawArtifactViewer: &config.RawArtifactViewer{SignedURLDownloads: true, SignedURLExpiry: &metav1.Duration{Duration: time.Hour}},
+			},
+		}}}
+	}
+	o := options{templateFilesLocation: "template"}
+
+	testCases := []struct {
+		name             string
+		path             string
+		expectedCode     int
+		expectedLocation string
+	}{
+		{
+			name:         "rendered artifacts are served by Deck",
+			path:         "/spyglass/raw/gs/bucket/logs/job/1/finished.json",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:             "downloads are redirected to signed URLs",
+			path:             "/spyglass/raw/gs/bucket/logs/job/1/finished.json?download",
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://signed.example.com/bucket/logs/job/1/finished.json",
+		},
+		{
+			name:             "binary artifacts are redirected to signed URLs",
+			path:             "/spyglass/raw/gs/bucket/logs/job/1/artifacts/report.tar",
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://signed.example.com/bucket/logs/job/1/artifacts/report.tar",
+		},
+		{
+			name:         "paths are validated before signing",
+			path:         "/spyglass/raw/gs/bucket/logs/job/1/../2/finished.json?download",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opener.opts = io.SignedURLOptions{}
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+			handleRawArtifact(o, cfg, opener, nil, logrus.WithField("handler", "/spyglass/raw/"))(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if location := rr.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("expected location %q, got %q", tc.expectedLocation, location)
+			}
+			if tc.expectedLocation == "" {
+				return
+			}
+			if !opener.opts.RequireSignature || opener.opts.Expiry != time.Hour || !strings.HasPrefix(opener.opts.ContentDisposition, "attachment") {
+				t.Errorf("unexpected signing options %+v", opener.opts)
+			}
+		})
+	}
+}
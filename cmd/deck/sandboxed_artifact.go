@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	stdio "io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/spyglass"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+// handleSandboxedArtifact serves a single artifact from storage as is, with
+// a Content-Security-Policy that sandboxes it in an opaque origin. HTML
+// documents have their relative URLs rewritten so stylesheets, scripts and
+// images next to them are served sandboxed as well. The url must look like
+// this:
+//
+// /spyglass/sandbox/<storage-provider>/<bucket-name>/<path-to-artifact>
+//
+// Example:
+// - /spyglass/sandbox/gs/kubernetes-jenkins/logs/ci-kubernetes-e2e-prow-canary/1234/artifacts/report.html
+func handleSandboxedArtifact(cfg config.Getter, opener io.Opener, decryption *artifactDecryption, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		w.Header().Set("Content-Security-Policy", spyglass.SandboxPolicy)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		viewer := cfg().Deck.Spyglass.RawArtifactViewer
+		ctx, cancel := context.WithTimeout(decryption.context(r.Context(), r), viewer.GetTimeout())
+		defer cancel()
+
+		artifactPath := strings.TrimPrefix(r.URL.Path, lenses.SandboxedArtifactPath)
+		storagePath, err := rawArtifactStoragePath(cfg, artifactPath)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to process request: %v", err), httpStatusForError(err))
+			return
+		}
+		attrs, err := opener.Attributes(ctx, storagePath)
+		if err != nil {
+			if io.IsNotExist(err) {
+				http.NotFound(w, r)
+				return
+			}
+			log.WithError(err).WithField("path", storagePath).Warn("Failed to get artifact attributes.")
+			http.Error(w, fmt.Sprintf("Failed to get artifact: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if attrs.Size > viewer.GetDownloadSizeLimit() {
+			http.Error(w, fmt.Sprintf("Artifact is %d bytes, which is over the limit of %d bytes.", attrs.Size, viewer.GetDownloadSizeLimit()), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		reader, err := opener.Reader(ctx, storagePath)
+		if err != nil {
+			log.WithError(err).WithField("path", storagePath).Warn("Failed to open artifact.")
+			http.Error(w, fmt.Sprintf("Failed to open artifact: %v", err), http.StatusInternalServerError)
+			return
+		}
+		defer reader.Close()
+		limited := stdio.LimitReader(reader, viewer.GetDownloadSizeLimit())
+		buffered := bufio.NewReaderSize(limited, spyglass.SniffLen)
+		head, err := buffered.Peek(spyglass.SniffLen)
+		if err != nil && !errors.Is(err, stdio.EOF) {
+			log.WithError(err).WithField("path", storagePath).Warn("Failed to read artifact.")
+			http.Error(w, fmt.Sprintf("Failed to read artifact: %v", err), http.StatusInternalServerError)
+			return
+		}
+		contentType := spyglass.SandboxContentType(path.Base(storagePath), head)
+		w.Header().Set("Content-Type", contentType)
+
+		if !spyglass.IsHTMLContentType(contentType) {
+			if attrs.ContentEncoding == "" {
+				w.Header().Set("Content-Length", strconv.FormatInt(attrs.Size, 10))
+			}
+			if _, err := stdio.Copy(w, buffered); err != nil {
+				log.WithError(err).WithField("path", storagePath).Info("Failed to stream artifact.")
+			}
+			return
+		}
+
+		// Documents are rewritten in memory, so they are held to the
+		// smaller limit of artifacts rendered by the raw artifact viewer.
+		content, err := stdio.ReadAll(stdio.LimitReader(buffered, viewer.GetRenderSizeLimit()+1))
+		if err != nil {
+			log.WithError(err).WithField("path", storagePath).Warn("Failed to read artifact.")
+			http.Error(w, fmt.Sprintf("Failed to read artifact: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if int64(len(content)) > viewer.GetRenderSizeLimit() {
+			http.Error(w, fmt.Sprintf("Document is over the limit of %d bytes.", viewer.GetRenderSizeLimit()), http.StatusRequestEntityTooLarge)
+			return
+		}
+		parts := strings.SplitN(artifactPath, "/", 3)
+		rootPath := lenses.SandboxedArtifactPath + parts[0] + "/" + parts[1] + "/"
+		if _, err := w.Write(spyglass.RewriteSandboxedHTML(content, r.URL.Path, rootPath)); err != nil {
+			log.WithError(err).WithField("path", storagePath).Info("Failed to write document.")
+		}
+	}
+}
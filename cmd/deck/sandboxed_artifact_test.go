@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/spyglass"
+)
+
+func TestHandleSandboxedArtifact(t *testing.T) {
+	gcsServer := fakestorage.NewServer([]fakestorage.Object{
+		{
+			BucketName: "bucket",
+			Name:       "logs/job/1/artifacts/report/index.html",
+			Content:    []byte(`<html><link rel="stylesheet" href="style.css"><img src="/logo.png"></html>`),
+		},
+		{
+			BucketName: "bucket",
+			Name:       "logs/job/1/artifacts/report/style.css",
+			Content:    []byte(`body { color: red; }`),
+		},
+		{
+			BucketName: "bucket",
+			Name:       "logs/job/1/artifacts/big.html",
+			Content:    []byte(strings.Repeat("x", 100)),
+		},
+	})
+	defer gcsServer.Stop()
+	opener := io.NewGCSOpener(gcsServer.Client())
+
+	boolTrue := true
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{
+			SkipStoragePathValidation: &boolTrue,
+			Spyglass: config.Spyglass{
+				RawArtifactViewer: &config.RawArtifactViewer{RenderSizeLimit: 90, DownloadSizeLimit: 1000},
+			},
+		}}}
+	}
+
+	testCases := []struct {
+		name                string
+		path                string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        []string
+	}{
+		{
+			name:                "html is served with its urls rewritten",
+			path:                "/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/index.html",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody: []string{
+				`href="/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/style.css"`,
+				`src="/spyglass/sandbox/gs/bucket/logo.png"`,
+				spyglass.SandboxHeightMessage,
+			},
+		},
+		{
+			name:                "assets are served as is",
+			path:                "/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/style.css",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/css; charset=utf-8",
+			expectedBody:        []string{`body { color: red; }`},
+		},
+		{
+			name:         "html over the render limit is rejected",
+			path:         "/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/big.html",
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "missing artifact",
+			path:         "/spyglass/sandbox/gs/bucket/logs/job/1/missing.html",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "parent references are rejected",
+			path:         "/spyglass/sandbox/gs/bucket/logs/../other/index.html",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+			handleSandboxedArtifact(cfg, opener, nil, logrus.WithField("handler", "/spyglass/sandbox/"))(rr, req)
+			if policy := rr.Header().Get("Content-Security-Policy"); policy != spyglass.SandboxPolicy {
+				t.Errorf("expected the sandbox policy, got %q", policy)
+			}
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			if contentType := rr.Header().Get("Content-Type"); contentType != tc.expectedContentType {
+				t.Errorf("expected content type %q, got %q", tc.expectedContentType, contentType)
+			}
+			if nosniff := rr.Header().Get("X-Content-Type-Options"); nosniff != "nosniff" {
+				t.Errorf("expected X-Content-Type-Options to be nosniff, got %q", nosniff)
+			}
+			for _, expected := range tc.expectedBody {
+				if !strings.Contains(rr.Body.String(), expected) {
+					t.Errorf("expected body to contain %q, got:\n%s", expected, rr.Body.String())
+				}
+			}
+		})
+	}
+}
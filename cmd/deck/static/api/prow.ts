@@ -90,6 +90,7 @@ export interface ProwJobSpec {
   type?: ProwJobType;
   agent?: ProwJobAgent;
   cluster?: string;
+  arch?: string;
   namespace?: string;
   job?: string;
   refs?: Refs;
@@ -108,6 +109,8 @@ export interface ProwJobSpec {
   rerun_auth_config?: object;
   hidden?: boolean;
   prowjob_default?: object;
+  job_queue_name?: string;
+  run_after?: string;
 }
 
 // ProwJobStatus provides runtime metadata, such as when it finished, whether it is running, etc.
@@ -17,7 +17,7 @@ export interface PullRequest extends BasePullRequest {
   Title: string;
 }
 
-export type Action = "WAIT" | "TRIGGER" | "TRIGGER_BATCH" | "MERGE" | "MERGE_BATCH" | "BLOCKED";
+export type Action = "WAIT" | "TRIGGER" | "TRIGGER_BATCH" | "MERGE" | "MERGE_BATCH" | "BLOCKED" | "INVALIDATE_STALE" | "DEFERRED";
 
 export interface Blocker {
   Number: number;
@@ -39,6 +39,12 @@ export interface TidePool {
   Action: Action;
   Target: PullRequest[];
   Blockers: Blocker[];
+
+  // BlockingContexts maps the number of each PR queued for retest to the
+  // required contexts that are not yet passing for it.
+  BlockingContexts?: {[number: string]: string[]};
+  // MergeOrder is the projected order in which the PRs in the pool will merge.
+  MergeOrder?: number[];
 }
 
 export interface TideData {
@@ -86,6 +86,19 @@ export namespace cell {
     return c;
   }
 
+  // deferred returns a state cell for a triggered job that must not start
+  // before runAfter.
+  export function deferred(runAfter: moment.Moment): HTMLTableDataCellElement {
+    const c = document.createElement("td");
+    const stateIndicator = document.createElement("i");
+    stateIndicator.classList.add("material-icons", "state", "deferred");
+    stateIndicator.innerText = "update";
+    c.appendChild(stateIndicator);
+    c.title = `Deferred until ${runAfter.format('MMM DD HH:mm:ss')}`;
+
+    return c;
+  }
+
   function stateToAdj(s: ProwJobState): string {
     switch (s) {
       case "success":
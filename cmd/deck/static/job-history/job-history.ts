@@ -2,6 +2,7 @@ import moment from "moment";
 import {cell, formatDuration} from '../common/common';
 
 declare const allBuilds: any;
+declare const triageNotes: boolean;
 
 window.onload = (): void => {
   const tbody = document.getElementById("history-table-body")!;
@@ -42,6 +43,13 @@ window.onload = (): void => {
     tr.appendChild(cell.time(build.ID, moment.unix(started)));
     tr.appendChild(cell.text(formatDuration(build.Duration / 1000000000 ))); // convert from ns to s.
     tr.appendChild(cell.text(build.Result));
+    if (build.Origin) {
+      tr.appendChild(cell.text(build.Origin));
+    }
+    if (triageNotes) {
+      const notes = build.TriageNotes || [];
+      tr.appendChild(cell.text(notes.map((note: any) => `${note.author}: ${note.text}`).join("; ")));
+    }
 
     for (const child of tr.children) {
       child.classList.add("mdl-data-table__cell--non-numeric");
@@ -33,10 +33,12 @@ interface RepoOptions {
   pulls: {[key: string]: boolean};
   states: {[key: string]: boolean};
   clusters: {[key: string]: boolean};
+  archs: {[key: string]: boolean};
 }
 
 function optionsForRepo(repository: string): RepoOptions {
   const opts: RepoOptions = {
+    archs: {},
     authors: {},
     clusters: {},
     jobs: {},
@@ -50,6 +52,7 @@ function optionsForRepo(repository: string): RepoOptions {
     const {
       spec: {
         cluster = "",
+        arch = "",
         type = "",
         job = "",
         refs: {
@@ -63,6 +66,9 @@ function optionsForRepo(repository: string): RepoOptions {
 
     opts.types[type] = true;
     opts.clusters[cluster] = true;
+    if (arch) {
+      opts.archs[arch] = true;
+    }
     opts.states[state] = true;
 
 
@@ -109,6 +115,8 @@ function redrawOptions(fz: FuzzySearch, opts: RepoOptions) {
   addOptions(ss, "state");
   const cs = Object.keys(opts.clusters).sort();
   addOptions(cs, "cluster");
+  const archs = Object.keys(opts.archs).sort();
+  addOptions(archs, "arch");
 }
 
 function adjustScroll(el: Element): void {
@@ -479,6 +487,7 @@ function redraw(fz: FuzzySearch, pushState = true): void {
   const jobSel = getSelectionFuzzySearch("job", "job-input");
   const stateSel = getSelection("state");
   const clusterSel = getSelection("cluster");
+  const archSel = getSelection("arch");
 
   if (pushState && window.history && window.history.pushState !== undefined) {
     if (args.length > 0) {
@@ -507,11 +516,13 @@ function redraw(fz: FuzzySearch, pushState = true): void {
       },
       spec: {
         cluster = "",
+        arch = "",
         type = "",
         job = "",
         agent = "",
         refs: {repo_link = "", base_sha = "", base_link = "", pulls = [], base_ref = ""} = {},
         pod_spec,
+        run_after: runAfter = "",
       },
       status: {startTime, completionTime = "", state = "", pod_name, build_id = "", url = ""},
     } = build;
@@ -543,6 +554,9 @@ function redraw(fz: FuzzySearch, pushState = true): void {
     if (!equalSelected(clusterSel, cluster)) {
       continue;
     }
+    if (!equalSelected(archSel, arch)) {
+      continue;
+    }
     if (!jobSel.test(job)) {
       continue;
     }
@@ -609,7 +623,11 @@ function redraw(fz: FuzzySearch, pushState = true): void {
     displayedJob++;
     const r = document.createElement("tr");
     // State column
-    r.appendChild(cell.state(state));
+    if (state === "triggered" && runAfter && Date.parse(runAfter) > Date.now()) {
+      r.appendChild(cell.deferred(moment(runAfter)));
+    } else {
+      r.appendChild(cell.state(state));
+    }
     // Log column
     r.appendChild(createLogCell(build, buildUrl));
     // Rerun column
@@ -37,6 +37,20 @@ export interface ShowOffset extends BaseMessage {
   left: number;
 }
 
+export interface StreamMessage extends BaseMessage {
+  type: 'stream';
+  data: string;
+}
+
+export interface StreamEvent extends BaseMessage {
+  type: 'streamEvent';
+  data: string;
+}
+
+export function isStreamEvent(data: any): data is StreamEvent {
+  return isBaseMessage(data) && data.type === 'streamEvent';
+}
+
 export interface Response extends BaseMessage {
   type: 'response';
   data: string;
@@ -46,7 +60,40 @@ export function isResponse(data: any): data is Response {
   return isBaseMessage(data) && data.type === 'response';
 }
 
-export type Message = ContentUpdatedMessage | RequestMessage | RequestPageMessage | UpdatePageMessage | UpdateHash | ShowOffset | Response;
+export type NavigationAction = 'nextFailure' | 'previousFailure' | 'expandAll' | 'collapseAll' | 'showArtifact';
+
+// NavigateMessage is sent by the Spyglass page to a lens, which answers with
+// a NavigatedMessage once it has navigated.
+export interface NavigateMessage extends BaseMessage {
+  type: 'navigate';
+  id: number;
+  action: NavigationAction;
+  // restart makes the lens move to its first failure, or its last one when
+  // moving backwards, instead of the one after its current failure.
+  restart?: boolean;
+  artifact?: string;
+}
+
+export function isNavigateMessage(data: any): data is NavigateMessage {
+  return isBaseMessage(data) && data.type === 'navigate' && typeof (data as NavigateMessage).id === 'number';
+}
+
+export interface NavigatedMessage extends BaseMessage {
+  type: 'navigated';
+  navigationId: number;
+  // handled is false when the lens had nothing to navigate to, e.g. no
+  // failure after its current one.
+  handled: boolean;
+}
+
+// KeyPressedMessage forwards the keyboard shortcuts pressed in a lens to the
+// Spyglass page, which handles them for all lenses.
+export interface KeyPressedMessage extends BaseMessage {
+  type: 'keyPressed';
+  key: string;
+}
+
+export type Message = ContentUpdatedMessage | RequestMessage | RequestPageMessage | UpdatePageMessage | UpdateHash | ShowOffset | StreamMessage | StreamEvent | Response | NavigatedMessage | KeyPressedMessage;
 
 export interface TransitMessage {
   id: number;
@@ -64,3 +111,23 @@ export function isUpdateHashMessage(data: any): data is UpdateHash {
 export function serialiseHashes(hashes: {[index: string]: string}): string {
   return Object.keys(hashes).map((i) => `${i}:${escape(hashes[i].substr(1))}`).join(';');
 }
+
+// navigationShortcuts are the keyboard shortcuts of Spyglass and what they do.
+export const navigationShortcuts: {[key: string]: string} = {
+  'j': 'Next failure',
+  'k': 'Previous failure',
+  'e': 'Expand all',
+  'c': 'Collapse all',
+  'a': 'Jump to artifact',
+  '?': 'Show keyboard shortcuts',
+};
+
+// isShortcut returns whether a key press is a shortcut of Spyglass rather
+// than, e.g., typing in a form.
+export function isShortcut(e: KeyboardEvent): boolean {
+  if (e.altKey || e.ctrlKey || e.metaKey || !navigationShortcuts.hasOwnProperty(e.key)) {
+    return false;
+  }
+  const target = e.target;
+  return !(target instanceof HTMLElement && (target.isContentEditable || target.closest('input, textarea, select')));
+}
@@ -1,9 +1,55 @@
 import {parseQuery} from '../common/urls';
-import {isResponse, isTransitMessage, isUpdateHashMessage, Message, Response, serialiseHashes} from './common';
+import {
+  isNavigateMessage,
+  isResponse,
+  isShortcut,
+  isStreamEvent,
+  isTransitMessage,
+  isUpdateHashMessage,
+  Message,
+  NavigateMessage,
+  Response,
+  serialiseHashes,
+} from './common';
 
 // Solution is inspired by https://stackoverflow.com/questions/29055828/regex-to-make-links-clickable-in-only-a-href-and-not-img-src
 const linkRegex = /((?:href|src)=")?(\b(https?|ftp|file):\/\/[-A-Z0-9+&@#\/%?=~_|!:,.;]*[-A-Z0-9+&@#\/%=~_|])/ig;
 
+/**
+ * Navigation customizes how the keyboard shortcuts of Spyglass navigate a
+ * lens. Every method is optional, and the defaults work for lenses that mark
+ * up their content:
+ *
+ * - failures are the elements with a data-spyglass-failure attribute,
+ * - expanding and collapsing all opens and closes all <details> elements,
+ * - an artifact is shown by scrolling to the element whose
+ *   data-spyglass-artifact attribute is its name.
+ */
+export interface Navigation {
+  /**
+   * Returns the failures of the lens in the order they are shown.
+   */
+  failures?(): Element[];
+  /**
+   * Makes a failure visible before Spyglass scrolls to it, e.g. by expanding
+   * the section it is in. <details> elements are opened without it.
+   */
+  reveal?(failure: Element): void | Promise<void>;
+  /**
+   * Expands all of the content, after the <details> elements are opened.
+   */
+  expandAll?(): void | Promise<void>;
+  /**
+   * Collapses all of the content, after the <details> elements are closed.
+   */
+  collapseAll?(): void | Promise<void>;
+  /**
+   * Shows an artifact of the lens, and returns whether it did; the default
+   * is used when it didn't.
+   */
+  showArtifact?(artifact: string): boolean | Promise<boolean>;
+}
+
 export interface Spyglass {
   /**
    * Replaces the lens display with a new server-rendered page.
@@ -32,6 +78,17 @@ export interface Spyglass {
    * recommended, but not required.
    */
   request(data: string): Promise<string>;
+  /**
+   * Opens a stream to the server-side lens backend with the provided data.
+   * onEvent is called with each event sent by the server, and the returned
+   * promise resolves once the server ends the stream or the connection fails.
+   * Only lenses that implement streaming on the server side support this.
+   *
+   * @param data Some data to pass back to the server. JSON encoding is
+   * recommended, but not required.
+   * @param onEvent Called with the data of each event.
+   */
+  stream(data: string, onEvent: (data: string) => void): Promise<void>;
   /**
    * Inform Spyglass that the lens content has updated. This should be called whenever
    * the visible content changes, so Spyglass can ensure that all content is visible.
@@ -54,14 +111,26 @@ export interface Spyglass {
    * @param y The y coordinate relative to the lens document to scroll to.
    */
   scrollTo(x: number, y: number): Promise<void>;
+
+  /**
+   * Registers how the keyboard shortcuts of Spyglass, e.g. moving to the next
+   * failure, navigate the lens, for lenses the defaults don't suit.
+   *
+   * @param navigation The navigation of the lens.
+   */
+  registerNavigation(navigation: Navigation): void;
 }
 
 class SpyglassImpl implements Spyglass {
   private pendingRequests = new Map<number, (v: Response) => void>();
+  private streamListeners = new Map<number, (data: string) => void>();
   private messageId = 0;
   private pendingUpdateTimer = 0;
   private currentHash = '';
+  private pageData = '';
   private observer: MutationObserver;
+  private navigation: Navigation = {};
+  private failureIndex = -1;
 
   constructor() {
     this.currentHash = location.hash;
@@ -69,6 +138,8 @@ class SpyglassImpl implements Spyglass {
 
     window.addEventListener('message', (e) => this.handleMessage(e));
     window.addEventListener('hashchange', (e) => this.handleHashChange(e));
+    document.addEventListener('click', (e) => this.handleClick(e));
+    document.addEventListener('keydown', (e) => this.handleKeyDown(e));
     window.addEventListener('DOMContentLoaded', () => {
       this.createHyperlinks(document.documentElement);
       this.fixAnchorLinks(document.documentElement);
@@ -86,7 +157,9 @@ class SpyglassImpl implements Spyglass {
   }
 
   public async updatePage(data: string): Promise<void> {
-    await this.postMessage({type: 'updatePage', data});
+    const result = await this.postMessage({type: 'updatePage', data});
+    this.pageData = data;
+    document.body.innerHTML = result.data;
     this.contentUpdated();
   }
   public async requestPage(data: string): Promise<string> {
@@ -97,6 +170,15 @@ class SpyglassImpl implements Spyglass {
     const result = await this.postMessage({type: 'request', data});
     return result.data;
   }
+  public async stream(data: string, onEvent: (data: string) => void): Promise<void> {
+    const id = this.messageId + 1;
+    this.streamListeners.set(id, onEvent);
+    try {
+      await this.postMessage({type: 'stream', data});
+    } finally {
+      this.streamListeners.delete(id);
+    }
+  }
   public contentUpdated(): void {
     this.updateHeight();
     clearTimeout(this.pendingUpdateTimer);
@@ -118,6 +200,10 @@ class SpyglassImpl implements Spyglass {
     await this.postMessage({type: 'showOffset', left: x, top: y});
   }
 
+  public registerNavigation(navigation: Navigation): void {
+    this.navigation = navigation;
+  }
+
   private updateHeight(): void {
     // .then() to suppress complaints about unhandled promises (we just don't care here).
     this.postMessage({type: 'contentUpdated', height: document.body.offsetHeight}).then();
@@ -143,10 +229,108 @@ class SpyglassImpl implements Spyglass {
           this.pendingRequests.get(data.id)!(data.message);
           this.pendingRequests.delete(data.id);
         }
+      } else if (isStreamEvent(data.message)) {
+        if (this.streamListeners.has(data.id)) {
+          this.streamListeners.get(data.id)!(data.message.data);
+        }
       }
     } else if (isUpdateHashMessage(data)) {
       location.hash = data.hash;
+    } else if (isNavigateMessage(data)) {
+      this.navigate(data).then((handled) => this.postMessage({type: 'navigated', navigationId: data.id, handled}));
+    }
+  }
+
+  // Keyboard shortcuts are handled by the Spyglass page, so that they work
+  // the same whichever lens has the focus.
+  private handleKeyDown(e: KeyboardEvent): void {
+    if (!isShortcut(e)) {
+      return;
     }
+    e.preventDefault();
+    this.postMessage({type: 'keyPressed', key: e.key}).then();
+  }
+
+  private async navigate(message: NavigateMessage): Promise<boolean> {
+    try {
+      switch (message.action) {
+        case 'nextFailure':
+          return await this.moveToFailure(1, !!message.restart);
+        case 'previousFailure':
+          return await this.moveToFailure(-1, !!message.restart);
+        case 'expandAll':
+        case 'collapseAll': {
+          const open = message.action === 'expandAll';
+          for (const details of Array.from(document.querySelectorAll('details'))) {
+            details.open = open;
+          }
+          if (open && this.navigation.expandAll) {
+            await this.navigation.expandAll();
+          } else if (!open && this.navigation.collapseAll) {
+            await this.navigation.collapseAll();
+          }
+          this.contentUpdated();
+          return true;
+        }
+        case 'showArtifact': {
+          if (this.navigation.showArtifact && await this.navigation.showArtifact(message.artifact)) {
+            return true;
+          }
+          const el = Array.from(document.querySelectorAll<HTMLElement>('[data-spyglass-artifact]'))
+            .find((e) => e.dataset.spyglassArtifact === message.artifact);
+          if (!el) {
+            return false;
+          }
+          await this.showElement(el);
+          return true;
+        }
+      }
+    } catch (err) {
+      console.error(`Failed to navigate (${message.action}):`, err);
+    }
+    return false;
+  }
+
+  private failures(): Element[] {
+    if (this.navigation.failures) {
+      return this.navigation.failures();
+    }
+    return Array.from(document.querySelectorAll('[data-spyglass-failure]'));
+  }
+
+  private async moveToFailure(step: number, restart: boolean): Promise<boolean> {
+    const failures = this.failures();
+    let index = this.failureIndex + step;
+    if (restart) {
+      index = step > 0 ? 0 : failures.length - 1;
+    }
+    if (index < 0 || index >= failures.length) {
+      return false;
+    }
+    this.failureIndex = index;
+    for (const failure of failures) {
+      failure.classList.remove('spyglass-current-failure');
+    }
+    const el = failures[index];
+    el.classList.add('spyglass-current-failure');
+    if (this.navigation.reveal) {
+      await this.navigation.reveal(el);
+    }
+    await this.showElement(el);
+    return true;
+  }
+
+  // showElement opens the <details> an element is in and scrolls the page
+  // to it.
+  private async showElement(el: Element): Promise<void> {
+    for (let parent: Element | null = el; parent; parent = parent.parentElement) {
+      if (parent instanceof HTMLDetailsElement) {
+        parent.open = true;
+      }
+    }
+    this.contentUpdated();
+    const top = el.getBoundingClientRect().top + window.pageYOffset;
+    await this.scrollTo(0, top);
   }
 
   // When any links on the page are added or mutated, we fix them up if they
@@ -175,6 +359,14 @@ class SpyglassImpl implements Spyglass {
     }
   }
 
+  // The lens server lists the artifacts it failed to fetch above the lens,
+  // with a button to render the lens again.
+  private handleClick(e: MouseEvent): void {
+    if (e.target instanceof HTMLElement && e.target.closest('button.lens-retry')) {
+      this.updatePage(this.pageData).then();
+    }
+  }
+
   private handleHashChange(e: HashChangeEvent): void {
     if (location.hash === this.currentHash) {
       return;
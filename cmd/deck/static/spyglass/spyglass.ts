@@ -2,7 +2,7 @@ import {ProwJobState} from "../api/prow";
 import {createAbortProwJobIcon} from "../common/abort";
 import {createRerunProwJobIcon} from "../common/rerun";
 import {getParameterByName} from "../common/urls";
-import {isTransitMessage, serialiseHashes} from "./common";
+import {isShortcut, isTransitMessage, navigationShortcuts, NavigationAction, serialiseHashes} from "./common";
 
 declare const src: string;
 declare const lensArtifacts: {[index: string]: string[]};
@@ -29,11 +29,15 @@ function loadLenses(): void {
 }
 
 function queryForLens(lens: string, index: number): string {
-  const data = {
+  const data: {artifacts: string[]; index: number; src: string; baseSrc?: string} = {
     artifacts: lensArtifacts[index],
     index,
     src,
   };
+  const baseSrc = getParameterByName('base');
+  if (baseSrc) {
+    data.baseSrc = baseSrc;
+  }
   return `req=${encodeURIComponent(JSON.stringify(data))}`;
 }
 
@@ -91,6 +95,7 @@ window.addEventListener('message', async (e) => {
 
     switch (message.type) {
       case "contentUpdated":
+        loadedLenses.add(index);
         frame.style.height = `${message.height}px`;
         frame.style.visibility = 'visible';
         if (frame.dataset.hideTitle) {
@@ -120,6 +125,21 @@ window.addEventListener('message', async (e) => {
         respond(await req.text());
         break;
       }
+      case "stream": {
+        // EventSource can only make GET requests, so the data goes in the query.
+        const source = new EventSource(`${urlForLensRequest(lens, index, 'stream')}&data=${encodeURIComponent(message.data)}`);
+        const close = (): void => {
+          // EventSource reconnects by default, which would replay the stream.
+          source.close();
+          respond('');
+        };
+        source.onmessage = (ev: MessageEvent) => {
+          frame.contentWindow.postMessage({id, message: {type: 'streamEvent', data: ev.data}}, '*');
+        };
+        source.addEventListener('end', close);
+        source.onerror = close;
+        break;
+      }
       case "updateHash": {
         updateHash(index, message.hash);
         respond('');
@@ -147,6 +167,20 @@ window.addEventListener('message', async (e) => {
         container.scrollLeft = containerOffset.left + message.left;
         break;
       }
+      case "navigated": {
+        const resolve = pendingNavigations.get(message.navigationId);
+        if (resolve) {
+          pendingNavigations.delete(message.navigationId);
+          resolve(message.handled);
+        }
+        respond('');
+        break;
+      }
+      case "keyPressed": {
+        handleShortcut(message.key);
+        respond('');
+        break;
+      }
       default:
         console.warn(`Unrecognised message type "${message.type}" from lens "${lens}":`, data);
         break;
@@ -165,11 +199,276 @@ window.addEventListener('hashchange', (e) => {
   }
 });
 
+interface SearchMatch {
+  artifact: string;
+  offset: number;
+  length: number;
+  line: number;
+  text: string;
+}
+
+interface SearchResult {
+  matches: SearchMatch[];
+  links?: {[artifact: string]: string};
+  searched: number;
+  skipped?: string[];
+  truncated?: boolean;
+}
+
+// buildLogLensIndex returns the index of the build log lens showing an
+// artifact, if any.
+function buildLogLensIndex(artifact: string): number | null {
+  for (const lensIndex of lensIndexes) {
+    const frame = document.querySelector<HTMLIFrameElement>(`#iframe-${lensIndex}`);
+    if (frame && frame.dataset.lensName === 'buildlog' && (lensArtifacts[lensIndex] || []).includes(artifact)) {
+      return lensIndex;
+    }
+  }
+  return null;
+}
+
+// renderMatch links a match to its line in the build log lens if it shows the
+// artifact, and to the raw artifact otherwise.
+function renderMatch(match: SearchMatch, links: {[artifact: string]: string}): HTMLElement {
+  const row = document.createElement('div');
+  row.className = 'search-match';
+  const link = document.createElement('a');
+  link.className = 'search-match-location';
+  link.textContent = `${match.artifact}:${match.line}`;
+  const lensIndex = buildLogLensIndex(match.artifact);
+  if (lensIndex !== null) {
+    link.href = '#';
+    link.onclick = (e) => {
+      e.preventDefault();
+      updateHash(lensIndex, `#${match.artifact}:${match.line}`);
+      document.querySelector<HTMLIFrameElement>(`#iframe-${lensIndex}`)!.scrollIntoView();
+    };
+  } else {
+    link.href = links[match.artifact] || '#';
+    link.target = '_blank';
+  }
+  row.appendChild(link);
+  const text = document.createElement('code');
+  text.textContent = match.text;
+  row.appendChild(text);
+  return row;
+}
+
+async function search(query: string): Promise<void> {
+  const results = document.querySelector<HTMLDivElement>('#search-results')!;
+  results.textContent = 'Searching...';
+  const resp = await fetch(`/spyglass/search?src=${encodeURIComponent(src)}&q=${encodeURIComponent(query)}`);
+  if (!resp.ok) {
+    results.textContent = await resp.text();
+    return;
+  }
+  const result = await resp.json() as SearchResult;
+  results.innerHTML = '';
+  const summary = document.createElement('div');
+  summary.className = 'search-summary';
+  let text = `${result.matches.length}${result.truncated ? '+' : ''} matching lines in ${result.searched} artifacts`;
+  if (result.skipped && result.skipped.length > 0) {
+    text += `, ${result.skipped.length} artifacts not searched`;
+    summary.title = result.skipped.join('\n');
+  }
+  summary.textContent = text;
+  results.appendChild(summary);
+  for (const match of result.matches) {
+    results.appendChild(renderMatch(match, result.links || {}));
+  }
+}
+
+function handleSearch(): void {
+  const form = document.querySelector<HTMLFormElement>('#search-form');
+  if (!form) {
+    return;
+  }
+  const query = document.querySelector<HTMLInputElement>('#search-query')!;
+  form.onsubmit = (e) => {
+    e.preventDefault();
+    search(query.value);
+  };
+}
+
+// Lenses can only navigate once they have loaded.
+const loadedLenses = new Set<number>();
+const pendingNavigations = new Map<number, (handled: boolean) => void>();
+let navigationId = 0;
+// failureLens is the lens showing the current failure, if any.
+let failureLens: number | null = null;
+
+// navigateLens asks a lens to navigate, and resolves with whether it did.
+function navigateLens(index: number, action: NavigationAction, options: {restart?: boolean; artifact?: string} = {}): Promise<boolean> {
+  const frame = document.querySelector<HTMLIFrameElement>(`#iframe-${index}`);
+  if (!frame || !frame.contentWindow || !loadedLenses.has(index)) {
+    return Promise.resolve(false);
+  }
+  const id = ++navigationId;
+  return new Promise<boolean>((resolve) => {
+    // Lenses that don't use the lens library never answer.
+    const timeout = window.setTimeout(() => {
+      pendingNavigations.delete(id);
+      resolve(false);
+    }, 2000);
+    pendingNavigations.set(id, (handled) => {
+      window.clearTimeout(timeout);
+      resolve(handled);
+    });
+    frame.contentWindow!.postMessage({type: 'navigate', id, action, ...options}, '*');
+  });
+}
+
+// moveToFailure moves to the failure after (or before) the current one,
+// which is in the next lens once the current lens has no more failures.
+async function moveToFailure(action: 'nextFailure' | 'previousFailure'): Promise<void> {
+  const order = action === 'nextFailure' ? lensIndexes : [...lensIndexes].reverse();
+  const start = failureLens === null ? 0 : Math.max(order.indexOf(failureLens), 0);
+  for (const index of order.slice(start)) {
+    if (await navigateLens(index, action, {restart: index !== failureLens})) {
+      failureLens = index;
+      return;
+    }
+  }
+  showNavigationStatus(action === 'nextFailure' ? 'No more failures below.' : 'No more failures above.');
+}
+
+async function jumpToArtifact(artifact: string): Promise<void> {
+  for (const index of lensIndexes) {
+    if (!(lensArtifacts[index] || []).includes(artifact)) {
+      continue;
+    }
+    document.querySelector<HTMLIFrameElement>(`#iframe-${index}`)!.scrollIntoView();
+    if (await navigateLens(index, 'showArtifact', {artifact})) {
+      return;
+    }
+  }
+  showNavigationStatus(`No lens shows ${artifact}.`);
+}
+
+function handleShortcut(key: string): void {
+  switch (key) {
+    case 'j':
+      moveToFailure('nextFailure');
+      break;
+    case 'k':
+      moveToFailure('previousFailure');
+      break;
+    case 'e':
+      lensIndexes.forEach((index) => navigateLens(index, 'expandAll'));
+      break;
+    case 'c':
+      lensIndexes.forEach((index) => navigateLens(index, 'collapseAll'));
+      break;
+    case 'a':
+      showNavigationOverlay(true);
+      break;
+    case '?':
+      showNavigationOverlay(false);
+      break;
+    default:
+      break;
+  }
+}
+
+function showNavigationStatus(text: string): void {
+  let status = document.querySelector<HTMLDivElement>('#navigation-status');
+  if (!status) {
+    status = document.createElement('div');
+    status.id = 'navigation-status';
+    document.body.appendChild(status);
+  }
+  status.textContent = text;
+  status.style.display = 'block';
+  window.setTimeout(() => {
+    status!.style.display = 'none';
+  }, 2000);
+}
+
+function createNavigationOverlay(): HTMLDivElement {
+  const overlay = document.createElement('div');
+  overlay.id = 'navigation-overlay';
+  const shortcuts = document.createElement('dl');
+  for (const key of Object.keys(navigationShortcuts)) {
+    const dt = document.createElement('dt');
+    const kbd = document.createElement('kbd');
+    kbd.textContent = key;
+    dt.appendChild(kbd);
+    const dd = document.createElement('dd');
+    dd.textContent = navigationShortcuts[key];
+    shortcuts.appendChild(dt);
+    shortcuts.appendChild(dd);
+  }
+  overlay.appendChild(shortcuts);
+
+  const form = document.createElement('form');
+  const input = document.createElement('input');
+  input.id = 'navigation-artifact';
+  input.placeholder = 'Jump to artifact';
+  input.setAttribute('list', 'navigation-artifacts');
+  const artifacts = document.createElement('datalist');
+  artifacts.id = 'navigation-artifacts';
+  const names = new Set<string>();
+  for (const index of lensIndexes) {
+    for (const name of lensArtifacts[index] || []) {
+      names.add(name);
+    }
+  }
+  for (const name of Array.from(names).sort()) {
+    const option = document.createElement('option');
+    option.value = name;
+    artifacts.appendChild(option);
+  }
+  form.appendChild(input);
+  form.appendChild(artifacts);
+  form.onsubmit = (e) => {
+    e.preventDefault();
+    hideNavigationOverlay();
+    if (input.value) {
+      jumpToArtifact(input.value);
+    }
+    input.value = '';
+  };
+  overlay.appendChild(form);
+  document.body.appendChild(overlay);
+  return overlay;
+}
+
+function showNavigationOverlay(jump: boolean): void {
+  const overlay = document.querySelector<HTMLDivElement>('#navigation-overlay') || createNavigationOverlay();
+  overlay.style.display = 'block';
+  if (jump) {
+    overlay.querySelector<HTMLInputElement>('#navigation-artifact')!.focus();
+  }
+}
+
+function hideNavigationOverlay(): void {
+  const overlay = document.querySelector<HTMLDivElement>('#navigation-overlay');
+  if (overlay) {
+    overlay.style.display = 'none';
+  }
+}
+
+function handleKeyboardShortcuts(): void {
+  document.addEventListener('keydown', (e) => {
+    if (e.key === 'Escape') {
+      hideNavigationOverlay();
+      return;
+    }
+    if (!isShortcut(e)) {
+      return;
+    }
+    e.preventDefault();
+    handleShortcut(e.key);
+  });
+}
+
 // We can't use DOMContentLoaded here or we end up with a bunch of flickering. This appears to be MDL's fault.
 window.addEventListener('load', () => {
   loadLenses();
   handleRerunButton();
   handleAbortButton();
+  handleSearch();
+  handleKeyboardShortcuts();
 });
 
 function handleRerunButton() {
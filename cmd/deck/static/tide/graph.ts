@@ -0,0 +1,137 @@
+import {PullRequest, TidePool} from '../api/tide';
+
+const svgNS = "http://www.w3.org/2000/svg";
+
+const nodeWidth = 72;
+const nodeHeight = 28;
+const nodeGap = 36;
+const padding = 12;
+const rowHeight = nodeHeight + 6;
+const labelHeight = 14;
+
+type NodeState = "success" | "pending" | "missing";
+
+function svgElem<K extends keyof SVGElementTagNameMap>(tag: K, attrs: {[key: string]: string | number}): SVGElementTagNameMap[K] {
+  const el = document.createElementNS(svgNS, tag);
+  for (const key of Object.keys(attrs)) {
+    el.setAttribute(key, String(attrs[key]));
+  }
+  return el;
+}
+
+function prStates(pool: TidePool): Map<number, NodeState> {
+  const states = new Map<number, NodeState>();
+  const add = (prs: PullRequest[] | null | undefined, state: NodeState) => {
+    for (const pr of prs || []) {
+      states.set(pr.Number, state);
+    }
+  };
+  add(pool.MissingPRs, "missing");
+  add(pool.PendingPRs, "pending");
+  add(pool.SuccessPRs, "success");
+  return states;
+}
+
+function prTitles(pool: TidePool): Map<number, string> {
+  const titles = new Map<number, string>();
+  for (const prs of [pool.SuccessPRs, pool.PendingPRs, pool.MissingPRs, pool.BatchPending]) {
+    for (const pr of prs || []) {
+      titles.set(pr.Number, pr.Title);
+    }
+  }
+  return titles;
+}
+
+// batchMembers returns the PRs that are currently tested or merged together.
+function batchMembers(pool: TidePool): Set<number> {
+  let prs = pool.BatchPending || [];
+  if (pool.Action === "MERGE_BATCH" || pool.Action === "TRIGGER_BATCH") {
+    prs = pool.Target || [];
+  }
+  return new Set(prs.map((pr) => pr.Number));
+}
+
+function prNode(pool: TidePool, num: number, state: NodeState, title: string | undefined, x: number, y: number): SVGAElement {
+  const a = svgElem("a", {href: `/github-link?dest=${pool.Org}/${pool.Repo}/pull/${num}`});
+  const rect = svgElem("rect", {x, y, width: nodeWidth, height: nodeHeight, rx: 4, ry: 4});
+  rect.classList.add("tide-graph-node", state);
+  a.appendChild(rect);
+  const text = svgElem("text", {"x": x + nodeWidth / 2, "y": y + nodeHeight / 2, "text-anchor": "middle", "dominant-baseline": "central"});
+  text.textContent = `#${num}`;
+  a.appendChild(text);
+  if (title) {
+    const t = svgElem("title", {});
+    t.textContent = title;
+    a.appendChild(t);
+  }
+  return a;
+}
+
+/**
+ * poolGraph renders the pool as an SVG graph: the PRs in their projected merge
+ * order connected by arrows, a box around the PRs of the current batch and the
+ * PRs queued for retest together with the contexts blocking them.
+ */
+export function poolGraph(pool: TidePool): SVGSVGElement {
+  const states = prStates(pool);
+  const titles = prTitles(pool);
+  const batch = batchMembers(pool);
+  const order = pool.MergeOrder || [];
+  const ordered = new Set(order);
+  const missing = (pool.MissingPRs || []).filter((pr) => !ordered.has(pr.Number));
+  const blocking = pool.BlockingContexts || {};
+
+  const chainWidth = order.length * nodeWidth + Math.max(order.length - 1, 0) * nodeGap;
+  const missingTop = padding * 2 + (order.length ? nodeHeight + padding + labelHeight : 0);
+  const width = Math.max(chainWidth + padding * 2, 480);
+  const height = missingTop + missing.length * rowHeight + padding;
+
+  const svg = svgElem("svg", {width, height, viewBox: `0 0 ${width} ${height}`});
+  svg.classList.add("tide-graph");
+  const defs = svgElem("defs", {});
+  const marker = svgElem("marker", {id: "tide-graph-arrow", markerWidth: 8, markerHeight: 8, refX: 8, refY: 4, orient: "auto"});
+  marker.appendChild(svgElem("path", {d: "M0,0 L8,4 L0,8 z"}));
+  defs.appendChild(marker);
+  svg.appendChild(defs);
+
+  // The batch always leads the merge order, so its members are contiguous.
+  const batchCount = order.filter((n) => batch.has(n)).length;
+  if (batchCount > 0) {
+    const box = svgElem("rect", {
+      x: padding / 2,
+      y: padding / 2,
+      width: batchCount * nodeWidth + (batchCount - 1) * nodeGap + padding,
+      height: nodeHeight + padding * 1.5,
+      rx: 6,
+      ry: 6,
+    });
+    box.classList.add("tide-graph-batch");
+    svg.appendChild(box);
+    const label = svgElem("text", {x: padding / 2, y: padding * 2 + nodeHeight + labelHeight - 2});
+    label.classList.add("tide-graph-label");
+    label.textContent = pool.Action.startsWith("MERGE") ? "merging as batch" : "testing as batch";
+    svg.appendChild(label);
+  }
+
+  order.forEach((num, i) => {
+    const x = padding + i * (nodeWidth + nodeGap);
+    if (i > 0) {
+      const edge = svgElem("line", {x1: x - nodeGap, y1: padding + nodeHeight / 2, x2: x - 2, y2: padding + nodeHeight / 2, "marker-end": "url(#tide-graph-arrow)"});
+      edge.classList.add("tide-graph-edge");
+      svg.appendChild(edge);
+    }
+    svg.appendChild(prNode(pool, num, states.get(num) || "pending", titles.get(num), x, padding));
+  });
+
+  missing.forEach((pr, i) => {
+    const y = missingTop + i * rowHeight;
+    svg.appendChild(prNode(pool, pr.Number, "missing", pr.Title, padding, y));
+    const contexts = blocking[String(pr.Number)] || [];
+    const text = svgElem("text", {"x": padding + nodeWidth + padding, "y": y + nodeHeight / 2, "dominant-baseline": "central"});
+    text.classList.add("tide-graph-label");
+    text.textContent = contexts.length ? `blocked by ${contexts.join(", ")}` : "waiting for retest";
+    svg.appendChild(text);
+  });
+
+  return svg;
+}
@@ -1,16 +1,39 @@
 import {PullRequest, TideData, TidePool} from '../api/tide';
 import {tidehistory, tooltip} from '../common/common';
+import {poolGraph} from './graph';
 
 declare const tideData: TideData;
 
+// refreshInterval is how often the pools are refetched to keep the page live.
+const refreshInterval = 30 * 1000;
+
+let currentData: TideData;
+// expandedPools holds the keys of the pools whose graph is shown.
+const expandedPools = new Set<string>();
+
 window.onload = (): void => {
   const infoDiv = document.getElementById("info-div")!;
   const infoH4 = infoDiv.getElementsByTagName("h4")[0]!;
   infoH4.addEventListener("click", infoToggle(infoDiv.getElementsByTagName("span")[0]), true);
 
+  currentData = tideData;
   redraw();
+  window.setInterval(refresh, refreshInterval);
 };
 
+async function refresh(): Promise<void> {
+  try {
+    const resp = await fetch("tide.js");
+    if (!resp.ok) {
+      return;
+    }
+    currentData = await resp.json() as TideData;
+  } catch (e) {
+    return;
+  }
+  redraw();
+}
+
 function infoToggle(toToggle: HTMLElement): (event: Event) => void {
   return (event): void => {
     if (toToggle.className === "hidden") {
@@ -112,12 +135,12 @@ function redrawQueries(): void {
     queries.removeChild(queries.firstChild);
   }
 
-  if (!tideData.Queries) {
+  if (!currentData.Queries) {
     return;
   }
-  for (let i = 0; i < tideData.Queries.length; i++) {
-    const query = tideData.Queries[i];
-    const tideQuery = tideData.TideQueries[i];
+  for (let i = 0; i < currentData.Queries.length; i++) {
+    const query = currentData.Queries[i];
+    const tideQuery = currentData.TideQueries[i];
 
     // create list entry for the query, all details will be within this element
     const li = document.createElement("li");
@@ -210,12 +233,25 @@ function redrawPools(): void {
     pools.removeChild(pools.firstChild);
   }
 
-  if (!tideData.Pools) {
+  if (!currentData.Pools) {
     return;
   }
-  for (const pool of tideData.Pools) {
+  for (const pool of currentData.Pools) {
     const r = document.createElement("tr");
+    const key = poolKey(pool);
+
+    const graphRow = document.createElement("tr");
+    graphRow.classList.add("tide-graph-row");
+    const graphCell = document.createElement("td");
+    graphCell.colSpan = 8;
+    graphRow.appendChild(graphCell);
+    if (expandedPools.has(key)) {
+      graphCell.appendChild(poolGraph(pool));
+    } else {
+      graphRow.classList.add("hidden");
+    }
 
+    r.appendChild(createGraphToggleCell(pool, graphRow));
     r.appendChild(createHistoryCell(pool));
     r.appendChild(createRepoCell(pool));
     r.appendChild(createActionCell(pool));
@@ -225,9 +261,45 @@ function redrawPools(): void {
     r.appendChild(createPRCell(pool, pool.MissingPRs));
 
     pools.appendChild(r);
+    pools.appendChild(graphRow);
   }
 }
 
+function poolKey(pool: TidePool): string {
+  return `${pool.Org}/${pool.Repo}:${pool.Branch}`;
+}
+
+// createGraphToggleCell creates a button that shows or hides the graph of the
+// pool in graphRow. The choice is kept across refreshes.
+function createGraphToggleCell(pool: TidePool, graphRow: HTMLTableRowElement): HTMLTableDataCellElement {
+  const td = document.createElement("td");
+  td.classList.add("icon-cell");
+  const button = document.createElement("button");
+  button.classList.add("mdl-button", "mdl-js-button", "mdl-button--icon");
+  button.title = "Show pool graph";
+  const icon = document.createElement("i");
+  icon.classList.add("material-icons");
+  icon.textContent = "account_tree";
+  button.appendChild(icon);
+  button.addEventListener("click", () => {
+    const key = poolKey(pool);
+    const cell = graphRow.firstChild as HTMLTableDataCellElement;
+    while (cell.firstChild) {
+      cell.removeChild(cell.firstChild);
+    }
+    if (expandedPools.has(key)) {
+      expandedPools.delete(key);
+      graphRow.classList.add("hidden");
+    } else {
+      expandedPools.add(key);
+      cell.appendChild(poolGraph(pool));
+      graphRow.classList.remove("hidden");
+    }
+  });
+  td.appendChild(button);
+  return td;
+}
+
 function createHistoryCell(pool: TidePool): HTMLTableDataCellElement {
   const td = document.createElement("td");
   td.classList.add("icon-cell");
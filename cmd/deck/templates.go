@@ -49,15 +49,21 @@ func getConcreteBrandingFunction(cfg config.Getter) func() config.Branding {
 }
 
 type baseTemplateSections struct {
-	PR   bool
-	Tide bool
+	PR              bool
+	Tide            bool
+	Federation      bool
+	Cost            bool
+	ComponentHealth bool
 }
 
-func getConcreteSectionFunction(o options) func() baseTemplateSections {
+func getConcreteSectionFunction(o options, cfg config.Getter) func() baseTemplateSections {
 	return func() baseTemplateSections {
 		return baseTemplateSections{
-			PR:   o.oauthURL != "" || o.pregeneratedData != "",
-			Tide: o.tideURL != "" || o.pregeneratedData != "",
+			PR:              o.oauthURL != "" || o.pregeneratedData != "",
+			Tide:            o.tideURL != "" || o.pregeneratedData != "",
+			Federation:      len(o.federationSources.Strings()) > 0,
+			Cost:            len(cfg().CostAccounting.Prices) > 0,
+			ComponentHealth: cfg().Deck.HealthAggregation != nil,
 		}
 	}
 }
@@ -66,7 +72,7 @@ func prepareBaseTemplate(o options, cfg config.Getter, csrfToken string, t *temp
 	return t.Funcs(map[string]interface{}{
 		"settings":         makeBaseTemplateSettings,
 		"branding":         getConcreteBrandingFunction(cfg),
-		"sections":         getConcreteSectionFunction(o),
+		"sections":         getConcreteSectionFunction(o, cfg),
 		"mobileFriendly":   func() bool { return true },
 		"mobileUnfriendly": func() bool { return false },
 		"darkMode":         func() bool { return true },
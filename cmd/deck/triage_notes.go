@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/githuboauth"
+	"sigs.k8s.io/prow/pkg/spyglass"
+)
+
+// triageNoteAuthors decides who may attach triage notes to job runs,
+// according to deck.spyglass.triage_notes.
+type triageNoteAuthors struct {
+	cfg config.Getter
+	// goa is set once GitHub OAuth is configured. Without it, nobody may add
+	// notes, as notes are attributed to their author.
+	goa *githuboauth.Agent
+	ghc githuboauth.AuthenticatedUserIdentifier
+	cli github.RerunClient
+	log *logrus.Entry
+}
+
+// author returns the login of the user of the request if they may add triage
+// notes, or the HTTP status to deny the request with.
+func (a *triageNoteAuthors) author(r *http.Request) (string, int, error) {
+	notes := a.cfg().Deck.Spyglass.TriageNotes
+	if notes == nil {
+		return "", http.StatusNotFound, fmt.Errorf("triage notes are not enabled")
+	}
+	if a.goa == nil {
+		return "", http.StatusForbidden, fmt.Errorf("adding triage notes requires GitHub OAuth to be configured")
+	}
+	login, err := a.goa.GetLogin(r, a.ghc)
+	if err != nil {
+		return "", http.StatusUnauthorized, fmt.Errorf("log in with GitHub to add triage notes")
+	}
+	allowed, err := notes.Authors.IsAuthorized("", login, a.cli)
+	if err != nil {
+		a.log.WithError(err).WithField("user", login).Warn("Failed to check whether the user may add triage notes.")
+		return "", http.StatusInternalServerError, fmt.Errorf("could not verify whether %s may add triage notes", login)
+	}
+	if !allowed {
+		return "", http.StatusForbidden, fmt.Errorf("%s may not add triage notes", login)
+	}
+	return login, http.StatusOK, nil
+}
+
+// handleTriageNotes serves the triage notes of the run given by the src
+// parameter as JSON on GET, and adds the note given by the text parameter on
+// POST. Posted forms are redirected back to the Spyglass page of the run.
+func handleTriageNotes(sg *spyglass.Spyglass, cfg config.Getter, authors *triageNoteAuthors, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		src := strings.TrimSuffix(r.FormValue("src"), "/")
+		if src == "" {
+			http.Error(w, "missing src parameter", http.StatusBadRequest)
+			return
+		}
+		l := log.WithField("src", src)
+
+		switch r.Method {
+		case http.MethodGet:
+			notes, err := sg.TriageNotes(r.Context(), src)
+			if err != nil {
+				l.WithError(err).Debug("Failed to read triage notes.")
+				http.Error(w, fmt.Sprintf("failed to read triage notes: %v", err), triageNotesStatusForError(err))
+				return
+			}
+			if notes == nil {
+				notes = []spyglass.TriageNote{}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(spyglass.TriageNotes{Notes: notes}); err != nil {
+				l.WithError(err).Warn("Failed to write triage notes.")
+			}
+		case http.MethodPost:
+			author, code, err := authors.author(r)
+			if err != nil {
+				http.Error(w, err.Error(), code)
+				return
+			}
+			text := strings.TrimSpace(r.FormValue("text"))
+			if text == "" {
+				http.Error(w, "the note must not be empty", http.StatusBadRequest)
+				return
+			}
+			if maxLength := cfg().Deck.Spyglass.TriageNotes.GetMaxLength(); utf8.RuneCountInString(text) > maxLength {
+				http.Error(w, fmt.Sprintf("the note must not be longer than %d characters", maxLength), http.StatusBadRequest)
+				return
+			}
+			note := spyglass.TriageNote{Author: author, Text: text, Created: time.Now().UTC()}
+			if _, err := sg.AddTriageNote(r.Context(), src, note); err != nil {
+				l.WithError(err).Warn("Failed to add triage note.")
+				http.Error(w, fmt.Sprintf("failed to add triage note: %v", err), triageNotesStatusForError(err))
+				return
+			}
+			l.WithField("user", author).Info("Added triage note.")
+			http.Redirect(w, r, "/view/"+src, http.StatusSeeOther)
+		default:
+			http.Error(w, fmt.Sprintf("method %s is not supported", r.Method), http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func triageNotesStatusForError(err error) int {
+	if config.IsNotAllowedBucketError(err) {
+		return http.StatusBadRequest
+	}
+	return httpStatusForError(err)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/spyglass"
+)
+
+func TestHandleTriageNotes(t *testing.T) {
+	gcsServer := fakestorage.NewServer([]fakestorage.Object{
+		{
+			BucketName: "bucket",
+			Name:       "logs/ci-job/1/" + spyglass.TriageNotesName,
+			Content:    []byte(`{"notes":[{"author":"alice","text":"known infra issue, ignore","created":"2024-05-01T12:00:00Z"}]}`),
+		},
+	})
+	defer gcsServer.Stop()
+
+	testCases := []struct {
+		name         string
+		notes        *config.TriageNotes
+		method       string
+		form         url.Values
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "notes of a run are served",
+			notes:        &config.TriageNotes{},
+			method:       http.MethodGet,
+			form:         url.Values{"src": {"gs/bucket/logs/ci-job/1"}},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"notes":[{"author":"alice","text":"known infra issue, ignore","created":"2024-05-01T12:00:00Z"}]}`,
+		},
+		{
+			name:         "run without notes has none",
+			notes:        &config.TriageNotes{},
+			method:       http.MethodGet,
+			form:         url.Values{"src": {"gs/bucket/logs/ci-job/2"}},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"notes":[]}`,
+		},
+		{
+			name:         "src is required",
+			notes:        &config.TriageNotes{},
+			method:       http.MethodGet,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "notes can't be added if they are not enabled",
+			method:       http.MethodPost,
+			form:         url.Values{"src": {"gs/bucket/logs/ci-job/1"}, "text": {"flake"}},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "notes can't be added without GitHub OAuth",
+			notes:        &config.TriageNotes{Authors: prowapi.RerunAuthConfig{GitHubUsers: []string{"alice"}}},
+			method:       http.MethodPost,
+			form:         url.Values{"src": {"gs/bucket/logs/ci-job/1"}, "text": {"flake"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "other methods are not supported",
+			notes:        &config.TriageNotes{},
+			method:       http.MethodDelete,
+			form:         url.Values{"src": {"gs/bucket/logs/ci-job/1"}},
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := func() *config.Config {
+				return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{TriageNotes: tc.notes}}}}
+			}
+			sg := spyglass.New(context.Background(), nil, cfg, io.NewGCSOpener(gcsServer.Client()), false)
+			authors := &triageNoteAuthors{cfg: cfg, log: logrus.WithField("test", t.Name())}
+			handler := handleTriageNotes(sg, cfg, authors, logrus.WithField("test", t.Name()))
+
+			var req *http.Request
+			if tc.method == http.MethodPost {
+				req = httptest.NewRequest(tc.method, "/spyglass/triage-notes", strings.NewReader(tc.form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req = httptest.NewRequest(tc.method, "/spyglass/triage-notes?"+tc.form.Encode(), nil)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.expectedBody != "" {
+				if actual := strings.TrimSpace(rr.Body.String()); actual != tc.expectedBody {
+					t.Errorf("expected body %s, got %s", tc.expectedBody, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/deck/jobs"
+	"sigs.k8s.io/prow/pkg/tide"
+)
+
+const (
+	// widgetsScriptPath serves the script that turns placeholders on other
+	// sites into widget iframes.
+	widgetsScriptPath = "/widgets.js"
+	// widgetsPath is the prefix of the paths of the widgets themselves.
+	widgetsPath = "/widgets/"
+
+	defaultWidgetRefresh = 60
+	minWidgetRefresh     = 10
+)
+
+// widgetAccent is the form of the accent colors embedders can theme widgets
+// with, a CSS hex color without the leading #.
+var widgetAccent = regexp.MustCompile(`^([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// widgetTheme holds the theming parameters of a widget.
+type widgetTheme struct {
+	Dark    bool
+	Accent  string
+	Refresh int
+}
+
+// parseWidgetTheme reads the theming parameters of a widget from the query:
+// theme (light or dark), accent (a hex color without #) and refresh (seconds
+// between reloads, 0 to disable).
+func parseWidgetTheme(query url.Values) (widgetTheme, error) {
+	theme := widgetTheme{Accent: "#326ce5", Refresh: defaultWidgetRefresh}
+	switch query.Get("theme") {
+	case "", "light":
+	case "dark":
+		theme.Dark = true
+	default:
+		return theme, fmt.Errorf("theme must be light or dark, not %q", query.Get("theme"))
+	}
+	if accent := query.Get("accent"); accent != "" {
+		if !widgetAccent.MatchString(accent) {
+			return theme, fmt.Errorf("accent must be a hex color like 326ce5, not %q", accent)
+		}
+		theme.Accent = "#" + accent
+	}
+	if refresh := query.Get("refresh"); refresh != "" {
+		seconds, err := strconv.Atoi(refresh)
+		if err != nil || seconds < 0 {
+			return theme, fmt.Errorf("refresh must be a number of seconds, not %q", refresh)
+		}
+		if seconds > 0 && seconds < minWidgetRefresh {
+			seconds = minWidgetRefresh
+		}
+		theme.Refresh = seconds
+	}
+	return theme, nil
+}
+
+// widget is what every kind of widget renders to: a title linking back to
+// Deck, an overall state and a row per job or pool.
+type widget struct {
+	Theme widgetTheme
+	Title string
+	Link  string
+	State string
+	Rows  []widgetRow
+	Empty string
+}
+
+type widgetRow struct {
+	Name   string
+	Link   string
+	State  string
+	Detail string
+}
+
+// widgetState rolls the states of the rows up into the state of the widget:
+// any failure fails it, then anything still running makes it pending.
+func widgetState(rows []widgetRow) string {
+	state := "success"
+	for _, row := range rows {
+		switch row.State {
+		case string(prowapi.FailureState), string(prowapi.ErrorState):
+			return string(prowapi.FailureState)
+		case string(prowapi.PendingState), string(prowapi.TriggeredState), string(prowapi.SchedulingState):
+			state = string(prowapi.PendingState)
+		}
+	}
+	if len(rows) == 0 {
+		return "unknown"
+	}
+	return state
+}
+
+// jobRows makes a row out of the latest run of each job.
+func jobRows(pjs []prowapi.ProwJob) []widgetRow {
+	rows := make([]widgetRow, 0, len(pjs))
+	for _, pj := range pjs {
+		detail := string(pj.Status.State)
+		if pj.Status.Description != "" {
+			detail = pj.Status.Description
+		}
+		rows = append(rows, widgetRow{Name: pj.Spec.Job, Link: pj.Status.URL, State: string(pj.Status.State), Detail: detail})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+	return rows
+}
+
+// jobWidget shows the latest run of each job matching the selector, like
+// the badge does.
+func jobWidget(pjs []prowapi.ProwJob, selector string) widget {
+	rows := jobRows(pickLatestJobs(pjs, selector))
+	return widget{
+		Title: selector,
+		Link:  "/?job=" + url.QueryEscape(selector),
+		State: widgetState(rows),
+		Rows:  rows,
+		Empty: "No runs of matching jobs.",
+	}
+}
+
+// prWidget shows the latest run of each presubmit of a pull request.
+func prWidget(pjs []prowapi.ProwJob, org, repo string, number int) widget {
+	var forPR []prowapi.ProwJob
+	for _, pj := range pjs {
+		if pj.Spec.Type != prowapi.PresubmitJob || pj.Spec.Refs == nil || pj.Spec.Refs.Org != org || pj.Spec.Refs.Repo != repo {
+			continue
+		}
+		for _, pull := range pj.Spec.Refs.Pulls {
+			if pull.Number == number {
+				forPR = append(forPR, pj)
+				break
+			}
+		}
+	}
+	rows := jobRows(pickLatestJobs(forPR, "*"))
+	return widget{
+		Title: fmt.Sprintf("%s/%s#%d", org, repo, number),
+		Link:  fmt.Sprintf("/?repo=%s&pull=%d", url.QueryEscape(org+"/"+repo), number),
+		State: widgetState(rows),
+		Rows:  rows,
+		Empty: "No jobs ran for this pull request.",
+	}
+}
+
+// tideWidget summarizes the Tide pools of a repo, or of one of its branches.
+func tideWidget(pools []tide.Pool, org, repo, branch string) widget {
+	var rows []widgetRow
+	for _, pool := range pools {
+		if pool.Org != org || pool.Repo != repo || (branch != "" && pool.Branch != branch) {
+			continue
+		}
+		state := "success"
+		switch {
+		case pool.Error != "" || len(pool.Blockers) > 0:
+			state = string(prowapi.FailureState)
+		case len(pool.BatchPending) > 0 || len(pool.PendingPRs) > 0:
+			state = string(prowapi.PendingState)
+		}
+		detail := fmt.Sprintf("%s: %d passing, %d pending, %d missing", pool.Action, len(pool.SuccessPRs), len(pool.PendingPRs), len(pool.MissingPRs))
+		if len(pool.Blockers) > 0 {
+			detail += fmt.Sprintf(", %d blocker(s)", len(pool.Blockers))
+		}
+		rows = append(rows, widgetRow{Name: pool.Branch, Link: "/tide", State: state, Detail: detail})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+	title := org + "/" + repo
+	if branch != "" {
+		title += ":" + branch
+	}
+	return widget{
+		Title: "Tide " + title,
+		Link:  "/tide",
+		State: widgetState(rows),
+		Rows:  rows,
+		Empty: "No pools for this repo.",
+	}
+}
+
+// parseWidgetRepo reads the org/repo of a widget from the repo query parameter.
+func parseWidgetRepo(query url.Values) (string, string, error) {
+	org, repo, ok := strings.Cut(query.Get("repo"), "/")
+	if !ok || org == "" || repo == "" {
+		return "", "", fmt.Errorf("repo query parameter must be an org/repo, not %q", query.Get("repo"))
+	}
+	return org, repo, nil
+}
+
+// handleWidget serves a widget built from the query of the request.
+func handleWidget(build func(query url.Values) (widget, error), log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+		theme, err := parseWidgetTheme(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		wd, err := build(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		wd.Theme = theme
+		var buf bytes.Buffer
+		if err := widgetTemplate.Execute(&buf, wd); err != nil {
+			log.WithError(err).Error("Error rendering widget.")
+			http.Error(w, "failed to render widget", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(buf.Bytes())
+	}
+}
+
+// handleJobWidget serves the job status tile: /widgets/job?jobs=<selector>.
+func handleJobWidget(ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
+	return handleWidget(func(query url.Values) (widget, error) {
+		selector := query.Get("jobs")
+		if selector == "" {
+			return widget{}, fmt.Errorf("missing jobs query parameter")
+		}
+		return jobWidget(ja.ProwJobs(), selector), nil
+	}, log)
+}
+
+// handlePRWidget serves the PR status card: /widgets/pr?repo=<org/repo>&pr=<number>.
+func handlePRWidget(ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
+	return handleWidget(func(query url.Values) (widget, error) {
+		org, repo, err := parseWidgetRepo(query)
+		if err != nil {
+			return widget{}, err
+		}
+		number, err := strconv.Atoi(query.Get("pr"))
+		if err != nil || number <= 0 {
+			return widget{}, fmt.Errorf("pr query parameter must be a pull request number, not %q", query.Get("pr"))
+		}
+		return prWidget(ja.ProwJobs(), org, repo, number), nil
+	}, log)
+}
+
+// handleTideWidget serves the Tide pool summary: /widgets/tide?repo=<org/repo>[&branch=<branch>].
+func handleTideWidget(ta *tideAgent, log *logrus.Entry) http.HandlerFunc {
+	return handleWidget(func(query url.Values) (widget, error) {
+		org, repo, err := parseWidgetRepo(query)
+		if err != nil {
+			return widget{}, err
+		}
+		ta.Lock()
+		pools := ta.pools
+		ta.Unlock()
+		return tideWidget(pools, org, repo, query.Get("branch")), nil
+	}, log)
+}
+
+// handleWidgetsScript serves the script that embedders include to turn
+// elements with a data-prow-widget attribute into widget iframes.
+func handleWidgetsScript() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Write([]byte(widgetsScript))
+	}
+}
+
+var widgetTemplate = template.Must(template.New("widget").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+{{- if .Theme.Refresh}}
+<meta http-equiv="refresh" content="{{.Theme.Refresh}}">
+{{- end}}
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; font: 13px/1.4 -apple-system, "Segoe UI", Roboto, sans-serif; color: {{if .Theme.Dark}}#e8eaed{{else}}#202124{{end}}; background: {{if .Theme.Dark}}#202124{{else}}#fff{{end}}; }
+.widget { border-left: 4px solid {{.Theme.Accent}}; padding: 6px 10px; }
+.title { font-weight: 600; }
+a { color: inherit; text-decoration: none; }
+a:hover { text-decoration: underline; }
+ul { list-style: none; margin: 4px 0 0; padding: 0; }
+li { display: flex; gap: 6px; align-items: baseline; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+.detail { opacity: .7; overflow: hidden; text-overflow: ellipsis; }
+.state { display: inline-block; width: 9px; height: 9px; border-radius: 50%; flex: none; background: #9aa0a6; }
+.state.success { background: #34a853; }
+.state.failure, .state.error { background: #ea4335; }
+.state.pending, .state.triggered, .state.scheduling { background: #fbbc04; }
+</style>
+</head>
+<body>
+<div class="widget">
+<div class="title"><span class="state {{.State}}" title="{{.State}}"></span> <a href="{{.Link}}" target="_blank" rel="noopener">{{.Title}}</a></div>
+{{- if .Rows}}
+<ul>
+{{- range .Rows}}
+<li><span class="state {{.State}}" title="{{.State}}"></span><a href="{{.Link}}" target="_blank" rel="noopener">{{.Name}}</a><span class="detail">{{.Detail}}</span></li>
+{{- end}}
+</ul>
+{{- else}}
+<div class="detail">{{.Empty}}</div>
+{{- end}}
+</div>
+</body>
+</html>
+`))
+
+// widgetsScript replaces every element with a data-prow-widget attribute with
+// an iframe of that widget, passing the other data- attributes on as query
+// parameters, for example:
+//
+//	<div data-prow-widget="job" data-jobs="ci-*" data-theme="dark"></div>
+//	<script src="https://prow.example.com/widgets.js"></script>
+const widgetsScript = `(function() {
+  var script = document.currentScript;
+  var base = new URL(script ? script.src : "/widgets.js", window.location.href);
+  function embed() {
+    document.querySelectorAll("[data-prow-widget]").forEach(function(el) {
+      var src = new URL("/widgets/" + encodeURIComponent(el.dataset.prowWidget), base);
+      Object.keys(el.dataset).forEach(function(key) {
+        if (key !== "prowWidget" && key !== "width" && key !== "height") {
+          src.searchParams.set(key, el.dataset[key]);
+        }
+      });
+      var frame = document.createElement("iframe");
+      frame.src = src.toString();
+      frame.title = "Prow " + el.dataset.prowWidget + " status";
+      frame.style.border = "0";
+      frame.width = el.dataset.width || "360";
+      frame.height = el.dataset.height || "160";
+      el.replaceWith(frame);
+    });
+  }
+  if (document.readyState === "loading") {
+    document.addEventListener("DOMContentLoaded", embed);
+  } else {
+    embed();
+  }
+})();
+`
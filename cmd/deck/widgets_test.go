@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/tide"
+	"sigs.k8s.io/prow/pkg/tide/blockers"
+)
+
+func TestParseWidgetTheme(t *testing.T) {
+	testCases := []struct {
+		name      string
+		query     string
+		expected  widgetTheme
+		expectErr bool
+	}{
+		{
+			name:     "defaults",
+			expected: widgetTheme{Accent: "#326ce5", Refresh: defaultWidgetRefresh},
+		},
+		{
+			name:     "dark theme with accent",
+			query:    "theme=dark&accent=ff0000",
+			expected: widgetTheme{Dark: true, Accent: "#ff0000", Refresh: defaultWidgetRefresh},
+		},
+		{
+			name:     "refresh is raised to the minimum",
+			query:    "refresh=1",
+			expected: widgetTheme{Accent: "#326ce5", Refresh: minWidgetRefresh},
+		},
+		{
+			name:     "refresh can be disabled",
+			query:    "refresh=0",
+			expected: widgetTheme{Accent: "#326ce5"},
+		},
+		{
+			name:      "unknown theme",
+			query:     "theme=blue",
+			expectErr: true,
+		},
+		{
+			name:      "accent that is not a hex color",
+			query:     "accent=red%3Bbackground:url(x)",
+			expectErr: true,
+		},
+		{
+			name:      "negative refresh",
+			query:     "refresh=-1",
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("failed to parse query: %v", err)
+			}
+			theme, err := parseWidgetTheme(query)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+			if err == nil {
+				if diff := cmp.Diff(tc.expected, theme); diff != "" {
+					t.Errorf("theme differs from expected (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestJobAndPRWidgets(t *testing.T) {
+	earlier := metav1.NewTime(metav1.Now().Add(-60e9))
+	later := metav1.Now()
+	refs := func(number int) *prowapi.Refs {
+		return &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: number}}}
+	}
+	pjs := []prowapi.ProwJob{
+		{Spec: prowapi.ProwJobSpec{Job: "ci-a", Type: prowapi.PeriodicJob}, Status: prowapi.ProwJobStatus{StartTime: earlier, State: prowapi.FailureState, URL: "/view/a/1"}},
+		{Spec: prowapi.ProwJobSpec{Job: "ci-a", Type: prowapi.PeriodicJob}, Status: prowapi.ProwJobStatus{StartTime: later, State: prowapi.SuccessState, URL: "/view/a/2"}},
+		{Spec: prowapi.ProwJobSpec{Job: "ci-b", Type: prowapi.PeriodicJob}, Status: prowapi.ProwJobStatus{StartTime: later, State: prowapi.PendingState, URL: "/view/b/1"}},
+		{Spec: prowapi.ProwJobSpec{Job: "pull-unit", Type: prowapi.PresubmitJob, Refs: refs(1)}, Status: prowapi.ProwJobStatus{StartTime: later, State: prowapi.FailureState, Description: "Job failed.", URL: "/view/unit/1"}},
+		{Spec: prowapi.ProwJobSpec{Job: "pull-lint", Type: prowapi.PresubmitJob, Refs: refs(1)}, Status: prowapi.ProwJobStatus{StartTime: later, State: prowapi.SuccessState, URL: "/view/lint/1"}},
+		{Spec: prowapi.ProwJobSpec{Job: "pull-unit", Type: prowapi.PresubmitJob, Refs: refs(2)}, Status: prowapi.ProwJobStatus{StartTime: later, State: prowapi.SuccessState, URL: "/view/unit/2"}},
+	}
+
+	job := jobWidget(pjs, "ci-*")
+	expectedJob := widget{
+		Title: "ci-*",
+		Link:  "/?job=ci-%2A",
+		State: "pending",
+		Rows: []widgetRow{
+			{Name: "ci-a", Link: "/view/a/2", State: "success", Detail: "success"},
+			{Name: "ci-b", Link: "/view/b/1", State: "pending", Detail: "pending"},
+		},
+		Empty: "No runs of matching jobs.",
+	}
+	if diff := cmp.Diff(expectedJob, job); diff != "" {
+		t.Errorf("job widget differs from expected (-want +got):\n%s", diff)
+	}
+
+	pr := prWidget(pjs, "org", "repo", 1)
+	expectedPR := widget{
+		Title: "org/repo#1",
+		Link:  "/?repo=org%2Frepo&pull=1",
+		State: "failure",
+		Rows: []widgetRow{
+			{Name: "pull-lint", Link: "/view/lint/1", State: "success", Detail: "success"},
+			{Name: "pull-unit", Link: "/view/unit/1", State: "failure", Detail: "Job failed."},
+		},
+		Empty: "No jobs ran for this pull request.",
+	}
+	if diff := cmp.Diff(expectedPR, pr); diff != "" {
+		t.Errorf("PR widget differs from expected (-want +got):\n%s", diff)
+	}
+
+	if empty := prWidget(pjs, "org", "other", 1); empty.State != "unknown" || len(empty.Rows) != 0 {
+		t.Errorf("expected an empty widget of unknown state for another repo, got %+v", empty)
+	}
+}
+
+func TestTideWidget(t *testing.T) {
+	pr := tide.CodeReviewCommon{Number: 1}
+	pools := []tide.Pool{
+		{Org: "org", Repo: "repo", Branch: "main", Action: tide.Merge, SuccessPRs: []tide.CodeReviewCommon{pr}},
+		{Org: "org", Repo: "repo", Branch: "release", Action: tide.Wait, PendingPRs: []tide.CodeReviewCommon{pr}},
+		{Org: "org", Repo: "repo", Branch: "old", Action: tide.Wait, Blockers: []blockers.Blocker{{Number: 2}}},
+		{Org: "org", Repo: "other", Branch: "main", Action: tide.Wait},
+	}
+
+	all := tideWidget(pools, "org", "repo", "")
+	expected := widget{
+		Title: "Tide org/repo",
+		Link:  "/tide",
+		State: "failure",
+		Rows: []widgetRow{
+			{Name: "main", Link: "/tide", State: "success", Detail: "MERGE: 1 passing, 0 pending, 0 missing"},
+			{Name: "old", Link: "/tide", State: "failure", Detail: "WAIT: 0 passing, 0 pending, 0 missing, 1 blocker(s)"},
+			{Name: "release", Link: "/tide", State: "pending", Detail: "WAIT: 0 passing, 1 pending, 0 missing"},
+		},
+		Empty: "No pools for this repo.",
+	}
+	if diff := cmp.Diff(expected, all); diff != "" {
+		t.Errorf("tide widget differs from expected (-want +got):\n%s", diff)
+	}
+
+	if branch := tideWidget(pools, "org", "repo", "release"); branch.Title != "Tide org/repo:release" || branch.State != "pending" || len(branch.Rows) != 1 {
+		t.Errorf("expected only the release pool, got %+v", branch)
+	}
+}
+
+func TestHandleWidget(t *testing.T) {
+	build := func(query url.Values) (widget, error) {
+		if _, _, err := parseWidgetRepo(query); err != nil {
+			return widget{}, err
+		}
+		return widget{
+			Title: "<org/repo>",
+			Link:  "/tide",
+			State: "success",
+			Rows:  []widgetRow{{Name: "main", Link: "javascript:alert(1)", State: "success", Detail: "ok"}},
+		}, nil
+	}
+	testCases := []struct {
+		name           string
+		method         string
+		query          string
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "renders the widget with its theme",
+			method:         http.MethodGet,
+			query:          "repo=org/repo&theme=dark&accent=abc",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"&lt;org/repo&gt;", "#abc", "#202124", `http-equiv="refresh" content="60"`, "#ZgotmplZ"},
+		},
+		{
+			name:           "invalid theme",
+			method:         http.MethodGet,
+			query:          "repo=org/repo&theme=blue",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid repo",
+			method:         http.MethodGet,
+			query:          "repo=org",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "only GET",
+			method:         http.MethodPost,
+			query:          "repo=org/repo",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/widgets/tide?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handleWidget(build, logrus.WithField("handler", "/widgets/tide")).ServeHTTP(rr, req)
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			for _, expected := range tc.expectedBody {
+				if !strings.Contains(rr.Body.String(), expected) {
+					t.Errorf("expected the widget to contain %q, got:\n%s", expected, rr.Body.String())
+				}
+			}
+		})
+	}
+}
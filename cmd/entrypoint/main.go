@@ -19,12 +19,15 @@ package main
 import (
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"runtime"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/prow/pkg/entrypoint"
 	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/pod-utils/options"
+	"sigs.k8s.io/prow/pkg/version"
 )
 
 // copy copies entrypoint binary from source to destination. This is because
@@ -44,7 +47,7 @@ func copy(src, dst string) error {
 		return fmt.Errorf("read file '%s': %w", src, err)
 	}
 	// Create dir if not exist
-	dstDir := path.Dir(dst)
+	dstDir := filepath.Dir(dst)
 	if err := os.MkdirAll(dstDir, 0755); err != nil {
 		return fmt.Errorf("create dir '%s': %w", dstDir, err)
 	}
@@ -63,9 +66,35 @@ func main() {
 	}
 
 	if o.CopyModeOnly {
-		if err := copy(os.Args[0], o.CopyDst); err != nil {
+		// os.Args[0] is only a path to the binary on Linux, e.g. Windows
+		// resolves /entrypoint to /entrypoint.exe.
+		src, err := os.Executable()
+		if err != nil {
+			src = os.Args[0]
+		}
+		dst := o.CopyDst
+		if runtime.GOOS == "windows" && filepath.Ext(dst) == "" {
+			// Windows only runs files with an executable extension, and
+			// resolves /tools/entrypoint to /tools/entrypoint.exe.
+			dst += ".exe"
+		}
+		if err := copy(src, dst); err != nil {
+			logrus.WithError(err).Fatal("Failed running in copy mode, this is a prow bug.")
+		}
+		if err := entrypoint.WriteChecksum(dst); err != nil {
 			logrus.WithError(err).Fatal("Failed running in copy mode, this is a prow bug.")
 		}
+		logrus.Infof("Copied entrypoint version %s to %s", version.Version, dst)
+		os.Exit(0)
+	}
+
+	// The liveness probe of a decorated container loads the options of its
+	// test process from the environment, where flags are not parsed.
+	if o.CheckHeartbeatOnly || (len(os.Args) == 2 && os.Args[1] == entrypoint.CheckHeartbeatFlag) {
+		if err := o.CheckHeartbeat(time.Now()); err != nil {
+			logrus.WithError(err).Error("The entrypoint looks hung.")
+			os.Exit(1)
+		}
 		os.Exit(0)
 	}
 
@@ -73,5 +102,18 @@ func main() {
 		logrus.Fatalf("Invalid options: %v", err)
 	}
 
+	// A binary that was not fully copied to the shared volume must not run
+	// the test, it would fail in ways that are hard to tell apart from the
+	// test failing. The process is recorded as failed so sidecar finishes.
+	if executable, err := os.Executable(); err == nil {
+		if err := entrypoint.VerifyChecksum(executable); err != nil {
+			logrus.WithError(err).Error("The entrypoint binary does not match the one copied in, this is a prow bug.")
+			if err := o.Mark(entrypoint.InternalErrorCode); err != nil {
+				logrus.WithError(err).Error("Error writing exit code to marker file")
+			}
+			os.Exit(entrypoint.InternalErrorCode)
+		}
+	}
+
 	os.Exit(o.Run())
 }
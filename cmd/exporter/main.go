@@ -103,10 +103,12 @@ func main() {
 
 	registry := mustRegister("exporter", pjLister)
 	registry.MustRegister(prowjobs.NewProwJobLifecycleHistogramVec(informerFactory.Prow().V1().ProwJobs().Informer()))
+	registry.MustRegister(prowjobs.NewProwJobCostCounterVec(informerFactory.Prow().V1().ProwJobs().Informer(), cfg))
 
 	// Expose prometheus metrics
 	metrics.ExposeMetricsWithRegistry("exporter", cfg().PushGateway, o.instrumentationOptions.MetricsPort, registry, nil)
 
 	logrus.Info("exporter is running ...")
 	health.ServeReady()
+	health.ServeConfigHash(cfg)
 }
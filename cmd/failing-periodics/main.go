@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/failingperiodics"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/metrics"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/pjutil/pprof"
+)
+
+const defaultHourlyTokens = 360
+
+type options struct {
+	config configflagutil.ConfigOptions
+
+	github                 prowflagutil.GitHubOptions
+	kubernetes             prowflagutil.KubernetesOptions
+	storage                prowflagutil.StorageClientOptions
+	instrumentationOptions prowflagutil.InstrumentationOptions
+
+	classifyBuildLogs bool
+	dryRun            bool
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	var o options
+	fs.BoolVar(&o.dryRun, "dry-run", true, "Dry run for testing. Uses API tokens but does not mutate.")
+	fs.BoolVar(&o.classifyBuildLogs, "classify-build-logs", true, "Classify failures by matching their build logs against the known failure patterns of Spyglass.")
+	o.github.AddCustomizedFlags(fs, prowflagutil.ThrottlerDefaults(defaultHourlyTokens, defaultHourlyTokens))
+	for _, group := range []flagutil.OptionGroup{&o.config, &o.kubernetes, &o.storage, &o.instrumentationOptions} {
+		group.AddFlags(fs)
+	}
+	fs.Parse(args)
+	return o
+}
+
+func (o *options) Validate() error {
+	for _, group := range []flagutil.OptionGroup{&o.github, &o.kubernetes, &o.storage, &o.config, &o.instrumentationOptions} {
+		if err := group.Validate(o.dryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	defer interrupts.WaitForGracefulShutdown()
+
+	pprof.Instrument(o.instrumentationOptions)
+	health := pjutil.NewHealthOnPort(o.instrumentationOptions.HealthPort)
+
+	configAgent, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error starting config agent.")
+	}
+	cfg := configAgent.Config
+
+	githubClient, err := o.github.GitHubClient(o.dryRun)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting GitHub client.")
+	}
+	prowJobClient, err := o.kubernetes.ProwJobClient(cfg().ProwJobNamespace, o.dryRun)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting ProwJob client.")
+	}
+	var opener io.Opener
+	if o.classifyBuildLogs {
+		opener, err = o.storage.StorageClient(context.Background())
+		if err != nil {
+			logrus.WithError(err).Fatal("Error creating opener.")
+		}
+	}
+
+	metrics.ExposeMetrics("failing-periodics", cfg().PushGateway, o.instrumentationOptions.MetricsPort)
+
+	c := failingperiodics.NewController(githubClient, prowJobClient, opener, cfg, o.dryRun)
+	interrupts.TickLiteral(func() {
+		start := time.Now()
+		if err := c.Sync(); err != nil {
+			logrus.WithError(err).Error("Error syncing the issues of periodic jobs.")
+		}
+		logrus.WithField("duration", time.Since(start).String()).Info("Synced periodic jobs.")
+	}, cfg().FailingPeriodics.GetResyncPeriod())
+
+	health.ServeReady()
+	health.ServeConfigHash(cfg)
+}
@@ -227,6 +227,7 @@ func main() {
 	// workaround for older Kubernetes clusters (older than K8s 1.24) that do
 	// not support native gRPC health checks.
 	healthHTTP.ServeReady()
+	healthHTTP.ServeConfigHash(configAgent.Config)
 
 	// Start serving gRPC requests! Note that ListenAndServe() does not block,
 	// while WaitForGracefulShutdown() does block.
@@ -190,7 +190,7 @@ func main() {
 	}, o.instrumentationOptions.MetricsPort)
 
 	proxy := proxy(o, http.DefaultTransport, time.Hour)
-	server := &http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: proxy}
+	server := &http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: withUsageReport(proxy)}
 
 	health := pjutil.NewHealthOnPort(o.instrumentationOptions.HealthPort)
 	health.ServeReady()
@@ -227,6 +227,19 @@ func newReverseProxy(upstreamURL *url.URL, transport http.RoundTripper, timeout
 	return http.TimeoutHandler(proxy, timeout, fmt.Sprintf("ghproxy timed out after %v", timeout))
 }
 
+// withUsageReport serves the rate limit usage report on ghcache.UsageReportPath
+// and proxies all other requests.
+func withUsageReport(proxy http.Handler) http.Handler {
+	report := ghcache.UsageReportHandler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == ghcache.UsageReportPath {
+			report.ServeHTTP(w, r)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
+
 // helper to update disk metrics (copied from greenhouse)
 func diskMonitor(interval time.Duration, diskRoot string) {
 	logger := logrus.WithField("sync-loop", "disk-monitor")
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/config/secret"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	pluginsflagutil "sigs.k8s.io/prow/pkg/flagutil/plugins"
+	git "sigs.k8s.io/prow/pkg/git/v2"
+	"sigs.k8s.io/prow/pkg/gitlab"
+	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/metrics"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/pjutil/pprof"
+	"sigs.k8s.io/prow/pkg/plugins"
+	"sigs.k8s.io/prow/pkg/plugins/ownersconfig"
+	"sigs.k8s.io/prow/pkg/repoowners"
+
+	_ "sigs.k8s.io/prow/pkg/version"
+)
+
+const (
+	defaultWebhookPath = "/hook"
+)
+
+type options struct {
+	port        int
+	webhookPath string
+
+	config        configflagutil.ConfigOptions
+	pluginsConfig pluginsflagutil.PluginOptions
+
+	dryRun                 bool
+	gracePeriod            time.Duration
+	instrumentationOptions flagutil.InstrumentationOptions
+
+	gitlabEndpoint    string
+	gitlabTokenPath   string
+	webhookSecretFile string
+}
+
+func (o *options) Validate() error {
+	for _, group := range []flagutil.OptionGroup{&o.config, &o.pluginsConfig} {
+		if err := group.Validate(o.dryRun); err != nil {
+			return err
+		}
+	}
+	if o.gitlabTokenPath == "" {
+		return errors.New("--gitlab-token-path is required")
+	}
+	if _, err := url.ParseRequestURI(o.gitlabEndpoint); err != nil {
+		return fmt.Errorf("invalid --gitlab-endpoint: %w", err)
+	}
+
+	return nil
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	var o options
+	fs.StringVar(&o.webhookPath, "webhook-path", defaultWebhookPath, "The path of webhook events, default is '/hook'.")
+	fs.IntVar(&o.port, "port", 8888, "Port to listen on.")
+
+	fs.BoolVar(&o.dryRun, "dry-run", true, "Dry run for testing. Uses API tokens but does not mutate.")
+	fs.DurationVar(&o.gracePeriod, "grace-period", 180*time.Second, "On shutdown, try to handle remaining events for the specified duration. ")
+	o.pluginsConfig.PluginConfigPathDefault = "/etc/plugins/plugins.yaml"
+	for _, group := range []flagutil.OptionGroup{&o.instrumentationOptions, &o.config, &o.pluginsConfig} {
+		group.AddFlags(fs)
+	}
+
+	fs.StringVar(&o.gitlabEndpoint, "gitlab-endpoint", gitlab.DefaultEndpoint, "GitLab's API endpoint.")
+	fs.StringVar(&o.gitlabTokenPath, "gitlab-token-path", "/etc/gitlab/token", "Path to the file containing the GitLab personal access token of the bot.")
+	fs.StringVar(&o.webhookSecretFile, "webhook-secret-file", "/etc/webhook/token", "Path to the file containing the secret token GitLab sends with webhook events.")
+	fs.Parse(args)
+	return o
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	configAgent, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error starting config agent.")
+	}
+
+	if err := secret.Add(o.gitlabTokenPath, o.webhookSecretFile); err != nil {
+		logrus.WithError(err).Fatal("Error starting secrets agent.")
+	}
+
+	pluginAgent, err := o.pluginsConfig.PluginAgent()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error starting plugins.")
+	}
+
+	endpoint, _ := url.Parse(o.gitlabEndpoint)
+	token := secret.GetTokenGenerator(o.gitlabTokenPath)
+	gitlabClient := gitlab.NewClient(o.gitlabEndpoint, token, o.dryRun)
+	githubClient := gitlab.NewGitHubClient(gitlabClient)
+
+	gitClient, err := git.NewClientFactory(func(opts *git.ClientFactoryOpts) {
+		opts.Host = endpoint.Host
+		opts.UseInsecureHTTP = new(bool)
+		*opts.UseInsecureHTTP = endpoint.Scheme == "http"
+		opts.CacheDirBase = &o.config.InRepoConfigCacheDirBase
+		opts.Username = func() (string, error) { return "oauth2", nil }
+		opts.Token = func(string) (string, error) { return string(token()), nil }
+		opts.Censor = secret.Censor
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting Git client.")
+	}
+
+	mdYAMLEnabled := func(org, repo string) bool {
+		return pluginAgent.Config().MDYAMLEnabled(org, repo)
+	}
+	skipCollaborators := func(org, repo string) bool {
+		return pluginAgent.Config().SkipCollaborators(org, repo)
+	}
+	ownersDirDenylist := func() *config.OwnersDirDenylist {
+		// OwnersDirDenylist struct contains some defaults that's required by all
+		// repos, so this function cannot return nil
+		res := &config.OwnersDirDenylist{}
+		if l := configAgent.Config().OwnersDirDenylist; l != nil {
+			res = l
+		}
+		return res
+	}
+	resolver := func(org, repo string) ownersconfig.Filenames {
+		return pluginAgent.Config().OwnersFilenames(org, repo)
+	}
+	ownersClient := repoowners.NewClient(gitClient, githubClient, mdYAMLEnabled, skipCollaborators, ownersDirDenylist, resolver)
+
+	defer interrupts.WaitForGracefulShutdown()
+
+	// Expose prometheus metrics
+	metrics.ExposeMetrics("gitlab-hook", configAgent.Config().PushGateway, o.instrumentationOptions.MetricsPort)
+	pprof.Instrument(o.instrumentationOptions)
+
+	// OWNERS files are linked to on GitLab itself rather than its API.
+	linkURL := *endpoint
+	linkURL.Path = strings.TrimSuffix(strings.TrimSuffix(linkURL.Path, "/"), "/api/v4")
+
+	server := &server{
+		gitlab:       gitlabClient,
+		ghc:          githubClient,
+		owners:       ownersClient,
+		pluginConfig: func() *plugins.Configuration { return pluginAgent.Config() },
+		linkURL:      &linkURL,
+		token:        secret.GetTokenGenerator(o.webhookSecretFile),
+	}
+	interrupts.OnInterrupt(func() {
+		server.GracefulShutdown()
+		if err := gitClient.Clean(); err != nil {
+			logrus.WithError(err).Error("Could not clean up git client cache.")
+		}
+	})
+
+	health := pjutil.NewHealthOnPort(o.instrumentationOptions.HealthPort)
+
+	mux := http.NewServeMux()
+	mux.Handle(o.webhookPath, server)
+	httpServer := &http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: mux}
+
+	health.ServeReady()
+	health.ServeConfigHash(configAgent.Config)
+
+	interrupts.ListenAndServe(httpServer, o.gracePeriod)
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/commentpruner"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/gitlab"
+	"sigs.k8s.io/prow/pkg/plugins"
+	"sigs.k8s.io/prow/pkg/plugins/approve"
+	"sigs.k8s.io/prow/pkg/plugins/lgtm"
+	"sigs.k8s.io/prow/pkg/repoowners"
+)
+
+// server handles GitLab webhook events with the approve and lgtm plugins.
+type server struct {
+	gitlab       gitlab.Client
+	ghc          *gitlab.GitHubClient
+	owners       repoowners.Interface
+	pluginConfig func() *plugins.Configuration
+	// linkURL is the web URL of GitLab that OWNERS files are linked to.
+	linkURL *url.URL
+	// token returns the secret token GitLab sends along with events.
+	token func() []byte
+
+	wg sync.WaitGroup
+}
+
+// ServeHTTP validates an event and handles it in the background.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "405 Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), s.token()) != 1 {
+		http.Error(w, "403 Forbidden: invalid X-Gitlab-Token", http.StatusForbidden)
+		return
+	}
+	kind := r.Header.Get("X-Gitlab-Event")
+	if kind == "" {
+		http.Error(w, "400 Bad Request: missing X-Gitlab-Event header", http.StatusBadRequest)
+		return
+	}
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "500 Internal Server Error: failed to read request body", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(w, "Event received. Have a nice day.")
+
+	l := logrus.WithFields(logrus.Fields{"event-type": kind, github.EventGUID: r.Header.Get("X-Gitlab-Event-UUID")})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.handleEvent(l, kind, payload); err != nil {
+			l.WithError(err).Error("Error handling event.")
+		}
+	}()
+}
+
+// GracefulShutdown waits for the events being handled.
+func (s *server) GracefulShutdown() {
+	s.wg.Wait()
+}
+
+func (s *server) handleEvent(l *logrus.Entry, kind string, payload []byte) error {
+	switch kind {
+	case gitlab.NoteHook:
+		var e gitlab.NoteEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return fmt.Errorf("failed to unmarshal note event: %w", err)
+		}
+		return s.handleNote(l, e)
+	case gitlab.MergeRequestHook:
+		var e gitlab.MergeRequestEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return fmt.Errorf("failed to unmarshal merge request event: %w", err)
+		}
+		return s.handleMergeRequest(l, e)
+	default:
+		l.Debug("Ignoring unhandled event type.")
+		return nil
+	}
+}
+
+// enabledPlugins returns which of the plugins are enabled for the project.
+func (s *server) enabledPlugins(project gitlab.Project) sets.Set[string] {
+	org, repo := project.OrgRepo()
+	return sets.New(s.pluginConfig().Plugins.EnabledPlugins(org, repo)...).Intersection(sets.New(approve.PluginName, lgtm.PluginName))
+}
+
+func (s *server) githubOptions() config.GitHubOptions {
+	return config.GitHubOptions{LinkURLFromConfig: s.linkURL.String(), LinkURL: s.linkURL}
+}
+
+func (s *server) handleNote(l *logrus.Entry, e gitlab.NoteEvent) error {
+	enabled := s.enabledPlugins(e.Project)
+	if enabled.Len() == 0 || e.MergeRequest == nil {
+		return nil
+	}
+	mr, err := s.gitlab.GetMergeRequest(e.Project.PathWithNamespace, e.MergeRequest.IID)
+	if err != nil {
+		return fmt.Errorf("failed to get merge request: %w", err)
+	}
+	ce, ok := e.GenericCommentEvent(mr)
+	if !ok {
+		return nil
+	}
+	l = l.WithFields(logrus.Fields{github.OrgLogField: ce.Repo.Owner.Login, github.RepoLogField: ce.Repo.Name, github.PrLogField: ce.Number})
+	pc := s.pluginConfig()
+	var errs []error
+	if enabled.Has(lgtm.PluginName) {
+		cp := commentpruner.NewEventClient(s.ghc, l, ce.Repo.Owner.Login, ce.Repo.Name, ce.Number)
+		if err := lgtm.HandleGenericComment(s.ghc, pc, s.owners, l.WithField("plugin", lgtm.PluginName), cp, ce); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", lgtm.PluginName, err))
+		}
+	}
+	if enabled.Has(approve.PluginName) {
+		if err := approve.HandleGenericComment(l.WithField("plugin", approve.PluginName), s.ghc, s.owners, s.githubOptions(), pc, &ce); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", approve.PluginName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *server) handleMergeRequest(l *logrus.Entry, e gitlab.MergeRequestEvent) error {
+	enabled := s.enabledPlugins(e.Project)
+	if enabled.Len() == 0 {
+		return nil
+	}
+	mr, err := s.gitlab.GetMergeRequest(e.Project.PathWithNamespace, e.ObjectAttributes.IID)
+	if err != nil {
+		return fmt.Errorf("failed to get merge request: %w", err)
+	}
+	org, repo := e.Project.OrgRepo()
+	l = l.WithFields(logrus.Fields{github.OrgLogField: org, github.RepoLogField: repo, github.PrLogField: mr.IID})
+	pc := s.pluginConfig()
+	var errs []error
+	for _, pre := range e.PullRequestEvents(mr) {
+		if enabled.Has(lgtm.PluginName) {
+			if err := lgtm.HandlePullRequest(l.WithField("plugin", lgtm.PluginName), s.ghc, pc, &pre); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", lgtm.PluginName, err))
+			}
+		}
+		if enabled.Has(approve.PluginName) {
+			if err := approve.HandlePullRequest(l.WithField("plugin", approve.PluginName), s.ghc, s.owners, s.githubOptions(), pc, &pre); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", approve.PluginName, err))
+			}
+		}
+	}
+	if re, ok := e.ReviewEvent(mr); ok {
+		if enabled.Has(lgtm.PluginName) {
+			cp := commentpruner.NewEventClient(s.ghc, l, org, repo, mr.IID)
+			if err := lgtm.HandlePullRequestReview(s.ghc, pc, s.owners, l.WithField("plugin", lgtm.PluginName), cp, re); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", lgtm.PluginName, err))
+			}
+		}
+		if enabled.Has(approve.PluginName) {
+			if err := approve.HandleReview(l.WithField("plugin", approve.PluginName), s.ghc, s.owners, s.githubOptions(), pc, &re); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", approve.PluginName, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
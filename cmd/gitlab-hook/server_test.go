@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/gitlab"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+func TestServeHTTP(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		token          string
+		kind           string
+		expectedStatus int
+	}{
+		{
+			name:           "valid event",
+			method:         http.MethodPost,
+			token:          "secret",
+			kind:           "Push Hook",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "wrong method",
+			method:         http.MethodGet,
+			token:          "secret",
+			kind:           "Push Hook",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "wrong token",
+			method:         http.MethodPost,
+			token:          "guess",
+			kind:           "Push Hook",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "missing event kind",
+			method:         http.MethodPost,
+			token:          "secret",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &server{token: func() []byte { return []byte("secret") }}
+			req := httptest.NewRequest(tc.method, "/hook", strings.NewReader("{}"))
+			req.Header.Set("X-Gitlab-Token", tc.token)
+			if tc.kind != "" {
+				req.Header.Set("X-Gitlab-Event", tc.kind)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			s.GracefulShutdown()
+			if w.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandleEventWithoutEnabledPlugins(t *testing.T) {
+	// The server must not talk to GitLab for projects without the plugins.
+	s := &server{pluginConfig: func() *plugins.Configuration {
+		return &plugins.Configuration{Plugins: plugins.Plugins{"org": {Plugins: []string{"approve"}}}}
+	}}
+	for kind, payload := range map[string]string{
+		gitlab.NoteHook:         `{"project":{"path_with_namespace":"other/repo"},"object_attributes":{"noteable_type":"MergeRequest"},"merge_request":{"iid":1}}`,
+		gitlab.MergeRequestHook: `{"project":{"path_with_namespace":"other/repo"},"object_attributes":{"iid":1,"action":"approved"}}`,
+	} {
+		if err := s.handleEvent(logrus.WithField("test", t.Name()), kind, []byte(payload)); err != nil {
+			t.Errorf("unexpected error handling %s: %v", kind, err)
+		}
+	}
+	if err := s.handleEvent(logrus.WithField("test", t.Name()), gitlab.NoteHook, []byte("not json")); err == nil {
+		t.Error("expected an invalid payload to fail")
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"errors"
 	"flag"
 	"net/http"
 	"os"
@@ -40,6 +41,7 @@ import (
 	jiraclient "sigs.k8s.io/prow/pkg/jira"
 	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/metrics"
+	"sigs.k8s.io/prow/pkg/moonraker"
 	"sigs.k8s.io/prow/pkg/pjutil"
 	pluginhelp "sigs.k8s.io/prow/pkg/pluginhelp/hook"
 	"sigs.k8s.io/prow/pkg/plugins"
@@ -74,6 +76,8 @@ type options struct {
 
 	webhookSecretFile string
 	slackTokenFile    string
+
+	trackAppInstallations bool
 }
 
 func (o *options) Validate() error {
@@ -82,6 +86,9 @@ func (o *options) Validate() error {
 			return err
 		}
 	}
+	if o.trackAppInstallations && o.github.AppID == "" {
+		return errors.New("--track-app-installations requires GitHub App authentication")
+	}
 
 	return nil
 }
@@ -100,6 +107,7 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 
 	fs.StringVar(&o.webhookSecretFile, "hmac-secret-file", "/etc/webhook/hmac", "Path to the file containing the GitHub HMAC secret.")
 	fs.StringVar(&o.slackTokenFile, "slack-token-file", "", "Path to the file containing the Slack token to use.")
+	fs.BoolVar(&o.trackAppInstallations, "track-app-installations", false, "Track GitHub App installations from installation events, only handle events for repos covered by an active installation and enable the installation_plugins of the installation covering a repo. Requires GitHub App authentication.")
 	fs.Parse(args)
 	return o
 }
@@ -156,8 +164,10 @@ func main() {
 		logrus.WithError(err).Fatal("Error getting Git client.")
 	}
 
+	// Clients are also created when only their flags are set, so that the
+	// plugins using them can be enabled later without restarting hook.
 	var bugzillaClient bugzilla.Client
-	if orgs, repos, _ := pluginAgent.Config().EnabledReposForPlugin(bzplugin.PluginName); orgs != nil || repos != nil {
+	if orgs, repos, _ := pluginAgent.Config().EnabledReposForPlugin(bzplugin.PluginName); orgs != nil || repos != nil || o.bugzilla.Configured() {
 		client, err := o.bugzilla.BugzillaClient()
 		if err != nil {
 			logrus.WithError(err).Fatal("Error getting Bugzilla client.")
@@ -170,7 +180,7 @@ func main() {
 	}
 
 	var jiraClient jiraclient.Client
-	if orgs, repos, _ := pluginAgent.Config().EnabledReposForPlugin(jira.PluginName); orgs != nil || repos != nil {
+	if orgs, repos, _ := pluginAgent.Config().EnabledReposForPlugin(jira.PluginName); orgs != nil || repos != nil || o.jira.Configured() {
 		client, err := o.jira.Client()
 		if err != nil {
 			logrus.WithError(err).Fatal("Failed to construct Jira Client")
@@ -203,6 +213,14 @@ func main() {
 		slackClient = slack.NewFakeClient()
 	}
 
+	var moonrakerClient *moonraker.Client
+	if o.config.MoonrakerAddress != "" {
+		moonrakerClient, err = moonraker.NewClient(o.config.MoonrakerAddress, configAgent)
+		if err != nil {
+			logrus.WithError(err).Fatal("Error getting Moonraker client.")
+		}
+	}
+
 	mdYAMLEnabled := func(org, repo string) bool {
 		return pluginAgent.Config().MDYAMLEnabled(org, repo)
 	}
@@ -233,6 +251,7 @@ func main() {
 		OwnersClient:              ownersClient,
 		BugzillaClient:            bugzillaClient,
 		JiraClient:                jiraClient,
+		MoonrakerClient:           moonrakerClient,
 	}
 
 	promMetrics := githubeventserver.NewMetrics()
@@ -251,6 +270,14 @@ func main() {
 		RepoEnabled:    o.githubEnablement.EnablementChecker(),
 		TokenGenerator: secret.GetTokenGenerator(o.webhookSecretFile),
 	}
+	if o.trackAppInstallations {
+		installations, err := hook.LoadInstallations(githubClient)
+		if err != nil {
+			logrus.WithError(err).Fatal("Error loading GitHub App installations.")
+		}
+		server.Installations = installations
+		pluginAgent.SetInstallationResolver(installations.InstallationFor)
+	}
 	interrupts.OnInterrupt(func() {
 		server.GracefulShutdown()
 		if err := gitClient.Clean(); err != nil {
@@ -269,10 +296,17 @@ func main() {
 	hookMux.Handle(o.webhookPath, server)
 	// Serve plugin help information from /plugin-help.
 	hookMux.Handle("/plugin-help", pluginhelp.NewHelpAgent(pluginAgent, githubClient))
+	// Report the plugins currently active for a repo from /active-plugins.
+	hookMux.HandleFunc("/active-plugins", server.ServeActivePlugins)
+	// Let pushes to the job config source be announced with a webhook.
+	if invalidator := o.config.JobConfigSourceInvalidator(); invalidator != nil {
+		hookMux.Handle("/job-config-source/invalidate", invalidator)
+	}
 
 	httpServer := &http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: hookMux}
 
 	health.ServeReady()
+	health.ServeConfigHash(configAgent.Config)
 
 	interrupts.ListenAndServe(httpServer, o.gracePeriod)
 }
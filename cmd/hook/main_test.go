@@ -77,6 +77,13 @@ func Test_gatherOptions(t *testing.T) {
 				o.webhookPath = "/random/hook"
 			},
 		},
+		{
+			name: "--track-app-installations without GitHub App auth is invalid",
+			args: map[string]string{
+				"--track-app-installations": "true",
+			},
+			err: true,
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
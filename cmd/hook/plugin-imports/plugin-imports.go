@@ -30,6 +30,8 @@ import (
 	_ "sigs.k8s.io/prow/pkg/plugins/cherrypickapproved"
 	_ "sigs.k8s.io/prow/pkg/plugins/cherrypickunapproved"
 	_ "sigs.k8s.io/prow/pkg/plugins/cla"
+	_ "sigs.k8s.io/prow/pkg/plugins/cleanup"
+	_ "sigs.k8s.io/prow/pkg/plugins/configpreview"
 	_ "sigs.k8s.io/prow/pkg/plugins/dco"
 	_ "sigs.k8s.io/prow/pkg/plugins/dog"
 	_ "sigs.k8s.io/prow/pkg/plugins/golint"
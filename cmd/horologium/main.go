@@ -132,13 +132,20 @@ func main() {
 
 	metrics.ExposeMetrics("horologium", configAgent.Config().PushGateway, o.instrumentationOptions.MetricsPort)
 
+	health := pjutil.NewHealthOnPort(o.instrumentationOptions.HealthPort)
+	health.ServeReady()
+	health.ServeConfigHash(configAgent.Config)
+
 	tickInterval := defaultTickInterval
 	if configAgent.Config().Horologium.TickInterval != nil {
 		tickInterval = configAgent.Config().Horologium.TickInterval.Duration
 	}
+	// queued are the periodics with a run waiting for the previous runs to
+	// complete, see the "queue" concurrency policy.
+	queued := sets.New[string]()
 	interrupts.TickLiteral(func() {
 		start := time.Now()
-		if err := sync(cluster.GetClient(), configAgent.Config(), cr, start); err != nil {
+		if err := sync(cluster.GetClient(), configAgent.Config(), cr, queued, start); err != nil {
 			logrus.WithError(err).Error("Error syncing periodic jobs.")
 		}
 		logrus.WithField("duration", time.Since(start)).Info("Synced periodic jobs")
@@ -150,12 +157,18 @@ type cronClient interface {
 	QueuedJobs() []string
 }
 
-func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronClient, now time.Time) error {
+func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronClient, queued sets.Set[string], now time.Time) error {
 	jobs := &prowapi.ProwJobList{}
 	if err := prowJobClient.List(context.TODO(), jobs, ctrlruntimeclient.InNamespace(cfg.ProwJobNamespace)); err != nil {
 		return fmt.Errorf("error listing prow jobs: %w", err)
 	}
 	latestJobs := pjutil.GetLatestProwJobs(jobs.Items, prowapi.PeriodicJob)
+	activeJobs := map[string][]prowapi.ProwJob{}
+	for _, job := range jobs.Items {
+		if job.Spec.Type == prowapi.PeriodicJob && !job.Complete() {
+			activeJobs[job.Spec.Job] = append(activeJobs[job.Spec.Job], job)
+		}
+	}
 
 	if err := cr.SyncConfig(cfg); err != nil {
 		logrus.WithError(err).Error("Error syncing cron jobs.")
@@ -167,7 +180,9 @@ func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronCli
 	}
 
 	var errs []error
+	periodics := sets.New[string]()
 	for _, p := range cfg.Periodics {
+		periodics.Insert(p.Name)
 		j, previousFound := latestJobs[p.Name]
 		logger := logrus.WithFields(logrus.Fields{
 			"job":            p.Name,
@@ -177,26 +192,21 @@ func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronCli
 		var shouldTrigger = false
 		switch {
 		case p.Cron == "": // no cron expression is set, we use interval to trigger
-			if j.Complete() {
-				intervalRef := j.Status.StartTime.Time
-				intervalDuration := p.GetInterval()
-				if p.MinimumInterval != "" {
-					intervalRef = j.Status.CompletionTime.Time
-					intervalDuration = p.GetMinimumInterval()
-				}
-				shouldTrigger = now.Sub(intervalRef) > intervalDuration
+			if p.MinimumInterval != "" {
+				// The next run is only due once the previous run completed.
+				shouldTrigger = j.Complete() && now.Sub(j.Status.CompletionTime.Time) > p.GetMinimumInterval()
+			} else {
+				shouldTrigger = now.Sub(j.Status.StartTime.Time) > p.GetInterval()
 			}
-		case cronTriggers.Has(p.Name):
-			shouldTrigger = j.Complete()
+		case cronTriggers.Has(p.Name), queued.Has(p.Name):
+			shouldTrigger = true
 		default:
-			if !cronTriggers.Has(p.Name) {
-				logger.WithFields(logrus.Fields{
-					"previous-found": previousFound,
-					"should-trigger": shouldTrigger,
-					"name":           p.Name,
-					"job":            p.JobBase.Name,
-				}).Info("Skipping cron periodic")
-			}
+			logger.WithFields(logrus.Fields{
+				"previous-found": previousFound,
+				"should-trigger": shouldTrigger,
+				"name":           p.Name,
+				"job":            p.JobBase.Name,
+			}).Info("Skipping cron periodic")
 			continue
 		}
 		if !shouldTrigger {
@@ -206,7 +216,33 @@ func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronCli
 				"job":            p.JobBase.Name,
 			}).Debug("Trigger time has not yet been reached.")
 		}
+		if active := activeJobs[p.Name]; shouldTrigger && len(active) > 0 {
+			shouldTrigger = false
+			logger = logger.WithFields(logrus.Fields{
+				"active-runs":        len(active),
+				"concurrency-policy": p.GetConcurrencyPolicy(),
+			})
+			switch p.GetConcurrencyPolicy() {
+			case config.PeriodicConcurrencyQueue:
+				logger.Info("Queueing run until the previous runs complete.")
+				queued.Insert(p.Name)
+			case config.PeriodicConcurrencyReplace:
+				if err := abortAll(prowJobClient, active, logger); err != nil {
+					errs = append(errs, err)
+					break
+				}
+				shouldTrigger = true
+			case config.PeriodicConcurrencyAllow:
+				shouldTrigger = p.MaxConcurrency == 0 || len(active) < p.MaxConcurrency
+				if !shouldTrigger {
+					logger.Info("Skipping run, max_concurrency is reached.")
+				}
+			default:
+				logger.Info("Skipping run, the previous run is still active.")
+			}
+		}
 		if !previousFound || shouldTrigger {
+			queued.Delete(p.Name)
 			prowJob := pjutil.NewProwJob(pjutil.PeriodicSpec(p), p.Labels, p.Annotations,
 				pjutil.RequireScheduling(cfg.Scheduler.Enabled))
 			prowJob.Namespace = cfg.ProwJobNamespace
@@ -221,9 +257,28 @@ func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronCli
 			}
 		}
 	}
+	// Forget the queued runs of periodics that were removed from the config.
+	queued.Delete(queued.Difference(periodics).UnsortedList()...)
 
 	if len(errs) > 0 {
-		return fmt.Errorf("failed to create %d prowjobs: %v", len(errs), errs)
+		return fmt.Errorf("failed to sync %d prowjobs: %v", len(errs), errs)
+	}
+	return nil
+}
+
+// abortAll aborts the active runs a new run of a periodic replaces.
+func abortAll(prowJobClient ctrlruntimeclient.Client, active []prowapi.ProwJob, logger *logrus.Entry) error {
+	for _, pj := range active {
+		if pj.Status.State == prowapi.AbortedState {
+			continue
+		}
+		prevPJ := pj.DeepCopy()
+		pj.Status.State = prowapi.AbortedState
+		pj.Status.Description = "Aborted by horologium, replaced by a newer run."
+		logger.WithFields(pjutil.ProwJobFields(&pj)).Info("Aborting run replaced by a newer run.")
+		if err := prowJobClient.Patch(context.TODO(), &pj, ctrlruntimeclient.MergeFrom(prevPJ)); err != nil {
+			return fmt.Errorf("failed to abort %s: %w", pj.Name, err)
+		}
 	}
 	return nil
 }
@@ -19,6 +19,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -141,7 +142,7 @@ func TestSync(t *testing.T) {
 		}
 		fakeProwJobClient := newCreateTrackingClient(jobs)
 		fc := &fakeCron{}
-		if err := sync(fakeProwJobClient, &cfg, fc, now); err != nil {
+		if err := sync(fakeProwJobClient, &cfg, fc, sets.New[string](), now); err != nil {
 			t.Fatalf("For case %s, didn't expect error: %v", tc.testName, err)
 		}
 
@@ -253,7 +254,7 @@ func TestSyncMinimumInterval(t *testing.T) {
 		}
 		fakeProwJobClient := newCreateTrackingClient(jobs)
 		fc := &fakeCron{}
-		if err := sync(fakeProwJobClient, &cfg, fc, now); err != nil {
+		if err := sync(fakeProwJobClient, &cfg, fc, sets.New[string](), now); err != nil {
 			t.Fatalf("For case %s, didn't expect error: %v", tc.testName, err)
 		}
 
@@ -336,7 +337,7 @@ func TestSyncCron(t *testing.T) {
 		}
 		fakeProwJobClient := newCreateTrackingClient(jobs)
 		fc := &fakeCron{}
-		if err := sync(fakeProwJobClient, &cfg, fc, now); err != nil {
+		if err := sync(fakeProwJobClient, &cfg, fc, sets.New[string](), now); err != nil {
 			t.Fatalf("For case %s, didn't expect error: %v", tc.testName, err)
 		}
 
@@ -356,6 +357,158 @@ func TestSyncCron(t *testing.T) {
 	}
 }
 
+func TestSyncConcurrencyPolicy(t *testing.T) {
+	testcases := []struct {
+		name           string
+		policy         config.PeriodicConcurrencyPolicy
+		maxConcurrency int
+		activeRuns     int
+		cronTriggered  bool
+		queued         bool
+
+		shouldStart   bool
+		shouldAbort   bool
+		shouldBeQueue bool
+	}{
+		{
+			name:          "skip: previous run is active",
+			cronTriggered: true,
+			activeRuns:    1,
+		},
+		{
+			name:          "skip: previous run completed",
+			cronTriggered: true,
+			shouldStart:   true,
+		},
+		{
+			name:          "queue: previous run is active",
+			policy:        config.PeriodicConcurrencyQueue,
+			cronTriggered: true,
+			activeRuns:    1,
+			shouldBeQueue: true,
+		},
+		{
+			name:          "queue: queued run waits for the previous run",
+			policy:        config.PeriodicConcurrencyQueue,
+			queued:        true,
+			activeRuns:    1,
+			shouldBeQueue: true,
+		},
+		{
+			name:        "queue: queued run starts once the previous run completed",
+			policy:      config.PeriodicConcurrencyQueue,
+			queued:      true,
+			shouldStart: true,
+		},
+		{
+			name:          "replace: previous run is active",
+			policy:        config.PeriodicConcurrencyReplace,
+			cronTriggered: true,
+			activeRuns:    1,
+			shouldStart:   true,
+			shouldAbort:   true,
+		},
+		{
+			name:          "allow: no max_concurrency",
+			policy:        config.PeriodicConcurrencyAllow,
+			cronTriggered: true,
+			activeRuns:    2,
+			shouldStart:   true,
+		},
+		{
+			name:           "allow: below max_concurrency",
+			policy:         config.PeriodicConcurrencyAllow,
+			maxConcurrency: 2,
+			cronTriggered:  true,
+			activeRuns:     1,
+			shouldStart:    true,
+		},
+		{
+			name:           "allow: max_concurrency is reached",
+			policy:         config.PeriodicConcurrencyAllow,
+			maxConcurrency: 2,
+			cronTriggered:  true,
+			activeRuns:     2,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Config{
+				ProwConfig: config.ProwConfig{
+					ProwJobNamespace: "prowjobs",
+				},
+				JobConfig: config.JobConfig{
+					Periodics: []config.Periodic{{
+						JobBase:           config.JobBase{Name: "j", MaxConcurrency: tc.maxConcurrency},
+						Cron:              "@every 1m",
+						ConcurrencyPolicy: tc.policy,
+					}},
+				},
+			}
+
+			now := time.Now()
+			complete := metav1.NewTime(now.Add(-time.Minute))
+			jobs := []client.Object{&prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "complete", Namespace: "prowjobs"},
+				Spec:       prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "j"},
+				Status: prowapi.ProwJobStatus{
+					State:          prowapi.SuccessState,
+					StartTime:      metav1.NewTime(now.Add(-time.Hour)),
+					CompletionTime: &complete,
+				},
+			}}
+			for i := 0; i < tc.activeRuns; i++ {
+				jobs = append(jobs, &prowapi.ProwJob{
+					ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("active-%d", i), Namespace: "prowjobs"},
+					Spec:       prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Job: "j"},
+					Status: prowapi.ProwJobStatus{
+						State:     prowapi.PendingState,
+						StartTime: metav1.NewTime(now.Add(-time.Duration(i+1) * time.Second)),
+					},
+				})
+			}
+			fakeProwJobClient := newCreateTrackingClient(jobs)
+			fc := &fakeCron{}
+			if tc.cronTriggered {
+				fc.jobs = []string{"j"}
+			}
+			queued := sets.New[string]()
+			if tc.queued {
+				queued.Insert("j")
+			}
+			if err := sync(fakeProwJobClient, &cfg, &staticCron{fc}, queued, now); err != nil {
+				t.Fatalf("didn't expect error: %v", err)
+			}
+
+			if tc.shouldStart != fakeProwJobClient.sawCreate {
+				t.Errorf("expected a new run: %t, got: %t", tc.shouldStart, fakeProwJobClient.sawCreate)
+			}
+			if tc.shouldBeQueue != queued.Has("j") {
+				t.Errorf("expected the run to be queued: %t, got: %t", tc.shouldBeQueue, queued.Has("j"))
+			}
+			for i := 0; i < tc.activeRuns; i++ {
+				var pj prowapi.ProwJob
+				if err := fakeProwJobClient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "prowjobs", Name: fmt.Sprintf("active-%d", i)}, &pj); err != nil {
+					t.Fatalf("failed to get active run: %v", err)
+				}
+				if aborted := pj.Status.State == prowapi.AbortedState; aborted != tc.shouldAbort {
+					t.Errorf("expected %s to be aborted: %t, got: %t", pj.Name, tc.shouldAbort, aborted)
+				}
+			}
+		})
+	}
+}
+
+// staticCron queues the jobs of the wrapped fakeCron without adding the ones of
+// the config.
+type staticCron struct {
+	*fakeCron
+}
+
+func (sc *staticCron) SyncConfig(*config.Config) error {
+	return nil
+}
+
 func TestFlags(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -0,0 +1,331 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// noOrg is the org of jobs that do not clone any repo.
+const noOrg = "(none)"
+
+// digest summarizes the health of the jobs of each org over a window.
+type digest struct {
+	Start time.Time   `json:"start"`
+	End   time.Time   `json:"end"`
+	Orgs  []orgDigest `json:"orgs"`
+}
+
+type orgDigest struct {
+	Org string `json:"org"`
+	// Runs is how many runs started in the window, and Failures how many of
+	// them failed.
+	Runs     int `json:"runs"`
+	Failures int `json:"failures"`
+	// NewFailures are the periodic and postsubmit jobs that failed in their
+	// latest run after passing in the run before it.
+	NewFailures []transition `json:"new_failures,omitempty"`
+	// Recovered are the periodic and postsubmit jobs that passed in their
+	// latest run after failing in the run before it.
+	Recovered []transition `json:"recovered,omitempty"`
+	// Slowest are the jobs that took the longest on average.
+	Slowest []jobDuration `json:"slowest,omitempty"`
+	// Flakes are the presubmits that both failed and passed on the same
+	// commit of a pull request.
+	Flakes []flake `json:"flakes,omitempty"`
+	// Capacity is how much the jobs ran.
+	Capacity capacity `json:"capacity"`
+}
+
+type transition struct {
+	Job  string    `json:"job"`
+	Repo string    `json:"repo,omitempty"`
+	URL  string    `json:"url,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+type jobDuration struct {
+	Job     string        `json:"job"`
+	Repo    string        `json:"repo,omitempty"`
+	Runs    int           `json:"runs"`
+	Average time.Duration `json:"average"`
+	Max     time.Duration `json:"max"`
+}
+
+type flake struct {
+	Job     string `json:"job"`
+	Repo    string `json:"repo,omitempty"`
+	Commits int    `json:"commits"`
+}
+
+type capacity struct {
+	// JobHours is the time the runs spent in the window, in hours.
+	JobHours float64 `json:"job_hours"`
+	// ByCluster is the job hours of each build cluster.
+	ByCluster map[string]float64 `json:"by_cluster,omitempty"`
+}
+
+// jobOrgRepo returns the org and repo the job tests, if any.
+func jobOrgRepo(pj prowapi.ProwJob) (string, string) {
+	refs := pj.Spec.Refs
+	if refs == nil && len(pj.Spec.ExtraRefs) > 0 {
+		refs = &pj.Spec.ExtraRefs[0]
+	}
+	if refs == nil {
+		return noOrg, ""
+	}
+	return refs.Org, refs.OrgRepoString()
+}
+
+// passed returns whether the job ended in a state that tells whether it
+// passed, and if so whether it did.
+func passed(pj prowapi.ProwJob) (bool, bool) {
+	switch pj.Status.State {
+	case prowapi.SuccessState:
+		return true, true
+	case prowapi.FailureState, prowapi.ErrorState:
+		return false, true
+	}
+	return false, false
+}
+
+type jobKey struct {
+	org, job, repo, branch string
+}
+
+type pullKey struct {
+	org, job, repo string
+	number         int
+	sha            string
+}
+
+// summarize builds the digest of the jobs that started in the window ending
+// at end. Earlier runs are only used to tell whether the jobs changed state.
+func summarize(pjs []prowapi.ProwJob, end time.Time, window time.Duration, top int) digest {
+	start := end.Add(-window)
+	sort.SliceStable(pjs, func(i, j int) bool {
+		return pjs[i].Status.StartTime.Before(&pjs[j].Status.StartTime)
+	})
+
+	orgs := map[string]*orgDigest{}
+	org := func(name string) *orgDigest {
+		if orgs[name] == nil {
+			orgs[name] = &orgDigest{Org: name, Capacity: capacity{ByCluster: map[string]float64{}}}
+		}
+		return orgs[name]
+	}
+	// history holds the completed runs of each periodic and postsubmit, by
+	// start time.
+	history := map[jobKey][]prowapi.ProwJob{}
+	durations := map[jobKey][]time.Duration{}
+	pulls := map[pullKey]map[bool]bool{}
+
+	for _, pj := range pjs {
+		orgName, repo := jobOrgRepo(pj)
+		started := pj.Status.StartTime.Time
+		pass, done := passed(pj)
+		if done && (pj.Spec.Type == prowapi.PeriodicJob || pj.Spec.Type == prowapi.PostsubmitJob) {
+			var branch string
+			if pj.Spec.Refs != nil {
+				branch = pj.Spec.Refs.BaseRef
+			}
+			key := jobKey{org: orgName, job: pj.Spec.Job, repo: repo, branch: branch}
+			history[key] = append(history[key], pj)
+		}
+		if started.Before(start) || !started.Before(end) {
+			continue
+		}
+
+		o := org(orgName)
+		o.Runs++
+		if done && !pass {
+			o.Failures++
+		}
+		finished := end
+		if pj.Status.CompletionTime != nil && pj.Status.CompletionTime.Time.Before(end) {
+			finished = pj.Status.CompletionTime.Time
+		}
+		hours := finished.Sub(started).Hours()
+		o.Capacity.JobHours += hours
+		o.Capacity.ByCluster[pj.ClusterAlias()] += hours
+
+		if done {
+			key := jobKey{org: orgName, job: pj.Spec.Job, repo: repo}
+			durations[key] = append(durations[key], pj.Status.CompletionTime.Sub(started))
+		}
+		if done && pj.Spec.Type == prowapi.PresubmitJob && pj.Spec.Refs != nil && len(pj.Spec.Refs.Pulls) == 1 {
+			pull := pj.Spec.Refs.Pulls[0]
+			key := pullKey{org: orgName, job: pj.Spec.Job, repo: repo, number: pull.Number, sha: pull.SHA}
+			if pulls[key] == nil {
+				pulls[key] = map[bool]bool{}
+			}
+			pulls[key][pass] = true
+		}
+	}
+
+	for key, runs := range history {
+		if len(runs) < 2 {
+			continue
+		}
+		latest, previous := runs[len(runs)-1], runs[len(runs)-2]
+		if latest.Status.StartTime.Time.Before(start) || !latest.Status.StartTime.Time.Before(end) {
+			continue
+		}
+		latestPassed, _ := passed(latest)
+		previousPassed, _ := passed(previous)
+		if latestPassed == previousPassed {
+			continue
+		}
+		o := org(key.org)
+		t := transition{Job: key.job, Repo: key.repo, URL: latest.Status.URL, Time: latest.Status.StartTime.Time}
+		if latestPassed {
+			o.Recovered = append(o.Recovered, t)
+		} else {
+			o.NewFailures = append(o.NewFailures, t)
+		}
+	}
+
+	for key, runs := range durations {
+		d := jobDuration{Job: key.job, Repo: key.repo, Runs: len(runs)}
+		var total time.Duration
+		for _, run := range runs {
+			total += run
+			if run > d.Max {
+				d.Max = run
+			}
+		}
+		d.Average = (total / time.Duration(len(runs))).Round(time.Second)
+		o := org(key.org)
+		o.Slowest = append(o.Slowest, d)
+	}
+
+	flakes := map[jobKey]int{}
+	for key, results := range pulls {
+		if results[true] && results[false] {
+			flakes[jobKey{org: key.org, job: key.job, repo: key.repo}]++
+		}
+	}
+	for key, commits := range flakes {
+		o := org(key.org)
+		o.Flakes = append(o.Flakes, flake{Job: key.job, Repo: key.repo, Commits: commits})
+	}
+
+	d := digest{Start: start, End: end}
+	for _, o := range orgs {
+		sortTransitions(o.NewFailures)
+		sortTransitions(o.Recovered)
+		sort.Slice(o.Slowest, func(i, j int) bool {
+			if o.Slowest[i].Average != o.Slowest[j].Average {
+				return o.Slowest[i].Average > o.Slowest[j].Average
+			}
+			return o.Slowest[i].Job < o.Slowest[j].Job
+		})
+		if len(o.Slowest) > top {
+			o.Slowest = o.Slowest[:top]
+		}
+		sort.Slice(o.Flakes, func(i, j int) bool {
+			if o.Flakes[i].Commits != o.Flakes[j].Commits {
+				return o.Flakes[i].Commits > o.Flakes[j].Commits
+			}
+			return o.Flakes[i].Job < o.Flakes[j].Job
+		})
+		if len(o.Flakes) > top {
+			o.Flakes = o.Flakes[:top]
+		}
+		if len(o.Capacity.ByCluster) == 0 {
+			o.Capacity.ByCluster = nil
+		}
+		d.Orgs = append(d.Orgs, *o)
+	}
+	sort.Slice(d.Orgs, func(i, j int) bool { return d.Orgs[i].Org < d.Orgs[j].Org })
+	return d
+}
+
+func sortTransitions(transitions []transition) {
+	sort.Slice(transitions, func(i, j int) bool {
+		if transitions[i].Repo != transitions[j].Repo {
+			return transitions[i].Repo < transitions[j].Repo
+		}
+		return transitions[i].Job < transitions[j].Job
+	})
+}
+
+// writeDigest writes the digest as Markdown, which also reads well as plain
+// text in Slack and emails.
+func writeDigest(w io.Writer, d digest) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Job digest for %s to %s\n", d.Start.UTC().Format(time.RFC3339), d.End.UTC().Format(time.RFC3339))
+	if len(d.Orgs) == 0 {
+		b.WriteString("\nNo jobs ran.\n")
+	}
+	for _, o := range d.Orgs {
+		fmt.Fprintf(&b, "\n## %s\n\n", o.Org)
+		fmt.Fprintf(&b, "%d runs, %d failed, %.1f job hours", o.Runs, o.Failures, o.Capacity.JobHours)
+		if len(o.Capacity.ByCluster) > 0 {
+			var clusters []string
+			for cluster, hours := range o.Capacity.ByCluster {
+				clusters = append(clusters, fmt.Sprintf("%s: %.1f", cluster, hours))
+			}
+			sort.Strings(clusters)
+			fmt.Fprintf(&b, " (%s)", strings.Join(clusters, ", "))
+		}
+		b.WriteString(".\n")
+		writeTransitions(&b, "New failures", o.NewFailures)
+		writeTransitions(&b, "Recovered", o.Recovered)
+		if len(o.Slowest) > 0 {
+			b.WriteString("\n### Slowest jobs\n\n")
+			for _, s := range o.Slowest {
+				fmt.Fprintf(&b, "- %s: %s on average, %s at most over %d runs\n", jobName(s.Job, s.Repo), s.Average, s.Max.Round(time.Second), s.Runs)
+			}
+		}
+		if len(o.Flakes) > 0 {
+			b.WriteString("\n### Top flakes\n\n")
+			for _, f := range o.Flakes {
+				fmt.Fprintf(&b, "- %s: failed and passed on %d commits\n", jobName(f.Job, f.Repo), f.Commits)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeTransitions(b *strings.Builder, title string, transitions []transition) {
+	if len(transitions) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for _, t := range transitions {
+		if t.URL != "" {
+			fmt.Fprintf(b, "- [%s](%s)\n", jobName(t.Job, t.Repo), t.URL)
+		} else {
+			fmt.Fprintf(b, "- %s\n", jobName(t.Job, t.Repo))
+		}
+	}
+}
+
+func jobName(job, repo string) string {
+	if repo == "" {
+		return job
+	}
+	return fmt.Sprintf("%s (%s)", job, repo)
+}
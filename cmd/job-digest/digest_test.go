@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+var now = time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+
+type run struct {
+	job      string
+	jobType  prowapi.ProwJobType
+	refs     *prowapi.Refs
+	state    prowapi.ProwJobState
+	cluster  string
+	started  time.Duration
+	duration time.Duration
+}
+
+func (r run) prowJob() prowapi.ProwJob {
+	pj := prowapi.ProwJob{
+		Spec: prowapi.ProwJobSpec{Type: r.jobType, Job: r.job, Cluster: r.cluster},
+		Status: prowapi.ProwJobStatus{
+			State:     r.state,
+			StartTime: metav1.NewTime(now.Add(-r.started)),
+			URL:       "https://prow.example.com/view/" + r.job,
+		},
+	}
+	if r.jobType == prowapi.PeriodicJob {
+		if r.refs != nil {
+			pj.Spec.ExtraRefs = []prowapi.Refs{*r.refs}
+		}
+	} else {
+		pj.Spec.Refs = r.refs
+	}
+	if r.state != prowapi.PendingState {
+		completed := metav1.NewTime(now.Add(-r.started + r.duration))
+		pj.Status.CompletionTime = &completed
+	}
+	return pj
+}
+
+func TestSummarize(t *testing.T) {
+	repo := &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main"}
+	pull := func(number int, sha string) *prowapi.Refs {
+		return &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", Pulls: []prowapi.Pull{{Number: number, SHA: sha}}}
+	}
+	runs := []run{
+		// Failed again after passing the day before.
+		{job: "ci-new-failure", jobType: prowapi.PeriodicJob, refs: repo, state: prowapi.SuccessState, started: 30 * time.Hour, duration: time.Hour},
+		{job: "ci-new-failure", jobType: prowapi.PeriodicJob, refs: repo, state: prowapi.FailureState, started: 2 * time.Hour, duration: time.Hour},
+		// Passed after failing.
+		{job: "post-recovered", jobType: prowapi.PostsubmitJob, refs: repo, state: prowapi.ErrorState, started: 10 * time.Hour, duration: 30 * time.Minute},
+		{job: "post-recovered", jobType: prowapi.PostsubmitJob, refs: repo, state: prowapi.SuccessState, started: 5 * time.Hour, duration: 30 * time.Minute},
+		// Still failing.
+		{job: "ci-broken", jobType: prowapi.PeriodicJob, state: prowapi.FailureState, started: 20 * time.Hour, duration: 15 * time.Minute},
+		{job: "ci-broken", jobType: prowapi.PeriodicJob, state: prowapi.FailureState, started: 8 * time.Hour, duration: 45 * time.Minute},
+		// Failed and passed on the same commit, and passed on another.
+		{job: "pull-flaky", jobType: prowapi.PresubmitJob, refs: pull(1, "a"), state: prowapi.FailureState, cluster: "build", started: 6 * time.Hour, duration: 15 * time.Minute},
+		{job: "pull-flaky", jobType: prowapi.PresubmitJob, refs: pull(1, "a"), state: prowapi.SuccessState, cluster: "build", started: 5 * time.Hour, duration: 15 * time.Minute},
+		{job: "pull-flaky", jobType: prowapi.PresubmitJob, refs: pull(2, "b"), state: prowapi.SuccessState, cluster: "build", started: 4 * time.Hour, duration: 15 * time.Minute},
+		// Failed on a commit, passed on the next.
+		{job: "pull-fixed", jobType: prowapi.PresubmitJob, refs: pull(3, "c"), state: prowapi.FailureState, cluster: "build", started: 4 * time.Hour, duration: 15 * time.Minute},
+		{job: "pull-fixed", jobType: prowapi.PresubmitJob, refs: pull(3, "d"), state: prowapi.SuccessState, cluster: "build", started: 3 * time.Hour, duration: 15 * time.Minute},
+		// Still running, so only counted in the capacity.
+		{job: "pull-running", jobType: prowapi.PresubmitJob, refs: pull(3, "d"), state: prowapi.PendingState, cluster: "build", started: 15 * time.Minute},
+	}
+	var pjs []prowapi.ProwJob
+	// List the runs out of order.
+	for i := len(runs) - 1; i >= 0; i-- {
+		pjs = append(pjs, runs[i].prowJob())
+	}
+
+	expected := digest{
+		Start: now.Add(-24 * time.Hour),
+		End:   now,
+		Orgs: []orgDigest{
+			{
+				Org:      noOrg,
+				Runs:     2,
+				Failures: 2,
+				Slowest: []jobDuration{
+					{Job: "ci-broken", Runs: 2, Average: 30 * time.Minute, Max: 45 * time.Minute},
+				},
+				Capacity: capacity{JobHours: 1, ByCluster: map[string]float64{"default": 1}},
+			},
+			{
+				Org:      "org",
+				Runs:     9,
+				Failures: 4,
+				NewFailures: []transition{
+					{Job: "ci-new-failure", Repo: "org/repo", URL: "https://prow.example.com/view/ci-new-failure", Time: now.Add(-2 * time.Hour)},
+				},
+				Recovered: []transition{
+					{Job: "post-recovered", Repo: "org/repo", URL: "https://prow.example.com/view/post-recovered", Time: now.Add(-5 * time.Hour)},
+				},
+				Slowest: []jobDuration{
+					{Job: "ci-new-failure", Repo: "org/repo", Runs: 1, Average: time.Hour, Max: time.Hour},
+					{Job: "post-recovered", Repo: "org/repo", Runs: 2, Average: 30 * time.Minute, Max: 30 * time.Minute},
+				},
+				Flakes: []flake{
+					{Job: "pull-flaky", Repo: "org/repo", Commits: 1},
+				},
+				Capacity: capacity{JobHours: 3.5, ByCluster: map[string]float64{"default": 2, "build": 1.5}},
+			},
+		},
+	}
+	actual := summarize(pjs, now, 24*time.Hour, 2)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected digest (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteDigest(t *testing.T) {
+	d := digest{
+		Start: now.Add(-24 * time.Hour),
+		End:   now,
+		Orgs: []orgDigest{
+			{
+				Org:         "org",
+				Runs:        3,
+				Failures:    1,
+				NewFailures: []transition{{Job: "ci-new-failure", Repo: "org/repo", URL: "https://prow.example.com/view/1"}},
+				Recovered:   []transition{{Job: "ci-recovered"}},
+				Slowest:     []jobDuration{{Job: "ci-slow", Repo: "org/repo", Runs: 2, Average: 90 * time.Minute, Max: 2 * time.Hour}},
+				Flakes:      []flake{{Job: "pull-flaky", Repo: "org/repo", Commits: 4}},
+				Capacity:    capacity{JobHours: 5.25, ByCluster: map[string]float64{"default": 1.25, "build": 4}},
+			},
+		},
+	}
+	expected := `# Job digest for 2024-03-01T12:00:00Z to 2024-03-02T12:00:00Z
+
+## org
+
+3 runs, 1 failed, 5.2 job hours (build: 4.0, default: 1.2).
+
+### New failures
+
+- [ci-new-failure (org/repo)](https://prow.example.com/view/1)
+
+### Recovered
+
+- ci-recovered
+
+### Slowest jobs
+
+- ci-slow (org/repo): 1h30m0s on average, 2h0m0s at most over 2 runs
+
+### Top flakes
+
+- pull-flaky (org/repo): failed and passed on 4 commits
+`
+	var actual strings.Builder
+	if err := writeDigest(&actual, d); err != nil {
+		t.Fatalf("failed to write digest: %v", err)
+	}
+	if diff := cmp.Diff(expected, actual.String()); diff != "" {
+		t.Errorf("unexpected digest (-want +got):\n%s", diff)
+	}
+}
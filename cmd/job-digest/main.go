@@ -0,0 +1,203 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// job-digest summarizes the health of the jobs of each org over the last day
+// from the ProwJobs in the cluster: new failures, recovered jobs, the slowest
+// jobs, the top flakes and how much the jobs ran. The digest is written as an
+// artifact and optionally posted to Slack or sent by email.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/prow/pkg/config/secret"
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/slack"
+)
+
+type options struct {
+	config     configflagutil.ConfigOptions
+	kubernetes prowflagutil.KubernetesOptions
+	storage    prowflagutil.StorageClientOptions
+
+	window    time.Duration
+	top       int
+	outputDir string
+
+	slackTokenFile string
+	slackChannel   string
+
+	smtpServer       string
+	smtpPasswordFile string
+	emailFrom        string
+	emailTo          prowflagutil.Strings
+}
+
+func (o *options) Validate() error {
+	for _, fs := range []interface{ Validate(bool) error }{&o.config, &o.kubernetes, &o.storage} {
+		if err := fs.Validate(false); err != nil {
+			return err
+		}
+	}
+	if o.window <= 0 {
+		return errors.New("--window must be positive")
+	}
+	if o.top < 1 {
+		return errors.New("--top must be positive")
+	}
+	if (o.slackTokenFile == "") != (o.slackChannel == "") {
+		return errors.New("--slack-token-file and --slack-channel must be set together")
+	}
+	if len(o.emailTo.Strings()) > 0 && (o.smtpServer == "" || o.emailFrom == "") {
+		return errors.New("--smtp-server and --email-from must be set to send the digest by email")
+	}
+	return nil
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	var o options
+	fs.DurationVar(&o.window, "window", 24*time.Hour, "Period summarized by the digest, ending now.")
+	fs.IntVar(&o.top, "top", 10, "Number of the slowest jobs and of the top flakes listed for each org.")
+	fs.StringVar(&o.outputDir, "output-dir", "", "Directory or bucket path, e.g. gs://bucket/digests, to write the digest to as <date>.json and <date>.md. Defaults to printing it.")
+	fs.StringVar(&o.slackTokenFile, "slack-token-file", "", "Path to the file containing the Slack token to post the digest with.")
+	fs.StringVar(&o.slackChannel, "slack-channel", "", "Slack channel to post the digest to.")
+	fs.StringVar(&o.smtpServer, "smtp-server", "", "Address of the SMTP server to send the digest by email with, in the host:port format.")
+	fs.StringVar(&o.smtpPasswordFile, "smtp-password-file", "", "Path to the file containing the password of --email-from on the SMTP server, if it requires authentication.")
+	fs.StringVar(&o.emailFrom, "email-from", "", "Address to send the digest by email from.")
+	fs.Var(&o.emailTo, "email-to", "Address to send the digest by email to. Can be passed multiple times.")
+	o.config.AddFlags(fs)
+	o.kubernetes.AddFlags(fs)
+	o.storage.AddFlags(fs)
+	fs.Parse(args)
+	return o
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	ca, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to load --config-path=%s", o.config.ConfigPath)
+	}
+	cfg := ca.Config()
+
+	var secrets []string
+	if o.slackTokenFile != "" {
+		secrets = append(secrets, o.slackTokenFile)
+	}
+	if o.smtpPasswordFile != "" {
+		secrets = append(secrets, o.smtpPasswordFile)
+	}
+	if err := secret.Add(secrets...); err != nil {
+		logrus.WithError(err).Fatal("Error starting secrets agent.")
+	}
+
+	pjClient, err := o.kubernetes.ProwJobClient(cfg.ProwJobNamespace, false)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting ProwJob client.")
+	}
+	ctx := context.Background()
+	pjs, err := pjClient.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to list ProwJobs.")
+	}
+
+	d := summarize(pjs.Items, time.Now(), o.window, o.top)
+	var text bytes.Buffer
+	if err := writeDigest(&text, d); err != nil {
+		logrus.WithError(err).Fatal("Failed to write the digest.")
+	}
+
+	var errs []error
+	if o.outputDir == "" {
+		fmt.Print(text.String())
+	} else if err := writeArtifacts(ctx, o, d, text.Bytes()); err != nil {
+		errs = append(errs, err)
+	}
+	if o.slackChannel != "" {
+		if err := slack.NewClient(secret.GetTokenGenerator(o.slackTokenFile)).WriteMessage(text.String(), o.slackChannel); err != nil {
+			errs = append(errs, fmt.Errorf("failed to post the digest to Slack: %w", err))
+		}
+	}
+	if len(o.emailTo.Strings()) > 0 {
+		if err := sendEmail(o, d, text.Bytes()); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send the digest by email: %w", err))
+		}
+	}
+	if n := len(errs); n > 0 {
+		for i, err := range errs {
+			logrus.WithError(err).Error(i)
+		}
+		logrus.Fatalf("Encountered %d errors publishing the digest", n)
+	}
+}
+
+// writeArtifacts writes the digest to the output directory, named after the
+// day it ends on.
+func writeArtifacts(ctx context.Context, o options, d digest, text []byte) error {
+	opener, err := o.storage.StorageClient(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the digest: %w", err)
+	}
+	prefix := strings.TrimSuffix(o.outputDir, "/") + "/" + d.End.UTC().Format("2006-01-02")
+	log := logrus.WithField("output-dir", o.outputDir)
+	if err := io.WriteContent(ctx, log, opener, prefix+".json", data); err != nil {
+		return fmt.Errorf("failed to write %s.json: %w", prefix, err)
+	}
+	if err := io.WriteContent(ctx, log, opener, prefix+".md", text); err != nil {
+		return fmt.Errorf("failed to write %s.md: %w", prefix, err)
+	}
+	return nil
+}
+
+func sendEmail(o options, d digest, text []byte) error {
+	var auth smtp.Auth
+	if o.smtpPasswordFile != "" {
+		host, _, _ := strings.Cut(o.smtpServer, ":")
+		auth = smtp.PlainAuth("", o.emailFrom, string(secret.GetSecret(o.smtpPasswordFile)), host)
+	}
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", o.emailFrom)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(o.emailTo.Strings(), ", "))
+	fmt.Fprintf(&message, "Subject: Job digest for %s\r\n", d.End.UTC().Format("2006-01-02"))
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	message.Write(text)
+	return smtp.SendMail(o.smtpServer, auth, o.emailFrom, o.emailTo.Strings(), message.Bytes())
+}
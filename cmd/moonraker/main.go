@@ -185,6 +185,7 @@ func main() {
 	mux := http.NewServeMux()
 	mux.HandleFunc(fmt.Sprintf("/%s", moonraker.PathPing), mr.ServePing)
 	mux.HandleFunc(fmt.Sprintf("/%s", moonraker.PathGetInrepoconfig), mr.ServeGetInrepoconfig)
+	mux.HandleFunc(fmt.Sprintf("/%s", moonraker.PathConfigPreview), mr.ServeConfigPreview)
 	server := &http.Server{
 		Addr:    ":" + strconv.Itoa(o.port),
 		Handler: mux,
@@ -194,6 +195,7 @@ func main() {
 	health.ServeReady(func() bool {
 		return true
 	})
+	health.ServeConfigHash(configAgent.Config)
 	interrupts.WaitForGracefulShutdown()
 }
 
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/metrics"
+	"sigs.k8s.io/prow/pkg/namespaceprovisioner"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/pjutil/pprof"
+)
+
+type options struct {
+	config configflagutil.ConfigOptions
+
+	kubernetes             prowflagutil.KubernetesOptions
+	instrumentationOptions prowflagutil.InstrumentationOptions
+
+	dryRun bool
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	var o options
+	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether or not to make mutating API calls to Kubernetes.")
+	for _, group := range []prowflagutil.OptionGroup{&o.config, &o.kubernetes, &o.instrumentationOptions} {
+		group.AddFlags(fs)
+	}
+	fs.Parse(args)
+	return o
+}
+
+func (o *options) Validate() error {
+	for _, group := range []prowflagutil.OptionGroup{&o.kubernetes, &o.config, &o.instrumentationOptions} {
+		if err := group.Validate(o.dryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	defer interrupts.WaitForGracefulShutdown()
+
+	pprof.Instrument(o.instrumentationOptions)
+	health := pjutil.NewHealthOnPort(o.instrumentationOptions.HealthPort)
+
+	configAgent, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error starting config agent.")
+	}
+	cfg := configAgent.Config
+	o.kubernetes.SetDisabledClusters(sets.New[string](cfg().DisabledClusters...))
+
+	infrastructureClusterConfig, err := o.kubernetes.InfrastructureClusterConfig(o.dryRun)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting config for infrastructure cluster.")
+	}
+	secretClient, err := ctrlruntimeclient.New(infrastructureClusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting infrastructure cluster client.")
+	}
+	buildClusterClients, err := o.kubernetes.BuildClusterUncachedRuntimeClients(o.dryRun)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to construct some build cluster clients, their namespaces are not provisioned.")
+	}
+
+	metrics.ExposeMetrics("namespace-provisioner", cfg().PushGateway, o.instrumentationOptions.MetricsPort)
+
+	c := namespaceprovisioner.NewController(buildClusterClients, secretClient, cfg)
+	interrupts.TickLiteral(func() {
+		start := time.Now()
+		if err := c.Sync(interrupts.Context()); err != nil {
+			logrus.WithError(err).Error("Error provisioning namespaces.")
+		}
+		logrus.WithField("duration", time.Since(start).String()).Info("Synced build clusters.")
+	}, cfg().NamespaceProvisioner.GetResyncPeriod())
+
+	health.ServeReady()
+}
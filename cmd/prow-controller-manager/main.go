@@ -220,6 +220,7 @@ func main() {
 	metrics.ExposeMetrics("plank", cfg().PushGateway, o.instrumentationOptions.MetricsPort)
 	// Serve readiness endpoint
 	health.ServeReady()
+	health.ServeConfigHash(cfg)
 
 	if err := mgr.Start(interrupts.Context()); err != nil {
 		logrus.WithError(err).Fatal("failed to start manager")
@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// prow is a command line tool for Prow contributors.
+//
+// Usage:
+//
+//	prow plugin new [--external] [--root=<repo root>] <name>
+//	prow release-branch create --branch=<branch> [--create-pr] [flags]
+//	prow job rehydrate [--pin-refs] [--pin-images] [--report] <artifacts path>
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/prow/cmd/generic-autobumper/bumper"
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/prow/pkg/plugins/scaffold"
+	"sigs.k8s.io/prow/pkg/rehydrate"
+	"sigs.k8s.io/prow/pkg/releasebranch"
+)
+
+const usage = `usage:
+  prow plugin new [--external] [--root=<repo root>] <name>
+  prow release-branch create --branch=<branch> [--create-pr] [flags]
+  prow job rehydrate [--pin-refs] [--pin-images] [--report] <artifacts path>`
+
+func gatherPluginNewOptions(args []string) (scaffold.Options, error) {
+	o := scaffold.Options{}
+	fs := flag.NewFlagSet("prow plugin new", flag.ContinueOnError)
+	fs.BoolVar(&o.External, "external", false, "Generate an external plugin under cmd/external-plugins instead of an in-tree plugin under pkg/plugins.")
+	fs.StringVar(&o.Root, "root", ".", "Root of the prow repository to generate the plugin in.")
+	// Allow the name to come before the flags.
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		o.Name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	if o.Name == "" && fs.NArg() > 0 {
+		o.Name = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		return o, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if o.Name == "" {
+		return o, errors.New("the plugin name is required")
+	}
+	return o, o.Validate()
+}
+
+type releaseBranchOptions struct {
+	releasebranch.Options
+	configPath    string
+	jobConfigPath string
+	rulesPath     string
+	createPR      bool
+	bumper        bumper.Options
+}
+
+func gatherReleaseBranchCreateOptions(args []string) (releaseBranchOptions, error) {
+	o := releaseBranchOptions{}
+	fs := flag.NewFlagSet("prow release-branch create", flag.ContinueOnError)
+	fs.StringVar(&o.Branch, "branch", "", "Name of the new release branch.")
+	fs.StringVar(&o.SourceBranch, "source-branch", "master", "Branch the release branch is cut from.")
+	fs.StringVar(&o.configPath, "config-path", "config/prow/config.yaml", "Path to the Prow config to add branch protection and Tide queries for the release branch to. Leave empty to only fork jobs.")
+	fs.StringVar(&o.jobConfigPath, "job-config-path", "config/jobs", "Path to the job configs to fork.")
+	fs.StringVar(&o.rulesPath, "rules", "", "Path to a YAML file with the rules for forking jobs.")
+	fs.BoolVar(&o.createPR, "create-pr", false, "Commit the changes, push them to the fork and create or update a pull request.")
+	fs.StringVar(&o.bumper.GitHubToken, "github-token-path", "", "Path to the GitHub token used to push the changes and create the pull request.")
+	fs.StringVar(&o.bumper.GitHubOrg, "github-org", "", "GitHub org of the config repository.")
+	fs.StringVar(&o.bumper.GitHubRepo, "github-repo", "", "GitHub repo of the config repository.")
+	fs.StringVar(&o.bumper.GitHubBaseBranch, "github-base-branch", "", "Branch of the config repository to create the pull request against. Defaults to its default branch.")
+	fs.StringVar(&o.bumper.GitHubLogin, "github-login", "", "GitHub login that owns the fork. Defaults to the owner of the token.")
+	fs.StringVar(&o.bumper.RemoteName, "remote-name", "", "Name of the fork of the config repository.")
+	fs.StringVar(&o.bumper.HeadBranchName, "head-branch", "", "Branch of the fork to push the changes to. Defaults to release-branch-<branch>.")
+	fs.StringVar(&o.bumper.GitName, "git-name", "", "Name to commit as. Defaults to the name of the owner of the token.")
+	fs.StringVar(&o.bumper.GitEmail, "git-email", "", "Email to commit as. Defaults to the email of the owner of the token.")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	if fs.NArg() > 0 {
+		return o, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if o.rulesPath != "" {
+		rules, err := releasebranch.LoadRules(o.rulesPath)
+		if err != nil {
+			return o, err
+		}
+		o.Rules = rules
+	}
+	if o.bumper.HeadBranchName == "" {
+		o.bumper.HeadBranchName = "release-branch-" + o.Branch
+	}
+	return o, o.Validate()
+}
+
+// releaseBranchPR creates the release branch changes in a single commit.
+type releaseBranchPR struct {
+	o     releaseBranchOptions
+	paths []string
+}
+
+func (p *releaseBranchPR) Changes() []func(context.Context) (string, error) {
+	return []func(context.Context) (string, error){
+		func(context.Context) (string, error) {
+			paths, err := releasebranch.Apply(p.o.configPath, p.o.jobConfigPath, p.o.Options)
+			p.paths = paths
+			return fmt.Sprintf("Create release branch %s", p.o.Branch), err
+		},
+	}
+}
+
+func (p *releaseBranchPR) PRTitleBody() (string, string) {
+	body := fmt.Sprintf("Fork the jobs running against `%s` for the new release branch `%s` and extend branch protection and Tide to it.\n\nUpdated files:\n", p.o.SourceBranch, p.o.Branch)
+	for _, path := range p.paths {
+		body += fmt.Sprintf("- `%s`\n", path)
+	}
+	return fmt.Sprintf("Create release branch %s", p.o.Branch), body
+}
+
+func releaseBranchCreate(args []string) {
+	o, err := gatherReleaseBranchCreateOptions(args)
+	if err != nil {
+		logrus.WithError(err).Fatal(usage)
+	}
+	if o.createPR {
+		if err := bumper.Run(context.Background(), &o.bumper, &releaseBranchPR{o: o}); err != nil {
+			logrus.WithError(err).Fatal("Failed to create the release branch pull request.")
+		}
+		return
+	}
+	paths, err := releasebranch.Apply(o.configPath, o.jobConfigPath, o.Options)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create the release branch.")
+	}
+	fmt.Println(strings.Join(paths, "\n"))
+}
+
+func main() {
+	logrusutil.ComponentInit()
+	if len(os.Args) < 3 {
+		logrus.Fatal(usage)
+	}
+	switch os.Args[1] + " " + os.Args[2] {
+	case "plugin new":
+		pluginNew(os.Args[3:])
+	case "release-branch create":
+		releaseBranchCreate(os.Args[3:])
+	case "job rehydrate":
+		jobRehydrate(os.Args[3:])
+	default:
+		logrus.Fatal(usage)
+	}
+}
+
+func pluginNew(args []string) {
+	o, err := gatherPluginNewOptions(args)
+	if err != nil {
+		logrus.WithError(err).Fatal(usage)
+	}
+	paths, err := scaffold.Generate(o)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to generate the plugin.")
+	}
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+}
+
+type jobRehydrateOptions struct {
+	rehydrate.Options
+	storage prowflagutil.StorageClientOptions
+	dir     string
+}
+
+func gatherJobRehydrateOptions(args []string) (jobRehydrateOptions, error) {
+	o := jobRehydrateOptions{}
+	fs := flag.NewFlagSet("prow job rehydrate", flag.ContinueOnError)
+	fs.BoolVar(&o.PinRefs, "pin-refs", false, "Check out the commits the run tested instead of the current heads of its base refs.")
+	fs.BoolVar(&o.PinImages, "pin-images", false, "Run the images the run ran, by digest, instead of their current versions.")
+	fs.BoolVar(&o.Report, "report", false, "Report the result of the job like the original run, e.g. to its pull request.")
+	o.storage.AddFlags(fs)
+	// Allow the path to come before the flags.
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		o.dir, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	if o.dir == "" && fs.NArg() > 0 {
+		o.dir, args = fs.Arg(0), fs.Args()[1:]
+	} else {
+		args = fs.Args()
+	}
+	if len(args) > 0 {
+		return o, fmt.Errorf("unexpected arguments: %v", args)
+	}
+	if o.dir == "" {
+		return o, errors.New("the path of the artifacts of the run is required")
+	}
+	return o, nil
+}
+
+func jobRehydrate(args []string) {
+	o, err := gatherJobRehydrateOptions(args)
+	if err != nil {
+		logrus.WithError(err).Fatal(usage)
+	}
+	ctx := context.Background()
+	opener, err := o.storage.StorageClient(ctx)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create the storage client.")
+	}
+	pj, err := rehydrate.Rehydrate(ctx, opener, o.dir, o.Options)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to rehydrate the job.")
+	}
+	b, err := yaml.Marshal(pj)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error marshalling YAML.")
+	}
+	fmt.Print(string(b))
+}
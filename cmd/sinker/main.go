@@ -122,6 +122,10 @@ func main() {
 
 	metrics.ExposeMetrics("sinker", cfg().PushGateway, o.instrumentationOptions.MetricsPort)
 
+	health := pjutil.NewHealthOnPort(o.instrumentationOptions.HealthPort)
+	health.ServeReady()
+	health.ServeConfigHash(cfg)
+
 	ctrlruntimelog.SetLogger(zap.New(zap.JSONEncoder()))
 
 	infrastructureClusterConfig, err := o.kubernetes.InfrastructureClusterConfig(o.dryRun)
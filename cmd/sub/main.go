@@ -120,6 +120,7 @@ func main() {
 		Metrics:       promMetrics,
 		ProwJobClient: prowjobClient,
 		Reporter:      pubsub.NewReporter(configAgent.Config), // reuse crier reporter
+		Replier:       subscriber.NewReplier(),
 	}
 
 	if o.config.MoonrakerAddress != "" {
@@ -36,9 +36,12 @@ import (
 	"sigs.k8s.io/prow/pkg/flagutil"
 	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
 	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	pluginsflagutil "sigs.k8s.io/prow/pkg/flagutil/plugins"
 	"sigs.k8s.io/prow/pkg/interrupts"
 	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/metrics"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/plugins"
 	"sigs.k8s.io/prow/pkg/tide"
 )
 
@@ -51,6 +54,9 @@ type options struct {
 	port int
 
 	config configflagutil.ConfigOptions
+	// pluginsConfig is optional, it provides the approve options and OWNERS
+	// settings used when Tide requires OWNERS approval.
+	pluginsConfig pluginsflagutil.PluginOptions
 
 	syncThrottle   int
 	statusThrottle int
@@ -87,7 +93,7 @@ type options struct {
 }
 
 func (o *options) Validate() error {
-	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.storage, &o.config, &o.controllerManager} {
+	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.storage, &o.config, &o.pluginsConfig, &o.controllerManager} {
 		if err := group.Validate(o.dryRun); err != nil {
 			return err
 		}
@@ -111,7 +117,7 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether to mutate any real-world state.")
 	fs.BoolVar(&o.runOnce, "run-once", false, "If true, run only once then quit.")
 	o.github.AddCustomizedFlags(fs, prowflagutil.DisableThrottlerOptions())
-	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.storage, &o.instrumentationOptions, &o.config, &o.gerrit} {
+	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.storage, &o.instrumentationOptions, &o.config, &o.pluginsConfig, &o.gerrit} {
 		group.AddFlags(fs)
 	}
 	fs.IntVar(&o.syncThrottle, "sync-hourly-tokens", 800, "The maximum number of tokens per hour to be used by the sync controller.")
@@ -203,6 +209,13 @@ func main() {
 		githubSync.Throttle(o.syncThrottle, 3*tokensPerIteration(o.syncThrottle, cfg().Tide.SyncPeriod.Duration))
 		githubStatus.Throttle(o.statusThrottle, o.statusThrottle/2)
 
+		var pluginAgent *plugins.ConfigAgent
+		if o.pluginsConfig.PluginConfigPath != "" {
+			if pluginAgent, err = o.pluginsConfig.PluginAgent(); err != nil {
+				logrus.WithError(err).Fatal("Error loading plugin config.")
+			}
+		}
+
 		c, err = tide.NewController(
 			githubSync,
 			githubStatus,
@@ -215,6 +228,7 @@ func main() {
 			o.statusURI,
 			nil,
 			o.github.AppPrivateKeyPath != "",
+			pluginAgent,
 		)
 		if err != nil {
 			logrus.WithError(err).Fatal("Error creating Tide controller.")
@@ -279,6 +293,10 @@ func main() {
 	// serve data
 	interrupts.ListenAndServe(server, 10*time.Second)
 
+	health := pjutil.NewHealthOnPort(o.instrumentationOptions.HealthPort)
+	health.ServeReady()
+	health.ServeConfigHash(cfg)
+
 	// run the controller, but only after one sync period expires after our first run
 	time.Sleep(time.Until(start.Add(cfg().Tide.SyncPeriod.Duration)))
 	interrupts.Tick(func() {
@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/flagutil"
 	configflagutil "sigs.k8s.io/prow/pkg/flagutil/config"
+	pluginsflagutil "sigs.k8s.io/prow/pkg/flagutil/plugins"
 )
 
 func Test_gatherOptions(t *testing.T) {
@@ -105,6 +106,9 @@ func Test_gatherOptions(t *testing.T) {
 					SupplementalProwConfigsFileNameSuffix: "_prowconfig.yaml",
 					InRepoConfigCacheSize:                 200,
 				},
+				pluginsConfig: pluginsflagutil.PluginOptions{
+					SupplementalPluginsConfigsFileNameSuffix: "_pluginconfig.yaml",
+				},
 				dryRun:                 true,
 				syncThrottle:           800,
 				statusThrottle:         400,
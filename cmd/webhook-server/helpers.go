@@ -436,7 +436,6 @@ func reconcileWebhooks(ctx context.Context, caPem string, cl ctrlruntimeclient.C
 func (wa *webhookAgent) fetchClusters(d time.Duration, ctx context.Context, statuses *map[string]plank.ClusterStatus, configAgent *config.Agent) error {
 	ticker := time.NewTicker(d)
 	defer ticker.Stop()
-	cfg := configAgent.Config()
 	opener, err := io.NewOpener(context.Background(), wa.storage.GCSCredentialsFile, wa.storage.S3CredentialsFile)
 	if err != nil {
 		return err
@@ -447,7 +446,7 @@ func (wa *webhookAgent) fetchClusters(d time.Duration, ctx context.Context, stat
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			if location := cfg.Plank.BuildClusterStatusFile; location != "" {
+			if location := configAgent.Config().Plank.BuildClusterStatusFile; location != "" {
 				reader, err := opener.Reader(context.Background(), location)
 				if err != nil {
 					if !io.IsNotExist(err) {
@@ -77,7 +77,7 @@ type webhookAgent struct {
 	storage  prowflagutil.StorageClientOptions
 	statuses map[string]plank.ClusterStatus
 	mu       sync.Mutex
-	plank    config.Plank
+	config   config.Getter
 }
 
 func (o *options) DefaultAndValidate() error {
@@ -172,11 +172,10 @@ func main() {
 	if err != nil {
 		logrus.WithError(err).Fatal("could not create config agent")
 	}
-	cfg := configAgent.Config()
 	wa := &webhookAgent{
 		storage:  o.storage,
 		statuses: statuses,
-		plank:    cfg.Plank,
+		config:   configAgent.Config,
 	}
 	interrupts.Run(func(ctx context.Context) {
 		wa.fetchClusters(time.Duration(o.time*int(time.Minute)), ctx, &wa.statuses, configAgent)
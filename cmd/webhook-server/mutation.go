@@ -55,7 +55,7 @@ func (wa *webhookAgent) serveMutate(w http.ResponseWriter, r *http.Request) {
 	}
 	var mutatedProwJobPatch []byte
 	if admissionRequest.Operation == "CREATE" {
-		mutatedProwJobPatch, err = generateMutatingPatch(&prowJob, wa.plank)
+		mutatedProwJobPatch, err = generateMutatingPatch(&prowJob, wa.config().Plank)
 		if err != nil {
 			logrus.WithError(err).Info("unable to return mutated prowjob patch")
 			http.Error(w, fmt.Sprintf("unable to return mutated prowjob patch %v", err), http.StatusInternalServerError)
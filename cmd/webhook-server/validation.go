@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/plank"
 )
@@ -66,6 +67,8 @@ func (wa *webhookAgent) serveValidate(w http.ResponseWriter, r *http.Request) {
 	if admissionRequest.Operation == "CREATE" {
 		if err := validateProwJobClusterOnCreate(prowJob, wa.statuses); err != nil {
 			admissionResponse = createValidatingAdmissionResponse(admissionRequest.UID, err)
+		} else if err := validateProwJobTimeoutOnCreate(prowJob, wa.config().Plank); err != nil {
+			admissionResponse = createValidatingAdmissionResponse(admissionRequest.UID, err)
 		} else {
 			admissionResponse = createValidatingAdmissionResponse(admissionRequest.UID, nil)
 		}
@@ -97,6 +100,19 @@ func validateProwJobClusterOnCreate(prowJob v1.ProwJob, statuses map[string]plan
 	return nil
 }
 
+// validateProwJobTimeoutOnCreate rejects ProwJobs whose decoration timeout
+// exceeds the configured maximum. Mutation runs before validation, so the
+// default decoration timeout has already been applied at this point.
+func validateProwJobTimeoutOnCreate(prowJob v1.ProwJob, plank config.Plank) error {
+	if plank.MaxDecorationTimeout == nil || prowJob.Spec.DecorationConfig == nil || prowJob.Spec.DecorationConfig.Timeout == nil {
+		return nil
+	}
+	if timeout := prowJob.Spec.DecorationConfig.Timeout.Duration; timeout > plank.MaxDecorationTimeout.Duration {
+		return fmt.Errorf("%s: decoration timeout %s exceeds the maximum of %s", prowJob.Name, timeout, plank.MaxDecorationTimeout.Duration)
+	}
+	return nil
+}
+
 func createValidatingAdmissionResponse(uid types.UID, err error) *v1beta1.AdmissionResponse {
 	var ar *v1beta1.AdmissionResponse
 	var result *apiv1.Status
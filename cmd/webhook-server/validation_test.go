@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestValidateProwJobTimeoutOnCreate(t *testing.T) {
+	withTimeout := func(d time.Duration) v1.ProwJob {
+		return v1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "job"},
+			Spec: v1.ProwJobSpec{
+				DecorationConfig: &v1.DecorationConfig{Timeout: &v1.Duration{Duration: d}},
+			},
+		}
+	}
+	max := config.Plank{MaxDecorationTimeout: &metav1.Duration{Duration: 2 * time.Hour}}
+	tests := []struct {
+		name    string
+		prowJob v1.ProwJob
+		plank   config.Plank
+		wantErr bool
+	}{
+		{
+			name:    "no maximum configured",
+			prowJob: withTimeout(24 * time.Hour),
+		},
+		{
+			name:    "undecorated job",
+			prowJob: v1.ProwJob{},
+			plank:   max,
+		},
+		{
+			name:    "decorated job without timeout",
+			prowJob: v1.ProwJob{Spec: v1.ProwJobSpec{DecorationConfig: &v1.DecorationConfig{}}},
+			plank:   max,
+		},
+		{
+			name:    "timeout at the maximum",
+			prowJob: withTimeout(2 * time.Hour),
+			plank:   max,
+		},
+		{
+			name:    "timeout above the maximum",
+			prowJob: withTimeout(3 * time.Hour),
+			plank:   max,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateProwJobTimeoutOnCreate(tc.prowJob, tc.plank)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validateProwJobTimeoutOnCreate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -22,6 +22,9 @@ import (
 	"fmt"
 	"mime"
 	"net/url"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -31,6 +34,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	prowgithub "sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/logrusutil"
 )
 
 // ProwJobType specifies how the job is triggered.
@@ -151,6 +155,10 @@ type ProwJobSpec struct {
 	// to run the job, only applicable for that
 	// specific agent
 	Cluster string `json:"cluster,omitempty"`
+	// Arch is the CPU architecture of the nodes
+	// to run the job on, e.g. arm64, only
+	// applicable for the kubernetes agent
+	Arch string `json:"arch,omitempty"`
 	// Namespace defines where to create pods/resources.
 	Namespace string `json:"namespace,omitempty"`
 	// Job is the name of the job
@@ -229,6 +237,11 @@ type ProwJobSpec struct {
 	// This behaviour may be superseded by MaxConcurrency field, if it
 	// is set to a constraining value.
 	JobQueueName string `json:"job_queue_name,omitempty"`
+
+	// RunAfter is an optional time before which the job must not be
+	// started. The ProwJob stays in the triggered state until then.
+	// If unset, the job is started as soon as possible.
+	RunAfter *metav1.Time `json:"run_after,omitempty"`
 }
 
 func (pjs ProwJobSpec) HasPipelineRunSpec() bool {
@@ -469,10 +482,37 @@ type DecorationConfig struct {
 	// after sending SIGINT to send SIGKILL when aborting
 	// a job. Only applicable if decorating the PodSpec.
 	GracePeriod *Duration `json:"grace_period,omitempty"`
+	// NoOutputTimeout is how long the pod utilities will wait
+	// for the test process to write to stdout or stderr before
+	// aborting the job as hung. No limit is enforced if unset.
+	NoOutputTimeout *Duration `json:"no_output_timeout,omitempty"`
+	// HeartbeatInterval is how often the entrypoint records that
+	// the test process is alive. If set, sidecar reports the test
+	// as hung once it has not produced output for ten intervals,
+	// before the job times out.
+	HeartbeatInterval *Duration `json:"heartbeat_interval,omitempty"`
+	// MaxTimeoutExtension is how much longer than Timeout the test
+	// process may run if it asks for more time by writing a duration,
+	// like 30m, to the timeout-override file in the artifacts directory.
+	// The test cannot extend its timeout if unset.
+	MaxTimeoutExtension *Duration `json:"max_timeout_extension,omitempty"`
+	// TimeoutSignal is the signal, SIGINT or SIGTERM, the pod
+	// utilities send to the test process when aborting a job.
+	// Defaults to SIGINT.
+	TimeoutSignal *string `json:"timeout_signal,omitempty"`
+	// SignalProcessGroup makes the pod utilities deliver signals,
+	// including the SIGKILL after the grace period, to the whole
+	// process group of the test process, so that its children do
+	// not outlive it.
+	SignalProcessGroup *bool `json:"signal_process_group,omitempty"`
 
 	// UtilityImages holds pull specs for utility container
 	// images used to decorate a PodSpec.
 	UtilityImages *UtilityImages `json:"utility_images,omitempty"`
+	// WindowsUtilityImages holds pull specs for utility container
+	// images used to decorate a PodSpec that runs on Windows nodes.
+	// Images that are not set default to the UtilityImages.
+	WindowsUtilityImages *UtilityImages `json:"windows_utility_images,omitempty"`
 	// Resources holds resource requests and limits for utility
 	// containers used to decorate a PodSpec.
 	Resources *Resources `json:"resources,omitempty"`
@@ -498,6 +538,16 @@ type DecorationConfig struct {
 	// BloblessFetch tells Prow to avoid fetching objects when cloning using
 	// the --filter=blob:none flag.
 	BloblessFetch *bool `json:"blobless_fetch,omitempty"`
+	// CloneDepth is the depth of the clones of the refs that do not set
+	// their own. A depth of zero will do a full clone.
+	CloneDepth *int `json:"clone_depth,omitempty"`
+	// Filter is the filter of partial clones of the refs that do not set
+	// their own, like blob:none or tree:0.
+	Filter *string `json:"filter,omitempty"`
+	// SingleBranch tells Prow to only fetch the base ref and the pulls of
+	// the refs that do not set their own, without their other branches and
+	// tags.
+	SingleBranch *bool `json:"single_branch,omitempty"`
 	// SkipCloning determines if we should clone source code in the
 	// initcontainers for jobs that specify refs
 	SkipCloning *bool `json:"skip_cloning,omitempty"`
@@ -526,6 +576,38 @@ type DecorationConfig struct {
 	// hope that the test process exits cleanly before starting an upload.
 	UploadIgnoresInterrupts *bool `json:"upload_ignores_interrupts,omitempty"`
 
+	// MetadataServerPort makes sidecar listen on this localhost port for
+	// metadata and links posted by the test process while it runs. They are
+	// added to the metadata in finished.json. The URL of the server is exposed
+	// to test containers in $PROW_METADATA_URL.
+	MetadataServerPort *int32 `json:"metadata_server_port,omitempty"`
+
+	// FailureDiagnostics are commands sidecar runs when a test process fails
+	// or times out, for example to dump the state of a cluster under test.
+	// Their output is uploaded to artifacts/diagnostics/<name>.txt. The
+	// commands must be available in the sidecar image.
+	FailureDiagnostics []DiagnosticCommand `json:"failure_diagnostics,omitempty"`
+
+	// FailureExcerpt makes sidecar extract the most relevant lines of the
+	// build log when a test process fails, which are then shown in the
+	// status description of the job instead of "Job failed.".
+	FailureExcerpt *FailureExcerpt `json:"failure_excerpt,omitempty"`
+
+	// IncrementalUploadInterval is how often sidecar uploads the build logs
+	// and artifacts of the test while it runs, along with an in-progress
+	// marker. Disabled if unset or zero.
+	IncrementalUploadInterval *Duration `json:"incremental_upload_interval,omitempty"`
+
+	// Coordination configures how the test containers of a job with multiple
+	// containers are coordinated, by container name. When set, the timeout is
+	// shared by all test containers and counted from when the first started.
+	Coordination map[string]ContainerCoordination `json:"coordination,omitempty"`
+
+	// LogLevels are the log levels of the pod utilities, in the syntax of
+	// the PROW_LOG_LEVELS environment variable: a default level optionally
+	// followed by per-package levels, e.g. "info,pkg/gcsupload=debug".
+	LogLevels string `json:"log_levels,omitempty"`
+
 	// SetLimitEqualsMemoryRequest sets memory limit equal to request.
 	SetLimitEqualsMemoryRequest *bool `json:"set_limit_equals_memory_request,omitempty"`
 	// DefaultMemoryRequest is the default requested memory on a test container.
@@ -559,6 +641,39 @@ type DecorationConfig struct {
 	FsGroup *int64 `json:"fs_group,omitempty"`
 }
 
+// DiagnosticCommand is a command that collects diagnostics on failure.
+type DiagnosticCommand struct {
+	// Name identifies the command and names the artifact holding its output.
+	Name string `json:"name"`
+	// Command is the command to run and its arguments.
+	Command []string `json:"command"`
+	// Timeout is how long the command may run before it is killed.
+	// Defaults to one minute.
+	Timeout *Duration `json:"timeout,omitempty"`
+}
+
+// FailureExcerpt configures how the excerpt of the build log of a failed job
+// is extracted.
+type FailureExcerpt struct {
+	// Patterns are regular expressions matching the relevant lines of the
+	// build log, by precedence: the excerpt holds the last lines matching
+	// the first pattern that matches any line. Defaults to Go test failures,
+	// then errors, fatal errors and panics, then any other failure.
+	Patterns []string `json:"patterns,omitempty"`
+	// MaxLines is the most lines the excerpt holds. Defaults to 2.
+	MaxLines int `json:"max_lines,omitempty"`
+}
+
+// ContainerCoordination configures when a test container runs and how its
+// result affects the job.
+type ContainerCoordination struct {
+	// After lists the test containers that must finish before this container
+	// runs. It is skipped if any of them that is not optional fails.
+	After []string `json:"after,omitempty"`
+	// Optional containers do not fail the job when they fail.
+	Optional bool `json:"optional,omitempty"`
+}
+
 type CensoringOptions struct {
 	// CensoringConcurrency is the maximum number of goroutines that should be censoring
 	// artifacts and logs at any time. If unset, defaults to 10.
@@ -584,6 +699,13 @@ type CensoringOptions struct {
 	// matches a glob in IncludeDirectories. Entries in this list are relative to $ARTIFACTS,
 	// and are parsed with the go-zglob library, allowing for globbed matches.
 	ExcludeDirectories []string `json:"exclude_directories,omitempty"`
+
+	// Patterns are regular expressions whose matches are censored from output logs
+	// and artifacts, for secrets that are not mounted from Kubernetes secrets, like
+	// cloud credentials the test obtains at runtime, e.g. `AKIA[0-9A-Z]{16}`. They
+	// are applied even if CensorSecrets is not set. Matches longer than half of
+	// CensoringBufferSize may not be censored.
+	Patterns []string `json:"patterns,omitempty"`
 }
 
 // ApplyDefault applies the defaults for CensoringOptions decorations. If a field has a zero value,
@@ -617,6 +739,10 @@ func (g *CensoringOptions) ApplyDefault(def *CensoringOptions) *CensoringOptions
 	if merged.ExcludeDirectories == nil {
 		merged.ExcludeDirectories = def.ExcludeDirectories
 	}
+
+	if merged.Patterns == nil {
+		merged.Patterns = def.Patterns
+	}
 	return &merged
 }
 
@@ -711,6 +837,7 @@ func (d *DecorationConfig) ApplyDefault(def *DecorationConfig) *DecorationConfig
 		return &merged
 	}
 	merged.UtilityImages = merged.UtilityImages.ApplyDefault(def.UtilityImages)
+	merged.WindowsUtilityImages = merged.WindowsUtilityImages.ApplyDefault(def.WindowsUtilityImages)
 	merged.Resources = merged.Resources.ApplyDefault(def.Resources)
 	merged.GCSConfiguration = merged.GCSConfiguration.ApplyDefault(def.GCSConfiguration)
 	merged.CensoringOptions = merged.CensoringOptions.ApplyDefault(def.CensoringOptions)
@@ -721,6 +848,21 @@ func (d *DecorationConfig) ApplyDefault(def *DecorationConfig) *DecorationConfig
 	if merged.GracePeriod == nil {
 		merged.GracePeriod = def.GracePeriod
 	}
+	if merged.NoOutputTimeout == nil {
+		merged.NoOutputTimeout = def.NoOutputTimeout
+	}
+	if merged.HeartbeatInterval == nil {
+		merged.HeartbeatInterval = def.HeartbeatInterval
+	}
+	if merged.MaxTimeoutExtension == nil {
+		merged.MaxTimeoutExtension = def.MaxTimeoutExtension
+	}
+	if merged.TimeoutSignal == nil {
+		merged.TimeoutSignal = def.TimeoutSignal
+	}
+	if merged.SignalProcessGroup == nil {
+		merged.SignalProcessGroup = def.SignalProcessGroup
+	}
 	if merged.GCSCredentialsSecret == nil {
 		merged.GCSCredentialsSecret = def.GCSCredentialsSecret
 	}
@@ -762,6 +904,30 @@ func (d *DecorationConfig) ApplyDefault(def *DecorationConfig) *DecorationConfig
 		merged.UploadIgnoresInterrupts = def.UploadIgnoresInterrupts
 	}
 
+	if merged.MetadataServerPort == nil {
+		merged.MetadataServerPort = def.MetadataServerPort
+	}
+
+	if merged.FailureDiagnostics == nil {
+		merged.FailureDiagnostics = def.FailureDiagnostics
+	}
+
+	if merged.FailureExcerpt == nil {
+		merged.FailureExcerpt = def.FailureExcerpt
+	}
+
+	if merged.IncrementalUploadInterval == nil {
+		merged.IncrementalUploadInterval = def.IncrementalUploadInterval
+	}
+
+	if merged.Coordination == nil {
+		merged.Coordination = def.Coordination
+	}
+
+	if merged.LogLevels == "" {
+		merged.LogLevels = def.LogLevels
+	}
+
 	if merged.SetLimitEqualsMemoryRequest == nil {
 		merged.SetLimitEqualsMemoryRequest = def.SetLimitEqualsMemoryRequest
 	}
@@ -797,6 +963,15 @@ func (d *DecorationConfig) ApplyDefault(def *DecorationConfig) *DecorationConfig
 	if merged.BloblessFetch == nil {
 		merged.BloblessFetch = def.BloblessFetch
 	}
+	if merged.CloneDepth == nil {
+		merged.CloneDepth = def.CloneDepth
+	}
+	if merged.Filter == nil {
+		merged.Filter = def.Filter
+	}
+	if merged.SingleBranch == nil {
+		merged.SingleBranch = def.SingleBranch
+	}
 	return &merged
 }
 
@@ -835,6 +1010,107 @@ func (d *DecorationConfig) Validate() error {
 	if d.OauthTokenSecret != nil && len(d.SSHKeySecrets) > 0 {
 		return errors.New("both OAuth token and SSH key secrets are specified")
 	}
+	if d.MetadataServerPort != nil && (*d.MetadataServerPort < 1 || *d.MetadataServerPort > 65535) {
+		return fmt.Errorf("metadata server port %d is not a valid port", *d.MetadataServerPort)
+	}
+	if d.NoOutputTimeout.Get() < 0 {
+		return errors.New("no output timeout must not be negative")
+	}
+	if d.HeartbeatInterval.Get() < 0 {
+		return errors.New("heartbeat interval must not be negative")
+	}
+	if d.MaxTimeoutExtension.Get() < 0 {
+		return errors.New("max timeout extension must not be negative")
+	}
+	if d.IncrementalUploadInterval.Get() < 0 {
+		return errors.New("incremental upload interval must not be negative")
+	}
+	if d.TimeoutSignal != nil && *d.TimeoutSignal != "SIGINT" && *d.TimeoutSignal != "SIGTERM" {
+		return fmt.Errorf("unsupported timeout signal %q, must be SIGINT or SIGTERM", *d.TimeoutSignal)
+	}
+	if _, err := logrusutil.ParseLevels(d.LogLevels); err != nil {
+		return fmt.Errorf("invalid log levels: %w", err)
+	}
+	if d.CloneDepth != nil && *d.CloneDepth < 0 {
+		return errors.New("clone depth must not be negative")
+	}
+	if d.Filter != nil {
+		if err := ValidateCloneFilter(*d.Filter); err != nil {
+			return err
+		}
+	}
+	names := map[string]bool{}
+	for i, diagnostic := range d.FailureDiagnostics {
+		if diagnostic.Name == "" || strings.ContainsAny(diagnostic.Name, `/\`) || strings.Contains(diagnostic.Name, "..") {
+			return fmt.Errorf("failure diagnostic %d has an invalid name %q", i, diagnostic.Name)
+		}
+		if names[diagnostic.Name] {
+			return fmt.Errorf("failure diagnostic name %q is used more than once", diagnostic.Name)
+		}
+		names[diagnostic.Name] = true
+		if len(diagnostic.Command) == 0 {
+			return fmt.Errorf("failure diagnostic %q has no command", diagnostic.Name)
+		}
+		if diagnostic.Timeout.Get() < 0 {
+			return fmt.Errorf("failure diagnostic %q has a negative timeout", diagnostic.Name)
+		}
+	}
+	if d.CensoringOptions != nil {
+		for _, pattern := range d.CensoringOptions.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("censoring pattern %q is invalid: %w", pattern, err)
+			}
+		}
+	}
+	if d.FailureExcerpt != nil {
+		for _, pattern := range d.FailureExcerpt.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("failure excerpt pattern %q is invalid: %w", pattern, err)
+			}
+		}
+		if d.FailureExcerpt.MaxLines < 0 {
+			return errors.New("failure excerpt max lines must not be negative")
+		}
+	}
+	return validateCoordination(d.Coordination)
+}
+
+// validateCoordination ensures that containers do not run after themselves,
+// directly or through other containers.
+func validateCoordination(coordination map[string]ContainerCoordination) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("containers run after themselves: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, after := range coordination[name].After {
+			if err := visit(after, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	names := make([]string, 0, len(coordination))
+	for name := range coordination {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -928,6 +1204,123 @@ type GCSConfiguration struct {
 	// Example: "txt", "json"
 	// Use "*" for all
 	CompressFileTypes []string `json:"compress_file_types,omitempty"`
+	// CompressionRules select the artifacts to gzip prior to upload by file
+	// name pattern and size. The first matching rule applies, and artifacts
+	// matching none of them fall back to CompressFileTypes.
+	CompressionRules []CompressionRule `json:"compression_rules,omitempty"`
+	// DetectContentTypes, if set, sets the Content-Type of every uploaded
+	// artifact from its extension or, if that is unknown, by sniffing its
+	// content, so that text artifacts without a known extension, like build
+	// logs, are rendered by browsers instead of being downloaded.
+	DetectContentTypes *bool `json:"detect_content_types,omitempty"`
+	// Encryption configures the client-side encryption of sensitive artifacts,
+	// like kubeconfigs or audit logs, before they are uploaded. Matching files
+	// are not compressed.
+	Encryption *ArtifactEncryption `json:"encryption,omitempty"`
+	// ContentAddressed, if set, stores the artifacts of the job by their
+	// content, so that artifacts that do not change between runs, like
+	// binaries or fixtures, are only stored once. Runs reference them in a
+	// manifest that Spyglass resolves transparently.
+	ContentAddressed *ContentAddressedStorage `json:"content_addressed,omitempty"`
+	// UploadRetry configures how failed uploads of artifacts are retried.
+	// Artifacts that fail to upload nonetheless are listed in the
+	// upload-report.json of the job.
+	UploadRetry *UploadRetry `json:"upload_retry,omitempty"`
+}
+
+// UploadRetry configures how failed uploads of artifacts are retried.
+type UploadRetry struct {
+	// Attempts is how many times an artifact is tried to be uploaded.
+	// Defaults to 4.
+	Attempts int `json:"attempts,omitempty"`
+	// InitialBackoff is how long to wait before retrying a failed upload.
+	// It doubles with every retry, up to MaxBackoff. Defaults to 1s.
+	InitialBackoff *Duration `json:"initial_backoff,omitempty"`
+	// MaxBackoff is the longest wait between retries. Defaults to 30s.
+	MaxBackoff *Duration `json:"max_backoff,omitempty"`
+	// ChunkSize is the size in bytes of the chunks of the resumable uploads
+	// of large artifacts to GCS, a multiple of 256KiB. A chunk that fails to
+	// upload is retried without uploading the artifact from the start.
+	// Defaults to 16MiB.
+	ChunkSize int `json:"chunk_size,omitempty"`
+	// ChunkRetryDeadline is how long a chunk that fails to upload is retried
+	// before the upload of the artifact is retried from the start.
+	// Defaults to 32s.
+	ChunkRetryDeadline *Duration `json:"chunk_retry_deadline,omitempty"`
+}
+
+// uploadChunkSizeMultiple is the size GCS requires chunks of resumable
+// uploads to be a multiple of.
+const uploadChunkSizeMultiple = 256 * 1024
+
+// CompressionRule selects artifacts to gzip prior to upload.
+type CompressionRule struct {
+	// Pattern is the file name pattern of the artifacts to compress, in the
+	// syntax of path.Match, e.g. "*.log" or "junit*.xml".
+	Pattern string `json:"pattern"`
+	// MinSize is the size in bytes from which matching artifacts are
+	// compressed, as compressing small artifacts saves little. The start of
+	// every matching artifact is held in memory until the size is reached.
+	// Defaults to 1024.
+	MinSize int `json:"min_size,omitempty"`
+}
+
+// GetMinSize returns the size in bytes from which artifacts matching the rule
+// are compressed.
+func (r CompressionRule) GetMinSize() int {
+	if r.MinSize == 0 {
+		return 1024
+	}
+	return r.MinSize
+}
+
+// maxCompressionMinSize bounds the start of artifacts held in memory to
+// decide on their compression.
+const maxCompressionMinSize = 16 * 1024 * 1024
+
+// ArtifactEncryption configures the envelope encryption of artifacts: each
+// matching artifact is encrypted with its own data key, which is stored next
+// to it wrapped by a key management service key.
+type ArtifactEncryption struct {
+	// KeyURL is the URL of the key that wraps the data keys. It takes any
+	// scheme of gocloud.dev/secrets, e.g.
+	// "gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k" or
+	// "awskms:///alias/k". The job needs to be able to encrypt with the key.
+	KeyURL string `json:"key_url"`
+	// Patterns are the file name patterns of the artifacts to encrypt, in the
+	// syntax of path.Match, e.g. "*.kubeconfig" or "audit*.log".
+	Patterns []string `json:"patterns"`
+}
+
+// ContentAddressedStorage configures storing artifacts by their content.
+// Artifacts matching the patterns of the encryption config, if any, are
+// uploaded as usual.
+type ContentAddressedStorage struct {
+	// PathPrefix is the path under the bucket under which artifacts are
+	// stored by their SHA-256 digest. Defaults to "cas".
+	PathPrefix string `json:"path_prefix,omitempty"`
+	// MinSize is the size in bytes from which artifacts are stored by their
+	// content. Smaller artifacts are uploaded as usual, as referencing them
+	// saves little. Defaults to 65536.
+	MinSize int64 `json:"min_size,omitempty"`
+}
+
+// GetPathPrefix returns the path under the bucket under which artifacts are
+// stored by their content.
+func (c *ContentAddressedStorage) GetPathPrefix() string {
+	if c.PathPrefix == "" {
+		return "cas"
+	}
+	return c.PathPrefix
+}
+
+// GetMinSize returns the size in bytes from which artifacts are stored by
+// their content.
+func (c *ContentAddressedStorage) GetMinSize() int64 {
+	if c.MinSize == 0 {
+		return 64 * 1024
+	}
+	return c.MinSize
 }
 
 // ApplyDefault applies the defaults for GCSConfiguration decorations. If a field has a zero value,
@@ -980,6 +1373,21 @@ func (g *GCSConfiguration) ApplyDefault(def *GCSConfiguration) *GCSConfiguration
 	if merged.CompressFileTypes == nil {
 		merged.CompressFileTypes = def.CompressFileTypes
 	}
+	if merged.CompressionRules == nil {
+		merged.CompressionRules = def.CompressionRules
+	}
+	if merged.DetectContentTypes == nil {
+		merged.DetectContentTypes = def.DetectContentTypes
+	}
+	if merged.Encryption == nil {
+		merged.Encryption = def.Encryption
+	}
+	if merged.ContentAddressed == nil {
+		merged.ContentAddressed = def.ContentAddressed
+	}
+	if merged.UploadRetry == nil {
+		merged.UploadRetry = def.UploadRetry
+	}
 	return &merged
 }
 
@@ -999,6 +1407,49 @@ func (g *GCSConfiguration) Validate() error {
 	if g.PathStrategy != PathStrategyExplicit && (g.DefaultOrg == "" || g.DefaultRepo == "") {
 		return fmt.Errorf("default org and repo must be provided for GCS strategy %q", g.PathStrategy)
 	}
+	for _, rule := range g.CompressionRules {
+		if rule.Pattern == "" {
+			return errors.New("compression rule pattern must be set")
+		}
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid compression rule pattern %q: %w", rule.Pattern, err)
+		}
+		if rule.MinSize < 0 || rule.MinSize > maxCompressionMinSize {
+			return fmt.Errorf("compression rule min_size must be between 0 and %d", maxCompressionMinSize)
+		}
+	}
+	if g.Encryption != nil {
+		if g.Encryption.KeyURL == "" {
+			return errors.New("encryption key_url must be set")
+		}
+		if len(g.Encryption.Patterns) == 0 {
+			return errors.New("encryption patterns must be set")
+		}
+		for _, pattern := range g.Encryption.Patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid encryption pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	if g.ContentAddressed != nil {
+		if g.ContentAddressed.MinSize < 0 {
+			return errors.New("content_addressed min_size must not be negative")
+		}
+		if prefix := g.ContentAddressed.PathPrefix; prefix != "" && (path.IsAbs(prefix) || path.Clean(prefix) != prefix || strings.HasPrefix(prefix, "..")) {
+			return fmt.Errorf("content_addressed path_prefix %q must be a clean relative path", prefix)
+		}
+	}
+	if r := g.UploadRetry; r != nil {
+		if r.Attempts < 0 {
+			return errors.New("upload_retry attempts must not be negative")
+		}
+		if r.InitialBackoff.Get() < 0 || r.MaxBackoff.Get() < 0 || r.ChunkRetryDeadline.Get() < 0 {
+			return errors.New("upload_retry durations must not be negative")
+		}
+		if r.ChunkSize < 0 || r.ChunkSize%uploadChunkSizeMultiple != 0 {
+			return fmt.Errorf("upload_retry chunk_size must be a multiple of %d", uploadChunkSizeMultiple)
+		}
+	}
 	return nil
 }
 
@@ -1090,6 +1541,12 @@ func (j *ProwJob) SetComplete() {
 	*j.Status.CompletionTime = metav1.Now()
 }
 
+// Deferred returns true if the job has not started yet and must not be
+// started before its RunAfter time.
+func (j *ProwJob) Deferred(now time.Time) bool {
+	return j.Status.State == TriggeredState && j.Spec.RunAfter != nil && now.Before(j.Spec.RunAfter.Time)
+}
+
 // ClusterAlias specifies the key in the clusters map to use.
 //
 // This allows scheduling a prow job somewhere aside from the default build cluster.
@@ -1100,6 +1557,15 @@ func (j *ProwJob) ClusterAlias() string {
 	return j.Spec.Cluster
 }
 
+// TraceID returns the ID that correlates the logs of everything done for
+// the ProwJob: the GUID of the GitHub event that triggered it, or its name.
+func (j *ProwJob) TraceID() string {
+	if guid := j.Labels[prowgithub.EventGUID]; guid != "" {
+		return guid
+	}
+	return j.Name
+}
+
 // Pull describes a pull request at a particular point in time.
 type Pull struct {
 	Number int    `json:"number"`
@@ -1169,6 +1635,34 @@ type Refs struct {
 	// using the --filter=blob:none flag. If unspecified, defaults to
 	// DecorationConfig.BloblessFetch.
 	BloblessFetch *bool `json:"blobless_fetch,omitempty"`
+	// Filter is the filter of a partial clone, like blob:none or tree:0,
+	// which tells prow to only fetch the objects it selects using the
+	// --filter flag. It takes precedence over BloblessFetch. If unspecified,
+	// defaults to DecorationConfig.Filter.
+	Filter string `json:"filter,omitempty"`
+	// SingleBranch tells prow to only fetch the base ref and the pulls,
+	// without the other branches and tags of the repository. If unspecified,
+	// defaults to DecorationConfig.SingleBranch.
+	SingleBranch *bool `json:"single_branch,omitempty"`
+}
+
+// cloneFilterRegex matches the filters of partial clones that git supports.
+var cloneFilterRegex = regexp.MustCompile(`^(blob:none|blob:limit=[0-9]+[kmg]?|tree:[0-9]+|object:type=(blob|tree|commit|tag)|sparse:oid=\S+)$`)
+
+// ValidateCloneFilter ensures that filter is the filter of a partial clone
+// that git supports, like blob:none, blob:limit=1m or tree:0, or a
+// combination of them, like combine:blob:none+tree:1.
+func ValidateCloneFilter(filter string) error {
+	filters := []string{filter}
+	if combined, ok := strings.CutPrefix(filter, "combine:"); ok {
+		filters = strings.Split(combined, "+")
+	}
+	for _, f := range filters {
+		if !cloneFilterRegex.MatchString(f) {
+			return fmt.Errorf("clone filter %q is invalid: %q is not a filter git supports", filter, f)
+		}
+	}
+	return nil
 }
 
 func (r Refs) String() string {
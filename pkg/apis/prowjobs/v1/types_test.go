@@ -26,6 +26,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	fuzz "github.com/google/gofuzz"
 	pipelinev1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func pStr(str string) *string {
@@ -435,6 +436,303 @@ func TestApplyDefaultsAppliesDefaultsForAllFields(t *testing.T) {
 	}
 }
 
+func TestValidateCoordination(t *testing.T) {
+	testCases := []struct {
+		name         string
+		coordination map[string]ContainerCoordination
+		expectedErr  string
+	}{
+		{
+			name: "no coordination",
+		},
+		{
+			name: "dependencies without cycles",
+			coordination: map[string]ContainerCoordination{
+				"build": {After: []string{"setup"}},
+				"test":  {After: []string{"build", "setup"}},
+				"lint":  {Optional: true},
+			},
+		},
+		{
+			name:         "container runs after itself",
+			coordination: map[string]ContainerCoordination{"test": {After: []string{"test"}}},
+			expectedErr:  "containers run after themselves: test -> test",
+		},
+		{
+			name: "containers run after each other",
+			coordination: map[string]ContainerCoordination{
+				"build": {After: []string{"test"}},
+				"test":  {After: []string{"setup"}},
+				"setup": {After: []string{"build"}},
+			},
+			expectedErr: "containers run after themselves: build -> test -> setup -> build",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var errMsg string
+			if err := validateCoordination(tc.coordination); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
+
+func TestProwJobTraceID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			name:     "job without event",
+			expected: "job-name",
+		},
+		{
+			name:     "job triggered by event",
+			labels:   map[string]string{"event-GUID": "guid"},
+			expected: "guid",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := &ProwJob{ObjectMeta: metav1.ObjectMeta{Name: "job-name", Labels: tc.labels}}
+			if actual := pj.TraceID(); actual != tc.expected {
+				t.Errorf("expected trace ID %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateCensoringPatterns(t *testing.T) {
+	testCases := []struct {
+		name        string
+		patterns    []string
+		expectedErr string
+	}{
+		{
+			name: "no patterns",
+		},
+		{
+			name:     "valid patterns",
+			patterns: []string{`AKIA[0-9A-Z]{16}`, `ghp_[0-9a-zA-Z]{36}`},
+		},
+		{
+			name:        "invalid pattern",
+			patterns:    []string{`AKIA[0-9A-Z{16}`},
+			expectedErr: "censoring pattern \"AKIA[0-9A-Z{16}\" is invalid: error parsing regexp: missing closing ]: `[0-9A-Z{16}`",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &DecorationConfig{
+				UtilityImages:    &UtilityImages{CloneRefs: "clonerefs", InitUpload: "initupload", Entrypoint: "entrypoint", Sidecar: "sidecar"},
+				GCSConfiguration: &GCSConfiguration{Bucket: "gs://bucket", PathStrategy: PathStrategyExplicit},
+				CensoringOptions: &CensoringOptions{Patterns: tc.patterns},
+			}
+			var errMsg string
+			if err := d.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateCloneFilter(t *testing.T) {
+	testCases := []struct {
+		name        string
+		filter      string
+		expectedErr string
+	}{
+		{
+			name:   "blobless",
+			filter: "blob:none",
+		},
+		{
+			name:   "blob size limit",
+			filter: "blob:limit=1m",
+		},
+		{
+			name:   "treeless",
+			filter: "tree:0",
+		},
+		{
+			name:   "combined filters",
+			filter: "combine:blob:none+tree:1",
+		},
+		{
+			name:        "unknown filter",
+			filter:      "blob:some",
+			expectedErr: `clone filter "blob:some" is invalid: "blob:some" is not a filter git supports`,
+		},
+		{
+			name:        "filters combined without combine",
+			filter:      "blob:none+tree:1",
+			expectedErr: `clone filter "blob:none+tree:1" is invalid: "blob:none+tree:1" is not a filter git supports`,
+		},
+		{
+			name:        "invalid combined filter",
+			filter:      "combine:blob:none+tree:x",
+			expectedErr: `clone filter "combine:blob:none+tree:x" is invalid: "tree:x" is not a filter git supports`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var errMsg string
+			if err := ValidateCloneFilter(tc.filter); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateGCSConfigurationEncryption(t *testing.T) {
+	testCases := []struct {
+		name        string
+		encryption  *ArtifactEncryption
+		expectedErr string
+	}{
+		{
+			name: "no encryption",
+		},
+		{
+			name:       "valid encryption",
+			encryption: &ArtifactEncryption{KeyURL: "gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k", Patterns: []string{"*.kubeconfig", "audit*.log"}},
+		},
+		{
+			name:        "missing key",
+			encryption:  &ArtifactEncryption{Patterns: []string{"*.kubeconfig"}},
+			expectedErr: "encryption key_url must be set",
+		},
+		{
+			name:        "missing patterns",
+			encryption:  &ArtifactEncryption{KeyURL: "base64key://"},
+			expectedErr: "encryption patterns must be set",
+		},
+		{
+			name:        "invalid pattern",
+			encryption:  &ArtifactEncryption{KeyURL: "base64key://", Patterns: []string{"[*.kubeconfig"}},
+			expectedErr: `invalid encryption pattern "[*.kubeconfig": syntax error in pattern`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &GCSConfiguration{Bucket: "gs://bucket", PathStrategy: PathStrategyExplicit, Encryption: tc.encryption}
+			var errMsg string
+			if err := g.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateGCSConfigurationContentAddressed(t *testing.T) {
+	testCases := []struct {
+		name             string
+		contentAddressed *ContentAddressedStorage
+		expectedErr      string
+	}{
+		{
+			name: "not content-addressed",
+		},
+		{
+			name:             "defaults",
+			contentAddressed: &ContentAddressedStorage{},
+		},
+		{
+			name:             "valid config",
+			contentAddressed: &ContentAddressedStorage{PathPrefix: "shared/cas", MinSize: 1024},
+		},
+		{
+			name:             "negative min size",
+			contentAddressed: &ContentAddressedStorage{MinSize: -1},
+			expectedErr:      "content_addressed min_size must not be negative",
+		},
+		{
+			name:             "absolute path prefix",
+			contentAddressed: &ContentAddressedStorage{PathPrefix: "/cas"},
+			expectedErr:      `content_addressed path_prefix "/cas" must be a clean relative path`,
+		},
+		{
+			name:             "path prefix outside of the bucket",
+			contentAddressed: &ContentAddressedStorage{PathPrefix: "../cas"},
+			expectedErr:      `content_addressed path_prefix "../cas" must be a clean relative path`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &GCSConfiguration{Bucket: "gs://bucket", PathStrategy: PathStrategyExplicit, ContentAddressed: tc.contentAddressed}
+			var errMsg string
+			if err := g.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateGCSConfigurationCompressionRules(t *testing.T) {
+	testCases := []struct {
+		name        string
+		rules       []CompressionRule
+		expectedErr string
+	}{
+		{
+			name: "no rules",
+		},
+		{
+			name:  "valid rules",
+			rules: []CompressionRule{{Pattern: "*.log", MinSize: 4096}, {Pattern: "junit*.xml"}},
+		},
+		{
+			name:        "missing pattern",
+			rules:       []CompressionRule{{MinSize: 4096}},
+			expectedErr: "compression rule pattern must be set",
+		},
+		{
+			name:        "invalid pattern",
+			rules:       []CompressionRule{{Pattern: "[*.log"}},
+			expectedErr: `invalid compression rule pattern "[*.log": syntax error in pattern`,
+		},
+		{
+			name:        "negative min size",
+			rules:       []CompressionRule{{Pattern: "*.log", MinSize: -1}},
+			expectedErr: "compression rule min_size must be between 0 and 16777216",
+		},
+		{
+			name:        "min size too large to buffer",
+			rules:       []CompressionRule{{Pattern: "*.log", MinSize: 1 << 30}},
+			expectedErr: "compression rule min_size must be between 0 and 16777216",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &GCSConfiguration{Bucket: "gs://bucket", PathStrategy: PathStrategyExplicit, CompressionRules: tc.rules}
+			var errMsg string
+			if err := g.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+		})
+	}
+}
+
 func TestSlackConfigApplyDefaultsAppliesDefaultsForAllFields(t *testing.T) {
 	t.Parallel()
 	seed := time.Now().UnixNano()
@@ -893,3 +1191,42 @@ func TestProwJobSpec_GetPipelineRunSpec(t *testing.T) {
 		})
 	}
 }
+
+func TestProwJobDeferred(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name     string
+		runAfter *metav1.Time
+		state    ProwJobState
+		expected bool
+	}{
+		{
+			name:  "no run_after",
+			state: TriggeredState,
+		},
+		{
+			name:     "run_after in the future",
+			runAfter: &metav1.Time{Time: now.Add(time.Hour)},
+			state:    TriggeredState,
+			expected: true,
+		},
+		{
+			name:     "run_after in the past",
+			runAfter: &metav1.Time{Time: now.Add(-time.Hour)},
+			state:    TriggeredState,
+		},
+		{
+			name:     "already started",
+			runAfter: &metav1.Time{Time: now.Add(time.Hour)},
+			state:    PendingState,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := &ProwJob{Spec: ProwJobSpec{RunAfter: tc.runAfter}, Status: ProwJobStatus{State: tc.state}}
+			if actual := pj.Deferred(now); actual != tc.expected {
+				t.Errorf("expected deferred %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -30,6 +30,27 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactEncryption) DeepCopyInto(out *ArtifactEncryption) {
+	*out = *in
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactEncryption.
+func (in *ArtifactEncryption) DeepCopy() *ArtifactEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CensoringOptions) DeepCopyInto(out *CensoringOptions) {
 	*out = *in
@@ -53,6 +74,11 @@ func (in *CensoringOptions) DeepCopyInto(out *CensoringOptions) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -66,6 +92,59 @@ func (in *CensoringOptions) DeepCopy() *CensoringOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerCoordination) DeepCopyInto(out *ContainerCoordination) {
+	*out = *in
+	if in.After != nil {
+		in, out := &in.After, &out.After
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerCoordination.
+func (in *ContainerCoordination) DeepCopy() *ContainerCoordination {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerCoordination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompressionRule) DeepCopyInto(out *CompressionRule) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompressionRule.
+func (in *CompressionRule) DeepCopy() *CompressionRule {
+	if in == nil {
+		return nil
+	}
+	out := new(CompressionRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContentAddressedStorage) DeepCopyInto(out *ContentAddressedStorage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContentAddressedStorage.
+func (in *ContentAddressedStorage) DeepCopy() *ContentAddressedStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(ContentAddressedStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DecorationConfig) DeepCopyInto(out *DecorationConfig) {
 	*out = *in
@@ -79,11 +158,41 @@ func (in *DecorationConfig) DeepCopyInto(out *DecorationConfig) {
 		*out = new(Duration)
 		**out = **in
 	}
+	if in.NoOutputTimeout != nil {
+		in, out := &in.NoOutputTimeout, &out.NoOutputTimeout
+		*out = new(Duration)
+		**out = **in
+	}
+	if in.HeartbeatInterval != nil {
+		in, out := &in.HeartbeatInterval, &out.HeartbeatInterval
+		*out = new(Duration)
+		**out = **in
+	}
+	if in.MaxTimeoutExtension != nil {
+		in, out := &in.MaxTimeoutExtension, &out.MaxTimeoutExtension
+		*out = new(Duration)
+		**out = **in
+	}
+	if in.TimeoutSignal != nil {
+		in, out := &in.TimeoutSignal, &out.TimeoutSignal
+		*out = new(string)
+		**out = **in
+	}
+	if in.SignalProcessGroup != nil {
+		in, out := &in.SignalProcessGroup, &out.SignalProcessGroup
+		*out = new(bool)
+		**out = **in
+	}
 	if in.UtilityImages != nil {
 		in, out := &in.UtilityImages, &out.UtilityImages
 		*out = new(UtilityImages)
 		**out = **in
 	}
+	if in.WindowsUtilityImages != nil {
+		in, out := &in.WindowsUtilityImages, &out.WindowsUtilityImages
+		*out = new(UtilityImages)
+		**out = **in
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = new(Resources)
@@ -124,6 +233,21 @@ func (in *DecorationConfig) DeepCopyInto(out *DecorationConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.CloneDepth != nil {
+		in, out := &in.CloneDepth, &out.CloneDepth
+		*out = new(int)
+		**out = **in
+	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(string)
+		**out = **in
+	}
+	if in.SingleBranch != nil {
+		in, out := &in.SingleBranch, &out.SingleBranch
+		*out = new(bool)
+		**out = **in
+	}
 	if in.SkipCloning != nil {
 		in, out := &in.SkipCloning, &out.SkipCloning
 		*out = new(bool)
@@ -164,6 +288,35 @@ func (in *DecorationConfig) DeepCopyInto(out *DecorationConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.MetadataServerPort != nil {
+		in, out := &in.MetadataServerPort, &out.MetadataServerPort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureDiagnostics != nil {
+		in, out := &in.FailureDiagnostics, &out.FailureDiagnostics
+		*out = make([]DiagnosticCommand, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FailureExcerpt != nil {
+		in, out := &in.FailureExcerpt, &out.FailureExcerpt
+		*out = new(FailureExcerpt)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IncrementalUploadInterval != nil {
+		in, out := &in.IncrementalUploadInterval, &out.IncrementalUploadInterval
+		*out = new(Duration)
+		**out = **in
+	}
+	if in.Coordination != nil {
+		in, out := &in.Coordination, &out.Coordination
+		*out = make(map[string]ContainerCoordination, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	if in.SetLimitEqualsMemoryRequest != nil {
 		in, out := &in.SetLimitEqualsMemoryRequest, &out.SetLimitEqualsMemoryRequest
 		*out = new(bool)
@@ -217,6 +370,32 @@ func (in *DecorationConfig) DeepCopy() *DecorationConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiagnosticCommand) DeepCopyInto(out *DiagnosticCommand) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiagnosticCommand.
+func (in *DiagnosticCommand) DeepCopy() *DiagnosticCommand {
+	if in == nil {
+		return nil
+	}
+	out := new(DiagnosticCommand)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Duration) DeepCopyInto(out *Duration) {
 	*out = *in
@@ -233,6 +412,27 @@ func (in *Duration) DeepCopy() *Duration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailureExcerpt) DeepCopyInto(out *FailureExcerpt) {
+	*out = *in
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailureExcerpt.
+func (in *FailureExcerpt) DeepCopy() *FailureExcerpt {
+	if in == nil {
+		return nil
+	}
+	out := new(FailureExcerpt)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCSConfiguration) DeepCopyInto(out *GCSConfiguration) {
 	*out = *in
@@ -248,6 +448,31 @@ func (in *GCSConfiguration) DeepCopyInto(out *GCSConfiguration) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CompressionRules != nil {
+		in, out := &in.CompressionRules, &out.CompressionRules
+		*out = make([]CompressionRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.DetectContentTypes != nil {
+		in, out := &in.DetectContentTypes, &out.DetectContentTypes
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(ArtifactEncryption)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContentAddressed != nil {
+		in, out := &in.ContentAddressed, &out.ContentAddressed
+		*out = new(ContentAddressedStorage)
+		**out = **in
+	}
+	if in.UploadRetry != nil {
+		in, out := &in.UploadRetry, &out.UploadRetry
+		*out = new(UploadRetry)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -462,6 +687,10 @@ func (in *ProwJobSpec) DeepCopyInto(out *ProwJobSpec) {
 		*out = new(ProwJobDefault)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RunAfter != nil {
+		in, out := &in.RunAfter, &out.RunAfter
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -557,6 +786,11 @@ func (in *Refs) DeepCopyInto(out *Refs) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.SingleBranch != nil {
+		in, out := &in.SingleBranch, &out.SingleBranch
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -726,6 +960,37 @@ func (in *TektonPipelineRunSpec) DeepCopy() *TektonPipelineRunSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UploadRetry) DeepCopyInto(out *UploadRetry) {
+	*out = *in
+	if in.InitialBackoff != nil {
+		in, out := &in.InitialBackoff, &out.InitialBackoff
+		*out = new(Duration)
+		**out = **in
+	}
+	if in.MaxBackoff != nil {
+		in, out := &in.MaxBackoff, &out.MaxBackoff
+		*out = new(Duration)
+		**out = **in
+	}
+	if in.ChunkRetryDeadline != nil {
+		in, out := &in.ChunkRetryDeadline, &out.ChunkRetryDeadline
+		*out = new(Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UploadRetry.
+func (in *UploadRetry) DeepCopy() *UploadRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(UploadRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UtilityImages) DeepCopyInto(out *UtilityImages) {
 	*out = *in
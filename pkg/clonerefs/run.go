@@ -215,7 +215,7 @@ func configureGlobalCookiefile(cookiePath string) (clone.Command, error) {
 func addHostFingerprints(fingerprints []string) (string, []clone.Command, error) {
 	// let's try to create the tmp dir if it doesn't exist
 	var cmds []clone.Command
-	sshDir := "/tmp"
+	sshDir := os.TempDir()
 	if _, err := os.Stat(sshDir); os.IsNotExist(err) {
 		err := os.MkdirAll(sshDir, 0755)
 		cmd := clone.Command{
@@ -265,7 +265,9 @@ func addHostFingerprints(fingerprints []string) (string, []clone.Command, error)
 		return "", cmds, fmt.Errorf("lookup ssh path: %w", err)
 	}
 	cmds = append(cmds, cmd)
-	return fmt.Sprintf("GIT_SSH_COMMAND=%s -o UserKnownHostsFile=%s", ssh, knownHostsFile), cmds, nil
+	// Git runs the command with a shell, which needs forward slashes and
+	// quotes for paths on Windows, e.g. C:/Program Files/Git/usr/bin/ssh.exe.
+	return fmt.Sprintf("GIT_SSH_COMMAND='%s' -o UserKnownHostsFile='%s'", filepath.ToSlash(ssh), filepath.ToSlash(knownHostsFile)), cmds, nil
 }
 
 // addSSHKeys will start the ssh-agent and add all the specified
@@ -20,6 +20,7 @@ package config
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -56,9 +57,13 @@ import (
 	"sigs.k8s.io/prow/pkg/git/types"
 	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/io/providers"
 	"sigs.k8s.io/prow/pkg/kube"
+	prowlabels "sigs.k8s.io/prow/pkg/labels"
+	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/pod-utils/decorate"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/prow/pkg/version"
 )
 
 const (
@@ -166,6 +171,30 @@ type ProwConfig struct {
 	// It has to be explicitly enabled.
 	Scheduler Scheduler `json:"scheduler,omitempty"`
 
+	// Retester contains configuration for the retester, which retests the
+	// flaky failures of pull requests that are otherwise ready to merge.
+	Retester Retester `json:"retester,omitempty"`
+
+	// FailingPeriodics contains configuration for failing-periodics, which
+	// opens issues for periodic jobs that fail repeatedly.
+	FailingPeriodics FailingPeriodics `json:"failing_periodics,omitempty"`
+
+	// LogLevels maps component names, like hook or tide, to the log levels
+	// they use, in the same syntax as the PROW_LOG_LEVELS environment
+	// variable: a default level optionally followed by per-package levels,
+	// e.g. "info,pkg/github=debug". The "*" key applies to all components
+	// without an entry of their own. Like LogLevel, which it takes
+	// precedence over, changes are applied without a restart.
+	LogLevels map[string]string `json:"log_levels,omitempty"`
+
+	// CostAccounting contains configuration for estimating the cost of jobs
+	// from the resources they request and the prices of their build clusters.
+	CostAccounting CostAccounting `json:"cost_accounting,omitempty"`
+
+	// NamespaceProvisioner contains configuration for the namespace-provisioner,
+	// which creates the namespaces of tenants in the build clusters.
+	NamespaceProvisioner NamespaceProvisioner `json:"namespace_provisioner,omitempty"`
+
 	// TODO: Move this out of the main config.
 	JenkinsOperators []JenkinsOperator `json:"jenkins_operators,omitempty"`
 
@@ -654,6 +683,12 @@ type Plank struct {
 	// PodUnscheduledTimeout defines how long the controller will wait to abort a prowjob
 	// stuck in an unscheduled state. Defaults to 5 minutes.
 	PodUnscheduledTimeout *metav1.Duration `json:"pod_unscheduled_timeout,omitempty"`
+	// MaxDecorationTimeout is the longest decoration timeout a ProwJob may
+	// have. If set, config validation (and so checkconfig) rejects static
+	// jobs with longer timeouts, and the ProwJob admission webhook
+	// (webhook-server) rejects such ProwJobs, including ones created directly
+	// through the API rather than from the job config.
+	MaxDecorationTimeout *metav1.Duration `json:"max_decoration_timeout,omitempty"`
 
 	// DefaultDecorationConfigs holds the default decoration config for specific values.
 	//
@@ -701,6 +736,12 @@ type Plank struct {
 	// e.g. gs://my-bucket/cluster-status.json
 	BuildClusterStatusFile string `json:"build_cluster_status_file,omitempty"`
 
+	// ClusterArchitectures is an optional field used to declare the CPU
+	// architectures of the nodes of each build cluster, by cluster alias.
+	// e.g. {"default": ["amd64"], "arm": ["amd64", "arm64"]}
+	// If set, checkconfig rejects jobs whose arch is not declared for their cluster.
+	ClusterArchitectures map[string][]string `json:"cluster_architectures,omitempty"`
+
 	// JobQueueCapacities is an optional field used to define job queue max concurrency.
 	// Each job can be assigned to a specific queue which has its own max concurrency,
 	// independent from the job's name. Setting the concurrency to 0 will block any job
@@ -708,6 +749,127 @@ type Plank struct {
 	// limit. An example use case would be easier scheduling of jobs using boskos resources.
 	// This mechanism is separate from ProwJob's MaxConcurrency setting.
 	JobQueueCapacities map[string]int `json:"job_queue_capacities,omitempty"`
+
+	// LoadShedding configures backpressure applied when build clusters are
+	// saturated with pods that are waiting to start.
+	LoadShedding *LoadShedding `json:"load_shedding,omitempty"`
+
+	// LabelPropagation configures which ProwJob labels and annotations are
+	// propagated to pods and reporter payloads, and which labels are required.
+	LabelPropagation *LabelPropagationPolicy `json:"label_propagation,omitempty"`
+
+	// ImagePrepull configures pulling the images of upcoming jobs on the
+	// nodes of build clusters before the jobs start.
+	ImagePrepull *ImagePrepull `json:"image_prepull,omitempty"`
+}
+
+// LoadShedding holds the configuration for delaying the start of low
+// priority jobs while a build cluster is saturated.
+type LoadShedding struct {
+	// MaxPendingPods is the number of Prow pods in the pending phase that a
+	// build cluster may have before low priority jobs are delayed. Keys are
+	// build cluster aliases, or '*' to match all clusters. A missing or
+	// non-positive value disables load shedding for the cluster.
+	MaxPendingPods map[string]int `json:"max_pending_pods,omitempty"`
+	// LowPriorityJobTypes are the job types that are delayed while a build
+	// cluster is saturated. Defaults to periodic.
+	LowPriorityJobTypes []prowapi.ProwJobType `json:"low_priority_job_types,omitempty"`
+	// RetryInterval is how long a delayed job waits before plank checks the
+	// build cluster again. Must be positive. Defaults to one minute.
+	RetryInterval *metav1.Duration `json:"retry_interval,omitempty"`
+}
+
+// MaxPendingPodsFor returns the pending pod limit for the build cluster, or
+// zero if load shedding is disabled for it.
+func (ls *LoadShedding) MaxPendingPodsFor(cluster string) int {
+	if limit, ok := ls.MaxPendingPods[cluster]; ok {
+		return limit
+	}
+	return ls.MaxPendingPods["*"]
+}
+
+// GetRetryInterval returns how long a delayed job waits before plank checks
+// the build cluster again.
+func (ls *LoadShedding) GetRetryInterval() time.Duration {
+	if ls.RetryInterval == nil {
+		return time.Minute
+	}
+	return ls.RetryInterval.Duration
+}
+
+// IsLowPriority returns whether jobs of the given type may be delayed.
+func (ls *LoadShedding) IsLowPriority(jobType prowapi.ProwJobType) bool {
+	if len(ls.LowPriorityJobTypes) == 0 {
+		return jobType == prowapi.PeriodicJob
+	}
+	for _, t := range ls.LowPriorityJobTypes {
+		if t == jobType {
+			return true
+		}
+	}
+	return false
+}
+
+// ImagePrepull holds the configuration for warming the images of upcoming
+// jobs on build cluster nodes. Plank keeps a DaemonSet in the pod namespace
+// of each build cluster whose pods pull the images of periodics that are due
+// soon and of jobs that recently ran in merge batches.
+type ImagePrepull struct {
+	// Clusters are the aliases of the build clusters images are pulled on,
+	// or '*' to match all clusters.
+	Clusters []string `json:"clusters"`
+	// LeadTime is how long before a periodic is due its images are pulled.
+	// Defaults to 15 minutes.
+	LeadTime *metav1.Duration `json:"lead_time,omitempty"`
+	// BatchWindow is how long the images of a job that ran in a merge batch
+	// are kept warm, as merge batches of a repo tend to run the same jobs
+	// again. Defaults to 24 hours.
+	BatchWindow *metav1.Duration `json:"batch_window,omitempty"`
+	// PauseImage is the image of the container that keeps the pods of the
+	// DaemonSet running once the images were pulled.
+	// Defaults to registry.k8s.io/pause:3.9.
+	PauseImage string `json:"pause_image,omitempty"`
+	// NodeSelector restricts the nodes images are pulled on, e.g. to the
+	// nodes that run tests.
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+	// Tolerations allow pulling images on tainted nodes.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+}
+
+// Matches returns whether images are pulled on the build cluster.
+func (ip *ImagePrepull) Matches(cluster string) bool {
+	for _, c := range ip.Clusters {
+		if c == "*" || c == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLeadTime returns how long before a periodic is due its images are
+// pulled.
+func (ip *ImagePrepull) GetLeadTime() time.Duration {
+	if ip.LeadTime == nil {
+		return 15 * time.Minute
+	}
+	return ip.LeadTime.Duration
+}
+
+// GetBatchWindow returns how long the images of batch jobs are kept warm.
+func (ip *ImagePrepull) GetBatchWindow() time.Duration {
+	if ip.BatchWindow == nil {
+		return 24 * time.Hour
+	}
+	return ip.BatchWindow.Duration
+}
+
+// GetPauseImage returns the image of the container that keeps the pods of
+// the DaemonSet running.
+func (ip *ImagePrepull) GetPauseImage() string {
+	if ip.PauseImage == "" {
+		return "registry.k8s.io/pause:3.9"
+	}
+	return ip.PauseImage
 }
 
 type ProwJobDefaultEntry struct {
@@ -948,6 +1110,11 @@ type Gerrit struct {
 	// RateLimit defines how many changes to query per gerrit API call
 	// default is 5.
 	RateLimit int `json:"ratelimit,omitempty"`
+	// QueryParallelism is how many pages of RateLimit changes are requested
+	// at once when querying the changes of a project. Raising it speeds up
+	// syncing projects with many changes on large instances. Pages are
+	// requested one at a time by default.
+	QueryParallelism int `json:"query_parallelism,omitempty"`
 	// DeckURL is the root URL of Deck. This is used to construct links to
 	// job runs for a given CL.
 	DeckURL        string                `json:"deck_url,omitempty"`
@@ -955,6 +1122,37 @@ type Gerrit struct {
 	// AllowedPresubmitTriggerRe is used to match presubmit test related commands in comments
 	AllowedPresubmitTriggerRe          *CopyableRegexp `json:"-"`
 	AllowedPresubmitTriggerReRawString string          `json:"allowed_presubmit_trigger_re,omitempty"`
+	// EventStream makes the adapter consume the events of the Gerrit
+	// instances and sync a project as soon as something happens in it,
+	// instead of waiting up to TickInterval for the next poll. Projects
+	// are still polled every TickInterval to catch up on missed events.
+	EventStream *GerritEventStream `json:"event_stream,omitempty"`
+}
+
+const (
+	// GerritEventStreamSSH consumes `gerrit stream-events` over SSH.
+	GerritEventStreamSSH = "ssh"
+	// GerritEventStreamEventsLog long-polls the REST API of the events-log
+	// plugin.
+	GerritEventStreamEventsLog = "events-log"
+)
+
+// GerritEventStream configures where the Gerrit adapter gets events from.
+type GerritEventStream struct {
+	// Source is either "ssh" to run `gerrit stream-events` over SSH or
+	// "events-log" to poll the REST API of the events-log plugin.
+	Source string `json:"source"`
+	// SSHUser is the user to connect to Gerrit over SSH as. Defaults to
+	// the user of the SSH configuration of the adapter.
+	SSHUser string `json:"ssh_user,omitempty"`
+	// SSHPort is the port of the SSH daemon of Gerrit. Defaults to 29418.
+	SSHPort int `json:"ssh_port,omitempty"`
+	// SSHIdentityFile is the path of the private key to authenticate with
+	// over SSH.
+	SSHIdentityFile string `json:"ssh_identity_file,omitempty"`
+	// PollInterval is how often the events-log plugin is asked for new
+	// events. Defaults to 5s.
+	PollInterval *metav1.Duration `json:"poll_interval,omitempty"`
 }
 
 func (g *Gerrit) DefaultAndValidate() error {
@@ -966,6 +1164,20 @@ func (g *Gerrit) DefaultAndValidate() error {
 		g.RateLimit = 5
 	}
 
+	if es := g.EventStream; es != nil {
+		switch es.Source {
+		case GerritEventStreamSSH, GerritEventStreamEventsLog:
+		default:
+			return fmt.Errorf("event_stream.source must be one of %q or %q, not %q", GerritEventStreamSSH, GerritEventStreamEventsLog, es.Source)
+		}
+		if es.SSHPort == 0 {
+			es.SSHPort = 29418
+		}
+		if es.PollInterval == nil {
+			es.PollInterval = &metav1.Duration{Duration: 5 * time.Second}
+		}
+	}
+
 	re, err := regexp.Compile(g.AllowedPresubmitTriggerReRawString)
 	if err != nil {
 		return fmt.Errorf("failed to compile regex for allowed presubmit triggers: %s", err.Error())
@@ -996,6 +1208,25 @@ type GerritOrgRepoConfig struct {
 	// Filters are used for limiting the scope of querying the Gerrit server.
 	// Currently supports branches and excluded branches.
 	Filters *GerritQueryFilter `json:"filters,omitempty"`
+	// VotingStrategy configures how the Gerrit reporter votes on the label of
+	// the reported jobs for the repos defined under here.
+	VotingStrategy *GerritVotingStrategy `json:"voting_strategy,omitempty"`
+}
+
+// GerritVotingStrategy configures how the Gerrit reporter votes on changes.
+type GerritVotingStrategy struct {
+	// LatestPatchsetOnly makes the reporter only vote when the reported
+	// revision is still the latest patchset of the change. Results for older
+	// patchsets are still posted as comments.
+	LatestPatchsetOnly bool `json:"latest_patchset_only,omitempty"`
+	// ResetOnNewPatchset makes the reporter reset its vote to 0 once jobs
+	// start on a new patchset, so that votes copied from a previous patchset
+	// do not linger until the new results are reported.
+	ResetOnNewPatchset bool `json:"reset_on_new_patchset,omitempty"`
+	// ReportSubmitRequirements makes the reporter list the submit
+	// requirements still blocking the change when all jobs passed. Requires
+	// Gerrit 3.5 or newer.
+	ReportSubmitRequirements bool `json:"report_submit_requirements,omitempty"`
 }
 
 type GerritQueryFilter struct {
@@ -1022,6 +1253,25 @@ func (goc *GerritOrgRepoConfigs) AllRepos() map[string]map[string]*GerritQueryFi
 	return res
 }
 
+// VotingStrategy returns the voting strategy configured for the repo of the
+// given Gerrit instance, or nil if there is none.
+func (goc *GerritOrgRepoConfigs) VotingStrategy(instance, repo string) *GerritVotingStrategy {
+	if goc == nil {
+		return nil
+	}
+	for _, orgConfig := range *goc {
+		if orgConfig.Org != instance {
+			continue
+		}
+		for _, r := range orgConfig.Repos {
+			if r == repo {
+				return orgConfig.VotingStrategy
+			}
+		}
+	}
+	return nil
+}
+
 func (goc *GerritOrgRepoConfigs) OptOutHelpRepos() map[string]sets.Set[string] {
 	var res map[string]sets.Set[string]
 	for _, orgConfig := range *goc {
@@ -1043,6 +1293,310 @@ type Horologium struct {
 	TickInterval *metav1.Duration `json:"tick_interval,omitempty"`
 }
 
+// Retester is config for the retester.
+//
+// The retester comments /retest-required on the open pull requests of its
+// repos that have all RequiredLabels, none of whose required jobs are running
+// and whose only failing required jobs failed like flakes. A failure looks
+// like a flake if the job errored, if it also passed on the same commit, or
+// if it failed on at least MinClusterSize other pull requests within the
+// FlakeWindow, the same as for the smart retests of the trigger plugin.
+type Retester struct {
+	// ResyncPeriod is how often pull requests are checked. Defaults to 10m.
+	ResyncPeriod *metav1.Duration `json:"resync_period,omitempty"`
+	// Repos are the orgs and org/repos whose pull requests are retested.
+	Repos []string `json:"repos,omitempty"`
+	// RequiredLabels are the labels a pull request needs to be retested.
+	// Defaults to lgtm and approved.
+	RequiredLabels []string `json:"required_labels,omitempty"`
+	// ExclusionLabel keeps pull requests from being retested.
+	// Defaults to do-not-retest.
+	ExclusionLabel string `json:"exclusion_label,omitempty"`
+	// MaxRetestsPerDay is the max number of retests of a pull request within
+	// any 24 hours. Defaults to 3.
+	MaxRetestsPerDay int `json:"max_retests_per_day,omitempty"`
+	// FlakeWindow is how far back runs of a job are considered when looking
+	// for clusters of failures. Defaults to 24h.
+	FlakeWindow *metav1.Duration `json:"flake_window,omitempty"`
+	// MinClusterSize is the number of other pull requests a job must have
+	// failed on within the FlakeWindow for its failures to be classified as
+	// flakes. Defaults to 3.
+	MinClusterSize int `json:"min_cluster_size,omitempty"`
+}
+
+// GetResyncPeriod returns the ResyncPeriod, or its default if unset.
+func (r Retester) GetResyncPeriod() time.Duration {
+	if r.ResyncPeriod == nil || r.ResyncPeriod.Duration <= 0 {
+		return 10 * time.Minute
+	}
+	return r.ResyncPeriod.Duration
+}
+
+// GetRequiredLabels returns the RequiredLabels, or their default if unset.
+func (r Retester) GetRequiredLabels() []string {
+	if len(r.RequiredLabels) == 0 {
+		return []string{prowlabels.LGTM, prowlabels.Approved}
+	}
+	return r.RequiredLabels
+}
+
+// GetExclusionLabel returns the ExclusionLabel, or its default if unset.
+func (r Retester) GetExclusionLabel() string {
+	if r.ExclusionLabel == "" {
+		return prowlabels.NoRetest
+	}
+	return r.ExclusionLabel
+}
+
+// GetMaxRetestsPerDay returns the MaxRetestsPerDay, or its default if unset.
+func (r Retester) GetMaxRetestsPerDay() int {
+	if r.MaxRetestsPerDay <= 0 {
+		return 3
+	}
+	return r.MaxRetestsPerDay
+}
+
+// GetFlakeWindow returns the FlakeWindow, or its default if unset.
+func (r Retester) GetFlakeWindow() time.Duration {
+	if r.FlakeWindow == nil || r.FlakeWindow.Duration <= 0 {
+		return 24 * time.Hour
+	}
+	return r.FlakeWindow.Duration
+}
+
+// GetMinClusterSize returns the MinClusterSize, or its default if unset.
+func (r Retester) GetMinClusterSize() int {
+	if r.MinClusterSize <= 0 {
+		return 3
+	}
+	return r.MinClusterSize
+}
+
+// FailingPeriodics is config for failing-periodics.
+//
+// failing-periodics opens an issue in the Repo for every periodic job whose
+// last ConsecutiveFailures runs failed or errored, keeps it up to date with the
+// latest failures while the job keeps failing, and closes it once the job
+// passes again. The issues are assigned to the Assignees of the owner of the
+// job, as declared by its prow.k8s.io/owner annotation.
+type FailingPeriodics struct {
+	// ResyncPeriod is how often periodic jobs are checked. Defaults to 10m.
+	ResyncPeriod *metav1.Duration `json:"resync_period,omitempty"`
+	// Repo is the org/repo issues are opened in. No issues are opened if
+	// it is unset.
+	Repo string `json:"repo,omitempty"`
+	// ConsecutiveFailures is the number of consecutive failures of a job
+	// after which an issue is opened. Defaults to 3.
+	ConsecutiveFailures int `json:"consecutive_failures,omitempty"`
+	// Labels are added to the issues when they are opened.
+	Labels []string `json:"labels,omitempty"`
+	// Assignees are the GitHub logins the issues of the jobs of an owner are
+	// assigned to, by owner.
+	Assignees map[string][]string `json:"assignees,omitempty"`
+	// RecentFailures is the number of the latest failures linked from an
+	// issue. Defaults to 5.
+	RecentFailures int `json:"recent_failures,omitempty"`
+}
+
+// GetResyncPeriod returns the ResyncPeriod, or its default if unset.
+func (f FailingPeriodics) GetResyncPeriod() time.Duration {
+	if f.ResyncPeriod == nil || f.ResyncPeriod.Duration <= 0 {
+		return 10 * time.Minute
+	}
+	return f.ResyncPeriod.Duration
+}
+
+// GetConsecutiveFailures returns the ConsecutiveFailures, or its default if
+// unset.
+func (f FailingPeriodics) GetConsecutiveFailures() int {
+	if f.ConsecutiveFailures <= 0 {
+		return 3
+	}
+	return f.ConsecutiveFailures
+}
+
+// GetRecentFailures returns the RecentFailures, or its default if unset.
+func (f FailingPeriodics) GetRecentFailures() int {
+	if f.RecentFailures <= 0 {
+		return 5
+	}
+	return f.RecentFailures
+}
+
+// Validate validates the FailingPeriodics.
+func (f FailingPeriodics) Validate() error {
+	if f.Repo != "" {
+		if org, repo, ok := strings.Cut(f.Repo, "/"); !ok || org == "" || repo == "" || strings.Contains(repo, "/") {
+			return fmt.Errorf("failing_periodics.repo: %q is not an org/repo", f.Repo)
+		}
+	}
+	if f.ConsecutiveFailures < 0 {
+		return errors.New("failing_periodics.consecutive_failures must not be negative")
+	}
+	if f.RecentFailures < 0 {
+		return errors.New("failing_periodics.recent_failures must not be negative")
+	}
+	return nil
+}
+
+// CostAccounting is config for estimating the cost of jobs.
+//
+// The cost of a job is the sum of the resources requested by the containers
+// of its pod, times the time the pod ran, times the prices of its build
+// cluster. The cost is recorded in the finished.json of the job by crier,
+// exported as a metric by the exporter and reported by repo and SIG in Deck.
+type CostAccounting struct {
+	// Prices are the prices of resources by build cluster alias. The prices
+	// of the "*" cluster apply to clusters without their own. The cost of jobs
+	// is only estimated for clusters with prices.
+	Prices map[string]ResourcePrices `json:"prices,omitempty"`
+	// Currency is the currency of the prices, as shown in reports.
+	// Defaults to USD.
+	Currency string `json:"currency,omitempty"`
+	// SigLabel is the job label whose value is the SIG a job is charged to.
+	// Defaults to sig.
+	SigLabel string `json:"sig_label,omitempty"`
+}
+
+// ResourcePrices are the hourly prices of the resources requested by jobs.
+type ResourcePrices struct {
+	// CPU is the price of a CPU core for an hour.
+	CPU float64 `json:"cpu,omitempty"`
+	// Memory is the price of a GiB of memory for an hour.
+	Memory float64 `json:"memory,omitempty"`
+	// GPU is the price of a nvidia.com/gpu for an hour.
+	GPU float64 `json:"gpu,omitempty"`
+}
+
+// Validate validates the prices of the CostAccounting.
+func (c CostAccounting) Validate() error {
+	for cluster, prices := range c.Prices {
+		if prices.CPU < 0 || prices.Memory < 0 || prices.GPU < 0 {
+			return fmt.Errorf("cost_accounting.prices[%q]: prices must not be negative", cluster)
+		}
+	}
+	return nil
+}
+
+// PricesFor returns the prices of a build cluster, and whether the cost of
+// its jobs is estimated at all.
+func (c CostAccounting) PricesFor(cluster string) (ResourcePrices, bool) {
+	if prices, ok := c.Prices[cluster]; ok {
+		return prices, true
+	}
+	prices, ok := c.Prices["*"]
+	return prices, ok
+}
+
+// GetCurrency returns the Currency, or its default if unset.
+func (c CostAccounting) GetCurrency() string {
+	if c.Currency == "" {
+		return "USD"
+	}
+	return c.Currency
+}
+
+// GetSigLabel returns the SigLabel, or its default if unset.
+func (c CostAccounting) GetSigLabel() string {
+	if c.SigLabel == "" {
+		return "sig"
+	}
+	return c.SigLabel
+}
+
+// NamespaceProvisioner is config for the namespace-provisioner.
+//
+// The namespace-provisioner creates the namespace of every tenant in the
+// build clusters, along with the ResourceQuota, LimitRange, NetworkPolicy
+// and image pull secrets configured for it, and keeps them up to date.
+// Namespaces that already exist are used as they are. Nothing is deleted
+// when a tenant is removed from the config.
+type NamespaceProvisioner struct {
+	// ResyncPeriod is how often the build clusters are synced. Defaults to 10m.
+	ResyncPeriod *metav1.Duration `json:"resync_period,omitempty"`
+	// Tenants are the tenants whose namespaces are provisioned.
+	Tenants []TenantNamespace `json:"tenants,omitempty"`
+}
+
+// TenantNamespace is the namespace of a tenant in the build clusters.
+type TenantNamespace struct {
+	// Name is the name of the tenant, which labels the objects created for it.
+	Name string `json:"name"`
+	// Namespace is the namespace of the jobs of the tenant. Defaults to Name.
+	Namespace string `json:"namespace,omitempty"`
+	// Clusters are the aliases of the build clusters the namespace is
+	// provisioned in. Defaults to all of them.
+	Clusters []string `json:"clusters,omitempty"`
+	// ResourceQuota is the total of the resources the pods of the namespace
+	// may use, e.g. requests.cpu or pods. No ResourceQuota is created if unset.
+	ResourceQuota v1.ResourceList `json:"resource_quota,omitempty"`
+	// DefaultRequests and DefaultLimits are the resources of the containers
+	// of the namespace that do not set them. No LimitRange is created if
+	// neither is set.
+	DefaultRequests v1.ResourceList `json:"default_requests,omitempty"`
+	DefaultLimits   v1.ResourceList `json:"default_limits,omitempty"`
+	// IsolateNetwork keeps the pods of other namespaces from connecting to
+	// the pods of the namespace.
+	IsolateNetwork bool `json:"isolate_network,omitempty"`
+	// ImagePullSecrets are the names of secrets in the ProwJob namespace that
+	// are copied to the namespace and used by its default service account.
+	ImagePullSecrets []string `json:"image_pull_secrets,omitempty"`
+}
+
+// GetNamespace returns the Namespace, or its default if unset.
+func (t TenantNamespace) GetNamespace() string {
+	if t.Namespace == "" {
+		return t.Name
+	}
+	return t.Namespace
+}
+
+// InCluster returns whether the namespace is provisioned in a build cluster.
+func (t TenantNamespace) InCluster(cluster string) bool {
+	return len(t.Clusters) == 0 || sets.New[string](t.Clusters...).Has(cluster)
+}
+
+// GetResyncPeriod returns the ResyncPeriod, or its default if unset.
+func (n NamespaceProvisioner) GetResyncPeriod() time.Duration {
+	if n.ResyncPeriod == nil || n.ResyncPeriod.Duration <= 0 {
+		return 10 * time.Minute
+	}
+	return n.ResyncPeriod.Duration
+}
+
+// Validate validates the tenants of the NamespaceProvisioner.
+func (n NamespaceProvisioner) Validate() error {
+	names := sets.New[string]()
+	for i, tenant := range n.Tenants {
+		if tenant.Name == "" {
+			return fmt.Errorf("namespace_provisioner.tenants[%d]: name must be set", i)
+		}
+		if names.Has(tenant.Name) {
+			return fmt.Errorf("namespace_provisioner.tenants[%d]: duplicate tenant %q", i, tenant.Name)
+		}
+		names.Insert(tenant.Name)
+		namespace := tenant.GetNamespace()
+		if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+			return fmt.Errorf("namespace_provisioner.tenants[%d]: invalid namespace %q: %s", i, namespace, strings.Join(errs, ", "))
+		}
+		for _, other := range n.Tenants[:i] {
+			if other.GetNamespace() == namespace && tenant.sharesClusters(other) {
+				return fmt.Errorf("namespace_provisioner.tenants[%d]: namespace %q is used by tenant %q in the same clusters", i, namespace, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// sharesClusters returns whether the namespaces of two tenants are
+// provisioned in any of the same build clusters.
+func (t TenantNamespace) sharesClusters(other TenantNamespace) bool {
+	if len(t.Clusters) == 0 || len(other.Clusters) == 0 {
+		return true
+	}
+	return sets.New[string](t.Clusters...).HasAny(other.Clusters...)
+}
+
 // JenkinsOperator is config for the jenkins-operator controller.
 type JenkinsOperator struct {
 	Controller `json:",inline"`
@@ -1073,6 +1627,107 @@ type GitHubReporter struct {
 	// comments is only sent when all jobs from current SHA are finished. Status
 	// contexts will still be written.
 	SummaryCommentRepos []string `json:"summary_comment_repos,omitempty"`
+	// AggregatedCommentRepos is a list of orgs and org/repos for which a single
+	// comment summarizing the results of all jobs for the latest commit of a PR
+	// is maintained instead of failure report comments. The comment is edited
+	// in place as jobs start and complete. Status contexts will still be written.
+	AggregatedCommentRepos []string `json:"aggregated_comment_repos,omitempty"`
+	// Templates are Go templates replacing the text of the failure comments
+	// and status contexts, by `org/repo`, `org` or `*`. The most specific
+	// template of each kind applies to a repo. These are unrelated to the
+	// report_templates of plank, which are appended to the default comments.
+	Templates map[string]GitHubReportTemplates `json:"templates,omitempty"`
+}
+
+// GitHubReportTemplates are Go templates for the text the GitHub reporter
+// posts. Besides the builtin functions of Go templates, they can use those of
+// ReportTemplateFuncs.
+type GitHubReportTemplates struct {
+	// Comment replaces the text of failure comments. It is executed with the
+	// report.CommentData of the pull request. The failed jobs are still
+	// tracked in a hidden part of the comment.
+	Comment string `json:"comment,omitempty"`
+	// Status replaces the descriptions of the status contexts of jobs. It is
+	// executed with the ProwJob.
+	Status string `json:"status,omitempty"`
+
+	commentTemplate *template.Template
+	statusTemplate  *template.Template
+}
+
+// ReportTemplateFuncs are the functions that GitHub report templates can use
+// besides the builtin ones.
+var ReportTemplateFuncs = template.FuncMap{
+	// truncate cuts text to at most n characters.
+	"truncate": func(n int, text string) string {
+		runes := []rune(text)
+		if len(runes) <= n {
+			return text
+		}
+		if n <= len("...") {
+			return string(runes[:n])
+		}
+		return string(runes[:n-len("...")]) + "..."
+	},
+	// codeBlock formats text as a Markdown code block.
+	"codeBlock": func(text string) string {
+		return "```\n" + strings.TrimSuffix(text, "\n") + "\n```"
+	},
+	// quote formats text as a Markdown quote.
+	"quote": func(text string) string {
+		return "> " + strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\n", "\n> ")
+	},
+}
+
+// CommentTemplate returns the compiled Comment template, or nil if unset.
+func (t GitHubReportTemplates) CommentTemplate() *template.Template {
+	return t.commentTemplate
+}
+
+// StatusTemplate returns the compiled Status template, or nil if unset.
+func (t GitHubReportTemplates) StatusTemplate() *template.Template {
+	return t.statusTemplate
+}
+
+// CommentTemplateForRepo returns the template of the failure comments of a
+// repo, or nil if it has none.
+func (r GitHubReporter) CommentTemplateForRepo(org, repo string) *template.Template {
+	for _, key := range []string{org + "/" + repo, org, "*"} {
+		if t := r.Templates[key].CommentTemplate(); t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// StatusTemplateForRepo returns the template of the status descriptions of
+// a repo, or nil if it has none.
+func (r GitHubReporter) StatusTemplateForRepo(org, repo string) *template.Template {
+	for _, key := range []string{org + "/" + repo, org, "*"} {
+		if t := r.Templates[key].StatusTemplate(); t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// parseTemplates compiles the Templates.
+func (r *GitHubReporter) parseTemplates() error {
+	for key, templates := range r.Templates {
+		var err error
+		if templates.Comment != "" {
+			if templates.commentTemplate, err = template.New("comment").Funcs(ReportTemplateFuncs).Parse(templates.Comment); err != nil {
+				return fmt.Errorf("github_reporter.templates[%q].comment: %w", key, err)
+			}
+		}
+		if templates.Status != "" {
+			if templates.statusTemplate, err = template.New("status").Funcs(ReportTemplateFuncs).Parse(templates.Status); err != nil {
+				return fmt.Errorf("github_reporter.templates[%q].status: %w", key, err)
+			}
+		}
+		r.Templates[key] = templates
+	}
+	return nil
 }
 
 // Sinker is config for the sinker controller.
@@ -1119,12 +1774,39 @@ type LensFileConfig struct {
 	Lens LensConfig `json:"lens"`
 	// RemoteConfig specifies how to access remote lenses.
 	RemoteConfig *LensRemoteConfig `json:"remote_config,omitempty"`
+	// Limits bound the requests that the lens server serves for the lens.
+	Limits *LensLimits `json:"limits,omitempty"`
+	// HistoryBuilds is how many prior builds of the job Deck gives the
+	// artifacts of to lenses that render the history of a job, like
+	// junithistory. Prior builds are read from the job history, so they
+	// are only found for jobs whose artifacts are in a storage bucket.
+	// Defaults to none.
+	HistoryBuilds int `json:"history_builds,omitempty"`
+	// Authorization restricts who is shown the lens, e.g. one that dumps
+	// config next to secrets. Only lenses served by Deck can be restricted.
+	Authorization *LensAuthorization `json:"authorization,omitempty"`
+}
+
+// LensAuthorization restricts who is shown a lens. The lens server denies the
+// requests of other viewers before fetching any artifact.
+type LensAuthorization struct {
+	// RequireLogin restricts the lens to users logged in with GitHub.
+	RequireLogin bool `json:"require_login,omitempty"`
+	// Viewers restricts the lens to these users once they logged in with
+	// GitHub, which implies require_login. As Deck has no org to resolve
+	// github_team_ids in, teams have to be given as github_team_slugs.
+	Viewers *prowapi.RerunAuthConfig `json:"viewers,omitempty"`
 }
 
 // LensRemoteConfig is the configuration for a remote lens.
 type LensRemoteConfig struct {
 	// The endpoint for the lense.
 	Endpoint string `json:"endpoint"`
+	// GRPCEndpoint is the host:port of a lens that serves version 2 of the
+	// lens protocol over gRPC. Deck then serves the lens itself, so it is
+	// mutually exclusive with Endpoint. Unless required_files or
+	// optional_files are set, the lens is given the files it declares.
+	GRPCEndpoint string `json:"grpc_endpoint,omitempty"`
 	// The parsed endpoint.
 	ParsedEndpoint *url.URL `json:"-"`
 	// The endpoint for static resources.
@@ -1137,6 +1819,71 @@ type LensRemoteConfig struct {
 	HideTitle *bool `json:"hide_title"`
 }
 
+// maxLensHistoryBuilds bounds how many builds a lens that renders the
+// history of a job fetches the artifacts of for each request.
+const maxLensHistoryBuilds = 100
+
+// LensLimits bound the requests a lens serves, so that a slow lens fails
+// its own requests instead of tying up the connections of the lens server.
+// Streams are not limited, as they are long-lived by design.
+type LensLimits struct {
+	// Timeout is the max duration of rendering the lens or of a callback,
+	// after which the request fails. Defaults to 30s.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// MaxConcurrency is the max number of requests the lens serves at once,
+	// including those that timed out but are still being rendered. Further
+	// requests are rejected. Unlimited if unset.
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+	// FailureThreshold is the number of consecutive timeouts after which all
+	// requests are rejected for CooldownPeriod. A single request is then let
+	// through to probe whether the lens recovered. Disabled if unset.
+	FailureThreshold int `json:"failure_threshold,omitempty"`
+	// CooldownPeriod is how long requests are rejected once FailureThreshold
+	// is reached. Defaults to 1m.
+	CooldownPeriod *metav1.Duration `json:"cooldown_period,omitempty"`
+}
+
+const (
+	defaultLensTimeout        = 30 * time.Second
+	defaultLensCooldownPeriod = time.Minute
+)
+
+// GetTimeout returns the timeout of requests, falling back to the default if
+// unset. It is safe to call on a nil receiver.
+func (l *LensLimits) GetTimeout() time.Duration {
+	if l == nil || l.Timeout == nil || l.Timeout.Duration <= 0 {
+		return defaultLensTimeout
+	}
+	return l.Timeout.Duration
+}
+
+// GetMaxConcurrency returns the max number of concurrent requests, or 0 if
+// unlimited. It is safe to call on a nil receiver.
+func (l *LensLimits) GetMaxConcurrency() int {
+	if l == nil || l.MaxConcurrency < 0 {
+		return 0
+	}
+	return l.MaxConcurrency
+}
+
+// GetFailureThreshold returns the number of consecutive timeouts that stop
+// requests, or 0 if disabled. It is safe to call on a nil receiver.
+func (l *LensLimits) GetFailureThreshold() int {
+	if l == nil || l.FailureThreshold < 0 {
+		return 0
+	}
+	return l.FailureThreshold
+}
+
+// GetCooldownPeriod returns how long requests are stopped, falling back to
+// the default if unset. It is safe to call on a nil receiver.
+func (l *LensLimits) GetCooldownPeriod() time.Duration {
+	if l == nil || l.CooldownPeriod == nil || l.CooldownPeriod.Duration <= 0 {
+		return defaultLensCooldownPeriod
+	}
+	return l.CooldownPeriod.Duration
+}
+
 // Spyglass holds config for Spyglass.
 type Spyglass struct {
 	// Lenses is a list of lens configurations.
@@ -1187,6 +1934,233 @@ type Spyglass struct {
 	// Keys represent aliases and their values are the authoritative
 	// bucket names they will be substituted with
 	BucketAliases map[string]string `json:"bucket_aliases,omitempty"`
+	// RawArtifactViewer configures Deck's raw artifact viewer served under
+	// /spyglass/raw/. If set, lenses link individual artifacts to the viewer
+	// instead of directly to the storage provider.
+	RawArtifactViewer *RawArtifactViewer `json:"raw_artifact_viewer,omitempty"`
+	// ArtifactDecryption configures the transparent decryption of the artifacts
+	// that jobs encrypt before uploading them. If unset, encrypted artifacts
+	// are shown as they are stored.
+	ArtifactDecryption *ArtifactDecryption `json:"artifact_decryption,omitempty"`
+	// ArtifactCache configures an in-memory cache of the attributes and
+	// content of artifacts, so that repeated views of the same job are served
+	// without fetching the artifacts from storage again. If unset, artifacts
+	// are not cached.
+	ArtifactCache *ArtifactCache `json:"artifact_cache,omitempty"`
+	// TriageNotes allows users to attach short notes to job runs, e.g. to
+	// tell the on-call that a failure is a known infra issue. Notes are
+	// stored next to the artifacts of the run and shown in Spyglass and the
+	// job history. If unset, notes can't be added.
+	TriageNotes *TriageNotes `json:"triage_notes,omitempty"`
+	// BuildLogHighlights are highlight rules of the buildlog lens for the
+	// jobs of an org or repo, keyed by "org" or "org/repo". The lines they
+	// match are expanded and pinned to the top of the log, on top of the
+	// lines matching the highlight_regexes of the lens. The lens needs
+	// prowjob.json among its optional files to know the repo of a job.
+	BuildLogHighlights map[string][]BuildLogHighlight `json:"build_log_highlights,omitempty"`
+	// KnownFailurePatterns are failures the buildlog lens recognizes in
+	// the logs of all jobs, e.g. known infra errors. The lines they match
+	// are highlighted and annotated with what the failure means and what
+	// to do about it.
+	KnownFailurePatterns []KnownFailurePattern `json:"known_failure_patterns,omitempty"`
+}
+
+// KnownFailurePattern is a known kind of failure and how to recognize it in
+// build logs.
+type KnownFailurePattern struct {
+	// Name is the class of the failure, e.g. "node NotReady".
+	Name string `json:"name"`
+	// Regex matches the lines of the failure.
+	Regex string `json:"regex"`
+	// Explanation tells what the failure means, e.g. whether it is caused
+	// by the infrastructure rather than the change under test.
+	Explanation string `json:"explanation"`
+	// Action is the suggested action, e.g. "/retest".
+	Action string `json:"action,omitempty"`
+	// Link points to more details, e.g. a runbook or tracking issue.
+	Link string `json:"link,omitempty"`
+}
+
+// BuildLogHighlight is a kind of build log lines worth pinning, e.g. error
+// patterns or timeout markers.
+type BuildLogHighlight struct {
+	// Name describes the lines, e.g. "timeout".
+	Name string `json:"name"`
+	// Regex matches the lines.
+	Regex string `json:"regex"`
+}
+
+// GetBuildLogHighlights returns the build log highlight rules of the jobs of
+// a repo, those of its org first.
+func (s Spyglass) GetBuildLogHighlights(org, repo string) []BuildLogHighlight {
+	if org == "" {
+		return nil
+	}
+	var highlights []BuildLogHighlight
+	highlights = append(highlights, s.BuildLogHighlights[org]...)
+	if repo != "" {
+		highlights = append(highlights, s.BuildLogHighlights[org+"/"+repo]...)
+	}
+	return highlights
+}
+
+// TriageNotes holds who may attach triage notes to job runs.
+type TriageNotes struct {
+	// Authors are the users who may add notes once they logged in with
+	// GitHub. As Deck has no org to resolve github_team_ids in, teams have to
+	// be given as github_team_slugs.
+	Authors prowapi.RerunAuthConfig `json:"authors"`
+	// MaxLength is the max number of characters of a note. Defaults to 500.
+	MaxLength int `json:"max_length,omitempty"`
+}
+
+const defaultTriageNoteMaxLength = 500
+
+// GetMaxLength returns the max length of a note, falling back to the default
+// if unset. It is safe to call on a nil receiver.
+func (n *TriageNotes) GetMaxLength() int {
+	if n == nil || n.MaxLength <= 0 {
+		return defaultTriageNoteMaxLength
+	}
+	return n.MaxLength
+}
+
+// ArtifactCache holds the limits of the Spyglass artifact cache.
+type ArtifactCache struct {
+	// TTL is how long the attributes of an artifact are cached before they
+	// are fetched again. Content is cached by object generation, so a changed
+	// artifact is never served stale once its attributes expired.
+	// Defaults to 5m.
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// MaxEntries is the max number of attributes and contents that are cached.
+	// It is only read when Deck starts. Defaults to 1000.
+	MaxEntries int `json:"max_entries,omitempty"`
+	// MaxArtifactSize is the max size in bytes of an artifact whose content is
+	// cached. Larger artifacts are always read from storage. Defaults to 10MiB.
+	MaxArtifactSize int64 `json:"max_artifact_size,omitempty"`
+}
+
+const (
+	defaultArtifactCacheTTL             = 5 * time.Minute
+	defaultArtifactCacheMaxEntries      = 1000
+	defaultArtifactCacheMaxArtifactSize = 10 * 1024 * 1024
+)
+
+// GetTTL returns the TTL of cached attributes, falling back to the default if
+// unset. It is safe to call on a nil receiver.
+func (c *ArtifactCache) GetTTL() time.Duration {
+	if c == nil || c.TTL == nil || c.TTL.Duration <= 0 {
+		return defaultArtifactCacheTTL
+	}
+	return c.TTL.Duration
+}
+
+// GetMaxEntries returns the max number of cache entries, falling back to the
+// default if unset. It is safe to call on a nil receiver.
+func (c *ArtifactCache) GetMaxEntries() int {
+	if c == nil || c.MaxEntries <= 0 {
+		return defaultArtifactCacheMaxEntries
+	}
+	return c.MaxEntries
+}
+
+// GetMaxArtifactSize returns the max size of cached content, falling back to
+// the default if unset. It is safe to call on a nil receiver.
+func (c *ArtifactCache) GetMaxArtifactSize() int64 {
+	if c == nil || c.MaxArtifactSize <= 0 {
+		return defaultArtifactCacheMaxArtifactSize
+	}
+	return c.MaxArtifactSize
+}
+
+// ArtifactDecryption holds who may see the content of encrypted artifacts in
+// Spyglass, and which keys Deck may use to decrypt them.
+type ArtifactDecryption struct {
+	// KeyURLs are the URLs of the keys that Deck may unwrap data keys with,
+	// matching the key_url of the encryption config of the jobs. Deck needs to
+	// be able to decrypt with them.
+	KeyURLs []string `json:"key_urls"`
+	// Viewers are the users who are shown the decrypted content of encrypted
+	// artifacts once they logged in with GitHub. As Deck has no org to resolve
+	// github_team_ids in, teams have to be given as github_team_slugs.
+	Viewers prowapi.RerunAuthConfig `json:"viewers"`
+}
+
+// GetKeyURLs returns the keys that Deck may decrypt artifacts with. It is
+// safe to call on a nil receiver.
+func (d *ArtifactDecryption) GetKeyURLs() []string {
+	if d == nil {
+		return nil
+	}
+	return d.KeyURLs
+}
+
+// RawArtifactViewer holds the limits enforced by the raw artifact viewer.
+type RawArtifactViewer struct {
+	// RenderSizeLimit is the max size in bytes of an artifact that will be
+	// pretty-printed and highlighted inline. Larger artifacts are downloaded
+	// instead. Defaults to 10MiB.
+	RenderSizeLimit int64 `json:"render_size_limit,omitempty"`
+	// DownloadSizeLimit is the max size in bytes of an artifact that will be
+	// served at all. Defaults to 1GiB.
+	DownloadSizeLimit int64 `json:"download_size_limit,omitempty"`
+	// Timeout is the max duration of a single request to the viewer,
+	// including streaming the artifact. Defaults to 5m.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// SignedURLDownloads redirects downloads to short-lived signed URLs of
+	// the storage provider instead of streaming them through Deck, once Deck
+	// checked the artifact may be viewed. Encrypted artifacts are still
+	// streamed so they can be decrypted. Requires credentials that can sign
+	// URLs, e.g. a GCS service account key or S3 credentials.
+	SignedURLDownloads bool `json:"signed_url_downloads,omitempty"`
+	// SignedURLExpiry is how long signed URLs are valid. Defaults to 10m,
+	// and must be at most 7 days.
+	SignedURLExpiry *metav1.Duration `json:"signed_url_expiry,omitempty"`
+}
+
+const (
+	defaultRawArtifactRenderSizeLimit   = 10 * 1024 * 1024
+	defaultRawArtifactDownloadSizeLimit = 1024 * 1024 * 1024
+	defaultRawArtifactTimeout           = 5 * time.Minute
+	defaultRawArtifactSignedURLExpiry   = 10 * time.Minute
+	// maxSignedURLExpiry is the longest expiry of GCS V4 and S3 signed URLs.
+	maxSignedURLExpiry = 7 * 24 * time.Hour
+)
+
+// GetRenderSizeLimit returns the render size limit, falling back to the
+// default if unset. It is safe to call on a nil receiver.
+func (r *RawArtifactViewer) GetRenderSizeLimit() int64 {
+	if r == nil || r.RenderSizeLimit <= 0 {
+		return defaultRawArtifactRenderSizeLimit
+	}
+	return r.RenderSizeLimit
+}
+
+// GetDownloadSizeLimit returns the download size limit, falling back to the
+// default if unset. It is safe to call on a nil receiver.
+func (r *RawArtifactViewer) GetDownloadSizeLimit() int64 {
+	if r == nil || r.DownloadSizeLimit <= 0 {
+		return defaultRawArtifactDownloadSizeLimit
+	}
+	return r.DownloadSizeLimit
+}
+
+// GetTimeout returns the request timeout, falling back to the default if
+// unset. It is safe to call on a nil receiver.
+func (r *RawArtifactViewer) GetTimeout() time.Duration {
+	if r == nil || r.Timeout == nil || r.Timeout.Duration <= 0 {
+		return defaultRawArtifactTimeout
+	}
+	return r.Timeout.Duration
+}
+
+// GetSignedURLExpiry returns how long signed URLs are valid, falling back to
+// the default if unset. It is safe to call on a nil receiver.
+func (r *RawArtifactViewer) GetSignedURLExpiry() time.Duration {
+	if r == nil || r.SignedURLExpiry == nil || r.SignedURLExpiry.Duration <= 0 {
+		return defaultRawArtifactSignedURLExpiry
+	}
+	return r.SignedURLExpiry.Duration
 }
 
 type GCSBrowserPrefixes map[string]string
@@ -1265,6 +2239,115 @@ type Deck struct {
 	// AllKnownStorageBuckets contains all storage buckets configured in all of the
 	// job configs.
 	AllKnownStorageBuckets sets.Set[string] `json:"-"`
+	// JobHistorySources are groups of storage locations that hold the results
+	// of the same jobs, e.g. before and after a storage migration or when
+	// results are uploaded to several clouds. The job history of a job stored
+	// under any location of a group merges the runs stored under all of them.
+	JobHistorySources []JobHistorySources `json:"job_history_sources,omitempty"`
+	// HealthAggregation configures the health view of Deck, which shows
+	// whether the Prow components are alive and agree on the config.
+	HealthAggregation *HealthAggregation `json:"health_aggregation,omitempty"`
+}
+
+// HealthAggregation lists the Prow components whose health Deck checks.
+type HealthAggregation struct {
+	// Components are the Prow components to check.
+	Components []HealthComponent `json:"components"`
+	// UpdatePeriod specifies how often the components are checked. Defaults
+	// to 1m.
+	UpdatePeriod *metav1.Duration `json:"update_period,omitempty"`
+}
+
+// HealthComponent is a Prow component that serves the health endpoints of
+// pjutil.Health: /healthz, /readyz and /confighash.
+type HealthComponent struct {
+	// Name of the component, e.g. hook.
+	Name string `json:"name"`
+	// URL is the base URL of the health endpoints of the component, e.g.
+	// http://hook.default.svc.cluster.local:8081.
+	URL string `json:"url"`
+}
+
+// GetUpdatePeriod returns how often the components are checked.
+func (h *HealthAggregation) GetUpdatePeriod() time.Duration {
+	if h.UpdatePeriod == nil {
+		return time.Minute
+	}
+	return h.UpdatePeriod.Duration
+}
+
+// Validate checks that the components have unique names and valid URLs.
+func (h *HealthAggregation) Validate() error {
+	if h.UpdatePeriod != nil && h.UpdatePeriod.Duration <= 0 {
+		return errors.New("update_period must be positive")
+	}
+	names := sets.New[string]()
+	for i, component := range h.Components {
+		if component.Name == "" {
+			return fmt.Errorf("components[%d] has no name", i)
+		}
+		if names.Has(component.Name) {
+			return fmt.Errorf("duplicate component %q", component.Name)
+		}
+		names.Insert(component.Name)
+		if u, err := url.Parse(component.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("component %q has an invalid URL %q", component.Name, component.URL)
+		}
+	}
+	return nil
+}
+
+// JobHistorySources is a group of storage locations that hold the results of
+// the same jobs.
+type JobHistorySources struct {
+	// Locations are the storage locations in the <provider>://<bucket>/<prefix>
+	// form, e.g. gs://old-bucket/logs. The job stored under
+	// gs://new-bucket/logs/ci-job is also looked up under
+	// gs://old-bucket/logs/ci-job if both locations are in the group.
+	// The prefix may be empty to group whole buckets.
+	Locations []string `json:"locations"`
+}
+
+// JobHistoryLocations returns the locations of the results of the job stored
+// at the given location, e.g. gs://bucket/logs/ci-job, in all the groups of
+// JobHistorySources that include it. The given location comes first.
+func (d *Deck) JobHistoryLocations(location string) []string {
+	locations := []string{location}
+	seen := sets.New[string](location)
+	for _, sources := range d.JobHistorySources {
+		for _, source := range sources.Locations {
+			source = strings.TrimSuffix(source, "/")
+			if location != source && !strings.HasPrefix(location, source+"/") {
+				continue
+			}
+			relative := strings.TrimPrefix(location, source)
+			for _, other := range sources.Locations {
+				if l := strings.TrimSuffix(other, "/") + relative; !seen.Has(l) {
+					seen.Insert(l)
+					locations = append(locations, l)
+				}
+			}
+			break
+		}
+	}
+	return locations
+}
+
+// Validate checks that the group has at least two valid locations.
+func (s JobHistorySources) Validate() error {
+	if len(s.Locations) < 2 {
+		return errors.New("at least two locations are needed")
+	}
+	for _, location := range s.Locations {
+		provider, _, _, err := providers.ParseStoragePath(location)
+		if err != nil {
+			return err
+		}
+		if provider == "" {
+			return fmt.Errorf("location %q has no storage provider", location)
+		}
+	}
+	return nil
 }
 
 // Validate performs validation and sanitization on the Deck object.
@@ -1279,6 +2362,84 @@ func (d *Deck) Validate() error {
 		}
 	}
 
+	for i, sources := range d.JobHistorySources {
+		if err := sources.Validate(); err != nil {
+			return fmt.Errorf("job_history_sources[%d]: %w", i, err)
+		}
+	}
+
+	if d.HealthAggregation != nil {
+		if err := d.HealthAggregation.Validate(); err != nil {
+			return fmt.Errorf("health_aggregation: %w", err)
+		}
+	}
+
+	if viewer := d.Spyglass.RawArtifactViewer; viewer != nil && viewer.SignedURLExpiry != nil {
+		if expiry := viewer.SignedURLExpiry.Duration; expiry <= 0 || expiry > maxSignedURLExpiry {
+			return fmt.Errorf("spyglass.raw_artifact_viewer.signed_url_expiry must be positive and at most %s", maxSignedURLExpiry)
+		}
+	}
+
+	if decryption := d.Spyglass.ArtifactDecryption; decryption != nil && len(decryption.KeyURLs) == 0 {
+		return errors.New("spyglass.artifact_decryption.key_urls must not be empty")
+	}
+
+	if notes := d.Spyglass.TriageNotes; notes != nil {
+		if notes.MaxLength < 0 {
+			return errors.New("spyglass.triage_notes.max_length must not be negative")
+		}
+		if err := notes.Authors.Validate(); err != nil {
+			return fmt.Errorf("spyglass.triage_notes.authors: %w", err)
+		}
+	}
+
+	for i, lens := range d.Spyglass.Lenses {
+		auth := lens.Authorization
+		if auth == nil {
+			continue
+		}
+		if !auth.RequireLogin && auth.Viewers == nil {
+			return fmt.Errorf("spyglass.lenses[%d].authorization: one of require_login or viewers must be set", i)
+		}
+		if auth.Viewers.IsAllowAnyone() {
+			return fmt.Errorf("spyglass.lenses[%d].authorization.viewers: allow_anyone would not restrict the lens", i)
+		}
+		if err := auth.Viewers.Validate(); err != nil {
+			return fmt.Errorf("spyglass.lenses[%d].authorization.viewers: %w", i, err)
+		}
+	}
+
+	for orgRepo, highlights := range d.Spyglass.BuildLogHighlights {
+		if orgRepo == "" || strings.Count(orgRepo, "/") > 1 || strings.HasPrefix(orgRepo, "/") || strings.HasSuffix(orgRepo, "/") {
+			return fmt.Errorf("spyglass.build_log_highlights: %q is not an org or org/repo", orgRepo)
+		}
+		for i, highlight := range highlights {
+			if highlight.Name == "" {
+				return fmt.Errorf("spyglass.build_log_highlights[%s][%d]: name must not be empty", orgRepo, i)
+			}
+			if _, err := regexp.Compile(highlight.Regex); err != nil || highlight.Regex == "" {
+				return fmt.Errorf("spyglass.build_log_highlights[%s][%d]: invalid regex %q", orgRepo, i, highlight.Regex)
+			}
+		}
+	}
+
+	for i, pattern := range d.Spyglass.KnownFailurePatterns {
+		if pattern.Name == "" {
+			return fmt.Errorf("spyglass.known_failure_patterns[%d]: name must not be empty", i)
+		}
+		if _, err := regexp.Compile(pattern.Regex); err != nil || pattern.Regex == "" {
+			return fmt.Errorf("spyglass.known_failure_patterns[%d]: invalid regex %q", i, pattern.Regex)
+		}
+		if pattern.Explanation == "" {
+			return fmt.Errorf("spyglass.known_failure_patterns[%d]: explanation must not be empty", i)
+		}
+		if pattern.Link != "" {
+			if u, err := url.Parse(pattern.Link); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("spyglass.known_failure_patterns[%d]: link %q is not an http(s) URL", i, pattern.Link)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -1304,6 +2465,16 @@ func IsNotAllowedBucketError(err error) bool {
 	return errors.Is(err, notAllowedBucketError{})
 }
 
+// Hash returns the SHA-256 hash of the serialized config. Components loaded
+// from the same config files have the same hash.
+func (c *Config) Hash() (string, error) {
+	raw, err := yaml.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(raw)), nil
+}
+
 // ValidateStorageBucket validates a storage bucket (unless the `Deck.SkipStoragePathValidation` field is true).
 // The bucket name must be included in any of the following:
 //  1. Any job's `.DecorationConfig.GCSConfiguration.Bucket` (except jobs defined externally via InRepoConfig).
@@ -1533,6 +2704,10 @@ type PubSubTrigger struct {
 	AllowedClusters []string `json:"allowed_clusters"`
 	// MaxOutstandingMessages is the max number of messaged being processed, default is 10.
 	MaxOutstandingMessages int `json:"max_outstanding_messages"`
+	// ReplyTopic is the topic of the project where sub publishes why the
+	// messages of the topics did not trigger ProwJobs, e.g. because their
+	// payload is invalid. Nothing is published if unset.
+	ReplyTopic string `json:"reply_topic,omitempty"`
 }
 
 // GitHubOptions allows users to control how prow applications display GitHub website links.
@@ -1752,7 +2927,42 @@ func ReadJobConfig(jobConfig string, yamlOpts ...yaml.JSONOpt) (JobConfig, error
 		}
 		return nil
 	})
-	err = utilerrors.NewAggregate(append(errs, err))
+	errs = append(errs, err)
+
+	// Files that were too large for a single shard of a sharded ConfigMap
+	// are reassembled from their parts.
+	shardedFiles, err := readShardedFiles(jobConfig)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for path, content := range shardedFiles {
+		if filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml" {
+			continue
+		}
+		base := filepath.Base(path)
+		if uniqueBasenames.Has(base) {
+			errs = append(errs, fmt.Errorf("duplicated basename is not allowed: %s", base))
+			continue
+		}
+		uniqueBasenames.Insert(base)
+		b, err := maybeGunzip(content)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error reading %s: %w", path, err))
+			continue
+		}
+		var subConfig JobConfig
+		if err := yamlBytesToConfig(path, b, &subConfig, yamlOpts...); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if jc, err = mergeJobConfigs(jc, subConfig); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		jobConfigCount++
+	}
+
+	err = utilerrors.NewAggregate(errs)
 	if err != nil {
 		return JobConfig{}, err
 	}
@@ -1907,6 +3117,12 @@ func yamlToConfig(path string, nc interface{}, opts ...yaml.JSONOpt) error {
 	if err != nil {
 		return fmt.Errorf("error reading %s: %w", path, err)
 	}
+	return yamlBytesToConfig(path, b, nc, opts...)
+}
+
+// yamlBytesToConfig converts the yaml content of the file at path into a
+// Config object.
+func yamlBytesToConfig(path string, b []byte, nc interface{}, opts ...yaml.JSONOpt) error {
 	if err := yaml.Unmarshal(b, nc, opts...); err != nil {
 		return fmt.Errorf("error unmarshalling %s: %w", path, err)
 	}
@@ -1954,6 +3170,12 @@ func ReadFileMaybeGZIP(path string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	return maybeGunzip(b)
+}
+
+// maybeGunzip returns the decompressed content if it is gzipped, or
+// otherwise the raw content.
+func maybeGunzip(b []byte) ([]byte, error) {
 	// check if file contains gzip header: http://www.zlib.org/rfc-gzip.html.
 	if !bytes.HasPrefix(b, []byte("\x1F\x8B")) {
 		// go ahead and return the contents if not gzipped.
@@ -2209,6 +3431,24 @@ func (c *Config) validateComponentConfig() error {
 		return err
 	}
 
+	if err := c.CostAccounting.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.FailingPeriodics.Validate(); err != nil {
+		return err
+	}
+
+	for component, spec := range c.LogLevels {
+		if _, err := logrusutil.ParseLevels(spec); err != nil {
+			return fmt.Errorf("invalid log_levels for %q: %w", component, err)
+		}
+	}
+
+	if err := c.NamespaceProvisioner.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -2264,6 +3504,9 @@ func (c Config) validateJobBase(v JobBase, jobType prowapi.ProwJobType) error {
 	if err := validateJobQueueName(v.JobQueueName, validJobQueueNames); err != nil {
 		return err
 	}
+	if err := c.validateDecorationTimeout(v); err != nil {
+		return err
+	}
 	if v.Spec == nil || len(v.Spec.Containers) == 0 {
 		return nil // jenkins jobs have no spec.
 	}
@@ -2281,6 +3524,18 @@ func (c Config) validateJobBase(v JobBase, jobType prowapi.ProwJobType) error {
 	return nil
 }
 
+// validateDecorationTimeout rejects jobs whose decoration timeout exceeds
+// plank.max_decoration_timeout, which webhook-server would refuse to admit.
+func (c Config) validateDecorationTimeout(v JobBase) error {
+	if c.Plank.MaxDecorationTimeout == nil || v.DecorationConfig == nil || v.DecorationConfig.Timeout == nil {
+		return nil
+	}
+	if timeout, max := v.DecorationConfig.Timeout.Duration, c.Plank.MaxDecorationTimeout.Duration; timeout > max {
+		return fmt.Errorf("decoration_config.timeout: %s exceeds plank.max_decoration_timeout of %s", timeout, max)
+	}
+	return nil
+}
+
 // validatePresubmits validates the presubmits for one repo.
 func (c Config) validatePresubmits(presubmits []Presubmit) error {
 	validPresubmits := map[string][]Presubmit{}
@@ -2425,6 +3680,16 @@ func (c Config) validatePeriodics(periodics []Periodic) error {
 			}
 		}
 
+		switch p.GetConcurrencyPolicy() {
+		case PeriodicConcurrencySkip:
+		case PeriodicConcurrencyQueue, PeriodicConcurrencyReplace, PeriodicConcurrencyAllow:
+			if p.MinimumInterval != "" {
+				errs = append(errs, fmt.Errorf("concurrency_policy %q can't be used with minimum_interval in periodic %s", p.ConcurrencyPolicy, p.Name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("invalid concurrency_policy %q in periodic %s, must be one of %q, %q, %q or %q", p.ConcurrencyPolicy, p.Name, PeriodicConcurrencySkip, PeriodicConcurrencyQueue, PeriodicConcurrencyReplace, PeriodicConcurrencyAllow))
+		}
+
 		// Set the interval on the periodic jobs. It doesn't make sense to do this
 		// for child jobs.
 		if p.Interval != "" {
@@ -2495,6 +3760,35 @@ func parseProwConfig(c *Config) error {
 		c.Plank.PodUnscheduledTimeout = &metav1.Duration{Duration: 5 * time.Minute}
 	}
 
+	if ls := c.Plank.LoadShedding; ls != nil {
+		for _, t := range ls.LowPriorityJobTypes {
+			switch t {
+			case prowapi.PresubmitJob, prowapi.PostsubmitJob, prowapi.PeriodicJob, prowapi.BatchJob:
+			default:
+				return fmt.Errorf("invalid plank.load_shedding.low_priority_job_types: %v", t)
+			}
+		}
+		if ls.GetRetryInterval() <= 0 {
+			return errors.New("plank.load_shedding.retry_interval must be positive")
+		}
+	}
+
+	if ip := c.Plank.ImagePrepull; ip != nil {
+		if len(ip.Clusters) == 0 {
+			return errors.New("plank.image_prepull.clusters must not be empty")
+		}
+		if ip.GetLeadTime() < 0 {
+			return errors.New("plank.image_prepull.lead_time must not be negative")
+		}
+		if ip.GetBatchWindow() < 0 {
+			return errors.New("plank.image_prepull.batch_window must not be negative")
+		}
+	}
+
+	if err := c.Plank.LabelPropagation.Validate(); err != nil {
+		return fmt.Errorf("invalid plank.label_propagation: %w", err)
+	}
+
 	if err := c.Gerrit.DefaultAndValidate(); err != nil {
 		return fmt.Errorf("validating gerrit config: %w", err)
 	}
@@ -2517,6 +3811,10 @@ func parseProwConfig(c *Config) error {
 		}
 	}
 
+	if err := c.GitHubReporter.parseTemplates(); err != nil {
+		return err
+	}
+
 	// jenkins operator controller template functions.
 	// reference:
 	// 	- https://helm.sh/docs/chart_template_guide/function_list/#string-functions
@@ -2589,6 +3887,15 @@ func parseProwConfig(c *Config) error {
 	sort.Slice(oldLenses, func(i, j int) bool { return oldLenses[i].Lens.Name < oldLenses[j].Lens.Name })
 	c.Deck.Spyglass.Lenses = append(c.Deck.Spyglass.Lenses, oldLenses...)
 
+	for _, lens := range c.Deck.Spyglass.Lenses {
+		if lens.RemoteConfig != nil && lens.RemoteConfig.GRPCEndpoint != "" && lens.RemoteConfig.Endpoint != "" {
+			return fmt.Errorf("lens %q: remote_config.endpoint and remote_config.grpc_endpoint are mutually exclusive", lens.Lens.Name)
+		}
+		if lens.HistoryBuilds < 0 || lens.HistoryBuilds > maxLensHistoryBuilds {
+			return fmt.Errorf("lens %q: history_builds must be between 0 and %d", lens.Lens.Name, maxLensHistoryBuilds)
+		}
+	}
+
 	// Parse and cache all our regexes upfront.
 	c.Deck.Spyglass.RegexCache = make(map[string]*regexp.Regexp)
 	for _, lens := range c.Deck.Spyglass.Lenses {
@@ -2683,6 +3990,19 @@ func parseProwConfig(c *Config) error {
 	if c.Tide.MaxGoroutines <= 0 {
 		return fmt.Errorf("tide has invalid max_goroutines (%d), it needs to be a positive number", c.Tide.MaxGoroutines)
 	}
+	for orgOrRepo, drift := range c.Tide.MaxBaseDriftMap {
+		if drift < 0 {
+			return fmt.Errorf("tide has invalid max_base_drift (%d) for %q, it needs to be a non-negative number", drift, orgOrRepo)
+		}
+	}
+	if fair := c.Tide.FairScheduling; fair != nil {
+		if fair.MaxTriggersPerSync <= 0 {
+			return fmt.Errorf("tide has invalid fair_scheduling.max_triggers_per_sync (%d), it needs to be a positive number", fair.MaxTriggersPerSync)
+		}
+		if fair.GetQueueAgeWeight() < 0 {
+			return fmt.Errorf("tide has invalid fair_scheduling.queue_age_weight (%v), it needs to be a non-negative number", fair.GetQueueAgeWeight())
+		}
+	}
 
 	if len(c.Tide.TargetURLs) > 0 && c.Tide.TargetURL != "" {
 		return fmt.Errorf("tide.target_url and tide.target_urls are mutually exclusive")
@@ -2719,24 +4039,8 @@ func parseProwConfig(c *Config) error {
 	}
 
 	for name, templates := range c.Tide.MergeTemplate {
-		if templates.TitleTemplate != "" {
-			titleTemplate, err := template.New("CommitTitle").Parse(templates.TitleTemplate)
-
-			if err != nil {
-				return fmt.Errorf("parsing template for commit title: %w", err)
-			}
-
-			templates.Title = titleTemplate
-		}
-
-		if templates.BodyTemplate != "" {
-			bodyTemplate, err := template.New("CommitBody").Parse(templates.BodyTemplate)
-
-			if err != nil {
-				return fmt.Errorf("parsing template for commit body: %w", err)
-			}
-
-			templates.Body = bodyTemplate
+		if err := templates.parse(); err != nil {
+			return err
 		}
 
 		c.Tide.MergeTemplate[name] = templates
@@ -2779,7 +4083,13 @@ func parseProwConfig(c *Config) error {
 	if err != nil {
 		return err
 	}
-	logrus.SetLevel(lvl)
+	levels := logrusutil.Levels{Default: lvl}
+	if spec := c.LogLevelsFor(version.Name); spec != "" {
+		if levels, err = logrusutil.ParseLevels(spec); err != nil {
+			return fmt.Errorf("invalid log_levels for %s: %w", version.Name, err)
+		}
+	}
+	logrusutil.SetLevels(levels)
 
 	// Avoid using a job timeout of infinity by setting the default value to 24 hours.
 	if c.DefaultJobTimeout == nil {
@@ -3014,6 +4324,23 @@ func validatePodSpec(jobType prowapi.ProwJobType, spec *v1.PodSpec, decorationCo
 		}
 	}
 
+	if decorationConfig != nil && len(decorationConfig.Coordination) > 0 {
+		containerNames := sets.Set[string]{}
+		for _, container := range spec.Containers {
+			containerNames.Insert(container.Name)
+		}
+		for _, name := range sets.List(sets.KeySet(decorationConfig.Coordination)) {
+			if !containerNames.Has(name) {
+				errs = append(errs, fmt.Errorf("coordination is configured for undefined container %q", name))
+			}
+			for _, after := range decorationConfig.Coordination[name].After {
+				if !containerNames.Has(after) {
+					errs = append(errs, fmt.Errorf("container %q runs after undefined container %q", name, after))
+				}
+			}
+		}
+	}
+
 	for i := range spec.Containers {
 		envNames := sets.Set[string]{}
 		for _, env := range spec.Containers[i].Env {
@@ -3177,6 +4504,15 @@ func DefaultRerunCommandFor(name string) string {
 	return fmt.Sprintf("/test %s", name)
 }
 
+// LogLevelsFor returns the log levels configured for the component, falling
+// back to the "*" entry. It returns an empty string if neither is set.
+func (pc *ProwConfig) LogLevelsFor(component string) string {
+	if spec, ok := pc.LogLevels[component]; ok {
+		return spec
+	}
+	return pc.LogLevels["*"]
+}
+
 // defaultJobBase configures common parameters, currently Agent and Namespace.
 func (c *ProwConfig) defaultJobBase(base *JobBase) {
 	if base.Agent == "" { // Use kubernetes by default.
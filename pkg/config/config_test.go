@@ -17,6 +17,7 @@ limitations under the License.
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -284,6 +285,20 @@ deck:
     gcs_browser_prefix: https://gcsweb.k8s.io/gcs/
     gcs_browser_prefixes_by_bucket:
       '*': https://gcsweb.k8s.io/gcs/
+`,
+			expectError: true,
+		},
+		{
+			name: "Lens with both an endpoint and a gRPC endpoint",
+			spyglassConfig: `
+deck:
+  spyglass:
+    lenses:
+    - lens:
+        name: flakes
+      remote_config:
+        endpoint: http://flakes.lenses.svc/dynamic/flakes
+        grpc_endpoint: flakes.lenses.svc:8080
 `,
 			expectError: true,
 		},
@@ -348,6 +363,47 @@ deck:
 
 }
 
+func TestGetBuildLogHighlights(t *testing.T) {
+	spyglass := Spyglass{BuildLogHighlights: map[string][]BuildLogHighlight{
+		"kubernetes":      {{Name: "timeout", Regex: "timed out"}},
+		"kubernetes/test": {{Name: "oom", Regex: "OOMKilled"}},
+		"other/repo":      {{Name: "panic", Regex: "panic:"}},
+	}}
+	testCases := []struct {
+		name      string
+		org, repo string
+		expected  []BuildLogHighlight
+	}{
+		{
+			name:     "org and repo rules apply, the org ones first",
+			org:      "kubernetes",
+			repo:     "test",
+			expected: []BuildLogHighlight{{Name: "timeout", Regex: "timed out"}, {Name: "oom", Regex: "OOMKilled"}},
+		},
+		{
+			name:     "org rules apply to all its repos",
+			org:      "kubernetes",
+			repo:     "other",
+			expected: []BuildLogHighlight{{Name: "timeout", Regex: "timed out"}},
+		},
+		{
+			name: "other orgs have none",
+			org:  "other",
+			repo: "other",
+		},
+		{
+			name: "jobs without a repo have none",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, spyglass.GetBuildLogHighlights(tc.org, tc.repo)); diff != "" {
+				t.Errorf("unexpected highlights (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestGetGCSBrowserPrefix(t *testing.T) {
 	testCases := []struct {
 		id       string
@@ -1217,6 +1273,35 @@ gerrit:
 				RateLimit:                          10,
 			},
 		},
+		{
+			name:        "event-stream-defaults",
+			expectError: false,
+			rawConfig: `
+gerrit:
+  event_stream:
+    source: ssh
+    ssh_identity_file: /etc/ssh-key/id_rsa
+`,
+			expected: Gerrit{
+				TickInterval: &metav1.Duration{Duration: time.Minute},
+				RateLimit:    5,
+				EventStream: &GerritEventStream{
+					Source:          GerritEventStreamSSH,
+					SSHPort:         29418,
+					SSHIdentityFile: "/etc/ssh-key/id_rsa",
+					PollInterval:    &metav1.Duration{Duration: 5 * time.Second},
+				},
+			},
+		},
+		{
+			name:        "event-stream-invalid-source",
+			expectError: true,
+			rawConfig: `
+gerrit:
+  event_stream:
+    source: webhook
+`,
+		},
 		{
 			name:        "simple-org-repo",
 			expectError: false,
@@ -1284,6 +1369,9 @@ gerrit:
 			} else if !tc.expectError && err != nil {
 				t.Fatalf("tc %s: Expect no error, but got error %v", tc.name, err)
 			}
+			if err != nil {
+				return
+			}
 
 			if d := cmp.Diff(tc.expected, cfg.Gerrit, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(Gerrit{}, "AllowedPresubmitTriggerRe")); d != "" {
 				t.Errorf("got d: %s", d)
@@ -1644,6 +1732,34 @@ func TestValidatePodSpec(t *testing.T) {
 				s.Containers[0].VolumeMounts = append(s.Containers[0].VolumeMounts, v1.VolumeMount{Name: "foo", MountPath: "/not-used-by-decoration-utils"})
 			},
 		},
+		{
+			name: "accept coordination of defined containers",
+			spec: func(s *v1.PodSpec) {
+				s.Containers = []v1.Container{{Name: "setup"}, {Name: "test"}}
+			},
+			decorationConfig: &prowapi.DecorationConfig{
+				Coordination: map[string]prowapi.ContainerCoordination{"test": {After: []string{"setup"}, Optional: true}},
+			},
+			pass: true,
+		},
+		{
+			name: "reject coordination of undefined container",
+			spec: func(s *v1.PodSpec) {
+				s.Containers = []v1.Container{{Name: "setup"}, {Name: "test"}}
+			},
+			decorationConfig: &prowapi.DecorationConfig{
+				Coordination: map[string]prowapi.ContainerCoordination{"lint": {Optional: true}},
+			},
+		},
+		{
+			name: "reject running after undefined container",
+			spec: func(s *v1.PodSpec) {
+				s.Containers = []v1.Container{{Name: "setup"}, {Name: "test"}}
+			},
+			decorationConfig: &prowapi.DecorationConfig{
+				Coordination: map[string]prowapi.ContainerCoordination{"test": {After: []string{"build"}}},
+			},
+		},
 	}
 
 	spec := v1.PodSpec{
@@ -2039,7 +2155,10 @@ func TestValidateJobBase(t *testing.T) {
 	}
 	cfg := Config{
 		ProwConfig: ProwConfig{
-			Plank:        Plank{JobQueueCapacities: map[string]int{"queue": 0}},
+			Plank: Plank{
+				JobQueueCapacities:   map[string]int{"queue": 0},
+				MaxDecorationTimeout: &metav1.Duration{Duration: time.Hour},
+			},
 			PodNamespace: "target-namespace",
 		},
 	}
@@ -2174,6 +2293,26 @@ func TestValidateJobBase(t *testing.T) {
 			},
 			pass: false,
 		},
+		{
+			name: "decoration timeout within max_decoration_timeout",
+			base: JobBase{
+				Name: "name",
+				UtilityConfig: UtilityConfig{
+					DecorationConfig: &prowapi.DecorationConfig{Timeout: &prowapi.Duration{Duration: time.Hour}},
+				},
+			},
+			pass: true,
+		},
+		{
+			name: "decoration timeout exceeding max_decoration_timeout",
+			base: JobBase{
+				Name: "name",
+				UtilityConfig: UtilityConfig{
+					DecorationConfig: &prowapi.DecorationConfig{Timeout: &prowapi.Duration{Duration: 2 * time.Hour}},
+				},
+			},
+			pass: false,
+		},
 	}
 
 	for _, tc := range cases {
@@ -2188,6 +2327,43 @@ func TestValidateJobBase(t *testing.T) {
 	}
 }
 
+func TestJobHistoryLocations(t *testing.T) {
+	deck := Deck{
+		JobHistorySources: []JobHistorySources{
+			{Locations: []string{"gs://old/logs/", "gs://new/logs/team", "s3://mirror"}},
+			{Locations: []string{"gs://other/pr-logs", "gs://new/pr-logs"}},
+		},
+	}
+	cases := []struct {
+		location string
+		expected []string
+	}{
+		{
+			location: "gs://old/logs/ci-job",
+			expected: []string{"gs://old/logs/ci-job", "gs://new/logs/team/ci-job", "s3://mirror/ci-job"},
+		},
+		{
+			location: "s3://mirror/ci-job",
+			expected: []string{"s3://mirror/ci-job", "gs://old/logs/ci-job", "gs://new/logs/team/ci-job"},
+		},
+		{
+			location: "gs://new/pr-logs/directory/pull-job",
+			expected: []string{"gs://new/pr-logs/directory/pull-job", "gs://other/pr-logs/directory/pull-job"},
+		},
+		{
+			location: "gs://old/logs-other/ci-job",
+			expected: []string{"gs://old/logs-other/ci-job"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.location, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, deck.JobHistoryLocations(tc.location)); diff != "" {
+				t.Errorf("locations differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestValidateDeck(t *testing.T) {
 	boolTrue := true
 	boolFalse := false
@@ -2216,6 +2392,141 @@ func TestValidateDeck(t *testing.T) {
 			deck:        Deck{SkipStoragePathValidation: &boolTrue, AdditionalAllowedBuckets: []string{"hello", "world"}},
 			expectedErr: "skip_storage_path_validation is enabled",
 		},
+		{
+			name:        "JobHistorySources with valid locations => no errors",
+			deck:        Deck{JobHistorySources: []JobHistorySources{{Locations: []string{"gs://old/logs", "s3://new"}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "JobHistorySources with a single location => error",
+			deck:        Deck{JobHistorySources: []JobHistorySources{{Locations: []string{"gs://old/logs"}}}},
+			expectedErr: "job_history_sources[0]: at least two locations are needed",
+		},
+		{
+			name:        "JobHistorySources with a location without provider => error",
+			deck:        Deck{JobHistorySources: []JobHistorySources{{Locations: []string{"gs://old/logs", "new/logs"}}}},
+			expectedErr: "job_history_sources[0]",
+		},
+		{
+			name:        "ArtifactDecryption with keys => no errors",
+			deck:        Deck{Spyglass: Spyglass{ArtifactDecryption: &ArtifactDecryption{KeyURLs: []string{"gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k"}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "ArtifactDecryption without keys => error",
+			deck:        Deck{Spyglass: Spyglass{ArtifactDecryption: &ArtifactDecryption{}}},
+			expectedErr: "key_urls must not be empty",
+		},
+		{
+			name:        "TriageNotes with authors => no errors",
+			deck:        Deck{Spyglass: Spyglass{TriageNotes: &TriageNotes{Authors: prowapi.RerunAuthConfig{GitHubOrgs: []string{"kubernetes"}}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "TriageNotes with negative max length => error",
+			deck:        Deck{Spyglass: Spyglass{TriageNotes: &TriageNotes{MaxLength: -1}}},
+			expectedErr: "max_length must not be negative",
+		},
+		{
+			name:        "TriageNotes allowing anyone and listing authors => error",
+			deck:        Deck{Spyglass: Spyglass{TriageNotes: &TriageNotes{Authors: prowapi.RerunAuthConfig{AllowAnyone: true, GitHubUsers: []string{"alice"}}}}},
+			expectedErr: "spyglass.triage_notes.authors",
+		},
+		{
+			name: "BuildLogHighlights of orgs and repos => no errors",
+			deck: Deck{Spyglass: Spyglass{BuildLogHighlights: map[string][]BuildLogHighlight{
+				"kubernetes":      {{Name: "timeout", Regex: `timed out after \d+`}},
+				"kubernetes/test": {{Name: "oom", Regex: "OOMKilled"}},
+			}}},
+			expectedErr: "",
+		},
+		{
+			name:        "BuildLogHighlights of an invalid repo => error",
+			deck:        Deck{Spyglass: Spyglass{BuildLogHighlights: map[string][]BuildLogHighlight{"kubernetes/": {{Name: "oom", Regex: "OOMKilled"}}}}},
+			expectedErr: "is not an org or org/repo",
+		},
+		{
+			name:        "BuildLogHighlights without a name => error",
+			deck:        Deck{Spyglass: Spyglass{BuildLogHighlights: map[string][]BuildLogHighlight{"kubernetes": {{Regex: "OOMKilled"}}}}},
+			expectedErr: "name must not be empty",
+		},
+		{
+			name:        "BuildLogHighlights with an invalid regex => error",
+			deck:        Deck{Spyglass: Spyglass{BuildLogHighlights: map[string][]BuildLogHighlight{"kubernetes": {{Name: "oom", Regex: "(OOMKilled"}}}}},
+			expectedErr: "invalid regex",
+		},
+		{
+			name: "KnownFailurePatterns => no errors",
+			deck: Deck{Spyglass: Spyglass{KnownFailurePatterns: []KnownFailurePattern{
+				{Name: "node NotReady", Regex: "node .* NotReady", Explanation: "The node of the pod went away.", Action: "/retest", Link: "https://example.com/runbook"},
+			}}},
+			expectedErr: "",
+		},
+		{
+			name:        "KnownFailurePatterns without an explanation => error",
+			deck:        Deck{Spyglass: Spyglass{KnownFailurePatterns: []KnownFailurePattern{{Name: "rate limited", Regex: "rate limit"}}}},
+			expectedErr: "explanation must not be empty",
+		},
+		{
+			name:        "KnownFailurePatterns with an invalid regex => error",
+			deck:        Deck{Spyglass: Spyglass{KnownFailurePatterns: []KnownFailurePattern{{Name: "rate limited", Regex: "(rate", Explanation: "GitHub throttled the job."}}}},
+			expectedErr: "invalid regex",
+		},
+		{
+			name:        "KnownFailurePatterns with a link that is not http(s) => error",
+			deck:        Deck{Spyglass: Spyglass{KnownFailurePatterns: []KnownFailurePattern{{Name: "rate limited", Regex: "rate limit", Explanation: "GitHub throttled the job.", Link: "javascript:alert(1)"}}}},
+			expectedErr: "is not an http(s) URL",
+		},
+		{
+			name:        "RawArtifactViewer with signed URLs valid for a day => no errors",
+			deck:        Deck{Spyglass: Spyglass{RawArtifactViewer: &RawArtifactViewer{SignedURLDownloads: true, SignedURLExpiry: &metav1.Duration{Duration: 24 * time.Hour}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "RawArtifactViewer with signed URLs valid for a month => error",
+			deck:        Deck{Spyglass: Spyglass{RawArtifactViewer: &RawArtifactViewer{SignedURLExpiry: &metav1.Duration{Duration: 30 * 24 * time.Hour}}}},
+			expectedErr: "signed_url_expiry must be positive and at most 168h0m0s",
+		},
+		{
+			name:        "HealthAggregation with valid components => no errors",
+			deck:        Deck{HealthAggregation: &HealthAggregation{Components: []HealthComponent{{Name: "hook", URL: "http://hook:8081"}, {Name: "tide", URL: "http://tide:8081"}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "HealthAggregation with duplicate components => error",
+			deck:        Deck{HealthAggregation: &HealthAggregation{Components: []HealthComponent{{Name: "hook", URL: "http://hook:8081"}, {Name: "hook", URL: "http://hook-2:8081"}}}},
+			expectedErr: `health_aggregation: duplicate component "hook"`,
+		},
+		{
+			name:        "HealthAggregation with a relative URL => error",
+			deck:        Deck{HealthAggregation: &HealthAggregation{Components: []HealthComponent{{Name: "hook", URL: "hook:8081/healthz"}}}},
+			expectedErr: "invalid URL",
+		},
+		{
+			name:        "HealthAggregation with a zero update period => error",
+			deck:        Deck{HealthAggregation: &HealthAggregation{UpdatePeriod: &metav1.Duration{}}},
+			expectedErr: "update_period must be positive",
+		},
+		{
+			name:        "Lens restricted to team => no error",
+			deck:        Deck{Spyglass: Spyglass{Lenses: []LensFileConfig{{Authorization: &LensAuthorization{Viewers: &prowapi.RerunAuthConfig{GitHubTeamSlugs: []prowapi.GitHubTeamSlug{{Org: "org", Slug: "admins"}}}}}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "Lens restricted to logged in users => no error",
+			deck:        Deck{Spyglass: Spyglass{Lenses: []LensFileConfig{{Authorization: &LensAuthorization{RequireLogin: true}}}}},
+			expectedErr: "",
+		},
+		{
+			name:        "Lens authorization without restriction => error",
+			deck:        Deck{Spyglass: Spyglass{Lenses: []LensFileConfig{{Authorization: &LensAuthorization{}}}}},
+			expectedErr: "spyglass.lenses[0].authorization: one of require_login or viewers must be set",
+		},
+		{
+			name:        "Lens authorization allowing anyone => error",
+			deck:        Deck{Spyglass: Spyglass{Lenses: []LensFileConfig{{Authorization: &LensAuthorization{Viewers: &prowapi.RerunAuthConfig{AllowAnyone: true}}}}}},
+			expectedErr: "allow_anyone would not restrict the lens",
+		},
 	}
 
 	for _, tc := range cases {
@@ -2235,6 +2546,60 @@ func TestValidateDeck(t *testing.T) {
 	}
 }
 
+func TestValidateNamespaceProvisioner(t *testing.T) {
+	cases := []struct {
+		name        string
+		tenants     []TenantNamespace
+		expectedErr string
+	}{
+		{
+			name:    "tenants with their own namespaces are valid",
+			tenants: []TenantNamespace{{Name: "team-a"}, {Name: "team-b", Namespace: "jobs-b"}},
+		},
+		{
+			name:        "tenant without name => error",
+			tenants:     []TenantNamespace{{Namespace: "jobs"}},
+			expectedErr: "name must be set",
+		},
+		{
+			name:        "duplicate tenant => error",
+			tenants:     []TenantNamespace{{Name: "team-a"}, {Name: "team-a", Namespace: "jobs"}},
+			expectedErr: `duplicate tenant "team-a"`,
+		},
+		{
+			name:        "namespace shared in the same clusters => error",
+			tenants:     []TenantNamespace{{Name: "team-a", Namespace: "jobs", Clusters: []string{"a", "b"}}, {Name: "team-b", Namespace: "jobs", Clusters: []string{"b"}}},
+			expectedErr: `namespace "jobs" is used by tenant "team-a" in the same clusters`,
+		},
+		{
+			name:        "namespace shared with a tenant of all clusters => error",
+			tenants:     []TenantNamespace{{Name: "team-a", Namespace: "jobs"}, {Name: "team-b", Namespace: "jobs", Clusters: []string{"b"}}},
+			expectedErr: `namespace "jobs" is used by tenant "team-a" in the same clusters`,
+		},
+		{
+			name:    "namespace shared in different clusters is valid",
+			tenants: []TenantNamespace{{Name: "team-a", Namespace: "jobs", Clusters: []string{"a"}}, {Name: "team-b", Namespace: "jobs", Clusters: []string{"b"}}},
+		},
+		{
+			name:        "invalid namespace => error",
+			tenants:     []TenantNamespace{{Name: "Team_A"}},
+			expectedErr: `invalid namespace "Team_A"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NamespaceProvisioner{Tenants: tc.tenants}.Validate()
+			if tc.expectedErr == "" && err != nil {
+				t.Fatalf("not expecting error, but got an error: %v", err)
+			}
+			if tc.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tc.expectedErr)) {
+				t.Fatalf("expected error (%v), but got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
 func TestValidateRefs(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -4433,6 +4798,54 @@ tide:
 				},
 			},
 		},
+		{
+			name: "templates for merge methods",
+			prowConfig: `
+tide:
+  merge_commit_template:
+    kubernetes/ingress:
+      title: "{{ .Title }}"
+      merge_methods:
+        squash:
+          body: "{{ .Body }}"
+`,
+			expect: map[string]TideMergeCommitTemplate{
+				"kubernetes/ingress": {
+					TitleTemplate: "{{ .Title }}",
+					Title:         template.Must(template.New("CommitTitle").Parse("{{ .Title }}")),
+					MergeMethods: map[types.PullRequestMergeType]TideCommitTemplate{
+						types.MergeSquash: {
+							BodyTemplate: "{{ .Body }}",
+							Body:         template.Must(template.New("CommitBody").Parse("{{ .Body }}")),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "template for rebase merges",
+			prowConfig: `
+tide:
+  merge_commit_template:
+    kubernetes/ingress:
+      merge_methods:
+        rebase:
+          title: "{{ .Title }}"
+`,
+			expectError: true,
+		},
+		{
+			name: "malformed template for merge method",
+			prowConfig: `
+tide:
+  merge_commit_template:
+    kubernetes/ingress:
+      merge_methods:
+        squash:
+          body: "{{ .Body"
+`,
+			expectError: true,
+		},
 		{
 			name: "malformed title template",
 			prowConfig: `
@@ -4722,6 +5135,31 @@ func TestValidateComponentConfig(t *testing.T) {
 			}}},
 			errExpected: false,
 		},
+		{
+			name:        "failing periodics repo is an org/repo, no err",
+			config:      &Config{ProwConfig: ProwConfig{FailingPeriodics: FailingPeriodics{Repo: "org/repo"}}},
+			errExpected: false,
+		},
+		{
+			name:        "failing periodics repo is an org, err",
+			config:      &Config{ProwConfig: ProwConfig{FailingPeriodics: FailingPeriodics{Repo: "org"}}},
+			errExpected: true,
+		},
+		{
+			name:        "negative failing periodics consecutive failures, err",
+			config:      &Config{ProwConfig: ProwConfig{FailingPeriodics: FailingPeriodics{ConsecutiveFailures: -1}}},
+			errExpected: true,
+		},
+		{
+			name:        "valid log levels, no err",
+			config:      &Config{ProwConfig: ProwConfig{LogLevels: map[string]string{"*": "info", "hook": "warn,pkg/github=debug"}}},
+			errExpected: false,
+		},
+		{
+			name:        "invalid log levels, err",
+			config:      &Config{ProwConfig: ProwConfig{LogLevels: map[string]string{"hook": "pkg/github=loud"}}},
+			errExpected: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -4733,6 +5171,41 @@ func TestValidateComponentConfig(t *testing.T) {
 	}
 }
 
+func TestLogLevelsFor(t *testing.T) {
+	testCases := []struct {
+		name      string
+		logLevels map[string]string
+		component string
+		expected  string
+	}{
+		{
+			name:      "nothing configured",
+			component: "hook",
+		},
+		{
+			name:      "component entry",
+			logLevels: map[string]string{"*": "info", "hook": "debug"},
+			component: "hook",
+			expected:  "debug",
+		},
+		{
+			name:      "wildcard entry",
+			logLevels: map[string]string{"*": "info", "tide": "debug"},
+			component: "hook",
+			expected:  "info",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pc := &ProwConfig{LogLevels: tc.logLevels}
+			if actual := pc.LogLevelsFor(tc.component); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
 func TestSlackReporterValidation(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -7976,6 +8449,96 @@ func TestDefaultAndValidateReportTemplate(t *testing.T) {
 	}
 }
 
+func TestGitHubReporterTemplates(t *testing.T) {
+	testCases := []struct {
+		name            string
+		templates       map[string]GitHubReportTemplates
+		org, repo       string
+		expectedComment string
+		expectedStatus  string
+		expectedErr     string
+	}{
+		{
+			name:      "no templates",
+			org:       "org",
+			repo:      "repo",
+			templates: nil,
+		},
+		{
+			name: "most specific template of each kind applies",
+			templates: map[string]GitHubReportTemplates{
+				"*":        {Comment: "global comment", Status: "global status"},
+				"org":      {Comment: "org comment"},
+				"org/repo": {Status: "repo status"},
+			},
+			org:             "org",
+			repo:            "repo",
+			expectedComment: "org comment",
+			expectedStatus:  "repo status",
+		},
+		{
+			name: "global templates apply to other orgs",
+			templates: map[string]GitHubReportTemplates{
+				"*":   {Comment: "global comment", Status: "global status"},
+				"org": {Comment: "org comment"},
+			},
+			org:             "other",
+			repo:            "repo",
+			expectedComment: "global comment",
+			expectedStatus:  "global status",
+		},
+		{
+			name: "template functions can be used",
+			templates: map[string]GitHubReportTemplates{
+				"org": {Comment: `{{quote (truncate 5 "long text")}}`, Status: `{{codeBlock "text"}}`},
+			},
+			org:             "org",
+			repo:            "repo",
+			expectedComment: "> lo...",
+			expectedStatus:  "```\ntext\n```",
+		},
+		{
+			name: "invalid template",
+			templates: map[string]GitHubReportTemplates{
+				"org": {Status: "{{.Status"},
+			},
+			expectedErr: `github_reporter.templates["org"].status: template: status:1: unclosed action`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := GitHubReporter{Templates: tc.templates}
+			var errStr string
+			if err := r.parseTemplates(); err != nil {
+				errStr = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, errStr); diff != "" {
+				t.Fatalf("error mismatch (-want +got):\n%s", diff)
+			}
+			if tc.expectedErr != "" {
+				return
+			}
+			execute := func(tmpl *template.Template) string {
+				if tmpl == nil {
+					return ""
+				}
+				var b bytes.Buffer
+				if err := tmpl.Execute(&b, nil); err != nil {
+					t.Fatalf("failed to execute template: %v", err)
+				}
+				return b.String()
+			}
+			if diff := cmp.Diff(tc.expectedComment, execute(r.CommentTemplateForRepo(tc.org, tc.repo))); diff != "" {
+				t.Errorf("comment mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedStatus, execute(r.StatusTemplateForRepo(tc.org, tc.repo))); diff != "" {
+				t.Errorf("status mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestValidatePresubmits(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -8212,6 +8775,31 @@ func TestValidatePeriodics(t *testing.T) {
 			},
 			expectedError: "cannot parse duration for a: time: invalid duration \"hello\"",
 		},
+		{
+			name: "Invalid concurrency_policy",
+			periodics: []Periodic{
+				{JobBase: JobBase{Name: "a"}, Cron: "@every 1m", ConcurrencyPolicy: "wait"},
+			},
+			expectedError: `invalid concurrency_policy "wait" in periodic a, must be one of "skip", "queue", "replace" or "allow"`,
+		},
+		{
+			name: "concurrency_policy with minimum_interval",
+			periodics: []Periodic{
+				{JobBase: JobBase{Name: "a"}, MinimumInterval: "1h", ConcurrencyPolicy: PeriodicConcurrencyReplace},
+			},
+			expectedError: `concurrency_policy "replace" can't be used with minimum_interval in periodic a`,
+		},
+		{
+			name: "Valid concurrency_policy",
+			periodics: []Periodic{
+				{JobBase: JobBase{Name: "a"}, Cron: "@every 1m", ConcurrencyPolicy: PeriodicConcurrencyQueue},
+				{JobBase: JobBase{Name: "b"}, MinimumInterval: "10ns", ConcurrencyPolicy: PeriodicConcurrencySkip},
+			},
+			expected: []Periodic{
+				{JobBase: JobBase{Name: "a"}, Cron: "@every 1m", ConcurrencyPolicy: PeriodicConcurrencyQueue},
+				{JobBase: JobBase{Name: "b"}, MinimumInterval: "10ns", ConcurrencyPolicy: PeriodicConcurrencySkip, minimum_interval: time.Duration(10)},
+			},
+		},
 		{
 			name: "Sets interval",
 			periodics: []Periodic{
@@ -8378,6 +8966,7 @@ branch-protection:
 			expectedProwConfig: `branch-protection:
   allow_disabled_job_policies: true
 config_version_sha: abc
+cost_accounting: {}
 deck:
   spyglass:
     gcs_browser_prefixes:
@@ -8387,6 +8976,7 @@ deck:
     size_limit: 100000000
   tide_update_period: 10s
 default_job_timeout: 24h0m0s
+failing_periodics: {}
 gangway: {}
 gerrit:
   ratelimit: 5
@@ -8408,6 +8998,7 @@ managed_webhooks:
   respect_legacy_global_token: false
 moonraker:
   client_timeout: 10m0s
+namespace_provisioner: {}
 plank:
   max_goroutines: 20
   pod_pending_timeout: 10m0s
@@ -8418,6 +9009,7 @@ prowjob_namespace: default
 push_gateway:
   interval: 1m0s
   serve_metrics: false
+retester: {}
 scheduler: {}
 sinker:
   max_pod_age: 24h0m0s
@@ -8462,6 +9054,7 @@ tide:
   merge_method:
     foo/bar: squash`},
 			expectedProwConfig: `branch-protection: {}
+cost_accounting: {}
 deck:
   spyglass:
     gcs_browser_prefixes:
@@ -8471,6 +9064,7 @@ deck:
     size_limit: 100000000
   tide_update_period: 10s
 default_job_timeout: 24h0m0s
+failing_periodics: {}
 gangway: {}
 gerrit:
   ratelimit: 5
@@ -8492,6 +9086,7 @@ managed_webhooks:
   respect_legacy_global_token: false
 moonraker:
   client_timeout: 10m0s
+namespace_provisioner: {}
 plank:
   max_goroutines: 20
   pod_pending_timeout: 10m0s
@@ -8502,6 +9097,7 @@ prowjob_namespace: default
 push_gateway:
   interval: 1m0s
   serve_metrics: false
+retester: {}
 scheduler: {}
 sinker:
   max_pod_age: 24h0m0s
@@ -8539,6 +9135,7 @@ tide:
     - another/repo
 `},
 			expectedProwConfig: `branch-protection: {}
+cost_accounting: {}
 deck:
   spyglass:
     gcs_browser_prefixes:
@@ -8548,6 +9145,7 @@ deck:
     size_limit: 100000000
   tide_update_period: 10s
 default_job_timeout: 24h0m0s
+failing_periodics: {}
 gangway: {}
 gerrit:
   ratelimit: 5
@@ -8569,6 +9167,7 @@ managed_webhooks:
   respect_legacy_global_token: false
 moonraker:
   client_timeout: 10m0s
+namespace_provisioner: {}
 plank:
   max_goroutines: 20
   pod_pending_timeout: 10m0s
@@ -8579,6 +9178,7 @@ prowjob_namespace: default
 push_gateway:
   interval: 1m0s
   serve_metrics: false
+retester: {}
 scheduler: {}
 sinker:
   max_pod_age: 24h0m0s
@@ -8621,6 +9221,7 @@ slack_reporter_configs:
 			},
 			expectedProwConfig: `branch-protection: {}
 config_version_sha: abc
+cost_accounting: {}
 deck:
   spyglass:
     gcs_browser_prefixes:
@@ -8630,6 +9231,7 @@ deck:
     size_limit: 100000000
   tide_update_period: 10s
 default_job_timeout: 24h0m0s
+failing_periodics: {}
 gangway: {}
 gerrit:
   ratelimit: 5
@@ -8651,6 +9253,7 @@ managed_webhooks:
   respect_legacy_global_token: false
 moonraker:
   client_timeout: 10m0s
+namespace_provisioner: {}
 plank:
   max_goroutines: 20
   pod_pending_timeout: 10m0s
@@ -8661,6 +9264,7 @@ prowjob_namespace: default
 push_gateway:
   interval: 1m0s
   serve_metrics: false
+retester: {}
 scheduler: {}
 sinker:
   max_pod_age: 24h0m0s
@@ -100,6 +100,10 @@ type JobBase struct {
 	// Cluster is the alias of the cluster to run this job in.
 	// (Default: kube.DefaultClusterAlias)
 	Cluster string `json:"cluster,omitempty"`
+	// Arch is the CPU architecture of the nodes to run this job on, e.g. arm64.
+	// The pods of the job select and tolerate nodes of the architecture.
+	// (Default: any node of the cluster)
+	Arch string `json:"arch,omitempty"`
 	// Namespace is the namespace in which pods schedule.
 	//   nil: results in config.PodNamespace (aka pod default)
 	//   empty: results in config.ProwJobNamespace (aka same as prowjob)
@@ -281,11 +285,46 @@ type Periodic struct {
 	Cron string `json:"cron,omitempty"`
 	// Tags for config entries
 	Tags []string `json:"tags,omitempty"`
+	// ConcurrencyPolicy decides what happens when a run of the job is due
+	// while a previous run is still active:
+	// "skip" (default) doesn't start the run,
+	// "queue" starts it as soon as the previous runs complete,
+	// "replace" aborts the previous runs and starts it,
+	// "allow" starts it next to the previous runs, up to max_concurrency.
+	// Only "skip" may be used with minimum_interval, as the next run of
+	// those jobs is only due once the previous run completes.
+	ConcurrencyPolicy PeriodicConcurrencyPolicy `json:"concurrency_policy,omitempty"`
 
 	interval         time.Duration
 	minimum_interval time.Duration
 }
 
+// PeriodicConcurrencyPolicy is what horologium does when a run of a periodic
+// is due while a previous run is still active.
+type PeriodicConcurrencyPolicy string
+
+const (
+	// PeriodicConcurrencySkip doesn't start the run.
+	PeriodicConcurrencySkip PeriodicConcurrencyPolicy = "skip"
+	// PeriodicConcurrencyQueue starts the run once the previous runs complete.
+	// At most one run is queued.
+	PeriodicConcurrencyQueue PeriodicConcurrencyPolicy = "queue"
+	// PeriodicConcurrencyReplace aborts the previous runs and starts the run.
+	PeriodicConcurrencyReplace PeriodicConcurrencyPolicy = "replace"
+	// PeriodicConcurrencyAllow starts the run next to the previous runs, up to
+	// the max_concurrency of the job.
+	PeriodicConcurrencyAllow PeriodicConcurrencyPolicy = "allow"
+)
+
+// GetConcurrencyPolicy returns the concurrency policy of the periodic,
+// defaulting to skip.
+func (p *Periodic) GetConcurrencyPolicy() PeriodicConcurrencyPolicy {
+	if p.ConcurrencyPolicy == "" {
+		return PeriodicConcurrencySkip
+	}
+	return p.ConcurrencyPolicy
+}
+
 // JenkinsSpec holds optional Jenkins job config
 type JenkinsSpec struct {
 	// Job is managed by the GH branch source plugin
@@ -567,6 +606,12 @@ type UtilityConfig struct {
 	// SkipFetchHead tells prow to avoid a git fetch <remote> call.
 	// The git fetch <remote> <BaseRef> call occurs regardless.
 	SkipFetchHead bool `json:"skip_fetch_head,omitempty"`
+	// Filter is the filter of a partial clone, like blob:none or tree:0,
+	// which tells prow to only fetch the objects it selects.
+	Filter string `json:"filter,omitempty"`
+	// SingleBranch tells prow to only fetch the base ref and the pulls,
+	// without the other branches and tags of the repository.
+	SingleBranch bool `json:"single_branch,omitempty"`
 
 	// ExtraRefs are auxiliary repositories that
 	// need to be cloned, determined from config
@@ -602,11 +647,27 @@ func (u *UtilityConfig) Validate() error {
 	if err := cloneURIValidate(u.CloneURI); err != nil {
 		return err
 	}
+	if u.CloneDepth < 0 {
+		return errors.New("clone_depth must not be negative")
+	}
+	if u.Filter != "" {
+		if err := prowapi.ValidateCloneFilter(u.Filter); err != nil {
+			return err
+		}
+	}
 
 	for i, ref := range u.ExtraRefs {
 		if err := cloneURIValidate(ref.CloneURI); err != nil {
 			return fmt.Errorf("extra_ref[%d]: %w", i, err)
 		}
+		if ref.CloneDepth < 0 {
+			return fmt.Errorf("extra_ref[%d]: clone_depth must not be negative", i)
+		}
+		if ref.Filter != "" {
+			if err := prowapi.ValidateCloneFilter(ref.Filter); err != nil {
+				return fmt.Errorf("extra_ref[%d]: %w", i, err)
+			}
+		}
 	}
 
 	return nil
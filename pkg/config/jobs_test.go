@@ -1257,6 +1257,28 @@ func TestUtilityConfigValidation(t *testing.T) {
 				},
 			},
 		},
+		{
+			id:    "shallow partial clone, no error",
+			valid: true,
+			uc:    UtilityConfig{CloneDepth: 1, Filter: "blob:none", SingleBranch: true},
+		},
+		{
+			id: "clone_depth is negative, error",
+			uc: UtilityConfig{CloneDepth: -1},
+		},
+		{
+			id: "filter is not valid, error",
+			uc: UtilityConfig{Filter: "blobs:none"},
+		},
+		{
+			id: "filter of one of the extra refs is not valid, error",
+			uc: UtilityConfig{
+				ExtraRefs: []prowapi.Refs{
+					{Org: "org1", Repo: "repo1", BaseRef: "main", Filter: "tree:0"},
+					{Org: "org2", Repo: "repo2", BaseRef: "main", Filter: "tree:none"},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// LabelPropagationPolicy configures which ProwJob labels and annotations are
+// copied to the pods created for jobs and to reports about jobs, and which
+// labels every job must have.
+type LabelPropagationPolicy struct {
+	// Pods filters the labels and annotations copied to the pods created for
+	// jobs. All of them are copied if unset.
+	Pods *PropagationRules `json:"pods,omitempty"`
+	// Reports filters the labels and annotations included in reporter
+	// payloads, e.g. Pub/Sub messages. None are included if unset.
+	Reports *PropagationRules `json:"reports,omitempty"`
+	// RequiredLabels are labels that every job must have, e.g. a cost center.
+	// They are always propagated.
+	RequiredLabels []RequiredLabel `json:"required_labels,omitempty"`
+}
+
+// PropagationRules filter labels and annotations by key with regular
+// expressions. A key is propagated if it matches any of the allowed
+// expressions, or if there are none, and none of the denied expressions.
+// Labels and annotations set by Prow itself are always propagated.
+type PropagationRules struct {
+	AllowedLabels      []string `json:"allowed_labels,omitempty"`
+	DeniedLabels       []string `json:"denied_labels,omitempty"`
+	AllowedAnnotations []string `json:"allowed_annotations,omitempty"`
+	DeniedAnnotations  []string `json:"denied_annotations,omitempty"`
+}
+
+// RequiredLabel is a label that every job must have.
+type RequiredLabel struct {
+	Key string `json:"key"`
+	// Default is injected into the pods and reports of jobs that do not set
+	// the label. Jobs without the label fail to start if there is no default.
+	Default string `json:"default,omitempty"`
+}
+
+// Validate checks the policy for invalid expressions and labels.
+func (p *LabelPropagationPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	var errs []error
+	for name, rules := range map[string]*PropagationRules{"pods": p.Pods, "reports": p.Reports} {
+		if rules == nil {
+			continue
+		}
+		for _, expressions := range [][]string{rules.AllowedLabels, rules.DeniedLabels, rules.AllowedAnnotations, rules.DeniedAnnotations} {
+			for _, expression := range expressions {
+				if _, err := regexp.Compile(expression); err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid expression %q: %w", name, expression, err))
+				}
+			}
+		}
+	}
+	for _, required := range p.RequiredLabels {
+		if msgs := validation.IsQualifiedName(required.Key); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("required label %q is not a valid label key: %s", required.Key, strings.Join(msgs, "; ")))
+		}
+		if msgs := validation.IsValidLabelValue(required.Default); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("the default %q of required label %q is not a valid label value: %s", required.Default, required.Key, strings.Join(msgs, "; ")))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// MissingRequiredLabels returns the required labels without a default that
+// are not set in labels.
+func (p *LabelPropagationPolicy) MissingRequiredLabels(labels map[string]string) []string {
+	if p == nil {
+		return nil
+	}
+	var missing []string
+	for _, required := range p.RequiredLabels {
+		if _, ok := labels[required.Key]; !ok && required.Default == "" {
+			missing = append(missing, required.Key)
+		}
+	}
+	return missing
+}
+
+// ForPod returns the labels and annotations of the pod of a job. It fails if
+// the job lacks a required label without a default.
+func (p *LabelPropagationPolicy) ForPod(labels, annotations map[string]string) (map[string]string, map[string]string, error) {
+	if p == nil {
+		return labels, annotations, nil
+	}
+	if missing := p.MissingRequiredLabels(labels); len(missing) > 0 {
+		return nil, nil, fmt.Errorf("the job is missing the required labels %s", strings.Join(missing, ", "))
+	}
+	labels, annotations = p.Pods.filter(labels, annotations, p.requiredKeys())
+	return p.withDefaults(labels), annotations, nil
+}
+
+// ForReport returns the labels and annotations of a job to include in
+// reporter payloads.
+func (p *LabelPropagationPolicy) ForReport(labels, annotations map[string]string) (map[string]string, map[string]string) {
+	if p == nil || p.Reports == nil {
+		return nil, nil
+	}
+	labels, annotations = p.Reports.filter(labels, annotations, p.requiredKeys())
+	return p.withDefaults(labels), annotations
+}
+
+func (p *LabelPropagationPolicy) requiredKeys() map[string]bool {
+	keys := make(map[string]bool, len(p.RequiredLabels))
+	for _, required := range p.RequiredLabels {
+		keys[required.Key] = true
+	}
+	return keys
+}
+
+func (p *LabelPropagationPolicy) withDefaults(labels map[string]string) map[string]string {
+	for _, required := range p.RequiredLabels {
+		if _, ok := labels[required.Key]; !ok && required.Default != "" {
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[required.Key] = required.Default
+		}
+	}
+	return labels
+}
+
+// DroppedPodLabels returns the keys of the labels that are not propagated to
+// pods, sorted.
+func (p *LabelPropagationPolicy) DroppedPodLabels(labels map[string]string) []string {
+	if p == nil {
+		return nil
+	}
+	filtered, _ := p.Pods.filter(labels, nil, p.requiredKeys())
+	var denied []string
+	for key := range labels {
+		if _, ok := filtered[key]; !ok {
+			denied = append(denied, key)
+		}
+	}
+	sort.Strings(denied)
+	return denied
+}
+
+func (r *PropagationRules) filter(labels, annotations map[string]string, keep map[string]bool) (map[string]string, map[string]string) {
+	if r == nil {
+		return labels, annotations
+	}
+	return filterKeys(labels, r.AllowedLabels, r.DeniedLabels, keep), filterKeys(annotations, r.AllowedAnnotations, r.DeniedAnnotations, keep)
+}
+
+func filterKeys(values map[string]string, allowed, denied []string, keep map[string]bool) map[string]string {
+	if values == nil {
+		return nil
+	}
+	filtered := make(map[string]string, len(values))
+	for key, value := range values {
+		if keep[key] || setByProw(key) || matchesAny(key, allowed, len(allowed) == 0) && !matchesAny(key, denied, false) {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+// setByProw returns whether the label or annotation is set by Prow itself
+// and required by its components.
+func setByProw(key string) bool {
+	return key == kube.CreatedByProw || key == kube.CreatedByTideLabel || strings.HasPrefix(key, "prow.k8s.io/")
+}
+
+// matchesAny returns whether the key matches any of the expressions, or
+// empty if there are none. Invalid expressions are rejected by Validate.
+func matchesAny(key string, expressions []string, empty bool) bool {
+	if len(expressions) == 0 {
+		return empty
+	}
+	for _, expression := range expressions {
+		if re, err := regexp.Compile(expression); err == nil && re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLabelPropagationPolicyValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      *LabelPropagationPolicy
+		expectedErr bool
+	}{
+		{
+			name: "nil policy is valid",
+		},
+		{
+			name: "valid policy",
+			policy: &LabelPropagationPolicy{
+				Pods:           &PropagationRules{AllowedLabels: []string{"^team$"}, DeniedAnnotations: []string{"secret"}},
+				Reports:        &PropagationRules{AllowedLabels: []string{"cost-center"}},
+				RequiredLabels: []RequiredLabel{{Key: "example.com/cost-center", Default: "shared"}},
+			},
+		},
+		{
+			name:        "invalid expression",
+			policy:      &LabelPropagationPolicy{Reports: &PropagationRules{DeniedLabels: []string{"("}}},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid required label key",
+			policy:      &LabelPropagationPolicy{RequiredLabels: []RequiredLabel{{Key: "not a key"}}},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid required label default",
+			policy:      &LabelPropagationPolicy{RequiredLabels: []RequiredLabel{{Key: "cost-center", Default: "not a value"}}},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.policy.Validate(); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestLabelPropagationPolicyForPod(t *testing.T) {
+	testCases := []struct {
+		name                string
+		policy              *LabelPropagationPolicy
+		labels              map[string]string
+		annotations         map[string]string
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+		expectedErr         bool
+	}{
+		{
+			name:                "nil policy propagates everything",
+			labels:              map[string]string{"a": "b"},
+			annotations:         map[string]string{"c": "d"},
+			expectedLabels:      map[string]string{"a": "b"},
+			expectedAnnotations: map[string]string{"c": "d"},
+		},
+		{
+			name: "allow list keeps matching keys and keys set by Prow",
+			policy: &LabelPropagationPolicy{Pods: &PropagationRules{
+				AllowedLabels:      []string{"^team$"},
+				AllowedAnnotations: []string{"^description$"},
+			}},
+			labels:              map[string]string{"team": "sig-testing", "owner": "me", "created-by-prow": "true", "prow.k8s.io/job": "job"},
+			annotations:         map[string]string{"description": "job", "note": "note", "prow.k8s.io/context": "ctx"},
+			expectedLabels:      map[string]string{"team": "sig-testing", "created-by-prow": "true", "prow.k8s.io/job": "job"},
+			expectedAnnotations: map[string]string{"description": "job", "prow.k8s.io/context": "ctx"},
+		},
+		{
+			name: "deny list takes precedence over allow list",
+			policy: &LabelPropagationPolicy{Pods: &PropagationRules{
+				AllowedLabels: []string{"team"},
+				DeniedLabels:  []string{"^internal-"},
+			}},
+			labels:         map[string]string{"team": "sig-testing", "internal-team": "x"},
+			expectedLabels: map[string]string{"team": "sig-testing"},
+		},
+		{
+			name: "required labels are kept and defaults injected",
+			policy: &LabelPropagationPolicy{
+				Pods:           &PropagationRules{AllowedLabels: []string{"^team$"}},
+				RequiredLabels: []RequiredLabel{{Key: "owner"}, {Key: "cost-center", Default: "shared"}},
+			},
+			labels:         map[string]string{"owner": "me"},
+			expectedLabels: map[string]string{"owner": "me", "cost-center": "shared"},
+		},
+		{
+			name:        "missing required label without a default fails",
+			policy:      &LabelPropagationPolicy{RequiredLabels: []RequiredLabel{{Key: "owner"}}},
+			labels:      map[string]string{"team": "sig-testing"},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labels, annotations, err := tc.policy.ForPod(tc.labels, tc.annotations)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expectedLabels, labels); diff != "" {
+				t.Errorf("labels differ from expected (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedAnnotations, annotations); diff != "" {
+				t.Errorf("annotations differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLabelPropagationPolicyForReport(t *testing.T) {
+	testCases := []struct {
+		name                string
+		policy              *LabelPropagationPolicy
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name: "nil policy includes nothing",
+		},
+		{
+			name:   "no report rules include nothing",
+			policy: &LabelPropagationPolicy{RequiredLabels: []RequiredLabel{{Key: "cost-center", Default: "shared"}}},
+		},
+		{
+			name: "report rules filter labels and annotations",
+			policy: &LabelPropagationPolicy{
+				Reports:        &PropagationRules{AllowedLabels: []string{"^team$"}, DeniedAnnotations: []string{".*"}},
+				RequiredLabels: []RequiredLabel{{Key: "cost-center", Default: "shared"}},
+			},
+			expectedLabels:      map[string]string{"team": "sig-testing", "cost-center": "shared", "created-by-prow": "true"},
+			expectedAnnotations: map[string]string{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labels, annotations := tc.policy.ForReport(
+				map[string]string{"team": "sig-testing", "owner": "me", "created-by-prow": "true"},
+				map[string]string{"description": "job"},
+			)
+			if diff := cmp.Diff(tc.expectedLabels, labels); diff != "" {
+				t.Errorf("labels differ from expected (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedAnnotations, annotations); diff != "" {
+				t.Errorf("annotations differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLabelPropagationPolicyDroppedPodLabels(t *testing.T) {
+	policy := &LabelPropagationPolicy{
+		Pods:           &PropagationRules{DeniedLabels: []string{"^internal-"}},
+		RequiredLabels: []RequiredLabel{{Key: "internal-cost-center"}},
+	}
+	labels := map[string]string{"team": "x", "internal-b": "x", "internal-a": "x", "internal-cost-center": "x"}
+	if diff := cmp.Diff([]string{"internal-a", "internal-b"}, policy.DroppedPodLabels(labels)); diff != "" {
+		t.Errorf("dropped labels differ from expected (-want +got):\n%s", diff)
+	}
+	var nilPolicy *LabelPropagationPolicy
+	if dropped := nilPolicy.DroppedPodLabels(labels); dropped != nil {
+		t.Errorf("expected no dropped labels for a nil policy, got %v", dropped)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// ShardIndexFileName is the name of the file that the config-updater
+	// adds to the index ConfigMap of a job config it sharded across several
+	// ConfigMaps.
+	ShardIndexFileName = "shard-index.json"
+	// ShardSize is how many bytes of data the config-updater puts in each
+	// shard, leaving room for the rest of the ConfigMap under its 1MiB limit.
+	ShardSize = 1000 * 1000
+)
+
+// ShardIndex describes how a job config was sharded across ConfigMaps. When
+// the ConfigMaps are mounted into the same directory with a projected volume,
+// the job config is read as if it were a single ConfigMap.
+type ShardIndex struct {
+	// Shards are the names of the ConfigMaps holding the keys.
+	Shards []string `json:"shards"`
+	// Parts are the keys of the parts of each file that did not fit in a
+	// single shard, in order.
+	Parts map[string][]string `json:"parts,omitempty"`
+}
+
+// ShardName is the name of the ConfigMap holding the i-th shard of the
+// sharded ConfigMap with the name.
+func ShardName(name string, i int) string {
+	return fmt.Sprintf("%s-%d", name, i)
+}
+
+// ShardPartKey is the key of the i-th part of a file that did not fit in a
+// single shard. The suffix keeps the parts from being loaded as job config.
+func ShardPartKey(key string, i int) string {
+	return fmt.Sprintf("%s.part-%03d", key, i)
+}
+
+// readShardedFiles returns the content of the files that were split into
+// parts across the shards mounted in dir, by their path, if dir holds a
+// sharded job config.
+func readShardedFiles(dir string) (map[string][]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ShardIndexFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var index ShardIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("error unmarshalling %s: %w", ShardIndexFileName, err)
+	}
+	files := map[string][]byte{}
+	for key, parts := range index.Parts {
+		var content []byte
+		for _, part := range parts {
+			data, err := os.ReadFile(filepath.Join(dir, part))
+			if err != nil {
+				return nil, fmt.Errorf("error reading part of %s: %w", key, err)
+			}
+			content = append(content, data...)
+		}
+		files[filepath.Join(dir, key)] = content
+	}
+	return files, nil
+}
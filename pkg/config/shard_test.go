@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestReadJobConfigSharded(t *testing.T) {
+	periodics := func(names ...string) string {
+		var b strings.Builder
+		b.WriteString("periodics:\n")
+		for _, name := range names {
+			b.WriteString("- name: " + name + "\n  interval: 1h\n  spec:\n    containers:\n    - image: alpine\n")
+		}
+		return b.String()
+	}
+	var gzipped bytes.Buffer
+	z := gzip.NewWriter(&gzipped)
+	z.Write([]byte(periodics("gzipped")))
+	z.Close()
+	split := func(content string) (string, string) {
+		return content[:len(content)/2], content[len(content)/2:]
+	}
+
+	testCases := []struct {
+		name          string
+		files         map[string]string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name: "split files are reassembled",
+			files: map[string]string{
+				"small.yaml":              periodics("small"),
+				ShardIndexFileName:        `{"shards":["job-config-0","job-config-1"],"parts":{"big.yaml":["big.yaml.part-000","big.yaml.part-001"],"gzipped.yaml":["gzipped.yaml.part-000","gzipped.yaml.part-001"]}}`,
+				"big.yaml.part-000":       "",
+				"big.yaml.part-001":       "",
+				"gzipped.yaml.part-000":   "",
+				"gzipped.yaml.part-001":   "",
+				ConfigVersionFileName:     "version",
+				"unrelated.json.part-000": "{",
+			},
+			expected: []string{"big-1", "big-2", "gzipped", "small"},
+		},
+		{
+			name: "a missing part is an error",
+			files: map[string]string{
+				ShardIndexFileName: `{"shards":["job-config-0"],"parts":{"big.yaml":["big.yaml.part-000"]}}`,
+			},
+			expectedError: "error reading part of big.yaml",
+		},
+		{
+			name: "a reassembled file cannot duplicate a basename",
+			files: map[string]string{
+				"big.yaml":          periodics("other"),
+				ShardIndexFileName:  `{"shards":["job-config-0"],"parts":{"big.yaml":["big.yaml.part-000"]}}`,
+				"big.yaml.part-000": periodics("big"),
+			},
+			expectedError: "duplicated basename is not allowed: big.yaml",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := tc.files
+			if _, ok := files["big.yaml.part-001"]; ok {
+				files["big.yaml.part-000"], files["big.yaml.part-001"] = split(periodics("big-1", "big-2"))
+				files["gzipped.yaml.part-000"], files["gzipped.yaml.part-001"] = split(gzipped.String())
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0666); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+
+			jc, err := ReadJobConfig(dir)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var actual []string
+			for _, periodic := range jc.Periodics {
+				actual = append(actual, periodic.Name)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("unexpected periodics (-want +got):\n%s", diff)
+			}
+			for _, periodic := range jc.Periodics {
+				if periodic.Name == "big-1" && periodic.SourcePath != filepath.Join(dir, "big.yaml") {
+					t.Errorf("expected the source path of the reassembled file, got %s", periodic.SourcePath)
+				}
+			}
+		})
+	}
+}
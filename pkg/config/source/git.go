@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var shaRe = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// gitSource is a directory of a git repository at a ref.
+type gitSource struct {
+	url    string
+	ref    string
+	subdir string
+}
+
+func newGitSource(url, ref, subdir string) (*gitSource, error) {
+	if url == "" || ref == "" {
+		return nil, fmt.Errorf("git source needs both a URL and a ref, got %q and %q", url, ref)
+	}
+	subdir = filepath.Clean(filepath.FromSlash(subdir))
+	if filepath.IsAbs(subdir) || subdir == ".." || strings.HasPrefix(subdir, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("path %q of git source must be within the repository", subdir)
+	}
+	return &gitSource{url: url, ref: ref, subdir: subdir}, nil
+}
+
+func (s *gitSource) Revision(ctx context.Context) (string, error) {
+	if shaRe.MatchString(s.ref) {
+		return s.ref, nil
+	}
+	out, err := git(ctx, "ls-remote", "--", s.url, s.ref)
+	if err != nil {
+		return "", err
+	}
+	sha, _, _ := strings.Cut(strings.TrimSpace(out), "\t")
+	if !shaRe.MatchString(sha) {
+		return "", fmt.Errorf("ref %s not found in %s", s.ref, s.url)
+	}
+	return sha, nil
+}
+
+func (s *gitSource) Fetch(ctx context.Context, revision, dir string) error {
+	checkout := dir
+	if s.subdir != "." {
+		checkout = dir + ".git-checkout"
+		defer os.RemoveAll(checkout)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet", checkout},
+		{"-C", checkout, "fetch", "--quiet", "--depth=1", "--", s.url, revision},
+		{"-C", checkout, "-c", "advice.detachedHead=false", "checkout", "--quiet", "FETCH_HEAD"},
+	} {
+		if _, err := git(ctx, args...); err != nil {
+			return err
+		}
+	}
+	if s.subdir == "." {
+		// Only the files of the repository are config.
+		return os.RemoveAll(filepath.Join(dir, ".git"))
+	}
+	return os.Rename(filepath.Join(checkout, s.subdir), dir)
+}
+
+func git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const (
+	// titleAnnotation names the file a layer holds, as set by e.g. oras push.
+	titleAnnotation = "org.opencontainers.image.title"
+	// unpackAnnotation marks layers that hold a directory as a tarball.
+	unpackAnnotation = "io.deis.oras.content.unpack"
+)
+
+// ociSource is an OCI artifact, whose layers are files or tarballs of
+// directories.
+type ociSource struct {
+	ref name.Reference
+}
+
+func newOCISource(reference string) (*ociSource, error) {
+	ref, err := name.ParseReference(reference)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCI reference %q: %w", reference, err)
+	}
+	return &ociSource{ref: ref}, nil
+}
+
+func (s *ociSource) options(ctx context.Context) []remote.Option {
+	return []remote.Option{remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain)}
+}
+
+func (s *ociSource) Revision(ctx context.Context) (string, error) {
+	desc, err := remote.Head(s.ref, s.options(ctx)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", s.ref, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+func (s *ociSource) Fetch(ctx context.Context, revision, dir string) error {
+	img, err := remote.Image(s.ref.Context().Digest(revision), s.options(ctx)...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s@%s: %w", s.ref.Context(), revision, err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("failed to read the manifest of %s@%s: %w", s.ref.Context(), revision, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, desc := range manifest.Layers {
+		if err := fetchLayer(img, desc, dir); err != nil {
+			return fmt.Errorf("failed to fetch layer %s: %w", desc.Digest, err)
+		}
+	}
+	return nil
+}
+
+func fetchLayer(img v1.Image, desc v1.Descriptor, dir string) error {
+	layer, err := img.LayerByDigest(desc.Digest)
+	if err != nil {
+		return err
+	}
+	title := desc.Annotations[titleAnnotation]
+	if title != "" && desc.Annotations[unpackAnnotation] != "true" {
+		blob, err := layer.Compressed()
+		if err != nil {
+			return err
+		}
+		defer blob.Close()
+		return writeFile(dir, title, blob)
+	}
+	content, err := layer.Uncompressed()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return untar(content, dir)
+}
+
+func untar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Directories are created for the files they hold, and links are
+		// skipped so that no file is written outside of dir.
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := writeFile(dir, header.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(dir, name string, r io.Reader) error {
+	path, err := localPath(dir, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// localPath returns the path to the named file in dir, if it is within dir.
+func localPath(dir, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("path %q is outside of the artifact", name)
+	}
+	return filepath.Join(dir, name), nil
+}
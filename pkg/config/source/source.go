@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package source loads job config from locations other than the filesystem,
+// like OCI artifacts and git refs, by keeping a local copy of it that the
+// config agent loads like a mounted ConfigMap.
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Source is a location that job config can be fetched from.
+type Source interface {
+	// Revision identifies the current content of the source, e.g. the digest
+	// of an artifact or the commit a git ref points to.
+	Revision(ctx context.Context) (string, error)
+	// Fetch writes the content of the source at the revision to dir, which
+	// must not exist yet.
+	Fetch(ctx context.Context, revision, dir string) error
+}
+
+// Parse returns the source at the location, which is either
+//   - oci://<image reference>, e.g. oci://gcr.io/project/prow-jobs:latest
+//   - git+<URL>@<ref>[#<path>], e.g. git+https://github.com/org/repo@main#config/jobs
+func Parse(location string) (Source, error) {
+	if ref, ok := strings.CutPrefix(location, "oci://"); ok {
+		return newOCISource(ref)
+	}
+	if rest, ok := strings.CutPrefix(location, "git+"); ok {
+		rest, subdir, _ := strings.Cut(rest, "#")
+		i := strings.LastIndex(rest, "@")
+		// The @ of user@host is part of the URL, not the ref.
+		if i < 0 || strings.Contains(rest[i:], "/") || strings.Contains(rest[i:], ":") {
+			return nil, fmt.Errorf("git source %q must end with @<ref>", location)
+		}
+		return newGitSource(rest[:i], rest[i+1:], subdir)
+	}
+	return nil, fmt.Errorf("unsupported source %q: must start with oci:// or git+", location)
+}
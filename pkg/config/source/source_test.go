@@ -0,0 +1,269 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		location      string
+		expected      Source
+		expectedError string
+	}{
+		{
+			location: "git+https://github.com/org/repo@main#config/jobs",
+			expected: &gitSource{url: "https://github.com/org/repo", ref: "main", subdir: filepath.FromSlash("config/jobs")},
+		},
+		{
+			location: "git+ssh://git@github.com/org/repo.git@v1.0",
+			expected: &gitSource{url: "ssh://git@github.com/org/repo.git", ref: "v1.0", subdir: "."},
+		},
+		{
+			location:      "git+ssh://git@github.com/org/repo",
+			expectedError: "must end with @<ref>",
+		},
+		{
+			location:      "git+https://github.com/org/repo@main#../other",
+			expectedError: "must be within the repository",
+		},
+		{
+			location:      "oci://",
+			expectedError: "invalid OCI reference",
+		},
+		{
+			location:      "/etc/job-config",
+			expectedError: "unsupported source",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.location, func(t *testing.T) {
+			actual, err := Parse(tc.location)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmp.AllowUnexported(gitSource{})); diff != "" {
+				t.Errorf("unexpected source (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := Parse("oci://gcr.io/project/prow-jobs:latest"); err != nil {
+		t.Errorf("unexpected error parsing an OCI source: %v", err)
+	}
+}
+
+// fakeSource serves the files of its current revision.
+type fakeSource struct {
+	revision string
+	files    map[string]string
+	fetches  int
+}
+
+func (s *fakeSource) Revision(context.Context) (string, error) {
+	return s.revision, nil
+}
+
+func (s *fakeSource) Fetch(_ context.Context, revision, dir string) error {
+	s.fetches++
+	for name, content := range s.files {
+		if err := writeFile(dir, name, strings.NewReader(revision+":"+content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readDir(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", dir, err)
+	}
+	return files
+}
+
+func TestSyncer(t *testing.T) {
+	dir := t.TempDir()
+	source := &fakeSource{revision: "1", files: map[string]string{"jobs.yaml": "a", "org/repo/jobs.yaml": "b"}}
+	syncer := &Syncer{source: source, dir: dir, log: logrus.WithField("test", t.Name()), invalidate: make(chan struct{}, 1)}
+
+	sync := func(expected map[string]string, expectedFetches int) {
+		t.Helper()
+		if err := syncer.Sync(context.Background()); err != nil {
+			t.Fatalf("failed to sync: %v", err)
+		}
+		if diff := cmp.Diff(expected, readDir(t, syncer.Path())); diff != "" {
+			t.Errorf("unexpected job config (-want +got):\n%s", diff)
+		}
+		if source.fetches != expectedFetches {
+			t.Errorf("expected %d fetches, got %d", expectedFetches, source.fetches)
+		}
+	}
+	sync(map[string]string{"jobs.yaml": "1:a", "org/repo/jobs.yaml": "1:b"}, 1)
+	// The same revision is not fetched again.
+	sync(map[string]string{"jobs.yaml": "1:a", "org/repo/jobs.yaml": "1:b"}, 1)
+	// Files removed from the source are removed from the copy.
+	source.revision = "2"
+	source.files = map[string]string{"jobs.yaml": "c"}
+	sync(map[string]string{"jobs.yaml": "2:c"}, 2)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", dir, err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the current job config to be kept, got %v", entries)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	syncer := &Syncer{invalidate: make(chan struct{}, 1)}
+	for _, tc := range []struct {
+		method     string
+		statusCode int
+	}{
+		{method: http.MethodGet, statusCode: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, statusCode: http.StatusAccepted},
+		// A sync is pending already.
+		{method: http.MethodPost, statusCode: http.StatusAccepted},
+	} {
+		rr := httptest.NewRecorder()
+		syncer.ServeHTTP(rr, httptest.NewRequest(tc.method, "/", nil))
+		if rr.Code != tc.statusCode {
+			t.Errorf("%s: expected status %d, got %d", tc.method, tc.statusCode, rr.Code)
+		}
+	}
+	if len(syncer.invalidate) != 1 {
+		t.Errorf("expected a pending sync")
+	}
+}
+
+func TestUntar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, header := range []tar.Header{
+		{Name: "jobs/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "jobs/org/jobs.yaml", Typeflag: tar.TypeReg, Mode: 0644, Size: 4},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	} {
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+		if header.Size > 0 {
+			tw.Write([]byte("jobs"))
+		}
+	}
+	tw.Close()
+
+	dir := t.TempDir()
+	if err := untar(bytes.NewReader(buf.Bytes()), dir); err != nil {
+		t.Fatalf("failed to untar: %v", err)
+	}
+	if diff := cmp.Diff(map[string]string{"jobs/org/jobs.yaml": "jobs"}, readDir(t, dir)); diff != "" {
+		t.Errorf("unexpected files (-want +got):\n%s", diff)
+	}
+
+	buf.Reset()
+	tw = tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0644})
+	tw.Close()
+	if err := untar(bytes.NewReader(buf.Bytes()), dir); err == nil || !strings.Contains(err.Error(), "outside of the artifact") {
+		t.Errorf("expected a file outside of the artifact to be an error, got %v", err)
+	}
+}
+
+func TestGitSource(t *testing.T) {
+	repo := t.TempDir()
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main", repo},
+		{"-C", repo, "config", "user.name", "test"},
+		{"-C", repo, "config", "user.email", "test@example.com"},
+	} {
+		if _, err := git(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writeFile(repo, "config/jobs/jobs.yaml", strings.NewReader("jobs")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(repo, "README.md", strings.NewReader("readme")); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-C", repo, "add", "."},
+		{"-C", repo, "commit", "--quiet", "-m", "jobs"},
+	} {
+		if _, err := git(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		subdir   string
+		expected map[string]string
+	}{
+		{subdir: "", expected: map[string]string{"config/jobs/jobs.yaml": "jobs", "README.md": "readme"}},
+		{subdir: "config/jobs", expected: map[string]string{"jobs.yaml": "jobs"}},
+	} {
+		source, err := newGitSource("file://"+filepath.ToSlash(repo), "main", tc.subdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		revision, err := source.Revision(ctx)
+		if err != nil {
+			t.Fatalf("failed to resolve the revision: %v", err)
+		}
+		dir := filepath.Join(t.TempDir(), "content")
+		if err := source.Fetch(ctx, revision, dir); err != nil {
+			t.Fatalf("failed to fetch %s: %v", revision, err)
+		}
+		if diff := cmp.Diff(tc.expected, readDir(t, dir)); diff != "" {
+			t.Errorf("%q: unexpected files (-want +got):\n%s", tc.subdir, diff)
+		}
+	}
+}
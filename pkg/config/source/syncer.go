@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Syncer keeps a local directory in sync with a source. The config agent
+// reloads the job config from the directory when its modification time
+// changes, like for a mounted ConfigMap.
+type Syncer struct {
+	source Source
+	dir    string
+	log    *logrus.Entry
+
+	lock       sync.Mutex
+	revision   string
+	invalidate chan struct{}
+}
+
+// NewSyncer returns a syncer that keeps a copy of the source in dir.
+func NewSyncer(location, dir string) (*Syncer, error) {
+	source, err := Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return &Syncer{
+		source:     source,
+		dir:        dir,
+		log:        logrus.WithField("job-config-source", location),
+		invalidate: make(chan struct{}, 1),
+	}, nil
+}
+
+// Path is the directory that holds the latest revision of the source.
+func (s *Syncer) Path() string {
+	return filepath.Join(s.dir, "current")
+}
+
+// Sync fetches the latest revision of the source if it changed.
+func (s *Syncer) Sync(ctx context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	revision, err := s.source.Revision(ctx)
+	if err != nil {
+		return err
+	}
+	if revision == s.revision {
+		return nil
+	}
+	staging, err := os.MkdirTemp(s.dir, "staging-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	fetched := filepath.Join(staging, "content")
+	if err := s.source.Fetch(ctx, revision, fetched); err != nil {
+		return err
+	}
+	// Swap the directories rather than a symlink to them, as the config
+	// agent does not follow a symlink at the root of the job config.
+	previous := filepath.Join(staging, "previous")
+	if err := os.Rename(s.Path(), previous); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(fetched, s.Path()); err != nil {
+		return err
+	}
+	s.log.WithField("revision", revision).Info("Fetched job config.")
+	s.revision = revision
+	return nil
+}
+
+// Run syncs on the interval, or sooner when invalidated, until the context
+// is done.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.invalidate:
+		}
+		if err := s.Sync(ctx); err != nil {
+			s.log.WithError(err).Error("Failed to sync job config.")
+		}
+	}
+}
+
+// Invalidate makes Run sync right away, e.g. after the source was pushed to.
+func (s *Syncer) Invalidate() {
+	select {
+	case s.invalidate <- struct{}{}:
+	default:
+		// A sync is already pending.
+	}
+}
+
+// ServeHTTP invalidates the source on POST requests, so that registries and
+// git hosts can notify of pushes with a webhook. The request is not trusted:
+// it only makes the syncer check the source it is configured with.
+func (s *Syncer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.Invalidate()
+	w.WriteHeader(http.StatusAccepted)
+}
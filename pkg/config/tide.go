@@ -188,11 +188,79 @@ type TideContextPolicyOptions struct {
 type TideMergeCommitTemplate struct {
 	TitleTemplate string `json:"title,omitempty"`
 	BodyTemplate  string `json:"body,omitempty"`
+	// MergeMethods holds the templates to use for the commits created by
+	// specific merge methods, "merge" or "squash". They take precedence over
+	// the title and body above.
+	MergeMethods map[types.PullRequestMergeType]TideCommitTemplate `json:"merge_methods,omitempty"`
 
 	Title *template.Template `json:"-"`
 	Body  *template.Template `json:"-"`
 }
 
+// TideCommitTemplate holds the templates to use for the commits created by a
+// merge method.
+type TideCommitTemplate struct {
+	TitleTemplate string `json:"title,omitempty"`
+	BodyTemplate  string `json:"body,omitempty"`
+
+	Title *template.Template `json:"-"`
+	Body  *template.Template `json:"-"`
+}
+
+// ForMergeMethod returns the templates to use for the commit created by the
+// given merge method.
+func (t TideMergeCommitTemplate) ForMergeMethod(method types.PullRequestMergeType) TideMergeCommitTemplate {
+	byMethod, ok := t.MergeMethods[method]
+	if !ok {
+		return t
+	}
+	if byMethod.Title != nil {
+		t.Title = byMethod.Title
+	}
+	if byMethod.Body != nil {
+		t.Body = byMethod.Body
+	}
+	return t
+}
+
+func (t *TideMergeCommitTemplate) parse() error {
+	var err error
+	if t.Title, t.Body, err = parseCommitTemplates(t.TitleTemplate, t.BodyTemplate); err != nil {
+		return err
+	}
+	for method, templates := range t.MergeMethods {
+		if method != types.MergeMerge && method != types.MergeSquash {
+			return fmt.Errorf("merge commit templates can only be set for the merge methods %q and %q, not %q", types.MergeMerge, types.MergeSquash, method)
+		}
+		if templates.Title, templates.Body, err = parseCommitTemplates(templates.TitleTemplate, templates.BodyTemplate); err != nil {
+			return fmt.Errorf("merge method %q: %w", method, err)
+		}
+		t.MergeMethods[method] = templates
+	}
+	return nil
+}
+
+func parseCommitTemplates(titleTemplate, bodyTemplate string) (*template.Template, *template.Template, error) {
+	var title, body *template.Template
+	var err error
+	if titleTemplate != "" {
+		title, err = template.New("CommitTitle").Parse(titleTemplate)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing template for commit title: %w", err)
+		}
+	}
+
+	if bodyTemplate != "" {
+		body, err = template.New("CommitBody").Parse(bodyTemplate)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing template for commit body: %w", err)
+		}
+	}
+	return title, body, nil
+}
+
 // TidePriority contains a list of labels used to prioritize PRs in the merge pool
 type TidePriority struct {
 	Labels []string `json:"labels,omitempty"`
@@ -219,10 +287,39 @@ type Tide struct {
 	// starting a new one requires to start new instances of all tests.
 	// Use '*' as key to set this globally. Defaults to true.
 	PrioritizeExistingBatchesMap map[string]bool `json:"prioritize_existing_batches,omitempty"`
+	// FairScheduling limits how many pools Tide triggers tests for in each
+	// sync and rotates the triggering across repos, so that busy repos
+	// sharing a Tide instance cannot monopolize the testing capacity.
+	// Disabled if unset.
+	FairScheduling *TideFairScheduling `json:"fair_scheduling,omitempty"`
 
 	TideGitHubConfig `json:",inline"`
 }
 
+// TideFairScheduling configures how Tide shares the testing capacity between
+// repos. Pools that want to trigger tests are ranked by the time since tests
+// were last triggered for their repo plus the weighted time the oldest of
+// their PRs has been waiting in the pool.
+type TideFairScheduling struct {
+	// MaxTriggersPerSync is the number of pools Tide triggers tests for,
+	// batches or retests, in each sync. The other pools that want to trigger
+	// tests wait for a later sync. Must be positive.
+	MaxTriggersPerSync int `json:"max_triggers_per_sync"`
+	// QueueAgeWeight is how much the time the oldest PR of a pool has been
+	// waiting counts relative to the time since tests were last triggered
+	// for its repo. 0 only rotates between repos. Defaults to 1.
+	QueueAgeWeight *float64 `json:"queue_age_weight,omitempty"`
+}
+
+// GetQueueAgeWeight returns the queue age weight, falling back to the
+// default if unset.
+func (f *TideFairScheduling) GetQueueAgeWeight() float64 {
+	if f.QueueAgeWeight == nil {
+		return 1
+	}
+	return *f.QueueAgeWeight
+}
+
 // TideGitHubConfig is the tide config for GitHub.
 type TideGitHubConfig struct {
 	// StatusUpdatePeriod specifies how often Tide will update GitHub status contexts.
@@ -238,7 +335,9 @@ type TideGitHubConfig struct {
 
 	// A key/value pair of an org/repo as the key and Go template to override
 	// the default merge commit title and/or message. Template is passed the
-	// PullRequest struct (prow/github/types.go#PullRequest)
+	// MergeCommitData struct (pkg/tide/mergecommit.go#MergeCommitData), which
+	// holds the fields of the pull request as well as its co-authors,
+	// approvers and linked issues.
 	MergeTemplate map[string]TideMergeCommitTemplate `json:"merge_commit_template,omitempty"`
 
 	// URL for tide status contexts.
@@ -294,6 +393,23 @@ type TideGitHubConfig struct {
 	// -1 => batch merging disabled :(
 	BatchSizeLimitMap map[string]int `json:"batch_size_limit,omitempty"`
 
+	// MaxBaseDriftMap is a key/value pair of an org or org/repo as the key and
+	// the number of commits the base branch may move past the SHA a PR or batch
+	// was tested against before Tide stops merging it. Tide checks the branch right
+	// before merging, so that results that went stale during a sync are retested
+	// against the new base by the next sync instead of being merged.
+	// Use "*" as key to set a global default. The check is disabled when unset.
+	MaxBaseDriftMap map[string]int `json:"max_base_drift,omitempty"`
+
+	// RequireOwnersApprovalMap is a key/value pair of an org or org/repo as the key
+	// and whether Tide requires every file changed by a PR to be approved by its
+	// OWNERS right before merging it. The approvals are computed from the comments
+	// and reviews of the PR like the approve plugin does, which protects against an
+	// approved label that predates pushes changing files of other OWNERS. Tide uses
+	// the approve options of the plugin config it is given, if any.
+	// Use "*" as key to set a global default. The check is disabled when unset.
+	RequireOwnersApprovalMap map[string]bool `json:"require_owners_approval,omitempty"`
+
 	// Priority is an ordered list of sets of labels that would be prioritized before other PRs
 	// PRs should match all labels contained in a set to be prioritized. The first entry has
 	// the highest priority.
@@ -362,6 +478,32 @@ func (t *Tide) BatchSizeLimit(repo OrgRepo) int {
 	return t.BatchSizeLimitMap["*"]
 }
 
+// MaxBaseDrift returns the number of commits the base branch of a repo may
+// move past the tested SHA before Tide stops merging, and whether it is
+// configured at all.
+func (t *Tide) MaxBaseDrift(repo OrgRepo) (int, bool) {
+	if drift, ok := t.MaxBaseDriftMap[repo.String()]; ok {
+		return drift, true
+	}
+	if drift, ok := t.MaxBaseDriftMap[repo.Org]; ok {
+		return drift, true
+	}
+	drift, ok := t.MaxBaseDriftMap["*"]
+	return drift, ok
+}
+
+// RequireOwnersApproval returns whether Tide requires the changes of PRs to
+// the repo to be approved by their OWNERS before merging.
+func (t *Tide) RequireOwnersApproval(repo OrgRepo) bool {
+	if required, ok := t.RequireOwnersApprovalMap[repo.String()]; ok {
+		return required
+	}
+	if required, ok := t.RequireOwnersApprovalMap[repo.Org]; ok {
+		return required
+	}
+	return t.RequireOwnersApprovalMap["*"]
+}
+
 // MergeMethod returns the merge method to use for a repo. The default of merge is
 // returned when not overridden.
 func (t *Tide) MergeMethod(repo OrgRepo) types.PullRequestMergeType {
@@ -22,6 +22,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"text/template"
 
 	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/util/diff"
@@ -622,6 +623,72 @@ func TestMergeMethod(t *testing.T) {
 	}
 }
 
+func TestMaxBaseDrift(t *testing.T) {
+	ti := &Tide{
+		TideGitHubConfig: TideGitHubConfig{
+			MaxBaseDriftMap: map[string]int{
+				"kubernetes":            5,
+				"kubernetes/kubernetes": 0,
+			},
+		},
+	}
+
+	var testcases = []struct {
+		org           string
+		repo          string
+		expected      int
+		expectedIsSet bool
+	}{
+		{"kubernetes", "kubernetes", 0, true},
+		{"kubernetes", "test-infra", 5, true},
+		{"kubernetes-sigs", "prow", 0, false},
+	}
+
+	for _, test := range testcases {
+		actual, isSet := ti.MaxBaseDrift(OrgRepo{Org: test.org, Repo: test.repo})
+		if actual != test.expected || isSet != test.expectedIsSet {
+			t.Errorf("Expected max base drift %d (set: %t) but got %d (set: %t) for %s/%s", test.expected, test.expectedIsSet, actual, isSet, test.org, test.repo)
+		}
+	}
+
+	ti.MaxBaseDriftMap["*"] = 2
+	if actual, isSet := ti.MaxBaseDrift(OrgRepo{Org: "kubernetes-sigs", Repo: "prow"}); actual != 2 || !isSet {
+		t.Errorf("Expected the global max base drift 2, got %d (set: %t)", actual, isSet)
+	}
+}
+
+func TestRequireOwnersApproval(t *testing.T) {
+	ti := &Tide{
+		TideGitHubConfig: TideGitHubConfig{
+			RequireOwnersApprovalMap: map[string]bool{
+				"*":                     true,
+				"kubernetes":            false,
+				"kubernetes/kubernetes": true,
+			},
+		},
+	}
+
+	var testcases = []struct {
+		org      string
+		repo     string
+		expected bool
+	}{
+		{"kubernetes", "kubernetes", true},
+		{"kubernetes", "test-infra", false},
+		{"kubernetes-sigs", "prow", true},
+	}
+
+	for _, test := range testcases {
+		if actual := ti.RequireOwnersApproval(OrgRepo{Org: test.org, Repo: test.repo}); actual != test.expected {
+			t.Errorf("Expected owners approval to be required: %t but got %t for %s/%s", test.expected, actual, test.org, test.repo)
+		}
+	}
+
+	if (&Tide{}).RequireOwnersApproval(OrgRepo{Org: "kubernetes", Repo: "kubernetes"}) {
+		t.Error("Expected owners approval not to be required by default")
+	}
+}
+
 func TestOrgRepoMatchMergeMethod(t *testing.T) {
 	var testCases = []struct {
 		name     string
@@ -1156,6 +1223,50 @@ func TestMergeTemplate(t *testing.T) {
 	}
 }
 
+func TestMergeCommitTemplateForMergeMethod(t *testing.T) {
+	title := template.Must(template.New("CommitTitle").Parse("{{ .Title }}"))
+	body := template.Must(template.New("CommitBody").Parse("{{ .Body }}"))
+	squashBody := template.Must(template.New("CommitBody").Parse("{{ .Title }}\n\n{{ .Body }}"))
+	templates := TideMergeCommitTemplate{
+		Title: title,
+		Body:  body,
+		MergeMethods: map[types.PullRequestMergeType]TideCommitTemplate{
+			types.MergeSquash: {Body: squashBody},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		method        types.PullRequestMergeType
+		expectedTitle *template.Template
+		expectedBody  *template.Template
+	}{
+		{
+			name:          "no templates for merge method",
+			method:        types.MergeMerge,
+			expectedTitle: title,
+			expectedBody:  body,
+		},
+		{
+			name:          "templates for merge method take precedence",
+			method:        types.MergeSquash,
+			expectedTitle: title,
+			expectedBody:  squashBody,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := templates.ForMergeMethod(tc.method)
+			if actual.Title != tc.expectedTitle {
+				t.Errorf("expected title template %v, got %v", tc.expectedTitle, actual.Title)
+			}
+			if actual.Body != tc.expectedBody {
+				t.Errorf("expected body template %v, got %v", tc.expectedBody, actual.Body)
+			}
+		})
+	}
+}
+
 func TestParseTideContextPolicyOptions(t *testing.T) {
 	yes := true
 	no := false
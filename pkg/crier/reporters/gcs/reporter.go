@@ -34,6 +34,7 @@ import (
 	"sigs.k8s.io/prow/pkg/crier/reporters/gcs/util"
 	"sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/pjutil"
 	"sigs.k8s.io/prow/pkg/pod-utils/clone"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 )
@@ -159,6 +160,8 @@ func (gr *gcsReporter) reportStartedJob(ctx context.Context, log *logrus.Entry,
 }
 
 // reportFinishedJob uploads a finished.json for the job, iff one did not already exist.
+// If the cost of the job can be estimated, it is added to the metadata of the
+// finished.json instead, whether it was uploaded by the pod or by crier.
 func (gr *gcsReporter) reportFinishedJob(ctx context.Context, log *logrus.Entry, pj *prowv1.ProwJob) error {
 	output, err := util.MarshalFinishedJSON(pj)
 	if err != nil {
@@ -174,15 +177,43 @@ func (gr *gcsReporter) reportFinishedJob(ctx context.Context, log *logrus.Entry,
 		log.WithFields(logrus.Fields{"bucketName": bucketName, "dir": dir}).Debug("Would upload finished.json")
 		return nil
 	}
-	//PreconditionDoesNotExist:true means create only when file not exist.
-	overwriteOpt := io.WriterOptions{PreconditionDoesNotExist: utilpointer.Bool(true)}
 	finishedFilePath, err := providers.StoragePath(bucketName, path.Join(dir, prowv1.FinishedStatusFile))
 	if err != nil {
 		return fmt.Errorf("failed to resolve finished.json path: %v", err)
 	}
+	if cost, ok := pjutil.EstimateCost(pj, gr.cfg().CostAccounting); ok {
+		return gr.reportFinishedJobCost(ctx, log, finishedFilePath, output, cost)
+	}
+	//PreconditionDoesNotExist:true means create only when file not exist.
+	overwriteOpt := io.WriterOptions{PreconditionDoesNotExist: utilpointer.Bool(true)}
 	return io.WriteContent(ctx, log, gr.opener, finishedFilePath, output, overwriteOpt)
 }
 
+// reportFinishedJobCost adds the cost of the job to the existing finished.json,
+// or to the one crier would upload if there is none yet.
+func (gr *gcsReporter) reportFinishedJobCost(ctx context.Context, log *logrus.Entry, finishedFilePath string, output []byte, cost pjutil.JobCost) error {
+	content, err := io.ReadContent(ctx, log, gr.opener, finishedFilePath)
+	if err != nil {
+		if !io.IsNotExist(err) {
+			return fmt.Errorf("failed to read finished.json: %w", err)
+		}
+		content = output
+	}
+	var finished metadata.Finished
+	if err := json.Unmarshal(content, &finished); err != nil {
+		return fmt.Errorf("failed to unmarshal finished.json: %w", err)
+	}
+	if finished.Metadata == nil {
+		finished.Metadata = metadata.Metadata{}
+	}
+	finished.Metadata["cost"] = cost
+	output, err = json.MarshalIndent(finished, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to marshal finished metadata: %w", err)
+	}
+	return io.WriteContent(ctx, log, gr.opener, finishedFilePath, output)
+}
+
 func (gr *gcsReporter) reportProwjob(ctx context.Context, log *logrus.Entry, pj *prowv1.ProwJob) error {
 	// Unconditionally dump the ProwJob to GCS, on all job updates.
 	output, err := util.MarshalProwJob(pj)
@@ -29,10 +29,13 @@ import (
 	"github.com/GoogleCloudPlatform/testgrid/metadata"
 	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/crier/reporters/gcs/util"
 	"sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/io/fakeopener"
 	"sigs.k8s.io/prow/pkg/io/providers"
@@ -161,6 +164,103 @@ func TestReportJobFinished(t *testing.T) {
 	}
 }
 
+func TestReportJobFinishedCost(t *testing.T) {
+	start := metav1.Time{Time: time.Date(2010, 10, 10, 18, 00, 0, 0, time.UTC)}
+	completion := metav1.Time{Time: time.Date(2010, 10, 10, 20, 00, 0, 0, time.UTC)}
+	expectedCost := map[string]interface{}{"cpu_core_hours": 4.0, "memory_gib_hours": 8.0, "total": 0.2, "currency": "USD"}
+	testCases := []struct {
+		name     string
+		existing string
+		expected metadata.Metadata
+	}{
+		{
+			name:     "cost is added to the finished.json of crier",
+			expected: metadata.Metadata{"uploader": "crier", "cost": expectedCost},
+		},
+		{
+			name:     "cost is added to the finished.json of the pod",
+			existing: `{"timestamp": 1286733600, "passed": true, "result": "SUCCESS", "metadata": {"uploader": "sidecar"}}`,
+			expected: metadata.Metadata{"uploader": "sidecar", "cost": expectedCost},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := fca{c: config.Config{
+				ProwConfig: config.ProwConfig{
+					Plank: config.Plank{
+						DefaultDecorationConfigs: config.DefaultDecorationMapToSliceTesting(
+							map[string]*prowv1.DecorationConfig{"*": {
+								GCSConfiguration: &prowv1.GCSConfiguration{
+									Bucket:       "kubernetes-jenkins",
+									PathPrefix:   "some-prefix",
+									PathStrategy: prowv1.PathStrategyLegacy,
+									DefaultOrg:   "kubernetes",
+									DefaultRepo:  "kubernetes",
+								},
+							}}),
+					},
+					CostAccounting: config.CostAccounting{
+						Prices: map[string]config.ResourcePrices{"*": {CPU: 0.04, Memory: 0.005}},
+					},
+				},
+			}}.Config
+			fakeOpener := &fakeopener.FakeOpener{}
+			reporter := New(cfg, fakeOpener, false)
+
+			pj := &prowv1.ProwJob{
+				Spec: prowv1.ProwJobSpec{
+					Type:  prowv1.PeriodicJob,
+					Agent: prowv1.KubernetesAgent,
+					Job:   "my-little-job",
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("2"),
+						v1.ResourceMemory: resource.MustParse("4Gi"),
+					}}}}},
+				},
+				Status: prowv1.ProwJobStatus{
+					State:          prowv1.SuccessState,
+					StartTime:      start,
+					CompletionTime: &completion,
+					PodName:        "some-pod",
+					BuildID:        "123",
+				},
+			}
+			_, dir, err := util.GetJobDestination(cfg, pj)
+			if err != nil {
+				t.Fatalf("Failed to get job destination: %v", err)
+			}
+			finishedPath, err := providers.StoragePath("kubernetes-jenkins", path.Join(dir, prowv1.FinishedStatusFile))
+			if err != nil {
+				t.Fatalf("Failed to get finished.json path: %v", err)
+			}
+			if tc.existing != "" {
+				if err := io.WriteContent(ctx, logrus.NewEntry(logrus.StandardLogger()), fakeOpener, finishedPath, []byte(tc.existing)); err != nil {
+					t.Fatalf("Failed to write existing finished.json: %v", err)
+				}
+			}
+
+			if err := reporter.reportFinishedJob(ctx, logrus.NewEntry(logrus.StandardLogger()), pj); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			content, err := io.ReadContent(ctx, logrus.NewEntry(logrus.StandardLogger()), fakeOpener, finishedPath)
+			if err != nil {
+				t.Fatalf("Failed to read finished.json: %v", err)
+			}
+			var result metadata.Finished
+			if err := json.Unmarshal(content, &result); err != nil {
+				t.Fatalf("Couldn't decode result as metadata.Finished: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, result.Metadata); diff != "" {
+				t.Errorf("finished.json metadata differs from expected (-want +got):\n%s", diff)
+			}
+			if result.Passed == nil || !*result.Passed {
+				t.Errorf("Expected finished.json to have passed")
+			}
+		})
+	}
+}
+
 func TestReportJobStarted(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -105,13 +105,15 @@ type gerritClient interface {
 	SetReview(instance, id, revision, message string, labels map[string]string) error
 	GetChange(instance, id string, additionalFields ...string) (*gerrit.ChangeInfo, error)
 	ChangeExist(instance, id string) (bool, error)
+	GetSubmitRequirements(instance, id string) ([]client.SubmitRequirementResultInfo, error)
 }
 
 // Client is a gerrit reporter client
 type Client struct {
-	gc          gerritClient
-	pjclientset ctrlruntimeclient.Client
-	prLocks     *criercommonlib.ShardedLock
+	gc                  gerritClient
+	pjclientset         ctrlruntimeclient.Client
+	prLocks             *criercommonlib.ShardedLock
+	orgRepoConfigGetter func() *config.GerritOrgRepoConfigs
 }
 
 // Job is the view of a prowjob scoped for a report
@@ -152,9 +154,10 @@ func NewReporter(orgRepoConfigGetter func() *config.GerritOrgRepoConfigs, cookie
 	gc.Authenticate(cookiefilePath, "")
 
 	c := &Client{
-		gc:          gc,
-		pjclientset: pjclientset,
-		prLocks:     criercommonlib.NewShardedLock(),
+		gc:                  gc,
+		pjclientset:         pjclientset,
+		prLocks:             criercommonlib.NewShardedLock(),
+		orgRepoConfigGetter: orgRepoConfigGetter,
 	}
 
 	c.prLocks.RunCleanup()
@@ -166,6 +169,19 @@ func (c *Client) GetName() string {
 	return "gerrit-reporter"
 }
 
+// votingStrategy returns the voting strategy configured for the repo of the
+// prowjob, which is empty if there is none.
+func (c *Client) votingStrategy(pj *v1.ProwJob) config.GerritVotingStrategy {
+	if c.orgRepoConfigGetter == nil || pj.Spec.Refs == nil {
+		return config.GerritVotingStrategy{}
+	}
+	strategy := c.orgRepoConfigGetter().VotingStrategy(pj.ObjectMeta.Annotations[kube.GerritInstance], pj.Spec.Refs.Repo)
+	if strategy == nil {
+		return config.GerritVotingStrategy{}
+	}
+	return *strategy
+}
+
 // ShouldReport returns if this prowjob should be reported by the gerrit reporter
 func (c *Client) ShouldReport(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob) bool {
 	if !pj.Spec.Report {
@@ -176,7 +192,14 @@ func (c *Client) ShouldReport(ctx context.Context, log *logrus.Entry, pj *v1.Pro
 	defer cancel()
 
 	if pj.Status.State == v1.TriggeredState || pj.Status.State == v1.PendingState {
-		// not done yet
+		// not done yet, but the vote may need to be reset for the new patchset
+		if c.votingStrategy(pj).ResetOnNewPatchset && pj.Spec.Type == v1.PresubmitJob &&
+			pj.ObjectMeta.Labels[kube.GerritReportLabel] != "" &&
+			pj.ObjectMeta.Annotations[kube.GerritID] != "" &&
+			pj.ObjectMeta.Annotations[kube.GerritInstance] != "" &&
+			pj.ObjectMeta.Labels[kube.GerritRevision] != "" {
+			return true
+		}
 		log.Info("PJ not finished")
 		return false
 	}
@@ -317,6 +340,11 @@ func (c *Client) Report(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJo
 	newCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
+	if pj.Status.State == v1.TriggeredState || pj.Status.State == v1.PendingState {
+		return nil, nil, c.resetVote(newCtx, logger, pj)
+	}
+
+	strategy := c.votingStrategy(pj)
 	clientGerritRevision := kube.GerritRevision
 	clientGerritID := kube.GerritID
 	clientGerritInstance := kube.GerritInstance
@@ -409,6 +437,25 @@ func (c *Client) Report(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJo
 			vote = lztm
 		}
 		reviewLabels = map[string]string{reportLabel: vote}
+
+		if strategy.LatestPatchsetOnly {
+			latest, err := c.gc.GetChange(gerritInstance, gerritID, "CURRENT_REVISION")
+			if err != nil {
+				logger.WithError(err).Warn("Unable to get change, voting anyway.")
+			} else if latest.CurrentRevision != gerritRevision {
+				logger.WithField("current_revision", latest.CurrentRevision).Info("Not voting on outdated patchset.")
+				reviewLabels = nil
+			}
+		}
+	}
+
+	if strategy.ReportSubmitRequirements && report.Success == report.Total {
+		requirements, err := c.gc.GetSubmitRequirements(gerritInstance, gerritID)
+		if err != nil {
+			logger.WithError(err).Warn("Unable to get submit requirements.")
+		} else if unsatisfied := client.UnsatisfiedSubmitRequirements(requirements); len(unsatisfied) > 0 {
+			message += fmt.Sprintf("\nSubmit requirements not yet satisfied: %s\n", strings.Join(unsatisfied, ", "))
+		}
 	}
 
 	logger.Infof("Reporting to instance %s on id %s with message %s", gerritInstance, gerritID, message)
@@ -491,6 +538,51 @@ func (c *Client) Report(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJo
 	return nil, nil, err
 }
 
+// resetVote resets the vote on the report label of the prowjob to 0 when
+// the first jobs start on a new patchset, so that votes copied from a
+// previous patchset do not stand until the new results are reported. Jobs
+// rerun on a patchset that was already reported on do not reset the vote.
+func (c *Client) resetVote(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJob) error {
+	reportLabel := pj.ObjectMeta.Labels[kube.GerritReportLabel]
+	selector := map[string]string{
+		kube.GerritRevision:    pj.ObjectMeta.Labels[kube.GerritRevision],
+		kube.ProwJobTypeLabel:  pj.ObjectMeta.Labels[kube.ProwJobTypeLabel],
+		kube.GerritReportLabel: reportLabel,
+	}
+	var pjs v1.ProwJobList
+	if err := c.pjclientset.List(ctx, &pjs, ctrlruntimeclient.MatchingLabels(selector)); err != nil {
+		return fmt.Errorf("failed to list prowjobs with selector %v: %w", selector, err)
+	}
+	reset := true
+	for _, other := range pjs.Items {
+		if _, reported := other.Status.PrevReportStates[c.GetName()]; reported {
+			reset = false
+			break
+		}
+	}
+
+	if reset {
+		gerritInstance := pj.ObjectMeta.Annotations[kube.GerritInstance]
+		gerritID := pj.ObjectMeta.Annotations[kube.GerritID]
+		message := fmt.Sprintf("Jobs started on this patchset, resetting %s until they finish.", reportLabel)
+		logger.WithFields(logrus.Fields{"instance": gerritInstance, "id": gerritID, "label": reportLabel}).Info("Resetting vote for new patchset.")
+		if err := c.gc.SetReview(gerritInstance, gerritID, pj.ObjectMeta.Labels[kube.GerritRevision], message, map[string]string{reportLabel: lztm}); err != nil {
+			exist, existErr := c.gc.ChangeExist(gerritInstance, gerritID)
+			if existErr != nil || exist {
+				return fmt.Errorf("failed to reset vote: %w", err)
+			}
+			logger.WithError(err).Info("Change doesn't exist any more, skip resetting vote.")
+		}
+	}
+
+	// Mark the job reported while still holding the lock, so that the other
+	// jobs of this patchset see the vote was already reset.
+	if pj.Status.PrevReportStates[c.GetName()] == pj.Status.State {
+		return nil
+	}
+	return criercommonlib.UpdateReportStateWithRetries(ctx, pj, logger, c.pjclientset, c.GetName())
+}
+
 func jobNames(jobs []*v1.ProwJob) []string {
 	names := make([]string, len(jobs))
 	for i, job := range jobs {
@@ -36,7 +36,9 @@ import (
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	v1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/crier/reporters/criercommonlib"
+	"sigs.k8s.io/prow/pkg/gerrit/client"
 	"sigs.k8s.io/prow/pkg/kube"
 )
 
@@ -48,11 +50,16 @@ const (
 )
 
 type fgc struct {
-	reportMessage string
-	reportLabel   map[string]string
-	instance      string
-	changes       map[string][]*gerrit.ChangeInfo
-	count         int
+	reportMessage      string
+	reportLabel        map[string]string
+	instance           string
+	changes            map[string][]*gerrit.ChangeInfo
+	submitRequirements map[string][]client.SubmitRequirementResultInfo
+	count              int
+}
+
+func (f *fgc) GetSubmitRequirements(instance, id string) ([]client.SubmitRequirementResultInfo, error) {
+	return f.submitRequirements[id], nil
 }
 
 func (f *fgc) SetReview(instance, id, revision, message string, labels map[string]string) error {
@@ -2064,6 +2071,198 @@ func TestReport(t *testing.T) {
 	}
 }
 
+func TestVotingStrategy(t *testing.T) {
+	changes := map[string][]*gerrit.ChangeInfo{
+		"gerrit": {
+			{ID: "123-abc", Status: "NEW", CurrentRevision: "def", Revisions: map[string]gerrit.RevisionInfo{"abc": {}, "def": {}}},
+		},
+	}
+	submitRequirements := map[string][]client.SubmitRequirementResultInfo{
+		"123-abc": {
+			{Name: "Code-Review", Status: client.SubmitRequirementUnsatisfied},
+			{Name: "Verified", Status: client.SubmitRequirementSatisfied},
+			{Name: "No-Unresolved-Comments", Status: client.SubmitRequirementError},
+			{Name: "Legacy", Status: client.SubmitRequirementNotApplicable},
+		},
+	}
+	makePJ := func(name, revision string, state v1.ProwJobState, reported v1.ProwJobState) *v1.ProwJob {
+		pj := &v1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					kube.GerritRevision:    revision,
+					kube.ProwJobTypeLabel:  presubmit,
+					kube.GerritReportLabel: "Verified",
+					kube.OrgLabel:          "gerrit",
+					kube.RepoLabel:         "foo",
+					kube.PullLabel:         "0",
+				},
+				Annotations: map[string]string{
+					kube.GerritID:       "123-abc",
+					kube.GerritInstance: "gerrit",
+				},
+			},
+			Spec: v1.ProwJobSpec{
+				Type:   v1.PresubmitJob,
+				Refs:   &v1.Refs{Repo: "foo", Pulls: []v1.Pull{{Number: 0}}},
+				Job:    name,
+				Report: true,
+			},
+			Status: v1.ProwJobStatus{
+				State: state,
+				URL:   "guber/" + name,
+			},
+		}
+		if reported != "" {
+			pj.Status.PrevReportStates = map[string]v1.ProwJobState{"gerrit-reporter": reported}
+		}
+		return pj
+	}
+
+	var testcases = []struct {
+		name          string
+		strategy      config.GerritVotingStrategy
+		pj            *v1.ProwJob
+		existingPJs   []*v1.ProwJob
+		expectReport  bool
+		reportInclude []string
+		reportExclude []string
+		expectLabel   map[string]string
+		expectCount   int
+	}{
+		{
+			name:          "default strategy votes on outdated patchset",
+			pj:            makePJ("ci-foo", "abc", v1.SuccessState, ""),
+			expectReport:  true,
+			reportInclude: []string{"1 out of 1", "ci-foo"},
+			reportExclude: []string{"Submit requirements"},
+			expectLabel:   map[string]string{"Verified": lgtm},
+			expectCount:   1,
+		},
+		{
+			name:          "latest patchset only does not vote on outdated patchset",
+			strategy:      config.GerritVotingStrategy{LatestPatchsetOnly: true},
+			pj:            makePJ("ci-foo", "abc", v1.SuccessState, ""),
+			expectReport:  true,
+			reportInclude: []string{"1 out of 1", "ci-foo"},
+			expectCount:   1,
+		},
+		{
+			name:          "latest patchset only votes on latest patchset",
+			strategy:      config.GerritVotingStrategy{LatestPatchsetOnly: true},
+			pj:            makePJ("ci-foo", "def", v1.FailureState, ""),
+			expectReport:  true,
+			reportInclude: []string{"0 out of 1", "ci-foo"},
+			expectLabel:   map[string]string{"Verified": lbtm},
+			expectCount:   1,
+		},
+		{
+			name:         "unfinished job is not reported by default",
+			pj:           makePJ("ci-foo", "def", v1.PendingState, ""),
+			expectReport: false,
+		},
+		{
+			name:          "reset on new patchset resets the vote",
+			strategy:      config.GerritVotingStrategy{ResetOnNewPatchset: true},
+			pj:            makePJ("ci-foo", "def", v1.TriggeredState, ""),
+			expectReport:  true,
+			reportInclude: []string{"resetting Verified"},
+			expectLabel:   map[string]string{"Verified": lztm},
+			expectCount:   1,
+		},
+		{
+			name:     "reset on new patchset only resets once per patchset",
+			strategy: config.GerritVotingStrategy{ResetOnNewPatchset: true},
+			pj:       makePJ("ci-foo", "def", v1.TriggeredState, ""),
+			existingPJs: []*v1.ProwJob{
+				makePJ("ci-bar", "def", v1.PendingState, v1.TriggeredState),
+			},
+			expectReport: true,
+		},
+		{
+			name:     "reset on new patchset does not reset for reruns",
+			strategy: config.GerritVotingStrategy{ResetOnNewPatchset: true},
+			pj:       makePJ("ci-foo", "def", v1.PendingState, ""),
+			existingPJs: []*v1.ProwJob{
+				makePJ("ci-foo-old", "def", v1.FailureState, v1.FailureState),
+			},
+			expectReport: true,
+		},
+		{
+			name:          "unsatisfied submit requirements are reported when all jobs passed",
+			strategy:      config.GerritVotingStrategy{ReportSubmitRequirements: true},
+			pj:            makePJ("ci-foo", "def", v1.SuccessState, ""),
+			expectReport:  true,
+			reportInclude: []string{"1 out of 1", "Submit requirements not yet satisfied: Code-Review, No-Unresolved-Comments"},
+			reportExclude: []string{"Legacy"},
+			expectLabel:   map[string]string{"Verified": lgtm},
+			expectCount:   1,
+		},
+		{
+			name:          "submit requirements are not reported when jobs failed",
+			strategy:      config.GerritVotingStrategy{ReportSubmitRequirements: true},
+			pj:            makePJ("ci-foo", "def", v1.FailureState, ""),
+			expectReport:  true,
+			reportInclude: []string{"0 out of 1"},
+			reportExclude: []string{"Submit requirements"},
+			expectLabel:   map[string]string{"Verified": lbtm},
+			expectCount:   1,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			fgc := &fgc{instance: "gerrit", changes: changes, submitRequirements: submitRequirements}
+
+			builder := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(tc.pj)
+			for _, pj := range tc.existingPJs {
+				builder.WithRuntimeObjects(pj)
+			}
+			strategy := tc.strategy
+			reporter := &Client{
+				gc:          fgc,
+				pjclientset: builder.Build(),
+				prLocks:     criercommonlib.NewShardedLock(),
+				orgRepoConfigGetter: func() *config.GerritOrgRepoConfigs {
+					return &config.GerritOrgRepoConfigs{{Org: "gerrit", Repos: []string{"foo"}, VotingStrategy: &strategy}}
+				},
+			}
+
+			shouldReport := reporter.ShouldReport(context.Background(), logrus.NewEntry(logrus.StandardLogger()), tc.pj)
+			if shouldReport != tc.expectReport {
+				t.Fatalf("shouldReport: %v, expectReport: %v", shouldReport, tc.expectReport)
+			}
+			if !shouldReport {
+				return
+			}
+
+			if _, _, err := reporter.Report(context.Background(), logrus.NewEntry(logrus.StandardLogger()), tc.pj); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for _, include := range tc.reportInclude {
+				if !strings.Contains(fgc.reportMessage, include) {
+					t.Errorf("message: got %q, does not contain %s", fgc.reportMessage, include)
+				}
+			}
+			for _, exclude := range tc.reportExclude {
+				if strings.Contains(fgc.reportMessage, exclude) {
+					t.Errorf("message: got %q, unexpectedly contains %s", fgc.reportMessage, exclude)
+				}
+			}
+			if !reflect.DeepEqual(tc.expectLabel, fgc.reportLabel) {
+				t.Errorf("labels: got %v, want %v", fgc.reportLabel, tc.expectLabel)
+			}
+			if fgc.count != tc.expectCount {
+				t.Errorf("review count: got %d, want %d", fgc.count, tc.expectCount)
+			}
+			if unfinished := tc.pj.Status.State == v1.TriggeredState || tc.pj.Status.State == v1.PendingState; unfinished && tc.pj.Status.PrevReportStates[reporter.GetName()] != tc.pj.Status.State {
+				t.Errorf("expected job to be marked as reported in state %s, got %v", tc.pj.Status.State, tc.pj.Status.PrevReportStates)
+			}
+		})
+	}
+}
+
 func TestMultipleWorks(t *testing.T) {
 	samplePJ := v1.ProwJob{
 		ObjectMeta: metav1.ObjectMeta{
@@ -135,6 +135,15 @@ func (c *Client) Report(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob)
 			return []*v1.ProwJob{pj}, nil, nil
 		}
 	}
+	for _, ident := range c.config().GitHubReporter.AggregatedCommentRepos {
+		if refs.Org == ident || fullRepo == ident {
+			toReport, err := pjsForLatestCommit(ctx, c.lister, pj)
+			if err != nil {
+				return []*v1.ProwJob{pj}, nil, err
+			}
+			return []*v1.ProwJob{pj}, nil, report.ReportAggregatedComment(ctx, c.gc, toReport, c.config().GitHubReporter)
+		}
+	}
 	// Check if this org or repo has opted out of failure report comments
 	toReport := []v1.ProwJob{*pj}
 	var mustCreateComment bool
@@ -191,6 +200,50 @@ func pjsToReport(ctx context.Context, log *logrus.Entry, lister ctrlruntimeclien
 	return toReport, nil
 }
 
+// pjsForLatestCommit returns the most recent run of each reported job of the
+// pull request of pj for the latest commit that jobs ran for, including jobs
+// that are still running. It returns no jobs if pj is for an older commit.
+func pjsForLatestCommit(ctx context.Context, lister ctrlruntimeclient.Reader, pj *v1.ProwJob) ([]v1.ProwJob, error) {
+	if len(pj.Spec.Refs.Pulls) != 1 {
+		return nil, nil
+	}
+	selector := map[string]string{}
+	for _, l := range []string{kube.OrgLabel, kube.RepoLabel, kube.PullLabel} {
+		selector[l] = pj.ObjectMeta.Labels[l]
+	}
+	var pjs v1.ProwJobList
+	if err := lister.List(ctx, &pjs, ctrlruntimeclient.MatchingLabels(selector)); err != nil {
+		return nil, fmt.Errorf("Cannot list prowjob with selector %v", selector)
+	}
+
+	latest := *pj
+	for _, pjob := range pjs.Items {
+		if pjob.Spec.Report && pjob.Spec.Refs != nil && len(pjob.Spec.Refs.Pulls) == 1 && pjob.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = pjob
+		}
+	}
+	sha := latest.Spec.Refs.Pulls[0].SHA
+	if pj.Spec.Refs.Pulls[0].SHA != sha {
+		// Results for an older commit must not replace the summary of the latest one.
+		return nil, nil
+	}
+
+	latestRuns := map[string]v1.ProwJob{pj.Spec.Job: *pj}
+	for _, pjob := range pjs.Items {
+		if !pjob.Spec.Report || pjob.Spec.Refs == nil || len(pjob.Spec.Refs.Pulls) != 1 || pjob.Spec.Refs.Pulls[0].SHA != sha {
+			continue
+		}
+		if existing, ok := latestRuns[pjob.Spec.Job]; !ok || pjob.CreationTimestamp.After(existing.CreationTimestamp.Time) {
+			latestRuns[pjob.Spec.Job] = pjob
+		}
+	}
+	var toReport []v1.ProwJob
+	for _, pjob := range latestRuns {
+		toReport = append(toReport, pjob)
+	}
+	return toReport, nil
+}
+
 func lockKeyForPJ(pj *v1.ProwJob) (*criercommonlib.SimplePull, error) {
 	if pj.Spec.Type != v1.PresubmitJob {
 		return nil, fmt.Errorf("can only get lock key for presubmit jobs, was %q", pj.Spec.Type)
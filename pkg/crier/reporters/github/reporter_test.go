@@ -1016,3 +1016,70 @@ func TestPjsToReport(t *testing.T) {
 		})
 	}
 }
+
+func TestPjsForLatestCommit(t *testing.T) {
+	timeNow := time.Now().Truncate(time.Second)
+	pj := func(name, job, sha string, age time.Duration, state v1.ProwJobState) *v1.ProwJob {
+		return &v1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					kube.OrgLabel:  "org",
+					kube.RepoLabel: "repo",
+					kube.PullLabel: "123",
+				},
+				CreationTimestamp: metav1.Time{Time: timeNow.Add(-age)},
+			},
+			Spec: v1.ProwJobSpec{
+				Type:   v1.PresubmitJob,
+				Job:    job,
+				Report: true,
+				Refs:   &v1.Refs{Org: "org", Repo: "repo", Pulls: []v1.Pull{{Number: 123, SHA: sha}}},
+			},
+			Status: v1.ProwJobStatus{State: state},
+		}
+	}
+	testCases := []struct {
+		name        string
+		pj          *v1.ProwJob
+		existingPJs []*v1.ProwJob
+		wantNames   []string
+	}{
+		{
+			name: "latest runs of the jobs for the latest commit, including running ones",
+			pj:   pj("unit-2", "unit", "new", time.Minute, v1.SuccessState),
+			existingPJs: []*v1.ProwJob{
+				pj("unit-1", "unit", "new", time.Hour, v1.FailureState),
+				pj("e2e-1", "e2e", "new", time.Hour, v1.PendingState),
+				pj("lint-1", "lint", "old", 2*time.Hour, v1.FailureState),
+			},
+			wantNames: []string{"e2e-1", "unit-2"},
+		},
+		{
+			name: "nothing for an older commit",
+			pj:   pj("unit-1", "unit", "old", time.Hour, v1.SuccessState),
+			existingPJs: []*v1.ProwJob{
+				pj("unit-2", "unit", "new", time.Minute, v1.PendingState),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.pj)
+			for _, pj := range tc.existingPJs {
+				builder.WithObjects(pj)
+			}
+			got, err := pjsForLatestCommit(context.Background(), builder.Build(), tc.pj)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var gotNames []string
+			for _, pj := range got {
+				gotNames = append(gotNames, pj.Name)
+			}
+			if diff := cmp.Diff(tc.wantNames, gotNames, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("jobs differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -57,6 +57,10 @@ type ReportMessage struct {
 	JobType prowapi.ProwJobType  `json:"job_type"`
 	JobName string               `json:"job_name"`
 	Message string               `json:"message,omitempty"`
+	// Labels and Annotations are the labels and annotations of the ProwJob
+	// allowed by plank.label_propagation.reports.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // Client is a reporter client fed to crier controller
@@ -182,16 +186,19 @@ func (c *Client) generateMessageFromPJ(pj *prowapi.ProwJob) *ReportMessage {
 
 	}
 
+	labels, annotations := c.config().Plank.LabelPropagation.ForReport(pj.Labels, pj.Annotations)
 	return &ReportMessage{
-		Project: pubSubMap[PubSubProjectLabel],
-		Topic:   pubSubMap[PubSubTopicLabel],
-		RunID:   pubSubMap[PubSubRunIDLabel],
-		Status:  pj.Status.State,
-		URL:     pj.Status.URL,
-		GCSPath: storagePath,
-		Refs:    refs,
-		JobType: pj.Spec.Type,
-		JobName: pj.Spec.Job,
-		Message: pj.Status.Description,
+		Project:     pubSubMap[PubSubProjectLabel],
+		Topic:       pubSubMap[PubSubTopicLabel],
+		RunID:       pubSubMap[PubSubRunIDLabel],
+		Status:      pj.Status.State,
+		URL:         pj.Status.URL,
+		GCSPath:     storagePath,
+		Refs:        refs,
+		JobType:     pj.Spec.Type,
+		JobName:     pj.Spec.Job,
+		Message:     pj.Status.Description,
+		Labels:      labels,
+		Annotations: annotations,
 	}
 }
@@ -48,11 +48,12 @@ func (f *fca) Config() *config.Config {
 
 func TestGenerateMessageFromPJ(t *testing.T) {
 	var testcases = []struct {
-		name            string
-		pj              *prowapi.ProwJob
-		jobURLPrefix    string
-		expectedMessage *ReportMessage
-		expectedError   error
+		name             string
+		pj               *prowapi.ProwJob
+		jobURLPrefix     string
+		labelPropagation *config.LabelPropagationPolicy
+		expectedMessage  *ReportMessage
+		expectedError    error
 	}{
 		// tests with gubernator job URLs
 		{
@@ -323,6 +324,54 @@ func TestGenerateMessageFromPJ(t *testing.T) {
 				Message: "this job went great",
 			},
 		},
+		{
+			name: "Labels and annotations allowed by the label propagation policy are included",
+			pj: &prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test1",
+					Labels: map[string]string{
+						PubSubProjectLabel: testPubSubProjectName,
+						PubSubTopicLabel:   testPubSubTopicName,
+						PubSubRunIDLabel:   testPubSubRunID,
+						"team":             "sig-testing",
+					},
+					Annotations: map[string]string{
+						"description": "job",
+					},
+				},
+				Status: prowapi.ProwJobStatus{
+					State: prowapi.SuccessState,
+					URL:   "guber/test1",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type: prowapi.PeriodicJob,
+					Job:  "test1",
+				},
+			},
+			jobURLPrefix: "guber/",
+			labelPropagation: &config.LabelPropagationPolicy{
+				Reports:        &config.PropagationRules{AllowedLabels: []string{"^team$"}, AllowedAnnotations: []string{"^description$"}},
+				RequiredLabels: []config.RequiredLabel{{Key: "cost-center", Default: "shared"}},
+			},
+			expectedMessage: &ReportMessage{
+				Project: testPubSubProjectName,
+				Topic:   testPubSubTopicName,
+				RunID:   testPubSubRunID,
+				Status:  prowapi.SuccessState,
+				URL:     "guber/test1",
+				GCSPath: "gs://test1",
+				JobType: prowapi.PeriodicJob,
+				JobName: "test1",
+				Labels: map[string]string{
+					PubSubProjectLabel: testPubSubProjectName,
+					PubSubTopicLabel:   testPubSubTopicName,
+					PubSubRunIDLabel:   testPubSubRunID,
+					"team":             "sig-testing",
+					"cost-center":      "shared",
+				},
+				Annotations: map[string]string{"description": "job"},
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -331,6 +380,7 @@ func TestGenerateMessageFromPJ(t *testing.T) {
 				ProwConfig: config.ProwConfig{
 					Plank: config.Plank{
 						JobURLPrefixConfig: map[string]string{"*": tc.jobURLPrefix},
+						LabelPropagation:   tc.labelPropagation,
 					},
 				},
 			},
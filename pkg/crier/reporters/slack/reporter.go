@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"text/template"
 
 	"github.com/sirupsen/logrus"
@@ -28,6 +29,7 @@ import (
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/pjutil"
 	slackclient "sigs.k8s.io/prow/pkg/slack"
 )
 
@@ -95,6 +97,9 @@ func (sr *slackReporter) report(log *logrus.Entry, pj *prowapi.ProwJob) error {
 		log.WithError(err).Error("failed to execute report template")
 		return fmt.Errorf("failed to execute report template: %w", err)
 	}
+	if footer := ownershipFooter(pj); footer != "" {
+		b.WriteString("\n" + footer)
+	}
 	if sr.dryRun {
 		log.WithField("messagetext", b.String()).Debug("Skipping reporting because dry-run is enabled")
 		return nil
@@ -106,6 +111,25 @@ func (sr *slackReporter) report(log *logrus.Entry, pj *prowapi.ProwJob) error {
 	return nil
 }
 
+// ownershipFooter returns a line listing the owner, escalation contact and
+// docs of the job as declared by its ownership annotations, if any.
+func ownershipFooter(pj *prowapi.ProwJob) string {
+	ownership := pjutil.OwnershipFor(pj.Annotations)
+	var parts []string
+	if ownership.Owner != "" {
+		parts = append(parts, fmt.Sprintf("Owner: *%s*", ownership.Owner))
+	}
+	if escalationURL := ownership.EscalationURL(); escalationURL != "" {
+		parts = append(parts, fmt.Sprintf("Escalation: <%s>", escalationURL))
+	} else if ownership.Escalation != "" {
+		parts = append(parts, fmt.Sprintf("Escalation: %s", ownership.Escalation))
+	}
+	if ownership.Docs != "" {
+		parts = append(parts, fmt.Sprintf("<%s|Job docs>", ownership.Docs))
+	}
+	return strings.Join(parts, " | ")
+}
+
 func (sr *slackReporter) GetName() string {
 	return reporterName
 }
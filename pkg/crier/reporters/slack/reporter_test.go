@@ -20,7 +20,9 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
@@ -669,3 +671,59 @@ func TestReportDefaultsToExtraRefs(t *testing.T) {
 		t.Errorf("expected the channel 'emergency' to contain message 'there you go' but wasn't the case, all messages: %v", fsc.messages)
 	}
 }
+
+func TestReportIncludesOwnership(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			name:     "no ownership annotations",
+			expected: "job failed",
+		},
+		{
+			name: "all ownership annotations",
+			annotations: map[string]string{
+				"prow.k8s.io/owner":      "sig-testing",
+				"prow.k8s.io/escalation": "#sig-testing",
+				"prow.k8s.io/docs":       "https://docs.example.com/job",
+			},
+			expected: "job failed\nOwner: *sig-testing* | Escalation: #sig-testing | <https://docs.example.com/job|Job docs>",
+		},
+		{
+			name: "escalation URL",
+			annotations: map[string]string{
+				"prow.k8s.io/escalation": "https://issues.example.com/new",
+			},
+			expected: "job failed\nEscalation: <https://issues.example.com/new>",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			job := &v1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+				Spec:       v1.ProwJobSpec{Type: v1.PeriodicJob},
+				Status:     v1.ProwJobStatus{State: v1.FailureState},
+			}
+			fsc := &fakeSlackClient{}
+			sr := slackReporter{
+				config: func(r *v1.Refs) config.SlackReporter {
+					return config.SlackReporter{
+						SlackReporterConfig: v1.SlackReporterConfig{
+							Channel:        "alerts",
+							ReportTemplate: "job failed",
+						},
+					}
+				},
+				clients: map[string]slackClient{DefaultHostName: fsc},
+			}
+			if _, _, err := sr.Report(context.Background(), logrus.NewEntry(logrus.StandardLogger()), job); err != nil {
+				t.Fatalf("reporting failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, fsc.messages["alerts"]); diff != "" {
+				t.Errorf("unexpected message (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
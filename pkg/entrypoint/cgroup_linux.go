@@ -0,0 +1,208 @@
+//go:build linux
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// cgroupRoot is where the cgroup v2 hierarchy is mounted.
+	cgroupRoot = "/sys/fs/cgroup"
+	// selfCgroupFile lists the cgroups of the entrypoint.
+	selfCgroupFile = "/proc/self/cgroup"
+)
+
+const (
+	// entrypointCgroup is the leaf cgroup the processes of the container are
+	// moved to, as cgroup v2 only allows limiting the resources of child
+	// cgroups of cgroups without processes.
+	entrypointCgroup = "entrypoint"
+	// processCgroup is the cgroup the wrapped process is run in.
+	processCgroup = "process"
+)
+
+// childCgroup is a cgroup v2 below the cgroup of the container that limits
+// the resources of the wrapped process.
+type childCgroup struct {
+	dir string
+	fd  *os.File
+}
+
+// newChildCgroup creates the cgroup of the wrapped process below the cgroup
+// of the entrypoint with the given limits, zero meaning no limit.
+func newChildCgroup(memoryLimit, pidsLimit int64) (*childCgroup, error) {
+	current, err := ownCgroup()
+	if err != nil {
+		return nil, err
+	}
+	parent := filepath.Join(cgroupRoot, current)
+
+	limits := map[string]string{}
+	var controllers []string
+	if memoryLimit > 0 {
+		controllers = append(controllers, "memory")
+		limits["memory.max"] = strconv.FormatInt(memoryLimit, 10)
+	}
+	if pidsLimit > 0 {
+		controllers = append(controllers, "pids")
+		limits["pids.max"] = strconv.FormatInt(pidsLimit, 10)
+	}
+	if err := enableControllers(parent, controllers); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(parent, processCgroup)
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return nil, fmt.Errorf("could not create cgroup %s: %w", dir, err)
+	}
+	for file, limit := range limits {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(limit), 0644); err != nil {
+			return nil, fmt.Errorf("could not set %s of cgroup %s: %w", file, dir, err)
+		}
+	}
+	if memoryLimit > 0 {
+		// Swapping would let the process exceed the limit instead of being
+		// OOM-killed. Kernels without swap accounting lack the file.
+		if err := os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0644); err != nil && !os.IsNotExist(err) {
+			logrus.WithError(err).Warn("Could not disable swap for the child cgroup")
+		}
+	}
+	fd, err := os.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not open cgroup %s: %w", dir, err)
+	}
+	return &childCgroup{dir: dir, fd: fd}, nil
+}
+
+// ownCgroup returns the path of the cgroup v2 of the entrypoint, relative to
+// the root of the hierarchy.
+func ownCgroup() (string, error) {
+	content, err := os.ReadFile(selfCgroupFile)
+	if err != nil {
+		return "", fmt.Errorf("could not read own cgroup: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		// The cgroup v2 entry is "0::<path>", see cgroups(7).
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", errors.New("child cgroups require cgroup v2, but the entrypoint is not in a cgroup v2")
+}
+
+// enableControllers enables the controllers for the child cgroups of parent,
+// moving the processes of parent to a leaf cgroup first if needed.
+func enableControllers(parent string, controllers []string) error {
+	available, err := os.ReadFile(filepath.Join(parent, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("could not read the controllers of cgroup %s: %w", parent, err)
+	}
+	var enable []string
+	for _, controller := range controllers {
+		if !slices.Contains(strings.Fields(string(available)), controller) {
+			return fmt.Errorf("the %s controller is not available in cgroup %s", controller, parent)
+		}
+		enable = append(enable, "+"+controller)
+	}
+	subtreeControl := filepath.Join(parent, "cgroup.subtree_control")
+	err = os.WriteFile(subtreeControl, []byte(strings.Join(enable, " ")), 0644)
+	if errors.Is(err, syscall.EBUSY) {
+		if err := moveProcesses(parent, filepath.Join(parent, entrypointCgroup)); err != nil {
+			return err
+		}
+		err = os.WriteFile(subtreeControl, []byte(strings.Join(enable, " ")), 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("could not enable the %s controllers of cgroup %s: %w", strings.Join(controllers, ", "), parent, err)
+	}
+	return nil
+}
+
+// moveProcesses moves all processes of a cgroup to another one.
+func moveProcesses(from, to string) error {
+	if err := os.Mkdir(to, 0755); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("could not create cgroup %s: %w", to, err)
+	}
+	procs, err := os.ReadFile(filepath.Join(from, "cgroup.procs"))
+	if err != nil {
+		return fmt.Errorf("could not list the processes of cgroup %s: %w", from, err)
+	}
+	for _, pid := range strings.Fields(string(procs)) {
+		// The kernel only moves one process per write.
+		err := os.WriteFile(filepath.Join(to, "cgroup.procs"), []byte(pid), 0644)
+		if err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("could not move process %s to cgroup %s: %w", pid, to, err)
+		}
+	}
+	return nil
+}
+
+// apply starts the command in the cgroup.
+func (c *childCgroup) apply(command *exec.Cmd) {
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.UseCgroupFD = true
+	command.SysProcAttr.CgroupFD = int(c.fd.Fd())
+}
+
+// oomKills returns how many processes of the cgroup the kernel killed for
+// exceeding the memory limit.
+func (c *childCgroup) oomKills() (int, error) {
+	content, err := os.ReadFile(filepath.Join(c.dir, "memory.events"))
+	if os.IsNotExist(err) {
+		// Without a memory limit the memory controller may be disabled.
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not read the memory events of cgroup %s: %w", c.dir, err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if count, ok := strings.CutPrefix(scanner.Text(), "oom_kill "); ok {
+			return strconv.Atoi(count)
+		}
+	}
+	return 0, nil
+}
+
+// close releases the cgroup. It is only removed once all of its processes
+// exited, e.g. daemons started by the process keep it around.
+func (c *childCgroup) close() {
+	if err := c.fd.Close(); err != nil {
+		logrus.WithError(err).Warn("Could not close the child cgroup")
+	}
+	if err := syscall.Rmdir(c.dir); err != nil {
+		logrus.WithError(err).Debug("Could not remove the child cgroup")
+	}
+}
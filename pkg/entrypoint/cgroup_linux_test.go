@@ -0,0 +1,142 @@
+//go:build linux
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeCgroupHierarchy points the cgroup files at a temporary directory with
+// the cgroup of the entrypoint and the given controllers.
+func fakeCgroupHierarchy(t *testing.T, selfCgroup, controllers string) string {
+	dir := t.TempDir()
+	oldRoot, oldSelf := cgroupRoot, selfCgroupFile
+	t.Cleanup(func() { cgroupRoot, selfCgroupFile = oldRoot, oldSelf })
+	cgroupRoot = filepath.Join(dir, "cgroup")
+	selfCgroupFile = filepath.Join(dir, "self")
+	if err := os.WriteFile(selfCgroupFile, []byte(selfCgroup), 0644); err != nil {
+		t.Fatalf("failed to write own cgroup: %v", err)
+	}
+	parent := filepath.Join(cgroupRoot, "kubepods", "pod")
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		t.Fatalf("failed to create cgroup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.controllers"), []byte(controllers), 0644); err != nil {
+		t.Fatalf("failed to write controllers: %v", err)
+	}
+	return parent
+}
+
+func TestNewChildCgroup(t *testing.T) {
+	testCases := []struct {
+		name        string
+		selfCgroup  string
+		controllers string
+		memoryLimit int64
+		pidsLimit   int64
+
+		expectedErr   bool
+		expectedFiles map[string]string
+	}{
+		{
+			name:        "memory and pids limits",
+			selfCgroup:  "0::/kubepods/pod\n",
+			controllers: "cpuset cpu io memory pids\n",
+			memoryLimit: 1 << 30,
+			pidsLimit:   100,
+			expectedFiles: map[string]string{
+				"cgroup.subtree_control":  "+memory +pids",
+				"process/memory.max":      "1073741824",
+				"process/memory.swap.max": "0",
+				"process/pids.max":        "100",
+			},
+		},
+		{
+			name:        "only a pids limit",
+			selfCgroup:  "12:pids:/ignored\n0::/kubepods/pod\n",
+			controllers: "pids\n",
+			pidsLimit:   10,
+			expectedFiles: map[string]string{
+				"cgroup.subtree_control": "+pids",
+				"process/pids.max":       "10",
+			},
+		},
+		{
+			name:        "cgroup v1",
+			selfCgroup:  "12:memory:/kubepods/pod\n",
+			controllers: "memory\n",
+			memoryLimit: 1 << 30,
+			expectedErr: true,
+		},
+		{
+			name:        "controller not available",
+			selfCgroup:  "0::/kubepods/pod\n",
+			controllers: "cpu pids\n",
+			memoryLimit: 1 << 30,
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parent := fakeCgroupHierarchy(t, tc.selfCgroup, tc.controllers)
+			cgroup, err := newChildCgroup(tc.memoryLimit, tc.pidsLimit)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer cgroup.fd.Close()
+			if cgroup.dir != filepath.Join(parent, processCgroup) {
+				t.Errorf("expected the cgroup %s, got %s", filepath.Join(parent, processCgroup), cgroup.dir)
+			}
+			files := map[string]string{}
+			for _, name := range []string{"cgroup.subtree_control", "process/memory.max", "process/memory.swap.max", "process/pids.max"} {
+				if content, err := os.ReadFile(filepath.Join(parent, name)); err == nil {
+					files[name] = string(content)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedFiles, files); diff != "" {
+				t.Errorf("unexpected cgroup files (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOOMKills(t *testing.T) {
+	dir := t.TempDir()
+	cgroup := &childCgroup{dir: dir}
+	if kills, err := cgroup.oomKills(); err != nil || kills != 0 {
+		t.Errorf("expected no OOM kills without memory events, got %d: %v", kills, err)
+	}
+	events := "low 0\nhigh 0\nmax 4\noom 2\noom_kill 2\noom_group_kill 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "memory.events"), []byte(events), 0644); err != nil {
+		t.Fatalf("failed to write memory events: %v", err)
+	}
+	if kills, err := cgroup.oomKills(); err != nil || kills != 2 {
+		t.Errorf("expected 2 OOM kills, got %d: %v", kills, err)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !linux
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// childCgroup is not supported on this platform.
+type childCgroup struct{}
+
+func newChildCgroup(memoryLimit, pidsLimit int64) (*childCgroup, error) {
+	return nil, errors.New("child cgroups are only supported on Linux")
+}
+
+func (c *childCgroup) apply(command *exec.Cmd) {}
+
+func (c *childCgroup) oomKills() (int, error) {
+	return 0, nil
+}
+
+func (c *childCgroup) close() {}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumFile returns the file the SHA256 checksum of the entrypoint binary
+// at path is written to when it is copied there, in the format of sha256sum.
+func ChecksumFile(path string) string {
+	return path + ".sha256"
+}
+
+// WriteChecksum writes the checksum file of the binary at path.
+func WriteChecksum(path string) error {
+	sum, err := checksum(path)
+	if err != nil {
+		return err
+	}
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(path))
+	if err := os.WriteFile(ChecksumFile(path), []byte(line), 0644); err != nil {
+		return fmt.Errorf("write checksum of '%s': %w", path, err)
+	}
+	return nil
+}
+
+// VerifyChecksum returns an error if the binary at path does not match its
+// checksum file, e.g. because it was only partially copied to the volume it
+// is shared through. Binaries without a checksum file are not verified.
+func VerifyChecksum(path string) error {
+	raw, err := os.ReadFile(ChecksumFile(path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read checksum of '%s': %w", path, err)
+	}
+	fields := strings.Fields(string(raw))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file of '%s' is empty", path)
+	}
+	sum, err := checksum(path)
+	if err != nil {
+		return err
+	}
+	if sum != fields[0] {
+		return fmt.Errorf("checksum of '%s' is %s, expected %s", path, sum, fields[0])
+	}
+	return nil
+}
+
+func checksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open '%s': %w", path, err)
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("hash '%s': %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "entrypoint")
+	if err := os.WriteFile(binary, []byte("binary"), 0755); err != nil {
+		t.Fatalf("could not write binary: %v", err)
+	}
+
+	if err := VerifyChecksum(binary); err != nil {
+		t.Errorf("expected a binary without checksum not to be verified, got %v", err)
+	}
+
+	if err := WriteChecksum(binary); err != nil {
+		t.Fatalf("could not write checksum: %v", err)
+	}
+	raw, err := os.ReadFile(ChecksumFile(binary))
+	if err != nil {
+		t.Fatalf("could not read checksum: %v", err)
+	}
+	// sha256sum of "binary"
+	if expected := "9a3a45d01531a20e89ac6ae10b0b0beb0492acd7216a368aa062d1a5fecaf9cd  entrypoint\n"; string(raw) != expected {
+		t.Errorf("expected checksum file %q, got %q", expected, string(raw))
+	}
+	if err := VerifyChecksum(binary); err != nil {
+		t.Errorf("expected the copied binary to match its checksum, got %v", err)
+	}
+
+	if err := os.WriteFile(binary, []byte("bin"), 0755); err != nil {
+		t.Fatalf("could not truncate binary: %v", err)
+	}
+	if err := VerifyChecksum(binary); err == nil || !strings.Contains(err.Error(), "expected 9a3a45d0") {
+		t.Errorf("expected a truncated binary not to match its checksum, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+// CheckHeartbeatFlag makes the entrypoint check the heartbeat written by the
+// entrypoint of the same container instead of running a process. Decorated
+// test containers that write heartbeats use it as their liveness probe.
+const CheckHeartbeatFlag = "--check-heartbeat-only"
+
+// CheckHeartbeat returns an error if the heartbeat file has not been written
+// for wrapper.HungIntervals intervals, which means the entrypoint itself is
+// stuck, as the heartbeat is written regardless of the output of the process.
+// It passes while the process did not start yet and once it finished.
+func (o Options) CheckHeartbeat(now time.Time) error {
+	if o.Options == nil || o.HeartbeatFile == "" {
+		return nil
+	}
+	if _, err := os.Stat(o.MarkerFile); err == nil {
+		return nil
+	}
+	heartbeat, err := wrapper.ReadHeartbeat(o.HeartbeatFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read heartbeat: %w", err)
+	}
+	threshold := wrapper.HungIntervals * heartbeat.Interval
+	if since := now.Sub(heartbeat.Timestamp); threshold > 0 && since > threshold {
+		return fmt.Errorf("no heartbeat for %s", since.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+func TestCheckHeartbeat(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name        string
+		heartbeat   *wrapper.Heartbeat
+		finished    bool
+		noHeartbeat bool
+		expectedErr bool
+	}{
+		{
+			name:      "recent heartbeat",
+			heartbeat: &wrapper.Heartbeat{Timestamp: now.Add(-time.Minute), LastOutput: now.Add(-time.Hour), Interval: time.Minute},
+		},
+		{
+			name:        "stale heartbeat",
+			heartbeat:   &wrapper.Heartbeat{Timestamp: now.Add(-11 * time.Minute), LastOutput: now.Add(-11 * time.Minute), Interval: time.Minute},
+			expectedErr: true,
+		},
+		{
+			name:      "stale heartbeat of a finished process",
+			heartbeat: &wrapper.Heartbeat{Timestamp: now.Add(-11 * time.Minute), LastOutput: now.Add(-11 * time.Minute), Interval: time.Minute},
+			finished:  true,
+		},
+		{
+			name: "process did not start yet",
+		},
+		{
+			name:        "heartbeats are not written",
+			heartbeat:   &wrapper.Heartbeat{Timestamp: now.Add(-11 * time.Minute), Interval: time.Minute},
+			noHeartbeat: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			options := Options{Options: &wrapper.Options{
+				MarkerFile:    path.Join(tmpDir, "marker-file.txt"),
+				HeartbeatFile: path.Join(tmpDir, "heartbeat.json"),
+			}}
+			if tc.heartbeat != nil {
+				if err := wrapper.WriteHeartbeat(options.HeartbeatFile, *tc.heartbeat); err != nil {
+					t.Fatalf("could not write heartbeat: %v", err)
+				}
+			}
+			if tc.finished {
+				if err := os.WriteFile(options.MarkerFile, []byte("0"), 0644); err != nil {
+					t.Fatalf("could not write marker file: %v", err)
+				}
+			}
+			if tc.noHeartbeat {
+				options.HeartbeatFile = ""
+			}
+			if err := options.CheckHeartbeat(now); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
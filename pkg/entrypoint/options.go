@@ -20,8 +20,14 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
 )
 
@@ -39,12 +45,52 @@ func NewOptions() *Options {
 // where in GCS an upload will land.
 type Options struct {
 	// Timeout determines how long to wait before the
-	// entrypoint sends SIGINT to the process
+	// entrypoint sends TimeoutSignal to the process
 	Timeout time.Duration `json:"timeout"`
 	// GracePeriod determines how long to wait after
-	// sending SIGINT before the entrypoint sends
+	// sending TimeoutSignal before the entrypoint sends
 	// SIGKILL.
 	GracePeriod time.Duration `json:"grace_period"`
+	// TimeoutSignal is the signal, SIGINT or SIGTERM, the
+	// entrypoint sends to the process when it times out or
+	// is aborted. Defaults to SIGINT. Ignored on Windows.
+	TimeoutSignal string `json:"timeout_signal,omitempty"`
+	// SignalProcessGroup starts the process in its own
+	// process group and delivers signals, including the
+	// SIGKILL after the grace period, to the whole group,
+	// so that child processes do not outlive the process.
+	SignalProcessGroup bool `json:"signal_process_group,omitempty"`
+	// NoOutputTimeout determines how long to wait for the
+	// process to write to stdout or stderr before treating
+	// it as hung and terminating it like on timeout.
+	// No limit is enforced if unset.
+	NoOutputTimeout time.Duration `json:"no_output_timeout,omitempty"`
+	// MaxTimeoutExtension is how much longer than Timeout the
+	// process may run if it asks for more time by writing a
+	// duration to the TimeoutOverrideFile in ArtifactDir. The
+	// process cannot extend its timeout if unset.
+	MaxTimeoutExtension time.Duration `json:"max_timeout_extension,omitempty"`
+	// HeartbeatInterval determines how often the heartbeat
+	// file is written while the process runs. No heartbeat
+	// is written if unset.
+	HeartbeatInterval time.Duration `json:"heartbeat_interval,omitempty"`
+	// ChildMemoryLimit is the memory limit, e.g. "2Gi", of a child cgroup
+	// the process is run in. Set below the limit of the container, the
+	// process is OOM-killed on its own instead of the whole container,
+	// so that entrypoint can still record the failure and the logs are
+	// kept. Requires a writable cgroup v2 hierarchy. Disabled if unset.
+	ChildMemoryLimit string `json:"child_memory_limit,omitempty"`
+	// ChildPidsLimit is the maximum number of processes of the child
+	// cgroup the process is run in. Disabled if unset.
+	ChildPidsLimit int64 `json:"child_pids_limit,omitempty"`
+	// SecretFiles are files, or directories of files like mounted
+	// secrets, whose contents are redacted from the output of the
+	// process before it is written to the process log, so that
+	// credentials the process echoes do not end up in the build log.
+	SecretFiles []string `json:"secret_files,omitempty"`
+	// SecretEnvVars are the names of environment variables whose
+	// values are redacted from the output of the process.
+	SecretEnvVars []string `json:"secret_env_vars,omitempty"`
 	// ArtifactDir is a directory where test processes can dump artifacts
 	// for upload to persistent storage (courtesy of sidecar).
 	// If specified, it is created by entrypoint before starting the test process.
@@ -66,25 +112,200 @@ type Options struct {
 	// Primarily useful in case you want to exit with a specific error code.
 	PropagateErrorCode bool `json:"propagate_error_code,omitempty"`
 
+	// Retries is how many more times the process is run after it exited
+	// with one of the RetryableExitCodes, e.g. to retry flaky infra steps
+	// like image pulls inside the pod. All attempts share the timeout and
+	// the exit code of each is recorded in the metadata file.
+	Retries int `json:"retries,omitempty"`
+	// RetryableExitCodes are the exit codes after which the process is
+	// retried. Timed out and aborted processes are never retried.
+	RetryableExitCodes []int `json:"retryable_exit_codes,omitempty"`
+	// RetryBackoff is how long to wait before the first retry. It doubles
+	// for every following one. Defaults to DefaultRetryBackoff.
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
+	// Steps are commands run one after the other instead of Args, e.g.
+	// the setup, test and teardown phases of a job. Once a step fails
+	// the following ones are skipped unless they always run. The marker
+	// file is written with the exit code of the first failing step, so
+	// PreviousMarker chaining works as with a single command.
+	Steps []Step `json:"steps,omitempty"`
+
 	CopyModeOnly bool   `json:"copy_mode_only,omitempty"`
 	CopyDst      string `json:"copy_dst,omitempty"`
 
+	// CheckHeartbeatOnly makes the entrypoint check the heartbeat file
+	// instead of running the process, see CheckHeartbeat.
+	CheckHeartbeatOnly bool `json:"check_heartbeat_only,omitempty"`
+
 	*wrapper.Options
 }
 
+// Step is one of several commands run in order by the entrypoint.
+type Step struct {
+	// Name identifies the step in the process log.
+	Name string `json:"name"`
+	// Args is the process and args to run.
+	Args []string `json:"args"`
+	// Timeout determines how long the step may run before
+	// the entrypoint sends SIGINT to it. The step is always
+	// bounded by the time left of the overall timeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// MarkerFile is written with the exit code of the step,
+	// or PreviousErrorCode if it was skipped, if set.
+	MarkerFile string `json:"marker_file,omitempty"`
+	// Always runs the step even if a previous step failed,
+	// e.g. to tear down what the previous steps set up.
+	Always bool `json:"always,omitempty"`
+}
+
 // Validate ensures that the set of options are
 // self-consistent and valid
 func (o *Options) Validate() error {
-	if len(o.Args) == 0 {
+	if len(o.Args) == 0 && len(o.Steps) == 0 {
 		return errors.New("no process to wrap specified")
 	}
+	if len(o.Args) > 0 && len(o.Steps) > 0 {
+		return errors.New("cannot wrap both a process and steps")
+	}
+	if err := o.validateSteps(); err != nil {
+		return err
+	}
 	if o.PropagateErrorCode && o.AlwaysZero {
 		return errors.New("cannot propagate error code and always exit zero")
 	}
+	if err := o.validateRetries(); err != nil {
+		return err
+	}
+	if o.NoOutputTimeout < 0 {
+		return errors.New("no output timeout must not be negative")
+	}
+	if o.MaxTimeoutExtension < 0 {
+		return errors.New("max timeout extension must not be negative")
+	}
+	if o.MaxTimeoutExtension > 0 && o.ArtifactDir == "" {
+		return errors.New("no artifact directory to read the timeout override from specified with --artifact-dir")
+	}
+	if o.HeartbeatInterval < 0 {
+		return errors.New("heartbeat interval must not be negative")
+	}
+	if o.HeartbeatInterval > 0 && o.HeartbeatFile == "" {
+		return errors.New("no heartbeat file specified with --heartbeat-file")
+	}
+
+	if _, ok := timeoutSignals[o.TimeoutSignal]; !ok && o.TimeoutSignal != "" {
+		return fmt.Errorf("unsupported timeout signal %q, must be SIGINT or SIGTERM", o.TimeoutSignal)
+	}
+
+	if _, err := o.childMemoryLimitBytes(); err != nil {
+		return err
+	}
+	if o.ChildPidsLimit < 0 {
+		return errors.New("child pids limit must not be negative")
+	}
+	for _, path := range o.SecretFiles {
+		if path == "" {
+			return errors.New("secret files must not be empty")
+		}
+	}
+	for _, name := range o.SecretEnvVars {
+		if name == "" {
+			return errors.New("secret environment variable names must not be empty")
+		}
+	}
 
 	return o.Options.Validate()
 }
 
+// validateSteps ensures that steps can be told apart and have a process
+// to run.
+func (o *Options) validateSteps() error {
+	names := map[string]bool{}
+	for i, step := range o.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("step %d has no name", i)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("step %s is specified more than once", step.Name)
+		}
+		names[step.Name] = true
+		if len(step.Args) == 0 {
+			return fmt.Errorf("step %s has no process to run", step.Name)
+		}
+		if step.Timeout < 0 {
+			return fmt.Errorf("timeout of step %s must not be negative", step.Name)
+		}
+		if step.MarkerFile != "" && o.Options != nil && step.MarkerFile == o.MarkerFile {
+			return fmt.Errorf("step %s must not write the marker file of the entrypoint", step.Name)
+		}
+	}
+	return nil
+}
+
+// validateRetries ensures that retries know which exit codes to retry.
+func (o *Options) validateRetries() error {
+	if o.Retries < 0 {
+		return errors.New("retries must not be negative")
+	}
+	if o.RetryBackoff < 0 {
+		return errors.New("retry backoff must not be negative")
+	}
+	if o.Retries > 0 && len(o.RetryableExitCodes) == 0 {
+		return errors.New("no retryable exit codes specified for retries")
+	}
+	for _, code := range o.RetryableExitCodes {
+		if code == 0 {
+			return errors.New("exit code 0 cannot be retried")
+		}
+	}
+	return nil
+}
+
+// retryable tells whether a process that exited with the code is retried.
+func (o *Options) retryable(code int) bool {
+	for _, c := range o.RetryableExitCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// timeoutSignals are the signals the process can be sent on timeout.
+var timeoutSignals = map[string]syscall.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+// timeoutSignal returns the signal sent to the process on timeout.
+func (o *Options) timeoutSignal() os.Signal {
+	if signal, ok := timeoutSignals[o.TimeoutSignal]; ok {
+		return signal
+	}
+	return os.Interrupt
+}
+
+// childMemoryLimitBytes returns the memory limit of the child cgroup in
+// bytes, or zero if unset.
+func (o *Options) childMemoryLimitBytes() (int64, error) {
+	if o.ChildMemoryLimit == "" {
+		return 0, nil
+	}
+	limit, err := resource.ParseQuantity(o.ChildMemoryLimit)
+	if err != nil {
+		return 0, fmt.Errorf("invalid child memory limit %q: %w", o.ChildMemoryLimit, err)
+	}
+	if limit.Value() <= 0 {
+		return 0, fmt.Errorf("child memory limit %q must be positive", o.ChildMemoryLimit)
+	}
+	return limit.Value(), nil
+}
+
+// usesChildCgroup tells whether the process is run in a child cgroup.
+func (o *Options) usesChildCgroup() bool {
+	return o.ChildMemoryLimit != "" || o.ChildPidsLimit > 0
+}
+
 const (
 	// JSONConfigEnvVar is the environment variable that
 	// utilities expect to find a full JSON configuration
@@ -107,10 +328,16 @@ func (o *Options) LoadConfig(config string) error {
 func (o *Options) AddFlags(flags *flag.FlagSet) {
 	flags.DurationVar(&o.Timeout, "timeout", DefaultTimeout, "Timeout for the test command.")
 	flags.DurationVar(&o.GracePeriod, "grace-period", DefaultGracePeriod, "Grace period after timeout for the test command.")
+	flags.DurationVar(&o.NoOutputTimeout, "no-output-timeout", 0, "Time the test command may go without writing output before it is terminated, disabled if zero.")
+	flags.DurationVar(&o.MaxTimeoutExtension, "max-timeout-extension", 0, "How much the test command may extend its timeout by writing a duration to the timeout-override file in the artifact directory, disabled if zero.")
+	flags.DurationVar(&o.HeartbeatInterval, "heartbeat-interval", 0, "How often to write the heartbeat file, disabled if zero.")
+	flags.StringVar(&o.ChildMemoryLimit, "child-memory-limit", "", "Memory limit, e.g. 2Gi, of a child cgroup the test command runs in so that it is OOM-killed on its own, disabled if empty.")
+	flags.Int64Var(&o.ChildPidsLimit, "child-pids-limit", 0, "Maximum number of processes of the child cgroup the test command runs in, disabled if zero.")
 	flags.StringVar(&o.ArtifactDir, "artifact-dir", "", "directory where test artifacts should be placed for upload to persistent storage")
 	flags.BoolVar(&o.CopyModeOnly, "copy-mode-only", false, "If true, copy current binary to /tools/entrypoint, dst can be overridden by --copy-destination")
 	flags.StringVar(&o.CopyDst, "copy-destination", defaultCopyDst, "Must be used with --copy-mode-only, default is /tools/entrypoint")
 	flags.BoolVar(&o.PropagateErrorCode, "propagate-error-code", false, "If true, propagate the error code from the child process")
+	flags.BoolVar(&o.CheckHeartbeatOnly, strings.TrimPrefix(CheckHeartbeatFlag, "--"), false, "If true, only check that the heartbeat file is written, as the liveness probe of the container")
 	o.Options.AddFlags(flags)
 }
 
@@ -18,6 +18,7 @@ package entrypoint
 
 import (
 	"testing"
+	"time"
 
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
 )
@@ -39,6 +40,154 @@ func TestOptions_Validate(t *testing.T) {
 			},
 			expectedErr: false,
 		},
+		{
+			name: "child cgroup limits",
+			input: Options{
+				ChildMemoryLimit: "2Gi",
+				ChildPidsLimit:   1000,
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: false,
+		},
+		{
+			name: "invalid child memory limit",
+			input: Options{
+				ChildMemoryLimit: "lots",
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "retries",
+			input: Options{
+				Retries:            2,
+				RetryableExitCodes: []int{3},
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: false,
+		},
+		{
+			name: "retries without retryable exit codes",
+			input: Options{
+				Retries: 2,
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "retrying success",
+			input: Options{
+				Retries:            2,
+				RetryableExitCodes: []int{0},
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "timeout signal",
+			input: Options{
+				TimeoutSignal:      "SIGTERM",
+				SignalProcessGroup: true,
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: false,
+		},
+		{
+			name: "unsupported timeout signal",
+			input: Options{
+				TimeoutSignal: "SIGKILL",
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "negative child pids limit",
+			input: Options{
+				ChildPidsLimit: -1,
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "secrets",
+			input: Options{
+				SecretFiles:   []string{"/secrets/token"},
+				SecretEnvVars: []string{"TOKEN"},
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+		},
+		{
+			name: "empty secret environment variable name",
+			input: Options{
+				SecretEnvVars: []string{""},
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "max timeout extension",
+			input: Options{
+				MaxTimeoutExtension: time.Hour,
+				ArtifactDir:         "/logs/artifacts",
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+		},
+		{
+			name: "max timeout extension without artifact dir",
+			input: Options{
+				MaxTimeoutExtension: time.Hour,
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
 		{
 			name: "missing args",
 			input: Options{
@@ -49,6 +198,68 @@ func TestOptions_Validate(t *testing.T) {
 			},
 			expectedErr: true,
 		},
+		{
+			name: "steps",
+			input: Options{
+				Steps: []Step{
+					{Name: "setup", Args: []string{"make", "setup"}, Timeout: time.Minute, MarkerFile: "setup-marker.txt"},
+					{Name: "teardown", Args: []string{"make", "teardown"}, Always: true},
+				},
+				Options: &wrapper.Options{
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: false,
+		},
+		{
+			name: "both args and steps",
+			input: Options{
+				Steps: []Step{{Name: "test", Args: []string{"make", "test"}}},
+				Options: &wrapper.Options{
+					Args:       []string{"/usr/bin/true"},
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "duplicate step names",
+			input: Options{
+				Steps: []Step{
+					{Name: "test", Args: []string{"make", "test"}},
+					{Name: "test", Args: []string{"make", "e2e"}},
+				},
+				Options: &wrapper.Options{
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "step without args",
+			input: Options{
+				Steps: []Step{{Name: "test"}},
+				Options: &wrapper.Options{
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "step writing the marker file",
+			input: Options{
+				Steps: []Step{{Name: "test", Args: []string{"make", "test"}, MarkerFile: "marker.txt"}},
+				Options: &wrapper.Options{
+					ProcessLog: "output.txt",
+					MarkerFile: "marker.txt",
+				},
+			},
+			expectedErr: true,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -0,0 +1,71 @@
+//go:build !windows
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+// prepareCommand configures the wrapped process before it starts. The
+// process is started in its own process group if its children are to be
+// signaled as well.
+func prepareCommand(command *exec.Cmd, processGroup bool) {
+	if processGroup {
+		command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+}
+
+// interruptProcess asks the wrapped process to exit with the timeout signal,
+// forwarding the signal the entrypoint received too, if any.
+func interruptProcess(process *os.Process, timeoutSignal os.Signal, signal *os.Signal, processGroup bool) {
+	if err := signalProcess(process, timeoutSignal, processGroup); err != nil {
+		logrus.WithError(err).Error("Could not interrupt process after timeout")
+	}
+	if signal != nil && *signal != timeoutSignal {
+		if err := signalProcess(process, *signal, processGroup); err != nil {
+			logrus.WithError(err).Errorf("Could not send signal %v to process after timeout", *signal)
+		}
+	}
+}
+
+// killProcess kills the wrapped process, or its whole process group.
+func killProcess(process *os.Process, processGroup bool) error {
+	return signalProcess(process, os.Kill, processGroup)
+}
+
+// signalProcess sends the signal to the process, or to its whole process
+// group.
+func signalProcess(process *os.Process, signal os.Signal, processGroup bool) error {
+	sig, ok := signal.(syscall.Signal)
+	if !processGroup || !ok {
+		return process.Signal(signal)
+	}
+	if err := syscall.Kill(-process.Pid, sig); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+	return nil
+}
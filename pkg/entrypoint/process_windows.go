@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+)
+
+// prepareCommand configures the wrapped process before it starts. Windows
+// cannot deliver signals to other processes, so the process is started in
+// its own process group that console control events can be sent to.
+func prepareCommand(command *exec.Cmd, processGroup bool) {
+	command.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP}
+}
+
+// interruptProcess asks the wrapped process to exit with a CTRL_BREAK_EVENT,
+// which Go programs receive as os.Interrupt and is delivered to the whole
+// process group. Neither the timeout signal nor the signal the entrypoint
+// received can be sent as is.
+func interruptProcess(process *os.Process, timeoutSignal os.Signal, signal *os.Signal, processGroup bool) {
+	if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(process.Pid)); err != nil {
+		logrus.WithError(err).Error("Could not interrupt process after timeout")
+	}
+}
+
+// killProcess kills the wrapped process. Windows cannot kill a process
+// group, its children are left running.
+func killProcess(process *os.Process, processGroup bool) error {
+	return process.Kill()
+}
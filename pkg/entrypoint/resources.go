@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ResourceUsageFile is the name of the artifact the resource usage of
+	// the wrapped process is written to.
+	ResourceUsageFile = "resource-usage.json"
+
+	// resourceSampleInterval is how often the memory of the process tree
+	// is sampled.
+	resourceSampleInterval = time.Second
+)
+
+// ResourceUsage summarizes the resources used by the wrapped process and its
+// children, adding up all steps and attempts, so that the resource requests
+// of the pod can be sized from real data.
+type ResourceUsage struct {
+	// PeakRSSBytes is the highest resident memory of the process tree. It is
+	// only measured on Linux.
+	PeakRSSBytes int64 `json:"peak_rss_bytes"`
+	// UserCPUSeconds and SystemCPUSeconds are the CPU time spent by the
+	// process and the children it waited for.
+	UserCPUSeconds   float64 `json:"user_cpu_seconds"`
+	SystemCPUSeconds float64 `json:"system_cpu_seconds"`
+	// WallClockSeconds is how long the processes ran.
+	WallClockSeconds float64 `json:"wall_clock_seconds"`
+	// Processes is how many times a process was run.
+	Processes int `json:"processes"`
+}
+
+// resourceTracker accumulates the resource usage of the processes run.
+type resourceTracker struct {
+	lock  sync.Mutex
+	usage ResourceUsage
+}
+
+// sample records the memory of the process tree rooted at pid every
+// interval until stopped.
+func (t *resourceTracker) sample(pid int, stop <-chan struct{}) {
+	ticker := time.NewTicker(resourceSampleInterval)
+	defer ticker.Stop()
+	for {
+		if rss, err := treeRSS(pid); err != nil {
+			logrus.WithError(err).Debug("Could not sample the memory of the process tree")
+		} else {
+			t.observeRSS(rss)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *resourceTracker) observeRSS(rss int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if rss > t.usage.PeakRSSBytes {
+		t.usage.PeakRSSBytes = rss
+	}
+}
+
+// record adds the usage of a process that ran for wallClock. The state is
+// nil if the process was not waited for.
+func (t *resourceTracker) record(state *os.ProcessState, wallClock time.Duration) {
+	if state != nil {
+		t.observeRSS(maxRSS(state))
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.usage.Processes++
+	t.usage.WallClockSeconds += wallClock.Seconds()
+	if state != nil {
+		t.usage.UserCPUSeconds += state.UserTime().Seconds()
+		t.usage.SystemCPUSeconds += state.SystemTime().Seconds()
+	}
+}
+
+// write writes the usage to path, unless no process ran.
+func (t *resourceTracker) write(path string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.usage.Processes == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(t.usage)
+	if err != nil {
+		return fmt.Errorf("could not marshal the resource usage: %w", err)
+	}
+	return os.WriteFile(path, raw, 0644)
+}
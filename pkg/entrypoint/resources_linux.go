@@ -0,0 +1,102 @@
+//go:build linux
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// procRoot is where the proc filesystem is mounted.
+var procRoot = "/proc"
+
+// treeRSS returns the resident memory of the process with the given pid and
+// all of its descendants.
+func treeRSS(pid int) (int64, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return 0, fmt.Errorf("could not list processes: %w", err)
+	}
+	children := map[int][]int{}
+	rss := map[int]int64{}
+	for _, entry := range entries {
+		id, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// Processes may exit while they are listed.
+		ppid, pages, err := readStat(filepath.Join(procRoot, entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		children[ppid] = append(children[ppid], id)
+		rss[id] = pages * int64(os.Getpagesize())
+	}
+	if _, ok := rss[pid]; !ok {
+		return 0, fmt.Errorf("process %d is not running", pid)
+	}
+	var total int64
+	for queue := []int{pid}; len(queue) > 0; queue = queue[1:] {
+		total += rss[queue[0]]
+		queue = append(queue, children[queue[0]]...)
+	}
+	return total, nil
+}
+
+// readStat returns the parent pid and the resident pages of a process from
+// its stat file.
+func readStat(path string) (int, int64, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	// The command name may contain spaces and parentheses, the fields
+	// following it start with the state and the parent pid.
+	end := strings.LastIndexByte(string(raw), ')')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("malformed stat file %s", path)
+	}
+	fields := strings.Fields(string(raw[end+1:]))
+	if len(fields) < 22 {
+		return 0, 0, fmt.Errorf("malformed stat file %s", path)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed parent pid in %s: %w", path, err)
+	}
+	pages, err := strconv.ParseInt(fields[21], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed resident set size in %s: %w", path, err)
+	}
+	return ppid, pages, nil
+}
+
+// maxRSS returns the highest resident memory of the largest process among
+// an exited process and the children it waited for.
+func maxRSS(state *os.ProcessState) int64 {
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		// Linux reports kilobytes.
+		return usage.Maxrss * 1024
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+//go:build linux
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTreeRSS(t *testing.T) {
+	dir := t.TempDir()
+	oldRoot := procRoot
+	t.Cleanup(func() { procRoot = oldRoot })
+	procRoot = dir
+
+	page := int64(os.Getpagesize())
+	// pid: parent pid, resident pages
+	processes := map[int][2]int{
+		1:  {0, 100},
+		10: {1, 1},
+		11: {10, 2},
+		12: {11, 4},
+		13: {10, 8},
+		20: {1, 16},
+	}
+	for pid, process := range processes {
+		if err := os.MkdirAll(filepath.Join(dir, fmt.Sprint(pid)), 0755); err != nil {
+			t.Fatalf("failed to create process dir: %v", err)
+		}
+		// The command name contains spaces and parentheses.
+		stat := fmt.Sprintf("%d (my (command)) S %d 1 1 0 -1 4194560 100 0 0 0 1 2 0 0 20 0 1 0 100 1000 %d 18446744073709551615\n", pid, process[0], process[1])
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprint(pid), "stat"), []byte(stat), 0644); err != nil {
+			t.Fatalf("failed to write stat: %v", err)
+		}
+	}
+	for _, name := range []string{"self", "meminfo"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		pid         int
+		expected    int64
+		expectedErr bool
+	}{
+		{
+			name:     "process with descendants",
+			pid:      10,
+			expected: 15 * page,
+		},
+		{
+			name:     "process without children",
+			pid:      20,
+			expected: 16 * page,
+		},
+		{
+			name:        "process that is not running",
+			pid:         30,
+			expectedErr: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rss, err := treeRSS(testCase.pid)
+			if (err != nil) != testCase.expectedErr {
+				t.Fatalf("expected error %t, got %v", testCase.expectedErr, err)
+			}
+			if rss != testCase.expected {
+				t.Errorf("expected %d bytes, got %d", testCase.expected, rss)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package entrypoint
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -26,6 +27,8 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -33,6 +36,7 @@ import (
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+	"sigs.k8s.io/prow/pkg/version"
 )
 
 const (
@@ -53,6 +57,12 @@ const (
 	// did not run this step.
 	PreviousErrorCode = internalCode + AbortedErrorCode
 
+	// OOMKilledErrorCode is what we write to the marker file to
+	// indicate that the process failed after the kernel killed it,
+	// or one of its children, for exceeding the memory limit of
+	// its child cgroup.
+	OOMKilledErrorCode = internalCode + 137
+
 	// DefaultTimeout is the default timeout for the test
 	// process before SIGINT is sent
 	DefaultTimeout = 120 * time.Minute
@@ -60,6 +70,14 @@ const (
 	// DefaultGracePeriod is the default timeout for the test
 	// process after SIGINT is sent before SIGKILL is sent
 	DefaultGracePeriod = 15 * time.Second
+
+	// DefaultRetryBackoff is the default time to wait before
+	// the process is retried for the first time
+	DefaultRetryBackoff = 10 * time.Second
+
+	// attemptsKey is the key of the metadata the exit codes
+	// of the attempts of retried processes are recorded under
+	attemptsKey = "attempts"
 )
 
 var (
@@ -69,6 +87,12 @@ var (
 	// errAborted is used as the command's error when the command
 	// is shut down by an external signal
 	errAborted = errors.New("process aborted")
+	// errNoOutput is used as the command's error when the command
+	// is terminated after not producing output for too long
+	errNoOutput = errors.New("process produced no output")
+	// errOOMKilled is used as the command's error when the command
+	// failed after exceeding the memory limit of its child cgroup
+	errOOMKilled = errors.New("process exceeded its memory limit")
 )
 
 // Run executes the test process then writes the exit code to the marker file.
@@ -101,13 +125,40 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 			return InternalErrorCode, fmt.Errorf("could not create artifact directory(%s): %w", o.ArtifactDir, err)
 		}
 	}
+	if o.MarkerFile != "" {
+		if err := wrapper.WriteVersion(o.MarkerFile, version.Version); err != nil {
+			logrus.WithError(err).Warn("Could not record the version of the entrypoint")
+		}
+	}
+	var usage *resourceTracker
+	if o.ArtifactDir != "" {
+		usage = &resourceTracker{}
+		defer func() {
+			if err := usage.write(filepath.Join(o.ArtifactDir, ResourceUsageFile)); err != nil {
+				logrus.WithError(err).Warn("Could not write the resource usage of the process")
+			}
+		}()
+	}
 	processLogFile, err := os.Create(o.ProcessLog)
 	if err != nil {
 		return InternalErrorCode, fmt.Errorf("could not create process logfile(%s): %w", o.ProcessLog, err)
 	}
 	defer processLogFile.Close()
 
-	output := io.MultiWriter(os.Stdout, processLogFile)
+	var output io.Writer = io.MultiWriter(os.Stdout, processLogFile)
+	if o.usesSecrets() {
+		secrets, err := o.loadSecrets()
+		if err != nil {
+			return InternalErrorCode, err
+		}
+		masking := newMaskingWriter(output, secrets)
+		defer func() {
+			if err := masking.flush(); err != nil {
+				logrus.WithError(err).Warn("Could not write the end of the process log")
+			}
+		}()
+		output = masking
+	}
 	logrus.SetOutput(output)
 	defer logrus.SetOutput(os.Stdout)
 
@@ -116,16 +167,16 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 	interrupt := signaledInterrupt
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 
+	timeout := optionOrDefault(o.Timeout, DefaultTimeout)
+	deadline := time.Now().Add(timeout)
+	if o.DeadlineFile != "" {
+		if deadline, err = wrapper.SharedDeadline(o.DeadlineFile, deadline); err != nil {
+			return InternalErrorCode, fmt.Errorf("could not determine the shared deadline: %w", err)
+		}
+	}
+
 	if o.PreviousMarker != "" {
-		ctx, cancel := context.WithCancel(context.Background())
-		go func() {
-			select {
-			case s := <-interrupt:
-				logrus.Errorf("Received interrupt %s, cancelling...", s)
-				cancel()
-			case <-ctx.Done():
-			}
-		}()
+		ctx, cancel := interruptibleContext(context.Background(), interrupt)
 		prevMarkerResult := wrapper.WaitForMarkers(ctx, o.PreviousMarker)[o.PreviousMarker]
 		code, err := prevMarkerResult.ReturnCode, prevMarkerResult.Err
 		cancel() // end previous go-routine when not interrupted
@@ -138,14 +189,200 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 		}
 	}
 
-	executable := o.Args[0]
+	if len(o.After) > 0 {
+		ctx := context.Background()
+		if o.DeadlineFile != "" {
+			var cancelDeadline context.CancelFunc
+			ctx, cancelDeadline = context.WithDeadline(ctx, deadline)
+			defer cancelDeadline()
+		}
+		ctx, cancel := interruptibleContext(ctx, interrupt)
+		failed, err := wrapper.WaitForDependencies(ctx, o.After...)
+		cancel() // end previous go-routine when not interrupted
+		if errors.Is(err, context.DeadlineExceeded) {
+			logrus.Errorf("Dependencies did not finish before the %s timeout", timeout)
+			return InternalErrorCode, errTimedOut
+		}
+		if err != nil {
+			return AbortedErrorCode, err
+		}
+		if len(failed) > 0 {
+			logrus.Infof("Skipping as dependencies failed: %s", strings.Join(failed, ", "))
+			return PreviousErrorCode, nil
+		}
+	}
+	if o.DeadlineFile != "" {
+		timeout = time.Until(deadline)
+		if timeout <= 0 {
+			logrus.Errorf("The shared deadline %s passed before the process started", deadline)
+			return InternalErrorCode, errTimedOut
+		}
+	}
+
+	var cgroup *childCgroup
+	if o.usesChildCgroup() {
+		memoryLimit, err := o.childMemoryLimitBytes()
+		if err != nil {
+			return InternalErrorCode, err
+		}
+		if cgroup, err = newChildCgroup(memoryLimit, o.ChildPidsLimit); err != nil {
+			return InternalErrorCode, fmt.Errorf("could not create the child cgroup: %w", err)
+		}
+		defer cgroup.close()
+	}
+
+	extension := o.newTimeoutExtension()
+	if len(o.Steps) > 0 {
+		return o.executeSteps(timeout, extension, cgroup, usage, output, processLogFile, interrupt)
+	}
+	return o.executeWithRetries("", o.Args, timeout, extension, cgroup, usage, output, processLogFile, interrupt)
+}
+
+// executeSteps runs the steps in order, each until it finishes or its own
+// timeout or the time left of the overall timeout passes. Once a step fails
+// the following ones are skipped unless they always run; none run anymore
+// once the entrypoint is interrupted or the overall timeout passed. The exit
+// code and error of the first failing step are returned. Extensions of the
+// timeout granted to a step also leave more time to the following ones.
+func (o Options) executeSteps(timeout time.Duration, extension *timeoutExtension, cgroup *childCgroup, usage *resourceTracker, output io.Writer, processLogFile *os.File, interrupt chan os.Signal) (int, error) {
+	deadline := time.Now().Add(timeout)
+	returnCode, stopped := 0, false
+	var commandErr error
+	for _, step := range o.Steps {
+		// Whole seconds keep the timeouts in the process log readable.
+		remaining := time.Until(deadline.Add(extension.total())).Round(time.Second)
+		if stopped || remaining <= 0 || (returnCode != 0 && !step.Always) {
+			logrus.Infof("Skipping step %s", step.Name)
+			o.markStep(step, PreviousErrorCode)
+			continue
+		}
+		stepTimeout := remaining
+		if step.Timeout > 0 && step.Timeout < remaining {
+			stepTimeout = step.Timeout
+		}
+		logrus.Infof("Running step %s", step.Name)
+		code, err := o.executeWithRetries(step.Name, step.Args, stepTimeout, extension, cgroup, usage, output, processLogFile, interrupt)
+		o.markStep(step, code)
+		if errors.Is(err, errAborted) || (errors.Is(err, errTimedOut) && stepTimeout == remaining) {
+			stopped = true
+		}
+		if code != 0 && returnCode == 0 {
+			returnCode, commandErr = code, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+	}
+	return returnCode, commandErr
+}
+
+// markStep writes the exit code of a step to its marker file, if it has one.
+func (o Options) markStep(step Step, exitCode int) {
+	if step.MarkerFile == "" {
+		return
+	}
+	if err := writeMarker(step.MarkerFile, o.ContainerName, exitCode); err != nil {
+		logrus.WithError(err).Errorf("Error writing exit code of step %s to marker file", step.Name)
+	}
+}
+
+// executeWithRetries runs the process like executeCommand and runs it again
+// with backoff while it exits with a retryable exit code, retries are left
+// and the timeout did not pass. The exit codes of all attempts are recorded
+// in the metadata file under the name of the step, if any.
+func (o Options) executeWithRetries(step string, args []string, timeout time.Duration, extension *timeoutExtension, cgroup *childCgroup, usage *resourceTracker, output io.Writer, processLogFile *os.File, interrupt chan os.Signal) (int, error) {
+	if o.Retries == 0 {
+		return o.executeCommand(args, timeout, extension, cgroup, usage, output, processLogFile, interrupt)
+	}
+	deadline := time.Now().Add(timeout)
+	backoff := optionOrDefault(o.RetryBackoff, DefaultRetryBackoff)
+	var codes []int
+	defer func() {
+		if err := o.recordAttempts(step, codes); err != nil {
+			logrus.WithError(err).Warn("Could not record the exit codes of the attempts in the metadata file")
+		}
+	}()
+	for attempt := 1; ; attempt++ {
+		code, err := o.executeCommand(args, timeout, extension, cgroup, usage, output, processLogFile, interrupt)
+		codes = append(codes, code)
+		if attempt > o.Retries || !o.retryable(code) || errors.Is(err, errTimedOut) || errors.Is(err, errAborted) || errors.Is(err, errNoOutput) {
+			return code, err
+		}
+		if time.Until(deadline.Add(extension.total())) <= backoff {
+			logrus.Errorf("Not retrying the process, the timeout passes before the %s backoff", backoff)
+			return code, err
+		}
+		logrus.WithError(err).Infof("Attempt %d of %d exited %d, retrying in %s", attempt, o.Retries+1, code, backoff)
+		select {
+		case s := <-interrupt:
+			logrus.Errorf("Entrypoint received interrupt: %v", s)
+			return AbortedErrorCode, errAborted
+		case <-time.After(backoff):
+		}
+		// Whole seconds keep the timeouts in the process log readable.
+		timeout = time.Until(deadline.Add(extension.total())).Round(time.Second)
+		backoff *= 2
+	}
+}
+
+// recordAttempts adds the exit codes of the attempts of the process, or of
+// a step, to the metadata file, keeping the metadata the process wrote.
+func (o Options) recordAttempts(step string, codes []int) error {
+	if o.MetadataFile == "" || len(codes) == 0 {
+		return nil
+	}
+	metadata := map[string]interface{}{}
+	raw, err := os.ReadFile(o.MetadataFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read the metadata file: %w", err)
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return fmt.Errorf("could not parse the metadata file: %w", err)
+		}
+	}
+	attempts, ok := metadata[attemptsKey].(map[string]interface{})
+	if !ok {
+		attempts = map[string]interface{}{}
+	}
+	name := o.ContainerName
+	if step != "" {
+		name = strings.TrimPrefix(name+"/"+step, "/")
+	}
+	if name == "" {
+		name = "process"
+	}
+	attempts[name] = codes
+	metadata[attemptsKey] = attempts
+	if raw, err = json.Marshal(metadata); err != nil {
+		return fmt.Errorf("could not marshal the metadata: %w", err)
+	}
+	return os.WriteFile(o.MetadataFile, raw, 0644)
+}
+
+// executeCommand runs the process with args, in the child cgroup if given,
+// until it finishes, the timeout passes, it stops producing output or the
+// entrypoint is interrupted. Its resource usage is added to usage, if given.
+// Once the timeout passes, the process is granted the extension it asked
+// for in the timeout override file, if any, before it is terminated.
+func (o Options) executeCommand(args []string, timeout time.Duration, extension *timeoutExtension, cgroup *childCgroup, usage *resourceTracker, output io.Writer, processLogFile *os.File, interrupt chan os.Signal) (int, error) {
+	executable := args[0]
 	var arguments []string
-	if len(o.Args) > 1 {
-		arguments = o.Args[1:]
+	if len(args) > 1 {
+		arguments = args[1:]
 	}
 	command := exec.Command(executable, arguments...)
-	command.Stderr = output
-	command.Stdout = output
+	prepareCommand(command, o.SignalProcessGroup)
+	// The cgroup is shared by the steps, only count the kills of this one.
+	var previousKills int
+	if cgroup != nil {
+		cgroup.apply(command)
+		var err error
+		if previousKills, err = cgroup.oomKills(); err != nil {
+			logrus.WithError(err).Warn("Could not count previous OOM kills")
+		}
+	}
+	processOutput := newOutputActivity(output)
+	command.Stderr = processOutput
+	command.Stdout = processOutput
+	started := time.Now()
 	if err := command.Start(); err != nil {
 		errs := []error{fmt.Errorf("could not start the process: %w", err)}
 		if _, err := processLogFile.Write([]byte(errs[0].Error())); err != nil {
@@ -154,26 +391,59 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 		return InternalErrorCode, utilerrors.NewAggregate(errs)
 	}
 
-	timeout := optionOrDefault(o.Timeout, DefaultTimeout)
 	gracePeriod := optionOrDefault(o.GracePeriod, DefaultGracePeriod)
+	stop := make(chan struct{})
+	defer close(stop)
+	if o.HeartbeatInterval > 0 {
+		go processOutput.heartbeat(o.HeartbeatFile, o.HeartbeatInterval, stop)
+	}
+	stalled := processOutput.watch(o.NoOutputTimeout, stop)
+	if usage != nil {
+		go usage.sample(command.Process.Pid, stop)
+	}
 	var commandErr error
-	cancelled, aborted := false, false
+	cancelled, aborted, noOutput, exited := false, false, false, true
 	done := make(chan error)
 	go func() {
 		done <- command.Wait()
 	}()
-	select {
-	case err := <-done:
-		commandErr = err
-	case <-time.After(timeout):
-		logrus.Errorf("Process did not finish before %s timeout", timeout)
-		cancelled = true
-		gracefullyTerminate(command, done, gracePeriod, nil)
-	case s := <-interrupt:
-		logrus.Errorf("Entrypoint received interrupt: %v", s)
-		cancelled = true
-		aborted = true
-		gracefullyTerminate(command, done, gracePeriod, &s)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for waiting := true; waiting; {
+		waiting = false
+		select {
+		case err := <-done:
+			commandErr = err
+		case <-timer.C:
+			if more := extension.extend(); more > 0 {
+				logrus.Infof("Extending the %s timeout by %s as asked for in %s", timeout, more, TimeoutOverrideFile)
+				timeout += more
+				timer.Reset(more)
+				waiting = true
+				continue
+			}
+			logrus.Errorf("Process did not finish before %s timeout", timeout)
+			cancelled = true
+			exited = o.gracefullyTerminate(command, done, gracePeriod, nil)
+		case <-stalled:
+			logrus.Errorf("Process did not produce output for %s", o.NoOutputTimeout)
+			cancelled = true
+			noOutput = true
+			exited = o.gracefullyTerminate(command, done, gracePeriod, nil)
+		case s := <-interrupt:
+			logrus.Errorf("Entrypoint received interrupt: %v", s)
+			cancelled = true
+			aborted = true
+			exited = o.gracefullyTerminate(command, done, gracePeriod, &s)
+		}
+	}
+	if usage != nil {
+		// The state of a killed process is set once it was waited for.
+		state := command.ProcessState
+		if !exited {
+			state = nil
+		}
+		usage.record(state, time.Since(started))
 	}
 
 	var returnCode int
@@ -187,6 +457,9 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 			}
 		} else {
 			commandErr = errTimedOut
+			if noOutput {
+				commandErr = errNoOutput
+			}
 			if o.PropagateErrorCode {
 				returnCode = command.ProcessState.ExitCode()
 			} else {
@@ -206,17 +479,37 @@ func (o Options) ExecuteProcess(signaledInterrupt chan os.Signal) (int, error) {
 			commandErr = fmt.Errorf("wrapped process failed: %w", commandErr)
 		}
 	}
+	if cgroup != nil {
+		kills, err := cgroup.oomKills()
+		kills -= previousKills
+		if err != nil {
+			logrus.WithError(err).Warn("Could not tell whether the process was OOM-killed")
+		} else if kills > 0 {
+			logrus.Errorf("The kernel killed %d process(es) for exceeding the memory limit of %s", kills, o.ChildMemoryLimit)
+			if !cancelled && returnCode != 0 {
+				commandErr = fmt.Errorf("%w: %w", errOOMKilled, commandErr)
+				if !o.PropagateErrorCode {
+					returnCode = OOMKilledErrorCode
+				}
+			}
+		}
+	}
 	return returnCode, commandErr
 }
 
 func (o *Options) Mark(exitCode int) error {
+	return writeMarker(o.MarkerFile, o.ContainerName, exitCode)
+}
+
+// writeMarker atomically writes the exit code to the marker file.
+func writeMarker(markerFile, containerName string, exitCode int) error {
 	content := []byte(strconv.Itoa(exitCode))
 
 	// create temp file in the same directory as the desired marker file
-	dir := filepath.Dir(o.MarkerFile)
-	tmpDir, err := os.MkdirTemp(dir, o.ContainerName)
+	dir := filepath.Dir(markerFile)
+	tmpDir, err := os.MkdirTemp(dir, containerName)
 	if err != nil {
-		return fmt.Errorf("%s: error creating temp dir: %w", o.ContainerName, err)
+		return fmt.Errorf("%s: error creating temp dir: %w", containerName, err)
 	}
 	tempFile, err := os.CreateTemp(tmpDir, "temp-marker")
 	if err != nil {
@@ -234,12 +527,27 @@ func (o *Options) Mark(exitCode int) error {
 	if err = os.Chmod(tempFile.Name(), os.ModePerm); err != nil {
 		return fmt.Errorf("could not chmod (%x) temp marker file (%s): %w", os.ModePerm, tempFile.Name(), err)
 	}
-	if err := os.Rename(tempFile.Name(), o.MarkerFile); err != nil {
-		return fmt.Errorf("could not move marker file to destination path (%s): %w", o.MarkerFile, err)
+	if err := os.Rename(tempFile.Name(), markerFile); err != nil {
+		return fmt.Errorf("could not move marker file to destination path (%s): %w", markerFile, err)
 	}
 	return nil
 }
 
+// interruptibleContext returns a context that is cancelled when an
+// interrupt is received.
+func interruptibleContext(parent context.Context, interrupt <-chan os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case s := <-interrupt:
+			logrus.Errorf("Received interrupt %s, cancelling...", s)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
 // optionOrDefault defaults to a value if option
 // is the zero value
 func optionOrDefault(option, defaultValue time.Duration) time.Duration {
@@ -250,23 +558,92 @@ func optionOrDefault(option, defaultValue time.Duration) time.Duration {
 	return option
 }
 
-func gracefullyTerminate(command *exec.Cmd, done <-chan error, gracePeriod time.Duration, signal *os.Signal) {
-	if err := command.Process.Signal(os.Interrupt); err != nil {
-		logrus.WithError(err).Error("Could not interrupt process after timeout")
+// outputActivity tracks when the process last wrote output.
+type outputActivity struct {
+	writer     io.Writer
+	lastOutput atomic.Int64
+}
+
+func newOutputActivity(writer io.Writer) *outputActivity {
+	a := &outputActivity{writer: writer}
+	a.lastOutput.Store(time.Now().UnixNano())
+	return a
+}
+
+func (a *outputActivity) Write(p []byte) (int, error) {
+	a.lastOutput.Store(time.Now().UnixNano())
+	return a.writer.Write(p)
+}
+
+func (a *outputActivity) last() time.Time {
+	return time.Unix(0, a.lastOutput.Load())
+}
+
+// watch returns a channel that is closed once no output was written for
+// the given timeout. The channel is never closed if the timeout is zero.
+func (a *outputActivity) watch(timeout time.Duration, stop <-chan struct{}) <-chan struct{} {
+	if timeout <= 0 {
+		return nil
 	}
-	if signal != nil {
-		if err := command.Process.Signal(*signal); err != nil {
-			logrus.WithError(err).Errorf("Could not send signal %v to process after timeout", signal)
+	stalled := make(chan struct{})
+	go func() {
+		for {
+			idle := time.Since(a.last())
+			if idle >= timeout {
+				close(stalled)
+				return
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(timeout - idle):
+			}
+		}
+	}()
+	return stalled
+}
+
+// heartbeat writes a heartbeat to the given file every interval until stopped.
+func (a *outputActivity) heartbeat(path string, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := wrapper.WriteHeartbeat(path, wrapper.Heartbeat{
+			Timestamp:  time.Now(),
+			LastOutput: a.last(),
+			Interval:   interval,
+		}); err != nil {
+			logrus.WithError(err).Warn("Could not write heartbeat")
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
 		}
 	}
+}
+
+// gracefullyTerminate sends the timeout signal and the signal the entrypoint
+// received, if any, to the process and kills it if it does not exit within
+// the grace period. It returns whether the process exited in time.
+func (o Options) gracefullyTerminate(command *exec.Cmd, done <-chan error, gracePeriod time.Duration, signal *os.Signal) bool {
+	interruptProcess(command.Process, o.timeoutSignal(), signal, o.SignalProcessGroup)
 	select {
 	case <-done:
 		logrus.Errorf("Process gracefully exited before %s grace period", gracePeriod)
 		// but we ignore the output error as we will want errTimedOut
+		if o.SignalProcessGroup {
+			// Children that ignored the signal are still running.
+			if err := killProcess(command.Process, true); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				logrus.WithError(err).Error("Could not kill the remaining processes of the process group")
+			}
+		}
+		return true
 	case <-time.After(gracePeriod):
 		logrus.Errorf("Process did not exit before %s grace period", gracePeriod)
-		if err := command.Process.Kill(); err != nil {
+		if err := killProcess(command.Process, o.SignalProcessGroup); err != nil {
 			logrus.WithError(err).Error("Could not kill process after grace period")
 		}
+		return false
 	}
 }
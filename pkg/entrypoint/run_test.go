@@ -18,15 +18,20 @@ package entrypoint
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"runtime"
 	"strconv"
+	"strings"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
 )
 
@@ -41,6 +46,9 @@ func TestOptions_Run(t *testing.T) {
 		previousMarker string
 		timeout        time.Duration
 		gracePeriod    time.Duration
+		noOutput       time.Duration
+		timeoutSignal  string
+		processGroup   bool
 		expectedLog    string
 		expectedMarker string
 		expectedCode   int
@@ -91,6 +99,46 @@ func TestOptions_Run(t *testing.T) {
 			expectedMarker: strconv.Itoa(InternalErrorCode),
 			expectedCode:   InternalErrorCode,
 		},
+		{
+			name:           "command times out and receives SIGTERM",
+			args:           []string{"bash", "-c", "trap 'echo terminated; exit 1' TERM; sleep 10 >/dev/null 2>&1 & wait $!"},
+			timeout:        1 * time.Second,
+			gracePeriod:    1 * time.Second,
+			timeoutSignal:  "SIGTERM",
+			expectedLog:    "level=error msg=\"Process did not finish before 1s timeout\"\nterminated\nlevel=error msg=\"Process gracefully exited before 1s grace period\"\n",
+			expectedMarker: strconv.Itoa(InternalErrorCode),
+			expectedCode:   InternalErrorCode,
+		},
+		{
+			// The child keeps the output open, the process is only
+			// done once the child received the signal as well.
+			name:           "command times out and its process group receives SIGTERM",
+			args:           []string{"bash", "-c", "trap 'echo terminated; exit 1' TERM; sleep 10 & wait $!"},
+			timeout:        1 * time.Second,
+			gracePeriod:    1 * time.Second,
+			timeoutSignal:  "SIGTERM",
+			processGroup:   true,
+			expectedLog:    "level=error msg=\"Process did not finish before 1s timeout\"\nterminated\nlevel=error msg=\"Process gracefully exited before 1s grace period\"\n",
+			expectedMarker: strconv.Itoa(InternalErrorCode),
+			expectedCode:   InternalErrorCode,
+		},
+		{
+			name:           "command produces no output",
+			args:           []string{"sh", "-c", "echo started; exec sleep 10"},
+			noOutput:       1 * time.Second,
+			gracePeriod:    1 * time.Second,
+			expectedLog:    "started\nlevel=error msg=\"Process did not produce output for 1s\"\nlevel=error msg=\"Process gracefully exited before 1s grace period\"\n",
+			expectedMarker: strconv.Itoa(InternalErrorCode),
+			expectedCode:   InternalErrorCode,
+		},
+		{
+			name:           "command producing output is not terminated",
+			args:           []string{"sh", "-c", "for i in 1 2 3 4; do echo $i; sleep 0.5; done"},
+			noOutput:       1 * time.Second,
+			expectedLog:    "1\n2\n3\n4\n",
+			expectedMarker: "0",
+			expectedCode:   0,
+		},
 		{
 			// Ensure that environment variables get passed through
 			name:           "$PATH is set",
@@ -202,6 +250,9 @@ wait`},
 				PropagateErrorCode: testCase.propagate,
 				Timeout:            testCase.timeout,
 				GracePeriod:        testCase.gracePeriod,
+				NoOutputTimeout:    testCase.noOutput,
+				TimeoutSignal:      testCase.timeoutSignal,
+				SignalProcessGroup: testCase.processGroup,
 				Options: &wrapper.Options{
 					Args:       testCase.args,
 					ProcessLog: path.Join(tmpDir, "process-log.txt"),
@@ -246,6 +297,222 @@ wait`},
 	}
 }
 
+func TestOptions_RunWritesHeartbeat(t *testing.T) {
+	tmpDir := t.TempDir()
+	options := Options{
+		HeartbeatInterval: 100 * time.Millisecond,
+		Options: &wrapper.Options{
+			Args:          []string{"sh", "-c", "echo started; sleep 1"},
+			ProcessLog:    path.Join(tmpDir, "process-log.txt"),
+			MarkerFile:    path.Join(tmpDir, "marker-file.txt"),
+			HeartbeatFile: path.Join(tmpDir, "heartbeat.json"),
+		},
+	}
+	start := time.Now()
+	if code := options.internalRun(make(chan os.Signal, 1)); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	heartbeat, err := wrapper.ReadHeartbeat(options.HeartbeatFile)
+	if err != nil {
+		t.Fatalf("could not read heartbeat: %v", err)
+	}
+	if heartbeat.Interval != options.HeartbeatInterval {
+		t.Errorf("expected interval %s, got %s", options.HeartbeatInterval, heartbeat.Interval)
+	}
+	if !heartbeat.Timestamp.After(heartbeat.LastOutput) {
+		t.Errorf("expected heartbeat at %s to be written after the last output at %s", heartbeat.Timestamp, heartbeat.LastOutput)
+	}
+	if heartbeat.LastOutput.Before(start) {
+		t.Errorf("expected last output at %s to be after the start at %s", heartbeat.LastOutput, start)
+	}
+}
+
+func TestOptions_RunWithDependencies(t *testing.T) {
+	testCases := []struct {
+		name           string
+		dependencies   map[string]string
+		optional       sets.Set[string]
+		missing        bool
+		deadline       time.Duration
+		expectedLog    string
+		expectedMarker string
+		expectedCode   int
+	}{
+		{
+			name:           "run once all dependencies passed",
+			dependencies:   map[string]string{"setup": "0", "build": "0"},
+			expectedLog:    "test\n",
+			expectedMarker: "0",
+		},
+		{
+			name:           "skip if a required dependency failed",
+			dependencies:   map[string]string{"setup": "0", "build": "2"},
+			expectedLog:    "level=info msg=\"Skipping as dependencies failed: build\"\n",
+			expectedMarker: strconv.Itoa(PreviousErrorCode),
+			expectedCode:   PreviousErrorCode,
+		},
+		{
+			name:           "run if only optional dependencies failed",
+			dependencies:   map[string]string{"setup": "0", "lint": "1"},
+			optional:       sets.New("lint"),
+			expectedLog:    "test\n",
+			expectedMarker: "0",
+		},
+		{
+			name:           "time out while waiting for a dependency",
+			dependencies:   map[string]string{"setup": "0"},
+			missing:        true,
+			deadline:       200 * time.Millisecond,
+			expectedLog:    "level=error msg=\"Dependencies did not finish before the 200ms timeout\"\n",
+			expectedMarker: strconv.Itoa(InternalErrorCode),
+			expectedCode:   InternalErrorCode,
+		},
+	}
+
+	logrus.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			options := Options{
+				Timeout: testCase.deadline,
+				Options: &wrapper.Options{
+					Args:         []string{"echo", "test"},
+					ProcessLog:   path.Join(tmpDir, "process-log.txt"),
+					MarkerFile:   path.Join(tmpDir, "marker-file.txt"),
+					DeadlineFile: path.Join(tmpDir, "deadline.txt"),
+				},
+			}
+			for _, name := range sets.List(sets.KeySet(testCase.dependencies)) {
+				p := path.Join(tmpDir, name+"-marker.txt")
+				if err := os.WriteFile(p, []byte(testCase.dependencies[name]), 0600); err != nil {
+					t.Fatalf("could not create marker: %v", err)
+				}
+				options.After = append(options.After, wrapper.Dependency{ContainerName: name, MarkerFile: p, Optional: testCase.optional.Has(name)})
+			}
+			if testCase.missing {
+				options.After = append(options.After, wrapper.Dependency{ContainerName: "missing", MarkerFile: path.Join(tmpDir, "missing-marker.txt")})
+			}
+
+			if code := options.internalRun(make(chan os.Signal, 1)); code != testCase.expectedCode {
+				t.Errorf("expected exit code %d != actual %d", testCase.expectedCode, code)
+			}
+			compareFileContents(testCase.name, options.ProcessLog, testCase.expectedLog, t)
+			compareFileContents(testCase.name, options.MarkerFile, testCase.expectedMarker, t)
+		})
+	}
+}
+
+func TestOptions_RunSharesDeadline(t *testing.T) {
+	tmpDir := t.TempDir()
+	options := Options{
+		Timeout: time.Hour,
+		Options: &wrapper.Options{
+			Args:         []string{"echo", "test"},
+			ProcessLog:   path.Join(tmpDir, "process-log.txt"),
+			MarkerFile:   path.Join(tmpDir, "marker-file.txt"),
+			DeadlineFile: path.Join(tmpDir, "deadline.txt"),
+		},
+	}
+	// Another container started first and its deadline already passed.
+	if _, err := wrapper.SharedDeadline(options.DeadlineFile, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("could not write deadline: %v", err)
+	}
+	if code := options.internalRun(make(chan os.Signal, 1)); code != InternalErrorCode {
+		t.Errorf("expected exit code %d != actual %d", InternalErrorCode, code)
+	}
+	compareFileContents("shared deadline", options.MarkerFile, strconv.Itoa(InternalErrorCode), t)
+}
+
+func TestOptions_RunSteps(t *testing.T) {
+	testCases := []struct {
+		name           string
+		steps          []Step
+		timeout        time.Duration
+		expectedLog    string
+		expectedMarker string
+		expectedCode   int
+		// expectedStepMarkers are the markers of the steps by name.
+		expectedStepMarkers map[string]string
+	}{
+		{
+			name: "all steps pass",
+			steps: []Step{
+				{Name: "setup", Args: []string{"echo", "setup"}},
+				{Name: "test", Args: []string{"echo", "test"}},
+			},
+			expectedLog:         "level=info msg=\"Running step setup\"\nsetup\nlevel=info msg=\"Running step test\"\ntest\n",
+			expectedMarker:      "0",
+			expectedStepMarkers: map[string]string{"setup": "0", "test": "0"},
+		},
+		{
+			name: "a failing step skips the following ones unless they always run",
+			steps: []Step{
+				{Name: "setup", Args: []string{"sh", "-c", "exit 0"}},
+				{Name: "test", Args: []string{"sh", "-c", "exit 3"}},
+				{Name: "report", Args: []string{"echo", "report"}},
+				{Name: "teardown", Args: []string{"echo", "teardown"}, Always: true},
+			},
+			expectedLog:         "level=info msg=\"Running step setup\"\nlevel=info msg=\"Running step test\"\nlevel=info msg=\"Skipping step report\"\nlevel=info msg=\"Running step teardown\"\nteardown\n",
+			expectedMarker:      "3",
+			expectedCode:        3,
+			expectedStepMarkers: map[string]string{"setup": "0", "test": "3", "report": strconv.Itoa(PreviousErrorCode), "teardown": "0"},
+		},
+		{
+			name: "a step times out on its own timeout",
+			steps: []Step{
+				{Name: "test", Args: []string{"sleep", "10"}, Timeout: time.Second},
+				{Name: "teardown", Args: []string{"echo", "teardown"}, Always: true},
+			},
+			expectedLog:         "level=info msg=\"Running step test\"\nlevel=error msg=\"Process did not finish before 1s timeout\"\nlevel=error msg=\"Process gracefully exited before 1s grace period\"\nlevel=info msg=\"Running step teardown\"\nteardown\n",
+			expectedMarker:      strconv.Itoa(InternalErrorCode),
+			expectedCode:        InternalErrorCode,
+			expectedStepMarkers: map[string]string{"test": strconv.Itoa(InternalErrorCode), "teardown": "0"},
+		},
+		{
+			name: "no step runs once the overall timeout passed",
+			steps: []Step{
+				{Name: "test", Args: []string{"sleep", "10"}, Timeout: time.Minute},
+				{Name: "teardown", Args: []string{"echo", "teardown"}, Always: true},
+			},
+			timeout:             time.Second,
+			expectedLog:         "level=info msg=\"Running step test\"\nlevel=error msg=\"Process did not finish before 1s timeout\"\nlevel=error msg=\"Process gracefully exited before 1s grace period\"\nlevel=info msg=\"Skipping step teardown\"\n",
+			expectedMarker:      strconv.Itoa(InternalErrorCode),
+			expectedCode:        InternalErrorCode,
+			expectedStepMarkers: map[string]string{"test": strconv.Itoa(InternalErrorCode), "teardown": strconv.Itoa(PreviousErrorCode)},
+		},
+	}
+
+	logrus.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			options := Options{
+				Timeout:     testCase.timeout,
+				GracePeriod: time.Second,
+				Options: &wrapper.Options{
+					ProcessLog: path.Join(tmpDir, "process-log.txt"),
+					MarkerFile: path.Join(tmpDir, "marker-file.txt"),
+				},
+			}
+			for _, step := range testCase.steps {
+				step.MarkerFile = path.Join(tmpDir, step.Name+"-marker.txt")
+				options.Steps = append(options.Steps, step)
+			}
+
+			if code := options.internalRun(make(chan os.Signal, 1)); code != testCase.expectedCode {
+				t.Errorf("expected exit code %d != actual %d", testCase.expectedCode, code)
+			}
+			compareFileContents(testCase.name, options.ProcessLog, testCase.expectedLog, t)
+			compareFileContents(testCase.name, options.MarkerFile, testCase.expectedMarker, t)
+			for name, expected := range testCase.expectedStepMarkers {
+				compareFileContents(testCase.name, path.Join(tmpDir, name+"-marker.txt"), expected, t)
+			}
+		})
+	}
+}
+
 func compareFileContents(name, file, expected string, t *testing.T) {
 	data, err := os.ReadFile(file)
 	if err != nil {
@@ -271,3 +538,204 @@ func waitForFileToBeWritten(ctx context.Context, file string) error {
 		}
 	}
 }
+
+func TestOptions_RunRetries(t *testing.T) {
+	testCases := []struct {
+		name string
+		// script is run with the number of previous attempts in $n.
+		script           string
+		expectedLog      string
+		expectedCode     int
+		expectedAttempts []interface{}
+	}{
+		{
+			name:             "retried until the process passes",
+			script:           `if [ "$n" -lt 2 ]; then exit 3; fi`,
+			expectedLog:      "level=info msg=\"Attempt 1 of 3 exited 3, retrying in 10ms\" error=\"wrapped process failed: exit status 3\"\nlevel=info msg=\"Attempt 2 of 3 exited 3, retrying in 20ms\" error=\"wrapped process failed: exit status 3\"\n",
+			expectedAttempts: []interface{}{3.0, 3.0, 0.0},
+		},
+		{
+			name:             "other exit codes are not retried",
+			script:           "exit 4",
+			expectedCode:     4,
+			expectedAttempts: []interface{}{4.0},
+		},
+		{
+			name:             "the last attempt fails the process",
+			script:           "exit 3",
+			expectedLog:      "level=info msg=\"Attempt 1 of 3 exited 3, retrying in 10ms\" error=\"wrapped process failed: exit status 3\"\nlevel=info msg=\"Attempt 2 of 3 exited 3, retrying in 20ms\" error=\"wrapped process failed: exit status 3\"\n",
+			expectedCode:     3,
+			expectedAttempts: []interface{}{3.0, 3.0, 3.0},
+		},
+	}
+
+	logrus.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			counter := path.Join(tmpDir, "attempts")
+			metadataFile := path.Join(tmpDir, "metadata.json")
+			if err := os.WriteFile(metadataFile, []byte(`{"version":"v1"}`), 0644); err != nil {
+				t.Fatalf("could not write metadata: %v", err)
+			}
+			options := Options{
+				Retries:            2,
+				RetryableExitCodes: []int{3},
+				RetryBackoff:       10 * time.Millisecond,
+				Options: &wrapper.Options{
+					Args:          []string{"sh", "-c", fmt.Sprintf(`n=$(cat %[1]s 2>/dev/null || echo 0); echo $((n+1)) > %[1]s; %s`, counter, testCase.script)},
+					ContainerName: "test",
+					ProcessLog:    path.Join(tmpDir, "process-log.txt"),
+					MarkerFile:    path.Join(tmpDir, "marker-file.txt"),
+					MetadataFile:  metadataFile,
+				},
+			}
+
+			if code := options.internalRun(make(chan os.Signal, 1)); code != testCase.expectedCode {
+				t.Errorf("expected exit code %d != actual %d", testCase.expectedCode, code)
+			}
+			compareFileContents(testCase.name, options.ProcessLog, testCase.expectedLog, t)
+			compareFileContents(testCase.name, options.MarkerFile, strconv.Itoa(testCase.expectedCode), t)
+
+			raw, err := os.ReadFile(metadataFile)
+			if err != nil {
+				t.Fatalf("could not read metadata: %v", err)
+			}
+			var metadata map[string]interface{}
+			if err := json.Unmarshal(raw, &metadata); err != nil {
+				t.Fatalf("could not parse metadata: %v", err)
+			}
+			expected := map[string]interface{}{
+				"version":   "v1",
+				attemptsKey: map[string]interface{}{"test": testCase.expectedAttempts},
+			}
+			if diff := cmp.Diff(expected, metadata); diff != "" {
+				t.Errorf("metadata differs from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOptions_RunWritesResourceUsage(t *testing.T) {
+	tmpDir := t.TempDir()
+	options := Options{
+		Steps: []Step{
+			{Name: "first", Args: []string{"sh", "-c", "sleep 0.1"}},
+			{Name: "second", Args: []string{"sh", "-c", "exit 0"}},
+		},
+		ArtifactDir: path.Join(tmpDir, "artifacts"),
+		Options: &wrapper.Options{
+			ProcessLog: path.Join(tmpDir, "process-log.txt"),
+			MarkerFile: path.Join(tmpDir, "marker-file.txt"),
+		},
+	}
+
+	if code := options.internalRun(make(chan os.Signal, 1)); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	raw, err := os.ReadFile(path.Join(options.ArtifactDir, ResourceUsageFile))
+	if err != nil {
+		t.Fatalf("could not read resource usage: %v", err)
+	}
+	var usage ResourceUsage
+	if err := json.Unmarshal(raw, &usage); err != nil {
+		t.Fatalf("could not parse resource usage: %v", err)
+	}
+	if usage.Processes != 2 {
+		t.Errorf("expected 2 processes, got %d", usage.Processes)
+	}
+	if usage.WallClockSeconds < 0.1 {
+		t.Errorf("expected at least 0.1s of wall clock, got %f", usage.WallClockSeconds)
+	}
+	if runtime.GOOS == "linux" && usage.PeakRSSBytes <= 0 {
+		t.Errorf("expected the peak RSS to be measured, got %d", usage.PeakRSSBytes)
+	}
+}
+
+func TestOptions_RunMasksSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	secretDir := path.Join(tmpDir, "secrets")
+	if err := os.MkdirAll(secretDir, 0755); err != nil {
+		t.Fatalf("could not create secret dir: %v", err)
+	}
+	if err := os.WriteFile(path.Join(secretDir, "token"), []byte("file-secret\n"), 0644); err != nil {
+		t.Fatalf("could not write secret: %v", err)
+	}
+	t.Setenv("ENTRYPOINT_TEST_SECRET", "env-secret")
+	options := Options{
+		SecretFiles:   []string{secretDir},
+		SecretEnvVars: []string{"ENTRYPOINT_TEST_SECRET"},
+		Options: &wrapper.Options{
+			Args:       []string{"sh", "-c", "echo token is $(cat " + path.Join(secretDir, "token") + "); printf env-; sleep 0.1; echo secret; printf file-sec"},
+			ProcessLog: path.Join(tmpDir, "process-log.txt"),
+			MarkerFile: path.Join(tmpDir, "marker-file.txt"),
+		},
+	}
+
+	if code := options.internalRun(make(chan os.Signal, 1)); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	log, err := os.ReadFile(options.ProcessLog)
+	if err != nil {
+		t.Fatalf("could not read process log: %v", err)
+	}
+	if expected := "token is XXXXXXXXXXX\nXXXXXXXXXX\nfile-sec"; !strings.HasPrefix(string(log), expected) {
+		t.Errorf("expected the process log to start with %q, got %q", expected, string(log))
+	}
+}
+
+func TestOptions_RunExtendsTimeout(t *testing.T) {
+	testCases := []struct {
+		name         string
+		script       string
+		maxExtension time.Duration
+		expectedLog  string
+		expectedCode int
+	}{
+		{
+			name:         "process finishes within the extension it asked for",
+			script:       "echo 2s > %s; sleep 2",
+			maxExtension: 5 * time.Second,
+			expectedLog:  "level=info msg=\"Extending the 1s timeout by 2s as asked for in timeout-override\"\n",
+		},
+		{
+			name:         "extension is capped at the maximum",
+			script:       "echo 1h > %s; exec sleep 10",
+			maxExtension: time.Second,
+			expectedLog:  "level=warning msg=\"The process asked for a 1h0m0s timeout extension, only granting the maximum of 1s\"\nlevel=info msg=\"Extending the 1s timeout by 1s as asked for in timeout-override\"\nlevel=error msg=\"Process did not finish before 2s timeout\"\nlevel=error msg=\"Process gracefully exited before 1s grace period\"\n",
+			expectedCode: InternalErrorCode,
+		},
+		{
+			name:         "override is ignored without a maximum",
+			script:       "echo 2s > %s; exec sleep 10",
+			expectedLog:  "level=error msg=\"Process did not finish before 1s timeout\"\nlevel=error msg=\"Process gracefully exited before 1s grace period\"\n",
+			expectedCode: InternalErrorCode,
+		},
+	}
+
+	logrus.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			artifactDir := path.Join(tmpDir, "artifacts")
+			options := Options{
+				Timeout:             time.Second,
+				GracePeriod:         time.Second,
+				MaxTimeoutExtension: testCase.maxExtension,
+				ArtifactDir:         artifactDir,
+				Options: &wrapper.Options{
+					Args:       []string{"sh", "-c", fmt.Sprintf(testCase.script, path.Join(artifactDir, TimeoutOverrideFile))},
+					ProcessLog: path.Join(tmpDir, "process-log.txt"),
+					MarkerFile: path.Join(tmpDir, "marker-file.txt"),
+				},
+			}
+
+			if code := options.internalRun(make(chan os.Signal, 1)); code != testCase.expectedCode {
+				t.Errorf("expected exit code %d != actual %d", testCase.expectedCode, code)
+			}
+			compareFileContents(testCase.name, options.ProcessLog, testCase.expectedLog, t)
+		})
+	}
+}
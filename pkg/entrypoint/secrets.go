@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/secretutil"
+)
+
+// usesSecrets tells whether secrets are redacted from the output of the
+// process.
+func (o *Options) usesSecrets() bool {
+	return len(o.SecretFiles) > 0 || len(o.SecretEnvVars) > 0
+}
+
+// loadSecrets returns the contents of the secret files, or of all files
+// under secret directories, and the values of the secret environment
+// variables.
+func (o *Options) loadSecrets() ([]string, error) {
+	var secrets []string
+	for _, path := range o.SecretFiles {
+		if err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(info.Name(), "..") {
+				// kubernetes volumes also include files we
+				// should not be looking into for secrets
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				return nil
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			secrets = append(secrets, string(raw))
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("could not load secret %s: %w", path, err)
+		}
+	}
+	for _, name := range o.SecretEnvVars {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			logrus.Warnf("Secret environment variable %s is not set", name)
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+	return secrets, nil
+}
+
+// maskingFlushDelay is how long output that could be the beginning of a
+// secret is held back if no more output follows.
+const maskingFlushDelay = time.Second
+
+// maskingWriter redacts secrets from the output written through it. Output
+// that could be the beginning of a secret is held back until the following
+// writes tell whether it is, so that secrets split across writes are redacted
+// as well. What is held back is written once no more output follows within
+// flushDelay, so that the end of the output is not delayed indefinitely, and
+// by flush.
+type maskingWriter struct {
+	lock     sync.Mutex
+	writer   io.Writer
+	censorer *secretutil.ReloadingCensorer
+	// patterns are the representations of the secrets the censorer redacts.
+	patterns   [][]byte
+	pending    []byte
+	flushDelay time.Duration
+	timer      *time.Timer
+}
+
+func newMaskingWriter(writer io.Writer, secrets []string) *maskingWriter {
+	w := &maskingWriter{writer: writer, censorer: secretutil.NewCensorer(), flushDelay: maskingFlushDelay}
+	w.censorer.Refresh(secrets...)
+	for _, secret := range secrets {
+		for _, value := range []string{secret, strings.TrimSpace(secret)} {
+			if value == "" {
+				continue
+			}
+			w.patterns = append(w.patterns, []byte(value), []byte(base64.StdEncoding.EncodeToString([]byte(value))))
+		}
+	}
+	return w
+}
+
+func (w *maskingWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	buffer := append(w.pending, p...)
+	w.censorer.Censor(&buffer)
+	held := w.heldBack(buffer)
+	if _, err := w.writer.Write(buffer[:len(buffer)-held]); err != nil {
+		return 0, err
+	}
+	w.pending = append([]byte{}, buffer[len(buffer)-held:]...)
+	if len(w.pending) > 0 {
+		w.scheduleFlush()
+	}
+	return len(p), nil
+}
+
+// scheduleFlush writes the output held back after flushDelay, unless it is
+// written before.
+func (w *maskingWriter) scheduleFlush() {
+	if w.timer != nil {
+		w.timer.Reset(w.flushDelay)
+		return
+	}
+	w.timer = time.AfterFunc(w.flushDelay, func() {
+		if err := w.flush(); err != nil {
+			logrus.WithError(err).Warn("Could not write the output held back.")
+		}
+	})
+}
+
+// heldBack returns the length of the longest end of the output that is the
+// beginning of a secret.
+func (w *maskingWriter) heldBack(output []byte) int {
+	var held int
+	for _, pattern := range w.patterns {
+		start := len(output) - len(pattern) + 1
+		if start < 0 {
+			start = 0
+		}
+		for i := start; i < len(output)-held; i++ {
+			if output[i] == pattern[0] && bytes.HasPrefix(pattern, output[i:]) {
+				held = len(output) - i
+				break
+			}
+		}
+	}
+	return held
+}
+
+// flush writes the output held back.
+func (w *maskingWriter) flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	if len(w.pending) == 0 {
+		return nil
+	}
+	_, err := w.writer.Write(w.pending)
+	w.pending = nil
+	return err
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestMaskingWriter(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		secrets  []string
+		writes   []string
+		written  string
+		expected string
+	}{
+		{
+			name:     "no secrets",
+			writes:   []string{"hello ", "world\n"},
+			written:  "hello world\n",
+			expected: "hello world\n",
+		},
+		{
+			name:     "secret in a single write",
+			secrets:  []string{"hunter2"},
+			writes:   []string{"password: hunter2\n"},
+			written:  "password: XXXXXXX\n",
+			expected: "password: XXXXXXX\n",
+		},
+		{
+			name:     "secret split across writes",
+			secrets:  []string{"hunter2"},
+			writes:   []string{"password: hun", "te", "r2\n"},
+			written:  "password: XXXXXXX\n",
+			expected: "password: XXXXXXX\n",
+		},
+		{
+			name:     "beginning of a secret is held back until flushed",
+			secrets:  []string{"hunter2"},
+			writes:   []string{"password: hunt"},
+			written:  "password: ",
+			expected: "password: hunt",
+		},
+		{
+			name:     "base64 encoded secret with trailing newline",
+			secrets:  []string{"hunter2\n"},
+			writes:   []string{"encoded: aHVudGVyMg==\n"},
+			written:  "encoded: XXXXXXXXXXXX\n",
+			expected: "encoded: XXXXXXXXXXXX\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var output bytes.Buffer
+			writer := newMaskingWriter(&output, testCase.secrets)
+			for _, write := range testCase.writes {
+				if n, err := writer.Write([]byte(write)); err != nil || n != len(write) {
+					t.Fatalf("expected to write %d bytes, wrote %d: %v", len(write), n, err)
+				}
+			}
+			if actual := output.String(); actual != testCase.written {
+				t.Errorf("expected %q to be written before flushing, got %q", testCase.written, actual)
+			}
+			if err := writer.flush(); err != nil {
+				t.Fatalf("could not flush: %v", err)
+			}
+			if actual := output.String(); actual != testCase.expected {
+				t.Errorf("expected %q to be written, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMaskingWriterFlushesHeldBackOutput(t *testing.T) {
+	var output bytes.Buffer
+	writer := newMaskingWriter(&output, []string{"hunter2"})
+	writer.flushDelay = 10 * time.Millisecond
+	if _, err := writer.Write([]byte("done: h")); err != nil {
+		t.Fatalf("could not write: %v", err)
+	}
+	written := func() string {
+		writer.lock.Lock()
+		defer writer.lock.Unlock()
+		return output.String()
+	}
+	if actual := written(); actual != "done: " {
+		t.Fatalf("expected the beginning of the secret to be held back, got %q", actual)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for written() != "done: h" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the output held back to be written without more output, got %q", written())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
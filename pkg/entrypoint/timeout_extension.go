@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrypoint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TimeoutOverrideFile is the file in the artifact directory the process can
+// write a duration to, like "30m", to ask for that much more time than its
+// timeout, e.g. when a long-tail e2e suite is still making progress. The
+// extension is granted up to MaxTimeoutExtension once the timeout passes.
+const TimeoutOverrideFile = "timeout-override"
+
+// timeoutExtension grants the process the extension of its timeout it asks
+// for in the timeout override file.
+type timeoutExtension struct {
+	path    string
+	max     time.Duration
+	granted time.Duration
+}
+
+// newTimeoutExtension returns nil if the process may not extend its timeout.
+func (o *Options) newTimeoutExtension() *timeoutExtension {
+	if o.MaxTimeoutExtension <= 0 || o.ArtifactDir == "" {
+		return nil
+	}
+	return &timeoutExtension{path: filepath.Join(o.ArtifactDir, TimeoutOverrideFile), max: o.MaxTimeoutExtension}
+}
+
+// extend returns how much more time to grant the process: the part of the
+// extension it asked for that was not granted yet, up to the maximum.
+func (e *timeoutExtension) extend() time.Duration {
+	if e == nil {
+		return 0
+	}
+	raw, err := os.ReadFile(e.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.WithError(err).Warn("Could not read the timeout override")
+		}
+		return 0
+	}
+	requested, err := time.ParseDuration(strings.TrimSpace(string(raw)))
+	if err != nil || requested < 0 {
+		logrus.Warnf("Ignoring the timeout override %q, it is not a duration like 30m", strings.TrimSpace(string(raw)))
+		return 0
+	}
+	granted := requested
+	if granted > e.max {
+		granted = e.max
+	}
+	more := granted - e.granted
+	if more <= 0 {
+		return 0
+	}
+	if granted < requested {
+		logrus.Warnf("The process asked for a %s timeout extension, only granting the maximum of %s", requested, e.max)
+	}
+	e.granted = granted
+	return more
+}
+
+// total returns the extension granted so far.
+func (e *timeoutExtension) total() time.Duration {
+	if e == nil {
+		return 0
+	}
+	return e.granted
+}
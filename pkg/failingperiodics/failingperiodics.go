@@ -0,0 +1,443 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package failingperiodics opens issues for periodic jobs that fail
+// repeatedly, keeps them up to date while the jobs keep failing and closes
+// them once the jobs pass again.
+package failingperiodics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowv1 "sigs.k8s.io/prow/pkg/client/clientset/versioned/typed/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	gcsutil "sigs.k8s.io/prow/pkg/crier/reporters/gcs/util"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/pjutil"
+)
+
+// markerPrefix starts the marker that identifies the issue of a job.
+const markerPrefix = "<!-- failing-periodics: "
+
+// Failure classes besides the known failure patterns of Spyglass.
+const (
+	classError        = "error"
+	classUnclassified = "unclassified"
+)
+
+// maxClassifiedFailures is the max number of the latest failures of a job
+// whose build logs are read to classify them.
+const maxClassifiedFailures = 20
+
+// Actions on issues, as reported by the issues metric.
+const (
+	actionOpened  = "opened"
+	actionUpdated = "updated"
+	actionClosed  = "closed"
+)
+
+var failingPeriodicsMetrics = struct {
+	issues *prometheus.CounterVec
+}{
+	issues: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "failing_periodics_issues",
+		Help: "Number of issues of failing periodic jobs opened, updated and closed by failing-periodics.",
+	}, []string{"action"}),
+}
+
+func init() {
+	prometheus.MustRegister(failingPeriodicsMetrics.issues)
+}
+
+type githubClient interface {
+	ListOpenIssues(org, repo string) ([]github.Issue, error)
+	CreateIssue(org, repo, title, body string, milestone int, labels, assignees []string) (int, error)
+	EditIssue(org, repo string, number int, issue *github.Issue) (*github.Issue, error)
+	CreateComment(org, repo string, number int, comment string) error
+	CloseIssue(org, repo string, number int) error
+	BotUserChecker() (func(candidate string) bool, error)
+}
+
+// Controller opens and closes the issues of periodic jobs according to the
+// failing-periodics config.
+type Controller struct {
+	ghc    githubClient
+	pjc    prowv1.ProwJobInterface
+	opener io.Opener
+	config config.Getter
+	logger *logrus.Entry
+	dryRun bool
+
+	// classes caches the failure classes of ProwJobs by name, so that their
+	// build logs are read once.
+	classes map[string]string
+}
+
+// NewController returns a new failing-periodics Controller. Failures are
+// classified by the known failure patterns of Spyglass if opener is not nil.
+func NewController(ghc githubClient, pjc prowv1.ProwJobInterface, opener io.Opener, cfg config.Getter, dryRun bool) *Controller {
+	return &Controller{
+		ghc:     ghc,
+		pjc:     pjc,
+		opener:  opener,
+		config:  cfg,
+		logger:  logrus.WithField("controller", "failing-periodics"),
+		dryRun:  dryRun,
+		classes: map[string]string{},
+	}
+}
+
+// Sync checks all periodic jobs once, and opens, updates or closes their
+// issues.
+func (c *Controller) Sync() error {
+	cfg := c.config()
+	if cfg.FailingPeriodics.Repo == "" {
+		return nil
+	}
+	org, repo, _ := strings.Cut(cfg.FailingPeriodics.Repo, "/")
+	runs, err := c.periodicRuns()
+	if err != nil {
+		return err
+	}
+	isBot, err := c.ghc.BotUserChecker()
+	if err != nil {
+		return fmt.Errorf("failed to get the bot user: %w", err)
+	}
+	issues, err := c.ghc.ListOpenIssues(org, repo)
+	if err != nil {
+		return fmt.Errorf("failed to list the issues of %s/%s: %w", org, repo, err)
+	}
+	open := issuesByJob(issues, isBot)
+	patterns := knownFailurePatterns(cfg)
+
+	var errs []error
+	for _, p := range cfg.AllPeriodics() {
+		if err := c.syncJob(cfg, org, repo, p, runs[p.Name], open[p.Name], patterns); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sync %s: %w", p.Name, err))
+		}
+	}
+	c.pruneClasses(runs)
+	return utilerrors.NewAggregate(errs)
+}
+
+// periodicRuns returns the periodic ProwJobs by job name.
+func (c *Controller) periodicRuns() (map[string][]prowapi.ProwJob, error) {
+	selector := labels.Set{kube.ProwJobTypeLabel: string(prowapi.PeriodicJob)}.AsSelector().String()
+	pjs, err := c.pjc.List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prowjobs: %w", err)
+	}
+	runs := map[string][]prowapi.ProwJob{}
+	for _, pj := range pjs.Items {
+		runs[pj.Spec.Job] = append(runs[pj.Spec.Job], pj)
+	}
+	return runs, nil
+}
+
+// issuesByJob returns the open issues opened by the bot for jobs, by job name.
+func issuesByJob(issues []github.Issue, isBot func(string) bool) map[string]*github.Issue {
+	byJob := map[string]*github.Issue{}
+	for i, issue := range issues {
+		if issue.IsPullRequest() || !isBot(issue.User.Login) {
+			continue
+		}
+		_, rest, ok := strings.Cut(issue.Body, markerPrefix)
+		if !ok {
+			continue
+		}
+		if job, _, ok := strings.Cut(rest, " -->"); ok {
+			byJob[job] = &issues[i]
+		}
+	}
+	return byJob
+}
+
+// streak returns the failures of a job since its last pass, latest first, and
+// its latest run if that passed. Runs that are still running or were aborted
+// are ignored.
+func streak(runs []prowapi.ProwJob) ([]prowapi.ProwJob, *prowapi.ProwJob) {
+	sorted := make([]prowapi.ProwJob, len(runs))
+	copy(sorted, runs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreationTimestamp.After(sorted[j].CreationTimestamp.Time)
+	})
+	var failures []prowapi.ProwJob
+	for i, pj := range sorted {
+		if !pj.Complete() {
+			continue
+		}
+		switch pj.Status.State {
+		case prowapi.SuccessState:
+			if len(failures) == 0 {
+				return nil, &sorted[i]
+			}
+			return failures, nil
+		case prowapi.FailureState, prowapi.ErrorState:
+			failures = append(failures, pj)
+		}
+	}
+	return failures, nil
+}
+
+func (c *Controller) syncJob(cfg *config.Config, org, repo string, p config.Periodic, runs []prowapi.ProwJob, issue *github.Issue, patterns []knownFailurePattern) error {
+	log := c.logger.WithField("job", p.Name)
+	failures, pass := streak(runs)
+	switch {
+	case issue != nil && pass != nil:
+		log = log.WithField("issue", issue.Number)
+		if c.dryRun {
+			log.Info("(dry-run) Closing the issue of the job, which passed again.")
+			return nil
+		}
+		log.Info("Closing the issue of the job, which passed again.")
+		if err := c.ghc.CreateComment(org, repo, issue.Number, recoveryComment(p.Name, pass)); err != nil {
+			return fmt.Errorf("failed to comment on issue %d: %w", issue.Number, err)
+		}
+		if err := c.ghc.CloseIssue(org, repo, issue.Number); err != nil {
+			return fmt.Errorf("failed to close issue %d: %w", issue.Number, err)
+		}
+		failingPeriodicsMetrics.issues.WithLabelValues(actionClosed).Inc()
+	case len(failures) >= cfg.FailingPeriodics.GetConsecutiveFailures():
+		body := c.issueBody(cfg, p, failures, patterns)
+		log = log.WithField("failures", len(failures))
+		if issue == nil {
+			assignees := cfg.FailingPeriodics.Assignees[pjutil.OwnershipFor(p.Annotations).Owner]
+			if c.dryRun {
+				log.Info("(dry-run) Opening an issue for the failing job.")
+				return nil
+			}
+			log.Info("Opening an issue for the failing job.")
+			if _, err := c.ghc.CreateIssue(org, repo, title(p.Name), body, 0, cfg.FailingPeriodics.Labels, assignees); err != nil {
+				return fmt.Errorf("failed to open an issue: %w", err)
+			}
+			failingPeriodicsMetrics.issues.WithLabelValues(actionOpened).Inc()
+			return nil
+		}
+		if issue.Body == body {
+			return nil
+		}
+		log = log.WithField("issue", issue.Number)
+		if c.dryRun {
+			log.Info("(dry-run) Updating the issue of the failing job.")
+			return nil
+		}
+		log.Info("Updating the issue of the failing job.")
+		edited := *issue
+		edited.Body = body
+		if _, err := c.ghc.EditIssue(org, repo, issue.Number, &edited); err != nil {
+			return fmt.Errorf("failed to update issue %d: %w", issue.Number, err)
+		}
+		failingPeriodicsMetrics.issues.WithLabelValues(actionUpdated).Inc()
+	}
+	return nil
+}
+
+func title(job string) string {
+	return fmt.Sprintf("Periodic job %s is failing", job)
+}
+
+func recoveryComment(job string, pass *prowapi.ProwJob) string {
+	run := "its latest run"
+	if pass.Status.URL != "" {
+		run = fmt.Sprintf("[its latest run](%s)", pass.Status.URL)
+	}
+	return fmt.Sprintf("The periodic job `%s` passed again in %s, closing.", job, run)
+}
+
+// cluster is a class of failures of a job.
+type cluster struct {
+	class       string
+	explanation string
+	failures    int
+}
+
+// clusters classifies the latest failures of a job, largest cluster first.
+func (c *Controller) clusters(failures []prowapi.ProwJob, patterns []knownFailurePattern) []cluster {
+	explanations := map[string]string{
+		classError:        "The job errored before it could report a result, e.g. because its pod could not start.",
+		classUnclassified: "The build log matches no known failure pattern.",
+	}
+	for _, pattern := range patterns {
+		explanations[pattern.name] = pattern.explanation
+	}
+	counts := map[string]int{}
+	for i, pj := range failures {
+		if i == maxClassifiedFailures {
+			break
+		}
+		counts[c.classify(pj, patterns)]++
+	}
+	var clusters []cluster
+	for class, n := range counts {
+		clusters = append(clusters, cluster{class: class, explanation: explanations[class], failures: n})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].failures != clusters[j].failures {
+			return clusters[i].failures > clusters[j].failures
+		}
+		return clusters[i].class < clusters[j].class
+	})
+	return clusters
+}
+
+func (c *Controller) issueBody(cfg *config.Config, p config.Periodic, failures []prowapi.ProwJob, patterns []knownFailurePattern) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The periodic job `%s` failed %d times in a row, most recently at %s.\n", p.Name, len(failures), finishedAt(failures[0]))
+
+	if o := pjutil.OwnershipFor(p.Annotations); !o.IsEmpty() {
+		b.WriteString("\n")
+		if o.Owner != "" {
+			fmt.Fprintf(&b, "**Owner:** %s\n", o.Owner)
+		}
+		if o.Escalation != "" {
+			fmt.Fprintf(&b, "**Escalation:** %s\n", o.Escalation)
+		}
+		if o.Docs != "" {
+			fmt.Fprintf(&b, "**Docs:** %s\n", o.Docs)
+		}
+	}
+
+	b.WriteString("\n### Failure clusters\n\n| Class | Failures | Explanation |\n| --- | --- | --- |\n")
+	for _, cl := range c.clusters(failures, patterns) {
+		fmt.Fprintf(&b, "| `%s` | %d | %s |\n", cl.class, cl.failures, cl.explanation)
+	}
+
+	b.WriteString("\n### Recent failures\n\n")
+	for i, pj := range failures {
+		if i == cfg.FailingPeriodics.GetRecentFailures() {
+			break
+		}
+		run := finishedAt(pj)
+		if pj.Status.URL != "" {
+			run = fmt.Sprintf("[%s](%s)", run, pj.Status.URL)
+		}
+		fmt.Fprintf(&b, "* %s: `%s`", run, c.classify(pj, patterns))
+		if pj.Status.Description != "" {
+			fmt.Fprintf(&b, " %s", pj.Status.Description)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nThis issue is kept up to date while the job keeps failing, and closed once it passes again.\n")
+	b.WriteString(markerPrefix + p.Name + " -->\n")
+	return b.String()
+}
+
+func finishedAt(pj prowapi.ProwJob) string {
+	t := pj.CreationTimestamp.Time
+	if pj.Status.CompletionTime != nil {
+		t = pj.Status.CompletionTime.Time
+	}
+	return t.UTC().Format("2006-01-02 15:04 MST")
+}
+
+// knownFailurePattern is a compiled config.KnownFailurePattern.
+type knownFailurePattern struct {
+	name        string
+	explanation string
+	re          *regexp.Regexp
+}
+
+func knownFailurePatterns(cfg *config.Config) []knownFailurePattern {
+	var patterns []knownFailurePattern
+	for _, pattern := range cfg.Deck.Spyglass.KnownFailurePatterns {
+		re, err := regexp.Compile(pattern.Regex)
+		if err != nil {
+			logrus.WithError(err).Warnf("Couldn't compile known failure pattern %q", pattern.Name)
+			continue
+		}
+		patterns = append(patterns, knownFailurePattern{name: pattern.Name, explanation: pattern.Explanation, re: re})
+	}
+	return patterns
+}
+
+// classify returns the class of a failure: the first known failure pattern
+// its build log matches, or classError if it errored.
+func (c *Controller) classify(pj prowapi.ProwJob, patterns []knownFailurePattern) string {
+	if pj.Status.State == prowapi.ErrorState {
+		return classError
+	}
+	if class, ok := c.classes[pj.Name]; ok {
+		return class
+	}
+	if c.opener == nil || len(patterns) == 0 {
+		return classUnclassified
+	}
+	class, err := c.classifyBuildLog(&pj, patterns)
+	if err != nil {
+		c.logger.WithError(err).WithField("prowjob", pj.Name).Debug("Couldn't classify the failure by its build log.")
+		return classUnclassified
+	}
+	c.classes[pj.Name] = class
+	return class
+}
+
+func (c *Controller) classifyBuildLog(pj *prowapi.ProwJob, patterns []knownFailurePattern) (string, error) {
+	bucket, dir, err := gcsutil.GetJobDestination(c.config, pj)
+	if err != nil {
+		return "", err
+	}
+	buildLog, err := providers.StoragePath(bucket, path.Join(dir, "build-log.txt"))
+	if err != nil {
+		return "", err
+	}
+	r, err := c.opener.Reader(context.TODO(), buildLog)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", buildLog, err)
+	}
+	defer io.LogClose(r)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		for _, pattern := range patterns {
+			if pattern.re.MatchString(scanner.Text()) {
+				return pattern.name, nil
+			}
+		}
+	}
+	return classUnclassified, scanner.Err()
+}
+
+// pruneClasses forgets the classes of the ProwJobs that are gone.
+func (c *Controller) pruneClasses(runs map[string][]prowapi.ProwJob) {
+	names := map[string]bool{}
+	for _, pjs := range runs {
+		for _, pj := range pjs {
+			names[pj.Name] = true
+		}
+	}
+	for name := range c.classes {
+		if !names[name] {
+			delete(c.classes, name)
+		}
+	}
+}
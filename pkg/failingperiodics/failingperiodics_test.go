@@ -0,0 +1,259 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package failingperiodics
+
+import (
+	"context"
+	"errors"
+	stdio "io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// fakeOpener serves the build logs of ProwJobs by build ID.
+type fakeOpener struct {
+	io.Opener
+	buildLogs map[string]string
+}
+
+func (o fakeOpener) Reader(_ context.Context, path string) (stdio.ReadCloser, error) {
+	for id, content := range o.buildLogs {
+		if strings.HasSuffix(path, "/"+id+"/build-log.txt") {
+			return stdio.NopCloser(strings.NewReader(content)), nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+var start = time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+
+func runOf(job, id string, state prowapi.ProwJobState, hour int) prowapi.ProwJob {
+	created := start.Add(time.Duration(hour) * time.Hour)
+	pj := prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              job + "-" + id,
+			Namespace:         "prowjobs",
+			Labels:            map[string]string{kube.ProwJobTypeLabel: string(prowapi.PeriodicJob)},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: prowapi.ProwJobSpec{
+			Type: prowapi.PeriodicJob,
+			Job:  job,
+			DecorationConfig: &prowapi.DecorationConfig{
+				GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "gs://bucket", PathStrategy: prowapi.PathStrategyExplicit},
+			},
+		},
+		Status: prowapi.ProwJobStatus{State: state, BuildID: id, URL: "https://prow/view/" + id},
+	}
+	if state != prowapi.PendingState && state != prowapi.TriggeredState {
+		completed := metav1.NewTime(created.Add(time.Minute))
+		pj.Status.CompletionTime = &completed
+	}
+	return pj
+}
+
+func TestStreak(t *testing.T) {
+	testCases := []struct {
+		name     string
+		runs     []prowapi.ProwJob
+		failures []string
+		pass     string
+	}{
+		{
+			name: "no runs",
+		},
+		{
+			name: "latest run passed",
+			runs: []prowapi.ProwJob{
+				runOf("job", "1", prowapi.FailureState, 1),
+				runOf("job", "2", prowapi.SuccessState, 2),
+			},
+			pass: "job-2",
+		},
+		{
+			name: "failures since the last pass, latest first",
+			runs: []prowapi.ProwJob{
+				runOf("job", "2", prowapi.FailureState, 2),
+				runOf("job", "1", prowapi.SuccessState, 1),
+				runOf("job", "4", prowapi.ErrorState, 4),
+				runOf("job", "3", prowapi.FailureState, 3),
+			},
+			failures: []string{"job-4", "job-3", "job-2"},
+		},
+		{
+			name: "running and aborted runs are ignored",
+			runs: []prowapi.ProwJob{
+				runOf("job", "1", prowapi.SuccessState, 1),
+				runOf("job", "2", prowapi.FailureState, 2),
+				runOf("job", "3", prowapi.AbortedState, 3),
+				runOf("job", "4", prowapi.PendingState, 4),
+			},
+			failures: []string{"job-2"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			failures, pass := streak(tc.runs)
+			var names []string
+			for _, pj := range failures {
+				names = append(names, pj.Name)
+			}
+			if diff := cmp.Diff(tc.failures, names); diff != "" {
+				t.Errorf("streak() got unexpected failures (-want +got):\n%s", diff)
+			}
+			var passName string
+			if pass != nil {
+				passName = pass.Name
+			}
+			if passName != tc.pass {
+				t.Errorf("streak() got pass %q, want %q", passName, tc.pass)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	bot := &github.User{Login: fakegithub.Bot}
+	cfg := &config.Config{
+		JobConfig: config.JobConfig{Periodics: []config.Periodic{
+			{JobBase: config.JobBase{Name: "failing", Annotations: map[string]string{kube.OwnerAnnotation: "sig-testing"}}},
+			{JobBase: config.JobBase{Name: "flaky"}},
+			{JobBase: config.JobBase{Name: "recovered"}},
+			{JobBase: config.JobBase{Name: "still-failing"}},
+		}},
+		ProwConfig: config.ProwConfig{
+			FailingPeriodics: config.FailingPeriodics{
+				Repo:      "org/repo",
+				Labels:    []string{"kind/failing-test"},
+				Assignees: map[string][]string{"sig-testing": {"alice"}},
+			},
+			Deck: config.Deck{Spyglass: config.Spyglass{KnownFailurePatterns: []config.KnownFailurePattern{
+				{Name: "image-pull", Regex: "ErrImagePull", Explanation: "The image could not be pulled."},
+			}}},
+		},
+	}
+	var runs []runtime.Object
+	for _, pj := range []prowapi.ProwJob{
+		runOf("failing", "f1", prowapi.FailureState, 1),
+		runOf("failing", "f2", prowapi.ErrorState, 2),
+		runOf("failing", "f3", prowapi.FailureState, 3),
+		runOf("flaky", "k1", prowapi.FailureState, 1),
+		runOf("flaky", "k2", prowapi.SuccessState, 2),
+		runOf("flaky", "k3", prowapi.FailureState, 3),
+		runOf("recovered", "r1", prowapi.FailureState, 1),
+		runOf("recovered", "r2", prowapi.SuccessState, 2),
+		runOf("still-failing", "s1", prowapi.FailureState, 1),
+		runOf("still-failing", "s2", prowapi.FailureState, 2),
+		runOf("still-failing", "s3", prowapi.FailureState, 3),
+		runOf("still-failing", "s4", prowapi.FailureState, 4),
+	} {
+		pj := pj
+		runs = append(runs, &pj)
+	}
+	pjc := fake.NewSimpleClientset(runs...).ProwV1().ProwJobs("prowjobs")
+	opener := fakeOpener{buildLogs: map[string]string{
+		"f1": "pulling\nFailed: ErrImagePull\n",
+		"f3": "FAIL: TestSomething\n",
+	}}
+
+	ghc := fakegithub.NewFakeClient()
+	ghc.Issues = map[int]*github.Issue{
+		1: {Number: 1, User: *bot, Title: title("recovered"), Body: "...\n<!-- failing-periodics: recovered -->\n"},
+		2: {Number: 2, User: *bot, Title: title("still-failing"), Body: "...\n<!-- failing-periodics: still-failing -->\n"},
+		3: {Number: 3, User: github.User{Login: "someone"}, Title: "Flaky job", Body: "<!-- failing-periodics: flaky -->"},
+	}
+	ghc.IssueID = 3
+
+	c := NewController(ghc, pjc, opener, func() *config.Config { return cfg }, false)
+	if err := c.Sync(); err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+
+	if got := ghc.Issues[1].State; got != "closed" {
+		t.Errorf("issue of the recovered job is %q, want closed", got)
+	}
+	if diff := cmp.Diff([]string{"The periodic job `recovered` passed again in [its latest run](https://prow/view/r2), closing."}, commentBodies(ghc.IssueComments[1])); diff != "" {
+		t.Errorf("unexpected comments on the issue of the recovered job (-want +got):\n%s", diff)
+	}
+	if body := ghc.Issues[2].Body; !strings.Contains(body, "failed 4 times in a row") || !strings.Contains(body, "<!-- failing-periodics: still-failing -->") {
+		t.Errorf("issue of the still failing job was not updated:\n%s", body)
+	}
+	if ghc.Issues[3].State == "closed" {
+		t.Error("issue not opened by the bot was closed")
+	}
+
+	opened, ok := ghc.Issues[4]
+	if !ok {
+		t.Fatalf("no issue opened for the failing job, got issues %v", ghc.Issues)
+	}
+	if len(ghc.Issues) != 4 {
+		t.Errorf("got %d issues, want 4", len(ghc.Issues))
+	}
+	if opened.Title != title("failing") {
+		t.Errorf("got title %q, want %q", opened.Title, title("failing"))
+	}
+	if diff := cmp.Diff([]github.User{{Name: "alice"}}, opened.Assignees); diff != "" {
+		t.Errorf("unexpected assignees (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]github.Label{{Name: "kind/failing-test"}}, opened.Labels); diff != "" {
+		t.Errorf("unexpected labels (-want +got):\n%s", diff)
+	}
+	expected := "The periodic job `failing` failed 3 times in a row, most recently at 2024-01-02 06:01 UTC.\n" +
+		"\n" +
+		"**Owner:** sig-testing\n" +
+		"\n" +
+		"### Failure clusters\n" +
+		"\n" +
+		"| Class | Failures | Explanation |\n" +
+		"| --- | --- | --- |\n" +
+		"| `error` | 1 | The job errored before it could report a result, e.g. because its pod could not start. |\n" +
+		"| `image-pull` | 1 | The image could not be pulled. |\n" +
+		"| `unclassified` | 1 | The build log matches no known failure pattern. |\n" +
+		"\n" +
+		"### Recent failures\n" +
+		"\n" +
+		"* [2024-01-02 06:01 UTC](https://prow/view/f3): `unclassified`\n" +
+		"* [2024-01-02 05:01 UTC](https://prow/view/f2): `error`\n" +
+		"* [2024-01-02 04:01 UTC](https://prow/view/f1): `image-pull`\n" +
+		"\n" +
+		"This issue is kept up to date while the job keeps failing, and closed once it passes again.\n" +
+		"<!-- failing-periodics: failing -->\n"
+	if diff := cmp.Diff(expected, opened.Body); diff != "" {
+		t.Errorf("unexpected body (-want +got):\n%s", diff)
+	}
+}
+
+func commentBodies(comments []github.IssueComment) []string {
+	var bodies []string
+	for _, comment := range comments {
+		bodies = append(bodies, comment.Body)
+	}
+	return bodies
+}
@@ -67,6 +67,11 @@ func (o *BugzillaOptions) Validate(dryRun bool) error {
 	return nil
 }
 
+// Configured returns whether a Bugzilla endpoint was provided.
+func (o *BugzillaOptions) Configured() bool {
+	return o.endpoint != ""
+}
+
 // BugzillaClient returns a Bugzilla client.
 func (o *BugzillaOptions) BugzillaClient() (bugzilla.Client, error) {
 	if o.endpoint == "" {
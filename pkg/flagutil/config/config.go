@@ -17,11 +17,18 @@ limitations under the License.
 package flagutil
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
+	"os"
+	"time"
 
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/config/source"
 	"sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/prow/pkg/interrupts"
 )
 
 const (
@@ -46,6 +53,13 @@ type ConfigOptions struct {
 	// Moonraker is the centralized Inrepconfig Caching Service. Using this flag
 	// overrides the use of the local InRepoConfigCache.
 	MoonrakerAddress string
+	// JobConfigSource is an OCI artifact or git ref to load the job config
+	// from instead of JobConfigPath.
+	JobConfigSource         string
+	JobConfigSourceDir      string
+	JobConfigSourceInterval time.Duration
+
+	jobConfigSyncer *source.Syncer
 }
 
 func (o *ConfigOptions) AddFlags(fs *flag.FlagSet) {
@@ -63,12 +77,26 @@ func (o *ConfigOptions) AddFlags(fs *flag.FlagSet) {
 	fs.IntVar(&o.InRepoConfigCacheSize, "in-repo-config-cache-size", 200, "Cache size for ProwYAMLs read from in-repo configs.")
 	fs.StringVar(&o.InRepoConfigCacheDirBase, "cache-dir-base", "", "Directory where the repo cache should be mounted.")
 	fs.StringVar(&o.MoonrakerAddress, "moonraker-address", "", "full HTTP address (domain and port) of moonraker service")
+	fs.StringVar(&o.JobConfigSource, "job-config-source", "", "Location to load the job configuration from instead of --"+o.JobConfigPathFlagName+": an OCI artifact as oci://<reference>, or a directory of a git ref as git+<URL>@<ref>[#<path>].")
+	fs.StringVar(&o.JobConfigSourceDir, "job-config-source-dir", "", "Directory to keep the job configuration loaded from --job-config-source in. Defaults to a temporary directory.")
+	fs.DurationVar(&o.JobConfigSourceInterval, "job-config-source-interval", 0, "How often to check --job-config-source for changes. Defaults to a minute.")
 }
 
 func (o *ConfigOptions) Validate(_ bool) error {
 	if o.ConfigPath == "" {
 		return fmt.Errorf("--%s is mandatory", o.ConfigPathFlagName)
 	}
+	if o.JobConfigSource != "" {
+		if o.JobConfigPath != "" {
+			return fmt.Errorf("--%s and --job-config-source are mutually exclusive", o.JobConfigPathFlagName)
+		}
+		if o.JobConfigSourceInterval < 0 {
+			return errors.New("--job-config-source-interval must not be negative")
+		}
+		if _, err := source.Parse(o.JobConfigSource); err != nil {
+			return fmt.Errorf("invalid --job-config-source: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -92,5 +120,48 @@ func (o *ConfigOptions) ConfigAgent(reuse ...*config.Agent) (*config.Agent, erro
 }
 
 func (o *ConfigOptions) ConfigAgentWithAdditionals(ca *config.Agent, additionals []func(*config.Config) error) (*config.Agent, error) {
+	if o.JobConfigSource != "" && o.jobConfigSyncer == nil {
+		if err := o.startJobConfigSyncer(); err != nil {
+			return nil, err
+		}
+	}
 	return ca, ca.Start(o.ConfigPath, o.JobConfigPath, o.SupplementalProwConfigDirs.Strings(), o.SupplementalProwConfigsFileNameSuffix, additionals...)
 }
+
+// startJobConfigSyncer fetches the job config from its source and keeps it
+// up to date in the background, loading it from the local copy.
+func (o *ConfigOptions) startJobConfigSyncer() error {
+	dir := o.JobConfigSourceDir
+	if dir == "" {
+		var err error
+		if dir, err = os.MkdirTemp("", "job-config-source"); err != nil {
+			return fmt.Errorf("failed to create a directory for --job-config-source: %w", err)
+		}
+	}
+	syncer, err := source.NewSyncer(o.JobConfigSource, dir)
+	if err != nil {
+		return fmt.Errorf("invalid --job-config-source: %w", err)
+	}
+	if err := syncer.Sync(context.Background()); err != nil {
+		return fmt.Errorf("failed to fetch --job-config-source=%s: %w", o.JobConfigSource, err)
+	}
+	interval := o.JobConfigSourceInterval
+	if interval == 0 {
+		interval = time.Minute
+	}
+	interrupts.Run(func(ctx context.Context) {
+		syncer.Run(ctx, interval)
+	})
+	o.jobConfigSyncer = syncer
+	o.JobConfigPath = syncer.Path()
+	return nil
+}
+
+// JobConfigSourceInvalidator returns a handler that makes the job config be
+// fetched from --job-config-source right away, or nil if it is not set.
+func (o *ConfigOptions) JobConfigSourceInvalidator() http.Handler {
+	if o.jobConfigSyncer == nil {
+		return nil
+	}
+	return o.jobConfigSyncer
+}
@@ -65,6 +65,11 @@ func (o *JiraOptions) Validate(_ bool) error {
 	return nil
 }
 
+// Configured returns whether a Jira endpoint was provided.
+func (o *JiraOptions) Configured() bool {
+	return o.endpoint != ""
+}
+
 func (o *JiraOptions) Client() (jira.Client, error) {
 	if o.endpoint == "" {
 		return nil, errors.New("empty --jira-endpoint, can not create a client")
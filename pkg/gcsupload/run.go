@@ -18,6 +18,8 @@ package gcsupload
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -28,8 +30,10 @@ import (
 	"strings"
 
 	"github.com/sirupsen/logrus"
+	utilpointer "k8s.io/utils/pointer"
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	pkgio "sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
 )
@@ -40,19 +44,60 @@ import (
 // to their destination in GCS, so the caller can
 // operate relative to the base of the GCS dir.
 func (o Options) Run(ctx context.Context, spec *downwardapi.JobSpec, extra map[string]gcs.UploadFunc) error {
+	return o.run(ctx, spec, func(*UploadReport) map[string]gcs.UploadFunc { return extra }, false)
+}
+
+// UploadReportName is the name of the report of the files that failed to
+// upload, which is uploaded next to the extra files.
+const UploadReportName = "upload-report.json"
+
+// UploadReport lists the files that failed to upload despite retries.
+type UploadReport struct {
+	Failed []gcs.FailedUpload `json:"failed"`
+}
+
+// RunWithReport uploads files like Run, except that the extra files, which
+// are uploaded after all others, are created from the report of the files
+// that failed to upload, which is nil if all were uploaded. The report is
+// uploaded along with them as UploadReportName.
+func (o Options) RunWithReport(ctx context.Context, spec *downwardapi.JobSpec, extraFor func(report *UploadReport) map[string]gcs.UploadFunc) error {
+	return o.run(ctx, spec, extraFor, true)
+}
+
+func (o Options) run(ctx context.Context, spec *downwardapi.JobSpec, extraFor func(report *UploadReport) map[string]gcs.UploadFunc, withReport bool) error {
 	logrus.WithField("options", o).Debug("Uploading to blob storage")
 
 	for extension, mediaType := range o.GCSConfiguration.MediaTypes {
 		mime.AddExtensionType("."+extension, mediaType)
 	}
 
-	uploadTargets, extraTargets, err := o.assembleTargets(spec, extra)
+	uploadTargets, _, err := o.assembleTargets(spec, nil)
 	if err != nil {
 		return fmt.Errorf("assembleTargets: %w", err)
 	}
 
 	err = completeUpload(ctx, o, uploadTargets)
 
+	var report *UploadReport
+	var uploadErr *gcs.UploadError
+	if errors.As(err, &uploadErr) {
+		report = &UploadReport{Failed: uploadErr.Failures}
+	}
+	extra := extraFor(report)
+	if withReport && report != nil {
+		content, marshalErr := json.Marshal(report)
+		if marshalErr != nil {
+			return fmt.Errorf("failed to marshal upload report: %w", marshalErr)
+		}
+		withUploadReport := make(map[string]gcs.UploadFunc, len(extra)+1)
+		for destination, upload := range extra {
+			withUploadReport[destination] = upload
+		}
+		withUploadReport[UploadReportName] = gcs.DataUploadWithOptions(newStringReadCloser(string(content)), pkgio.WriterOptions{ContentType: utilpointer.String("application/json")})
+		extra = withUploadReport
+	}
+	extraTargets := o.extraTargets(spec, extra)
+
 	if extraErr := completeUpload(ctx, o, extraTargets); extraErr != nil {
 		if err == nil {
 			err = extraErr
@@ -73,7 +118,14 @@ func completeUpload(ctx context.Context, o Options, uploadTargets map[string]gcs
 	}
 
 	if o.LocalOutputDir == "" {
-		if err := gcs.Upload(ctx, o.Bucket, o.StorageClientOptions.GCSCredentialsFile, o.StorageClientOptions.S3CredentialsFile, o.CompressFileTypes, uploadTargets); err != nil {
+		uploadOptions := gcs.UploadOptions{
+			CompressFileTypes:  o.CompressFileTypes,
+			CompressionRules:   o.CompressionRules,
+			DetectContentTypes: o.DetectContentTypes != nil && *o.DetectContentTypes,
+			Encryption:         o.Encryption,
+			Retry:              retryPolicy(o.UploadRetry),
+		}
+		if err := gcs.UploadWithOptions(ctx, o.Bucket, o.StorageClientOptions.GCSCredentialsFile, o.StorageClientOptions.S3CredentialsFile, uploadOptions, uploadTargets); err != nil {
 			return fmt.Errorf("failed to upload to blob storage: %w", err)
 		}
 		logrus.Info("Finished upload to blob storage")
@@ -86,6 +138,20 @@ func completeUpload(ctx context.Context, o Options, uploadTargets map[string]gcs
 	return nil
 }
 
+// retryPolicy returns the policy of the configured upload retries.
+func retryPolicy(retry *prowapi.UploadRetry) gcs.RetryPolicy {
+	if retry == nil {
+		return gcs.RetryPolicy{}
+	}
+	return gcs.RetryPolicy{
+		Attempts:           retry.Attempts,
+		InitialBackoff:     retry.InitialBackoff.Get(),
+		MaxBackoff:         retry.MaxBackoff.Get(),
+		ChunkSize:          retry.ChunkSize,
+		ChunkRetryDeadline: retry.ChunkRetryDeadline.Get(),
+	}
+}
+
 func (o Options) assembleTargets(spec *downwardapi.JobSpec, extra map[string]gcs.UploadFunc) (map[string]gcs.UploadFunc, map[string]gcs.UploadFunc, error) {
 	jobBasePath, blobStoragePath, builder := PathsForJob(o.GCSConfiguration, spec, o.SubDir)
 
@@ -127,6 +193,11 @@ func (o Options) assembleTargets(spec *downwardapi.JobSpec, extra map[string]gcs
 		blobStoragePath = ""
 	}
 
+	// Artifacts stored by their content are referenced by a manifest.
+	var contentAddressed map[string]string
+	if o.ContentAddressed != nil && o.LocalOutputDir == "" {
+		contentAddressed = map[string]string{}
+	}
 	for _, item := range o.Items {
 		info, err := os.Stat(item)
 		if err != nil {
@@ -134,7 +205,7 @@ func (o Options) assembleTargets(spec *downwardapi.JobSpec, extra map[string]gcs
 			continue
 		}
 		if info.IsDir() {
-			gatherArtifacts(item, blobStoragePath, info.Name(), uploadTargets)
+			gatherArtifacts(item, blobStoragePath, info.Name(), uploadTargets, contentAddressed, o.storeByContent)
 		} else {
 			metadataFromFileName, writerOptions := gcs.WriterOptionsFromFileName(info.Name())
 			destination := path.Join(blobStoragePath, metadataFromFileName)
@@ -146,16 +217,29 @@ func (o Options) assembleTargets(spec *downwardapi.JobSpec, extra map[string]gcs
 		}
 	}
 
+	if len(contentAddressed) > 0 {
+		uploadTargets[path.Join(blobStoragePath, gcs.ContentAddressedManifestName)] = gcs.ContentAddressedUpload(o.ContentAddressed, contentAddressed)
+	}
+
+	return uploadTargets, o.extraTargets(spec, extra), nil
+}
+
+// extraTargets roots the destinations of the extra files at the path of the
+// job in blob storage, or in the output dir in local mode.
+func (o Options) extraTargets(spec *downwardapi.JobSpec, extra map[string]gcs.UploadFunc) map[string]gcs.UploadFunc {
 	if len(extra) == 0 {
-		return uploadTargets, nil, nil
+		return nil
+	}
+	var blobStoragePath string
+	if o.LocalOutputDir == "" {
+		_, blobStoragePath, _ = PathsForJob(o.GCSConfiguration, spec, o.SubDir)
 	}
 
 	extraTargets := make(map[string]gcs.UploadFunc, len(extra))
 	for destination, upload := range extra {
 		extraTargets[path.Join(blobStoragePath, destination)] = upload
 	}
-
-	return uploadTargets, extraTargets, nil
+	return extraTargets
 }
 
 // PathsForJob determines the following for a job:
@@ -194,7 +278,19 @@ func builderForStrategy(strategy, defaultOrg, defaultRepo string) gcs.RepoPathBu
 	return builder
 }
 
-func gatherArtifacts(artifactDir, blobStoragePath, subDir string, uploadTargets map[string]gcs.UploadFunc) {
+// storeByContent returns whether the artifact uploaded to dest is stored by
+// its content. Encrypted and compressed artifacts are uploaded as usual.
+func (o Options) storeByContent(dest string, info os.FileInfo, writerOptions pkgio.WriterOptions) bool {
+	if o.Encryption != nil && pkgio.MatchesEncryptionPatterns(o.Encryption.Patterns, dest) {
+		return false
+	}
+	return writerOptions.ContentEncoding == nil && info.Size() >= o.ContentAddressed.GetMinSize()
+}
+
+// gatherArtifacts adds the uploads of the files in artifactDir to
+// uploadTargets. If contentAddressed is not nil, the files that storeByContent
+// accepts are added to it instead, keyed by their path under blobStoragePath.
+func gatherArtifacts(artifactDir, blobStoragePath, subDir string, uploadTargets map[string]gcs.UploadFunc, contentAddressed map[string]string, storeByContent func(string, os.FileInfo, pkgio.WriterOptions) bool) {
 	logrus.Printf("Gathering artifacts from artifact directory: %s", artifactDir)
 	filepath.Walk(artifactDir, func(fspath string, info os.FileInfo, err error) error {
 		if info == nil || info.IsDir() {
@@ -206,6 +302,7 @@ func gatherArtifacts(artifactDir, blobStoragePath, subDir string, uploadTargets
 		// this error as we can be certain it won't occur and best-
 		// effort upload is OK in any case
 		if relPath, err := filepath.Rel(artifactDir, fspath); err == nil {
+			relPath = filepath.ToSlash(relPath)
 			dir, filename := path.Split(path.Join(blobStoragePath, subDir, relPath))
 			metadataFromFileName, writerOptions := gcs.WriterOptionsFromFileName(filename)
 			destination := escapeFileName(path.Join(dir, metadataFromFileName))
@@ -213,6 +310,11 @@ func gatherArtifacts(artifactDir, blobStoragePath, subDir string, uploadTargets
 				logrus.Warnf("Encountered duplicate upload of %s, skipping...", destination)
 				return nil
 			}
+			if contentAddressed != nil && storeByContent(destination, info, writerOptions) {
+				logrus.Printf("Found %s in artifact directory. Storing by content as %s\n", fspath, destination)
+				contentAddressed[strings.TrimPrefix(destination, blobStoragePath+"/")] = fspath
+				return nil
+			}
 			logrus.Printf("Found %s in artifact directory. Uploading as %s\n", fspath, destination)
 			uploadTargets[destination] = gcs.FileUploadWithOptions(fspath, writerOptions)
 		} else {
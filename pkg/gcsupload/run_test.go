@@ -17,6 +17,9 @@ limitations under the License.
 package gcsupload
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"io"
 	"os"
 	"path"
@@ -251,6 +254,116 @@ func TestOptions_AssembleTargets(t *testing.T) {
 	}
 }
 
+func TestAssembleTargetsContentAddressed(t *testing.T) {
+	tmpDir := t.TempDir()
+	artifacts := path.Join(tmpDir, "artifacts")
+	for name, size := range map[string]int{
+		"binary":            2048,
+		"fixtures/data.bin": 1024,
+		"small.txt":         10,
+		"admin.kubeconfig":  2048,
+		"log.txt.gz":        2048,
+	} {
+		if err := os.MkdirAll(path.Dir(path.Join(artifacts, name)), 0755); err != nil {
+			t.Fatalf("could not create test directory: %v", err)
+		}
+		if err := os.WriteFile(path.Join(artifacts, name), bytes.Repeat([]byte("a"), size), 0644); err != nil {
+			t.Fatalf("could not create test file: %v", err)
+		}
+	}
+	options := Options{
+		Items: []string{artifacts},
+		GCSConfiguration: &prowapi.GCSConfiguration{
+			PathStrategy:     prowapi.PathStrategyExplicit,
+			Bucket:           "bucket",
+			Encryption:       &prowapi.ArtifactEncryption{KeyURL: "base64key://", Patterns: []string{"*.kubeconfig"}},
+			ContentAddressed: &prowapi.ContentAddressedStorage{MinSize: 1024},
+		},
+	}
+	spec := &downwardapi.JobSpec{Job: "job", Type: prowapi.PeriodicJob, BuildID: "build"}
+
+	targets, _, err := options.assembleTargets(spec, nil)
+	if err != nil {
+		t.Fatalf("assembleTargets() error = %v", err)
+	}
+	want := sets.New[string](
+		"logs/job/latest-build.txt",
+		"logs/job/build/artifacts/small.txt",
+		"logs/job/build/artifacts/admin.kubeconfig",
+		"logs/job/build/artifacts/log.txt",
+		"logs/job/build/cas-manifest.json",
+	)
+	got := sets.New[string]()
+	for uploadPath := range targets {
+		got.Insert(uploadPath)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("assembleTargets() got unexpected target diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunWithReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	artifacts := path.Join(tmpDir, "artifacts")
+	if err := os.MkdirAll(artifacts, 0755); err != nil {
+		t.Fatalf("could not create test directory: %v", err)
+	}
+	if err := os.WriteFile(path.Join(artifacts, "ok.txt"), []byte("ok"), 0644); err != nil {
+		t.Fatalf("could not create test file: %v", err)
+	}
+	// The artifact is found, but cannot be read when it is uploaded.
+	if err := os.Symlink(path.Join(tmpDir, "missing"), path.Join(artifacts, "broken.txt")); err != nil {
+		t.Fatalf("could not create test symlink: %v", err)
+	}
+	bucket := path.Join(tmpDir, "bucket")
+	options := Options{
+		Items: []string{artifacts},
+		GCSConfiguration: &prowapi.GCSConfiguration{
+			PathStrategy: prowapi.PathStrategyExplicit,
+			Bucket:       "file://" + bucket,
+			UploadRetry:  &prowapi.UploadRetry{Attempts: 1},
+		},
+	}
+	spec := &downwardapi.JobSpec{Job: "job", Type: prowapi.PeriodicJob, BuildID: "build"}
+
+	var report *UploadReport
+	err := options.RunWithReport(context.Background(), spec, func(r *UploadReport) map[string]gcs.UploadFunc {
+		report = r
+		return map[string]gcs.UploadFunc{
+			prowapi.FinishedStatusFile: gcs.DataUpload(newStringReadCloser("{}")),
+		}
+	})
+	if err == nil {
+		t.Error("expected an error for the artifact that failed to upload")
+	}
+	if report == nil {
+		t.Fatal("expected a report of the artifact that failed to upload")
+	}
+	if n := len(report.Failed); n != 1 {
+		t.Fatalf("expected one failed upload, got %d: %v", n, report.Failed)
+	}
+	if failed := report.Failed[0]; failed.Dest != "logs/job/build/artifacts/broken.txt" || failed.Attempts != 1 || failed.Error == "" {
+		t.Errorf("unexpected failed upload: %+v", failed)
+	}
+
+	for _, uploaded := range []string{"artifacts/ok.txt", prowapi.FinishedStatusFile, UploadReportName} {
+		if _, err := os.Stat(path.Join(bucket, "logs/job/build", uploaded)); err != nil {
+			t.Errorf("expected %s to be uploaded: %v", uploaded, err)
+		}
+	}
+	content, err := os.ReadFile(path.Join(bucket, "logs/job/build", UploadReportName))
+	if err != nil {
+		t.Fatalf("failed to read the upload report: %v", err)
+	}
+	var uploaded UploadReport
+	if err := json.Unmarshal(content, &uploaded); err != nil {
+		t.Fatalf("failed to unmarshal the upload report: %v", err)
+	}
+	if diff := cmp.Diff(*report, uploaded); diff != "" {
+		t.Errorf("unexpected upload report (-want +got):\n%s", diff)
+	}
+}
+
 func TestBuilderForStrategy(t *testing.T) {
 	type info struct {
 		org, repo string
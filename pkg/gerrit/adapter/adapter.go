@@ -59,6 +59,7 @@ var gerritMetrics = struct {
 	gerritRepoQueryDuration     *prometheus.HistogramVec
 	pickupChangeLatency         *prometheus.HistogramVec
 	jobCreationDuration         *prometheus.HistogramVec
+	streamedEvents              *prometheus.CounterVec
 }{
 	processingResults: prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "gerrit_processing_results",
@@ -131,6 +132,13 @@ var gerritMetrics = struct {
 		"org",
 		"repo",
 	}),
+	streamedEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gerrit_streamed_events",
+		Help: "Count of events received from the event stream by instance and result (synced, duplicate or ignored).",
+	}, []string{
+		"org",
+		"result",
+	}),
 }
 
 func init() {
@@ -144,6 +152,7 @@ func init() {
 	prometheus.MustRegister(gerritMetrics.gerritRepoQueryDuration)
 	prometheus.MustRegister(gerritMetrics.pickupChangeLatency)
 	prometheus.MustRegister(gerritMetrics.jobCreationDuration)
+	prometheus.MustRegister(gerritMetrics.streamedEvents)
 }
 
 type prowJobClient interface {
@@ -158,6 +167,7 @@ type gerritClient interface {
 	SetReview(instance, id, revision, message string, labels map[string]string) error
 	Account(instance string) (*gerrit.AccountInfo, error)
 	HasRelatedChanges(instance, id, revision string) (bool, error)
+	EventsSince(instance string, since time.Time) ([]client.Event, error)
 }
 
 // Controller manages gerrit changes.
@@ -175,6 +185,13 @@ type Controller struct {
 	projectsWithWorker          map[string]bool
 	latestMux                   sync.Mutex
 	workerPoolSize              int
+
+	ctx context.Context
+	// kicks wake up the worker of a project to sync it before its next poll.
+	kicks map[string]chan struct{}
+	// streaming holds the instances whose events are being streamed.
+	streaming      map[string]bool
+	newEventSource func(config.GerritEventStream) eventSource
 }
 
 type LastSyncTracker interface {
@@ -212,7 +229,11 @@ func NewController(ctx context.Context, prowJobClient prowv1.ProwJobInterface, o
 		inRepoConfigFailuresTracker: map[string]bool{},
 		projectsWithWorker:          make(map[string]bool),
 		workerPoolSize:              workerPoolSize,
+		ctx:                         ctx,
+		kicks:                       map[string]chan struct{}{},
+		streaming:                   map[string]bool{},
 	}
+	c.newEventSource = c.eventSource
 
 	// applyGlobalConfig reads gerrit configurations from global gerrit config,
 	// it will completely override previously configured gerrit hosts and projects.
@@ -221,6 +242,7 @@ func NewController(ctx context.Context, prowJobClient prowv1.ProwJobInterface, o
 		return cfg().Gerrit.OrgReposConfig
 	}
 	c.gc.ApplyGlobalConfig(orgRepoConfigGetter, lastSyncTracker, cookiefilePath, tokenPathOverride, func() {
+		gerritClient.SetQueryParallelism(cfg().Gerrit.QueryParallelism)
 		orgReposConfig := orgRepoConfigGetter()
 		if orgReposConfig == nil {
 			return
@@ -363,16 +385,21 @@ func checkAndLogQuery(log *logrus.Entry, changes []gerrit.ChangeInfo) {
 	log.Infof("Query returned changes: %v", seen.List())
 }
 
+func projectID(instance, project string) string {
+	return fmt.Sprintf("%s/%s", instance, project)
+}
+
 // Sync looks for newly made gerrit changes
 // and creates prowjobs according to specs
 func (c *Controller) Sync() {
+	c.startEventStreams()
+
 	// Identify projects without worker threads
-	id := func(instance, project string) string { return fmt.Sprintf("%s/%s", instance, project) }
 	needsWorker := map[string][]string{}
 	needsWorkerCount := map[string]int{}
 	for instance, projects := range c.config().Gerrit.OrgReposConfig.AllRepos() {
 		for project := range projects {
-			if _, ok := c.projectsWithWorker[id(instance, project)]; ok {
+			if _, ok := c.projectsWithWorker[projectID(instance, project)]; ok {
 				// The worker thread is already up for this project, nothing needs
 				// to be done.
 				continue
@@ -386,7 +413,11 @@ func (c *Controller) Sync() {
 	for instance, projects := range needsWorker {
 		staggerIncrement := c.config().Gerrit.TickInterval.Duration / time.Duration(needsWorkerCount[instance])
 		for _, project := range projects {
-			c.projectsWithWorker[id(instance, project)] = true
+			c.projectsWithWorker[projectID(instance, project)] = true
+			kick := make(chan struct{}, 1)
+			c.lock.Lock()
+			c.kicks[projectID(instance, project)] = kick
+			c.lock.Unlock()
 			logrus.WithFields(logrus.Fields{"instance": instance, "repo": project}).Info("Starting worker for project.")
 			go func(instance, project string, staggerPosition int) {
 				// Stagger new worker threads across the loop period to reduce load on the Gerrit API and Git server.
@@ -398,7 +429,10 @@ func (c *Controller) Sync() {
 				for {
 					timeDiff := time.Until(previousRun.Add(c.config().Gerrit.TickInterval.Duration))
 					if timeDiff > 0 {
-						time.Sleep(timeDiff)
+						select {
+						case <-time.After(timeDiff):
+						case <-kick:
+						}
 					}
 					previousRun = time.Now()
 					c.processSingleProject(instance, project)
@@ -409,6 +443,24 @@ func (c *Controller) Sync() {
 	}
 }
 
+// startEventStreams starts streaming the events of the instances that are not
+// streamed yet, if the event stream is enabled.
+func (c *Controller) startEventStreams() {
+	if c.config().Gerrit.EventStream == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for instance := range c.config().Gerrit.OrgReposConfig.AllRepos() {
+		if c.streaming[instance] {
+			continue
+		}
+		c.streaming[instance] = true
+		logrus.WithField("instance", instance).Info("Starting event stream for instance.")
+		go c.streamEvents(instance)
+	}
+}
+
 // CreateRefs creates refs for a presubmit job from given changes.
 //
 // Passed in instance must contain https:// prefix.
@@ -77,6 +77,10 @@ func (f *fgc) HasRelatedChanges(instance, id, revision string) (bool, error) {
 	return false, nil
 }
 
+func (f *fgc) EventsSince(instance string, since time.Time) ([]client.Event, error) {
+	return nil, nil
+}
+
 func (f *fgc) ApplyGlobalConfig(orgRepoConfigGetter func() *config.GerritOrgRepoConfigs, lastSyncTracker *client.SyncTime, cookiefilePath, tokenPathOverride string, additionalFunc func()) {
 
 }
@@ -0,0 +1,260 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/gerrit/client"
+	"sigs.k8s.io/prow/pkg/gerrit/source"
+)
+
+const maxStreamBackoff = time.Minute
+
+// streamedEventTypes are the events that can make the adapter trigger jobs
+// for a change. Other events are not worth syncing a project for.
+var streamedEventTypes = sets.New[string](
+	"patchset-created",
+	"comment-added",
+	"change-merged",
+	"change-restored",
+	"wip-state-changed",
+	"private-state-changed",
+)
+
+// eventSource streams the events of a Gerrit instance.
+type eventSource interface {
+	// Stream calls handle for the events of instance that happen from since
+	// on, until ctx is done or the stream breaks.
+	Stream(ctx context.Context, instance string, since time.Time, handle func(client.Event)) error
+}
+
+// sshEventSource runs `gerrit stream-events` over SSH. The command cannot
+// replay events, so since is ignored and events that happen while it is not
+// connected are only picked up by the next poll.
+type sshEventSource struct {
+	stream  config.GerritEventStream
+	command func(ctx context.Context, name string, args ...string) *exec.Cmd
+}
+
+func (s *sshEventSource) args(instance string) []string {
+	host := source.TrimHTTPSPrefix(instance)
+	if s.stream.SSHUser != "" {
+		host = s.stream.SSHUser + "@" + host
+	}
+	args := []string{"-p", strconv.Itoa(s.stream.SSHPort), "-o", "BatchMode=yes", "-o", "ServerAliveInterval=30"}
+	if s.stream.SSHIdentityFile != "" {
+		args = append(args, "-i", s.stream.SSHIdentityFile)
+	}
+	args = append(args, host, "gerrit", "stream-events")
+	for _, eventType := range sets.List(streamedEventTypes) {
+		args = append(args, "-s", eventType)
+	}
+	return args
+}
+
+func (s *sshEventSource) Stream(ctx context.Context, instance string, _ time.Time, handle func(client.Event)) error {
+	cmd := s.command(ctx, "ssh", s.args(instance)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get the output of ssh: %w", err)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ssh: %w", err)
+	}
+	readErr := client.ReadEvents(stdout, handle)
+	waitErr := cmd.Wait()
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case readErr != nil:
+		return readErr
+	case waitErr != nil:
+		return fmt.Errorf("ssh failed: %w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return errors.New("event stream closed by the server")
+}
+
+type eventsGetter interface {
+	EventsSince(instance string, since time.Time) ([]client.Event, error)
+}
+
+// eventsLogSource long-polls the REST API of the events-log plugin. The
+// plugin keeps the events, so nothing is lost while the adapter is down.
+type eventsLogSource struct {
+	gc       eventsGetter
+	interval time.Duration
+}
+
+func (s *eventsLogSource) Stream(ctx context.Context, instance string, since time.Time, handle func(client.Event)) error {
+	for {
+		events, err := s.gc.EventsSince(instance, since)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			handle(event)
+			if created := event.Created(); created.After(since) {
+				since = created
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+func (c *Controller) eventSource(stream config.GerritEventStream) eventSource {
+	if stream.Source == config.GerritEventStreamEventsLog {
+		return &eventsLogSource{gc: c.gc, interval: stream.PollInterval.Duration}
+	}
+	return &sshEventSource{stream: stream, command: exec.CommandContext}
+}
+
+// eventTracker deduplicates the events of an instance and checkpoints the
+// time of the newest one, which is where the stream resumes after breaking.
+type eventTracker struct {
+	checkpoint time.Time
+	// seen holds the keys of the events that happened at the checkpoint
+	// second or later, as those are delivered again on resume.
+	seen map[string]int64
+}
+
+func newEventTracker(checkpoint time.Time) *eventTracker {
+	return &eventTracker{checkpoint: checkpoint.Truncate(time.Second), seen: map[string]int64{}}
+}
+
+// observe returns whether the event is new, and records it if so.
+func (t *eventTracker) observe(event client.Event) bool {
+	key := event.Key()
+	if _, seen := t.seen[key]; seen {
+		return false
+	}
+	created := event.Created()
+	if created.Before(t.checkpoint) {
+		return false
+	}
+	t.seen[key] = event.EventCreatedOn
+	if created.After(t.checkpoint) {
+		t.checkpoint = created
+		for key, createdOn := range t.seen {
+			if createdOn < created.Unix() {
+				delete(t.seen, key)
+			}
+		}
+	}
+	return true
+}
+
+// checkpoint returns when the instance was last synced completely, which is
+// where its event stream starts from.
+func (c *Controller) checkpoint(instance string) time.Time {
+	var oldest time.Time
+	for _, synced := range c.tracker.Current()[instance] {
+		if oldest.IsZero() || synced.Before(oldest) {
+			oldest = synced
+		}
+	}
+	if oldest.IsZero() {
+		return time.Now()
+	}
+	return oldest
+}
+
+// kick makes the worker of the project sync it right away, and returns
+// whether the project has a worker. Kicks that arrive while the project is
+// already waiting to be synced are merged.
+func (c *Controller) kick(instance, project string) bool {
+	c.lock.RLock()
+	kick, ok := c.kicks[projectID(instance, project)]
+	c.lock.RUnlock()
+	if !ok {
+		return false
+	}
+	select {
+	case kick <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (c *Controller) handleEvent(log *logrus.Entry, instance string, tracker *eventTracker, event client.Event) {
+	result := "ignored"
+	switch {
+	case !streamedEventTypes.Has(event.Type):
+	case !tracker.observe(event):
+		result = "duplicate"
+	case c.kick(instance, event.Project()):
+		result = "synced"
+		log.WithFields(logrus.Fields{"repo": event.Project(), "type": event.Type}).Debug("Syncing project for event.")
+	}
+	gerritMetrics.streamedEvents.WithLabelValues(instance, result).Inc()
+}
+
+// streamEvents consumes the events of the instance until the event stream is
+// disabled, reconnecting with a backoff whenever the stream breaks.
+func (c *Controller) streamEvents(instance string) {
+	log := logrus.WithFields(logrus.Fields{"host": instance, "component": "event-stream"})
+	tracker := newEventTracker(c.checkpoint(instance))
+	backoff := time.Second
+	for reconnect := false; ; reconnect = true {
+		stream := c.config().Gerrit.EventStream
+		if stream == nil {
+			log.Info("Event stream disabled, stopping.")
+			c.lock.Lock()
+			delete(c.streaming, instance)
+			c.lock.Unlock()
+			return
+		}
+		if reconnect {
+			// Events might have been missed while the stream was down.
+			for project := range c.config().Gerrit.OrgReposConfig.AllRepos()[instance] {
+				c.kick(instance, project)
+			}
+		}
+		started := time.Now()
+		err := c.newEventSource(*stream).Stream(c.ctx, instance, tracker.checkpoint, func(event client.Event) {
+			c.handleEvent(log, instance, tracker, event)
+		})
+		if c.ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).Warn("Event stream broke, reconnecting.")
+		if time.Since(started) > maxStreamBackoff {
+			backoff = time.Second
+		}
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxStreamBackoff {
+			backoff = maxStreamBackoff
+		}
+	}
+}
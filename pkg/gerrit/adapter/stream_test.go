@@ -0,0 +1,213 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/gerrit/client"
+)
+
+func TestSSHEventSource(t *testing.T) {
+	testCases := []struct {
+		name           string
+		stream         config.GerritEventStream
+		output         string
+		fail           bool
+		expectedArgs   []string
+		expectedEvents []client.Event
+	}{
+		{
+			name:   "events are read until the stream closes",
+			stream: config.GerritEventStream{SSHUser: "prow", SSHPort: 29418, SSHIdentityFile: "/etc/ssh/id_rsa"},
+			output: `{"type":"patchset-created","change":{"project":"foo","number":1},"eventCreatedOn":100}
+{"type":"comment-added","change":{"project":"bar","number":2},"eventCreatedOn":101}
+`,
+			expectedArgs: []string{"-p", "29418", "-o", "BatchMode=yes", "-o", "ServerAliveInterval=30", "-i", "/etc/ssh/id_rsa", "prow@gerrit.example.com", "gerrit", "stream-events",
+				"-s", "change-merged", "-s", "change-restored", "-s", "comment-added", "-s", "patchset-created", "-s", "private-state-changed", "-s", "wip-state-changed"},
+			expectedEvents: []client.Event{
+				{Type: "patchset-created", EventCreatedOn: 100, Change: &client.EventChange{Project: "foo", Number: 1}},
+				{Type: "comment-added", EventCreatedOn: 101, Change: &client.EventChange{Project: "bar", Number: 2}},
+			},
+		},
+		{
+			name:   "failing ssh",
+			stream: config.GerritEventStream{SSHPort: 22},
+			fail:   true,
+			expectedArgs: []string{"-p", "22", "-o", "BatchMode=yes", "-o", "ServerAliveInterval=30", "gerrit.example.com", "gerrit", "stream-events",
+				"-s", "change-merged", "-s", "change-restored", "-s", "comment-added", "-s", "patchset-created", "-s", "private-state-changed", "-s", "wip-state-changed"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var args []string
+			source := &sshEventSource{
+				stream: tc.stream,
+				command: func(ctx context.Context, name string, a ...string) *exec.Cmd {
+					args = a
+					if tc.fail {
+						return exec.CommandContext(ctx, "false")
+					}
+					return exec.CommandContext(ctx, "printf", "%s", tc.output)
+				},
+			}
+			var events []client.Event
+			err := source.Stream(context.Background(), "https://gerrit.example.com", time.Time{}, func(e client.Event) { events = append(events, e) })
+			if err == nil {
+				t.Error("expected an error once the stream ends")
+			}
+			if diff := cmp.Diff(tc.expectedArgs, args); diff != "" {
+				t.Errorf("unexpected ssh arguments (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedEvents, events); diff != "" {
+				t.Errorf("unexpected events (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type fakeEventsGetter struct {
+	events [][]client.Event
+	since  []time.Time
+}
+
+func (f *fakeEventsGetter) EventsSince(instance string, since time.Time) ([]client.Event, error) {
+	f.since = append(f.since, since)
+	if len(f.events) == 0 {
+		return nil, errors.New("injected error")
+	}
+	events := f.events[0]
+	f.events = f.events[1:]
+	return events, nil
+}
+
+func TestEventsLogSource(t *testing.T) {
+	start := time.Unix(100, 0)
+	getter := &fakeEventsGetter{events: [][]client.Event{
+		{{Type: "patchset-created", EventCreatedOn: 100}, {Type: "comment-added", EventCreatedOn: 102}},
+		{},
+		{{Type: "comment-added", EventCreatedOn: 102}, {Type: "change-merged", EventCreatedOn: 105}},
+	}}
+	source := &eventsLogSource{gc: getter, interval: time.Millisecond}
+	var handled []int64
+	err := source.Stream(context.Background(), "https://gerrit.example.com", start, func(e client.Event) { handled = append(handled, e.EventCreatedOn) })
+	if err == nil {
+		t.Error("expected the injected error")
+	}
+	if diff := cmp.Diff([]int64{100, 102, 102, 105}, handled); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+	expectedSince := []time.Time{start, time.Unix(102, 0), time.Unix(102, 0), time.Unix(105, 0)}
+	if diff := cmp.Diff(expectedSince, getter.since); diff != "" {
+		t.Errorf("unexpected polls (-want +got):\n%s", diff)
+	}
+}
+
+func TestHandleEvent(t *testing.T) {
+	change := func(eventType, project string, number int, created int64) client.Event {
+		return client.Event{Type: eventType, EventCreatedOn: created, Change: &client.EventChange{Project: project, Number: number}}
+	}
+	testCases := []struct {
+		name           string
+		events         []client.Event
+		expectedKicked []string
+		expectedSeen   []string
+	}{
+		{
+			name:           "event kicks the project",
+			events:         []client.Event{change("patchset-created", "foo", 1, 100)},
+			expectedKicked: []string{"foo"},
+			expectedSeen:   []string{"patchset-created/foo/100/1"},
+		},
+		{
+			name: "irrelevant events and unknown projects are ignored",
+			events: []client.Event{
+				change("hashtags-changed", "foo", 1, 100),
+				change("patchset-created", "unknown", 1, 100),
+			},
+			expectedSeen: []string{"patchset-created/unknown/100/1"},
+		},
+		{
+			name: "duplicate events are dropped",
+			events: []client.Event{
+				change("comment-added", "foo", 1, 100),
+				change("comment-added", "foo", 1, 100),
+			},
+			expectedKicked: []string{"foo"},
+			expectedSeen:   []string{"comment-added/foo/100/1"},
+		},
+		{
+			name: "events before the checkpoint are dropped",
+			events: []client.Event{
+				change("comment-added", "foo", 1, 101),
+				change("comment-added", "bar", 1, 100),
+			},
+			expectedKicked: []string{"foo"},
+			expectedSeen:   []string{"comment-added/foo/101/1"},
+		},
+		{
+			name: "older events are forgotten when the checkpoint moves",
+			events: []client.Event{
+				change("comment-added", "foo", 1, 100),
+				change("comment-added", "bar", 2, 100),
+				change("change-merged", "bar", 2, 102),
+			},
+			expectedKicked: []string{"bar", "foo"},
+			expectedSeen:   []string{"change-merged/bar/102/2"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kicks := map[string]chan struct{}{
+				projectID("https://gerrit", "foo"): make(chan struct{}, 1),
+				projectID("https://gerrit", "bar"): make(chan struct{}, 1),
+			}
+			c := &Controller{kicks: kicks}
+			tracker := newEventTracker(time.Unix(100, 0))
+			for _, event := range tc.events {
+				c.handleEvent(logrus.NewEntry(logrus.StandardLogger()), "https://gerrit", tracker, event)
+			}
+
+			var kicked []string
+			for _, project := range []string{"bar", "foo"} {
+				select {
+				case <-kicks[projectID("https://gerrit", project)]:
+					kicked = append(kicked, project)
+				default:
+				}
+			}
+			if diff := cmp.Diff(tc.expectedKicked, kicked); diff != "" {
+				t.Errorf("unexpected kicked projects (-want +got):\n%s", diff)
+			}
+			var seen []string
+			for key := range tracker.seen {
+				seen = append(seen, key)
+			}
+			if diff := cmp.Diff(tc.expectedSeen, seen); diff != "" {
+				t.Errorf("unexpected seen events (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	gerrit "github.com/andygrunwald/go-gerrit"
@@ -59,6 +60,15 @@ const (
 
 	ResultError   = "ERROR"
 	ResultSuccess = "SUCCESS"
+
+	// Statuses of a submit requirement, see
+	// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#submit-requirement-result-info
+	SubmitRequirementSatisfied     = "SATISFIED"
+	SubmitRequirementUnsatisfied   = "UNSATISFIED"
+	SubmitRequirementOverridden    = "OVERRIDDEN"
+	SubmitRequirementNotApplicable = "NOT_APPLICABLE"
+	SubmitRequirementError         = "ERROR"
+	SubmitRequirementForced        = "FORCED"
 )
 
 var clientMetrics = struct {
@@ -104,16 +114,28 @@ type gerritRevision interface {
 	GetMergeable(changeID, revisionID string, opt *gerrit.MergableOptions) (*gerrit.MergeableInfo, *gerrit.Response, error)
 }
 
+// gerritRequester sends raw requests for endpoints not covered by the
+// go-gerrit services.
+type gerritRequester interface {
+	NewRequest(method, urlStr string, body interface{}) (*http.Request, error)
+	Do(req *http.Request, v interface{}) (*gerrit.Response, error)
+}
+
 // gerritInstanceHandler holds all actual gerrit handlers
 type gerritInstanceHandler struct {
 	instance string
 	projects map[string]*config.GerritQueryFilter
+	// queryParallelism is the number of result pages requested at once when
+	// querying the changes of a project. It is updated by
+	// SetQueryParallelism while queries may be running.
+	queryParallelism atomic.Int64
 
 	authService     gerritAuthentication
 	accountService  gerritAccount
 	changeService   gerritChange
 	projectService  gerritProjects
 	revisionService gerritRevision
+	requester       gerritRequester
 
 	log logrus.FieldLogger
 }
@@ -129,6 +151,8 @@ type Client struct {
 	authentication func() (string, error)
 	previousToken  string
 	lock           sync.RWMutex
+
+	queryParallelism int
 }
 
 // ChangeInfo is a gerrit.ChangeInfo
@@ -140,6 +164,28 @@ type RevisionInfo = gerrit.RevisionInfo
 // FileInfo is a gerrit.FileInfo
 type FileInfo = gerrit.FileInfo
 
+// SubmitRequirementResultInfo is the result of evaluating a submit requirement
+// on a change. Submit requirements are only reported by Gerrit 3.5 and newer.
+type SubmitRequirementResultInfo struct {
+	Name                           string                           `json:"name"`
+	Description                    string                           `json:"description,omitempty"`
+	Status                         string                           `json:"status"`
+	IsLegacy                       bool                             `json:"is_legacy,omitempty"`
+	ApplicabilityExpressionResult  *SubmitRequirementExpressionInfo `json:"applicability_expression_result,omitempty"`
+	SubmittabilityExpressionResult *SubmitRequirementExpressionInfo `json:"submittability_expression_result,omitempty"`
+	OverrideExpressionResult       *SubmitRequirementExpressionInfo `json:"override_expression_result,omitempty"`
+}
+
+// SubmitRequirementExpressionInfo is the result of evaluating a single
+// expression of a submit requirement.
+type SubmitRequirementExpressionInfo struct {
+	Expression   string   `json:"expression,omitempty"`
+	Fulfilled    bool     `json:"fulfilled"`
+	PassingAtoms []string `json:"passing_atoms,omitempty"`
+	FailingAtoms []string `json:"failing_atoms,omitempty"`
+	ErrorMessage string   `json:"error_message,omitempty"`
+}
+
 // Map from instance name to repos to lastsync time for that repo
 type LastSyncState map[string]map[string]time.Time
 
@@ -321,15 +367,31 @@ func (c *Client) newInstanceHandler(instance string, projects map[string]*config
 		return nil, fmt.Errorf("failed to create gerrit client: %w", err)
 	}
 
-	return &gerritInstanceHandler{
+	handler := &gerritInstanceHandler{
 		instance:       instance,
 		projects:       projects,
 		authService:    gc.Authentication,
 		accountService: gc.Accounts,
 		changeService:  gc.Changes,
 		projectService: gc.Projects,
+		requester:      gc,
 		log:            logrus.WithField("host", instance),
-	}, nil
+	}
+	handler.queryParallelism.Store(int64(c.queryParallelism))
+	return handler, nil
+}
+
+// SetQueryParallelism sets how many result pages are requested at once when
+// querying the changes of a project. Large instances benefit from requesting
+// pages in parallel, at the cost of some wasted requests once the last page
+// is reached. Values below 2 query pages sequentially.
+func (c *Client) SetQueryParallelism(pages int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.queryParallelism = pages
+	for _, handler := range c.handlers {
+		handler.queryParallelism.Store(int64(pages))
+	}
 }
 
 // UpdateClients update gerrit clients with new instances map
@@ -434,6 +496,44 @@ func (c *Client) GetChange(instance, id string, additionalFields ...string) (*Ch
 	return info, nil
 }
 
+// GetSubmitRequirements returns the results of evaluating the submit
+// requirements of a change. Gerrit versions before 3.5 do not know about
+// submit requirements and return none.
+func (c *Client) GetSubmitRequirements(instance, id string) ([]SubmitRequirementResultInfo, error) {
+	c.lock.RLock()
+	h, ok := c.handlers[instance]
+	c.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("not activated gerrit instance: %s", instance)
+	}
+
+	req, err := h.requester.NewRequest(http.MethodGet, fmt.Sprintf("changes/%s?o=SUBMIT_REQUIREMENTS", id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating submit requirements request: %w", err)
+	}
+	var info struct {
+		SubmitRequirements []SubmitRequirementResultInfo `json:"submit_requirements"`
+	}
+	if resp, err := h.requester.Do(req, &info); err != nil {
+		return nil, fmt.Errorf("error getting submit requirements: %w", responseBodyError(err, resp))
+	}
+
+	return info.SubmitRequirements, nil
+}
+
+// UnsatisfiedSubmitRequirements returns the names of the submit requirements
+// that block submitting the change.
+func UnsatisfiedSubmitRequirements(requirements []SubmitRequirementResultInfo) []string {
+	var unsatisfied []string
+	for _, requirement := range requirements {
+		switch requirement.Status {
+		case SubmitRequirementUnsatisfied, SubmitRequirementError:
+			unsatisfied = append(unsatisfied, requirement.Name)
+		}
+	}
+	return unsatisfied
+}
+
 func (c *Client) SubmitChange(instance, id string, wait bool) (*ChangeInfo, error) {
 	c.lock.RLock()
 	h, ok := c.handlers[instance]
@@ -696,105 +796,136 @@ func (h *gerritInstanceHandler) queryChangesForProjectWithoutMetrics(log logrus.
 	}
 
 	for {
-		opt.Limit = rateLimit
-		opt.Start = start
-
-		// override log just for this for loop
-		log := log.WithField("start", opt.Start)
 		// The change output is sorted by the last update time, most recently updated to oldest updated.
 		// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#list-changes
-
-		changes, resp, err := h.changeService.QueryChanges(&opt)
-
+		pages, err := h.queryPages(opt, start, rateLimit)
 		if err != nil {
 			// should not happen? Let next sync loop catch up
-			return nil, responseBodyError(err, resp)
+			return nil, err
 		}
 
-		if changes == nil || len(*changes) == 0 {
-			log.Info("No more changes")
-			return deduper.result, nil
-		}
+		pagesStart := start
+		for i, changes := range pages {
+			// override log just for this for loop
+			log := log.WithField("start", pagesStart+i*rateLimit)
 
-		log.WithField("changes", len(*changes)).Debug("Found gerrit changes from page.")
+			if len(changes) == 0 {
+				log.Info("No more changes")
+				return deduper.result, nil
+			}
 
-		start += len(*changes)
+			log.WithField("changes", len(changes)).Debug("Found gerrit changes from page.")
 
-		for _, change := range *changes {
-			// if we already processed this change, then we stop the current sync loop
-			updated := parseStamp(change.Updated)
+			// Gerrit may return fewer changes than requested, so only skip
+			// past the changes actually returned.
+			start += len(changes)
 
-			log := log.WithFields(logrus.Fields{
-				"change":     change.Number,
-				"updated":    change.Updated,
-				"status":     change.Status,
-				"lastUpdate": lastUpdate,
-			})
+			for _, change := range changes {
+				// if we already processed this change, then we stop the current sync loop
+				updated := parseStamp(change.Updated)
 
-			// stop when we find a change last updated before lastUpdate
-			if !updated.After(lastUpdate) {
-				log.Debug("No more recently updated changes")
-				return deduper.result, nil
-			}
+				log := log.WithFields(logrus.Fields{
+					"change":     change.Number,
+					"updated":    change.Updated,
+					"status":     change.Status,
+					"lastUpdate": lastUpdate,
+				})
 
-			// process recently updated change
-			switch change.Status {
-			case Merged:
-				submitted := parseStamp(*change.Submitted)
-				log := log.WithField("submitted", submitted)
-				if !submitted.After(lastUpdate) {
-					log.Debug("Skipping previously merged change")
-					continue
-				}
-				log.Debug("Found merged change")
-				deduper.dedupeIntoResult(change)
-			case New:
-				// we need to make sure the change update is from a fresh commit change
-				rev, ok := change.Revisions[change.CurrentRevision]
-				if !ok {
-					log.WithError(err).WithField("revision", change.CurrentRevision).Error("Revision not found")
-					continue
+				// stop when we find a change last updated before lastUpdate
+				if !updated.After(lastUpdate) {
+					log.Debug("No more recently updated changes")
+					return deduper.result, nil
 				}
 
-				created := parseStamp(rev.Created)
-				log := log.WithField("created", created)
-				if err := h.injectPatchsetMessages(&change); err != nil {
-					log.WithError(err).Error("Failed to inject patchset messages")
-				}
-				changeMessages := change.Messages
-				var newMessages bool
-
-				for _, message := range changeMessages {
-					if message.RevisionNumber == rev.Number {
-						messageTime := parseStamp(message.Date)
-						if messageTime.After(lastUpdate) {
-							log.WithFields(logrus.Fields{
-								"message":     message.Message,
-								"messageDate": messageTime,
-							}).Info("New messages")
-							newMessages = true
-							break
+				// process recently updated change
+				switch change.Status {
+				case Merged:
+					submitted := parseStamp(*change.Submitted)
+					log := log.WithField("submitted", submitted)
+					if !submitted.After(lastUpdate) {
+						log.Debug("Skipping previously merged change")
+						continue
+					}
+					log.Debug("Found merged change")
+					deduper.dedupeIntoResult(change)
+				case New:
+					// we need to make sure the change update is from a fresh commit change
+					rev, ok := change.Revisions[change.CurrentRevision]
+					if !ok {
+						log.WithError(err).WithField("revision", change.CurrentRevision).Error("Revision not found")
+						continue
+					}
+
+					created := parseStamp(rev.Created)
+					log := log.WithField("created", created)
+					if err := h.injectPatchsetMessages(&change); err != nil {
+						log.WithError(err).Error("Failed to inject patchset messages")
+					}
+					changeMessages := change.Messages
+					var newMessages bool
+
+					for _, message := range changeMessages {
+						if message.RevisionNumber == rev.Number {
+							messageTime := parseStamp(message.Date)
+							if messageTime.After(lastUpdate) {
+								log.WithFields(logrus.Fields{
+									"message":     message.Message,
+									"messageDate": messageTime,
+								}).Info("New messages")
+								newMessages = true
+								break
+							}
 						}
 					}
-				}
 
-				if !newMessages && !created.After(lastUpdate) {
-					// stale commit
-					log.Debug("Skipping existing change")
-					continue
-				}
-				if !newMessages {
-					log.Debug("Found updated change")
+					if !newMessages && !created.After(lastUpdate) {
+						// stale commit
+						log.Debug("Skipping existing change")
+						continue
+					}
+					if !newMessages {
+						log.Debug("Found updated change")
+					}
+					deduper.dedupeIntoResult(change)
+				default:
+					// change has been abandoned, do nothing
+					log.Debug("Ignored change")
 				}
-				deduper.dedupeIntoResult(change)
-			default:
-				// change has been abandoned, do nothing
-				log.Debug("Ignored change")
 			}
 		}
 	}
 }
 
+// queryPages requests queryParallelism consecutive result pages of the query
+// at once, starting at the given offset. The pages are returned in order.
+func (h *gerritInstanceHandler) queryPages(opt gerrit.QueryChangeOptions, start, rateLimit int) ([][]gerrit.ChangeInfo, error) {
+	parallelism := int(h.queryParallelism.Load())
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	pages := make([][]gerrit.ChangeInfo, parallelism)
+	errs := make([]error, parallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func(i int, opt gerrit.QueryChangeOptions) {
+			defer wg.Done()
+			opt.Limit = rateLimit
+			opt.Start = start + i*rateLimit
+			changes, resp, err := h.changeService.QueryChanges(&opt)
+			if err != nil {
+				errs[i] = responseBodyError(err, resp)
+				return
+			}
+			if changes != nil {
+				pages[i] = *changes
+			}
+		}(i, opt)
+	}
+	wg.Wait()
+	return pages, utilerrors.NewAggregate(errs)
+}
+
 // ChangedFilesProvider lists (in lexicographic order) the files changed as part of a Gerrit patchset.
 // It includes the original paths of renamed files.
 func ChangedFilesProvider(changeInfo *ChangeInfo) config.ChangedFilesProvider {
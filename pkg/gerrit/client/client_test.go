@@ -18,6 +18,9 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -1051,54 +1054,106 @@ func TestQueryChange(t *testing.T) {
 	}
 
 	for _, tc := range testcases {
-		client := &Client{
-			handlers: map[string]*gerritInstanceHandler{
-				"foo": {
-					instance: "foo",
-					projects: map[string]*config.GerritQueryFilter{"bar": nil},
-					changeService: &fgc{
-						changes:  tc.changes,
+		for _, parallelism := range []int{0, 3} {
+			client := &Client{
+				handlers: map[string]*gerritInstanceHandler{
+					"foo": {
 						instance: "foo",
-						comments: tc.comments,
+						projects: map[string]*config.GerritQueryFilter{"bar": nil},
+						changeService: &fgc{
+							changes:  tc.changes,
+							instance: "foo",
+							comments: tc.comments,
+						},
+						log: logrus.WithField("host", "foo"),
 					},
-					log: logrus.WithField("host", "foo"),
-				},
-				"baz": {
-					instance: "baz",
-					projects: map[string]*config.GerritQueryFilter{"boo": nil},
-					changeService: &fgc{
-						changes:  tc.changes,
+					"baz": {
 						instance: "baz",
+						projects: map[string]*config.GerritQueryFilter{"boo": nil},
+						changeService: &fgc{
+							changes:  tc.changes,
+							instance: "baz",
+						},
+						log: logrus.WithField("host", "baz"),
 					},
-					log: logrus.WithField("host", "baz"),
 				},
-			},
-		}
+			}
+			client.SetQueryParallelism(parallelism)
 
-		testLastSync := LastSyncState{"foo": tc.lastUpdate, "baz": tc.lastUpdate}
-		changes := client.QueryChanges(testLastSync, 2)
+			testLastSync := LastSyncState{"foo": tc.lastUpdate, "baz": tc.lastUpdate}
+			changes := client.QueryChanges(testLastSync, 2)
 
-		revisions := map[string][]string{}
-		messages := map[string][]gerrit.ChangeMessageInfo{}
-		seen := sets.NewInt()
-		for instance, changes := range changes {
-			revisions[instance] = []string{}
-			for _, change := range changes {
-				if seen.Has(change.Number) {
-					t.Errorf("Change number %d appears multiple times in the query results.", change.Number)
+			revisions := map[string][]string{}
+			messages := map[string][]gerrit.ChangeMessageInfo{}
+			seen := sets.NewInt()
+			for instance, changes := range changes {
+				revisions[instance] = []string{}
+				for _, change := range changes {
+					if seen.Has(change.Number) {
+						t.Errorf("Change number %d appears multiple times in the query results.", change.Number)
+					}
+					seen.Insert(change.Number)
+					revisions[instance] = append(revisions[instance], change.CurrentRevision)
+					messages[change.ChangeID] = append(messages[change.ChangeID], change.Messages...)
 				}
-				seen.Insert(change.Number)
-				revisions[instance] = append(revisions[instance], change.CurrentRevision)
-				messages[change.ChangeID] = append(messages[change.ChangeID], change.Messages...)
 			}
-		}
 
-		if !reflect.DeepEqual(revisions, tc.revisions) {
-			t.Errorf("tc %s - wrong revisions: got %#v, expect %#v", tc.name, revisions, tc.revisions)
+			if !reflect.DeepEqual(revisions, tc.revisions) {
+				t.Errorf("tc %s - wrong revisions: got %#v, expect %#v", tc.name, revisions, tc.revisions)
+			}
+
+			if tc.messages != nil && !reflect.DeepEqual(messages, tc.messages) {
+				t.Errorf("tc %s - wrong messages:\nhave %#v,\nwant %#v", tc.name, messages, tc.messages)
+			}
 		}
+	}
+}
 
-		if tc.messages != nil && !reflect.DeepEqual(messages, tc.messages) {
-			t.Errorf("tc %s - wrong messages:\nhave %#v,\nwant %#v", tc.name, messages, tc.messages)
+func TestGetSubmitRequirements(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/changes/123" || r.URL.Query().Get("o") != "SUBMIT_REQUIREMENTS" {
+			http.NotFound(w, r)
+			return
 		}
+		fmt.Fprint(w, `)]}'
+{"id": "123", "submit_requirements": [
+  {"name": "Code-Review", "status": "UNSATISFIED", "submittability_expression_result": {"expression": "label:Code-Review=MAX", "fulfilled": false, "failing_atoms": ["label:Code-Review=MAX"]}},
+  {"name": "Verified", "status": "SATISFIED", "is_legacy": true}
+]}`)
+	}))
+	defer server.Close()
+	gc, err := gerrit.NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create gerrit client: %v", err)
+	}
+	client := &Client{handlers: map[string]*gerritInstanceHandler{"foo": {instance: "foo", requester: gc}}}
+
+	requirements, err := client.GetSubmitRequirements("foo", "123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []SubmitRequirementResultInfo{
+		{
+			Name:   "Code-Review",
+			Status: SubmitRequirementUnsatisfied,
+			SubmittabilityExpressionResult: &SubmitRequirementExpressionInfo{
+				Expression:   "label:Code-Review=MAX",
+				FailingAtoms: []string{"label:Code-Review=MAX"},
+			},
+		},
+		{Name: "Verified", Status: SubmitRequirementSatisfied, IsLegacy: true},
+	}
+	if diff := cmp.Diff(expected, requirements); diff != "" {
+		t.Errorf("unexpected submit requirements (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"Code-Review"}, UnsatisfiedSubmitRequirements(requirements)); diff != "" {
+		t.Errorf("unexpected unsatisfied submit requirements (-want +got):\n%s", diff)
+	}
+
+	if _, err := client.GetSubmitRequirements("foo", "456"); err == nil {
+		t.Error("expected an error for a missing change")
+	}
+	if _, err := client.GetSubmitRequirements("bar", "123"); err == nil {
+		t.Error("expected an error for an unknown instance")
 	}
 }
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// EventsLogTimeFormat is the format of the timestamps accepted by the REST
+// API of the events-log plugin.
+const EventsLogTimeFormat = "2006-01-02 15:04:05"
+
+// Event is an event as reported by `gerrit stream-events` and the events-log
+// plugin. Only the fields needed to tell which project changed are decoded.
+type Event struct {
+	Type           string          `json:"type"`
+	EventCreatedOn int64           `json:"eventCreatedOn"`
+	ProjectName    string          `json:"project,omitempty"`
+	Change         *EventChange    `json:"change,omitempty"`
+	PatchSet       *EventPatchSet  `json:"patchSet,omitempty"`
+	RefUpdate      *EventRefUpdate `json:"refUpdate,omitempty"`
+}
+
+// EventChange is the change an event is about.
+type EventChange struct {
+	Project string `json:"project"`
+	Branch  string `json:"branch"`
+	Number  int    `json:"number"`
+}
+
+// EventPatchSet is the patch set an event is about.
+type EventPatchSet struct {
+	Number   int    `json:"number"`
+	Revision string `json:"revision"`
+}
+
+// EventRefUpdate is the ref update of a ref-updated event.
+type EventRefUpdate struct {
+	Project string `json:"project"`
+	RefName string `json:"refName"`
+	NewRev  string `json:"newRev"`
+}
+
+// Project returns the project the event happened in, if any.
+func (e Event) Project() string {
+	switch {
+	case e.Change != nil && e.Change.Project != "":
+		return e.Change.Project
+	case e.RefUpdate != nil && e.RefUpdate.Project != "":
+		return e.RefUpdate.Project
+	}
+	// Newer Gerrit versions report the project at the top level of every
+	// event, older ones only report it in the change or ref update.
+	return e.ProjectName
+}
+
+// Created returns when the event happened.
+func (e Event) Created() time.Time {
+	return time.Unix(e.EventCreatedOn, 0)
+}
+
+// Key identifies the event, so that events that are delivered more than once
+// can be told apart from new ones.
+func (e Event) Key() string {
+	key := fmt.Sprintf("%s/%s/%d", e.Type, e.Project(), e.EventCreatedOn)
+	if e.Change != nil {
+		key += fmt.Sprintf("/%d", e.Change.Number)
+	}
+	if e.PatchSet != nil {
+		key += fmt.Sprintf("/%d", e.PatchSet.Number)
+	}
+	if e.RefUpdate != nil {
+		key += fmt.Sprintf("/%s/%s", e.RefUpdate.RefName, e.RefUpdate.NewRev)
+	}
+	return key
+}
+
+// ReadEvents decodes the newline separated events of r and calls handle for
+// every one of them, until r is exhausted.
+func ReadEvents(r io.Reader, handle func(Event)) error {
+	scanner := bufio.NewScanner(r)
+	// Events carry commit messages and comments, which can be long.
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		// Skip empty lines and the magic prefix of Gerrit REST responses.
+		if len(line) == 0 || string(line) == ")]}'" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return fmt.Errorf("failed to decode event %q: %w", string(line), err)
+		}
+		handle(event)
+	}
+	return scanner.Err()
+}
+
+// EventsSince returns the events of an instance that happened at or after
+// since, as recorded by the events-log plugin.
+func (c *Client) EventsSince(instance string, since time.Time) ([]Event, error) {
+	c.lock.RLock()
+	h, ok := c.handlers[instance]
+	c.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("not activated gerrit instance: %s", instance)
+	}
+
+	u := "plugins/events-log/events/?t1=" + url.QueryEscape(since.UTC().Format(EventsLogTimeFormat))
+	req, err := h.requester.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating events request: %w", err)
+	}
+	var body bytes.Buffer
+	if resp, err := h.requester.Do(req, &body); err != nil {
+		return nil, fmt.Errorf("error getting events: %w", responseBodyError(err, resp))
+	}
+	var events []Event
+	if err := ReadEvents(&body, func(e Event) { events = append(events, e) }); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gerrit "github.com/andygrunwald/go-gerrit"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadEvents(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expected    []Event
+		expectedErr bool
+	}{
+		{
+			name: "change and ref events",
+			input: `{"type":"patchset-created","change":{"project":"foo","branch":"main","number":1},"patchSet":{"number":2,"revision":"abc"},"eventCreatedOn":100}
+
+{"type":"ref-updated","refUpdate":{"project":"bar","refName":"refs/heads/main","newRev":"def"},"eventCreatedOn":101}
+{"type":"project-created","project":"baz","eventCreatedOn":102}
+`,
+			expected: []Event{
+				{Type: "patchset-created", EventCreatedOn: 100, Change: &EventChange{Project: "foo", Branch: "main", Number: 1}, PatchSet: &EventPatchSet{Number: 2, Revision: "abc"}},
+				{Type: "ref-updated", EventCreatedOn: 101, RefUpdate: &EventRefUpdate{Project: "bar", RefName: "refs/heads/main", NewRev: "def"}},
+				{Type: "project-created", EventCreatedOn: 102, ProjectName: "baz"},
+			},
+		},
+		{
+			name:  "magic prefix is skipped",
+			input: ")]}'\n{\"type\":\"change-merged\",\"change\":{\"project\":\"foo\",\"number\":1},\"eventCreatedOn\":100}\n",
+			expected: []Event{
+				{Type: "change-merged", EventCreatedOn: 100, Change: &EventChange{Project: "foo", Number: 1}},
+			},
+		},
+		{
+			name:        "invalid event",
+			input:       "{\"type\":",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []Event
+			err := ReadEvents(strings.NewReader(tc.input), func(e Event) { events = append(events, e) })
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, events); diff != "" {
+				t.Errorf("unexpected events (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEventProjectAndKey(t *testing.T) {
+	testCases := []struct {
+		name            string
+		event           Event
+		expectedProject string
+		expectedKey     string
+	}{
+		{
+			name:            "change event",
+			event:           Event{Type: "comment-added", EventCreatedOn: 100, ProjectName: "foo", Change: &EventChange{Project: "foo", Number: 1}, PatchSet: &EventPatchSet{Number: 2}},
+			expectedProject: "foo",
+			expectedKey:     "comment-added/foo/100/1/2",
+		},
+		{
+			name:            "ref event",
+			event:           Event{Type: "ref-updated", EventCreatedOn: 100, RefUpdate: &EventRefUpdate{Project: "bar", RefName: "refs/heads/main", NewRev: "abc"}},
+			expectedProject: "bar",
+			expectedKey:     "ref-updated/bar/100/refs/heads/main/abc",
+		},
+		{
+			name:            "top level project",
+			event:           Event{Type: "project-created", EventCreatedOn: 100, ProjectName: "baz"},
+			expectedProject: "baz",
+			expectedKey:     "project-created/baz/100",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if project := tc.event.Project(); project != tc.expectedProject {
+				t.Errorf("expected project %q, got %q", tc.expectedProject, project)
+			}
+			if key := tc.event.Key(); key != tc.expectedKey {
+				t.Errorf("expected key %q, got %q", tc.expectedKey, key)
+			}
+		})
+	}
+}
+
+func TestEventsSince(t *testing.T) {
+	since := time.Date(2024, time.May, 1, 10, 20, 30, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/plugins/events-log/events/" || r.URL.Query().Get("t1") != "2024-05-01 10:20:30" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{"type":"patchset-created","change":{"project":"foo","number":1},"eventCreatedOn":1714558830}`)
+		fmt.Fprintln(w, `{"type":"change-merged","change":{"project":"foo","number":2},"eventCreatedOn":1714558831}`)
+	}))
+	defer server.Close()
+	gc, err := gerrit.NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create gerrit client: %v", err)
+	}
+	client := &Client{handlers: map[string]*gerritInstanceHandler{"foo": {instance: "foo", requester: gc}}}
+
+	events, err := client.EventsSince("foo", since)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Event{
+		{Type: "patchset-created", EventCreatedOn: 1714558830, Change: &EventChange{Project: "foo", Number: 1}},
+		{Type: "change-merged", EventCreatedOn: 1714558831, Change: &EventChange{Project: "foo", Number: 2}},
+	}
+	if diff := cmp.Diff(expected, events); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	if _, err := client.EventsSince("foo", since.Add(time.Second)); err == nil {
+		t.Error("expected an error for a failing request")
+	}
+	if _, err := client.EventsSince("bar", since); err == nil {
+		t.Error("expected an error for an unknown instance")
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ghcache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/github/ghmetrics"
+)
+
+const (
+	// CoolDownHeader is set on the responses ghproxy answers itself while
+	// it holds off the requests of a token after a secondary rate limit, to
+	// the time the cool-down ends in RFC3339 format.
+	CoolDownHeader = "X-PROW-GHCACHE-COOL-DOWN-UNTIL"
+
+	// DefaultSecondaryRateLimitWait is how long to wait after a secondary
+	// rate limit response that does not tell how long to wait, as GitHub
+	// recommends.
+	DefaultSecondaryRateLimitWait = time.Minute
+)
+
+// secondaryRateLimitMessages are parts of the messages of secondary rate
+// limit and abuse detection responses.
+var secondaryRateLimitMessages = [][]byte{
+	[]byte("secondary rate limit"),
+	[]byte("abuse detection"),
+}
+
+// SecondaryRateLimit tells whether a response is a secondary rate limit or
+// abuse detection response, as opposed to the primary rate limit running out,
+// and how long GitHub asks to wait before retrying.
+//
+// See https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api#about-secondary-rate-limits
+func SecondaryRateLimit(header http.Header, statusCode int, body []byte) (time.Duration, bool) {
+	if statusCode != http.StatusForbidden && statusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		return 0, false
+	}
+	if rawTime := header.Get("Retry-After"); rawTime != "" && rawTime != "0" {
+		if seconds, err := strconv.Atoi(rawTime); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	lowered := bytes.ToLower(body)
+	for _, message := range secondaryRateLimitMessages {
+		if bytes.Contains(lowered, message) {
+			return DefaultSecondaryRateLimitWait, true
+		}
+	}
+	return 0, false
+}
+
+// PeekBody reads the body of the response and replaces it with a reader of
+// what was read, so that it can still be read by the caller.
+func PeekBody(resp *http.Response) []byte {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to read response body.")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// coolDowns records until when the requests of each token budget are held
+// off after a secondary rate limit, so that the components sharing a token
+// through ghproxy all stop hammering GitHub instead of each finding out on
+// their own.
+var coolDowns = &coolDownRegistry{until: map[string]time.Time{}}
+
+type coolDownRegistry struct {
+	lock  sync.Mutex
+	until map[string]time.Time
+}
+
+// start starts or extends the cool-down of the token budget.
+func (r *coolDownRegistry) start(tokenBudget string, until time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if until.After(r.until[tokenBudget]) {
+		r.until[tokenBudget] = until
+	}
+}
+
+// active returns when the cool-down of the token budget ends, if it is
+// cooling down at the given time.
+func (r *coolDownRegistry) active(tokenBudget string, now time.Time) (time.Time, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	until, ok := r.until[tokenBudget]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !now.Before(until) {
+		delete(r.until, tokenBudget)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// observeSecondaryRateLimit starts the cool-down of the token budget if the
+// upstream response is a secondary rate limit response.
+func observeSecondaryRateLimit(req *http.Request, resp *http.Response, tokenBudget string, now time.Time) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	wait, ok := SecondaryRateLimit(resp.Header, resp.StatusCode, PeekBody(resp))
+	if !ok {
+		return
+	}
+	until := now.Add(wait)
+	coolDowns.start(tokenBudget, until)
+	ghmetrics.CollectGitHubSecondaryRateLimitMetrics(tokenBudget, req.URL.Path, req.Header.Get("User-Agent"))
+	logrus.WithFields(logrus.Fields{
+		"token-budget":    tokenBudget,
+		"path":            req.URL.Path,
+		"consumer":        consumerFor(req),
+		"status":          resp.StatusCode,
+		"cool-down-until": until.Format(time.RFC3339),
+	}).Warn("Hit a secondary rate limit of GitHub, holding off the requests of the token.")
+}
+
+// coolDownResponse answers the request without sending it upstream if the
+// token budget is cooling down after a secondary rate limit. The response
+// asks the client to retry once the cool-down ends.
+func coolDownResponse(req *http.Request, tokenBudget string, now time.Time) (*http.Response, bool) {
+	until, ok := coolDowns.active(tokenBudget, now)
+	if !ok {
+		return nil, false
+	}
+	ghmetrics.CollectCoolDownResponseMetrics(tokenBudget, req.URL.Path, req.Header.Get("User-Agent"))
+	// Round up so that clients do not retry before the cool-down ends.
+	retryAfter := int((until.Sub(now) + time.Second - 1) / time.Second)
+	body := fmt.Sprintf(`{"message":"You have exceeded a secondary rate limit. ghproxy holds off the requests of this token until %s."}`, until.Format(time.RFC3339))
+	header := http.Header{}
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Cache-Control", "no-store")
+	header.Set("Retry-After", strconv.Itoa(retryAfter))
+	header.Set(CoolDownHeader, until.Format(time.RFC3339))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests)),
+		StatusCode:    http.StatusTooManyRequests,
+		Proto:         req.Proto,
+		ProtoMajor:    req.ProtoMajor,
+		ProtoMinor:    req.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, true
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ghcache
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/prow/pkg/github/ghmetrics"
+)
+
+func TestSecondaryRateLimit(t *testing.T) {
+	testCases := []struct {
+		name       string
+		status     int
+		header     http.Header
+		body       string
+		expectWait time.Duration
+		expectOk   bool
+	}{
+		{
+			name:       "Retry-After is honored",
+			status:     http.StatusForbidden,
+			header:     http.Header{"Retry-After": []string{"30"}},
+			expectWait: 30 * time.Second,
+			expectOk:   true,
+		},
+		{
+			name:       "secondary rate limit message without Retry-After waits the default",
+			status:     http.StatusTooManyRequests,
+			header:     http.Header{},
+			body:       `{"message":"You have exceeded a Secondary Rate Limit."}`,
+			expectWait: DefaultSecondaryRateLimitWait,
+			expectOk:   true,
+		},
+		{
+			name:       "abuse detection message with an unparseable Retry-After waits the default",
+			status:     http.StatusForbidden,
+			header:     http.Header{"Retry-After": []string{"soon"}},
+			body:       `{"message":"You have triggered an abuse detection mechanism."}`,
+			expectWait: DefaultSecondaryRateLimitWait,
+			expectOk:   true,
+		},
+		{
+			name:   "primary rate limit is not a secondary one",
+			status: http.StatusForbidden,
+			header: http.Header{"X-Ratelimit-Remaining": []string{"0"}, "Retry-After": []string{"30"}},
+			body:   `{"message":"API rate limit exceeded."}`,
+		},
+		{
+			name:   "other 403 is not a secondary rate limit",
+			status: http.StatusForbidden,
+			header: http.Header{},
+			body:   `{"message":"Resource not accessible by integration"}`,
+		},
+		{
+			name:   "other status is not a secondary rate limit",
+			status: http.StatusInternalServerError,
+			header: http.Header{"Retry-After": []string{"30"}},
+			body:   `{"message":"You have exceeded a secondary rate limit."}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wait, ok := SecondaryRateLimit(tc.header, tc.status, []byte(tc.body))
+			if ok != tc.expectOk {
+				t.Errorf("expected ok %t, got %t", tc.expectOk, ok)
+			}
+			if wait != tc.expectWait {
+				t.Errorf("expected wait %v, got %v", tc.expectWait, wait)
+			}
+		})
+	}
+}
+
+// fakeGitHub answers every request with the configured response and counts
+// the requests it got.
+type fakeGitHub struct {
+	status int
+	header http.Header
+	body   string
+	hits   int
+}
+
+func (f *fakeGitHub) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.hits++
+	header := http.Header{}
+	for k, v := range f.header {
+		header[k] = v
+	}
+	return &http.Response{
+		StatusCode: f.status,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewBufferString(f.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestCoolDown(t *testing.T) {
+	upstream := &fakeGitHub{
+		status: http.StatusForbidden,
+		header: http.Header{"Retry-After": []string{"60"}},
+		body:   `{"message":"You have exceeded a secondary rate limit."}`,
+	}
+	hasher := ghmetrics.NewCachingHasher()
+	transport := newThrottlingTransport(1, upstreamTransport{roundTripper: upstream, hasher: hasher}, hasher, RequestThrottlingTimes{})
+
+	request := func(tokenBudget string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, "https://api.github.com/repos/org/repo/pulls", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set(TokenBudgetIdentifierHeader, tokenBudget)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	resp := request("cool-down-test")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the upstream response, got status %d", resp.StatusCode)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != upstream.body {
+		t.Errorf("expected the upstream body to still be readable, got %q", string(body))
+	}
+
+	resp = request("cool-down-test")
+	if upstream.hits != 1 {
+		t.Errorf("expected the request not to reach GitHub during the cool-down, got %d hits", upstream.hits)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status %d during the cool-down, got %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+	if resp.Header.Get(CoolDownHeader) == "" {
+		t.Errorf("expected the %s header to be set", CoolDownHeader)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if wait, ok := SecondaryRateLimit(resp.Header, resp.StatusCode, body); !ok || wait <= 0 || wait > time.Minute {
+		t.Errorf("expected clients to recognize the cool-down response as a secondary rate limit of at most a minute, got %v, %t", wait, ok)
+	}
+
+	upstream.status = http.StatusOK
+	upstream.header = nil
+	if resp := request("other-token"); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected other tokens not to cool down, got status %d", resp.StatusCode)
+	}
+	if upstream.hits != 2 {
+		t.Errorf("expected the request of the other token to reach GitHub, got %d hits", upstream.hits)
+	}
+}
+
+func TestCoolDownRegistry(t *testing.T) {
+	now := time.Now()
+	r := &coolDownRegistry{until: map[string]time.Time{}}
+	if _, ok := r.active("token", now); ok {
+		t.Error("expected no cool-down before a secondary rate limit")
+	}
+	r.start("token", now.Add(time.Minute))
+	r.start("token", now.Add(time.Second))
+	if until, ok := r.active("token", now); !ok || !until.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected the cool-down not to be shortened, got %v, %t", until, ok)
+	}
+	if _, ok := r.active("token", now.Add(time.Minute)); ok {
+		t.Error("expected the cool-down to be over")
+	}
+}
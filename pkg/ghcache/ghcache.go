@@ -76,6 +76,11 @@ const (
 	// the Authorization header will be used.
 	TokenBudgetIdentifierHeader = "X-PROW-GHCACHE-TOKEN-BUDGET-IDENTIFIER"
 
+	// ConsumerHeader identifies the component, and the plugin if any, that
+	// made a request so the rate limit it spends can be attributed to it. If
+	// unset, the User-Agent without its version is used.
+	ConsumerHeader = "X-PROW-GHCACHE-CONSUMER"
+
 	// TokenExpiryAtHeader includes a date at which the passed token expires and all associated caches
 	// can be cleaned up. It's value must be in RFC3339 format.
 	TokenExpiryAtHeader = "X-PROW-TOKEN-EXPIRES-AT"
@@ -305,6 +310,11 @@ func (c *throttlingTransport) holdRequest(req *http.Request) {
 }
 
 func (c *throttlingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Don't send anything for a token that is cooling down after a secondary
+	// rate limit, it would only make GitHub extend the limit.
+	if resp, ok := coolDownResponse(req, c.getTokenBudgetName(req), time.Now()); ok {
+		return resp, nil
+	}
 	pendingOutboundConnectionsGauge.Inc()
 	if c.timeThrottlingEnabled {
 		c.holdRequest(req)
@@ -383,6 +393,14 @@ func (u upstreamTransport) RoundTrip(req *http.Request) (*http.Response, error)
 	ghmetrics.CollectGitHubTokenMetrics(tokenBudgetName, apiVersion, resp.Header, reqStartTime, responseTime)
 	ghmetrics.CollectGitHubRequestMetrics(tokenBudgetName, req.URL.Path, strconv.Itoa(resp.StatusCode), req.Header.Get("User-Agent"), roundTripTime.Seconds())
 
+	// Conditional requests answered with a 304 don't count against the rate limit.
+	charged := resp.StatusCode != http.StatusNotModified
+	consumer := consumerFor(req)
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	ghmetrics.CollectGitHubConsumerMetrics(tokenBudgetName, consumer, apiVersion, resource, charged)
+	usage.record(tokenBudgetName, consumer, resource, charged, responseTime)
+	observeSecondaryRateLimit(req, resp, tokenBudgetName, responseTime)
+
 	return resp, nil
 }
 
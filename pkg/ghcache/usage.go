@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ghcache
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// UsageReportPath is the path on which ghproxy serves the usage report.
+const UsageReportPath = "/ghproxy/usage"
+
+// usage records the upstream requests made through this process.
+var usage = newUsageTracker(time.Now())
+
+// UsageReportHandler serves a report of which consumers spent how much of
+// each token's rate limit since the process started. The report is rendered
+// as HTML, or as JSON if the `format=json` query parameter is set.
+func UsageReportHandler() http.Handler {
+	return usage
+}
+
+// consumerFor identifies the consumer that made the request.
+func consumerFor(req *http.Request) string {
+	if consumer := req.Header.Get(ConsumerHeader); consumer != "" {
+		return consumer
+	}
+	consumer := strings.SplitN(req.Header.Get("User-Agent"), "/", 2)[0]
+	if consumer == "" {
+		return "unknown"
+	}
+	return consumer
+}
+
+type usageKey struct {
+	tokenBudget string
+	consumer    string
+	resource    string
+}
+
+// UsageEntry is the usage of a token's rate limit by a single consumer.
+type UsageEntry struct {
+	TokenBudget string `json:"token_budget"`
+	Consumer    string `json:"consumer"`
+	Resource    string `json:"ratelimit_resource"`
+	// Requests is the number of upstream requests made.
+	Requests int `json:"requests"`
+	// Charged is the number of upstream requests that counted against the
+	// rate limit.
+	Charged int `json:"charged"`
+	// Share is the fraction of the charged requests for the token budget and
+	// resource that were made by this consumer.
+	Share       float64   `json:"share"`
+	LastRequest time.Time `json:"last_request"`
+}
+
+// UsageReport is the usage of all tokens' rate limits since Since.
+type UsageReport struct {
+	Since   time.Time    `json:"since"`
+	Entries []UsageEntry `json:"entries"`
+}
+
+type usageTracker struct {
+	lock  sync.Mutex
+	since time.Time
+	usage map[usageKey]*UsageEntry
+}
+
+func newUsageTracker(since time.Time) *usageTracker {
+	return &usageTracker{since: since, usage: map[usageKey]*UsageEntry{}}
+}
+
+func (u *usageTracker) record(tokenBudget, consumer, resource string, charged bool, now time.Time) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	key := usageKey{tokenBudget: tokenBudget, consumer: consumer, resource: resource}
+	entry, ok := u.usage[key]
+	if !ok {
+		entry = &UsageEntry{TokenBudget: tokenBudget, Consumer: consumer, Resource: resource}
+		u.usage[key] = entry
+	}
+	entry.Requests++
+	if charged {
+		entry.Charged++
+	}
+	if now.After(entry.LastRequest) {
+		entry.LastRequest = now
+	}
+}
+
+// report returns the recorded usage, heaviest consumers first.
+func (u *usageTracker) report() UsageReport {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	type budget struct{ tokenBudget, resource string }
+	totals := map[budget]int{}
+	for key, entry := range u.usage {
+		totals[budget{key.tokenBudget, key.resource}] += entry.Charged
+	}
+	report := UsageReport{Since: u.since, Entries: make([]UsageEntry, 0, len(u.usage))}
+	for key, entry := range u.usage {
+		e := *entry
+		if total := totals[budget{key.tokenBudget, key.resource}]; total > 0 {
+			e.Share = float64(e.Charged) / float64(total)
+		}
+		report.Entries = append(report.Entries, e)
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i], report.Entries[j]
+		if a.Charged != b.Charged {
+			return a.Charged > b.Charged
+		}
+		if a.TokenBudget != b.TokenBudget {
+			return a.TokenBudget < b.TokenBudget
+		}
+		if a.Consumer != b.Consumer {
+			return a.Consumer < b.Consumer
+		}
+		return a.Resource < b.Resource
+	})
+	return report
+}
+
+var usageReportTemplate = template.Must(template.New("usage").Funcs(template.FuncMap{
+	"percent": func(share float64) float64 { return share * 100 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>ghproxy usage</title></head>
+<body>
+<h1>GitHub API usage since {{.Since.Format "2006-01-02 15:04:05 MST"}}</h1>
+<table border="1" cellpadding="4">
+<tr><th>Token budget</th><th>Resource</th><th>Consumer</th><th>Charged requests</th><th>Share of token budget</th><th>Upstream requests</th><th>Last request</th></tr>
+{{range .Entries}}<tr><td>{{.TokenBudget}}</td><td>{{.Resource}}</td><td>{{.Consumer}}</td><td>{{.Charged}}</td><td>{{printf "%.1f" (percent .Share)}}%</td><td>{{.Requests}}</td><td>{{.LastRequest.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func (u *usageTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := u.report()
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logrus.WithError(err).Warn("Failed to write usage report.")
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := usageReportTemplate.Execute(w, report); err != nil {
+		logrus.WithError(err).Warn("Failed to render usage report.")
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ghcache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConsumerFor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{
+			name:     "consumer header",
+			headers:  map[string]string{ConsumerHeader: "hook.lgtm", "User-Agent": "hook.approve/v20240101"},
+			expected: "hook.lgtm",
+		},
+		{
+			name:     "user agent without version",
+			headers:  map[string]string{"User-Agent": "hook.approve/v20240101"},
+			expected: "hook.approve",
+		},
+		{
+			name:     "nothing",
+			expected: "unknown",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/repos/org/repo", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			if actual := consumerFor(req); actual != tc.expected {
+				t.Errorf("expected consumer %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUsageTracker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := start.Add(time.Minute)
+	tracker := newUsageTracker(start)
+	tracker.record("bot", "hook.lgtm", "core", true, start)
+	tracker.record("bot", "hook.lgtm", "core", false, later)
+	tracker.record("bot", "tide", "core", true, start)
+	tracker.record("bot", "tide", "core", true, later)
+	tracker.record("bot", "tide", "core", true, start)
+	tracker.record("bot", "tide", "graphql", true, start)
+
+	expected := UsageReport{
+		Since: start,
+		Entries: []UsageEntry{
+			{TokenBudget: "bot", Consumer: "tide", Resource: "core", Requests: 3, Charged: 3, Share: 0.75, LastRequest: later},
+			{TokenBudget: "bot", Consumer: "hook.lgtm", Resource: "core", Requests: 2, Charged: 1, Share: 0.25, LastRequest: later},
+			{TokenBudget: "bot", Consumer: "tide", Resource: "graphql", Requests: 1, Charged: 1, Share: 1, LastRequest: start},
+		},
+	}
+	if diff := cmp.Diff(expected, tracker.report()); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+
+	rec := httptest.NewRecorder()
+	tracker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, UsageReportPath+"?format=json", nil))
+	var served UsageReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil {
+		t.Fatalf("failed to unmarshal JSON report: %v", err)
+	}
+	if diff := cmp.Diff(expected, served); diff != "" {
+		t.Errorf("unexpected JSON report (-want +got):\n%s", diff)
+	}
+
+	rec = httptest.NewRecorder()
+	tracker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, UsageReportPath, nil))
+	if body := rec.Body.String(); !strings.Contains(body, "<td>hook.lgtm</td>") || !strings.Contains(body, "75.0%") {
+		t.Errorf("HTML report is missing usage:\n%s", body)
+	}
+}
@@ -0,0 +1,424 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultMinRemainingTokens is how many API tokens bulk operations leave
+	// to the other users of the token by default.
+	defaultMinRemainingTokens = 500
+	// defaultCheckpointEvery is after how many items bulk operations save
+	// their progress by default.
+	defaultCheckpointEvery = 10
+	// rateLimitCheckEvery is after how many items bulk operations check the
+	// API token budget again.
+	rateLimitCheckEvery = 20
+)
+
+// BulkClient is the subset of the client used by bulk operations.
+type BulkClient interface {
+	FindIssuesWithOrg(org, query, sort string, asc bool) ([]Issue, error)
+	GetPullRequests(org, repo string) ([]PullRequest, error)
+	AddLabels(org, repo string, number int, labels ...string) error
+	RemoveLabel(org, repo string, number int, label string) error
+	ClosePullRequest(org, repo string, number int) error
+	GetRateLimits(org string) (*RateLimits, error)
+}
+
+// BulkProgress is the progress of a bulk operation.
+type BulkProgress struct {
+	Operation string `json:"operation"`
+	// Done are the issues and pull requests the operation was applied to,
+	// as org/repo#number.
+	Done []string `json:"done"`
+}
+
+// BulkCheckpointer stores the progress of bulk operations, so that they
+// resume where they stopped after a restart.
+type BulkCheckpointer interface {
+	// Load returns the progress of an operation, or nil if there is none.
+	Load(operation string) (*BulkProgress, error)
+	Save(progress *BulkProgress) error
+	// Clear forgets the progress of an operation once it is finished.
+	Clear(operation string) error
+}
+
+// FileCheckpointer stores the progress of each bulk operation in a JSON file
+// of a directory, e.g. on a persistent volume.
+type FileCheckpointer struct {
+	dir string
+}
+
+// NewFileCheckpointer creates a FileCheckpointer storing progress in dir,
+// which is created if needed.
+func NewFileCheckpointer(dir string) (*FileCheckpointer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	return &FileCheckpointer{dir: dir}, nil
+}
+
+func (f *FileCheckpointer) path(operation string) string {
+	sum := sha256.Sum256([]byte(operation))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Load implements BulkCheckpointer.
+func (f *FileCheckpointer) Load(operation string) (*BulkProgress, error) {
+	b, err := os.ReadFile(f.path(operation))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var progress BulkProgress
+	if err := json.Unmarshal(b, &progress); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint of %q: %w", operation, err)
+	}
+	return &progress, nil
+}
+
+// Save implements BulkCheckpointer. The checkpoint is replaced atomically, so
+// a restart while saving does not lose the previous one.
+func (f *FileCheckpointer) Save(progress *BulkProgress) error {
+	b, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.dir, "checkpoint-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(progress.Operation))
+}
+
+// Clear implements BulkCheckpointer.
+func (f *FileCheckpointer) Clear(operation string) error {
+	if err := os.Remove(f.path(operation)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// BulkOptions configures a bulk operation.
+type BulkOptions struct {
+	// Checkpointer stores the progress of the operation. If nil, an
+	// interrupted operation starts over.
+	Checkpointer BulkCheckpointer
+	// CheckpointEvery is after how many items the progress is saved,
+	// 10 by default.
+	CheckpointEvery int
+	// MinRemainingTokens is how many API tokens the operation leaves to the
+	// other users of the token, 500 by default. The operation spreads the
+	// rest of the tokens over the time left until they are reset, and waits
+	// for the reset once only MinRemainingTokens are left.
+	MinRemainingTokens int
+	// DryRun lists the items without changing them.
+	DryRun bool
+	Logger *logrus.Entry
+
+	// sleep is overridden in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+	now   func() time.Time
+}
+
+// BulkResult is the outcome of a bulk operation.
+type BulkResult struct {
+	// Done are the items the operation was applied to by this run.
+	Done []string
+	// Resumed are the items done by earlier, interrupted runs.
+	Resumed int
+	// Failed are the items the operation failed for, with why. They are
+	// retried by the next run.
+	Failed map[string]string
+}
+
+// bulkItem is an issue or pull request of a bulk operation.
+type bulkItem struct {
+	org, repo string
+	number    int
+}
+
+func (i bulkItem) String() string {
+	return fmt.Sprintf("%s/%s#%d", i.org, i.repo, i.number)
+}
+
+// RelabelIssues adds and removes labels on all the issues and pull requests
+// of a repo matching a search query, e.g. to rename a label.
+//
+// The matches are listed before any is changed, and listed again until none
+// is left to change, so changes that make items stop matching the query do
+// not make pages of results skip items. The search API only returns the first
+// 1000 matches, so queries with more matches must exclude the items that were
+// changed, e.g. by searching for a removed label.
+func RelabelIssues(ctx context.Context, client BulkClient, org, repo, query string, add, remove []string, opts BulkOptions) (*BulkResult, error) {
+	operation := fmt.Sprintf("relabel %s/%s %q +%v -%v", org, repo, query, add, remove)
+	query = fmt.Sprintf("%s repo:%s/%s", query, org, repo)
+	list := func() ([]bulkItem, error) {
+		issues, err := client.FindIssuesWithOrg(org, query, "created", true)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]bulkItem, 0, len(issues))
+		for _, issue := range issues {
+			items = append(items, bulkItem{org: org, repo: repo, number: issue.Number})
+		}
+		return items, nil
+	}
+	apply := func(item bulkItem) error {
+		if len(add) > 0 {
+			if err := client.AddLabels(item.org, item.repo, item.number, add...); err != nil {
+				return err
+			}
+		}
+		for _, label := range remove {
+			if err := client.RemoveLabel(item.org, item.repo, item.number, label); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return runBulk(ctx, client, org, operation, list, apply, opts)
+}
+
+// ClosePullRequestsFromFork closes the open pull requests of a repo whose
+// head branch is in a repo owned by forkOwner, e.g. to clean up after spam.
+func ClosePullRequestsFromFork(ctx context.Context, client BulkClient, org, repo, forkOwner string, opts BulkOptions) (*BulkResult, error) {
+	operation := fmt.Sprintf("close-fork-prs %s/%s %s", org, repo, forkOwner)
+	list := func() ([]bulkItem, error) {
+		prs, err := client.GetPullRequests(org, repo)
+		if err != nil {
+			return nil, err
+		}
+		var items []bulkItem
+		for _, pr := range prs {
+			if pr.Head.Repo.Owner.Login != "" && NormLogin(pr.Head.Repo.Owner.Login) == NormLogin(forkOwner) {
+				items = append(items, bulkItem{org: org, repo: repo, number: pr.Number})
+			}
+		}
+		return items, nil
+	}
+	apply := func(item bulkItem) error {
+		return client.ClosePullRequest(item.org, item.repo, item.number)
+	}
+	return runBulk(ctx, client, org, operation, list, apply, opts)
+}
+
+// runBulk applies an operation to all the items listed, resuming from the
+// checkpoint of the operation if any, until listing finds no item left.
+func runBulk(ctx context.Context, client BulkClient, org, operation string, list func() ([]bulkItem, error), apply func(bulkItem) error, opts BulkOptions) (*BulkResult, error) {
+	if opts.CheckpointEvery <= 0 {
+		opts.CheckpointEvery = defaultCheckpointEvery
+	}
+	if opts.MinRemainingTokens <= 0 {
+		opts.MinRemainingTokens = defaultMinRemainingTokens
+	}
+	if opts.Logger == nil {
+		opts.Logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	if opts.sleep == nil {
+		opts.sleep = sleepWithContext
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	log := opts.Logger.WithField("operation", operation)
+
+	progress := &BulkProgress{Operation: operation}
+	if opts.Checkpointer != nil && !opts.DryRun {
+		saved, err := opts.Checkpointer.Load(operation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+		if saved != nil {
+			progress = saved
+			log.WithField("done", len(progress.Done)).Info("Resuming bulk operation.")
+		}
+	}
+	done := map[string]bool{}
+	for _, key := range progress.Done {
+		done[key] = true
+	}
+	result := &BulkResult{Resumed: len(progress.Done), Failed: map[string]string{}}
+	save := func() error {
+		if opts.Checkpointer == nil || opts.DryRun {
+			return nil
+		}
+		return opts.Checkpointer.Save(progress)
+	}
+
+	pacer := &bulkPacer{client: client, org: org, opts: opts, log: log}
+	for {
+		items, err := list()
+		if err != nil {
+			return result, fmt.Errorf("failed to list items: %w", err)
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].String() < items[j].String() })
+		var pending []bulkItem
+		for _, item := range items {
+			key := item.String()
+			if !done[key] && result.Failed[key] == "" {
+				pending = append(pending, item)
+			}
+		}
+		if len(pending) == 0 || opts.DryRun {
+			for _, item := range pending {
+				result.Done = append(result.Done, item.String())
+			}
+			break
+		}
+		for i, item := range pending {
+			if err := pacer.wait(ctx); err != nil {
+				if saveErr := save(); saveErr != nil {
+					log.WithError(saveErr).Warn("Failed to save checkpoint.")
+				}
+				return result, err
+			}
+			key := item.String()
+			if err := apply(item); err != nil {
+				log.WithError(err).WithField("item", key).Warn("Bulk operation failed for item.")
+				result.Failed[key] = err.Error()
+				continue
+			}
+			done[key] = true
+			progress.Done = append(progress.Done, key)
+			result.Done = append(result.Done, key)
+			if (i+1)%opts.CheckpointEvery == 0 {
+				if err := save(); err != nil {
+					return result, fmt.Errorf("failed to save checkpoint: %w", err)
+				}
+			}
+		}
+	}
+
+	if opts.Checkpointer != nil && !opts.DryRun {
+		if err := opts.Checkpointer.Clear(operation); err != nil {
+			return result, fmt.Errorf("failed to clear checkpoint: %w", err)
+		}
+	}
+	log.WithFields(logrus.Fields{"done": len(result.Done), "failed": len(result.Failed)}).Info("Finished bulk operation.")
+	return result, nil
+}
+
+// bulkPacer spreads the API tokens of a bulk operation over the time left
+// until they are reset.
+type bulkPacer struct {
+	client BulkClient
+	org    string
+	opts   BulkOptions
+	log    *logrus.Entry
+
+	calls int
+	delay time.Duration
+}
+
+// wait waits before the next item, checking the API token budget again every
+// rateLimitCheckEvery items.
+func (p *bulkPacer) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.calls%rateLimitCheckEvery == 0 {
+		if err := p.update(ctx); err != nil {
+			return err
+		}
+	}
+	p.calls++
+	if p.delay > 0 {
+		return p.opts.sleep(ctx, p.delay)
+	}
+	return nil
+}
+
+func (p *bulkPacer) update(ctx context.Context) error {
+	limits, err := p.client.GetRateLimits(p.org)
+	if err != nil {
+		// The client still waits for the reset once no token is left.
+		p.log.WithError(err).Debug("Failed to get rate limits, not pacing the bulk operation.")
+		p.delay = 0
+		return nil
+	}
+	core := limits.Resources.Core
+	if core.Limit == 0 {
+		p.delay = 0
+		return nil
+	}
+	untilReset := time.Unix(core.Reset, 0).Sub(p.opts.now())
+	if untilReset < 0 {
+		untilReset = 0
+	}
+	spare := core.Remaining - p.opts.MinRemainingTokens
+	if spare <= 0 {
+		p.log.WithField("reset", untilReset.String()).Info("Waiting for the API token budget to be reset.")
+		if err := p.opts.sleep(ctx, untilReset+time.Second); err != nil {
+			return err
+		}
+		return p.update(ctx)
+	}
+	p.delay = untilReset / time.Duration(spare)
+	return nil
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// String formats the result for humans, e.g. in a comment.
+func (r *BulkResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d done", len(r.Done)+r.Resumed)
+	if len(r.Failed) > 0 {
+		var failed []string
+		for key, reason := range r.Failed {
+			failed = append(failed, fmt.Sprintf("%s: %s", key, reason))
+		}
+		sort.Strings(failed)
+		fmt.Fprintf(&b, ", %d failed:\n- %s", len(failed), strings.Join(failed, "\n- "))
+	}
+	return b.String()
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+)
+
+type fakeBulkClient struct {
+	labels map[int][]string
+	prs    []PullRequest
+	// searchCap is how many matches searches return at most, like the 1000
+	// of the search API.
+	searchCap int
+	failing   map[int]bool
+	limits    []RateLimits
+	// onApply is called for each item changed.
+	onApply func(number int)
+
+	closed []int
+}
+
+func (f *fakeBulkClient) FindIssuesWithOrg(org, query, sort string, asc bool) ([]Issue, error) {
+	label := strings.TrimPrefix(strings.Fields(query)[0], "label:")
+	var issues []Issue
+	for number := 1; number <= len(f.labels); number++ {
+		for _, l := range f.labels[number] {
+			if l == label {
+				issues = append(issues, Issue{Number: number})
+			}
+		}
+		if f.searchCap > 0 && len(issues) == f.searchCap {
+			break
+		}
+	}
+	return issues, nil
+}
+
+func (f *fakeBulkClient) GetPullRequests(org, repo string) ([]PullRequest, error) {
+	var open []PullRequest
+	for _, pr := range f.prs {
+		if pr.State == "open" {
+			open = append(open, pr)
+		}
+	}
+	return open, nil
+}
+
+func (f *fakeBulkClient) AddLabels(org, repo string, number int, labels ...string) error {
+	if f.failing[number] {
+		return errors.New("injected failure")
+	}
+	if f.onApply != nil {
+		f.onApply(number)
+	}
+	f.labels[number] = append(f.labels[number], labels...)
+	return nil
+}
+
+func (f *fakeBulkClient) RemoveLabel(org, repo string, number int, label string) error {
+	var kept []string
+	for _, l := range f.labels[number] {
+		if l != label {
+			kept = append(kept, l)
+		}
+	}
+	f.labels[number] = kept
+	return nil
+}
+
+func (f *fakeBulkClient) ClosePullRequest(org, repo string, number int) error {
+	for i := range f.prs {
+		if f.prs[i].Number == number {
+			f.prs[i].State = "closed"
+		}
+	}
+	f.closed = append(f.closed, number)
+	return nil
+}
+
+func (f *fakeBulkClient) GetRateLimits(org string) (*RateLimits, error) {
+	if len(f.limits) == 0 {
+		return &RateLimits{}, nil
+	}
+	limits := f.limits[0]
+	if len(f.limits) > 1 {
+		f.limits = f.limits[1:]
+	}
+	return &limits, nil
+}
+
+func noSleep(context.Context, time.Duration) error { return nil }
+
+func TestRelabelIssues(t *testing.T) {
+	client := &fakeBulkClient{
+		labels: map[int][]string{
+			1: {"old"},
+			2: {"other"},
+			3: {"old", "other"},
+			4: {"old"},
+			5: {"old"},
+		},
+		searchCap: 2,
+		failing:   map[int]bool{4: true},
+	}
+	result, err := RelabelIssues(context.Background(), client, "org", "repo", "label:old", []string{"new"}, []string{"old"}, BulkOptions{sleep: noSleep})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"org/repo#1", "org/repo#3", "org/repo#5"}, result.Done); diff != "" {
+		t.Errorf("unexpected items done (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"org/repo#4": "injected failure"}, result.Failed); diff != "" {
+		t.Errorf("unexpected failures (-want +got):\n%s", diff)
+	}
+	expectedLabels := map[int][]string{
+		1: {"new"},
+		2: {"other"},
+		3: {"other", "new"},
+		4: {"old"},
+		5: {"new"},
+	}
+	if diff := cmp.Diff(expectedLabels, client.labels); diff != "" {
+		t.Errorf("unexpected labels (-want +got):\n%s", diff)
+	}
+}
+
+func TestClosePullRequestsFromFork(t *testing.T) {
+	pr := func(number int, owner string) PullRequest {
+		return PullRequest{Number: number, State: "open", Head: PullRequestBranch{Repo: Repo{Owner: User{Login: owner}}}}
+	}
+	client := &fakeBulkClient{prs: []PullRequest{pr(1, "spammer"), pr(2, "someone"), pr(3, "Spammer"), pr(4, "")}}
+	result, err := ClosePullRequestsFromFork(context.Background(), client, "org", "repo", "spammer", BulkOptions{sleep: noSleep})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]int{1, 3}, client.closed); diff != "" {
+		t.Errorf("unexpected pull requests closed (-want +got):\n%s", diff)
+	}
+	if len(result.Done) != 2 || len(result.Failed) != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	client.closed = nil
+	result, err = ClosePullRequestsFromFork(context.Background(), client, "org", "repo", "someone", BulkOptions{sleep: noSleep, DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.closed) != 0 {
+		t.Errorf("expected a dry run to close nothing, closed %v", client.closed)
+	}
+	if diff := cmp.Diff([]string{"org/repo#2"}, result.Done); diff != "" {
+		t.Errorf("unexpected items listed (-want +got):\n%s", diff)
+	}
+}
+
+func TestBulkResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	checkpointer, err := NewFileCheckpointer(dir)
+	if err != nil {
+		t.Fatalf("failed to create checkpointer: %v", err)
+	}
+	labels := map[int][]string{}
+	for number := 1; number <= 5; number++ {
+		labels[number] = []string{"old"}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var applied []int
+	client := &fakeBulkClient{labels: labels, onApply: func(number int) {
+		applied = append(applied, number)
+		if len(applied) == 3 {
+			cancel()
+		}
+	}}
+	// Adding a label does not change the matches, so only the checkpoint
+	// tells which items are done.
+	opts := BulkOptions{Checkpointer: checkpointer, CheckpointEvery: 100, sleep: noSleep}
+	result, err := RelabelIssues(ctx, client, "org", "repo", "label:old", []string{"new"}, nil, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the operation to be canceled, got %v", err)
+	}
+	if len(result.Done) != 3 {
+		t.Fatalf("expected 3 items done before cancellation, got %v", result.Done)
+	}
+
+	result, err = RelabelIssues(context.Background(), client, "org", "repo", "label:old", []string{"new"}, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]int{1, 2, 3, 4, 5}, applied); diff != "" {
+		t.Errorf("expected each item to be changed once (-want +got):\n%s", diff)
+	}
+	if result.Resumed != 3 || len(result.Done) != 2 {
+		t.Errorf("expected 3 items resumed and 2 done, got %+v", result)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read checkpoint directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the checkpoint of the finished operation to be cleared, found %d files", len(entries))
+	}
+}
+
+func TestBulkPacer(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limits := func(remaining int, reset int64) RateLimits {
+		var l RateLimits
+		l.Resources.Core = RateLimit{Limit: 5000, Remaining: remaining, Reset: reset}
+		return l
+	}
+	testCases := []struct {
+		name           string
+		limits         []RateLimits
+		expectedSleeps []time.Duration
+	}{
+		{
+			name:   "unknown rate limits are not paced",
+			limits: nil,
+		},
+		{
+			name:           "spare tokens are spread until the reset",
+			limits:         []RateLimits{limits(510, 1100)},
+			expectedSleeps: []time.Duration{10 * time.Second, 10 * time.Second},
+		},
+		{
+			name:           "waits for the reset without spare tokens",
+			limits:         []RateLimits{limits(400, 1060), limits(5000, 1000+3600)},
+			expectedSleeps: []time.Duration{61 * time.Second, 800 * time.Millisecond, 800 * time.Millisecond},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var sleeps []time.Duration
+			p := &bulkPacer{
+				client: &fakeBulkClient{limits: tc.limits},
+				log:    logrus.NewEntry(logrus.New()),
+				opts: BulkOptions{
+					MinRemainingTokens: 500,
+					now:                func() time.Time { return now },
+					sleep: func(_ context.Context, d time.Duration) error {
+						sleeps = append(sleeps, d)
+						return nil
+					},
+				},
+			}
+			for i := 0; i < 2; i++ {
+				if err := p.wait(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedSleeps, sleeps); diff != "" {
+				t.Errorf("unexpected sleeps (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
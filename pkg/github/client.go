@@ -42,6 +42,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"sigs.k8s.io/prow/pkg/ghcache"
+	"sigs.k8s.io/prow/pkg/github/ghmetrics"
 	"sigs.k8s.io/prow/pkg/throttle"
 	"sigs.k8s.io/prow/pkg/version"
 )
@@ -156,6 +157,7 @@ type CommitClient interface {
 	ListCheckRuns(org, repo, ref string) (*CheckRunList, error)
 	GetRef(org, repo, ref string) (string, error)
 	DeleteRef(org, repo, ref string) error
+	CompareCommits(org, repo, base, head string) (*CommitComparison, error)
 	ListFileCommits(org, repo, path string) ([]RepositoryCommit, error)
 	CreateCheckRun(org, repo string, checkRun CheckRun) error
 }
@@ -168,6 +170,7 @@ type RepositoryClient interface {
 	GetBranchProtection(org, repo, branch string) (*BranchProtection, error)
 	RemoveBranchProtection(org, repo, branch string) error
 	UpdateBranchProtection(org, repo, branch string, config BranchProtectionRequest) error
+	UpdateRequiredStatusChecks(org, repo, branch string, checks RequiredStatusChecks) error
 	AddRepoLabel(org, repo, label, description, color string) error
 	UpdateRepoLabel(org, repo, label, newName, description, color string) error
 	DeleteRepoLabel(org, repo, label string) error
@@ -266,6 +269,7 @@ type Client interface {
 	IsAppInstalled(org, repo string) (bool, error)
 	UsesAppAuth() bool
 	ListAppInstallationsForOrg(org string) ([]AppInstallation, error)
+	ListAppInstallationRepos(org string) ([]Repo, error)
 	GetApp() (*App, error)
 	GetAppWithContext(ctx context.Context) (*App, error)
 	GetFailedActionRunsByHeadBranch(org, repo, branchName, headSHA string) ([]WorkflowRun, error)
@@ -275,6 +279,7 @@ type Client interface {
 	MutateWithGitHubAppsSupport(ctx context.Context, m interface{}, input githubql.Input, vars map[string]interface{}, org string) error
 
 	SetMax404Retries(int)
+	GetRateLimits(org string) (*RateLimits, error)
 
 	WithFields(fields logrus.Fields) Client
 	ForPlugin(plugin string) Client
@@ -360,6 +365,12 @@ func (c *client) userAgent() string {
 	return version.UserAgent()
 }
 
+// consumerFromUserAgent identifies the component, and the plugin if any,
+// to ghproxy so that it can attribute rate limit usage.
+func consumerFromUserAgent(userAgent string) string {
+	return strings.SplitN(userAgent, "/", 2)[0]
+}
+
 // WithFields clones the client, keeping the underlying delegate the same but adding
 // fields to the logging context
 func (c *client) WithFields(fields logrus.Fields) Client {
@@ -488,6 +499,28 @@ func (c *client) SetMax404Retries(max int) {
 	c.max404Retries = max
 }
 
+// GetRateLimits returns the API token budgets of the client, which are those
+// of the installation of the org when using GitHub apps auth. Asking for them
+// does not consume a token.
+//
+// See https://docs.github.com/en/rest/rate-limit/rate-limit#get-rate-limit-status-for-the-authenticated-user
+func (c *client) GetRateLimits(org string) (*RateLimits, error) {
+	durationLogger := c.log("GetRateLimits", org)
+	defer durationLogger()
+
+	var limits RateLimits
+	_, err := c.request(&request{
+		method:    http.MethodGet,
+		path:      "/rate_limit",
+		org:       org,
+		exitCodes: []int{200},
+	}, &limits)
+	if err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
 // ClientOptions holds options for creating a new client
 type ClientOptions struct {
 	// censor knows how to censor output
@@ -713,6 +746,7 @@ func (s *addHeaderTransport) RoundTrip(r *http.Request) (*http.Response, error)
 	// We use the context to pass the UserAgent through the V4 client we depend on
 	if v := r.Context().Value(userAgentContextKey); v != nil {
 		r.Header.Add("User-Agent", v.(string))
+		r.Header.Set(ghcache.ConsumerHeader, consumerFromUserAgent(v.(string)))
 	}
 
 	return s.upstream.RoundTrip(r)
@@ -846,6 +880,15 @@ func NewNotFound() error {
 	}
 }
 
+// NewNotFoundWithMessage returns a NotFound error with the given message
+// which may be useful for tests
+func NewNotFoundWithMessage(message string) error {
+	return requestError{
+		StatusCode:  http.StatusNotFound,
+		ErrorString: message,
+	}
+}
+
 func IsNotFound(err error) bool {
 	if err == nil {
 		return false
@@ -924,6 +967,34 @@ func (c *client) requestRawWithContext(ctx context.Context, r *request) (int, []
 	return resp.StatusCode, b, err
 }
 
+// secondaryRateLimitHasher attributes the secondary rate limits the client
+// runs into to the token it used.
+var secondaryRateLimitHasher = ghmetrics.NewCachingHasher()
+
+// secondaryRateLimit tells whether the response is a secondary rate limit or
+// abuse detection response and how long to wait before retrying. It logs and
+// records the event unless ghproxy answered the request on its own during
+// the cool-down of the token, as ghproxy already did so when it started.
+func (c *client) secondaryRateLimit(resp *http.Response, path string) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	wait, ok := ghcache.SecondaryRateLimit(resp.Header, resp.StatusCode, ghcache.PeekBody(resp))
+	if !ok {
+		return 0, false
+	}
+	logger := c.logger.WithFields(logrus.Fields{"path": path, "status": resp.StatusCode, "backoff": (wait + time.Second).String()})
+	if until := resp.Header.Get(ghcache.CoolDownHeader); until != "" {
+		logger.WithField("cool-down-until", until).Debug("Retrying after the cool-down of the token in ghproxy")
+		return wait, true
+	}
+	if resp.Request != nil {
+		ghmetrics.CollectGitHubSecondaryRateLimitMetrics(secondaryRateLimitHasher.Hash(resp.Request), path, c.userAgent())
+	}
+	logger.Warn("Hit a secondary rate limit of GitHub, retrying after it is lifted")
+	return wait, true
+}
+
 // Retry on transport failures. Retries on 500s, retries after sleep on
 // ratelimit exceeded, and retries 404s a couple times.
 // This function closes the response body iff it also returns an error.
@@ -952,6 +1023,19 @@ func (c *client) requestRetryWithContext(ctx context.Context, method, path, acce
 				c.logger.WithField("backoff", backoff.String()).Debug("Retrying 404")
 				c.time.Sleep(backoff)
 				backoff *= 2
+			} else if wait, ok := c.secondaryRateLimit(resp, path); ok {
+				// If we are getting secondary or abuse rate limited, we need to
+				// wait or else we risk continuing to make the situation worse.
+				// Sleep an extra second plus how long GitHub wants us to
+				// sleep. If it's going to take too long, then break.
+				sleepTime := wait + time.Second
+				if sleepTime < c.maxSleepTime {
+					c.time.Sleep(sleepTime)
+				} else {
+					err = fmt.Errorf("sleep time for secondary rate limit exceeds max sleep time (%v > %v)", sleepTime, c.maxSleepTime)
+					resp.Body.Close()
+					break
+				}
 			} else if resp.StatusCode == 403 {
 				if resp.Header.Get("X-RateLimit-Remaining") == "0" {
 					// If we are out of API tokens, sleep first. The X-RateLimit-Reset
@@ -974,27 +1058,6 @@ func (c *client) requestRetryWithContext(ctx context.Context, method, path, acce
 						resp.Body.Close()
 						break
 					}
-				} else if rawTime := resp.Header.Get("Retry-After"); rawTime != "" && rawTime != "0" {
-					// If we are getting abuse rate limited, we need to wait or
-					// else we risk continuing to make the situation worse
-					var t int
-					if t, err = strconv.Atoi(rawTime); err == nil {
-						// Sleep an extra second plus how long GitHub wants us to
-						// sleep. If it's going to take too long, then break.
-						sleepTime := time.Duration(t+1) * time.Second
-						if sleepTime < c.maxSleepTime {
-							c.logger.WithField("backoff", sleepTime.String()).WithField("path", path).Debug("Retrying after abuse ratelimit reset")
-							c.time.Sleep(sleepTime)
-						} else {
-							err = fmt.Errorf("sleep time for abuse rate limit exceeds max sleep time (%v > %v)", sleepTime, c.maxSleepTime)
-							resp.Body.Close()
-							break
-						}
-					} else {
-						err = fmt.Errorf("failed to parse abuse rate limit wait time %q: %w", rawTime, err)
-						resp.Body.Close()
-						break
-					}
 				} else {
 					acceptedScopes := resp.Header.Get("X-Accepted-OAuth-Scopes")
 					authorizedScopes := resp.Header.Get("X-OAuth-Scopes")
@@ -1080,6 +1143,7 @@ func (c *client) doRequest(ctx context.Context, method, path, accept, org string
 	}
 	if userAgent := c.userAgent(); userAgent != "" {
 		req.Header.Add("User-Agent", userAgent)
+		req.Header.Set(ghcache.ConsumerHeader, consumerFromUserAgent(userAgent))
 	}
 	if org != "" {
 		req = req.WithContext(context.WithValue(req.Context(), githubOrgContextKey, org))
@@ -2690,6 +2754,24 @@ func (c *client) UpdateBranchProtection(org, repo, branch string, config BranchP
 	return err
 }
 
+// UpdateRequiredStatusChecks configures the required status checks of
+// org/repo=branch, leaving the rest of its protection unchanged.
+//
+// See https://docs.github.com/en/rest/branches/branch-protection#update-status-check-protection
+func (c *client) UpdateRequiredStatusChecks(org, repo, branch string, checks RequiredStatusChecks) error {
+	durationLogger := c.log("UpdateRequiredStatusChecks", org, repo, branch, checks)
+	defer durationLogger()
+
+	_, err := c.request(&request{
+		method:      http.MethodPatch,
+		path:        fmt.Sprintf("/repos/%s/%s/branches/%s/protection/required_status_checks", org, repo, branch),
+		org:         org,
+		requestBody: checks,
+		exitCodes:   []int{200},
+	}, nil)
+	return err
+}
+
 // AddRepoLabel adds a defined label given org/repo
 //
 // See https://developer.github.com/v3/issues/labels/#create-a-label
@@ -3376,6 +3458,27 @@ func (c *client) DeleteRef(org, repo, ref string) error {
 	return err
 }
 
+// CompareCommits compares the head commit with the base commit, e.g. to tell
+// by how many commits a branch advanced past a SHA.
+//
+// See https://docs.github.com/en/rest/commits/commits#compare-two-commits
+func (c *client) CompareCommits(org, repo, base, head string) (*CommitComparison, error) {
+	durationLogger := c.log("CompareCommits", org, repo, base, head)
+	defer durationLogger()
+
+	var comparison CommitComparison
+	_, err := c.request(&request{
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("/repos/%s/%s/compare/%s...%s", org, repo, base, head),
+		org:       org,
+		exitCodes: []int{200},
+	}, &comparison)
+	if err != nil {
+		return nil, err
+	}
+	return &comparison, nil
+}
+
 // ListFileCommits returns the commits for this file path.
 //
 // See https://developer.github.com/v3/repos/#list-commits
@@ -3468,6 +3571,11 @@ type FileNotFound struct {
 	org, repo, path, commit string
 }
 
+// NewFileNotFound returns a FileNotFound error which may be useful for tests
+func NewFileNotFound(org, repo, path, commit string) *FileNotFound {
+	return &FileNotFound{org: org, repo: repo, path: path, commit: commit}
+}
+
 func (e *FileNotFound) Error() string {
 	return fmt.Sprintf("%s/%s/%s @ %s not found", e.org, e.repo, e.path, e.commit)
 }
@@ -4743,6 +4851,35 @@ func (c *client) ListAppInstallationsForOrg(org string) ([]AppInstallation, erro
 	return ais, nil
 }
 
+// ListAppInstallationRepos lists the repositories the installation of the
+// current app for the org can access. Will not work with a Personal Access Token.
+//
+// See https://docs.github.com/en/rest/apps/installations#list-repositories-accessible-to-the-app-installation
+func (c *client) ListAppInstallationRepos(org string) ([]Repo, error) {
+	durationLogger := c.log("ListAppInstallationRepos", org)
+	defer durationLogger()
+
+	if !c.usesAppsAuth {
+		return nil, fmt.Errorf("ListAppInstallationRepos was called when not using appsAuth")
+	}
+
+	var repos []Repo
+	if err := c.readPaginatedResults(
+		"/installation/repositories",
+		acceptNone,
+		org,
+		func() interface{} {
+			return &InstallationRepositoryList{}
+		},
+		func(obj interface{}) {
+			repos = append(repos, obj.(*InstallationRepositoryList).Repositories...)
+		},
+	); err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
 func (c *client) getAppInstallationToken(installationId int64) (*AppInstallationToken, error) {
 	durationLogger := c.log("AppInstallationToken")
 	defer durationLogger()
@@ -41,6 +41,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/diff"
 
+	"sigs.k8s.io/prow/pkg/ghcache"
 	"sigs.k8s.io/prow/pkg/throttle"
 	"sigs.k8s.io/prow/pkg/version"
 )
@@ -132,6 +133,83 @@ func TestAbuseRateLimit(t *testing.T) {
 	}
 }
 
+func TestSecondaryRateLimit(t *testing.T) {
+	testCases := []struct {
+		name       string
+		status     int
+		header     map[string]string
+		body       string
+		maxSleep   time.Duration
+		expectErr  bool
+		expectWait time.Duration
+	}{
+		{
+			name:       "429 with a secondary rate limit message and no Retry-After waits the default",
+			status:     http.StatusTooManyRequests,
+			body:       `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`,
+			maxSleep:   2 * time.Minute,
+			expectWait: ghcache.DefaultSecondaryRateLimitWait + time.Second,
+		},
+		{
+			name:       "403 abuse detection with Retry-After waits as asked",
+			status:     http.StatusForbidden,
+			header:     map[string]string{"Retry-After": "30"},
+			body:       `{"message":"You have triggered an abuse detection mechanism."}`,
+			maxSleep:   2 * time.Minute,
+			expectWait: 31 * time.Second,
+		},
+		{
+			name:       "ghproxy cool-down response waits as asked",
+			status:     http.StatusTooManyRequests,
+			header:     map[string]string{"Retry-After": "5", ghcache.CoolDownHeader: "2024-01-01T00:00:00Z"},
+			body:       `{"message":"You have exceeded a secondary rate limit."}`,
+			maxSleep:   2 * time.Minute,
+			expectWait: 6 * time.Second,
+		},
+		{
+			name:      "wait longer than the max sleep time errors",
+			status:    http.StatusTooManyRequests,
+			body:      `{"message":"You have exceeded a secondary rate limit."}`,
+			maxSleep:  time.Second,
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := &testTime{now: time.Now()}
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.slept == 0 {
+					for k, v := range tc.header {
+						w.Header().Set(k, v)
+					}
+					w.WriteHeader(tc.status)
+					fmt.Fprint(w, tc.body)
+				}
+			}))
+			defer ts.Close()
+			c := getClient(ts.URL)
+			c.time = tt
+			c.maxSleepTime = tc.maxSleep
+			resp, err := c.requestRetry(http.MethodGet, "/", "", "", nil)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got status %d", resp.StatusCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error from request: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected status code 200, got %d", resp.StatusCode)
+			}
+			if tt.slept != tc.expectWait {
+				t.Errorf("Expected to sleep for %v, got %v", tc.expectWait, tt.slept)
+			}
+		})
+	}
+}
+
 func TestRetry404(t *testing.T) {
 	tc := &testTime{now: time.Now()}
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -2202,6 +2280,36 @@ func TestGetBranches(t *testing.T) {
 	}
 }
 
+func TestGetRateLimits(t *testing.T) {
+	var expected RateLimits
+	expected.Resources.Core = RateLimit{Limit: 5000, Remaining: 4321, Reset: 1700000000}
+	expected.Resources.Search = RateLimit{Limit: 30, Remaining: 29, Reset: 1700000060}
+	ts := simpleTestServer(t, "/rate_limit", expected, http.StatusOK)
+	defer ts.Close()
+	c := getClient(ts.URL)
+	limits, err := c.GetRateLimits("org")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(&expected, limits); diff != "" {
+		t.Errorf("Unexpected rate limits (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompareCommits(t *testing.T) {
+	expected := CommitComparison{Status: "diverged", AheadBy: 3, BehindBy: 1}
+	ts := simpleTestServer(t, "/repos/org/repo/compare/base...head", expected, http.StatusOK)
+	defer ts.Close()
+	c := getClient(ts.URL)
+	comparison, err := c.CompareCommits("org", "repo", "base", "head")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(&expected, comparison); diff != "" {
+		t.Errorf("Unexpected comparison (-want +got):\n%s", diff)
+	}
+}
+
 func TestGetBranchProtection(t *testing.T) {
 	contexts := []string{"foo-pr-test", "other"}
 	pushers := []Team{{Slug: "movers"}, {Slug: "awesome-team"}, {Slug: "shakers"}}
@@ -2441,6 +2549,34 @@ func TestUpdateBranchProtection(t *testing.T) {
 	}
 }
 
+func TestUpdateRequiredStatusChecks(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/org/repo/branches/master/protection/required_status_checks" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var checks RequiredStatusChecks
+		if err := json.Unmarshal(b, &checks); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		}
+		if expected := (RequiredStatusChecks{Strict: true, Contexts: []string{"foo-pr-test"}}); !reflect.DeepEqual(checks, expected) {
+			t.Errorf("Bad required status checks: %v, expected %v", checks, expected)
+		}
+		http.Error(w, "200 OK", http.StatusOK)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.UpdateRequiredStatusChecks("org", "repo", "master", RequiredStatusChecks{Strict: true, Contexts: []string{"foo-pr-test"}}); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
 func TestClearMilestone(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPatch {
@@ -2888,6 +3024,7 @@ func TestAuthHeaderGetsSet(t *testing.T) {
 			// Bazel injects some stuff in here, exclude it from comparison so both bazel test
 			// and go test yield the same result.
 			delete(fake.received[0].Header, "User-Agent")
+			fake.received[0].Header.Del(ghcache.ConsumerHeader)
 			if diff := cmp.Diff(tc.expectedHeader, fake.received[0].Header); diff != "" {
 				t.Errorf("expected header differs from actual: %s", diff)
 			}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegithub
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// The builders below populate the fake and return it, so that the state a
+// test starts from can be set up in a single expression:
+//
+//	fc := fakegithub.NewFakeClient().
+//		WithPullRequests(github.PullRequest{Number: 1, User: github.User{Login: "author"}}).
+//		WithIssueLabels("org", "repo", 1, "lgtm").
+//		WithOrgMembers("org", "approver")
+
+// WithIssues adds the issues, keyed by their number.
+func (f *FakeClient) WithIssues(issues ...github.Issue) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.Issues == nil {
+		f.Issues = map[int]*github.Issue{}
+	}
+	for i := range issues {
+		issue := issues[i]
+		f.Issues[issue.Number] = &issue
+	}
+	return f
+}
+
+// WithPullRequests adds the pull requests, keyed by their number.
+func (f *FakeClient) WithPullRequests(prs ...github.PullRequest) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.PullRequests == nil {
+		f.PullRequests = map[int]*github.PullRequest{}
+	}
+	for i := range prs {
+		pr := prs[i]
+		f.PullRequests[pr.Number] = &pr
+	}
+	return f
+}
+
+// WithIssueComments adds comments to the issue or pull request. Comments
+// without an ID are assigned the next free one.
+func (f *FakeClient) WithIssueComments(number int, comments ...github.IssueComment) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.IssueComments == nil {
+		f.IssueComments = map[int][]github.IssueComment{}
+	}
+	for _, comment := range comments {
+		if comment.ID == 0 {
+			f.IssueCommentID++
+			comment.ID = f.IssueCommentID
+		}
+		f.IssueComments[number] = append(f.IssueComments[number], comment)
+	}
+	return f
+}
+
+// WithReviews adds reviews to the pull request.
+func (f *FakeClient) WithReviews(number int, reviews ...github.Review) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.Reviews == nil {
+		f.Reviews = map[int][]github.Review{}
+	}
+	f.Reviews[number] = append(f.Reviews[number], reviews...)
+	return f
+}
+
+// WithPullRequestChanges sets the files changed by the pull request.
+func (f *FakeClient) WithPullRequestChanges(number int, changes ...github.PullRequestChange) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.PullRequestChanges == nil {
+		f.PullRequestChanges = map[int][]github.PullRequestChange{}
+	}
+	f.PullRequestChanges[number] = changes
+	return f
+}
+
+// WithRepoLabels adds labels that exist in the repo.
+func (f *FakeClient) WithRepoLabels(labels ...string) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.RepoLabelsExisting = append(f.RepoLabelsExisting, labels...)
+	return f
+}
+
+// WithIssueLabels adds labels to the issue or pull request. The labels are
+// also added to the repo if they don't exist yet.
+func (f *FakeClient) WithIssueLabels(org, repo string, number int, labels ...string) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	existing := map[string]bool{}
+	for _, label := range f.RepoLabelsExisting {
+		existing[label] = true
+	}
+	for _, label := range labels {
+		f.IssueLabelsExisting = append(f.IssueLabelsExisting, fmt.Sprintf("%s/%s#%d:%s", org, repo, number, label))
+		if !existing[label] {
+			f.RepoLabelsExisting = append(f.RepoLabelsExisting, label)
+			existing[label] = true
+		}
+	}
+	return f
+}
+
+// WithOrgMembers adds members to the org.
+func (f *FakeClient) WithOrgMembers(org string, logins ...string) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.OrgMembers == nil {
+		f.OrgMembers = map[string][]string{}
+	}
+	f.OrgMembers[org] = append(f.OrgMembers[org], logins...)
+	return f
+}
+
+// WithCollaborators adds collaborators to every repo.
+func (f *FakeClient) WithCollaborators(logins ...string) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.Collaborators = append(f.Collaborators, logins...)
+	return f
+}
+
+// WithFile adds a file to the fake remote storage at the given ref. Files at
+// the "master" ref are returned when no ref is requested.
+func (f *FakeClient) WithFile(path, ref, content string) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.RemoteFiles == nil {
+		f.RemoteFiles = map[string]map[string]string{}
+	}
+	if f.RemoteFiles[path] == nil {
+		f.RemoteFiles[path] = map[string]string{}
+	}
+	f.RemoteFiles[path][ref] = content
+	return f
+}
+
+// WithCombinedStatus sets the combined status of the ref.
+func (f *FakeClient) WithCombinedStatus(ref string, status github.CombinedStatus) *FakeClient {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.CombinedStatuses == nil {
+		f.CombinedStatuses = map[string]*github.CombinedStatus{}
+	}
+	f.CombinedStatuses[ref] = &status
+	return f
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakegithub provides an in-memory fake of the GitHub client for unit
+// tests of plugins and other Prow components, including external plugins.
+//
+// The FakeClient keeps its state in exported fields that tests may set up
+// directly or with the With* builders, and that record the mutations made
+// by the code under test (e.g. IssueLabelsAdded or IssueCommentsAdded):
+//
+//	fc := fakegithub.NewFakeClient().
+//		WithPullRequests(github.PullRequest{Number: 1}).
+//		WithIssueLabels("org", "repo", 1, "lgtm")
+//	// ... run the code under test against fc ...
+//	if len(fc.IssueLabelsAdded) != 1 { ... }
+//
+// The fake behaves like the real client where tests commonly depend on it:
+//
+//   - List methods return every item at once, like the real client does
+//     after following pagination.
+//   - Missing issues and pull requests yield errors for which
+//     github.IsNotFound returns true, and missing files and directories yield
+//     a *github.FileNotFound.
+//
+// Failures can be injected per method with InjectFault, InjectFaultTimes and
+// InjectNotFound to test error handling and retries:
+//
+//	fc.InjectFaultTimes("CreateComment", errors.New("injected"), 2)
+//
+// The fake is safe for concurrent use. Only the exported API of this package
+// is supported; the fake does not model permissions, rate limits or events.
+package fakegithub
@@ -101,6 +101,9 @@ type FakeClient struct {
 	// and values map SHA to directory content
 	RemoteDirectories map[string]map[string][]github.DirectoryContent
 
+	// Maps org/repo to its branches
+	Branches map[string][]github.Branch
+
 	// A list of refs that got deleted via DeleteRef
 	RefsDeleted []struct{ Org, Repo, Ref string }
 
@@ -147,6 +150,10 @@ type FakeClient struct {
 	// lock to be thread safe
 	lock sync.RWMutex
 
+	// faults are the errors injected with InjectFault, by method name
+	faults    map[string]*fault
+	faultLock sync.Mutex
+
 	// Team is a map org->teamSlug->TeamWithMembers
 	Teams map[string]map[string]TeamWithMembers
 
@@ -160,6 +167,9 @@ type TeamWithMembers struct {
 }
 
 func (f *FakeClient) BotUser() (*github.UserData, error) {
+	if err := f.injectedFault("BotUser"); err != nil {
+		return nil, err
+	}
 	return &github.UserData{Login: botName}, nil
 }
 
@@ -207,6 +217,9 @@ func NewFakeClient() *FakeClient {
 
 // IsMember returns true if user is in org.
 func (f *FakeClient) IsMember(org, user string) (bool, error) {
+	if err := f.injectedFault("IsMember"); err != nil {
+		return false, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	for _, m := range f.OrgMembers[org] {
@@ -218,6 +231,9 @@ func (f *FakeClient) IsMember(org, user string) (bool, error) {
 }
 
 func (f *FakeClient) WasLabelAddedByHuman(_, _ string, _ int, _ string) (bool, error) {
+	if err := f.injectedFault("WasLabelAddedByHuman"); err != nil {
+		return false, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return f.WasLabelAddedByHumanVal, nil
@@ -226,6 +242,9 @@ func (f *FakeClient) WasLabelAddedByHuman(_, _ string, _ int, _ string) (bool, e
 // ListOpenIssues returns f.issues
 // To mock a mix of issues and pull requests, see github.Issue.PullRequest
 func (f *FakeClient) ListOpenIssues(org, repo string) ([]github.Issue, error) {
+	if err := f.injectedFault("ListOpenIssues"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	var issues []github.Issue
@@ -241,6 +260,9 @@ func (f *FakeClient) ListIssueComments(owner, repo string, number int) ([]github
 }
 
 func (f *FakeClient) ListIssueCommentsWithContext(ctx context.Context, owner, repo string, number int) ([]github.IssueComment, error) {
+	if err := f.injectedFault("ListIssueComments"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	if f.ListIssueCommentsWithContextError != nil {
@@ -251,6 +273,9 @@ func (f *FakeClient) ListIssueCommentsWithContext(ctx context.Context, owner, re
 
 // ListPullRequestComments returns review comments.
 func (f *FakeClient) ListPullRequestComments(owner, repo string, number int) ([]github.ReviewComment, error) {
+	if err := f.injectedFault("ListPullRequestComments"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return append([]github.ReviewComment{}, f.PullRequestComments[number]...), nil
@@ -258,6 +283,9 @@ func (f *FakeClient) ListPullRequestComments(owner, repo string, number int) ([]
 
 // ListReviews returns reviews.
 func (f *FakeClient) ListReviews(owner, repo string, number int) ([]github.Review, error) {
+	if err := f.injectedFault("ListReviews"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return append([]github.Review{}, f.Reviews[number]...), nil
@@ -265,6 +293,9 @@ func (f *FakeClient) ListReviews(owner, repo string, number int) ([]github.Revie
 
 // ListIssueEvents returns issue events
 func (f *FakeClient) ListIssueEvents(owner, repo string, number int) ([]github.ListedIssueEvent, error) {
+	if err := f.injectedFault("ListIssueEvents"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return append([]github.ListedIssueEvent{}, f.IssueEvents[number]...), nil
@@ -276,6 +307,9 @@ func (f *FakeClient) CreateComment(owner, repo string, number int, comment strin
 }
 
 func (f *FakeClient) CreateCommentWithContext(_ context.Context, owner, repo string, number int, comment string) error {
+	if err := f.injectedFault("CreateComment"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.IssueCommentID++
@@ -294,6 +328,9 @@ func (f *FakeClient) EditComment(org, repo string, ID int, comment string) error
 }
 
 func (f *FakeClient) EditCommentWithContext(_ context.Context, org, repo string, ID int, comment string) error {
+	if err := f.injectedFault("EditComment"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.IssueCommentsEdited = append(f.IssueCommentsEdited, fmt.Sprintf("%s/%s#%d:%s", org, repo, ID, comment))
@@ -309,6 +346,9 @@ func (f *FakeClient) EditCommentWithContext(_ context.Context, org, repo string,
 
 // CreateReview adds a review to a PR
 func (f *FakeClient) CreateReview(org, repo string, number int, r github.DraftReview) error {
+	if err := f.injectedFault("CreateReview"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.ReviewID++
@@ -322,6 +362,9 @@ func (f *FakeClient) CreateReview(org, repo string, number int, r github.DraftRe
 
 // CreateCommentReaction adds emoji to a comment.
 func (f *FakeClient) CreateCommentReaction(org, repo string, ID int, reaction string) error {
+	if err := f.injectedFault("CreateCommentReaction"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.CommentReactionsAdded = append(f.CommentReactionsAdded, fmt.Sprintf("%s/%s#%d:%s", org, repo, ID, reaction))
@@ -330,6 +373,9 @@ func (f *FakeClient) CreateCommentReaction(org, repo string, ID int, reaction st
 
 // CreateIssueReaction adds an emoji to an issue.
 func (f *FakeClient) CreateIssueReaction(org, repo string, ID int, reaction string) error {
+	if err := f.injectedFault("CreateIssueReaction"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.IssueReactionsAdded = append(f.IssueReactionsAdded, fmt.Sprintf("%s/%s#%d:%s", org, repo, ID, reaction))
@@ -342,6 +388,9 @@ func (f *FakeClient) DeleteComment(owner, repo string, ID int) error {
 }
 
 func (f *FakeClient) DeleteCommentWithContext(_ context.Context, owner, repo string, ID int) error {
+	if err := f.injectedFault("DeleteComment"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.IssueCommentsDeleted = append(f.IssueCommentsDeleted, fmt.Sprintf("%s/%s#%d", owner, repo, ID))
@@ -363,6 +412,9 @@ func (f *FakeClient) DeleteStaleComments(org, repo string, number int, comments
 
 // DeleteStaleCommentsWithContext deletes comments flagged by isStale with a provided context.
 func (f *FakeClient) DeleteStaleCommentsWithContext(ctx context.Context, org, repo string, number int, comments []github.IssueComment, isStale func(github.IssueComment) bool) error {
+	if err := f.injectedFault("DeleteStaleComments"); err != nil {
+		return err
+	}
 	if comments == nil {
 		comments, _ = f.ListIssueComments(org, repo, number)
 	}
@@ -378,21 +430,27 @@ func (f *FakeClient) DeleteStaleCommentsWithContext(ctx context.Context, org, re
 
 // GetPullRequest returns details about the PR.
 func (f *FakeClient) GetPullRequest(owner, repo string, number int) (*github.PullRequest, error) {
+	if err := f.injectedFault("GetPullRequest"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	val, exists := f.PullRequests[number]
 	if !exists {
-		return nil, fmt.Errorf("pull request number %d does not exist", number)
+		return nil, github.NewNotFoundWithMessage(fmt.Sprintf("pull request number %d does not exist", number))
 	}
 	return val, nil
 }
 
 // EditPullRequest edits the pull request.
 func (f *FakeClient) EditPullRequest(org, repo string, number int, issue *github.PullRequest) (*github.PullRequest, error) {
+	if err := f.injectedFault("EditPullRequest"); err != nil {
+		return nil, err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	if _, exists := f.PullRequests[number]; !exists {
-		return nil, fmt.Errorf("issue number %d does not exist", number)
+		return nil, github.NewNotFoundWithMessage(fmt.Sprintf("issue number %d does not exist", number))
 	}
 	f.PullRequests[number] = issue
 	return issue, nil
@@ -400,21 +458,27 @@ func (f *FakeClient) EditPullRequest(org, repo string, number int, issue *github
 
 // GetIssue returns the issue.
 func (f *FakeClient) GetIssue(owner, repo string, number int) (*github.Issue, error) {
+	if err := f.injectedFault("GetIssue"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	val, exists := f.Issues[number]
 	if !exists {
-		return nil, fmt.Errorf("issue number %d does not exist", number)
+		return nil, github.NewNotFoundWithMessage(fmt.Sprintf("issue number %d does not exist", number))
 	}
 	return val, nil
 }
 
 // EditIssue edits the issue.
 func (f *FakeClient) EditIssue(org, repo string, number int, issue *github.Issue) (*github.Issue, error) {
+	if err := f.injectedFault("EditIssue"); err != nil {
+		return nil, err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	if _, exists := f.Issues[number]; !exists {
-		return nil, fmt.Errorf("issue number %d does not exist", number)
+		return nil, github.NewNotFoundWithMessage(fmt.Sprintf("issue number %d does not exist", number))
 	}
 	f.Issues[number] = issue
 	return issue, nil
@@ -422,6 +486,9 @@ func (f *FakeClient) EditIssue(org, repo string, number int, issue *github.Issue
 
 // CreateIssue creates the issue.
 func (f *FakeClient) CreateIssue(org, repo, title, body string, milestone int, labels, assignees []string) (int, error) {
+	if err := f.injectedFault("CreateIssue"); err != nil {
+		return 0, err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.IssueID++
@@ -450,11 +517,14 @@ func (f *FakeClient) CreateIssue(org, repo, title, body string, milestone int, l
 }
 
 func (f *FakeClient) CloseIssue(org, repo string, number int) error {
+	if err := f.injectedFault("CloseIssue"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
 	if _, ok := f.Issues[number]; !ok {
-		return fmt.Errorf("issue number %d does not exist", number)
+		return github.NewNotFoundWithMessage(fmt.Sprintf("issue number %d does not exist", number))
 	}
 
 	f.Issues[number].State = "closed"
@@ -464,11 +534,14 @@ func (f *FakeClient) CloseIssue(org, repo string, number int) error {
 }
 
 func (f *FakeClient) CloseIssueAsNotPlanned(org, repo string, number int) error {
+	if err := f.injectedFault("CloseIssueAsNotPlanned"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
 	if _, ok := f.Issues[number]; !ok {
-		return fmt.Errorf("issue number %d does not exist", number)
+		return github.NewNotFoundWithMessage(fmt.Sprintf("issue number %d does not exist", number))
 	}
 
 	f.Issues[number].State = "closed"
@@ -479,6 +552,9 @@ func (f *FakeClient) CloseIssueAsNotPlanned(org, repo string, number int) error
 
 // GetPullRequestChanges returns the file modifications in a PR.
 func (f *FakeClient) GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error) {
+	if err := f.injectedFault("GetPullRequestChanges"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return f.PullRequestChanges[number], nil
@@ -486,19 +562,44 @@ func (f *FakeClient) GetPullRequestChanges(org, repo string, number int) ([]gith
 
 // GetRef returns the hash of a ref.
 func (f *FakeClient) GetRef(owner, repo, ref string) (string, error) {
+	if err := f.injectedFault("GetRef"); err != nil {
+		return "", err
+	}
 	return TestRef, nil
 }
 
 // DeleteRef returns an error indicating if deletion of the given ref was successful
 func (f *FakeClient) DeleteRef(owner, repo, ref string) error {
+	if err := f.injectedFault("DeleteRef"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.RefsDeleted = append(f.RefsDeleted, struct{ Org, Repo, Ref string }{Org: owner, Repo: repo, Ref: ref})
 	return nil
 }
 
+// GetBranches returns the branches of the repo.
+func (f *FakeClient) GetBranches(org, repo string, onlyProtected bool) ([]github.Branch, error) {
+	if err := f.injectedFault("GetBranches"); err != nil {
+		return nil, err
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	var branches []github.Branch
+	for _, branch := range f.Branches[org+"/"+repo] {
+		if !onlyProtected || branch.Protected {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
 // GetSingleCommit returns a single commit.
 func (f *FakeClient) GetSingleCommit(org, repo, SHA string) (github.RepositoryCommit, error) {
+	if err := f.injectedFault("GetSingleCommit"); err != nil {
+		return github.RepositoryCommit{}, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return f.Commits[SHA], nil
@@ -509,6 +610,9 @@ func (f *FakeClient) CreateStatus(owner, repo, SHA string, s github.Status) erro
 	return f.CreateStatusWithContext(context.Background(), owner, repo, SHA, s)
 }
 func (f *FakeClient) CreateStatusWithContext(_ context.Context, owner, repo, SHA string, s github.Status) error {
+	if err := f.injectedFault("CreateStatus"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	if f.Error != nil {
@@ -538,6 +642,9 @@ func (f *FakeClient) CreateStatusWithContext(_ context.Context, owner, repo, SHA
 
 // ListStatuses returns individual status contexts on a commit.
 func (f *FakeClient) ListStatuses(org, repo, ref string) ([]github.Status, error) {
+	if err := f.injectedFault("ListStatuses"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return f.CreatedStatuses[ref], nil
@@ -545,6 +652,9 @@ func (f *FakeClient) ListStatuses(org, repo, ref string) ([]github.Status, error
 
 // GetCombinedStatus returns the overall status for a commit.
 func (f *FakeClient) GetCombinedStatus(owner, repo, ref string) (*github.CombinedStatus, error) {
+	if err := f.injectedFault("GetCombinedStatus"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return f.CombinedStatuses[ref], nil
@@ -552,6 +662,9 @@ func (f *FakeClient) GetCombinedStatus(owner, repo, ref string) (*github.Combine
 
 // GetRepoLabels gets labels in a repo.
 func (f *FakeClient) GetRepoLabels(owner, repo string) ([]github.Label, error) {
+	if err := f.injectedFault("GetRepoLabels"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	la := []github.Label{}
@@ -563,6 +676,9 @@ func (f *FakeClient) GetRepoLabels(owner, repo string) ([]github.Label, error) {
 
 // AddRepoLabel adds a defined label given org/repo
 func (f *FakeClient) AddRepoLabel(org, repo, label, description, color string) error {
+	if err := f.injectedFault("AddRepoLabel"); err != nil {
+		return err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 
@@ -572,6 +688,9 @@ func (f *FakeClient) AddRepoLabel(org, repo, label, description, color string) e
 
 // GetIssueLabels gets labels on an issue
 func (f *FakeClient) GetIssueLabels(owner, repo string, number int) ([]github.Label, error) {
+	if err := f.injectedFault("GetIssueLabels"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	re := regexp.MustCompile(fmt.Sprintf(`^%s/%s#%d:(.*)$`, owner, repo, number))
@@ -590,11 +709,17 @@ func (f *FakeClient) GetIssueLabels(owner, repo string, number int) ([]github.La
 
 // AddLabel adds a label
 func (f *FakeClient) AddLabel(owner, repo string, number int, label string) error {
+	if err := f.injectedFault("AddLabel"); err != nil {
+		return err
+	}
 	return f.AddLabelsWithContext(context.Background(), owner, repo, number, label)
 }
 
 // AddLabelWithContext adds a label with a provided context
 func (f *FakeClient) AddLabelWithContext(ctx context.Context, owner, repo string, number int, label string) error {
+	if err := f.injectedFault("AddLabel"); err != nil {
+		return err
+	}
 	return f.AddLabelsWithContext(context.Background(), owner, repo, number, label)
 }
 
@@ -605,6 +730,9 @@ func (f *FakeClient) AddLabels(owner, repo string, number int, labels ...string)
 
 // AddLabelsWithContext adds a list of labels with a provided context
 func (f *FakeClient) AddLabelsWithContext(ctx context.Context, owner, repo string, number int, labels ...string) error {
+	if err := f.injectedFault("AddLabels"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	for _, label := range labels {
@@ -639,6 +767,9 @@ func (f *FakeClient) RemoveLabel(owner, repo string, number int, label string) e
 
 // RemoveLabelWithContext removes a label with a provided context
 func (f *FakeClient) RemoveLabelWithContext(ctx context.Context, owner, repo string, number int, label string) error {
+	if err := f.injectedFault("RemoveLabel"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	labelString := fmt.Sprintf("%s/%s#%d:%s", owner, repo, number, label)
@@ -651,11 +782,17 @@ func (f *FakeClient) RemoveLabelWithContext(ctx context.Context, owner, repo str
 
 // FindIssues returns the same results as FindIssuesWithOrg
 func (f *FakeClient) FindIssues(query, sort string, asc bool) ([]github.Issue, error) {
+	if err := f.injectedFault("FindIssues"); err != nil {
+		return nil, err
+	}
 	return f.FindIssuesWithOrg("", query, sort, asc)
 }
 
 // FindIssuesWithOrg returns f.Issues
 func (f *FakeClient) FindIssuesWithOrg(org, query, sort string, asc bool) ([]github.Issue, error) {
+	if err := f.injectedFault("FindIssuesWithOrg"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	var issues []github.Issue
@@ -673,6 +810,9 @@ func (f *FakeClient) FindIssuesWithOrg(org, query, sort string, asc bool) ([]git
 
 // AssignIssue adds assignees.
 func (f *FakeClient) AssignIssue(owner, repo string, number int, assignees []string) error {
+	if err := f.injectedFault("AssignIssue"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	var m github.MissingUsers
@@ -691,29 +831,35 @@ func (f *FakeClient) AssignIssue(owner, repo string, number int, assignees []str
 
 // GetFile returns the bytes of the file.
 func (f *FakeClient) GetFile(org, repo, file, commit string) ([]byte, error) {
+	if err := f.injectedFault("GetFile"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	contents, ok := f.RemoteFiles[file]
 	if !ok {
-		return nil, fmt.Errorf("could not find file %s", file)
+		return nil, github.NewFileNotFound(org, repo, file, commit)
 	}
 	if commit == "" {
 		if master, ok := contents["master"]; ok {
 			return []byte(master), nil
 		}
 
-		return nil, fmt.Errorf("could not find file %s in master", file)
+		return nil, github.NewFileNotFound(org, repo, file, commit)
 	}
 
 	if content, ok := contents[commit]; ok {
 		return []byte(content), nil
 	}
 
-	return nil, fmt.Errorf("could not find file %s with ref %s", file, commit)
+	return nil, github.NewFileNotFound(org, repo, file, commit)
 }
 
 // ListTeams return a list of fake teams that correspond to the fake team members returned by ListTeamMembers
 func (f *FakeClient) ListTeams(org string) ([]github.Team, error) {
+	if err := f.injectedFault("ListTeams"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return []github.Team{
@@ -732,6 +878,9 @@ func (f *FakeClient) ListTeams(org string) ([]github.Team, error) {
 
 // ListTeamMembers return a fake team with a single "sig-lead" GitHub teammember
 func (f *FakeClient) ListTeamMembers(org string, teamID int, role string) ([]github.TeamMember, error) {
+	if err := f.injectedFault("ListTeamMembers"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	if role != github.RoleAll {
@@ -750,6 +899,9 @@ func (f *FakeClient) ListTeamMembers(org string, teamID int, role string) ([]git
 
 // ListTeamMembers return a fake team with a single "sig-lead" GitHub teammember
 func (f *FakeClient) ListTeamMembersBySlug(org, teamSlug, role string) ([]github.TeamMember, error) {
+	if err := f.injectedFault("ListTeamMembersBySlug"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	if role != github.RoleAll {
@@ -767,6 +919,9 @@ func (f *FakeClient) ListTeamMembersBySlug(org, teamSlug, role string) ([]github
 }
 
 func (f *FakeClient) TeamBySlugHasMember(org string, teamSlug string, memberLogin string) (bool, error) {
+	if err := f.injectedFault("TeamBySlugHasMember"); err != nil {
+		return false, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	if f.Teams[org] != nil {
@@ -777,6 +932,9 @@ func (f *FakeClient) TeamBySlugHasMember(org string, teamSlug string, memberLogi
 
 // IsCollaborator returns true if the user is a collaborator of the repo.
 func (f *FakeClient) IsCollaborator(org, repo, login string) (bool, error) {
+	if err := f.injectedFault("IsCollaborator"); err != nil {
+		return false, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	normed := github.NormLogin(login)
@@ -790,6 +948,9 @@ func (f *FakeClient) IsCollaborator(org, repo, login string) (bool, error) {
 
 // ListCollaborators lists the collaborators.
 func (f *FakeClient) ListCollaborators(org, repo string) ([]github.User, error) {
+	if err := f.injectedFault("ListCollaborators"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	result := make([]github.User, 0, len(f.Collaborators))
@@ -801,12 +962,18 @@ func (f *FakeClient) ListCollaborators(org, repo string) ([]github.User, error)
 
 // ClearMilestone removes the milestone
 func (f *FakeClient) ClearMilestone(org, repo string, issueNum int) error {
+	if err := f.injectedFault("ClearMilestone"); err != nil {
+		return err
+	}
 	f.Milestone = 0
 	return nil
 }
 
 // SetMilestone sets the milestone.
 func (f *FakeClient) SetMilestone(org, repo string, issueNum, milestoneNum int) error {
+	if err := f.injectedFault("SetMilestone"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	if milestoneNum < 0 {
@@ -818,6 +985,9 @@ func (f *FakeClient) SetMilestone(org, repo string, issueNum, milestoneNum int)
 
 // ListMilestones lists milestones.
 func (f *FakeClient) ListMilestones(org, repo string) ([]github.Milestone, error) {
+	if err := f.injectedFault("ListMilestones"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	milestones := []github.Milestone{}
@@ -829,6 +999,9 @@ func (f *FakeClient) ListMilestones(org, repo string) ([]github.Milestone, error
 
 // ListPullRequestCommits lists commits for a given PR.
 func (f *FakeClient) ListPullRequestCommits(org, repo string, prNumber int) ([]github.RepositoryCommit, error) {
+	if err := f.injectedFault("ListPullRequestCommits"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	k := fmt.Sprintf("%s/%s#%d", org, repo, prNumber)
@@ -837,6 +1010,9 @@ func (f *FakeClient) ListPullRequestCommits(org, repo string, prNumber int) ([]g
 
 // GetRepoProjects returns the list of projects under a repo.
 func (f *FakeClient) GetRepoProjects(owner, repo string) ([]github.Project, error) {
+	if err := f.injectedFault("GetRepoProjects"); err != nil {
+		return nil, err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	return f.RepoProjects[fmt.Sprintf("%s/%s", owner, repo)], nil
@@ -844,6 +1020,9 @@ func (f *FakeClient) GetRepoProjects(owner, repo string) ([]github.Project, erro
 
 // GetOrgProjects returns the list of projects under an org
 func (f *FakeClient) GetOrgProjects(org string) ([]github.Project, error) {
+	if err := f.injectedFault("GetOrgProjects"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	return f.RepoProjects[fmt.Sprintf("%s/*", org)], nil
@@ -851,6 +1030,9 @@ func (f *FakeClient) GetOrgProjects(org string) ([]github.Project, error) {
 
 // GetProjectColumns returns the list of columns for a given project.
 func (f *FakeClient) GetProjectColumns(org string, projectID int) ([]github.ProjectColumn, error) {
+	if err := f.injectedFault("GetProjectColumns"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	defer f.lock.RUnlock()
 	// Get project name
@@ -866,6 +1048,9 @@ func (f *FakeClient) GetProjectColumns(org string, projectID int) ([]github.Proj
 
 // CreateProjectCard creates a project card under a given column.
 func (f *FakeClient) CreateProjectCard(org string, columnID int, projectCard github.ProjectCard) (*github.ProjectCard, error) {
+	if err := f.injectedFault("CreateProjectCard"); err != nil {
+		return nil, err
+	}
 	cards, err := f.GetColumnProjectCards(org, columnID)
 	if err != nil {
 		return nil, err
@@ -901,6 +1086,9 @@ func (f *FakeClient) CreateProjectCard(org string, columnID int, projectCard git
 
 // DeleteProjectCard deletes the project card of a specific issue or PR
 func (f *FakeClient) DeleteProjectCard(org string, projectCardID int) error {
+	if err := f.injectedFault("DeleteProjectCard"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	if f.ColumnCardsMap == nil {
@@ -935,6 +1123,9 @@ func (f *FakeClient) DeleteProjectCard(org string, projectCardID int) error {
 
 // GetColumnProjectCards fetches project cards  under given column
 func (f *FakeClient) GetColumnProjectCards(org string, columnID int) ([]github.ProjectCard, error) {
+	if err := f.injectedFault("GetColumnProjectCards"); err != nil {
+		return nil, err
+	}
 	f.lock.RLock()
 	if f.ColumnCardsMap == nil {
 		f.ColumnCardsMap = make(map[int][]github.ProjectCard)
@@ -946,6 +1137,9 @@ func (f *FakeClient) GetColumnProjectCards(org string, columnID int) ([]github.P
 
 // GetColumnProjectCard fetches project card if the content_url in the card matched the issue/pr
 func (f *FakeClient) GetColumnProjectCard(org string, columnID int, contentURL string) (*github.ProjectCard, error) {
+	if err := f.injectedFault("GetColumnProjectCard"); err != nil {
+		return nil, err
+	}
 	cards, err := f.GetColumnProjectCards(org, columnID)
 	if err != nil {
 		return nil, err
@@ -959,6 +1153,9 @@ func (f *FakeClient) GetColumnProjectCard(org string, columnID int, contentURL s
 }
 
 func (f *FakeClient) GetRepos(org string, isUser bool) ([]github.Repo, error) {
+	if err := f.injectedFault("GetRepos"); err != nil {
+		return nil, err
+	}
 	return []github.Repo{
 		{
 			Owner: github.User{
@@ -976,6 +1173,9 @@ func (f *FakeClient) GetRepos(org string, isUser bool) ([]github.Repo, error) {
 }
 
 func (f *FakeClient) GetRepo(owner, name string) (github.FullRepo, error) {
+	if err := f.injectedFault("GetRepo"); err != nil {
+		return github.FullRepo{}, err
+	}
 	if f.GetRepoError != nil {
 		return github.FullRepo{}, f.GetRepoError
 	}
@@ -993,6 +1193,9 @@ func (f *FakeClient) GetRepo(owner, name string) (github.FullRepo, error) {
 
 // MoveProjectCard moves a specific project card to a specified column in the same project
 func (f *FakeClient) MoveProjectCard(org string, projectCardID int, newColumnID int) error {
+	if err := f.injectedFault("MoveProjectCard"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	// Remove project card from old column
@@ -1038,6 +1241,9 @@ func (f *FakeClient) MoveProjectCard(org string, projectCardID int, newColumnID
 
 // TeamHasMember checks if a user belongs to a team
 func (f *FakeClient) TeamHasMember(org string, teamID int, memberLogin string) (bool, error) {
+	if err := f.injectedFault("TeamHasMember"); err != nil {
+		return false, err
+	}
 	teamMembers, _ := f.ListTeamMembers(org, teamID, github.RoleAll)
 	for _, member := range teamMembers {
 		if member.Login == memberLogin {
@@ -1048,6 +1254,9 @@ func (f *FakeClient) TeamHasMember(org string, teamID int, memberLogin string) (
 }
 
 func (f *FakeClient) GetTeamBySlug(slug string, org string) (*github.Team, error) {
+	if err := f.injectedFault("GetTeamBySlug"); err != nil {
+		return nil, err
+	}
 	teams, _ := f.ListTeams(org)
 	for _, team := range teams {
 		if team.Name == slug {
@@ -1058,6 +1267,9 @@ func (f *FakeClient) GetTeamBySlug(slug string, org string) (*github.Team, error
 }
 
 func (f *FakeClient) CreatePullRequest(org, repo, title, body, head, base string, canModify bool) (int, error) {
+	if err := f.injectedFault("CreatePullRequest"); err != nil {
+		return 0, err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	if f.PullRequests == nil {
@@ -1085,6 +1297,9 @@ func (f *FakeClient) CreatePullRequest(org, repo, title, body, head, base string
 }
 
 func (f *FakeClient) UpdatePullRequest(org, repo string, number int, title, body *string, open *bool, branch *string, canModify *bool) error {
+	if err := f.injectedFault("UpdatePullRequest"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	pr, found := f.PullRequests[number]
@@ -1103,32 +1318,41 @@ func (f *FakeClient) UpdatePullRequest(org, repo string, number int, title, body
 // Query simply exists to allow the fake client to match the interface for packages that need it.
 // It does not modify the passed interface at all.
 func (f *FakeClient) Query(ctx context.Context, q interface{}, vars map[string]interface{}) error {
+	if err := f.injectedFault("Query"); err != nil {
+		return err
+	}
 	return nil
 }
 
 // GetDirectory returns the contents of the file.
 func (f *FakeClient) GetDirectory(org, repo, dir, commit string) ([]github.DirectoryContent, error) {
+	if err := f.injectedFault("GetDirectory"); err != nil {
+		return nil, err
+	}
 	contents, ok := f.RemoteDirectories[dir]
 	if !ok {
-		return nil, fmt.Errorf("could not find dir %s", dir)
+		return nil, github.NewFileNotFound(org, repo, dir, commit)
 	}
 	if commit == "" {
 		if master, ok := contents["master"]; ok {
 			return master, nil
 		}
 
-		return nil, fmt.Errorf("could not find dir %s in master", dir)
+		return nil, github.NewFileNotFound(org, repo, dir, commit)
 	}
 
 	if content, ok := contents[commit]; ok {
 		return content, nil
 	}
 
-	return nil, fmt.Errorf("could not find dir %s with ref %s", dir, commit)
+	return nil, github.NewFileNotFound(org, repo, dir, commit)
 }
 
 // CreatePullRequestReviewComment adds a comment on a PR.
 func (f *FakeClient) CreatePullRequestReviewComment(owner, repo string, number int, rc github.ReviewComment) error {
+	if err := f.injectedFault("CreatePullRequestReviewComment"); err != nil {
+		return err
+	}
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.PullRequestReviewCommentID++
@@ -1138,6 +1362,9 @@ func (f *FakeClient) CreatePullRequestReviewComment(owner, repo string, number i
 }
 
 func (f *FakeClient) ListCurrentUserRepoInvitations() ([]github.UserRepoInvitation, error) {
+	if err := f.injectedFault("ListCurrentUserRepoInvitations"); err != nil {
+		return nil, err
+	}
 	var ret []github.UserRepoInvitation
 	for _, inv := range f.UserRepoInvitations {
 		ret = append(ret, inv)
@@ -1151,6 +1378,9 @@ func (f *FakeClient) ListCurrentUserRepoInvitations() ([]github.UserRepoInvitati
 }
 
 func (f *FakeClient) AcceptUserRepoInvitation(invitationID int) error {
+	if err := f.injectedFault("AcceptUserRepoInvitation"); err != nil {
+		return err
+	}
 	if _, ok := f.UserRepoInvitations[invitationID]; !ok {
 		return fmt.Errorf("couldn't find invitation id: %d", invitationID)
 	}
@@ -1160,6 +1390,9 @@ func (f *FakeClient) AcceptUserRepoInvitation(invitationID int) error {
 }
 
 func (f *FakeClient) AcceptUserOrgInvitation(org string) error {
+	if err := f.injectedFault("AcceptUserOrgInvitation"); err != nil {
+		return err
+	}
 	if _, ok := f.UserOrgInvitations[org]; !ok {
 		return fmt.Errorf("couldn't find invitation for org: %s", org)
 	}
@@ -1169,6 +1402,9 @@ func (f *FakeClient) AcceptUserOrgInvitation(org string) error {
 }
 
 func (f *FakeClient) ListCurrentUserOrgInvitations() ([]github.UserOrgInvitation, error) {
+	if err := f.injectedFault("ListCurrentUserOrgInvitations"); err != nil {
+		return nil, err
+	}
 	var ret []github.UserOrgInvitation
 	for _, inv := range f.UserOrgInvitations {
 		ret = append(ret, inv)
@@ -1182,22 +1418,37 @@ func (f *FakeClient) ListCurrentUserOrgInvitations() ([]github.UserOrgInvitation
 }
 
 func (f *FakeClient) MutateWithGitHubAppsSupport(ctx context.Context, m interface{}, input githubql.Input, vars map[string]interface{}, org string) error {
+	if err := f.injectedFault("MutateWithGitHubAppsSupport"); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (f *FakeClient) GetFailedActionRunsByHeadBranch(org, repo, branchName, headSHA string) ([]github.WorkflowRun, error) {
+	if err := f.injectedFault("GetFailedActionRunsByHeadBranch"); err != nil {
+		return nil, err
+	}
 	return []github.WorkflowRun{}, nil
 }
 
 func (f *FakeClient) TriggerGitHubWorkflow(org, repo string, id int) error {
+	if err := f.injectedFault("TriggerGitHubWorkflow"); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (f *FakeClient) TriggerFailedGitHubWorkflow(org, repo string, id int) error {
+	if err := f.injectedFault("TriggerFailedGitHubWorkflow"); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (f *FakeClient) RequestReview(org, repo string, number int, logins []string) error {
+	if err := f.injectedFault("RequestReview"); err != nil {
+		return err
+	}
 	f.ReviewersRequested = logins
 	return nil
 }
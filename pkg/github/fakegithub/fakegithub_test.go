@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegithub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+func TestBuilders(t *testing.T) {
+	fc := (&FakeClient{}).
+		WithPullRequests(github.PullRequest{Number: 1, Title: "pr"}).
+		WithIssues(github.Issue{Number: 2, Title: "issue"}).
+		WithIssueComments(1, github.IssueComment{Body: "first"}, github.IssueComment{Body: "second"}).
+		WithIssueLabels("org", "repo", 1, "lgtm").
+		WithOrgMembers("org", "member").
+		WithFile("OWNERS", "master", "approvers: [member]")
+
+	if pr, err := fc.GetPullRequest("org", "repo", 1); err != nil || pr.Title != "pr" {
+		t.Errorf("expected pull request to be returned, got %v: %v", pr, err)
+	}
+	if issue, err := fc.GetIssue("org", "repo", 2); err != nil || issue.Title != "issue" {
+		t.Errorf("expected issue to be returned, got %v: %v", issue, err)
+	}
+	comments, err := fc.ListIssueComments("org", "repo", 1)
+	if err != nil {
+		t.Fatalf("unexpected error listing comments: %v", err)
+	}
+	expectedComments := []github.IssueComment{{ID: 1, Body: "first"}, {ID: 2, Body: "second"}}
+	if diff := cmp.Diff(expectedComments, comments); diff != "" {
+		t.Errorf("unexpected comments (-want +got):\n%s", diff)
+	}
+	labels, err := fc.GetIssueLabels("org", "repo", 1)
+	if err != nil {
+		t.Fatalf("unexpected error getting labels: %v", err)
+	}
+	if diff := cmp.Diff([]github.Label{{Name: "lgtm"}}, labels); diff != "" {
+		t.Errorf("unexpected labels (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"lgtm"}, fc.RepoLabelsExisting); diff != "" {
+		t.Errorf("unexpected repo labels (-want +got):\n%s", diff)
+	}
+	if member, err := fc.IsMember("org", "member"); err != nil || !member {
+		t.Errorf("expected member to be an org member, got %t: %v", member, err)
+	}
+	if content, err := fc.GetFile("org", "repo", "OWNERS", ""); err != nil || string(content) != "approvers: [member]" {
+		t.Errorf("expected file content, got %q: %v", content, err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	fc := NewFakeClient()
+	if _, err := fc.GetPullRequest("org", "repo", 1); !github.IsNotFound(err) {
+		t.Errorf("expected not found error for missing pull request, got %v", err)
+	}
+	if _, err := fc.GetIssue("org", "repo", 1); !github.IsNotFound(err) {
+		t.Errorf("expected not found error for missing issue, got %v", err)
+	}
+	var notFound *github.FileNotFound
+	if _, err := fc.GetFile("org", "repo", "OWNERS", "abc"); !errors.As(err, &notFound) {
+		t.Errorf("expected FileNotFound for missing file, got %v", err)
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	injected := errors.New("injected")
+	fc := NewFakeClient().InjectFaultTimes("CreateComment", injected, 2)
+	for i := 0; i < 2; i++ {
+		if err := fc.CreateComment("org", "repo", 1, "hello"); !errors.Is(err, injected) {
+			t.Fatalf("call %d: expected injected error, got %v", i, err)
+		}
+	}
+	if err := fc.CreateCommentWithContext(context.Background(), "org", "repo", 1, "hello"); err != nil {
+		t.Fatalf("expected fault to be exhausted, got %v", err)
+	}
+	if diff := cmp.Diff([]string{"org/repo#1:hello"}, fc.IssueCommentsAdded); diff != "" {
+		t.Errorf("unexpected comments added (-want +got):\n%s", diff)
+	}
+
+	fc.InjectNotFound("GetRepo")
+	for i := 0; i < 3; i++ {
+		if _, err := fc.GetRepo("org", "repo"); !github.IsNotFound(err) {
+			t.Fatalf("call %d: expected not found error, got %v", i, err)
+		}
+	}
+	fc.ClearFaults()
+	if _, err := fc.GetRepo("org", "repo"); err != nil {
+		t.Errorf("expected no error after clearing faults, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegithub
+
+import (
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// fault is an error injected into the calls of a method.
+type fault struct {
+	err error
+	// remaining is the number of calls that still fail, or -1 if all calls fail.
+	remaining int
+}
+
+// InjectFault makes every call of the named method fail with err until the
+// fault is cleared. Methods are named like on the github.Client interface,
+// e.g. "GetPullRequest"; a fault for a method also applies to its
+// WithContext variant.
+func (f *FakeClient) InjectFault(method string, err error) *FakeClient {
+	return f.injectFault(method, err, -1)
+}
+
+// InjectFaultTimes makes the next times calls of the named method fail with
+// err, after which calls succeed again. This is useful to test retries.
+func (f *FakeClient) InjectFaultTimes(method string, err error, times int) *FakeClient {
+	if times < 1 {
+		return f
+	}
+	return f.injectFault(method, err, times)
+}
+
+// InjectNotFound makes every call of the named method fail like the real
+// client does when GitHub responds with 404, so that github.IsNotFound
+// returns true for the error.
+func (f *FakeClient) InjectNotFound(method string) *FakeClient {
+	return f.InjectFault(method, github.NewNotFoundWithMessage(method+": status code 404"))
+}
+
+// ClearFaults removes all injected faults.
+func (f *FakeClient) ClearFaults() {
+	f.faultLock.Lock()
+	defer f.faultLock.Unlock()
+	f.faults = nil
+}
+
+func (f *FakeClient) injectFault(method string, err error, times int) *FakeClient {
+	f.faultLock.Lock()
+	defer f.faultLock.Unlock()
+	if f.faults == nil {
+		f.faults = map[string]*fault{}
+	}
+	f.faults[strings.TrimSuffix(method, "WithContext")] = &fault{err: err, remaining: times}
+	return f
+}
+
+// injectedFault returns the error injected for the method, if any.
+func (f *FakeClient) injectedFault(method string) error {
+	f.faultLock.Lock()
+	defer f.faultLock.Unlock()
+	injected, ok := f.faults[method]
+	if !ok {
+		return nil
+	}
+	if injected.remaining > 0 {
+		injected.remaining--
+		if injected.remaining == 0 {
+			delete(f.faults, method)
+		}
+	}
+	return injected.err
+}
@@ -101,6 +101,37 @@ var cacheEntryAge = prometheus.NewHistogramVec(
 	[]string{"token_hash", "path", "user_agent"},
 )
 
+// consumerRequests provides the 'github_consumer_requests' counter that
+// attributes upstream GitHub requests to the Prow component that made them.
+var consumerRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "github_consumer_requests",
+		Help: "How many upstream GitHub requests each consumer made per token, and whether they counted against the rate limit.",
+	},
+	[]string{"token_hash", "consumer", "api_version", "ratelimit_resource", "charged"},
+)
+
+// secondaryRateLimits provides the 'github_secondary_rate_limits' counter
+// of the secondary rate limit and abuse detection responses of GitHub.
+var secondaryRateLimits = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "github_secondary_rate_limits",
+		Help: "How many secondary rate limit and abuse detection responses GitHub sent per token and path.",
+	},
+	[]string{"token_hash", "path", "user_agent"},
+)
+
+// coolDownResponses provides the 'ghcache_cool_down_responses' counter of
+// the requests that were held off while their token cooled down after a
+// secondary rate limit.
+var coolDownResponses = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ghcache_cool_down_responses",
+		Help: "How many requests were not sent to GitHub because their token was cooling down after a secondary rate limit.",
+	},
+	[]string{"token_hash", "path", "user_agent"},
+)
+
 var muxTokenUsage sync.Mutex
 var lastGitHubResponse time.Time
 
@@ -112,6 +143,9 @@ func init() {
 	prometheus.MustRegister(cacheCounter)
 	prometheus.MustRegister(timeoutDuration)
 	prometheus.MustRegister(cacheEntryAge)
+	prometheus.MustRegister(consumerRequests)
+	prometheus.MustRegister(secondaryRateLimits)
+	prometheus.MustRegister(coolDownResponses)
 }
 
 // CollectGitHubTokenMetrics publishes the rate limits of the github api to
@@ -156,6 +190,12 @@ func CollectGitHubRequestMetrics(tokenHash, path, statusCode, userAgent string,
 	ghRequestDurationHistVec.With(prometheus.Labels{"token_hash": tokenHash, "path": simplifier.Simplify(path), "status": statusCode, "user_agent": userAgentWithoutVersion(userAgent)}).Observe(roundTripTime)
 }
 
+// CollectGitHubConsumerMetrics publishes an upstream request made by a consumer
+// to `github_consumer_requests` on prometheus.
+func CollectGitHubConsumerMetrics(tokenHash, consumer, apiVersion, resource string, charged bool) {
+	consumerRequests.With(prometheus.Labels{"token_hash": tokenHash, "consumer": userAgentWithoutVersion(consumer), "api_version": apiVersion, "ratelimit_resource": resource, "charged": strconv.FormatBool(charged)}).Inc()
+}
+
 // timestampStringToTime takes a unix timestamp and returns a `time.Time`
 // from the given time.
 func timestampStringToTime(tstamp string) time.Time {
@@ -194,3 +234,15 @@ func CollectRequestTimeoutMetrics(tokenHash, path, userAgent string, reqStartTim
 func CollectGitHubRequestWaitDurationMetrics(tokenHash, requestType, api string, duration time.Duration) {
 	ghRequestWaitDurationHistVec.With(prometheus.Labels{"token_hash": tokenHash, "request_type": requestType, "api": api}).Observe(duration.Seconds())
 }
+
+// CollectGitHubSecondaryRateLimitMetrics records a secondary rate limit or
+// abuse detection response to 'github_secondary_rate_limits' on prometheus.
+func CollectGitHubSecondaryRateLimitMetrics(tokenHash, path, userAgent string) {
+	secondaryRateLimits.With(prometheus.Labels{"token_hash": tokenHash, "path": simplifier.Simplify(path), "user_agent": userAgentWithoutVersion(userAgent)}).Inc()
+}
+
+// CollectCoolDownResponseMetrics records a request held off during the
+// cool-down of its token to 'ghcache_cool_down_responses' on prometheus.
+func CollectCoolDownResponseMetrics(tokenHash, path, userAgent string) {
+	coolDownResponses.With(prometheus.Labels{"token_hash": tokenHash, "path": simplifier.Simplify(path), "user_agent": userAgentWithoutVersion(userAgent)}).Inc()
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+const (
+	aggregatedCommentTag = "<!-- job results summary -->"
+)
+
+// ReportAggregatedComment maintains a single comment on a pull request that
+// summarizes the results of all its jobs. The comment is created with the
+// first result and edited in place as jobs progress. All jobs must be for the
+// same pull request and commit.
+func ReportAggregatedComment(ctx context.Context, ghc GitHubClient, pjs []prowapi.ProwJob, config config.GitHubReporter) error {
+	if ghc == nil {
+		return errors.New("trying to report pj, but found empty github client")
+	}
+
+	var validPjs []prowapi.ProwJob
+	for _, pj := range pjs {
+		if ShouldReport(pj, config.JobTypesToReport) {
+			validPjs = append(validPjs, pj)
+		}
+	}
+	if len(validPjs) == 0 {
+		return nil
+	}
+	refs := validPjs[0].Spec.Refs
+	if refs == nil || len(refs.Pulls) != 1 {
+		return nil
+	}
+
+	ics, err := ghc.ListIssueCommentsWithContext(ctx, refs.Org, refs.Repo, refs.Pulls[0].Number)
+	if err != nil {
+		return fmt.Errorf("error listing comments: %w", err)
+	}
+	botNameChecker, err := ghc.BotUserCheckerWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting bot name checker: %w", err)
+	}
+	comment := createAggregatedComment(validPjs)
+	deletes, update := parseAggregatedComments(botNameChecker, ics)
+	for _, delete := range deletes {
+		if err := ghc.DeleteCommentWithContext(ctx, refs.Org, refs.Repo, delete); err != nil {
+			return fmt.Errorf("error deleting comment: %w", err)
+		}
+	}
+	switch {
+	case update == nil:
+		if err := ghc.CreateCommentWithContext(ctx, refs.Org, refs.Repo, refs.Pulls[0].Number, comment); err != nil {
+			return fmt.Errorf("error creating comment: %w", err)
+		}
+	case update.Body != comment:
+		if err := ghc.EditCommentWithContext(ctx, refs.Org, refs.Repo, update.ID, comment); err != nil {
+			return fmt.Errorf("error updating comment: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseAggregatedComments returns the IDs of the summary comments to delete
+// and the summary comment to update, which is nil if there is none.
+func parseAggregatedComments(isBot func(string) bool, ics []github.IssueComment) ([]int, *github.IssueComment) {
+	var deletes []int
+	var latest *github.IssueComment
+	for i, ic := range ics {
+		if !isBot(ic.User.Login) || !strings.Contains(ic.Body, aggregatedCommentTag) {
+			continue
+		}
+		if latest != nil {
+			deletes = append(deletes, latest.ID)
+		}
+		latest = &ics[i]
+	}
+	return deletes, latest
+}
+
+// createAggregatedComment formats a table of the results of the jobs, sorted
+// by context.
+func createAggregatedComment(pjs []prowapi.ProwJob) string {
+	sorted := make([]prowapi.ProwJob, len(pjs))
+	copy(sorted, pjs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return jobContext(sorted[i]) < jobContext(sorted[j])
+	})
+	var complete, failed int
+	for _, pj := range sorted {
+		if pj.Complete() {
+			complete++
+		}
+		if pj.Status.State == prowapi.FailureState || pj.Status.State == prowapi.ErrorState {
+			failed++
+		}
+	}
+	lines := []string{
+		fmt.Sprintf("Job results for commit %s: %d/%d complete, %d failed.", sorted[0].Spec.Refs.Pulls[0].SHA, complete, len(sorted), failed),
+		"",
+		"Job | Status | Duration | Required | Rerun command",
+		"--- | --- | --- | --- | ---",
+	}
+	for _, pj := range sorted {
+		name := jobContext(pj)
+		if pj.Status.URL != "" {
+			name = fmt.Sprintf("[%s](%s)", name, pj.Status.URL)
+		}
+		rerun := ""
+		if pj.Spec.RerunCommand != "" {
+			rerun = fmt.Sprintf("`%s`", pj.Spec.RerunCommand)
+		}
+		lines = append(lines, strings.Join([]string{name, stateWithIcon(pj.Status.State), jobDuration(pj), requiredString(pj), rerun}, " | "))
+	}
+	lines = append(lines, []string{
+		"",
+		"<details>",
+		"",
+		plugins.AboutThisBot,
+		"</details>",
+		aggregatedCommentTag,
+	}...)
+	return strings.Join(lines, "\n")
+}
+
+func jobContext(pj prowapi.ProwJob) string {
+	if pj.Spec.Context != "" {
+		return pj.Spec.Context
+	}
+	return pj.Spec.Job
+}
+
+func stateWithIcon(state prowapi.ProwJobState) string {
+	icon := ":grey_question:"
+	switch state {
+	case prowapi.TriggeredState, prowapi.PendingState, prowapi.SchedulingState:
+		icon = ":hourglass_flowing_sand:"
+	case prowapi.SuccessState:
+		icon = ":white_check_mark:"
+	case prowapi.FailureState:
+		icon = ":x:"
+	case prowapi.ErrorState:
+		icon = ":warning:"
+	case prowapi.AbortedState:
+		icon = ":no_entry_sign:"
+	}
+	return fmt.Sprintf("%s %s", icon, state)
+}
+
+// jobDuration formats how long a complete job ran.
+func jobDuration(pj prowapi.ProwJob) string {
+	if pj.Status.CompletionTime == nil || pj.Status.StartTime.IsZero() {
+		return "-"
+	}
+	return pj.Status.CompletionTime.Sub(pj.Status.StartTime.Time).Round(time.Second).String()
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+func aggregatedTestJob(context string, state prowapi.ProwJobState, duration time.Duration) prowapi.ProwJob {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pj := prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{kube.IsOptionalLabel: "false"}},
+		Spec: prowapi.ProwJobSpec{
+			Type:         prowapi.PresubmitJob,
+			Report:       true,
+			Context:      context,
+			RerunCommand: "/test " + context,
+			Refs: &prowapi.Refs{
+				Org:   "org",
+				Repo:  "repo",
+				Pulls: []prowapi.Pull{{Number: 1, SHA: "abcdef"}},
+			},
+		},
+		Status: prowapi.ProwJobStatus{
+			State:     state,
+			StartTime: metav1.NewTime(start),
+			URL:       "https://prow.example.com/view/" + context,
+		},
+	}
+	if duration > 0 {
+		pj.Status.CompletionTime = &metav1.Time{Time: start.Add(duration)}
+	}
+	return pj
+}
+
+func TestCreateAggregatedComment(t *testing.T) {
+	pjs := []prowapi.ProwJob{
+		aggregatedTestJob("unit", prowapi.SuccessState, 90*time.Second),
+		aggregatedTestJob("e2e", prowapi.PendingState, 0),
+		aggregatedTestJob("lint", prowapi.FailureState, time.Minute),
+	}
+	pjs[2].Labels[kube.IsOptionalLabel] = "true"
+	pjs[1].Status.URL = ""
+	expected := strings.Join([]string{
+		"Job results for commit abcdef: 2/3 complete, 1 failed.",
+		"",
+		"Job | Status | Duration | Required | Rerun command",
+		"--- | --- | --- | --- | ---",
+		"e2e | :hourglass_flowing_sand: pending | - | true | `/test e2e`",
+		"[lint](https://prow.example.com/view/lint) | :x: failure | 1m0s | false | `/test lint`",
+		"[unit](https://prow.example.com/view/unit) | :white_check_mark: success | 1m30s | true | `/test unit`",
+		"",
+		"<details>",
+		"",
+		plugins.AboutThisBot,
+		"</details>",
+		aggregatedCommentTag,
+	}, "\n")
+	if diff := cmp.Diff(expected, createAggregatedComment(pjs)); diff != "" {
+		t.Errorf("comment differs from expected (-want +got):\n%s", diff)
+	}
+}
+
+func TestReportAggregatedComment(t *testing.T) {
+	pjs := []prowapi.ProwJob{
+		aggregatedTestJob("unit", prowapi.SuccessState, time.Minute),
+		aggregatedTestJob("e2e", prowapi.PendingState, 0),
+	}
+	current := createAggregatedComment(pjs)
+	testCases := []struct {
+		name            string
+		comments        []github.IssueComment
+		expectedAdded   []string
+		expectedEdited  []string
+		expectedDeleted []string
+	}{
+		{
+			name:          "create the comment",
+			comments:      []github.IssueComment{{ID: 1, Body: "lgtm", User: github.User{Login: "someone"}}},
+			expectedAdded: []string{"org/repo#1:" + current},
+		},
+		{
+			name: "edit the existing comment",
+			comments: []github.IssueComment{
+				{ID: 1, Body: "old\n" + aggregatedCommentTag, User: github.User{Login: fakegithub.Bot}},
+			},
+			expectedEdited: []string{"org/repo#1:" + current},
+		},
+		{
+			name: "leave an up to date comment alone",
+			comments: []github.IssueComment{
+				{ID: 1, Body: current, User: github.User{Login: fakegithub.Bot}},
+			},
+		},
+		{
+			name: "delete duplicate comments and ignore comments of others",
+			comments: []github.IssueComment{
+				{ID: 1, Body: "old\n" + aggregatedCommentTag, User: github.User{Login: fakegithub.Bot}},
+				{ID: 2, Body: "copied\n" + aggregatedCommentTag, User: github.User{Login: "someone"}},
+				{ID: 3, Body: "old\n" + aggregatedCommentTag, User: github.User{Login: fakegithub.Bot}},
+			},
+			expectedDeleted: []string{"org/repo#1"},
+			expectedEdited:  []string{"org/repo#3:" + current},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fghc := fakegithub.NewFakeClient()
+			fghc.IssueComments[1] = tc.comments
+			if err := ReportAggregatedComment(context.Background(), fghc, pjs, config.GitHubReporter{JobTypesToReport: []prowapi.ProwJobType{prowapi.PresubmitJob}}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for name, diff := range map[string]string{
+				"added":   cmp.Diff(tc.expectedAdded, fghc.IssueCommentsAdded),
+				"edited":  cmp.Diff(tc.expectedEdited, fghc.IssueCommentsEdited),
+				"deleted": cmp.Diff(tc.expectedDeleted, fghc.IssueCommentsDeleted),
+			} {
+				if diff != "" {
+					t.Errorf("%s comments differ from expected (-want +got):\n%s", name, diff)
+				}
+			}
+		})
+	}
+}
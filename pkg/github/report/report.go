@@ -23,10 +23,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/github"
@@ -36,6 +42,14 @@ import (
 
 const (
 	commentTag = "<!-- test report -->"
+
+	// failuresStart and failuresEnd delimit the table of failures of
+	// templated comments.
+	failuresStart = "<!-- failed tests"
+	failuresEnd   = "-->"
+
+	tableHeader    = "Test name | Commit | Details | Required | Rerun command"
+	tableSeparator = "--- | --- | --- | --- | ---"
 )
 
 // GitHubClient provides a client interface to report job status updates
@@ -70,8 +84,9 @@ func prowjobStateToGitHubStatus(pjState prowapi.ProwJobState) (string, error) {
 	return "", fmt.Errorf("Unknown prowjob state: %s", pjState)
 }
 
-// reportStatus should be called on any prowjob status changes
-func reportStatus(ctx context.Context, ghc GitHubClient, pj prowapi.ProwJob) error {
+// reportStatus should be called on any prowjob status changes. The
+// description of the status is that of statusTemplate if it is not nil.
+func reportStatus(ctx context.Context, ghc GitHubClient, pj prowapi.ProwJob, statusTemplate *template.Template) error {
 	refs := pj.Spec.Refs
 	if pj.Spec.Report {
 		contextState, err := prowjobStateToGitHubStatus(pj.Status.State)
@@ -82,9 +97,18 @@ func reportStatus(ctx context.Context, ghc GitHubClient, pj prowapi.ProwJob) err
 		if len(refs.Pulls) > 0 {
 			sha = refs.Pulls[0].SHA
 		}
+		description := pj.Status.Description
+		if statusTemplate != nil {
+			var b bytes.Buffer
+			if err := statusTemplate.Execute(&b, &pj); err != nil {
+				logrus.WithError(err).WithField("prowjob", pj.Name).Warn("Failed to execute the status template, using the default description.")
+			} else {
+				description = strings.TrimSpace(b.String())
+			}
+		}
 		if err := ghc.CreateStatusWithContext(ctx, refs.Org, refs.Repo, sha, github.Status{
 			State:       contextState,
-			Description: config.ContextDescriptionWithBaseSha(pj.Status.Description, refs.BaseSHA),
+			Description: config.ContextDescriptionWithBaseSha(description, refs.BaseSHA),
 			Context:     pj.Spec.Context, // consider truncating this too
 			TargetURL:   pj.Status.URL,
 		}); err != nil {
@@ -140,7 +164,7 @@ func ReportStatusContext(ctx context.Context, ghc GitHubClient, pj prowapi.ProwJ
 		return nil
 	}
 
-	if err := reportStatus(ctx, ghc, pj); err != nil {
+	if err := reportStatus(ctx, ghc, pj, config.StatusTemplateForRepo(refs.Org, refs.Repo)); err != nil {
 		return fmt.Errorf("error setting status: %w", err)
 	}
 	return nil
@@ -201,9 +225,18 @@ func ReportComment(ctx context.Context, ghc GitHubClient, reportTemplate *templa
 	}
 
 	if len(entries) > 0 || (mustCreate && !aborted) {
-		comment, err := createComment(reportTemplate, validPjs, entries)
-		if err != nil {
-			return fmt.Errorf("generating comment: %w", err)
+		var comment string
+		if commentTemplate := config.CommentTemplateForRepo(refs.Org, refs.Repo); commentTemplate != nil {
+			comment, err = createTemplatedComment(commentTemplate, validPjs, entries)
+			if err != nil {
+				logrus.WithError(err).WithField("repo", refs.Org+"/"+refs.Repo).Warn("Failed to execute the comment template, using the default comment.")
+			}
+		}
+		if comment == "" {
+			comment, err = createComment(reportTemplate, validPjs, entries)
+			if err != nil {
+				return fmt.Errorf("generating comment: %w", err)
+			}
 		}
 		if updateID == 0 {
 			if err := ghc.CreateCommentWithContext(ctx, refs.Org, refs.Repo, refs.Pulls[0].Number, comment); err != nil {
@@ -239,8 +272,17 @@ func parseIssueComments(pjs []prowapi.ProwJob, isBot func(string) bool, ics []gi
 			previousComments = append(previousComments, latestComment)
 		}
 		latestComment = ic.ID
+		body := ic.Body
+		// Templated comments keep the table of failures in a hidden block,
+		// apart from the text of the template.
+		if start := strings.Index(body, failuresStart); start != -1 {
+			body = body[start+len(failuresStart):]
+			if end := strings.Index(body, failuresEnd); end != -1 {
+				body = body[:end]
+			}
+		}
 		var tracking bool
-		for _, line := range strings.Split(ic.Body, "\n") {
+		for _, line := range strings.Split(body, "\n") {
 			line = strings.TrimSpace(line)
 			if strings.HasPrefix(line, "---") {
 				tracking = true
@@ -294,23 +336,26 @@ func parseIssueComments(pjs []prowapi.ProwJob, isBot func(string) bool, ics []gi
 }
 
 func createEntry(pj prowapi.ProwJob) string {
-	required := "unknown"
+	return strings.Join([]string{
+		pj.Spec.Context,
+		pj.Spec.Refs.Pulls[0].SHA,
+		fmt.Sprintf("[link](%s)", pj.Status.URL),
+		requiredString(pj),
+		fmt.Sprintf("`%s`", pj.Spec.RerunCommand),
+	}, " | ")
+}
 
+// requiredString returns whether a presubmit is required to merge, or
+// "unknown" if that is not known.
+func requiredString(pj prowapi.ProwJob) string {
 	if pj.Spec.Type == prowapi.PresubmitJob {
 		if label, exist := pj.Labels[kube.IsOptionalLabel]; exist {
 			if optional, err := strconv.ParseBool(label); err == nil {
-				required = strconv.FormatBool(!optional)
+				return strconv.FormatBool(!optional)
 			}
 		}
 	}
-
-	return strings.Join([]string{
-		pj.Spec.Context,
-		pj.Spec.Refs.Pulls[0].SHA,
-		fmt.Sprintf("[link](%s)", pj.Status.URL),
-		required,
-		fmt.Sprintf("`%s`", pj.Spec.RerunCommand),
-	}, " | ")
+	return "unknown"
 }
 
 // createComment take a ProwJob and a list of entries generated with
@@ -338,8 +383,8 @@ func createComment(reportTemplate *template.Template, pjs []prowapi.ProwJob, ent
 	lines := []string{
 		fmt.Sprintf("@%s: The following test%s **failed**, say `/retest` to rerun all failed tests or `/retest-required` to rerun all mandatory failed tests:", pjs[0].Spec.Refs.Pulls[0].Author, plural),
 		"",
-		"Test name | Commit | Details | Required | Rerun command",
-		"--- | --- | --- | --- | ---",
+		tableHeader,
+		tableSeparator,
 	}
 	if len(entries) == 0 { // No test failed
 		lines = []string{
@@ -361,3 +406,128 @@ func createComment(reportTemplate *template.Template, pjs []prowapi.ProwJob, ent
 	}...)
 	return strings.Join(lines, "\n"), nil
 }
+
+// CommentData is what the comment templates of the GitHub reporter are
+// executed with.
+type CommentData struct {
+	// Author is the login of the author of the pull request.
+	Author string
+	// Org, Repo and Number identify the pull request.
+	Org    string
+	Repo   string
+	Number int
+	// Failures are the failed tests of the pull request, including those of
+	// earlier reports. All tests passed if there are none.
+	Failures []Failure
+	// Jobs are the jobs being reported, e.g. for the descriptions of their
+	// failures.
+	Jobs []prowapi.ProwJob
+}
+
+// Failure is a failed test of a pull request.
+type Failure struct {
+	Context      string
+	SHA          string
+	URL          string
+	Required     string
+	RerunCommand string
+}
+
+var linkRegex = regexp.MustCompile(`^\[link\]\((.*)\)$`)
+
+// parseEntry parses an entry of createEntry, or of its earlier version
+// without the Required column.
+func parseEntry(entry string) Failure {
+	fields := strings.Split(entry, " | ")
+	var failure Failure
+	failure.Context = fields[0]
+	if len(fields) > 1 {
+		failure.SHA = fields[1]
+	}
+	if len(fields) > 2 {
+		if match := linkRegex.FindStringSubmatch(fields[2]); match != nil {
+			failure.URL = match[1]
+		}
+	}
+	if len(fields) > 4 {
+		failure.Required = fields[3]
+	}
+	if len(fields) > 3 {
+		failure.RerunCommand = strings.Trim(fields[len(fields)-1], "`")
+	}
+	return failure
+}
+
+// createTemplatedComment returns the comment of the template for the
+// entries of createEntry, followed by the hidden table of the entries that
+// parseIssueComments reads back.
+func createTemplatedComment(commentTemplate *template.Template, pjs []prowapi.ProwJob, entries []string) (string, error) {
+	if len(pjs) == 0 {
+		return "", nil
+	}
+	refs := pjs[0].Spec.Refs
+	data := CommentData{
+		Author: refs.Pulls[0].Author,
+		Org:    refs.Org,
+		Repo:   refs.Repo,
+		Number: refs.Pulls[0].Number,
+		Jobs:   pjs,
+	}
+	for _, entry := range entries {
+		data.Failures = append(data.Failures, parseEntry(entry))
+	}
+	var b bytes.Buffer
+	if err := commentTemplate.Execute(&b, data); err != nil {
+		return "", err
+	}
+	lines := []string{strings.TrimSpace(b.String()), "", failuresStart, tableHeader, tableSeparator}
+	lines = append(lines, entries...)
+	lines = append(lines, failuresEnd, commentTag)
+	return strings.Join(lines, "\n"), nil
+}
+
+// ValidateTemplates executes the templates of the GitHub reporter with
+// example data, which catches the errors that parsing them does not, e.g.
+// references to fields that do not exist.
+func ValidateTemplates(cfg config.GitHubReporter) error {
+	pj := prowapi.ProwJob{
+		Spec: prowapi.ProwJobSpec{
+			Type:         prowapi.PresubmitJob,
+			Job:          "pull-test",
+			Context:      "pull-test",
+			RerunCommand: "/test pull-test",
+			Refs: &prowapi.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseSHA: "base",
+				Pulls:   []prowapi.Pull{{Number: 1, Author: "author", SHA: "head"}},
+			},
+			Report: true,
+		},
+		Status: prowapi.ProwJobStatus{
+			State:       prowapi.FailureState,
+			Description: "Job failed.",
+			URL:         "https://prow.example.com/view/pull-test/1",
+		},
+	}
+	var errs []error
+	var keys []string
+	for key := range cfg.Templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		templates := cfg.Templates[key]
+		if t := templates.CommentTemplate(); t != nil {
+			if _, err := createTemplatedComment(t, []prowapi.ProwJob{pj}, []string{createEntry(pj)}); err != nil {
+				errs = append(errs, fmt.Errorf("github_reporter.templates[%q].comment: %w", key, err))
+			}
+		}
+		if t := templates.StatusTemplate(); t != nil {
+			if err := t.Execute(io.Discard, &pj); err != nil {
+				errs = append(errs, fmt.Errorf("github_reporter.templates[%q].status: %w", key, err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -19,6 +19,8 @@ package report
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -158,6 +160,20 @@ func TestParseIssueComment(t *testing.T) {
 			expectedEntries: []string{"foo test"},
 			expectedUpdate:  123,
 		},
+		{
+			name:    "should only read the failures of a templated comment",
+			context: "bla test",
+			state:   github.StatusFailure,
+			ics: []github.IssueComment{
+				{
+					User: github.User{Login: "k8s-ci-robot"},
+					Body: "Jobs failed:\n\n--- | ---\nnot an entry | at all\n\n" + failuresStart + "\n--- | --- | ---\nfoo test | wow | aye\n" + failuresEnd + "\n" + commentTag,
+					ID:   123,
+				},
+			},
+			expectedDeletes: []int{123},
+			expectedEntries: []string{"bla test", "foo test"},
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -276,6 +292,7 @@ func TestReportStatus(t *testing.T) {
 		report           bool
 		desc             string // override default msg
 		pjType           prowapi.ProwJobType
+		statusTemplate   string
 		expectedStatuses []string
 		expectedDesc     string
 	}{
@@ -337,12 +354,46 @@ func TestReportStatus(t *testing.T) {
 
 			expectedStatuses: []string{"success"},
 		},
+		{
+			name: "status template sets the description",
+
+			state:            prowapi.FailureState,
+			report:           true,
+			pjType:           prowapi.PresubmitJob,
+			statusTemplate:   `{{.Spec.Job}} {{.Status.State}}, see https://runbooks.example.com`,
+			expectedStatuses: []string{"failure"},
+			expectedDesc:     "job-name failure, see https://runbooks.example.com",
+		},
+		{
+			name: "status template is truncated",
+
+			state:            prowapi.FailureState,
+			report:           true,
+			pjType:           prowapi.PresubmitJob,
+			statusTemplate:   `{{.Status.Description}} {{.Status.Description}}`,
+			desc:             shout(10),
+			expectedStatuses: []string{"failure"},
+			expectedDesc:     config.ContextDescriptionWithBaseSha(shout(10)+" "+shout(10), ""),
+		},
+		{
+			name: "failing status template falls back to the description",
+
+			state:            prowapi.FailureState,
+			report:           true,
+			pjType:           prowapi.PresubmitJob,
+			statusTemplate:   `{{.Spec.Job.Missing}}`,
+			expectedStatuses: []string{"failure"},
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Setup
 			ghc := &fakeGhClient{}
+			var statusTemplate *template.Template
+			if tc.statusTemplate != "" {
+				statusTemplate = mustParseTemplate(t, tc.statusTemplate)
+			}
 
 			if tc.desc == "" {
 				tc.desc = defMsg
@@ -374,7 +425,7 @@ func TestReportStatus(t *testing.T) {
 				},
 			}
 			// Run
-			if err := reportStatus(context.Background(), ghc, pj); err != nil {
+			if err := reportStatus(context.Background(), ghc, pj, statusTemplate); err != nil {
 				t.Error(err)
 			}
 			// Check
@@ -724,3 +775,142 @@ func TestReportComment(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateTemplatedComment(t *testing.T) {
+	pjs := []prowapi.ProwJob{{
+		Spec: prowapi.ProwJobSpec{
+			Job: "job-a",
+			Refs: &prowapi.Refs{
+				Org:   "org",
+				Repo:  "repo",
+				Pulls: []prowapi.Pull{{Number: 5, Author: "chaodaig"}},
+			},
+		},
+		Status: prowapi.ProwJobStatus{
+			Description: "Job failed.\nIt really did.",
+		},
+	}}
+	const commentTemplate = `{{.Author}}: {{len .Failures}} failure(s) in {{.Org}}/{{.Repo}}#{{.Number}}.
+{{range .Failures}}
+* [{{.Context}}]({{.URL}}) at {{.SHA}}{{if eq .Required "true"}} (required){{end}}: ` + "`{{.RerunCommand}}`" + `
+{{- end}}
+{{range .Jobs}}
+{{quote .Status.Description}}
+{{codeBlock (truncate 12 .Status.Description)}}
+{{- end}}`
+
+	tests := []struct {
+		name    string
+		entries []string
+		want    string
+	}{
+		{
+			name: "failures",
+			entries: []string{
+				"aaa | bbb | [link](https://prow/aaa) | true | `/test aaa`",
+				"ccc | ddd | [link](https://prow/ccc) | `/test ccc`",
+			},
+			want: "chaodaig: 2 failure(s) in org/repo#5." + `
+
+* [aaa](https://prow/aaa) at bbb (required): ` + "`/test aaa`" + `
+* [ccc](https://prow/ccc) at ddd: ` + "`/test ccc`" + `
+
+> Job failed.
+> It really did.
+` + "```\nJob faile...\n```" + `
+
+<!-- failed tests
+Test name | Commit | Details | Required | Rerun command
+--- | --- | --- | --- | ---
+aaa | bbb | [link](https://prow/aaa) | true | ` + "`/test aaa`" + `
+ccc | ddd | [link](https://prow/ccc) | ` + "`/test ccc`" + `
+-->
+<!-- test report -->`,
+		},
+		{
+			name: "all passed",
+			want: "chaodaig: 0 failure(s) in org/repo#5." + `
+
+
+> Job failed.
+> It really did.
+` + "```\nJob faile...\n```" + `
+
+<!-- failed tests
+Test name | Commit | Details | Required | Rerun command
+--- | --- | --- | --- | ---
+-->
+<!-- test report -->`,
+		},
+	}
+
+	tmpl, err := template.New("test").Funcs(config.ReportTemplateFuncs).Parse(commentTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := createTemplatedComment(tmpl, pjs, tc.entries)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("comment mismatch (-want +got):\n%s", diff)
+			}
+			isBot := func(string) bool { return true }
+			_, entries, _ := parseIssueComments(nil, isBot, []github.IssueComment{{Body: got}})
+			if diff := cmp.Diff(tc.entries, entries); diff != "" {
+				t.Errorf("parsed entries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "valid templates",
+			config: `github_reporter:
+  templates:
+    org:
+      comment: "{{range .Failures}}{{.Context}}{{end}}"
+      status: "{{truncate 20 .Status.Description}}"
+`,
+		},
+		{
+			name: "unknown fields",
+			config: `github_reporter:
+  templates:
+    org:
+      comment: "{{.Failures.Missing}}"
+    org/repo:
+      status: "{{.Missing}}"
+`,
+			wantErr: `[github_reporter.templates["org"].comment: template: comment:1:11: executing "comment" at <.Failures.Missing>: can't evaluate field Missing in type []report.Failure, github_reporter.templates["org/repo"].status: template: status:1:2: executing "status" at <.Missing>: can't evaluate field Missing in type *v1.ProwJob]`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tc.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := config.Load(path, "", nil, "")
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			var gotErr string
+			if err := ValidateTemplates(cfg.GitHubReporter); err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Errorf("error mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -216,6 +216,8 @@ type GenericEvent struct {
 	Sender User         `json:"sender"`
 	Org    Organization `json:"organization"`
 	Repo   Repo         `json:"repository"`
+	// Installation is only set for events delivered to a GitHub App.
+	Installation EventInstallation `json:"installation"`
 }
 
 // PullRequestEvent is what GitHub sends us when a PR is changed.
@@ -543,6 +545,8 @@ const (
 type Branch struct {
 	Name      string `json:"name"`
 	Protected bool   `json:"protected"` // only included for ?protection=true requests
+	// Commit is the head of the branch. Only its SHA and URL are set.
+	Commit GitCommit `json:"commit,omitempty"`
 	// TODO(fejta): consider including undocumented protection key
 }
 
@@ -915,6 +919,32 @@ type StatusEvent struct {
 	GUID string
 }
 
+// RateLimit is the API token budget of a resource, e.g. the REST API.
+type RateLimit struct {
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+	// Reset is the unix time at which the budget is reset.
+	Reset int64 `json:"reset"`
+}
+
+// RateLimits holds the API token budgets of each resource.
+type RateLimits struct {
+	Resources struct {
+		Core   RateLimit `json:"core"`
+		Search RateLimit `json:"search"`
+	} `json:"resources"`
+}
+
+// CommitComparison is the comparison of a head commit with a base commit.
+type CommitComparison struct {
+	// Status is one of "ahead", "behind", "diverged" or "identical".
+	Status string `json:"status"`
+	// AheadBy is the number of commits of the head that the base lacks.
+	AheadBy int `json:"ahead_by"`
+	// BehindBy is the number of commits of the base that the head lacks.
+	BehindBy int `json:"behind_by"`
+}
+
 // IssuesSearchResult represents the result of an issues search.
 type IssuesSearchResult struct {
 	Total  int     `json:"total_count,omitempty"`
@@ -1550,6 +1580,71 @@ type AppInstallation struct {
 	Permissions         InstallationPermissions `json:"permissions,omitempty"`
 	CreatedAt           string                  `json:"created_at,omitempty"`
 	UpdatedAt           string                  `json:"updated_at,omitempty"`
+	SuspendedAt         string                  `json:"suspended_at,omitempty"`
+}
+
+// EventInstallation is the installation reference GitHub includes in every
+// webhook payload delivered to a GitHub App.
+type EventInstallation struct {
+	ID     int64  `json:"id,omitempty"`
+	NodeID string `json:"node_id,omitempty"`
+}
+
+// InstallationRepository is the abbreviated repository representation used
+// in installation webhook payloads.
+type InstallationRepository struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Private  bool   `json:"private"`
+}
+
+// InstallationEventAction enumerates the triggers for this
+// webhook payload type. See also:
+// https://docs.github.com/en/webhooks/webhook-events-and-payloads#installation
+type InstallationEventAction string
+
+const (
+	// InstallationActionCreated means the GitHub App was installed.
+	InstallationActionCreated InstallationEventAction = "created"
+	// InstallationActionDeleted means the GitHub App was uninstalled.
+	InstallationActionDeleted InstallationEventAction = "deleted"
+	// InstallationActionSuspend means the installation was suspended.
+	InstallationActionSuspend InstallationEventAction = "suspend"
+	// InstallationActionUnsuspend means the installation was unsuspended.
+	InstallationActionUnsuspend InstallationEventAction = "unsuspend"
+	// InstallationActionNewPermissionsAccepted means new permissions were accepted.
+	InstallationActionNewPermissionsAccepted InstallationEventAction = "new_permissions_accepted"
+	// InstallationRepositoriesActionAdded means repositories were added to the installation.
+	InstallationRepositoriesActionAdded InstallationEventAction = "added"
+	// InstallationRepositoriesActionRemoved means repositories were removed from the installation.
+	InstallationRepositoriesActionRemoved InstallationEventAction = "removed"
+)
+
+// InstallationEvent is what GitHub sends a GitHub App when it is installed,
+// uninstalled, suspended or unsuspended.
+type InstallationEvent struct {
+	Action       InstallationEventAction  `json:"action"`
+	Installation AppInstallation          `json:"installation"`
+	Repositories []InstallationRepository `json:"repositories,omitempty"`
+	Sender       User                     `json:"sender"`
+
+	// GUID is included in the header of the request received by GitHub.
+	GUID string
+}
+
+// InstallationRepositoriesEvent is what GitHub sends a GitHub App when
+// repositories are added to or removed from an installation.
+type InstallationRepositoriesEvent struct {
+	Action              InstallationEventAction  `json:"action"`
+	Installation        AppInstallation          `json:"installation"`
+	RepositorySelection string                   `json:"repository_selection"`
+	RepositoriesAdded   []InstallationRepository `json:"repositories_added,omitempty"`
+	RepositoriesRemoved []InstallationRepository `json:"repositories_removed,omitempty"`
+	Sender              User                     `json:"sender"`
+
+	// GUID is included in the header of the request received by GitHub.
+	GUID string
 }
 
 // AppInstallationList represents the result of an AppInstallationList search.
@@ -1558,6 +1653,13 @@ type AppInstallationList struct {
 	Installations []AppInstallation `json:"installations,omitempty"`
 }
 
+// InstallationRepositoryList represents the result of listing the
+// repositories an app installation can access.
+type InstallationRepositoryList struct {
+	Total        int    `json:"total_count,omitempty"`
+	Repositories []Repo `json:"repositories,omitempty"`
+}
+
 // AppInstallationToken is the response when retrieving an app installation
 // token.
 type AppInstallationToken struct {
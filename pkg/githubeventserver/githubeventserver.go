@@ -31,6 +31,7 @@ import (
 
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/pluginhelp"
 	pluginhelp_externalplugins "sigs.k8s.io/prow/pkg/pluginhelp/externalplugins"
 	pluginhelp_hook "sigs.k8s.io/prow/pkg/pluginhelp/hook"
@@ -223,7 +224,7 @@ func (s *serveMuxHandler) handleEvent(eventType, eventGUID string, payload []byt
 	var org string
 	var repo string
 
-	l := logrus.WithFields(logrus.Fields{eventTypeField: eventType, github.EventGUID: eventGUID})
+	l := logrus.WithFields(logrus.Fields{eventTypeField: eventType, github.EventGUID: eventGUID, logrusutil.TraceIDField: eventGUID})
 
 	// We don't want to fail the webhook due to a metrics error.
 	if counter, err := s.metrics.WebhookCounter.GetMetricWithLabelValues(eventType); err != nil {
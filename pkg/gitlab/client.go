@@ -0,0 +1,341 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/version"
+)
+
+// DefaultEndpoint is the API endpoint of gitlab.com.
+const DefaultEndpoint = "https://gitlab.com/api/v4"
+
+// Client talks to the GitLab REST API. Projects and groups are identified by
+// their full path, e.g. "group/subgroup/project".
+type Client interface {
+	// BotUser returns the user the client is authenticated as.
+	BotUser() (*User, error)
+	// GetUser returns the user with the username.
+	GetUser(username string) (*User, error)
+
+	GetMergeRequest(project string, iid int) (*MergeRequest, error)
+	UpdateMergeRequest(project string, iid int, update MergeRequestUpdate) error
+	ListMergeRequestDiffs(project string, iid int) ([]Diff, error)
+	ListMergeRequestNotes(project string, iid int) ([]Note, error)
+	CreateMergeRequestNote(project string, iid int, body string) (*Note, error)
+	DeleteMergeRequestNote(project string, iid, noteID int) error
+	ListMergeRequestLabelEvents(project string, iid int) ([]LabelEvent, error)
+	GetMergeRequestApprovals(project string, iid int) (*Approvals, error)
+
+	// GetProjectMember returns the membership of the user in the project,
+	// including inherited memberships, or nil if the user is no member.
+	GetProjectMember(project string, userID int) (*Member, error)
+	// ListProjectMembers lists the members of the project, including
+	// inherited members.
+	ListProjectMembers(project string) ([]Member, error)
+	// GetGroupMember returns the membership of the user in the group,
+	// including inherited memberships, or nil if the user is no member.
+	GetGroupMember(group string, userID int) (*Member, error)
+	// ListGroupMembers lists the members of the group, including inherited
+	// members.
+	ListGroupMembers(group string) ([]Member, error)
+	ListSubgroups(group string) ([]Group, error)
+
+	GetBranch(project, branch string) (*Branch, error)
+}
+
+type client struct {
+	endpoint string
+	token    func() []byte
+	dryRun   bool
+	client   *http.Client
+	logger   *logrus.Entry
+
+	botUserLock sync.Mutex
+	botUser     *User
+}
+
+// NewClient returns a client for the API at endpoint, authenticated with the
+// token. In dry run mode, the client does not mutate anything.
+func NewClient(endpoint string, token func() []byte, dryRun bool) Client {
+	return &client{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    token,
+		dryRun:   dryRun,
+		client:   &http.Client{Timeout: time.Minute},
+		logger:   logrus.WithField("client", "gitlab"),
+	}
+}
+
+// requestError is returned for responses with an unexpected status.
+type requestError struct {
+	method, path string
+	statusCode   int
+	body         string
+}
+
+func (e *requestError) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.method, e.path, e.statusCode, e.body)
+}
+
+// IsNotFound returns whether the error is a response saying that the
+// requested object does not exist.
+func IsNotFound(err error) bool {
+	var reqErr *requestError
+	return errors.As(err, &reqErr) && reqErr.statusCode == http.StatusNotFound
+}
+
+// do sends the request and decodes the response into ret, if given. It
+// returns the response headers.
+func (c *client) do(method, path string, query url.Values, body, ret interface{}) (http.Header, error) {
+	if c.dryRun && method != http.MethodGet {
+		c.logger.WithFields(logrus.Fields{"method": method, "path": path}).Info("Not mutating in dry run mode.")
+		return http.Header{}, nil
+	}
+	var reqBody io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(raw)
+	}
+	u := c.endpoint + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if token := c.token(); len(token) > 0 {
+		req.Header.Set("PRIVATE-TOKEN", strings.TrimSpace(string(token)))
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", version.UserAgentWithIdentifier("gitlab"))
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &requestError{method: method, path: path, statusCode: resp.StatusCode, body: string(raw)}
+	}
+	if ret != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, ret); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the response of %s %s: %w", method, path, err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// list fetches all pages of the list at path, calling newPage to decode each
+// page.
+func (c *client) list(path string, query url.Values, newPage func() interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", "100")
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		header, err := c.do(http.MethodGet, path, query, nil, newPage())
+		if err != nil {
+			return err
+		}
+		page = header.Get("X-Next-Page")
+	}
+	return nil
+}
+
+func projectPath(project string) string {
+	return "/projects/" + url.PathEscape(project)
+}
+
+func groupPath(group string) string {
+	return "/groups/" + url.PathEscape(group)
+}
+
+func mergeRequestPath(project string, iid int) string {
+	return projectPath(project) + "/merge_requests/" + strconv.Itoa(iid)
+}
+
+func (c *client) BotUser() (*User, error) {
+	c.botUserLock.Lock()
+	defer c.botUserLock.Unlock()
+	if c.botUser != nil {
+		return c.botUser, nil
+	}
+	var user User
+	if _, err := c.do(http.MethodGet, "/user", nil, nil, &user); err != nil {
+		return nil, err
+	}
+	c.botUser = &user
+	return c.botUser, nil
+}
+
+func (c *client) GetUser(username string) (*User, error) {
+	var users []User
+	if _, err := c.do(http.MethodGet, "/users", url.Values{"username": {username}}, nil, &users); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, &requestError{method: http.MethodGet, path: "/users", statusCode: http.StatusNotFound, body: fmt.Sprintf("no user %q", username)}
+	}
+	return &users[0], nil
+}
+
+func (c *client) GetMergeRequest(project string, iid int) (*MergeRequest, error) {
+	var mr MergeRequest
+	if _, err := c.do(http.MethodGet, mergeRequestPath(project, iid), nil, nil, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+func (c *client) UpdateMergeRequest(project string, iid int, update MergeRequestUpdate) error {
+	_, err := c.do(http.MethodPut, mergeRequestPath(project, iid), nil, update, nil)
+	return err
+}
+
+func (c *client) ListMergeRequestDiffs(project string, iid int) ([]Diff, error) {
+	var diffs []Diff
+	err := c.list(mergeRequestPath(project, iid)+"/diffs", nil, func() interface{} {
+		return &pageOf[Diff]{items: &diffs}
+	})
+	return diffs, err
+}
+
+func (c *client) ListMergeRequestNotes(project string, iid int) ([]Note, error) {
+	var notes []Note
+	err := c.list(mergeRequestPath(project, iid)+"/notes", url.Values{"sort": {"asc"}, "order_by": {"created_at"}}, func() interface{} {
+		return &pageOf[Note]{items: &notes}
+	})
+	return notes, err
+}
+
+func (c *client) CreateMergeRequestNote(project string, iid int, body string) (*Note, error) {
+	var note Note
+	if _, err := c.do(http.MethodPost, mergeRequestPath(project, iid)+"/notes", nil, map[string]string{"body": body}, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (c *client) DeleteMergeRequestNote(project string, iid, noteID int) error {
+	_, err := c.do(http.MethodDelete, fmt.Sprintf("%s/notes/%d", mergeRequestPath(project, iid), noteID), nil, nil, nil)
+	return err
+}
+
+func (c *client) ListMergeRequestLabelEvents(project string, iid int) ([]LabelEvent, error) {
+	var events []LabelEvent
+	err := c.list(mergeRequestPath(project, iid)+"/resource_label_events", nil, func() interface{} {
+		return &pageOf[LabelEvent]{items: &events}
+	})
+	return events, err
+}
+
+func (c *client) GetMergeRequestApprovals(project string, iid int) (*Approvals, error) {
+	var approvals Approvals
+	if _, err := c.do(http.MethodGet, mergeRequestPath(project, iid)+"/approvals", nil, nil, &approvals); err != nil {
+		return nil, err
+	}
+	return &approvals, nil
+}
+
+func (c *client) getMember(path string) (*Member, error) {
+	var member Member
+	if _, err := c.do(http.MethodGet, path, nil, nil, &member); err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (c *client) GetProjectMember(project string, userID int) (*Member, error) {
+	return c.getMember(fmt.Sprintf("%s/members/all/%d", projectPath(project), userID))
+}
+
+func (c *client) ListProjectMembers(project string) ([]Member, error) {
+	var members []Member
+	err := c.list(projectPath(project)+"/members/all", nil, func() interface{} {
+		return &pageOf[Member]{items: &members}
+	})
+	return members, err
+}
+
+func (c *client) GetGroupMember(group string, userID int) (*Member, error) {
+	return c.getMember(fmt.Sprintf("%s/members/all/%d", groupPath(group), userID))
+}
+
+func (c *client) ListGroupMembers(group string) ([]Member, error) {
+	var members []Member
+	err := c.list(groupPath(group)+"/members/all", nil, func() interface{} {
+		return &pageOf[Member]{items: &members}
+	})
+	return members, err
+}
+
+func (c *client) ListSubgroups(group string) ([]Group, error) {
+	var groups []Group
+	err := c.list(groupPath(group)+"/subgroups", nil, func() interface{} {
+		return &pageOf[Group]{items: &groups}
+	})
+	return groups, err
+}
+
+func (c *client) GetBranch(project, branch string) (*Branch, error) {
+	var b Branch
+	if _, err := c.do(http.MethodGet, projectPath(project)+"/repository/branches/"+url.PathEscape(branch), nil, nil, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// pageOf appends the items of a page of a list to items.
+type pageOf[T any] struct {
+	items *[]T
+}
+
+func (p *pageOf[T]) UnmarshalJSON(raw []byte) error {
+	var page []T
+	if err := json.Unmarshal(raw, &page); err != nil {
+		return err
+	}
+	*p.items = append(*p.items, page...)
+	return nil
+}
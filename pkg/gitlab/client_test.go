@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.Header.Get("PRIVATE-TOKEN"); token != "secret" {
+			t.Errorf("expected the token to be sent, got %q", token)
+		}
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath()+" "+r.URL.Query().Get("page"))
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /api/v4/projects/group%2Fsub%2Fproject/merge_requests/1/notes":
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				fmt.Fprint(w, `[{"id":1,"body":"first"}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"id":2,"body":"second"}]`)
+		case "GET /api/v4/projects/group%2Fsub%2Fproject/members/all/3":
+			fmt.Fprint(w, `{"id":3,"username":"alice","access_level":30}`)
+		case "DELETE /api/v4/projects/group%2Fsub%2Fproject/merge_requests/1/notes/2":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, `{"message":"404 Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	token := func() []byte { return []byte("secret\n") }
+	c := NewClient(server.URL+"/api/v4/", token, false)
+
+	notes, err := c.ListMergeRequestNotes("group/sub/project", 1)
+	if err != nil {
+		t.Fatalf("failed to list notes: %v", err)
+	}
+	if diff := cmp.Diff([]Note{{ID: 1, Body: "first"}, {ID: 2, Body: "second"}}, notes); diff != "" {
+		t.Errorf("notes differ from expected (-want +got):\n%s", diff)
+	}
+
+	member, err := c.GetProjectMember("group/sub/project", 3)
+	if err != nil {
+		t.Fatalf("failed to get member: %v", err)
+	}
+	if member == nil || member.AccessLevel != DeveloperAccess {
+		t.Errorf("expected a developer, got %+v", member)
+	}
+	if member, err := c.GetProjectMember("group/sub/project", 4); err != nil || member != nil {
+		t.Errorf("expected no member and no error, got %+v and %v", member, err)
+	}
+
+	if _, err := c.GetMergeRequest("group/sub/project", 2); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	if err := c.DeleteMergeRequestNote("group/sub/project", 1, 2); err != nil {
+		t.Errorf("failed to delete note: %v", err)
+	}
+	dryRun := NewClient(server.URL+"/api/v4", token, true)
+	if err := dryRun.DeleteMergeRequestNote("group/sub/project", 1, 1); err != nil {
+		t.Errorf("failed to delete note in dry run mode: %v", err)
+	}
+
+	expected := []string{
+		"GET /api/v4/projects/group%2Fsub%2Fproject/merge_requests/1/notes 1",
+		"GET /api/v4/projects/group%2Fsub%2Fproject/merge_requests/1/notes 2",
+		"GET /api/v4/projects/group%2Fsub%2Fproject/members/all/3 ",
+		"GET /api/v4/projects/group%2Fsub%2Fproject/members/all/4 ",
+		"GET /api/v4/projects/group%2Fsub%2Fproject/merge_requests/2 ",
+		"DELETE /api/v4/projects/group%2Fsub%2Fproject/merge_requests/1/notes/2 ",
+	}
+	if diff := cmp.Diff(expected, requests); diff != "" {
+		t.Errorf("requests differ from expected (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// Kinds of webhook events, as sent in the X-Gitlab-Event header.
+// See https://docs.gitlab.com/ee/user/project/integrations/webhook_events.html
+const (
+	NoteHook         = "Note Hook"
+	MergeRequestHook = "Merge Request Hook"
+)
+
+// Project is the project of a webhook event.
+type Project struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+	DefaultBranch     string `json:"default_branch"`
+}
+
+// OrgRepo splits the path of the project into the org and the repo it maps
+// to. Projects in subgroups keep the full path of their group as their org.
+func (p Project) OrgRepo() (string, string) {
+	idx := strings.LastIndex(p.PathWithNamespace, "/")
+	if idx < 0 {
+		return "", p.PathWithNamespace
+	}
+	return p.PathWithNamespace[:idx], p.PathWithNamespace[idx+1:]
+}
+
+// NoteEvent is sent when a note is added to a merge request, issue, commit or
+// snippet.
+type NoteEvent struct {
+	User             User    `json:"user"`
+	Project          Project `json:"project"`
+	ObjectAttributes struct {
+		ID           int    `json:"id"`
+		Note         string `json:"note"`
+		NoteableType string `json:"noteable_type"`
+		System       bool   `json:"system"`
+		URL          string `json:"url"`
+	} `json:"object_attributes"`
+	MergeRequest *struct {
+		IID int `json:"iid"`
+	} `json:"merge_request"`
+}
+
+// Actions of merge request events.
+const (
+	MergeRequestActionOpen       = "open"
+	MergeRequestActionClose      = "close"
+	MergeRequestActionReopen     = "reopen"
+	MergeRequestActionUpdate     = "update"
+	MergeRequestActionApproved   = "approved"
+	MergeRequestActionUnapproved = "unapproved"
+	MergeRequestActionMerge      = "merge"
+)
+
+// MergeRequestEvent is sent when a merge request is opened, updated, approved
+// or closed.
+type MergeRequestEvent struct {
+	User             User    `json:"user"`
+	Project          Project `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Action string `json:"action"`
+		// OldRev is set on updates that pushed commits.
+		OldRev string `json:"oldrev"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Labels *struct {
+			Previous []Label `json:"previous"`
+			Current  []Label `json:"current"`
+		} `json:"labels"`
+	} `json:"changes"`
+}
+
+// AddedLabels returns the labels the update of the event added.
+func (e MergeRequestEvent) AddedLabels() []string {
+	if e.Changes.Labels == nil {
+		return nil
+	}
+	previous := map[string]bool{}
+	for _, label := range e.Changes.Labels.Previous {
+		previous[label.Title] = true
+	}
+	var added []string
+	for _, label := range e.Changes.Labels.Current {
+		if !previous[label.Title] {
+			added = append(added, label.Title)
+		}
+	}
+	return added
+}
+
+// GitHubRepo returns the GitHub repo the project maps to.
+func (p Project) GitHubRepo() github.Repo {
+	org, repo := p.OrgRepo()
+	return github.Repo{
+		Owner:         github.User{Login: org},
+		Name:          repo,
+		FullName:      p.PathWithNamespace,
+		HTMLURL:       p.WebURL,
+		DefaultBranch: p.DefaultBranch,
+	}
+}
+
+// GitHubUser returns the GitHub user the GitLab user maps to.
+func (u User) GitHubUser() github.User {
+	return github.User{Login: u.Username, Name: u.Name, ID: u.ID, HTMLURL: u.WebURL}
+}
+
+// GitHubPullRequest returns the GitHub pull request the merge request maps to.
+func (mr *MergeRequest) GitHubPullRequest(project Project) github.PullRequest {
+	state := github.PullRequestStateOpen
+	if mr.State != MergeRequestStateOpened {
+		state = github.PullRequestStateClosed
+	}
+	repo := project.GitHubRepo()
+	var labels []github.Label
+	for _, label := range mr.Labels {
+		labels = append(labels, github.Label{Name: label})
+	}
+	return github.PullRequest{
+		ID:        mr.ID,
+		Number:    mr.IID,
+		HTMLURL:   mr.WebURL,
+		User:      mr.Author.GitHubUser(),
+		Labels:    labels,
+		Base:      github.PullRequestBranch{Ref: mr.TargetBranch, Repo: repo},
+		Head:      github.PullRequestBranch{Ref: mr.SourceBranch, SHA: mr.SHA, Repo: repo},
+		Title:     mr.Title,
+		Body:      mr.Description,
+		State:     state,
+		Merged:    mr.State == MergeRequestStateMerged,
+		Assignees: gitHubUsers(mr.Assignees),
+		CreatedAt: mr.CreatedAt,
+		UpdatedAt: mr.UpdatedAt,
+	}
+}
+
+func gitHubUsers(users []User) []github.User {
+	var converted []github.User
+	for _, user := range users {
+		converted = append(converted, user.GitHubUser())
+	}
+	return converted
+}
+
+// GenericCommentEvent returns the GitHub comment event the note on the merge
+// request maps to. It returns false if the note is not a user's note on a
+// merge request.
+func (e NoteEvent) GenericCommentEvent(mr *MergeRequest) (github.GenericCommentEvent, bool) {
+	if e.ObjectAttributes.NoteableType != "MergeRequest" || e.ObjectAttributes.System || e.MergeRequest == nil {
+		return github.GenericCommentEvent{}, false
+	}
+	pr := mr.GitHubPullRequest(e.Project)
+	commentID := e.ObjectAttributes.ID
+	return github.GenericCommentEvent{
+		ID:           commentID,
+		CommentID:    &commentID,
+		IsPR:         true,
+		Action:       github.GenericCommentActionCreated,
+		Body:         e.ObjectAttributes.Note,
+		HTMLURL:      e.ObjectAttributes.URL,
+		Number:       mr.IID,
+		Repo:         e.Project.GitHubRepo(),
+		User:         e.User.GitHubUser(),
+		IssueAuthor:  pr.User,
+		Assignees:    pr.Assignees,
+		IssueState:   pr.State,
+		IssueTitle:   pr.Title,
+		IssueBody:    pr.Body,
+		IssueHTMLURL: pr.HTMLURL,
+	}, true
+}
+
+// PullRequestEvents returns the GitHub pull request events the merge request
+// event maps to. Updates adding labels map to one event per added label.
+func (e MergeRequestEvent) PullRequestEvents(mr *MergeRequest) []github.PullRequestEvent {
+	event := func(action github.PullRequestEventAction) github.PullRequestEvent {
+		return github.PullRequestEvent{
+			Action:      action,
+			Number:      mr.IID,
+			PullRequest: mr.GitHubPullRequest(e.Project),
+			Repo:        e.Project.GitHubRepo(),
+			Sender:      e.User.GitHubUser(),
+		}
+	}
+	switch e.ObjectAttributes.Action {
+	case MergeRequestActionOpen:
+		return []github.PullRequestEvent{event(github.PullRequestActionOpened)}
+	case MergeRequestActionReopen:
+		return []github.PullRequestEvent{event(github.PullRequestActionReopened)}
+	case MergeRequestActionClose, MergeRequestActionMerge:
+		return []github.PullRequestEvent{event(github.PullRequestActionClosed)}
+	case MergeRequestActionUpdate:
+		var events []github.PullRequestEvent
+		if e.ObjectAttributes.OldRev != "" {
+			events = append(events, event(github.PullRequestActionSynchronize))
+		}
+		for _, label := range e.AddedLabels() {
+			labeled := event(github.PullRequestActionLabeled)
+			labeled.Label = github.Label{Name: label}
+			events = append(events, labeled)
+		}
+		return events
+	}
+	return nil
+}
+
+// ReviewEvent returns the GitHub review event an approval or its revocation
+// maps to. It returns false for other merge request events.
+func (e MergeRequestEvent) ReviewEvent(mr *MergeRequest) (github.ReviewEvent, bool) {
+	var action github.ReviewEventAction
+	var state github.ReviewState
+	switch e.ObjectAttributes.Action {
+	case MergeRequestActionApproved:
+		action, state = github.ReviewActionSubmitted, github.ReviewStateApproved
+	case MergeRequestActionUnapproved:
+		action, state = github.ReviewActionDismissed, github.ReviewStateDismissed
+	default:
+		return github.ReviewEvent{}, false
+	}
+	return github.ReviewEvent{
+		Action:      action,
+		PullRequest: mr.GitHubPullRequest(e.Project),
+		Repo:        e.Project.GitHubRepo(),
+		Review: github.Review{
+			User:    e.User.GitHubUser(),
+			State:   state,
+			HTMLURL: mr.WebURL,
+		},
+	}, true
+}
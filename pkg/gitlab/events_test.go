@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+func TestProjectOrgRepo(t *testing.T) {
+	testCases := []struct {
+		path         string
+		expectedOrg  string
+		expectedRepo string
+	}{
+		{path: "org/repo", expectedOrg: "org", expectedRepo: "repo"},
+		{path: "group/subgroup/repo", expectedOrg: "group/subgroup", expectedRepo: "repo"},
+		{path: "repo", expectedOrg: "", expectedRepo: "repo"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			org, repo := Project{PathWithNamespace: tc.path}.OrgRepo()
+			if org != tc.expectedOrg || repo != tc.expectedRepo {
+				t.Errorf("expected %q/%q, got %q/%q", tc.expectedOrg, tc.expectedRepo, org, repo)
+			}
+		})
+	}
+}
+
+func TestNoteEventGenericCommentEvent(t *testing.T) {
+	mr := &MergeRequest{IID: 7, State: MergeRequestStateOpened, Author: User{ID: 1, Username: "author"}, Title: "Fix things"}
+	testCases := []struct {
+		name       string
+		payload    string
+		expectedOK bool
+	}{
+		{
+			name:       "note on a merge request",
+			payload:    `{"user":{"id":2,"username":"reviewer"},"project":{"path_with_namespace":"org/repo"},"object_attributes":{"id":3,"note":"/lgtm","noteable_type":"MergeRequest"},"merge_request":{"iid":7}}`,
+			expectedOK: true,
+		},
+		{
+			name:    "system note",
+			payload: `{"user":{"id":2,"username":"reviewer"},"project":{"path_with_namespace":"org/repo"},"object_attributes":{"id":3,"note":"added 1 commit","noteable_type":"MergeRequest","system":true},"merge_request":{"iid":7}}`,
+		},
+		{
+			name:    "note on an issue",
+			payload: `{"user":{"id":2,"username":"reviewer"},"project":{"path_with_namespace":"org/repo"},"object_attributes":{"id":3,"note":"/lgtm","noteable_type":"Issue"}}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var e NoteEvent
+			if err := json.Unmarshal([]byte(tc.payload), &e); err != nil {
+				t.Fatalf("failed to unmarshal event: %v", err)
+			}
+			ce, ok := e.GenericCommentEvent(mr)
+			if ok != tc.expectedOK {
+				t.Fatalf("expected ok to be %t, got %t", tc.expectedOK, ok)
+			}
+			if !ok {
+				return
+			}
+			commentID := 3
+			expected := github.GenericCommentEvent{
+				ID:          3,
+				CommentID:   &commentID,
+				IsPR:        true,
+				Action:      github.GenericCommentActionCreated,
+				Body:        "/lgtm",
+				Number:      7,
+				Repo:        github.Repo{Owner: github.User{Login: "org"}, Name: "repo", FullName: "org/repo"},
+				User:        github.User{Login: "reviewer", ID: 2},
+				IssueAuthor: github.User{Login: "author", ID: 1},
+				IssueState:  github.PullRequestStateOpen,
+				IssueTitle:  "Fix things",
+			}
+			if diff := cmp.Diff(expected, ce); diff != "" {
+				t.Errorf("event differs from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeRequestEventConversions(t *testing.T) {
+	mr := &MergeRequest{IID: 7, State: MergeRequestStateOpened, SHA: "abc"}
+	testCases := []struct {
+		name            string
+		payload         string
+		expectedActions []github.PullRequestEventAction
+		expectedLabels  []string
+		expectedReview  github.ReviewState
+	}{
+		{
+			name:            "opened",
+			payload:         `{"object_attributes":{"iid":7,"action":"open"}}`,
+			expectedActions: []github.PullRequestEventAction{github.PullRequestActionOpened},
+			expectedLabels:  []string{""},
+		},
+		{
+			name:            "merged",
+			payload:         `{"object_attributes":{"iid":7,"action":"merge"}}`,
+			expectedActions: []github.PullRequestEventAction{github.PullRequestActionClosed},
+			expectedLabels:  []string{""},
+		},
+		{
+			name:            "pushed and labeled",
+			payload:         `{"object_attributes":{"iid":7,"action":"update","oldrev":"def"},"changes":{"labels":{"previous":[{"title":"a"}],"current":[{"title":"a"},{"title":"b"},{"title":"c"}]}}}`,
+			expectedActions: []github.PullRequestEventAction{github.PullRequestActionSynchronize, github.PullRequestActionLabeled, github.PullRequestActionLabeled},
+			expectedLabels:  []string{"", "b", "c"},
+		},
+		{
+			name:    "label removed",
+			payload: `{"object_attributes":{"iid":7,"action":"update"},"changes":{"labels":{"previous":[{"title":"a"}],"current":[]}}}`,
+		},
+		{
+			name:           "approved",
+			payload:        `{"object_attributes":{"iid":7,"action":"approved"}}`,
+			expectedReview: github.ReviewStateApproved,
+		},
+		{
+			name:           "unapproved",
+			payload:        `{"object_attributes":{"iid":7,"action":"unapproved"}}`,
+			expectedReview: github.ReviewStateDismissed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var e MergeRequestEvent
+			if err := json.Unmarshal([]byte(tc.payload), &e); err != nil {
+				t.Fatalf("failed to unmarshal event: %v", err)
+			}
+			var actions []github.PullRequestEventAction
+			var labels []string
+			for _, pe := range e.PullRequestEvents(mr) {
+				actions = append(actions, pe.Action)
+				labels = append(labels, pe.Label.Name)
+				if pe.PullRequest.Head.SHA != "abc" {
+					t.Errorf("expected the head SHA of the merge request, got %q", pe.PullRequest.Head.SHA)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedActions, actions); diff != "" {
+				t.Errorf("actions differ from expected (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedLabels, labels); diff != "" {
+				t.Errorf("labels differ from expected (-want +got):\n%s", diff)
+			}
+			re, ok := e.ReviewEvent(mr)
+			if ok != (tc.expectedReview != "") {
+				t.Fatalf("expected a review event to be returned: %t, got %t", tc.expectedReview != "", ok)
+			}
+			if re.Review.State != tc.expectedReview {
+				t.Errorf("expected review state %q, got %q", tc.expectedReview, re.Review.State)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// GitHubClient implements the parts of the GitHub client that the approve and
+// lgtm plugins and the OWNERS client use on top of the GitLab API, so that
+// they act on merge requests as they do on pull requests:
+//   - pull requests are merge requests and their numbers are IIDs,
+//   - comments are the notes of users on merge requests,
+//   - reviews are approvals of merge requests,
+//   - collaborators are project members with at least developer access,
+//   - org members are members of the group of the project, and
+//   - teams are subgroups of that group, with their path as slug.
+//
+// GitLab does not expose the tree hash of commits, so commits stand in for
+// their tree and LGTM stored by tree hash is removed on every push.
+type GitHubClient struct {
+	client Client
+
+	// noteMergeRequests maps notes to the IIDs of their merge requests, as
+	// GitLab needs both to delete a note.
+	lock              sync.Mutex
+	noteMergeRequests map[noteKey]int
+}
+
+type noteKey struct {
+	project string
+	id      int
+}
+
+// NewGitHubClient returns a GitHub client that acts on GitLab through client.
+func NewGitHubClient(client Client) *GitHubClient {
+	return &GitHubClient{client: client, noteMergeRequests: map[noteKey]int{}}
+}
+
+func projectFor(org, repo string) string {
+	return org + "/" + repo
+}
+
+func (c *GitHubClient) rememberNote(project string, iid, id int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.noteMergeRequests[noteKey{project: project, id: id}] = iid
+}
+
+// BotUserChecker returns a func checking whether a user is the user the
+// client is authenticated as.
+func (c *GitHubClient) BotUserChecker() (func(candidate string) bool, error) {
+	bot, err := c.client.BotUser()
+	if err != nil {
+		return nil, err
+	}
+	botLogin := github.NormLogin(bot.Username)
+	return func(candidate string) bool {
+		return github.NormLogin(candidate) == botLogin
+	}, nil
+}
+
+// GetPullRequest returns the merge request with the IID number.
+func (c *GitHubClient) GetPullRequest(org, repo string, number int) (*github.PullRequest, error) {
+	mr, err := c.client.GetMergeRequest(projectFor(org, repo), number)
+	if err != nil {
+		return nil, err
+	}
+	pr := mr.GitHubPullRequest(Project{PathWithNamespace: projectFor(org, repo)})
+	return &pr, nil
+}
+
+// GetPullRequestChanges returns the files the merge request changes.
+func (c *GitHubClient) GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error) {
+	diffs, err := c.client.ListMergeRequestDiffs(projectFor(org, repo), number)
+	if err != nil {
+		return nil, err
+	}
+	var changes []github.PullRequestChange
+	for _, diff := range diffs {
+		change := github.PullRequestChange{Filename: diff.NewPath, Patch: diff.Diff, Status: string(github.PullRequestFileModified)}
+		switch {
+		case diff.NewFile:
+			change.Status = github.PullRequestFileAdded
+		case diff.DeletedFile:
+			change.Status = github.PullRequestFileRemoved
+		case diff.RenamedFile:
+			change.Status = github.PullRequestFileRenamed
+			change.PreviousFilename = diff.OldPath
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// GetIssueLabels returns the labels of the merge request.
+func (c *GitHubClient) GetIssueLabels(org, repo string, number int) ([]github.Label, error) {
+	pr, err := c.GetPullRequest(org, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return pr.Labels, nil
+}
+
+// AddLabel adds the label to the merge request.
+func (c *GitHubClient) AddLabel(org, repo string, number int, label string) error {
+	return c.client.UpdateMergeRequest(projectFor(org, repo), number, MergeRequestUpdate{AddLabels: label})
+}
+
+// RemoveLabel removes the label from the merge request.
+func (c *GitHubClient) RemoveLabel(org, repo string, number int, label string) error {
+	return c.client.UpdateMergeRequest(projectFor(org, repo), number, MergeRequestUpdate{RemoveLabels: label})
+}
+
+// WasLabelAddedByHuman returns whether the label was last added to the merge
+// request by someone other than the bot.
+func (c *GitHubClient) WasLabelAddedByHuman(org, repo string, number int, label string) (bool, error) {
+	isBot, err := c.BotUserChecker()
+	if err != nil {
+		return false, err
+	}
+	events, err := c.client.ListMergeRequestLabelEvents(projectFor(org, repo), number)
+	if err != nil {
+		return false, err
+	}
+	var lastAdder string
+	for _, event := range events {
+		if event.Action == LabelEventActionAdd && strings.EqualFold(labelName(event.Label), label) {
+			lastAdder = event.User.Username
+		}
+	}
+	return lastAdder != "" && !isBot(lastAdder), nil
+}
+
+func labelName(label Label) string {
+	if label.Name != "" {
+		return label.Name
+	}
+	return label.Title
+}
+
+// ListIssueComments returns the notes of users on the merge request, oldest
+// first. Notes GitLab creates on changes are left out.
+func (c *GitHubClient) ListIssueComments(org, repo string, number int) ([]github.IssueComment, error) {
+	project := projectFor(org, repo)
+	notes, err := c.client.ListMergeRequestNotes(project, number)
+	if err != nil {
+		return nil, err
+	}
+	var comments []github.IssueComment
+	for _, note := range notes {
+		if note.System {
+			continue
+		}
+		c.rememberNote(project, number, note.ID)
+		comments = append(comments, github.IssueComment{
+			ID:        note.ID,
+			Body:      note.Body,
+			User:      note.Author.GitHubUser(),
+			CreatedAt: note.CreatedAt,
+			UpdatedAt: note.UpdatedAt,
+		})
+	}
+	return comments, nil
+}
+
+// ListPullRequestComments returns no comments, as notes on the diff of a
+// merge request are listed by ListIssueComments.
+func (c *GitHubClient) ListPullRequestComments(org, repo string, number int) ([]github.ReviewComment, error) {
+	return nil, nil
+}
+
+// ListReviews returns the approvals of the merge request as approving
+// reviews.
+func (c *GitHubClient) ListReviews(org, repo string, number int) ([]github.Review, error) {
+	approvals, err := c.client.GetMergeRequestApprovals(projectFor(org, repo), number)
+	if err != nil {
+		return nil, err
+	}
+	var reviews []github.Review
+	for _, approval := range approvals.ApprovedBy {
+		reviews = append(reviews, github.Review{User: approval.User.GitHubUser(), State: github.ReviewStateApproved})
+	}
+	return reviews, nil
+}
+
+// CreateComment adds a note to the merge request.
+func (c *GitHubClient) CreateComment(org, repo string, number int, comment string) error {
+	project := projectFor(org, repo)
+	note, err := c.client.CreateMergeRequestNote(project, number, comment)
+	if err != nil {
+		return err
+	}
+	c.rememberNote(project, number, note.ID)
+	return nil
+}
+
+// DeleteComment deletes a note the client listed or created before.
+func (c *GitHubClient) DeleteComment(org, repo string, id int) error {
+	project := projectFor(org, repo)
+	c.lock.Lock()
+	iid, ok := c.noteMergeRequests[noteKey{project: project, id: id}]
+	c.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown merge request of note %d in %s", id, project)
+	}
+	if err := c.client.DeleteMergeRequestNote(project, iid, id); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	delete(c.noteMergeRequests, noteKey{project: project, id: id})
+	c.lock.Unlock()
+	return nil
+}
+
+// IsCollaborator returns whether the user has at least developer access to
+// the project.
+func (c *GitHubClient) IsCollaborator(org, repo, login string) (bool, error) {
+	user, err := c.client.GetUser(login)
+	if err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	member, err := c.client.GetProjectMember(projectFor(org, repo), user.ID)
+	if err != nil {
+		return false, err
+	}
+	return member != nil && member.AccessLevel >= DeveloperAccess, nil
+}
+
+// ListCollaborators returns the project members with at least developer
+// access.
+func (c *GitHubClient) ListCollaborators(org, repo string) ([]github.User, error) {
+	members, err := c.client.ListProjectMembers(projectFor(org, repo))
+	if err != nil {
+		return nil, err
+	}
+	var collaborators []github.User
+	for _, member := range members {
+		if member.AccessLevel >= DeveloperAccess {
+			collaborators = append(collaborators, member.User.GitHubUser())
+		}
+	}
+	return collaborators, nil
+}
+
+// IsMember returns whether the user is a member of the group.
+func (c *GitHubClient) IsMember(org, login string) (bool, error) {
+	user, err := c.client.GetUser(login)
+	if err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	member, err := c.client.GetGroupMember(org, user.ID)
+	if err != nil {
+		return false, err
+	}
+	return member != nil, nil
+}
+
+// ListTeams returns the subgroups of the group.
+func (c *GitHubClient) ListTeams(org string) ([]github.Team, error) {
+	groups, err := c.client.ListSubgroups(org)
+	if err != nil {
+		return nil, err
+	}
+	var teams []github.Team
+	for _, group := range groups {
+		teams = append(teams, github.Team{ID: group.ID, Name: group.Name, Slug: group.Path})
+	}
+	return teams, nil
+}
+
+// ListTeamMembersBySlug returns the members of the subgroup with the path
+// slug. Subgroups have no maintainers in the GitHub sense, so role is ignored.
+func (c *GitHubClient) ListTeamMembersBySlug(org, slug, role string) ([]github.TeamMember, error) {
+	members, err := c.client.ListGroupMembers(org + "/" + slug)
+	if err != nil {
+		return nil, err
+	}
+	var teamMembers []github.TeamMember
+	for _, member := range members {
+		teamMembers = append(teamMembers, github.TeamMember{Login: member.Username})
+	}
+	return teamMembers, nil
+}
+
+// userIDs returns the IDs of the users with the logins, in addition to the
+// IDs of the given users.
+func (c *GitHubClient) userIDs(users []User, logins []string) ([]int, error) {
+	var ids []int
+	known := map[string]bool{}
+	for _, user := range users {
+		ids = append(ids, user.ID)
+		known[github.NormLogin(user.Username)] = true
+	}
+	for _, login := range logins {
+		if known[github.NormLogin(login)] {
+			continue
+		}
+		user, err := c.client.GetUser(login)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, user.ID)
+		known[github.NormLogin(login)] = true
+	}
+	return ids, nil
+}
+
+// AssignIssue adds the users to the assignees of the merge request.
+func (c *GitHubClient) AssignIssue(org, repo string, number int, logins []string) error {
+	project := projectFor(org, repo)
+	mr, err := c.client.GetMergeRequest(project, number)
+	if err != nil {
+		return err
+	}
+	ids, err := c.userIDs(mr.Assignees, logins)
+	if err != nil {
+		return err
+	}
+	return c.client.UpdateMergeRequest(project, number, MergeRequestUpdate{AssigneeIDs: ids})
+}
+
+// RequestReview adds the users to the reviewers of the merge request.
+func (c *GitHubClient) RequestReview(org, repo string, number int, logins []string) error {
+	project := projectFor(org, repo)
+	mr, err := c.client.GetMergeRequest(project, number)
+	if err != nil {
+		return err
+	}
+	ids, err := c.userIDs(mr.Reviewers, logins)
+	if err != nil {
+		return err
+	}
+	return c.client.UpdateMergeRequest(project, number, MergeRequestUpdate{ReviewerIDs: ids})
+}
+
+// GetSingleCommit returns the commit with its SHA standing in for its tree
+// hash, which GitLab does not expose.
+func (c *GitHubClient) GetSingleCommit(org, repo, sha string) (github.RepositoryCommit, error) {
+	return github.RepositoryCommit{SHA: sha, Commit: github.GitCommit{SHA: sha, Tree: github.Tree{SHA: sha}}}, nil
+}
+
+// GetRef returns the SHA of the branch of the ref "heads/<branch>".
+func (c *GitHubClient) GetRef(org, repo, ref string) (string, error) {
+	branch, err := c.client.GetBranch(projectFor(org, repo), strings.TrimPrefix(ref, "heads/"))
+	if err != nil {
+		return "", err
+	}
+	return branch.Commit.ID, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeClient implements the parts of Client the tests use.
+type fakeClient struct {
+	Client
+
+	users        map[string]*User
+	members      map[int]*Member
+	notes        []Note
+	deletedNotes []int
+}
+
+func (f *fakeClient) GetUser(username string) (*User, error) {
+	if user, ok := f.users[username]; ok {
+		return user, nil
+	}
+	return nil, &requestError{statusCode: http.StatusNotFound}
+}
+
+func (f *fakeClient) GetProjectMember(project string, userID int) (*Member, error) {
+	return f.members[userID], nil
+}
+
+func (f *fakeClient) ListMergeRequestNotes(project string, iid int) ([]Note, error) {
+	return f.notes, nil
+}
+
+func (f *fakeClient) DeleteMergeRequestNote(project string, iid, noteID int) error {
+	f.deletedNotes = append(f.deletedNotes, noteID)
+	return nil
+}
+
+func TestGitHubClientIsCollaborator(t *testing.T) {
+	fc := &fakeClient{
+		users: map[string]*User{
+			"developer": {ID: 1},
+			"reporter":  {ID: 2},
+			"stranger":  {ID: 3},
+		},
+		members: map[int]*Member{
+			1: {AccessLevel: DeveloperAccess},
+			2: {AccessLevel: ReporterAccess},
+		},
+	}
+	c := NewGitHubClient(fc)
+	for login, expected := range map[string]bool{"developer": true, "reporter": false, "stranger": false, "ghost": false} {
+		t.Run(login, func(t *testing.T) {
+			isCollaborator, err := c.IsCollaborator("org", "repo", login)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if isCollaborator != expected {
+				t.Errorf("expected %t, got %t", expected, isCollaborator)
+			}
+		})
+	}
+}
+
+func TestGitHubClientComments(t *testing.T) {
+	fc := &fakeClient{notes: []Note{
+		{ID: 1, Body: "/lgtm", Author: User{Username: "alice"}},
+		{ID: 2, Body: "added 1 commit", System: true},
+		{ID: 3, Body: "[APPROVALNOTIFIER] This PR is **APPROVED**", Author: User{Username: "bot"}},
+	}}
+	c := NewGitHubClient(fc)
+	if err := c.DeleteComment("org", "repo", 3); err == nil {
+		t.Error("expected deleting a note that was not listed to fail")
+	}
+	comments, err := c.ListIssueComments("org", "repo", 5)
+	if err != nil {
+		t.Fatalf("failed to list comments: %v", err)
+	}
+	var ids []int
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	if diff := cmp.Diff([]int{1, 3}, ids); diff != "" {
+		t.Errorf("comments differ from expected (-want +got):\n%s", diff)
+	}
+	if err := c.DeleteComment("org", "repo", 3); err != nil {
+		t.Fatalf("failed to delete comment: %v", err)
+	}
+	if diff := cmp.Diff([]int{3}, fc.deletedNotes); diff != "" {
+		t.Errorf("deleted notes differ from expected (-want +got):\n%s", diff)
+	}
+}
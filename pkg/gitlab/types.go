@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gitlab contains a client for the GitLab REST API and the types of
+// its webhook events, as well as an adapter that lets Prow plugins written
+// against the GitHub client act on GitLab merge requests.
+package gitlab
+
+import "time"
+
+// User is a GitLab user.
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name,omitempty"`
+	WebURL   string `json:"web_url,omitempty"`
+	// Bot is true for bot users, e.g. project and group access tokens.
+	Bot bool `json:"bot,omitempty"`
+}
+
+// Access levels of project and group members.
+// See https://docs.gitlab.com/ee/api/members.html#roles
+const (
+	GuestAccess      = 10
+	ReporterAccess   = 20
+	DeveloperAccess  = 30
+	MaintainerAccess = 40
+	OwnerAccess      = 50
+)
+
+// Member is a user who is a member of a project or group.
+type Member struct {
+	User
+	AccessLevel int    `json:"access_level"`
+	State       string `json:"state,omitempty"`
+}
+
+// Group is a GitLab group. Subgroups are what GitHub teams map to.
+type Group struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	FullPath string `json:"full_path"`
+}
+
+// MergeRequest is a GitLab merge request. Merge requests are identified by
+// their IID within their project.
+type MergeRequest struct {
+	ID           int       `json:"id"`
+	IID          int       `json:"iid"`
+	ProjectID    int       `json:"project_id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	State        string    `json:"state"`
+	TargetBranch string    `json:"target_branch"`
+	SourceBranch string    `json:"source_branch"`
+	SHA          string    `json:"sha"`
+	Author       User      `json:"author"`
+	Assignees    []User    `json:"assignees"`
+	Reviewers    []User    `json:"reviewers"`
+	Labels       []string  `json:"labels"`
+	WebURL       string    `json:"web_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// States of merge requests.
+const (
+	MergeRequestStateOpened = "opened"
+	MergeRequestStateClosed = "closed"
+	MergeRequestStateMerged = "merged"
+	MergeRequestStateLocked = "locked"
+)
+
+// MergeRequestUpdate holds the changes to apply to a merge request. Unset
+// fields are left unchanged.
+type MergeRequestUpdate struct {
+	AddLabels    string `json:"add_labels,omitempty"`
+	RemoveLabels string `json:"remove_labels,omitempty"`
+	AssigneeIDs  []int  `json:"assignee_ids,omitempty"`
+	ReviewerIDs  []int  `json:"reviewer_ids,omitempty"`
+}
+
+// Diff is the diff of a single file of a merge request.
+type Diff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// Note is a comment on a merge request.
+type Note struct {
+	ID     int    `json:"id"`
+	Body   string `json:"body"`
+	Author User   `json:"author"`
+	// System is true for notes GitLab creates on changes of the merge
+	// request, e.g. "added 1 commit".
+	System       bool      `json:"system"`
+	NoteableType string    `json:"noteable_type"`
+	NoteableIID  int       `json:"noteable_iid"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// LabelEvent is an addition or removal of a label of a merge request.
+type LabelEvent struct {
+	ID        int       `json:"id"`
+	User      User      `json:"user"`
+	Label     Label     `json:"label"`
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Actions of label events.
+const (
+	LabelEventActionAdd    = "add"
+	LabelEventActionRemove = "remove"
+)
+
+// Label is a label of a project or group.
+type Label struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Name  string `json:"name"`
+}
+
+// Approvals are the approvals of a merge request.
+type Approvals struct {
+	Approved   bool `json:"approved"`
+	ApprovedBy []struct {
+		User User `json:"user"`
+	} `json:"approved_by"`
+}
+
+// Branch is a branch of a project.
+type Branch struct {
+	Name   string `json:"name"`
+	Commit Commit `json:"commit"`
+}
+
+// Commit is a commit of a project.
+type Commit struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
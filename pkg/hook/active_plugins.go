@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// ActivePlugins describes the plugins that handle the events of a repo.
+type ActivePlugins struct {
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	// Enabled is false when hook ignores events for the repo altogether.
+	Enabled         bool                     `json:"enabled"`
+	Plugins         []ActivePlugin           `json:"plugins"`
+	ExternalPlugins []plugins.ExternalPlugin `json:"external_plugins"`
+	// ConfigLoaded is when the plugin configuration in use was loaded.
+	ConfigLoaded time.Time `json:"config_loaded"`
+}
+
+// ActivePlugin is a plugin together with the events it handles.
+type ActivePlugin struct {
+	Name   string   `json:"name"`
+	Events []string `json:"events"`
+}
+
+// ServeActivePlugins reports the plugins that are active for the repo given
+// as the `repo` query parameter in org/repo form, as of the current plugin
+// configuration.
+func (s *Server) ServeActivePlugins(w http.ResponseWriter, r *http.Request) {
+	org, repo, ok := strings.Cut(r.URL.Query().Get("repo"), "/")
+	if !ok || org == "" || repo == "" {
+		http.Error(w, "the repo query parameter must be set in org/repo form", http.StatusBadRequest)
+		return
+	}
+
+	names, external, loaded := s.Plugins.ActivePlugins(org, repo)
+	active := ActivePlugins{
+		Org:             org,
+		Repo:            repo,
+		Enabled:         s.repoEnabled(org, repo),
+		Plugins:         []ActivePlugin{},
+		ExternalPlugins: external,
+		ConfigLoaded:    loaded,
+	}
+	if active.ExternalPlugins == nil {
+		active.ExternalPlugins = []plugins.ExternalPlugin{}
+	}
+	for _, name := range names {
+		active.Plugins = append(active.Plugins, ActivePlugin{Name: name, Events: plugins.EventsForPlugin(name)})
+	}
+
+	b, err := json.Marshal(active)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to marshal active plugins.")
+		http.Error(w, fmt.Sprintf("failed to marshal active plugins: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, string(b))
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const repositorySelectionAll = "all"
+
+// installation is what we know about a single GitHub App installation.
+type installation struct {
+	account   string
+	allRepos  bool
+	suspended bool
+	repos     sets.Set[string]
+}
+
+// Installations keeps track of the GitHub App installations that deliver
+// webhooks to hook and the repositories they cover. It is seeded from the
+// installations of the App and only updated from installation and
+// installation_repositories events, which lets hook route events without
+// any org or repo webhooks configured.
+type Installations struct {
+	lock          sync.RWMutex
+	installations map[int64]*installation
+	// client lists the repos of installations that are unsuspended, which
+	// are not listed while they are suspended. It is nil if the tracker was
+	// not loaded with LoadInstallations.
+	client installationsClient
+}
+
+// NewInstallations returns an Installations tracker seeded with the given
+// installations, typically the result of listing the App's installations.
+func NewInstallations(seed []github.AppInstallation) *Installations {
+	i := &Installations{installations: map[int64]*installation{}}
+	for _, ai := range seed {
+		i.upsert(ai).suspended = ai.SuspendedAt != ""
+	}
+	return i
+}
+
+// installationsClient lists the installations of the App and the repos
+// they cover.
+type installationsClient interface {
+	ListAppInstallations() ([]github.AppInstallation, error)
+	ListAppInstallationRepos(org string) ([]github.Repo, error)
+}
+
+// LoadInstallations returns an Installations tracker seeded with the
+// installations of the App and, for those that only cover selected repos,
+// the repos they cover.
+func LoadInstallations(gc installationsClient) (*Installations, error) {
+	ais, err := gc.ListAppInstallations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the installations of the app: %w", err)
+	}
+	i := NewInstallations(ais)
+	i.client = gc
+	for _, ai := range ais {
+		inst := i.installations[ai.ID]
+		if inst.allRepos || inst.suspended {
+			continue
+		}
+		repos, err := gc.ListAppInstallationRepos(ai.Account.Login)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the repos of installation %d: %w", ai.ID, err)
+		}
+		for _, repo := range repos {
+			inst.repos.Insert(strings.ToLower(repo.FullName))
+		}
+	}
+	return i, nil
+}
+
+// upsert records the installation, returning the tracked state.
+// Callers must hold the lock or be the constructor.
+func (i *Installations) upsert(ai github.AppInstallation) *installation {
+	inst, ok := i.installations[ai.ID]
+	if !ok {
+		inst = &installation{repos: sets.New[string]()}
+		i.installations[ai.ID] = inst
+	}
+	if ai.Account.Login != "" {
+		inst.account = ai.Account.Login
+	}
+	if ai.RepositorySelection != "" {
+		inst.allRepos = ai.RepositorySelection == repositorySelectionAll
+	}
+	return inst
+}
+
+// handleInstallationEvent updates the tracker for an installation event.
+// The repos of an unsuspended installation that covers selected repos are
+// listed, as they are not listed while it is suspended; the installation is
+// unsuspended even if they cannot be listed.
+func (i *Installations) handleInstallationEvent(ie github.InstallationEvent) error {
+	var repos []github.Repo
+	var listErr error
+	listed := false
+	if ie.Action == github.InstallationActionUnsuspend && i.client != nil {
+		if account, allRepos := i.selection(ie.Installation); !allRepos && account != "" {
+			repos, listErr = i.client.ListAppInstallationRepos(account)
+			if listErr != nil {
+				listErr = fmt.Errorf("failed to list the repos of installation %d: %w", ie.Installation.ID, listErr)
+			}
+			listed = listErr == nil
+		}
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if ie.Action == github.InstallationActionDeleted {
+		delete(i.installations, ie.Installation.ID)
+		return nil
+	}
+	inst := i.upsert(ie.Installation)
+	switch ie.Action {
+	case github.InstallationActionSuspend:
+		inst.suspended = true
+	case github.InstallationActionUnsuspend, github.InstallationActionCreated:
+		inst.suspended = false
+	}
+	if listed {
+		inst.repos = sets.New[string]()
+		for _, repo := range repos {
+			inst.repos.Insert(strings.ToLower(repo.FullName))
+		}
+	}
+	for _, repo := range ie.Repositories {
+		inst.repos.Insert(strings.ToLower(repo.FullName))
+	}
+	return listErr
+}
+
+// selection returns the account of the installation and whether it covers
+// all repos of the account, from the event or else from what is tracked.
+func (i *Installations) selection(ai github.AppInstallation) (account string, allRepos bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	if inst, ok := i.installations[ai.ID]; ok {
+		account, allRepos = inst.account, inst.allRepos
+	}
+	if ai.Account.Login != "" {
+		account = ai.Account.Login
+	}
+	if ai.RepositorySelection != "" {
+		allRepos = ai.RepositorySelection == repositorySelectionAll
+	}
+	return account, allRepos
+}
+
+// handleInstallationRepositoriesEvent updates the tracker for an
+// installation_repositories event.
+func (i *Installations) handleInstallationRepositoriesEvent(ire github.InstallationRepositoriesEvent) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	inst := i.upsert(ire.Installation)
+	if ire.RepositorySelection != "" {
+		inst.allRepos = ire.RepositorySelection == repositorySelectionAll
+	}
+	for _, repo := range ire.RepositoriesAdded {
+		inst.repos.Insert(strings.ToLower(repo.FullName))
+	}
+	for _, repo := range ire.RepositoriesRemoved {
+		inst.repos.Delete(strings.ToLower(repo.FullName))
+	}
+}
+
+// InstallationFor returns the ID of the active installation that covers
+// the repo, if any. An empty repo matches any installation for the org.
+func (i *Installations) InstallationFor(org, repo string) (int64, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	fullName := strings.ToLower(fmt.Sprintf("%s/%s", org, repo))
+	for id, inst := range i.installations {
+		if inst.suspended || !strings.EqualFold(inst.account, org) {
+			continue
+		}
+		if repo == "" || inst.allRepos || inst.repos.Has(fullName) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// RepoInstalled returns whether an active installation covers the repo.
+func (i *Installations) RepoInstalled(org, repo string) bool {
+	_, ok := i.InstallationFor(org, repo)
+	return ok
+}
+
+// Repos returns the sorted list of repos explicitly known to be covered by
+// the installation and whether the installation covers all repos of its
+// account.
+func (i *Installations) Repos(id int64) (repos []string, allRepos bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	inst, ok := i.installations[id]
+	if !ok {
+		return nil, false
+	}
+	return sets.List(inst.repos), inst.allRepos
+}
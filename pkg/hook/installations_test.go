@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+func TestInstallations(t *testing.T) {
+	seed := []github.AppInstallation{
+		{ID: 1, Account: github.User{Login: "all-org"}, RepositorySelection: "all"},
+		{ID: 2, Account: github.User{Login: "selected-org"}, RepositorySelection: "selected"},
+		{ID: 4, Account: github.User{Login: "suspended-org"}, RepositorySelection: "all", SuspendedAt: "2024-01-01T00:00:00Z"},
+	}
+
+	type check struct {
+		org, repo string
+		installed bool
+	}
+	testCases := []struct {
+		name   string
+		update func(*Installations)
+		checks []check
+	}{
+		{
+			name: "seeded installations",
+			checks: []check{
+				{org: "all-org", repo: "any", installed: true},
+				{org: "selected-org", repo: "unknown", installed: false},
+				{org: "selected-org", installed: true},
+				{org: "other-org", repo: "repo", installed: false},
+				{org: "suspended-org", repo: "repo", installed: false},
+			},
+		},
+		{
+			name: "repos added and removed",
+			update: func(i *Installations) {
+				i.handleInstallationRepositoriesEvent(github.InstallationRepositoriesEvent{
+					Action:              github.InstallationRepositoriesActionAdded,
+					Installation:        github.AppInstallation{ID: 2},
+					RepositorySelection: "selected",
+					RepositoriesAdded:   []github.InstallationRepository{{FullName: "selected-org/a"}, {FullName: "selected-org/B"}},
+				})
+				i.handleInstallationRepositoriesEvent(github.InstallationRepositoriesEvent{
+					Action:              github.InstallationRepositoriesActionRemoved,
+					Installation:        github.AppInstallation{ID: 2},
+					RepositoriesRemoved: []github.InstallationRepository{{FullName: "selected-org/a"}},
+				})
+			},
+			checks: []check{
+				{org: "selected-org", repo: "a", installed: false},
+				{org: "selected-org", repo: "b", installed: true},
+			},
+		},
+		{
+			name: "suspended and deleted installations",
+			update: func(i *Installations) {
+				i.handleInstallationEvent(github.InstallationEvent{
+					Action:       github.InstallationActionSuspend,
+					Installation: github.AppInstallation{ID: 1},
+				})
+				i.handleInstallationEvent(github.InstallationEvent{
+					Action:       github.InstallationActionDeleted,
+					Installation: github.AppInstallation{ID: 2},
+				})
+			},
+			checks: []check{
+				{org: "all-org", repo: "any", installed: false},
+				{org: "selected-org", installed: false},
+			},
+		},
+		{
+			name: "new installation",
+			update: func(i *Installations) {
+				i.handleInstallationEvent(github.InstallationEvent{
+					Action:       github.InstallationActionCreated,
+					Installation: github.AppInstallation{ID: 3, Account: github.User{Login: "new-org"}, RepositorySelection: "selected"},
+					Repositories: []github.InstallationRepository{{FullName: "new-org/repo"}},
+				})
+			},
+			checks: []check{
+				{org: "new-org", repo: "repo", installed: true},
+				{org: "new-org", repo: "other", installed: false},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			i := NewInstallations(seed)
+			if tc.update != nil {
+				tc.update(i)
+			}
+			for _, c := range tc.checks {
+				if installed := i.RepoInstalled(c.org, c.repo); installed != c.installed {
+					t.Errorf("%s/%s: expected installed %t, got %t", c.org, c.repo, c.installed, installed)
+				}
+			}
+		})
+	}
+}
+
+func TestInstallationsRepos(t *testing.T) {
+	i := NewInstallations([]github.AppInstallation{{ID: 2, Account: github.User{Login: "org"}, RepositorySelection: "selected"}})
+	i.handleInstallationRepositoriesEvent(github.InstallationRepositoriesEvent{
+		Action:            github.InstallationRepositoriesActionAdded,
+		Installation:      github.AppInstallation{ID: 2},
+		RepositoriesAdded: []github.InstallationRepository{{FullName: "org/b"}, {FullName: "org/a"}},
+	})
+	repos, all := i.Repos(2)
+	if all {
+		t.Error("expected installation to cover selected repos only")
+	}
+	if diff := cmp.Diff([]string{"org/a", "org/b"}, repos); diff != "" {
+		t.Errorf("unexpected repos (-want +got):\n%s", diff)
+	}
+}
+
+type fakeInstallationsClient struct {
+	installations []github.AppInstallation
+	repos         map[string][]github.Repo
+}
+
+func (c *fakeInstallationsClient) ListAppInstallations() ([]github.AppInstallation, error) {
+	return c.installations, nil
+}
+
+func (c *fakeInstallationsClient) ListAppInstallationRepos(org string) ([]github.Repo, error) {
+	repos, ok := c.repos[org]
+	if !ok {
+		return nil, fmt.Errorf("unexpected listing of the repos of %s", org)
+	}
+	return repos, nil
+}
+
+func TestLoadInstallations(t *testing.T) {
+	gc := &fakeInstallationsClient{
+		installations: []github.AppInstallation{
+			{ID: 1, Account: github.User{Login: "all-org"}, RepositorySelection: "all"},
+			{ID: 2, Account: github.User{Login: "selected-org"}, RepositorySelection: "selected"},
+			{ID: 3, Account: github.User{Login: "suspended-org"}, RepositorySelection: "selected", SuspendedAt: "2024-01-01T00:00:00Z"},
+		},
+		repos: map[string][]github.Repo{
+			"selected-org": {{FullName: "selected-org/Repo"}},
+		},
+	}
+	i, err := LoadInstallations(gc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, c := range []struct {
+		org, repo string
+		installed bool
+	}{
+		{org: "all-org", repo: "any", installed: true},
+		{org: "selected-org", repo: "repo", installed: true},
+		{org: "selected-org", repo: "other", installed: false},
+		{org: "suspended-org", repo: "repo", installed: false},
+	} {
+		if installed := i.RepoInstalled(c.org, c.repo); installed != c.installed {
+			t.Errorf("%s/%s: expected installed %t, got %t", c.org, c.repo, c.installed, installed)
+		}
+	}
+}
+
+func TestUnsuspendedInstallationRepos(t *testing.T) {
+	gc := &fakeInstallationsClient{
+		installations: []github.AppInstallation{
+			{ID: 1, Account: github.User{Login: "org"}, RepositorySelection: "selected", SuspendedAt: "2024-01-01T00:00:00Z"},
+			{ID: 2, Account: github.User{Login: "unlisted-org"}, RepositorySelection: "selected", SuspendedAt: "2024-01-01T00:00:00Z"},
+		},
+		repos: map[string][]github.Repo{},
+	}
+	i, err := LoadInstallations(gc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gc.repos["org"] = []github.Repo{{FullName: "org/Repo"}}
+	if err := i.handleInstallationEvent(github.InstallationEvent{
+		Action:       github.InstallationActionUnsuspend,
+		Installation: github.AppInstallation{ID: 1},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := i.handleInstallationEvent(github.InstallationEvent{
+		Action:       github.InstallationActionUnsuspend,
+		Installation: github.AppInstallation{ID: 2},
+	}); err == nil {
+		t.Error("expected an error for the installation whose repos cannot be listed")
+	}
+
+	for _, c := range []struct {
+		org, repo string
+		installed bool
+	}{
+		{org: "org", repo: "repo", installed: true},
+		{org: "org", repo: "other", installed: false},
+		{org: "unlisted-org", installed: true},
+		{org: "unlisted-org", repo: "repo", installed: false},
+	} {
+		if installed := i.RepoInstalled(c.org, c.repo); installed != c.installed {
+			t.Errorf("%s/%s: expected installed %t, got %t", c.org, c.repo, c.installed, installed)
+		}
+	}
+}
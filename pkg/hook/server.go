@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/githubeventserver"
 	_ "sigs.k8s.io/prow/pkg/hook/plugin-imports"
+	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/plugins"
 )
 
@@ -45,6 +46,9 @@ type Server struct {
 	TokenGenerator func() []byte
 	Metrics        *githubeventserver.Metrics
 	RepoEnabled    func(org, repo string) bool
+	// Installations tracks GitHub App installations. If set, events are only
+	// handled for repos covered by an active installation of the App.
+	Installations *Installations
 
 	// c is an http client used for dispatching events
 	// to external plugin services.
@@ -77,8 +81,9 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.Header) error {
 	l := logrus.WithFields(
 		logrus.Fields{
-			eventTypeField:   eventType,
-			github.EventGUID: eventGUID,
+			eventTypeField:          eventType,
+			github.EventGUID:        eventGUID,
+			logrusutil.TraceIDField: eventGUID,
 		},
 	)
 	// We don't want to fail the webhook due to a metrics error.
@@ -87,8 +92,42 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 	} else {
 		counter.Inc()
 	}
+	if s.Installations != nil {
+		var ge github.GenericEvent
+		if err := json.Unmarshal(payload, &ge); err != nil {
+			return err
+		}
+		// Deliveries only identify the installation. What it covers is only
+		// learned from installation events, so that a delivery cannot grant
+		// itself access to a repo.
+		if ge.Installation.ID != 0 {
+			l = l.WithField("installation", ge.Installation.ID)
+		}
+	}
 	var srcRepo string
 	switch eventType {
+	case "installation":
+		var ie github.InstallationEvent
+		if err := json.Unmarshal(payload, &ie); err != nil {
+			return err
+		}
+		ie.GUID = eventGUID
+		if s.Installations != nil {
+			if err := s.Installations.handleInstallationEvent(ie); err != nil {
+				l.WithError(err).Warn("Failed to update GitHub App installation.")
+			}
+			l.WithField("action", ie.Action).Info("Updated GitHub App installation.")
+		}
+	case "installation_repositories":
+		var ire github.InstallationRepositoriesEvent
+		if err := json.Unmarshal(payload, &ire); err != nil {
+			return err
+		}
+		ire.GUID = eventGUID
+		if s.Installations != nil {
+			s.Installations.handleInstallationRepositoriesEvent(ire)
+			l.WithField("action", ire.Action).Info("Updated GitHub App installation repositories.")
+		}
 	case "issues":
 		var i github.IssueEvent
 		if err := json.Unmarshal(payload, &i); err != nil {
@@ -96,7 +135,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		}
 		i.GUID = eventGUID
 		srcRepo = i.Repo.FullName
-		if s.RepoEnabled(i.Repo.Owner.Login, i.Repo.Name) {
+		if s.repoEnabled(i.Repo.Owner.Login, i.Repo.Name) {
 			s.wg.Add(1)
 			go s.handleIssueEvent(l, i)
 		}
@@ -107,7 +146,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		}
 		ic.GUID = eventGUID
 		srcRepo = ic.Repo.FullName
-		if s.RepoEnabled(ic.Repo.Owner.Login, ic.Repo.Name) {
+		if s.repoEnabled(ic.Repo.Owner.Login, ic.Repo.Name) {
 			s.wg.Add(1)
 			go s.handleIssueCommentEvent(l, ic)
 		}
@@ -118,7 +157,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		}
 		pr.GUID = eventGUID
 		srcRepo = pr.Repo.FullName
-		if s.RepoEnabled(pr.Repo.Owner.Login, pr.Repo.Name) {
+		if s.repoEnabled(pr.Repo.Owner.Login, pr.Repo.Name) {
 			s.wg.Add(1)
 			go s.handlePullRequestEvent(l, pr)
 		}
@@ -129,7 +168,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		}
 		re.GUID = eventGUID
 		srcRepo = re.Repo.FullName
-		if s.RepoEnabled(re.Repo.Owner.Login, re.Repo.Name) {
+		if s.repoEnabled(re.Repo.Owner.Login, re.Repo.Name) {
 			s.wg.Add(1)
 			go s.handleReviewEvent(l, re)
 		}
@@ -140,7 +179,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		}
 		rce.GUID = eventGUID
 		srcRepo = rce.Repo.FullName
-		if s.RepoEnabled(rce.Repo.Owner.Login, rce.Repo.Name) {
+		if s.repoEnabled(rce.Repo.Owner.Login, rce.Repo.Name) {
 			s.wg.Add(1)
 			go s.handleReviewCommentEvent(l, rce)
 		}
@@ -151,7 +190,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		}
 		pe.GUID = eventGUID
 		srcRepo = pe.Repo.FullName
-		if s.RepoEnabled(pe.Repo.Owner.Login, pe.Repo.Name) {
+		if s.repoEnabled(pe.Repo.Owner.Login, pe.Repo.Name) {
 			s.wg.Add(1)
 			go s.handlePushEvent(l, pe)
 		}
@@ -162,7 +201,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		}
 		se.GUID = eventGUID
 		srcRepo = se.Repo.FullName
-		if s.RepoEnabled(se.Repo.Owner.Login, se.Repo.Name) {
+		if s.repoEnabled(se.Repo.Owner.Login, se.Repo.Name) {
 			s.wg.Add(1)
 			go s.handleStatusEvent(l, se)
 		}
@@ -182,6 +221,14 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 	return nil
 }
 
+// repoEnabled returns whether events for the repo should be handled.
+func (s *Server) repoEnabled(org, repo string) bool {
+	if !s.RepoEnabled(org, repo) {
+		return false
+	}
+	return s.Installations == nil || s.Installations.RepoInstalled(org, repo)
+}
+
 // needDemux returns whether there are any external plugins that need to
 // get the present event.
 func (s *Server) needDemux(eventType, orgRepo string) []plugins.ExternalPlugin {
@@ -192,28 +239,21 @@ func (s *Server) needDemux(eventType, orgRepo string) []plugins.ExternalPlugin {
 	if len(split) > 1 {
 		srcRepo = split[1]
 	}
-	if !s.RepoEnabled(srcOrg, srcRepo) {
+	if !s.repoEnabled(srcOrg, srcRepo) {
 		return nil
 	}
 
-	for repo, plugins := range s.Plugins.Config().ExternalPlugins {
-		// Make sure the repositories match
-		if repo != orgRepo && repo != srcOrg {
-			continue
-		}
-
+	for _, p := range s.Plugins.Config().EnabledExternalPlugins(srcOrg, srcRepo) {
 		// Make sure the events match
-		for _, p := range plugins {
-			if len(p.Events) == 0 {
-				matching = append(matching, p)
-			} else {
-				for _, et := range p.Events {
-					if et != eventType {
-						continue
-					}
-					matching = append(matching, p)
-					break
+		if len(p.Events) == 0 {
+			matching = append(matching, p)
+		} else {
+			for _, et := range p.Events {
+				if et != eventType {
+					continue
 				}
+				matching = append(matching, p)
+				break
 			}
 		}
 	}
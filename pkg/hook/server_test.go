@@ -18,6 +18,7 @@ package hook
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -29,6 +30,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 
+	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/githubeventserver"
 	"sigs.k8s.io/prow/pkg/plugins"
 )
@@ -578,3 +580,150 @@ foo/bar:
 		})
 	}
 }
+
+func TestServeActivePlugins(t *testing.T) {
+	pa := &plugins.ConfigAgent{}
+	pa.Set(&plugins.Configuration{
+		Plugins: plugins.Plugins{"org": {Plugins: []string{"unregistered"}}},
+		ExternalPlugins: map[string][]plugins.ExternalPlugin{
+			"org/repo": {{Name: "external", Endpoint: "http://external", Events: []string{"push"}}},
+		},
+	})
+	s := &Server{
+		Plugins:     pa,
+		RepoEnabled: func(org, repo string) bool { return repo != "disabled" },
+	}
+
+	var testcases = []struct {
+		name string
+
+		query string
+
+		expectedStatus int
+		expected       *ActivePlugins
+	}{
+		{
+			name:           "missing repo",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "repo without org",
+			query:          "?repo=repo",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "plugins and external plugins of the repo",
+			query:          "?repo=org/repo",
+			expectedStatus: http.StatusOK,
+			expected: &ActivePlugins{
+				Org:             "org",
+				Repo:            "repo",
+				Enabled:         true,
+				Plugins:         []ActivePlugin{{Name: "unregistered"}},
+				ExternalPlugins: []plugins.ExternalPlugin{{Name: "external", Endpoint: "http://external", Events: []string{"push"}}},
+			},
+		},
+		{
+			name:           "repo with events disabled",
+			query:          "?repo=org/disabled",
+			expectedStatus: http.StatusOK,
+			expected: &ActivePlugins{
+				Org:             "org",
+				Repo:            "disabled",
+				Plugins:         []ActivePlugin{{Name: "unregistered"}},
+				ExternalPlugins: []plugins.ExternalPlugin{},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeActivePlugins(w, httptest.NewRequest(http.MethodGet, "/active-plugins"+tc.query, nil))
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedStatus, w.Code, w.Body.String())
+			}
+			if tc.expected == nil {
+				return
+			}
+			var actual ActivePlugins
+			if err := json.Unmarshal(w.Body.Bytes(), &actual); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, &actual, cmpopts.IgnoreFields(ActivePlugins{}, "ConfigLoaded")); diff != "" {
+				t.Errorf("active plugins differ from expected: %s", diff)
+			}
+			if !actual.ConfigLoaded.Equal(pa.Loaded()) {
+				t.Errorf("expected config_loaded %v, got %v", pa.Loaded(), actual.ConfigLoaded)
+			}
+		})
+	}
+}
+
+func TestDemuxEventInstallationGate(t *testing.T) {
+	const repositoryEvent = `{
+  "action": "edited",
+  "installation": {"id": 2},
+  "repository": {
+    "name": "repo",
+    "full_name": "org/repo",
+    "owner": {"login": "org"}
+  }
+}`
+	const installationRepositoriesEvent = `{
+  "action": "added",
+  "installation": {"id": 2, "account": {"login": "org"}},
+  "repository_selection": "selected",
+  "repositories_added": [{"full_name": "org/repo"}]
+}`
+
+	pa := &plugins.ConfigAgent{}
+	pa.Set(&plugins.Configuration{
+		ExternalPlugins: map[string][]plugins.ExternalPlugin{
+			"org": {{Name: "coffee", Endpoint: "/coffee"}},
+		},
+	})
+	var calledExternalPlugins []string
+	var m sync.Mutex
+	client := newTestClient(func(req *http.Request) *http.Response {
+		m.Lock()
+		calledExternalPlugins = append(calledExternalPlugins, req.URL.String())
+		m.Unlock()
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(`OK`)),
+			Header:     make(http.Header),
+		}
+	})
+	s := &Server{
+		Metrics:       githubeventserver.NewMetrics(),
+		Plugins:       pa,
+		RepoEnabled:   func(org, repo string) bool { return true },
+		Installations: NewInstallations([]github.AppInstallation{{ID: 2, Account: github.User{Login: "org"}, RepositorySelection: "selected"}}),
+		c:             *client,
+	}
+
+	// A delivery for a repo the installation is not known to cover must
+	// neither be handled nor extend the coverage of the installation.
+	if err := s.demuxEvent("repository", "guid", []byte(repositoryEvent), http.Header{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.wg.Wait()
+	if len(calledExternalPlugins) != 0 {
+		t.Errorf("expected no dispatch for an uncovered repo, got %v", calledExternalPlugins)
+	}
+	if s.Installations.RepoInstalled("org", "repo") {
+		t.Error("expected a delivery not to mark the repo as installed")
+	}
+
+	if err := s.demuxEvent("installation_repositories", "guid", []byte(installationRepositoriesEvent), http.Header{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.demuxEvent("repository", "guid", []byte(repositoryEvent), http.Header{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.wg.Wait()
+	if diff := cmp.Diff([]string{"/coffee"}, calledExternalPlugins); diff != "" {
+		t.Errorf("unexpected dispatch once the repo was added (-want +got):\n%s", diff)
+	}
+}
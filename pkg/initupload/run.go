@@ -51,6 +51,9 @@ func (o Options) Run() error {
 	}
 
 	started := downwardapi.SpecToStarted(spec, cloneRecords)
+	if versions := downwardapi.ProwVersions(spec, os.Getenv(downwardapi.ProwVersionEnv)); len(versions) > 0 {
+		started.Metadata = metadata.Metadata{downwardapi.ProwVersionsKey: versions}
+	}
 
 	startedData, err := json.Marshal(&started)
 	if err != nil {
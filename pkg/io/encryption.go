@@ -0,0 +1,475 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package io
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+
+	"gocloud.dev/secrets"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	// Register the key management services that can wrap data keys.
+	_ "gocloud.dev/secrets/awskms"
+	_ "gocloud.dev/secrets/gcpkms"
+	_ "gocloud.dev/secrets/localsecrets"
+)
+
+// Encrypted objects are stored as an envelope: the envelopeMagic, the length
+// of the header as a big endian uint32, the JSON envelopeHeader and the
+// content, sealed with AES-256-GCM in chunks of envelopeChunkSize bytes. Each
+// object has its own random data key, which is stored in the header wrapped
+// by a key management service. The nonce of a chunk is its index followed by
+// a flag marking the last chunk, so chunks can neither be reordered nor
+// truncated, and the header is authenticated as additional data of every
+// chunk.
+const (
+	envelopeMagic     = "PROWENC1"
+	envelopeChunkSize = 64 * 1024
+	// envelopeMaxHeaderSize bounds the header, which is read before it can be
+	// authenticated.
+	envelopeMaxHeaderSize = 64 * 1024
+	dataKeySize           = 32
+)
+
+type envelopeHeader struct {
+	// KeyURL is the URL of the key that wrapped the data key, e.g.
+	// gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k.
+	KeyURL string `json:"key_url"`
+	// WrappedKey is the data key, encrypted with the key at KeyURL.
+	WrappedKey []byte `json:"wrapped_key"`
+	// ChunkSize is the size of the plaintext chunks.
+	ChunkSize int `json:"chunk_size"`
+}
+
+// MatchesEncryptionPatterns returns whether an object at the given path must
+// be encrypted, given the patterns of the encryption config. The patterns are
+// matched against the file name, e.g. "*.kubeconfig" or "audit*.log".
+func MatchesEncryptionPatterns(patterns []string, objectPath string) bool {
+	name := path.Base(objectPath)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// NewEncryptingOpener returns an opener that encrypts the objects matching
+// the patterns with data keys wrapped by the key at keyURL, and writes all
+// the other objects unchanged. The key URL takes any scheme of
+// gocloud.dev/secrets, e.g. gcpkms://, awskms:// or base64key://.
+func NewEncryptingOpener(ctx context.Context, o Opener, keyURL string, patterns []string) (Opener, error) {
+	keeper, err := secrets.OpenKeeper(ctx, keyURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key %q: %w", keyURL, err)
+	}
+	return &encryptingOpener{Opener: o, keyURL: keyURL, keeper: keeper, patterns: patterns}, nil
+}
+
+type encryptingOpener struct {
+	Opener
+	keyURL   string
+	keeper   *secrets.Keeper
+	patterns []string
+}
+
+func (o *encryptingOpener) Writer(ctx context.Context, p string, opts ...WriterOptions) (WriteCloser, error) {
+	if !MatchesEncryptionPatterns(o.patterns, p) {
+		return o.Opener.Writer(ctx, p, opts...)
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrappedKey, err := o.keeper.Encrypt(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key with %q: %w", o.keyURL, err)
+	}
+	header, err := json.Marshal(envelopeHeader{KeyURL: o.keyURL, WrappedKey: wrappedKey, ChunkSize: envelopeChunkSize})
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := o.Opener.Writer(ctx, p, opts...)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, len(envelopeMagic)+4, len(envelopeMagic)+4+len(header))
+	copy(prefix, envelopeMagic)
+	binary.BigEndian.PutUint32(prefix[len(envelopeMagic):], uint32(len(header)))
+	if _, err := w.Write(append(prefix, header...)); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return &encryptingWriter{w: w, aead: aead, header: header}, nil
+}
+
+type encryptingWriter struct {
+	w       WriteCloser
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	counter uint32
+}
+
+func (w *encryptingWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	// Keep at least one byte buffered unless the writer is closed, so the
+	// last chunk is never empty unless the content is.
+	for len(w.buf) > envelopeChunkSize {
+		if err := w.seal(w.buf[:envelopeChunkSize], false); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[envelopeChunkSize:]
+	}
+	return len(p), nil
+}
+
+func (w *encryptingWriter) seal(chunk []byte, last bool) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.counter, last), chunk, w.header)
+	w.counter++
+	_, err := w.w.Write(sealed)
+	return err
+}
+
+func (w *encryptingWriter) Close() error {
+	if err := w.seal(w.buf, true); err != nil {
+		w.w.Close()
+		return err
+	}
+	w.buf = nil
+	return w.w.Close()
+}
+
+// decryptionKey is the context key under which WithDecryption stores its
+// value.
+type decryptionKey struct{}
+
+// WithDecryption returns a context in which the openers created by
+// NewDecryptingOpener decrypt encrypted objects. It must only be used on
+// behalf of viewers authorized to see the content of the objects.
+func WithDecryption(ctx context.Context) context.Context {
+	return context.WithValue(ctx, decryptionKey{}, true)
+}
+
+// DecryptionAllowed returns whether ctx was returned by WithDecryption.
+func DecryptionAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(decryptionKey{}).(bool)
+	return allowed
+}
+
+// IsEncrypted returns whether the object at the given path was encrypted by
+// NewEncryptingOpener, reading its first bytes as they are stored.
+func IsEncrypted(ctx context.Context, o Opener, p string) (bool, error) {
+	r, err := o.Reader(context.WithValue(ctx, decryptionKey{}, false), p)
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+	magic := make([]byte, len(envelopeMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return string(magic) == envelopeMagic, nil
+}
+
+// NewDecryptingOpener returns an opener that transparently decrypts the
+// objects encrypted by NewEncryptingOpener, as long as the context of the
+// call was returned by WithDecryption and the key that wrapped the data key
+// of the object is one of the keyURLs. In any other case, objects are read
+// as they are stored.
+func NewDecryptingOpener(o Opener, keyURLs func() []string) Opener {
+	return &decryptingOpener{Opener: o, keyURLs: keyURLs, keepers: map[string]*secrets.Keeper{}}
+}
+
+type decryptingOpener struct {
+	Opener
+	keyURLs func() []string
+
+	lock    sync.Mutex
+	keepers map[string]*secrets.Keeper
+}
+
+func (o *decryptingOpener) keeper(ctx context.Context, keyURL string) (*secrets.Keeper, error) {
+	if !sets.New[string](o.keyURLs()...).Has(keyURL) {
+		return nil, fmt.Errorf("object is encrypted with key %q, which is not allowed for decryption", keyURL)
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if keeper, ok := o.keepers[keyURL]; ok {
+		return keeper, nil
+	}
+	keeper, err := secrets.OpenKeeper(ctx, keyURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key %q: %w", keyURL, err)
+	}
+	o.keepers[keyURL] = keeper
+	return keeper, nil
+}
+
+// envelope is the parsed header of an encrypted object.
+type envelope struct {
+	header []byte
+	aead   cipher.AEAD
+	// offset is where the encrypted chunks start.
+	offset    int64
+	chunkSize int64
+}
+
+// readEnvelope reads the header of an encrypted object from r. It returns a
+// nil envelope if the object is not encrypted, in which case the bytes that
+// were read are still available from r.
+func (o *decryptingOpener) readEnvelope(ctx context.Context, r *bufio.Reader) (*envelope, error) {
+	magic, err := r.Peek(len(envelopeMagic) + 4)
+	if err != nil || string(magic[:len(envelopeMagic)]) != envelopeMagic {
+		return nil, nil
+	}
+	size := binary.BigEndian.Uint32(magic[len(envelopeMagic):])
+	if size > envelopeMaxHeaderSize {
+		return nil, fmt.Errorf("encryption header of %d bytes is too large", size)
+	}
+	if _, err := r.Discard(len(magic)); err != nil {
+		return nil, err
+	}
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, fmt.Errorf("failed to read encryption header: %w", err)
+	}
+	var header envelopeHeader
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse encryption header: %w", err)
+	}
+	// The header is only authenticated once the first chunk is opened, so a
+	// chunk size other than the one we write must not size any allocation.
+	if header.ChunkSize != envelopeChunkSize {
+		return nil, fmt.Errorf("invalid chunk size %d in encryption header", header.ChunkSize)
+	}
+	keeper, err := o.keeper(ctx, header.KeyURL)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := keeper.Decrypt(ctx, header.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key with %q: %w", header.KeyURL, err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &envelope{header: raw, aead: aead, offset: int64(len(magic)) + int64(size), chunkSize: int64(header.ChunkSize)}, nil
+}
+
+// readPathEnvelope reads the header of the object at the given path.
+func (o *decryptingOpener) readPathEnvelope(ctx context.Context, p string) (*envelope, error) {
+	r, err := o.Opener.Reader(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return o.readEnvelope(ctx, bufio.NewReader(r))
+}
+
+// sealedChunkSize is the size of an encrypted chunk.
+func (e *envelope) sealedChunkSize() int64 {
+	return e.chunkSize + int64(e.aead.Overhead())
+}
+
+// chunks returns the number of chunks of an encrypted object of the given
+// size. Only the last chunk may be shorter than the chunk size.
+func (e *envelope) chunks(size int64) int64 {
+	sealed := e.sealedChunkSize()
+	return (size - e.offset + sealed - 1) / sealed
+}
+
+// plaintextSize returns the size of the content of an encrypted object of
+// the given size.
+func (e *envelope) plaintextSize(size int64) int64 {
+	return size - e.offset - e.chunks(size)*int64(e.aead.Overhead())
+}
+
+func (o *decryptingOpener) Reader(ctx context.Context, p string) (ReadCloser, error) {
+	r, err := o.Opener.Reader(ctx, p)
+	if err != nil || !DecryptionAllowed(ctx) {
+		return r, err
+	}
+	br := bufio.NewReader(r)
+	e, err := o.readEnvelope(ctx, br)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	if e == nil {
+		return &bufferedReadCloser{Reader: br, Closer: r}, nil
+	}
+	return &decryptingReader{r: br, closer: r, envelope: e, last: -1}, nil
+}
+
+func (o *decryptingOpener) RangeReader(ctx context.Context, p string, offset, length int64) (io.ReadCloser, error) {
+	if !DecryptionAllowed(ctx) {
+		return o.Opener.RangeReader(ctx, p, offset, length)
+	}
+	e, err := o.readPathEnvelope(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return o.Opener.RangeReader(ctx, p, offset, length)
+	}
+	attrs, err := o.Opener.Attributes(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the chunks that hold the range, and skip the start of the first.
+	first := offset / e.chunkSize
+	sealedOffset := e.offset + first*e.sealedChunkSize()
+	sealedLength := int64(-1)
+	if length >= 0 {
+		last := (offset + length + e.chunkSize - 1) / e.chunkSize
+		sealedLength = (last - first) * e.sealedChunkSize()
+	}
+	r, err := o.Opener.RangeReader(ctx, p, sealedOffset, sealedLength)
+	if err != nil {
+		return nil, err
+	}
+	dr := &decryptingReader{r: bufio.NewReader(r), closer: r, envelope: e, counter: uint32(first), last: e.chunks(attrs.Size) - 1}
+	if _, err := io.CopyN(io.Discard, dr, offset-first*e.chunkSize); err != nil && err != io.EOF {
+		r.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return dr, nil
+	}
+	return &bufferedReadCloser{Reader: io.LimitReader(dr, length), Closer: dr}, nil
+}
+
+func (o *decryptingOpener) Attributes(ctx context.Context, p string) (Attributes, error) {
+	attrs, err := o.Opener.Attributes(ctx, p)
+	if err != nil || !DecryptionAllowed(ctx) {
+		return attrs, err
+	}
+	e, err := o.readPathEnvelope(ctx, p)
+	if err != nil {
+		return Attributes{}, err
+	}
+	if e != nil {
+		attrs.Size = e.plaintextSize(attrs.Size)
+	}
+	return attrs, nil
+}
+
+type bufferedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// decryptingReader decrypts the chunks of an encrypted object.
+type decryptingReader struct {
+	r      *bufio.Reader
+	closer io.Closer
+	*envelope
+	counter uint32
+	// last is the index of the last chunk, or -1 if the chunks are read up
+	// to the end of the object and the last one is the one before it.
+	last  int64
+	chunk []byte
+	done  bool
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+func (r *decryptingReader) next() error {
+	sealed := make([]byte, r.sealedChunkSize())
+	n, err := io.ReadFull(r.r, sealed)
+	switch {
+	case err == io.EOF && r.last >= 0:
+		// The range ends at a chunk boundary.
+		r.done = true
+		return nil
+	case err == io.EOF:
+		return errors.New("encrypted object is truncated")
+	case err != nil && err != io.ErrUnexpectedEOF:
+		return err
+	}
+	last := n < len(sealed)
+	if r.last >= 0 {
+		last = int64(r.counter) == r.last
+	} else if !last {
+		_, err := r.r.Peek(1)
+		last = err == io.EOF
+	}
+	chunk, err := r.aead.Open(nil, chunkNonce(r.counter, last), sealed[:n], r.header)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chunk %d: %w", r.counter, err)
+	}
+	r.counter++
+	r.chunk = chunk
+	r.done = last
+	return nil
+}
+
+func (r *decryptingReader) Close() error {
+	return r.closer.Close()
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce of the chunk with the given index.
+func chunkNonce(counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint32(nonce[7:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
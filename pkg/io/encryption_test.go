@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package io
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// memoryOpener stores objects in memory.
+type memoryOpener struct {
+	Opener
+	objects map[string][]byte
+}
+
+type memoryWriter struct {
+	bytes.Buffer
+	objects map[string][]byte
+	path    string
+}
+
+func (w *memoryWriter) Close() error {
+	w.objects[w.path] = w.Bytes()
+	return nil
+}
+
+func (o *memoryOpener) Writer(_ context.Context, path string, _ ...WriterOptions) (WriteCloser, error) {
+	return &memoryWriter{objects: o.objects, path: path}, nil
+}
+
+func (o *memoryOpener) Reader(ctx context.Context, path string) (ReadCloser, error) {
+	return o.RangeReader(ctx, path, 0, -1)
+}
+
+func (o *memoryOpener) RangeReader(_ context.Context, path string, offset, length int64) (io.ReadCloser, error) {
+	data, ok := o.objects[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	data = data[offset:]
+	if length >= 0 && length < int64(len(data)) {
+		data = data[:length]
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (o *memoryOpener) Attributes(_ context.Context, path string) (Attributes, error) {
+	data, ok := o.objects[path]
+	if !ok {
+		return Attributes{}, os.ErrNotExist
+	}
+	return Attributes{Size: int64(len(data))}, nil
+}
+
+var testKeyURL = "base64key://" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("k"), 32))
+
+func TestMatchesEncryptionPatterns(t *testing.T) {
+	patterns := []string{"*.kubeconfig", "audit*.log"}
+	for path, expected := range map[string]bool{
+		"gs://bucket/logs/job/1/artifacts/admin.kubeconfig": true,
+		"gs://bucket/logs/job/1/artifacts/audit-1.log":      true,
+		"gs://bucket/logs/job/1/artifacts/kubeconfig":       false,
+		"gs://bucket/logs/job/1/build-log.txt":              false,
+		"gs://bucket/logs/job/1/audit/build.log":            false,
+	} {
+		if actual := MatchesEncryptionPatterns(patterns, path); actual != expected {
+			t.Errorf("%s: expected %t, got %t", path, expected, actual)
+		}
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.WithField("test", t.Name())
+	opener := &memoryOpener{objects: map[string][]byte{}}
+	encrypting, err := NewEncryptingOpener(ctx, opener, testKeyURL, []string{"*.kubeconfig"})
+	if err != nil {
+		t.Fatalf("failed to create encrypting opener: %v", err)
+	}
+	allowedKeys := []string{testKeyURL}
+	decrypting := NewDecryptingOpener(opener, func() []string { return allowedKeys })
+
+	content := func(size int) []byte {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i % 251)
+		}
+		return data
+	}
+	for _, size := range []int{0, 10, envelopeChunkSize, 2*envelopeChunkSize + 5} {
+		t.Run(fmt.Sprintf("%d bytes", size), func(t *testing.T) {
+			expected := content(size)
+			path := fmt.Sprintf("gs://bucket/%d.kubeconfig", size)
+			if err := WriteContent(ctx, log, encrypting, path, expected); err != nil {
+				t.Fatalf("failed to write: %v", err)
+			}
+
+			stored, err := ReadContent(ctx, log, decrypting, path)
+			if err != nil {
+				t.Fatalf("failed to read without decryption: %v", err)
+			}
+			if !bytes.HasPrefix(stored, []byte(envelopeMagic)) || (size > 0 && bytes.Contains(stored, expected)) {
+				t.Fatal("expected the stored object to be encrypted")
+			}
+
+			ctx := WithDecryption(ctx)
+			if encrypted, err := IsEncrypted(ctx, decrypting, path); err != nil || !encrypted {
+				t.Errorf("expected IsEncrypted to be true, got %t (err: %v)", encrypted, err)
+			}
+			actual, err := ReadContent(ctx, log, decrypting, path)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if !bytes.Equal(expected, actual) {
+				t.Errorf("expected %d decrypted bytes, got %d different ones", len(expected), len(actual))
+			}
+			attrs, err := decrypting.Attributes(ctx, path)
+			if err != nil {
+				t.Fatalf("failed to get attributes: %v", err)
+			}
+			if attrs.Size != int64(size) {
+				t.Errorf("expected size %d, got %d", size, attrs.Size)
+			}
+
+			for _, r := range [][2]int64{{0, -1}, {0, 5}, {3, 4}, {envelopeChunkSize - 2, 4}, {envelopeChunkSize, -1}, {envelopeChunkSize + 1, envelopeChunkSize}, {int64(size) - 3, 3}} {
+				offset, length := r[0], r[1]
+				if offset < 0 || offset > int64(size) || length > int64(size)-offset {
+					continue
+				}
+				reader, err := decrypting.RangeReader(ctx, path, offset, length)
+				if err != nil {
+					t.Fatalf("failed to read range %v: %v", r, err)
+				}
+				actual, err := io.ReadAll(reader)
+				reader.Close()
+				if err != nil {
+					t.Fatalf("failed to read range %v: %v", r, err)
+				}
+				end := int64(size)
+				if length >= 0 {
+					end = offset + length
+				}
+				if !bytes.Equal(expected[offset:end], actual) {
+					t.Errorf("range %v: expected %d decrypted bytes, got %d different ones", r, end-offset, len(actual))
+				}
+			}
+		})
+	}
+
+	t.Run("objects not matching the patterns are not encrypted", func(t *testing.T) {
+		path := "gs://bucket/build-log.txt"
+		if err := WriteContent(ctx, log, encrypting, path, []byte("plain")); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		if stored := opener.objects[path]; string(stored) != "plain" {
+			t.Errorf("expected the object to be stored as is, got %q", stored)
+		}
+		if encrypted, err := IsEncrypted(ctx, decrypting, path); err != nil || encrypted {
+			t.Errorf("expected IsEncrypted to be false, got %t (err: %v)", encrypted, err)
+		}
+		actual, err := ReadContent(WithDecryption(ctx), log, decrypting, path)
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		if string(actual) != "plain" {
+			t.Errorf("expected %q, got %q", "plain", actual)
+		}
+	})
+
+	t.Run("tampered and truncated objects are rejected", func(t *testing.T) {
+		path := "gs://bucket/tampered.kubeconfig"
+		if err := WriteContent(ctx, log, encrypting, path, content(2*envelopeChunkSize)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		stored := opener.objects[path]
+		tampered := append([]byte{}, stored...)
+		tampered[len(tampered)-1] ^= 1
+		truncated := stored[:len(stored)-(envelopeChunkSize+16)]
+		for name, data := range map[string][]byte{"tampered": tampered, "truncated": truncated} {
+			opener.objects[path] = data
+			if _, err := ReadContent(WithDecryption(ctx), log, decrypting, path); err == nil {
+				t.Errorf("expected an error reading the %s object", name)
+			}
+		}
+	})
+
+	t.Run("headers with a tampered chunk size are rejected", func(t *testing.T) {
+		path := "gs://bucket/chunk-size.kubeconfig"
+		if err := WriteContent(ctx, log, encrypting, path, []byte("secret")); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		stored := opener.objects[path]
+		size := binary.BigEndian.Uint32(stored[len(envelopeMagic):])
+		headerStart := len(envelopeMagic) + 4
+		var header envelopeHeader
+		if err := json.Unmarshal(stored[headerStart:headerStart+int(size)], &header); err != nil {
+			t.Fatalf("failed to parse header: %v", err)
+		}
+		for _, chunkSize := range []int{1, 1 << 40, math.MaxInt64} {
+			header.ChunkSize = chunkSize
+			raw, err := json.Marshal(header)
+			if err != nil {
+				t.Fatalf("failed to marshal header: %v", err)
+			}
+			data := append([]byte(envelopeMagic), binary.BigEndian.AppendUint32(nil, uint32(len(raw)))...)
+			data = append(append(data, raw...), stored[headerStart+int(size):]...)
+			opener.objects[path] = data
+			if _, err := ReadContent(WithDecryption(ctx), log, decrypting, path); err == nil {
+				t.Errorf("expected an error reading an object with a chunk size of %d", chunkSize)
+			}
+		}
+	})
+
+	t.Run("keys that are not allowed are not used", func(t *testing.T) {
+		path := "gs://bucket/other.kubeconfig"
+		if err := WriteContent(ctx, log, encrypting, path, []byte("secret")); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		allowedKeys = []string{"base64key://other"}
+		defer func() { allowedKeys = []string{testKeyURL} }()
+		if _, err := ReadContent(WithDecryption(ctx), log, decrypting, path); err == nil {
+			t.Error("expected an error reading an object encrypted with a key that is not allowed")
+		}
+	})
+}
@@ -26,6 +26,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -77,6 +78,9 @@ type Attributes struct {
 	Size int64
 	// Metadata includes user-metadata associated with the file
 	Metadata map[string]string
+	// Generation identifies the version of the blob's content, if the
+	// provider reports one. It changes whenever the content is replaced.
+	Generation string
 }
 
 type ObjectAttrsToUpdate struct {
@@ -343,8 +347,16 @@ func (o *opener) Attributes(ctx context.Context, path string) (Attributes, error
 			ContentLanguage:    attr.ContentLanguage,
 			Size:               attr.Size,
 			Metadata:           attr.Metadata,
+			Generation:         strconv.FormatInt(attr.Generation, 10),
 		}, nil
 	}
+	if strings.HasPrefix(path, "/") || strings.HasPrefix(path, providers.File+"://") {
+		info, err := os.Stat(strings.TrimPrefix(path, providers.File+"://"))
+		if err != nil {
+			return Attributes{}, err
+		}
+		return Attributes{Size: info.Size(), Generation: blobGeneration(info.ModTime())}, nil
+	}
 
 	bucket, relativePath, err := o.getBucket(ctx, path)
 	if err != nil {
@@ -362,9 +374,19 @@ func (o *opener) Attributes(ctx context.Context, path string) (Attributes, error
 		ContentLanguage:    attr.ContentLanguage,
 		Size:               attr.Size,
 		Metadata:           attr.Metadata,
+		Generation:         blobGeneration(attr.ModTime),
 	}, nil
 }
 
+// blobGeneration derives a generation from the modification time of blobs of
+// providers that do not version their blobs.
+func blobGeneration(modTime time.Time) string {
+	if modTime.IsZero() {
+		return ""
+	}
+	return modTime.UTC().Format(time.RFC3339Nano)
+}
+
 func (o *opener) UpdateAttributes(ctx context.Context, path string, attrs ObjectAttrsToUpdate) (*Attributes, error) {
 	if !strings.HasPrefix(path, providers.GS+"://") {
 		return nil, fmt.Errorf("unsupported provider: %q", path)
@@ -401,10 +423,14 @@ func (o *opener) SignedURL(ctx context.Context, p string, opts SignedURLOptions)
 	if err != nil {
 		return "", fmt.Errorf("could not get bucket: %w", err)
 	}
+	expiry := opts.Expiry
+	if expiry <= 0 {
+		expiry = DefaultSignedURLExpiry
+	}
 	if strings.HasPrefix(p, providers.GS+"://") {
 		// We specifically want to use cookie auth, see:
 		// https://cloud.google.com/storage/docs/access-control/cookie-based-authentication
-		if opts.UseGSCookieAuth {
+		if opts.UseGSCookieAuth && !opts.RequireSignature {
 			artifactLink := &url.URL{
 				Scheme: httpsScheme,
 				Host:   GSCookieHost,
@@ -415,6 +441,9 @@ func (o *opener) SignedURL(ctx context.Context, p string, opts SignedURLOptions)
 
 		// If we're anonymous we can just return a plain URL.
 		if o.gcsCredentialsFile == "" {
+			if opts.RequireSignature {
+				return "", errors.New("no GCS credentials to sign URLs with")
+			}
 			artifactLink := &url.URL{
 				Scheme: httpsScheme,
 				Host:   GSAnonHost,
@@ -442,12 +471,19 @@ func (o *opener) SignedURL(ctx context.Context, p string, opts SignedURLOptions)
 		if auth.Type != "service_account" {
 			return "", fmt.Errorf("only service_account GCS auth is supported, got %q", auth.Type)
 		}
-		return storage.SignedURL(bucketName, relativePath, &storage.SignedURLOptions{
+		signOpts := &storage.SignedURLOptions{
 			Method:         "GET",
-			Expires:        time.Now().Add(10 * time.Minute),
+			Expires:        time.Now().Add(expiry),
 			GoogleAccessID: auth.ClientEmail,
 			PrivateKey:     []byte(auth.PrivateKey),
-		})
+		}
+		if opts.RequireSignature {
+			signOpts.Scheme = storage.SigningSchemeV4
+			if opts.ContentDisposition != "" {
+				signOpts.QueryParameters = url.Values{"response-content-disposition": {opts.ContentDisposition}}
+			}
+		}
+		return storage.SignedURL(bucketName, relativePath, signOpts)
 	}
 
 	bucket, relativePath, err := o.getBucket(ctx, p)
@@ -456,7 +492,7 @@ func (o *opener) SignedURL(ctx context.Context, p string, opts SignedURLOptions)
 	}
 	return bucket.SignedURL(ctx, relativePath, &blob.SignedURLOptions{
 		Method: "GET",
-		Expiry: 10 * time.Minute,
+		Expiry: expiry,
 	})
 }
 
@@ -25,6 +25,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"google.golang.org/api/googleapi"
@@ -114,6 +115,37 @@ RU5EIFBSSVZBVEUgS0VZLS0tLS1cbgo=`)
 				"Signature=", // Do not particularly care about the Signature contents
 			},
 		},
+		{
+			name: "required signatures are V4 and ignore cookie auth",
+			args: args{
+				p: "gs://foo/bar/stuff",
+				opts: SignedURLOptions{
+					UseGSCookieAuth:    true,
+					RequireSignature:   true,
+					Expiry:             time.Hour,
+					ContentDisposition: `attachment; filename="stuff"`,
+				},
+			},
+			fakeCreds: `{
+			  "type": "service_account",
+			  "private_key": "` + fakePrivateKey + `",
+			  "client_email": "fake-user@k8s.io"
+			}`,
+			contains: []string{
+				"https://storage.googleapis.com/foo/bar/stuff?",
+				"X-Goog-Algorithm=GOOG4-RSA-SHA256",
+				"response-content-disposition=attachment%3B+filename%3D%22stuff%22",
+				"X-Goog-Signature=",
+			},
+		},
+		{
+			name: "required signatures need credentials",
+			args: args{
+				p:    "gs://foo/bar/stuff",
+				opts: SignedURLOptions{RequireSignature: true},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
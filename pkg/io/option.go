@@ -17,6 +17,8 @@ limitations under the License.
 package io
 
 import (
+	"time"
+
 	"cloud.google.com/go/storage"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"gocloud.dev/blob"
@@ -31,6 +33,12 @@ type WriterOptions struct {
 	Metadata                 map[string]string
 	PreconditionDoesNotExist *bool
 	CacheControl             *string
+	// ChunkSize is the size of the chunks of resumable uploads to GCS,
+	// for objects larger than BufferSize.
+	ChunkSize *int
+	// ChunkRetryDeadline is how long a chunk of a resumable upload to GCS
+	// is retried before the upload fails.
+	ChunkRetryDeadline *time.Duration
 }
 
 func (wo WriterOptions) Apply(opts *WriterOptions) {
@@ -52,6 +60,12 @@ func (wo WriterOptions) Apply(opts *WriterOptions) {
 	if wo.CacheControl != nil {
 		opts.CacheControl = wo.CacheControl
 	}
+	if wo.ChunkSize != nil {
+		opts.ChunkSize = wo.ChunkSize
+	}
+	if wo.ChunkRetryDeadline != nil {
+		opts.ChunkRetryDeadline = wo.ChunkRetryDeadline
+	}
 }
 
 // Apply applies the WriterOptions to storage.Writer and blob.WriterOptions
@@ -75,6 +89,13 @@ func (wo WriterOptions) apply(writer *storage.Writer, o *blob.WriterOptions) {
 		if wo.CacheControl != nil {
 			writer.ObjectAttrs.CacheControl = *wo.CacheControl
 		}
+		// Objects that fit in their buffer are uploaded in a single chunk.
+		if wo.ChunkSize != nil && (wo.BufferSize == nil || *wo.BufferSize > int64(*wo.ChunkSize)) {
+			writer.ChunkSize = *wo.ChunkSize
+		}
+		if wo.ChunkRetryDeadline != nil {
+			writer.ChunkRetryDeadline = *wo.ChunkRetryDeadline
+		}
 	}
 
 	if o == nil {
@@ -109,4 +130,19 @@ type SignedURLOptions struct {
 	// UseGSCookieAuth defines if we should use cookie auth for GCS, see:
 	// https://cloud.google.com/storage/docs/access-control/cookie-based-authentication
 	UseGSCookieAuth bool
+	// RequireSignature asks for a URL that lets anyone download the object
+	// until it expires, e.g. to download objects of private buckets directly
+	// from the storage provider. GCS URLs are signed with the V4 scheme, and
+	// it is an error if the opener has no credentials to sign them with
+	// instead of falling back to an unsigned link. UseGSCookieAuth is ignored.
+	RequireSignature bool
+	// Expiry is how long a signed URL is valid. Defaults to 10m.
+	Expiry time.Duration
+	// ContentDisposition is the Content-Disposition header the storage
+	// provider responds to signed URLs with. Only GCS supports it, when
+	// RequireSignature is set.
+	ContentDisposition string
 }
+
+// DefaultSignedURLExpiry is how long signed URLs are valid by default.
+const DefaultSignedURLExpiry = 10 * time.Minute
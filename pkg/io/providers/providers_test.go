@@ -38,6 +38,11 @@ func TestHasStorageProviderPrefix(t *testing.T) {
 			path: "gs/kubernetes-jenkins",
 			want: true,
 		},
+		{
+			name: "azblob prefix",
+			path: "azblob/kubernetes-jenkins",
+			want: true,
+		},
 		{
 			name: "no prefix",
 			path: "kubernetes-jenkins",
@@ -69,6 +69,23 @@ const (
 	// IsOptionalLabel is added in resources created by prow and
 	// carries the Optional from a Presubmit job.
 	IsOptionalLabel = "prow.k8s.io/is-optional"
+	// DelayedReasonAnnotation is added by plank to ProwJobs whose start
+	// was delayed by load shedding and carries the reason for the delay.
+	DelayedReasonAnnotation = "prow.k8s.io/delayed-reason"
+	// OwnerAnnotation can be set on jobs to declare the team owning them.
+	OwnerAnnotation = "prow.k8s.io/owner"
+	// EscalationAnnotation can be set on jobs to declare where to escalate
+	// problems with them, e.g. a chat channel, mailing list or issue tracker.
+	EscalationAnnotation = "prow.k8s.io/escalation"
+	// DocsAnnotation can be set on jobs to link to their documentation.
+	DocsAnnotation = "prow.k8s.io/docs"
+	// RehydratedFromAnnotation is added to ProwJobs recreated from the
+	// artifacts of an earlier run and carries the path of the artifacts.
+	RehydratedFromAnnotation = "prow.k8s.io/rehydrated-from"
+
+	// TenantLabel names the tenant the namespace-provisioner created an
+	// object in a build cluster for.
+	TenantLabel = "prow.k8s.io/tenant"
 
 	// Gerrit related labels that are used by Prow
 
@@ -44,6 +44,7 @@ const (
 	MergeCommits                = "do-not-merge/contains-merge-commits"
 	NeedsOkToTest               = "needs-ok-to-test"
 	NeedsRebase                 = "needs-rebase"
+	NoRetest                    = "do-not-retest"
 	OkToTest                    = "ok-to-test"
 	ReleaseNoteLabelNeeded      = "do-not-merge/release-note-label-needed"
 	ReleaseNote                 = "release-note"
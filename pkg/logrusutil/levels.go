@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logrusutil
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelsEnv is the environment variable ComponentInit takes the log levels of
+// the component from, in the syntax of ParseLevels.
+const LevelsEnv = "PROW_LOG_LEVELS"
+
+// modulePrefix is the import path under which modules are named.
+const modulePrefix = "sigs.k8s.io/prow/"
+
+// Levels are the log levels of a component: a default level and the levels
+// of modules, the packages of Prow under a path like "pkg/github", that log
+// at another level than the rest of the component.
+type Levels struct {
+	Default logrus.Level
+	Modules map[string]logrus.Level
+}
+
+// ParseLevels parses log levels like "info,pkg/github=debug,pkg/tide=warn",
+// i.e. the default level followed by the levels of modules. The default level
+// defaults to info.
+func ParseLevels(spec string) (Levels, error) {
+	levels := Levels{Default: logrus.InfoLevel}
+	for i, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		module, levelName, isModule := strings.Cut(part, "=")
+		if !isModule {
+			if i > 0 {
+				return Levels{}, fmt.Errorf("the default log level %q must come first", part)
+			}
+			levelName = part
+		}
+		level, err := logrus.ParseLevel(strings.TrimSpace(levelName))
+		if err != nil {
+			return Levels{}, err
+		}
+		if !isModule {
+			levels.Default = level
+			continue
+		}
+		module = strings.Trim(strings.TrimSpace(module), "/")
+		if module == "" {
+			return Levels{}, fmt.Errorf("missing module in %q", part)
+		}
+		if levels.Modules == nil {
+			levels.Modules = map[string]logrus.Level{}
+		}
+		levels.Modules[module] = level
+	}
+	return levels, nil
+}
+
+// String formats the levels in the syntax of ParseLevels.
+func (l Levels) String() string {
+	parts := []string{l.Default.String()}
+	for module, level := range l.Modules {
+		parts = append(parts, module+"="+level.String())
+	}
+	sort.Strings(parts[1:])
+	return strings.Join(parts, ",")
+}
+
+// levelFor returns the level of the module of the given function, as
+// reported by the caller of a log entry. The most specific module wins.
+func (l Levels) levelFor(function string) logrus.Level {
+	pkg := strings.TrimPrefix(function, modulePrefix)
+	if pkg == function {
+		return l.Default
+	}
+	// The package path ends before the first dot after the last slash, e.g.
+	// in "pkg/tide.(*syncController).Sync".
+	if dot := strings.Index(pkg[strings.LastIndex(pkg, "/")+1:], "."); dot >= 0 {
+		pkg = pkg[:strings.LastIndex(pkg, "/")+1+dot]
+	}
+	level, matched := l.Default, ""
+	for module, moduleLevel := range l.Modules {
+		if (pkg == module || strings.HasPrefix(pkg, module+"/")) && len(module) > len(matched) {
+			level, matched = moduleLevel, module
+		}
+	}
+	return level
+}
+
+// max returns the most verbose of the levels.
+func (l Levels) max() logrus.Level {
+	level := l.Default
+	for _, moduleLevel := range l.Modules {
+		if moduleLevel > level {
+			level = moduleLevel
+		}
+	}
+	return level
+}
+
+var currentLevels struct {
+	sync.RWMutex
+	levels *Levels
+}
+
+// SetLevels sets the log levels of the component. The standard logger logs
+// at the most verbose of them, and the formatter set by Init drops the
+// entries of the modules that log at less verbose levels.
+func SetLevels(levels Levels) {
+	currentLevels.Lock()
+	defer currentLevels.Unlock()
+	currentLevels.levels = &levels
+	logrus.SetLevel(levels.max())
+	if len(levels.Modules) > 0 {
+		// The module of an entry is told by its caller.
+		logrus.SetReportCaller(true)
+	}
+}
+
+// CurrentLevels returns the log levels of the component.
+func CurrentLevels() Levels {
+	currentLevels.RLock()
+	defer currentLevels.RUnlock()
+	if currentLevels.levels == nil {
+		return Levels{Default: logrus.GetLevel()}
+	}
+	return *currentLevels.levels
+}
+
+// dropped tells whether the entry is below the level of its module.
+func dropped(entry *logrus.Entry) bool {
+	currentLevels.RLock()
+	defer currentLevels.RUnlock()
+	levels := currentLevels.levels
+	if levels == nil || len(levels.Modules) == 0 {
+		return false
+	}
+	if entry.Caller == nil {
+		return entry.Level > levels.Default
+	}
+	return entry.Level > levels.levelFor(entry.Caller.Function)
+}
+
+// LevelsHandler serves the log levels of the component on GET requests and
+// sets them from the body of PUT requests, both in the syntax of ParseLevels,
+// so that e.g. a module can log at debug level while an issue is debugged.
+func LevelsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to read the log levels: %v", err), http.StatusBadRequest)
+				return
+			}
+			levels, err := ParseLevels(string(body))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid log levels: %v", err), http.StatusBadRequest)
+				return
+			}
+			SetLevels(levels)
+			logrus.WithField("levels", levels.String()).Info("Set log levels.")
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "only GET and PUT are supported", http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprintln(w, CurrentLevels().String())
+	})
+}
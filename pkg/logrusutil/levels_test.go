@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logrusutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseLevels(t *testing.T) {
+	testCases := []struct {
+		name        string
+		spec        string
+		expected    Levels
+		expectedErr bool
+	}{
+		{
+			name:     "empty",
+			expected: Levels{Default: logrus.InfoLevel},
+		},
+		{
+			name:     "default level",
+			spec:     "debug",
+			expected: Levels{Default: logrus.DebugLevel},
+		},
+		{
+			name: "module levels",
+			spec: "warn, pkg/github=debug,/pkg/tide/=error",
+			expected: Levels{
+				Default: logrus.WarnLevel,
+				Modules: map[string]logrus.Level{"pkg/github": logrus.DebugLevel, "pkg/tide": logrus.ErrorLevel},
+			},
+		},
+		{
+			name: "module levels without default level",
+			spec: "pkg/github=trace",
+			expected: Levels{
+				Default: logrus.InfoLevel,
+				Modules: map[string]logrus.Level{"pkg/github": logrus.TraceLevel},
+			},
+		},
+		{
+			name:        "invalid level",
+			spec:        "pkg/github=loud",
+			expectedErr: true,
+		},
+		{
+			name:        "default level after module levels",
+			spec:        "pkg/github=debug,info",
+			expectedErr: true,
+		},
+		{
+			name:        "missing module",
+			spec:        "=debug",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			levels, err := ParseLevels(tc.spec)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, levels); diff != "" {
+				t.Errorf("levels differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLevelsString(t *testing.T) {
+	spec := "warning,pkg/github=debug,pkg/tide=error"
+	levels, err := ParseLevels("warn,pkg/tide=error,pkg/github=debug")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if levels.String() != spec {
+		t.Errorf("expected %q, got %q", spec, levels.String())
+	}
+}
+
+func TestLevelFor(t *testing.T) {
+	levels := Levels{
+		Default: logrus.InfoLevel,
+		Modules: map[string]logrus.Level{
+			"pkg/tide":         logrus.DebugLevel,
+			"pkg/tide/history": logrus.ErrorLevel,
+			"pkg/git":          logrus.WarnLevel,
+		},
+	}
+	testCases := []struct {
+		function string
+		expected logrus.Level
+	}{
+		{function: "sigs.k8s.io/prow/pkg/tide.(*syncController).Sync", expected: logrus.DebugLevel},
+		{function: "sigs.k8s.io/prow/pkg/tide/blockers.FindAll", expected: logrus.DebugLevel},
+		{function: "sigs.k8s.io/prow/pkg/tide/history.(*History).Record", expected: logrus.ErrorLevel},
+		{function: "sigs.k8s.io/prow/pkg/git/v2.(*clientFactory).ClientFor", expected: logrus.WarnLevel},
+		{function: "sigs.k8s.io/prow/pkg/github.(*client).request", expected: logrus.InfoLevel},
+		{function: "sigs.k8s.io/prow/cmd/tide.main", expected: logrus.InfoLevel},
+		{function: "main.main", expected: logrus.InfoLevel},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.function, func(t *testing.T) {
+			if level := levels.levelFor(tc.function); level != tc.expected {
+				t.Errorf("expected level %s, got %s", tc.expected, level)
+			}
+		})
+	}
+}
+
+func TestDefaultFieldsFormatterDropsEntriesBelowModuleLevel(t *testing.T) {
+	defer resetLevels(logrus.GetLevel(), logrus.StandardLogger().ReportCaller)
+	SetLevels(Levels{Default: logrus.InfoLevel, Modules: map[string]logrus.Level{"pkg/logrusutil": logrus.DebugLevel, "pkg/github": logrus.ErrorLevel}})
+	if level := logrus.GetLevel(); level != logrus.DebugLevel {
+		t.Errorf("expected the standard logger to log at the most verbose level, got %s", level)
+	}
+
+	formatter := &DefaultFieldsFormatter{WrappedFormatter: &logrus.JSONFormatter{}}
+	pc, _, _, _ := runtime.Caller(0)
+	thisFunction := runtime.FuncForPC(pc).Name()
+	testCases := []struct {
+		name     string
+		entry    *logrus.Entry
+		expected bool
+	}{
+		{
+			name:     "debug entry of module logging at debug level",
+			entry:    &logrus.Entry{Level: logrus.DebugLevel, Caller: &runtime.Frame{Function: thisFunction}},
+			expected: true,
+		},
+		{
+			name:  "warning of module logging at error level",
+			entry: &logrus.Entry{Level: logrus.WarnLevel, Caller: &runtime.Frame{Function: "sigs.k8s.io/prow/pkg/github.(*client).request"}},
+		},
+		{
+			name:     "error of module logging at error level",
+			entry:    &logrus.Entry{Level: logrus.ErrorLevel, Caller: &runtime.Frame{Function: "sigs.k8s.io/prow/pkg/github.(*client).request"}},
+			expected: true,
+		},
+		{
+			name:  "debug entry without caller",
+			entry: &logrus.Entry{Level: logrus.DebugLevel},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := formatter.Format(tc.entry)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if logged := len(raw) > 0; logged != tc.expected {
+				t.Errorf("expected entry to be logged: %t, got: %q", tc.expected, string(raw))
+			}
+		})
+	}
+}
+
+func TestLevelsHandler(t *testing.T) {
+	defer resetLevels(logrus.GetLevel(), logrus.StandardLogger().ReportCaller)
+	handler := LevelsHandler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/debug/log-levels", strings.NewReader("warn,pkg/tide=debug")))
+	if rr.Code != http.StatusOK || rr.Body.String() != "warning,pkg/tide=debug\n" {
+		t.Errorf("unexpected response to PUT: %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/log-levels", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "warning,pkg/tide=debug\n" {
+		t.Errorf("unexpected response to GET: %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/debug/log-levels", strings.NewReader("pkg/tide=loud")))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected invalid levels to be rejected, got: %d %q", rr.Code, rr.Body.String())
+	}
+	if levels := CurrentLevels().String(); levels != "warning,pkg/tide=debug" {
+		t.Errorf("expected invalid levels to be ignored, got %q", levels)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/debug/log-levels", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected POST to be rejected, got: %d", rr.Code)
+	}
+}
+
+func resetLevels(level logrus.Level, reportCaller bool) {
+	currentLevels.Lock()
+	currentLevels.levels = nil
+	currentLevels.Unlock()
+	logrus.SetLevel(level)
+	logrus.SetReportCaller(reportCaller)
+}
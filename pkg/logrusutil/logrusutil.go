@@ -18,6 +18,8 @@ limitations under the License.
 package logrusutil
 
 import (
+	"encoding/json"
+	"os"
 	"sync"
 	"time"
 
@@ -30,6 +32,16 @@ import (
 	"sigs.k8s.io/prow/pkg/version"
 )
 
+const (
+	// TraceIDField is the field that correlates the logs about the same
+	// webhook event or ProwJob across components.
+	TraceIDField = "trace_id"
+	// TraceIDEnv is the environment variable ComponentInit takes the trace
+	// ID of the component from. Without it, the ID of the ProwJob in
+	// $JOB_SPEC, if any, is used.
+	TraceIDEnv = "PROW_TRACE_ID"
+)
+
 // DefaultFieldsFormatter wraps another logrus.Formatter, injecting
 // DefaultFields into each Format() call, existing fields are preserved
 // if they have the same key
@@ -55,20 +67,50 @@ func Init(formatter *DefaultFieldsFormatter) {
 	log.SetLogger(logrusr.New(logrus.StandardLogger()))
 }
 
-// ComponentInit is a syntax sugar for easier Init
+// ComponentInit is a syntax sugar for easier Init. It also sets the log
+// levels from $PROW_LOG_LEVELS and the trace ID from $PROW_TRACE_ID, if set.
 func ComponentInit() {
+	fields := logrus.Fields{"component": version.Name}
+	if traceID := traceIDFromEnv(); traceID != "" {
+		fields[TraceIDField] = traceID
+	}
 	Init(
 		&DefaultFieldsFormatter{
 			PrintLineNumber: true,
-			DefaultFields:   logrus.Fields{"component": version.Name},
+			DefaultFields:   fields,
 		},
 	)
+	if spec := os.Getenv(LevelsEnv); spec != "" {
+		levels, err := ParseLevels(spec)
+		if err != nil {
+			logrus.WithError(err).Warnf("Ignoring invalid $%s.", LevelsEnv)
+			return
+		}
+		SetLevels(levels)
+	}
+}
+
+func traceIDFromEnv() string {
+	if traceID := os.Getenv(TraceIDEnv); traceID != "" {
+		return traceID
+	}
+	// The pod utilities get the job spec in $JOB_SPEC.
+	var spec struct {
+		ProwJobID string `json:"prowjobid"`
+	}
+	if raw := os.Getenv("JOB_SPEC"); raw != "" && json.Unmarshal([]byte(raw), &spec) == nil {
+		return spec.ProwJobID
+	}
+	return ""
 }
 
 // Format implements logrus.Formatter's Format. We allocate a new Fields
 // map in order to not modify the caller's Entry, as that is not a thread
 // safe operation.
 func (f *DefaultFieldsFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if dropped(entry) {
+		return nil, nil
+	}
 	data := make(logrus.Fields, len(entry.Data)+len(f.DefaultFields)+1)
 	// GCP's log collection expects a "severity" field instead of "level"
 	data["severity"] = entry.Level
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prowjobs
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/pjutil"
+)
+
+func updateCost(counterVec *prometheus.CounterVec, cfg config.Getter, oldJob *prowapi.ProwJob, newJob *prowapi.ProwJob) {
+	if oldJob == nil || oldJob.Complete() || !newJob.Complete() {
+		return
+	}
+	cost, ok := pjutil.EstimateCost(newJob, cfg().CostAccounting)
+	if !ok {
+		return
+	}
+	var org, repo string
+	if newJob.Spec.Refs != nil {
+		org, repo = newJob.Spec.Refs.Org, newJob.Spec.Refs.Repo
+	} else if len(newJob.Spec.ExtraRefs) > 0 {
+		org, repo = newJob.Spec.ExtraRefs[0].Org, newJob.Spec.ExtraRefs[0].Repo
+	}
+	counter, err := counterVec.GetMetricWithLabelValues(newJob.Namespace, newJob.Spec.Job, string(newJob.Spec.Type), org, repo, newJob.ClusterAlias())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get a cost counter for a prowjob")
+		return
+	}
+	counter.Add(cost.Total)
+}
+
+// NewProwJobCostCounterVec creates counters of the estimated cost of ProwJobs,
+// in the currency of the cost accounting config, which are increased when a
+// job completes. Data is collected by hooking itself into the prowjob informer.
+func NewProwJobCostCounterVec(informer cache.SharedIndexInformer, cfg config.Getter) *prometheus.CounterVec {
+	counterVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "prow_job_cost",
+			Help: "Estimated cost of completed ProwJobs, from their resource requests, run time and cluster prices.",
+		},
+		[]string{
+			// namespace of the job
+			"job_namespace",
+			// name of the job
+			"job_name",
+			// type of the prowjob: presubmit, postsubmit, periodic, batch
+			"type",
+			// the org of the prowjob's repo
+			"org",
+			// the prowjob's repo
+			"repo",
+			// the build cluster the job ran on
+			"cluster",
+		},
+	)
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldJob, newJob interface{}) {
+			updateCost(counterVec, cfg, oldJob.(*prowapi.ProwJob), newJob.(*prowapi.ProwJob))
+		},
+	})
+	return counterVec
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prowjobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestUpdateCost(t *testing.T) {
+	start := v1.NewTime(time.Now())
+	completion := v1.NewTime(start.Add(2 * time.Hour))
+	job := func(state prowapi.ProwJobState) *prowapi.ProwJob {
+		pj := &prowapi.ProwJob{
+			ObjectMeta: v1.ObjectMeta{Namespace: "prowjobs"},
+			Spec: prowapi.ProwJobSpec{
+				Type:    prowapi.PeriodicJob,
+				Agent:   prowapi.KubernetesAgent,
+				Job:     "job",
+				Cluster: "build",
+				PodSpec: &coreapi.PodSpec{Containers: []coreapi.Container{{Resources: coreapi.ResourceRequirements{
+					Requests: coreapi.ResourceList{coreapi.ResourceCPU: resource.MustParse("2")},
+				}}}},
+			},
+			Status: prowapi.ProwJobStatus{State: state, StartTime: start},
+		}
+		if state != prowapi.PendingState {
+			pj.Status.CompletionTime = &completion
+		}
+		return pj
+	}
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{CostAccounting: config.CostAccounting{
+			Prices: map[string]config.ResourcePrices{"build": {CPU: 0.5}},
+		}}}
+	}
+	testCases := []struct {
+		name     string
+		oldJob   *prowapi.ProwJob
+		newJob   *prowapi.ProwJob
+		expected float64
+	}{
+		{
+			name:     "cost is counted when the job completes",
+			oldJob:   job(prowapi.PendingState),
+			newJob:   job(prowapi.SuccessState),
+			expected: 2,
+		},
+		{
+			name:   "cost is not counted again for updates of completed jobs",
+			oldJob: job(prowapi.FailureState),
+			newJob: job(prowapi.FailureState),
+		},
+		{
+			name:   "cost is not counted for running jobs",
+			oldJob: job(prowapi.PendingState),
+			newJob: job(prowapi.PendingState),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			counterVec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "cost"}, []string{"job_namespace", "job_name", "type", "org", "repo", "cluster"})
+			updateCost(counterVec, cfg, tc.oldJob, tc.newJob)
+			if actual := testutil.ToFloat64(counterVec.WithLabelValues("prowjobs", "job", "periodic", "", "", "build")); actual != tc.expected {
+				t.Errorf("expected cost %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
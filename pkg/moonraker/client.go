@@ -165,6 +165,38 @@ func (c *Client) GetProwYAML(refs *prowapi.Refs) (*config.ProwYAML, error) {
 	return &prowYAML, nil
 }
 
+// GetConfigPreview returns the jobs the repo of the Refs would have if its
+// Pulls[] merged, along with how they differ from the jobs of its BaseSHA.
+func (c *Client) GetConfigPreview(refs *prowapi.Refs) (*ConfigPreview, error) {
+	payload := payload{
+		Refs: *refs,
+	}
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal %v", payload)
+	}
+
+	resp, err := c.do(http.MethodPost, PathConfigPreview, buf, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("got %v response: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	preview := ConfigPreview{}
+	if err := json.Unmarshal(body, &preview); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal configPreview response: %w", err)
+	}
+
+	return &preview, nil
+}
+
 // GetInRepoConfig just wraps around GetProwYAML(), converting the input
 // parameters into a prowapi.Refs{} type.
 //
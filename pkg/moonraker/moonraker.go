@@ -32,6 +32,7 @@ import (
 
 const (
 	PathGetInrepoconfig = "inrepoconfig"
+	PathConfigPreview   = "config-preview"
 	PathPing            = "ping"
 )
 
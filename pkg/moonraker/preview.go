@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package moonraker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// JobChange is how the jobs of a repo change when pull requests merge.
+type JobChange string
+
+const (
+	JobAdded   JobChange = "added"
+	JobRemoved JobChange = "removed"
+	JobChanged JobChange = "changed"
+)
+
+// JobDiff is an in-repo job that pull requests add, remove or change.
+type JobDiff struct {
+	Name string `json:"name"`
+	// Type is either presubmit or postsubmit.
+	Type   string    `json:"type"`
+	Change JobChange `json:"change"`
+}
+
+// ConfigPreview is the set of jobs a repo would have if pull requests merged
+// into their base branch.
+type ConfigPreview struct {
+	// Presubmits and Postsubmits are the names of all the jobs, both static
+	// and in-repo, that the repo would have.
+	Presubmits  []string `json:"presubmits"`
+	Postsubmits []string `json:"postsubmits"`
+	// Diff lists the in-repo jobs that differ from the ones of the base
+	// branch.
+	Diff []JobDiff `json:"diff,omitempty"`
+	// Error is set instead of the jobs when the in-repo config of the pull
+	// requests is invalid.
+	Error string `json:"error,omitempty"`
+}
+
+// ServeConfigPreview returns the ConfigPreview of the pull requests of the
+// Refs in the payload, marshaled into JSON. Unlike ServeGetInrepoconfig, the
+// in-repo config is defaulted and validated, so that invalid configs are
+// reported.
+func (mr *Moonraker) ServeConfigPreview(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logrus.WithError(err).Info("unable to read request")
+		http.Error(w, fmt.Sprintf("bad request %v", err), http.StatusBadRequest)
+		return
+	}
+
+	payload := &payload{}
+	if err := json.Unmarshal(body, payload); err != nil {
+		logrus.WithError(err).Info("unable to unmarshal configPreview request")
+		http.Error(w, fmt.Sprintf("unable to unmarshal configPreview request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(payload.Refs.Pulls) == 0 {
+		http.Error(w, "configPreview request has no pulls", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := mr.configPreview(payload)
+	if err != nil {
+		logrus.WithError(err).Error("unable to preview inrepoconfig")
+		http.Error(w, fmt.Sprintf("unable to preview inrepoconfig: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		logrus.WithError(err).Error("unable to encode config preview into JSON")
+		http.Error(w, fmt.Sprintf("unable to encode config preview into JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+}
+
+func (mr *Moonraker) configPreview(payload *payload) (*ConfigPreview, error) {
+	baseSHAGetter := func() (string, error) {
+		return payload.Refs.BaseSHA, nil
+	}
+	var headSHAGetters []func() (string, error)
+	for _, pull := range payload.Refs.Pulls {
+		pull := pull
+		headSHAGetters = append(headSHAGetters, func() (string, error) {
+			return pull.SHA, nil
+		})
+	}
+	identifier := payload.Refs.Org + "/" + payload.Refs.Repo
+	cfg := mr.ConfigAgent.Config()
+
+	base, err := mr.InRepoConfigCache.GetProwYAMLWithoutDefaults(identifier, payload.Refs.BaseRef, baseSHAGetter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the inrepoconfig of the base: %w", err)
+	}
+	// The base may be invalid as well, e.g. if the config of Prow changed
+	// since it merged. Diff against no jobs then.
+	if err := config.DefaultAndValidateProwYAML(cfg, base, identifier); err != nil {
+		logrus.WithError(err).WithField("repo", identifier).Info("Inrepoconfig of the base is invalid.")
+		base = &config.ProwYAML{}
+	}
+
+	head, err := mr.InRepoConfigCache.GetProwYAMLWithoutDefaults(identifier, payload.Refs.BaseRef, baseSHAGetter, headSHAGetters...)
+	if err != nil {
+		return &ConfigPreview{Error: err.Error()}, nil
+	}
+	if err := config.DefaultAndValidateProwYAML(cfg, head, identifier); err != nil {
+		return &ConfigPreview{Error: err.Error()}, nil
+	}
+
+	return newConfigPreview(cfg.GetPresubmitsStatic(identifier), cfg.GetPostsubmitsStatic(identifier), base, head), nil
+}
+
+// newConfigPreview returns the ConfigPreview of a repo with the given static
+// jobs when its in-repo config changes from base to head.
+func newConfigPreview(staticPresubmits []config.Presubmit, staticPostsubmits []config.Postsubmit, base, head *config.ProwYAML) *ConfigPreview {
+	preview := &ConfigPreview{
+		Presubmits:  []string{},
+		Postsubmits: []string{},
+	}
+	for _, p := range append(staticPresubmits, head.Presubmits...) {
+		preview.Presubmits = append(preview.Presubmits, p.Name)
+	}
+	for _, p := range append(staticPostsubmits, head.Postsubmits...) {
+		preview.Postsubmits = append(preview.Postsubmits, p.Name)
+	}
+	sort.Strings(preview.Presubmits)
+	sort.Strings(preview.Postsubmits)
+
+	preview.Diff = append(preview.Diff, diffJobs("presubmit", jobsByName(base.Presubmits), jobsByName(head.Presubmits))...)
+	preview.Diff = append(preview.Diff, diffJobs("postsubmit", jobsByName(base.Postsubmits), jobsByName(head.Postsubmits))...)
+	return preview
+}
+
+// jobsByName maps the names of jobs to their JSON serialization, which is
+// what tells whether a job changed.
+func jobsByName[T config.Presubmit | config.Postsubmit](jobs []T) map[string]string {
+	byName := make(map[string]string, len(jobs))
+	for _, job := range jobs {
+		var name string
+		switch j := any(job).(type) {
+		case config.Presubmit:
+			name = j.Name
+		case config.Postsubmit:
+			name = j.Name
+		}
+		serialized, err := json.Marshal(job)
+		if err != nil {
+			// Jobs always marshal, but never report a job as unchanged
+			// because of an error.
+			serialized = []byte(err.Error())
+		}
+		byName[name] = string(serialized)
+	}
+	return byName
+}
+
+func diffJobs(jobType string, base, head map[string]string) []JobDiff {
+	var diff []JobDiff
+	for name, job := range head {
+		baseJob, ok := base[name]
+		switch {
+		case !ok:
+			diff = append(diff, JobDiff{Name: name, Type: jobType, Change: JobAdded})
+		case baseJob != job:
+			diff = append(diff, JobDiff{Name: name, Type: jobType, Change: JobChanged})
+		}
+	}
+	for name := range base {
+		if _, ok := head[name]; !ok {
+			diff = append(diff, JobDiff{Name: name, Type: jobType, Change: JobRemoved})
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Name < diff[j].Name
+	})
+	return diff
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package moonraker
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestNewConfigPreview(t *testing.T) {
+	presubmit := func(name, command string) config.Presubmit {
+		return config.Presubmit{JobBase: config.JobBase{Name: name, Labels: map[string]string{"command": command}}}
+	}
+	postsubmit := func(name string) config.Postsubmit {
+		return config.Postsubmit{JobBase: config.JobBase{Name: name}}
+	}
+
+	base := &config.ProwYAML{
+		Presubmits:  []config.Presubmit{presubmit("pull-unit", "make test"), presubmit("pull-lint", "make lint")},
+		Postsubmits: []config.Postsubmit{postsubmit("post-old")},
+	}
+	head := &config.ProwYAML{
+		Presubmits:  []config.Presubmit{presubmit("pull-unit", "make test"), presubmit("pull-lint", "make verify"), presubmit("pull-e2e", "make e2e")},
+		Postsubmits: []config.Postsubmit{postsubmit("post-new")},
+	}
+
+	expected := &ConfigPreview{
+		Presubmits:  []string{"pull-e2e", "pull-lint", "pull-static", "pull-unit"},
+		Postsubmits: []string{"post-new"},
+		Diff: []JobDiff{
+			{Name: "pull-e2e", Type: "presubmit", Change: JobAdded},
+			{Name: "pull-lint", Type: "presubmit", Change: JobChanged},
+			{Name: "post-new", Type: "postsubmit", Change: JobAdded},
+			{Name: "post-old", Type: "postsubmit", Change: JobRemoved},
+		},
+	}
+	actual := newConfigPreview([]config.Presubmit{presubmit("pull-static", "make")}, nil, base, head)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected config preview (-want +got):\n%s", diff)
+	}
+}
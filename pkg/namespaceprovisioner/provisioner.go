@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package namespaceprovisioner creates the namespaces of tenants in the build
+// clusters, along with their quotas, default resources, network policies and
+// image pull secrets.
+package namespaceprovisioner
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// objectName is the name of the ResourceQuota, LimitRange and NetworkPolicy
+// of a tenant's namespace.
+const objectName = "prow-tenant"
+
+// Controller provisions the namespaces of tenants in the build clusters.
+type Controller struct {
+	buildClusterClients map[string]ctrlruntimeclient.Client
+	// secretClient reads the image pull secrets from the ProwJob namespace.
+	secretClient ctrlruntimeclient.Client
+	config       config.Getter
+	logger       *logrus.Entry
+}
+
+// NewController returns a Controller provisioning namespaces in the build
+// clusters.
+func NewController(buildClusterClients map[string]ctrlruntimeclient.Client, secretClient ctrlruntimeclient.Client, cfg config.Getter) *Controller {
+	return &Controller{
+		buildClusterClients: buildClusterClients,
+		secretClient:        secretClient,
+		config:              cfg,
+		logger:              logrus.WithField("controller", "namespace-provisioner"),
+	}
+}
+
+// Sync provisions the namespaces of all tenants in their build clusters.
+func (c *Controller) Sync(ctx context.Context) error {
+	cfg := c.config()
+	var clusters []string
+	for cluster := range c.buildClusterClients {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	var errs []error
+	for _, cluster := range clusters {
+		for _, tenant := range cfg.NamespaceProvisioner.Tenants {
+			if !tenant.InCluster(cluster) {
+				continue
+			}
+			log := c.logger.WithFields(logrus.Fields{"cluster": cluster, "tenant": tenant.Name, "namespace": tenant.GetNamespace()})
+			if err := c.provision(ctx, log, c.buildClusterClients[cluster], tenant, cfg.ProwJobNamespace); err != nil {
+				errs = append(errs, fmt.Errorf("failed to provision the namespace of tenant %s in cluster %s: %w", tenant.Name, cluster, err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *Controller) provision(ctx context.Context, log *logrus.Entry, client ctrlruntimeclient.Client, tenant config.TenantNamespace, prowJobNamespace string) error {
+	namespace := tenant.GetNamespace()
+	labels := map[string]string{
+		kube.CreatedByProw: "true",
+		kube.TenantLabel:   tenant.Name,
+	}
+
+	ns := &corev1.Namespace{}
+	if err := client.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get namespace: %w", err)
+		}
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: labels}}
+		if err := client.Create(ctx, ns); err != nil {
+			return fmt.Errorf("failed to create namespace: %w", err)
+		}
+		log.Info("Created namespace.")
+	}
+
+	var errs []error
+	if len(tenant.ResourceQuota) > 0 {
+		quota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: objectName, Namespace: namespace}}
+		errs = append(errs, apply(ctx, log, client, quota, labels, func() {
+			quota.Spec = corev1.ResourceQuotaSpec{Hard: tenant.ResourceQuota}
+		}))
+	} else {
+		errs = append(errs, deleteOwned(ctx, log, client, &corev1.ResourceQuota{}, namespace, tenant.Name))
+	}
+
+	if len(tenant.DefaultRequests) > 0 || len(tenant.DefaultLimits) > 0 {
+		limitRange := &corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: objectName, Namespace: namespace}}
+		errs = append(errs, apply(ctx, log, client, limitRange, labels, func() {
+			limitRange.Spec = corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+				Type:           corev1.LimitTypeContainer,
+				DefaultRequest: tenant.DefaultRequests,
+				Default:        tenant.DefaultLimits,
+			}}}
+		}))
+	} else {
+		errs = append(errs, deleteOwned(ctx, log, client, &corev1.LimitRange{}, namespace, tenant.Name))
+	}
+
+	if tenant.IsolateNetwork {
+		policy := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: objectName, Namespace: namespace}}
+		errs = append(errs, apply(ctx, log, client, policy, labels, func() {
+			policy.Spec = isolationPolicy()
+		}))
+	} else {
+		errs = append(errs, deleteOwned(ctx, log, client, &networkingv1.NetworkPolicy{}, namespace, tenant.Name))
+	}
+
+	if len(tenant.ImagePullSecrets) > 0 {
+		errs = append(errs, c.provisionImagePullSecrets(ctx, log, client, tenant, prowJobNamespace, labels))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// isolationPolicy only lets pods of the same namespace connect to the pods
+// of a namespace.
+func isolationPolicy() networkingv1.NetworkPolicySpec {
+	return networkingv1.NetworkPolicySpec{
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{{
+			From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+		}},
+	}
+}
+
+func (c *Controller) provisionImagePullSecrets(ctx context.Context, log *logrus.Entry, client ctrlruntimeclient.Client, tenant config.TenantNamespace, prowJobNamespace string, labels map[string]string) error {
+	namespace := tenant.GetNamespace()
+	var errs []error
+	var provisioned []string
+	for _, name := range tenant.ImagePullSecrets {
+		source := &corev1.Secret{}
+		if err := c.secretClient.Get(ctx, types.NamespacedName{Namespace: prowJobNamespace, Name: name}, source); err != nil {
+			errs = append(errs, fmt.Errorf("failed to get image pull secret %s: %w", name, err))
+			continue
+		}
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if err := apply(ctx, log, client, secret, labels, func() {
+			secret.Type = source.Type
+			secret.Data = source.Data
+		}); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		provisioned = append(provisioned, name)
+	}
+
+	sa := &corev1.ServiceAccount{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "default"}, sa); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The default service account is created shortly after the namespace.
+			log.Info("The default service account does not exist yet, its image pull secrets are set on the next sync.")
+			return utilerrors.NewAggregate(errs)
+		}
+		return utilerrors.NewAggregate(append(errs, fmt.Errorf("failed to get the default service account: %w", err)))
+	}
+	referenced := map[string]bool{}
+	for _, ref := range sa.ImagePullSecrets {
+		referenced[ref.Name] = true
+	}
+	var missing bool
+	for _, name := range provisioned {
+		if !referenced[name] {
+			sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+			missing = true
+		}
+	}
+	if missing {
+		if err := client.Update(ctx, sa); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add the image pull secrets to the default service account: %w", err))
+		} else {
+			log.Info("Added the image pull secrets to the default service account.")
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// apply creates or updates obj, with the changes of mutate. Objects of the
+// same name that were not created for the tenant are left alone.
+func apply(ctx context.Context, log *logrus.Entry, client ctrlruntimeclient.Client, obj ctrlruntimeclient.Object, labels map[string]string, mutate func()) error {
+	kind := kindOf(obj)
+	result, err := controllerutil.CreateOrUpdate(ctx, client, obj, func() error {
+		// Objects that do not exist yet have no resource version.
+		if obj.GetResourceVersion() == "" || obj.GetLabels()[kube.TenantLabel] == labels[kube.TenantLabel] {
+			obj.SetLabels(labels)
+			mutate()
+			return nil
+		}
+		return fmt.Errorf("%s %s/%s was not created for the tenant", kind, obj.GetNamespace(), obj.GetName())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply %s %s: %w", kind, obj.GetName(), err)
+	}
+	if result != controllerutil.OperationResultNone {
+		log.WithField("object", obj.GetName()).Infof("%s %s.", kind, result)
+	}
+	return nil
+}
+
+// deleteOwned deletes the object of the tenant that is no longer configured,
+// if it was created for the tenant.
+func deleteOwned(ctx context.Context, log *logrus.Entry, client ctrlruntimeclient.Client, obj ctrlruntimeclient.Object, namespace, tenant string) error {
+	if err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: objectName}, obj); err != nil {
+		return ctrlruntimeclient.IgnoreNotFound(err)
+	}
+	if obj.GetLabels()[kube.TenantLabel] != tenant {
+		return nil
+	}
+	if err := client.Delete(ctx, obj); ctrlruntimeclient.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete %s %s: %w", kindOf(obj), objectName, err)
+	}
+	log.WithField("object", objectName).Infof("%s deleted.", kindOf(obj))
+	return nil
+}
+
+func kindOf(obj ctrlruntimeclient.Object) string {
+	return reflect.TypeOf(obj).Elem().Name()
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespaceprovisioner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+var tenantLabels = map[string]string{kube.CreatedByProw: "true", kube.TenantLabel: "team-a"}
+
+func TestSync(t *testing.T) {
+	quota := corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("100")}
+	requests := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prow", Name: "registry"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")},
+	}
+
+	testCases := []struct {
+		name     string
+		tenant   config.TenantNamespace
+		existing []ctrlruntimeclient.Object
+		// expected are the objects expected in the build cluster, absent is
+		// the object named prow-tenant expected not to exist.
+		expected    []ctrlruntimeclient.Object
+		absent      []ctrlruntimeclient.Object
+		expectedErr bool
+	}{
+		{
+			name: "namespace and its objects are created",
+			tenant: config.TenantNamespace{
+				Name:             "team-a",
+				ResourceQuota:    quota,
+				DefaultRequests:  requests,
+				IsolateNetwork:   true,
+				ImagePullSecrets: []string{"registry"},
+			},
+			expected: []ctrlruntimeclient.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: tenantLabels}},
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName, Labels: tenantLabels},
+					Spec:       corev1.ResourceQuotaSpec{Hard: quota},
+				},
+				&corev1.LimitRange{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName, Labels: tenantLabels},
+					Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+						Type:           corev1.LimitTypeContainer,
+						DefaultRequest: requests,
+					}}},
+				},
+				&networkingv1.NetworkPolicy{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName, Labels: tenantLabels},
+					Spec:       isolationPolicy(),
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "registry", Labels: tenantLabels},
+					Type:       corev1.SecretTypeDockerConfigJson,
+					Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")},
+				},
+			},
+		},
+		{
+			name: "existing namespace is used and its service account pulls with the secrets",
+			tenant: config.TenantNamespace{
+				Name:             "team-a",
+				Namespace:        "jobs",
+				ImagePullSecrets: []string{"registry"},
+			},
+			existing: []ctrlruntimeclient.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "jobs"}},
+				&corev1.ServiceAccount{
+					ObjectMeta:       metav1.ObjectMeta{Namespace: "jobs", Name: "default"},
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "other"}},
+				},
+			},
+			expected: []ctrlruntimeclient.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "jobs"}},
+				&corev1.ServiceAccount{
+					ObjectMeta:       metav1.ObjectMeta{Namespace: "jobs", Name: "default"},
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "other"}, {Name: "registry"}},
+				},
+			},
+		},
+		{
+			name:   "objects of the tenant that are no longer configured are deleted",
+			tenant: config.TenantNamespace{Name: "team-a"},
+			existing: []ctrlruntimeclient.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+				&corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName, Labels: tenantLabels}},
+				&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName}},
+			},
+			expected: []ctrlruntimeclient.Object{
+				&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName}},
+			},
+			absent: []ctrlruntimeclient.Object{&corev1.ResourceQuota{}},
+		},
+		{
+			name:   "existing quota is updated",
+			tenant: config.TenantNamespace{Name: "team-a", ResourceQuota: quota},
+			existing: []ctrlruntimeclient.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName, Labels: tenantLabels},
+					Spec:       corev1.ResourceQuotaSpec{Hard: requests},
+				},
+			},
+			expected: []ctrlruntimeclient.Object{
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName, Labels: tenantLabels},
+					Spec:       corev1.ResourceQuotaSpec{Hard: quota},
+				},
+			},
+		},
+		{
+			name:   "objects that were not created for the tenant are left alone",
+			tenant: config.TenantNamespace{Name: "team-a", ResourceQuota: quota},
+			existing: []ctrlruntimeclient.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName},
+					Spec:       corev1.ResourceQuotaSpec{Hard: requests},
+				},
+			},
+			expected: []ctrlruntimeclient.Object{
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: objectName},
+					Spec:       corev1.ResourceQuotaSpec{Hard: requests},
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name:   "missing image pull secret is an error",
+			tenant: config.TenantNamespace{Name: "team-a", ImagePullSecrets: []string{"missing"}},
+			expected: []ctrlruntimeclient.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: tenantLabels}},
+			},
+			expectedErr: true,
+		},
+		{
+			name:   "namespace is only provisioned in its clusters",
+			tenant: config.TenantNamespace{Name: "team-a", Clusters: []string{"other"}},
+			absent: []ctrlruntimeclient.Object{&corev1.Namespace{}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.existing...).Build()
+			secretClient := fakectrlruntimeclient.NewClientBuilder().WithObjects(pullSecret.DeepCopy()).Build()
+			cfg := &config.Config{ProwConfig: config.ProwConfig{
+				ProwJobNamespace:     "prow",
+				NamespaceProvisioner: config.NamespaceProvisioner{Tenants: []config.TenantNamespace{tc.tenant}},
+			}}
+			c := NewController(map[string]ctrlruntimeclient.Client{"default": client}, secretClient, func() *config.Config { return cfg })
+
+			err := c.Sync(context.Background())
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+
+			for _, expected := range tc.expected {
+				actual := expected.DeepCopyObject().(ctrlruntimeclient.Object)
+				if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKeyFromObject(expected), actual); err != nil {
+					t.Fatalf("failed to get %s %s: %v", kindOf(expected), expected.GetName(), err)
+				}
+				actual.SetResourceVersion("")
+				actual.GetObjectKind().SetGroupVersionKind(expected.GetObjectKind().GroupVersionKind())
+				if diff := cmp.Diff(expected, actual); diff != "" {
+					t.Errorf("unexpected %s %s (-want +got):\n%s", kindOf(expected), expected.GetName(), diff)
+				}
+			}
+			for _, absent := range tc.absent {
+				key := types.NamespacedName{Namespace: tc.tenant.GetNamespace(), Name: objectName}
+				if _, ok := absent.(*corev1.Namespace); ok {
+					key = types.NamespacedName{Name: tc.tenant.GetNamespace()}
+				}
+				if err := client.Get(context.Background(), key, absent); !apierrors.IsNotFound(err) {
+					t.Errorf("expected %s %s not to exist, got: %v", kindOf(absent), key, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	coreapi "k8s.io/api/core/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// gpuResource is the resource name of the GPUs priced by the cost accounting.
+const gpuResource coreapi.ResourceName = "nvidia.com/gpu"
+
+// JobCost is the estimated cost of a run of a job.
+type JobCost struct {
+	CPUCoreHours   float64 `json:"cpu_core_hours"`
+	MemoryGiBHours float64 `json:"memory_gib_hours"`
+	GPUHours       float64 `json:"gpu_hours,omitempty"`
+	Total          float64 `json:"total"`
+	Currency       string  `json:"currency"`
+}
+
+// EstimateCost estimates the cost of a completed ProwJob from the resources
+// requested by its pod, the time the pod ran and the prices of its build
+// cluster. It returns false if the job did not run in a pod, did not
+// complete or ran on a cluster without prices.
+func EstimateCost(pj *prowapi.ProwJob, cfg config.CostAccounting) (JobCost, bool) {
+	if pj.Spec.Agent != prowapi.KubernetesAgent || pj.Spec.PodSpec == nil || pj.Status.CompletionTime == nil {
+		return JobCost{}, false
+	}
+	prices, ok := cfg.PricesFor(pj.ClusterAlias())
+	if !ok {
+		return JobCost{}, false
+	}
+	start := pj.Status.StartTime
+	if pj.Status.PendingTime != nil {
+		start = *pj.Status.PendingTime
+	}
+	hours := pj.Status.CompletionTime.Sub(start.Time).Hours()
+	if hours < 0 {
+		hours = 0
+	}
+
+	requests := podRequests(pj.Spec.PodSpec)
+	cost := JobCost{
+		CPUCoreHours:   float64(requests.Cpu().MilliValue()) / 1000 * hours,
+		MemoryGiBHours: float64(requests.Memory().Value()) / (1 << 30) * hours,
+		Currency:       cfg.GetCurrency(),
+	}
+	if gpus, ok := requests[gpuResource]; ok {
+		cost.GPUHours = float64(gpus.Value()) * hours
+	}
+	cost.Total = cost.CPUCoreHours*prices.CPU + cost.MemoryGiBHours*prices.Memory + cost.GPUHours*prices.GPU
+	return cost, true
+}
+
+// podRequests returns the resources requested by a pod, which are the sum of
+// the requests of its containers, or the largest request of its init
+// containers if that is larger, as they run one at a time.
+func podRequests(spec *coreapi.PodSpec) coreapi.ResourceList {
+	requests := coreapi.ResourceList{}
+	for _, c := range spec.Containers {
+		for name, quantity := range c.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+	for _, c := range spec.InitContainers {
+		for name, quantity := range c.Resources.Requests {
+			if total, ok := requests[name]; !ok || quantity.Cmp(total) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return requests
+}
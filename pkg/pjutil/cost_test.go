@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestEstimateCost(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	container := func(requests coreapi.ResourceList) coreapi.Container {
+		return coreapi.Container{Resources: coreapi.ResourceRequirements{Requests: requests}}
+	}
+	job := func(cluster string, spec *coreapi.PodSpec, ran time.Duration) *prowapi.ProwJob {
+		completed := metav1.NewTime(start.Add(time.Hour + ran))
+		pending := metav1.NewTime(start.Add(time.Hour))
+		return &prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{Agent: prowapi.KubernetesAgent, Cluster: cluster, PodSpec: spec},
+			Status: prowapi.ProwJobStatus{
+				StartTime:      metav1.NewTime(start),
+				PendingTime:    &pending,
+				CompletionTime: &completed,
+			},
+		}
+	}
+	cfg := config.CostAccounting{
+		Prices: map[string]config.ResourcePrices{
+			"*":   {CPU: 0.04, Memory: 0.005},
+			"gpu": {CPU: 0.05, Memory: 0.01, GPU: 2},
+		},
+	}
+	testCases := []struct {
+		name     string
+		pj       *prowapi.ProwJob
+		cfg      config.CostAccounting
+		expected JobCost
+		ok       bool
+	}{
+		{
+			name: "requests of containers are added up for the time the pod ran",
+			pj: job("default", &coreapi.PodSpec{Containers: []coreapi.Container{
+				container(coreapi.ResourceList{coreapi.ResourceCPU: resource.MustParse("1500m"), coreapi.ResourceMemory: resource.MustParse("4Gi")}),
+				container(coreapi.ResourceList{coreapi.ResourceCPU: resource.MustParse("500m"), coreapi.ResourceMemory: resource.MustParse("4Gi")}),
+			}}, 2*time.Hour),
+			cfg:      cfg,
+			expected: JobCost{CPUCoreHours: 4, MemoryGiBHours: 16, Total: 0.24, Currency: "USD"},
+			ok:       true,
+		},
+		{
+			name: "larger init container requests count instead",
+			pj: job("default", &coreapi.PodSpec{
+				InitContainers: []coreapi.Container{container(coreapi.ResourceList{coreapi.ResourceCPU: resource.MustParse("4")})},
+				Containers:     []coreapi.Container{container(coreapi.ResourceList{coreapi.ResourceCPU: resource.MustParse("1"), coreapi.ResourceMemory: resource.MustParse("1Gi")})},
+			}, time.Hour),
+			cfg:      cfg,
+			expected: JobCost{CPUCoreHours: 4, MemoryGiBHours: 1, Total: 0.165, Currency: "USD"},
+			ok:       true,
+		},
+		{
+			name: "cluster prices and GPUs",
+			pj: job("gpu", &coreapi.PodSpec{Containers: []coreapi.Container{
+				container(coreapi.ResourceList{coreapi.ResourceCPU: resource.MustParse("2"), gpuResource: resource.MustParse("1")}),
+			}}, 30*time.Minute),
+			cfg:      config.CostAccounting{Prices: cfg.Prices, Currency: "EUR"},
+			expected: JobCost{CPUCoreHours: 1, GPUHours: 0.5, Total: 1.05, Currency: "EUR"},
+			ok:       true,
+		},
+		{
+			name: "cluster without prices",
+			pj:   job("default", &coreapi.PodSpec{}, time.Hour),
+			cfg:  config.CostAccounting{Prices: map[string]config.ResourcePrices{"gpu": {CPU: 1}}},
+		},
+		{
+			name: "incomplete job",
+			pj: func() *prowapi.ProwJob {
+				pj := job("default", &coreapi.PodSpec{}, time.Hour)
+				pj.Status.CompletionTime = nil
+				return pj
+			}(),
+			cfg: cfg,
+		},
+		{
+			name: "job without a pod",
+			pj: func() *prowapi.ProwJob {
+				pj := job("default", nil, time.Hour)
+				pj.Spec.Agent = prowapi.JenkinsAgent
+				return pj
+			}(),
+			cfg: cfg,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cost, ok := EstimateCost(tc.pj, tc.cfg)
+			if ok != tc.ok {
+				t.Fatalf("expected ok %t, got %t", tc.ok, ok)
+			}
+			if diff := cmp.Diff(tc.expected, cost, cmp.Comparer(func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 })); diff != "" {
+				t.Errorf("cost differs from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
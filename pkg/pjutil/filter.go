@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -37,6 +38,31 @@ var RetestRequiredRe = regexp.MustCompile(`(?m)^/retest-required\s*$`)
 
 var OkToTestRe = regexp.MustCompile(`(?m)^/ok-to-test\s*$`)
 
+// TestInRe provides the regex for `/test <job name>|all in <duration>`,
+// which defers the start of the requested jobs.
+var TestInRe = regexp.MustCompile(`(?m)^/test\s.*\sin\s+(\S+)\s*$`)
+
+// MaxTestDelay is the longest delay `/test ... in <duration>` accepts.
+const MaxTestDelay = 24 * time.Hour
+
+// RequestedDelay returns how long the start of the jobs requested by a
+// `/test ... in <duration>` comment is deferred, or zero if the comment
+// does not defer them.
+func RequestedDelay(body string) (time.Duration, error) {
+	match := TestInRe.FindStringSubmatch(body)
+	if match == nil {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(match[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid delay %q, expected a duration like 30m or 2h", match[1])
+	}
+	if delay <= 0 || delay > MaxTestDelay {
+		return 0, fmt.Errorf("invalid delay %s, it must be positive and at most %s", delay, MaxTestDelay)
+	}
+	return delay, nil
+}
+
 // AvailablePresubmits returns 3 sets of presubmits:
 // 1. presubmits that can be run with '/test all' command.
 // 2. optional presubmits commands that can be run with their trigger, e.g. '/test job'
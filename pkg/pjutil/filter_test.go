@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/prow/pkg/github"
@@ -116,6 +117,64 @@ func TestTestAllFilter(t *testing.T) {
 	}
 }
 
+func TestRequestedDelay(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		body          string
+		expected      time.Duration
+		expectedError bool
+	}{
+		{
+			name: "test without delay",
+			body: "/test job",
+		},
+		{
+			name:     "test of one job in two hours",
+			body:     "/test job in 2h",
+			expected: 2 * time.Hour,
+		},
+		{
+			name:     "test of all jobs in thirty minutes",
+			body:     "/test all in 30m",
+			expected: 30 * time.Minute,
+		},
+		{
+			name: "retest is not deferred",
+			body: "/retest in 2h",
+		},
+		{
+			name:          "invalid delay",
+			body:          "/test job in soon",
+			expectedError: true,
+		},
+		{
+			name:          "negative delay",
+			body:          "/test job in -1h",
+			expectedError: true,
+		},
+		{
+			name:          "delay longer than a day",
+			body:          "/test job in 25h",
+			expectedError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			delay, err := RequestedDelay(testCase.body)
+			if err != nil && !testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && testCase.expectedError {
+				t.Fatal("expected an error, got none")
+			}
+			if delay != testCase.expected {
+				t.Errorf("expected delay %s, got %s", testCase.expected, delay)
+			}
+		})
+	}
+}
+
 func TestCommandFilter(t *testing.T) {
 	var testCases = []struct {
 		name         string
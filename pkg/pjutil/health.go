@@ -21,13 +21,27 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
+	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/version"
 )
 
 const healthPort = 8081
 
+// Paths of the health endpoints every component serves on its health port.
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+	// legacyReadinessPath is kept for probes that predate ReadinessPath.
+	legacyReadinessPath = "/healthz/ready"
+	ConfigHashPath      = "/confighash"
+	// VersionPath serves the version of the component.
+	VersionPath = "/version"
+)
+
 // Health keeps a request multiplexer for health liveness and readiness endpoints
 type Health struct {
 	healthMux *http.ServeMux
@@ -39,11 +53,12 @@ func NewHealth() *Health {
 	return NewHealthOnPort(healthPort)
 }
 
-// NewHealth creates a new health request multiplexer and starts serving the liveness endpoint
-// on the given port
+// NewHealth creates a new health request multiplexer and starts serving the liveness and
+// version endpoints on the given port
 func NewHealthOnPort(port int) *Health {
 	healthMux := http.NewServeMux()
-	healthMux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "OK") })
+	healthMux.HandleFunc(LivenessPath, func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "OK") })
+	healthMux.HandleFunc(VersionPath, func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, version.Version) })
 	server := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: healthMux}
 	interrupts.ListenAndServe(server, 5*time.Second)
 	return &Health{
@@ -55,7 +70,7 @@ type ReadinessCheck func() bool
 
 // ServeReady starts serving the readiness endpoint
 func (h *Health) ServeReady(readinessChecks ...ReadinessCheck) {
-	h.healthMux.HandleFunc("/healthz/ready", func(w http.ResponseWriter, r *http.Request) {
+	ready := func(w http.ResponseWriter, r *http.Request) {
 		for _, readinessCheck := range readinessChecks {
 			if !readinessCheck() {
 				w.WriteHeader(http.StatusServiceUnavailable)
@@ -64,5 +79,34 @@ func (h *Health) ServeReady(readinessChecks ...ReadinessCheck) {
 			}
 		}
 		fmt.Fprint(w, "OK")
-	})
+	}
+	h.healthMux.HandleFunc(ReadinessPath, ready)
+	h.healthMux.HandleFunc(legacyReadinessPath, ready)
+}
+
+// ServeConfigHash starts serving the hash of the current config, so that
+// operators can tell whether all components have loaded the same config.
+func (h *Health) ServeConfigHash(cfg config.Getter) {
+	h.healthMux.Handle(ConfigHashPath, configHashHandler(cfg))
+}
+
+func configHashHandler(cfg config.Getter) http.HandlerFunc {
+	// The config agent replaces the config on every reload, so the hash
+	// only needs to be recomputed when the config changes.
+	var lock sync.Mutex
+	var hashed *config.Config
+	var hash string
+	return func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if c := cfg(); c != hashed {
+			h, err := c.Hash()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to hash config: %v", err), http.StatusInternalServerError)
+				return
+			}
+			hashed, hash = c, h
+		}
+		fmt.Fprint(w, hash)
+	}
 }
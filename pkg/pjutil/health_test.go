@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestConfigHashHandler(t *testing.T) {
+	current := &config.Config{ProwConfig: config.ProwConfig{ProwJobNamespace: "a"}}
+	handler := configHashHandler(func() *config.Config { return current })
+	get := func() string {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, ConfigHashPath, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		return rr.Body.String()
+	}
+
+	first := get()
+	if len(first) != 64 {
+		t.Errorf("expected a hex encoded SHA-256 hash, got %q", first)
+	}
+	current = &config.Config{ProwConfig: config.ProwConfig{ProwJobNamespace: "a"}}
+	if hash := get(); hash != first {
+		t.Errorf("expected an equal config to have the same hash %q, got %q", first, hash)
+	}
+	current = &config.Config{ProwConfig: config.ProwConfig{ProwJobNamespace: "b"}}
+	if hash := get(); hash == first {
+		t.Errorf("expected a different config to change the hash %q", first)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+// JobOwnership is the ownership metadata a job declares through the
+// kube.OwnerAnnotation, kube.EscalationAnnotation and kube.DocsAnnotation
+// annotations.
+type JobOwnership struct {
+	// Owner is the team owning the job.
+	Owner string
+	// Escalation is where to escalate problems with the job. It is either a
+	// URL or a free-form contact like a chat channel or mailing list.
+	Escalation string
+	// Docs is a link to the documentation of the job.
+	Docs string
+}
+
+// OwnershipFor returns the ownership metadata declared by the annotations
+// of a job or ProwJob.
+func OwnershipFor(annotations map[string]string) JobOwnership {
+	return JobOwnership{
+		Owner:      strings.TrimSpace(annotations[kube.OwnerAnnotation]),
+		Escalation: strings.TrimSpace(annotations[kube.EscalationAnnotation]),
+		Docs:       strings.TrimSpace(annotations[kube.DocsAnnotation]),
+	}
+}
+
+// IsEmpty returns whether no ownership metadata is declared.
+func (o JobOwnership) IsEmpty() bool {
+	return o.Owner == "" && o.Escalation == "" && o.Docs == ""
+}
+
+// EscalationURL returns the escalation contact if it is a URL that can be
+// linked to, or an empty string otherwise.
+func (o JobOwnership) EscalationURL() string {
+	if err := validateHTTPURL(o.Escalation); err != nil {
+		return ""
+	}
+	return o.Escalation
+}
+
+// ValidateOwnershipAnnotations validates the ownership annotations that are
+// present in the given job annotations.
+func ValidateOwnershipAnnotations(annotations map[string]string) error {
+	var errs []error
+	for _, key := range []string{kube.OwnerAnnotation, kube.EscalationAnnotation, kube.DocsAnnotation} {
+		if value, ok := annotations[key]; ok && strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("annotation %s must not be empty", key))
+		}
+	}
+	o := OwnershipFor(annotations)
+	if strings.Contains(o.Escalation, "://") {
+		if err := validateHTTPURL(o.Escalation); err != nil {
+			errs = append(errs, fmt.Errorf("annotation %s: %w", kube.EscalationAnnotation, err))
+		}
+	}
+	if o.Docs != "" {
+		if err := validateHTTPURL(o.Docs); err != nil {
+			errs = append(errs, fmt.Errorf("annotation %s: %w", kube.DocsAnnotation, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http(s) URL", raw)
+	}
+	return nil
+}
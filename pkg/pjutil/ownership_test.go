@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOwnershipFor(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		annotations           map[string]string
+		expected              JobOwnership
+		expectedEscalationURL string
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "all annotations",
+			annotations: map[string]string{
+				"prow.k8s.io/owner":      " sig-testing ",
+				"prow.k8s.io/escalation": "https://issues.example.com/new",
+				"prow.k8s.io/docs":       "https://docs.example.com/job",
+				"unrelated":              "annotation",
+			},
+			expected: JobOwnership{
+				Owner:      "sig-testing",
+				Escalation: "https://issues.example.com/new",
+				Docs:       "https://docs.example.com/job",
+			},
+			expectedEscalationURL: "https://issues.example.com/new",
+		},
+		{
+			name:                  "escalation channel is not a URL",
+			annotations:           map[string]string{"prow.k8s.io/escalation": "#sig-testing"},
+			expected:              JobOwnership{Escalation: "#sig-testing"},
+			expectedEscalationURL: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := OwnershipFor(tc.annotations)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected ownership (-want +got):\n%s", diff)
+			}
+			if actual.IsEmpty() != (tc.annotations == nil) {
+				t.Errorf("expected IsEmpty to be %t", tc.annotations == nil)
+			}
+			if url := actual.EscalationURL(); url != tc.expectedEscalationURL {
+				t.Errorf("expected escalation URL %q, got %q", tc.expectedEscalationURL, url)
+			}
+		})
+	}
+}
+
+func TestValidateOwnershipAnnotations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expectedErr string
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "valid annotations",
+			annotations: map[string]string{
+				"prow.k8s.io/owner":      "sig-testing",
+				"prow.k8s.io/escalation": "sig-testing@example.com",
+				"prow.k8s.io/docs":       "https://docs.example.com/job",
+			},
+		},
+		{
+			name:        "empty owner",
+			annotations: map[string]string{"prow.k8s.io/owner": ""},
+			expectedErr: "annotation prow.k8s.io/owner must not be empty",
+		},
+		{
+			name:        "relative docs link",
+			annotations: map[string]string{"prow.k8s.io/docs": "/docs/job"},
+			expectedErr: `annotation prow.k8s.io/docs: "/docs/job" is not an absolute http(s) URL`,
+		},
+		{
+			name:        "non-http escalation URL",
+			annotations: map[string]string{"prow.k8s.io/escalation": "ftp://example.com/escalate"},
+			expectedErr: `annotation prow.k8s.io/escalation: "ftp://example.com/escalate" is not an absolute http(s) URL`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actualErr string
+			if err := ValidateOwnershipAnnotations(tc.annotations); err != nil {
+				actualErr = err.Error()
+			}
+			if actualErr != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, actualErr)
+			}
+		})
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/prow/pkg/gcsupload"
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/pod-utils/decorate"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 )
@@ -231,6 +232,7 @@ func specFromJobBase(jb config.JobBase) prowapi.ProwJobSpec {
 		Job:             jb.Name,
 		Agent:           prowapi.ProwJobAgent(jb.Agent),
 		Cluster:         jb.Cluster,
+		Arch:            jb.Arch,
 		Namespace:       namespace,
 		MaxConcurrency:  jb.MaxConcurrency,
 		ErrorOnEviction: jb.ErrorOnEviction,
@@ -269,6 +271,15 @@ func DecorateRefs(refs prowapi.Refs, jb config.JobBase) *prowapi.Refs {
 	if refs.BloblessFetch == nil {
 		refs.BloblessFetch = dc.BloblessFetch
 	}
+	if refs.CloneDepth == 0 && dc.CloneDepth != nil {
+		refs.CloneDepth = *dc.CloneDepth
+	}
+	if refs.Filter == "" && dc.Filter != nil {
+		refs.Filter = *dc.Filter
+	}
+	if refs.SingleBranch == nil {
+		refs.SingleBranch = dc.SingleBranch
+	}
 	return &refs
 }
 
@@ -288,6 +299,12 @@ func CompletePrimaryRefs(refs prowapi.Refs, jb config.JobBase) *prowapi.Refs {
 	if jb.SkipFetchHead {
 		refs.SkipFetchHead = jb.SkipFetchHead
 	}
+	if jb.Filter != "" {
+		refs.Filter = jb.Filter
+	}
+	if jb.SingleBranch {
+		refs.SingleBranch = boolPtr(jb.SingleBranch)
+	}
 	return DecorateRefs(refs, jb)
 }
 
@@ -355,6 +372,7 @@ func ProwJobFields(pj *prowapi.ProwJob) logrus.Fields {
 	fields["job"] = pj.Spec.Job
 	fields["type"] = pj.Spec.Type
 	fields["state"] = pj.Status.State
+	fields[logrusutil.TraceIDField] = pj.TraceID()
 	if len(pj.ObjectMeta.Labels[github.EventGUID]) > 0 {
 		fields[github.EventGUID] = pj.ObjectMeta.Labels[github.EventGUID]
 	}
@@ -280,6 +280,7 @@ func TestBatchSpec(t *testing.T) {
 }
 
 func TestCompletePrimaryRefs(t *testing.T) {
+	cloneDepth, filter := 1, "blob:none"
 	cases := []struct {
 		name     string
 		refs     prowapi.Refs
@@ -349,6 +350,58 @@ func TestCompletePrimaryRefs(t *testing.T) {
 				CloneDepth: 2,
 			},
 		},
+		{
+			name: "use partial clone values from job base",
+			jobBase: config.JobBase{
+				UtilityConfig: config.UtilityConfig{
+					Filter:       "tree:0",
+					SingleBranch: true,
+				},
+			},
+			expected: prowapi.Refs{
+				Filter:       "tree:0",
+				SingleBranch: boolPtr(true),
+			},
+		},
+		{
+			name: "use clone defaults of the decoration config",
+			jobBase: config.JobBase{
+				UtilityConfig: config.UtilityConfig{
+					DecorationConfig: &prowapi.DecorationConfig{
+						CloneDepth:   &cloneDepth,
+						Filter:       &filter,
+						SingleBranch: boolPtr(true),
+					},
+				},
+			},
+			expected: prowapi.Refs{
+				CloneDepth:   1,
+				Filter:       "blob:none",
+				SingleBranch: boolPtr(true),
+			},
+		},
+		{
+			name: "prefer values over the clone defaults of the decoration config",
+			refs: prowapi.Refs{
+				CloneDepth:   3,
+				SingleBranch: boolPtr(false),
+			},
+			jobBase: config.JobBase{
+				UtilityConfig: config.UtilityConfig{
+					Filter: "tree:0",
+					DecorationConfig: &prowapi.DecorationConfig{
+						CloneDepth:   &cloneDepth,
+						Filter:       &filter,
+						SingleBranch: boolPtr(true),
+					},
+				},
+			},
+			expected: prowapi.Refs{
+				CloneDepth:   3,
+				Filter:       "tree:0",
+				SingleBranch: boolPtr(false),
+			},
+		},
 	}
 
 	for _, tc := range cases {
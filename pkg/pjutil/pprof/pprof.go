@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/prow/pkg/flagutil"
 
 	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/logrusutil"
 )
 
 // Instrument implements the profiling options a user has asked for on the command line.
@@ -44,7 +45,8 @@ func Instrument(opts flagutil.InstrumentationOptions) {
 // Serve sets up a handler for pprof debug endpoints and starts a server for them asynchronously.
 // The contents of this function are identical to what the `net/http/pprof` package does on import for
 // the simple case where the default mux is to be used, but with a custom mux to ensure we don't serve
-// this data from an exposed port.
+// this data from an exposed port. The server also serves the log levels of the component on
+// /debug/log-levels, which can be changed there at runtime.
 func Serve(port int) {
 	pprofMux := http.NewServeMux()
 	pprofMux.HandleFunc("/debug/pprof/", pprof.Index)
@@ -53,6 +55,7 @@ func Serve(port int) {
 	pprofMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	pprofMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 	pprofMux.Handle("/debug/fgprof", fgprof.Handler())
+	pprofMux.Handle("/debug/log-levels", logrusutil.LevelsHandler())
 	server := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: pprofMux}
 	interrupts.ListenAndServe(server, 5*time.Second)
 }
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// RetestClass is what a retest of a pull request does with a presubmit job.
+type RetestClass int
+
+const (
+	// RetestRun reruns the job, because it did not run on the head of the
+	// pull request yet or its failure looks like a flake.
+	RetestRun RetestClass = iota
+	// RetestPending skips the job, because it is already running on the
+	// head of the pull request.
+	RetestPending
+	// RetestFailing skips the job, because it failed consistently.
+	RetestFailing
+)
+
+// ClassifyRetest decides what a retest of the pull request does with a job,
+// given the runs of the job and the number and head commit of the pull request.
+//
+// A failure of the job on the head of the pull request looks like a flake if
+// the job also passed there, or if it failed on at least minClusterSize other
+// pull requests since the given time: a cluster of failures across unrelated
+// changes points at the job rather than at the change. Jobs that errored or
+// were aborted, or whose runs are gone, are always rerun.
+func ClassifyRetest(runs []prowapi.ProwJob, number int, headSHA string, onlyFlakes bool, since time.Time, minClusterSize int) RetestClass {
+	var latest *prowapi.ProwJob
+	passed := false
+	failedElsewhere := sets.New[int]()
+	for i, pj := range runs {
+		if pj.Spec.Refs == nil || len(pj.Spec.Refs.Pulls) != 1 {
+			continue
+		}
+		pull := pj.Spec.Refs.Pulls[0]
+		if pull.Number != number {
+			if pj.Status.State == prowapi.FailureState && pj.Status.CompletionTime != nil && pj.Status.CompletionTime.After(since) {
+				failedElsewhere.Insert(pull.Number)
+			}
+			continue
+		}
+		if pull.SHA != headSHA {
+			continue
+		}
+		if !pj.Complete() {
+			return RetestPending
+		}
+		if pj.Status.State == prowapi.SuccessState {
+			passed = true
+		}
+		if latest == nil || pj.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = &runs[i]
+		}
+	}
+
+	if !onlyFlakes || latest == nil || latest.Status.State != prowapi.FailureState || passed {
+		return RetestRun
+	}
+	if failedElsewhere.Len() >= minClusterSize {
+		return RetestRun
+	}
+	return RetestFailing
+}
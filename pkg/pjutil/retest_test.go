@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/kube"
+)
+
+func retestRunOf(job string, pull int, sha string, state prowapi.ProwJobState, created time.Time) prowapi.ProwJob {
+	pj := prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              job + sha + created.String(),
+			Namespace:         "prowjobs",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				kube.ProwJobTypeLabel: string(prowapi.PresubmitJob),
+				kube.OrgLabel:         "org",
+				kube.RepoLabel:        "repo",
+			},
+		},
+		Spec: prowapi.ProwJobSpec{
+			Type: prowapi.PresubmitJob,
+			Job:  job,
+			Refs: &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: pull, SHA: sha}}},
+		},
+		Status: prowapi.ProwJobStatus{State: state},
+	}
+	if state != prowapi.PendingState && state != prowapi.TriggeredState {
+		completed := metav1.NewTime(created.Add(time.Minute))
+		pj.Status.CompletionTime = &completed
+	}
+	return pj
+}
+
+func TestClassifyRetest(t *testing.T) {
+	now := time.Now()
+	failedElsewhere := []prowapi.ProwJob{
+		retestRunOf("job", 2, "a", prowapi.FailureState, now.Add(-time.Hour)),
+		retestRunOf("job", 3, "b", prowapi.FailureState, now.Add(-time.Hour)),
+		retestRunOf("job", 3, "c", prowapi.FailureState, now.Add(-time.Hour)),
+	}
+	testCases := []struct {
+		name       string
+		runs       []prowapi.ProwJob
+		onlyFlakes bool
+		expected   RetestClass
+	}{
+		{
+			name:     "job that did not run is retested",
+			expected: RetestRun,
+		},
+		{
+			name:       "job that did not run on the head is retested",
+			runs:       []prowapi.ProwJob{retestRunOf("job", 1, "old", prowapi.FailureState, now)},
+			onlyFlakes: true,
+			expected:   RetestRun,
+		},
+		{
+			name: "job running on the head is not retested again",
+			runs: []prowapi.ProwJob{
+				retestRunOf("job", 1, "head", prowapi.FailureState, now.Add(-time.Hour)),
+				retestRunOf("job", 1, "head", prowapi.PendingState, now),
+			},
+			expected: RetestPending,
+		},
+		{
+			name:     "failure is retested without only flakes",
+			runs:     []prowapi.ProwJob{retestRunOf("job", 1, "head", prowapi.FailureState, now)},
+			expected: RetestRun,
+		},
+		{
+			name:       "failure only on this pull request is not retested",
+			runs:       []prowapi.ProwJob{retestRunOf("job", 1, "head", prowapi.FailureState, now)},
+			onlyFlakes: true,
+			expected:   RetestFailing,
+		},
+		{
+			name:       "error is retested",
+			runs:       []prowapi.ProwJob{retestRunOf("job", 1, "head", prowapi.ErrorState, now)},
+			onlyFlakes: true,
+			expected:   RetestRun,
+		},
+		{
+			name: "failure after a pass on the same commit is retested",
+			runs: []prowapi.ProwJob{
+				retestRunOf("job", 1, "head", prowapi.SuccessState, now.Add(-time.Hour)),
+				retestRunOf("job", 1, "head", prowapi.FailureState, now),
+			},
+			onlyFlakes: true,
+			expected:   RetestRun,
+		},
+		{
+			name:       "failure clustered with failures on too few other pull requests is not retested",
+			runs:       append([]prowapi.ProwJob{retestRunOf("job", 1, "head", prowapi.FailureState, now)}, failedElsewhere...),
+			onlyFlakes: true,
+			expected:   RetestFailing,
+		},
+		{
+			name: "failure clustered with failures on enough other pull requests is retested",
+			runs: append([]prowapi.ProwJob{
+				retestRunOf("job", 1, "head", prowapi.FailureState, now),
+				retestRunOf("job", 4, "d", prowapi.FailureState, now.Add(-time.Hour)),
+			}, failedElsewhere...),
+			onlyFlakes: true,
+			expected:   RetestRun,
+		},
+		{
+			name: "failures on other pull requests outside of the window are ignored",
+			runs: append([]prowapi.ProwJob{
+				retestRunOf("job", 1, "head", prowapi.FailureState, now),
+				retestRunOf("job", 4, "d", prowapi.FailureState, now.Add(-48*time.Hour)),
+			}, failedElsewhere...),
+			onlyFlakes: true,
+			expected:   RetestFailing,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := ClassifyRetest(tc.runs, 1, "head", tc.onlyFlakes, now.Add(-24*time.Hour), 3); actual != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
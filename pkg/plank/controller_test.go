@@ -320,6 +320,7 @@ func TestSyncTriggeredJobs(t *testing.T) {
 		PJ             prowapi.ProwJob
 		PendingJobs    map[string]int
 		MaxConcurrency int
+		LoadShedding   *config.LoadShedding
 		Pods           map[string][]v1.Pod
 		PodErr         error
 
@@ -332,6 +333,23 @@ func TestSyncTriggeredJobs(t *testing.T) {
 		ExpectedBuildID     string
 		ExpectError         bool
 		ExpectedPendingTime *metav1.Time
+		// ExpectedDelayAnnotation is true if the job was delayed by load shedding.
+		ExpectedDelayAnnotation bool
+	}
+
+	saturatedPods := map[string][]v1.Pod{
+		"default": {
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "waiting",
+					Namespace: "pods",
+					Labels:    map[string]string{kube.CreatedByProw: "true"},
+				},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+				},
+			},
+		},
 	}
 
 	testcases := []testCase{
@@ -359,6 +377,134 @@ func TestSyncTriggeredJobs(t *testing.T) {
 			ExpectedURL:         "blabla/pending",
 			ExpectedBuildID:     "0987654321",
 		},
+		{
+			Name: "deferred job is not started before run_after",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "blabla",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job:      "boop",
+					Type:     prowapi.PeriodicJob,
+					PodSpec:  &v1.PodSpec{Containers: []v1.Container{{Name: "test-name", Env: []v1.EnvVar{}}}},
+					RunAfter: &metav1.Time{Time: fakeClock.Now().Add(time.Hour)},
+				},
+				Status: prowapi.ProwJobStatus{
+					State: prowapi.TriggeredState,
+				},
+			},
+			Pods:            map[string][]v1.Pod{"default": {}},
+			ExpectedState:   prowapi.TriggeredState,
+			ExpectedNumPods: map[string]int{"default": 0},
+		},
+		{
+			Name: "deferred job is started after run_after",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "blabla",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job:      "boop",
+					Type:     prowapi.PeriodicJob,
+					PodSpec:  &v1.PodSpec{Containers: []v1.Container{{Name: "test-name", Env: []v1.EnvVar{}}}},
+					RunAfter: &metav1.Time{Time: fakeClock.Now().Add(-time.Minute)},
+				},
+				Status: prowapi.ProwJobStatus{
+					State: prowapi.TriggeredState,
+				},
+			},
+			Pods:                map[string][]v1.Pod{"default": {}},
+			ExpectedState:       prowapi.PendingState,
+			ExpectedPendingTime: &pendingTime,
+			ExpectedPodHasName:  true,
+			ExpectedNumPods:     map[string]int{"default": 1},
+			ExpectedURL:         "blabla/pending",
+			ExpectedBuildID:     "0987654321",
+		},
+		{
+			Name: "low priority job is delayed while build cluster is saturated",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "blabla",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job:     "boop",
+					Type:    prowapi.PeriodicJob,
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test-name", Env: []v1.EnvVar{}}}},
+				},
+				Status: prowapi.ProwJobStatus{
+					State: prowapi.TriggeredState,
+				},
+			},
+			LoadShedding: &config.LoadShedding{
+				MaxPendingPods:      map[string]int{"*": 1},
+				LowPriorityJobTypes: []prowapi.ProwJobType{prowapi.PeriodicJob},
+			},
+			Pods:                    saturatedPods,
+			ExpectedState:           prowapi.TriggeredState,
+			ExpectedNumPods:         map[string]int{"default": 1},
+			ExpectedDelayAnnotation: true,
+		},
+		{
+			Name: "high priority job is started while build cluster is saturated",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "blabla",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job:     "boop",
+					Type:    prowapi.PresubmitJob,
+					Refs:    &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: 1}}},
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test-name", Env: []v1.EnvVar{}}}},
+				},
+				Status: prowapi.ProwJobStatus{
+					State: prowapi.TriggeredState,
+				},
+			},
+			LoadShedding: &config.LoadShedding{
+				MaxPendingPods:      map[string]int{"*": 1},
+				LowPriorityJobTypes: []prowapi.ProwJobType{prowapi.PeriodicJob},
+			},
+			Pods:                saturatedPods,
+			ExpectedState:       prowapi.PendingState,
+			ExpectedPendingTime: &pendingTime,
+			ExpectedPodHasName:  true,
+			ExpectedNumPods:     map[string]int{"default": 2},
+			ExpectedURL:         "blabla/pending",
+			ExpectedBuildID:     "0987654321",
+		},
+		{
+			Name: "delayed job is started once the build cluster has capacity",
+			PJ: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "blabla",
+					Namespace:   "prowjobs",
+					Annotations: map[string]string{kube.DelayedReasonAnnotation: "saturated"},
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job:     "boop",
+					Type:    prowapi.PeriodicJob,
+					PodSpec: &v1.PodSpec{Containers: []v1.Container{{Name: "test-name", Env: []v1.EnvVar{}}}},
+				},
+				Status: prowapi.ProwJobStatus{
+					State: prowapi.TriggeredState,
+				},
+			},
+			LoadShedding: &config.LoadShedding{
+				MaxPendingPods:      map[string]int{"*": 1},
+				LowPriorityJobTypes: []prowapi.ProwJobType{prowapi.PeriodicJob},
+			},
+			ExpectedState:       prowapi.PendingState,
+			ExpectedPendingTime: &pendingTime,
+			ExpectedPodHasName:  true,
+			ExpectedNumPods:     map[string]int{"default": 1},
+			ExpectedURL:         "blabla/pending",
+			ExpectedBuildID:     "0987654321",
+		},
 		{
 			Name: "pod with a max concurrency of 1",
 			PJ: prowapi.ProwJob{
@@ -717,7 +863,11 @@ func TestSyncTriggeredJobs(t *testing.T) {
 			tc.PJ.Spec.Agent = prowapi.KubernetesAgent
 
 			ctx := context.Background()
-			config := newFakeConfigAgent(t, tc.MaxConcurrency, nil).Config
+			fca := newFakeConfigAgent(t, tc.MaxConcurrency, nil)
+			if tc.LoadShedding != nil {
+				fca.c.Plank.LoadShedding = tc.LoadShedding
+			}
+			config := fca.Config
 			fakeMgr, err := testutil.NewFakeManager(
 				ctx,
 				[]runtime.Object{&tc.PJ},
@@ -733,7 +883,7 @@ func TestSyncTriggeredJobs(t *testing.T) {
 
 			buildClients := map[string]buildClient{}
 			for alias, pods := range tc.Pods {
-				builder := fakectrlruntimeclient.NewClientBuilder()
+				builder := fakectrlruntimeclient.NewClientBuilder().WithIndex(&v1.Pod{}, podPhaseIndexName, podPhaseIndexer)
 				for i := range pods {
 					builder.WithRuntimeObjects(&pods[i])
 				}
@@ -748,7 +898,7 @@ func TestSyncTriggeredJobs(t *testing.T) {
 			if _, exists := buildClients[prowapi.DefaultClusterAlias]; !exists {
 				buildClients[prowapi.DefaultClusterAlias] = buildClient{
 					Client: &clientWrapper{
-						Client:      fakectrlruntimeclient.NewClientBuilder().Build(),
+						Client:      fakectrlruntimeclient.NewClientBuilder().WithIndex(&v1.Pod{}, podPhaseIndexName, podPhaseIndexer).Build(),
 						createError: tc.PodErr,
 					},
 				}
@@ -831,6 +981,9 @@ func TestSyncTriggeredJobs(t *testing.T) {
 			if actual.Complete() != tc.ExpectedComplete {
 				t.Error("got wrong completion")
 			}
+			if _, delayed := actual.Annotations[kube.DelayedReasonAnnotation]; delayed != tc.ExpectedDelayAnnotation {
+				t.Errorf("expected delay annotation %t, got %t", tc.ExpectedDelayAnnotation, delayed)
+			}
 		})
 	}
 }
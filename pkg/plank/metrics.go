@@ -0,0 +1,47 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	buildClusterPendingPods = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "plank_build_cluster_pending_pods",
+		Help: "Number of Prow pods in the pending phase per build cluster, as last observed by load shedding.",
+	}, []string{"cluster"})
+	buildClusterSaturation = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "plank_build_cluster_saturation",
+		Help: "Ratio of pending Prow pods to the configured load shedding limit per build cluster.",
+	}, []string{"cluster"})
+	loadSheddingDelayedJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "plank_load_shedding_delayed_jobs_total",
+		Help: "Number of times the start of a ProwJob was delayed by load shedding.",
+	}, []string{"cluster", "type"})
+	prepulledImages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "plank_prepulled_images",
+		Help: "Number of images of upcoming jobs pre-pulled on the nodes of each build cluster.",
+	}, []string{"cluster"})
+)
+
+func init() {
+	prometheus.MustRegister(buildClusterPendingPods)
+	prometheus.MustRegister(buildClusterSaturation)
+	prometheus.MustRegister(loadSheddingDelayedJobs)
+	prometheus.MustRegister(prepulledImages)
+}
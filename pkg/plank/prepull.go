@@ -0,0 +1,255 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	cron "gopkg.in/robfig/cron.v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/pjutil"
+)
+
+const (
+	// prepullerName is the name of the DaemonSet that pulls the images of
+	// upcoming jobs on the nodes of a build cluster.
+	prepullerName = "prow-image-prepuller"
+	// prepullerLabel identifies the pods of the DaemonSet. It is not the
+	// created-by-prow label, so that sinker leaves the pods alone.
+	prepullerLabel = "prow.k8s.io/image-prepuller"
+)
+
+// syncImagePrepull periodically updates the DaemonSets that pull the
+// images of upcoming jobs on build cluster nodes.
+func (r *reconciler) syncImagePrepull(interval time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		// The images last applied per cluster, the DaemonSets are only
+		// updated when they change.
+		applied := map[string]string{}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				if err := r.prepullImages(ctx, applied, r.clock.Now()); err != nil {
+					r.log.WithError(err).Error("Failed to pre-pull images of upcoming jobs.")
+				}
+			}
+		}
+	}
+}
+
+// prepullImages updates the DaemonSet of every configured build cluster to
+// pull the images of its upcoming jobs.
+func (r *reconciler) prepullImages(ctx context.Context, applied map[string]string, now time.Time) error {
+	cfg := r.config()
+	prepull := cfg.Plank.ImagePrepull
+	if prepull == nil {
+		return nil
+	}
+	pjs := &prowv1.ProwJobList{}
+	if err := r.pjClient.List(ctx, pjs, ctrlruntimeclient.InNamespace(cfg.ProwJobNamespace)); err != nil {
+		return fmt.Errorf("failed to list prowjobs: %w", err)
+	}
+	images := upcomingImages(cfg, pjs.Items, now)
+	for cluster, client := range r.buildClients {
+		if !prepull.Matches(cluster) {
+			continue
+		}
+		log := r.log.WithField("cluster", cluster)
+		dc := cfg.Plank.GuessDefaultDecorationConfig("", cluster)
+		if dc == nil || dc.UtilityImages == nil || dc.UtilityImages.Entrypoint == "" {
+			log.Warn("Not pre-pulling images, no entrypoint image is configured for the build cluster.")
+			continue
+		}
+		clusterImages := sets.List(images[cluster])
+		prepulledImages.WithLabelValues(cluster).Set(float64(len(clusterImages)))
+		key := strings.Join(clusterImages, ",")
+		if last, ok := applied[cluster]; ok && last == key {
+			continue
+		}
+		if len(clusterImages) == 0 {
+			ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Namespace: cfg.PodNamespace, Name: prepullerName}}
+			if err := client.Delete(ctx, ds); err != nil && !kerrors.IsNotFound(err) {
+				log.WithError(err).Error("Failed to delete the image pre-puller.")
+				continue
+			}
+		} else {
+			ds := prepullerDaemonSet(cfg.PodNamespace, dc.UtilityImages.Entrypoint, clusterImages, prepull)
+			if err := applyDaemonSet(ctx, client, ds); err != nil {
+				log.WithError(err).Error("Failed to update the image pre-puller.")
+				continue
+			}
+		}
+		log.WithField("images", clusterImages).Info("Updated the images pre-pulled on the build cluster.")
+		applied[cluster] = key
+	}
+	return nil
+}
+
+// applyDaemonSet creates the DaemonSet or replaces the spec of an existing
+// one.
+func applyDaemonSet(ctx context.Context, client ctrlruntimeclient.Client, ds *appsv1.DaemonSet) error {
+	err := client.Patch(ctx, ds.DeepCopy(), ctrlruntimeclient.MergeFrom(&appsv1.DaemonSet{}))
+	if kerrors.IsNotFound(err) {
+		err = client.Create(ctx, ds)
+	}
+	return err
+}
+
+// upcomingImages returns the images of the kubernetes jobs per build cluster
+// that are likely to start soon: periodics that are due within the lead time
+// and jobs that ran in merge batches within the batch window.
+func upcomingImages(cfg *config.Config, pjs []prowv1.ProwJob, now time.Time) map[string]sets.Set[string] {
+	prepull := cfg.Plank.ImagePrepull
+	images := map[string]sets.Set[string]{}
+	add := func(cluster string, spec *corev1.PodSpec) {
+		if spec == nil {
+			return
+		}
+		if images[cluster] == nil {
+			images[cluster] = sets.New[string]()
+		}
+		for _, c := range append(spec.InitContainers, spec.Containers...) {
+			if c.Image != "" {
+				images[cluster].Insert(c.Image)
+			}
+		}
+	}
+
+	latest := pjutil.GetLatestProwJobs(pjs, prowv1.PeriodicJob)
+	for _, p := range cfg.AllPeriodics() {
+		if p.Agent != string(prowv1.KubernetesAgent) {
+			continue
+		}
+		previous, found := latest[p.Name]
+		if next, ok := nextPeriodicRun(p, previous, found, now); ok && next.Sub(now) <= prepull.GetLeadTime() {
+			add(clusterAlias(p.Cluster), p.Spec)
+		}
+	}
+
+	for _, pj := range pjs {
+		if pj.Spec.Type != prowv1.BatchJob || pj.Spec.Agent != prowv1.KubernetesAgent {
+			continue
+		}
+		if now.Sub(pj.Status.StartTime.Time) <= prepull.GetBatchWindow() {
+			add(pj.ClusterAlias(), pj.Spec.PodSpec)
+		}
+	}
+	return images
+}
+
+// nextPeriodicRun returns when the periodic is next due, mirroring how
+// horologium triggers periodics. It returns false if the periodic only runs
+// once its running job completed.
+func nextPeriodicRun(p config.Periodic, previous prowv1.ProwJob, found bool, now time.Time) (time.Time, bool) {
+	switch {
+	case p.Cron != "":
+		schedule, err := cron.Parse("TZ=UTC " + p.Cron)
+		if err != nil {
+			logrus.WithError(err).WithField("job", p.Name).Warn("Failed to parse the cron of the periodic.")
+			return time.Time{}, false
+		}
+		return schedule.Next(now), true
+	case !found:
+		return now, true
+	case p.MinimumInterval != "":
+		if !previous.Complete() {
+			return time.Time{}, false
+		}
+		return previous.Status.CompletionTime.Add(p.GetMinimumInterval()), true
+	default:
+		return previous.Status.StartTime.Add(p.GetInterval()), true
+	}
+}
+
+// clusterAlias returns the alias of the build cluster of a job.
+func clusterAlias(cluster string) string {
+	if cluster == "" {
+		return prowv1.DefaultClusterAlias
+	}
+	return cluster
+}
+
+// prepullerDaemonSet returns a DaemonSet whose pods pull the images on every
+// node. Each image is pulled by an init container that runs the entrypoint
+// binary in copy mode, which exits right away without depending on the
+// contents of the image.
+func prepullerDaemonSet(namespace, entrypointImage string, images []string, prepull *config.ImagePrepull) *appsv1.DaemonSet {
+	labels := map[string]string{prepullerLabel: "true"}
+	tools := corev1.VolumeMount{Name: "tools", MountPath: "/tools"}
+	initContainers := []corev1.Container{{
+		Name:         "place-entrypoint",
+		Image:        entrypointImage,
+		Args:         []string{"--copy-mode-only"},
+		VolumeMounts: []corev1.VolumeMount{tools},
+	}}
+	for i, image := range images {
+		initContainers = append(initContainers, corev1.Container{
+			Name:         fmt.Sprintf("prepull-%d", i),
+			Image:        image,
+			Command:      []string{"/tools/entrypoint"},
+			Args:         []string{"--copy-mode-only", "--copy-destination=/tools/prepulled"},
+			VolumeMounts: []corev1.VolumeMount{tools},
+		})
+	}
+	nodeSelector := map[string]string{corev1.LabelOSStable: string(corev1.Linux)}
+	for k, v := range prepull.NodeSelector {
+		nodeSelector[k] = v
+	}
+	return &appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      prepullerName,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					InitContainers: initContainers,
+					Containers: []corev1.Container{{
+						Name:  "pause",
+						Image: prepull.GetPauseImage(),
+					}},
+					Volumes: []corev1.Volume{{
+						Name:         tools.Name,
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					}},
+					NodeSelector: nodeSelector,
+					Tolerations:  prepull.Tolerations,
+				},
+			},
+		},
+	}
+}
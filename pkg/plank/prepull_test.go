@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plank
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func periodic(name, cluster, image string, configure func(*config.Periodic)) config.Periodic {
+	p := config.Periodic{JobBase: config.JobBase{
+		Name:    name,
+		Agent:   string(prowv1.KubernetesAgent),
+		Cluster: cluster,
+		Spec:    &corev1.PodSpec{Containers: []corev1.Container{{Image: image}}},
+	}}
+	configure(&p)
+	return p
+}
+
+func TestUpcomingImages(t *testing.T) {
+	now := time.Date(2024, 1, 1, 11, 55, 0, 0, time.UTC)
+	cfg := &config.Config{
+		ProwConfig: config.ProwConfig{Plank: config.Plank{ImagePrepull: &config.ImagePrepull{
+			Clusters:    []string{"*"},
+			LeadTime:    &metav1.Duration{Duration: 10 * time.Minute},
+			BatchWindow: &metav1.Duration{Duration: time.Hour},
+		}}},
+		JobConfig: config.JobConfig{Periodics: []config.Periodic{
+			periodic("cron-due", "default", "cron-due", func(p *config.Periodic) { p.Cron = "0 12 * * *" }),
+			periodic("cron-later", "default", "cron-later", func(p *config.Periodic) { p.Cron = "0 13 * * *" }),
+			periodic("interval-due", "other", "interval-due", func(p *config.Periodic) {
+				p.Interval = "1h"
+				p.SetInterval(time.Hour)
+			}),
+			periodic("interval-later", "default", "interval-later", func(p *config.Periodic) {
+				p.Interval = "1h"
+				p.SetInterval(time.Hour)
+			}),
+			periodic("interval-never-ran", "", "interval-never-ran", func(p *config.Periodic) {
+				p.Interval = "24h"
+				p.SetInterval(24 * time.Hour)
+			}),
+			periodic("minimum-interval-running", "default", "minimum-interval-running", func(p *config.Periodic) {
+				p.MinimumInterval = "1m"
+				p.SetMinimumInterval(time.Minute)
+			}),
+			periodic("other-agent", "default", "other-agent", func(p *config.Periodic) {
+				p.Agent = string(prowv1.TektonAgent)
+				p.Interval = "1h"
+				p.SetInterval(time.Hour)
+			}),
+		}},
+	}
+	job := func(name string, jobType prowv1.ProwJobType, cluster, image string, started time.Time, complete bool) prowv1.ProwJob {
+		pj := prowv1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name + started.String()},
+			Spec: prowv1.ProwJobSpec{
+				Job:     name,
+				Type:    jobType,
+				Agent:   prowv1.KubernetesAgent,
+				Cluster: cluster,
+				PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{Image: image}}},
+			},
+			Status: prowv1.ProwJobStatus{StartTime: metav1.NewTime(started)},
+		}
+		if complete {
+			pj.SetComplete()
+		}
+		return pj
+	}
+	pjs := []prowv1.ProwJob{
+		job("interval-due", prowv1.PeriodicJob, "other", "", now.Add(-58*time.Minute), true),
+		job("interval-later", prowv1.PeriodicJob, "default", "", now.Add(-10*time.Minute), true),
+		job("minimum-interval-running", prowv1.PeriodicJob, "default", "", now.Add(-2*time.Hour), false),
+		job("other-agent", prowv1.PeriodicJob, "default", "", now.Add(-2*time.Hour), true),
+		job("recent-batch", prowv1.BatchJob, "default", "batch:recent", now.Add(-30*time.Minute), true),
+		job("old-batch", prowv1.BatchJob, "default", "batch:old", now.Add(-2*time.Hour), true),
+		job("presubmit", prowv1.PresubmitJob, "default", "presubmit", now.Add(-time.Minute), false),
+	}
+
+	expected := map[string]sets.Set[string]{
+		"default": sets.New("cron-due", "interval-never-ran", "batch:recent"),
+		"other":   sets.New("interval-due"),
+	}
+	if diff := cmp.Diff(expected, upcomingImages(cfg, pjs, now)); diff != "" {
+		t.Errorf("images differ from expected (-want +got):\n%s", diff)
+	}
+}
+
+func TestPrepullImages(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	prepull := &config.ImagePrepull{Clusters: []string{"default"}}
+	cfg := &config.Config{ProwConfig: config.ProwConfig{
+		ProwJobNamespace: "prowjobs",
+		PodNamespace:     "pods",
+		Plank: config.Plank{
+			ImagePrepull: prepull,
+			DefaultDecorationConfigs: []*config.DefaultDecorationConfigEntry{{
+				Config: &prowv1.DecorationConfig{UtilityImages: &prowv1.UtilityImages{Entrypoint: "entrypoint"}},
+			}},
+		},
+	}}
+	batch := &prowv1.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prowjobs", Name: "batch"},
+		Spec: prowv1.ProwJobSpec{
+			Type:    prowv1.BatchJob,
+			Agent:   prowv1.KubernetesAgent,
+			PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{Image: "test-image"}}},
+		},
+		Status: prowv1.ProwJobStatus{StartTime: metav1.NewTime(now.Add(-time.Minute))},
+	}
+	pjClient := fakectrlruntimeclient.NewClientBuilder().WithObjects(batch).Build()
+	defaultClient := fakectrlruntimeclient.NewClientBuilder().Build()
+	otherClient := fakectrlruntimeclient.NewClientBuilder().Build()
+	r := &reconciler{
+		pjClient: pjClient,
+		buildClients: map[string]buildClient{
+			"default": {Client: defaultClient},
+			"other":   {Client: otherClient},
+		},
+		log:    logrus.NewEntry(logrus.New()),
+		config: func() *config.Config { return cfg },
+	}
+	applied := map[string]string{}
+	if err := r.prepullImages(ctx, applied, now); err != nil {
+		t.Fatalf("prepullImages: %v", err)
+	}
+
+	key := types.NamespacedName{Namespace: "pods", Name: prepullerName}
+	pulledImages := func() []string {
+		ds := &appsv1.DaemonSet{}
+		if err := defaultClient.Get(ctx, key, ds); err != nil {
+			t.Fatalf("failed to get the image pre-puller: %v", err)
+		}
+		var images []string
+		for _, c := range ds.Spec.Template.Spec.InitContainers {
+			images = append(images, c.Image)
+		}
+		return images
+	}
+	if diff := cmp.Diff([]string{"entrypoint", "test-image"}, pulledImages()); diff != "" {
+		t.Errorf("pulled images differ from expected (-want +got):\n%s", diff)
+	}
+	if err := otherClient.Get(ctx, key, &appsv1.DaemonSet{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected no image pre-puller on a cluster that is not configured, got %v", err)
+	}
+
+	// The DaemonSet is updated when another job needs to be warmed.
+	other := batch.DeepCopy()
+	other.Name = "other-batch"
+	other.ResourceVersion = ""
+	other.Spec.PodSpec.Containers[0].Image = "other-image"
+	if err := pjClient.Create(ctx, other); err != nil {
+		t.Fatalf("failed to create prowjob: %v", err)
+	}
+	if err := r.prepullImages(ctx, applied, now); err != nil {
+		t.Fatalf("prepullImages: %v", err)
+	}
+	if diff := cmp.Diff([]string{"entrypoint", "other-image", "test-image"}, pulledImages()); diff != "" {
+		t.Errorf("pulled images differ from expected (-want +got):\n%s", diff)
+	}
+
+	// Once the batch is old, the images are no longer pulled.
+	prepull.BatchWindow = &metav1.Duration{}
+	if err := r.prepullImages(ctx, applied, now); err != nil {
+		t.Fatalf("prepullImages: %v", err)
+	}
+	if err := defaultClient.Get(ctx, key, &appsv1.DaemonSet{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the image pre-puller to be deleted, got %v", err)
+	}
+}
@@ -144,6 +144,9 @@ func add(
 		blder = blder.WatchesRawSource(
 			source.Kind(buildCluster.GetCache(), &corev1.Pod{}),
 			podEventRequestMapper(cfg().ProwJobNamespace))
+		if err := buildCluster.GetFieldIndexer().IndexField(ctx, &corev1.Pod{}, podPhaseIndexName, podPhaseIndexer); err != nil {
+			return fmt.Errorf("failed to add pod phase indexer for build cluster %q: %w", buildClusterName, err)
+		}
 		bc := buildClient{
 			Client: buildCluster.GetClient()}
 		if restConfig, ok := knownClusters[buildClusterName]; ok {
@@ -168,6 +171,10 @@ func add(
 		return fmt.Errorf("failed to add cluster status runnable to manager: %w", err)
 	}
 
+	if err := mgr.Add(manager.RunnableFunc(r.syncImagePrepull(time.Minute))); err != nil {
+		return fmt.Errorf("failed to add image pre-pull runnable to manager: %w", err)
+	}
+
 	return nil
 }
 
@@ -549,6 +556,9 @@ func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (*r
 			pj.SetComplete()
 			pj.Status.State = prowv1.FailureState
 			pj.Status.Description = "Job failed."
+			if excerpt := decorate.FailureExcerpt(pod); excerpt != "" {
+				pj.Status.Description = "Job failed: " + excerpt
+			}
 
 		case corev1.PodPending:
 			var requeueAfter time.Duration
@@ -690,6 +700,14 @@ func (r *reconciler) syncTriggeredJob(ctx context.Context, pj *prowv1.ProwJob) (
 		id = getPodBuildID(pod)
 		pn = pod.ObjectMeta.Name
 	} else {
+		// Do not start deferred jobs before their time has come.
+		if now := r.clock.Now(); pj.Deferred(now) {
+			return r.deferTriggeredJob(ctx, pj, now)
+		}
+		// Do not add low priority jobs to a saturated build cluster.
+		if result, err := r.shedLoad(ctx, pj); err != nil || result != nil {
+			return result, err
+		}
 		// Do not start more jobs than specified and check again later.
 		canExecuteConcurrently, err := r.canExecuteConcurrently(ctx, pj)
 		if err != nil {
@@ -719,6 +737,7 @@ func (r *reconciler) syncTriggeredJob(ctx context.Context, pj *prowv1.ProwJob) (
 		pj.Status.State = prowv1.PendingState
 		pj.Status.PodName = pn
 		pj.Status.Description = "Job triggered."
+		delete(pj.Annotations, kube.DelayedReasonAnnotation)
 		pj.Status.URL, err = pjutil.JobURL(r.config().Plank, *pj, r.log)
 		if err != nil {
 			r.log.WithFields(pjutil.ProwJobFields(pj)).WithError(err).Warn("failed to get jobURL")
@@ -832,6 +851,9 @@ func (r *reconciler) startPod(ctx context.Context, pj *prowv1.ProwJob) (string,
 	if err != nil {
 		return "", "", err
 	}
+	if pod.Labels, pod.Annotations, err = r.config().Plank.LabelPropagation.ForPod(pod.Labels, pod.Annotations); err != nil {
+		return "", "", TerminalError(err)
+	}
 	pod.Namespace = r.config().PodNamespace
 	// Add prow version as a label for better debugging prowjobs.
 	pod.ObjectMeta.Labels[kube.PlankVersionLabel] = version.Version
@@ -868,6 +890,75 @@ func (r *reconciler) getBuildID(name string) (string, error) {
 	return pjutil.GetBuildID(name, r.totURL)
 }
 
+// deferTriggeredJob records that the job is deferred and requeues it for
+// when it may be started.
+func (r *reconciler) deferTriggeredJob(ctx context.Context, pj *prowv1.ProwJob, now time.Time) (*reconcile.Result, error) {
+	description := fmt.Sprintf("Job deferred until %s.", pj.Spec.RunAfter.UTC().Format(time.RFC3339))
+	if pj.Status.Description != description {
+		prevPJ := pj.DeepCopy()
+		pj.Status.Description = description
+		if err := r.pjClient.Patch(ctx, pj.DeepCopy(), ctrlruntimeclient.MergeFrom(prevPJ)); err != nil {
+			return nil, fmt.Errorf("patch prowjob: %w", err)
+		}
+		r.log.WithFields(pjutil.ProwJobFields(pj)).WithField("run-after", pj.Spec.RunAfter.Time).Info("Deferring job.")
+	}
+	return &reconcile.Result{RequeueAfter: pj.Spec.RunAfter.Sub(now)}, nil
+}
+
+// shedLoad delays the start of low priority jobs while their build cluster
+// has too many pending pods. It returns a non-nil result if the job was
+// delayed.
+func (r *reconciler) shedLoad(ctx context.Context, pj *prowv1.ProwJob) (*reconcile.Result, error) {
+	loadShedding := r.config().Plank.LoadShedding
+	if loadShedding == nil {
+		return nil, nil
+	}
+	cluster := pj.ClusterAlias()
+	limit := loadShedding.MaxPendingPodsFor(cluster)
+	if limit <= 0 || !loadShedding.IsLowPriority(pj.Spec.Type) {
+		return nil, nil
+	}
+
+	buildClient, buildClientExists := r.buildClients[cluster]
+	if !buildClientExists {
+		return nil, TerminalError(fmt.Errorf("no build client found for cluster %q", cluster))
+	}
+	// The pod phase index of the build cluster cache spares listing the
+	// pods that are already running or done.
+	pods := &corev1.PodList{}
+	if err := buildClient.List(ctx, pods,
+		ctrlruntimeclient.InNamespace(r.config().PodNamespace),
+		ctrlruntimeclient.MatchingLabels{kube.CreatedByProw: "true"},
+		ctrlruntimeclient.MatchingFields{podPhaseIndexName: string(corev1.PodPending)},
+	); err != nil {
+		return nil, fmt.Errorf("failed to list pending pods in cluster %q: %w", cluster, err)
+	}
+	pending := len(pods.Items)
+	buildClusterPendingPods.WithLabelValues(cluster).Set(float64(pending))
+	buildClusterSaturation.WithLabelValues(cluster).Set(float64(pending) / float64(limit))
+	if pending < limit {
+		return nil, nil
+	}
+
+	// The reason must not change while the job waits, or every retry would
+	// patch the ProwJob.
+	reason := fmt.Sprintf("Build cluster %q reached its limit of %d pending pods.", cluster, limit)
+	if pj.Annotations[kube.DelayedReasonAnnotation] != reason {
+		prevPJ := pj.DeepCopy()
+		if pj.Annotations == nil {
+			pj.Annotations = map[string]string{}
+		}
+		pj.Annotations[kube.DelayedReasonAnnotation] = reason
+		pj.Status.Description = "Job delayed: build cluster is saturated."
+		if err := r.pjClient.Patch(ctx, pj.DeepCopy(), ctrlruntimeclient.MergeFrom(prevPJ)); err != nil {
+			return nil, fmt.Errorf("patch prowjob: %w", err)
+		}
+	}
+	loadSheddingDelayedJobs.WithLabelValues(cluster, string(pj.Spec.Type)).Inc()
+	r.log.WithFields(pjutil.ProwJobFields(pj)).WithField("pending-pods", pending).Info("Delaying job, build cluster is saturated.")
+	return &reconcile.Result{RequeueAfter: loadShedding.GetRetryInterval()}, nil
+}
+
 // canExecuteConcurrently determines if the cocurrency settings allow our job
 // to be started. We start jobs with a limited concurrency in order, oldest
 // first. This allows us to get away without any global locking by just looking
@@ -903,7 +994,7 @@ func (r *reconciler) canExecuteConcurrentlyPerJob(ctx context.Context, pj *prowv
 	}
 	r.log.Infof("got %d not completed with same name", len(pjs.Items))
 
-	pendingOrOlderMatchingPJs := countPendingOrOlderTriggeredMatchingPJs(*pj, pjs.Items)
+	pendingOrOlderMatchingPJs := countPendingOrOlderTriggeredMatchingPJs(*pj, pjs.Items, r.clock.Now())
 	if pendingOrOlderMatchingPJs >= pj.Spec.MaxConcurrency {
 		r.log.WithFields(pjutil.ProwJobFields(pj)).
 			Debugf("Not starting another instance of %s, have %d instances that are pending or older, %d is the limit",
@@ -937,7 +1028,7 @@ func (r *reconciler) canExecuteConcurrentlyPerQueue(ctx context.Context, pj *pro
 	}
 	r.log.Infof("got %d not completed within queue %s", len(pjs.Items), queueName)
 
-	pendingOrOlderMatchingPJs := countPendingOrOlderTriggeredMatchingPJs(*pj, pjs.Items)
+	pendingOrOlderMatchingPJs := countPendingOrOlderTriggeredMatchingPJs(*pj, pjs.Items, r.clock.Now())
 	if pendingOrOlderMatchingPJs >= queueConcurrency {
 		r.log.WithFields(pjutil.ProwJobFields(pj)).
 			Debugf("Not starting another instance of %s, have %d instances in queue %s that are pending or older, %d is the limit",
@@ -1000,6 +1091,9 @@ const (
 	// that are currently pending AKA a corresponding pod
 	// exists but didn't yet finish
 	prowJobIndexKeyPending = "pending"
+	// podPhaseIndexName is the name of an index of build
+	// cluster pods by their phase
+	podPhaseIndexName = "plank-pod-phase"
 )
 
 func pendingTriggeredIndexKeyByName(jobName string) string {
@@ -1035,6 +1129,10 @@ func prowJobIndexer(prowJobNamespace string) ctrlruntimeclient.IndexerFunc {
 	}
 }
 
+func podPhaseIndexer(o ctrlruntimeclient.Object) []string {
+	return []string{string(o.(*corev1.Pod).Status.Phase)}
+}
+
 func optAllProwJobs() ctrlruntimeclient.ListOption {
 	return ctrlruntimeclient.MatchingFields{prowJobIndexName: prowJobIndexKeyAll}
 }
@@ -1090,7 +1188,7 @@ func isRequestError(err error) bool {
 	return 400 <= code && code < 500
 }
 
-func countPendingOrOlderTriggeredMatchingPJs(pj prowv1.ProwJob, pjs []prowv1.ProwJob) int {
+func countPendingOrOlderTriggeredMatchingPJs(pj prowv1.ProwJob, pjs []prowv1.ProwJob, now time.Time) int {
 	var pendingOrOlderTriggeredMatchingPJs int
 
 	for _, foundPJ := range pjs {
@@ -1102,6 +1200,10 @@ func countPendingOrOlderTriggeredMatchingPJs(pj prowv1.ProwJob, pjs []prowv1.Pro
 			pendingOrOlderTriggeredMatchingPJs++
 			continue
 		}
+		// Deferred jobs can not run yet, so they must not hold up others.
+		if foundPJ.Deferred(now) {
+			continue
+		}
 
 		// At this point if foundPJ is older than our prowJobs it gets
 		// priorized to make sure we execute jobs in creation order.
@@ -55,6 +55,7 @@ import (
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/testutil"
 )
 
@@ -572,6 +573,100 @@ func TestStartPodBlocksUntilItHasThePodInCache(t *testing.T) {
 	}
 }
 
+func TestStartPodAppliesLabelPropagationPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := &config.LabelPropagationPolicy{
+		Pods: &config.PropagationRules{
+			DeniedLabels:      []string{"^internal/"},
+			DeniedAnnotations: []string{"^internal/"},
+		},
+		RequiredLabels: []config.RequiredLabel{{Key: "cost-center", Default: "shared"}, {Key: "team"}},
+	}
+	testCases := []struct {
+		name                string
+		labels              map[string]string
+		annotations         map[string]string
+		expectedErr         bool
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:        "denied labels and annotations are dropped and defaults are injected",
+			labels:      map[string]string{"team": "sig-testing", "internal/owner": "me"},
+			annotations: map[string]string{"internal/note": "secret", "description": "job"},
+			expectedLabels: map[string]string{
+				"team":        "sig-testing",
+				"cost-center": "shared",
+			},
+			expectedAnnotations: map[string]string{
+				"description": "job",
+			},
+		},
+		{
+			name:        "missing required label without a default fails terminally",
+			labels:      map[string]string{"cost-center": "ci"},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := func() *config.Config {
+				return &config.Config{ProwConfig: config.ProwConfig{Plank: config.Plank{LabelPropagation: policy}}}
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().Build()
+			r := &reconciler{
+				log:          logrus.NewEntry(logrus.New()),
+				buildClients: map[string]buildClient{"default": {Client: client}},
+				config:       cfg,
+			}
+			pj := &prowv1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "name", Labels: tc.labels, Annotations: tc.annotations},
+				Spec: prowv1.ProwJobSpec{
+					PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{}}},
+					Refs:    &prowv1.Refs{},
+					Type:    prowv1.PeriodicJob,
+				},
+			}
+			_, _, err := r.startPod(context.Background(), pj)
+			if tc.expectedErr {
+				if !IsTerminalError(err) {
+					t.Fatalf("expected a terminal error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("startPod: %v", err)
+			}
+			var pod corev1.Pod
+			if err := client.Get(context.Background(), types.NamespacedName{Name: "name"}, &pod); err != nil {
+				t.Fatalf("failed to get pod: %v", err)
+			}
+			for key, value := range tc.expectedLabels {
+				if pod.Labels[key] != value {
+					t.Errorf("expected label %s=%s, got labels %v", key, value, pod.Labels)
+				}
+			}
+			if _, ok := pod.Labels["internal/owner"]; ok {
+				t.Errorf("expected denied label to be dropped, got labels %v", pod.Labels)
+			}
+			if pod.Labels[kube.CreatedByProw] != "true" {
+				t.Errorf("expected the %s label to be kept, got labels %v", kube.CreatedByProw, pod.Labels)
+			}
+			for key, value := range tc.expectedAnnotations {
+				if pod.Annotations[key] != value {
+					t.Errorf("expected annotation %s=%s, got annotations %v", key, value, pod.Annotations)
+				}
+			}
+			if _, ok := pod.Annotations["internal/note"]; ok {
+				t.Errorf("expected denied annotation to be dropped, got annotations %v", pod.Annotations)
+			}
+		})
+	}
+}
+
 type fakeOpener struct {
 	io.Opener
 	strings.Builder
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/github"
@@ -56,16 +57,22 @@ var (
 	handleFunc = handle
 )
 
-type githubClient interface {
-	GetPullRequest(org, repo string, number int) (*github.PullRequest, error)
+// ApprovalClient is the subset of the GitHub client needed to compute the
+// approvals of a PR.
+type ApprovalClient interface {
 	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
-	GetIssueLabels(org, repo string, number int) ([]github.Label, error)
 	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
 	ListReviews(org, repo string, number int) ([]github.Review, error)
 	ListPullRequestComments(org, repo string, number int) ([]github.ReviewComment, error)
+	BotUserChecker() (func(candidate string) bool, error)
+}
+
+type githubClient interface {
+	ApprovalClient
+	GetPullRequest(org, repo string, number int) (*github.PullRequest, error)
+	GetIssueLabels(org, repo string, number int) ([]github.Label, error)
 	DeleteComment(org, repo string, ID int) error
 	CreateComment(org, repo string, number int, comment string) error
-	BotUserChecker() (func(candidate string) bool, error)
 	AddLabel(org, repo string, number int, label string) error
 	RemoveLabel(org, repo string, number int, label string) error
 	WasLabelAddedByHuman(org, repo string, num int, label string) (bool, error)
@@ -110,7 +117,7 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 	approveConfig := map[string]string{}
 	for _, repo := range enabledRepos {
 		opts := config.ApproveFor(repo.Org, repo.Repo)
-		approveConfig[repo.String()] = fmt.Sprintf("Pull requests %s require an associated issue.<br>Pull request authors %s implicitly approve their own PRs.<br>The /lgtm [cancel] command(s) %s act as approval.<br>A GitHub approved or changes requested review %s act as approval or cancel respectively.", doNot(opts.IssueRequired), doNot(opts.HasSelfApproval()), willNot(opts.LgtmActsAsApprove), willNot(opts.ConsiderReviewState()))
+		approveConfig[repo.String()] = fmt.Sprintf("Pull requests %s require an associated issue.<br>Pull request authors %s implicitly approve their own PRs.<br>The /lgtm [cancel] command(s) %s act as approval.<br>A GitHub approved or changes requested review %s act as approval or cancel respectively.", doNot(opts.RequiresIssue()), doNot(opts.HasSelfApproval()), willNot(opts.LgtmActsAsApproval()), willNot(opts.ConsiderReviewState()))
 	}
 
 	yamlSnippet, err := plugins.CommentMap.GenYaml(&plugins.Configuration{
@@ -174,7 +181,7 @@ func handleGenericComment(log *logrus.Entry, ghc githubClient, oc ownersClient,
 	}
 
 	opts := config.ApproveFor(ce.Repo.Owner.Login, ce.Repo.Name)
-	if !isApprovalCommand(botUserChecker, opts.LgtmActsAsApprove, &comment{Body: ce.Body, Author: ce.User.Login}) {
+	if !isApprovalCommand(botUserChecker, opts.LgtmActsAsApproval(), &comment{Body: ce.Body, Author: ce.User.Login}) {
 		log.Debug("Comment does not constitute approval, skipping event.")
 		return nil
 	}
@@ -243,7 +250,7 @@ func handleReview(log *logrus.Entry, ghc githubClient, oc ownersClient, githubCo
 	// Check for an approval command is in the body. If one exists, let the
 	// genericCommentEventHandler handle this event. Approval commands override
 	// review state.
-	if isApprovalCommand(botUserChecker, opts.LgtmActsAsApprove, &comment{Body: re.Review.Body, Author: re.Review.User.Login}) {
+	if isApprovalCommand(botUserChecker, opts.LgtmActsAsApproval(), &comment{Body: re.Review.Body, Author: re.Review.User.Login}) {
 		log.Debug("Review constitutes approval, skipping event.")
 		return nil
 	}
@@ -339,6 +346,25 @@ func handlePullRequest(log *logrus.Entry, ghc githubClient, oc ownersClient, git
 	)
 }
 
+// HandleGenericComment handles approval commands in comments on pull requests
+// of SCM providers other than GitHub, whose clients implement the parts of
+// the GitHub client that the plugin uses.
+func HandleGenericComment(log *logrus.Entry, ghc githubClient, oc ownersClient, githubConfig config.GitHubOptions, config *plugins.Configuration, ce *github.GenericCommentEvent) error {
+	return handleGenericComment(log, ghc, oc, githubConfig, config, ce)
+}
+
+// HandleReview handles reviews of pull requests of SCM providers other than
+// GitHub, like HandleGenericComment.
+func HandleReview(log *logrus.Entry, ghc githubClient, oc ownersClient, githubConfig config.GitHubOptions, config *plugins.Configuration, re *github.ReviewEvent) error {
+	return handleReview(log, ghc, oc, githubConfig, config, re)
+}
+
+// HandlePullRequest handles changes of pull requests of SCM providers other
+// than GitHub, like HandleGenericComment.
+func HandlePullRequest(log *logrus.Entry, ghc githubClient, oc ownersClient, githubConfig config.GitHubOptions, config *plugins.Configuration, pre *github.PullRequestEvent) error {
+	return handlePullRequest(log, ghc, oc, githubConfig, config, pre)
+}
+
 // Returns associated issue, or 0 if it can't find any.
 // This is really simple, and could be improved later.
 func findAssociatedIssue(body, org string) (int, error) {
@@ -383,13 +409,9 @@ func handle(log *logrus.Entry, ghc githubClient, repo approvers.Repo, githubConf
 	}
 
 	start := time.Now()
-	changes, err := ghc.GetPullRequestChanges(pr.org, pr.repo, pr.number)
+	activity, err := fetchActivity(ghc, pr)
 	if err != nil {
-		return fetchErr("PR file changes", err)
-	}
-	var filenames []string
-	for _, change := range changes {
-		filenames = append(filenames, change.Filename)
+		return err
 	}
 	issueLabels, err := ghc.GetIssueLabels(pr.org, pr.repo, pr.number)
 	if err != nil {
@@ -402,39 +424,101 @@ func handle(log *logrus.Entry, ghc githubClient, repo approvers.Repo, githubConf
 			break
 		}
 	}
-	botUserChecker, err := ghc.BotUserChecker()
-	if err != nil {
-		return fetchErr("bot name", err)
+	log.WithField("duration", time.Since(start).String()).Debug("Completed github functions in handle")
+
+	start = time.Now()
+	approversHandler := newApprovers(log, repo, opts, pr, activity)
+	approversHandler.ManuallyApproved = humanAddedApproved(ghc, log, pr.org, pr.repo, pr.number, hasApprovedLabel)
+	log.WithField("duration", time.Since(start).String()).Debug("Completed computing the approvers in handle")
+
+	start = time.Now()
+	notifications := filterComments(commentsFromIssueComments(activity.issueComments), notificationMatcher(activity.isBot))
+	latestNotification := getLast(notifications)
+	newMessage := updateNotification(githubConfig.LinkURL, opts.CommandHelpLink, opts.PrProcessLink, pr.org, pr.repo, pr.branch, latestNotification, approversHandler)
+	log.WithField("duration", time.Since(start).String()).Debug("Completed getting notifications in handle")
+	start = time.Now()
+	if newMessage != nil {
+		for _, notif := range notifications {
+			if err := ghc.DeleteComment(pr.org, pr.repo, notif.ID); err != nil {
+				log.WithError(err).Errorf("Failed to delete comment from %s/%s#%d, ID: %d.", pr.org, pr.repo, pr.number, notif.ID)
+			}
+		}
+		if err := ghc.CreateComment(pr.org, pr.repo, pr.number, *newMessage); err != nil {
+			log.WithError(err).Errorf("Failed to create comment on %s/%s#%d: %q.", pr.org, pr.repo, pr.number, *newMessage)
+		}
 	}
-	issueComments, err := ghc.ListIssueComments(pr.org, pr.repo, pr.number)
-	if err != nil {
-		return fetchErr("issue comments", err)
+	log.WithField("duration", time.Since(start).String()).Debug("Completed adding/deleting approval comments in handle")
+
+	start = time.Now()
+	if !approversHandler.IsApproved() {
+		if hasApprovedLabel {
+			if err := ghc.RemoveLabel(pr.org, pr.repo, pr.number, labels.Approved); err != nil {
+				log.WithError(err).Errorf("Failed to remove %q label from %s/%s#%d.", labels.Approved, pr.org, pr.repo, pr.number)
+			}
+		}
+	} else if !hasApprovedLabel {
+		if err := ghc.AddLabel(pr.org, pr.repo, pr.number, labels.Approved); err != nil {
+			log.WithError(err).Errorf("Failed to add %q label to %s/%s#%d.", labels.Approved, pr.org, pr.repo, pr.number)
+		}
 	}
-	reviewComments, err := ghc.ListPullRequestComments(pr.org, pr.repo, pr.number)
-	if err != nil {
-		return fetchErr("review comments", err)
+	log.WithField("duration", time.Since(start).String()).Debug("Completed adding/deleting approval labels in handle")
+	return nil
+}
+
+// activity is what the approvals of a PR are computed from.
+type activity struct {
+	filenames      []string
+	issueComments  []github.IssueComment
+	reviewComments []github.ReviewComment
+	reviews        []github.Review
+	isBot          func(string) bool
+}
+
+// fetchActivity fetches the changed files, comments and reviews of a PR.
+func fetchActivity(ghc ApprovalClient, pr *state) (*activity, error) {
+	fetchErr := func(context string, err error) error {
+		return fmt.Errorf("failed to get %s for %s/%s#%d: %w", context, pr.org, pr.repo, pr.number, err)
 	}
-	reviews, err := ghc.ListReviews(pr.org, pr.repo, pr.number)
+	a := &activity{}
+	changes, err := ghc.GetPullRequestChanges(pr.org, pr.repo, pr.number)
 	if err != nil {
-		return fetchErr("reviews", err)
+		return nil, fetchErr("PR file changes", err)
 	}
-	log.WithField("duration", time.Since(start).String()).Debug("Completed github functions in handle")
+	for _, change := range changes {
+		a.filenames = append(a.filenames, change.Filename)
+	}
+	if a.isBot, err = ghc.BotUserChecker(); err != nil {
+		return nil, fetchErr("bot name", err)
+	}
+	if a.issueComments, err = ghc.ListIssueComments(pr.org, pr.repo, pr.number); err != nil {
+		return nil, fetchErr("issue comments", err)
+	}
+	if a.reviewComments, err = ghc.ListPullRequestComments(pr.org, pr.repo, pr.number); err != nil {
+		return nil, fetchErr("review comments", err)
+	}
+	if a.reviews, err = ghc.ListReviews(pr.org, pr.repo, pr.number); err != nil {
+		return nil, fetchErr("reviews", err)
+	}
+	return a, nil
+}
 
-	start = time.Now()
+// newApprovers computes the approvers of the changed files of a PR from its
+// comments and reviews.
+func newApprovers(log *logrus.Entry, repo approvers.Repo, opts *plugins.Approve, pr *state, a *activity) approvers.Approvers {
 	approversHandler := approvers.NewApprovers(
 		approvers.NewOwners(
 			log,
-			filenames,
+			a.filenames,
 			repo,
 			int64(pr.number),
 		),
 	)
+	var err error
 	approversHandler.AssociatedIssue, err = findAssociatedIssue(pr.body, pr.org)
 	if err != nil {
 		log.WithError(err).Errorf("Failed to find associated issue from PR body: %v", err)
 	}
-	approversHandler.RequireIssue = opts.IssueRequired
-	approversHandler.ManuallyApproved = humanAddedApproved(ghc, log, pr.org, pr.repo, pr.number, hasApprovedLabel)
+	approversHandler.RequireIssue = opts.RequiresIssue()
 
 	// Author implicitly approves their own PR if config allows it
 	if opts.HasSelfApproval() {
@@ -443,55 +527,45 @@ func handle(log *logrus.Entry, ghc githubClient, repo approvers.Repo, githubConf
 		// Treat the author as an assignee, and suggest them if possible
 		approversHandler.AddAssignees(pr.author)
 	}
-	log.WithField("duration", time.Since(start).String()).Debug("Completed configuring approversHandler in handle")
 
-	start = time.Now()
-	commentsFromIssueComments := commentsFromIssueComments(issueComments)
-	comments := append(commentsFromReviewComments(reviewComments), commentsFromIssueComments...)
-	comments = append(comments, commentsFromReviews(reviews)...)
+	comments := append(commentsFromReviewComments(a.reviewComments), commentsFromIssueComments(a.issueComments)...)
+	comments = append(comments, commentsFromReviews(a.reviews)...)
 	sort.SliceStable(comments, func(i, j int) bool {
 		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
 	})
-	approveComments := filterComments(comments, approvalMatcher(botUserChecker, opts.LgtmActsAsApprove, opts.ConsiderReviewState()))
+	approveComments := filterComments(comments, approvalMatcher(a.isBot, opts.LgtmActsAsApproval(), opts.ConsiderReviewState()))
 	addApprovers(&approversHandler, approveComments, pr.author, opts.ConsiderReviewState())
-	log.WithField("duration", time.Since(start).String()).Debug("Completed filtering approval comments in handle")
 
 	for _, user := range pr.assignees {
 		approversHandler.AddAssignees(user.Login)
 	}
+	return approversHandler
+}
 
-	start = time.Now()
-	notifications := filterComments(commentsFromIssueComments, notificationMatcher(botUserChecker))
-	latestNotification := getLast(notifications)
-	newMessage := updateNotification(githubConfig.LinkURL, opts.CommandHelpLink, opts.PrProcessLink, pr.org, pr.repo, pr.branch, latestNotification, approversHandler)
-	log.WithField("duration", time.Since(start).String()).Debug("Completed getting notifications in handle")
-	start = time.Now()
-	if newMessage != nil {
-		for _, notif := range notifications {
-			if err := ghc.DeleteComment(pr.org, pr.repo, notif.ID); err != nil {
-				log.WithError(err).Errorf("Failed to delete comment from %s/%s#%d, ID: %d.", pr.org, pr.repo, pr.number, notif.ID)
-			}
-		}
-		if err := ghc.CreateComment(pr.org, pr.repo, pr.number, *newMessage); err != nil {
-			log.WithError(err).Errorf("Failed to create comment on %s/%s#%d: %q.", pr.org, pr.repo, pr.number, *newMessage)
-		}
+// UnapprovedFiles computes the approvals of a PR the same way the plugin does
+// and returns the OWNERS files that still need to approve its changes. Unlike
+// the approved label, it does not consider manual approvals or whether an
+// associated issue is required, only approvals from the OWNERS of the files
+// changed by the PR as it is now.
+func UnapprovedFiles(log *logrus.Entry, ghc ApprovalClient, repo approvers.Repo, opts *plugins.Approve, org, repoName string, number int, author string) ([]string, error) {
+	pr := &state{org: org, repo: repoName, number: number, author: author}
+	a, err := fetchActivity(ghc, pr)
+	if err != nil {
+		return nil, err
 	}
-	log.WithField("duration", time.Since(start).String()).Debug("Completed adding/deleting approval comments in handle")
+	return sets.List(newApprovers(log, repo, opts, pr, a).UnapprovedFiles()), nil
+}
 
-	start = time.Now()
-	if !approversHandler.IsApproved() {
-		if hasApprovedLabel {
-			if err := ghc.RemoveLabel(pr.org, pr.repo, pr.number, labels.Approved); err != nil {
-				log.WithError(err).Errorf("Failed to remove %q label from %s/%s#%d.", labels.Approved, pr.org, pr.repo, pr.number)
-			}
-		}
-	} else if !hasApprovedLabel {
-		if err := ghc.AddLabel(pr.org, pr.repo, pr.number, labels.Approved); err != nil {
-			log.WithError(err).Errorf("Failed to add %q label to %s/%s#%d.", labels.Approved, pr.org, pr.repo, pr.number)
-		}
+// CurrentApprovers computes the approvals of a PR the same way the plugin
+// does and returns the logins of its approvers, including its author if the
+// repo lets authors approve their own PRs.
+func CurrentApprovers(log *logrus.Entry, ghc ApprovalClient, repo approvers.Repo, opts *plugins.Approve, org, repoName string, number int, author string) ([]string, error) {
+	pr := &state{org: org, repo: repoName, number: number, author: author}
+	a, err := fetchActivity(ghc, pr)
+	if err != nil {
+		return nil, err
 	}
-	log.WithField("duration", time.Since(start).String()).Debug("Completed adding/deleting approval labels in handle")
-	return nil
+	return sets.List(newApprovers(log, repo, opts, pr, a).GetCurrentApproversSetCased()), nil
 }
 
 func humanAddedApproved(ghc githubClient, log *logrus.Entry, org, repo string, number int, hasLabel bool) func() bool {
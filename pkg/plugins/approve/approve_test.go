@@ -1263,8 +1263,8 @@ Approvers can cancel approval by writing ` + "`/approve cancel`" + ` in a commen
 				&plugins.Approve{
 					Repos:               []string{"org/repo"},
 					RequireSelfApproval: &rsa,
-					IssueRequired:       test.needsIssue,
-					LgtmActsAsApprove:   test.lgtmActsAsApprove,
+					IssueRequired:       &test.needsIssue,
+					LgtmActsAsApprove:   &test.lgtmActsAsApprove,
 					IgnoreReviewState:   &irs,
 					CommandHelpLink:     "https://go.k8s.io/bot-commands",
 					PrProcessLink:       "https://git.k8s.io/community/contributors/guide/owners.md#the-code-review-process",
@@ -1551,7 +1551,7 @@ func TestHandleGenericComment(t *testing.T) {
 		config := &plugins.Configuration{}
 		config.Approve = append(config.Approve, plugins.Approve{
 			Repos:             []string{test.commentEvent.Repo.Owner.Login},
-			LgtmActsAsApprove: test.lgtmActsAsApprove,
+			LgtmActsAsApprove: &test.lgtmActsAsApprove,
 		})
 		err := handleGenericComment(
 			logrus.WithField("plugin", "approve"),
@@ -1771,7 +1771,7 @@ func TestHandleReview(t *testing.T) {
 		irs := !test.reviewActsAsApprove
 		config.Approve = append(config.Approve, plugins.Approve{
 			Repos:             []string{test.reviewEvent.Repo.Owner.Login},
-			LgtmActsAsApprove: test.lgtmActsAsApprove,
+			LgtmActsAsApprove: &test.lgtmActsAsApprove,
 			IgnoreReviewState: &irs,
 		})
 		err := handleReview(
@@ -1946,6 +1946,129 @@ func TestHandlePullRequest(t *testing.T) {
 	}
 }
 
+func TestUnapprovedFiles(t *testing.T) {
+	fr := fakeRepo{
+		approvers: map[string]layeredsets.String{
+			"a": layeredsets.NewString("alice"),
+			"c": layeredsets.NewString("cblecker"),
+		},
+		leafApprovers: map[string]sets.Set[string]{
+			"a": sets.New[string]("alice"),
+			"c": sets.New[string]("cblecker"),
+		},
+		approverOwners: map[string]string{
+			"a/a.go": "a",
+			"c/c.go": "c",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		files    []string
+		comments []github.IssueComment
+		reviews  []github.Review
+		expected []string
+	}{
+		{
+			name:     "no approval",
+			files:    []string{"a/a.go"},
+			expected: []string{"a"},
+		},
+		{
+			name:     "all files approved",
+			files:    []string{"a/a.go", "c/c.go"},
+			comments: []github.IssueComment{newTestComment("alice", "/approve"), newTestComment("cblecker", "/approve")},
+			expected: []string{},
+		},
+		{
+			name:     "approval by review",
+			files:    []string{"a/a.go"},
+			reviews:  []github.Review{newTestReview("alice", "/approve", github.ReviewStateApproved)},
+			expected: []string{},
+		},
+		{
+			name:     "file added after the approval lacks its approval",
+			files:    []string{"a/a.go", "c/c.go"},
+			comments: []github.IssueComment{newTestComment("alice", "/approve")},
+			expected: []string{"c"},
+		},
+		{
+			name:     "manual approval is not considered",
+			files:    []string{"a/a.go"},
+			comments: []github.IssueComment{newTestComment("k8s-ci-robot", "[APPROVALNOTIFIER] This PR is **APPROVED**")},
+			expected: []string{"a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fghc := newFakeGitHubClient(true, true, test.files, test.comments, test.reviews)
+			opts := &plugins.Approve{Repos: []string{"org/repo"}}
+			unapproved, err := UnapprovedFiles(logrus.WithField("plugin", "approve"), fghc, fr, opts, "org", "repo", prNumber, "author")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.expected, unapproved); diff != "" {
+				t.Errorf("unapproved files differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCurrentApprovers(t *testing.T) {
+	fr := fakeRepo{
+		approvers: map[string]layeredsets.String{
+			"a": layeredsets.NewString("alice"),
+			"c": layeredsets.NewString("cblecker"),
+		},
+		leafApprovers: map[string]sets.Set[string]{
+			"a": sets.New[string]("alice"),
+			"c": sets.New[string]("cblecker"),
+		},
+		approverOwners: map[string]string{
+			"a/a.go": "a",
+			"c/c.go": "c",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		comments []github.IssueComment
+		reviews  []github.Review
+		expected []string
+	}{
+		{
+			name:     "no approval",
+			expected: []string{},
+		},
+		{
+			name:     "approvals by comment and review",
+			comments: []github.IssueComment{newTestComment("cblecker", "/approve")},
+			reviews:  []github.Review{newTestReview("alice", "/approve", github.ReviewStateApproved)},
+			expected: []string{"alice", "cblecker"},
+		},
+		{
+			name:     "cancelled approval",
+			comments: []github.IssueComment{newTestComment("alice", "/approve"), newTestComment("alice", "/approve cancel")},
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fghc := newFakeGitHubClient(true, true, []string{"a/a.go", "c/c.go"}, test.comments, test.reviews)
+			opts := &plugins.Approve{Repos: []string{"org/repo"}, RequireSelfApproval: &[]bool{true}[0]}
+			approvers, err := CurrentApprovers(logrus.WithField("plugin", "approve"), fghc, fr, opts, "org", "repo", prNumber, "author")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.expected, approvers); diff != "" {
+				t.Errorf("approvers differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestHelpProvider(t *testing.T) {
 	enabledRepos := []config.OrgRepo{
 		{Org: "org1", Repo: "repo"},
@@ -1968,9 +2091,9 @@ func TestHelpProvider(t *testing.T) {
 				Approve: []plugins.Approve{
 					{
 						Repos:               []string{"org2/repo"},
-						IssueRequired:       true,
+						IssueRequired:       &[]bool{true}[0],
 						RequireSelfApproval: &[]bool{true}[0],
-						LgtmActsAsApprove:   true,
+						LgtmActsAsApprove:   &[]bool{true}[0],
 						IgnoreReviewState:   &[]bool{true}[0],
 					},
 				},
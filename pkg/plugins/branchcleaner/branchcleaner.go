@@ -18,6 +18,10 @@ package branchcleaner
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -35,8 +39,19 @@ var (
 	preservedBranchesMsg = "The preserved branches for repo %s is %v"
 )
 
+const (
+	// staleReportMarker identifies the comment with the stale branch report.
+	staleReportMarker = "<!-- branchcleaner: stale branches -->"
+	// staleReportInterval is how often the stale branch report of a repo is updated.
+	staleReportInterval = 24 * time.Hour
+)
+
+// lastStaleReports records when the stale branch report of each repo was last updated.
+var lastStaleReports = &staleReports{}
+
 func init() {
 	plugins.RegisterPullRequestHandler(pluginName, handlePullRequest, helpProvider)
+	plugins.RegisterPushEventHandler(pluginName, handlePush, helpProvider)
 }
 
 func helpProvider(config *plugins.Configuration, enabledRepos []prowconfig.OrgRepo) (*pluginhelp.PluginHelp, error) {
@@ -55,13 +70,19 @@ func helpProvider(config *plugins.Configuration, enabledRepos []prowconfig.OrgRe
 		logrus.WithError(err).Warnf("cannot generate comments for %s plugin", pluginName)
 	}
 	return &pluginhelp.PluginHelp{
-		Description: "The branchcleaner plugin automatically deletes source branches for merged PRs between two branches on the same repository. This is helpful to keep repos that don't allow forking clean.",
+		Description: "The branchcleaner plugin automatically deletes source branches for merged PRs between two branches on the same repository. This is helpful to keep repos that don't allow forking clean. It can also report branches without recent commits to an issue.",
 		Config: func(repos []prowconfig.OrgRepo) map[string]string {
 			configMap := make(map[string]string)
 			for _, repo := range repos {
-				preservedBranches, exists := config.BranchCleaner.PreservedBranches[repo.String()]
-				if exists {
-					configMap[repo.String()] = msgForPreservedBranches(repo.String(), preservedBranches)
+				var msgs []string
+				if preservedBranches, exists := config.BranchCleaner.PreservedBranches[repo.String()]; exists {
+					msgs = append(msgs, msgForPreservedBranches(repo.String(), preservedBranches))
+				}
+				if report, exists := config.BranchCleaner.StaleBranchReports[repo.String()]; exists {
+					msgs = append(msgs, fmt.Sprintf("Stale branches are reported to issue #%d.", report.Issue))
+				}
+				if len(msgs) > 0 {
+					configMap[repo.String()] = strings.Join(msgs, " ")
 				}
 			}
 			return configMap
@@ -76,6 +97,7 @@ func handlePullRequest(pc plugins.Agent, pre github.PullRequestEvent) error {
 
 type githubClient interface {
 	DeleteRef(owner, repo, ref string) error
+	BotUser() (*github.UserData, error)
 }
 
 func handle(gc githubClient, log *logrus.Entry, config plugins.BranchCleaner, pre github.PullRequestEvent) error {
@@ -96,6 +118,17 @@ func handle(gc githubClient, log *logrus.Entry, config plugins.BranchCleaner, pr
 		return nil
 	}
 
+	if config.RestrictAuthors {
+		botUser, err := gc.BotUser()
+		if err != nil {
+			return fmt.Errorf("failed to get the bot user: %w", err)
+		}
+		if !config.IsOptedIn(pr.User.Login, botUser.Login) {
+			log.Debugf("Not deleting branch %s of %s, who did not opt in.", pr.Head.Ref, pr.User.Login)
+			return nil
+		}
+	}
+
 	if err := gc.DeleteRef(pr.Base.Repo.Owner.Login, pr.Base.Repo.Name, fmt.Sprintf("heads/%s", pr.Head.Ref)); err != nil {
 		return fmt.Errorf("failed to delete branch %s on repo %s/%s after Pull Request #%d got merged: %w",
 			pr.Head.Ref, pr.Base.Repo.Owner.Login, pr.Base.Repo.Name, pre.PullRequest.Number, err)
@@ -103,3 +136,119 @@ func handle(gc githubClient, log *logrus.Entry, config plugins.BranchCleaner, pr
 
 	return nil
 }
+
+func handlePush(pc plugins.Agent, pe github.PushEvent) error {
+	return handleStaleBranches(pc.GitHubClient, pc.Logger, pc.PluginConfig.BranchCleaner, lastStaleReports, pe, time.Now())
+}
+
+type staleBranchClient interface {
+	GetBranches(org, repo string, onlyProtected bool) ([]github.Branch, error)
+	GetSingleCommit(org, repo, SHA string) (github.RepositoryCommit, error)
+	BotUserChecker() (func(candidate string) bool, error)
+	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
+	CreateComment(org, repo string, number int, comment string) error
+	EditComment(org, repo string, id int, comment string) error
+}
+
+// staleReports records when the stale branch report of each repo was last updated.
+type staleReports struct {
+	lock    sync.Mutex
+	updated map[string]time.Time
+}
+
+// due checks whether the report of the repo should be updated and, if so,
+// records it as updated now.
+func (s *staleReports) due(repo string, now time.Time) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if last, ok := s.updated[repo]; ok && now.Sub(last) < staleReportInterval {
+		return false
+	}
+	if s.updated == nil {
+		s.updated = map[string]time.Time{}
+	}
+	s.updated[repo] = now
+	return true
+}
+
+// handleStaleBranches updates the stale branch report of the repo after
+// pushes to its default branch.
+func handleStaleBranches(gc staleBranchClient, log *logrus.Entry, config plugins.BranchCleaner, reports *staleReports, pe github.PushEvent, now time.Time) error {
+	org, repo := pe.Repo.Owner.Login, pe.Repo.Name
+	report, ok := config.StaleBranchReports[pe.Repo.FullName]
+	if !ok || pe.Deleted || pe.Ref != "refs/heads/"+pe.Repo.DefaultBranch {
+		return nil
+	}
+	if !reports.due(pe.Repo.FullName, now) {
+		return nil
+	}
+
+	branches, err := gc.GetBranches(org, repo, false)
+	if err != nil {
+		return fmt.Errorf("failed to list branches of %s: %w", pe.Repo.FullName, err)
+	}
+	staleAfter := report.StaleAfter(now)
+	stale := map[string]time.Time{}
+	for _, branch := range branches {
+		if branch.Protected || branch.Name == pe.Repo.DefaultBranch || config.IsPreservedBranch(org, repo, branch.Name) {
+			continue
+		}
+		commit, err := gc.GetSingleCommit(org, repo, branch.Commit.SHA)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to get the head commit of branch %s.", branch.Name)
+			continue
+		}
+		if committed := commit.Commit.Committer.Date; committed.Before(staleAfter) {
+			stale[branch.Name] = committed
+		}
+	}
+
+	botUserChecker, err := gc.BotUserChecker()
+	if err != nil {
+		return fmt.Errorf("failed to get the bot user: %w", err)
+	}
+	comments, err := gc.ListIssueComments(org, repo, report.Issue)
+	if err != nil {
+		return fmt.Errorf("failed to list comments of %s#%d: %w", pe.Repo.FullName, report.Issue, err)
+	}
+	body := staleBranchReport(stale, staleAfter)
+	for _, comment := range comments {
+		if botUserChecker(comment.User.Login) && strings.Contains(comment.Body, staleReportMarker) {
+			if comment.Body == body {
+				return nil
+			}
+			log.Infof("Updating the report of %d stale branches.", len(stale))
+			return gc.EditComment(org, repo, comment.ID, body)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	log.Infof("Reporting %d stale branches.", len(stale))
+	return gc.CreateComment(org, repo, report.Issue, body)
+}
+
+// staleBranchReport formats the report of stale branches, oldest first.
+func staleBranchReport(stale map[string]time.Time, staleAfter time.Time) string {
+	var b strings.Builder
+	b.WriteString(staleReportMarker + "\n")
+	if len(stale) == 0 {
+		fmt.Fprintf(&b, "There are no branches without commits since %s.\n", staleAfter.Format("2006-01-02"))
+		return b.String()
+	}
+	names := make([]string, 0, len(stale))
+	for name := range stale {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if !stale[names[i]].Equal(stale[names[j]]) {
+			return stale[names[i]].Before(stale[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	fmt.Fprintf(&b, "The following branches have had no commits since %s. Please delete them if they are no longer needed:\n\n", staleAfter.Format("2006-01-02"))
+	for _, name := range names {
+		fmt.Fprintf(&b, "- `%s` (last commit on %s)\n", name, stale[name].Format("2006-01-02"))
+	}
+	return b.String()
+}
@@ -19,7 +19,9 @@ package branchcleaner
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
 
 	"sigs.k8s.io/prow/pkg/github"
@@ -39,6 +41,9 @@ func TestBranchCleaner(t *testing.T) {
 		headRepoFullName     string
 		srcBranchName        string
 		preservedBranches    map[string][]string
+		restrictAuthors      bool
+		optedInAuthors       []string
+		author               string
 		branchDeleteExpected bool
 	}{
 		{
@@ -144,6 +149,38 @@ func TestBranchCleaner(t *testing.T) {
 			headRepoFullName:     "my-org/repo",
 			branchDeleteExpected: true,
 		},
+		{
+			name:                 "restricted authors, PR by bot",
+			prAction:             github.PullRequestActionClosed,
+			srcBranchName:        "autobump",
+			merged:               true,
+			headRepoFullName:     "my-org/repo",
+			restrictAuthors:      true,
+			author:               fakegithub.Bot,
+			branchDeleteExpected: true,
+		},
+		{
+			name:                 "restricted authors, PR by opted in user",
+			prAction:             github.PullRequestActionClosed,
+			srcBranchName:        "my-chore2",
+			merged:               true,
+			headRepoFullName:     "my-org/repo",
+			restrictAuthors:      true,
+			optedInAuthors:       []string{"Alice"},
+			author:               "alice",
+			branchDeleteExpected: true,
+		},
+		{
+			name:                 "restricted authors, PR by other user",
+			prAction:             github.PullRequestActionClosed,
+			srcBranchName:        "my-chore3",
+			merged:               true,
+			headRepoFullName:     "my-org/repo",
+			restrictAuthors:      true,
+			optedInAuthors:       []string{"alice"},
+			author:               "bob",
+			branchDeleteExpected: false,
+		},
 	}
 
 	mergeSHA := "abc"
@@ -172,6 +209,7 @@ func TestBranchCleaner(t *testing.T) {
 							FullName: tc.headRepoFullName,
 						},
 					},
+					User:   github.User{Login: tc.author},
 					Merged: tc.merged},
 			}
 			if tc.merged {
@@ -186,6 +224,8 @@ func TestBranchCleaner(t *testing.T) {
 			}
 			if err := handle(fgc, log, plugins.BranchCleaner{
 				PreservedBranches: tc.preservedBranches,
+				RestrictAuthors:   tc.restrictAuthors,
+				OptedInAuthors:    tc.optedInAuthors,
 			}, event); err != nil {
 				t.Fatalf("error in handle: %v", err)
 			}
@@ -210,3 +250,101 @@ func TestBranchCleaner(t *testing.T) {
 
 	}
 }
+
+func TestHandleStaleBranches(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, -4, 0)
+	older := now.AddDate(-1, 0, 0)
+	recent := now.AddDate(0, 0, -1)
+	config := plugins.BranchCleaner{
+		PreservedBranches:  map[string][]string{"org/repo": {"release-.*"}},
+		StaleBranchReports: map[string]plugins.StaleBranchReport{"org/repo": {Issue: 10}},
+	}
+	push := github.PushEvent{
+		Ref:  "refs/heads/main",
+		Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo", FullName: "org/repo", DefaultBranch: "main"},
+	}
+	expectedReport := staleReportMarker + "\n" +
+		"The following branches have had no commits since 2024-03-01. Please delete them if they are no longer needed:\n\n" +
+		"- `ancient` (last commit on 2023-06-01)\n" +
+		"- `abandoned` (last commit on 2024-02-01)\n"
+
+	testCases := []struct {
+		name             string
+		push             func(github.PushEvent) github.PushEvent
+		existingReport   string
+		alreadyReported  bool
+		expectedComments []string
+		expectedEdits    []string
+	}{
+		{
+			name:             "report stale branches",
+			expectedComments: []string{"org/repo#10:" + expectedReport},
+		},
+		{
+			name:           "update existing report",
+			existingReport: staleReportMarker + "\nold report",
+			expectedEdits:  []string{"org/repo#1:" + expectedReport},
+		},
+		{
+			name:           "existing report is up to date",
+			existingReport: expectedReport,
+		},
+		{
+			name:            "reported recently",
+			alreadyReported: true,
+		},
+		{
+			name: "push to other branch",
+			push: func(pe github.PushEvent) github.PushEvent {
+				pe.Ref = "refs/heads/feature"
+				return pe
+			},
+		},
+		{
+			name: "repo without report",
+			push: func(pe github.PushEvent) github.PushEvent {
+				pe.Repo.FullName = "org/other"
+				return pe
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fgc := fakegithub.NewFakeClient()
+			fgc.Branches = map[string][]github.Branch{"org/repo": {
+				{Name: "main", Commit: github.GitCommit{SHA: "main"}},
+				{Name: "protected", Protected: true, Commit: github.GitCommit{SHA: "old"}},
+				{Name: "release-1.0", Commit: github.GitCommit{SHA: "old"}},
+				{Name: "abandoned", Commit: github.GitCommit{SHA: "old"}},
+				{Name: "ancient", Commit: github.GitCommit{SHA: "older"}},
+				{Name: "active", Commit: github.GitCommit{SHA: "recent"}},
+			}}
+			for sha, date := range map[string]time.Time{"main": older, "old": old, "older": older, "recent": recent} {
+				fgc.Commits[sha] = github.RepositoryCommit{SHA: sha, Commit: github.GitCommit{Committer: github.CommitAuthor{Date: date}}}
+			}
+			if tc.existingReport != "" {
+				fgc.IssueComments[10] = []github.IssueComment{
+					{ID: 1, Body: tc.existingReport, User: github.User{Login: fakegithub.Bot}},
+				}
+			}
+			reports := &staleReports{}
+			if tc.alreadyReported {
+				reports.due("org/repo", now.Add(-time.Hour))
+			}
+			pe := push
+			if tc.push != nil {
+				pe = tc.push(pe)
+			}
+			if err := handleStaleBranches(fgc, logrus.WithField("plugin", pluginName), config, reports, pe, now); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedComments, fgc.IssueCommentsAdded); diff != "" {
+				t.Errorf("unexpected comments (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedEdits, fgc.IssueCommentsEdited); diff != "" {
+				t.Errorf("unexpected edits (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
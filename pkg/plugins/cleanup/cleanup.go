@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cleanup implements the `/close-fork-prs` and `/relabel-all`
+// commands which let repo admins clean up many issues and PRs at once.
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/pluginhelp"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+const pluginName = "cleanup"
+
+var (
+	closeForkPRsRe = regexp.MustCompile(`(?mi)^/close-fork-prs\s+@?([\w-]+)\s*$`)
+	relabelAllRe   = regexp.MustCompile(`(?mi)^/relabel-all\s+(\S+)\s+(\S+)\s*$`)
+)
+
+type githubClient interface {
+	github.BulkClient
+	CreateComment(owner, repo string, number int, comment string) error
+	HasPermission(org, repo, user string, roles ...string) (bool, error)
+}
+
+func init() {
+	plugins.RegisterGenericCommentHandler(pluginName, handleGenericComment, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, _ []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
+	pluginHelp := &pluginhelp.PluginHelp{
+		Description: "The cleanup plugin lets repo admins close or relabel many issues and PRs at once. The changes are paced to leave API tokens to the other plugins, and commands can be repeated to finish an interrupted cleanup.",
+	}
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/close-fork-prs <user>",
+		Description: "Closes the open PRs of the repo from the forks of a user, e.g. a spammer.",
+		Featured:    false,
+		WhoCanUse:   "Repo admins.",
+		Examples:    []string{"/close-fork-prs spammer"},
+	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/relabel-all <old-label> <new-label>",
+		Description: "Replaces a label with another on all the issues and PRs of the repo, e.g. after renaming the label.",
+		Featured:    false,
+		WhoCanUse:   "Repo admins.",
+		Examples:    []string{"/relabel-all kind/bugfix kind/bug"},
+	})
+	return pluginHelp, nil
+}
+
+func handleGenericComment(pc plugins.Agent, e github.GenericCommentEvent) error {
+	return handle(context.Background(), pc.GitHubClient, pc.Logger, &e)
+}
+
+func handle(ctx context.Context, gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent) error {
+	if e.Action != github.GenericCommentActionCreated {
+		return nil
+	}
+	closeMatch := closeForkPRsRe.FindStringSubmatch(e.Body)
+	relabelMatch := relabelAllRe.FindStringSubmatch(e.Body)
+	if closeMatch == nil && relabelMatch == nil {
+		return nil
+	}
+
+	org := e.Repo.Owner.Login
+	repo := e.Repo.Name
+	number := e.Number
+	user := e.User.Login
+
+	respond := func(resp string) error {
+		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, user, resp))
+	}
+
+	admin, err := gc.HasPermission(org, repo, user, github.RoleAdmin)
+	if err != nil {
+		log.WithError(err).Warnf("Cannot determine whether %s is an admin of %s/%s.", user, org, repo)
+		return respond(fmt.Sprintf("Cannot determine whether you are an admin of %s/%s: %v", org, repo, err))
+	}
+	if !admin {
+		return respond(fmt.Sprintf("Only admins of %s/%s can clean up its issues and PRs.", org, repo))
+	}
+
+	opts := github.BulkOptions{Logger: log}
+	var what string
+	var result *github.BulkResult
+	if closeMatch != nil {
+		what = fmt.Sprintf("Closing the PRs from the forks of %s", closeMatch[1])
+		result, err = github.ClosePullRequestsFromFork(ctx, gc, org, repo, closeMatch[1], opts)
+	} else {
+		old, replacement := relabelMatch[1], relabelMatch[2]
+		what = fmt.Sprintf("Replacing the `%s` label with `%s`", old, replacement)
+		result, err = github.RelabelIssues(ctx, gc, org, repo, fmt.Sprintf("label:%q", old), []string{replacement}, []string{old}, opts)
+	}
+	if err != nil {
+		log.WithError(err).Warn("Bulk cleanup failed.")
+		return respond(fmt.Sprintf("%s failed: %v", what, err))
+	}
+	return respond(fmt.Sprintf("%s: %s", what, result))
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cleanup
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+type fakeClient struct {
+	admins   []string
+	prs      []github.PullRequest
+	labels   map[int][]string
+	comments []string
+	closed   []int
+}
+
+func (f *fakeClient) FindIssuesWithOrg(org, query, sort string, asc bool) ([]github.Issue, error) {
+	var issues []github.Issue
+	for number := 1; number <= len(f.labels); number++ {
+		for _, l := range f.labels[number] {
+			if strings.HasPrefix(query, `label:"`+l+`"`) {
+				issues = append(issues, github.Issue{Number: number})
+			}
+		}
+	}
+	return issues, nil
+}
+
+func (f *fakeClient) GetPullRequests(org, repo string) ([]github.PullRequest, error) {
+	return f.prs, nil
+}
+
+func (f *fakeClient) AddLabels(org, repo string, number int, labels ...string) error {
+	f.labels[number] = append(f.labels[number], labels...)
+	return nil
+}
+
+func (f *fakeClient) RemoveLabel(org, repo string, number int, label string) error {
+	var kept []string
+	for _, l := range f.labels[number] {
+		if l != label {
+			kept = append(kept, l)
+		}
+	}
+	f.labels[number] = kept
+	return nil
+}
+
+func (f *fakeClient) ClosePullRequest(org, repo string, number int) error {
+	f.closed = append(f.closed, number)
+	var open []github.PullRequest
+	for _, pr := range f.prs {
+		if pr.Number != number {
+			open = append(open, pr)
+		}
+	}
+	f.prs = open
+	return nil
+}
+
+func (f *fakeClient) GetRateLimits(org string) (*github.RateLimits, error) {
+	return &github.RateLimits{}, nil
+}
+
+func (f *fakeClient) CreateComment(owner, repo string, number int, comment string) error {
+	f.comments = append(f.comments, comment)
+	return nil
+}
+
+func (f *fakeClient) HasPermission(org, repo, user string, roles ...string) (bool, error) {
+	for _, admin := range f.admins {
+		if admin == user {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestHandle(t *testing.T) {
+	fromFork := func(number int, owner string) github.PullRequest {
+		return github.PullRequest{Number: number, Head: github.PullRequestBranch{Repo: github.Repo{Owner: github.User{Login: owner}}}}
+	}
+	testCases := []struct {
+		name             string
+		body             string
+		user             string
+		expectedClosed   []int
+		expectedLabels   map[int][]string
+		expectedResponse string
+	}{
+		{
+			name:           "no command",
+			body:           "close the spam please",
+			user:           "admin",
+			expectedLabels: map[int][]string{1: {"kind/bugfix"}, 2: {"kind/feature"}},
+		},
+		{
+			name:             "non-admins cannot clean up",
+			body:             "/close-fork-prs spammer",
+			user:             "someone",
+			expectedLabels:   map[int][]string{1: {"kind/bugfix"}, 2: {"kind/feature"}},
+			expectedResponse: "Only admins of org/repo can clean up its issues and PRs.",
+		},
+		{
+			name:             "close the PRs from the forks of a user",
+			body:             "/close-fork-prs @spammer",
+			user:             "admin",
+			expectedClosed:   []int{1, 3},
+			expectedLabels:   map[int][]string{1: {"kind/bugfix"}, 2: {"kind/feature"}},
+			expectedResponse: "Closing the PRs from the forks of spammer: 2 done",
+		},
+		{
+			name:             "relabel all",
+			body:             "/relabel-all kind/bugfix kind/bug",
+			user:             "admin",
+			expectedLabels:   map[int][]string{1: {"kind/bug"}, 2: {"kind/feature"}},
+			expectedResponse: "Replacing the `kind/bugfix` label with `kind/bug`: 1 done",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fc := &fakeClient{
+				admins: []string{"admin"},
+				prs:    []github.PullRequest{fromFork(1, "spammer"), fromFork(2, "contributor"), fromFork(3, "spammer")},
+				labels: map[int][]string{1: {"kind/bugfix"}, 2: {"kind/feature"}},
+			}
+			e := &github.GenericCommentEvent{
+				Action: github.GenericCommentActionCreated,
+				Body:   tc.body,
+				Number: 10,
+				Repo:   github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+				User:   github.User{Login: tc.user},
+			}
+			if err := handle(context.Background(), fc, logrus.WithField("plugin", pluginName), e); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedClosed, fc.closed); diff != "" {
+				t.Errorf("unexpected PRs closed (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedLabels, fc.labels); diff != "" {
+				t.Errorf("unexpected labels (-want +got):\n%s", diff)
+			}
+			switch {
+			case tc.expectedResponse == "" && len(fc.comments) != 0:
+				t.Errorf("expected no comment, got %v", fc.comments)
+			case tc.expectedResponse != "" && (len(fc.comments) != 1 || !strings.Contains(fc.comments[0], tc.expectedResponse)):
+				t.Errorf("expected a comment containing %q, got %v", tc.expectedResponse, fc.comments)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,11 +33,13 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"sigs.k8s.io/prow/pkg/bugzilla"
 	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/labels"
 	"sigs.k8s.io/prow/pkg/logrusutil"
@@ -53,11 +56,19 @@ type Configuration struct {
 	// (eg "o/r") to lists of enabled plugin names.
 	// If it is defined on both organization and repository levels, the list of enabled
 	// plugin names for the repository is the merging list of the two levels.
+	// Repositories inherit the plugins enabled for their organization and may opt
+	// out of individual inherited plugins with disabled_plugins.
 	// You can find a comprehensive list of the default available plugins here
 	// https://github.com/kubernetes-sigs/prow/tree/main/pkg/plugins
 	// note that you're also able to add external plugins.
 	Plugins Plugins `json:"plugins,omitempty"`
 
+	// InstallationPlugins is a map of GitHub App installation IDs to lists of
+	// plugin names enabled for all the repos the installation covers, on top
+	// of those enabled for their org and repo. Only honored by hook when it
+	// tracks App installations (--track-app-installations).
+	InstallationPlugins map[string][]string `json:"installation_plugins,omitempty"`
+
 	// ExternalPlugins is a map of repositories (eg "k/k") to lists of
 	// external plugins.
 	ExternalPlugins map[string][]ExternalPlugin `json:"external_plugins,omitempty"`
@@ -66,6 +77,12 @@ type Configuration struct {
 	Owners Owners `json:"owners,omitempty"`
 
 	// Built-in plugins specific configuration.
+	// The approve, dco, lgtm and triggers settings of an organization are
+	// inherited by its repositories, which may override individual settings.
+	// A repository entry no longer replaces the organization entry as a
+	// whole: settings it leaves unset take the organization's value, so a
+	// repository has to set e.g. only_org_members: false to turn off a
+	// setting its organization turns on.
 	Approve              []Approve                    `json:"approve,omitempty"`
 	Blockades            []Blockade                   `json:"blockades,omitempty"`
 	Blunderbuss          Blunderbuss                  `json:"blunderbuss,omitempty"`
@@ -128,8 +145,35 @@ type Golint struct {
 type Plugins map[string]OrgPlugins
 
 type OrgPlugins struct {
+	// ExcludedRepos is a list of repositories that do not inherit any of the
+	// plugins enabled at the organization level. Only valid for organizations.
 	ExcludedRepos []string `json:"excluded_repos,omitempty"`
-	Plugins       []string `json:"plugins,omitempty"`
+	// Plugins is the list of enabled plugin names.
+	Plugins []string `json:"plugins,omitempty"`
+	// DisabledPlugins is a list of plugins enabled at the organization level
+	// that the repository opts out of. Only valid for repositories.
+	DisabledPlugins []string `json:"disabled_plugins,omitempty"`
+}
+
+// EnabledPlugins returns the effective list of plugins enabled for the given
+// org and repo: the plugins inherited from the org, unless the repo is excluded
+// or has disabled them, followed by the plugins enabled for the repo itself.
+func (p Plugins) EnabledPlugins(org, repo string) []string {
+	var plugins []string
+
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	repoConfig := p[fullName]
+	if !sets.New[string](p[org].ExcludedRepos...).Has(repo) {
+		disabled := sets.New[string](repoConfig.DisabledPlugins...)
+		for _, plugin := range p[org].Plugins {
+			if !disabled.Has(plugin) {
+				plugins = append(plugins, plugin)
+			}
+		}
+	}
+	plugins = append(plugins, repoConfig.Plugins...)
+
+	return plugins
 }
 
 // ExternalPlugin holds configuration for registering an external
@@ -313,13 +357,13 @@ type Approve struct {
 	Repos []string `json:"repos,omitempty"`
 	// IssueRequired indicates if an associated issue is required for approval in
 	// the specified repos.
-	IssueRequired bool `json:"issue_required,omitempty"`
+	IssueRequired *bool `json:"issue_required,omitempty"`
 	// RequireSelfApproval disables automatic approval from PR authors with approval rights.
 	// Otherwise the plugin assumes the author of the PR with approval rights approves the changes in the PR.
 	RequireSelfApproval *bool `json:"require_self_approval,omitempty"`
 	// LgtmActsAsApprove indicates that the lgtm command should be used to
 	// indicate approval
-	LgtmActsAsApprove bool `json:"lgtm_acts_as_approve,omitempty"`
+	LgtmActsAsApprove *bool `json:"lgtm_acts_as_approve,omitempty"`
 	// IgnoreReviewState causes the approve plugin to ignore the GitHub review state. Otherwise:
 	// * an APPROVE github review is equivalent to leaving an "/approve" message.
 	// * A REQUEST_CHANGES github review is equivalent to leaving an /approve cancel" message.
@@ -337,6 +381,16 @@ var (
 	warnDependentBugTargetRelease time.Time
 )
 
+// RequiresIssue reports whether an associated issue is required for approval.
+func (a Approve) RequiresIssue() bool {
+	return isTrue(a.IssueRequired)
+}
+
+// LgtmActsAsApproval reports whether the lgtm command indicates approval.
+func (a Approve) LgtmActsAsApproval() bool {
+	return isTrue(a.LgtmActsAsApprove)
+}
+
 func (a Approve) HasSelfApproval() bool {
 	if a.RequireSelfApproval != nil {
 		return !*a.RequireSelfApproval
@@ -362,10 +416,10 @@ type Lgtm struct {
 	Repos []string `json:"repos,omitempty"`
 	// ReviewActsAsLgtm indicates that a GitHub review of "approve" or "request changes"
 	// acts as adding or removing the lgtm label
-	ReviewActsAsLgtm bool `json:"review_acts_as_lgtm,omitempty"`
+	ReviewActsAsLgtm *bool `json:"review_acts_as_lgtm,omitempty"`
 	// StoreTreeHash indicates if tree_hash should be stored inside a comment to detect
 	// squashed commits before removing lgtm labels
-	StoreTreeHash bool `json:"store_tree_hash,omitempty"`
+	StoreTreeHash *bool `json:"store_tree_hash,omitempty"`
 	// WARNING: This disables the security mechanism that prevents a malicious member (or
 	// compromised GitHub account) from merging arbitrary code. Use with caution.
 	//
@@ -374,6 +428,16 @@ type Lgtm struct {
 	StickyLgtmTeam string `json:"trusted_team_for_sticky_lgtm,omitempty"`
 }
 
+// ReviewsActAsLgtm reports whether GitHub reviews add or remove the lgtm label.
+func (l Lgtm) ReviewsActAsLgtm() bool {
+	return isTrue(l.ReviewActsAsLgtm)
+}
+
+// StoresTreeHash reports whether the tree hash is stored to detect squashed commits.
+func (l Lgtm) StoresTreeHash() bool {
+	return isTrue(l.StoreTreeHash)
+}
+
 // Jira holds the config for the jira plugin.
 type Jira struct {
 	// DisabledJiraProjects are projects for which we will never try to create a link,
@@ -462,12 +526,47 @@ type Trigger struct {
 	JoinOrgURL string `json:"join_org_url,omitempty"`
 	// OnlyOrgMembers requires PRs and/or /ok-to-test comments to come from org members.
 	// By default, trigger also include repo collaborators.
-	OnlyOrgMembers bool `json:"only_org_members,omitempty"`
+	OnlyOrgMembers *bool `json:"only_org_members,omitempty"`
 	// IgnoreOkToTest makes trigger ignore /ok-to-test comments.
 	// This is a security mitigation to only allow testing from trusted users.
-	IgnoreOkToTest bool `json:"ignore_ok_to_test,omitempty"`
+	IgnoreOkToTest *bool `json:"ignore_ok_to_test,omitempty"`
 	// TriggerGitHubWorkflows enables workflows run by github to be triggered by prow.
-	TriggerGitHubWorkflows bool `json:"trigger_github_workflows,omitempty"`
+	TriggerGitHubWorkflows *bool `json:"trigger_github_workflows,omitempty"`
+	// SmartRetest makes /retest-required only rerun the failed required jobs
+	// whose failures look like flakes, and makes /retest and /retest-required
+	// skip the jobs that are already being retested.
+	SmartRetest *SmartRetest `json:"smart_retest,omitempty"`
+}
+
+// SmartRetest configures how trigger classifies failures as flakes.
+//
+// A failure of a job on a pull request is classified as a flake if the job
+// errored, if it also passed on the same commit, or if it failed on at least
+// MinClusterSize other pull requests within the FlakeWindow.
+type SmartRetest struct {
+	// FlakeWindow is how far back runs of a job are considered when looking
+	// for clusters of failures. Defaults to 24h.
+	FlakeWindow *metav1.Duration `json:"flake_window,omitempty"`
+	// MinClusterSize is the number of other pull requests a job must have
+	// failed on within the FlakeWindow for its failures to be classified as
+	// flakes. Defaults to 3.
+	MinClusterSize int `json:"min_cluster_size,omitempty"`
+}
+
+// GetFlakeWindow returns the FlakeWindow, or its default if unset.
+func (s *SmartRetest) GetFlakeWindow() time.Duration {
+	if s == nil || s.FlakeWindow == nil {
+		return 24 * time.Hour
+	}
+	return s.FlakeWindow.Duration
+}
+
+// GetMinClusterSize returns the MinClusterSize, or its default if unset.
+func (s *SmartRetest) GetMinClusterSize() int {
+	if s == nil || s.MinClusterSize == 0 {
+		return 3
+	}
+	return s.MinClusterSize
 }
 
 // Heart contains the configuration for the heart plugin.
@@ -536,6 +635,14 @@ type ConfigMapSpec struct {
 	// repository root should be used as the configmap key. Slashes will be replaced by
 	// dashes. Using this avoids the need for unique file names in the original repo.
 	UseFullPathAsKey bool `json:"use_full_path_as_key,omitempty"`
+	// Sharded spreads the keys across as many ConfigMaps named <name>-0,
+	// <name>-1, ... as they need to stay under the ConfigMap size limit,
+	// splitting files too large for a single ConfigMap into parts. The
+	// ConfigMap named Name holds the index of the shards. Mounting all of
+	// them into one directory with a projected volume lets the job config
+	// be loaded as if it were a single ConfigMap.
+	// Sharded is mutually exclusive with PartitionedNames and Key.
+	Sharded bool `json:"sharded,omitempty"`
 }
 
 // A ClusterGroup is a list of clusters with namespaces
@@ -557,6 +664,9 @@ type ConfigUpdater struct {
 	// If GZIP is true then files will be gzipped before insertion into
 	// their corresponding configmap
 	GZIP bool `json:"gzip"`
+	// GZIPMinSize gzips the files of at least this many bytes even if GZIP
+	// is false, unless gzip is set to false for their configmap.
+	GZIPMinSize int `json:"gzip_min_size,omitempty"`
 }
 
 type configUpdatedWithoutUnmarshaler ConfigUpdater
@@ -695,7 +805,7 @@ func (w Welcome) getRepos() []string {
 // Dco is config for the DCO (https://developercertificate.org/) checker plugin.
 type Dco struct {
 	// SkipDCOCheckForMembers is used to skip DCO check for trusted org members
-	SkipDCOCheckForMembers bool `json:"skip_dco_check_for_members,omitempty"`
+	SkipDCOCheckForMembers *bool `json:"skip_dco_check_for_members,omitempty"`
 	// TrustedApps defines list of apps which commits will not be checked for DCO singoff.
 	// The list should contain usernames of each GitHub App without [bot] suffix.
 	// By default, this option is ignored.
@@ -704,7 +814,7 @@ type Dco struct {
 	// if the skip DCO option is enabled. The default is the PR's org.
 	TrustedOrg string `json:"trusted_org,omitempty"`
 	// SkipDCOCheckForCollaborators is used to skip DCO check for trusted org members
-	SkipDCOCheckForCollaborators bool `json:"skip_dco_check_for_collaborators,omitempty"`
+	SkipDCOCheckForCollaborators *bool `json:"skip_dco_check_for_collaborators,omitempty"`
 	// ContributingRepo is used to point users to a different repo containing CONTRIBUTING.md
 	ContributingRepo string `json:"contributing_repo,omitempty"`
 	// ContributingBranch allows setting a custom branch where to find CONTRIBUTING.md
@@ -846,26 +956,121 @@ func (r RequireMatchingLabel) Describe() string {
 	return str.String()
 }
 
+// selectBool returns the child argument if set, otherwise the parent
+func selectBool(parent, child *bool) *bool {
+	if child != nil {
+		return child
+	}
+	return parent
+}
+
+// isTrue returns whether the optional setting is set and enabled
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+// selectString returns the child argument if set, otherwise the parent
+func selectString(parent, child string) string {
+	if child != "" {
+		return child
+	}
+	return parent
+}
+
+// selectStrings returns the child argument if set, otherwise the parent
+func selectStrings(parent, child []string) []string {
+	if len(child) > 0 {
+		return child
+	}
+	return parent
+}
+
+// Apply returns the approve config of a repo that inherits the settings of
+// its org unless it overrides them.
+func (a Approve) Apply(child Approve) Approve {
+	return Approve{
+		Repos:               child.Repos,
+		IssueRequired:       selectBool(a.IssueRequired, child.IssueRequired),
+		RequireSelfApproval: selectBool(a.RequireSelfApproval, child.RequireSelfApproval),
+		LgtmActsAsApprove:   selectBool(a.LgtmActsAsApprove, child.LgtmActsAsApprove),
+		IgnoreReviewState:   selectBool(a.IgnoreReviewState, child.IgnoreReviewState),
+		CommandHelpLink:     selectString(a.CommandHelpLink, child.CommandHelpLink),
+		PrProcessLink:       selectString(a.PrProcessLink, child.PrProcessLink),
+	}
+}
+
+// Apply returns the lgtm config of a repo that inherits the settings of its
+// org unless it overrides them.
+func (l Lgtm) Apply(child Lgtm) Lgtm {
+	return Lgtm{
+		Repos:            child.Repos,
+		ReviewActsAsLgtm: selectBool(l.ReviewActsAsLgtm, child.ReviewActsAsLgtm),
+		StoreTreeHash:    selectBool(l.StoreTreeHash, child.StoreTreeHash),
+		StickyLgtmTeam:   selectString(l.StickyLgtmTeam, child.StickyLgtmTeam),
+	}
+}
+
+// Apply returns the trigger config of a repo that inherits the settings of
+// its org unless it overrides them.
+func (t Trigger) Apply(child Trigger) Trigger {
+	smartRetest := t.SmartRetest
+	if child.SmartRetest != nil {
+		smartRetest = child.SmartRetest
+	}
+	return Trigger{
+		Repos:                  child.Repos,
+		TrustedApps:            selectStrings(t.TrustedApps, child.TrustedApps),
+		TrustedOrg:             selectString(t.TrustedOrg, child.TrustedOrg),
+		JoinOrgURL:             selectString(t.JoinOrgURL, child.JoinOrgURL),
+		OnlyOrgMembers:         selectBool(t.OnlyOrgMembers, child.OnlyOrgMembers),
+		IgnoreOkToTest:         selectBool(t.IgnoreOkToTest, child.IgnoreOkToTest),
+		TriggerGitHubWorkflows: selectBool(t.TriggerGitHubWorkflows, child.TriggerGitHubWorkflows),
+		SmartRetest:            smartRetest,
+	}
+}
+
+// Apply returns the dco config of a repo or org that inherits the settings of
+// its parent unless it overrides them.
+func (d Dco) Apply(child Dco) Dco {
+	return Dco{
+		SkipDCOCheckForMembers:       selectBool(d.SkipDCOCheckForMembers, child.SkipDCOCheckForMembers),
+		TrustedApps:                  selectStrings(d.TrustedApps, child.TrustedApps),
+		TrustedOrg:                   selectString(d.TrustedOrg, child.TrustedOrg),
+		SkipDCOCheckForCollaborators: selectBool(d.SkipDCOCheckForCollaborators, child.SkipDCOCheckForCollaborators),
+		ContributingRepo:             selectString(d.ContributingRepo, child.ContributingRepo),
+		ContributingBranch:           selectString(d.ContributingBranch, child.ContributingBranch),
+		ContributingPath:             selectString(d.ContributingPath, child.ContributingPath),
+	}
+}
+
 // ApproveFor finds the Approve for a repo, if one exists.
 // Approval configuration can be listed for a repository
-// or an organization.
+// or an organization. A repository inherits the settings
+// of its organization that it does not override.
 func (c *Configuration) ApproveFor(org, repo string) *Approve {
 	fullName := fmt.Sprintf("%s/%s", org, repo)
 
 	a := func() *Approve {
-		// First search for repo config
-		for _, approve := range c.Approve {
-			if !sets.New[string](approve.Repos...).Has(fullName) {
-				continue
+		var orgApprove, repoApprove *Approve
+		for i := range c.Approve {
+			repos := sets.New[string](c.Approve[i].Repos...)
+			if repoApprove == nil && repos.Has(fullName) {
+				repoApprove = &c.Approve[i]
+			}
+			if orgApprove == nil && repos.Has(org) {
+				orgApprove = &c.Approve[i]
 			}
-			return &approve
 		}
 
-		// If you don't find anything, loop again looking for an org config
-		for _, approve := range c.Approve {
-			if !sets.New[string](approve.Repos...).Has(org) {
-				continue
-			}
+		switch {
+		case orgApprove != nil && repoApprove != nil:
+			approve := orgApprove.Apply(*repoApprove)
+			return &approve
+		case repoApprove != nil:
+			approve := *repoApprove
+			return &approve
+		case orgApprove != nil:
+			approve := *orgApprove
 			return &approve
 		}
 
@@ -883,43 +1088,56 @@ func (c *Configuration) ApproveFor(org, repo string) *Approve {
 
 // LgtmFor finds the Lgtm for a repo, if one exists
 // a trigger can be listed for the repo itself or for the
-// owning organization
+// owning organization, whose settings the repo inherits
+// unless it overrides them
 func (c *Configuration) LgtmFor(org, repo string) *Lgtm {
 	fullName := fmt.Sprintf("%s/%s", org, repo)
-	for _, lgtm := range c.Lgtm {
-		if !sets.New[string](lgtm.Repos...).Has(fullName) {
-			continue
+	var orgLgtm, repoLgtm *Lgtm
+	for i := range c.Lgtm {
+		repos := sets.New[string](c.Lgtm[i].Repos...)
+		if repoLgtm == nil && repos.Has(fullName) {
+			repoLgtm = &c.Lgtm[i]
 		}
-		return &lgtm
-	}
-	// If you don't find anything, loop again looking for an org config
-	for _, lgtm := range c.Lgtm {
-		if !sets.New[string](lgtm.Repos...).Has(org) {
-			continue
+		if orgLgtm == nil && repos.Has(org) {
+			orgLgtm = &c.Lgtm[i]
 		}
-		return &lgtm
 	}
-	return &Lgtm{}
+	lgtm := Lgtm{}
+	switch {
+	case orgLgtm != nil && repoLgtm != nil:
+		lgtm = orgLgtm.Apply(*repoLgtm)
+	case repoLgtm != nil:
+		lgtm = *repoLgtm
+	case orgLgtm != nil:
+		lgtm = *orgLgtm
+	}
+	return &lgtm
 }
 
 // TriggerFor finds the Trigger for a repo, if one exists
 // a trigger can be listed for the repo itself or for the
-// owning organization
+// owning organization, whose settings the repo inherits
+// unless it overrides them
 func (c *Configuration) TriggerFor(org, repo string) Trigger {
 	fullName := fmt.Sprintf("%s/%s", org, repo)
-	// Prioritize repo level triggers over org level triggers.
-	for _, trigger := range c.Triggers {
-		if !sets.NewString(trigger.Repos...).Has(fullName) {
-			continue
+	var orgTrigger, repoTrigger *Trigger
+	for i := range c.Triggers {
+		repos := sets.NewString(c.Triggers[i].Repos...)
+		if repoTrigger == nil && repos.Has(fullName) {
+			repoTrigger = &c.Triggers[i]
 		}
-		return trigger
-	}
-	// If you don't find anything, loop again looking for an org config
-	for _, trigger := range c.Triggers {
-		if !sets.NewString(trigger.Repos...).Has(org) {
-			continue
+		if orgTrigger == nil && repos.Has(org) {
+			orgTrigger = &c.Triggers[i]
 		}
-		return trigger
+	}
+	// Prioritize repo level settings over org level settings.
+	switch {
+	case orgTrigger != nil && repoTrigger != nil:
+		return orgTrigger.Apply(*repoTrigger)
+	case repoTrigger != nil:
+		return *repoTrigger
+	case orgTrigger != nil:
+		return *orgTrigger
 	}
 
 	var tr Trigger
@@ -927,26 +1145,49 @@ func (c *Configuration) TriggerFor(org, repo string) Trigger {
 	return tr
 }
 
+// OnlyTrustsOrgMembers reports whether only org members are trusted.
+func (t Trigger) OnlyTrustsOrgMembers() bool {
+	return isTrue(t.OnlyOrgMembers)
+}
+
+// IgnoresOkToTest reports whether /ok-to-test comments are ignored.
+func (t Trigger) IgnoresOkToTest() bool {
+	return isTrue(t.IgnoreOkToTest)
+}
+
+// TriggersGitHubWorkflows reports whether GitHub workflows can be triggered.
+func (t Trigger) TriggersGitHubWorkflows() bool {
+	return isTrue(t.TriggerGitHubWorkflows)
+}
+
 func (t *Trigger) SetDefaults() {
 	if t.TrustedOrg != "" && t.JoinOrgURL == "" {
 		t.JoinOrgURL = fmt.Sprintf("https://github.com/orgs/%s/people", t.TrustedOrg)
 	}
 }
 
+// SkipsDCOCheckForMembers reports whether commits of trusted org members skip the DCO check.
+func (d Dco) SkipsDCOCheckForMembers() bool {
+	return isTrue(d.SkipDCOCheckForMembers)
+}
+
+// SkipsDCOCheckForCollaborators reports whether commits of repo collaborators skip the DCO check.
+func (d Dco) SkipsDCOCheckForCollaborators() bool {
+	return isTrue(d.SkipDCOCheckForCollaborators)
+}
+
 // DcoFor finds the Dco for a repo, if one exists
-// a Dco can be listed for the repo itself or for the
-// owning organization
+// a Dco can be listed for the repo itself, for the
+// owning organization or globally, and inherits the
+// settings of the broader levels it does not override
 func (c *Configuration) DcoFor(org, repo string) *Dco {
-	if c.Dco[fmt.Sprintf("%s/%s", org, repo)] != nil {
-		return c.Dco[fmt.Sprintf("%s/%s", org, repo)]
-	}
-	if c.Dco[org] != nil {
-		return c.Dco[org]
-	}
-	if c.Dco["*"] != nil {
-		return c.Dco["*"]
+	dco := Dco{}
+	for _, orgRepo := range []string{"*", org, fmt.Sprintf("%s/%s", org, repo)} {
+		if c.Dco[orgRepo] != nil {
+			dco = dco.Apply(*c.Dco[orgRepo])
+		}
 	}
-	return &Dco{}
+	return &dco
 }
 
 func OldToNewPlugins(oldPlugins map[string][]string) Plugins {
@@ -999,6 +1240,16 @@ func (c *Configuration) EnabledReposForPlugin(plugin string) (orgs, repos []stri
 			}
 		}
 	}
+	// repos may opt out of a plugin inherited from their org
+	for repo, plugins := range c.Plugins {
+		if !strings.Contains(repo, "/") {
+			continue
+		}
+		org := strings.Split(repo, "/")[0]
+		if exceptions, ok := orgExceptions[org]; ok && sets.New[string](plugins.DisabledPlugins...).Has(plugin) {
+			exceptions.Insert(repo)
+		}
+	}
 	// <plugin> plugin might be declared in both org and org/repo
 	// in that case, remove repo from org's orgExceptions despite the excluded_repo in org
 	for _, repo := range repos {
@@ -1029,6 +1280,16 @@ func (c *Configuration) EnabledReposForExternalPlugin(plugin string) (orgs, repo
 	return
 }
 
+// EnabledExternalPlugins returns the external plugins configured for the
+// repo and for its org.
+func (c *Configuration) EnabledExternalPlugins(org, repo string) []ExternalPlugin {
+	plugins := append([]ExternalPlugin{}, c.ExternalPlugins[org]...)
+	if repo != "" {
+		plugins = append(plugins, c.ExternalPlugins[fmt.Sprintf("%s/%s", org, repo)]...)
+	}
+	return plugins
+}
+
 // SetDefaults sets default options for config updating
 func (cu *ConfigUpdater) SetDefaults() {
 	if len(cu.Maps) == 0 {
@@ -1117,6 +1378,29 @@ func validatePluginsDupes(plugins Plugins) error {
 	return utilerrors.NewAggregate(errors)
 }
 
+// validatePluginsOptOut will return an error if excluded_repos is set for a
+// repository, or disabled_plugins is set for an organization or lists plugins
+// that are not enabled for the organization.
+func validatePluginsOptOut(plugins Plugins) error {
+	var errors []error
+	for orgOrRepo, config := range plugins {
+		if !strings.Contains(orgOrRepo, "/") {
+			if len(config.DisabledPlugins) > 0 {
+				errors = append(errors, fmt.Errorf("disabled_plugins is only valid for repositories, but is set for org %s", orgOrRepo))
+			}
+			continue
+		}
+		if len(config.ExcludedRepos) > 0 {
+			errors = append(errors, fmt.Errorf("excluded_repos is only valid for organizations, but is set for repo %s", orgOrRepo))
+		}
+		org := strings.Split(orgOrRepo, "/")[0]
+		if unknown := sets.List(sets.New[string](config.DisabledPlugins...).Difference(sets.New[string](plugins[org].Plugins...))); len(unknown) > 0 {
+			errors = append(errors, fmt.Errorf("plugins %v are disabled for %s but are not enabled for %s", unknown, orgOrRepo, org))
+		}
+	}
+	return utilerrors.NewAggregate(errors)
+}
+
 // ValidatePluginsUnknown will return an error if there are any unrecognized
 // plugins configured.
 func (c *Configuration) ValidatePluginsUnknown() error {
@@ -1128,6 +1412,25 @@ func (c *Configuration) ValidatePluginsUnknown() error {
 			}
 		}
 	}
+	for _, plugins := range c.InstallationPlugins {
+		for _, plugin := range plugins {
+			if _, ok := pluginHelp[plugin]; !ok {
+				errors = append(errors, fmt.Errorf("unknown plugin: %s", plugin))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errors)
+}
+
+// validateInstallationPlugins will return an error if installation_plugins
+// is keyed by anything but GitHub App installation IDs.
+func validateInstallationPlugins(installationPlugins map[string][]string) error {
+	var errors []error
+	for id := range installationPlugins {
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			errors = append(errors, fmt.Errorf("installation_plugins key %q is not a GitHub App installation ID", id))
+		}
+	}
 	return utilerrors.NewAggregate(errors)
 }
 
@@ -1139,6 +1442,22 @@ func validateSizes(size Size) error {
 	return nil
 }
 
+func validateBranchCleaner(bc BranchCleaner) error {
+	var errs []error
+	for repo, report := range bc.StaleBranchReports {
+		if _, _, err := config.SplitRepoName(repo); err != nil {
+			errs = append(errs, fmt.Errorf("stale branch report for %q must be configured by org/repo", repo))
+		}
+		if report.Issue <= 0 {
+			errs = append(errs, fmt.Errorf("stale branch report for %s must set a positive issue number", repo))
+		}
+		if report.StaleAfterMonths < 0 {
+			errs = append(errs, fmt.Errorf("stale branch report for %s must not set a negative stale_after_months", repo))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
 func findDuplicatedPluginConfig(repoConfig, orgConfig []string) []string {
 	var dupes []string
 	for _, repoPlugin := range repoConfig {
@@ -1202,6 +1521,9 @@ func validateConfigUpdater(updater *ConfigUpdater) error {
 		if config.Name != "" && len(config.PartitionedNames) > 0 {
 			return errors.New("'name' and 'partitioned_names' are mutually exclusive in the config_updater plugin configuration")
 		}
+		if config.Sharded && (len(config.PartitionedNames) > 0 || config.Key != "") {
+			return errors.New("'sharded' is mutually exclusive with 'partitioned_names' and 'key' in the config_updater plugin configuration")
+		}
 		name := config.Name
 		if name == "" {
 			name = strings.Join(config.PartitionedNames, ",")
@@ -1313,6 +1635,14 @@ func validateTrigger(triggers []Trigger) error {
 		if trigger.TrustedOrg != "" {
 			logrusutil.ThrottledWarnf(&warnTriggerTrustedOrg, 5*time.Minute, "trusted_org functionality is deprecated. Please ensure your configuration is updated before the end of December 2019.")
 		}
+		if sr := trigger.SmartRetest; sr != nil {
+			if sr.FlakeWindow != nil && sr.FlakeWindow.Duration <= 0 {
+				return fmt.Errorf("trigger for %s: smart_retest.flake_window must be positive", strings.Join(trigger.Repos, ", "))
+			}
+			if sr.MinClusterSize < 0 {
+				return fmt.Errorf("trigger for %s: smart_retest.min_cluster_size must not be negative", strings.Join(trigger.Repos, ", "))
+			}
+		}
 	}
 	return nil
 }
@@ -1398,6 +1728,12 @@ func (c *Configuration) Validate() error {
 	if err := validatePluginsDupes(c.Plugins); err != nil {
 		return err
 	}
+	if err := validatePluginsOptOut(c.Plugins); err != nil {
+		return err
+	}
+	if err := validateInstallationPlugins(c.InstallationPlugins); err != nil {
+		return err
+	}
 	if err := validateExternalPlugins(c.ExternalPlugins); err != nil {
 		return err
 	}
@@ -1410,6 +1746,9 @@ func (c *Configuration) Validate() error {
 	if err := validateSizes(c.Size); err != nil {
 		return err
 	}
+	if err := validateBranchCleaner(c.BranchCleaner); err != nil {
+		return err
+	}
 	if err := validateRequireMatchingLabel(c.RequireMatchingLabel); err != nil {
 		return err
 	}
@@ -1971,6 +2310,48 @@ type BranchCleaner struct {
 	// branches in this allow map would be exempt from branch gc
 	// even if the branches are already merged into the target branch
 	PreservedBranches map[string][]string `json:"preserved_branches,omitempty"`
+	// RestrictAuthors limits the deletion of merged branches to pull requests
+	// authored by the bot or by one of the OptedInAuthors.
+	RestrictAuthors bool `json:"restrict_authors,omitempty"`
+	// OptedInAuthors are the users whose branches are deleted after their
+	// pull requests merged when RestrictAuthors is set.
+	OptedInAuthors []string `json:"opted_in_authors,omitempty"`
+	// StaleBranchReports configures reporting branches without recent commits
+	// to an issue, by org/repo. Reports are updated at most once a day after
+	// pushes to the default branch of the repo.
+	StaleBranchReports map[string]StaleBranchReport `json:"stale_branch_reports,omitempty"`
+}
+
+// StaleBranchReport configures the stale branch report of a repo.
+type StaleBranchReport struct {
+	// Issue is the number of the issue in the repo the report is posted to.
+	Issue int `json:"issue"`
+	// StaleAfterMonths is the number of months without commits after which
+	// a branch is reported as stale. Defaults to 3.
+	StaleAfterMonths int `json:"stale_after_months,omitempty"`
+}
+
+// StaleAfter returns the time before which a branch without commits is stale.
+func (r StaleBranchReport) StaleAfter(now time.Time) time.Time {
+	months := r.StaleAfterMonths
+	if months == 0 {
+		months = 3
+	}
+	return now.AddDate(0, -months, 0)
+}
+
+// IsOptedIn checks if the branches of the author are deleted after their
+// pull requests merged.
+func (b *BranchCleaner) IsOptedIn(author, botUser string) bool {
+	if !b.RestrictAuthors || github.NormLogin(author) == github.NormLogin(botUser) {
+		return true
+	}
+	for _, optedIn := range b.OptedInAuthors {
+		if github.NormLogin(optedIn) == github.NormLogin(author) {
+			return true
+		}
+	}
+	return false
 }
 
 // IsPreservedBranch check if the branch is in the preserved branch list or not.
@@ -28,6 +28,7 @@ import (
 	fuzz "github.com/google/gofuzz"
 
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/sets"
 	utilpointer "k8s.io/utils/pointer"
@@ -105,6 +106,80 @@ func TestValidateExternalPlugins(t *testing.T) {
 	}
 }
 
+func TestValidatePluginsOptOut(t *testing.T) {
+	tests := []struct {
+		name        string
+		plugins     Plugins
+		expectedErr bool
+	}{
+		{
+			name: "valid config",
+			plugins: Plugins{
+				"kubernetes":            {Plugins: []string{"cat", "dog"}, ExcludedRepos: []string{"website"}},
+				"kubernetes/test-infra": {Plugins: []string{"yuks"}, DisabledPlugins: []string{"cat"}},
+			},
+		},
+		{
+			name: "disabled_plugins set for org",
+			plugins: Plugins{
+				"kubernetes": {Plugins: []string{"cat", "dog"}, DisabledPlugins: []string{"cat"}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "excluded_repos set for repo",
+			plugins: Plugins{
+				"kubernetes/test-infra": {Plugins: []string{"cat"}, ExcludedRepos: []string{"website"}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "disabled plugin not enabled for org",
+			plugins: Plugins{
+				"kubernetes":            {Plugins: []string{"dog"}},
+				"kubernetes/test-infra": {DisabledPlugins: []string{"cat"}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePluginsOptOut(tc.plugins)
+			if tc.expectedErr != (err != nil) {
+				t.Errorf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateInstallationPlugins(t *testing.T) {
+	tests := []struct {
+		name                string
+		installationPlugins map[string][]string
+		expectedErr         bool
+	}{
+		{
+			name:                "valid config",
+			installationPlugins: map[string][]string{"12345": {"cat"}},
+		},
+		{
+			name:                "key is not an installation ID",
+			installationPlugins: map[string][]string{"kubernetes": {"cat"}},
+			expectedErr:         true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateInstallationPlugins(tc.installationPlugins)
+			if tc.expectedErr != (err != nil) {
+				t.Errorf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
 func TestOwnersFilenames(t *testing.T) {
 	cases := []struct {
 		org      string
@@ -275,6 +350,139 @@ func TestTriggerFor(t *testing.T) {
 	}
 }
 
+func TestOrgSettingsInheritance(t *testing.T) {
+	yes, no := true, false
+	smartRetest := &SmartRetest{MinClusterSize: 5}
+	config := Configuration{
+		Approve: []Approve{
+			{
+				Repos:               []string{"org"},
+				IssueRequired:       &yes,
+				RequireSelfApproval: &yes,
+				PrProcessLink:       "https://org.example/process",
+			},
+			{
+				Repos:               []string{"org/repo"},
+				RequireSelfApproval: &no,
+				CommandHelpLink:     "https://org.example/commands",
+			},
+		},
+		Lgtm: []Lgtm{
+			{Repos: []string{"org"}, StoreTreeHash: &yes, StickyLgtmTeam: "org-team"},
+			{Repos: []string{"org/repo"}, ReviewActsAsLgtm: &yes, StickyLgtmTeam: "repo-team"},
+		},
+		Triggers: []Trigger{
+			{Repos: []string{"org"}, OnlyOrgMembers: &yes, TrustedApps: []string{"app"}, SmartRetest: smartRetest},
+			{Repos: []string{"org/repo"}, TrustedOrg: "trusted"},
+		},
+		Dco: map[string]*Dco{
+			"*":        {ContributingPath: "CONTRIBUTING.md"},
+			"org":      {SkipDCOCheckForMembers: &yes, TrustedOrg: "org"},
+			"org/repo": {TrustedOrg: "repo-org", ContributingBranch: "main"},
+		},
+	}
+	config.setDefaults()
+
+	expectedApprove := &Approve{
+		Repos:               []string{"org/repo"},
+		IssueRequired:       &yes,
+		RequireSelfApproval: &no,
+		CommandHelpLink:     "https://org.example/commands",
+		PrProcessLink:       "https://org.example/process",
+	}
+	if diff := cmp.Diff(expectedApprove, config.ApproveFor("org", "repo")); diff != "" {
+		t.Errorf("unexpected approve config (-want +got):\n%s", diff)
+	}
+
+	expectedLgtm := &Lgtm{
+		Repos:            []string{"org/repo"},
+		ReviewActsAsLgtm: &yes,
+		StoreTreeHash:    &yes,
+		StickyLgtmTeam:   "repo-team",
+	}
+	if diff := cmp.Diff(expectedLgtm, config.LgtmFor("org", "repo")); diff != "" {
+		t.Errorf("unexpected lgtm config (-want +got):\n%s", diff)
+	}
+
+	expectedTrigger := Trigger{
+		Repos:          []string{"org/repo"},
+		TrustedApps:    []string{"app"},
+		TrustedOrg:     "trusted",
+		JoinOrgURL:     "https://github.com/orgs/trusted/people",
+		OnlyOrgMembers: &yes,
+		SmartRetest:    smartRetest,
+	}
+	if diff := cmp.Diff(expectedTrigger, config.TriggerFor("org", "repo")); diff != "" {
+		t.Errorf("unexpected trigger config (-want +got):\n%s", diff)
+	}
+
+	expectedDco := &Dco{
+		SkipDCOCheckForMembers: &yes,
+		TrustedOrg:             "repo-org",
+		ContributingBranch:     "main",
+		ContributingPath:       "CONTRIBUTING.md",
+	}
+	if diff := cmp.Diff(expectedDco, config.DcoFor("org", "repo")); diff != "" {
+		t.Errorf("unexpected dco config (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(&Dco{ContributingPath: "CONTRIBUTING.md"}, config.DcoFor("other", "repo")); diff != "" {
+		t.Errorf("unexpected global dco config (-want +got):\n%s", diff)
+	}
+}
+
+func TestRepoDisablesOrgSettings(t *testing.T) {
+	yes, no := true, false
+	config := Configuration{
+		Approve: []Approve{
+			{Repos: []string{"org"}, IssueRequired: &yes, LgtmActsAsApprove: &yes},
+			{Repos: []string{"org/repo"}, IssueRequired: &no, LgtmActsAsApprove: &no},
+		},
+		Lgtm: []Lgtm{
+			{Repos: []string{"org"}, ReviewActsAsLgtm: &yes, StoreTreeHash: &yes},
+			{Repos: []string{"org/repo"}, ReviewActsAsLgtm: &no, StoreTreeHash: &no},
+		},
+		Triggers: []Trigger{
+			{Repos: []string{"org"}, OnlyOrgMembers: &yes, IgnoreOkToTest: &yes, TriggerGitHubWorkflows: &yes},
+			{Repos: []string{"org/repo"}, OnlyOrgMembers: &no, IgnoreOkToTest: &no, TriggerGitHubWorkflows: &no},
+		},
+		Dco: map[string]*Dco{
+			"org":      {SkipDCOCheckForMembers: &yes, SkipDCOCheckForCollaborators: &yes},
+			"org/repo": {SkipDCOCheckForMembers: &no, SkipDCOCheckForCollaborators: &no},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		repo     string
+		expected bool
+	}{
+		{name: "org settings apply to repos that do not override them", repo: "other", expected: true},
+		{name: "repo turns off the settings its org turns on", repo: "repo", expected: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			approve := config.ApproveFor("org", tc.repo)
+			lgtm := config.LgtmFor("org", tc.repo)
+			trigger := config.TriggerFor("org", tc.repo)
+			dco := config.DcoFor("org", tc.repo)
+			for setting, actual := range map[string]bool{
+				"issue_required":                   approve.RequiresIssue(),
+				"lgtm_acts_as_approve":             approve.LgtmActsAsApproval(),
+				"review_acts_as_lgtm":              lgtm.ReviewsActAsLgtm(),
+				"store_tree_hash":                  lgtm.StoresTreeHash(),
+				"only_org_members":                 trigger.OnlyTrustsOrgMembers(),
+				"ignore_ok_to_test":                trigger.IgnoresOkToTest(),
+				"trigger_github_workflows":         trigger.TriggersGitHubWorkflows(),
+				"skip_dco_check_for_members":       dco.SkipsDCOCheckForMembers(),
+				"skip_dco_check_for_collaborators": dco.SkipsDCOCheckForCollaborators(),
+			} {
+				if actual != tc.expected {
+					t.Errorf("expected %s to be %t for org/%s, got %t", setting, tc.expected, tc.repo, actual)
+				}
+			}
+		})
+	}
+}
+
 func TestSetApproveDefaults(t *testing.T) {
 	c := &Configuration{
 		Approve: []Approve{
@@ -1417,6 +1625,33 @@ func TestValidateConfigUpdater(t *testing.T) {
 			},
 			expected: nil,
 		},
+		{
+			name: "sharded cm",
+			cu: &ConfigUpdater{
+				Maps: map[string]ConfigMapSpec{
+					"config/jobs/**/*.yaml": {
+						Name:     "job-config",
+						Sharded:  true,
+						Clusters: map[string][]string{"default": {""}},
+					},
+				},
+			},
+			expected: nil,
+		},
+		{
+			name: "sharded cm with a key",
+			cu: &ConfigUpdater{
+				Maps: map[string]ConfigMapSpec{
+					"config/jobs/jobs.yaml": {
+						Name:     "job-config",
+						Key:      "jobs.yaml",
+						Sharded:  true,
+						Clusters: map[string][]string{"default": {""}},
+					},
+				},
+			},
+			expected: fmt.Errorf("'sharded' is mutually exclusive with 'partitioned_names' and 'key' in the config_updater plugin configuration"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1513,6 +1748,9 @@ orgA/repoB:
  plugins:
  - pluginCommon
  - pluginOnlyForRepoB
+orgA/repoC:
+ disabled_plugins:
+ - pluginNotForRepoB
 `)
 	var p Plugins
 	err := yaml.Unmarshal(pluginsYaml, &p)
@@ -1538,7 +1776,7 @@ orgA/repoB:
 			name:              "pluginNotForRepoB",
 			wantOrgs:          []string{"orgA"},
 			wantRepos:         nil,
-			wantExcludedRepos: map[string]sets.Set[string]{"orgA": {"orgA/repoB": {}}},
+			wantExcludedRepos: map[string]sets.Set[string]{"orgA": {"orgA/repoB": {}, "orgA/repoC": {}}},
 		},
 		{
 			name:              "pluginOnlyForRepoB",
@@ -1574,6 +1812,9 @@ orgA/repoB:
  plugins:
  - pluginCommon
  - pluginOnlyForRepoB
+orgA/repoC:
+ disabled_plugins:
+ - pluginNotForRepoB
 `)
 	var p Plugins
 	err := p.UnmarshalJSON(badPluginsYaml)
@@ -2356,3 +2597,77 @@ func TestMergeFrom(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateBranchCleaner(t *testing.T) {
+	testCases := []struct {
+		name      string
+		reports   map[string]StaleBranchReport
+		expectErr bool
+	}{
+		{
+			name:    "valid",
+			reports: map[string]StaleBranchReport{"org/repo": {Issue: 1, StaleAfterMonths: 6}},
+		},
+		{
+			name:      "org instead of repo",
+			reports:   map[string]StaleBranchReport{"org": {Issue: 1}},
+			expectErr: true,
+		},
+		{
+			name:      "missing issue",
+			reports:   map[string]StaleBranchReport{"org/repo": {}},
+			expectErr: true,
+		},
+		{
+			name:      "negative months",
+			reports:   map[string]StaleBranchReport{"org/repo": {Issue: 1, StaleAfterMonths: -1}},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateBranchCleaner(BranchCleaner{StaleBranchReports: tc.reports})
+			if err != nil != tc.expectErr {
+				t.Errorf("expected error: %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateTrigger(t *testing.T) {
+	testCases := []struct {
+		name        string
+		smartRetest *SmartRetest
+		expectErr   bool
+	}{
+		{
+			name: "no smart retest",
+		},
+		{
+			name:        "defaulted smart retest",
+			smartRetest: &SmartRetest{},
+		},
+		{
+			name:        "valid smart retest",
+			smartRetest: &SmartRetest{FlakeWindow: &metav1.Duration{Duration: time.Hour}, MinClusterSize: 2},
+		},
+		{
+			name:        "zero flake window",
+			smartRetest: &SmartRetest{FlakeWindow: &metav1.Duration{}},
+			expectErr:   true,
+		},
+		{
+			name:        "negative min cluster size",
+			smartRetest: &SmartRetest{MinClusterSize: -1},
+			expectErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTrigger([]Trigger{{Repos: []string{"org/repo"}, SmartRetest: tc.smartRetest}})
+			if err != nil != tc.expectErr {
+				t.Errorf("expected error: %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
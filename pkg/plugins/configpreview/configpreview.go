@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package configpreview implements the `/config-preview` command which
+// comments the jobs a PR would have once merged, according to the in-repo
+// config of its head.
+package configpreview
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/moonraker"
+	"sigs.k8s.io/prow/pkg/pluginhelp"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+const pluginName = "config-preview"
+
+var (
+	configPreviewRe = regexp.MustCompile(`(?mi)^/config-preview\s*$`)
+)
+
+type githubClient interface {
+	CreateComment(owner, repo string, number int, comment string) error
+	GetPullRequest(org, repo string, number int) (*github.PullRequest, error)
+	GetRef(org, repo, ref string) (string, error)
+}
+
+type previewClient interface {
+	GetConfigPreview(refs *prowapi.Refs) (*moonraker.ConfigPreview, error)
+}
+
+func init() {
+	plugins.RegisterGenericCommentHandler(pluginName, handleGenericComment, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, _ []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
+	pluginHelp := &pluginhelp.PluginHelp{
+		Description: "The config-preview plugin comments the jobs a PR would have once merged, according to the in-repo config (.prow.yaml or .prow/) of its head. It requires hook to be configured with a Moonraker.",
+	}
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/config-preview",
+		Description: "Comments the presubmits and postsubmits the PR would have once merged, and which in-repo jobs it adds, removes or changes.",
+		Featured:    false,
+		WhoCanUse:   "Anyone can trigger this command on a PR.",
+		Examples:    []string{"/config-preview"},
+	})
+	return pluginHelp, nil
+}
+
+func handleGenericComment(pc plugins.Agent, e github.GenericCommentEvent) error {
+	var pv previewClient
+	// Don't wrap a nil *moonraker.Client into a non-nil interface.
+	if pc.MoonrakerClient != nil {
+		pv = pc.MoonrakerClient
+	}
+	return handle(pc.GitHubClient, pv, pc.Logger, &e)
+}
+
+func handle(gc githubClient, pv previewClient, log *logrus.Entry, e *github.GenericCommentEvent) error {
+	if !e.IsPR || e.IssueState != "open" || e.Action != github.GenericCommentActionCreated {
+		return nil
+	}
+
+	if !configPreviewRe.MatchString(e.Body) {
+		return nil
+	}
+
+	org := e.Repo.Owner.Login
+	repo := e.Repo.Name
+	number := e.Number
+
+	respond := func(resp string) error {
+		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
+	}
+
+	if pv == nil {
+		return respond("Config previews are not available: hook is not configured with a Moonraker.")
+	}
+
+	pr, err := gc.GetPullRequest(org, repo, number)
+	if err != nil {
+		resp := fmt.Sprintf("Cannot get PR #%d in %s/%s: %v", number, org, repo, err)
+		log.Warn(resp)
+		return respond(resp)
+	}
+	baseSHA, err := gc.GetRef(org, repo, "heads/"+pr.Base.Ref)
+	if err != nil {
+		resp := fmt.Sprintf("Cannot get the SHA of branch %s in %s/%s: %v", pr.Base.Ref, org, repo, err)
+		log.Warn(resp)
+		return respond(resp)
+	}
+
+	preview, err := pv.GetConfigPreview(&prowapi.Refs{
+		Org:     org,
+		Repo:    repo,
+		BaseRef: pr.Base.Ref,
+		BaseSHA: baseSHA,
+		Pulls: []prowapi.Pull{{
+			Number: number,
+			Author: pr.User.Login,
+			SHA:    pr.Head.SHA,
+		}},
+	})
+	if err != nil {
+		resp := fmt.Sprintf("Cannot preview the config of PR #%d in %s/%s: %v", number, org, repo, err)
+		log.Warn(resp)
+		return respond(resp)
+	}
+	return respond(formatPreview(pr.Head.SHA, preview))
+}
+
+// formatPreview formats a ConfigPreview into a comment.
+func formatPreview(headSHA string, preview *moonraker.ConfigPreview) string {
+	var b strings.Builder
+	if preview.Error != "" {
+		fmt.Fprintf(&b, "The in-repo config at %s is invalid:\n\n```\n%s\n```", headSHA, preview.Error)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Once merged, the in-repo config at %s gives this repository %d presubmits and %d postsubmits.\n\n", headSHA, len(preview.Presubmits), len(preview.Postsubmits))
+	if len(preview.Diff) == 0 {
+		b.WriteString("It does not add, remove or change any job.\n")
+	} else {
+		b.WriteString("| Job | Type | Change |\n| --- | --- | --- |\n")
+		for _, d := range preview.Diff {
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", d.Name, d.Type, d.Change)
+		}
+	}
+
+	b.WriteString("\n<details>\n<summary>All jobs</summary>\n\n")
+	for _, jobs := range []struct {
+		title string
+		names []string
+	}{
+		{"Presubmits", preview.Presubmits},
+		{"Postsubmits", preview.Postsubmits},
+	} {
+		if len(jobs.names) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:\n", jobs.title)
+		for _, name := range jobs.names {
+			fmt.Fprintf(&b, "- `%s`\n", name)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("</details>")
+	return b.String()
+}
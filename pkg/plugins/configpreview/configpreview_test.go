@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configpreview
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/moonraker"
+)
+
+type fakePreviewClient struct {
+	preview *moonraker.ConfigPreview
+	err     error
+	refs    *prowapi.Refs
+}
+
+func (f *fakePreviewClient) GetConfigPreview(refs *prowapi.Refs) (*moonraker.ConfigPreview, error) {
+	f.refs = refs
+	return f.preview, f.err
+}
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		name string
+
+		body          string
+		noMoonraker   bool
+		preview       *moonraker.ConfigPreview
+		previewErr    error
+		expectRefs    bool
+		expectComment []string
+	}{
+		{
+			name: "other comments are ignored",
+			body: "/test all",
+		},
+		{
+			name:          "no Moonraker",
+			body:          "/config-preview",
+			noMoonraker:   true,
+			expectComment: []string{"hook is not configured with a Moonraker"},
+		},
+		{
+			name: "jobs are diffed",
+			body: "/config-preview",
+			preview: &moonraker.ConfigPreview{
+				Presubmits:  []string{"pull-lint", "pull-unit"},
+				Postsubmits: []string{"post-push"},
+				Diff: []moonraker.JobDiff{
+					{Name: "pull-unit", Type: "presubmit", Change: moonraker.JobAdded},
+					{Name: "post-old", Type: "postsubmit", Change: moonraker.JobRemoved},
+				},
+			},
+			expectRefs: true,
+			expectComment: []string{
+				"2 presubmits and 1 postsubmits",
+				"| `pull-unit` | presubmit | added |",
+				"| `post-old` | postsubmit | removed |",
+				"- `post-push`",
+			},
+		},
+		{
+			name:          "no changes",
+			body:          "/config-preview",
+			preview:       &moonraker.ConfigPreview{Presubmits: []string{"pull-lint"}},
+			expectRefs:    true,
+			expectComment: []string{"It does not add, remove or change any job."},
+		},
+		{
+			name:          "invalid config",
+			body:          "/config-preview",
+			preview:       &moonraker.ConfigPreview{Error: "duplicated presubmit job: pull-lint"},
+			expectRefs:    true,
+			expectComment: []string{"The in-repo config at abcdef is invalid", "duplicated presubmit job: pull-lint"},
+		},
+		{
+			name:          "preview fails",
+			body:          "/config-preview",
+			previewErr:    errors.New("got 400 response"),
+			expectRefs:    true,
+			expectComment: []string{"Cannot preview the config of PR #1 in org/repo: got 400 response"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fghc := fakegithub.NewFakeClient()
+			fghc.PullRequests = map[int]*github.PullRequest{
+				1: {
+					Base: github.PullRequestBranch{Ref: "main"},
+					Head: github.PullRequestBranch{SHA: "abcdef"},
+					User: github.User{Login: "author"},
+				},
+			}
+			event := &github.GenericCommentEvent{
+				IsPR:       true,
+				IssueState: "open",
+				Action:     github.GenericCommentActionCreated,
+				Body:       tc.body,
+				Number:     1,
+				Repo:       github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+			}
+			fpc := &fakePreviewClient{preview: tc.preview, err: tc.previewErr}
+			var pv previewClient = fpc
+			if tc.noMoonraker {
+				pv = nil
+			}
+
+			if err := handle(fghc, pv, logrus.WithField("plugin", pluginName), event); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectRefs {
+				if fpc.refs == nil {
+					t.Fatal("expected a config preview, got none")
+				}
+				if fpc.refs.BaseSHA != fakegithub.TestRef || fpc.refs.BaseRef != "main" || len(fpc.refs.Pulls) != 1 || fpc.refs.Pulls[0].SHA != "abcdef" {
+					t.Errorf("unexpected refs: %+v", fpc.refs)
+				}
+			} else if fpc.refs != nil {
+				t.Errorf("expected no config preview, got one for %+v", fpc.refs)
+			}
+
+			comments := fghc.IssueComments[1]
+			if len(tc.expectComment) == 0 {
+				if len(comments) != 0 {
+					t.Errorf("expected no comment, got %v", comments)
+				}
+				return
+			}
+			if len(comments) != 1 {
+				t.Fatalf("expected one comment, got %d", len(comments))
+			}
+			for _, expected := range tc.expectComment {
+				if !strings.Contains(comments[0].Body, expected) {
+					t.Errorf("expected comment to contain %q, got:\n%s", expected, comments[0].Body)
+				}
+			}
+		})
+	}
+}
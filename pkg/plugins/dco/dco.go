@@ -71,16 +71,17 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 	configInfo := map[string]string{}
 	for _, repo := range enabledRepos {
 		opts := config.DcoFor(repo.Org, repo.Repo)
-		if opts.SkipDCOCheckForMembers || opts.SkipDCOCheckForCollaborators {
+		if opts.SkipsDCOCheckForMembers() || opts.SkipsDCOCheckForCollaborators() {
 			configInfo[repo.String()] = fmt.Sprintf("The trusted GitHub organization for this repository is %q.", repo)
 		}
 	}
+	enabled := true
 	yamlSnippet, err := plugins.CommentMap.GenYaml(&plugins.Configuration{
 		Dco: map[string]*plugins.Dco{
 			"org/repo": {
-				SkipDCOCheckForMembers:       true,
+				SkipDCOCheckForMembers:       &enabled,
 				TrustedOrg:                   "org",
-				SkipDCOCheckForCollaborators: true,
+				SkipDCOCheckForCollaborators: &enabled,
 				ContributingRepo:             "other-org/other-repo",
 				ContributingBranch:           "main",
 				ContributingPath:             "docs/CONTRIBUTING.md",
@@ -296,8 +297,8 @@ func handle(config plugins.Dco, gc gitHubClient, cp commentPruner, log *logrus.E
 		return err
 	}
 
-	if config.SkipDCOCheckForMembers || config.SkipDCOCheckForCollaborators {
-		commitsMissingDCO, err = filterTrustedUsers(gc, l, config.SkipDCOCheckForCollaborators, config.TrustedApps, config.TrustedOrg, org, repo, commitsMissingDCO)
+	if config.SkipsDCOCheckForMembers() || config.SkipsDCOCheckForCollaborators() {
+		commitsMissingDCO, err = filterTrustedUsers(gc, l, config.SkipsDCOCheckForCollaborators(), config.TrustedApps, config.TrustedOrg, org, repo, commitsMissingDCO)
 		if err != nil {
 			l.WithError(err).Infof("Error running trusted org member check against commits in PR")
 			return err
@@ -233,7 +233,7 @@ Instructions for interacting with me using PR comments are available [here](http
 		{
 			name: "should add label and update status context if an user is member of the trusted org (commit non-signed)",
 			config: plugins.Dco{
-				SkipDCOCheckForMembers: true,
+				SkipDCOCheckForMembers: &[]bool{true}[0],
 				TrustedOrg:             "kubernetes",
 			},
 			pullRequestEvent: github.PullRequestEvent{
@@ -259,7 +259,7 @@ Instructions for interacting with me using PR comments are available [here](http
 		{
 			name: "should add label and update status context if an user is member of the trusted org (one commit signed, one non-signed)",
 			config: plugins.Dco{
-				SkipDCOCheckForMembers: true,
+				SkipDCOCheckForMembers: &[]bool{true}[0],
 				TrustedOrg:             "kubernetes",
 			},
 			pullRequestEvent: github.PullRequestEvent{
@@ -292,7 +292,7 @@ Instructions for interacting with me using PR comments are available [here](http
 		{
 			name: "should add label and update status context if one commit is signed-off and another is from a trusted user",
 			config: plugins.Dco{
-				SkipDCOCheckForMembers: true,
+				SkipDCOCheckForMembers: &[]bool{true}[0],
 				TrustedOrg:             "kubernetes",
 			},
 			pullRequestEvent: github.PullRequestEvent{
@@ -325,7 +325,7 @@ Instructions for interacting with me using PR comments are available [here](http
 		{
 			name: "should fail dco check as one unsigned commit is from member not from the trusted org",
 			config: plugins.Dco{
-				SkipDCOCheckForMembers: true,
+				SkipDCOCheckForMembers: &[]bool{true}[0],
 				TrustedOrg:             "kubernetes",
 			},
 			pullRequestEvent: github.PullRequestEvent{
@@ -373,7 +373,7 @@ Instructions for interacting with me using PR comments are available [here](http
 		{
 			name: "should add label and update status context as one unsigned commit is from member not from the trusted org",
 			config: plugins.Dco{
-				SkipDCOCheckForMembers: true,
+				SkipDCOCheckForMembers: &[]bool{true}[0],
 				TrustedOrg:             "kubernetes",
 			},
 			pullRequestEvent: github.PullRequestEvent{
@@ -422,7 +422,7 @@ Instructions for interacting with me using PR comments are available [here](http
 		{
 			name: "should fail dco check as skip feature is disabled",
 			config: plugins.Dco{
-				SkipDCOCheckForMembers: false,
+				SkipDCOCheckForMembers: &[]bool{false}[0],
 				TrustedOrg:             "kubernetes",
 			},
 			pullRequestEvent: github.PullRequestEvent{
@@ -463,8 +463,8 @@ Instructions for interacting with me using PR comments are available [here](http
 		{
 			name: "should skip dco check as commit is from a collaborator",
 			config: plugins.Dco{
-				SkipDCOCheckForMembers:       true,
-				SkipDCOCheckForCollaborators: true,
+				SkipDCOCheckForMembers:       &[]bool{true}[0],
+				SkipDCOCheckForCollaborators: &[]bool{true}[0],
 				TrustedOrg:                   "kubernetes",
 			},
 			pullRequestEvent: github.PullRequestEvent{
@@ -490,7 +490,7 @@ Instructions for interacting with me using PR comments are available [here](http
 		{
 			name: "should fail dco check for a collaborator as skip dco for collaborators is disabled",
 			config: plugins.Dco{
-				SkipDCOCheckForCollaborators: false,
+				SkipDCOCheckForCollaborators: &[]bool{false}[0],
 				TrustedOrg:                   "kubernetes",
 			},
 			pullRequestEvent: github.PullRequestEvent{
@@ -747,7 +747,7 @@ Instructions for interacting with me using PR comments are available [here](http
 		{
 			name: "should succeed as skip dco is enabled",
 			config: plugins.Dco{
-				SkipDCOCheckForMembers: true,
+				SkipDCOCheckForMembers: &[]bool{true}[0],
 				TrustedOrg:             "kubernetes",
 			},
 			commentEvent: github.GenericCommentEvent{
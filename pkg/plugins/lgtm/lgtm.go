@@ -75,11 +75,11 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 		var isConfigured bool
 		var configInfoStrings []string
 		configInfoStrings = append(configInfoStrings, "The plugin has the following configuration:<ul>")
-		if opts.ReviewActsAsLgtm {
+		if opts.ReviewsActAsLgtm() {
 			configInfoStrings = append(configInfoStrings, "<li>"+configInfoReviewActsAsLgtm+"</li>")
 			isConfigured = true
 		}
-		if opts.StoreTreeHash {
+		if opts.StoresTreeHash() {
 			configInfoStrings = append(configInfoStrings, "<li>"+configInfoStoreTreeHash+"</li>")
 			isConfigured = true
 		}
@@ -92,13 +92,14 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 			configInfo[repo.String()] = strings.Join(configInfoStrings, "\n")
 		}
 	}
+	enabled := true
 	yamlSnippet, err := plugins.CommentMap.GenYaml(&plugins.Configuration{
 		Lgtm: []plugins.Lgtm{
 			{
 				Repos:            []string{"kubernetes/test-infra"},
-				ReviewActsAsLgtm: true,
+				ReviewActsAsLgtm: &enabled,
 				StickyLgtmTeam:   "team1",
-				StoreTreeHash:    true,
+				StoreTreeHash:    &enabled,
 			},
 		},
 	})
@@ -167,7 +168,7 @@ func handlePullRequestEvent(pc plugins.Agent, pre github.PullRequestEvent) error
 func handlePullRequestReviewEvent(pc plugins.Agent, e github.ReviewEvent) error {
 	// If ReviewActsAsLgtm is disabled, ignore review event.
 	opts := pc.PluginConfig.LgtmFor(e.Repo.Owner.Login, e.Repo.Name)
-	if !opts.ReviewActsAsLgtm {
+	if !opts.ReviewsActAsLgtm() {
 		return nil
 	}
 	cp, err := pc.CommentPruner()
@@ -177,6 +178,28 @@ func handlePullRequestReviewEvent(pc plugins.Agent, e github.ReviewEvent) error
 	return handlePullRequestReview(pc.GitHubClient, pc.PluginConfig, pc.OwnersClient, pc.Logger, cp, e)
 }
 
+// HandleGenericComment handles LGTM commands in comments on pull requests of
+// SCM providers other than GitHub, whose clients implement the parts of the
+// GitHub client that the plugin uses.
+func HandleGenericComment(gc githubClient, config *plugins.Configuration, ownersClient repoowners.Interface, log *logrus.Entry, cp commentPruner, e github.GenericCommentEvent) error {
+	return handleGenericComment(gc, config, ownersClient, log, cp, e)
+}
+
+// HandlePullRequestReview handles reviews of pull requests of SCM providers
+// other than GitHub, like HandleGenericComment.
+func HandlePullRequestReview(gc githubClient, config *plugins.Configuration, ownersClient repoowners.Interface, log *logrus.Entry, cp commentPruner, e github.ReviewEvent) error {
+	if !config.LgtmFor(e.Repo.Owner.Login, e.Repo.Name).ReviewsActAsLgtm() {
+		return nil
+	}
+	return handlePullRequestReview(gc, config, ownersClient, log, cp, e)
+}
+
+// HandlePullRequest handles changes of pull requests of SCM providers other
+// than GitHub, like HandleGenericComment.
+func HandlePullRequest(log *logrus.Entry, gc githubClient, config *plugins.Configuration, pe *github.PullRequestEvent) error {
+	return handlePullRequest(log, gc, config, pe)
+}
+
 func handleGenericComment(gc githubClient, config *plugins.Configuration, ownersClient repoowners.Interface, log *logrus.Entry, cp commentPruner, e github.GenericCommentEvent) error {
 	rc := reviewCtx{
 		author:      e.User.Login,
@@ -335,10 +358,10 @@ func handle(wantLGTM bool, config *plugins.Configuration, ownersClient repoowner
 	opts := config.LgtmFor(rc.repo.Owner.Login, rc.repo.Name)
 	if hasLGTM && !wantLGTM {
 		log.Info("Removing LGTM label.")
-		if err := removeLGTMAndRequestReview(gc, org, repoName, number, getLogins(assignees), opts.StoreTreeHash); err != nil {
+		if err := removeLGTMAndRequestReview(gc, org, repoName, number, getLogins(assignees), opts.StoresTreeHash()); err != nil {
 			return err
 		}
-		if opts.StoreTreeHash {
+		if opts.StoresTreeHash() {
 			cp.PruneComments(func(comment github.IssueComment) bool {
 				return addLGTMLabelNotificationRe.MatchString(comment.Body)
 			})
@@ -349,7 +372,7 @@ func handle(wantLGTM bool, config *plugins.Configuration, ownersClient repoowner
 			return err
 		}
 		if !stickyLgtm(log, gc, config, opts, issueAuthor, org) {
-			if opts.StoreTreeHash {
+			if opts.StoresTreeHash() {
 				pr, err := gc.GetPullRequest(org, repoName, number)
 				if err != nil {
 					log.WithError(err).Error("Failed to get pull request.")
@@ -432,7 +455,7 @@ func handlePullRequest(log *logrus.Entry, gc githubClient, config *plugins.Confi
 		return nil
 	}
 
-	if opts.StoreTreeHash {
+	if opts.StoresTreeHash() {
 		// Check if we have a tree-hash comment
 		var lastLgtmTreeHash string
 		botUserChecker, err := gc.BotUserChecker()
@@ -468,7 +491,7 @@ func handlePullRequest(log *logrus.Entry, gc githubClient, config *plugins.Confi
 		}
 	}
 
-	if err := removeLGTMAndRequestReview(gc, org, repo, number, getLogins(pe.PullRequest.Assignees), opts.StoreTreeHash); err != nil {
+	if err := removeLGTMAndRequestReview(gc, org, repo, number, getLogins(pe.PullRequest.Assignees), opts.StoresTreeHash()); err != nil {
 		return fmt.Errorf("failed removing lgtm label: %w", err)
 	}
 
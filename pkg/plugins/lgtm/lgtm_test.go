@@ -424,7 +424,7 @@ func TestLGTMComment(t *testing.T) {
 		}
 		pc.Lgtm = append(pc.Lgtm, plugins.Lgtm{
 			Repos:         []string{"org/repo"},
-			StoreTreeHash: true,
+			StoreTreeHash: &[]bool{true}[0],
 		})
 		fp := &fakePruner{
 			GitHubClient:  fc,
@@ -787,7 +787,7 @@ func TestLGTMFromApproveReview(t *testing.T) {
 		pc := &plugins.Configuration{}
 		pc.Lgtm = append(pc.Lgtm, plugins.Lgtm{
 			Repos:         []string{"org/repo"},
-			StoreTreeHash: tc.storeTreeHash,
+			StoreTreeHash: &tc.storeTreeHash,
 		})
 		fp := &fakePruner{
 			GitHubClient:  fc,
@@ -1169,7 +1169,7 @@ func TestHandlePullRequest(t *testing.T) {
 			pc := &plugins.Configuration{}
 			pc.Lgtm = append(pc.Lgtm, plugins.Lgtm{
 				Repos:          []string{"kubernetes/kubernetes"},
-				StoreTreeHash:  true,
+				StoreTreeHash:  &[]bool{true}[0],
 				StickyLgtmTeam: c.trustedTeam,
 			})
 			err := handlePullRequest(
@@ -1248,7 +1248,7 @@ func TestAddTreeHashComment(t *testing.T) {
 			pc := &plugins.Configuration{}
 			pc.Lgtm = append(pc.Lgtm, plugins.Lgtm{
 				Repos:          []string{"kubernetes/kubernetes"},
-				StoreTreeHash:  true,
+				StoreTreeHash:  &[]bool{true}[0],
 				StickyLgtmTeam: c.trustedTeam,
 			})
 			rc := reviewCtx{
@@ -1306,7 +1306,7 @@ func TestRemoveTreeHashComment(t *testing.T) {
 	pc := &plugins.Configuration{}
 	pc.Lgtm = append(pc.Lgtm, plugins.Lgtm{
 		Repos:         []string{"kubernetes/kubernetes"},
-		StoreTreeHash: true,
+		StoreTreeHash: &[]bool{true}[0],
 	})
 	rc := reviewCtx{
 		author:      "collab1",
@@ -1374,7 +1374,7 @@ func TestHelpProvider(t *testing.T) {
 				Lgtm: []plugins.Lgtm{
 					{
 						Repos:         []string{"org2/repo"},
-						StoreTreeHash: true,
+						StoreTreeHash: &[]bool{true}[0],
 					},
 				},
 			},
@@ -1388,8 +1388,8 @@ func TestHelpProvider(t *testing.T) {
 				Lgtm: []plugins.Lgtm{
 					{
 						Repos:            []string{"org2/repo"},
-						ReviewActsAsLgtm: true,
-						StoreTreeHash:    true,
+						ReviewActsAsLgtm: &[]bool{true}[0],
+						StoreTreeHash:    &[]bool{true}[0],
 						StickyLgtmTeam:   "team1",
 					},
 				},
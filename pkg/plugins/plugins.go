@@ -24,12 +24,11 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
-	"k8s.io/apimachinery/pkg/util/sets"
-
 	"sigs.k8s.io/prow/pkg/genyaml"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -46,6 +45,7 @@ import (
 	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/jira"
+	"sigs.k8s.io/prow/pkg/moonraker"
 	"sigs.k8s.io/prow/pkg/pluginhelp"
 	"sigs.k8s.io/prow/pkg/repoowners"
 	"sigs.k8s.io/prow/pkg/slack"
@@ -182,6 +182,8 @@ type Agent struct {
 	SlackClient               *slack.Client
 	BugzillaClient            bugzilla.Client
 	JiraClient                jira.Client
+	// MoonrakerClient may be nil if hook is not configured with a Moonraker.
+	MoonrakerClient *moonraker.Client
 
 	OwnersClient repoowners.Interface
 
@@ -220,6 +222,7 @@ func NewAgent(configAgent *config.Agent, pluginConfigAgent *ConfigAgent, clientA
 		OwnersClient:              clientAgent.OwnersClient.WithFields(logger.Data).WithGitHubClient(gitHubClient).ForPlugin(plugin),
 		BugzillaClient:            clientAgent.BugzillaClient.WithFields(logger.Data).ForPlugin(plugin),
 		JiraClient:                jiraClient,
+		MoonrakerClient:           clientAgent.MoonrakerClient,
 		Metrics:                   metrics,
 		Config:                    prowConfig,
 		PluginConfig:              pluginConfig,
@@ -265,16 +268,31 @@ type ClientAgent struct {
 	OwnersClient              repoowners.Interface
 	BugzillaClient            bugzilla.Client
 	JiraClient                jira.Client
+	// MoonrakerClient is nil unless hook is configured with a Moonraker.
+	MoonrakerClient *moonraker.Client
 }
 
-// ConfigAgent contains the agent mutex and the Agent configuration.
+// ConfigAgent holds the current plugin Configuration. Reloaded configurations
+// are swapped in atomically, and the handlers of an event are looked up in a
+// single snapshot, so that enabling, disabling and configuring plugins takes
+// effect without restarting hook.
 type ConfigAgent struct {
-	mut           sync.Mutex
+	current atomic.Pointer[configSnapshot]
+	// installationFor resolves the GitHub App installation that covers a
+	// repo, if installations are tracked.
+	installationFor func(org, repo string) (int64, bool)
+}
+
+// configSnapshot is a configuration together with when it was loaded.
+type configSnapshot struct {
 	configuration *Configuration
+	loaded        time.Time
 }
 
-func NewFakeConfigAgent() ConfigAgent {
-	return ConfigAgent{configuration: &Configuration{}}
+func NewFakeConfigAgent() *ConfigAgent {
+	pa := &ConfigAgent{}
+	pa.Set(&Configuration{})
+	return pa
 }
 
 // Load attempts to load config from the path. It returns an error if either
@@ -363,9 +381,20 @@ func (pa *ConfigAgent) Load(path string, supplementalPluginConfigDirs []string,
 
 // Config returns the agent current Configuration.
 func (pa *ConfigAgent) Config() *Configuration {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-	return pa.configuration
+	return pa.snapshot().configuration
+}
+
+// Loaded returns when the current Configuration was set.
+func (pa *ConfigAgent) Loaded() time.Time {
+	return pa.snapshot().loaded
+}
+
+// snapshot returns the current configuration, which is empty if none was set.
+func (pa *ConfigAgent) snapshot() configSnapshot {
+	if current := pa.current.Load(); current != nil {
+		return *current
+	}
+	return configSnapshot{}
 }
 
 // Set attempts to set the plugins that are enabled on repos. Plugins are listed
@@ -373,9 +402,14 @@ func (pa *ConfigAgent) Config() *Configuration {
 // Specifying simply an org name will also work, and will enable the plugin on
 // all repos in the org.
 func (pa *ConfigAgent) Set(pc *Configuration) {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-	pa.configuration = pc
+	pa.current.Store(&configSnapshot{configuration: pc, loaded: time.Now()})
+}
+
+// SetInstallationResolver makes the agent enable the installation_plugins of
+// the GitHub App installation that covers a repo, as resolved by
+// installationFor. It must be called before the agent is used.
+func (pa *ConfigAgent) SetInstallationResolver(installationFor func(org, repo string) (int64, bool)) {
+	pa.installationFor = installationFor
 }
 
 // Start starts polling path for plugin config. If the first attempt fails,
@@ -399,9 +433,6 @@ func (pa *ConfigAgent) Start(path string, supplementalPluginConfigDirs []string,
 
 // GenericCommentHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) GenericCommentHandlers(owner, repo string) map[string]GenericCommentHandler {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-
 	hs := map[string]GenericCommentHandler{}
 	for _, p := range pa.getPlugins(owner, repo) {
 		if h, ok := genericCommentHandlers[p]; ok {
@@ -413,9 +444,6 @@ func (pa *ConfigAgent) GenericCommentHandlers(owner, repo string) map[string]Gen
 
 // IssueHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) IssueHandlers(owner, repo string) map[string]IssueHandler {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-
 	hs := map[string]IssueHandler{}
 	for _, p := range pa.getPlugins(owner, repo) {
 		if h, ok := issueHandlers[p]; ok {
@@ -427,9 +455,6 @@ func (pa *ConfigAgent) IssueHandlers(owner, repo string) map[string]IssueHandler
 
 // IssueCommentHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) IssueCommentHandlers(owner, repo string) map[string]IssueCommentHandler {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-
 	hs := map[string]IssueCommentHandler{}
 	for _, p := range pa.getPlugins(owner, repo) {
 		if h, ok := issueCommentHandlers[p]; ok {
@@ -442,9 +467,6 @@ func (pa *ConfigAgent) IssueCommentHandlers(owner, repo string) map[string]Issue
 
 // PullRequestHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) PullRequestHandlers(owner, repo string) map[string]PullRequestHandler {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-
 	hs := map[string]PullRequestHandler{}
 	for _, p := range pa.getPlugins(owner, repo) {
 		if h, ok := pullRequestHandlers[p]; ok {
@@ -457,9 +479,6 @@ func (pa *ConfigAgent) PullRequestHandlers(owner, repo string) map[string]PullRe
 
 // ReviewEventHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) ReviewEventHandlers(owner, repo string) map[string]ReviewEventHandler {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-
 	hs := map[string]ReviewEventHandler{}
 	for _, p := range pa.getPlugins(owner, repo) {
 		if h, ok := reviewEventHandlers[p]; ok {
@@ -472,9 +491,6 @@ func (pa *ConfigAgent) ReviewEventHandlers(owner, repo string) map[string]Review
 
 // ReviewCommentEventHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) ReviewCommentEventHandlers(owner, repo string) map[string]ReviewCommentEventHandler {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-
 	hs := map[string]ReviewCommentEventHandler{}
 	for _, p := range pa.getPlugins(owner, repo) {
 		if h, ok := reviewCommentEventHandlers[p]; ok {
@@ -487,9 +503,6 @@ func (pa *ConfigAgent) ReviewCommentEventHandlers(owner, repo string) map[string
 
 // StatusEventHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) StatusEventHandlers(owner, repo string) map[string]StatusEventHandler {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-
 	hs := map[string]StatusEventHandler{}
 	for _, p := range pa.getPlugins(owner, repo) {
 		if h, ok := statusEventHandlers[p]; ok {
@@ -502,9 +515,6 @@ func (pa *ConfigAgent) StatusEventHandlers(owner, repo string) map[string]Status
 
 // PushEventHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) PushEventHandlers(owner, repo string) map[string]PushEventHandler {
-	pa.mut.Lock()
-	defer pa.mut.Unlock()
-
 	hs := map[string]PushEventHandler{}
 	for _, p := range pa.getPlugins(owner, repo) {
 		if h, ok := pushEventHandlers[p]; ok {
@@ -515,16 +525,35 @@ func (pa *ConfigAgent) PushEventHandlers(owner, repo string) map[string]PushEven
 	return hs
 }
 
+// ActivePlugins returns the plugins and external plugins that currently
+// handle the events of a repo, and when their configuration was loaded.
+func (pa *ConfigAgent) ActivePlugins(org, repo string) ([]string, []ExternalPlugin, time.Time) {
+	current := pa.snapshot()
+	if current.configuration == nil {
+		return nil, nil, current.loaded
+	}
+	return pa.enabledPlugins(current.configuration, org, repo), current.configuration.EnabledExternalPlugins(org, repo), current.loaded
+}
+
 // getPlugins returns a list of plugins that are enabled on a given (org, repository).
 func (pa *ConfigAgent) getPlugins(owner, repo string) []string {
-	var plugins []string
-
-	fullName := fmt.Sprintf("%s/%s", owner, repo)
-	if !sets.NewString(pa.configuration.Plugins[owner].ExcludedRepos...).Has(repo) {
-		plugins = append(plugins, pa.configuration.Plugins[owner].Plugins...)
+	pc := pa.Config()
+	if pc == nil {
+		return nil
 	}
-	plugins = append(plugins, pa.configuration.Plugins[fullName].Plugins...)
+	return pa.enabledPlugins(pc, owner, repo)
+}
 
+// enabledPlugins returns the plugins enabled for the org and repo and for
+// the GitHub App installation that covers the repo.
+func (pa *ConfigAgent) enabledPlugins(pc *Configuration, owner, repo string) []string {
+	plugins := pc.Plugins.EnabledPlugins(owner, repo)
+	if pa.installationFor == nil {
+		return plugins
+	}
+	if id, ok := pa.installationFor(owner, repo); ok {
+		plugins = append(plugins, pc.InstallationPlugins[strconv.FormatInt(id, 10)]...)
+	}
 	return plugins
 }
 
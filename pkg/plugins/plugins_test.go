@@ -150,7 +150,8 @@ func TestGetPluginsLegacy(t *testing.T) {
 		},
 	}
 	for _, tc := range testcases {
-		pa := ConfigAgent{configuration: &Configuration{Plugins: OldToNewPlugins(tc.pluginMap)}}
+		pa := &ConfigAgent{}
+		pa.Set(&Configuration{Plugins: OldToNewPlugins(tc.pluginMap)})
 
 		plugins := pa.getPlugins(tc.owner, tc.repo)
 		if diff := cmp.Diff(plugins, tc.expectedPlugins); diff != "" {
@@ -196,6 +197,26 @@ func TestGetPlugins(t *testing.T) {
 			repo:            "repo",
 			expectedPlugins: []string{"plugin3"},
 		},
+		{
+			name: "Disabled plugins for org/repo should not be inherited from org",
+			pluginMap: Plugins{
+				"org1":      {Plugins: []string{"plugin1", "plugin2"}},
+				"org1/repo": {Plugins: []string{"plugin3"}, DisabledPlugins: []string{"plugin2"}},
+			},
+			owner:           "org1",
+			repo:            "repo",
+			expectedPlugins: []string{"plugin1", "plugin3"},
+		},
+		{
+			name: "Disabled plugins for org1/repo should not affect org1/other query",
+			pluginMap: Plugins{
+				"org1":      {Plugins: []string{"plugin1", "plugin2"}},
+				"org1/repo": {DisabledPlugins: []string{"plugin2"}},
+			},
+			owner:           "org1",
+			repo:            "other",
+			expectedPlugins: []string{"plugin1", "plugin2"},
+		},
 		{
 			name: "Plugins for org1/repo should not be returned for org2/repo query",
 			pluginMap: Plugins{
@@ -218,7 +239,8 @@ func TestGetPlugins(t *testing.T) {
 		},
 	}
 	for _, tc := range testcases {
-		pa := ConfigAgent{configuration: &Configuration{Plugins: tc.pluginMap}}
+		pa := &ConfigAgent{}
+		pa.Set(&Configuration{Plugins: tc.pluginMap})
 
 		plugins := pa.getPlugins(tc.owner, tc.repo)
 		if diff := cmp.Diff(plugins, tc.expectedPlugins); diff != "" {
@@ -227,6 +249,73 @@ func TestGetPlugins(t *testing.T) {
 	}
 }
 
+func TestGetPluginsForInstallation(t *testing.T) {
+	pa := &ConfigAgent{}
+	pa.Set(&Configuration{
+		Plugins: Plugins{"org": {Plugins: []string{"plugin1"}}},
+		InstallationPlugins: map[string][]string{
+			"1": {"plugin2"},
+			"2": {"plugin3"},
+		},
+	})
+	if diff := cmp.Diff([]string{"plugin1"}, pa.getPlugins("org", "repo")); diff != "" {
+		t.Errorf("plugins without tracked installations differ from expected: %s", diff)
+	}
+
+	pa.SetInstallationResolver(func(org, repo string) (int64, bool) {
+		if org == "org" && repo == "repo" {
+			return 1, true
+		}
+		return 0, false
+	})
+	if diff := cmp.Diff([]string{"plugin1", "plugin2"}, pa.getPlugins("org", "repo")); diff != "" {
+		t.Errorf("plugins of an installed repo differ from expected: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"plugin1"}, pa.getPlugins("org", "other")); diff != "" {
+		t.Errorf("plugins of a repo without installation differ from expected: %s", diff)
+	}
+}
+
+func TestActivePlugins(t *testing.T) {
+	pa := &ConfigAgent{}
+	if plugins, external, loaded := pa.ActivePlugins("org", "repo"); plugins != nil || external != nil || !loaded.IsZero() {
+		t.Errorf("expected nothing to be active without a configuration, got %v, %v, %v", plugins, external, loaded)
+	}
+
+	pa.Set(&Configuration{
+		Plugins: Plugins{"org": {Plugins: []string{"plugin1"}}},
+		ExternalPlugins: map[string][]ExternalPlugin{
+			"org":       {{Name: "external1", Endpoint: "http://external1"}},
+			"org/other": {{Name: "external2", Endpoint: "http://external2"}},
+		},
+	})
+	plugins, external, first := pa.ActivePlugins("org", "repo")
+	if diff := cmp.Diff([]string{"plugin1"}, plugins); diff != "" {
+		t.Errorf("active plugins differ from expected: %s", diff)
+	}
+	if diff := cmp.Diff([]ExternalPlugin{{Name: "external1", Endpoint: "http://external1"}}, external); diff != "" {
+		t.Errorf("active external plugins differ from expected: %s", diff)
+	}
+
+	// A reloaded configuration takes effect immediately.
+	pa.Set(&Configuration{
+		Plugins: Plugins{"org/repo": {Plugins: []string{"plugin2"}}},
+		ExternalPlugins: map[string][]ExternalPlugin{
+			"org/repo": {{Name: "external3", Endpoint: "http://external3"}},
+		},
+	})
+	plugins, external, second := pa.ActivePlugins("org", "repo")
+	if diff := cmp.Diff([]string{"plugin2"}, plugins); diff != "" {
+		t.Errorf("active plugins after reload differ from expected: %s", diff)
+	}
+	if diff := cmp.Diff([]ExternalPlugin{{Name: "external3", Endpoint: "http://external3"}}, external); diff != "" {
+		t.Errorf("active external plugins after reload differ from expected: %s", diff)
+	}
+	if second.Before(first) {
+		t.Errorf("expected the reload time %v not to be before the initial load %v", second, first)
+	}
+}
+
 func TestLoad(t *testing.T) {
 	t.Parallel()
 
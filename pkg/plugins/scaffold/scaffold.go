@@ -0,0 +1,200 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scaffold generates the skeleton of a new plugin: event handlers, a
+// help provider, a validated config struct and unit tests using the fake
+// GitHub client, plus a deployment manifest for external plugins.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var nameRe = regexp.MustCompile(`^[a-z][a-z0-9-]*[a-z0-9]$`)
+
+// pluginImports are the files that link in-tree plugins into hook.
+var pluginImports = []string{
+	"cmd/hook/plugin-imports/plugin-imports.go",
+	"pkg/hook/plugin-imports/plugin-imports.go",
+}
+
+const pluginImportPrefix = `	_ "sigs.k8s.io/prow/pkg/plugins/`
+
+// Options configure the generated plugin.
+type Options struct {
+	// Name is the name of the plugin, e.g. "my-plugin".
+	Name string
+	// External generates an external plugin under cmd/external-plugins
+	// instead of an in-tree plugin under pkg/plugins.
+	External bool
+	// Root is the root of the prow repository to generate the plugin in.
+	Root string
+	// Year is used in the license headers. Defaults to the current year.
+	Year int
+}
+
+// Validate checks that the plugin can be generated.
+func (o Options) Validate() error {
+	if !nameRe.MatchString(o.Name) {
+		return fmt.Errorf("invalid plugin name %q: must consist of lower case letters, digits and dashes", o.Name)
+	}
+	if o.Root == "" {
+		return errors.New("the repository root must be set")
+	}
+	return nil
+}
+
+type templateData struct {
+	Name    string
+	Package string
+	Year    int
+}
+
+type file struct {
+	template string
+	path     string
+}
+
+func (o Options) files() []file {
+	if o.External {
+		dir := filepath.Join("cmd", "external-plugins", o.Name)
+		return []file{
+			{template: "templates/external/main.go.tmpl", path: filepath.Join(dir, "main.go")},
+			{template: "templates/external/plugin.go.tmpl", path: filepath.Join(dir, "plugin", "plugin.go")},
+			{template: "templates/external/plugin_test.go.tmpl", path: filepath.Join(dir, "plugin", "plugin_test.go")},
+			{template: "templates/external/deployment.yaml.tmpl", path: filepath.Join(dir, "deployment.yaml")},
+		}
+	}
+	dir := filepath.Join("pkg", "plugins", o.Name)
+	return []file{
+		{template: "templates/intree/plugin.go.tmpl", path: filepath.Join(dir, o.Name+".go")},
+		{template: "templates/intree/plugin_test.go.tmpl", path: filepath.Join(dir, o.Name+"_test.go")},
+	}
+}
+
+// Render returns the content of the generated files by their path relative
+// to the repository root.
+func Render(o Options) (map[string][]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	data := templateData{Name: o.Name, Package: strings.ReplaceAll(o.Name, "-", ""), Year: o.Year}
+	if data.Year == 0 {
+		data.Year = time.Now().Year()
+	}
+	rendered := map[string][]byte{}
+	for _, f := range o.files() {
+		t, err := template.ParseFS(templates, f.template, "templates/license.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", f.template, err)
+		}
+		var buf bytes.Buffer
+		if err := t.ExecuteTemplate(&buf, filepath.Base(f.template), data); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", f.path, err)
+		}
+		content := buf.Bytes()
+		if strings.HasSuffix(f.path, ".go") {
+			if content, err = format.Source(content); err != nil {
+				return nil, fmt.Errorf("failed to format %s: %w", f.path, err)
+			}
+		}
+		rendered[f.path] = content
+	}
+	return rendered, nil
+}
+
+// Generate writes the plugin to the repository and returns the paths of the
+// files it wrote. Existing files are never overwritten. In-tree plugins are
+// also linked into hook.
+func Generate(o Options) ([]string, error) {
+	rendered, err := Render(o)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for path := range rendered {
+		if _, err := os.Stat(filepath.Join(o.Root, path)); err == nil {
+			return nil, fmt.Errorf("%s already exists", path)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		target := filepath.Join(o.Root, path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		if err := os.WriteFile(target, rendered[path], 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	if o.External {
+		return paths, nil
+	}
+	for _, path := range pluginImports {
+		if err := addPluginImport(filepath.Join(o.Root, path), o.Name); err != nil {
+			return nil, fmt.Errorf("failed to link the plugin into hook: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// addPluginImport adds the blank import of the plugin to the plugin imports
+// file, keeping the imports sorted.
+func addPluginImport(path, name string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(raw), "\n")
+	newImport := pluginImportPrefix + name + `"`
+	insertAt := -1
+	for i, line := range lines {
+		if !strings.HasPrefix(line, pluginImportPrefix) {
+			continue
+		}
+		imported := strings.TrimPrefix(line, pluginImportPrefix)
+		imported = imported[:strings.Index(imported, `"`)]
+		if imported == name {
+			return nil
+		}
+		if imported < name {
+			insertAt = i + 1
+		} else if insertAt == -1 {
+			insertAt = i
+		}
+	}
+	if insertAt == -1 {
+		return fmt.Errorf("no plugin imports found in %s", path)
+	}
+	lines = append(lines[:insertAt], append([]string{newImport}, lines[insertAt:]...)...)
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
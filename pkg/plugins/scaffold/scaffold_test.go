@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		name          string
+		options       Options
+		expectedFiles []string
+		expectErr     bool
+	}{
+		{
+			name:          "in-tree plugin",
+			options:       Options{Name: "my-plugin", Root: "."},
+			expectedFiles: []string{"pkg/plugins/my-plugin/my-plugin.go", "pkg/plugins/my-plugin/my-plugin_test.go"},
+		},
+		{
+			name:    "external plugin",
+			options: Options{Name: "my-plugin", Root: ".", External: true},
+			expectedFiles: []string{
+				"cmd/external-plugins/my-plugin/deployment.yaml",
+				"cmd/external-plugins/my-plugin/main.go",
+				"cmd/external-plugins/my-plugin/plugin/plugin.go",
+				"cmd/external-plugins/my-plugin/plugin/plugin_test.go",
+			},
+		},
+		{
+			name:      "invalid name",
+			options:   Options{Name: "My_Plugin", Root: "."},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rendered, err := Render(tc.options)
+			if err != nil != tc.expectErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectErr, err)
+			}
+			var files []string
+			for path, content := range rendered {
+				files = append(files, path)
+				if strings.Contains(string(content), "{{") || strings.Contains(string(content), "<no value>") {
+					t.Errorf("%s was not fully rendered:\n%s", path, content)
+				}
+			}
+			sort.Strings(files)
+			if diff := cmp.Diff(tc.expectedFiles, files); diff != "" {
+				t.Errorf("unexpected files (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	root := t.TempDir()
+	imports := "package pluginimports\n\nimport (\n" +
+		"\t_ \"sigs.k8s.io/prow/pkg/plugins/approve\" // Import all enabled plugins.\n" +
+		"\t_ \"sigs.k8s.io/prow/pkg/plugins/yuks\"\n" +
+		")\n"
+	for _, path := range pluginImports {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, path), []byte(imports), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := Options{Name: "my-plugin", Root: root}
+	paths, err := Generate(o)
+	if err != nil {
+		t.Fatalf("failed to generate plugin: %v", err)
+	}
+	expectedPaths := []string{
+		"pkg/plugins/my-plugin/my-plugin.go",
+		"pkg/plugins/my-plugin/my-plugin_test.go",
+		"cmd/hook/plugin-imports/plugin-imports.go",
+		"pkg/hook/plugin-imports/plugin-imports.go",
+	}
+	if diff := cmp.Diff(expectedPaths, paths); diff != "" {
+		t.Errorf("unexpected paths (-want +got):\n%s", diff)
+	}
+	expectedImports := "package pluginimports\n\nimport (\n" +
+		"\t_ \"sigs.k8s.io/prow/pkg/plugins/approve\" // Import all enabled plugins.\n" +
+		"\t_ \"sigs.k8s.io/prow/pkg/plugins/my-plugin\"\n" +
+		"\t_ \"sigs.k8s.io/prow/pkg/plugins/yuks\"\n" +
+		")\n"
+	for _, path := range pluginImports {
+		actual, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expectedImports, string(actual)); diff != "" {
+			t.Errorf("unexpected imports in %s (-want +got):\n%s", path, diff)
+		}
+	}
+
+	if _, err := Generate(o); err == nil {
+		t.Error("expected generating the plugin again to fail")
+	}
+}
@@ -18,10 +18,12 @@ package trigger
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/prow/pkg/kube"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/github"
@@ -76,7 +78,7 @@ func handleGenericComment(c Client, trigger plugins.Trigger, gc github.GenericCo
 	}
 
 	// Skip untrusted users comments.
-	trustedResponse, err := TrustedUser(c.GitHubClient, trigger.OnlyOrgMembers, trigger.TrustedApps, trigger.TrustedOrg, commentAuthor, org, repo)
+	trustedResponse, err := TrustedUser(c.GitHubClient, trigger.OnlyTrustsOrgMembers(), trigger.TrustedApps, trigger.TrustedOrg, commentAuthor, org, repo)
 	if err != nil {
 		return fmt.Errorf("error checking trust of %s: %w", commentAuthor, err)
 	}
@@ -117,6 +119,18 @@ func handleGenericComment(c Client, trigger plugins.Trigger, gc github.GenericCo
 		}
 	}
 
+	// `/test ... in <duration>` defers the start of the requested jobs.
+	var runAfter *metav1.Time
+	delay, err := pjutil.RequestedDelay(gc.Body)
+	if err != nil {
+		resp := fmt.Sprintf("Cannot defer the requested jobs: %v.", err)
+		c.Logger.Infof("Commenting \"%s\".", resp)
+		return c.GitHubClient.CreateComment(org, repo, number, plugins.FormatResponseRaw(gc.Body, gc.HTMLURL, commentAuthor, resp))
+	}
+	if delay > 0 {
+		runAfter = &metav1.Time{Time: time.Now().Add(delay)}
+	}
+
 	pr, err := refGetter.PullRequest()
 	if err != nil {
 		return err
@@ -133,13 +147,26 @@ func handleGenericComment(c Client, trigger plugins.Trigger, gc github.GenericCo
 	if needsHelp, note := pjutil.ShouldRespondWithHelp(gc.Body, len(toTest)); needsHelp {
 		return addHelpComment(c.GitHubClient, gc.Body, org, repo, pr.Base.Ref, pr.Number, presubmits, gc.HTMLURL, commentAuthor, note, c.Logger)
 	}
+	if trigger.SmartRetest != nil && (pjutil.RetestRe.MatchString(gc.Body) || pjutil.RetestRequiredRe.MatchString(gc.Body)) {
+		var failing []config.Presubmit
+		toTest, failing, err = smartRetest(c, trigger.SmartRetest, pr, toTest, pjutil.RetestRequiredRe.MatchString(gc.Body))
+		if err != nil {
+			return err
+		}
+		if len(failing) > 0 {
+			resp := consistentFailuresMessage(failing)
+			if err := c.GitHubClient.CreateComment(org, repo, number, plugins.FormatResponseRaw(gc.Body, gc.HTMLURL, commentAuthor, resp)); err != nil {
+				return err
+			}
+		}
+	}
 	// we want to be able to track re-tests separately from the general body of tests
 	additionalLabels := map[string]string{}
 	if pjutil.RetestRe.MatchString(gc.Body) || pjutil.RetestRequiredRe.MatchString(gc.Body) {
 		additionalLabels[kube.RetestLabel] = "true"
 	}
 	// run failed github actions
-	if trigger.TriggerGitHubWorkflows && (pjutil.RetestRe.MatchString(gc.Body) || pjutil.TestAllRe.MatchString(gc.Body)) {
+	if trigger.TriggersGitHubWorkflows() && (pjutil.RetestRe.MatchString(gc.Body) || pjutil.TestAllRe.MatchString(gc.Body)) {
 		headSHA, err := refGetter.HeadSHA()
 		if err != nil {
 			c.Logger.Warnf("headSHA unavailable, failed github actions for pr will not be triggered: %v", pr)
@@ -167,11 +194,11 @@ func handleGenericComment(c Client, trigger plugins.Trigger, gc github.GenericCo
 			}
 		}
 	}
-	return RunRequestedWithLabels(c, pr, baseSHA, toTest, gc.GUID, additionalLabels)
+	return runRequested(c, pr, baseSHA, toTest, gc.GUID, additionalLabels, runAfter)
 }
 
 func HonorOkToTest(trigger plugins.Trigger) bool {
-	return !trigger.IgnoreOkToTest
+	return !trigger.IgnoresOkToTest()
 }
 
 type GitHubClient interface {
@@ -62,6 +62,7 @@ type testcase struct {
 	IssueLabels    []string
 	IgnoreOkToTest bool
 	AddedComment   string
+	Deferred       bool
 }
 
 func TestHandleGenericComment(t *testing.T) {
@@ -220,6 +221,27 @@ func TestHandleGenericComment(t *testing.T) {
 			ShouldBuild:   true,
 			StartsExactly: "pull-jib",
 		},
+		{
+			name: "Deferred test of one job",
+
+			Author:        "trusted-member",
+			Body:          "/test jib in 2h",
+			State:         "open",
+			IsPR:          true,
+			ShouldBuild:   true,
+			StartsExactly: "pull-jib",
+			Deferred:      true,
+		},
+		{
+			name: "Deferred test with an invalid delay",
+
+			Author:       "trusted-member",
+			Body:         "/test jib in soon",
+			State:        "open",
+			IsPR:         true,
+			ShouldBuild:  false,
+			AddedComment: "Cannot defer the requested jobs",
+		},
 		{
 			name: "Retest with one running and one failed, trailing space.",
 
@@ -1341,7 +1363,7 @@ func TestHandleGenericComment(t *testing.T) {
 			}
 
 			trigger := plugins.Trigger{
-				IgnoreOkToTest: tc.IgnoreOkToTest,
+				IgnoreOkToTest: &tc.IgnoreOkToTest,
 			}
 			trigger.SetDefaults()
 
@@ -1368,6 +1390,9 @@ func validate(t *testing.T, actions []clienttesting.Action, g *fakegithub.FakeCl
 		case clienttesting.CreateActionImpl:
 			if prowJob, ok := action.Object.(*prowapi.ProwJob); ok {
 				startedContexts.Insert(prowJob.Spec.Context)
+				if deferred := prowJob.Spec.RunAfter != nil; deferred != tc.Deferred {
+					t.Errorf("expected job %s to be deferred: %t, got %t", prowJob.Spec.Job, tc.Deferred, deferred)
+				}
 			}
 		}
 	}
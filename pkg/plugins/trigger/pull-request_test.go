@@ -95,7 +95,7 @@ func TestTrusted(t *testing.T) {
 			g.IssueComments = map[int][]github.IssueComment{}
 			trigger := plugins.Trigger{
 				TrustedOrg:     "kubernetes",
-				OnlyOrgMembers: tc.onlyOrg,
+				OnlyOrgMembers: &tc.onlyOrg,
 			}
 			var labels []github.Label
 			for _, label := range tc.labels {
@@ -493,7 +493,7 @@ func TestHandlePullRequest(t *testing.T) {
 			}
 			trigger := plugins.Trigger{
 				TrustedOrg:     "org",
-				OnlyOrgMembers: true,
+				OnlyOrgMembers: &[]bool{true}[0],
 			}
 			trigger.SetDefaults()
 			if err := handlePR(c, trigger, pr); err != nil {
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+// smartRetest drops the jobs that are already running on the head of the pull
+// request from the jobs to retest, so concurrent retests of a pull request
+// only trigger each job once. If onlyFlakes is set, it also drops the jobs
+// whose failures don't look like flakes and returns them separately.
+func smartRetest(c Client, sr *plugins.SmartRetest, pr *github.PullRequest, toTest []config.Presubmit, onlyFlakes bool) ([]config.Presubmit, []config.Presubmit, error) {
+	if len(toTest) == 0 {
+		return nil, nil, nil
+	}
+	// A single list serves all the jobs, instead of one request per job.
+	selector := labels.Set{
+		kube.ProwJobTypeLabel: string(prowapi.PresubmitJob),
+		kube.OrgLabel:         pr.Base.Repo.Owner.Login,
+		kube.RepoLabel:        pr.Base.Repo.Name,
+	}.AsSelector().String()
+	pjs, err := c.ProwJobClient.List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list prowjobs: %w", err)
+	}
+	runs := map[string][]prowapi.ProwJob{}
+	for _, pj := range pjs.Items {
+		runs[pj.Spec.Job] = append(runs[pj.Spec.Job], pj)
+	}
+
+	since := time.Now().Add(-sr.GetFlakeWindow())
+	var run, failing []config.Presubmit
+	for _, ps := range toTest {
+		switch pjutil.ClassifyRetest(runs[ps.Name], pr.Number, pr.Head.SHA, onlyFlakes, since, sr.GetMinClusterSize()) {
+		case pjutil.RetestRun:
+			run = append(run, ps)
+		case pjutil.RetestPending:
+			c.Logger.WithField("job", ps.Name).Info("Skipping job that is already running on the head of the pull request.")
+		case pjutil.RetestFailing:
+			c.Logger.WithField("job", ps.Name).Info("Skipping job that failed consistently.")
+			failing = append(failing, ps)
+		}
+	}
+	return run, failing, nil
+}
+
+// consistentFailuresMessage explains why jobs were not retested.
+func consistentFailuresMessage(failing []config.Presubmit) string {
+	var commands []string
+	for _, ps := range failing {
+		commands = append(commands, fmt.Sprintf("* `%s`", ps.RerunCommand))
+	}
+	return fmt.Sprintf("The following required jobs failed consistently, so their failures do not look like flakes and they were not retested:\n%s\n\nUse the commands above to rerun them anyway.", strings.Join(commands, "\n"))
+}
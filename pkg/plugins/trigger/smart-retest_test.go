@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/plugins"
+)
+
+func retestRunOf(job string, pull int, sha string, state prowapi.ProwJobState, created time.Time) prowapi.ProwJob {
+	pj := prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              job + sha + created.String(),
+			Namespace:         "prowjobs",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				kube.ProwJobTypeLabel: string(prowapi.PresubmitJob),
+				kube.OrgLabel:         "org",
+				kube.RepoLabel:        "repo",
+			},
+		},
+		Spec: prowapi.ProwJobSpec{
+			Type: prowapi.PresubmitJob,
+			Job:  job,
+			Refs: &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: pull, SHA: sha}}},
+		},
+		Status: prowapi.ProwJobStatus{State: state},
+	}
+	if state != prowapi.PendingState && state != prowapi.TriggeredState {
+		completed := metav1.NewTime(created.Add(time.Minute))
+		pj.Status.CompletionTime = &completed
+	}
+	return pj
+}
+
+func TestSmartRetest(t *testing.T) {
+	now := time.Now()
+	pr := &github.PullRequest{
+		Number: 1,
+		Head:   github.PullRequestBranch{SHA: "head"},
+		Base: github.PullRequestBranch{
+			Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+		},
+	}
+	fakeProwJobClient := fake.NewSimpleClientset()
+	for _, pj := range []prowapi.ProwJob{
+		retestRunOf("flaky", 1, "head", prowapi.FailureState, now),
+		retestRunOf("flaky", 1, "head", prowapi.SuccessState, now.Add(-time.Hour)),
+		retestRunOf("broken", 1, "head", prowapi.FailureState, now),
+		retestRunOf("running", 1, "head", prowapi.PendingState, now),
+	} {
+		pj := pj
+		if _, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").Create(context.Background(), &pj, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create prowjob: %v", err)
+		}
+	}
+	c := Client{
+		ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+		Logger:        logrus.WithField("plugin", PluginName),
+	}
+	var toTest []config.Presubmit
+	for _, name := range []string{"flaky", "broken", "running", "new"} {
+		toTest = append(toTest, config.Presubmit{JobBase: config.JobBase{Name: name}, RerunCommand: "/test " + name})
+	}
+	names := func(presubmits []config.Presubmit) []string {
+		var names []string
+		for _, ps := range presubmits {
+			names = append(names, ps.Name)
+		}
+		return names
+	}
+
+	run, failing, err := smartRetest(c, &plugins.SmartRetest{}, pr, toTest, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"flaky", "new"}, names(run)); diff != "" {
+		t.Errorf("retested jobs differ from expected (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"broken"}, names(failing)); diff != "" {
+		t.Errorf("failing jobs differ from expected (-want +got):\n%s", diff)
+	}
+	expected := "The following required jobs failed consistently, so their failures do not look like flakes and they were not retested:\n* `/test broken`\n\nUse the commands above to rerun them anyway."
+	if actual := consistentFailuresMessage(failing); actual != expected {
+		t.Errorf("expected message %q, got %q", expected, actual)
+	}
+
+	run, failing, err = smartRetest(c, &plugins.SmartRetest{}, pr, toTest, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"flaky", "broken", "new"}, names(run)); diff != "" {
+		t.Errorf("retested jobs differ from expected (-want +got):\n%s", diff)
+	}
+	if len(failing) != 0 {
+		t.Errorf("expected no failing jobs without only flakes, got %v", names(failing))
+	}
+}
@@ -86,7 +86,11 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 			org = trigger.TrustedOrg
 		}
 		configInfo[repo.String()] = fmt.Sprintf("The trusted GitHub organization for this repository is %q.", org)
+		if trigger.SmartRetest != nil {
+			configInfo[repo.String()] += fmt.Sprintf(" '/retest-required' only reruns failed required jobs that look flaky, i.e. that errored, passed on the same commit or failed on at least %d other PRs in the last %s.", trigger.SmartRetest.GetMinClusterSize(), trigger.SmartRetest.GetFlakeWindow())
+		}
 	}
+	enabled := true
 	yamlSnippet, err := plugins.CommentMap.GenYaml(&plugins.Configuration{
 		Triggers: []plugins.Trigger{
 			{
@@ -95,8 +99,8 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 					"org/repo2",
 				},
 				JoinOrgURL:     "https://github.com/kubernetes/community/blob/master/community-membership.md",
-				OnlyOrgMembers: true,
-				IgnoreOkToTest: true,
+				OnlyOrgMembers: &enabled,
+				IgnoreOkToTest: &enabled,
 			},
 		},
 	})
@@ -122,11 +126,11 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 		Examples:    []string{"/ok-to-test"},
 	})
 	pluginHelp.AddCommand(pluginhelp.Command{
-		Usage:       "/test [<job name>|all]",
-		Description: "Manually starts a/all automatically triggered test job(s). Lists all possible job(s) when no jobs/an invalid job are specified.",
+		Usage:       "/test [<job name>|all] [in <duration>]",
+		Description: "Manually starts a/all automatically triggered test job(s), optionally deferred by up to 24 hours. Lists all possible job(s) when no jobs/an invalid job are specified.",
 		Featured:    true,
 		WhoCanUse:   "Anyone can trigger this command on a trusted PR.",
-		Examples:    []string{"/test all", "/test pull-bazel-test"},
+		Examples:    []string{"/test all", "/test pull-bazel-test", "/test pull-bazel-test in 2h"},
 	})
 	pluginHelp.AddCommand(pluginhelp.Command{
 		Usage:       "/retest",
@@ -135,6 +139,13 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 		WhoCanUse:   "Anyone can trigger this command on a trusted PR.",
 		Examples:    []string{"/retest"},
 	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/retest-required",
+		Description: "Rerun required test jobs that have failed. With smart retest configured, only those whose failures look like flakes are rerun.",
+		Featured:    false,
+		WhoCanUse:   "Anyone can trigger this command on a trusted PR.",
+		Examples:    []string{"/retest-required"},
+	})
 	pluginHelp.AddCommand(pluginhelp.Command{
 		Usage:       "/test ?",
 		Description: "List available test job(s) for a trusted PR.",
@@ -311,15 +322,17 @@ func validateContextOverlap(toRun, toSkip []config.Presubmit) error {
 
 // RunRequested executes the config.Presubmits that are requested
 func RunRequested(c Client, pr *github.PullRequest, baseSHA string, requestedJobs []config.Presubmit, eventGUID string) error {
-	return runRequested(c, pr, baseSHA, requestedJobs, eventGUID, nil)
+	return runRequested(c, pr, baseSHA, requestedJobs, eventGUID, nil, nil)
 }
 
 // RunRequestedWithLabels executes the config.Presubmits that are requested with the additional labels
 func RunRequestedWithLabels(c Client, pr *github.PullRequest, baseSHA string, requestedJobs []config.Presubmit, eventGUID string, labels map[string]string) error {
-	return runRequested(c, pr, baseSHA, requestedJobs, eventGUID, labels)
+	return runRequested(c, pr, baseSHA, requestedJobs, eventGUID, labels, nil)
 }
 
-func runRequested(c Client, pr *github.PullRequest, baseSHA string, requestedJobs []config.Presubmit, eventGUID string, labels map[string]string, millisecondOverride ...time.Duration) error {
+// runRequested executes the requested config.Presubmits. If runAfter is set,
+// the jobs are not started before then.
+func runRequested(c Client, pr *github.PullRequest, baseSHA string, requestedJobs []config.Presubmit, eventGUID string, labels map[string]string, runAfter *metav1.Time, millisecondOverride ...time.Duration) error {
 	var errors []error
 
 	// If the PR is not mergeable (e.g. due to merge conflicts),we will not trigger any jobs,
@@ -331,6 +344,7 @@ func runRequested(c Client, pr *github.PullRequest, baseSHA string, requestedJob
 	for _, job := range requestedJobs {
 		c.Logger.Infof("Starting %s build.", job.Name)
 		pj := pjutil.NewPresubmit(*pr, baseSHA, job, eventGUID, labels, pjutil.RequireScheduling(c.Config.Scheduler.Enabled))
+		pj.Spec.RunAfter = runAfter
 		c.Logger.WithFields(pjutil.ProwJobFields(&pj)).Info("Creating a new prowjob.")
 		if err := createWithRetry(context.TODO(), c.ProwJobClient, &pj, millisecondOverride...); err != nil {
 			c.Logger.WithError(err).Error("Failed to create prowjob.")
@@ -63,8 +63,8 @@ func TestHelpProvider(t *testing.T) {
 						Repos:          []string{"org2/repo"},
 						TrustedOrg:     "org2",
 						JoinOrgURL:     "https://join.me",
-						OnlyOrgMembers: true,
-						IgnoreOkToTest: true,
+						OnlyOrgMembers: &[]bool{true}[0],
+						IgnoreOkToTest: &[]bool{true}[0],
 					},
 				},
 			},
@@ -242,7 +242,7 @@ func TestRunRequested(t *testing.T) {
 				Logger:        logrus.WithField("testcase", testCase.name),
 			}
 
-			err := runRequested(client, testCase.pr, fakegithub.TestRef, testCase.requestedJobs, "event-guid", nil, time.Nanosecond)
+			err := runRequested(client, testCase.pr, fakegithub.TestRef, testCase.requestedJobs, "event-guid", nil, nil, time.Nanosecond)
 			if err == nil && testCase.expectedErr {
 				t.Error("failed to receive an error")
 			}
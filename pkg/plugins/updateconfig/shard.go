@@ -0,0 +1,261 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package updateconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func isSharded(updates []ConfigMapUpdate) bool {
+	for _, upd := range updates {
+		if upd.Sharded {
+			return true
+		}
+	}
+	return false
+}
+
+// updateSharded updates a ConfigMap that is sharded across several
+// ConfigMaps. As keys move between shards when they grow or shrink, all the
+// shards are rewritten from the current content of every key. The ConfigMap
+// with the name holds the index of the shards.
+func updateSharded(fg FileGetter, kc corev1.ConfigMapInterface, name, namespace string, updates []ConfigMapUpdate, bootstrap bool, metrics *prometheus.GaugeVec, logger *logrus.Entry, sha string) (Changes, error) {
+	// In bootstrap mode, the updates are the only keys that we want, like
+	// for other ConfigMaps.
+	readData := !bootstrap || len(updates) == 0
+	index, data, err := readShards(kc, name, readData)
+	if err != nil {
+		return Changes{}, err
+	}
+
+	var changes Changes
+	current := map[string][]byte{}
+	for key, value := range data {
+		current[key] = value
+	}
+	for _, upd := range updates {
+		if upd.Filename == "" {
+			logger.WithField("key", upd.Key).Debug("Deleting key.")
+			changes.track(upd, current, nil)
+			delete(data, upd.Key)
+			continue
+		}
+		value, err := fileValue(fg, upd, logger)
+		if err != nil {
+			return Changes{}, err
+		}
+		changes.track(upd, current, value)
+		data[upd.Key] = value
+	}
+
+	shards, parts := shard(data)
+	newIndex := config.ShardIndex{Shards: []string{}, Parts: parts}
+	for i, shardData := range shards {
+		cm := newConfigMap(config.ShardName(name, i), namespace)
+		for key, value := range shardData {
+			setValue(cm, key, value)
+		}
+		if err := createOrUpdate(kc, cm); err != nil {
+			return Changes{}, err
+		}
+		recordSize(metrics, cm)
+		newIndex.Shards = append(newIndex.Shards, cm.Name)
+	}
+
+	// The index is updated once all the shards it lists are, so that it
+	// never points to parts that do not exist yet.
+	rawIndex, err := json.Marshal(newIndex)
+	if err != nil {
+		return Changes{}, fmt.Errorf("failed to marshal the shard index: %w", err)
+	}
+	cm := newConfigMap(name, namespace)
+	cm.Data[config.ShardIndexFileName] = string(rawIndex)
+	if sha != "" {
+		cm.Data[config.ConfigVersionFileName] = sha
+	}
+	if err := createOrUpdate(kc, cm); err != nil {
+		return Changes{}, err
+	}
+	recordSize(metrics, cm)
+
+	shardNames := sets.New[string](newIndex.Shards...)
+	for _, shardName := range index.Shards {
+		if shardNames.Has(shardName) {
+			continue
+		}
+		logger.WithField("shard", shardName).Info("Deleting shard that is no longer needed.")
+		if err := kc.Delete(context.TODO(), shardName, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return Changes{}, fmt.Errorf("delete config map err: %w", err)
+		}
+	}
+	return changes, nil
+}
+
+// readShards returns the index of the sharded ConfigMap with the name and,
+// if readData is set, the content of its keys, with the files that were
+// split into parts reassembled.
+func readShards(kc corev1.ConfigMapInterface, name string, readData bool) (config.ShardIndex, map[string][]byte, error) {
+	var index config.ShardIndex
+	data := map[string][]byte{}
+	cm, err := kc.Get(context.TODO(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return index, data, nil
+	}
+	if err != nil {
+		return index, nil, fmt.Errorf("failed to fetch current state of configmap: %w", err)
+	}
+	rawIndex, ok := cm.Data[config.ShardIndexFileName]
+	if !ok {
+		// The ConfigMap is not sharded yet, so it holds the keys itself.
+		if readData {
+			addData(data, cm)
+			delete(data, config.ConfigVersionFileName)
+		}
+		return index, data, nil
+	}
+	if err := json.Unmarshal([]byte(rawIndex), &index); err != nil {
+		return index, nil, fmt.Errorf("failed to parse the shard index of configmap %s: %w", name, err)
+	}
+	if !readData {
+		return index, data, nil
+	}
+
+	for _, shardName := range index.Shards {
+		shard, err := kc.Get(context.TODO(), shardName, metav1.GetOptions{})
+		if err != nil {
+			return index, nil, fmt.Errorf("failed to fetch current state of shard %s: %w", shardName, err)
+		}
+		addData(data, shard)
+	}
+	for key, parts := range index.Parts {
+		var value []byte
+		for _, part := range parts {
+			value = append(value, data[part]...)
+			delete(data, part)
+		}
+		data[key] = value
+	}
+	return index, data, nil
+}
+
+func addData(data map[string][]byte, cm *coreapi.ConfigMap) {
+	for key, value := range cm.Data {
+		data[key] = []byte(value)
+	}
+	for key, value := range cm.BinaryData {
+		data[key] = value
+	}
+}
+
+// shard packs the keys into as few shards as it can without any going over
+// the shard size, in the order of the keys. Keys that are too large for a
+// single shard are split into parts, which are returned by key.
+func shard(data map[string][]byte) ([]map[string][]byte, map[string][]string) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var shards []map[string][]byte
+	var size int
+	add := func(key string, value []byte) {
+		if len(shards) == 0 || size+len(key)+len(value) > config.ShardSize {
+			shards = append(shards, map[string][]byte{})
+			size = 0
+		}
+		shards[len(shards)-1][key] = value
+		size += len(key) + len(value)
+	}
+	parts := map[string][]string{}
+	for _, key := range keys {
+		value := data[key]
+		if len(key)+len(value) <= config.ShardSize {
+			add(key, value)
+			continue
+		}
+		for i := 0; len(value) > 0; i++ {
+			part := config.ShardPartKey(key, i)
+			// The first part fills up the current shard.
+			room := config.ShardSize - size - len(part)
+			if len(shards) == 0 || room <= 0 {
+				room = config.ShardSize - len(part)
+			}
+			n := min(len(value), room)
+			add(part, value[:n])
+			parts[key] = append(parts[key], part)
+			value = value[n:]
+		}
+	}
+	if len(parts) == 0 {
+		parts = nil
+	}
+	return shards, parts
+}
+
+func newConfigMap(name, namespace string) *coreapi.ConfigMap {
+	return &coreapi.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels(),
+		},
+		Data:       map[string]string{},
+		BinaryData: map[string][]byte{},
+	}
+}
+
+// createOrUpdate replaces the data of the ConfigMap, creating it if needed.
+// ConfigMaps whose data does not change are not written.
+func createOrUpdate(kc corev1.ConfigMapInterface, cm *coreapi.ConfigMap) error {
+	existing, err := kc.Get(context.TODO(), cm.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := kc.Create(context.TODO(), cm, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("create config map err: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch current state of configmap: %w", err)
+	}
+	if existing.Labels != nil && equality.Semantic.DeepEqual(existing.Data, cm.Data) && equality.Semantic.DeepEqual(existing.BinaryData, cm.BinaryData) {
+		return nil
+	}
+	existing.Data = cm.Data
+	existing.BinaryData = cm.BinaryData
+	if existing.Labels == nil {
+		existing.Labels = cm.Labels
+	}
+	if _, err := kc.Update(context.TODO(), existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update config map err: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package updateconfig
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestUpdateSharded(t *testing.T) {
+	ns, name, commit := "ns", "job-config", "da28634f10160f8c746c387cd33b488909036e1f"
+	log := logrus.NewEntry(logrus.New())
+	small := "periodics: []\n"
+	big := strings.Repeat("#", config.ShardSize*3/2)
+	fs := MapFS{
+		"jobs/small.yaml": {Data: []byte(small)},
+		"jobs/big.yaml":   {Data: []byte(big)},
+		"jobs/other.yaml": {Data: []byte(small)},
+	}
+	client := fake.NewSimpleClientset(&coreapi.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Data:       map[string]string{"old.yaml": small, config.ConfigVersionFileName: "old"},
+	}).CoreV1().ConfigMaps(ns)
+
+	testCases := []struct {
+		name           string
+		updates        []ConfigMapUpdate
+		bootstrap      bool
+		expectedShards []string
+		expectedData   map[string]string
+	}{
+		{
+			name:           "the keys of a ConfigMap that was not sharded yet are kept",
+			updates:        []ConfigMapUpdate{{Key: "small.yaml", Filename: "jobs/small.yaml", Sharded: true}},
+			expectedShards: []string{"job-config-0"},
+			expectedData:   map[string]string{"old.yaml": small, "small.yaml": small},
+		},
+		{
+			name:           "a file too large for a shard is split into parts",
+			updates:        []ConfigMapUpdate{{Key: "big.yaml", Filename: "jobs/big.yaml", Sharded: true}},
+			expectedShards: []string{"job-config-0", "job-config-1"},
+			expectedData:   map[string]string{"big.yaml": big, "old.yaml": small, "small.yaml": small},
+		},
+		{
+			name: "unused shards are deleted",
+			updates: []ConfigMapUpdate{
+				{Key: "big.yaml", Sharded: true},
+				{Key: "other.yaml", Filename: "jobs/other.yaml", Sharded: true},
+			},
+			expectedShards: []string{"job-config-0"},
+			expectedData:   map[string]string{"old.yaml": small, "other.yaml": small, "small.yaml": small},
+		},
+		{
+			name:           "bootstrapping replaces all the keys",
+			updates:        []ConfigMapUpdate{{Key: "big.yaml", Filename: "jobs/big.yaml", Sharded: true}},
+			bootstrap:      true,
+			expectedShards: []string{"job-config-0", "job-config-1"},
+			expectedData:   map[string]string{"big.yaml": big},
+		},
+	}
+	// The cases build on each other.
+	for _, tc := range testCases {
+		if err := Update(fs, client, name, ns, tc.updates, tc.bootstrap, nil, log, commit); err != nil {
+			t.Fatalf("%s: unexpected error updating: %v", tc.name, err)
+		}
+		index, data, err := readShards(client, name, true)
+		if err != nil {
+			t.Fatalf("%s: failed to read the shards: %v", tc.name, err)
+		}
+		if diff := cmp.Diff(tc.expectedShards, index.Shards); diff != "" {
+			t.Errorf("%s: unexpected shards (-want +got):\n%s", tc.name, diff)
+		}
+		actualData := map[string]string{}
+		for key, value := range data {
+			actualData[key] = string(value)
+		}
+		if diff := cmp.Diff(tc.expectedData, actualData); diff != "" {
+			t.Errorf("%s: unexpected data (-want +got):\n%s", tc.name, diff)
+		}
+		cms, err := client.List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("%s: failed to list configmaps: %v", tc.name, err)
+		}
+		if len(cms.Items) != len(tc.expectedShards)+1 {
+			t.Errorf("%s: expected the index and %d shards, got %d configmaps", tc.name, len(tc.expectedShards), len(cms.Items))
+		}
+		for _, cm := range cms.Items {
+			var size int
+			for key, value := range cm.Data {
+				size += len(key) + len(value)
+			}
+			for key, value := range cm.BinaryData {
+				size += len(key) + len(value)
+			}
+			if size > config.ShardSize {
+				t.Errorf("%s: configmap %s holds %d bytes, more than a shard", tc.name, cm.Name, size)
+			}
+		}
+		indexCM, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("%s: failed to get the index: %v", tc.name, err)
+		}
+		if version := indexCM.Data[config.ConfigVersionFileName]; version != commit {
+			t.Errorf("%s: expected version %s, got %s", tc.name, commit, version)
+		}
+	}
+}
+
+func TestShard(t *testing.T) {
+	data := map[string][]byte{
+		"a": []byte(strings.Repeat("a", config.ShardSize/2)),
+		"b": []byte(strings.Repeat("b", config.ShardSize)),
+		"c": []byte(strings.Repeat("c", config.ShardSize/2)),
+	}
+	shards, parts := shard(data)
+	var sizes []int
+	for _, shard := range shards {
+		var size int
+		for key, value := range shard {
+			size += len(key) + len(value)
+		}
+		sizes = append(sizes, size)
+	}
+	// The first part of b fills up the shard of a, and c does not fit next
+	// to the second part.
+	expectedSizes := []int{config.ShardSize, 1 + config.ShardSize/2 + 2*len(config.ShardPartKey("b", 0)), 1 + config.ShardSize/2}
+	if diff := cmp.Diff(expectedSizes, sizes); diff != "" {
+		t.Errorf("unexpected shard sizes (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string][]string{"b": {"b.part-000", "b.part-001"}}, parts); diff != "" {
+		t.Errorf("unexpected parts (-want +got):\n%s", diff)
+	}
+}
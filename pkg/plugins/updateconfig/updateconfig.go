@@ -21,10 +21,12 @@ import (
 	"compress/gzip"
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"unicode/utf8"
 
@@ -34,6 +36,7 @@ import (
 	coreapi "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -102,15 +105,50 @@ func (g *OSFileGetter) GetFile(filename string) ([]byte, error) {
 // Existing configmap keys that are not included in the updates are left alone
 // unless bootstrap is true in which case they are deleted.
 func Update(fg FileGetter, kc corev1.ConfigMapInterface, name, namespace string, updates []ConfigMapUpdate, bootstrap bool, metrics *prometheus.GaugeVec, logger *logrus.Entry, sha string) error {
+	_, err := update(fg, kc, name, namespace, updates, bootstrap, metrics, logger, sha)
+	return err
+}
+
+// Changes are the keys of a configmap that an update changed.
+type Changes struct {
+	// Updated are the updates that changed the value of their key.
+	Updated []ConfigMapUpdate
+	// Deleted are the keys that were deleted.
+	Deleted []string
+}
+
+func (c Changes) empty() bool {
+	return len(c.Updated) == 0 && len(c.Deleted) == 0
+}
+
+// track records the change of the key from the update, if any.
+func (c *Changes) track(upd ConfigMapUpdate, current map[string][]byte, value []byte) {
+	old, exists := current[upd.Key]
+	switch {
+	case upd.Filename == "" && exists:
+		c.Deleted = append(c.Deleted, upd.Key)
+	case upd.Filename != "" && (!exists || !bytes.Equal(old, value)):
+		c.Updated = append(c.Updated, upd)
+	}
+}
+
+// update updates the configmap like Update and returns the keys it changed.
+// Only the changed keys are written, and the configmap is not written at all
+// if none changed.
+func update(fg FileGetter, kc corev1.ConfigMapInterface, name, namespace string, updates []ConfigMapUpdate, bootstrap bool, metrics *prometheus.GaugeVec, logger *logrus.Entry, sha string) (Changes, error) {
+	if isSharded(updates) {
+		return updateSharded(fg, kc, name, namespace, updates, bootstrap, metrics, logger, sha)
+	}
 	cm, getErr := kc.Get(context.TODO(), name, metav1.GetOptions{})
 	isNotFound := errors.IsNotFound(getErr)
 	if getErr != nil && !isNotFound {
-		return fmt.Errorf("failed to fetch current state of configmap: %w", getErr)
+		return Changes{}, fmt.Errorf("failed to fetch current state of configmap: %w", getErr)
 	}
-
-	labels := map[string]string{
-		"app.kubernetes.io/name":      "prow",
-		"app.kubernetes.io/component": "updateconfig-plugin",
+	var existing *coreapi.ConfigMap
+	current := map[string][]byte{}
+	if cm != nil && !isNotFound {
+		existing = cm.DeepCopy()
+		addData(current, existing)
 	}
 
 	// For bootstrap mode, if the existing ConfigMap has any keys, make note of
@@ -140,13 +178,13 @@ func Update(fg FileGetter, kc corev1.ConfigMapInterface, name, namespace string,
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      name,
 				Namespace: namespace,
-				Labels:    labels,
+				Labels:    labels(),
 			},
 		}
 	}
 
 	if cm.ObjectMeta.Labels == nil {
-		cm.ObjectMeta.Labels = labels
+		cm.ObjectMeta.Labels = labels()
 	}
 
 	if cm.Data == nil || bootstrap {
@@ -159,71 +197,150 @@ func Update(fg FileGetter, kc corev1.ConfigMapInterface, name, namespace string,
 		cm.BinaryData = map[string][]byte{}
 	}
 
+	var changes Changes
 	for _, upd := range updates {
 		if upd.Filename == "" {
 			logger.WithField("key", upd.Key).Debug("Deleting key.")
+			changes.track(upd, current, nil)
 			delete(cm.Data, upd.Key)
 			delete(cm.BinaryData, upd.Key)
 			continue
 		}
 
-		content, err := fg.GetFile(upd.Filename)
+		value, err := fileValue(fg, upd, logger)
 		if err != nil {
-			return fmt.Errorf("get file err: %w", err)
-		}
-		logger.WithFields(logrus.Fields{"key": upd.Key, "filename": upd.Filename}).Debug("Populating key.")
-		value := content
-		if upd.GZIP {
-			buff := bytes.NewBuffer([]byte{})
-			// TODO: this error is wildly unlikely for anything that
-			// would actually fit in a configmap, we could just as well return
-			// the error instead of falling back to the raw content
-			z := gzip.NewWriter(buff)
-			if _, err := z.Write(content); err != nil {
-				logger.WithError(err).Error("failed to gzip content, falling back to raw")
-			} else {
-				if err := z.Close(); err != nil {
-					logger.WithError(err).Error("failed to flush gzipped content (!?), falling back to raw")
-				} else {
-					value = buff.Bytes()
-				}
-			}
-		}
-		if utf8.ValidString(string(value)) {
-			delete(cm.BinaryData, upd.Key)
-			cm.Data[upd.Key] = string(value)
-		} else {
-			delete(cm.Data, upd.Key)
-			cm.BinaryData[upd.Key] = value
+			return Changes{}, err
 		}
+		changes.track(upd, current, value)
+		setValue(cm, upd.Key, value)
 	}
+	versionChanged := sha != "" && string(current[config.ConfigVersionFileName]) != sha
 
 	var updateErr error
 	var verb string
-	if getErr != nil && isNotFound {
+	switch {
+	case getErr != nil && isNotFound:
 		verb = "create"
 		_, updateErr = kc.Create(context.TODO(), cm, metav1.CreateOptions{})
-	} else {
+	case changes.empty() && !versionChanged:
+		logger.Debug("Configmap is up to date.")
+		return changes, nil
+	case bootstrap:
 		verb = "update"
 		_, updateErr = kc.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	default:
+		verb = "patch"
+		keys := changes.Deleted
+		for _, upd := range changes.Updated {
+			keys = append(keys, upd.Key)
+		}
+		if versionChanged {
+			keys = append(keys, config.ConfigVersionFileName)
+		}
+		cm, updateErr = patch(kc, existing, cm, keys)
 	}
 	if updateErr != nil {
-		return fmt.Errorf("%s config map err: %w", verb, updateErr)
+		return Changes{}, fmt.Errorf("%s config map err: %w", verb, updateErr)
 	}
-	if metrics != nil {
-		var size float64
-		for _, data := range cm.Data {
-			size += float64(len(data))
+	recordSize(metrics, cm)
+	return changes, nil
+}
+
+// patch writes only the keys of the configmap that changed from the existing
+// one, so that the keys of other files are not sent back and forth.
+func patch(kc corev1.ConfigMapInterface, existing, cm *coreapi.ConfigMap, keys []string) (*coreapi.ConfigMap, error) {
+	data := map[string]interface{}{}
+	binaryData := map[string]interface{}{}
+	for _, key := range keys {
+		if value, ok := cm.Data[key]; ok {
+			data[key] = value
+		} else if _, ok := existing.Data[key]; ok {
+			data[key] = nil
 		}
-		for _, data := range cm.BinaryData {
-			size += float64(len(data))
+		if value, ok := cm.BinaryData[key]; ok {
+			binaryData[key] = value
+		} else if _, ok := existing.BinaryData[key]; ok {
+			binaryData[key] = nil
 		}
-		// in a strict sense this can race to update the value with other goroutines
-		// handling other events, but as events are serialized due to the fact that
-		// merges are serial in repositories, this is effectively not an issue here
-		metrics.WithLabelValues(cm.Name, cm.Namespace).Set(size)
 	}
-	return nil
+	body := map[string]interface{}{}
+	if len(data) > 0 {
+		body["data"] = data
+	}
+	if len(binaryData) > 0 {
+		body["binaryData"] = binaryData
+	}
+	if existing.Labels == nil {
+		body["metadata"] = map[string]interface{}{"labels": cm.Labels}
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return kc.Patch(context.TODO(), cm.Name, types.MergePatchType, raw, metav1.PatchOptions{})
+}
+
+func labels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      "prow",
+		"app.kubernetes.io/component": "updateconfig-plugin",
+	}
+}
+
+// fileValue returns the value of the key from the file of the update.
+func fileValue(fg FileGetter, upd ConfigMapUpdate, logger *logrus.Entry) ([]byte, error) {
+	content, err := fg.GetFile(upd.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("get file err: %w", err)
+	}
+	logger.WithFields(logrus.Fields{"key": upd.Key, "filename": upd.Filename}).Debug("Populating key.")
+	value := content
+	if upd.GZIP || (upd.GZIPMinSize > 0 && len(content) >= upd.GZIPMinSize) {
+		buff := bytes.NewBuffer([]byte{})
+		// TODO: this error is wildly unlikely for anything that
+		// would actually fit in a configmap, we could just as well return
+		// the error instead of falling back to the raw content
+		z := gzip.NewWriter(buff)
+		if _, err := z.Write(content); err != nil {
+			logger.WithError(err).Error("failed to gzip content, falling back to raw")
+		} else {
+			if err := z.Close(); err != nil {
+				logger.WithError(err).Error("failed to flush gzipped content (!?), falling back to raw")
+			} else {
+				value = buff.Bytes()
+			}
+		}
+	}
+	return value, nil
+}
+
+// setValue sets the key of the configmap to the value, as binary data if it
+// is not valid UTF-8.
+func setValue(cm *coreapi.ConfigMap, key string, value []byte) {
+	if utf8.ValidString(string(value)) {
+		delete(cm.BinaryData, key)
+		cm.Data[key] = string(value)
+	} else {
+		delete(cm.Data, key)
+		cm.BinaryData[key] = value
+	}
+}
+
+func recordSize(metrics *prometheus.GaugeVec, cm *coreapi.ConfigMap) {
+	if metrics == nil {
+		return
+	}
+	var size float64
+	for _, data := range cm.Data {
+		size += float64(len(data))
+	}
+	for _, data := range cm.BinaryData {
+		size += float64(len(data))
+	}
+	// in a strict sense this can race to update the value with other goroutines
+	// handling other events, but as events are serialized due to the fact that
+	// merges are serial in repositories, this is effectively not an issue here
+	metrics.WithLabelValues(cm.Name, cm.Namespace).Set(size)
 }
 
 // MarkStaledKeysForDeletion returns a slice of ConfigMapUpdate entries for keys
@@ -277,6 +394,11 @@ func MarkStaleKeysForDeletion(
 type ConfigMapUpdate struct {
 	Key, Filename string
 	GZIP          bool
+	// GZIPMinSize gzips the file if it has at least this many bytes.
+	GZIPMinSize int
+	// Sharded is set for updates of a ConfigMap that is sharded across
+	// several ConfigMaps.
+	Sharded bool
 }
 
 // FilterChanges determines which of the changes are relevant for config updating, returning mapping of
@@ -346,18 +468,21 @@ func FilterChanges(cfg plugins.ConfigUpdater, changes []github.PullRequestChange
 						// not setting the filename field will cause the key to be
 						// deleted
 						id := idForKey(oldKey)
-						toUpdate[id] = append(toUpdate[id], ConfigMapUpdate{Key: oldKey})
+						toUpdate[id] = append(toUpdate[id], ConfigMapUpdate{Key: oldKey, Sharded: cm.Sharded})
 					}
 				}
 				id := idForKey(key)
 				if change.Status == github.PullRequestFileRemoved {
-					toUpdate[id] = append(toUpdate[id], ConfigMapUpdate{Key: key})
+					toUpdate[id] = append(toUpdate[id], ConfigMapUpdate{Key: key, Sharded: cm.Sharded})
 				} else {
-					gzip := cfg.GZIP
+					gzip, gzipMinSize := cfg.GZIP, cfg.GZIPMinSize
 					if cm.GZIP != nil {
 						gzip = *cm.GZIP
+						if !gzip {
+							gzipMinSize = 0
+						}
 					}
-					toUpdate[id] = append(toUpdate[id], ConfigMapUpdate{Key: key, Filename: change.Filename, GZIP: gzip})
+					toUpdate[id] = append(toUpdate[id], ConfigMapUpdate{Key: key, Filename: change.Filename, GZIP: gzip, GZIPMinSize: gzipMinSize, Sharded: cm.Sharded})
 				}
 			}
 		}
@@ -413,7 +538,7 @@ func handle(gc githubClient, gitClient git.ClientFactory, kc corev1.ConfigMapsGe
 		return err
 	}
 
-	message := func(name, cluster, namespace string, updates []ConfigMapUpdate, indent string) string {
+	identifier := func(name, cluster, namespace string) string {
 		identifier := fmt.Sprintf("`%s` configmap", name)
 		if namespace != "" {
 			identifier = fmt.Sprintf("%s in namespace `%s`", identifier, namespace)
@@ -421,10 +546,16 @@ func handle(gc githubClient, gitClient git.ClientFactory, kc corev1.ConfigMapsGe
 		if cluster != "" {
 			identifier = fmt.Sprintf("%s at cluster `%s`", identifier, cluster)
 		}
-		msg := fmt.Sprintf("%s using the following files:", identifier)
-		for _, u := range updates {
+		return identifier
+	}
+	message := func(name, cluster, namespace string, changes Changes, indent string) string {
+		msg := fmt.Sprintf("%s with the following keys:", identifier(name, cluster, namespace))
+		for _, u := range changes.Updated {
 			msg = fmt.Sprintf("%s\n%s- key `%s` using file `%s`", msg, indent, u.Key, u.Filename)
 		}
+		for _, key := range changes.Deleted {
+			msg = fmt.Sprintf("%s\n%s- deleted key `%s`", msg, indent, key)
+		}
 		return msg
 	}
 
@@ -435,7 +566,7 @@ func handle(gc githubClient, gitClient git.ClientFactory, kc corev1.ConfigMapsGe
 		"changes":              len(changes),
 	}).Debug("Identified configmaps to update")
 
-	var updated []string
+	var updated, failed []string
 	indent := " " // one space
 	if len(toUpdate) > 1 {
 		indent = "   " // three spaces for sub bullets
@@ -461,19 +592,31 @@ func handle(gc githubClient, gitClient git.ClientFactory, kc corev1.ConfigMapsGe
 		if err != nil {
 			log.WithError(err).Errorf("Failed to find configMap client")
 			errs = append(errs, err)
+			failed = append(failed, identifier(cm.Name, cm.Cluster, cm.Namespace))
 			continue
 		}
-		if err := Update(&OSFileGetter{Root: gitRepo.Directory()}, configMapClient, cm.Name, cm.Namespace, data, bootstrapMode, metrics, logger, *pr.MergeSHA); err != nil {
+		changes, err := update(&OSFileGetter{Root: gitRepo.Directory()}, configMapClient, cm.Name, cm.Namespace, data, bootstrapMode, metrics, logger, *pr.MergeSHA)
+		if err != nil {
 			errs = append(errs, err)
+			failed = append(failed, identifier(cm.Name, cm.Cluster, cm.Namespace))
+			continue
+		}
+		if changes.empty() {
+			// The files changed, but not in a way that changed the keys.
 			continue
 		}
-		updated = append(updated, message(cm.Name, cm.Cluster, cm.Namespace, data, indent))
+		updated = append(updated, message(cm.Name, cm.Cluster, cm.Namespace, changes, indent))
 	}
+	// The configmaps are updated in no particular order.
+	sort.Strings(updated)
+	sort.Strings(failed)
 
 	var msg string
 	switch n := len(updated); n {
 	case 0:
-		return utilerrors.NewAggregate(errs)
+		if len(failed) == 0 {
+			return utilerrors.NewAggregate(errs)
+		}
 	case 1:
 		msg = fmt.Sprintf("Updated the %s", updated[0])
 	default:
@@ -482,6 +625,15 @@ func handle(gc githubClient, gitClient git.ClientFactory, kc corev1.ConfigMapsGe
 			msg += fmt.Sprintf(" * %s\n", updateMsg) // one space indent
 		}
 	}
+	if len(failed) > 0 {
+		if msg != "" {
+			msg = strings.TrimSuffix(msg, "\n") + "\n\n"
+		}
+		msg += "Failed to update the following configmaps, see the logs of hook for details:\n"
+		for _, failedMsg := range failed {
+			msg += fmt.Sprintf(" * %s\n", failedMsg)
+		}
+	}
 
 	if err := gc.CreateComment(org, repo, pr.Number, plugins.FormatResponseRaw(pr.Body, pr.HTMLURL, pr.User.Login, msg)); err != nil {
 		errs = append(errs, fmt.Errorf("comment err: %w", err))
@@ -17,6 +17,7 @@ limitations under the License.
 package updateconfig
 
 import (
+	"bytes"
 	"context"
 	"strings"
 	"testing"
@@ -38,6 +39,7 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 
+	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/git/localgit"
 	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/github"
@@ -1567,6 +1569,9 @@ func testUpdateConfig(clients localgit.Clients, t *testing.T) {
 				obj = action.Object
 			case clienttesting.UpdateActionImpl:
 				obj = action.Object
+			case clienttesting.PatchActionImpl:
+				modifiedConfigMaps.Insert(action.GetName())
+				continue
 			default:
 				continue
 			}
@@ -1874,6 +1879,9 @@ func testUpdate(clients localgit.Clients, t *testing.T) {
 				obj = action.Object
 			case clienttesting.UpdateActionImpl:
 				obj = action.Object
+			case clienttesting.PatchActionImpl:
+				modifiedConfigMaps.Insert(action.GetName())
+				continue
 			default:
 				continue
 			}
@@ -2089,3 +2097,102 @@ func TestMarkStaleKeysForDeletion(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateChanges(t *testing.T) {
+	ns, name, commit := "ns", "name", "12345"
+	log := logrus.NewEntry(logrus.New())
+	fs := MapFS{
+		"same.yaml":    {Data: []byte("same")},
+		"changed.yaml": {Data: []byte("changed")},
+		"large.yaml":   {Data: []byte(strings.Repeat("large", 10))},
+	}
+	existing := func() *coreapi.ConfigMap {
+		return &coreapi.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Data: map[string]string{
+				"same.yaml":                  "same",
+				"changed.yaml":               "old",
+				"other.yaml":                 "other",
+				config.ConfigVersionFileName: commit,
+			},
+		}
+	}
+	testCases := []struct {
+		name            string
+		updates         []ConfigMapUpdate
+		sha             string
+		expectedChanges Changes
+		expectedData    map[string]string
+		expectedBinary  bool
+		expectedVerbs   []string
+	}{
+		{
+			name:          "unchanged keys are not written",
+			updates:       []ConfigMapUpdate{{Key: "same.yaml", Filename: "same.yaml"}, {Key: "missing.yaml"}},
+			sha:           commit,
+			expectedData:  existing().Data,
+			expectedVerbs: []string{"get"},
+		},
+		{
+			name:          "a new version is written even if no key changed",
+			updates:       []ConfigMapUpdate{{Key: "same.yaml", Filename: "same.yaml"}},
+			sha:           "67890",
+			expectedData:  map[string]string{"same.yaml": "same", "changed.yaml": "old", "other.yaml": "other", config.ConfigVersionFileName: "67890"},
+			expectedVerbs: []string{"get", "patch"},
+		},
+		{
+			name: "only changed keys are patched",
+			updates: []ConfigMapUpdate{
+				{Key: "same.yaml", Filename: "same.yaml"},
+				{Key: "changed.yaml", Filename: "changed.yaml"},
+				{Key: "other.yaml"},
+			},
+			sha: commit,
+			expectedChanges: Changes{
+				Updated: []ConfigMapUpdate{{Key: "changed.yaml", Filename: "changed.yaml"}},
+				Deleted: []string{"other.yaml"},
+			},
+			expectedData:  map[string]string{"same.yaml": "same", "changed.yaml": "changed", config.ConfigVersionFileName: commit},
+			expectedVerbs: []string{"get", "patch"},
+		},
+		{
+			name:            "large files are gzipped",
+			updates:         []ConfigMapUpdate{{Key: "large.yaml", Filename: "large.yaml", GZIPMinSize: 20}, {Key: "same.yaml", Filename: "same.yaml", GZIPMinSize: 20}},
+			sha:             commit,
+			expectedChanges: Changes{Updated: []ConfigMapUpdate{{Key: "large.yaml", Filename: "large.yaml", GZIPMinSize: 20}}},
+			expectedData:    existing().Data,
+			expectedBinary:  true,
+			expectedVerbs:   []string{"get", "patch"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fkc := fake.NewSimpleClientset(existing())
+			client := fkc.CoreV1().ConfigMaps(ns)
+			changes, err := update(fs, client, name, ns, tc.updates, false, nil, log, tc.sha)
+			if err != nil {
+				t.Fatalf("unexpected error updating: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedChanges, changes, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected changes (-want +got):\n%s", diff)
+			}
+			var verbs []string
+			for _, action := range fkc.Fake.Actions() {
+				verbs = append(verbs, action.GetVerb())
+			}
+			if diff := cmp.Diff(tc.expectedVerbs, verbs); diff != "" {
+				t.Errorf("unexpected actions (-want +got):\n%s", diff)
+			}
+			cm, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get configmap: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedData, cm.Data); diff != "" {
+				t.Errorf("unexpected data (-want +got):\n%s", diff)
+			}
+			if large, ok := cm.BinaryData["large.yaml"]; ok != tc.expectedBinary || (ok && !bytes.HasPrefix(large, []byte("\x1F\x8B"))) {
+				t.Errorf("expected large.yaml to be gzipped: %t, got %q", tc.expectedBinary, large)
+			}
+		})
+	}
+}
@@ -360,7 +360,7 @@ func TestHandlePR(t *testing.T) {
 
 		tr := plugins.Trigger{
 			TrustedOrg:     "kubernetes",
-			OnlyOrgMembers: tc.onlyOrgMembers,
+			OnlyOrgMembers: &tc.onlyOrgMembers,
 		}
 
 		// try handling it
@@ -243,12 +243,10 @@ func (g *gitCtx) commandsForBaseRef(refs prowapi.Refs, gitUserName, gitUserEmail
 	if d := refs.CloneDepth; d > 0 {
 		depthArgs = append(depthArgs, "--depth", strconv.Itoa(d))
 	}
-	var filterArgs []string
-	if refs.BloblessFetch != nil && *refs.BloblessFetch {
-		filterArgs = append(filterArgs, "--filter=blob:none")
-	}
+	filterArgs := fetchFilterArgs(refs)
 
-	if !refs.SkipFetchHead {
+	// Fetching a single branch skips the other branches and tags.
+	if !refs.SkipFetchHead && !singleBranch(refs) {
 		var fetchArgs []string
 		fetchArgs = append(fetchArgs, depthArgs...)
 		fetchArgs = append(fetchArgs, filterArgs...)
@@ -270,6 +268,9 @@ func (g *gitCtx) commandsForBaseRef(refs prowapi.Refs, gitUserName, gitUserEmail
 		var fetchArgs []string
 		fetchArgs = append(fetchArgs, depthArgs...)
 		fetchArgs = append(fetchArgs, filterArgs...)
+		if singleBranch(refs) {
+			fetchArgs = append(fetchArgs, "--no-tags")
+		}
 		fetchArgs = append(fetchArgs, g.repositoryURI, fetchRef)
 		commands = append(commands, g.gitFetch(fetchArgs...))
 	}
@@ -287,6 +288,24 @@ func (g *gitCtx) commandsForBaseRef(refs prowapi.Refs, gitUserName, gitUserEmail
 	return commands
 }
 
+// fetchFilterArgs returns the arguments of git fetch selecting the objects of
+// a partial clone, if any.
+func fetchFilterArgs(refs prowapi.Refs) []string {
+	if refs.Filter != "" {
+		return []string{"--filter=" + refs.Filter}
+	}
+	if refs.BloblessFetch != nil && *refs.BloblessFetch {
+		return []string{"--filter=blob:none"}
+	}
+	return nil
+}
+
+// singleBranch returns whether only the base ref and the pulls of refs are
+// to be fetched.
+func singleBranch(refs prowapi.Refs) bool {
+	return refs.SingleBranch != nil && *refs.SingleBranch
+}
+
 // gitHeadTimestamp returns the timestamp of the HEAD commit as seconds from the
 // UNIX epoch. If unable to read the timestamp for any reason (such as missing
 // the git, or not using a git repo), it returns 0 and an error.
@@ -336,9 +355,9 @@ func (g *gitCtx) gitRevParse() (string, error) {
 func (g *gitCtx) commandsForPullRefs(refs prowapi.Refs, fakeTimestamp int) []runnable {
 	var commands []runnable
 	for _, prRef := range refs.Pulls {
-		var fetchArgs []string
-		if refs.BloblessFetch != nil && *refs.BloblessFetch {
-			fetchArgs = append(fetchArgs, "--filter=blob:none")
+		fetchArgs := fetchFilterArgs(refs)
+		if singleBranch(refs) {
+			fetchArgs = append(fetchArgs, "--no-tags")
 		}
 		ref := fmt.Sprintf("pull/%d/head", prRef.Number)
 		if prRef.SHA != "" {
@@ -38,16 +38,19 @@ import (
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/initupload"
 	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/pod-utils/clone"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
 	"sigs.k8s.io/prow/pkg/sidecar"
+	"sigs.k8s.io/prow/pkg/version"
 )
 
 const (
 	logMountName            = "logs"
 	logMountPath            = "/logs"
 	artifactsEnv            = "ARTIFACTS"
+	metadataURLEnv          = "PROW_METADATA_URL"
 	artifactsPath           = logMountPath + "/artifacts"
 	codeMountName           = "code"
 	codeMountPath           = "/home/prow/go"
@@ -98,6 +101,18 @@ func PodUtilsContainerNames() sets.Set[string] {
 	return sets.New[string](cloneRefsName, initUploadName, entrypointName, sidecarName)
 }
 
+// FailureExcerpt returns the excerpt of the build logs that the sidecar
+// container of a decorated pod extracted when the test process failed.
+func FailureExcerpt(pod *coreapi.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != sidecarName || status.State.Terminated == nil {
+			continue
+		}
+		return sidecar.ParseTerminationMessage(status.State.Terminated.Message).FailureExcerpt
+	}
+	return ""
+}
+
 // LabelsAndAnnotationsForSpec returns a minimal set of labels to add to prowjobs or its owned resources.
 //
 // User-provided extraLabels and extraAnnotations values will take precedence over auto-provided values.
@@ -205,6 +220,7 @@ func ProwJobToPodLocal(pj prowapi.ProwJob, outputDir string) (*coreapi.Pod, erro
 	if err != nil {
 		return nil, err
 	}
+	rawEnv[downwardapi.ProwVersionEnv] = version.UserAgent()
 
 	spec := pj.Spec.PodSpec.DeepCopy()
 	spec.RestartPolicy = "Never"
@@ -221,6 +237,21 @@ func ProwJobToPodLocal(pj prowapi.ProwJob, outputDir string) (*coreapi.Pod, erro
 		spec.AutomountServiceAccountToken = &myFalse
 	}
 
+	if arch := pj.Spec.Arch; arch != "" {
+		// Nodes of other architectures are often tainted so that only
+		// workloads built for them are scheduled there.
+		if spec.NodeSelector == nil {
+			spec.NodeSelector = map[string]string{}
+		}
+		spec.NodeSelector[coreapi.LabelArchStable] = arch
+		spec.Tolerations = append(spec.Tolerations, coreapi.Toleration{
+			Key:      coreapi.LabelArchStable,
+			Operator: coreapi.TolerationOpEqual,
+			Value:    arch,
+			Effect:   coreapi.TaintEffectNoSchedule,
+		})
+	}
+
 	if pj.Spec.DecorationConfig == nil {
 		for i, container := range spec.Containers {
 			spec.Containers[i].Env = append(container.Env, KubeEnv(rawEnv)...)
@@ -530,16 +561,37 @@ func metadataFile(log coreapi.VolumeMount, prefix string) string {
 	return filepath.Join(ad, fmt.Sprintf("%s-metadata.json", prefix))
 }
 
+func heartbeatFile(log coreapi.VolumeMount, prefix string) string {
+	if prefix == "" {
+		return filepath.Join(log.MountPath, "heartbeat.json")
+	}
+	return filepath.Join(log.MountPath, fmt.Sprintf("%s-heartbeat.json", prefix))
+}
+
+func deadlineFile(log coreapi.VolumeMount) string {
+	return filepath.Join(log.MountPath, "deadline.txt")
+}
+
 func artifactsDir(log coreapi.VolumeMount) string {
 	return filepath.Join(log.MountPath, "artifacts")
 }
 
+// isWindows determines whether the pod is scheduled on Windows nodes.
+func isWindows(spec *coreapi.PodSpec) bool {
+	if spec.OS != nil {
+		return spec.OS.Name == coreapi.Windows
+	}
+	return spec.NodeSelector[coreapi.LabelOSStable] == string(coreapi.Windows)
+}
+
 func entrypointLocation(tools coreapi.VolumeMount) string {
 	return filepath.Join(tools.MountPath, "entrypoint")
 }
 
 // InjectEntrypoint will make the entrypoint binary in the tools volume the container's entrypoint, which will output to the log volume.
-func InjectEntrypoint(c *coreapi.Container, timeout, gracePeriod time.Duration, prefix, previousMarker string, propagateErrorCode bool, exitZero bool, log, tools coreapi.VolumeMount) (*wrapper.Options, error) {
+// If coordination is set, the container shares its deadline with the other test
+// containers and runs after the ones listed for it.
+func InjectEntrypoint(c *coreapi.Container, timeout, gracePeriod, noOutputTimeout, heartbeatInterval, maxTimeoutExtension time.Duration, timeoutSignal string, signalProcessGroup bool, prefix, previousMarker string, coordination map[string]prowapi.ContainerCoordination, propagateErrorCode bool, exitZero bool, log, tools coreapi.VolumeMount) (*wrapper.Options, error) {
 	wrapperOptions := &wrapper.Options{
 		Args:          append(c.Command, c.Args...),
 		ContainerName: c.Name,
@@ -547,15 +599,34 @@ func InjectEntrypoint(c *coreapi.Container, timeout, gracePeriod time.Duration,
 		MarkerFile:    markerFile(log, prefix),
 		MetadataFile:  metadataFile(log, prefix),
 	}
+	if heartbeatInterval > 0 {
+		wrapperOptions.HeartbeatFile = heartbeatFile(log, prefix)
+	}
+	if len(coordination) > 0 {
+		wrapperOptions.DeadlineFile = deadlineFile(log)
+		wrapperOptions.Optional = coordination[c.Name].Optional
+		for _, after := range coordination[c.Name].After {
+			wrapperOptions.After = append(wrapperOptions.After, wrapper.Dependency{
+				ContainerName: after,
+				MarkerFile:    markerFile(log, after),
+				Optional:      coordination[after].Optional,
+			})
+		}
+	}
 	// TODO(fejta): use flags
 	entrypointConfigEnv, err := entrypoint.Encode(entrypoint.Options{
-		ArtifactDir:        artifactsDir(log),
-		GracePeriod:        gracePeriod,
-		Options:            wrapperOptions,
-		Timeout:            timeout,
-		PropagateErrorCode: propagateErrorCode,
-		AlwaysZero:         exitZero,
-		PreviousMarker:     previousMarker,
+		ArtifactDir:         artifactsDir(log),
+		GracePeriod:         gracePeriod,
+		Options:             wrapperOptions,
+		Timeout:             timeout,
+		NoOutputTimeout:     noOutputTimeout,
+		HeartbeatInterval:   heartbeatInterval,
+		MaxTimeoutExtension: maxTimeoutExtension,
+		TimeoutSignal:       timeoutSignal,
+		SignalProcessGroup:  signalProcessGroup,
+		PropagateErrorCode:  propagateErrorCode,
+		AlwaysZero:          exitZero,
+		PreviousMarker:      previousMarker,
 	})
 	if err != nil {
 		return nil, err
@@ -564,10 +635,31 @@ func InjectEntrypoint(c *coreapi.Container, timeout, gracePeriod time.Duration,
 	c.Command = []string{entrypointLocation(tools)}
 	c.Args = nil
 	c.Env = append(c.Env, KubeEnv(map[string]string{entrypoint.JSONConfigEnvVar: entrypointConfigEnv})...)
+	if heartbeatInterval > 0 && c.LivenessProbe == nil {
+		c.LivenessProbe = heartbeatProbe(heartbeatInterval, tools)
+	}
 	c.VolumeMounts = append(c.VolumeMounts, log, tools)
 	return wrapperOptions, nil
 }
 
+// heartbeatProbe returns a liveness probe that fails once the entrypoint
+// stopped writing heartbeats, so that the kubelet terminates a container whose
+// entrypoint is stuck instead of letting it run until the pod times out.
+func heartbeatProbe(heartbeatInterval time.Duration, tools coreapi.VolumeMount) *coreapi.Probe {
+	period := int32(heartbeatInterval / time.Second)
+	if period < 1 {
+		period = 1
+	}
+	return &coreapi.Probe{
+		ProbeHandler: coreapi.ProbeHandler{
+			Exec: &coreapi.ExecAction{Command: []string{entrypointLocation(tools), entrypoint.CheckHeartbeatFlag}},
+		},
+		PeriodSeconds:    period,
+		TimeoutSeconds:   period,
+		FailureThreshold: 3,
+	}
+}
+
 // PlaceEntrypoint will copy entrypoint from the entrypoint image to the tools volume
 func PlaceEntrypoint(config *prowapi.DecorationConfig, toolsMount coreapi.VolumeMount) coreapi.Container {
 	container := coreapi.Container{
@@ -654,6 +746,7 @@ func InitUpload(config *prowapi.DecorationConfig, gcsOptions gcsupload.Options,
 		Image: config.UtilityImages.InitUpload,
 		Env: KubeEnv(map[string]string{
 			downwardapi.JobSpecEnv:      encodedJobSpec,
+			downwardapi.ProwVersionEnv:  version.UserAgent(),
 			initupload.JSONConfigEnvVar: initUploadConfigEnv,
 		}),
 		VolumeMounts: mounts,
@@ -706,8 +799,18 @@ func ToolsMountAndVolume() (coreapi.VolumeMount, coreapi.Volume) {
 func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]string, outputDir string) error {
 	// TODO(fejta): we should pass around volume names rather than forcing particular mount paths.
 
+	if isWindows(spec) {
+		// Windows nodes can only run the utilities built for Windows.
+		pj = pj.DeepCopy()
+		dc := pj.Spec.DecorationConfig
+		dc.UtilityImages = dc.WindowsUtilityImages.ApplyDefault(dc.UtilityImages)
+	}
+
 	rawEnv[artifactsEnv] = artifactsPath
 	rawEnv[gopathEnv] = codeMountPath // TODO(fejta): remove this once we can assume go modules
+	if port := pj.Spec.DecorationConfig.MetadataServerPort; port != nil {
+		rawEnv[metadataURLEnv] = "http://" + sidecar.MetadataServerAddress(*port)
+	}
 	logMount, logVolume := LogMountAndVolume()
 	codeMount, codeVolume := CodeMountAndVolume()
 	toolsMount, toolsVolume := ToolsMountAndVolume()
@@ -738,8 +841,10 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 	if err != nil {
 		return fmt.Errorf("create clonerefs container: %w", err)
 	}
+	utilityEnv := UtilityEnv(pj)
 	var cloneLogMount *coreapi.VolumeMount
 	if cloner != nil {
+		cloner.Env = append(cloner.Env, utilityEnv...)
 		spec.InitContainers = append([]coreapi.Container{*cloner}, spec.InitContainers...)
 		cloneLogMount = &logMount
 	}
@@ -749,6 +854,7 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 	if err != nil {
 		return fmt.Errorf("create initupload container: %w", err)
 	}
+	initUpload.Env = append(initUpload.Env, utilityEnv...)
 	spec.InitContainers = append(
 		spec.InitContainers,
 		*initUpload,
@@ -776,6 +882,11 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 		exitZero           = false
 		propagateErrorCode = false
 	)
+	var timeoutSignal string
+	if pj.Spec.DecorationConfig.TimeoutSignal != nil {
+		timeoutSignal = *pj.Spec.DecorationConfig.TimeoutSignal
+	}
+	signalProcessGroup := pj.Spec.DecorationConfig.SignalProcessGroup != nil && *pj.Spec.DecorationConfig.SignalProcessGroup
 	var secretVolumeMounts []coreapi.VolumeMount
 	var wrappers []wrapper.Options
 
@@ -784,7 +895,8 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 		if len(spec.Containers) == 1 {
 			prefix = ""
 		}
-		wrapperOptions, err := InjectEntrypoint(&spec.Containers[i], pj.Spec.DecorationConfig.Timeout.Get(), pj.Spec.DecorationConfig.GracePeriod.Get(), prefix, previous, propagateErrorCode, exitZero, logMount, toolsMount)
+		dc := pj.Spec.DecorationConfig
+		wrapperOptions, err := InjectEntrypoint(&spec.Containers[i], dc.Timeout.Get(), dc.GracePeriod.Get(), dc.NoOutputTimeout.Get(), dc.HeartbeatInterval.Get(), dc.MaxTimeoutExtension.Get(), timeoutSignal, signalProcessGroup, prefix, previous, dc.Coordination, propagateErrorCode, exitZero, logMount, toolsMount)
 		if err != nil {
 			return fmt.Errorf("wrap container: %w", err)
 		}
@@ -802,6 +914,7 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 	if err != nil {
 		return fmt.Errorf("create sidecar: %w", err)
 	}
+	sidecar.Env = append(sidecar.Env, utilityEnv...)
 
 	spec.Volumes = append(spec.Volumes, logVolume, toolsVolume)
 	spec.Volumes = append(spec.Volumes, blobStorageVolumes...)
@@ -879,6 +992,20 @@ func decorate(spec *coreapi.PodSpec, pj *prowapi.ProwJob, rawEnv map[string]stri
 	return nil
 }
 
+// UtilityEnv returns the environment that configures the logging of the pod
+// utilities: their log levels and, for jobs triggered by an event, the trace
+// ID of the event. The trace ID otherwise defaults to the job ID.
+func UtilityEnv(pj *prowapi.ProwJob) []coreapi.EnvVar {
+	env := map[string]string{}
+	if pj.Spec.DecorationConfig != nil && pj.Spec.DecorationConfig.LogLevels != "" {
+		env[logrusutil.LevelsEnv] = pj.Spec.DecorationConfig.LogLevels
+	}
+	if traceID := pj.TraceID(); traceID != pj.Name {
+		env[logrusutil.TraceIDEnv] = traceID
+	}
+	return KubeEnv(env)
+}
+
 // DetermineWorkDir determines the working directory to use for a given set of refs to clone
 func DetermineWorkDir(baseDir string, refs []prowapi.Refs) string {
 	for _, ref := range refs {
@@ -908,13 +1035,40 @@ func Sidecar(config *prowapi.DecorationConfig, gcsOptions gcsupload.Options, blo
 		censoringOptions.CensoringBufferSize = config.CensoringOptions.CensoringBufferSize
 		censoringOptions.IncludeDirectories = config.CensoringOptions.IncludeDirectories
 		censoringOptions.ExcludeDirectories = config.CensoringOptions.ExcludeDirectories
+		censoringOptions.Patterns = config.CensoringOptions.Patterns
+	}
+	var metadataServerAddress string
+	if config.MetadataServerPort != nil {
+		metadataServerAddress = sidecar.MetadataServerAddress(*config.MetadataServerPort)
+	}
+	var failureDiagnostics []sidecar.DiagnosticCommand
+	var failureDiagnosticsDir string
+	for _, diagnostic := range config.FailureDiagnostics {
+		failureDiagnostics = append(failureDiagnostics, sidecar.DiagnosticCommand{
+			Name:    diagnostic.Name,
+			Command: diagnostic.Command,
+			Timeout: diagnostic.Timeout.Get(),
+		})
+		failureDiagnosticsDir = filepath.Join(artifactsDir(logMount), "diagnostics")
+	}
+	var failureExcerpt *sidecar.FailureExcerptOptions
+	if config.FailureExcerpt != nil {
+		failureExcerpt = &sidecar.FailureExcerptOptions{
+			Patterns: config.FailureExcerpt.Patterns,
+			MaxLines: config.FailureExcerpt.MaxLines,
+		}
 	}
 	sidecarConfigEnv, err := sidecar.Encode(sidecar.Options{
-		GcsOptions:       &gcsOptions,
-		Entries:          wrappers,
-		EntryError:       requirePassingEntries,
-		IgnoreInterrupts: ignoreInterrupts,
-		CensoringOptions: censoringOptions,
+		GcsOptions:                &gcsOptions,
+		Entries:                   wrappers,
+		EntryError:                requirePassingEntries,
+		IgnoreInterrupts:          ignoreInterrupts,
+		CensoringOptions:          censoringOptions,
+		MetadataServerAddress:     metadataServerAddress,
+		FailureDiagnostics:        failureDiagnostics,
+		FailureDiagnosticsDir:     failureDiagnosticsDir,
+		FailureExcerpt:            failureExcerpt,
+		IncrementalUploadInterval: config.IncrementalUploadInterval.Get(),
 	})
 
 	if err != nil {
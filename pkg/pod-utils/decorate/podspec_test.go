@@ -1135,6 +1135,22 @@ func TestProwJobToPod(t *testing.T) {
 				},
 			},
 		},
+		{
+			podName: "pod",
+			buildID: "blabla",
+			pjSpec: prowapi.ProwJobSpec{
+				Type:  prowapi.PeriodicJob,
+				Job:   "job-name",
+				Agent: prowapi.KubernetesAgent,
+				Arch:  "arm64",
+				PodSpec: &coreapi.PodSpec{
+					Containers: []coreapi.Container{
+						{Image: "tester", Command: []string{"/bin/thing"}},
+					},
+					NodeSelector: map[string]string{"pool": "ci"},
+				},
+			},
+		},
 	}
 
 	findContainer := func(name string, pod coreapi.Pod) *coreapi.Container {
@@ -1229,6 +1245,46 @@ func TestProwJobToPod(t *testing.T) {
 	}
 }
 
+func TestFailureExcerpt(t *testing.T) {
+	terminated := func(name, message string) coreapi.ContainerStatus {
+		return coreapi.ContainerStatus{Name: name, State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{Message: message}}}
+	}
+	testCases := []struct {
+		name     string
+		statuses []coreapi.ContainerStatus
+		expected string
+	}{
+		{
+			name: "sidecar has not terminated",
+			statuses: []coreapi.ContainerStatus{
+				{Name: sidecarName, State: coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}}},
+			},
+		},
+		{
+			name: "sidecar wrote an excerpt",
+			statuses: []coreapi.ContainerStatus{
+				terminated("test", `{"failure_excerpt":"not from sidecar"}`),
+				terminated(sidecarName, `{"failure_excerpt":"--- FAIL: TestFoo (0.00s)"}`),
+			},
+			expected: "--- FAIL: TestFoo (0.00s)",
+		},
+		{
+			name: "message falls back to the logs of sidecar",
+			statuses: []coreapi.ContainerStatus{
+				terminated(sidecarName, `{"component":"sidecar","msg":"Failed to upload"}`),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &coreapi.Pod{Status: coreapi.PodStatus{ContainerStatuses: tc.statuses}}
+			if actual := FailureExcerpt(pod); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
 func TestProwJobToPod_setsTerminationGracePeriodSeconds(t *testing.T) {
 	testCases := []struct {
 		name                                  string
@@ -1278,6 +1334,7 @@ func TestProwJobToPod_setsTerminationGracePeriodSeconds(t *testing.T) {
 }
 
 func TestSidecar(t *testing.T) {
+	metadataServerPort := int32(8099)
 	var testCases = []struct {
 		name                                    string
 		config                                  *prowapi.DecorationConfig
@@ -1329,6 +1386,41 @@ func TestSidecar(t *testing.T) {
 			},
 			wrappers: []wrapper.Options{{Args: []string{"yes"}}},
 		},
+		{
+			name: "with failure diagnostics",
+			config: &prowapi.DecorationConfig{
+				UtilityImages: &prowapi.UtilityImages{Sidecar: "sidecar-image"},
+				FailureDiagnostics: []prowapi.DiagnosticCommand{
+					{Name: "dmesg", Command: []string{"dmesg"}},
+					{Name: "cluster-info", Command: []string{"kubectl", "cluster-info", "dump"}, Timeout: &prowapi.Duration{Duration: 5 * time.Minute}},
+				},
+			},
+			gcsOptions: gcsupload.Options{
+				Items:            []string{"first", "second"},
+				GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "bucket"},
+			},
+			blobStorageMounts: []coreapi.VolumeMount{{Name: "blob", MountPath: "/blob"}},
+			logMount:          coreapi.VolumeMount{Name: "logs", MountPath: "/logs"},
+			outputMount:       &coreapi.VolumeMount{Name: "outputs", MountPath: "/outputs"},
+			encodedJobSpec:    "spec",
+			wrappers:          []wrapper.Options{{Args: []string{"yes"}}},
+		},
+		{
+			name: "with metadata server",
+			config: &prowapi.DecorationConfig{
+				UtilityImages:      &prowapi.UtilityImages{Sidecar: "sidecar-image"},
+				MetadataServerPort: &metadataServerPort,
+			},
+			gcsOptions: gcsupload.Options{
+				Items:            []string{"first", "second"},
+				GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "bucket"},
+			},
+			blobStorageMounts: []coreapi.VolumeMount{{Name: "blob", MountPath: "/blob"}},
+			logMount:          coreapi.VolumeMount{Name: "logs", MountPath: "/logs"},
+			outputMount:       &coreapi.VolumeMount{Name: "outputs", MountPath: "/outputs"},
+			encodedJobSpec:    "spec",
+			wrappers:          []wrapper.Options{{Args: []string{"yes"}}},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -1353,6 +1445,7 @@ func TestDecorate(t *testing.T) {
 	defaultServiceAccountName := "default-sa"
 	censor := true
 	ignoreInterrupts := true
+	metadataServerPort := int32(8099)
 	resourcePtr := func(s string) *resource.Quantity {
 		q := resource.MustParse(s)
 		return &q
@@ -1410,6 +1503,313 @@ func TestDecorate(t *testing.T) {
 			},
 			rawEnv: map[string]string{"custom": "env"},
 		},
+		{
+			name: "windows pod",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"powershell.exe"}, Args: []string{"-File", "test.ps1"}},
+				},
+				OS: &coreapi.PodOS{Name: coreapi.Windows},
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Hour},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						WindowsUtilityImages: &prowapi.UtilityImages{
+							Entrypoint: "entrypointimage-windows",
+							Sidecar:    "sidecarimage-windows",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+					},
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234"},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "hang detection",
+			spec: &coreapi.PodSpec{
+				Volumes: []coreapi.Volume{
+					{Name: "secret", VolumeSource: coreapi.VolumeSource{Secret: &coreapi.SecretVolumeSource{SecretName: "secretname"}}},
+				},
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"/bin/ls"}, Args: []string{"-l", "-a"}, VolumeMounts: []coreapi.VolumeMount{{Name: "secret", MountPath: "/secret"}}},
+				},
+				ServiceAccountName: "tester",
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:           &prowapi.Duration{Duration: time.Minute},
+						GracePeriod:       &prowapi.Duration{Duration: time.Hour},
+						NoOutputTimeout:   &prowapi.Duration{Duration: 30 * time.Minute},
+						HeartbeatInterval: &prowapi.Duration{Duration: time.Minute},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						Resources: &prowapi.Resources{
+							CloneRefs:       &coreapi.ResourceRequirements{Limits: coreapi.ResourceList{"cpu": resource.Quantity{}}, Requests: coreapi.ResourceList{"memory": resource.Quantity{}}},
+							InitUpload:      &coreapi.ResourceRequirements{Limits: coreapi.ResourceList{"cpu": resource.Quantity{}}, Requests: coreapi.ResourceList{"memory": resource.Quantity{}}},
+							PlaceEntrypoint: &coreapi.ResourceRequirements{Limits: coreapi.ResourceList{"cpu": resource.Quantity{}}, Requests: coreapi.ResourceList{"memory": resource.Quantity{}}},
+							Sidecar:         &coreapi.ResourceRequirements{Limits: coreapi.ResourceList{"cpu": resource.Quantity{}}, Requests: coreapi.ResourceList{"memory": resource.Quantity{}}},
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret:      &gCSCredentialsSecret,
+						DefaultServiceAccountName: &defaultServiceAccountName,
+					},
+					Refs: &prowapi.Refs{
+						Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234",
+						Pulls: []prowapi.Pull{{Number: 1, SHA: "aksdjhfkds"}},
+					},
+					ExtraRefs: []prowapi.Refs{{Org: "other", Repo: "something", BaseRef: "release", BaseSHA: "sldijfsd"}},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "timeout signal",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"/bin/test"}},
+				},
+				ServiceAccountName: "tester",
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:            &prowapi.Duration{Duration: time.Minute},
+						GracePeriod:        &prowapi.Duration{Duration: time.Hour},
+						TimeoutSignal:      utilpointer.String("SIGTERM"),
+						SignalProcessGroup: utilpointer.Bool(true),
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+					},
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234"},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "timeout extension",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"/bin/test"}},
+				},
+				ServiceAccountName: "tester",
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:             &prowapi.Duration{Duration: time.Minute},
+						GracePeriod:         &prowapi.Duration{Duration: time.Hour},
+						MaxTimeoutExtension: &prowapi.Duration{Duration: 30 * time.Minute},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+					},
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234"},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "incremental uploads",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"/bin/test"}},
+				},
+				ServiceAccountName: "tester",
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:                   &prowapi.Duration{Duration: time.Minute},
+						GracePeriod:               &prowapi.Duration{Duration: time.Hour},
+						IncrementalUploadInterval: &prowapi.Duration{Duration: 10 * time.Minute},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+					},
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234"},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "log levels and trace ID",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"/bin/test"}},
+				},
+				ServiceAccountName: "tester",
+			},
+			pj: &prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "job-name",
+					Labels: map[string]string{github.EventGUID: "event-guid"},
+				},
+				Spec: prowapi.ProwJobSpec{
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Hour},
+						LogLevels:   "info,pkg/gcsupload=debug",
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+					},
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234"},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "coordinated containers",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "setup", Command: []string{"/bin/setup"}},
+					{Name: "test", Command: []string{"/bin/test"}},
+					{Name: "lint", Command: []string{"/bin/lint"}},
+				},
+				ServiceAccountName: "tester",
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Hour},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						Coordination: map[string]prowapi.ContainerCoordination{
+							"test": {After: []string{"setup"}},
+							"lint": {Optional: true},
+						},
+					},
+					Refs: &prowapi.Refs{
+						Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234",
+					},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
+		{
+			name: "metadata server",
+			spec: &coreapi.PodSpec{
+				Volumes: []coreapi.Volume{
+					{Name: "secret", VolumeSource: coreapi.VolumeSource{Secret: &coreapi.SecretVolumeSource{SecretName: "secretname"}}},
+				},
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"/bin/ls"}, Args: []string{"-l", "-a"}, VolumeMounts: []coreapi.VolumeMount{{Name: "secret", MountPath: "/secret"}}},
+				},
+				ServiceAccountName: "tester",
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Hour},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						Resources: &prowapi.Resources{
+							CloneRefs:       &coreapi.ResourceRequirements{Limits: coreapi.ResourceList{"cpu": resource.Quantity{}}, Requests: coreapi.ResourceList{"memory": resource.Quantity{}}},
+							InitUpload:      &coreapi.ResourceRequirements{Limits: coreapi.ResourceList{"cpu": resource.Quantity{}}, Requests: coreapi.ResourceList{"memory": resource.Quantity{}}},
+							PlaceEntrypoint: &coreapi.ResourceRequirements{Limits: coreapi.ResourceList{"cpu": resource.Quantity{}}, Requests: coreapi.ResourceList{"memory": resource.Quantity{}}},
+							Sidecar:         &coreapi.ResourceRequirements{Limits: coreapi.ResourceList{"cpu": resource.Quantity{}}, Requests: coreapi.ResourceList{"memory": resource.Quantity{}}},
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret:      &gCSCredentialsSecret,
+						DefaultServiceAccountName: &defaultServiceAccountName,
+						MetadataServerPort:        &metadataServerPort,
+					},
+					Refs: &prowapi.Refs{
+						Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234",
+						Pulls: []prowapi.Pull{{Number: 1, SHA: "aksdjhfkds"}},
+					},
+					ExtraRefs: []prowapi.Refs{{Org: "other", Repo: "something", BaseRef: "release", BaseSHA: "sldijfsd"}},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
 		{
 			name: "enforcing memory limit",
 			spec: &coreapi.PodSpec{
@@ -1575,6 +1975,41 @@ func TestDecorate(t *testing.T) {
 			},
 			rawEnv: map[string]string{"custom": "env"},
 		},
+		{
+			name: "censoring patterns",
+			spec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "test", Command: []string{"/bin/test"}},
+				},
+				ServiceAccountName: "tester",
+			},
+			pj: &prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Hour},
+						UtilityImages: &prowapi.UtilityImages{
+							CloneRefs:  "cloneimage",
+							InitUpload: "initimage",
+							Entrypoint: "entrypointimage",
+							Sidecar:    "sidecarimage",
+						},
+						GCSConfiguration: &prowapi.GCSConfiguration{
+							Bucket:       "bucket",
+							PathStrategy: "single",
+							DefaultOrg:   "org",
+							DefaultRepo:  "repo",
+						},
+						GCSCredentialsSecret: &gCSCredentialsSecret,
+						CensoringOptions: &prowapi.CensoringOptions{
+							Patterns: []string{`AKIA[0-9A-Z]{16}`},
+						},
+					},
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abcd1234"},
+				},
+			},
+			rawEnv: map[string]string{"custom": "env"},
+		},
 		{
 			name: "ignore interrupts in sidecar",
 			spec: &coreapi.PodSpec{
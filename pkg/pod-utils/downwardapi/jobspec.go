@@ -22,6 +22,7 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/GoogleCloudPlatform/testgrid/metadata"
@@ -105,6 +106,19 @@ const (
 	PullPullShaEnv = "PULL_PULL_SHA"
 	PullHeadRefEnv = "PULL_HEAD_REF"
 	PullTitleEnv   = "PULL_TITLE"
+
+	// ProwVersionEnv holds the name and version of the Prow component that
+	// created the pod of the job, e.g. plank/v20240101-abcdef.
+	ProwVersionEnv = "PROW_VERSION"
+	// The images of the pod utilities that decorate the job.
+	ClonerefsImageEnv  = "PROW_CLONEREFS_IMAGE"
+	InitUploadImageEnv = "PROW_INITUPLOAD_IMAGE"
+	EntrypointImageEnv = "PROW_ENTRYPOINT_IMAGE"
+	SidecarImageEnv    = "PROW_SIDECAR_IMAGE"
+
+	// ProwVersionsKey is the key of the metadata of started.json that holds
+	// the versions of the Prow components that touched the job.
+	ProwVersionsKey = "prow-versions"
 )
 
 // EnvForSpec returns a mapping of environment variables
@@ -125,6 +139,20 @@ func EnvForSpec(spec JobSpec) (map[string]string, error) {
 		env[ProwBuildIDEnv] = spec.BuildID
 	}
 
+	if spec.DecorationConfig != nil && spec.DecorationConfig.UtilityImages != nil {
+		images := spec.DecorationConfig.UtilityImages
+		for name, image := range map[string]string{
+			ClonerefsImageEnv:  images.CloneRefs,
+			InitUploadImageEnv: images.InitUpload,
+			EntrypointImageEnv: images.Entrypoint,
+			SidecarImageEnv:    images.Sidecar,
+		} {
+			if image != "" {
+				env[name] = image
+			}
+		}
+	}
+
 	raw, err := json.Marshal(spec)
 	if err != nil {
 		return env, fmt.Errorf("failed to marshal job spec: %w", err)
@@ -214,6 +242,31 @@ func SpecToStarted(spec *JobSpec, cloneRecords []clone.Record) metadata.Started
 	return refsToStarted(spec.Refs, spec.ExtraRefs, cloneRecords, time.Now().Unix())
 }
 
+// ProwVersions returns the versions of the Prow components that touched a job
+// to record in started.json: the version of the component that created its
+// pod, given as $PROW_VERSION, by the name of the component, and the images
+// of the pod utilities, by the name of the utility.
+func ProwVersions(spec *JobSpec, prowVersion string) map[string]string {
+	versions := map[string]string{}
+	if name, version, ok := strings.Cut(prowVersion, "/"); ok && name != "" && version != "" {
+		versions[name] = version
+	}
+	if spec.DecorationConfig != nil && spec.DecorationConfig.UtilityImages != nil {
+		images := spec.DecorationConfig.UtilityImages
+		for name, image := range map[string]string{
+			"clonerefs":  images.CloneRefs,
+			"initupload": images.InitUpload,
+			"entrypoint": images.Entrypoint,
+			"sidecar":    images.Sidecar,
+		} {
+			if image != "" {
+				versions[name] = image
+			}
+		}
+	}
+	return versions
+}
+
 // refsToStarted translate refs into a Started struct
 // optionally overwrite RepoVersion with provided cloneRecords
 func refsToStarted(refs *prowapi.Refs, extraRefs []prowapi.Refs, cloneRecords []clone.Record, startTime int64) metadata.Started {
@@ -190,6 +190,33 @@ func TestEnvironmentForSpec(t *testing.T) {
 				"JOB_SPEC":    `{"type":"periodic","job":"job-name","buildid":"0","prowjobid":"prowjob"}`,
 			},
 		},
+		{
+			name: "decorated job",
+			spec: JobSpec{
+				Type:      prowapi.PeriodicJob,
+				Job:       "job-name",
+				BuildID:   "0",
+				ProwJobID: "prowjob",
+				DecorationConfig: &prowapi.DecorationConfig{UtilityImages: &prowapi.UtilityImages{
+					CloneRefs:  "clonerefs:v1",
+					InitUpload: "initupload:v1",
+					Entrypoint: "entrypoint:v1",
+					Sidecar:    "sidecar:v1",
+				}},
+			},
+			expected: map[string]string{
+				"CI":                    "true",
+				"JOB_NAME":              "job-name",
+				"BUILD_ID":              "0",
+				"PROW_JOB_ID":           "prowjob",
+				"JOB_TYPE":              "periodic",
+				"JOB_SPEC":              `{"type":"periodic","job":"job-name","buildid":"0","prowjobid":"prowjob","decoration_config":{"utility_images":{"clonerefs":"clonerefs:v1","initupload":"initupload:v1","entrypoint":"entrypoint:v1","sidecar":"sidecar:v1"}}}`,
+				"PROW_CLONEREFS_IMAGE":  "clonerefs:v1",
+				"PROW_INITUPLOAD_IMAGE": "initupload:v1",
+				"PROW_ENTRYPOINT_IMAGE": "entrypoint:v1",
+				"PROW_SIDECAR_IMAGE":    "sidecar:v1",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -203,6 +230,49 @@ func TestEnvironmentForSpec(t *testing.T) {
 	}
 }
 
+func TestProwVersions(t *testing.T) {
+	decorated := &JobSpec{DecorationConfig: &prowapi.DecorationConfig{UtilityImages: &prowapi.UtilityImages{
+		CloneRefs:  "clonerefs:v1",
+		InitUpload: "initupload:v1",
+		Entrypoint: "entrypoint:v1",
+	}}}
+	var tests = []struct {
+		name        string
+		spec        *JobSpec
+		prowVersion string
+		expected    map[string]string
+	}{
+		{
+			name:     "undecorated job without version",
+			spec:     &JobSpec{},
+			expected: map[string]string{},
+		},
+		{
+			name:        "decorated job",
+			spec:        decorated,
+			prowVersion: "plank/v20240101-abcdef",
+			expected: map[string]string{
+				"plank":      "v20240101-abcdef",
+				"clonerefs":  "clonerefs:v1",
+				"initupload": "initupload:v1",
+				"entrypoint": "entrypoint:v1",
+			},
+		},
+		{
+			name:        "malformed version",
+			spec:        &JobSpec{},
+			prowVersion: "v20240101-abcdef",
+			expected:    map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		if actual := ProwVersions(test.spec, test.prowVersion); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: got versions %v but expected %v", test.name, actual, test.expected)
+		}
+	}
+}
+
 func TestGetRevisionFromSpec(t *testing.T) {
 	var tests = []struct {
 		name     string
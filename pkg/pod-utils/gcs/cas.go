@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	utilpointer "k8s.io/utils/pointer"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+)
+
+// ContentAddressedManifestName is the name of the manifest referencing the
+// artifacts of a run that are stored by their content.
+const ContentAddressedManifestName = "cas-manifest.json"
+
+// ContentAddressedManifest references the artifacts of a run that are stored
+// by their content.
+type ContentAddressedManifest struct {
+	// Artifacts are keyed by their path relative to the manifest.
+	Artifacts map[string]ContentAddressedArtifact `json:"artifacts"`
+}
+
+// ContentAddressedArtifact references the content of an artifact.
+type ContentAddressedArtifact struct {
+	// Path is the path of the content under the bucket.
+	Path string `json:"path"`
+	// Digest is the digest of the content, e.g. "sha256:<hex>".
+	Digest string `json:"digest"`
+	// Size is the size of the content in bytes.
+	Size int64 `json:"size"`
+}
+
+// ContentAddressedUpload returns an UploadFunc which writes the manifest
+// referencing the given files, after storing those whose content is not
+// stored yet under the path prefix of cas in the same bucket. The files are
+// keyed by their path relative to the manifest.
+func ContentAddressedUpload(cas *prowapi.ContentAddressedStorage, files map[string]string) UploadFunc {
+	return func(writer dataWriter) error {
+		store, ok := writer.(contentStore)
+		if !ok {
+			return errors.New("content-addressed uploads are only supported to blob storage")
+		}
+
+		manifest := ContentAddressedManifest{Artifacts: map[string]ContentAddressedArtifact{}}
+		contentTargets := map[string]UploadFunc{}
+		for name, file := range files {
+			digest, size, err := fileDigest(file)
+			if err != nil {
+				return fmt.Errorf("failed to digest %s: %w", file, err)
+			}
+			contentPath := path.Join(cas.GetPathPrefix(), "sha256", digest)
+			manifest.Artifacts[name] = ContentAddressedArtifact{Path: contentPath, Digest: "sha256:" + digest, Size: size}
+			if _, queued := contentTargets[contentPath]; queued {
+				continue
+			}
+			exists, err := store.objectExists(contentPath)
+			if err != nil {
+				return fmt.Errorf("failed to check whether %s is stored: %w", contentPath, err)
+			}
+			if !exists {
+				contentTargets[contentPath] = FileUpload(file)
+			}
+		}
+		if err := upload(store.objectWriter, contentTargets, store.retryPolicy()); err != nil {
+			return err
+		}
+
+		content, err := json.Marshal(manifest)
+		if err != nil {
+			return fmt.Errorf("failed to marshal manifest: %w", err)
+		}
+		return DataUploadWithOptions(func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		}, pkgio.WriterOptions{ContentType: utilpointer.String("application/json")})(writer)
+	}
+}
+
+// contentStore is implemented by the writers of objects in a bucket, next to
+// which content-addressed uploads store the content of artifacts.
+type contentStore interface {
+	objectExists(dest string) (bool, error)
+	objectWriter(dest string) dataWriter
+	retryPolicy() RetryPolicy
+}
+
+func (w *openerObjectWriter) objectExists(dest string) (bool, error) {
+	if _, err := w.Opener.Attributes(w.Context, fmt.Sprintf("%s/%s", w.Bucket, dest)); err != nil {
+		if pkgio.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// objectWriter returns a writer of another object in the same bucket. The
+// content is stored as is, as it is shared by artifacts of any name.
+func (w *openerObjectWriter) objectWriter(dest string) dataWriter {
+	writer := &openerObjectWriter{Opener: w.Opener, Context: w.Context, Bucket: w.Bucket, Dest: dest, retry: w.retry}
+	writer.ApplyWriterOptions(w.retry.writerOptions())
+	return writer
+}
+
+func (w *openerObjectWriter) retryPolicy() RetryPolicy {
+	return w.retry
+}
+
+func fileDigest(file string) (string, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
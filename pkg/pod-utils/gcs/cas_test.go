@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/io/providers"
+)
+
+func TestContentAddressedUpload(t *testing.T) {
+	artifactDir := t.TempDir()
+	for name, content := range map[string]string{
+		"binary":   "unchanged",
+		"fixture":  "unchanged",
+		"coverage": "changed",
+	} {
+		if err := os.WriteFile(path.Join(artifactDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write artifact: %v", err)
+		}
+	}
+	bucketDir := t.TempDir()
+	bucket := fmt.Sprintf("%s://%s", providers.File, bucketDir)
+	cas := &prowapi.ContentAddressedStorage{}
+	files := map[string]string{
+		"artifacts/binary":   path.Join(artifactDir, "binary"),
+		"artifacts/fixture":  path.Join(artifactDir, "fixture"),
+		"artifacts/coverage": path.Join(artifactDir, "coverage"),
+	}
+
+	// Content stored by a previous run is not uploaded again.
+	changedDigest, _, err := fileDigest(path.Join(artifactDir, "coverage"))
+	if err != nil {
+		t.Fatalf("failed to digest artifact: %v", err)
+	}
+	unchangedDigest, _, err := fileDigest(path.Join(artifactDir, "binary"))
+	if err != nil {
+		t.Fatalf("failed to digest artifact: %v", err)
+	}
+	if err := os.MkdirAll(path.Join(bucketDir, "cas", "sha256"), 0755); err != nil {
+		t.Fatalf("failed to create content directory: %v", err)
+	}
+	if err := os.WriteFile(path.Join(bucketDir, "cas", "sha256", unchangedDigest), []byte("stored before"), 0644); err != nil {
+		t.Fatalf("failed to write stored content: %v", err)
+	}
+
+	if err := Upload(context.TODO(), bucket, "", "", nil, nil, map[string]UploadFunc{
+		"logs/job/1/" + ContentAddressedManifestName: ContentAddressedUpload(cas, files),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	raw, err := os.ReadFile(path.Join(bucketDir, "logs/job/1", ContentAddressedManifestName))
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	var manifest ContentAddressedManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		t.Fatalf("failed to unmarshal manifest: %v", err)
+	}
+	expected := ContentAddressedManifest{Artifacts: map[string]ContentAddressedArtifact{
+		"artifacts/binary":   {Path: "cas/sha256/" + unchangedDigest, Digest: "sha256:" + unchangedDigest, Size: 9},
+		"artifacts/fixture":  {Path: "cas/sha256/" + unchangedDigest, Digest: "sha256:" + unchangedDigest, Size: 9},
+		"artifacts/coverage": {Path: "cas/sha256/" + changedDigest, Digest: "sha256:" + changedDigest, Size: 7},
+	}}
+	if diff := cmp.Diff(expected, manifest); diff != "" {
+		t.Errorf("unexpected manifest (-want +got):\n%s", diff)
+	}
+
+	for digest, want := range map[string]string{unchangedDigest: "stored before", changedDigest: "changed"} {
+		content, err := os.ReadFile(path.Join(bucketDir, "cas", "sha256", digest))
+		if err != nil {
+			t.Fatalf("failed to read content: %v", err)
+		}
+		if string(content) != want {
+			t.Errorf("expected content %s to be %q, got %q", digest, want, content)
+		}
+	}
+}
+
+func TestContentAddressedUploadLocally(t *testing.T) {
+	err := LocalExport(context.TODO(), t.TempDir(), map[string]UploadFunc{
+		ContentAddressedManifestName: ContentAddressedUpload(&prowapi.ContentAddressedStorage{}, nil),
+	})
+	if err != nil {
+		t.Errorf("expected an empty manifest to be exported, got: %v", err)
+	}
+}
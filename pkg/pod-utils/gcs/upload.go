@@ -26,7 +26,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -36,6 +38,7 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilpointer "k8s.io/utils/pointer"
 
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	pkgio "sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/io/providers"
 )
@@ -47,12 +50,121 @@ type ReaderFunc func() (io.ReadCloser, error)
 
 type destToWriter func(dest string) dataWriter
 
-const retryCount = 4
+// RetryPolicy configures how failed uploads are retried.
+type RetryPolicy struct {
+	// Attempts is how many times an object is tried to be uploaded.
+	Attempts int
+	// InitialBackoff is how long to wait before the first retry. It doubles
+	// with every retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// ChunkSize is the size of the chunks of resumable uploads to GCS, if set.
+	ChunkSize int
+	// ChunkRetryDeadline is how long a chunk of a resumable upload is retried
+	// before the upload is retried from the start, if set.
+	ChunkRetryDeadline time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy of uploads unless configured.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:       4,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// withDefaults fills in the unset fields of the policy from the
+// DefaultRetryPolicy.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Attempts <= 0 {
+		p.Attempts = DefaultRetryPolicy.Attempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	return p
+}
+
+// backoff is how long to wait before the given retry, counted from 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// writerOptions are the options for resumable uploads under the policy.
+func (p RetryPolicy) writerOptions() pkgio.WriterOptions {
+	var opts pkgio.WriterOptions
+	if p.ChunkSize > 0 {
+		opts.ChunkSize = &p.ChunkSize
+	}
+	if p.ChunkRetryDeadline > 0 {
+		opts.ChunkRetryDeadline = &p.ChunkRetryDeadline
+	}
+	return opts
+}
+
+// FailedUpload describes an object that could not be uploaded.
+type FailedUpload struct {
+	// Dest is where the object was to be uploaded.
+	Dest string `json:"dest"`
+	// Attempts is how many times the upload was tried.
+	Attempts int `json:"attempts"`
+	// Error is the error of the last attempt.
+	Error string `json:"error"`
+}
+
+// UploadError is returned when objects fail to upload despite retries.
+type UploadError struct {
+	Failures []FailedUpload
+}
+
+func (e *UploadError) Error() string {
+	errs := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure.Error)
+	}
+	return fmt.Sprintf("encountered errors during upload: %v", errs)
+}
+
+// UploadOptions configure how files are written to blob storage.
+type UploadOptions struct {
+	// CompressFileTypes are the extensions of the files to compress prior
+	// to uploading, or "*" for all.
+	CompressFileTypes []string
+	// CompressionRules select the files to compress by name and size. The
+	// first matching rule wins over CompressFileTypes.
+	CompressionRules []prowapi.CompressionRule
+	// DetectContentTypes sets the content type of every file from its
+	// extension or content.
+	DetectContentTypes bool
+	// Encryption, if set, encrypts the matching files instead of
+	// compressing them.
+	Encryption *prowapi.ArtifactEncryption
+	// Retry configures how failed uploads are retried. Unset fields
+	// default to the DefaultRetryPolicy.
+	Retry RetryPolicy
+}
 
 // Upload uploads all the data in the uploadTargets map to blob storage in parallel.
 // The map is keyed on blob storage path under the bucket.
 // Files with an extension in the compressFileTypes list will be compressed prior to uploading
-func Upload(ctx context.Context, bucket, gcsCredentialsFile, s3CredentialsFile string, compressFileTypes []string, uploadTargets map[string]UploadFunc) error {
+// Files matching the patterns of the encryption config, if any, will be encrypted instead
+func Upload(ctx context.Context, bucket, gcsCredentialsFile, s3CredentialsFile string, compressFileTypes []string, encryption *prowapi.ArtifactEncryption, uploadTargets map[string]UploadFunc) error {
+	return UploadWithOptions(ctx, bucket, gcsCredentialsFile, s3CredentialsFile, UploadOptions{CompressFileTypes: compressFileTypes, Encryption: encryption}, uploadTargets)
+}
+
+// UploadWithOptions uploads all the data in the uploadTargets map to blob
+// storage in parallel like Upload, compressing and typing the files as
+// configured by opts.
+func UploadWithOptions(ctx context.Context, bucket, gcsCredentialsFile, s3CredentialsFile string, opts UploadOptions, uploadTargets map[string]UploadFunc) error {
 	parsedBucket, err := url.Parse(bucket)
 	if err != nil {
 		return fmt.Errorf("cannot parse bucket name %s: %w", bucket, err)
@@ -65,18 +177,49 @@ func Upload(ctx context.Context, bucket, gcsCredentialsFile, s3CredentialsFile s
 	if err != nil {
 		return fmt.Errorf("new opener: %w", err)
 	}
+	var encryptionPatterns []string
+	if opts.Encryption != nil {
+		opener, err = pkgio.NewEncryptingOpener(ctx, opener, opts.Encryption.KeyURL, opts.Encryption.Patterns)
+		if err != nil {
+			return fmt.Errorf("new encrypting opener: %w", err)
+		}
+		encryptionPatterns = opts.Encryption.Patterns
+	}
+	compressFileTypes := sets.New[string](opts.CompressFileTypes...)
 	dtw := func(dest string) dataWriter {
-		compressFileType := shouldCompressFileType(dest, sets.New[string](compressFileTypes...))
-		return &openerObjectWriter{Opener: opener, Context: ctx, Bucket: parsedBucket.String(), Dest: dest, compressFileType: compressFileType}
+		w := &openerObjectWriter{Opener: opener, Context: ctx, Bucket: parsedBucket.String(), Dest: dest, detectContentType: opts.DetectContentTypes, retry: opts.Retry}
+		w.ApplyWriterOptions(opts.Retry.writerOptions())
+		// Encrypted content does not compress.
+		if !pkgio.MatchesEncryptionPatterns(encryptionPatterns, dest) {
+			w.compressFileType, w.compressMinSize = shouldCompress(dest, opts.CompressionRules, compressFileTypes)
+		}
+		return w
 	}
-	return upload(dtw, uploadTargets)
+	return upload(dtw, uploadTargets, opts.Retry)
 }
 
-func shouldCompressFileType(dest string, compressFileTypes sets.Set[string]) bool {
+// shouldCompress determines whether the file uploaded to dest is compressed
+// and from which size on.
+func shouldCompress(dest string, rules []prowapi.CompressionRule, compressFileTypes sets.Set[string]) (bool, int) {
+	name := path.Base(dest)
+	for _, rule := range rules {
+		if matched, _ := path.Match(rule.Pattern, name); matched {
+			return !isGzipFile(dest), rule.GetMinSize()
+		}
+	}
+	return shouldCompressFileType(dest, compressFileTypes), 0
+}
+
+func isGzipFile(dest string) bool {
 	ext := strings.TrimPrefix(filepath.Ext(dest), ".")
-	if ext == "gz" || ext == "gzip" {
+	return ext == "gz" || ext == "gzip"
+}
+
+func shouldCompressFileType(dest string, compressFileTypes sets.Set[string]) bool {
+	if isGzipFile(dest) {
 		return false
 	}
+	ext := strings.TrimPrefix(filepath.Ext(dest), ".")
 	return compressFileTypes.Has("*") || compressFileTypes.Has(ext)
 }
 
@@ -90,11 +233,15 @@ func LocalExport(ctx context.Context, exportDir string, uploadTargets map[string
 	dtw := func(dest string) dataWriter {
 		return &openerObjectWriter{Opener: opener, Context: ctx, Bucket: exportDir, Dest: dest}
 	}
-	return upload(dtw, uploadTargets)
+	return upload(dtw, uploadTargets, DefaultRetryPolicy)
 }
 
-func upload(dtw destToWriter, uploadTargets map[string]UploadFunc) error {
-	errCh := make(chan error, len(uploadTargets))
+// upload uploads the targets in parallel, retrying failed uploads as
+// configured by the policy. The targets that fail to upload nonetheless
+// are listed by the returned *UploadError.
+func upload(dtw destToWriter, uploadTargets map[string]UploadFunc, retry RetryPolicy) error {
+	retry = retry.withDefaults()
+	errCh := make(chan FailedUpload, len(uploadTargets))
 	group := &sync.WaitGroup{}
 	sem := semaphore.NewWeighted(4)
 	group.Add(len(uploadTargets))
@@ -102,12 +249,12 @@ func upload(dtw destToWriter, uploadTargets map[string]UploadFunc) error {
 		writer := dtw(dest)
 		log := logrus.WithField("dest", writer.fullUploadPath())
 		log.Info("Queued for upload")
-		go func(f UploadFunc, writer dataWriter, log *logrus.Entry) {
+		go func(dest string, f UploadFunc, writer dataWriter, log *logrus.Entry) {
 			defer group.Done()
 
 			var err error
 
-			for retryIndex := 1; retryIndex <= retryCount; retryIndex++ {
+			for retryIndex := 1; retryIndex <= retry.Attempts; retryIndex++ {
 				err = func() error {
 					sem.Acquire(context.Background(), 1)
 					defer sem.Release(1)
@@ -120,27 +267,30 @@ func upload(dtw destToWriter, uploadTargets map[string]UploadFunc) error {
 				if err == nil {
 					break
 				}
-				if retryIndex < retryCount {
-					time.Sleep(time.Duration(retryIndex*retryIndex) * time.Second)
+				if retryIndex < retry.Attempts {
+					time.Sleep(retry.backoff(retryIndex))
 				}
 			}
 
 			if err != nil {
-				errCh <- err
-				log.Info("Failed upload")
+				errCh <- FailedUpload{Dest: dest, Attempts: retry.Attempts, Error: err.Error()}
+				log.WithError(err).Info("Failed upload")
 			} else {
 				log.Info("Finished upload")
 			}
-		}(upload, writer, log)
+		}(dest, upload, writer, log)
 	}
 	group.Wait()
 	close(errCh)
 	if len(errCh) != 0 {
-		var uploadErrors []error
-		for err := range errCh {
-			uploadErrors = append(uploadErrors, err)
+		uploadErr := &UploadError{}
+		for failure := range errCh {
+			uploadErr.Failures = append(uploadErr.Failures, failure)
 		}
-		return fmt.Errorf("encountered errors during upload: %v", uploadErrors)
+		sort.Slice(uploadErr.Failures, func(i, j int) bool {
+			return uploadErr.Failures[i].Dest < uploadErr.Failures[j].Dest
+		})
+		return uploadErr
 	}
 	return nil
 }
@@ -238,57 +388,106 @@ type openerObjectWriter struct {
 	Bucket           string
 	Dest             string
 	compressFileType bool
-	opts             []pkgio.WriterOptions
-	writer           pkgio.Writer
-	closers          []pkgio.Closer
+	// compressMinSize is the size from which the object is compressed,
+	// defaulting to anything larger than 1KiB.
+	compressMinSize   int
+	detectContentType bool
+	// retry is the retry policy of the upload, which also applies to the
+	// content of content-addressed artifacts.
+	retry RetryPolicy
+	opts  []pkgio.WriterOptions
+	// head holds the start of the object until there is enough of it to
+	// decide on its compression and content type.
+	head    []byte
+	writer  pkgio.Writer
+	closers []pkgio.Closer
+}
+
+// headSize is the size of the start of the object needed to decide on its
+// compression and content type.
+func (w *openerObjectWriter) headSize() int {
+	switch {
+	case w.compressFileType && w.compressMinSize > 0:
+		return w.compressMinSize
+	case w.compressFileType:
+		return 1025
+	case w.detectContentType:
+		// http.DetectContentType considers at most 512 bytes.
+		return 512
+	}
+	return 0
 }
 
 func (w *openerObjectWriter) Write(p []byte) (n int, err error) {
 	if w.writer == nil {
-		largerThanOneKB := len(p) > 1024
-		shouldCompressFile := w.compressFileType && largerThanOneKB && http.DetectContentType(p) != "application/x-gzip"
-		if shouldCompressFile {
-			path := w.fullUploadPath()
-			ext := filepath.Ext(path)
-			mediaType := mime.TypeByExtension(ext)
-			if mediaType == "" {
-				mediaType = "text/plain; charset=utf-8"
-			}
-			ce := "gzip"
-			w.opts = append(w.opts, pkgio.WriterOptions{
-				ContentType:     &mediaType,
-				ContentEncoding: &ce,
-			})
+		head := p
+		if len(w.head) > 0 || len(p) < w.headSize() {
+			w.head = append(w.head, p...)
+			head = w.head
 		}
-		var storageWriter pkgio.WriteCloser
-		storageWriter, err = w.Opener.Writer(w.Context, w.fullUploadPath(), w.opts...)
-		if err != nil {
-			return 0, err
+		if len(head) < w.headSize() {
+			return len(p), nil
 		}
-		if shouldCompressFile {
-			zipWriter := gzip.NewWriter(storageWriter)
-			w.writer = zipWriter
-			w.closers = append(w.closers, zipWriter)
-		} else {
-			w.writer = storageWriter
+		if err := w.open(head); err != nil {
+			return 0, err
 		}
-		// The storage closer needs to be last in the list to close in the correct order
-		w.closers = append(w.closers, storageWriter)
+		return len(p), nil
 	}
 	return w.writer.Write(p)
 }
 
+// open opens the object, compressing it and setting its content type as
+// configured, and writes its start.
+func (w *openerObjectWriter) open(head []byte) error {
+	w.head = nil
+	sniffed := http.DetectContentType(head)
+	mediaType := mime.TypeByExtension(filepath.Ext(w.Dest))
+	if mediaType == "" && w.detectContentType && len(head) > 0 {
+		mediaType = sniffed
+	}
+	opts := w.opts
+	shouldCompressFile := w.compressFileType && len(head) >= w.headSize() && sniffed != "application/x-gzip"
+	if shouldCompressFile {
+		if mediaType == "" {
+			mediaType = "text/plain; charset=utf-8"
+		}
+		ce := "gzip"
+		opts = append(opts[:len(opts):len(opts)], pkgio.WriterOptions{
+			ContentType:     &mediaType,
+			ContentEncoding: &ce,
+		})
+	} else if w.detectContentType && mediaType != "" {
+		// The content type set by the caller, if any, wins.
+		opts = append([]pkgio.WriterOptions{{ContentType: &mediaType}}, opts...)
+	}
+	storageWriter, err := w.Opener.Writer(w.Context, w.fullUploadPath(), opts...)
+	if err != nil {
+		return err
+	}
+	if shouldCompressFile {
+		zipWriter := gzip.NewWriter(storageWriter)
+		w.writer = zipWriter
+		w.closers = append(w.closers, zipWriter)
+	} else {
+		w.writer = storageWriter
+	}
+	// The storage closer needs to be last in the list to close in the correct order
+	w.closers = append(w.closers, storageWriter)
+	_, err = w.writer.Write(head)
+	return err
+}
+
 func (w *openerObjectWriter) Close() error {
+	var errs []error
 	if w.writer == nil {
 		// Always create a writer even if Write() was never called
 		// otherwise empty files are never created, because Write() is
 		// never called for them
-		if _, err := w.Write([]byte("")); err != nil {
-			return err
+		if err := w.open(w.head); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
-	var errs []error
 	for _, closer := range w.closers {
 		if err := closer.Close(); err != nil {
 			errs = append(errs, err)
@@ -19,6 +19,7 @@ package gcs
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	stdio "io"
@@ -28,10 +29,12 @@ import (
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/fsouza/fake-gcs-server/fakestorage"
 
 	"k8s.io/apimachinery/pkg/util/diff"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/io/providers"
 )
@@ -153,7 +156,7 @@ func TestUploadNewReaderFunc(t *testing.T) {
 			readerFunc, readerFuncMeta := newReaderFunc(testCase.readerFuncOpts)
 			uploadTargets[path.Base(f.Name())] = DataUpload(readerFunc)
 			bucket := fmt.Sprintf("%s://%s", providers.File, path.Dir(f.Name()))
-			err = Upload(context.TODO(), bucket, "", "", testCase.compressFileTypes, uploadTargets)
+			err = Upload(context.TODO(), bucket, "", "", testCase.compressFileTypes, nil, uploadTargets)
 			if testCase.isErrExpected && err == nil {
 				t.Errorf("error expected but got nil")
 			}
@@ -280,7 +283,7 @@ func TestUploadWithRetries(t *testing.T) {
 			}
 
 			ctx := context.Background()
-			err := Upload(ctx, "", "", "", []string{}, uploadFuncs)
+			err := Upload(ctx, "", "", "", []string{}, nil, uploadFuncs)
 
 			isErrExpected := false
 			for _, currentTestState := range currentTestStates {
@@ -298,11 +301,87 @@ func TestUploadWithRetries(t *testing.T) {
 			if (err != nil) != isErrExpected {
 				t.Errorf("%v: Got unexpected error response: %v", testCase.name, err)
 			}
+
+			var expectedFailures []string
+			for _, destBehavior := range testCase.destUploadBehaviors {
+				if !destBehavior.doesPass {
+					expectedFailures = append(expectedFailures, destBehavior.dest)
+				}
+			}
+			var failures []string
+			var uploadErr *UploadError
+			if errors.As(err, &uploadErr) {
+				for _, failure := range uploadErr.Failures {
+					if failure.Attempts != DefaultRetryPolicy.Attempts {
+						t.Errorf("expected %d attempts to upload %s, got %d", DefaultRetryPolicy.Attempts, failure.Dest, failure.Attempts)
+					}
+					failures = append(failures, failure.Dest)
+				}
+			}
+			if !reflect.DeepEqual(expectedFailures, failures) {
+				t.Errorf("expected failed uploads %v, got %v", expectedFailures, failures)
+			}
 		})
 
 	}
 }
 
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{Attempts: 6, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for retry, backoff := range expected {
+		if actual := policy.backoff(retry + 1); actual != backoff {
+			t.Errorf("expected a backoff of %s before retry %d, got %s", backoff, retry+1, actual)
+		}
+	}
+}
+
+func TestRetryPolicyWithDefaults(t *testing.T) {
+	if actual := (RetryPolicy{}).withDefaults(); actual != DefaultRetryPolicy {
+		t.Errorf("expected the default retry policy %+v, got %+v", DefaultRetryPolicy, actual)
+	}
+	policy := RetryPolicy{Attempts: 2, ChunkSize: 8 << 20}
+	expected := RetryPolicy{Attempts: 2, InitialBackoff: time.Second, MaxBackoff: 30 * time.Second, ChunkSize: 8 << 20}
+	if actual := policy.withDefaults(); actual != expected {
+		t.Errorf("expected retry policy %+v, got %+v", expected, actual)
+	}
+}
+
+func TestUploadEncryption(t *testing.T) {
+	tempDir := t.TempDir()
+	content := bytes.Repeat([]byte("apiVersion: v1\n"), 100)
+	newReader := func() (stdio.ReadCloser, error) {
+		return stdio.NopCloser(bytes.NewReader(content)), nil
+	}
+	uploadTargets := map[string]UploadFunc{
+		"admin.kubeconfig": DataUpload(newReader),
+		"build-log.txt":    DataUpload(newReader),
+	}
+	encryption := &prowapi.ArtifactEncryption{
+		KeyURL:   "base64key://" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("k"), 32)),
+		Patterns: []string{"*.kubeconfig"},
+	}
+	bucket := fmt.Sprintf("%s://%s", providers.File, tempDir)
+	if err := Upload(context.TODO(), bucket, "", "", []string{"*"}, encryption, uploadTargets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	encrypted, err := os.ReadFile(path.Join(tempDir, "admin.kubeconfig"))
+	if err != nil {
+		t.Fatalf("failed to read encrypted file: %v", err)
+	}
+	if !bytes.HasPrefix(encrypted, []byte("PROWENC1")) || bytes.Contains(encrypted, content) {
+		t.Error("expected the matching file to be encrypted without compression")
+	}
+	compressed, err := os.ReadFile(path.Join(tempDir, "build-log.txt"))
+	if err != nil {
+		t.Fatalf("failed to read compressed file: %v", err)
+	}
+	if !bytes.HasPrefix(compressed, []byte{0x1f, 0x8b}) {
+		t.Error("expected the other file to be compressed")
+	}
+}
+
 func TestShouldCompressFileType(t *testing.T) {
 	testCases := []struct {
 		name              string
@@ -339,6 +418,57 @@ func TestShouldCompressFileType(t *testing.T) {
 	}
 }
 
+func TestShouldCompress(t *testing.T) {
+	rules := []prowapi.CompressionRule{
+		{Pattern: "*.log", MinSize: 4096},
+		{Pattern: "junit*"},
+	}
+	testCases := []struct {
+		name         string
+		dest         string
+		expected     bool
+		expectedSize int
+	}{
+		{
+			name:         "matching rule",
+			dest:         "artifacts/kubelet.log",
+			expected:     true,
+			expectedSize: 4096,
+		},
+		{
+			name:         "matching rule with default min size",
+			dest:         "artifacts/junit_01.xml",
+			expected:     true,
+			expectedSize: 1024,
+		},
+		{
+			name:     "no matching rule falls back to file types",
+			dest:     "build-log.txt",
+			expected: true,
+		},
+		{
+			name: "matching neither rules nor file types",
+			dest: "artifacts/metadata.json",
+		},
+		{
+			name:         "gzipped file matching rule",
+			dest:         "artifacts/junit_01.xml.gz",
+			expectedSize: 1024,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, size := shouldCompress(tc.dest, rules, sets.New[string]("txt"))
+			if tc.expected != result {
+				t.Errorf("result (%v) did not match expected (%v)", result, tc.expected)
+			}
+			if tc.expectedSize != size {
+				t.Errorf("min size (%d) did not match expected (%d)", size, tc.expectedSize)
+			}
+		})
+	}
+}
+
 func Test_openerObjectWriter_Write(t *testing.T) {
 
 	fakeBucket := "test-bucket"
@@ -352,8 +482,13 @@ func Test_openerObjectWriter_Write(t *testing.T) {
 		ObjectDest       string
 		ObjectContent    []byte
 		compressFileType bool
+		compressMinSize  int
+		detectType       bool
 		wantN            int
 		wantErr          bool
+		wantContentType  string
+		wantEncoding     string
+		wantUncompressed bool
 	}{
 		{
 			name:          "write regular file",
@@ -376,6 +511,7 @@ func Test_openerObjectWriter_Write(t *testing.T) {
 			compressFileType: true,
 			wantN:            1132,
 			wantErr:          false,
+			wantEncoding:     "gzip",
 		},
 		{
 			name:             "compress filetype, but file is too small to compress",
@@ -393,15 +529,52 @@ func Test_openerObjectWriter_Write(t *testing.T) {
 			wantN:            1136,
 			wantErr:          false,
 		},
+		{
+			name:             "compress file from min size of rule",
+			ObjectDest:       "build/build-log.txt",
+			ObjectContent:    []byte("Oh wow\nlogs\nthis is\ncrazy"),
+			compressFileType: true,
+			compressMinSize:  16,
+			wantN:            25,
+			wantContentType:  "text/plain; charset=utf-8",
+			wantEncoding:     "gzip",
+		},
+		{
+			name:             "file smaller than min size of rule is not compressed",
+			ObjectDest:       "build/build-log.txt",
+			ObjectContent:    []byte("Oh wow\nlogs\nthis is\ncrazy"),
+			compressFileType: true,
+			compressMinSize:  64,
+			wantN:            25,
+			wantUncompressed: true,
+		},
+		{
+			name:            "detect content type of file without extension",
+			ObjectDest:      "build/finished",
+			ObjectContent:   []byte("Oh wow\nlogs\nthis is\ncrazy"),
+			detectType:      true,
+			wantN:           25,
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "detect content type from extension",
+			ObjectDest:      "build/report.html",
+			ObjectContent:   []byte("Oh wow\nlogs\nthis is\ncrazy"),
+			detectType:      true,
+			wantN:           25,
+			wantContentType: "text/html; charset=utf-8",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := &openerObjectWriter{
-				Opener:           io.NewGCSOpener(fakeGCSClient),
-				Context:          context.Background(),
-				Bucket:           fmt.Sprintf("gs://%s", fakeBucket),
-				Dest:             tt.ObjectDest,
-				compressFileType: tt.compressFileType,
+				Opener:            io.NewGCSOpener(fakeGCSClient),
+				Context:           context.Background(),
+				Bucket:            fmt.Sprintf("gs://%s", fakeBucket),
+				Dest:              tt.ObjectDest,
+				compressFileType:  tt.compressFileType,
+				compressMinSize:   tt.compressMinSize,
+				detectContentType: tt.detectType,
 			}
 			gotN, err := w.Write(tt.ObjectContent)
 			if (err != nil) != tt.wantErr {
@@ -429,6 +602,20 @@ func Test_openerObjectWriter_Write(t *testing.T) {
 			if !bytes.Equal(tt.ObjectContent, gotObjectContent) {
 				t.Errorf("Write() gotObjectContent = %v, want %v", gotObjectContent, tt.ObjectContent)
 			}
+
+			attrs, err := fakeGCSClient.Bucket(fakeBucket).Object(tt.ObjectDest).Attrs(context.Background())
+			if err != nil {
+				t.Fatalf("Got unexpected error reading attributes of object %s: %v", tt.ObjectDest, err)
+			}
+			if tt.wantContentType != "" && attrs.ContentType != tt.wantContentType {
+				t.Errorf("Got content type %q, want %q", attrs.ContentType, tt.wantContentType)
+			}
+			if tt.wantEncoding != "" && attrs.ContentEncoding != tt.wantEncoding {
+				t.Errorf("Got content encoding %q, want %q", attrs.ContentEncoding, tt.wantEncoding)
+			}
+			if tt.wantUncompressed && attrs.Size != int64(len(tt.ObjectContent)) {
+				t.Errorf("Got stored size %d, want the uncompressed size %d", attrs.Size, len(tt.ObjectContent))
+			}
 		})
 	}
 }
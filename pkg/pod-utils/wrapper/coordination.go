@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Dependency is a process that must finish before another one runs.
+type Dependency struct {
+	// ContainerName is the name of the container of the process.
+	ContainerName string `json:"container_name"`
+	// MarkerFile is the marker file of the process.
+	MarkerFile string `json:"marker_file"`
+	// Optional dependencies do not prevent the processes
+	// that depend on them from running when they fail.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// WaitForDependencies waits for the marker files of the dependencies and
+// returns the names of the containers of the required dependencies that
+// failed. Markers that could not be read count as failures.
+func WaitForDependencies(ctx context.Context, dependencies ...Dependency) ([]string, error) {
+	var paths []string
+	for _, dependency := range dependencies {
+		paths = append(paths, dependency.MarkerFile)
+	}
+	results := WaitForMarkers(ctx, paths...)
+	var failed []string
+	for _, dependency := range dependencies {
+		result := results[dependency.MarkerFile]
+		if errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("wait for %s: %w", dependency.ContainerName, result.Err)
+		}
+		if (result.Err != nil || result.ReturnCode != 0) && !dependency.Optional {
+			failed = append(failed, dependency.ContainerName)
+		}
+	}
+	return failed, nil
+}
+
+// SharedDeadline returns the deadline stored in the deadline file at path.
+// The first process to call it stores the proposed deadline, so all the
+// processes of a pod share the deadline of the one that started first.
+func SharedDeadline(path string, proposed time.Time) (time.Time, error) {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not create temp deadline file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.WriteString(proposed.UTC().Format(time.RFC3339Nano)); err != nil {
+		tempFile.Close()
+		return time.Time{}, fmt.Errorf("could not write temp deadline file (%s): %w", tempFile.Name(), err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return time.Time{}, fmt.Errorf("could not close temp deadline file (%s): %w", tempFile.Name(), err)
+	}
+	// Linking fails if the deadline file exists, so only the first process
+	// stores its deadline and no process ever reads a partial file.
+	if err := os.Link(tempFile.Name(), path); err == nil {
+		return proposed, nil
+	} else if !errors.Is(err, os.ErrExist) {
+		return time.Time{}, fmt.Errorf("could not link deadline file to destination path (%s): %w", path, err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not read deadline file: %w", err)
+	}
+	deadline, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(raw)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid deadline in %s: %w", path, err)
+	}
+	return deadline, nil
+}
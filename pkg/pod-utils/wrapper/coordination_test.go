@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWaitForDependencies(t *testing.T) {
+	testCases := []struct {
+		name           string
+		markers        map[string]string
+		dependencies   []Dependency
+		cancel         bool
+		expectedFailed []string
+		expectedErr    bool
+	}{
+		{
+			name:         "all dependencies passed",
+			markers:      map[string]string{"setup": "0", "build": "0"},
+			dependencies: []Dependency{{ContainerName: "setup"}, {ContainerName: "build"}},
+		},
+		{
+			name:           "required dependencies failed",
+			markers:        map[string]string{"setup": "1", "build": "0", "lint": "not-an-exit-code"},
+			dependencies:   []Dependency{{ContainerName: "setup"}, {ContainerName: "build"}, {ContainerName: "lint"}},
+			expectedFailed: []string{"setup", "lint"},
+		},
+		{
+			name:         "optional dependency failed",
+			markers:      map[string]string{"setup": "0", "lint": "1"},
+			dependencies: []Dependency{{ContainerName: "setup"}, {ContainerName: "lint", Optional: true}},
+		},
+		{
+			name:         "cancelled while waiting",
+			markers:      map[string]string{"setup": "0"},
+			dependencies: []Dependency{{ContainerName: "setup"}, {ContainerName: "build"}},
+			cancel:       true,
+			expectedErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for i, dependency := range tc.dependencies {
+				tc.dependencies[i].MarkerFile = filepath.Join(dir, dependency.ContainerName+"-marker.txt")
+				if code, ok := tc.markers[dependency.ContainerName]; ok {
+					if err := os.WriteFile(tc.dependencies[i].MarkerFile, []byte(code), 0600); err != nil {
+						t.Fatalf("could not write marker: %v", err)
+					}
+				}
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancel {
+				go cancel()
+			}
+			failed, err := WaitForDependencies(ctx, tc.dependencies...)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expectedFailed, failed); diff != "" {
+				t.Errorf("failed dependencies differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSharedDeadline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deadline.txt")
+	first := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	deadline, err := SharedDeadline(path, first)
+	if err != nil {
+		t.Fatalf("could not determine the deadline: %v", err)
+	}
+	if !deadline.Equal(first) {
+		t.Errorf("expected the first process to store its deadline %s, got %s", first, deadline)
+	}
+	deadline, err = SharedDeadline(path, first.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("could not determine the deadline: %v", err)
+	}
+	if !deadline.Equal(first) {
+		t.Errorf("expected later processes to share the deadline %s, got %s", first, deadline)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("could not read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the deadline file to remain, got %d files", len(entries))
+	}
+}
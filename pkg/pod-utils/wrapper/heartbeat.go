@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HungIntervals is the number of heartbeat intervals without output
+// or without a heartbeat after which a test process is considered hung.
+const HungIntervals = 10
+
+// Heartbeat is periodically written to the heartbeat file by the
+// entrypoint while the test process runs.
+type Heartbeat struct {
+	// Timestamp is when the heartbeat was written.
+	Timestamp time.Time `json:"timestamp"`
+	// LastOutput is when the test process last wrote
+	// to stdout or stderr, or when it started if it
+	// has not written anything yet.
+	LastOutput time.Time `json:"last_output"`
+	// Interval is how often the heartbeat is written.
+	Interval time.Duration `json:"interval"`
+}
+
+// Hung determines whether the test process writing the heartbeat
+// looks hung at the given time, either because it has not produced
+// output or because the heartbeat itself stopped being written.
+func (h Heartbeat) Hung(now time.Time) (bool, string) {
+	threshold := HungIntervals * h.Interval
+	if threshold <= 0 {
+		return false, ""
+	}
+	if since := now.Sub(h.Timestamp); since > threshold {
+		return true, fmt.Sprintf("no heartbeat for %s", since.Round(time.Second))
+	}
+	if since := now.Sub(h.LastOutput); since > threshold {
+		return true, fmt.Sprintf("no output for %s", since.Round(time.Second))
+	}
+	return false, ""
+}
+
+// WriteHeartbeat atomically writes the heartbeat to the given path.
+func WriteHeartbeat(path string, heartbeat Heartbeat) error {
+	raw, err := json.Marshal(heartbeat)
+	if err != nil {
+		return fmt.Errorf("could not marshal heartbeat: %w", err)
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("could not create temp heartbeat file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(raw); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("could not write temp heartbeat file (%s): %w", tempFile.Name(), err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("could not close temp heartbeat file (%s): %w", tempFile.Name(), err)
+	}
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		return fmt.Errorf("could not move heartbeat file to destination path (%s): %w", path, err)
+	}
+	return nil
+}
+
+// ReadHeartbeat reads the heartbeat from the given path.
+func ReadHeartbeat(path string) (Heartbeat, error) {
+	var heartbeat Heartbeat
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return heartbeat, err
+	}
+	if err := json.Unmarshal(raw, &heartbeat); err != nil {
+		return heartbeat, fmt.Errorf("could not unmarshal heartbeat from %s: %w", path, err)
+	}
+	return heartbeat, nil
+}
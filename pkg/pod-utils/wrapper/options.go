@@ -57,6 +57,25 @@ type Options struct {
 	// Prow will parse the file and merge it into
 	// the `metadata` field in finished.json
 	MetadataFile string `json:"metadata_file"`
+
+	// HeartbeatFile is periodically written with a
+	// Heartbeat while the test process runs, if set.
+	HeartbeatFile string `json:"heartbeat_file,omitempty"`
+
+	// After lists the processes that must finish before
+	// the test process runs. It is skipped if any of the
+	// required ones fails.
+	After []Dependency `json:"after,omitempty"`
+
+	// Optional test processes do not fail the job when
+	// they fail.
+	Optional bool `json:"optional,omitempty"`
+
+	// DeadlineFile holds the deadline shared by all the
+	// test processes of the pod, if set. The timeout is
+	// then counted from when the first process started,
+	// including the time spent waiting for dependencies.
+	DeadlineFile string `json:"deadline_file,omitempty"`
 }
 
 type MarkerResult struct {
@@ -70,6 +89,7 @@ func (o *Options) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&o.ProcessLog, "process-log", "", "path to the log where stdout and stderr are streamed for the process we execute")
 	fs.StringVar(&o.MarkerFile, "marker-file", "", "file we write the return code of the process we execute once it has finished running")
 	fs.StringVar(&o.MetadataFile, "metadata-file", "", "path to the metadata file generated from the job")
+	fs.StringVar(&o.HeartbeatFile, "heartbeat-file", "", "path to the heartbeat file written while the process we execute is running")
 }
 
 // Validate ensures that the set of options are
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	"os"
+	"strings"
+)
+
+// VersionFile returns the file next to the marker file the entrypoint records
+// its build version in, so that sidecar can tell whether the pod utilities of
+// the pod are of the same version and agree on the format of their files.
+func VersionFile(markerFile string) string {
+	return markerFile + ".version"
+}
+
+// WriteVersion records the build version of the entrypoint for the process
+// with the given marker file.
+func WriteVersion(markerFile, version string) error {
+	return os.WriteFile(VersionFile(markerFile), []byte(version+"\n"), 0644)
+}
+
+// ReadVersion reads the build version the entrypoint for the process with the
+// given marker file recorded. Entrypoints that predate version files leave
+// none, which is reported as an error satisfying os.IsNotExist.
+func ReadVersion(markerFile string) (string, error) {
+	raw, err := os.ReadFile(VersionFile(markerFile))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorResponseEvent is the event type of the ErrorResponses published to
+// reply topics.
+const ErrorResponseEvent = "prow.k8s.io/pubsub.ErrorResponse"
+
+// ErrorResponse is published to the reply topic of a trigger for each message
+// that did not trigger a ProwJob, so that its producer learns why.
+type ErrorResponse struct {
+	// MessageID is the ID of the message that did not trigger a ProwJob.
+	MessageID     string `json:"message_id"`
+	Subscription  string `json:"subscription"`
+	SchemaVersion string `json:"schema_version"`
+	// EventType is the event type of the message, if it has one.
+	EventType string       `json:"event_type,omitempty"`
+	Code      ErrorCode    `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+type replyClient interface {
+	Publish(ctx context.Context, project, topic string, msg *pubsub.Message) error
+}
+
+// Replier publishes ErrorResponses to Pub/Sub topics.
+type Replier struct{}
+
+// NewReplier creates a new Replier.
+func NewReplier() *Replier {
+	return &Replier{}
+}
+
+// Publish publishes a message to a topic of a project.
+func (r *Replier) Publish(ctx context.Context, project, topic string, msg *pubsub.Message) error {
+	// TODO: Consider caching the pubsub client.
+	client, err := pubsub.NewClient(ctx, project)
+	if err != nil {
+		return fmt.Errorf("could not create pubsub Client: %w", err)
+	}
+	defer func() {
+		logrus.WithError(client.Close()).Debug("Closed pubsub client.")
+	}()
+	t := client.Topic(topic)
+	defer t.Stop()
+	if _, err := t.Publish(ctx, msg).Get(ctx); err != nil {
+		return fmt.Errorf("failed to publish to topic \"%s/%s\": %w", project, topic, err)
+	}
+	return nil
+}
+
+// newErrorResponse returns the ErrorResponse telling why msg did not trigger
+// a ProwJob.
+func newErrorResponse(msg messageInterface, subscription string, err error) *ErrorResponse {
+	attrs := msg.getAttributes()
+	version, ok := attrs[ProwSchemaVersion]
+	if !ok {
+		version = SchemaVersionV1
+	}
+	resp := &ErrorResponse{
+		MessageID:     msg.getID(),
+		Subscription:  subscription,
+		SchemaVersion: version,
+		EventType:     attrs[ProwEventType],
+		Code:          ErrorCodeMalformedMessage,
+		Message:       err.Error(),
+	}
+	var msgErr *MessageError
+	if errors.As(err, &msgErr) {
+		resp.Code = msgErr.Code
+		resp.Fields = msgErr.Fields
+	}
+	return resp
+}
+
+// replyError publishes why msg did not trigger a ProwJob to the reply topic of
+// its trigger, if it has one.
+func (s *Subscriber) replyError(ctx context.Context, l *logrus.Entry, msg messageInterface, subscription, project, replyTopic string, err error) {
+	if s.Replier == nil || replyTopic == "" {
+		return
+	}
+	l = l.WithFields(logrus.Fields{"reply-topic": replyTopic, "pubsub-id": msg.getID()})
+	data, marshalErr := json.Marshal(newErrorResponse(msg, subscription, err))
+	if marshalErr != nil {
+		l.WithError(marshalErr).Error("Failed to marshal error response.")
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := s.Replier.Publish(ctx, project, replyTopic, &pubsub.Message{
+		Data:       data,
+		Attributes: map[string]string{ProwEventType: ErrorResponseEvent},
+	}); err != nil {
+		l.WithError(err).Warn("Failed to publish error response.")
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// ProwSchemaVersion is the attribute holding the version of the schema
+	// of the payload of a message. Messages without it use SchemaVersionV1.
+	ProwSchemaVersion = "prow.k8s.io/pubsub.SchemaVersion"
+
+	// SchemaVersionV1 is the original, lenient schema of ProwJobEvent
+	// payloads: unknown fields are ignored and nothing is validated before
+	// creating the ProwJob.
+	SchemaVersionV1 = "v1"
+	// SchemaVersionV2 is the strict schema of ProwJobEvent payloads: unknown
+	// fields are rejected, and the fields each event type requires must be
+	// set.
+	SchemaVersionV2 = "v2"
+)
+
+// ErrorCode classifies why a message did not trigger a ProwJob.
+type ErrorCode string
+
+const (
+	// ErrorCodeMalformedMessage is for messages whose attributes or payload
+	// cannot be read.
+	ErrorCodeMalformedMessage ErrorCode = "malformed-message"
+	// ErrorCodeUnsupportedSchemaVersion is for messages with an unknown
+	// schema version.
+	ErrorCodeUnsupportedSchemaVersion ErrorCode = "unsupported-schema-version"
+	// ErrorCodeInvalidPayload is for payloads that do not match their schema.
+	ErrorCodeInvalidPayload ErrorCode = "invalid-payload"
+	// ErrorCodeUnsupportedEventType is for messages with an unknown event
+	// type.
+	ErrorCodeUnsupportedEventType ErrorCode = "unsupported-event-type"
+	// ErrorCodeFailedHandleProwJob is for valid messages whose ProwJob could
+	// not be created, e.g. because the job does not exist.
+	ErrorCodeFailedHandleProwJob ErrorCode = "failed-handle-prowjob"
+)
+
+// FieldError is a field of a payload that does not match its schema.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. refs.pulls.
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// MessageError is the reason why a message did not trigger a ProwJob.
+type MessageError struct {
+	Code ErrorCode
+	// Fields are the invalid fields of the payload, for ErrorCodeInvalidPayload.
+	Fields []FieldError
+	Err    error
+}
+
+func (e *MessageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MessageError) Unwrap() error {
+	return e.Err
+}
+
+func newMessageError(code ErrorCode, err error) *MessageError {
+	return &MessageError{Code: code, Err: err}
+}
+
+func invalidPayloadError(fields []FieldError) *MessageError {
+	var reasons []string
+	for _, field := range fields {
+		reasons = append(reasons, field.Field+": "+field.Reason)
+	}
+	return &MessageError{
+		Code:   ErrorCodeInvalidPayload,
+		Fields: fields,
+		Err:    fmt.Errorf("invalid payload: %s", strings.Join(reasons, "; ")),
+	}
+}
+
+// payloadSchema reads the payloads of a schema version.
+type payloadSchema interface {
+	// decode reads a payload into a ProwJobEvent.
+	decode(payload []byte) (*ProwJobEvent, error)
+	// validate checks that a ProwJobEvent of the given event type has the
+	// fields it requires.
+	validate(pe *ProwJobEvent, eventType string) []FieldError
+}
+
+var payloadSchemas = map[string]payloadSchema{
+	SchemaVersionV1: v1Schema{},
+	SchemaVersionV2: v2Schema{},
+}
+
+// schemaForMessage returns the version and the schema of the payload of a
+// message.
+func schemaForMessage(attrs map[string]string) (string, payloadSchema, error) {
+	version, ok := attrs[ProwSchemaVersion]
+	if !ok {
+		version = SchemaVersionV1
+	}
+	schema, ok := payloadSchemas[version]
+	if !ok {
+		return version, nil, newMessageError(ErrorCodeUnsupportedSchemaVersion, fmt.Errorf("unsupported schema version: %s", version))
+	}
+	return version, schema, nil
+}
+
+type v1Schema struct{}
+
+func (v1Schema) decode(payload []byte) (*ProwJobEvent, error) {
+	var pe ProwJobEvent
+	if err := pe.FromPayload(payload); err != nil {
+		return nil, newMessageError(ErrorCodeMalformedMessage, err)
+	}
+	return &pe, nil
+}
+
+func (v1Schema) validate(*ProwJobEvent, string) []FieldError {
+	return nil
+}
+
+type v2Schema struct{}
+
+func (v2Schema) decode(payload []byte) (*ProwJobEvent, error) {
+	var pe ProwJobEvent
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&pe)
+	if err == nil {
+		return &pe, nil
+	}
+	// The decoder does not have a typed error for unknown fields.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return nil, invalidPayloadError([]FieldError{{Field: strings.Trim(field, `"`), Reason: "is not a known field"}})
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return nil, invalidPayloadError([]FieldError{{Field: typeErr.Field, Reason: "must be of type " + typeErr.Type.String()}})
+	}
+	return nil, newMessageError(ErrorCodeMalformedMessage, err)
+}
+
+func (v2Schema) validate(pe *ProwJobEvent, eventType string) []FieldError {
+	var errs []FieldError
+	if strings.TrimSpace(pe.Name) == "" {
+		errs = append(errs, FieldError{Field: "name", Reason: "is required"})
+	}
+	var jobType string
+	switch eventType {
+	case PresubmitProwJobEvent:
+		jobType = "presubmits"
+	case PostsubmitProwJobEvent:
+		jobType = "postsubmits"
+	default:
+		return errs
+	}
+	if pe.Refs == nil {
+		return append(errs, FieldError{Field: "refs", Reason: "is required for " + jobType})
+	}
+	for _, field := range []struct{ name, value string }{
+		{"refs.org", pe.Refs.Org},
+		{"refs.repo", pe.Refs.Repo},
+		{"refs.base_ref", pe.Refs.BaseRef},
+	} {
+		if field.value == "" {
+			errs = append(errs, FieldError{Field: field.name, Reason: "is required for " + jobType})
+		}
+	}
+	switch eventType {
+	case PresubmitProwJobEvent:
+		if len(pe.Refs.Pulls) == 0 {
+			errs = append(errs, FieldError{Field: "refs.pulls", Reason: "is required for " + jobType})
+		}
+	case PostsubmitProwJobEvent:
+		if pe.Refs.BaseSHA == "" {
+			errs = append(errs, FieldError{Field: "refs.base_sha", Reason: "is required for " + jobType})
+		}
+	}
+	return errs
+}
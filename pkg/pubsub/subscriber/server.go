@@ -111,7 +111,7 @@ func (s *PullServer) handlePulls(ctx context.Context, projectSubscriptions confi
 	// Since config might change we need be able to cancel the current run
 	errGroup, derivedCtx := errgroup.WithContext(ctx)
 	for _, topics := range projectSubscriptions {
-		project, subscriptions, allowedClusters := topics.Project, topics.Topics, topics.AllowedClusters
+		project, subscriptions, allowedClusters, replyTopic := topics.Project, topics.Topics, topics.AllowedClusters, topics.ReplyTopic
 		client, err := s.Client.new(ctx, project)
 		if err != nil {
 			return errGroup, derivedCtx, err
@@ -128,6 +128,7 @@ func (s *PullServer) handlePulls(ctx context.Context, projectSubscriptions confi
 				err := sub.receive(derivedCtx, func(ctx context.Context, msg messageInterface) {
 					if err = s.Subscriber.handleMessage(msg, sub.string(), allowedClusters); err != nil {
 						s.Subscriber.Metrics.ACKMessageCounter.With(prometheus.Labels{subscriptionLabel: sub.string()}).Inc()
+						s.Subscriber.replyError(ctx, logger, msg, sub.string(), project, replyTopic, err)
 					} else {
 						s.Subscriber.Metrics.NACKMessageCounter.With(prometheus.Labels{subscriptionLabel: sub.string()}).Inc()
 					}
@@ -19,6 +19,7 @@ package subscriber
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -87,6 +88,9 @@ type Subscriber struct {
 	ProwJobClient      gangway.ProwJobClient
 	Reporter           reportClient
 	InRepoConfigGetter config.InRepoConfigGetter
+	// Replier publishes why messages did not trigger ProwJobs to the reply
+	// topics of their triggers.
+	Replier replyClient
 }
 
 type messageInterface interface {
@@ -174,8 +178,9 @@ func (s *Subscriber) handleMessage(msg messageInterface, subscription string, al
 			// This should be the only case prow operator should pay more
 			// attention too, because errors here are more likely caused by
 			// prow. (There are exceptions, which we can iterate slightly later)
-			errorTypeLabel: "failed-handle-prowjob",
+			errorTypeLabel: string(ErrorCodeFailedHandleProwJob),
 		}).Inc()
+		err = newMessageError(ErrorCodeFailedHandleProwJob, err)
 	}
 
 	// TODO(chaodaiG): debugging purpose, remove once done debugging.
@@ -193,31 +198,50 @@ func (s *Subscriber) msgToCjer(l *logrus.Entry, msg messageInterface, subscripti
 	l.WithField("payload", string(msgPayload)).Debug("Received message")
 	s.Metrics.MessageCounter.With(prometheus.Labels{subscriptionLabel: subscription}).Inc()
 
+	countError := func(err error) error {
+		code := ErrorCodeMalformedMessage
+		var msgErr *MessageError
+		if errors.As(err, &msgErr) {
+			code = msgErr.Code
+		}
+		l.WithError(err).Error("failed to read message")
+		s.Metrics.ErrorCounter.With(prometheus.Labels{
+			subscriptionLabel: subscription,
+			errorTypeLabel:    string(code),
+		}).Inc()
+		return err
+	}
+
+	version, schema, err := schemaForMessage(msgAttributes)
+	if err != nil {
+		return nil, countError(err)
+	}
+	l = l.WithField("schema-version", version)
+
 	// Note that a CreateJobExecutionRequest is a superset of ProwJobEvent.
 	// However we still use ProwJobEvent here because we want to use the
 	// existing jobHandlers to fetch the prowJobSpec (and the jobHandlers expect
 	// a ProwJobEvent as an argument).
-	var pe ProwJobEvent
-
+	//
 	// We use ProwJobEvent here mainly to ensrue that the incoming payload
-	// (JSON) is well-formed. We convert it into a CreateJobExecutionRequest
+	// (JSON) matches its schema. We convert it into a CreateJobExecutionRequest
 	// type here and never use it anywhere else.
 	l.WithField("raw-payload", string(msgPayload)).Debug("Raw payload passed in handleProwJob.")
-	if err := pe.FromPayload(msgPayload); err != nil {
-		return nil, err
+	pe, err := schema.decode(msgPayload)
+	if err != nil {
+		return nil, countError(err)
 	}
 
 	eType, err := extractFromAttribute(msgAttributes, ProwEventType)
 	if err != nil {
-		l.WithError(err).Error("failed to read message")
-		s.Metrics.ErrorCounter.With(prometheus.Labels{
-			subscriptionLabel: subscription,
-			errorTypeLabel:    "malformed-message",
-		}).Inc()
-		return nil, err
+		return nil, countError(newMessageError(ErrorCodeMalformedMessage, err))
+	}
+
+	if fieldErrs := schema.validate(pe, eType); len(fieldErrs) > 0 {
+		return nil, countError(invalidPayloadError(fieldErrs))
 	}
 
-	return s.peToCjer(l, &pe, eType, subscription)
+	return s.peToCjer(l, pe, eType, subscription)
 }
 
 func (s *Subscriber) peToCjer(l *logrus.Entry, pe *ProwJobEvent, eType, subscription string) (*gangway.CreateJobExecutionRequest, error) {
@@ -238,9 +262,9 @@ func (s *Subscriber) peToCjer(l *logrus.Entry, pe *ProwJobEvent, eType, subscrip
 		l.WithField("type", eType).Info("Unsupported event type")
 		s.Metrics.ErrorCounter.With(prometheus.Labels{
 			subscriptionLabel: subscription,
-			errorTypeLabel:    "unsupported-event-type",
+			errorTypeLabel:    string(ErrorCodeUnsupportedEventType),
 		}).Inc()
-		return nil, fmt.Errorf("unsupported event type: %s", eType)
+		return nil, newMessageError(ErrorCodeUnsupportedEventType, fmt.Errorf("unsupported event type: %s", eType))
 	}
 
 	pso := gangway.PodSpecOptions{}
@@ -18,6 +18,7 @@ package subscriber
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -882,3 +883,172 @@ func fakeProwYAMLGetter(
 	}
 	return &res, nil
 }
+
+func TestMsgToCjerSchemaVersions(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		attributes map[string]string
+		data       string
+		wantErr    string
+		wantCode   ErrorCode
+		wantFields []FieldError
+	}{
+		{
+			name:       "v1 is the default and ignores unknown fields",
+			attributes: map[string]string{ProwEventType: PeriodicProwJobEvent},
+			data:       `{"name":"test","unknown":"field"}`,
+		},
+		{
+			name:       "valid v2 periodic",
+			attributes: map[string]string{ProwEventType: PeriodicProwJobEvent, ProwSchemaVersion: SchemaVersionV2},
+			data:       `{"name":"test","envs":{"A":"B"}}`,
+		},
+		{
+			name:       "valid v2 postsubmit",
+			attributes: map[string]string{ProwEventType: PostsubmitProwJobEvent, ProwSchemaVersion: SchemaVersionV2},
+			data:       `{"name":"test","refs":{"org":"org","repo":"repo","base_ref":"main","base_sha":"abc"}}`,
+		},
+		{
+			name:       "unsupported schema version",
+			attributes: map[string]string{ProwEventType: PeriodicProwJobEvent, ProwSchemaVersion: "v0"},
+			data:       `{"name":"test"}`,
+			wantErr:    "unsupported schema version: v0",
+			wantCode:   ErrorCodeUnsupportedSchemaVersion,
+		},
+		{
+			name:       "malformed payload",
+			attributes: map[string]string{ProwEventType: PeriodicProwJobEvent, ProwSchemaVersion: SchemaVersionV2},
+			data:       `{"name":`,
+			wantErr:    "unexpected EOF",
+			wantCode:   ErrorCodeMalformedMessage,
+		},
+		{
+			name:       "v2 rejects unknown fields",
+			attributes: map[string]string{ProwEventType: PeriodicProwJobEvent, ProwSchemaVersion: SchemaVersionV2},
+			data:       `{"name":"test","env":{"A":"B"}}`,
+			wantErr:    "invalid payload: env: is not a known field",
+			wantCode:   ErrorCodeInvalidPayload,
+			wantFields: []FieldError{{Field: "env", Reason: "is not a known field"}},
+		},
+		{
+			name:       "v2 rejects fields of the wrong type",
+			attributes: map[string]string{ProwEventType: PeriodicProwJobEvent, ProwSchemaVersion: SchemaVersionV2},
+			data:       `{"name":42}`,
+			wantErr:    "invalid payload: name: must be of type string",
+			wantCode:   ErrorCodeInvalidPayload,
+			wantFields: []FieldError{{Field: "name", Reason: "must be of type string"}},
+		},
+		{
+			name:       "v2 presubmit without pulls",
+			attributes: map[string]string{ProwEventType: PresubmitProwJobEvent, ProwSchemaVersion: SchemaVersionV2},
+			data:       `{"name":" ","refs":{"org":"org","base_ref":"main"}}`,
+			wantErr:    "invalid payload: name: is required; refs.repo: is required for presubmits; refs.pulls: is required for presubmits",
+			wantCode:   ErrorCodeInvalidPayload,
+			wantFields: []FieldError{
+				{Field: "name", Reason: "is required"},
+				{Field: "refs.repo", Reason: "is required for presubmits"},
+				{Field: "refs.pulls", Reason: "is required for presubmits"},
+			},
+		},
+		{
+			name:       "v2 postsubmit without refs",
+			attributes: map[string]string{ProwEventType: PostsubmitProwJobEvent, ProwSchemaVersion: SchemaVersionV2},
+			data:       `{"name":"test"}`,
+			wantErr:    "invalid payload: refs: is required for postsubmits",
+			wantCode:   ErrorCodeInvalidPayload,
+			wantFields: []FieldError{{Field: "refs", Reason: "is required for postsubmits"}},
+		},
+		{
+			name:       "unsupported event type",
+			attributes: map[string]string{ProwEventType: "unsupported", ProwSchemaVersion: SchemaVersionV2},
+			data:       `{"name":"test"}`,
+			wantErr:    "unsupported event type: unsupported",
+			wantCode:   ErrorCodeUnsupportedEventType,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Subscriber{Metrics: NewMetrics()}
+			msg := &fakeMessage{ID: "id", Attributes: tc.attributes, Data: []byte(tc.data)}
+			_, err := s.msgToCjer(logrus.WithField("test", tc.name), msg, "subscription")
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			var msgErr *MessageError
+			if !errors.As(err, &msgErr) {
+				t.Fatalf("expected a MessageError, got %T", err)
+			}
+			if msgErr.Code != tc.wantCode {
+				t.Errorf("expected code %q, got %q", tc.wantCode, msgErr.Code)
+			}
+			if !reflect.DeepEqual(msgErr.Fields, tc.wantFields) {
+				t.Errorf("expected fields %v, got %v", tc.wantFields, msgErr.Fields)
+			}
+		})
+	}
+}
+
+type fakeReplier struct {
+	project, topic string
+	published      []*pubsub.Message
+}
+
+func (r *fakeReplier) Publish(_ context.Context, project, topic string, msg *pubsub.Message) error {
+	r.project, r.topic = project, topic
+	r.published = append(r.published, msg)
+	return nil
+}
+
+func TestReplyError(t *testing.T) {
+	msg := &fakeMessage{
+		ID:         "id",
+		Attributes: map[string]string{ProwEventType: PresubmitProwJobEvent, ProwSchemaVersion: SchemaVersionV2},
+	}
+	err := invalidPayloadError([]FieldError{{Field: "refs", Reason: "is required for presubmits"}})
+
+	t.Run("nothing is published without a reply topic", func(t *testing.T) {
+		replier := &fakeReplier{}
+		s := Subscriber{Replier: replier}
+		s.replyError(context.Background(), logrus.NewEntry(logrus.New()), msg, "subscription", "project", "", err)
+		if len(replier.published) != 0 {
+			t.Errorf("expected nothing to be published, got %d messages", len(replier.published))
+		}
+	})
+
+	t.Run("error response is published to the reply topic", func(t *testing.T) {
+		replier := &fakeReplier{}
+		s := Subscriber{Replier: replier}
+		s.replyError(context.Background(), logrus.NewEntry(logrus.New()), msg, "subscription", "project", "replies", err)
+		if len(replier.published) != 1 {
+			t.Fatalf("expected 1 message to be published, got %d", len(replier.published))
+		}
+		if replier.project != "project" || replier.topic != "replies" {
+			t.Errorf("expected to publish to project/replies, published to %s/%s", replier.project, replier.topic)
+		}
+		published := replier.published[0]
+		if published.Attributes[ProwEventType] != ErrorResponseEvent {
+			t.Errorf("expected event type %q, got %q", ErrorResponseEvent, published.Attributes[ProwEventType])
+		}
+		var got ErrorResponse
+		if err := json.Unmarshal(published.Data, &got); err != nil {
+			t.Fatalf("failed to unmarshal error response: %v", err)
+		}
+		want := ErrorResponse{
+			MessageID:     "id",
+			Subscription:  "subscription",
+			SchemaVersion: SchemaVersionV2,
+			EventType:     PresubmitProwJobEvent,
+			Code:          ErrorCodeInvalidPayload,
+			Message:       "invalid payload: refs: is required for presubmits",
+			Fields:        []FieldError{{Field: "refs", Reason: "is required for presubmits"}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected error response %+v, got %+v", want, got)
+		}
+	})
+}
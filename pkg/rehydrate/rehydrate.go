@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rehydrate recreates a runnable ProwJob from the artifacts that an
+// earlier run of the job stored, so that old failures can be re-run exactly.
+package rehydrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata"
+	"github.com/sirupsen/logrus"
+	coreapi "k8s.io/api/core/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/pod-utils/clone"
+)
+
+// podInfoFile is the JSON file that stores the pod of the run, as uploaded
+// by the Kubernetes reporter of crier.
+const podInfoFile = "podinfo.json"
+
+var shaRe = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// Options configure how the ProwJob is recreated.
+type Options struct {
+	// PinRefs checks out the commits the run tested instead of the current
+	// heads of the base refs.
+	PinRefs bool
+	// PinImages runs the images the run ran, by digest, instead of their
+	// current versions.
+	PinImages bool
+	// Report keeps reporting the result of the job, e.g. to the pull request
+	// it tests. Recreated jobs do not report by default.
+	Report bool
+}
+
+// Rehydrate recreates the ProwJob of the run whose artifacts are stored in
+// dir, e.g. gs://bucket/logs/job/123.
+func Rehydrate(ctx context.Context, opener io.Opener, dir string, o Options) (*prowapi.ProwJob, error) {
+	dir = strings.TrimSuffix(dir, "/")
+	log := logrus.WithField("dir", dir)
+
+	var original prowapi.ProwJob
+	if err := readJSON(ctx, log, opener, dir+"/"+prowapi.ProwJobFile, &original); err != nil {
+		return nil, err
+	}
+	spec := original.Spec.DeepCopy()
+	if !o.Report {
+		spec.Report = false
+	}
+
+	if o.PinRefs {
+		var records []clone.Record
+		if err := readJSON(ctx, log, opener, dir+"/"+prowapi.CloneRecordFile, &records); err != nil && !io.IsNotExist(err) {
+			return nil, err
+		}
+		var started metadata.Started
+		if err := readJSON(ctx, log, opener, dir+"/"+prowapi.StartedStatusFile, &started); err != nil && !io.IsNotExist(err) {
+			return nil, err
+		}
+		// The repo commit of started.json is the commit of the main refs,
+		// which are the first extra refs of jobs without refs.
+		repoCommit := started.RepoCommit
+		if spec.Refs != nil {
+			if err := pinRefs(spec.Refs, records, repoCommit); err != nil {
+				return nil, err
+			}
+			repoCommit = ""
+		}
+		for i := range spec.ExtraRefs {
+			if err := pinRefs(&spec.ExtraRefs[i], records, repoCommit); err != nil {
+				return nil, err
+			}
+			repoCommit = ""
+		}
+	}
+
+	if o.PinImages {
+		var report struct {
+			Pod *coreapi.Pod `json:"pod,omitempty"`
+		}
+		if err := readJSON(ctx, log, opener, dir+"/"+podInfoFile, &report); err != nil {
+			return nil, fmt.Errorf("cannot pin images: %w", err)
+		}
+		if report.Pod == nil {
+			return nil, fmt.Errorf("cannot pin images: %s has no pod", podInfoFile)
+		}
+		if err := pinImages(spec, report.Pod); err != nil {
+			return nil, err
+		}
+	}
+
+	labels := map[string]string{}
+	for key, value := range original.Labels {
+		switch key {
+		case kube.ProwJobIDLabel, kube.ProwBuildIDLabel, kube.PlankVersionLabel:
+			// These identify the original run.
+		default:
+			labels[key] = value
+		}
+	}
+	annotations := map[string]string{kube.RehydratedFromAnnotation: dir}
+	for key, value := range original.Annotations {
+		annotations[key] = value
+	}
+	pj := pjutil.NewProwJob(*spec, labels, annotations)
+	return &pj, nil
+}
+
+func readJSON(ctx context.Context, log *logrus.Entry, opener io.Opener, path string, v interface{}) error {
+	data, err := io.ReadContent(ctx, log, opener, path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// pinRefs sets the base SHA of the refs to the commit that was cloned. The
+// SHAs of pulls are always set. repoCommit is the commit the main refs of the
+// run resolved to, from started.json, if known.
+func pinRefs(refs *prowapi.Refs, records []clone.Record, repoCommit string) error {
+	if refs.BaseSHA != "" {
+		return nil
+	}
+	if len(refs.Pulls) == 0 {
+		// Without pulls, the final state of the clone is the base SHA.
+		for _, record := range records {
+			if record.Refs.Org == refs.Org && record.Refs.Repo == refs.Repo && record.Refs.BaseRef == refs.BaseRef && record.FinalSHA != "" {
+				refs.BaseSHA = record.FinalSHA
+				return nil
+			}
+		}
+		if shaRe.MatchString(repoCommit) {
+			refs.BaseSHA = repoCommit
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot pin %s to the commit of %s that was tested", refs.OrgRepoString(), refs.BaseRef)
+}
+
+// utilityContainers maps the names of the pod utility containers to their
+// images in the decoration config.
+var utilityContainers = map[string]func(*prowapi.UtilityImages) *string{
+	"clonerefs":        func(u *prowapi.UtilityImages) *string { return &u.CloneRefs },
+	"initupload":       func(u *prowapi.UtilityImages) *string { return &u.InitUpload },
+	"place-entrypoint": func(u *prowapi.UtilityImages) *string { return &u.Entrypoint },
+	"sidecar":          func(u *prowapi.UtilityImages) *string { return &u.Sidecar },
+}
+
+// pinImages replaces the images of the containers of the spec with the
+// digests that the pod ran.
+func pinImages(spec *prowapi.ProwJobSpec, pod *coreapi.Pod) error {
+	statuses := map[string]coreapi.ContainerStatus{}
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		statuses[status.Name] = status
+	}
+	pin := func(name string, image *string) error {
+		status, ok := statuses[name]
+		if !ok {
+			return fmt.Errorf("cannot pin the image of container %s: the pod has no status for it", name)
+		}
+		pinned := pinnedImage(*image, status.ImageID)
+		if pinned == "" {
+			return fmt.Errorf("cannot pin the image of container %s: image ID %q has no digest", name, status.ImageID)
+		}
+		*image = pinned
+		return nil
+	}
+
+	if spec.PodSpec != nil {
+		for i := range spec.PodSpec.Containers {
+			container := &spec.PodSpec.Containers[i]
+			name := container.Name
+			if name == "" {
+				name = kube.TestContainerName
+			}
+			if err := pin(name, &container.Image); err != nil {
+				return err
+			}
+		}
+	}
+	if spec.DecorationConfig != nil && spec.DecorationConfig.UtilityImages != nil {
+		for name, image := range utilityContainers {
+			if _, ok := statuses[name]; !ok {
+				// The container is not needed by every job, e.g. clonerefs.
+				continue
+			}
+			if err := pin(name, image(spec.DecorationConfig.UtilityImages)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pinnedImage returns the image by the digest in the image ID that the
+// kubelet reported for it, or nothing if the ID has no digest.
+func pinnedImage(image, imageID string) string {
+	// Image IDs that are not repo digests identify local images.
+	_, digest, ok := strings.Cut(imageID, "@")
+	if !ok || !strings.HasPrefix(digest, "sha256:") {
+		return ""
+	}
+	name, _, _ := strings.Cut(image, "@")
+	// Drop the tag, but not the port of the registry.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name + "@" + digest
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rehydrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/io/fakeopener"
+	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/pod-utils/clone"
+)
+
+const (
+	dir        = "gs://bucket/logs/ci-job/123"
+	baseSHA    = "0123456789abcdef0123456789abcdef01234567"
+	extraSHA   = "89abcdef0123456789abcdef0123456789abcdef"
+	testDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	sideDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func original() prowapi.ProwJob {
+	return prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "original",
+			Labels: map[string]string{
+				kube.ProwJobIDLabel:   "original",
+				kube.ProwBuildIDLabel: "123",
+				"custom":              "label",
+			},
+			Annotations: map[string]string{kube.ProwJobAnnotation: "ci-job"},
+		},
+		Spec: prowapi.ProwJobSpec{
+			Type:      prowapi.PeriodicJob,
+			Job:       "ci-job",
+			Report:    true,
+			ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "repo", BaseRef: "main"}, {Org: "org", Repo: "other", BaseRef: "main"}},
+			PodSpec: &coreapi.PodSpec{Containers: []coreapi.Container{
+				{Image: "registry.example.com:5000/test-image:latest"},
+			}},
+			DecorationConfig: &prowapi.DecorationConfig{UtilityImages: &prowapi.UtilityImages{
+				CloneRefs: "clonerefs:v1", InitUpload: "initupload:v1", Entrypoint: "entrypoint:v1", Sidecar: "sidecar:v1",
+			}},
+		},
+		Status: prowapi.ProwJobStatus{State: prowapi.FailureState, BuildID: "123"},
+	}
+}
+
+func artifacts(t *testing.T, files map[string]interface{}) *fakeopener.FakeOpener {
+	opener := &fakeopener.FakeOpener{Buffer: map[string]*bytes.Buffer{}}
+	for name, content := range files {
+		data, err := json.Marshal(content)
+		if err != nil {
+			t.Fatalf("failed to marshal %s: %v", name, err)
+		}
+		opener.Buffer[dir+"/"+name] = bytes.NewBuffer(data)
+	}
+	return opener
+}
+
+func TestRehydrate(t *testing.T) {
+	records := []clone.Record{
+		{Refs: prowapi.Refs{Org: "org", Repo: "other", BaseRef: "main"}, FinalSHA: extraSHA},
+	}
+	pod := map[string]interface{}{"pod": coreapi.Pod{Status: coreapi.PodStatus{
+		InitContainerStatuses: []coreapi.ContainerStatus{
+			{Name: "clonerefs", ImageID: "docker-pullable://clonerefs@sha256:3333333333333333333333333333333333333333333333333333333333333333"},
+			{Name: "initupload", ImageID: "initupload@sha256:4444444444444444444444444444444444444444444444444444444444444444"},
+			{Name: "place-entrypoint", ImageID: "entrypoint@sha256:5555555555555555555555555555555555555555555555555555555555555555"},
+		},
+		ContainerStatuses: []coreapi.ContainerStatus{
+			{Name: kube.TestContainerName, ImageID: "registry.example.com:5000/test-image@" + testDigest},
+			{Name: "sidecar", ImageID: "sidecar@" + sideDigest},
+		},
+	}}}
+
+	testCases := []struct {
+		name          string
+		files         map[string]interface{}
+		options       Options
+		expectedSpec  func(*prowapi.ProwJobSpec)
+		expectedError string
+	}{
+		{
+			name:  "the spec is reused without reporting",
+			files: map[string]interface{}{prowapi.ProwJobFile: original()},
+			expectedSpec: func(spec *prowapi.ProwJobSpec) {
+				spec.Report = false
+			},
+		},
+		{
+			name:         "reporting is kept",
+			files:        map[string]interface{}{prowapi.ProwJobFile: original()},
+			options:      Options{Report: true},
+			expectedSpec: func(spec *prowapi.ProwJobSpec) {},
+		},
+		{
+			name:          "prowjob.json is required",
+			expectedError: "failed to read gs://bucket/logs/ci-job/123/prowjob.json",
+		},
+		{
+			name: "refs are pinned to the tested commits",
+			files: map[string]interface{}{
+				prowapi.ProwJobFile:       original(),
+				prowapi.CloneRecordFile:   records,
+				prowapi.StartedStatusFile: map[string]string{"repo-commit": baseSHA},
+			},
+			options: Options{PinRefs: true, Report: true},
+			expectedSpec: func(spec *prowapi.ProwJobSpec) {
+				spec.ExtraRefs[0].BaseSHA = baseSHA
+				spec.ExtraRefs[1].BaseSHA = extraSHA
+			},
+		},
+		{
+			name: "refs that cannot be pinned are an error",
+			files: map[string]interface{}{
+				prowapi.ProwJobFile:       original(),
+				prowapi.StartedStatusFile: map[string]string{"repo-commit": baseSHA},
+			},
+			options:       Options{PinRefs: true},
+			expectedError: "cannot pin org/other to the commit of main that was tested",
+		},
+		{
+			name:    "images are pinned to the digests that ran",
+			files:   map[string]interface{}{prowapi.ProwJobFile: original(), podInfoFile: pod},
+			options: Options{PinImages: true, Report: true},
+			expectedSpec: func(spec *prowapi.ProwJobSpec) {
+				spec.PodSpec.Containers[0].Image = "registry.example.com:5000/test-image@" + testDigest
+				spec.DecorationConfig.UtilityImages = &prowapi.UtilityImages{
+					CloneRefs:  "clonerefs@sha256:3333333333333333333333333333333333333333333333333333333333333333",
+					InitUpload: "initupload@sha256:4444444444444444444444444444444444444444444444444444444444444444",
+					Entrypoint: "entrypoint@sha256:5555555555555555555555555555555555555555555555555555555555555555",
+					Sidecar:    "sidecar@" + sideDigest,
+				}
+			},
+		},
+		{
+			name:          "images cannot be pinned without the pod",
+			files:         map[string]interface{}{prowapi.ProwJobFile: original()},
+			options:       Options{PinImages: true},
+			expectedError: "cannot pin images",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pj, err := Rehydrate(context.Background(), artifacts(t, tc.files), dir+"/", tc.options)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := original().Spec
+			tc.expectedSpec(&expected)
+			if diff := cmp.Diff(expected, pj.Spec); diff != "" {
+				t.Errorf("unexpected spec (-want +got):\n%s", diff)
+			}
+			if pj.Name == "original" || pj.Status.State != prowapi.TriggeredState || pj.Status.BuildID != "" {
+				t.Errorf("expected a new triggered ProwJob, got %s in state %s with build ID %q", pj.Name, pj.Status.State, pj.Status.BuildID)
+			}
+			if pj.Labels["custom"] != "label" || pj.Labels[kube.ProwJobIDLabel] == "original" || pj.Labels[kube.ProwBuildIDLabel] != "" {
+				t.Errorf("expected the labels of the original run without its IDs, got %v", pj.Labels)
+			}
+			if pj.Annotations[kube.RehydratedFromAnnotation] != dir {
+				t.Errorf("expected the %s annotation to be %s, got %v", kube.RehydratedFromAnnotation, dir, pj.Annotations)
+			}
+		})
+	}
+}
+
+func TestPinnedImage(t *testing.T) {
+	for _, tc := range []struct {
+		image, imageID, expected string
+	}{
+		{image: "gcr.io/project/image:v1", imageID: "docker-pullable://gcr.io/project/image@" + testDigest, expected: "gcr.io/project/image@" + testDigest},
+		{image: "localhost:5000/image", imageID: "localhost:5000/image@" + testDigest, expected: "localhost:5000/image@" + testDigest},
+		{image: "image@" + sideDigest, imageID: "docker.io/library/image@" + testDigest, expected: "image@" + testDigest},
+		{image: "image:v1", imageID: testDigest},
+		{image: "image:v1"},
+	} {
+		if actual := pinnedImage(tc.image, tc.imageID); actual != tc.expected {
+			t.Errorf("pinnedImage(%q, %q): expected %q, got %q", tc.image, tc.imageID, tc.expected, actual)
+		}
+	}
+}
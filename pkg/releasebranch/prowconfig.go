@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasebranch
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UpdateProwConfig extends the Prow config to the release branch: the branch
+// protection of the source branch is copied to the release branch, and the
+// release branch is added to the Tide queries that include the source branch.
+// The config is edited in place to preserve its comments and key order.
+func UpdateProwConfig(raw []byte, o Options) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the Prow config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return raw, nil
+	}
+	root := doc.Content[0]
+	changed := false
+	if orgs := lookup(root, "branch-protection", "orgs"); orgs != nil {
+		changed = copyBranchProtection(orgs, o) || changed
+	}
+	if queries := lookup(root, "tide", "queries"); queries != nil && queries.Kind == yaml.SequenceNode {
+		changed = extendTideQueries(queries, o) || changed
+	}
+	if !changed {
+		return raw, nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal the Prow config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func copyBranchProtection(orgs *yaml.Node, o Options) bool {
+	changed := false
+	forEach(orgs, func(org string, orgNode *yaml.Node) {
+		forEach(lookup(orgNode, "repos"), func(repo string, repoNode *yaml.Node) {
+			if !o.Rules.includesRepo(org + "/" + repo) {
+				return
+			}
+			branches := lookup(repoNode, "branches")
+			source := lookup(branches, o.SourceBranch)
+			if source == nil || lookup(branches, o.Branch) != nil {
+				return
+			}
+			branches.Content = append(branches.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: o.Branch}, deepCopy(source))
+			changed = true
+		})
+	})
+	return changed
+}
+
+func extendTideQueries(queries *yaml.Node, o Options) bool {
+	changed := false
+	for _, query := range queries.Content {
+		included := lookup(query, "includedBranches")
+		if included == nil || included.Kind != yaml.SequenceNode || !contains(included, o.SourceBranch) || contains(included, o.Branch) {
+			continue
+		}
+		if len(o.Rules.Repos) > 0 {
+			repos := lookup(query, "repos")
+			matches := false
+			for _, repo := range o.Rules.Repos {
+				matches = matches || contains(repos, repo)
+			}
+			if !matches {
+				continue
+			}
+		}
+		included.Content = append(included.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: o.Branch})
+		changed = true
+	}
+	return changed
+}
+
+// lookup returns the node at the path of mapping keys, or nil.
+func lookup(node *yaml.Node, path ...string) *yaml.Node {
+	for _, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+func forEach(node *yaml.Node, fn func(key string, value *yaml.Node)) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		fn(node.Content[i].Value, node.Content[i+1])
+	}
+}
+
+func contains(seq *yaml.Node, value string) bool {
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return false
+	}
+	for _, item := range seq.Content {
+		if item.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func deepCopy(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = deepCopy(child)
+	}
+	return &copied
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasebranch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const prowConfig = `# The Prow config.
+branch-protection:
+  orgs:
+    org:
+      repos:
+        repo:
+          branches:
+            master:
+              # Require the tests.
+              protect: true
+              required_status_checks:
+                contexts:
+                - test
+        other:
+          branches:
+            master:
+              protect: true
+tide:
+  queries:
+  - repos:
+    - org/repo
+    includedBranches:
+    - master
+    labels:
+    - lgtm
+  - repos:
+    - org/other
+    includedBranches:
+    - master
+  - repos:
+    - org/repo
+    excludedBranches:
+    - gh-pages
+`
+
+func TestUpdateProwConfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rules    Rules
+		expected string
+	}{
+		{
+			name: "all repos",
+			expected: `# The Prow config.
+branch-protection:
+  orgs:
+    org:
+      repos:
+        repo:
+          branches:
+            master:
+              # Require the tests.
+              protect: true
+              required_status_checks:
+                contexts:
+                  - test
+            release-1.30:
+              # Require the tests.
+              protect: true
+              required_status_checks:
+                contexts:
+                  - test
+        other:
+          branches:
+            master:
+              protect: true
+            release-1.30:
+              protect: true
+tide:
+  queries:
+    - repos:
+        - org/repo
+      includedBranches:
+        - master
+        - release-1.30
+      labels:
+        - lgtm
+    - repos:
+        - org/other
+      includedBranches:
+        - master
+        - release-1.30
+    - repos:
+        - org/repo
+      excludedBranches:
+        - gh-pages
+`,
+		},
+		{
+			name:  "restricted to repos",
+			rules: Rules{Repos: []string{"org/other"}},
+			expected: `# The Prow config.
+branch-protection:
+  orgs:
+    org:
+      repos:
+        repo:
+          branches:
+            master:
+              # Require the tests.
+              protect: true
+              required_status_checks:
+                contexts:
+                  - test
+        other:
+          branches:
+            master:
+              protect: true
+            release-1.30:
+              protect: true
+tide:
+  queries:
+    - repos:
+        - org/repo
+      includedBranches:
+        - master
+      labels:
+        - lgtm
+    - repos:
+        - org/other
+      includedBranches:
+        - master
+        - release-1.30
+    - repos:
+        - org/repo
+      excludedBranches:
+        - gh-pages
+`,
+		},
+		{
+			name:     "nothing to update",
+			rules:    Rules{Repos: []string{"org/unknown"}},
+			expected: prowConfig,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := UpdateProwConfig([]byte(prowConfig), Options{Branch: "release-1.30", SourceBranch: "master", Rules: tc.rules})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, string(actual)); diff != "" {
+				t.Errorf("unexpected config (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
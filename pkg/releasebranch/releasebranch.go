@@ -0,0 +1,300 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package releasebranch prepares a config repository for a new release
+// branch: it forks the jobs running against the source branch into variants
+// for the new branch and extends branch protection and Tide queries to it.
+package releasebranch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// Rules configure how jobs are forked for a release branch.
+type Rules struct {
+	// Repos limits forking to these "org/repo" repositories. All repositories
+	// are forked if unset.
+	Repos []string `json:"repos,omitempty"`
+	// Annotation limits forking to the jobs that have this annotation set to
+	// "true". All jobs running against the source branch are forked if unset.
+	Annotation string `json:"annotation,omitempty"`
+	// Substitutions are applied to the generated job configs, e.g. to pin
+	// images to the released version.
+	Substitutions []Substitution `json:"substitutions,omitempty"`
+}
+
+// Substitution replaces all matches of a regular expression in the generated
+// job configs. "{branch}" and "{version}" in the replacement are replaced by
+// the new branch and its version, which is the branch without any leading
+// non-numeric prefix, e.g. "1.30" for "release-1.30".
+type Substitution struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+
+	re *regexp.Regexp
+}
+
+// LoadRules reads the rules from the YAML file at path.
+func LoadRules(path string) (Rules, error) {
+	var r Rules
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return r, fmt.Errorf("failed to read rules: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(raw, &r); err != nil {
+		return r, fmt.Errorf("failed to parse rules: %w", err)
+	}
+	return r, r.compile()
+}
+
+func (r *Rules) compile() error {
+	for i := range r.Substitutions {
+		re, err := regexp.Compile(r.Substitutions[i].Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid substitution pattern %q: %w", r.Substitutions[i].Pattern, err)
+		}
+		r.Substitutions[i].re = re
+	}
+	return nil
+}
+
+func (r Rules) includesRepo(orgRepo string) bool {
+	if len(r.Repos) == 0 {
+		return true
+	}
+	for _, repo := range r.Repos {
+		if repo == orgRepo {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rules) includesJob(base config.JobBase) bool {
+	return r.Annotation == "" || base.Annotations[r.Annotation] == "true"
+}
+
+// Options configure the release branch to create.
+type Options struct {
+	// Branch is the name of the new release branch.
+	Branch string
+	// SourceBranch is the branch the release branch is cut from.
+	SourceBranch string
+	// Rules configure how jobs are forked.
+	Rules Rules
+}
+
+// Validate checks that the release branch can be created.
+func (o *Options) Validate() error {
+	if o.Branch == "" {
+		return errors.New("the release branch must be set")
+	}
+	if o.SourceBranch == "" {
+		return errors.New("the source branch must be set")
+	}
+	if o.Branch == o.SourceBranch {
+		return fmt.Errorf("the release branch must differ from the source branch %q", o.SourceBranch)
+	}
+	return o.Rules.compile()
+}
+
+// Version returns the version of the release branch.
+func (o Options) Version() string {
+	return strings.TrimLeftFunc(o.Branch, func(r rune) bool { return r < '0' || r > '9' })
+}
+
+func (o Options) substitute(raw []byte) []byte {
+	for _, s := range o.Rules.Substitutions {
+		replacement := strings.NewReplacer("{branch}", o.Branch, "{version}", o.Version()).Replace(s.Replacement)
+		raw = s.re.ReplaceAll(raw, []byte(replacement))
+	}
+	return raw
+}
+
+// jobFile is the subset of a job config file the generated files contain.
+type jobFile struct {
+	Presubmits  map[string][]config.Presubmit  `json:"presubmits,omitempty"`
+	Postsubmits map[string][]config.Postsubmit `json:"postsubmits,omitempty"`
+	Periodics   []config.Periodic              `json:"periodics,omitempty"`
+}
+
+func (f jobFile) empty() bool {
+	return len(f.Presubmits) == 0 && len(f.Postsubmits) == 0 && len(f.Periodics) == 0
+}
+
+// ForkJobs returns the variants of the jobs in jc that run against the source
+// branch, restricted to the release branch. The variants are named after the
+// original job with the release branch appended.
+func ForkJobs(jc config.JobConfig, o Options) (config.JobConfig, error) {
+	var forked config.JobConfig
+	suffix := "-" + o.Branch
+	for orgRepo, jobs := range jc.PresubmitsStatic {
+		if !o.Rules.includesRepo(orgRepo) {
+			continue
+		}
+		if err := config.SetPresubmitRegexes(jobs); err != nil {
+			return forked, fmt.Errorf("invalid branches in presubmits for %s: %w", orgRepo, err)
+		}
+		for _, job := range jobs {
+			if !job.Brancher.ShouldRun(o.SourceBranch) || !o.Rules.includesJob(job.JobBase) {
+				continue
+			}
+			fork := job.DeepCopy()
+			fork.Name += suffix
+			if fork.Context != "" {
+				fork.Context += suffix
+			}
+			fork.Brancher = config.Brancher{Branches: []string{o.Branch}}
+			if forked.PresubmitsStatic == nil {
+				forked.PresubmitsStatic = map[string][]config.Presubmit{}
+			}
+			forked.PresubmitsStatic[orgRepo] = append(forked.PresubmitsStatic[orgRepo], *fork)
+		}
+	}
+	for orgRepo, jobs := range jc.PostsubmitsStatic {
+		if !o.Rules.includesRepo(orgRepo) {
+			continue
+		}
+		if err := config.SetPostsubmitRegexes(jobs); err != nil {
+			return forked, fmt.Errorf("invalid branches in postsubmits for %s: %w", orgRepo, err)
+		}
+		for _, job := range jobs {
+			if !job.Brancher.ShouldRun(o.SourceBranch) || !o.Rules.includesJob(job.JobBase) {
+				continue
+			}
+			fork := job.DeepCopy()
+			fork.Name += suffix
+			if fork.Context != "" {
+				fork.Context += suffix
+			}
+			fork.Brancher = config.Brancher{Branches: []string{o.Branch}}
+			if forked.PostsubmitsStatic == nil {
+				forked.PostsubmitsStatic = map[string][]config.Postsubmit{}
+			}
+			forked.PostsubmitsStatic[orgRepo] = append(forked.PostsubmitsStatic[orgRepo], *fork)
+		}
+	}
+	for _, job := range jc.Periodics {
+		if !o.Rules.includesJob(job.JobBase) {
+			continue
+		}
+		forks := false
+		extraRefs := make([]prowapi.Refs, len(job.ExtraRefs))
+		copy(extraRefs, job.ExtraRefs)
+		for i, ref := range extraRefs {
+			if ref.BaseRef == o.SourceBranch && o.Rules.includesRepo(ref.Org+"/"+ref.Repo) {
+				extraRefs[i].BaseRef = o.Branch
+				forks = true
+			}
+		}
+		if !forks {
+			continue
+		}
+		job.Name += suffix
+		job.ExtraRefs = extraRefs
+		forked.Periodics = append(forked.Periodics, job)
+	}
+	return forked, nil
+}
+
+// ForkJobConfigs forks the jobs in the job config files under jobConfigPath
+// and returns the generated job config files by path. The jobs forked from a
+// file are written next to it, with the release branch appended to its name.
+func ForkJobConfigs(jobConfigPath string, o Options) (map[string][]byte, error) {
+	generated := map[string][]byte{}
+	suffix := "-" + o.Branch + ".yaml"
+	err := filepath.WalkDir(jobConfigPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml" || strings.HasSuffix(path, suffix) {
+			return nil
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var jc config.JobConfig
+		if err := yaml.Unmarshal(raw, &jc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		forked, err := ForkJobs(jc, o)
+		if err != nil {
+			return fmt.Errorf("failed to fork the jobs in %s: %w", path, err)
+		}
+		f := jobFile{Presubmits: forked.PresubmitsStatic, Postsubmits: forked.PostsubmitsStatic, Periodics: forked.Periodics}
+		if f.empty() {
+			return nil
+		}
+		out, err := yaml.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the jobs forked from %s: %w", path, err)
+		}
+		generated[strings.TrimSuffix(path, filepath.Ext(path))+suffix] = o.substitute(out)
+		return nil
+	})
+	return generated, err
+}
+
+// Apply forks the jobs under jobConfigPath and extends the branch protection
+// and Tide queries in the Prow config at configPath to the release branch. It
+// returns the paths of the files it wrote.
+func Apply(configPath, jobConfigPath string, o Options) ([]string, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	generated, err := ForkJobConfigs(jobConfigPath, o)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for path, content := range generated {
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if configPath == "" {
+		return paths, nil
+	}
+	raw, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Prow config: %w", err)
+	}
+	updated, err := UpdateProwConfig(raw, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", configPath, err)
+	}
+	if string(updated) != string(raw) {
+		if err := os.WriteFile(configPath, updated, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", configPath, err)
+		}
+		paths = append(paths, configPath)
+	}
+	return paths, nil
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasebranch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func jobNames(jc config.JobConfig) []string {
+	var names []string
+	for repo, jobs := range jc.PresubmitsStatic {
+		for _, job := range jobs {
+			names = append(names, "presubmit "+repo+" "+job.Name+" "+job.Context+" "+job.Branches[0])
+		}
+	}
+	for repo, jobs := range jc.PostsubmitsStatic {
+		for _, job := range jobs {
+			names = append(names, "postsubmit "+repo+" "+job.Name+" "+job.Branches[0])
+		}
+	}
+	for _, job := range jc.Periodics {
+		names = append(names, "periodic "+job.Name+" "+job.ExtraRefs[0].BaseRef)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestForkJobs(t *testing.T) {
+	jc := config.JobConfig{
+		PresubmitsStatic: map[string][]config.Presubmit{
+			"org/repo": {
+				{JobBase: config.JobBase{Name: "all-branches"}},
+				{JobBase: config.JobBase{Name: "master-only"}, Brancher: config.Brancher{Branches: []string{"master"}}, Reporter: config.Reporter{Context: "master-only"}},
+				{JobBase: config.JobBase{Name: "skips-master"}, Brancher: config.Brancher{SkipBranches: []string{"master"}}},
+				{JobBase: config.JobBase{Name: "annotated", Annotations: map[string]string{"fork-per-release": "true"}}},
+			},
+			"org/other": {
+				{JobBase: config.JobBase{Name: "other"}},
+			},
+		},
+		PostsubmitsStatic: map[string][]config.Postsubmit{
+			"org/repo": {
+				{JobBase: config.JobBase{Name: "post"}, Brancher: config.Brancher{Branches: []string{"^master$"}}},
+				{JobBase: config.JobBase{Name: "post-release"}, Brancher: config.Brancher{Branches: []string{"release-.*"}}},
+			},
+		},
+		Periodics: []config.Periodic{
+			{JobBase: config.JobBase{Name: "ci", UtilityConfig: config.UtilityConfig{ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "repo", BaseRef: "master"}}}}},
+			{JobBase: config.JobBase{Name: "ci-other", UtilityConfig: config.UtilityConfig{ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "other", BaseRef: "master"}}}}},
+			{JobBase: config.JobBase{Name: "ci-stable", UtilityConfig: config.UtilityConfig{ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "repo", BaseRef: "stable"}}}}},
+			{JobBase: config.JobBase{Name: "no-refs"}},
+		},
+	}
+	testCases := []struct {
+		name     string
+		rules    Rules
+		expected []string
+	}{
+		{
+			name: "all jobs running against the source branch",
+			expected: []string{
+				"periodic ci-other-release-1.30 release-1.30",
+				"periodic ci-release-1.30 release-1.30",
+				"postsubmit org/repo post-release-1.30 release-1.30",
+				"presubmit org/other other-release-1.30  release-1.30",
+				"presubmit org/repo all-branches-release-1.30  release-1.30",
+				"presubmit org/repo annotated-release-1.30  release-1.30",
+				"presubmit org/repo master-only-release-1.30 master-only-release-1.30 release-1.30",
+			},
+		},
+		{
+			name:  "restricted to repos",
+			rules: Rules{Repos: []string{"org/other"}},
+			expected: []string{
+				"periodic ci-other-release-1.30 release-1.30",
+				"presubmit org/other other-release-1.30  release-1.30",
+			},
+		},
+		{
+			name:  "restricted to annotated jobs",
+			rules: Rules{Annotation: "fork-per-release"},
+			expected: []string{
+				"presubmit org/repo annotated-release-1.30  release-1.30",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forked, err := ForkJobs(jc, Options{Branch: "release-1.30", SourceBranch: "master", Rules: tc.rules})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, jobNames(forked)); diff != "" {
+				t.Errorf("unexpected forked jobs (-want +got):\n%s", diff)
+			}
+		})
+	}
+	if jc.Periodics[0].ExtraRefs[0].BaseRef != "master" {
+		t.Error("forking modified the refs of the original periodic")
+	}
+}
+
+func TestForkJobConfigs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"org/repo/repo.yaml": `presubmits:
+  org/repo:
+  - name: pull-repo-test
+    branches:
+    - master
+    spec:
+      containers:
+      - image: gcr.io/repo/builder:latest
+`,
+		"org/repo/repo-release-1.29.yaml": `presubmits:
+  org/repo:
+  - name: pull-repo-test-release-1.29
+    branches:
+    - release-1.29
+    spec:
+      containers:
+      - image: gcr.io/repo/builder:v1.29
+`,
+		"org/repo/README.md": "not a job config",
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o := Options{
+		Branch:       "release-1.30",
+		SourceBranch: "master",
+		Rules: Rules{Substitutions: []Substitution{
+			{Pattern: `(gcr.io/repo/[^:]+):latest`, Replacement: "${1}:v{version}"},
+		}},
+	}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	generated, err := ForkJobConfigs(dir, o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		filepath.Join(dir, "org/repo/repo-release-1.30.yaml"): `presubmits:
+  org/repo:
+  - always_run: false
+    branches:
+    - release-1.30
+    name: pull-repo-test-release-1.30
+    spec:
+      containers:
+      - image: gcr.io/repo/builder:v1.30
+        name: ""
+        resources: {}
+`,
+	}
+	actual := map[string]string{}
+	for path, content := range generated {
+		actual[path] = string(content)
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected generated files (-want +got):\n%s", diff)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		options     Options
+		expectedErr bool
+	}{
+		{name: "valid", options: Options{Branch: "release-1.30", SourceBranch: "main"}},
+		{name: "no branch", options: Options{SourceBranch: "main"}, expectedErr: true},
+		{name: "no source branch", options: Options{Branch: "release-1.30"}, expectedErr: true},
+		{name: "same branch", options: Options{Branch: "main", SourceBranch: "main"}, expectedErr: true},
+		{name: "invalid substitution", options: Options{Branch: "release-1.30", SourceBranch: "main", Rules: Rules{Substitutions: []Substitution{{Pattern: "("}}}}, expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.options.Validate(); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	for branch, expected := range map[string]string{"release-1.30": "1.30", "v2": "2", "stable": ""} {
+		if actual := (Options{Branch: branch}).Version(); actual != expected {
+			t.Errorf("%s: expected version %q, got %q", branch, expected, actual)
+		}
+	}
+}
@@ -214,7 +214,7 @@ func (c *Client) Used() bool {
 // NewClient is the constructor for Client
 func NewClient(
 	gc git.ClientFactory,
-	ghc github.Client,
+	ghc githubClient,
 	mdYAMLEnabled func(org, repo string) bool,
 	skipCollaborators func(org, repo string) bool,
 	ownersDirDenylist func() *prowConf.OwnersDirDenylist,
@@ -0,0 +1,337 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package retester retests the flaky failures of pull requests that are
+// otherwise ready to merge.
+package retester
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowv1 "sigs.k8s.io/prow/pkg/client/clientset/versioned/typed/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/pjutil"
+)
+
+// commentMarker identifies the comments of the retester, so that its retests
+// can be counted against the budget of a pull request.
+const commentMarker = "<!-- retester -->"
+
+// Reasons for not retesting a pull request, as reported by the skipped metric.
+const (
+	skipPending  = "pending"
+	skipFailing  = "failing"
+	skipBudget   = "budget"
+	skipNoFlakes = "no_flakes"
+)
+
+var retesterMetrics = struct {
+	retests *prometheus.CounterVec
+	skipped *prometheus.CounterVec
+}{
+	retests: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "retester_retests",
+		Help: "Number of retests of pull requests by the retester.",
+	}, []string{"org", "repo"}),
+	skipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "retester_skipped_pull_requests",
+		Help: "Number of failing pull requests the retester did not retest, by reason.",
+	}, []string{"org", "repo", "reason"}),
+}
+
+func init() {
+	prometheus.MustRegister(retesterMetrics.retests)
+	prometheus.MustRegister(retesterMetrics.skipped)
+}
+
+type githubClient interface {
+	QueryWithGitHubAppsSupport(ctx context.Context, q interface{}, vars map[string]interface{}, org string) error
+	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
+	CreateComment(org, repo string, number int, comment string) error
+	BotUserChecker() (func(candidate string) bool, error)
+}
+
+// Controller retests pull requests according to the retester config.
+type Controller struct {
+	ghc    githubClient
+	pjc    prowv1.ProwJobInterface
+	config config.Getter
+	logger *logrus.Entry
+	dryRun bool
+	now    func() time.Time
+}
+
+// NewController returns a new retester Controller.
+func NewController(ghc githubClient, pjc prowv1.ProwJobInterface, cfg config.Getter, dryRun bool) *Controller {
+	return &Controller{
+		ghc:    ghc,
+		pjc:    pjc,
+		config: cfg,
+		logger: logrus.WithField("controller", "retester"),
+		dryRun: dryRun,
+		now:    time.Now,
+	}
+}
+
+// pullRequest is a pull request as found by the search of the retester.
+// See: https://developer.github.com/v4/object/pullrequest/.
+type pullRequest struct {
+	Number     githubql.Int
+	HeadRefOID githubql.String `graphql:"headRefOid"`
+	BaseRef    struct {
+		Name githubql.String
+	}
+	Repository struct {
+		Name  githubql.String
+		Owner struct {
+			Login githubql.String
+		}
+	}
+}
+
+// See: https://developer.github.com/v4/query/.
+type searchQuery struct {
+	Search struct {
+		PageInfo struct {
+			HasNextPage githubql.Boolean
+			EndCursor   githubql.String
+		}
+		Nodes []struct {
+			PullRequest pullRequest `graphql:"... on PullRequest"`
+		}
+	} `graphql:"search(type: ISSUE, first: 100, after: $searchCursor, query: $query)"`
+}
+
+// Sync checks the pull requests of all configured repos once and retests
+// the ones whose only failures look like flakes.
+func (c *Controller) Sync() error {
+	cfg := c.config()
+	var errs []error
+	for _, orgRepo := range cfg.Retester.Repos {
+		if err := c.syncRepos(cfg, orgRepo); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sync %s: %w", orgRepo, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// syncRepos syncs the pull requests of an org or org/repo.
+func (c *Controller) syncRepos(cfg *config.Config, orgRepo string) error {
+	org, _, _ := strings.Cut(orgRepo, "/")
+	prs, err := c.search(query(cfg.Retester, orgRepo), org)
+	if err != nil {
+		return err
+	}
+	// A single list of the ProwJobs of each repo serves all of its pull requests.
+	runsByRepo := map[string]map[string][]prowapi.ProwJob{}
+	isBot, err := c.ghc.BotUserChecker()
+	if err != nil {
+		return fmt.Errorf("failed to get the bot user: %w", err)
+	}
+	var errs []error
+	for _, pr := range prs {
+		org, repo := string(pr.Repository.Owner.Login), string(pr.Repository.Name)
+		key := org + "/" + repo
+		if _, ok := runsByRepo[key]; !ok {
+			runs, err := c.presubmitRuns(cfg, org, repo)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			runsByRepo[key] = runs
+		}
+		if err := c.syncPR(cfg, pr, runsByRepo[key], isBot); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sync %s#%d: %w", key, int(pr.Number), err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// query returns the search query for the pull requests of an org or org/repo
+// that may be retested.
+func query(r config.Retester, orgRepo string) string {
+	parts := []string{"is:pr", "is:open", "archived:false", "status:failure"}
+	if strings.Contains(orgRepo, "/") {
+		parts = append(parts, fmt.Sprintf("repo:%q", orgRepo))
+	} else {
+		parts = append(parts, fmt.Sprintf("org:%q", orgRepo))
+	}
+	for _, label := range r.GetRequiredLabels() {
+		parts = append(parts, fmt.Sprintf("label:%q", label))
+	}
+	parts = append(parts, fmt.Sprintf("-label:%q", r.GetExclusionLabel()))
+	return strings.Join(parts, " ")
+}
+
+func (c *Controller) search(q, org string) ([]pullRequest, error) {
+	var prs []pullRequest
+	vars := map[string]interface{}{
+		"query":        githubql.String(q),
+		"searchCursor": (*githubql.String)(nil),
+	}
+	for {
+		sq := searchQuery{}
+		if err := c.ghc.QueryWithGitHubAppsSupport(context.Background(), &sq, vars, org); err != nil {
+			return nil, fmt.Errorf("failed to search pull requests: %w", err)
+		}
+		for _, n := range sq.Search.Nodes {
+			prs = append(prs, n.PullRequest)
+		}
+		if !sq.Search.PageInfo.HasNextPage {
+			break
+		}
+		vars["searchCursor"] = githubql.NewString(sq.Search.PageInfo.EndCursor)
+	}
+	c.logger.WithFields(logrus.Fields{"query": q, "pull_requests": len(prs)}).Debug("Searched pull requests.")
+	return prs, nil
+}
+
+// presubmitRuns returns the presubmit ProwJobs of a repo by job name.
+func (c *Controller) presubmitRuns(cfg *config.Config, org, repo string) (map[string][]prowapi.ProwJob, error) {
+	selector := labels.Set{
+		kube.ProwJobTypeLabel: string(prowapi.PresubmitJob),
+		kube.OrgLabel:         org,
+		kube.RepoLabel:        repo,
+	}.AsSelector().String()
+	pjs, err := c.pjc.List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prowjobs: %w", err)
+	}
+	runs := map[string][]prowapi.ProwJob{}
+	for _, pj := range pjs.Items {
+		runs[pj.Spec.Job] = append(runs[pj.Spec.Job], pj)
+	}
+	return runs, nil
+}
+
+// flakyJobs returns the required jobs that failed on the head of the pull
+// request like flakes, or the reason to skip the pull request if any
+// required job is still running or failed consistently.
+func flakyJobs(cfg *config.Config, pr pullRequest, runs map[string][]prowapi.ProwJob, now time.Time) ([]string, string) {
+	org, repo := string(pr.Repository.Owner.Login), string(pr.Repository.Name)
+	number, sha := int(pr.Number), string(pr.HeadRefOID)
+	since := now.Add(-cfg.Retester.GetFlakeWindow())
+	var flaky []string
+	for _, ps := range cfg.GetPresubmitsStatic(org + "/" + repo) {
+		if !ps.ContextRequired() || !ps.CouldRun(string(pr.BaseRef.Name)) {
+			continue
+		}
+		latest := latestRun(runs[ps.Name], number, sha)
+		if latest == nil {
+			// The job did not run on the head, a retest would not run it either.
+			continue
+		}
+		if !latest.Complete() {
+			return nil, skipPending
+		}
+		if latest.Status.State != prowapi.FailureState && latest.Status.State != prowapi.ErrorState {
+			continue
+		}
+		if pjutil.ClassifyRetest(runs[ps.Name], number, sha, true, since, cfg.Retester.GetMinClusterSize()) != pjutil.RetestRun {
+			return nil, skipFailing
+		}
+		flaky = append(flaky, ps.Name)
+	}
+	if len(flaky) == 0 {
+		return nil, skipNoFlakes
+	}
+	sort.Strings(flaky)
+	return flaky, ""
+}
+
+// latestRun returns the latest run of a job on the head of a pull request.
+func latestRun(runs []prowapi.ProwJob, number int, sha string) *prowapi.ProwJob {
+	var latest *prowapi.ProwJob
+	for i, pj := range runs {
+		if pj.Spec.Refs == nil || len(pj.Spec.Refs.Pulls) != 1 {
+			continue
+		}
+		if pull := pj.Spec.Refs.Pulls[0]; pull.Number != number || pull.SHA != sha {
+			continue
+		}
+		if latest == nil || pj.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = &runs[i]
+		}
+	}
+	return latest
+}
+
+func (c *Controller) syncPR(cfg *config.Config, pr pullRequest, runs map[string][]prowapi.ProwJob, isBot func(string) bool) error {
+	org, repo, number := string(pr.Repository.Owner.Login), string(pr.Repository.Name), int(pr.Number)
+	log := c.logger.WithFields(logrus.Fields{"org": org, "repo": repo, "pr": number, "sha": string(pr.HeadRefOID)})
+	now := c.now()
+	flaky, skip := flakyJobs(cfg, pr, runs, now)
+	if skip == "" {
+		comments, err := c.ghc.ListIssueComments(org, repo, number)
+		if err != nil {
+			return fmt.Errorf("failed to list comments: %w", err)
+		}
+		if retests(comments, isBot, now.Add(-24*time.Hour)) >= cfg.Retester.GetMaxRetestsPerDay() {
+			skip = skipBudget
+		}
+	}
+	if skip != "" {
+		log.WithField("reason", skip).Debug("Not retesting pull request.")
+		retesterMetrics.skipped.WithLabelValues(org, repo, skip).Inc()
+		return nil
+	}
+
+	log = log.WithField("jobs", flaky)
+	if c.dryRun {
+		log.Info("(dry-run) Retesting pull request.")
+		return nil
+	}
+	log.Info("Retesting pull request.")
+	if err := c.ghc.CreateComment(org, repo, number, comment(flaky, cfg.Retester.GetExclusionLabel())); err != nil {
+		return fmt.Errorf("failed to comment: %w", err)
+	}
+	retesterMetrics.retests.WithLabelValues(org, repo).Inc()
+	return nil
+}
+
+// retests counts the retests of the retester on a pull request since the
+// given time.
+func retests(comments []github.IssueComment, isBot func(string) bool, since time.Time) int {
+	var n int
+	for _, comment := range comments {
+		if isBot(comment.User.Login) && strings.Contains(comment.Body, commentMarker) && comment.CreatedAt.After(since) {
+			n++
+		}
+	}
+	return n
+}
+
+func comment(flaky []string, exclusionLabel string) string {
+	var jobs []string
+	for _, job := range flaky {
+		jobs = append(jobs, fmt.Sprintf("* `%s`", job))
+	}
+	return fmt.Sprintf("/retest-required\n\nThis pull request is ready to merge except for failures that look like flakes:\n%s\n\nAdd the `%s` label to stop automatic retests.\n%s", strings.Join(jobs, "\n"), exclusionLabel, commentMarker)
+}
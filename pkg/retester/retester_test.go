@@ -0,0 +1,292 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+)
+
+// fakeGitHub adds the search of pull requests, which the tests do not use,
+// to the fake client.
+type fakeGitHub struct {
+	*fakegithub.FakeClient
+}
+
+func (fakeGitHub) QueryWithGitHubAppsSupport(context.Context, interface{}, map[string]interface{}, string) error {
+	return nil
+}
+
+func runOf(job string, pull int, sha string, state prowapi.ProwJobState, created time.Time) prowapi.ProwJob {
+	pj := prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              job + sha + created.String(),
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: prowapi.ProwJobSpec{
+			Type: prowapi.PresubmitJob,
+			Job:  job,
+			Refs: &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: pull, SHA: sha}}},
+		},
+		Status: prowapi.ProwJobStatus{State: state},
+	}
+	if state != prowapi.PendingState && state != prowapi.TriggeredState {
+		completed := metav1.NewTime(created.Add(time.Minute))
+		pj.Status.CompletionTime = &completed
+	}
+	return pj
+}
+
+func testConfig(t *testing.T) *config.Config {
+	cfg := &config.Config{}
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}},
+		{JobBase: config.JobBase{Name: "e2e"}, Reporter: config.Reporter{Context: "e2e"}},
+		{JobBase: config.JobBase{Name: "optional"}, Reporter: config.Reporter{Context: "optional"}, Optional: true},
+		{
+			JobBase:  config.JobBase{Name: "release"},
+			Reporter: config.Reporter{Context: "release"},
+			Brancher: config.Brancher{Branches: []string{"release"}},
+		},
+	}
+	if err := cfg.SetPresubmits(map[string][]config.Presubmit{"org/repo": presubmits}); err != nil {
+		t.Fatalf("failed to set presubmits: %v", err)
+	}
+	return cfg
+}
+
+func testPR() pullRequest {
+	pr := pullRequest{Number: 1, HeadRefOID: "head"}
+	pr.BaseRef.Name = "main"
+	pr.Repository.Name = "repo"
+	pr.Repository.Owner.Login = "org"
+	return pr
+}
+
+func TestFlakyJobs(t *testing.T) {
+	now := time.Now()
+	passed := func(job string) prowapi.ProwJob {
+		return runOf(job, 1, "head", prowapi.SuccessState, now.Add(-time.Hour))
+	}
+	testCases := []struct {
+		name     string
+		runs     []prowapi.ProwJob
+		expected []string
+		skip     string
+	}{
+		{
+			name: "all required jobs passed",
+			runs: []prowapi.ProwJob{passed("unit"), passed("e2e")},
+			skip: skipNoFlakes,
+		},
+		{
+			name: "errored job is a flake",
+			runs: []prowapi.ProwJob{
+				passed("unit"),
+				runOf("e2e", 1, "head", prowapi.ErrorState, now),
+			},
+			expected: []string{"e2e"},
+		},
+		{
+			name: "failure after a pass on the same commit is a flake",
+			runs: []prowapi.ProwJob{
+				passed("unit"),
+				passed("e2e"),
+				runOf("e2e", 1, "head", prowapi.FailureState, now),
+			},
+			expected: []string{"e2e"},
+		},
+		{
+			name: "failure clustered with failures on other pull requests is a flake",
+			runs: []prowapi.ProwJob{
+				passed("e2e"),
+				runOf("unit", 1, "head", prowapi.FailureState, now),
+				runOf("unit", 2, "a", prowapi.FailureState, now.Add(-time.Hour)),
+				runOf("unit", 3, "b", prowapi.FailureState, now.Add(-time.Hour)),
+				runOf("unit", 4, "c", prowapi.FailureState, now.Add(-time.Hour)),
+			},
+			expected: []string{"unit"},
+		},
+		{
+			name: "failure only on this pull request is not retested",
+			runs: []prowapi.ProwJob{
+				runOf("unit", 1, "head", prowapi.ErrorState, now),
+				runOf("e2e", 1, "head", prowapi.FailureState, now),
+			},
+			skip: skipFailing,
+		},
+		{
+			name: "running required job skips the pull request",
+			runs: []prowapi.ProwJob{
+				runOf("unit", 1, "head", prowapi.ErrorState, now.Add(-time.Hour)),
+				runOf("e2e", 1, "head", prowapi.PendingState, now),
+			},
+			skip: skipPending,
+		},
+		{
+			name: "optional jobs and jobs of other branches are ignored",
+			runs: []prowapi.ProwJob{
+				passed("unit"),
+				passed("e2e"),
+				runOf("optional", 1, "head", prowapi.FailureState, now),
+				runOf("release", 1, "head", prowapi.FailureState, now),
+			},
+			skip: skipNoFlakes,
+		},
+		{
+			name: "runs on older commits are ignored",
+			runs: []prowapi.ProwJob{
+				passed("unit"),
+				passed("e2e"),
+				runOf("unit", 1, "old", prowapi.ErrorState, now),
+			},
+			skip: skipNoFlakes,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runs := map[string][]prowapi.ProwJob{}
+			for _, pj := range tc.runs {
+				runs[pj.Spec.Job] = append(runs[pj.Spec.Job], pj)
+			}
+			flaky, skip := flakyJobs(testConfig(t), testPR(), runs, now)
+			if diff := cmp.Diff(tc.expected, flaky); diff != "" {
+				t.Errorf("flaky jobs differ from expected (-want +got):\n%s", diff)
+			}
+			if skip != tc.skip {
+				t.Errorf("expected skip reason %q, got %q", tc.skip, skip)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		retester config.Retester
+		orgRepo  string
+		expected string
+	}{
+		{
+			name:     "defaults for an org",
+			orgRepo:  "org",
+			expected: `is:pr is:open archived:false status:failure org:"org" label:"lgtm" label:"approved" -label:"do-not-retest"`,
+		},
+		{
+			name:     "custom labels for a repo",
+			retester: config.Retester{RequiredLabels: []string{"ready"}, ExclusionLabel: "no-retest"},
+			orgRepo:  "org/repo",
+			expected: `is:pr is:open archived:false status:failure repo:"org/repo" label:"ready" -label:"no-retest"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := query(tc.retester, tc.orgRepo); actual != tc.expected {
+				t.Errorf("expected query %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSyncPR(t *testing.T) {
+	now := time.Now()
+	flakyRuns := map[string][]prowapi.ProwJob{
+		"unit": {runOf("unit", 1, "head", prowapi.ErrorState, now)},
+		"e2e":  {runOf("e2e", 1, "head", prowapi.SuccessState, now)},
+	}
+	retest := func(ago time.Duration) github.IssueComment {
+		return github.IssueComment{
+			Body:      "/retest-required\n" + commentMarker,
+			User:      github.User{Login: fakegithub.Bot},
+			CreatedAt: now.Add(-ago),
+		}
+	}
+	testCases := []struct {
+		name      string
+		runs      map[string][]prowapi.ProwJob
+		comments  []github.IssueComment
+		dryRun    bool
+		commented bool
+	}{
+		{
+			name:      "flaky pull request is retested",
+			runs:      flakyRuns,
+			commented: true,
+		},
+		{
+			name: "pull request without flakes is not retested",
+			runs: map[string][]prowapi.ProwJob{
+				"unit": {runOf("unit", 1, "head", prowapi.SuccessState, now)},
+				"e2e":  {runOf("e2e", 1, "head", prowapi.SuccessState, now)},
+			},
+		},
+		{
+			name:     "pull request over the budget is not retested",
+			runs:     flakyRuns,
+			comments: []github.IssueComment{retest(time.Hour), retest(2 * time.Hour), retest(3 * time.Hour)},
+		},
+		{
+			name: "retests older than a day and comments of others do not count against the budget",
+			runs: flakyRuns,
+			comments: []github.IssueComment{
+				retest(time.Hour),
+				retest(2 * time.Hour),
+				retest(25 * time.Hour),
+				{Body: "/retest-required\n" + commentMarker, User: github.User{Login: "someone"}, CreatedAt: now},
+			},
+			commented: true,
+		},
+		{
+			name:   "dry run does not comment",
+			runs:   flakyRuns,
+			dryRun: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ghc := fakegithub.NewFakeClient()
+			ghc.IssueComments[1] = tc.comments
+			c := &Controller{
+				ghc:    fakeGitHub{ghc},
+				logger: logrus.WithField("test", tc.name),
+				dryRun: tc.dryRun,
+				now:    func() time.Time { return now },
+			}
+			isBot, _ := ghc.BotUserChecker()
+			if err := c.syncPR(testConfig(t), testPR(), tc.runs, isBot); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var expected []string
+			if tc.commented {
+				expected = []string{"org/repo#1:" + comment([]string{"unit"}, "do-not-retest")}
+			}
+			if diff := cmp.Diff(expected, ghc.IssueCommentsAdded); diff != "" {
+				t.Errorf("comments differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package secretutil
 
 import (
 	"encoding/base64"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -121,6 +122,43 @@ func (c *ReloadingCensorer) Refresh(secrets ...string) {
 	c.Unlock()
 }
 
+// RegexCensorer censors the matches of regular expressions, for secrets that
+// are not known in advance, like tokens of a well-known format.
+type RegexCensorer struct {
+	patterns []*regexp.Regexp
+}
+
+var _ Censorer = &RegexCensorer{}
+
+// NewRegexCensorer returns a censorer for the matches of the patterns.
+func NewRegexCensorer(patterns ...*regexp.Regexp) *RegexCensorer {
+	return &RegexCensorer{patterns: patterns}
+}
+
+// Censor replaces every match of the patterns in the input with Xs. This is
+// thread-safe, will mutate the input and will never change its size.
+func (c *RegexCensorer) Censor(input *[]byte) {
+	for _, pattern := range c.patterns {
+		for _, match := range pattern.FindAllIndex(*input, -1) {
+			for i := match[0]; i < match[1]; i++ {
+				(*input)[i] = 'X'
+			}
+		}
+	}
+}
+
+// Censorers censors with all of its censorers in turn.
+type Censorers []Censorer
+
+var _ Censorer = Censorers{}
+
+// Censor censors the input with each of the censorers.
+func (c Censorers) Censor(input *[]byte) {
+	for _, censorer := range c {
+		censorer.Censor(input)
+	}
+}
+
 // AdaptCensorer returns a func that censors without touching the input, to
 // be used in places where the previous behavior is required while migrations
 // occur.
@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -77,14 +78,29 @@ func (o Options) censor() error {
 		return fmt.Errorf("could not load secrets: %w", err)
 	}
 	logrus.WithField("secrets", len(secrets)).Debug("Loaded secrets to censor.")
-	censorer := secretutil.NewCensorer()
-	censorer.RefreshBytes(secrets...)
+	secretCensorer := secretutil.NewCensorer()
+	secretCensorer.RefreshBytes(secrets...)
+	censorer := secretutil.Censorers{secretCensorer}
+	if len(o.CensoringOptions.Patterns) > 0 {
+		var patterns []*regexp.Regexp
+		for _, pattern := range o.CensoringOptions.Patterns {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				// Censor what we can rather than nothing at all.
+				errors <- fmt.Errorf("invalid censoring pattern %q: %w", pattern, err)
+				continue
+			}
+			patterns = append(patterns, compiled)
+		}
+		logrus.WithField("patterns", len(patterns)).Debug("Loaded patterns to censor.")
+		censorer = append(censorer, secretutil.NewRegexCensorer(patterns...))
+	}
 
 	bufferSize := defaultBufferSize
 	if o.CensoringOptions.CensoringBufferSize != nil {
 		bufferSize = *o.CensoringOptions.CensoringBufferSize
 	}
-	if largest := censorer.LargestSecret(); 2*largest > bufferSize {
+	if largest := secretCensorer.LargestSecret(); 2*largest > bufferSize {
 		bufferSize = 2 * largest
 	}
 	logrus.WithField("buffer_size", bufferSize).Debug("Determined censoring buffer size.")
@@ -114,7 +130,7 @@ func (o Options) censor() error {
 			if shouldNotErr != nil {
 				logrus.WithError(shouldNotErr).Warnf("filepath.Rel returned an error, but we assumed there must be a relative path between %s and %s", item, absPath)
 			}
-			should, err := shouldCensor(*o.CensoringOptions, relpath)
+			should, err := shouldCensor(*o.CensoringOptions, filepath.ToSlash(relpath))
 			if err != nil {
 				errors <- fmt.Errorf("could not determine if we should censor path: %w", err)
 				return nil
@@ -362,7 +378,7 @@ func archive(srcDir, destArchive string) error {
 		if shouldNotErr != nil {
 			logrus.WithError(shouldNotErr).Warnf("filepath.Rel returned an error, but we assumed there must be a relative path between %s and %s", srcDir, absPath)
 		}
-		header.Name = relpath
+		header.Name = filepath.ToSlash(relpath)
 		if err := tarWriter.WriteHeader(header); err != nil {
 			return fmt.Errorf("could not write tar header: %w", err)
 		}
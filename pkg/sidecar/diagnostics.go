@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultDiagnosticTimeout is how long a diagnostic command may run if
+	// it does not configure a timeout.
+	defaultDiagnosticTimeout = time.Minute
+	// maxDiagnosticOutput is the most output kept for a diagnostic command.
+	maxDiagnosticOutput = 10 * 1024 * 1024
+)
+
+// DiagnosticCommand is a command run to collect diagnostics when a test
+// process fails.
+type DiagnosticCommand struct {
+	// Name identifies the command, its output is written to <name>.txt.
+	Name string `json:"name"`
+	// Command is the command to run and its arguments.
+	Command []string `json:"command"`
+	// Timeout is how long the command may run, defaults to one minute.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// runFailureDiagnostics runs the failure diagnostics one after the other,
+// writing their output to the failure diagnostics directory. Failing
+// diagnostics are logged and recorded in their output, but never fail
+// the upload.
+func (o Options) runFailureDiagnostics(ctx context.Context) {
+	if len(o.FailureDiagnostics) == 0 {
+		return
+	}
+	if err := os.MkdirAll(o.FailureDiagnosticsDir, os.ModePerm); err != nil {
+		logrus.WithError(err).Error("Failed to create failure diagnostics directory.")
+		return
+	}
+	for _, diagnostic := range o.FailureDiagnostics {
+		log := logrus.WithField("diagnostic", diagnostic.Name)
+		log.Info("Running failure diagnostic.")
+		start := time.Now()
+		if err := runDiagnostic(ctx, diagnostic, filepath.Join(o.FailureDiagnosticsDir, diagnostic.Name+".txt")); err != nil {
+			log.WithError(err).Warn("Failure diagnostic failed.")
+			continue
+		}
+		log.WithField("duration", time.Since(start).String()).Info("Finished failure diagnostic.")
+	}
+}
+
+func runDiagnostic(ctx context.Context, diagnostic DiagnosticCommand, outputPath string) error {
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("could not create output file: %w", err)
+	}
+	defer output.Close()
+
+	timeout := diagnostic.Timeout
+	if timeout <= 0 {
+		timeout = defaultDiagnosticTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	writer := &limitedWriter{writer: output, remaining: maxDiagnosticOutput}
+	command := exec.CommandContext(ctx, diagnostic.Command[0], diagnostic.Command[1:]...)
+	command.Stdout = writer
+	command.Stderr = writer
+	// Do not wait for children holding on to the output after a timeout.
+	command.WaitDelay = 5 * time.Second
+	runErr := command.Run()
+	if writer.truncated {
+		fmt.Fprintf(output, "\n[output truncated after %d bytes]\n", maxDiagnosticOutput)
+	}
+	if runErr != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			runErr = fmt.Errorf("timed out after %s: %w", timeout, runErr)
+		}
+		fmt.Fprintf(output, "\n[%s failed: %v]\n", diagnostic.Name, runErr)
+		return runErr
+	}
+	return nil
+}
+
+// limitedWriter writes up to a limit and silently drops the rest, so that
+// a chatty command is not killed by a write error.
+type limitedWriter struct {
+	writer    io.Writer
+	remaining int64
+	truncated bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if int64(len(p)) > w.remaining {
+		p = p[:w.remaining]
+		w.truncated = true
+	}
+	if len(p) > 0 {
+		written, err := w.writer.Write(p)
+		w.remaining -= int64(written)
+		if err != nil {
+			return written, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunFailureDiagnostics(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "diagnostics")
+	o := Options{
+		FailureDiagnostics: []DiagnosticCommand{
+			{Name: "passing", Command: []string{"sh", "-c", "echo out; echo err >&2"}},
+			{Name: "failing", Command: []string{"sh", "-c", "echo partial; exit 3"}},
+			{Name: "slow", Command: []string{"sleep", "10"}, Timeout: 100 * time.Millisecond},
+			{Name: "missing", Command: []string{"./this-command-does-not-exist"}},
+		},
+		FailureDiagnosticsDir: dir,
+	}
+	o.runFailureDiagnostics(context.Background())
+
+	expected := map[string]string{
+		"passing.txt": "out\nerr\n",
+		"failing.txt": "partial\n\n[failing failed: exit status 3]\n",
+		"slow.txt":    "\n[slow failed: timed out after 100ms: signal: killed]\n",
+		"missing.txt": "\n[missing failed: fork/exec ./this-command-does-not-exist: no such file or directory]\n",
+	}
+	for name, content := range expected {
+		actual, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("could not read %s: %v", name, err)
+			continue
+		}
+		if string(actual) != content {
+			t.Errorf("%s: expected %q, got %q", name, content, actual)
+		}
+	}
+}
+
+func TestLimitedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &limitedWriter{writer: &buf, remaining: 5}
+	for _, chunk := range []string{"abc", "def", "ghi"} {
+		if n, err := w.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("expected to write %d bytes without error, wrote %d: %v", len(chunk), n, err)
+		}
+	}
+	if buf.String() != "abcde" {
+		t.Errorf("expected %q, got %q", "abcde", buf.String())
+	}
+	if !w.truncated {
+		t.Error("expected output to be truncated")
+	}
+}
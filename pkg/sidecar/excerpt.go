@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+const (
+	// defaultExcerptMaxLines is how many lines an excerpt holds if the
+	// options do not configure it.
+	defaultExcerptMaxLines = 2
+	// maxExcerptScan is how much of the end of each build log is scanned.
+	maxExcerptScan = 1024 * 1024
+	// maxExcerptLineLength is how much of each line is kept.
+	maxExcerptLineLength = 200
+)
+
+// defaultExcerptPatterns are used if the options do not configure patterns:
+// Go test failures, then errors, then any other failure.
+var defaultExcerptPatterns = []string{
+	`^\s*--- FAIL: `,
+	`(?i)\b(error|fatal|panic)\b`,
+	`(?i)\bfail(ed|ure)?\b`,
+}
+
+// terminationMessagePath is where the sidecar container writes its
+// termination message, which the kubelet adds to the container status.
+var terminationMessagePath = "/dev/termination-log"
+
+// FailureExcerptOptions configures the excerpt of the build logs that is
+// extracted when a test process fails.
+type FailureExcerptOptions struct {
+	// Patterns are regular expressions matching the relevant lines, by
+	// precedence.
+	Patterns []string `json:"patterns,omitempty"`
+	// MaxLines is the most lines the excerpt holds.
+	MaxLines int `json:"max_lines,omitempty"`
+}
+
+// TerminationMessage is the termination message of the sidecar container.
+type TerminationMessage struct {
+	// FailureExcerpt holds the most relevant lines of the build logs of a
+	// failed job.
+	FailureExcerpt string `json:"failure_excerpt,omitempty"`
+}
+
+// ParseTerminationMessage parses the termination message of the sidecar
+// container. As the kubelet falls back to the end of the logs of the
+// container if it exits with an error without writing a message, messages
+// that were not written by sidecar are ignored.
+func ParseTerminationMessage(message string) TerminationMessage {
+	var parsed TerminationMessage
+	if err := json.Unmarshal([]byte(message), &parsed); err != nil {
+		return TerminationMessage{}
+	}
+	return parsed
+}
+
+// writeFailureExcerpt extracts the excerpt of the build logs of the entries
+// and writes it to the termination message.
+func (o Options) writeFailureExcerpt(entries []wrapper.Options) {
+	if o.FailureExcerpt == nil {
+		return
+	}
+	excerpt, err := o.FailureExcerpt.extract(entries)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to extract failure excerpt.")
+		return
+	}
+	if excerpt == "" {
+		return
+	}
+	message, err := json.Marshal(TerminationMessage{FailureExcerpt: excerpt})
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to marshal termination message.")
+		return
+	}
+	if err := os.WriteFile(terminationMessagePath, message, 0644); err != nil {
+		logrus.WithError(err).Warn("Failed to write termination message.")
+	}
+}
+
+func (o FailureExcerptOptions) extract(entries []wrapper.Options) (string, error) {
+	patterns := o.Patterns
+	if len(patterns) == 0 {
+		patterns = defaultExcerptPatterns
+	}
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		res = append(res, re)
+	}
+	maxLines := o.MaxLines
+	if maxLines <= 0 {
+		maxLines = defaultExcerptMaxLines
+	}
+
+	// matches holds the matching lines of each pattern.
+	matches := make([][]string, len(res))
+	for _, entry := range entries {
+		if err := matchBuildLog(entry.ProcessLog, res, matches); err != nil {
+			return "", err
+		}
+	}
+	for _, lines := range matches {
+		if len(lines) == 0 {
+			continue
+		}
+		if len(lines) > maxLines {
+			lines = lines[len(lines)-maxLines:]
+		}
+		return strings.Join(lines, " / "), nil
+	}
+	return "", nil
+}
+
+// matchBuildLog adds the lines at the end of the build log that match each
+// of the patterns to the matches of the pattern.
+func matchBuildLog(path string, res []*regexp.Regexp, matches [][]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	partial := false
+	if info, err := f.Stat(); err == nil && info.Size() > maxExcerptScan {
+		if _, err := f.Seek(-maxExcerptScan, io.SeekEnd); err != nil {
+			return err
+		}
+		partial = true
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxExcerptScan)
+	for scanner.Scan() {
+		if partial {
+			// Skip the line the scan started in the middle of.
+			partial = false
+			continue
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		for i, re := range res {
+			if re.MatchString(line) {
+				if len(line) > maxExcerptLineLength {
+					line = line[:maxExcerptLineLength] + "..."
+				}
+				matches[i] = append(matches[i], line)
+				break
+			}
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+func TestWriteFailureExcerpt(t *testing.T) {
+	testCases := []struct {
+		name     string
+		excerpt  *FailureExcerptOptions
+		logs     []string
+		expected string
+	}{
+		{
+			name: "excerpts are disabled",
+			logs: []string{"--- FAIL: TestFoo (0.00s)\n"},
+		},
+		{
+			name:     "go test failures take precedence over errors",
+			excerpt:  &FailureExcerptOptions{},
+			logs:     []string{"error: flaky\n--- FAIL: TestFoo (0.00s)\n  --- FAIL: TestFoo/bar (0.00s)\n--- FAIL: TestBaz (1.00s)\nFAIL\n"},
+			expected: "--- FAIL: TestFoo/bar (0.00s) / --- FAIL: TestBaz (1.00s)",
+		},
+		{
+			name:     "lines of all the build logs are matched",
+			excerpt:  &FailureExcerptOptions{MaxLines: 3},
+			logs:     []string{"make: *** [all] Error 2\n", "ok\npanic: runtime error\n"},
+			expected: "make: *** [all] Error 2 / panic: runtime error",
+		},
+		{
+			name:     "configured patterns are used",
+			excerpt:  &FailureExcerptOptions{Patterns: []string{`^Step \d+ failed`}, MaxLines: 1},
+			logs:     []string{"Step 1 failed\nStep 2 failed\nerror: oops\n"},
+			expected: "Step 2 failed",
+		},
+		{
+			name:     "long lines are truncated",
+			excerpt:  &FailureExcerptOptions{},
+			logs:     []string{"error: " + strings.Repeat("x", 300) + "\n"},
+			expected: "error: " + strings.Repeat("x", maxExcerptLineLength-len("error: ")) + "...",
+		},
+		{
+			name:    "nothing matches",
+			excerpt: &FailureExcerptOptions{},
+			logs:    []string{"all good\n"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			terminationMessagePath = filepath.Join(dir, "termination-log")
+			var entries []wrapper.Options
+			for i, log := range tc.logs {
+				path := filepath.Join(dir, string(rune('a'+i))+".txt")
+				if err := os.WriteFile(path, []byte(log), 0644); err != nil {
+					t.Fatalf("could not write build log: %v", err)
+				}
+				entries = append(entries, wrapper.Options{ProcessLog: path})
+			}
+			// Entries whose process never started have no build log.
+			entries = append(entries, wrapper.Options{ProcessLog: filepath.Join(dir, "missing.txt")})
+
+			Options{FailureExcerpt: tc.excerpt}.writeFailureExcerpt(entries)
+
+			var actual string
+			if message, err := os.ReadFile(terminationMessagePath); err == nil {
+				actual = ParseTerminationMessage(string(message)).FailureExcerpt
+			} else if !os.IsNotExist(err) {
+				t.Fatalf("could not read termination message: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
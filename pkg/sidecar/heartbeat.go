@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+const (
+	// hungKey is the metadata key under which hung test processes are recorded.
+	hungKey = "hung-processes"
+	// heartbeatCheckInterval is how often heartbeats are checked.
+	heartbeatCheckInterval = 30 * time.Second
+)
+
+// hungProcesses records the test processes that were reported as hung.
+type hungProcesses struct {
+	lock sync.Mutex
+	hung map[string]string
+}
+
+// check reports the test processes that look hung at the given time and were
+// not reported yet. Processes that already exited are never hung.
+func (h *hungProcesses) check(entries []wrapper.Options, now time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for i, opt := range entries {
+		if opt.HeartbeatFile == "" {
+			continue
+		}
+		name := opt.ContainerName
+		if name == "" {
+			name = nameEntry(i, opt)
+		}
+		if _, reported := h.hung[name]; reported {
+			continue
+		}
+		if _, err := os.Stat(opt.MarkerFile); err == nil {
+			continue
+		}
+		heartbeat, err := wrapper.ReadHeartbeat(opt.HeartbeatFile)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logrus.WithError(err).WithField("container", name).Warn("Failed to read heartbeat.")
+			}
+			continue
+		}
+		if hung, reason := heartbeat.Hung(now); hung {
+			logrus.WithField("container", name).Errorf("Test process appears to be hung: %s.", reason)
+			if h.hung == nil {
+				h.hung = map[string]string{}
+			}
+			h.hung[name] = reason
+		}
+	}
+}
+
+// watch checks the heartbeats of the entries until the context is cancelled.
+func (h *hungProcesses) watch(ctx context.Context, entries []wrapper.Options, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.check(entries, now)
+		}
+	}
+}
+
+// addTo records the hung test processes in the metadata.
+func (h *hungProcesses) addTo(metadata map[string]interface{}) map[string]interface{} {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.hung) == 0 {
+		return metadata
+	}
+	hung := make(map[string]interface{}, len(h.hung))
+	for name, reason := range h.hung {
+		hung[name] = reason
+	}
+	metadata[hungKey] = hung
+	return metadata
+}
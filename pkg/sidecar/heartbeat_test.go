@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+func TestHungProcessesCheck(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		heartbeat *wrapper.Heartbeat
+		exited    bool
+		expected  map[string]interface{}
+	}{
+		{
+			name:     "no heartbeat yet",
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "recent output",
+			heartbeat: &wrapper.Heartbeat{
+				Timestamp:  now.Add(-time.Second),
+				LastOutput: now.Add(-time.Minute),
+				Interval:   time.Minute,
+			},
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "no output",
+			heartbeat: &wrapper.Heartbeat{
+				Timestamp:  now.Add(-time.Second),
+				LastOutput: now.Add(-11 * time.Minute),
+				Interval:   time.Minute,
+			},
+			expected: map[string]interface{}{hungKey: map[string]interface{}{"test": "no output for 11m0s"}},
+		},
+		{
+			name: "no heartbeat",
+			heartbeat: &wrapper.Heartbeat{
+				Timestamp:  now.Add(-20 * time.Minute),
+				LastOutput: now.Add(-20 * time.Minute),
+				Interval:   time.Minute,
+			},
+			expected: map[string]interface{}{hungKey: map[string]interface{}{"test": "no heartbeat for 20m0s"}},
+		},
+		{
+			name: "process already exited",
+			heartbeat: &wrapper.Heartbeat{
+				Timestamp:  now.Add(-20 * time.Minute),
+				LastOutput: now.Add(-20 * time.Minute),
+				Interval:   time.Minute,
+			},
+			exited:   true,
+			expected: map[string]interface{}{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			entry := wrapper.Options{
+				ContainerName: "test",
+				MarkerFile:    filepath.Join(tmpDir, "marker-file.txt"),
+				HeartbeatFile: filepath.Join(tmpDir, "heartbeat.json"),
+			}
+			if tc.heartbeat != nil {
+				if err := wrapper.WriteHeartbeat(entry.HeartbeatFile, *tc.heartbeat); err != nil {
+					t.Fatalf("failed to write heartbeat: %v", err)
+				}
+			}
+			if tc.exited {
+				if err := os.WriteFile(entry.MarkerFile, []byte("0"), 0600); err != nil {
+					t.Fatalf("failed to write marker: %v", err)
+				}
+			}
+			hung := &hungProcesses{}
+			hung.check([]wrapper.Options{entry, {ContainerName: "no-heartbeat"}}, now)
+			if diff := cmp.Diff(tc.expected, hung.addTo(map[string]interface{}{})); diff != "" {
+				t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
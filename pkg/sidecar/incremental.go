@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+// InProgressFile is uploaded with every incremental upload and holds the
+// Unix time of the upload. A run whose InProgressFile exists but whose
+// finished.json does not is still in progress.
+const InProgressFile = "in-progress"
+
+// startIncrementalUploads uploads the build logs and artifacts every
+// IncrementalUploadInterval until the returned function is called, which
+// waits for an ongoing upload to be cancelled.
+func (o Options) startIncrementalUploads(ctx context.Context, spec *downwardapi.JobSpec, entries []wrapper.Options) func() {
+	if o.IncrementalUploadInterval <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(o.IncrementalUploadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := o.uploadIncrementally(ctx, spec, entries, time.Now()); err != nil {
+					logrus.WithError(err).Warn("Failed to upload incrementally.")
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+}
+
+// uploadIncrementally uploads the build logs and artifacts as they are now,
+// along with the InProgressFile. They are uploaded from a censored snapshot,
+// as the test processes are still writing to them.
+func (o Options) uploadIncrementally(ctx context.Context, spec *downwardapi.JobSpec, entries []wrapper.Options, now time.Time) error {
+	dir, err := os.MkdirTemp("", "sidecar-incremental")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	snapshot, err := o.snapshot(dir, entries)
+	if err != nil {
+		return err
+	}
+	if snapshot.CensoringOptions != nil {
+		if err := snapshot.censor(); err != nil {
+			return fmt.Errorf("failed to censor snapshot: %w", err)
+		}
+	}
+
+	uploadTargets := map[string]gcs.UploadFunc{}
+	for logName, readerFunc := range logReadersFuncs(snapshot.Entries) {
+		uploadTargets[logName] = gcs.DataUpload(readerFunc)
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	uploadTargets[InProgressFile] = gcs.DataUpload(func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(timestamp)), nil
+	})
+	logrus.Info("Uploading incrementally.")
+	return snapshot.GcsOptions.Run(ctx, spec, uploadTargets)
+}
+
+// snapshot copies the process logs of the entries and the items to upload
+// to dir, and returns options that refer to the copies.
+func (o Options) snapshot(dir string, entries []wrapper.Options) (Options, error) {
+	gcsOptions := *o.GcsOptions
+	// Artifacts are only stored by their content once the test processes
+	// exited, as their content may still change.
+	gcsOptions.ContentAddressed = nil
+	gcsOptions.Items = nil
+	for i, item := range o.GcsOptions.Items {
+		destination := filepath.Join(dir, "items", strconv.Itoa(i), filepath.Base(item))
+		if err := copyTree(item, destination); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return Options{}, fmt.Errorf("failed to copy %s: %w", item, err)
+		}
+		gcsOptions.Items = append(gcsOptions.Items, destination)
+	}
+
+	snapshot := o
+	snapshot.GcsOptions = &gcsOptions
+	snapshot.DeprecatedWrapperOptions = nil
+	snapshot.Entries = nil
+	if err := os.MkdirAll(filepath.Join(dir, "logs"), 0755); err != nil {
+		return Options{}, err
+	}
+	for i, entry := range entries {
+		destination := filepath.Join(dir, "logs", strconv.Itoa(i))
+		if err := copyFile(entry.ProcessLog, destination); err != nil {
+			if !os.IsNotExist(err) {
+				return Options{}, fmt.Errorf("failed to copy %s: %w", entry.ProcessLog, err)
+			}
+			// The test process did not start logging yet.
+			if err := os.WriteFile(destination, nil, 0644); err != nil {
+				return Options{}, err
+			}
+		}
+		entry.ProcessLog = destination
+		snapshot.Entries = append(snapshot.Entries, entry)
+	}
+	return snapshot, nil
+}
+
+// copyTree copies the regular files under src, which may be a file itself,
+// to dst.
+func copyTree(src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Files may be removed by the test processes while they are copied.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(path, filepath.Join(dst, rel)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
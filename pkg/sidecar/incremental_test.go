@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/gcsupload"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+func TestUploadIncrementally(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	secrets := filepath.Join(dir, "secrets")
+	write(filepath.Join(secrets, "token"), "hunter2")
+	artifacts := filepath.Join(dir, "artifacts")
+	write(filepath.Join(artifacts, "nested", "result.txt"), "token is hunter2")
+	processLog := filepath.Join(dir, "process-log.txt")
+	write(processLog, "logging in with hunter2\n")
+	outputDir := filepath.Join(dir, "output")
+
+	options := Options{
+		GcsOptions: &gcsupload.Options{
+			GCSConfiguration: &prowapi.GCSConfiguration{
+				PathStrategy:   prowapi.PathStrategyExplicit,
+				Bucket:         "bucket",
+				LocalOutputDir: outputDir,
+			},
+			Items: []string{artifacts, filepath.Join(dir, "missing")},
+		},
+		CensoringOptions: &CensoringOptions{SecretDirectories: []string{secrets}},
+	}
+	entries := []wrapper.Options{{ProcessLog: processLog}}
+	spec := &downwardapi.JobSpec{Job: "job", Type: prowapi.PeriodicJob, BuildID: "build"}
+	now := time.Unix(1700000000, 0)
+	if err := options.uploadIncrementally(context.Background(), spec, entries, now); err != nil {
+		t.Fatalf("uploadIncrementally() failed: %v", err)
+	}
+
+	for path, expected := range map[string]string{
+		filepath.Join(outputDir, "build-log.txt"):                     "logging in with XXXXXXX\n",
+		filepath.Join(outputDir, "artifacts", "nested", "result.txt"): "token is XXXXXXX",
+		filepath.Join(outputDir, InProgressFile):                      "1700000000",
+		filepath.Join(artifacts, "nested", "result.txt"):              "token is hunter2",
+		processLog: "logging in with hunter2\n",
+	} {
+		actual, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("failed to read %s: %v", path, err)
+			continue
+		}
+		if string(actual) != expected {
+			t.Errorf("%s: got %q, want %q", path, actual, expected)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, prowapi.FinishedStatusFile)); !os.IsNotExist(err) {
+		t.Errorf("expected no %s to be uploaded, got err %v", prowapi.FinishedStatusFile, err)
+	}
+}
+
+func TestSnapshotWithoutProcessLog(t *testing.T) {
+	dir := t.TempDir()
+	options := Options{GcsOptions: &gcsupload.Options{GCSConfiguration: &prowapi.GCSConfiguration{}}}
+	snapshot, err := options.snapshot(dir, []wrapper.Options{{ProcessLog: filepath.Join(dir, "missing.txt"), ContainerName: "test"}})
+	if err != nil {
+		t.Fatalf("snapshot() failed: %v", err)
+	}
+	if len(snapshot.Entries) != 1 || snapshot.Entries[0].ContainerName != "test" {
+		t.Fatalf("unexpected entries %v", snapshot.Entries)
+	}
+	content, err := os.ReadFile(snapshot.Entries[0].ProcessLog)
+	if err != nil || len(content) != 0 {
+		t.Errorf("expected an empty process log, got %q, err %v", content, err)
+	}
+}
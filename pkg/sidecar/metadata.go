@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// linksKey is the metadata key under which Spyglass looks for links.
+	linksKey = "links"
+	// maxMetadataRequestSize bounds the size of a single request body.
+	maxMetadataRequestSize = 1 << 20
+)
+
+// MetadataServerAddress returns the localhost address sidecar listens on for
+// metadata when configured with the given port.
+func MetadataServerAddress(port int32) string {
+	return fmt.Sprintf("127.0.0.1:%d", port)
+}
+
+// metadataLink is a link posted to the metadata server.
+type metadataLink struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// metadataServer collects metadata and links posted by the test process
+// while it runs. It serves:
+//
+//	POST /metadata with a JSON object whose keys are merged into the metadata
+//	POST /links with a JSON object with a name, url and optional description
+//	GET /metadata returning the metadata collected so far
+type metadataServer struct {
+	lock     sync.Mutex
+	metadata map[string]interface{}
+}
+
+func newMetadataServer() *metadataServer {
+	return &metadataServer{metadata: map[string]interface{}{}}
+}
+
+func (s *metadataServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/metadata" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.snapshot()); err != nil {
+			logrus.WithError(err).Warn("Failed to write metadata response.")
+		}
+	case r.URL.Path == "/metadata" && r.Method == http.MethodPost:
+		var piece map[string]interface{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMetadataRequestSize)).Decode(&piece); err != nil {
+			http.Error(w, fmt.Sprintf("metadata must be a JSON object: %v", err), http.StatusBadRequest)
+			return
+		}
+		s.lock.Lock()
+		for k, v := range piece {
+			s.metadata[k] = v
+		}
+		s.lock.Unlock()
+		logrus.WithField("keys", len(piece)).Info("Received metadata.")
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/links" && r.Method == http.MethodPost:
+		var link metadataLink
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMetadataRequestSize)).Decode(&link); err != nil {
+			http.Error(w, fmt.Sprintf("link must be a JSON object: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := link.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.addLink(link)
+		logrus.WithField("link", link.Name).Info("Received link.")
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/metadata" || r.URL.Path == "/links":
+		http.Error(w, fmt.Sprintf("method %s is not allowed", r.Method), http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (l metadataLink) validate() error {
+	if l.Name == "" {
+		return fmt.Errorf("link name must not be empty")
+	}
+	u, err := url.Parse(l.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("link url %q is not an absolute http(s) URL", l.URL)
+	}
+	return nil
+}
+
+// addLink records the link in the format Spyglass reads extra links from.
+func (s *metadataServer) addLink(link metadataLink) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	links, ok := s.metadata[linksKey].(map[string]interface{})
+	if !ok {
+		links = map[string]interface{}{}
+		s.metadata[linksKey] = links
+	}
+	entry := map[string]interface{}{"url": link.URL}
+	if link.Description != "" {
+		entry["description"] = link.Description
+	}
+	links[link.Name] = entry
+}
+
+// snapshot returns a copy of the metadata collected so far.
+func (s *metadataServer) snapshot() map[string]interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	snapshot := make(map[string]interface{}, len(s.metadata))
+	for k, v := range s.metadata {
+		if links, ok := v.(map[string]interface{}); ok && k == linksKey {
+			copied := make(map[string]interface{}, len(links))
+			for name, link := range links {
+				copied[name] = link
+			}
+			v = copied
+		}
+		snapshot[k] = v
+	}
+	return snapshot
+}
+
+// start starts serving on the given address. The returned function stops
+// the server and returns the metadata collected.
+func (s *metadataServer) start(address string) (func() map[string]interface{}, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	server := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logrus.WithError(err).Error("Metadata server failed.")
+		}
+	}()
+	logrus.WithField("address", address).Info("Serving metadata API.")
+	return func() map[string]interface{} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logrus.WithError(err).Warn("Failed to shut down metadata server.")
+		}
+		return s.snapshot()
+	}, nil
+}
+
+// mergeServedMetadata merges the metadata posted to the metadata server into
+// the metadata read from the metadata files. Posted values win, except for
+// links which are merged by name.
+func mergeServedMetadata(metadata, served map[string]interface{}) map[string]interface{} {
+	for k, v := range served {
+		if k == linksKey {
+			fileLinks, ok := metadata[k].(map[string]interface{})
+			servedLinks, servedOK := v.(map[string]interface{})
+			if ok && servedOK {
+				for name, link := range servedLinks {
+					fileLinks[name] = link
+				}
+				continue
+			}
+		}
+		metadata[k] = v
+	}
+	return metadata
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMetadataServer(t *testing.T) {
+	type request struct {
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}
+	testCases := []struct {
+		name     string
+		requests []request
+		expected map[string]interface{}
+	}{
+		{
+			name:     "nothing posted",
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "metadata is merged",
+			requests: []request{
+				{method: http.MethodPost, path: "/metadata", body: `{"a": "1", "b": {"c": "2"}}`, expectedCode: http.StatusNoContent},
+				{method: http.MethodPost, path: "/metadata", body: `{"a": "3"}`, expectedCode: http.StatusNoContent},
+			},
+			expected: map[string]interface{}{"a": "3", "b": map[string]interface{}{"c": "2"}},
+		},
+		{
+			name: "links are added by name",
+			requests: []request{
+				{method: http.MethodPost, path: "/links", body: `{"name": "report", "url": "https://example.com/report", "description": "The report"}`, expectedCode: http.StatusNoContent},
+				{method: http.MethodPost, path: "/links", body: `{"name": "dashboard", "url": "http://example.com/dash"}`, expectedCode: http.StatusNoContent},
+			},
+			expected: map[string]interface{}{
+				"links": map[string]interface{}{
+					"report":    map[string]interface{}{"url": "https://example.com/report", "description": "The report"},
+					"dashboard": map[string]interface{}{"url": "http://example.com/dash"},
+				},
+			},
+		},
+		{
+			name: "invalid requests are rejected",
+			requests: []request{
+				{method: http.MethodPost, path: "/metadata", body: `["not", "an", "object"]`, expectedCode: http.StatusBadRequest},
+				{method: http.MethodPost, path: "/links", body: `{"name": "", "url": "https://example.com"}`, expectedCode: http.StatusBadRequest},
+				{method: http.MethodPost, path: "/links", body: `{"name": "xss", "url": "javascript:alert(1)"}`, expectedCode: http.StatusBadRequest},
+				{method: http.MethodPost, path: "/links", body: `{"name": "relative", "url": "/report"}`, expectedCode: http.StatusBadRequest},
+				{method: http.MethodDelete, path: "/metadata", expectedCode: http.StatusMethodNotAllowed},
+				{method: http.MethodGet, path: "/links", expectedCode: http.StatusMethodNotAllowed},
+				{method: http.MethodGet, path: "/other", expectedCode: http.StatusNotFound},
+			},
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "metadata is served",
+			requests: []request{
+				{method: http.MethodPost, path: "/metadata", body: `{"a": "1"}`, expectedCode: http.StatusNoContent},
+				{method: http.MethodGet, path: "/metadata", expectedCode: http.StatusOK},
+			},
+			expected: map[string]interface{}{"a": "1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newMetadataServer()
+			for _, req := range tc.requests {
+				rr := httptest.NewRecorder()
+				s.ServeHTTP(rr, httptest.NewRequest(req.method, req.path, strings.NewReader(req.body)))
+				if rr.Code != req.expectedCode {
+					t.Errorf("%s %s: expected status %d, got %d: %s", req.method, req.path, req.expectedCode, rr.Code, rr.Body.String())
+				}
+			}
+			if diff := cmp.Diff(tc.expected, s.snapshot()); diff != "" {
+				t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeServedMetadata(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata map[string]interface{}
+		served   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "nothing served",
+			metadata: map[string]interface{}{"a": "1"},
+			served:   map[string]interface{}{},
+			expected: map[string]interface{}{"a": "1"},
+		},
+		{
+			name:     "served values win",
+			metadata: map[string]interface{}{"a": "1", "b": "2"},
+			served:   map[string]interface{}{"a": "3"},
+			expected: map[string]interface{}{"a": "3", "b": "2"},
+		},
+		{
+			name: "links are merged by name",
+			metadata: map[string]interface{}{"links": map[string]interface{}{
+				"a": map[string]interface{}{"url": "http://a"},
+				"b": map[string]interface{}{"url": "http://b"},
+			}},
+			served: map[string]interface{}{"links": map[string]interface{}{
+				"b": map[string]interface{}{"url": "http://b2"},
+				"c": map[string]interface{}{"url": "http://c"},
+			}},
+			expected: map[string]interface{}{"links": map[string]interface{}{
+				"a": map[string]interface{}{"url": "http://a"},
+				"b": map[string]interface{}{"url": "http://b2"},
+				"c": map[string]interface{}{"url": "http://c"},
+			}},
+		},
+		{
+			name:     "malformed links from files are replaced",
+			metadata: map[string]interface{}{"links": "not a map"},
+			served: map[string]interface{}{"links": map[string]interface{}{
+				"c": map[string]interface{}{"url": "http://c"},
+			}},
+			expected: map[string]interface{}{"links": map[string]interface{}{
+				"c": map[string]interface{}{"url": "http://c"},
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, mergeServedMetadata(tc.metadata, tc.served)); diff != "" {
+				t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
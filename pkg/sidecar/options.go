@@ -21,6 +21,9 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"sigs.k8s.io/prow/pkg/gcsupload"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
@@ -80,6 +83,27 @@ type Options struct {
 	// load the data into time series and plot it for analysis.
 	WriteMemoryProfile bool `json:"write_memory_profile,omitempty"`
 
+	// MetadataServerAddress is the address on which sidecar accepts metadata
+	// and links posted by the test process while it runs. They are added to
+	// the metadata in finished.json. If unset, no server is started.
+	MetadataServerAddress string `json:"metadata_server_address,omitempty"`
+
+	// FailureDiagnostics are commands run when a test process fails or
+	// times out. Their output is uploaded with the artifacts.
+	FailureDiagnostics []DiagnosticCommand `json:"failure_diagnostics,omitempty"`
+	// FailureDiagnosticsDir is the directory the output of the failure
+	// diagnostics is written to. It should be inside one of the uploaded
+	// items so that the output is censored and uploaded with them.
+	FailureDiagnosticsDir string `json:"failure_diagnostics_dir,omitempty"`
+	// FailureExcerpt configures the excerpt of the build logs that is
+	// written to the termination message when a test process fails.
+	FailureExcerpt *FailureExcerptOptions `json:"failure_excerpt,omitempty"`
+
+	// IncrementalUploadInterval is how often the build logs and artifacts
+	// are uploaded while the test processes run, along with the InProgressFile
+	// marker. Disabled if zero.
+	IncrementalUploadInterval time.Duration `json:"incremental_upload_interval,omitempty"`
+
 	// CensoringOptions are options that pertain to censoring output before upload.
 	CensoringOptions *CensoringOptions `json:"censoring_options,omitempty"`
 
@@ -125,6 +149,10 @@ type CensoringOptions struct {
 	// IniFilenames are secret filenames that should be parsed as INI files in order to
 	// censor the values in the key-value mapping as well as the full content of the file.
 	IniFilenames []string `json:"ini_filenames,omitempty"`
+
+	// Patterns are regular expressions whose matches are censored in addition to the
+	// content of the secret files.
+	Patterns []string `json:"patterns,omitempty"`
 }
 
 func (o Options) entries() []wrapper.Options {
@@ -150,6 +178,32 @@ func (o *Options) Validate() error {
 		o.CensoringOptions = &opts
 	}
 
+	if o.CensoringOptions != nil {
+		for _, pattern := range o.CensoringOptions.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid censoring pattern %q: %w", pattern, err)
+			}
+		}
+	}
+
+	if len(o.FailureDiagnostics) > 0 && o.FailureDiagnosticsDir == "" {
+		return errors.New("failure diagnostics require a directory to write their output to")
+	}
+	for i, d := range o.FailureDiagnostics {
+		if d.Name == "" || len(d.Command) == 0 {
+			return fmt.Errorf("failure diagnostic %d needs a name and a command", i)
+		}
+		// The output of a diagnostic is written to a file named after it,
+		// which must stay in the diagnostics directory.
+		if strings.ContainsAny(d.Name, `/\`) || strings.Contains(d.Name, "..") {
+			return fmt.Errorf("failure diagnostic %d has an invalid name %q", i, d.Name)
+		}
+	}
+
+	if o.IncrementalUploadInterval < 0 {
+		return errors.New("incremental upload interval must not be negative")
+	}
+
 	ents := o.entries()
 	if len(ents) == 0 {
 		return errors.New("no wrapper.Option entries")
@@ -18,6 +18,7 @@ package sidecar
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
@@ -154,3 +155,48 @@ func TestOptions_LoadConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestOptions_ValidateFailureDiagnostics(t *testing.T) {
+	tests := []struct {
+		name    string
+		diag    string
+		wantErr bool
+	}{
+		{
+			name: "plain name",
+			diag: "pods",
+		},
+		{
+			name:    "name with a slash",
+			diag:    "../pods",
+			wantErr: true,
+		},
+		{
+			name:    "name with a backslash",
+			diag:    `dir\pods`,
+			wantErr: true,
+		},
+		{
+			name:    "parent directory",
+			diag:    "..",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Options{
+				GcsOptions: gcsupload.NewOptions(),
+				Entries: []wrapper.Options{{
+					ProcessLog: "/logs/process-log.txt",
+					MarkerFile: "/logs/marker-file.txt",
+				}},
+				FailureDiagnostics:    []DiagnosticCommand{{Name: tt.diag, Command: []string{"true"}}},
+				FailureDiagnosticsDir: "/logs/artifacts/diagnostics",
+			}
+			err := o.Validate()
+			if gotErr := err != nil && strings.Contains(err.Error(), "invalid name"); gotErr != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -37,9 +37,11 @@ import (
 
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/entrypoint"
+	"sigs.k8s.io/prow/pkg/gcsupload"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+	"sigs.k8s.io/prow/pkg/version"
 
 	testgridmetadata "github.com/GoogleCloudPlatform/testgrid/metadata"
 )
@@ -48,7 +50,9 @@ const LogFileName = "sidecar-logs.json"
 
 func LogSetup() (*os.File, error) {
 	logrusutil.ComponentInit()
-	logrus.SetLevel(logrus.DebugLevel)
+	if os.Getenv(logrusutil.LevelsEnv) == "" {
+		logrus.SetLevel(logrus.DebugLevel)
+	}
 	logFile, err := os.CreateTemp("", "sidecar-logs*.txt")
 	if err == nil {
 		logrus.SetOutput(io.MultiWriter(os.Stderr, logFile))
@@ -74,9 +78,16 @@ func wait(ctx context.Context, entries []wrapper.Options) (bool, bool, int) {
 	var aborted bool
 	var failures int
 
-	for _, res := range results {
-		passed = passed && res.Err == nil && res.ReturnCode == 0
+	for _, opt := range entries {
+		res := results[opt.MarkerFile]
 		aborted = aborted || res.ReturnCode == entrypoint.AbortedErrorCode
+		if opt.Optional && res.ReturnCode != entrypoint.AbortedErrorCode {
+			if res.Err != nil || res.ReturnCode != 0 {
+				logrus.WithError(res.Err).Infof("Ignoring the failure of optional container %s with code %d.", opt.ContainerName, res.ReturnCode)
+			}
+			continue
+		}
+		passed = passed && res.Err == nil && res.ReturnCode == 0
 		if res.ReturnCode != 0 && res.ReturnCode != entrypoint.PreviousErrorCode {
 			failures++
 		}
@@ -101,8 +112,25 @@ func (o Options) Run(ctx context.Context, logFile *os.File) (int, error) {
 	entries := o.entries()
 	var once sync.Once
 
+	served := newMetadataServer()
+	if o.MetadataServerAddress != "" {
+		// Failing to serve metadata must not fail the job, the test process
+		// will see its requests fail and can decide what to do about it.
+		stop, err := served.start(o.MetadataServerAddress)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to start metadata server.")
+		} else {
+			defer stop()
+		}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 
+	hung := &hungProcesses{}
+	go hung.watch(ctx, entries, heartbeatCheckInterval)
+
+	stopIncrementalUploads := o.startIncrementalUploads(ctx, spec, entries)
+
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 	go func() {
@@ -118,12 +146,13 @@ func (o Options) Run(ctx context.Context, logFile *os.File) (int, error) {
 				// second upload but we can tolerate this as we'd rather get SOME
 				// data into GCS than attempt to cancel these uploads and get none.
 				logrus.Errorf("Received an interrupt: %s, cancelling...", s)
+				stopIncrementalUploads()
 
 				// perform pre upload tasks
 				o.preUpload()
 
 				buildLogs := logReadersFuncs(entries)
-				metadata := combineMetadata(entries)
+				metadata := addVersionSkew(hung.addTo(mergeServedMetadata(combineMetadata(entries), served.snapshot())), entries, version.Version)
 
 				// perform best-effort upload
 				err := o.doUpload(ctx, spec, false, true, metadata, buildLogs, logFile, &once)
@@ -140,6 +169,7 @@ func (o Options) Run(ctx context.Context, logFile *os.File) (int, error) {
 	passed, aborted, failures := wait(ctx, entries)
 
 	cancel()
+	stopIncrementalUploads()
 	// If we are being asked to terminate by the kubelet but we have
 	// seen the test process exit cleanly, we need a chance to upload
 	// artifacts to GCS. The only valid way for this program to exit
@@ -147,10 +177,15 @@ func (o Options) Run(ctx context.Context, logFile *os.File) (int, error) {
 	// uploading, so we ignore the signals.
 	signal.Ignore(os.Interrupt, syscall.SIGTERM)
 
+	if !passed && !aborted {
+		o.runFailureDiagnostics(context.Background())
+		o.writeFailureExcerpt(entries)
+	}
+
 	o.preUpload()
 
 	buildLogs := logReadersFuncs(entries)
-	metadata := combineMetadata(entries)
+	metadata := addVersionSkew(hung.addTo(mergeServedMetadata(combineMetadata(entries), served.snapshot())), entries, version.Version)
 	return failures, o.doUpload(context.Background(), spec, passed, aborted, metadata, buildLogs, logFile, &once)
 }
 
@@ -276,30 +311,59 @@ func (o Options) doUpload(ctx context.Context, spec *downwardapi.JobSpec, passed
 	}
 
 	now := time.Now().Unix()
-	finished := testgridmetadata.Finished{
-		Timestamp: &now,
-		Passed:    &passed,
-		Result:    result,
-		Metadata:  metadata,
-		// TODO(fejta): JobVersion,
-	}
+	// finished.json is uploaded after the artifacts, to record those that
+	// failed to upload.
+	extraFor := func(report *gcsupload.UploadReport) map[string]gcs.UploadFunc {
+		finished := testgridmetadata.Finished{
+			Timestamp: &now,
+			Passed:    &passed,
+			Result:    result,
+			Metadata:  addUploadFailures(metadata, report),
+			// TODO(fejta): JobVersion,
+		}
 
-	// TODO(fejta): move to initupload and Started.Repos, RepoVersion
-	finished.DeprecatedRevision = downwardapi.GetRevisionFromSpec(spec)
+		// TODO(fejta): move to initupload and Started.Repos, RepoVersion
+		finished.DeprecatedRevision = downwardapi.GetRevisionFromSpec(spec)
 
-	finishedData, err := json.Marshal(&finished)
-	if err != nil {
-		logrus.WithError(err).Warn("Could not marshal finishing data")
-	} else {
-		newReader := func() (io.ReadCloser, error) {
-			return io.NopCloser(bytes.NewReader(finishedData)), nil
+		finishedData, err := json.Marshal(&finished)
+		if err != nil {
+			logrus.WithError(err).Warn("Could not marshal finishing data")
+		} else {
+			newReader := func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(finishedData)), nil
+			}
+			uploadTargets[prowv1.FinishedStatusFile] = gcs.DataUpload(newReader)
 		}
-		uploadTargets[prowv1.FinishedStatusFile] = gcs.DataUpload(newReader)
+		return uploadTargets
 	}
 
-	if err := o.GcsOptions.Run(ctx, spec, uploadTargets); err != nil {
+	if err := o.GcsOptions.RunWithReport(ctx, spec, extraFor); err != nil {
 		return fmt.Errorf("failed to upload to GCS: %w", err)
 	}
 
 	return nil
 }
+
+// uploadFailuresKey is the metadata key under which the artifacts that
+// failed to upload are recorded.
+const uploadFailuresKey = "upload-failures"
+
+// addUploadFailures records the artifacts that failed to upload in the
+// metadata, referring to the report of their errors.
+func addUploadFailures(metadata map[string]interface{}, report *gcsupload.UploadReport) map[string]interface{} {
+	if report == nil || len(report.Failed) == 0 {
+		return metadata
+	}
+	artifacts := make([]string, 0, len(report.Failed))
+	for _, failed := range report.Failed {
+		artifacts = append(artifacts, failed.Dest)
+	}
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata[uploadFailuresKey] = map[string]interface{}{
+		"artifacts": artifacts,
+		"report":    gcsupload.UploadReportName,
+	}
+	return metadata
+}
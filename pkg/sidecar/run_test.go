@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/prow/pkg/entrypoint"
 	"sigs.k8s.io/prow/pkg/gcsupload"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
 	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
 
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -59,6 +60,7 @@ func TestWait(t *testing.T) {
 		pass         bool
 		accessDenied bool
 		missing      bool
+		optional     sets.Set[int]
 		failures     int
 	}{
 		{
@@ -110,6 +112,25 @@ func TestWait(t *testing.T) {
 			abort:    true,
 			failures: 3,
 		},
+		{
+			name:     "pass when only optional items fail",
+			markers:  []string{pass, fail, "not-an-exit-code"},
+			optional: sets.New(1, 2),
+			pass:     true,
+		},
+		{
+			name:     "fail when a required item fails next to an optional one",
+			markers:  []string{fail, fail, skip},
+			optional: sets.New(1),
+			failures: 1,
+		},
+		{
+			name:     "abort when an optional item aborts",
+			markers:  []string{pass, aborted},
+			optional: sets.New(1),
+			abort:    true,
+			failures: 1,
+		},
 	}
 
 	for _, tc := range cases {
@@ -122,6 +143,7 @@ func TestWait(t *testing.T) {
 				p := path.Join(tmpDir, fmt.Sprintf("marker-%d.txt", i))
 				var opt wrapper.Options
 				opt.MarkerFile = p
+				opt.Optional = tc.optional.Has(i)
 				if err := os.WriteFile(p, []byte(m), 0600); err != nil {
 					t.Fatalf("could not create marker %d: %v", i, err)
 				}
@@ -574,3 +596,60 @@ func TestSideCarLogsUpload(t *testing.T) {
 	}
 
 }
+
+func TestAddUploadFailures(t *testing.T) {
+	cases := []struct {
+		name     string
+		metadata map[string]interface{}
+		report   *gcsupload.UploadReport
+		expected map[string]interface{}
+	}{
+		{
+			name:     "no report leaves metadata alone",
+			metadata: map[string]interface{}{"foo": "bar"},
+			expected: map[string]interface{}{"foo": "bar"},
+		},
+		{
+			name:     "report without failures leaves metadata alone",
+			metadata: map[string]interface{}{"foo": "bar"},
+			report:   &gcsupload.UploadReport{},
+			expected: map[string]interface{}{"foo": "bar"},
+		},
+		{
+			name:     "failures are recorded",
+			metadata: map[string]interface{}{"foo": "bar"},
+			report: &gcsupload.UploadReport{Failed: []gcs.FailedUpload{
+				{Dest: "artifacts/a.txt", Attempts: 4, Error: "boom"},
+				{Dest: "build-log.txt", Attempts: 4, Error: "boom"},
+			}},
+			expected: map[string]interface{}{
+				"foo": "bar",
+				uploadFailuresKey: map[string]interface{}{
+					"artifacts": []string{"artifacts/a.txt", "build-log.txt"},
+					"report":    gcsupload.UploadReportName,
+				},
+			},
+		},
+		{
+			name: "failures are recorded without prior metadata",
+			report: &gcsupload.UploadReport{Failed: []gcs.FailedUpload{
+				{Dest: "build-log.txt", Attempts: 1, Error: "boom"},
+			}},
+			expected: map[string]interface{}{
+				uploadFailuresKey: map[string]interface{}{
+					"artifacts": []string{"build-log.txt"},
+					"report":    gcsupload.UploadReportName,
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := addUploadFailures(tc.metadata, tc.report)
+			if !equality.Semantic.DeepEqual(tc.expected, actual) {
+				t.Errorf("metadata differs from expected:\n%s", diff.ObjectReflectDiff(tc.expected, actual))
+			}
+		})
+	}
+}
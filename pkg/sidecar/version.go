@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+// versionSkewKey is the metadata key under which the versions of entrypoints
+// that differ from the version of sidecar are recorded.
+const versionSkewKey = "pod-utils-version-skew"
+
+// addVersionSkew compares the versions the entrypoints recorded with the
+// version of sidecar and records the entrypoints of other versions in the
+// metadata, as the pod utilities of different versions may not agree on
+// the format of the files they share. Entrypoints that predate version
+// files are not compared.
+func addVersionSkew(metadata map[string]interface{}, entries []wrapper.Options, sidecarVersion string) map[string]interface{} {
+	skewed := map[string]interface{}{}
+	for i, opt := range entries {
+		name := opt.ContainerName
+		if name == "" {
+			name = nameEntry(i, opt)
+		}
+		entrypointVersion, err := wrapper.ReadVersion(opt.MarkerFile)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logrus.WithError(err).WithField("container", name).Warn("Failed to read the version of the entrypoint.")
+			}
+			continue
+		}
+		if entrypointVersion == sidecarVersion {
+			continue
+		}
+		logrus.WithFields(logrus.Fields{
+			"container":          name,
+			"entrypoint-version": entrypointVersion,
+			"sidecar-version":    sidecarVersion,
+		}).Warn("The entrypoint is not the same version as sidecar.")
+		skewed[name] = entrypointVersion
+	}
+	if len(skewed) == 0 {
+		return metadata
+	}
+	metadata[versionSkewKey] = map[string]interface{}{
+		"sidecar":     sidecarVersion,
+		"entrypoints": skewed,
+	}
+	return metadata
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/pod-utils/wrapper"
+)
+
+func TestAddVersionSkew(t *testing.T) {
+	dir := t.TempDir()
+	entry := func(name, version string) wrapper.Options {
+		opt := wrapper.Options{ContainerName: name, MarkerFile: filepath.Join(dir, name+"-marker.txt")}
+		if version != "" {
+			if err := wrapper.WriteVersion(opt.MarkerFile, version); err != nil {
+				t.Fatalf("could not write version: %v", err)
+			}
+		}
+		return opt
+	}
+	testCases := []struct {
+		name     string
+		entries  []wrapper.Options
+		expected map[string]interface{}
+	}{
+		{
+			name:     "same versions",
+			entries:  []wrapper.Options{entry("same", "v20240101-abcdef")},
+			expected: map[string]interface{}{"key": "value"},
+		},
+		{
+			name:     "entrypoint without version file",
+			entries:  []wrapper.Options{entry("old", "")},
+			expected: map[string]interface{}{"key": "value"},
+		},
+		{
+			name:    "skewed entrypoint",
+			entries: []wrapper.Options{entry("test", "v20230101-123456"), entry("lint", "v20240101-abcdef")},
+			expected: map[string]interface{}{
+				"key": "value",
+				versionSkewKey: map[string]interface{}{
+					"sidecar":     "v20240101-abcdef",
+					"entrypoints": map[string]interface{}{"test": "v20230101-123456"},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := addVersionSkew(map[string]interface{}{"key": "value"}, tc.entries, "v20240101-abcdef")
+			if diff := cmp.Diff(tc.expected, metadata); diff != "" {
+				t.Errorf("metadata differs from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
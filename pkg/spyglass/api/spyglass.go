@@ -17,7 +17,9 @@ limitations under the License.
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 
 	"sigs.k8s.io/prow/pkg/config"
 )
@@ -40,6 +42,127 @@ type Lens interface {
 	Callback(artifacts []Artifact, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string
 }
 
+// StreamingLens is implemented by lenses that can push updates to their
+// front-end, e.g. to show the output of a job while it is running.
+type StreamingLens interface {
+	Lens
+	// Stream receives a string sent by the lens's front-end code and calls send for every
+	// update to push to it, until ctx is done or there is nothing left to stream.
+	Stream(ctx context.Context, artifacts []Artifact, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass, send func(string) error) error
+}
+
+// ComparingLens is implemented by lenses that compare the artifacts of a build
+// with the artifacts of the same names of another build, the base.
+type ComparingLens interface {
+	Lens
+	// Compare is called instead of Body when the request names a base build,
+	// with the artifacts of the base build that exist.
+	Compare(artifacts, baseArtifacts []Artifact, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string
+}
+
+// HistoryLens is implemented by lenses that render the artifacts of a build
+// along with the artifacts of the same names of prior builds of its job.
+type HistoryLens interface {
+	Lens
+	// History is called instead of Body when the request names prior builds,
+	// with the artifacts of those builds, most recent first. Builds without
+	// any of the artifacts are left out.
+	History(artifacts []Artifact, history []BuildArtifacts, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string
+}
+
+// LensV2 defines the interface of lenses that can fail and that stop when the
+// request they serve is cancelled or times out. Its methods correspond to
+// those of Lens, returning an error along with their content.
+type LensV2 interface {
+	// Header returns a string that is injected into the rendered lens's <head>
+	Header(ctx context.Context, artifacts []Artifact, resourceRoot string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error)
+	// Body returns a string that is initially injected into the rendered lens's <body>.
+	// The lens's front-end code may call back to Body again, passing in some data string of its choosing.
+	Body(ctx context.Context, artifacts []Artifact, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error)
+	// Callback receives a string sent by the lens's front-end code and returns another string to be returned
+	// to that frontend code.
+	Callback(ctx context.Context, artifacts []Artifact, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error)
+}
+
+// StreamingLensV2 is implemented by version 2 lenses that can push updates to
+// their front-end, like StreamingLens.
+type StreamingLensV2 interface {
+	LensV2
+	// Stream receives a string sent by the lens's front-end code and calls send for every
+	// update to push to it, until ctx is done or there is nothing left to stream.
+	Stream(ctx context.Context, artifacts []Artifact, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass, send func(string) error) error
+}
+
+// LensAdapter serves a Lens as a LensV2. The Lens cannot be interrupted, so
+// it renders until it is done, but its content is discarded once the context
+// of the request is done.
+type LensAdapter struct {
+	lens Lens
+}
+
+var _ LensV2 = &LensAdapter{}
+
+// AdaptLens returns a LensV2 serving lens.
+func AdaptLens(lens Lens) *LensAdapter {
+	return &LensAdapter{lens: lens}
+}
+
+// UnadaptLens returns the Lens that lens serves if it is served by a
+// LensAdapter, so that the optional interfaces of the Lens, like
+// ComparingLens, can be used.
+func UnadaptLens(lens LensV2) (Lens, bool) {
+	adapter, ok := lens.(interface{ adapted() Lens })
+	if !ok {
+		return nil, false
+	}
+	return adapter.adapted(), true
+}
+
+func (a *LensAdapter) adapted() Lens {
+	return a.lens
+}
+
+// Header calls the Header method of the Lens.
+func (a *LensAdapter) Header(ctx context.Context, artifacts []Artifact, resourceRoot string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error) {
+	return RenderWithin(ctx, func() string {
+		return a.lens.Header(artifacts, resourceRoot, config, spyglassConfig)
+	})
+}
+
+// Body calls the Body method of the Lens.
+func (a *LensAdapter) Body(ctx context.Context, artifacts []Artifact, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error) {
+	return RenderWithin(ctx, func() string {
+		return a.lens.Body(artifacts, resourceRoot, data, config, spyglassConfig)
+	})
+}
+
+// Callback calls the Callback method of the Lens.
+func (a *LensAdapter) Callback(ctx context.Context, artifacts []Artifact, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error) {
+	return RenderWithin(ctx, func() string {
+		return a.lens.Callback(artifacts, resourceRoot, data, config, spyglassConfig)
+	})
+}
+
+// RenderWithin renders content with render, which cannot be interrupted,
+// unless ctx is done before or while it renders.
+func RenderWithin(ctx context.Context, render func() string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	content := render()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return content, nil
+}
+
+// BuildArtifacts are the artifacts of a build of a job.
+type BuildArtifacts struct {
+	// BuildID is the ID of the build.
+	BuildID   string
+	Artifacts []Artifact
+}
+
 // Artifact represents some output of a prow job
 type Artifact interface {
 	// ReadAt reads len(p) bytes of the artifact at offset off. (unsupported on some compressed files)
@@ -62,6 +185,26 @@ type Artifact interface {
 	UpdateMetadata(map[string]string) error
 }
 
+// LiveArtifact is implemented by artifacts that can still grow, like the log
+// of a pod that is running.
+type LiveArtifact interface {
+	Artifact
+	// Live returns whether the artifact can still grow.
+	Live() bool
+}
+
+// RangeReader is implemented by artifacts that can stream a range of their
+// content, e.g. the members of an archive, without reading all of it into
+// memory at once.
+type RangeReader interface {
+	Artifact
+	// NewRangeReader returns a reader of length bytes of the artifact from
+	// offset off, or of the rest of the artifact if length is negative.
+	// Reading ranges other than the whole artifact is unsupported on some
+	// compressed files.
+	NewRangeReader(off, length int64) (io.ReadCloser, error)
+}
+
 // RequestAction defines the action for a request
 type RequestAction string
 
@@ -72,6 +215,8 @@ const (
 	RequestActionRerender RequestAction = "rerender"
 	// RequestActionCallBack means that this is an arbitrary callback
 	RequestActionCallBack RequestAction = "callback"
+	// RequestActionStream means that this is a request for a stream of updates
+	RequestActionStream RequestAction = "stream"
 )
 
 type LensRequest struct {
@@ -88,7 +233,16 @@ type LensRequest struct {
 	Artifacts []string `json:"artifacts"`
 	// ArtifactSource is the source from which to fetch the artifacts
 	ArtifactSource string
+	// BaseArtifactSource is the source of the build to compare the artifacts
+	// with, for lenses that compare builds.
+	BaseArtifactSource string `json:"baseArtifactSource,omitempty"`
+	// HistoryArtifactSources are the sources of the prior builds of the job,
+	// most recent first, for lenses that render the history of a job.
+	HistoryArtifactSources []string `json:"historyArtifactSources,omitempty"`
 	// LensIndex is the index by which the lens config can be found
 	// TODO: Replace with something proper or avoid needing this
 	LensIndex int `json:"index"`
+	// Decrypt is set if the viewer is authorized to see the decrypted
+	// content of encrypted artifacts.
+	Decrypt bool `json:"decrypt,omitempty"`
 }
@@ -0,0 +1,295 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.2
+// source: lens.proto
+
+// Version 2 of the protocol between Spyglass and remote lenses. Lenses
+// implement the Lens service, in any language with gRPC support, or serve its
+// HTTP/JSON mapping.
+
+package v2
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Lens_Negotiate_FullMethodName = "/spyglass.lens.v2.Lens/Negotiate"
+	Lens_Render_FullMethodName    = "/spyglass.lens.v2.Lens/Render"
+	Lens_Callback_FullMethodName  = "/spyglass.lens.v2.Lens/Callback"
+	Lens_Stream_FullMethodName    = "/spyglass.lens.v2.Lens/Stream"
+)
+
+// LensClient is the client API for Lens service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LensClient interface {
+	// Negotiate returns what the lens supports. Spyglass calls it before any
+	// other method, and again from time to time.
+	Negotiate(ctx context.Context, in *NegotiateRequest, opts ...grpc.CallOption) (*LensInfo, error)
+	// Render renders the lens. The bodies of all responses are concatenated,
+	// which lets lenses send what they rendered so far before they are done.
+	Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (Lens_RenderClient, error)
+	// Callback answers a request of the front-end of the lens. Only called if
+	// the lens has the CAPABILITY_CALLBACK capability.
+	Callback(ctx context.Context, in *CallbackRequest, opts ...grpc.CallOption) (*CallbackResponse, error)
+	// Stream sends updates to the front-end of the lens, e.g. while a job is
+	// running. Only called if the lens has the CAPABILITY_STREAM capability.
+	Stream(ctx context.Context, in *CallbackRequest, opts ...grpc.CallOption) (Lens_StreamClient, error)
+}
+
+type lensClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLensClient(cc grpc.ClientConnInterface) LensClient {
+	return &lensClient{cc}
+}
+
+func (c *lensClient) Negotiate(ctx context.Context, in *NegotiateRequest, opts ...grpc.CallOption) (*LensInfo, error) {
+	out := new(LensInfo)
+	err := c.cc.Invoke(ctx, Lens_Negotiate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lensClient) Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (Lens_RenderClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Lens_ServiceDesc.Streams[0], Lens_Render_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &lensRenderClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Lens_RenderClient interface {
+	Recv() (*RenderResponse, error)
+	grpc.ClientStream
+}
+
+type lensRenderClient struct {
+	grpc.ClientStream
+}
+
+func (x *lensRenderClient) Recv() (*RenderResponse, error) {
+	m := new(RenderResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *lensClient) Callback(ctx context.Context, in *CallbackRequest, opts ...grpc.CallOption) (*CallbackResponse, error) {
+	out := new(CallbackResponse)
+	err := c.cc.Invoke(ctx, Lens_Callback_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lensClient) Stream(ctx context.Context, in *CallbackRequest, opts ...grpc.CallOption) (Lens_StreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Lens_ServiceDesc.Streams[1], Lens_Stream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &lensStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Lens_StreamClient interface {
+	Recv() (*CallbackResponse, error)
+	grpc.ClientStream
+}
+
+type lensStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *lensStreamClient) Recv() (*CallbackResponse, error) {
+	m := new(CallbackResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LensServer is the server API for Lens service.
+// All implementations must embed UnimplementedLensServer
+// for forward compatibility
+type LensServer interface {
+	// Negotiate returns what the lens supports. Spyglass calls it before any
+	// other method, and again from time to time.
+	Negotiate(context.Context, *NegotiateRequest) (*LensInfo, error)
+	// Render renders the lens. The bodies of all responses are concatenated,
+	// which lets lenses send what they rendered so far before they are done.
+	Render(*RenderRequest, Lens_RenderServer) error
+	// Callback answers a request of the front-end of the lens. Only called if
+	// the lens has the CAPABILITY_CALLBACK capability.
+	Callback(context.Context, *CallbackRequest) (*CallbackResponse, error)
+	// Stream sends updates to the front-end of the lens, e.g. while a job is
+	// running. Only called if the lens has the CAPABILITY_STREAM capability.
+	Stream(*CallbackRequest, Lens_StreamServer) error
+	mustEmbedUnimplementedLensServer()
+}
+
+// UnimplementedLensServer must be embedded to have forward compatible implementations.
+type UnimplementedLensServer struct {
+}
+
+func (UnimplementedLensServer) Negotiate(context.Context, *NegotiateRequest) (*LensInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Negotiate not implemented")
+}
+func (UnimplementedLensServer) Render(*RenderRequest, Lens_RenderServer) error {
+	return status.Errorf(codes.Unimplemented, "method Render not implemented")
+}
+func (UnimplementedLensServer) Callback(context.Context, *CallbackRequest) (*CallbackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Callback not implemented")
+}
+func (UnimplementedLensServer) Stream(*CallbackRequest, Lens_StreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedLensServer) mustEmbedUnimplementedLensServer() {}
+
+// UnsafeLensServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LensServer will
+// result in compilation errors.
+type UnsafeLensServer interface {
+	mustEmbedUnimplementedLensServer()
+}
+
+func RegisterLensServer(s grpc.ServiceRegistrar, srv LensServer) {
+	s.RegisterService(&Lens_ServiceDesc, srv)
+}
+
+func _Lens_Negotiate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NegotiateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LensServer).Negotiate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lens_Negotiate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LensServer).Negotiate(ctx, req.(*NegotiateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lens_Render_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RenderRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LensServer).Render(m, &lensRenderServer{stream})
+}
+
+type Lens_RenderServer interface {
+	Send(*RenderResponse) error
+	grpc.ServerStream
+}
+
+type lensRenderServer struct {
+	grpc.ServerStream
+}
+
+func (x *lensRenderServer) Send(m *RenderResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Lens_Callback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CallbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LensServer).Callback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lens_Callback_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LensServer).Callback(ctx, req.(*CallbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lens_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CallbackRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LensServer).Stream(m, &lensStreamServer{stream})
+}
+
+type Lens_StreamServer interface {
+	Send(*CallbackResponse) error
+	grpc.ServerStream
+}
+
+type lensStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *lensStreamServer) Send(m *CallbackResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Lens_ServiceDesc is the grpc.ServiceDesc for Lens service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Lens_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "spyglass.lens.v2.Lens",
+	HandlerType: (*LensServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Negotiate",
+			Handler:    _Lens_Negotiate_Handler,
+		},
+		{
+			MethodName: "Callback",
+			Handler:    _Lens_Callback_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Render",
+			Handler:       _Lens_Render_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Stream",
+			Handler:       _Lens_Stream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "lens.proto",
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/cache"
+	"sigs.k8s.io/prow/pkg/config"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+)
+
+// artifactCache caches the attributes and content of storage artifacts, so
+// that repeated views of the same job do not fetch them from storage again.
+// Attributes are cached by object for a TTL, and content by object
+// generation, as storage objects are immutable within a generation.
+type artifactCache struct {
+	cfg config.Getter
+	// The caches are created once caching is first enabled, as their size is
+	// only known then.
+	init    sync.Once
+	attrs   *cache.LRUCache
+	content *cache.LRUCache
+}
+
+// attrsKey and contentKey are the cache keys of attributes and content.
+// Decrypted and encrypted objects differ in attributes and content, so they
+// are cached separately.
+type attrsKey struct {
+	name    string
+	decrypt bool
+}
+
+type contentKey struct {
+	name       string
+	generation string
+	decrypt    bool
+}
+
+type cachedAttrs struct {
+	attrs   pkgio.Attributes
+	expires time.Time
+}
+
+func newArtifactCache(cfg config.Getter) *artifactCache {
+	return &artifactCache{cfg: cfg}
+}
+
+// wrap returns a handle that reads through the cache, or the handle itself if
+// caching is disabled.
+func (c *artifactCache) wrap(h *storageArtifactHandle) artifactHandle {
+	conf := c.cfg().Deck.Spyglass.ArtifactCache
+	if conf == nil {
+		return h
+	}
+	c.init.Do(func() {
+		size := conf.GetMaxEntries()
+		var err error
+		if c.attrs, err = cache.NewLRUCache(size, cache.Callbacks{}); err != nil {
+			logrus.WithError(err).Error("Failed to create artifact attributes cache, artifacts are not cached.")
+			return
+		}
+		if c.content, err = cache.NewLRUCache(size, cache.Callbacks{}); err != nil {
+			logrus.WithError(err).Error("Failed to create artifact content cache, artifacts are not cached.")
+			c.attrs = nil
+		}
+	})
+	if c.attrs == nil {
+		return h
+	}
+	return &cachingArtifactHandle{storageArtifactHandle: h, cache: c}
+}
+
+func (c *artifactCache) getAttrs(ctx context.Context, h *storageArtifactHandle) (pkgio.Attributes, error) {
+	key := attrsKey{name: h.Name, decrypt: pkgio.DecryptionAllowed(ctx)}
+	fetch := func() (interface{}, error) {
+		attrs, err := h.Attrs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return cachedAttrs{attrs: attrs, expires: time.Now().Add(c.cfg().Deck.Spyglass.ArtifactCache.GetTTL())}, nil
+	}
+	val, _, err := c.attrs.GetOrAdd(key, fetch)
+	if err != nil {
+		return pkgio.Attributes{}, err
+	}
+	if cached := val.(cachedAttrs); time.Now().Before(cached.expires) {
+		return cached.attrs, nil
+	}
+	c.forgetAttrs(key)
+	if val, _, err = c.attrs.GetOrAdd(key, fetch); err != nil {
+		return pkgio.Attributes{}, err
+	}
+	return val.(cachedAttrs).attrs, nil
+}
+
+func (c *artifactCache) forgetAttrs(key attrsKey) {
+	c.attrs.Lock()
+	c.attrs.Remove(key)
+	c.attrs.Unlock()
+}
+
+// getContent returns the content of the object, and false if it is not
+// cacheable, in which case it has to be read from storage.
+func (c *artifactCache) getContent(ctx context.Context, h *storageArtifactHandle) ([]byte, bool, error) {
+	attrs, err := c.getAttrs(ctx, h)
+	if err != nil {
+		return nil, false, err
+	}
+	// Gzipped objects are decompressed on read, so their size does not bound
+	// the size of their content.
+	if attrs.Generation == "" || attrs.ContentEncoding == "gzip" || attrs.Size > c.cfg().Deck.Spyglass.ArtifactCache.GetMaxArtifactSize() {
+		return nil, false, nil
+	}
+	key := contentKey{name: h.Name, generation: attrs.Generation, decrypt: pkgio.DecryptionAllowed(ctx)}
+	val, _, err := c.content.GetOrAdd(key, func() (interface{}, error) {
+		r, err := h.NewReader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return val.([]byte), true, nil
+}
+
+// cachingArtifactHandle reads the attributes and content of an artifact
+// through the artifact cache.
+type cachingArtifactHandle struct {
+	*storageArtifactHandle
+	cache *artifactCache
+}
+
+func (h *cachingArtifactHandle) Attrs(ctx context.Context) (pkgio.Attributes, error) {
+	return h.cache.getAttrs(ctx, h.storageArtifactHandle)
+}
+
+func (h *cachingArtifactHandle) NewReader(ctx context.Context) (io.ReadCloser, error) {
+	content, ok, err := h.cache.getContent(ctx, h.storageArtifactHandle)
+	if err != nil || !ok {
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", h.Name).Debug("Failed to read artifact through the cache.")
+		}
+		return h.storageArtifactHandle.NewReader(ctx)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (h *cachingArtifactHandle) NewRangeReader(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+	content, ok, err := h.cache.getContent(ctx, h.storageArtifactHandle)
+	if err != nil || !ok || offset > int64(len(content)) {
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", h.Name).Debug("Failed to read artifact through the cache.")
+		}
+		return h.storageArtifactHandle.NewRangeReader(ctx, offset, length)
+	}
+	content = content[offset:]
+	if length >= 0 && length < int64(len(content)) {
+		content = content[:length]
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (h *cachingArtifactHandle) UpdateAttrs(ctx context.Context, attrs pkgio.ObjectAttrsToUpdate) (*pkgio.Attributes, error) {
+	updated, err := h.storageArtifactHandle.UpdateAttrs(ctx, attrs)
+	for _, decrypt := range []bool{false, true} {
+		h.cache.forgetAttrs(attrsKey{name: h.Name, decrypt: decrypt})
+	}
+	return updated, err
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/prow/pkg/config"
+	pkgio "sigs.k8s.io/prow/pkg/io"
+)
+
+// countingOpener serves a single object and counts the reads of it.
+type countingOpener struct {
+	pkgio.Opener
+	content    []byte
+	generation string
+	attrReads  int
+	reads      int
+}
+
+func (o *countingOpener) Attributes(ctx context.Context, path string) (pkgio.Attributes, error) {
+	o.attrReads++
+	return pkgio.Attributes{Size: int64(len(o.content)), Generation: o.generation}, nil
+}
+
+func (o *countingOpener) Reader(ctx context.Context, path string) (pkgio.ReadCloser, error) {
+	o.reads++
+	return io.NopCloser(bytes.NewReader(o.content)), nil
+}
+
+func (o *countingOpener) RangeReader(ctx context.Context, path string, offset, length int64) (io.ReadCloser, error) {
+	o.reads++
+	end := int64(len(o.content))
+	if length >= 0 && offset+length < end {
+		end = offset + length
+	}
+	return io.NopCloser(bytes.NewReader(o.content[offset:end])), nil
+}
+
+func TestArtifactCache(t *testing.T) {
+	testCases := []struct {
+		name          string
+		cache         *config.ArtifactCache
+		generation    string
+		wantAttrReads int
+		// expiring is set if attributes expire while being read, so that
+		// wantAttrReads is only the least number of reads.
+		expiring  bool
+		wantReads int
+	}{
+		{
+			name:          "caching disabled",
+			generation:    "1",
+			wantAttrReads: 4,
+			wantReads:     4,
+		},
+		{
+			name:          "attributes and content are cached",
+			cache:         &config.ArtifactCache{},
+			generation:    "1",
+			wantAttrReads: 1,
+			wantReads:     1,
+		},
+		{
+			name:          "content without generation is not cached",
+			cache:         &config.ArtifactCache{},
+			wantAttrReads: 1,
+			wantReads:     4,
+		},
+		{
+			name:          "content over the size limit is not cached",
+			cache:         &config.ArtifactCache{MaxArtifactSize: 4},
+			generation:    "1",
+			wantAttrReads: 1,
+			wantReads:     4,
+		},
+		{
+			name:          "expired attributes are fetched again",
+			cache:         &config.ArtifactCache{TTL: &metav1.Duration{Duration: time.Nanosecond}},
+			generation:    "1",
+			wantAttrReads: 4,
+			expiring:      true,
+			wantReads:     1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opener := &countingOpener{content: []byte("hello world"), generation: tc.generation}
+			cfg := func() *config.Config {
+				return &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{ArtifactCache: tc.cache}}}}
+			}
+			c := newArtifactCache(cfg)
+			// Read the artifact twice, as repeated page loads would.
+			for i := 0; i < 2; i++ {
+				handle := c.wrap(&storageArtifactHandle{Opener: opener, Name: "gs://bucket/logs/job/1/build-log.txt"})
+				artifact := NewStorageArtifact(context.Background(), handle, "", "build-log.txt", 1024)
+				if size, err := artifact.Size(); err != nil || size != 11 {
+					t.Fatalf("Size() = %d, %v; want 11", size, err)
+				}
+				all, err := artifact.ReadAll()
+				if err != nil {
+					t.Fatalf("ReadAll() failed: %v", err)
+				}
+				if diff := cmp.Diff("hello world", string(all)); diff != "" {
+					t.Errorf("ReadAll() got unexpected diff (-want +got):\n%s", diff)
+				}
+				tail, err := artifact.ReadTail(5)
+				if err != nil {
+					t.Fatalf("ReadTail() failed: %v", err)
+				}
+				if diff := cmp.Diff("world", string(tail)); diff != "" {
+					t.Errorf("ReadTail() got unexpected diff (-want +got):\n%s", diff)
+				}
+			}
+			if opener.attrReads != tc.wantAttrReads && !(tc.expiring && opener.attrReads > tc.wantAttrReads) {
+				t.Errorf("read attributes %d times, want %d", opener.attrReads, tc.wantAttrReads)
+			}
+			if opener.reads != tc.wantReads {
+				t.Errorf("read content %d times, want %d", opener.reads, tc.wantReads)
+			}
+		})
+	}
+}
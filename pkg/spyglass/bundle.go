@@ -0,0 +1,303 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	k8sreporter "sigs.k8s.io/prow/pkg/crier/reporters/gcs/kubernetes"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/common"
+)
+
+const (
+	prowJobArtifact = "prowjob.json"
+	podInfoArtifact = "podinfo.json"
+)
+
+// debugBundleArtifacts are the names of the artifacts, wherever they are in
+// the run, that are added to debug bundles besides build logs and JUnit
+// results.
+var debugBundleArtifacts = map[string]bool{
+	prowJobArtifact:      true,
+	podInfoArtifact:      true,
+	"started.json":       true,
+	"finished.json":      true,
+	"metadata.json":      true,
+	"clone-records.json": true,
+}
+
+// inDebugBundle tells whether an artifact is added to debug bundles.
+func inDebugBundle(name string) bool {
+	base := path.Base(name)
+	if debugBundleArtifacts[base] || strings.HasSuffix(base, singleLogName) {
+		return true
+	}
+	return strings.HasPrefix(base, "junit") && strings.HasSuffix(base, ".xml")
+}
+
+// DebugBundle is the context needed to debug a run: its ProwJob, pod, events,
+// build logs, JUnit results and metadata, to be attached to bug reports.
+type DebugBundle struct {
+	JobName string
+	BuildID string
+	// Source is the src of the run.
+	Source string
+
+	prowJob   *prowapi.ProwJob
+	artifacts []api.Artifact
+	sizeLimit int64
+}
+
+// DebugBundle collects the artifacts of the debug bundle of the run specified
+// by src. At most Deck.Spyglass.SizeLimit bytes of them are added to the
+// bundle in total.
+func (s *Spyglass) DebugBundle(ctx context.Context, src string) (*DebugBundle, error) {
+	jobName, buildID, err := common.KeyToJob(src)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing src: %w", err)
+	}
+	names, err := s.ListArtifacts(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("error listing artifacts: %w", err)
+	}
+	var selected []string
+	for _, name := range names {
+		if inDebugBundle(name) {
+			selected = append(selected, name)
+		}
+	}
+	sizeLimit := s.config().Deck.Spyglass.SizeLimit
+	artifacts, err := s.FetchArtifacts(ctx, src, "", sizeLimit, selected)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching artifacts: %w", err)
+	}
+	bundle := &DebugBundle{
+		JobName:   jobName,
+		BuildID:   buildID,
+		Source:    src,
+		artifacts: artifacts,
+		sizeLimit: sizeLimit,
+	}
+	// The ProwJob of a run that did not finish yet is only stored once it
+	// does, the one of the cluster is also more current.
+	if job, err := s.jobAgent.GetProwJob(jobName, buildID); err == nil {
+		bundle.prowJob = &job
+	}
+	return bundle, nil
+}
+
+// Filename is the name of the archive of the bundle.
+func (b *DebugBundle) Filename() string {
+	return fmt.Sprintf("%s-%s-debug-bundle.tar.gz", b.JobName, b.BuildID)
+}
+
+// debugBundleIndex is rendered as the index.html of the bundle.
+type debugBundleIndex struct {
+	JobName string
+	BuildID string
+	Source  string
+	ProwJob *prowapi.ProwJob
+	Created time.Time
+	Files   []debugBundleFile
+	Skipped []string
+}
+
+type debugBundleFile struct {
+	Name        string
+	Description string
+	// Truncated is true if only part of the artifact fit in the bundle.
+	Truncated bool
+}
+
+var debugBundleIndexTemplate = template.Must(template.New("index.html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.JobName}} #{{.BuildID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+td, th { padding: 0.2em 1em 0.2em 0; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.JobName}} #{{.BuildID}}</h1>
+<table>
+<tr><th>Source</th><td>{{.Source}}</td></tr>
+{{with .ProwJob}}<tr><th>State</th><td>{{.Status.State}}</td></tr>
+{{if .Status.Description}}<tr><th>Description</th><td>{{.Status.Description}}</td></tr>{{end}}
+{{if not .Status.StartTime.IsZero}}<tr><th>Started</th><td>{{.Status.StartTime.Format "2006-01-02 15:04:05 MST"}}</td></tr>{{end}}
+{{with .Status.CompletionTime}}<tr><th>Completed</th><td>{{.Format "2006-01-02 15:04:05 MST"}}</td></tr>{{end}}
+{{if .Status.URL}}<tr><th>Status</th><td><a href="{{.Status.URL}}">{{.Status.URL}}</a></td></tr>{{end}}{{end}}
+<tr><th>Bundled</th><td>{{.Created.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+</table>
+<h2>Files</h2>
+<ul>
+{{range .Files}}<li><a href="{{.Name}}">{{.Name}}</a>{{if .Description}} - {{.Description}}{{end}}{{if .Truncated}} (truncated){{end}}</li>
+{{end}}</ul>
+{{if .Skipped}}<h2>Not bundled</h2>
+<ul>
+{{range .Skipped}}<li>{{.}}</li>
+{{end}}</ul>{{end}}
+</body>
+</html>
+`))
+
+// Write writes the bundle to w as a gzipped tarball of a directory with an
+// index.html. Artifacts that cannot be read are listed in the index instead.
+func (b *DebugBundle) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	dir := strings.TrimSuffix(b.Filename(), ".tar.gz")
+	created := time.Now()
+	index := debugBundleIndex{
+		JobName: b.JobName,
+		BuildID: b.BuildID,
+		Source:  b.Source,
+		Created: created,
+	}
+	add := func(file debugBundleFile, content []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    path.Join(dir, file.Name),
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: created,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+		index.Files = append(index.Files, file)
+		return nil
+	}
+
+	prowJob := b.prowJob
+	budget := b.sizeLimit
+	for _, artifact := range b.artifacts {
+		name := artifact.JobPath()
+		if budget <= 0 {
+			index.Skipped = append(index.Skipped, fmt.Sprintf("%s: the size limit of the bundle was reached", name))
+			continue
+		}
+		content, truncated, err := readForBundle(artifact, budget)
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", name).Debug("Failed to read artifact to bundle.")
+			index.Skipped = append(index.Skipped, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		budget -= int64(len(content))
+		if err := add(debugBundleFile{Name: path.Join("artifacts", name), Truncated: truncated}, content); err != nil {
+			return err
+		}
+		if truncated {
+			continue
+		}
+		switch name {
+		case prowJobArtifact:
+			if prowJob == nil {
+				var job prowapi.ProwJob
+				if err := json.Unmarshal(content, &job); err == nil {
+					prowJob = &job
+				}
+			}
+		case podInfoArtifact:
+			var report k8sreporter.PodReport
+			if err := json.Unmarshal(content, &report); err != nil {
+				break
+			}
+			if report.Pod != nil {
+				if err := addYAML(add, "pod.yaml", "The pod of the run", report.Pod); err != nil {
+					return err
+				}
+			}
+			if len(report.Events) > 0 {
+				if err := addYAML(add, "events.yaml", "The events of the pod of the run", report.Events); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if prowJob != nil {
+		index.ProwJob = prowJob
+		if err := addYAML(add, "prowjob.yaml", "The ProwJob of the run", prowJob); err != nil {
+			return err
+		}
+	}
+
+	var rendered bytes.Buffer
+	if err := debugBundleIndexTemplate.Execute(&rendered, index); err != nil {
+		return fmt.Errorf("error rendering the index: %w", err)
+	}
+	if err := add(debugBundleFile{Name: "index.html"}, rendered.Bytes()); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// addYAML adds the object serialized as YAML to the bundle.
+func addYAML(add func(debugBundleFile, []byte) error, name, description string, obj interface{}) error {
+	content, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("error serializing %s: %w", name, err)
+	}
+	return add(debugBundleFile{Name: name, Description: description}, content)
+}
+
+// readForBundle reads an artifact, or as much of it as fits in budget bytes.
+// The end of build logs is kept, as that is where failures mostly are, and
+// the beginning of other artifacts.
+func readForBundle(artifact api.Artifact, budget int64) ([]byte, bool, error) {
+	size, err := artifact.Size()
+	if err != nil {
+		return nil, false, err
+	}
+	if size <= budget {
+		content, err := artifact.ReadAll()
+		return content, false, err
+	}
+	var content []byte
+	if strings.HasSuffix(artifact.JobPath(), singleLogName) {
+		content, err = artifact.ReadTail(budget)
+	} else {
+		content, err = artifact.ReadAtMost(budget)
+	}
+	if err != nil && err != io.EOF {
+		return nil, false, err
+	}
+	if int64(len(content)) > budget {
+		content = content[:budget]
+	}
+	return content, true, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+func TestInDebugBundle(t *testing.T) {
+	testCases := map[string]bool{
+		"prowjob.json":              true,
+		"podinfo.json":              true,
+		"finished.json":             true,
+		"build-log.txt":             true,
+		"test-build-log.txt":        true,
+		"artifacts/junit_01.xml":    true,
+		"artifacts/e2e/junit.xml":   true,
+		"artifacts/metadata.json":   true,
+		"artifacts/coverage.out":    false,
+		"artifacts/junit_notes.txt": false,
+	}
+	for name, expected := range testCases {
+		if actual := inDebugBundle(name); actual != expected {
+			t.Errorf("expected inDebugBundle(%q) to be %t, got %t", name, expected, actual)
+		}
+	}
+}
+
+// unreadableArtifact cannot be read.
+type unreadableArtifact struct {
+	fake.Artifact
+}
+
+func (a *unreadableArtifact) Size() (int64, error) {
+	return 0, errors.New("access denied")
+}
+
+func TestDebugBundleWrite(t *testing.T) {
+	prowJob := []byte(`{"metadata":{"name":"abc"},"spec":{"job":"job"},"status":{"state":"failure"}}`)
+	podInfo := []byte(`{"pod":{"metadata":{"name":"abc"}},"events":[{"message":"Pulled image"}]}`)
+	buildLog := []byte("starting\nFAIL: TestFoo\n")
+	bundle := &DebugBundle{
+		JobName: "job",
+		BuildID: "123",
+		Source:  "gs/bucket/logs/job/123",
+		artifacts: []api.Artifact{
+			&fake.Artifact{Path: "prowjob.json", Content: prowJob},
+			&fake.Artifact{Path: "podinfo.json", Content: podInfo},
+			&fake.Artifact{Path: "build-log.txt", Content: buildLog},
+			&unreadableArtifact{fake.Artifact{Path: "finished.json"}},
+			&fake.Artifact{Path: "artifacts/junit.xml", Content: []byte("<testsuites></testsuites>")},
+		},
+		// The size limit leaves room for the beginning of the JUnit results.
+		sizeLimit: int64(len(prowJob) + len(podInfo) + len(buildLog) + len("<testsuites>")),
+	}
+
+	var archive bytes.Buffer
+	if err := bundle.Write(&archive); err != nil {
+		t.Fatalf("failed to write bundle: %v", err)
+	}
+	gz, err := gzip.NewReader(&archive)
+	if err != nil {
+		t.Fatalf("failed to decompress bundle: %v", err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read bundle: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", header.Name, err)
+		}
+		files[header.Name] = string(content)
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expectedNames := []string{
+		"job-123-debug-bundle/artifacts/artifacts/junit.xml",
+		"job-123-debug-bundle/artifacts/build-log.txt",
+		"job-123-debug-bundle/artifacts/podinfo.json",
+		"job-123-debug-bundle/artifacts/prowjob.json",
+		"job-123-debug-bundle/events.yaml",
+		"job-123-debug-bundle/index.html",
+		"job-123-debug-bundle/pod.yaml",
+		"job-123-debug-bundle/prowjob.yaml",
+	}
+	if diff := cmp.Diff(expectedNames, names); diff != "" {
+		t.Errorf("files differ from expected (-want +got):\n%s", diff)
+	}
+	if junit := files["job-123-debug-bundle/artifacts/artifacts/junit.xml"]; junit != "<testsuites>" {
+		t.Errorf("expected the beginning of the JUnit results, got %q", junit)
+	}
+	if !strings.Contains(files["job-123-debug-bundle/prowjob.yaml"], "state: failure") {
+		t.Errorf("expected prowjob.yaml to contain the state, got %q", files["job-123-debug-bundle/prowjob.yaml"])
+	}
+	if !strings.Contains(files["job-123-debug-bundle/events.yaml"], "message: Pulled image") {
+		t.Errorf("expected events.yaml to contain the events, got %q", files["job-123-debug-bundle/events.yaml"])
+	}
+	index := files["job-123-debug-bundle/index.html"]
+	for _, expected := range []string{
+		`<a href="artifacts/build-log.txt">artifacts/build-log.txt</a>`,
+		"artifacts/artifacts/junit.xml</a> (truncated)",
+		"finished.json: access denied",
+		"<td>failure</td>",
+	} {
+		if !strings.Contains(index, expected) {
+			t.Errorf("expected the index to contain %q, got %q", expected, index)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
+)
+
+const (
+	// contentAddressedManifestTTL is how long manifests are cached, including
+	// the absence of a manifest.
+	contentAddressedManifestTTL = 5 * time.Minute
+	// maxContentAddressedManifests bounds the number of cached manifests.
+	maxContentAddressedManifests = 10000
+)
+
+// contentAddressedManifests caches the manifests referencing the artifacts of
+// runs that are stored by their content, keyed by the path of the manifest.
+type contentAddressedManifests struct {
+	lock      sync.Mutex
+	manifests map[string]cachedManifest
+	now       func() time.Time
+}
+
+type cachedManifest struct {
+	// artifacts is nil if there is no manifest.
+	artifacts map[string]gcs.ContentAddressedArtifact
+	expires   time.Time
+}
+
+func newContentAddressedManifests() *contentAddressedManifests {
+	return &contentAddressedManifests{manifests: map[string]cachedManifest{}, now: time.Now}
+}
+
+func (m *contentAddressedManifests) get(manifestPath string) (map[string]gcs.ContentAddressedArtifact, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	cached, ok := m.manifests[manifestPath]
+	if !ok || m.now().After(cached.expires) {
+		return nil, false
+	}
+	return cached.artifacts, true
+}
+
+func (m *contentAddressedManifests) set(manifestPath string, artifacts map[string]gcs.ContentAddressedArtifact) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now := m.now()
+	if len(m.manifests) >= maxContentAddressedManifests {
+		for cachedPath, cached := range m.manifests {
+			if now.After(cached.expires) {
+				delete(m.manifests, cachedPath)
+			}
+		}
+		if len(m.manifests) >= maxContentAddressedManifests {
+			m.manifests = map[string]cachedManifest{}
+		}
+	}
+	m.manifests[manifestPath] = cachedManifest{artifacts: artifacts, expires: now.Add(contentAddressedManifestTTL)}
+}
+
+// manifestPath returns the path of the manifest in the directory dir of the
+// artifacts of the job source.
+func (src *storageJobSource) manifestPath(dir string) string {
+	return fmt.Sprintf("%s%s/%s", src.linkPrefix, src.bucket, path.Join(src.jobPrefix, dir, gcs.ContentAddressedManifestName))
+}
+
+// readContentAddressedManifest reads the manifest in the directory dir of the
+// artifacts of the job source, returning nil if there is none.
+func (af *StorageArtifactFetcher) readContentAddressedManifest(ctx context.Context, src *storageJobSource, dir string) (map[string]gcs.ContentAddressedArtifact, error) {
+	manifestPath := src.manifestPath(dir)
+	if artifacts, ok := af.manifests.get(manifestPath); ok {
+		return artifacts, nil
+	}
+	reader, err := af.opener.Reader(ctx, manifestPath)
+	if err != nil {
+		if pkgio.IsNotExist(err) {
+			af.manifests.set(manifestPath, nil)
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var manifest gcs.ContentAddressedManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest %s: %w", manifestPath, err)
+	}
+	if manifest.Artifacts == nil {
+		manifest.Artifacts = map[string]gcs.ContentAddressedArtifact{}
+	}
+	af.manifests.set(manifestPath, manifest.Artifacts)
+	return manifest.Artifacts, nil
+}
+
+// withContentAddressedArtifacts adds the artifacts referenced by the
+// manifests among the listed artifacts of the job source. As the listing is
+// complete, the directories without a manifest are cached as such.
+func (af *StorageArtifactFetcher) withContentAddressedArtifacts(ctx context.Context, src *storageJobSource, artifacts []string) []string {
+	listed := sets.New(artifacts...)
+	dirs := sets.New[string]()
+	for _, artifact := range artifacts {
+		for dir := path.Dir(artifact); ; dir = path.Dir(dir) {
+			if dirs.Has(dir) {
+				break
+			}
+			dirs.Insert(dir)
+			if dir == "." {
+				break
+			}
+		}
+	}
+	for _, dir := range sets.List(dirs) {
+		if dir == "." {
+			dir = ""
+		}
+		if !listed.Has(path.Join(dir, gcs.ContentAddressedManifestName)) {
+			af.manifests.set(src.manifestPath(dir), nil)
+			continue
+		}
+		manifest, err := af.readContentAddressedManifest(ctx, src, dir)
+		if err != nil {
+			logrus.WithFields(fieldsForJob(src)).WithError(err).Warn("Failed to read the manifest of artifacts stored by their content.")
+			continue
+		}
+		for name := range manifest {
+			if name := path.Join(dir, name); !listed.Has(name) {
+				listed.Insert(name)
+				artifacts = append(artifacts, name)
+			}
+		}
+	}
+	return artifacts
+}
+
+// contentAddressedObject returns the path under the bucket of the content of
+// the artifact, if it is stored by its content.
+func (af *StorageArtifactFetcher) contentAddressedObject(ctx context.Context, src *storageJobSource, artifactName string) (string, bool) {
+	segments := strings.Split(artifactName, "/")
+	for i := range segments[:len(segments)-1] {
+		dir := strings.Join(segments[:i], "/")
+		manifest, err := af.readContentAddressedManifest(ctx, src, dir)
+		if err != nil {
+			logrus.WithFields(fieldsForJob(src)).WithError(err).Warn("Failed to read the manifest of artifacts stored by their content.")
+			continue
+		}
+		if artifact, ok := manifest[strings.Join(segments[i:], "/")]; ok {
+			return artifact.Path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
+)
+
+func TestContentAddressedArtifacts(t *testing.T) {
+	server := fakestorage.NewServer([]fakestorage.Object{
+		{
+			BucketName: "test-bucket",
+			Name:       "logs/job/1/build-log.txt",
+			Content:    []byte("stored as usual"),
+		},
+		{
+			BucketName: "test-bucket",
+			Name:       "logs/job/1/" + gcs.ContentAddressedManifestName,
+			Content: []byte(`{"artifacts": {
+				"artifacts/binary": {"path": "cas/sha256/abc", "digest": "sha256:abc", "size": 15},
+				"artifacts/fixtures/data.bin": {"path": "cas/sha256/abc", "digest": "sha256:abc", "size": 15}
+			}}`),
+		},
+		{
+			BucketName: "test-bucket",
+			Name:       "logs/job/1/artifacts/small.txt",
+			Content:    []byte("small"),
+		},
+		{
+			BucketName: "test-bucket",
+			Name:       "cas/sha256/abc",
+			Content:    []byte("stored once now"),
+		},
+		{
+			BucketName: "test-bucket",
+			Name:       "logs/job/2/build-log.txt",
+			Content:    []byte("no manifest"),
+		},
+	})
+	defer server.Stop()
+	newFetcher := func() *StorageArtifactFetcher {
+		return NewStorageArtifactFetcher(io.NewGCSOpener(server.Client()), createConfigGetter("test-bucket"), false)
+	}
+
+	testCases := []struct {
+		name              string
+		source            string
+		list              bool
+		expectedArtifacts []string
+		expectedContent   map[string]string
+	}{
+		{
+			name:   "referenced artifacts are listed and resolved",
+			source: "gs://test-bucket/logs/job/1",
+			list:   true,
+			expectedArtifacts: []string{
+				"build-log.txt",
+				gcs.ContentAddressedManifestName,
+				"artifacts/small.txt",
+				"artifacts/binary",
+				"artifacts/fixtures/data.bin",
+			},
+			expectedContent: map[string]string{
+				"build-log.txt":               "stored as usual",
+				"artifacts/small.txt":         "small",
+				"artifacts/binary":            "stored once now",
+				"artifacts/fixtures/data.bin": "stored once now",
+			},
+		},
+		{
+			name:   "referenced artifacts are resolved without listing",
+			source: "gs://test-bucket/logs/job/1",
+			expectedContent: map[string]string{
+				"build-log.txt":               "stored as usual",
+				"artifacts/binary":            "stored once now",
+				"artifacts/fixtures/data.bin": "stored once now",
+			},
+		},
+		{
+			name:              "runs without manifest are read as usual",
+			source:            "gs://test-bucket/logs/job/2",
+			list:              true,
+			expectedArtifacts: []string{"build-log.txt"},
+			expectedContent: map[string]string{
+				"build-log.txt": "no manifest",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fetcher := newFetcher()
+			if tc.list {
+				artifacts, err := fetcher.artifacts(context.Background(), tc.source)
+				if err != nil {
+					t.Fatalf("failed to list artifacts: %v", err)
+				}
+				if diff := cmp.Diff(tc.expectedArtifacts, artifacts, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("unexpected artifacts (-want +got):\n%s", diff)
+				}
+			}
+			for name, expected := range tc.expectedContent {
+				artifact, err := fetcher.Artifact(context.Background(), tc.source, name, 500e6)
+				if err != nil {
+					t.Fatalf("failed to get artifact %s: %v", name, err)
+				}
+				content, err := artifact.ReadAll()
+				if err != nil {
+					t.Fatalf("failed to read artifact %s: %v", name, err)
+				}
+				if string(content) != expected {
+					t.Errorf("expected artifact %s to be %q, got %q", name, expected, content)
+				}
+			}
+		})
+	}
+}
+
+func TestContentAddressedManifestsExpire(t *testing.T) {
+	manifests := newContentAddressedManifests()
+	now := time.Now()
+	manifests.now = func() time.Time { return now }
+
+	manifests.set("gs://bucket/logs/job/1/cas-manifest.json", nil)
+	if _, ok := manifests.get("gs://bucket/logs/job/1/cas-manifest.json"); !ok {
+		t.Error("expected the absence of the manifest to be cached")
+	}
+	now = now.Add(contentAddressedManifestTTL + time.Second)
+	if _, ok := manifests.get("gs://bucket/logs/job/1/cas-manifest.json"); ok {
+		t.Error("expected the manifest to expire")
+	}
+}
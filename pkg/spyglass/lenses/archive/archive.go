@@ -0,0 +1,303 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+type format string
+
+const (
+	formatZip format = "zip"
+	formatTar format = "tar"
+	// formatTarGz also covers tarballs that the storage provider already
+	// decompresses, e.g. because they were uploaded with a gzip encoding.
+	formatTarGz format = "tar.gz"
+)
+
+// detectFormat returns the format of an archive from its name.
+func detectFormat(name string) (format, bool) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return formatZip, true
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return formatTarGz, true
+	case strings.HasSuffix(name, ".tar"):
+		return formatTar, true
+	}
+	return "", false
+}
+
+// entry is a member of an archive.
+type entry struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Dir  bool   `json:"dir,omitempty"`
+}
+
+// listEntries returns the first maxEntries members of an archive, and
+// whether there are more.
+func listEntries(artifact api.Artifact, f format, maxEntries int) ([]entry, bool, error) {
+	var entries []entry
+	if f == formatZip {
+		r, err := newZipReader(artifact)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, file := range r.File {
+			if len(entries) == maxEntries {
+				return entries, true, nil
+			}
+			entries = append(entries, entry{Name: file.Name, Size: int64(file.UncompressedSize64), Dir: file.FileInfo().IsDir()})
+		}
+		return entries, false, nil
+	}
+
+	tr, closer, err := newTarReader(artifact)
+	if err != nil {
+		return nil, false, err
+	}
+	defer closer.Close()
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read the tarball: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeDir {
+			continue
+		}
+		if len(entries) == maxEntries {
+			return entries, true, nil
+		}
+		entries = append(entries, entry{Name: header.Name, Size: header.Size, Dir: header.Typeflag == tar.TypeDir})
+	}
+}
+
+// openMember returns a reader of the content of a member of an archive.
+func openMember(artifact api.Artifact, f format, name string) (io.ReadCloser, error) {
+	if f == formatZip {
+		r, err := newZipReader(artifact)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range r.File {
+			if file.Name == name && !file.FileInfo().IsDir() {
+				return openZipFile(artifact, file)
+			}
+		}
+		return nil, fmt.Errorf("no file named %s in the archive", name)
+	}
+
+	tr, closer, err := newTarReader(artifact)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			closer.Close()
+			return nil, fmt.Errorf("no file named %s in the archive", name)
+		}
+		if err != nil {
+			closer.Close()
+			return nil, fmt.Errorf("failed to read the tarball: %w", err)
+		}
+		if header.Name == name && header.Typeflag == tar.TypeReg {
+			return readCloser{Reader: tr, closers: []io.Closer{closer}}, nil
+		}
+	}
+}
+
+// readCloser closes the readers a reader reads from.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r readCloser) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newTarReader streams a tarball, decompressing it if needed.
+func newTarReader(artifact api.Artifact) (*tar.Reader, io.Closer, error) {
+	var r io.ReadCloser
+	if rr, ok := artifact.(api.RangeReader); ok {
+		var err error
+		if r, err = rr.NewRangeReader(0, -1); err != nil {
+			return nil, nil, fmt.Errorf("failed to read the archive: %w", err)
+		}
+	} else {
+		content, err := artifact.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read the archive: %w", err)
+		}
+		r = io.NopCloser(bytes.NewReader(content))
+	}
+	buffered := bufio.NewReader(r)
+	// Look at the content rather than the name, tarballs stored with a gzip
+	// encoding are decompressed by the storage provider.
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			r.Close()
+			return nil, nil, fmt.Errorf("failed to decompress the archive: %w", err)
+		}
+		return tar.NewReader(gz), readCloser{closers: []io.Closer{gz, r}}, nil
+	}
+	return tar.NewReader(buffered), r, nil
+}
+
+// newZipReader reads the central directory of a zip archive, which is at
+// its end, without reading the members.
+func newZipReader(artifact api.Artifact) (*zip.Reader, error) {
+	ra, size, err := newReaderAt(artifact)
+	if err != nil {
+		return nil, err
+	}
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the zip archive: %w", err)
+	}
+	return r, nil
+}
+
+// openZipFile streams the compressed content of a member with a single
+// range read where possible, rather than with the many small reads of
+// zip.File.Open.
+func openZipFile(artifact api.Artifact, file *zip.File) (io.ReadCloser, error) {
+	rr, ok := artifact.(api.RangeReader)
+	if !ok || (file.Method != zip.Store && file.Method != zip.Deflate) {
+		return file.Open()
+	}
+	offset, err := file.DataOffset()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s in the archive: %w", file.Name, err)
+	}
+	body, err := rr.NewRangeReader(offset, int64(file.CompressedSize64))
+	if errors.Is(err, lenses.ErrGzipOffsetRead) {
+		return file.Open()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	if file.Method == zip.Store {
+		return body, nil
+	}
+	inflated := flate.NewReader(body)
+	return readCloser{Reader: inflated, closers: []io.Closer{inflated, body}}, nil
+}
+
+const (
+	// blockSize is the size of the reads of blockReaderAt.
+	blockSize = 256 << 10
+	// maxCachedBlocks is the number of blocks blockReaderAt keeps.
+	maxCachedBlocks = 16
+)
+
+// newReaderAt returns a reader of the artifact at offsets and its size. Files
+// that cannot be read at offsets, e.g. because they are stored with a gzip
+// encoding, are read into memory.
+func newReaderAt(artifact api.Artifact) (io.ReaderAt, int64, error) {
+	size, err := artifact.Size()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get the size of the archive: %w", err)
+	}
+	r := &blockReaderAt{artifact: artifact, size: size, blocks: map[int64][]byte{}}
+	if size == 0 {
+		return r, 0, nil
+	}
+	if _, err := r.block(0); errors.Is(err, lenses.ErrGzipOffsetRead) {
+		content, err := artifact.ReadAll()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read the archive: %w", err)
+		}
+		return bytes.NewReader(content), int64(len(content)), nil
+	} else if err != nil {
+		return nil, 0, fmt.Errorf("failed to read the archive: %w", err)
+	}
+	return r, size, nil
+}
+
+// blockReaderAt reads an artifact in blocks and caches the last ones, as
+// the zip reader makes many small reads of the same regions.
+type blockReaderAt struct {
+	artifact api.Artifact
+	size     int64
+	blocks   map[int64][]byte
+	// order are the indices of the cached blocks, the oldest first.
+	order []int64
+}
+
+func (r *blockReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	n := 0
+	for n < len(p) && off+int64(n) < r.size {
+		pos := off + int64(n)
+		block, err := r.block(pos / blockSize)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], block[pos%blockSize:])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *blockReaderAt) block(i int64) ([]byte, error) {
+	if block, ok := r.blocks[i]; ok {
+		return block, nil
+	}
+	start := i * blockSize
+	block := make([]byte, min(blockSize, r.size-start))
+	if _, err := r.artifact.ReadAt(block, start); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(r.order) == maxCachedBlocks {
+		delete(r.blocks, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.blocks[i] = block
+	r.order = append(r.order, i)
+	return block, nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+type Action = 'list' | 'view' | 'download';
+
+interface Entry {
+  name: string;
+  size: number;
+  dir?: boolean;
+}
+
+interface ArchiveResponse {
+  error?: string;
+  entries?: Entry[];
+  truncated?: boolean;
+  content?: string;
+  binary?: boolean;
+}
+
+async function request(archive: HTMLElement, action: Action, member?: string): Promise<ArchiveResponse> {
+  return JSON.parse(await spyglass.request(JSON.stringify({
+    action,
+    artifact: archive.dataset.artifact,
+    member,
+  }))) as ArchiveResponse;
+}
+
+function formatSize(size: number): string {
+  const units = ['B', 'KiB', 'MiB', 'GiB'];
+  let i = 0;
+  while (size >= 1024 && i < units.length - 1) {
+    size /= 1024;
+    i++;
+  }
+  return i === 0 ? `${size} B` : `${size.toFixed(1)} ${units[i]}`;
+}
+
+function showError(elem: HTMLElement, message: string): void {
+  elem.innerHTML = '';
+  const error = document.createElement('div');
+  error.className = 'archive-error';
+  error.textContent = message;
+  elem.appendChild(error);
+  spyglass.contentUpdated();
+}
+
+// viewMember shows the content of a member below its row, or hides it if it
+// is already shown.
+async function viewMember(archive: HTMLElement, row: HTMLTableRowElement, entry: Entry): Promise<void> {
+  const next = row.nextElementSibling;
+  if (next && next.classList.contains('archive-member-row')) {
+    next.remove();
+    spyglass.contentUpdated();
+    return;
+  }
+  const memberRow = document.createElement('tr');
+  memberRow.className = 'archive-member-row';
+  const cell = memberRow.insertCell();
+  cell.colSpan = 3;
+  cell.textContent = 'Loading...';
+  row.after(memberRow);
+  spyglass.contentUpdated();
+
+  const resp = await request(archive, 'view', entry.name);
+  if (resp.error) {
+    showError(cell, resp.error);
+    return;
+  }
+  cell.innerHTML = '';
+  if (resp.binary) {
+    const note = document.createElement('div');
+    note.className = 'archive-note';
+    note.textContent = 'This file is not text, download it instead.';
+    cell.appendChild(note);
+  } else {
+    const pre = document.createElement('pre');
+    pre.className = 'archive-member';
+    pre.textContent = resp.content || '';
+    cell.appendChild(pre);
+    if (resp.truncated) {
+      const note = document.createElement('div');
+      note.className = 'archive-note';
+      note.textContent = 'The file is too large to be shown in full, download it instead.';
+      cell.appendChild(note);
+    }
+  }
+  spyglass.contentUpdated();
+}
+
+async function downloadMember(archive: HTMLElement, entry: Entry): Promise<void> {
+  const resp = await request(archive, 'download', entry.name);
+  if (resp.error) {
+    alert(resp.error);
+    return;
+  }
+  const raw = atob(resp.content || '');
+  const bytes = new Uint8Array(raw.length);
+  for (let i = 0; i < raw.length; i++) {
+    bytes[i] = raw.charCodeAt(i);
+  }
+  const url = URL.createObjectURL(new Blob([bytes]));
+  const link = document.createElement('a');
+  link.href = url;
+  link.download = entry.name.substring(entry.name.lastIndexOf('/') + 1);
+  document.body.appendChild(link);
+  link.click();
+  link.remove();
+  URL.revokeObjectURL(url);
+}
+
+function actionLink(text: string, onclick: () => void): HTMLElement {
+  const link = document.createElement('span');
+  link.className = 'archive-action';
+  link.textContent = text;
+  link.onclick = onclick;
+  return link;
+}
+
+function renderEntries(archive: HTMLElement, entries: Entry[]): HTMLTableElement {
+  const table = document.createElement('table');
+  table.className = 'archive-entries';
+  const body = table.createTBody();
+  for (const entry of entries) {
+    const row = body.insertRow();
+    row.dataset.name = entry.name;
+    const name = row.insertCell();
+    const size = row.insertCell();
+    size.className = 'archive-size';
+    const actions = row.insertCell();
+    if (entry.dir) {
+      name.textContent = entry.name;
+      continue;
+    }
+    name.appendChild(actionLink(entry.name, () => viewMember(archive, row, entry)));
+    size.textContent = formatSize(entry.size);
+    actions.appendChild(actionLink('Download', () => downloadMember(archive, entry)));
+  }
+  return table;
+}
+
+// filterEntries hides the members whose names do not contain the filter,
+// along with the content shown below them.
+function filterEntries(table: HTMLTableElement, filter: string): void {
+  let hidden = false;
+  for (const row of Array.from(table.rows)) {
+    if (row.classList.contains('archive-member-row')) {
+      row.hidden = hidden;
+      continue;
+    }
+    hidden = !(row.dataset.name || '').includes(filter);
+    row.hidden = hidden;
+  }
+  spyglass.contentUpdated();
+}
+
+async function expand(archive: HTMLElement): Promise<void> {
+  const button = archive.querySelector<HTMLButtonElement>('.archive-expand')!;
+  const filter = archive.querySelector<HTMLInputElement>('.archive-filter')!;
+  const content = archive.querySelector<HTMLDivElement>('.archive-content')!;
+  button.disabled = true;
+  content.textContent = 'Loading...';
+  spyglass.contentUpdated();
+
+  const resp = await request(archive, 'list');
+  if (resp.error) {
+    button.disabled = false;
+    showError(content, resp.error);
+    return;
+  }
+  button.hidden = true;
+  content.innerHTML = '';
+  const entries = resp.entries || [];
+  if (entries.length === 0) {
+    content.textContent = 'The archive is empty.';
+    spyglass.contentUpdated();
+    return;
+  }
+  const table = renderEntries(archive, entries);
+  content.appendChild(table);
+  if (resp.truncated) {
+    const note = document.createElement('div');
+    note.className = 'archive-note';
+    note.textContent = `Only the first ${entries.length} members are listed.`;
+    content.appendChild(note);
+  }
+  filter.hidden = false;
+  filter.oninput = () => filterEntries(table, filter.value);
+  spyglass.contentUpdated();
+}
+
+window.addEventListener('load', () => {
+  for (const archive of Array.from(document.querySelectorAll<HTMLDivElement>('.archive'))) {
+    const button = archive.querySelector<HTMLButtonElement>('.archive-expand');
+    if (button) {
+      button.onclick = () => expand(archive);
+    }
+  }
+});
@@ -0,0 +1,268 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package archive provides a lens that browses the members of tarballs and
+// zip archives without downloading them.
+package archive
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "archive"
+	title    = "Archives"
+	priority = 35
+
+	defaultMaxEntries       = 10000
+	defaultMaxViewBytes     = 1 << 20
+	defaultMaxDownloadBytes = 20 << 20
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens browses archives.
+type Lens struct{}
+
+type lensConfig struct {
+	// MaxEntries is the number of members listed. Defaults to 10000.
+	MaxEntries int `json:"max_entries,omitempty"`
+	// MaxViewBytes is the number of bytes of a member shown. Defaults to 1MiB.
+	MaxViewBytes int64 `json:"max_view_bytes,omitempty"`
+	// MaxDownloadBytes is the size of the largest member that can be
+	// downloaded. Defaults to 20MiB.
+	MaxDownloadBytes int64 `json:"max_download_bytes,omitempty"`
+}
+
+func parseConfig(raw json.RawMessage) lensConfig {
+	var c lensConfig
+	if len(raw) != 0 {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			logrus.WithError(err).Warn("Failed to parse the config of the archive lens, using the defaults.")
+		}
+	}
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = defaultMaxEntries
+	}
+	if c.MaxViewBytes <= 0 {
+		c.MaxViewBytes = defaultMaxViewBytes
+	}
+	if c.MaxDownloadBytes <= 0 {
+		c.MaxDownloadBytes = defaultMaxDownloadBytes
+	}
+	return c
+}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "header", nil)
+	if err != nil {
+		logrus.Warnf("Failed to render header: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+type archiveLink struct {
+	Name string
+	Link string
+	// Supported is false for files that are not archives of a known format.
+	Supported bool
+}
+
+// Body renders a placeholder for each archive. Archives are only read once
+// they are expanded through callbacks.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	var archives []archiveLink
+	for _, artifact := range artifacts {
+		_, supported := detectFormat(artifact.JobPath())
+		archives = append(archives, archiveLink{
+			Name:      artifact.JobPath(),
+			Link:      lenses.RawArtifactLink(artifact, spyglassConfig),
+			Supported: supported,
+		})
+	}
+	output, err := renderTemplate(resourceDir, "body", archives)
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+type action string
+
+const (
+	actionList     action = "list"
+	actionView     action = "view"
+	actionDownload action = "download"
+)
+
+// callbackRequest asks for the members of an archive or the content of one.
+type callbackRequest struct {
+	Artifact string `json:"artifact"`
+	Action   action `json:"action"`
+	Member   string `json:"member,omitempty"`
+}
+
+// callbackResponse holds the members of an archive or the content of one.
+type callbackResponse struct {
+	Error   string  `json:"error,omitempty"`
+	Entries []entry `json:"entries,omitempty"`
+	// Truncated is true if there are more members or more content than
+	// returned.
+	Truncated bool `json:"truncated,omitempty"`
+	// Content is the text of a viewed member or the base64 encoded content
+	// of a downloaded one.
+	Content string `json:"content,omitempty"`
+	// Binary is true if a viewed member is not text.
+	Binary bool `json:"binary,omitempty"`
+}
+
+// Callback lists the members of an archive, or returns the content of one
+// to view or download.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	resp := callback(artifacts, data, parseConfig(rawConfig))
+	b, err := json.Marshal(resp)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to marshal archive response.")
+		return fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	return string(b)
+}
+
+func callback(artifacts []api.Artifact, data string, c lensConfig) *callbackResponse {
+	var request callbackRequest
+	if err := json.Unmarshal([]byte(data), &request); err != nil {
+		return &callbackResponse{Error: "Failed to unmarshal request"}
+	}
+	var artifact api.Artifact
+	for _, a := range artifacts {
+		if a.JobPath() == request.Artifact {
+			artifact = a
+			break
+		}
+	}
+	if artifact == nil {
+		return &callbackResponse{Error: fmt.Sprintf("No artifact named %s", request.Artifact)}
+	}
+	f, ok := detectFormat(artifact.JobPath())
+	if !ok {
+		return &callbackResponse{Error: fmt.Sprintf("%s is not a tarball or zip archive", request.Artifact)}
+	}
+	log := logrus.WithField("artifact", artifact.CanonicalLink())
+
+	switch request.Action {
+	case actionList:
+		entries, truncated, err := listEntries(artifact, f, c.MaxEntries)
+		if err != nil {
+			log.WithError(err).Info("Failed to list archive.")
+			return &callbackResponse{Error: err.Error()}
+		}
+		return &callbackResponse{Entries: entries, Truncated: truncated}
+	case actionView, actionDownload:
+		limit := c.MaxViewBytes
+		if request.Action == actionDownload {
+			limit = c.MaxDownloadBytes
+		}
+		content, truncated, err := readMember(artifact, f, request.Member, limit)
+		if err != nil {
+			log.WithError(err).WithField("member", request.Member).Info("Failed to read archive member.")
+			return &callbackResponse{Error: err.Error()}
+		}
+		if request.Action == actionDownload {
+			if truncated {
+				return &callbackResponse{Error: fmt.Sprintf("%s is larger than %d bytes, download the whole archive instead", request.Member, limit)}
+			}
+			return &callbackResponse{Content: base64.StdEncoding.EncodeToString(content)}
+		}
+		if !isText(content) {
+			return &callbackResponse{Binary: true}
+		}
+		return &callbackResponse{Content: string(content), Truncated: truncated}
+	}
+	return &callbackResponse{Error: fmt.Sprintf("Unknown action %q", request.Action)}
+}
+
+// readMember reads at most limit bytes of a member, and whether there are
+// more.
+func readMember(artifact api.Artifact, f format, member string, limit int64) ([]byte, bool, error) {
+	r, err := openMember(artifact, f, member)
+	if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+	content, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", member, err)
+	}
+	if int64(len(content)) > limit {
+		return content[:limit], true, nil
+	}
+	return content, false, nil
+}
+
+// isText tells whether content is UTF-8 without NUL bytes, ignoring a last
+// rune cut in half by a limit.
+func isText(content []byte) bool {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return false
+	}
+	for i := 0; i < utf8.UTFMax && len(content) > 0; i++ {
+		if utf8.Valid(content) {
+			return true
+		}
+		content = content[:len(content)-1]
+	}
+	return utf8.Valid(content)
+}
+
+func renderTemplate(resourceDir, block string, params interface{}) (string, error) {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, block, params); err != nil {
+		return "", fmt.Errorf("Failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+type testFile struct {
+	name    string
+	content string
+}
+
+var testFiles = []testFile{
+	{name: "logs/"},
+	{name: "logs/build.log", content: "building\ndone\n"},
+	{name: "logs/test.log", content: "PASS\n"},
+	{name: "bin/tool", content: "\x7fELF\x00\x01"},
+}
+
+func testZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range testFiles {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", f.name, err)
+		}
+		if _, err := fw.Write([]byte(f.content)); err != nil {
+			t.Fatalf("failed to write %s: %v", f.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close the archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func testTarGz(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gw)
+	for _, f := range testFiles {
+		header := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content)), Typeflag: tar.TypeReg}
+		if f.name[len(f.name)-1] == '/' {
+			header.Typeflag = tar.TypeDir
+			header.Mode = 0755
+		}
+		if err := w.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write the header of %s: %v", f.name, err)
+		}
+		if _, err := w.Write([]byte(f.content)); err != nil {
+			t.Fatalf("failed to write %s: %v", f.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close the tarball: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("failed to close the gzip stream: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCallback(t *testing.T) {
+	allEntries := []entry{
+		{Name: "logs/", Dir: true},
+		{Name: "logs/build.log", Size: 14},
+		{Name: "logs/test.log", Size: 5},
+		{Name: "bin/tool", Size: 6},
+	}
+	archives := map[string][]byte{
+		"artifacts/out.zip":    testZip(t),
+		"artifacts/out.tar.gz": testTarGz(t),
+	}
+	testCases := []struct {
+		name     string
+		request  callbackRequest
+		config   lensConfig
+		expected *callbackResponse
+	}{
+		{
+			name:     "list",
+			request:  callbackRequest{Action: actionList},
+			config:   lensConfig{MaxEntries: 10},
+			expected: &callbackResponse{Entries: allEntries},
+		},
+		{
+			name:     "list is truncated",
+			request:  callbackRequest{Action: actionList},
+			config:   lensConfig{MaxEntries: 2},
+			expected: &callbackResponse{Entries: allEntries[:2], Truncated: true},
+		},
+		{
+			name:     "view",
+			request:  callbackRequest{Action: actionView, Member: "logs/build.log"},
+			config:   lensConfig{MaxViewBytes: 100},
+			expected: &callbackResponse{Content: "building\ndone\n"},
+		},
+		{
+			name:     "view is truncated",
+			request:  callbackRequest{Action: actionView, Member: "logs/build.log"},
+			config:   lensConfig{MaxViewBytes: 8},
+			expected: &callbackResponse{Content: "building", Truncated: true},
+		},
+		{
+			name:     "view of a binary file",
+			request:  callbackRequest{Action: actionView, Member: "bin/tool"},
+			config:   lensConfig{MaxViewBytes: 100},
+			expected: &callbackResponse{Binary: true},
+		},
+		{
+			name:     "download",
+			request:  callbackRequest{Action: actionDownload, Member: "bin/tool"},
+			config:   lensConfig{MaxDownloadBytes: 100},
+			expected: &callbackResponse{Content: base64.StdEncoding.EncodeToString([]byte("\x7fELF\x00\x01"))},
+		},
+		{
+			name:     "download is too large",
+			request:  callbackRequest{Action: actionDownload, Member: "logs/build.log"},
+			config:   lensConfig{MaxDownloadBytes: 4},
+			expected: &callbackResponse{Error: "logs/build.log is larger than 4 bytes, download the whole archive instead"},
+		},
+		{
+			name:     "missing member",
+			request:  callbackRequest{Action: actionView, Member: "logs/missing.log"},
+			config:   lensConfig{MaxViewBytes: 100},
+			expected: &callbackResponse{Error: "no file named logs/missing.log in the archive"},
+		},
+		{
+			name:     "directory",
+			request:  callbackRequest{Action: actionView, Member: "logs/"},
+			config:   lensConfig{MaxViewBytes: 100},
+			expected: &callbackResponse{Error: "no file named logs/ in the archive"},
+		},
+		{
+			name:     "unknown action",
+			request:  callbackRequest{Action: "delete"},
+			expected: &callbackResponse{Error: `Unknown action "delete"`},
+		},
+	}
+	for path, content := range archives {
+		artifacts := []api.Artifact{&fake.Artifact{Path: path, Content: content}}
+		for _, tc := range testCases {
+			t.Run(path+"/"+tc.name, func(t *testing.T) {
+				tc.request.Artifact = path
+				data, err := json.Marshal(tc.request)
+				if err != nil {
+					t.Fatalf("failed to marshal request: %v", err)
+				}
+				resp := callback(artifacts, string(data), tc.config)
+				if diff := cmp.Diff(tc.expected, resp); diff != "" {
+					t.Errorf("unexpected response (-want +got):\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestCallbackErrors(t *testing.T) {
+	artifacts := []api.Artifact{
+		&fake.Artifact{Path: "artifacts/out.tar", Content: []byte("not a tarball, but long enough to be read as one")},
+		&fake.Artifact{Path: "artifacts/out.txt", Content: []byte("text")},
+	}
+	testCases := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "bad request",
+			data:     "{",
+			expected: "Failed to unmarshal request",
+		},
+		{
+			name:     "missing artifact",
+			data:     `{"artifact": "artifacts/missing.zip", "action": "list"}`,
+			expected: "No artifact named artifacts/missing.zip",
+		},
+		{
+			name:     "not an archive",
+			data:     `{"artifact": "artifacts/out.txt", "action": "list"}`,
+			expected: "artifacts/out.txt is not a tarball or zip archive",
+		},
+		{
+			name:     "corrupt archive",
+			data:     `{"artifact": "artifacts/out.tar", "action": "list"}`,
+			expected: "failed to read the tarball: unexpected EOF",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := callback(artifacts, tc.data, parseConfig(nil))
+			if resp.Error != tc.expected {
+				t.Errorf("expected error %q, got %q", tc.expected, resp.Error)
+			}
+		})
+	}
+}
+
+func TestIsText(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  []byte
+		expected bool
+	}{
+		{name: "empty", content: nil, expected: true},
+		{name: "ascii", content: []byte("hello\n"), expected: true},
+		{name: "rune cut in half", content: []byte("caf\xc3"), expected: true},
+		{name: "NUL", content: []byte("a\x00b"), expected: false},
+		{name: "invalid UTF-8", content: []byte("\xff\xfe\xfd\xfc\xfb"), expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := isText(tc.content); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
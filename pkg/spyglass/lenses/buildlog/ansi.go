@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildlog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// escapeRE matches the control sequences (CSI) and operating system commands
+// (OSC) of terminals. Only the CSI that select a graphic rendition (SGR) are
+// rendered, the others are dropped.
+var escapeRE = regexp.MustCompile(`\x1b\[([0-?]*)[ -/]*([@-~])|\x1b\][^\x07\x1b]*(\x07|\x1b\\)?`)
+
+// ansiStyle is the graphic rendition a log line is written with.
+type ansiStyle struct {
+	// fg and bg are the indexes of the 16 colors, or -1 for the default.
+	fg, bg                  int
+	bold, italic, underline bool
+}
+
+var defaultStyle = ansiStyle{fg: -1, bg: -1}
+
+// class returns the CSS classes of buildlog.css rendering the style.
+func (s ansiStyle) class() string {
+	var classes []string
+	if s.fg >= 0 {
+		classes = append(classes, "ansi-"+strconv.Itoa(s.fg))
+	}
+	if s.bg >= 0 {
+		classes = append(classes, "ansi-bg-"+strconv.Itoa(s.bg))
+	}
+	if s.bold {
+		classes = append(classes, "ansi-bold")
+	}
+	if s.italic {
+		classes = append(classes, "ansi-italic")
+	}
+	if s.underline {
+		classes = append(classes, "ansi-underline")
+	}
+	return strings.Join(classes, " ")
+}
+
+// apply updates the style with the parameters of an SGR sequence.
+func (s *ansiStyle) apply(params string) {
+	if params == "" {
+		*s = defaultStyle
+		return
+	}
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			// Empty parameters default to 0, anything else is not SGR.
+			if codes[i] != "" {
+				continue
+			}
+			code = 0
+		}
+		switch {
+		case code == 0:
+			*s = defaultStyle
+		case code == 1:
+			s.bold = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 22:
+			s.bold = false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case code >= 30 && code <= 37:
+			s.fg = code - 30
+		case code == 39:
+			s.fg = -1
+		case code >= 40 && code <= 47:
+			s.bg = code - 40
+		case code == 49:
+			s.bg = -1
+		case code >= 90 && code <= 97:
+			s.fg = code - 90 + 8
+		case code >= 100 && code <= 107:
+			s.bg = code - 100 + 8
+		case code == 38 || code == 48:
+			// 256 and 24-bit colors are not rendered, but their
+			// parameters must not be read as codes.
+			if i+1 < len(codes) {
+				switch codes[i+1] {
+				case "5":
+					i += 2
+				case "2":
+					i += 4
+				}
+			}
+		}
+	}
+}
+
+// styledText is a part of a line written with the same style.
+type styledText struct {
+	class string
+	text  string
+}
+
+// parseANSI splits a line into the parts written with the same style, with
+// the escape sequences removed. style is the style at the start of the line,
+// it is updated to the one at its end as styles carry over to the next lines.
+func parseANSI(line string, style *ansiStyle) []styledText {
+	var parts []styledText
+	add := func(text string) {
+		if text == "" {
+			return
+		}
+		class := style.class()
+		if n := len(parts); n > 0 && parts[n-1].class == class {
+			parts[n-1].text += text
+			return
+		}
+		parts = append(parts, styledText{class: class, text: text})
+	}
+	for {
+		loc := escapeRE.FindStringSubmatchIndex(line)
+		if loc == nil {
+			break
+		}
+		add(line[:loc[0]])
+		// Only CSI ending with "m" and without private parameters are SGR.
+		if loc[4] >= 0 && line[loc[4]:loc[5]] == "m" && !strings.ContainsAny(line[loc[2]:loc[3]], "<=>?:") {
+			style.apply(line[loc[2]:loc[3]])
+		}
+		line = line[loc[1]:]
+	}
+	add(line)
+	return parts
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildlog
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseANSI(t *testing.T) {
+	cases := []struct {
+		name      string
+		lines     []string
+		want      [][]styledText
+		wantStyle ansiStyle
+	}{
+		{
+			name:      "plain",
+			lines:     []string{"hello world"},
+			want:      [][]styledText{{{text: "hello world"}}},
+			wantStyle: defaultStyle,
+		},
+		{
+			name:  "colors and reset",
+			lines: []string{"\x1b[31mFAIL\x1b[0m: \x1b[1;92mok\x1b[m done"},
+			want: [][]styledText{{
+				{class: "ansi-1", text: "FAIL"},
+				{text: ": "},
+				{class: "ansi-10 ansi-bold", text: "ok"},
+				{text: " done"},
+			}},
+			wantStyle: defaultStyle,
+		},
+		{
+			name:  "backgrounds and attributes",
+			lines: []string{"\x1b[44;3;4mnote\x1b[23;24;49m plain"},
+			want: [][]styledText{{
+				{class: "ansi-bg-4 ansi-italic ansi-underline", text: "note"},
+				{text: " plain"},
+			}},
+			wantStyle: defaultStyle,
+		},
+		{
+			name:  "styles carry over to the next lines",
+			lines: []string{"\x1b[33mwarning", "still yellow\x1b[39m"},
+			want: [][]styledText{
+				{{class: "ansi-3", text: "warning"}},
+				{{class: "ansi-3", text: "still yellow"}},
+			},
+			wantStyle: defaultStyle,
+		},
+		{
+			name:      "unfinished style",
+			lines:     []string{"\x1b[1mbold"},
+			want:      [][]styledText{{{class: "ansi-bold", text: "bold"}}},
+			wantStyle: ansiStyle{fg: -1, bg: -1, bold: true},
+		},
+		{
+			name:  "extended colors are dropped with their parameters",
+			lines: []string{"\x1b[38;5;196;1mred\x1b[38;2;0;128;255m blue"},
+			want: [][]styledText{{
+				{class: "ansi-bold", text: "red blue"},
+			}},
+			wantStyle: ansiStyle{fg: -1, bg: -1, bold: true},
+		},
+		{
+			name:      "other sequences are dropped",
+			lines:     []string{"\x1b[2K\x1b[1Gprogress\x1b[?25l \x1b]0;title\x07100%"},
+			want:      [][]styledText{{{text: "progress 100%"}}},
+			wantStyle: defaultStyle,
+		},
+		{
+			name:      "empty",
+			lines:     []string{"\x1b[0m"},
+			want:      [][]styledText{nil},
+			wantStyle: defaultStyle,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			style := defaultStyle
+			var got [][]styledText
+			for _, line := range tc.lines {
+				got = append(got, parseANSI(line, &style))
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(styledText{})); diff != "" {
+				t.Errorf("parseANSI() got unexpected diff (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantStyle, style, cmp.AllowUnexported(ansiStyle{})); diff != "" {
+				t.Errorf("parseANSI() got unexpected style (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,49 +1,5 @@
 function showElem(elem: HTMLElement): void {
   elem.className = 'shown';
-  elem.innerHTML = ansiToHTML(elem.innerHTML);
-}
-
-// given a string containing ansi formatting directives, return a new one
-// with designated regions of text marked with the appropriate color directives,
-// and with all unknown directives stripped
-function ansiToHTML(orig: string): string {
-  // Given a cmd (like "32" or "0;97"), some enclosed body text, and the original string,
-  // either return the body wrapped in an element to achieve the desired result, or the
-  // original string if nothing works.
-  function annotate(cmd: string, body: string): string {
-    const code = +(cmd.replace('0;', ''));
-    if (code === 0) {
-      // reset
-      return body;
-    } else if (code === 1) {
-      // bold
-      return `<strong>${body}</strong>`;
-    } else if (code === 3) {
-      // italic
-      return `<em>${body}</em>`;
-    } else if (30 <= code && code <= 37) {
-      // foreground color
-      return `<span class="ansi-${(code - 30)}">${body}</span>`;
-    } else if (90 <= code && code <= 97) {
-      // foreground color, bright
-      return `<span class="ansi-${(code - 90 + 8)}">${body}</span>`;
-    }
-    return body;  // fallback: don't change anything
-  }
-  // Find commands, optionally followed by a bold command, with some content, then a reset command.
-  // Unpaired commands are *not* handled here, but they're very uncommon.
-  const filtered = orig.replace(/\033\[([0-9;]*)\w(\033\[1m)?([^\033]*?)\033\[0m/g, (match: string, cmd: string, bold: string, body: string, offset: number, str: string) => {
-    if (bold !== undefined) {
-      // normal code + bold
-      return `<strong>${annotate(cmd, body)}</strong>`;
-    }
-    return annotate(cmd, body);
-  });
-  // Strip out anything left over.
-  return filtered.replace(/\033\[([0-9;]*\w)/g, (match: string, cmd: string, offset: number, str: string) => {
-    console.log('unhandled ansi code: ', cmd, "context:", filtered);
-    return '';
-  });
 }
 
 interface ArtifactRequest {
@@ -74,7 +30,7 @@ async function replaceElementWithContent(element: HTMLDivElement, top: number, b
   };
   const content = await spyglass.request(JSON.stringify(r));
   showElem(element);
-  element.outerHTML = ansiToHTML(content);
+  element.outerHTML = content;
   fixLinks(document.documentElement);
   for (const button of Array.from(document.querySelectorAll<HTMLDivElement>(".show-skipped"))) {
     if (button.classList.contains("showable")) {
@@ -140,11 +96,42 @@ async function handleShowAll(this: HTMLButtonElement) {
   }
 
   const {artifact} = this.dataset;
-  const content = await spyglass.request(JSON.stringify({artifact, offset: 0, length: -1}));
-  document.getElementById(`${artifact}-content`)!.innerHTML = `<tbody class="shown">${ansiToHTML(content)}</tbody>`;
+  const container = document.getElementById(`${artifact}-content`)!;
+  // Lines after the live offset are appended by the stream, don't load them twice.
+  let length = -1;
+  if (container.dataset.liveOffset && Number(container.dataset.liveOffset) > 0) {
+    length = Number(container.dataset.liveOffset) - 1;
+  }
+  const content = await spyglass.request(JSON.stringify({artifact, offset: 0, length}));
+  container.innerHTML = `<tbody class="shown">${content}</tbody>`;
   spyglass.contentUpdated();
 }
 
+interface TailUpdate {
+  html: string;
+  offset: number;
+  startLine: number;
+}
+
+// tailLog appends the lines the job writes to a running log until the server
+// ends the stream.
+async function tailLog(container: HTMLElement): Promise<void> {
+  const {artifact, liveOffset, liveLines} = container.dataset;
+  const r = {artifact, offset: Number(liveOffset), startLine: Number(liveLines)};
+  await spyglass.stream(JSON.stringify(r), (data: string) => {
+    const update: TailUpdate = JSON.parse(data);
+    container.insertAdjacentHTML('beforeend', update.html);
+    container.dataset.liveOffset = String(update.offset);
+    container.dataset.liveLines = String(update.startLine);
+    fixLinks(container);
+    spyglass.contentUpdated();
+  });
+  const indicator = container.parentElement!.querySelector('.live-indicator');
+  if (indicator) {
+    indicator.remove();
+  }
+}
+
 async function handleAnalyze(this: HTMLButtonElement) {
   this.disabled = true;
   this.title = "Requesting analysis...";
@@ -397,11 +384,6 @@ function clipLine(lineEl: HTMLDivElement) {
 window.addEventListener('hashchange', () => handleHash());
 
 window.addEventListener('load', () => {
-  const shown = document.getElementsByClassName("shown");
-  for (const child of Array.from(shown)) {
-    child.innerHTML = ansiToHTML(child.innerHTML);
-  }
-
   for (const button of Array.from(document.querySelectorAll<HTMLDivElement>(".show-skipped"))) {
     button.addEventListener('click', handleShowSkipped);
     button.classList.add("showable");
@@ -415,10 +397,22 @@ window.addEventListener('load', () => {
     button.addEventListener('click', handleAnalyze);
   }
 
-  for (const container of Array.from(document.querySelectorAll<HTMLElement>('.loglines'))) {
+  for (const container of Array.from(document.querySelectorAll<HTMLElement>('.loglines, .pinned-lines'))) {
     container.addEventListener('click', handleLineLink, {capture: true});
   }
   fixLinks(document.documentElement);
 
+  for (const container of Array.from(document.querySelectorAll<HTMLElement>('.loglines[data-live-offset]'))) {
+    tailLog(container).then();
+  }
+
+  // Failures are the highlighted lines, which the template marks.
+  spyglass.registerNavigation({
+    expandAll: async () => {
+      const buttons = Array.from(document.querySelectorAll<HTMLButtonElement>(".show-all-button"));
+      await Promise.all(buttons.map((button) => handleShowAll.call(button)));
+    },
+  });
+
   handleHash();
 });
@@ -19,6 +19,7 @@ package buildlog
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,9 +31,11 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	prowconfig "sigs.k8s.io/prow/pkg/config"
 	pkgio "sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/spyglass/api"
@@ -45,6 +48,12 @@ const (
 	priority        = 10
 	neighborLines   = 5 // number of "important" lines to be displayed in either direction
 	minLinesSkipped = 5
+
+	tailInterval    = 2 * time.Second  // how often live logs are checked for new lines
+	tailIdleTimeout = 30 * time.Minute // how long to wait for new lines before giving up
+	tailChunkSize   = 1024 * 1024      // maximum number of bytes read from live logs at once
+
+	maxPinnedLines = 50 // maximum number of lines pinned to the top of a log
 )
 
 var defaultHighlightLineLengthMax = 10000 // Default maximum length of a line worth highlighting
@@ -72,9 +81,44 @@ type parsedConfig struct {
 	showRawLog         bool
 	highlighter        *highlightConfig
 	highlightLengthMax int
+	// rules are the highlight rules of the repo of the job, whose lines
+	// are pinned.
+	rules []highlightRule
+	// knownFailures are the known failure patterns, whose lines are
+	// annotated.
+	knownFailures []knownFailureRule
+}
+
+// highlightRule is a compiled prowconfig.BuildLogHighlight.
+type highlightRule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// knownFailureRule is a compiled prowconfig.KnownFailurePattern.
+type knownFailureRule struct {
+	failure KnownFailure
+	re      *regexp.Regexp
+}
+
+// KnownFailure explains a line matching a known failure pattern.
+type KnownFailure struct {
+	Name        string
+	Explanation string
+	Action      string
+	Link        string
+}
+
+// Tooltip returns the explanation of the failure shown when hovering the line.
+func (f KnownFailure) Tooltip() string {
+	tooltip := f.Name + ": " + f.Explanation
+	if f.Action != "" {
+		tooltip += "\nSuggested action: " + f.Action
+	}
+	return tooltip
 }
 
-var _ api.Lens = Lens{}
+var _ api.StreamingLens = Lens{}
 
 // Lens implements the build lens.
 type Lens struct{}
@@ -101,10 +145,33 @@ func init() {
 	lenses.RegisterLens(Lens{})
 }
 
-// SubLine represents an substring within a LogLine. It it used so error terms can be highlighted.
+// SubLine represents an substring within a LogLine. It it used so error terms can be highlighted,
+// and so the colors the line was written with can be rendered.
 type SubLine struct {
 	Highlighted bool
 	Text        string
+	// Class holds the CSS classes of the ANSI style of the text.
+	Class string
+}
+
+// Classes returns the CSS classes of the substring.
+func (s SubLine) Classes() string {
+	if !s.Highlighted {
+		return s.Class
+	}
+	if s.Class == "" {
+		return "match-highlighted"
+	}
+	return "match-highlighted " + s.Class
+}
+
+// text returns the text of a line, without the escape sequences.
+func (l LogLine) text() string {
+	var b strings.Builder
+	for _, s := range l.SubLines {
+		b.WriteString(s.Text)
+	}
+	return b.String()
 }
 
 // LogLine represents a line displayed in the LogArtifactView.
@@ -117,6 +184,8 @@ type LogLine struct {
 	SubLines     []SubLine
 	Focused      bool
 	Clip         bool
+	// KnownFailure is set if the line matches a known failure pattern.
+	KnownFailure *KnownFailure
 }
 
 // LineGroup holds multiple lines that can be collapsed/expanded as a block
@@ -161,6 +230,22 @@ type LogArtifactView struct {
 	ShowRawLog   bool
 	CanSave      bool
 	CanAnalyze   bool
+	// Live is set if the log is still being written to. LiveOffset and
+	// LiveLines are the number of bytes and lines shown, from where new
+	// lines are streamed.
+	Live       bool
+	LiveOffset int64
+	LiveLines  int
+	// Pinned are the lines matching the highlight rules of the repo.
+	Pinned []PinnedLine
+}
+
+// PinnedLine is a line shown at the top of a log, as it matches a highlight
+// rule of the repo of the job.
+type PinnedLine struct {
+	Name   string
+	Number int
+	Text   string
 }
 
 // buildLogsView holds each log file view
@@ -207,18 +292,134 @@ func getConfig(rawConfig json.RawMessage) parsedConfig {
 	return conf
 }
 
+// withSpyglassRules adds the known failure patterns and the highlight rules of
+// the repo of the job to the config, the latter if the prowjob.json of the job
+// is among the artifacts. It returns the other artifacts, which are the logs.
+func withSpyglassRules(conf parsedConfig, artifacts []api.Artifact, spyglassConfig prowconfig.Spyglass) (parsedConfig, []api.Artifact) {
+	var logs []api.Artifact
+	var org, repo string
+	for _, a := range artifacts {
+		if a.JobPath() != "prowjob.json" {
+			logs = append(logs, a)
+			continue
+		}
+		content, err := a.ReadAll()
+		if err != nil {
+			logrus.WithError(err).Warn("Couldn't read a prowjob file that should exist.")
+			continue
+		}
+		var pj prowapi.ProwJob
+		if err := json.Unmarshal(content, &pj); err != nil {
+			logrus.WithError(err).Info("Error unmarshalling prowjob")
+			continue
+		}
+		refs := pj.Spec.Refs
+		if refs == nil && len(pj.Spec.ExtraRefs) > 0 {
+			refs = &pj.Spec.ExtraRefs[0]
+		}
+		if refs != nil {
+			org, repo = refs.Org, refs.Repo
+		}
+	}
+
+	regexes := []string{conf.highlightRegex.String()}
+	for _, highlight := range spyglassConfig.GetBuildLogHighlights(org, repo) {
+		re, err := regexp.Compile(highlight.Regex)
+		if err != nil {
+			logrus.WithError(err).Warnf("Couldn't compile build log highlight %q", highlight.Name)
+			continue
+		}
+		conf.rules = append(conf.rules, highlightRule{name: highlight.Name, re: re})
+		regexes = append(regexes, highlight.Regex)
+	}
+	for _, pattern := range spyglassConfig.KnownFailurePatterns {
+		re, err := regexp.Compile(pattern.Regex)
+		if err != nil {
+			logrus.WithError(err).Warnf("Couldn't compile known failure pattern %q", pattern.Name)
+			continue
+		}
+		conf.knownFailures = append(conf.knownFailures, knownFailureRule{
+			failure: KnownFailure{Name: pattern.Name, Explanation: pattern.Explanation, Action: pattern.Action, Link: pattern.Link},
+			re:      re,
+		})
+		regexes = append(regexes, pattern.Regex)
+	}
+	if len(conf.rules) == 0 && len(conf.knownFailures) == 0 {
+		return conf, logs
+	}
+	re, err := regexp.Compile("(?:" + strings.Join(regexes, ")|(?:") + ")")
+	if err != nil {
+		logrus.WithError(err).Warnf("Couldn't compile %q", regexes)
+		return conf, logs
+	}
+	conf.highlightRegex = re
+	if conf.highlightLengthMax == 0 {
+		conf.highlightLengthMax = defaultHighlightLineLengthMax
+	}
+	return conf, logs
+}
+
+// logLines highlights the lines and annotates the known failures among them.
+func (conf parsedConfig) logLines(lines []string, startLine int, artifact *string) []LogLine {
+	return annotateKnownFailures(conf.knownFailures, highlightLines(lines, startLine, artifact, conf.highlightRegex, conf.highlightLengthMax))
+}
+
+// annotateKnownFailures annotates the highlighted lines matching a known
+// failure pattern with the first pattern they match.
+func annotateKnownFailures(rules []knownFailureRule, logLines []LogLine) []LogLine {
+	if len(rules) == 0 {
+		return logLines
+	}
+	for i, line := range logLines {
+		if !line.Highlighted {
+			continue
+		}
+		text := line.text()
+		for _, rule := range rules {
+			if rule.re.MatchString(text) {
+				failure := rule.failure
+				logLines[i].KnownFailure = &failure
+				break
+			}
+		}
+	}
+	return logLines
+}
+
+// pinnedLines returns the lines matching the highlight rules of the repo.
+func pinnedLines(rules []highlightRule, logLines []LogLine) []PinnedLine {
+	var pinned []PinnedLine
+	for _, line := range logLines {
+		if !line.Highlighted {
+			continue
+		}
+		text := line.text()
+		for _, rule := range rules {
+			if !rule.re.MatchString(text) {
+				continue
+			}
+			pinned = append(pinned, PinnedLine{Name: rule.name, Number: line.Number, Text: text})
+			if len(pinned) == maxPinnedLines {
+				return pinned
+			}
+			break
+		}
+	}
+	return pinned
+}
+
 // Body returns the <body> content for a build log (or multiple build logs)
 func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig prowconfig.Spyglass) string {
 	buildLogsView := buildLogsView{
 		LogViews: []LogArtifactView{},
 	}
 
-	conf := getConfig(rawConfig)
+	conf, artifacts := withSpyglassRules(getConfig(rawConfig), artifacts, spyglassConfig)
 	// Read log artifacts and construct template structs
 	for _, a := range artifacts {
 		av := LogArtifactView{
 			ArtifactName: a.JobPath(),
-			ArtifactLink: a.CanonicalLink(),
+			ArtifactLink: lenses.RawArtifactLink(a, spyglassConfig),
 			ShowRawLog:   conf.showRawLog,
 		}
 		lines, err := logLinesAll(a)
@@ -226,6 +427,15 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 			logrus.WithError(err).Info("Error reading log.")
 			continue
 		}
+		if live, ok := a.(api.LiveArtifact); ok && live.Live() {
+			// The last line might not be complete yet, it is streamed once it is.
+			lines = lines[:len(lines)-1]
+			av.Live = true
+			av.LiveLines = len(lines)
+			for _, line := range lines {
+				av.LiveOffset += int64(len(line) + 1)
+			}
+		}
 		artifact := av.ArtifactName
 		meta, _ := a.Metadata()
 		start, end := -1, -1
@@ -255,7 +465,9 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 				start, end = resp.Min, resp.Max
 			}
 		}
-		av.LineGroups = groupLines(&artifact, start, end, highlightLines(lines, 0, &artifact, conf.highlightRegex, conf.highlightLengthMax)...)
+		logLines := conf.logLines(lines, 0, &artifact)
+		av.Pinned = pinnedLines(conf.rules, logLines)
+		av.LineGroups = groupLines(&artifact, start, end, logLines...)
 		av.ViewAll = true
 		av.CanSave = canSave(a.CanonicalLink())
 		av.CanAnalyze = analyze
@@ -300,7 +512,98 @@ func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data str
 	if request.SaveEnd != nil {
 		return storeHighlightedLines(&request, artifact)
 	}
-	return loadLines(&request, artifact, resourceDir, rawConfig)
+	conf, _ := withSpyglassRules(getConfig(rawConfig), artifacts, spyglassConfig)
+	return loadLines(&request, artifact, resourceDir, conf)
+}
+
+// tailRequest asks for the lines that are appended to a live log from Offset
+// on. StartLine is the number of lines before Offset.
+type tailRequest struct {
+	Artifact  string `json:"artifact"`
+	Offset    int64  `json:"offset"`
+	StartLine int    `json:"startLine"`
+}
+
+// tailUpdate holds lines that were appended to a live log, along with the
+// position in the log after them.
+type tailUpdate struct {
+	HTML      string `json:"html"`
+	Offset    int64  `json:"offset"`
+	StartLine int    `json:"startLine"`
+}
+
+// Stream sends the lines that are appended to a live log, until it stops
+// growing or can no longer be read because the job finished.
+func (lens Lens) Stream(ctx context.Context, artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig prowconfig.Spyglass, send func(string) error) error {
+	var request tailRequest
+	if err := json.Unmarshal([]byte(data), &request); err != nil {
+		return errors.New(failedUnmarshal)
+	}
+	artifact, ok := artifactByName(artifacts, request.Artifact)
+	if !ok {
+		return fmt.Errorf(missingArtifact, request.Artifact)
+	}
+	conf, _ := withSpyglassRules(getConfig(rawConfig), artifacts, spyglassConfig)
+	update := tailUpdate{Offset: request.Offset, StartLine: request.StartLine}
+	lastChange := time.Now()
+	ticker := time.NewTicker(tailInterval)
+	defer ticker.Stop()
+	for time.Since(lastChange) < tailIdleTimeout {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		lines, read, err := tailLines(artifact, update.Offset)
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", request.Artifact).Debug("Stopped tailing log.")
+			return nil
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		lastChange = time.Now()
+		logLines := conf.logLines(lines, update.StartLine, &request.Artifact)
+		update.HTML = executeTemplate(resourceDir, "line groups", []LineGroup{{LogLines: logLines, ArtifactName: &request.Artifact}})
+		update.Offset += read
+		update.StartLine += len(lines)
+		buf, err := json.Marshal(update)
+		if err != nil {
+			return err
+		}
+		if err := send(string(buf)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tailLines returns the complete lines of the artifact from offset on, along
+// with the number of bytes they take up.
+func tailLines(artifact api.Artifact, offset int64) ([]string, int64, error) {
+	size, err := artifact.Size()
+	if err != nil {
+		return nil, 0, err
+	}
+	if size <= offset {
+		return nil, 0, nil
+	}
+	b := make([]byte, min(size-offset, tailChunkSize))
+	n, err := artifact.ReadAt(b, offset)
+	if err != nil && err != io.EOF {
+		return nil, 0, fmt.Errorf("couldn't read new lines: %w", err)
+	}
+	b = b[:n]
+	end := bytes.LastIndexByte(b, '\n')
+	if end == -1 {
+		if len(b) < tailChunkSize {
+			// The line is not complete yet.
+			return nil, 0, nil
+		}
+		// Break lines that do not even fit a chunk.
+		return []string{string(b)}, int64(len(b)), nil
+	}
+	return strings.Split(string(b[:end]), "\n"), int64(end + 1), nil
 }
 
 type highlightRequest struct {
@@ -397,7 +700,7 @@ func storeHighlightedLines(request *callbackRequest, artifact api.Artifact) stri
 	return ""
 }
 
-func loadLines(request *callbackRequest, artifact api.Artifact, resourceDir string, rawConfig json.RawMessage) string {
+func loadLines(request *callbackRequest, artifact api.Artifact, resourceDir string, conf parsedConfig) string {
 
 	var err error
 	var lines []string
@@ -433,9 +736,8 @@ func loadLines(request *callbackRequest, artifact api.Artifact, resourceDir stri
 		skipFirst = true
 	}
 	var skipGroup *LineGroup
-	conf := getConfig(rawConfig)
 	if len(skipLines) > 0 {
-		logLines := highlightLines(skipLines, skipRequest.StartLine, &request.Artifact, conf.highlightRegex, conf.highlightLengthMax)
+		logLines := conf.logLines(skipLines, skipRequest.StartLine, &request.Artifact)
 		skipGroup = &LineGroup{
 			Skip:         true,
 			Start:        skipRequest.StartLine,
@@ -452,7 +754,7 @@ func loadLines(request *callbackRequest, artifact api.Artifact, resourceDir stri
 		groups = append(groups, skipGroup)
 		skipGroup = nil
 	}
-	logLines := highlightLines(lines, request.StartLine, &request.Artifact, conf.highlightRegex, conf.highlightLengthMax)
+	logLines := conf.logLines(lines, request.StartLine, &request.Artifact)
 	groups = append(groups, &LineGroup{
 		LogLines:     logLines,
 		ArtifactName: &request.Artifact,
@@ -502,24 +804,34 @@ func logLines(artifact api.Artifact, offset, length int64) ([]string, error) {
 func highlightLines(lines []string, startLine int, artifact *string, highlightRegex *regexp.Regexp, maxLen int) []LogLine {
 	// mark highlighted lines
 	logLines := make([]LogLine, 0, len(lines))
-	for i, text := range lines {
-		length := len(text)
+	style := defaultStyle
+	for i, line := range lines {
+		length := len(line)
+		styled := parseANSI(line, &style)
+		var text string
+		for _, part := range styled {
+			text += part.text
+		}
 		subLines := []SubLine{}
+		var highlighted bool
+		var offset int
 		if length <= maxLen {
 			loc := highlightRegex.FindStringIndex(text)
 			for loc != nil {
-				subLines = append(subLines, SubLine{false, text[:loc[0]]})
-				subLines = append(subLines, SubLine{true, text[loc[0]:loc[1]]})
+				highlighted = true
+				subLines = appendStyled(subLines, styled, offset, false, text[:loc[0]])
+				subLines = appendStyled(subLines, styled, offset+loc[0], true, text[loc[0]:loc[1]])
+				offset += loc[1]
 				text = text[loc[1]:]
 				loc = highlightRegex.FindStringIndex(text)
 			}
 		}
-		subLines = append(subLines, SubLine{false, text})
+		subLines = appendStyled(subLines, styled, offset, false, text)
 		logLines = append(logLines, LogLine{
 			Length:       length + 1, // counting the "\n"
 			SubLines:     subLines,
 			Number:       startLine + i + 1,
-			Highlighted:  len(subLines) > 1,
+			Highlighted:  highlighted,
 			ArtifactName: artifact,
 			Skip:         true,
 		})
@@ -527,6 +839,27 @@ func highlightLines(lines []string, startLine int, artifact *string, highlightRe
 	return logLines
 }
 
+// appendStyled appends the text starting at offset of a line as substrings
+// written with the same style.
+func appendStyled(subLines []SubLine, styled []styledText, offset int, highlighted bool, text string) []SubLine {
+	var class string
+	for _, part := range styled {
+		if offset >= len(part.text) {
+			offset -= len(part.text)
+			continue
+		}
+		class = part.class
+		if n := len(part.text) - offset; n < len(text) {
+			subLines = append(subLines, SubLine{Highlighted: highlighted, Text: text[:n], Class: class})
+			text = text[n:]
+			offset = 0
+			continue
+		}
+		break
+	}
+	return append(subLines, SubLine{Highlighted: highlighted, Text: text, Class: class})
+}
+
 // breaks lines into important/unimportant groups
 func groupLines(artifact *string, start, end int, logLines ...LogLine) []LineGroup {
 	// show highlighted lines and their neighboring lines
@@ -22,6 +22,7 @@ import (
 	stdio "io"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -1149,3 +1150,197 @@ func BenchmarkHighlightLines(b *testing.B) {
 		_ = highlightLines(lorem, 0, &art, defaultErrRE, defaultHighlightLineLengthMax)
 	})
 }
+
+func TestHighlightLinesANSI(t *testing.T) {
+	art := "build-log.txt"
+	lines := []string{
+		"\x1b[31mE1016 12:00:00.000] timed out\x1b[0m waiting",
+		"\x1b[32mok\x1b[0m",
+	}
+	want := []LogLine{
+		{
+			ArtifactName: &art,
+			Number:       1,
+			Length:       len(lines[0]) + 1,
+			Highlighted:  true,
+			Skip:         true,
+			SubLines: []SubLine{
+				{Text: "E1016 12:00:00.000] ", Class: "ansi-1"},
+				{Highlighted: true, Text: "timed out", Class: "ansi-1"},
+				{Text: " waiting"},
+			},
+		},
+		{
+			ArtifactName: &art,
+			Number:       2,
+			Length:       len(lines[1]) + 1,
+			Skip:         true,
+			SubLines: []SubLine{
+				{Text: "ok", Class: "ansi-2"},
+			},
+		},
+	}
+	got := highlightLines(lines, 0, &art, regexp.MustCompile("timed out"), defaultHighlightLineLengthMax)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("highlightLines() got unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestRepoRules(t *testing.T) {
+	spyglassConfig := prowconfig.Spyglass{BuildLogHighlights: map[string][]prowconfig.BuildLogHighlight{
+		"org":      {{Name: "timeout", Regex: `deadline exceeded`}},
+		"org/repo": {{Name: "oom", Regex: `OOMKilled`}},
+	}}
+	log := &fake.Artifact{
+		Path:    "build-log.txt",
+		Content: []byte("start\nOOMKilled: pod\nfine\n\x1b[31mcontext deadline exceeded\x1b[0m\nFAIL"),
+	}
+	cases := []struct {
+		name    string
+		prowJob string
+		want    []PinnedLine
+	}{
+		{
+			name:    "rules of the org and repo of the job",
+			prowJob: `{"spec": {"refs": {"org": "org", "repo": "repo"}}}`,
+			want: []PinnedLine{
+				{Name: "oom", Number: 2, Text: "OOMKilled: pod"},
+				{Name: "timeout", Number: 4, Text: "context deadline exceeded"},
+			},
+		},
+		{
+			name:    "rules of the org of the extra refs of periodics",
+			prowJob: `{"spec": {"extra_refs": [{"org": "org", "repo": "other"}]}}`,
+			want: []PinnedLine{
+				{Name: "timeout", Number: 4, Text: "context deadline exceeded"},
+			},
+		},
+		{
+			name:    "no rules for other orgs",
+			prowJob: `{"spec": {"refs": {"org": "other", "repo": "repo"}}}`,
+		},
+		{
+			name: "no rules without a prowjob",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			artifacts := []api.Artifact{log}
+			if tc.prowJob != "" {
+				artifacts = append(artifacts, &fake.Artifact{Path: "prowjob.json", Content: []byte(tc.prowJob)})
+			}
+			conf, logs := withSpyglassRules(getConfig(nil), artifacts, spyglassConfig)
+			if diff := cmp.Diff([]api.Artifact{log}, logs); diff != "" {
+				t.Errorf("withSpyglassRules() got unexpected logs (-want +got):\n%s", diff)
+			}
+			lines, err := logLinesAll(log)
+			if err != nil {
+				t.Fatalf("logLinesAll() failed: %v", err)
+			}
+			got := pinnedLines(conf.rules, highlightLines(lines, 0, &log.Path, conf.highlightRegex, conf.highlightLengthMax))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("pinnedLines() got unexpected diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKnownFailures(t *testing.T) {
+	spyglassConfig := prowconfig.Spyglass{KnownFailurePatterns: []prowconfig.KnownFailurePattern{
+		{Name: "image-pull", Regex: `ErrImagePull`, Explanation: "The image could not be pulled.", Action: "Retest.", Link: "https://example.com/image-pull"},
+		{Name: "dns", Regex: `no such host`, Explanation: "A DNS lookup failed."},
+	}}
+	log := &fake.Artifact{
+		Path:    "build-log.txt",
+		Content: []byte("start\nFailed: ErrImagePull\nfine\ndial tcp: lookup registry: no such host\nend"),
+	}
+	conf, _ := withSpyglassRules(getConfig(nil), []api.Artifact{log}, spyglassConfig)
+	lines, err := logLinesAll(log)
+	if err != nil {
+		t.Fatalf("logLinesAll() failed: %v", err)
+	}
+	got := map[int]KnownFailure{}
+	for _, line := range conf.logLines(lines, 0, &log.Path) {
+		if line.KnownFailure != nil {
+			if !line.Highlighted {
+				t.Errorf("line %d has a known failure but isn't highlighted", line.Number)
+			}
+			got[line.Number] = *line.KnownFailure
+		}
+	}
+	want := map[int]KnownFailure{
+		2: {Name: "image-pull", Explanation: "The image could not be pulled.", Action: "Retest.", Link: "https://example.com/image-pull"},
+		4: {Name: "dns", Explanation: "A DNS lookup failed."},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("logLines() got unexpected known failures (-want +got):\n%s", diff)
+	}
+	if got, want := want[2].Tooltip(), "image-pull: The image could not be pulled.\nSuggested action: Retest."; got != want {
+		t.Errorf("Tooltip() = %q, want %q", got, want)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	long := strings.Repeat("x", tailChunkSize+10)
+	cases := []struct {
+		name       string
+		content    string
+		offset     int64
+		want       []string
+		wantOffset int64
+	}{
+		{
+			name:    "empty",
+			content: "",
+		},
+		{
+			name:    "partial line only",
+			content: "hello",
+		},
+		{
+			name:       "complete lines",
+			content:    "hello\nworld\n",
+			want:       []string{"hello", "world"},
+			wantOffset: 12,
+		},
+		{
+			name:       "trailing partial line is held back",
+			content:    "hello\nworld\nagai",
+			want:       []string{"hello", "world"},
+			wantOffset: 12,
+		},
+		{
+			name:       "starts at offset",
+			content:    "hello\nworld\n",
+			offset:     6,
+			want:       []string{"world"},
+			wantOffset: 6,
+		},
+		{
+			name:    "nothing new",
+			content: "hello\n",
+			offset:  6,
+		},
+		{
+			name:       "overlong line is broken at the chunk size",
+			content:    long,
+			want:       []string{long[:tailChunkSize]},
+			wantOffset: tailChunkSize,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			artifact := &fake.Artifact{Path: "build-log.txt", Content: []byte(tc.content)}
+			got, gotOffset, err := tailLines(artifact, tc.offset)
+			if err != nil {
+				t.Fatalf("tailLines() failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("tailLines() got unexpected diff (-want +got):\n%s", diff)
+			}
+			if gotOffset != tc.wantOffset {
+				t.Errorf("tailLines() offset = %d, want %d", gotOffset, tc.wantOffset)
+			}
+		})
+	}
+}
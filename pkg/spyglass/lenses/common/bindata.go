@@ -67,7 +67,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _staticSpyglassLensHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x92\x4f\x8b\xdb\x30\x10\xc5\xef\xf9\x14\xaa\xae\x6d\x24\x97\x16\x5a\x82\xed\x42\xfa\x87\x16\x52\x5a\xd2\xe4\xd0\x53\x90\xa5\x89\xad\x8d\x2c\x19\xcd\xec\xc1\x18\x7f\xf7\x45\xb6\x97\xdd\x25\x0b\xbb\x27\xc3\xf3\xfb\xbd\x79\x92\x26\x7f\xf3\xed\xcf\xd7\xc3\xff\xbf\xdf\x59\x43\xad\x2b\x57\x79\xfa\x30\xa7\x7c\x5d\x70\xf0\x3c\x09\xa0\x4c\xb9\x62\x2c\x6f\x81\x14\xd3\x8d\x8a\x08\x54\xf0\xe3\xe1\xc7\xfa\x33\x9f\x7e\x90\x25\x07\xe5\xbf\xae\xaf\x9d\x42\x64\x3b\xf0\xb8\x61\xc3\x20\x0e\x49\x1f\xc7\x5c\xce\x86\x64\xad\x14\x02\x6b\x22\x9c\x0b\x3e\x0c\x62\xab\x10\x8e\xfb\xdd\x38\x72\x46\x2a\xd6\x29\xf6\xd4\xa9\x08\x9e\xe6\x60\x67\xfd\x85\x45\x70\x05\x47\xea\x1d\x60\x03\x40\x7c\xc1\x1b\xa2\x0e\x37\x52\xea\x60\x40\xd4\x40\xad\x71\xc2\x06\xf9\x5e\x7c\x10\x99\x6c\x15\x41\xb4\xca\x09\xeb\x8d\xad\xc3\xba\xb3\xfe\x22\x5a\xeb\x85\x46\x7c\x14\xfd\x34\xe9\x1c\x3c\xa1\xa8\x43\xa8\x1d\xa8\xce\xa2\xd0\xa1\x95\x1a\xf1\xcb\x59\xb5\xd6\xf5\xc5\x3e\x54\x81\xc2\xe6\x63\x96\xbd\xfb\x94\x65\xfc\xaa\xd8\xab\x2b\x3f\x3b\xc8\xea\xe0\xef\x27\xfd\x5e\xea\xbf\xfd\xa5\x83\xc7\x97\x83\x25\x92\x22\xab\x25\x2e\x4f\x20\x1d\x78\x7c\x38\x2b\xea\x68\x3b\x62\x18\xf5\xb5\xf5\x94\xac\xa7\xea\xd6\x1b\x07\xd3\x0d\xdd\x20\x2f\x73\x39\x23\x89\x1e\x06\xf1\x13\x94\x19\xc7\x55\x2e\xe7\x55\xc8\xab\x60\x7a\xa6\x13\x5d\xf0\x84\xaf\x93\xc0\x17\xf3\x36\x98\x7e\x32\x27\xb1\x4c\xd0\xb4\x57\x77\x01\x00\x00\xff\xff\x57\x0c\x6e\x14\x68\x02\x00\x00")
+var _staticSpyglassLensHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x53\x4d\x6b\xdc\x3c\x10\xbe\xe7\x57\xe8\xd5\xf5\xcd\x4a\x5b\x5a\x68\x59\xbc\x2e\xa4\x4d\x68\x21\xfd\x20\x4d\x0e\x3d\x05\xad\x35\xb6\x95\xc8\x92\xd1\xcc\xa6\x2c\x42\xff\xbd\x8c\xed\x25\x09\x29\xb4\x27\x49\xb3\xcf\xc7\xcc\xec\xe3\xea\xbf\x8f\xdf\x3e\x5c\xff\xfc\x7e\x2e\x7a\x1a\x7c\x7d\x52\xf1\x21\xbc\x09\xdd\x56\x42\x90\x5c\x00\x63\xeb\x13\x21\xaa\x01\xc8\x88\xa6\x37\x09\x81\xb6\xf2\xe6\xfa\x62\xf5\x4e\x4e\x3f\x90\x23\x0f\xf5\x8f\xf1\xd0\x79\x83\x28\x2e\x21\xe0\x46\xe4\xac\xae\xb9\x5e\x4a\xa5\x67\x00\x43\x77\x06\x41\xf4\x09\xda\xad\xcc\x59\x9d\x19\x84\x9b\xab\xcb\x52\xa4\x20\x93\x3a\x96\xbd\x1d\x4d\x82\x40\xb3\xb0\x77\xe1\x5e\x24\xf0\x5b\x89\x74\xf0\x80\x3d\x00\xc9\x85\xde\x13\x8d\xb8\xd1\xba\x89\x16\x54\x07\x34\x58\xaf\x5c\xd4\xaf\xd4\x6b\xb5\xd6\x83\x21\x48\xce\x78\xe5\x82\x75\x5d\x5c\x8d\x2e\xdc\xab\xc1\x05\xd5\x20\x3e\x91\x7e\xae\xd4\xc6\x40\xa8\xba\x18\x3b\x0f\x66\x74\xa8\x9a\x38\xe8\x06\xf1\x7d\x6b\x06\xe7\x0f\xdb\xab\xb8\x8b\x14\x37\x6f\xd6\xeb\xd3\xb7\xeb\xb5\x7c\xd1\xd8\x3f\xb7\xfc\x47\x23\xd7\xc4\x70\x74\xfa\xb2\xb4\xff\xff\xe7\x26\x06\xfc\xbb\xb0\x46\x32\xe4\x1a\x8d\xcb\x5f\xa0\x3d\x04\x7c\x9c\x15\x9b\xe4\x46\x12\x98\x9a\x97\xd0\x5b\x86\xde\xee\xf6\xc1\x7a\x98\x36\x74\x87\xb2\xae\xf4\x4c\x61\x76\xce\xea\x13\x18\x5b\xca\x49\xa5\xe7\x28\x54\xbb\x68\x0f\xa2\x61\xf6\x56\x32\x7d\xc5\x05\x39\x83\x09\x86\xd1\x1b\x02\x21\x21\xa5\x98\x50\x0a\x75\x3e\x5d\x4a\x99\xc5\xce\xa2\x3d\x4c\x62\x4c\xaa\x59\x74\xca\x5d\xce\x16\x5a\x17\x1e\x79\xa5\xe4\xec\x5a\xa1\x18\x6b\xdd\xc3\x33\xbf\x05\x32\x0d\x37\xd6\x17\xc6\x79\xb0\x82\xa2\x68\x81\x9a\x5e\x60\x1c\x40\xc4\x56\x50\x0f\xc2\x24\x72\xad\x69\x08\xe7\x82\x43\xc1\x02\xa7\xc2\x91\xc0\x3e\xfe\xc2\x09\x14\xa9\x87\x84\x9b\x4a\x8f\x93\xe2\xde\xf3\xc1\xcd\x26\x13\x3a\xe0\x16\x2a\xef\xea\x8a\x93\x56\xe7\xac\xbe\x9a\x61\x4a\xf5\xf2\xe6\x26\x39\xc8\xa5\x1c\x4d\xa7\x90\xef\xf6\xce\xdb\x9c\x21\xd8\x52\xa6\xaf\xe1\x3c\x25\x66\x79\x57\x2f\x55\xf6\xd2\xb3\x59\xb5\xdb\x13\xc5\x70\x1c\x72\xb0\x7e\xb5\x54\xf8\x7a\x87\x4f\x5f\xf3\x75\xb5\x4a\xc6\x21\xd8\x69\x9e\x55\x02\x4a\x07\x59\x5f\xf1\x51\xe9\x19\xc1\xbb\xb5\xee\x81\x57\x3b\xd9\x1d\x5d\x7f\x07\x00\x00\xff\xff\xae\x83\x51\x84\xf2\x03\x00\x00")
 
 func staticSpyglassLensHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -82,7 +82,7 @@ func staticSpyglassLensHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "static/spyglass-lens.html", size: 616, mode: os.FileMode(436), modTime: time.Unix(1582751428, 0)}
+	info := bindataFileInfo{name: "static/spyglass-lens.html", size: 1010, mode: os.FileMode(436), modTime: time.Unix(1792234877, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -24,15 +24,17 @@ import (
 	"html/template"
 	"io"
 	"net/http"
+	"path"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
-	"k8s.io/apimachinery/pkg/util/sets"
 
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
+	pkgio "sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/io/providers"
 	"sigs.k8s.io/prow/pkg/spyglass/api"
 )
@@ -43,42 +45,133 @@ var buildLogRegex = regexp.MustCompile(`^(?:[^/]*-)?build-log\.txt$`)
 type LensWithConfiguration struct {
 	Config LensOpt
 	Lens   api.Lens
+	// LensV2 is a version 2 lens, which is served instead of Lens if set.
+	LensV2 api.LensV2
+	// Client is the client of a lens served over gRPC, which is served
+	// instead of Lens if set.
+	Client *LensV2Client
 }
 
+// LensAuthorizer decides whether the viewer of a request may be shown a lens
+// that restricts its viewers. Deck forwards the requests of viewers to the
+// lens server along with their cookies, which identify them.
+type LensAuthorizer interface {
+	// AuthorizeLens returns nil if the viewer of the request may be shown
+	// the lens, and otherwise why not and the HTTP status to deny the
+	// request with.
+	AuthorizeLens(r *http.Request, lens config.LensFileConfig) (int, error)
+}
+
+// LensesForConfig returns the lenses the lens server serves for a config.
+type LensesForConfig func(*config.Config) ([]LensWithConfiguration, error)
+
+// NewLensServer returns a server serving lenses, which fetches the artifacts
+// of jobs with the fetcher of the storage provider they were uploaded to.
+// Requests for lenses that restrict their viewers are denied unless the
+// authorizer allows them. The server follows the config, serving the lenses
+// that lenses returns for the current one.
 func NewLensServer(
 	listenAddress string,
 	pjFetcher ProwJobFetcher,
-	storageArtifactFetcher ArtifactFetcher,
+	storageArtifactFetchers ArtifactFetchers,
 	podLogArtifactFetcher ArtifactFetcher,
 	cfg config.Getter,
-	lenses []LensWithConfiguration,
+	authorizer LensAuthorizer,
+	lenses LensesForConfig,
 ) (*http.Server, error) {
+	mux := &lensMux{
+		lenses: lenses,
+		opts: lensHandlerOpts{
+			PJFetcher:              pjFetcher,
+			StorageArtifactFetcher: storageArtifactFetchers,
+			PodLogArtifactFetcher:  podLogArtifactFetcher,
+			ConfigGetter:           cfg,
+			Authorizer:             authorizer,
+		},
+	}
+	if err := mux.load(cfg()); err != nil {
+		return nil, err
+	}
+	return &http.Server{Addr: listenAddress, Handler: mux}, nil
+}
 
-	mux := http.NewServeMux()
+// lensMux routes the requests of lenses to their handlers. When the config
+// changes, it serves the lenses of the new one, keeping the handlers of the
+// lenses that did not change along with their limits.
+type lensMux struct {
+	lenses LensesForConfig
+	opts   lensHandlerOpts
+
+	lock sync.Mutex
+	// loaded is the config that the handlers were loaded for.
+	loaded   *config.Config
+	handlers map[string]lensMuxEntry
+}
+
+type lensMuxEntry struct {
+	lens    LensWithConfiguration
+	handler http.Handler
+}
 
-	seenLens := sets.Set[string]{}
+// load loads the handlers of the lenses of a config.
+func (m *lensMux) load(c *config.Config) error {
+	lenses, err := m.lenses(c)
+	if err != nil {
+		return err
+	}
+	handlers := map[string]lensMuxEntry{}
 	for _, lens := range lenses {
-		if seenLens.Has(lens.Config.LensName) {
-			return nil, fmt.Errorf("duplicate lens named %q", lens.Config.LensName)
+		path := DynamicPathForLens(lens.Config.LensName)
+		if _, seen := handlers[path]; seen {
+			return fmt.Errorf("duplicate lens named %q", lens.Config.LensName)
+		}
+		if old, ok := m.handlers[path]; ok && old.lens.Config == lens.Config && old.lens.Client == lens.Client && old.lens.LensV2 == lens.LensV2 {
+			handlers[path] = old
+			continue
 		}
-		seenLens.Insert(lens.Config.LensName)
 
 		logrus.WithField("Lens", lens.Config.LensName).Info("Adding handler for lens")
-		opt := lensHandlerOpts{
-			PJFetcher:              pjFetcher,
-			StorageArtifactFetcher: storageArtifactFetcher,
-			PodLogArtifactFetcher:  podLogArtifactFetcher,
-			ConfigGetter:           cfg,
-			LensOpt:                lens.Config,
+		opt := m.opts
+		opt.LensOpt = lens.Config
+		entry := lensMuxEntry{lens: lens}
+		if lens.Client != nil {
+			entry.handler = newLensV2Handler(lens.Client, opt)
+		} else if lens.LensV2 != nil {
+			entry.handler = newLocalLensHandler(lens.LensV2, opt)
+		} else {
+			entry.handler = newLensHandler(lens.Lens, opt)
 		}
-		mux.Handle(DynamicPathForLens(lens.Config.LensName), newLensHandler(lens.Lens, opt))
+		handlers[path] = entry
 	}
-	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	m.loaded = c
+	m.handlers = handlers
+	return nil
+}
+
+// handler returns the handler of a path for the current config, loading the
+// handlers again if the config changed since they were loaded.
+func (m *lensMux) handler(path string) (http.Handler, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if c := m.opts.ConfigGetter(); c != m.loaded {
+		if err := m.load(c); err != nil {
+			logrus.WithError(err).Error("Failed to load the lenses of the new config, serving the previous ones.")
+			// Only try again once the config changes.
+			m.loaded = c
+		}
+	}
+	entry, ok := m.handlers[path]
+	return entry.handler, ok
+}
+
+func (m *lensMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, ok := m.handler(r.URL.Path)
+	if !ok {
 		logrus.WithField("path", r.URL.Path).Error("LensServer got request on unhandled path")
 		http.NotFound(w, r)
-	}))
-
-	return &http.Server{Addr: listenAddress, Handler: mux}, nil
+		return
+	}
+	handler.ServeHTTP(w, r)
 }
 
 type LensOpt struct {
@@ -92,10 +185,26 @@ type lensHandlerOpts struct {
 	StorageArtifactFetcher ArtifactFetcher
 	PodLogArtifactFetcher  ArtifactFetcher
 	ConfigGetter           config.Getter
+	Authorizer             LensAuthorizer
 	LensOpt
 }
 
 func newLensHandler(lens api.Lens, opts lensHandlerOpts) http.HandlerFunc {
+	return newLocalLensHandler(api.AdaptLens(lens), opts)
+}
+
+// newLocalLensHandler returns the handler of a lens served by Deck itself,
+// as opposed to over gRPC.
+func newLocalLensHandler(lens api.LensV2, opts lensHandlerOpts) http.HandlerFunc {
+	return limitedLensHandler(opts, func(ctx context.Context, w http.ResponseWriter, request *api.LensRequest) {
+		serveLensRequest(ctx, w, lens, opts, request)
+	})
+}
+
+// limitedLensHandler parses the requests of a lens, and serves them with
+// serve within the limits of the lens.
+func limitedLensHandler(opts lensHandlerOpts, serve func(ctx context.Context, w http.ResponseWriter, request *api.LensRequest)) http.HandlerFunc {
+	limiter := newLensLimiter()
 	return func(w http.ResponseWriter, r *http.Request) {
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
@@ -108,46 +217,292 @@ func newLensHandler(lens api.Lens, opts lensHandlerOpts) http.HandlerFunc {
 			writeHTTPError(w, fmt.Errorf("failed to unmarshal request: %w", err), http.StatusBadRequest)
 			return
 		}
+		if request.LensIndex < 0 || request.LensIndex >= len(opts.ConfigGetter().Deck.Spyglass.Lenses) {
+			writeHTTPError(w, fmt.Errorf("invalid lens index %d", request.LensIndex), http.StatusBadRequest)
+			return
+		}
+		lensConfig := opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex]
+		if lensConfig.Lens.Name != opts.LensName {
+			writeHTTPError(w, fmt.Errorf("lens index %d is not lens %s", request.LensIndex, opts.LensName), http.StatusBadRequest)
+			return
+		}
+		if status, err := authorizeLens(r, opts, lensConfig); err != nil {
+			writeHTTPError(w, err, status)
+			return
+		}
+		ctx, span := startLensSpan(r, opts, request)
+		defer span.End()
+		r = r.WithContext(ctx)
 
-		artifacts, err := FetchArtifacts(r.Context(), opts.PJFetcher, opts.ConfigGetter, opts.StorageArtifactFetcher, opts.PodLogArtifactFetcher, request.ArtifactSource, "", opts.ConfigGetter().Deck.Spyglass.SizeLimit, request.Artifacts)
-		if err != nil || len(artifacts) == 0 {
-			statusCode := http.StatusInternalServerError
-			if len(artifacts) == 0 {
-				statusCode = http.StatusNotFound
-				err = errors.New("no artifacts found")
-			}
-
-			writeHTTPError(w, fmt.Errorf("failed to retrieve expected artifacts: %w", err), statusCode)
+		if request.Action == api.RequestActionStream {
+			serve(r.Context(), w, request)
+			return
+		}
+		limits := opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Limits
+		if !limiter.acquire(limits) {
+			writeHTTPError(w, fmt.Errorf("lens %s is serving too many requests", opts.LensName), http.StatusServiceUnavailable)
+			return
+		}
+		if !limiter.allow(limits) {
+			limiter.release()
+			writeHTTPError(w, fmt.Errorf("lens %s is unavailable after timing out repeatedly", opts.LensName), http.StatusServiceUnavailable)
 			return
 		}
+		// Version 1 lenses cannot be interrupted, so those that time out keep
+		// their slot until they are done. Version 2 lenses stop once the
+		// context of the request is done.
+		timeout := limits.GetTimeout()
+		handler := http.TimeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer limiter.release()
+			serve(r.Context(), w, request)
+		}), timeout, fmt.Sprintf("lens %s timed out after %s", opts.LensName, timeout))
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(recorder, r)
+		// Only the TimeoutHandler responds with this status, also when the
+		// request is cancelled, which is no fault of the lens.
+		timedOut := recorder.status == http.StatusServiceUnavailable && r.Context().Err() == nil
+		if timedOut {
+			logrus.WithField("lens", opts.LensName).WithField("timeout", timeout).Warn("Lens timed out")
+		}
+		limiter.record(limits, timedOut)
+	}
+}
+
+// authorizeLens checks that the viewer of a request may be shown a lens. Only
+// the authorizer may allow the viewers of lenses that restrict them.
+func authorizeLens(r *http.Request, opts lensHandlerOpts, lens config.LensFileConfig) (int, error) {
+	if lens.Authorization == nil {
+		return http.StatusOK, nil
+	}
+	if opts.Authorizer == nil {
+		return http.StatusForbidden, fmt.Errorf("lens %s restricts its viewers, but nothing authorizes them", opts.LensName)
+	}
+	return opts.Authorizer.AuthorizeLens(r, lens)
+}
+
+// serveLensRequest serves a request of a lens. Lenses adapted from version 1
+// lenses may compare builds or render their history.
+func serveLensRequest(ctx context.Context, w http.ResponseWriter, lens api.LensV2, opts lensHandlerOpts, request *api.LensRequest) {
+	if request.Decrypt {
+		ctx = pkgio.WithDecryption(ctx)
+	}
+	artifacts, failures, ok := fetchRequestArtifacts(ctx, w, opts, request)
+	if !ok {
+		return
+	}
+	rendering := isRendering(request)
 
-		switch request.Action {
-		case api.RequestActionInitial:
-			w.Header().Set("Content-Type", "text/html; encoding=utf-8")
-			lensTemplate.Execute(w, struct {
-				Title   string
-				BaseURL string
-				Head    template.HTML
-				Body    template.HTML
-			}{
-				opts.LensTitle,
-				request.ResourceRoot,
-				template.HTML(lens.Header(artifacts, opts.LensResourcesDir, opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config, opts.ConfigGetter().Deck.Spyglass)),
-				template.HTML(lens.Body(artifacts, opts.LensResourcesDir, "", opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config, opts.ConfigGetter().Deck.Spyglass)),
+	lensConfig := opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config
+	renderBody := func(ctx context.Context, data string) (string, error) {
+		return lens.Body(ctx, artifacts, opts.LensResourcesDir, data, lensConfig, opts.ConfigGetter().Deck.Spyglass)
+	}
+	v1Lens, _ := api.UnadaptLens(lens)
+	if comparingLens, ok := v1Lens.(api.ComparingLens); ok && rendering && request.BaseArtifactSource != "" {
+		// Artifacts missing from the base build are for the lens to report.
+		baseArtifacts, baseFailures, err := fetchLensArtifacts(ctx, opts, request, request.BaseArtifactSource)
+		if err != nil {
+			writeHTTPError(w, fmt.Errorf("failed to retrieve base artifacts: %w", err), http.StatusInternalServerError)
+			return
+		}
+		for _, failure := range baseFailures {
+			failure.Base = true
+			failures = append(failures, failure)
+		}
+		renderBody = func(ctx context.Context, data string) (string, error) {
+			return api.RenderWithin(ctx, func() string {
+				return comparingLens.Compare(artifacts, baseArtifacts, opts.LensResourcesDir, data, lensConfig, opts.ConfigGetter().Deck.Spyglass)
+			})
+		}
+	} else if historyLens, ok := v1Lens.(api.HistoryLens); ok && rendering && len(request.HistoryArtifactSources) > 0 {
+		history := fetchHistoryArtifacts(ctx, opts, request)
+		renderBody = func(ctx context.Context, data string) (string, error) {
+			return api.RenderWithin(ctx, func() string {
+				return historyLens.History(artifacts, history, opts.LensResourcesDir, data, lensConfig, opts.ConfigGetter().Deck.Spyglass)
 			})
+		}
+	}
+	if len(artifacts) == 0 {
+		renderBody = func(context.Context, string) (string, error) { return "", nil }
+	}
+	ctx, done := startRender(ctx, opts, request)
+	defer done()
 
-		case api.RequestActionRerender:
-			w.Header().Set("Content-Type", "text/html; encoding=utf-8")
-			w.Write([]byte(lens.Body(artifacts, opts.LensResourcesDir, request.Data, opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config, opts.ConfigGetter().Deck.Spyglass)))
+	switch request.Action {
+	case api.RequestActionInitial:
+		header, err := lens.Header(ctx, artifacts, opts.LensResourcesDir, lensConfig, opts.ConfigGetter().Deck.Spyglass)
+		if err != nil {
+			writeLensError(w, opts, err)
+			return
+		}
+		body, err := renderBody(ctx, "")
+		if err != nil {
+			writeLensError(w, opts, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; encoding=utf-8")
+		lensTemplate.Execute(w, struct {
+			Title   string
+			BaseURL string
+			Head    template.HTML
+			Errors  []*artifactFailure
+			Body    template.HTML
+		}{
+			opts.LensTitle,
+			request.ResourceRoot,
+			template.HTML(header),
+			failures,
+			template.HTML(body),
+		})
+
+	case api.RequestActionRerender:
+		body, err := renderBody(ctx, request.Data)
+		if err != nil {
+			writeLensError(w, opts, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; encoding=utf-8")
+		if err := lensTemplate.ExecuteTemplate(w, "errors", failures); err != nil {
+			logrus.WithError(err).Error("Failed to render the artifacts that could not be fetched")
+		}
+		w.Write([]byte(body))
+
+	case api.RequestActionCallBack:
+		response, err := lens.Callback(ctx, artifacts, opts.LensResourcesDir, request.Data, lensConfig, opts.ConfigGetter().Deck.Spyglass)
+		if err != nil {
+			writeLensError(w, opts, err)
+			return
+		}
+		w.Write([]byte(response))
+
+	case api.RequestActionStream:
+		streamingLens, ok := lens.(streamer)
+		if !ok {
+			streamingLens, ok = v1Lens.(streamer)
+		}
+		if !ok {
+			writeHTTPError(w, fmt.Errorf("lens %s does not support streaming", opts.LensName), http.StatusBadRequest)
+			return
+		}
+		serveStream(ctx, w, func(send func(string) error) error {
+			return streamingLens.Stream(ctx, artifacts, opts.LensResourcesDir, request.Data, lensConfig, opts.ConfigGetter().Deck.Spyglass, send)
+		})
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		// This is a bit weird as we proxy this and the request we are complaining about was issued by Deck, not by the original client that sees this error
+		w.Write([]byte(fmt.Sprintf("Invalid action %q", request.Action)))
+	}
+}
+
+// streamer is implemented by lenses of both versions that support streaming,
+// as api.StreamingLens and api.StreamingLensV2 share the Stream method.
+type streamer interface {
+	Stream(ctx context.Context, artifacts []api.Artifact, resourceRoot string, data string, config json.RawMessage, spyglassConfig config.Spyglass, send func(string) error) error
+}
+
+// writeLensError responds with the error of a lens that failed to serve a
+// request, telling lenses that timed out apart from those that failed.
+func writeLensError(w http.ResponseWriter, opts lensHandlerOpts, err error) {
+	statusCode := http.StatusInternalServerError
+	if errors.Is(err, context.DeadlineExceeded) {
+		statusCode = http.StatusGatewayTimeout
+	}
+	writeHTTPError(w, fmt.Errorf("lens %s failed: %w", opts.LensName, err), statusCode)
+}
+
+// fetchHistoryArtifacts fetches the artifacts of the prior builds of a
+// request concurrently. Prior builds often lack some of the artifacts, so
+// failures are not reported, and builds without artifacts are left out.
+func fetchHistoryArtifacts(ctx context.Context, opts lensHandlerOpts, request *api.LensRequest) []api.BuildArtifacts {
+	builds := make([]api.BuildArtifacts, len(request.HistoryArtifactSources))
+	var wg sync.WaitGroup
+	for i, src := range request.HistoryArtifactSources {
+		wg.Add(1)
+		go func(i int, src string) {
+			defer wg.Done()
+			builds[i].BuildID = path.Base(strings.TrimSuffix(src, "/"))
+			artifacts, _, err := fetchLensArtifacts(ctx, opts, request, src)
+			if err != nil {
+				logrus.WithError(err).WithField("src", src).Debug("Failed to fetch the artifacts of a prior build")
+				return
+			}
+			builds[i].Artifacts = artifacts
+		}(i, src)
+	}
+	wg.Wait()
 
-		case api.RequestActionCallBack:
-			w.Write([]byte(lens.Callback(artifacts, opts.LensResourcesDir, request.Data, opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config, opts.ConfigGetter().Deck.Spyglass)))
+	var history []api.BuildArtifacts
+	for _, build := range builds {
+		if len(build.Artifacts) > 0 {
+			history = append(history, build)
+		}
+	}
+	return history
+}
+
+// isRendering is whether a request renders the lens, as opposed to a
+// callback or a stream.
+func isRendering(request *api.LensRequest) bool {
+	return request.Action == api.RequestActionInitial || request.Action == api.RequestActionRerender
+}
 
-		default:
-			w.WriteHeader(http.StatusBadRequest)
-			// This is a bit weird as we proxy this and the request we are complaining about was issued by Deck, not by the original client that sees this error
-			w.Write([]byte(fmt.Sprintf("Invalid action %q", request.Action)))
+// fetchRequestArtifacts fetches the artifacts of a request. If it fails, it
+// responds with the error and returns false.
+func fetchRequestArtifacts(ctx context.Context, w http.ResponseWriter, opts lensHandlerOpts, request *api.LensRequest) ([]api.Artifact, []*artifactFailure, bool) {
+	artifacts, failures, err := fetchLensArtifacts(ctx, opts, request, request.ArtifactSource)
+	if err != nil || (len(artifacts) == 0 && len(failures) == 0) {
+		statusCode := http.StatusInternalServerError
+		if err == nil {
+			statusCode = http.StatusNotFound
+			err = errors.New("no artifacts found")
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			statusCode = http.StatusGatewayTimeout
 		}
+
+		writeHTTPError(w, fmt.Errorf("failed to retrieve expected artifacts: %w", err), statusCode)
+		return nil, nil, false
+	}
+	// Pages are rendered with the artifacts that could be fetched, below
+	// the list of the others, but callbacks and streams need them all.
+	if len(artifacts) == 0 && !isRendering(request) {
+		writeHTTPError(w, fmt.Errorf("failed to retrieve expected artifacts: %w", failures[0].Err), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return artifacts, failures, true
+}
+
+// serveStream serves the updates sent by stream as server-sent events. An
+// "end" event tells the client that the stream is over, so that it does not
+// reconnect.
+func serveStream(ctx context.Context, w http.ResponseWriter, stream func(send func(string) error) error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeHTTPError(w, errors.New("streaming is not supported by the connection"), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(data string) error {
+		var event strings.Builder
+		for _, line := range strings.Split(data, "\n") {
+			event.WriteString("data: " + line + "\n")
+		}
+		event.WriteString("\n")
+		if _, err := io.WriteString(w, event.String()); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	if err := stream(send); err != nil {
+		logrus.WithError(err).Debug("Stream failed")
+	}
+	if ctx.Err() == nil {
+		io.WriteString(w, "event: end\ndata:\n\n")
+		flusher.Flush()
 	}
 }
 
@@ -167,7 +522,42 @@ type ArtifactFetcher interface {
 	Artifact(ctx context.Context, key string, artifactName string, sizeLimit int64) (api.Artifact, error)
 }
 
-// FetchArtifacts fetches artifacts.
+// ArtifactFetchers fetches artifacts from storage with the ArtifactFetcher
+// registered for the storage provider of their key, e.g. gs, s3 or azblob.
+type ArtifactFetchers map[string]ArtifactFetcher
+
+// NewArtifactFetchers returns ArtifactFetchers fetching artifacts from all the
+// known storage providers with fetcher.
+func NewArtifactFetchers(fetcher ArtifactFetcher) ArtifactFetchers {
+	fetchers := ArtifactFetchers{}
+	for _, storageProvider := range []string{providers.GS, providers.S3, providers.Azure} {
+		fetchers.Register(storageProvider, fetcher)
+	}
+	return fetchers
+}
+
+// Register makes fetcher fetch the artifacts stored with storageProvider,
+// replacing the fetcher previously registered for it, if any.
+func (f ArtifactFetchers) Register(storageProvider string, fetcher ArtifactFetcher) {
+	f[storageProvider] = fetcher
+}
+
+// Artifact fetches an artifact with the fetcher registered for the storage
+// provider of key, which is a storage path like gs://bucket/logs/job/1.
+func (f ArtifactFetchers) Artifact(ctx context.Context, key string, artifactName string, sizeLimit int64) (api.Artifact, error) {
+	storageProvider, _, _, err := providers.ParseStoragePath(key)
+	if err != nil {
+		return nil, err
+	}
+	fetcher, ok := f[storageProvider]
+	if !ok {
+		return nil, fmt.Errorf("no artifact fetcher for storage provider %q", storageProvider)
+	}
+	return fetcher.Artifact(ctx, key, artifactName, sizeLimit)
+}
+
+// FetchArtifacts fetches artifacts. Artifacts that fail to be fetched are
+// left out.
 // TODO: Unexport once we only have remote lenses
 func FetchArtifacts(
 	ctx context.Context,
@@ -180,11 +570,37 @@ func FetchArtifacts(
 	sizeLimit int64,
 	artifactNames []string,
 ) ([]api.Artifact, error) {
+	arts, _, err := fetchArtifacts(ctx, pjFetcher, cfg, storageArtifactFetcher, podLogArtifactFetcher, src, podName, sizeLimit, artifactNames)
+	return arts, err
+}
+
+// artifactFailure is an artifact that failed to be fetched.
+type artifactFailure struct {
+	Name string
+	Err  error
+	// Base is whether the artifact is of the base build of a comparing lens.
+	Base bool
+}
+
+// fetchArtifacts fetches artifacts, and returns those that failed to be
+// fetched separately. It only fails if src is invalid or ctx is done.
+func fetchArtifacts(
+	ctx context.Context,
+	pjFetcher ProwJobFetcher,
+	cfg config.Getter,
+	storageArtifactFetcher ArtifactFetcher,
+	podLogArtifactFetcher ArtifactFetcher,
+	src string,
+	podName string,
+	sizeLimit int64,
+	artifactNames []string,
+) ([]api.Artifact, []*artifactFailure, error) {
 	artStart := time.Now()
 	arts := []api.Artifact{}
+	var failures []*artifactFailure
 	keyType, key, err := splitSrc(src)
 	if err != nil {
-		return arts, fmt.Errorf("error parsing src: %w", err)
+		return arts, nil, fmt.Errorf("error parsing src: %w", err)
 	}
 	gcsKey := ""
 	switch keyType {
@@ -204,6 +620,9 @@ func FetchArtifacts(
 	logsNeeded := []string{}
 
 	for _, name := range artifactNames {
+		if err := ctx.Err(); err != nil {
+			return arts, failures, fmt.Errorf("stopped fetching artifacts: %w", err)
+		}
 		art, err := storageArtifactFetcher.Artifact(ctx, gcsKey, name, sizeLimit)
 		if err == nil {
 			// Actually try making a request, because calling StorageArtifactFetcher.artifact does no I/O.
@@ -214,6 +633,8 @@ func FetchArtifacts(
 		if err != nil {
 			if buildLogRegex.MatchString(name) {
 				logsNeeded = append(logsNeeded, name)
+			} else {
+				failures = append(failures, &artifactFailure{Name: name, Err: err})
 			}
 			logrus.WithError(err).WithField("artifact", name).Debug("Failed to fetch artifact")
 			continue
@@ -222,6 +643,9 @@ func FetchArtifacts(
 	}
 
 	for _, logName := range logsNeeded {
+		if err := ctx.Err(); err != nil {
+			return arts, failures, fmt.Errorf("stopped fetching artifacts: %w", err)
+		}
 		art, err := podLogArtifactFetcher.Artifact(ctx, src, logName, sizeLimit)
 		if config.IsNotAllowedBucketError(err) {
 			logrus.Debugf("Failed to fetch pod log: %v", err)
@@ -229,11 +653,13 @@ func FetchArtifacts(
 			logrus.Errorf("Failed to fetch pod log: %v", err)
 		} else {
 			arts = append(arts, art)
+			continue
 		}
+		failures = append(failures, &artifactFailure{Name: logName, Err: err})
 	}
 
 	logrus.WithField("duration", time.Since(artStart).String()).Infof("Retrieved artifacts for %v", src)
-	return arts, nil
+	return arts, failures, nil
 }
 
 // ProwJobFetcher knows how to get a ProwJob
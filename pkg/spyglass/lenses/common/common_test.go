@@ -17,11 +17,26 @@ limitations under the License.
 package common
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
 )
 
 // fakeProwJobFetcher is used to fetch ProwJobs in tests
@@ -199,3 +214,702 @@ func TestProwToGCS(t *testing.T) {
 		})
 	}
 }
+
+// fakeArtifactFetcher fetches the artifacts that have content and fails to
+// fetch the others.
+type fakeArtifactFetcher map[string]string
+
+func (f fakeArtifactFetcher) Artifact(_ context.Context, key string, name string, _ int64) (api.Artifact, error) {
+	content, ok := f[name]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch %s", name)
+	}
+	return &fake.Artifact{Path: name, Content: []byte(content)}, nil
+}
+
+// namesLens renders the names of its artifacts.
+type namesLens struct{}
+
+func (namesLens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return ""
+}
+
+func (namesLens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	var names []string
+	for _, artifact := range artifacts {
+		names = append(names, artifact.JobPath())
+	}
+	return "names: " + strings.Join(names, ",")
+}
+
+func (namesLens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return "callback"
+}
+
+func TestLensHandlerFailedArtifacts(t *testing.T) {
+	cases := []struct {
+		name           string
+		action         api.RequestAction
+		artifacts      []string
+		wantStatus     int
+		wantContains   []string
+		wantNoContains []string
+	}{
+		{
+			name:         "all artifacts are fetched",
+			action:       api.RequestActionInitial,
+			artifacts:    []string{"started.json", "finished.json"},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"names: started.json,finished.json"},
+			wantNoContains: []string{
+				"lens-errors",
+			},
+		},
+		{
+			name:       "page is rendered with the fetched artifacts",
+			action:     api.RequestActionInitial,
+			artifacts:  []string{"started.json", "missing.json"},
+			wantStatus: http.StatusOK,
+			wantContains: []string{
+				"names: started.json",
+				`<li><code>missing.json</code>: failed to fetch missing.json</li>`,
+				"lens-retry",
+			},
+		},
+		{
+			name:       "rerender lists the failed artifacts above the body",
+			action:     api.RequestActionRerender,
+			artifacts:  []string{"started.json", "missing.json"},
+			wantStatus: http.StatusOK,
+			wantContains: []string{
+				"<code>missing.json</code>",
+				"lens-retry",
+				"</div>\nnames: started.json",
+			},
+		},
+		{
+			name:           "page without artifacts only lists the failures",
+			action:         api.RequestActionInitial,
+			artifacts:      []string{"missing.json"},
+			wantStatus:     http.StatusOK,
+			wantContains:   []string{"<code>missing.json</code>", "lens-retry"},
+			wantNoContains: []string{"names:"},
+		},
+		{
+			name:       "callback is made with the fetched artifacts",
+			action:     api.RequestActionCallBack,
+			artifacts:  []string{"started.json", "missing.json"},
+			wantStatus: http.StatusOK,
+			wantContains: []string{
+				"callback",
+			},
+		},
+		{
+			name:         "callback fails without artifacts",
+			action:       api.RequestActionCallBack,
+			artifacts:    []string{"missing.json"},
+			wantStatus:   http.StatusInternalServerError,
+			wantContains: []string{"failed to fetch missing.json"},
+		},
+		{
+			name:         "no artifacts requested",
+			action:       api.RequestActionInitial,
+			wantStatus:   http.StatusNotFound,
+			wantContains: []string{"no artifacts found"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{
+				Lenses: []config.LensFileConfig{{Lens: config.LensConfig{Name: "names"}}},
+			}}}}
+			fetcher := fakeArtifactFetcher{"started.json": "{}", "finished.json": "{}"}
+			handler := newLensHandler(namesLens{}, lensHandlerOpts{
+				StorageArtifactFetcher: fetcher,
+				PodLogArtifactFetcher:  fetcher,
+				ConfigGetter:           func() *config.Config { return cfg },
+				LensOpt:                LensOpt{LensName: "names"},
+			})
+			body, err := json.Marshal(api.LensRequest{
+				Action:         tc.action,
+				Artifacts:      tc.artifacts,
+				ArtifactSource: "gs/bucket/logs/job/1",
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal request: %v", err)
+			}
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, w.Code)
+			}
+			got := w.Body.String()
+			for _, want := range tc.wantContains {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected the response to contain %q, got:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tc.wantNoContains {
+				if strings.Contains(got, notWant) {
+					t.Errorf("expected the response not to contain %q, got:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+// failingLens is a version 2 lens that fails with err, if set.
+type failingLens struct {
+	err error
+}
+
+func (l failingLens) Header(ctx context.Context, artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error) {
+	return "<title>failing</title>", nil
+}
+
+func (l failingLens) Body(ctx context.Context, artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error) {
+	if l.err != nil {
+		return "", l.err
+	}
+	return "body: " + data, nil
+}
+
+func (l failingLens) Callback(ctx context.Context, artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error) {
+	if l.err != nil {
+		return "", l.err
+	}
+	return "callback: " + data, nil
+}
+
+func TestLocalLensHandler(t *testing.T) {
+	cases := []struct {
+		name         string
+		lensErr      error
+		action       api.RequestAction
+		wantStatus   int
+		wantContains string
+	}{
+		{
+			name:         "page is rendered",
+			action:       api.RequestActionInitial,
+			wantStatus:   http.StatusOK,
+			wantContains: "body: ",
+		},
+		{
+			name:         "callback is answered",
+			action:       api.RequestActionCallBack,
+			wantStatus:   http.StatusOK,
+			wantContains: "callback: data",
+		},
+		{
+			name:         "failure to render is reported",
+			lensErr:      errors.New("injected failure"),
+			action:       api.RequestActionRerender,
+			wantStatus:   http.StatusInternalServerError,
+			wantContains: "lens failing failed: injected failure",
+		},
+		{
+			name:         "failed callback is reported",
+			lensErr:      errors.New("injected failure"),
+			action:       api.RequestActionCallBack,
+			wantStatus:   http.StatusInternalServerError,
+			wantContains: "lens failing failed: injected failure",
+		},
+		{
+			name:         "lens timing out is told apart",
+			lensErr:      fmt.Errorf("rendering: %w", context.DeadlineExceeded),
+			action:       api.RequestActionInitial,
+			wantStatus:   http.StatusGatewayTimeout,
+			wantContains: "context deadline exceeded",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{
+				Lenses: []config.LensFileConfig{{Lens: config.LensConfig{Name: "failing"}}},
+			}}}}
+			fetcher := fakeArtifactFetcher{"started.json": "{}"}
+			handler := newLocalLensHandler(failingLens{err: tc.lensErr}, lensHandlerOpts{
+				StorageArtifactFetcher: fetcher,
+				PodLogArtifactFetcher:  fetcher,
+				ConfigGetter:           func() *config.Config { return cfg },
+				LensOpt:                LensOpt{LensName: "failing"},
+			})
+			body, err := json.Marshal(api.LensRequest{
+				Action:         tc.action,
+				Data:           "data",
+				Artifacts:      []string{"started.json"},
+				ArtifactSource: "gs/bucket/logs/job/1",
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal request: %v", err)
+			}
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, w.Code)
+			}
+			if got := w.Body.String(); !strings.Contains(got, tc.wantContains) {
+				t.Errorf("expected the response to contain %q, got:\n%s", tc.wantContains, got)
+			}
+		})
+	}
+}
+
+func TestFetchArtifactsStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fetcher := &recordingFetcher{fakeArtifactFetcher: fakeArtifactFetcher{"started.json": "{}"}}
+	cfg := func() *config.Config { return &config.Config{} }
+	_, _, err := fetchArtifacts(ctx, nil, cfg, fetcher, fetcher, "gs/bucket/logs/job/1", "", 1024, []string{"started.json"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the fetch to be cancelled, got %v", err)
+	}
+	if fetcher.fetched {
+		t.Error("expected no artifacts to be fetched")
+	}
+}
+
+// fakeLensAuthorizer denies the requests of lenses with its status, unless
+// it is OK.
+type fakeLensAuthorizer struct {
+	status int
+}
+
+func (a fakeLensAuthorizer) AuthorizeLens(r *http.Request, lens config.LensFileConfig) (int, error) {
+	if a.status != http.StatusOK {
+		return a.status, fmt.Errorf("may not view lens %s", lens.Lens.Name)
+	}
+	return http.StatusOK, nil
+}
+
+// recordingFetcher records whether artifacts were fetched.
+type recordingFetcher struct {
+	fakeArtifactFetcher
+	fetched bool
+}
+
+func (f *recordingFetcher) Artifact(ctx context.Context, key string, name string, sizeLimit int64) (api.Artifact, error) {
+	f.fetched = true
+	return f.fakeArtifactFetcher.Artifact(ctx, key, name, sizeLimit)
+}
+
+func TestLensAuthorization(t *testing.T) {
+	restricted := &config.LensAuthorization{RequireLogin: true}
+	cases := []struct {
+		name          string
+		authorization *config.LensAuthorization
+		authorizer    LensAuthorizer
+		lensIndex     int
+		wantStatus    int
+	}{
+		{
+			name:       "lens is not restricted",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "restricted lens without authorizer",
+			authorization: restricted,
+			wantStatus:    http.StatusForbidden,
+		},
+		{
+			name:          "viewer is not logged in",
+			authorization: restricted,
+			authorizer:    fakeLensAuthorizer{status: http.StatusUnauthorized},
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "viewer is authorized",
+			authorization: restricted,
+			authorizer:    fakeLensAuthorizer{status: http.StatusOK},
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "lens index of another lens",
+			authorization: restricted,
+			authorizer:    fakeLensAuthorizer{status: http.StatusUnauthorized},
+			lensIndex:     1,
+			wantStatus:    http.StatusBadRequest,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{
+				Lenses: []config.LensFileConfig{
+					{Lens: config.LensConfig{Name: "names"}, Authorization: tc.authorization},
+					{Lens: config.LensConfig{Name: "other"}},
+				},
+			}}}}
+			fetcher := &recordingFetcher{fakeArtifactFetcher: fakeArtifactFetcher{"started.json": "{}"}}
+			handler := newLensHandler(namesLens{}, lensHandlerOpts{
+				StorageArtifactFetcher: fetcher,
+				PodLogArtifactFetcher:  fetcher,
+				ConfigGetter:           func() *config.Config { return cfg },
+				Authorizer:             tc.authorizer,
+				LensOpt:                LensOpt{LensName: "names"},
+			})
+			body, err := json.Marshal(api.LensRequest{
+				Action:         api.RequestActionInitial,
+				Artifacts:      []string{"started.json"},
+				ArtifactSource: "gs/bucket/logs/job/1",
+				LensIndex:      tc.lensIndex,
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal request: %v", err)
+			}
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+			if allowed := tc.wantStatus == http.StatusOK; fetcher.fetched != allowed {
+				t.Errorf("expected artifacts to be fetched: %t, got: %t", allowed, fetcher.fetched)
+			}
+		})
+	}
+}
+
+func TestLensMuxFollowsConfig(t *testing.T) {
+	configWithLenses := func(names ...string) *config.Config {
+		c := &config.Config{}
+		for _, name := range names {
+			c.Deck.Spyglass.Lenses = append(c.Deck.Spyglass.Lenses, config.LensFileConfig{Lens: config.LensConfig{Name: name}})
+		}
+		return c
+	}
+	current := configWithLenses("names")
+	fetcher := fakeArtifactFetcher{"started.json": "{}"}
+	mux := &lensMux{
+		lenses: func(c *config.Config) ([]LensWithConfiguration, error) {
+			var lenses []LensWithConfiguration
+			for _, lens := range c.Deck.Spyglass.Lenses {
+				lenses = append(lenses, LensWithConfiguration{Config: LensOpt{LensName: lens.Lens.Name}, Lens: namesLens{}})
+			}
+			return lenses, nil
+		},
+		opts: lensHandlerOpts{
+			StorageArtifactFetcher: fetcher,
+			PodLogArtifactFetcher:  fetcher,
+			ConfigGetter:           func() *config.Config { return current },
+		},
+	}
+	if err := mux.load(current); err != nil {
+		t.Fatalf("failed to load lenses: %v", err)
+	}
+	serve := func(lens string, index int) int {
+		body, err := json.Marshal(api.LensRequest{
+			Action:         api.RequestActionInitial,
+			Artifacts:      []string{"started.json"},
+			ArtifactSource: "gs/bucket/logs/job/1",
+			LensIndex:      index,
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal request: %v", err)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, DynamicPathForLens(lens), bytes.NewReader(body)))
+		return w.Code
+	}
+
+	if status := serve("names", 0); status != http.StatusOK {
+		t.Errorf("expected lens names to be served, got status %d", status)
+	}
+	if status := serve("added", 1); status != http.StatusNotFound {
+		t.Errorf("expected lens added not to be served yet, got status %d", status)
+	}
+
+	current = configWithLenses("names", "added")
+	if status := serve("added", 1); status != http.StatusOK {
+		t.Errorf("expected added lens to be served, got status %d", status)
+	}
+
+	// The lenses of a config that fails to load are not served, the previous ones are.
+	current = configWithLenses("added", "added", "names")
+	if status := serve("names", 2); status != http.StatusOK {
+		t.Errorf("expected lens names to still be served, got status %d", status)
+	}
+
+	current = configWithLenses("added")
+	if status := serve("names", 0); status != http.StatusNotFound {
+		t.Errorf("expected removed lens not to be served, got status %d", status)
+	}
+}
+
+func TestArtifactFetchers(t *testing.T) {
+	fetchers := NewArtifactFetchers(fakeArtifactFetcher{"started.json": "gcs"})
+	fetchers.Register(providers.Azure, fakeArtifactFetcher{"started.json": "azure"})
+
+	cases := []struct {
+		name        string
+		key         string
+		wantContent string
+		wantErr     string
+	}{
+		{
+			name:        "gs artifacts are fetched with the default fetcher",
+			key:         "gs://bucket/logs/job/1",
+			wantContent: "gcs",
+		},
+		{
+			name:        "s3 artifacts are fetched with the default fetcher",
+			key:         "s3://bucket/logs/job/1",
+			wantContent: "gcs",
+		},
+		{
+			name:        "azblob artifacts are fetched with the registered fetcher",
+			key:         "azblob://container/logs/job/1",
+			wantContent: "azure",
+		},
+		{
+			name:    "unknown storage provider",
+			key:     "ftp://bucket/logs/job/1",
+			wantErr: `no artifact fetcher for storage provider "ftp"`,
+		},
+		{
+			name:    "key without bucket",
+			key:     "gs://",
+			wantErr: "could not find bucket",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			art, err := fetchers.Artifact(context.Background(), tc.key, "started.json", 0)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			content, err := art.ReadAll()
+			if err != nil {
+				t.Fatalf("failed to read artifact: %v", err)
+			}
+			if string(content) != tc.wantContent {
+				t.Errorf("expected content %q, got %q", tc.wantContent, string(content))
+			}
+		})
+	}
+}
+
+// blockingLens renders its body once it is unblocked.
+type blockingLens struct {
+	namesLens
+	unblock chan struct{}
+}
+
+func (l blockingLens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	<-l.unblock
+	return l.namesLens.Body(artifacts, resourceDir, data, config, spyglassConfig)
+}
+
+func TestLensHandlerLimits(t *testing.T) {
+	cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{
+		Lenses: []config.LensFileConfig{{
+			Lens: config.LensConfig{Name: "blocking"},
+			Limits: &config.LensLimits{
+				Timeout:          &metav1.Duration{Duration: 10 * time.Millisecond},
+				MaxConcurrency:   1,
+				FailureThreshold: 1,
+				CooldownPeriod:   &metav1.Duration{Duration: time.Hour},
+			},
+		}},
+	}}}}
+	lens := blockingLens{unblock: make(chan struct{})}
+	fetcher := fakeArtifactFetcher{"started.json": "{}"}
+	handler := newLensHandler(lens, lensHandlerOpts{
+		StorageArtifactFetcher: fetcher,
+		PodLogArtifactFetcher:  fetcher,
+		ConfigGetter:           func() *config.Config { return cfg },
+		LensOpt:                LensOpt{LensName: "blocking"},
+	})
+	serve := func(action api.RequestAction) *httptest.ResponseRecorder {
+		body, err := json.Marshal(api.LensRequest{
+			Action:         action,
+			Artifacts:      []string{"started.json"},
+			ArtifactSource: "gs/bucket/logs/job/1",
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal request: %v", err)
+		}
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+		return w
+	}
+	expect := func(w *httptest.ResponseRecorder, wantStatus int, wantContains string) {
+		t.Helper()
+		if w.Code != wantStatus {
+			t.Errorf("expected status %d, got %d", wantStatus, w.Code)
+		}
+		if got := w.Body.String(); !strings.Contains(got, wantContains) {
+			t.Errorf("expected the response to contain %q, got:\n%s", wantContains, got)
+		}
+	}
+
+	expect(serve(api.RequestActionRerender), http.StatusServiceUnavailable, "lens blocking timed out after 10ms")
+	// The lens is still rendering the first request.
+	expect(serve(api.RequestActionRerender), http.StatusServiceUnavailable, "lens blocking is serving too many requests")
+	close(lens.unblock)
+	if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return serve(api.RequestActionCallBack).Body.String() != "lens blocking is serving too many requests", nil
+	}); err != nil {
+		t.Fatalf("the lens did not release its slot: %v", err)
+	}
+	expect(serve(api.RequestActionRerender), http.StatusServiceUnavailable, "lens blocking is unavailable after timing out repeatedly")
+
+	cfg.Deck.Spyglass.Lenses[0].Limits = nil
+	expect(serve(api.RequestActionRerender), http.StatusOK, "names: started.json")
+}
+
+func TestServeStream(t *testing.T) {
+	cases := []struct {
+		name   string
+		events []string
+		err    error
+		cancel bool
+		want   string
+	}{
+		{
+			name: "no events",
+			want: "event: end\ndata:\n\n",
+		},
+		{
+			name:   "single and multi-line events",
+			events: []string{"hello", "multi\nline"},
+			want:   "data: hello\n\ndata: multi\ndata: line\n\nevent: end\ndata:\n\n",
+		},
+		{
+			name:   "stream error still ends the stream",
+			events: []string{"hello"},
+			err:    errors.New("injected"),
+			want:   "data: hello\n\nevent: end\ndata:\n\n",
+		},
+		{
+			name:   "cancelled request does not end the stream",
+			events: []string{"hello"},
+			cancel: true,
+			want:   "data: hello\n\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w := httptest.NewRecorder()
+			serveStream(ctx, w, func(send func(string) error) error {
+				for _, event := range tc.events {
+					if err := send(event); err != nil {
+						return err
+					}
+				}
+				if tc.cancel {
+					cancel()
+				}
+				return tc.err
+			})
+			if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+				t.Errorf("Content-Type = %q, want text/event-stream", got)
+			}
+			if diff := cmp.Diff(tc.want, w.Body.String()); diff != "" {
+				t.Errorf("serveStream() got unexpected diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// buildArtifactFetcher fetches the artifacts of builds by their key and name.
+type buildArtifactFetcher map[string]string
+
+func (f buildArtifactFetcher) Artifact(_ context.Context, key string, name string, _ int64) (api.Artifact, error) {
+	content, ok := f[key+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch %s", name)
+	}
+	return &fake.Artifact{Path: name, Content: []byte(content)}, nil
+}
+
+// historyLens renders the contents of its artifacts in each build.
+type historyLens struct {
+	namesLens
+}
+
+func (historyLens) History(artifacts []api.Artifact, history []api.BuildArtifacts, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	var builds []string
+	for _, build := range append([]api.BuildArtifacts{{BuildID: "latest", Artifacts: artifacts}}, history...) {
+		var contents []string
+		for _, artifact := range build.Artifacts {
+			content, _ := artifact.ReadAll()
+			contents = append(contents, string(content))
+		}
+		builds = append(builds, build.BuildID+"="+strings.Join(contents, ","))
+	}
+	return "history: " + strings.Join(builds, " ")
+}
+
+func TestLensHandlerHistory(t *testing.T) {
+	cases := []struct {
+		name    string
+		action  api.RequestAction
+		sources []string
+		want    string
+	}{
+		{
+			name:    "prior builds without artifacts are left out",
+			action:  api.RequestActionInitial,
+			sources: []string{"gs/bucket/logs/job/3", "gs/bucket/logs/job/2", "gs/bucket/logs/job/1"},
+			want:    "history: latest=four 3=three 1=one",
+		},
+		{
+			name:   "body is rendered without prior builds",
+			action: api.RequestActionRerender,
+			want:   "names: junit.xml",
+		},
+		{
+			name:    "callbacks ignore prior builds",
+			action:  api.RequestActionCallBack,
+			sources: []string{"gs/bucket/logs/job/3"},
+			want:    "callback",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{
+				Lenses: []config.LensFileConfig{{Lens: config.LensConfig{Name: "history"}}},
+			}}}}
+			fetcher := buildArtifactFetcher{
+				"gs://bucket/logs/job/4/junit.xml": "four",
+				"gs://bucket/logs/job/3/junit.xml": "three",
+				"gs://bucket/logs/job/1/junit.xml": "one",
+			}
+			handler := newLensHandler(historyLens{}, lensHandlerOpts{
+				StorageArtifactFetcher: fetcher,
+				PodLogArtifactFetcher:  fetcher,
+				ConfigGetter:           func() *config.Config { return cfg },
+				LensOpt:                LensOpt{LensName: "history"},
+			})
+			body, err := json.Marshal(api.LensRequest{
+				Action:                 tc.action,
+				Artifacts:              []string{"junit.xml"},
+				ArtifactSource:         "gs/bucket/logs/job/4",
+				HistoryArtifactSources: tc.sources,
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal request: %v", err)
+			}
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if got := w.Body.String(); !strings.Contains(got, tc.want) {
+				t.Errorf("expected the response to contain %q, got:\n%s", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// lensLimiter enforces the LensLimits of a lens. It bounds the requests in
+// flight, and rejects all requests for a while once too many timed out in a
+// row, i.e. it is a circuit breaker.
+type lensLimiter struct {
+	lock sync.Mutex
+	// inFlight is the number of requests being served.
+	inFlight int
+	// timeouts is the number of consecutive requests that timed out.
+	timeouts int
+	// openUntil is when requests are let through again once the lens
+	// reached the failure threshold.
+	openUntil time.Time
+	// probing is whether a request is probing whether the lens recovered.
+	probing bool
+
+	now func() time.Time
+}
+
+func newLensLimiter() *lensLimiter {
+	return &lensLimiter{now: time.Now}
+}
+
+// acquire reserves a slot for a request, unless the lens serves as many as it
+// may already. release must be called once the request is done.
+func (l *lensLimiter) acquire(limits *config.LensLimits) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if max := limits.GetMaxConcurrency(); max > 0 && l.inFlight >= max {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+func (l *lensLimiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.inFlight--
+}
+
+// allow is whether a request may be served, i.e. whether the circuit is
+// closed, or a request may probe whether the lens recovered. record must be
+// called with the outcome of allowed requests.
+func (l *lensLimiter) allow(limits *config.LensLimits) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	threshold := limits.GetFailureThreshold()
+	if threshold == 0 || l.timeouts < threshold {
+		return true
+	}
+	if l.probing || l.now().Before(l.openUntil) {
+		return false
+	}
+	l.probing = true
+	return true
+}
+
+// record records whether a request timed out.
+func (l *lensLimiter) record(limits *config.LensLimits, timedOut bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.probing = false
+	if !timedOut {
+		l.timeouts = 0
+		return
+	}
+	l.timeouts++
+	if threshold := limits.GetFailureThreshold(); threshold > 0 && l.timeouts >= threshold {
+		l.openUntil = l.now().Add(limits.GetCooldownPeriod())
+	}
+}
+
+// statusRecorder records the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+func TestLensLimiter(t *testing.T) {
+	type step struct {
+		// advance moves the clock before the step.
+		advance time.Duration
+		// timedOut is the outcome of the request, if it is allowed.
+		timedOut  bool
+		wantAllow bool
+	}
+	cases := []struct {
+		name   string
+		limits *config.LensLimits
+		steps  []step
+	}{
+		{
+			name: "disabled without a threshold",
+			steps: []step{
+				{timedOut: true, wantAllow: true},
+				{timedOut: true, wantAllow: true},
+				{timedOut: true, wantAllow: true},
+			},
+		},
+		{
+			name:   "opens after consecutive timeouts",
+			limits: &config.LensLimits{FailureThreshold: 2},
+			steps: []step{
+				{timedOut: true, wantAllow: true},
+				{timedOut: false, wantAllow: true},
+				{timedOut: true, wantAllow: true},
+				{timedOut: true, wantAllow: true},
+				{wantAllow: false},
+				{advance: 59 * time.Second, wantAllow: false},
+			},
+		},
+		{
+			name:   "probe closes the circuit",
+			limits: &config.LensLimits{FailureThreshold: 1, CooldownPeriod: &metav1.Duration{Duration: time.Second}},
+			steps: []step{
+				{timedOut: true, wantAllow: true},
+				{wantAllow: false},
+				{advance: time.Second, timedOut: false, wantAllow: true},
+				{timedOut: true, wantAllow: true},
+				{wantAllow: false},
+			},
+		},
+		{
+			name:   "failed probe reopens the circuit",
+			limits: &config.LensLimits{FailureThreshold: 1, CooldownPeriod: &metav1.Duration{Duration: time.Second}},
+			steps: []step{
+				{timedOut: true, wantAllow: true},
+				{advance: time.Second, timedOut: true, wantAllow: true},
+				{wantAllow: false},
+				{advance: time.Second, timedOut: false, wantAllow: true},
+				{wantAllow: true},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Now()
+			limiter := newLensLimiter()
+			limiter.now = func() time.Time { return now }
+			for i, step := range tc.steps {
+				now = now.Add(step.advance)
+				allowed := limiter.allow(tc.limits)
+				if allowed != step.wantAllow {
+					t.Fatalf("step %d: allowed %t, want %t", i, allowed, step.wantAllow)
+				}
+				if allowed {
+					limiter.record(tc.limits, step.timedOut)
+				}
+			}
+		})
+	}
+}
+
+func TestLensLimiterProbe(t *testing.T) {
+	limits := &config.LensLimits{FailureThreshold: 1}
+	now := time.Now()
+	limiter := newLensLimiter()
+	limiter.now = func() time.Time { return now }
+	limiter.allow(limits)
+	limiter.record(limits, true)
+
+	now = now.Add(limits.GetCooldownPeriod())
+	if !limiter.allow(limits) {
+		t.Fatal("probe was not allowed after the cooldown period")
+	}
+	if limiter.allow(limits) {
+		t.Error("request was allowed while probing")
+	}
+}
+
+func TestLensLimiterConcurrency(t *testing.T) {
+	limiter := newLensLimiter()
+	limits := &config.LensLimits{MaxConcurrency: 2}
+	for i := 0; i < 2; i++ {
+		if !limiter.acquire(limits) {
+			t.Fatalf("request %d was rejected", i)
+		}
+	}
+	if limiter.acquire(limits) {
+		t.Fatal("request beyond the max concurrency was accepted")
+	}
+	limiter.release()
+	if !limiter.acquire(limits) {
+		t.Fatal("request was rejected after a slot was released")
+	}
+	if !limiter.acquire(nil) {
+		t.Error("request was rejected without limits")
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+)
+
+var (
+	lensArtifactFetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "spyglass_lens_artifact_fetch_duration_seconds",
+		Help:    "Time spent fetching the artifacts of lens requests, by lens and action.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"lens", "action"})
+	lensRenderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "spyglass_lens_render_duration_seconds",
+		Help:    "Time spent by lenses serving requests once their artifacts are fetched, by lens and action. Streams are not observed.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"lens", "action"})
+)
+
+func init() {
+	prometheus.MustRegister(lensArtifactFetchDuration)
+	prometheus.MustRegister(lensRenderDuration)
+}
+
+// tracer traces lens requests. Spans are only recorded once the binary
+// registers a global TracerProvider, e.g. with an OTLP exporter.
+var tracer = otel.Tracer("sigs.k8s.io/prow/pkg/spyglass/lenses/common")
+
+func lensAttributes(opts lensHandlerOpts, request *api.LensRequest) trace.SpanStartEventOption {
+	return trace.WithAttributes(
+		attribute.String("spyglass.lens", opts.LensName),
+		attribute.String("spyglass.action", string(request.Action)),
+	)
+}
+
+// startLensSpan starts the span of a lens request, continuing the trace of
+// the request to Deck if any.
+func startLensSpan(r *http.Request, opts lensHandlerOpts, request *api.LensRequest) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return tracer.Start(ctx, "spyglass.lens/"+opts.LensName, lensAttributes(opts, request), trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// fetchLensArtifacts fetches the artifacts of a lens request from src,
+// observing how long it takes.
+func fetchLensArtifacts(ctx context.Context, opts lensHandlerOpts, request *api.LensRequest, src string) ([]api.Artifact, []*artifactFailure, error) {
+	ctx, span := tracer.Start(ctx, "spyglass.lens.FetchArtifacts", lensAttributes(opts, request), trace.WithAttributes(
+		attribute.String("spyglass.src", src),
+		attribute.Int("spyglass.artifacts", len(request.Artifacts)),
+	))
+	defer span.End()
+
+	start := time.Now()
+	artifacts, failures, err := fetchArtifacts(ctx, opts.PJFetcher, opts.ConfigGetter, opts.StorageArtifactFetcher, opts.PodLogArtifactFetcher, src, "", opts.ConfigGetter().Deck.Spyglass.SizeLimit, request.Artifacts)
+	lensArtifactFetchDuration.WithLabelValues(opts.LensName, string(request.Action)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.SetAttributes(attribute.Int("spyglass.failures", len(failures)))
+	return artifacts, failures, err
+}
+
+// startRender starts observing how long a lens takes to serve a request once
+// its artifacts are fetched. The returned function ends the observation.
+func startRender(ctx context.Context, opts lensHandlerOpts, request *api.LensRequest) (context.Context, func()) {
+	ctx, span := tracer.Start(ctx, "spyglass.lens.Render", lensAttributes(opts, request))
+	start := time.Now()
+	return ctx, func() {
+		if request.Action != api.RequestActionStream {
+			lensRenderDuration.WithLabelValues(opts.LensName, string(request.Action)).Observe(time.Since(start).Seconds())
+		}
+		span.End()
+	}
+}
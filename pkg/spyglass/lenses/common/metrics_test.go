@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+)
+
+// sampleCount returns how many times a histogram observed the requests of a
+// lens with an action.
+func sampleCount(t *testing.T, histogram *prometheus.HistogramVec, lens string, action api.RequestAction) uint64 {
+	t.Helper()
+	metric := &dto.Metric{}
+	if err := histogram.WithLabelValues(lens, string(action)).(prometheus.Metric).Write(metric); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestLensHandlerMetrics(t *testing.T) {
+	lensArtifactFetchDuration.Reset()
+	lensRenderDuration.Reset()
+
+	cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{
+		Lenses: []config.LensFileConfig{{Lens: config.LensConfig{Name: "names"}}},
+	}}}}
+	fetcher := fakeArtifactFetcher{"started.json": "{}"}
+	handler := newLensHandler(namesLens{}, lensHandlerOpts{
+		StorageArtifactFetcher: fetcher,
+		PodLogArtifactFetcher:  fetcher,
+		ConfigGetter:           func() *config.Config { return cfg },
+		LensOpt:                LensOpt{LensName: "names"},
+	})
+	serve := func(request api.LensRequest) {
+		body, err := json.Marshal(request)
+		if err != nil {
+			t.Fatalf("failed to marshal request: %v", err)
+		}
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	serve(api.LensRequest{Action: api.RequestActionInitial, Artifacts: []string{"started.json"}, ArtifactSource: "gs/bucket/logs/job/1"})
+	serve(api.LensRequest{Action: api.RequestActionInitial, Artifacts: []string{"started.json"}, ArtifactSource: "gs/bucket/logs/job/1", BaseArtifactSource: "gs/bucket/logs/job/0"})
+	serve(api.LensRequest{Action: api.RequestActionCallBack, Artifacts: []string{"started.json"}, ArtifactSource: "gs/bucket/logs/job/1"})
+
+	for _, tc := range []struct {
+		name      string
+		histogram *prometheus.HistogramVec
+		action    api.RequestAction
+		want      uint64
+	}{
+		{
+			name:      "base artifacts are only fetched by comparing lenses",
+			histogram: lensArtifactFetchDuration,
+			action:    api.RequestActionInitial,
+			want:      2,
+		},
+		{
+			name:      "initial renders",
+			histogram: lensRenderDuration,
+			action:    api.RequestActionInitial,
+			want:      2,
+		},
+		{
+			name:      "callback artifacts",
+			histogram: lensArtifactFetchDuration,
+			action:    api.RequestActionCallBack,
+			want:      1,
+		},
+		{
+			name:      "callbacks",
+			histogram: lensRenderDuration,
+			action:    api.RequestActionCallBack,
+			want:      1,
+		},
+		{
+			name:      "no rerenders",
+			histogram: lensRenderDuration,
+			action:    api.RequestActionRerender,
+			want:      0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sampleCount(t, tc.histogram, "names", tc.action); got != tc.want {
+				t.Errorf("expected %d observations, got %d", tc.want, got)
+			}
+		})
+	}
+}
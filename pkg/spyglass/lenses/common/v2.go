@@ -0,0 +1,420 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	lensv2 "sigs.k8s.io/prow/pkg/spyglass/api/v2"
+)
+
+const (
+	// lensV2ProtocolVersion is the version of the lens protocol spoken with
+	// lenses served over gRPC.
+	lensV2ProtocolVersion = "v2"
+	// lensV2NegotiationTTL is how long what a lens negotiated is cached.
+	lensV2NegotiationTTL = 5 * time.Minute
+	// lensV2NegotiationRetry is how long what a lens negotiated earlier is
+	// used after negotiating again failed.
+	lensV2NegotiationRetry = 30 * time.Second
+	// lensV2NegotiationTimeout bounds negotiating with a lens.
+	lensV2NegotiationTimeout = 10 * time.Second
+)
+
+// LensV2Client is a client of a lens that serves version 2 of the lens
+// protocol, which lets Deck serve the lens like the lenses it runs itself.
+type LensV2Client struct {
+	client lensv2.LensClient
+
+	lock       sync.Mutex
+	negotiated *lensV2Info
+	expires    time.Time
+	now        func() time.Time
+}
+
+// lensV2Info is what a lens negotiated.
+type lensV2Info struct {
+	*lensv2.LensInfo
+	capabilities  sets.Set[lensv2.Capability]
+	requiredFiles []*regexp.Regexp
+	optionalFiles []*regexp.Regexp
+}
+
+// NewLensV2Client returns a client of the lens served by client.
+func NewLensV2Client(client lensv2.LensClient) *LensV2Client {
+	return &LensV2Client{client: client, now: time.Now}
+}
+
+var lensV2Clients = struct {
+	sync.Mutex
+	clients map[string]*LensV2Client
+}{clients: map[string]*LensV2Client{}}
+
+// LensV2ClientForEndpoint returns the client of the lens served over gRPC at
+// endpoint. Clients are shared, so that what the lens negotiated is too.
+func LensV2ClientForEndpoint(endpoint string) (*LensV2Client, error) {
+	lensV2Clients.Lock()
+	defer lensV2Clients.Unlock()
+	if client, ok := lensV2Clients.clients[endpoint]; ok {
+		return client, nil
+	}
+	// Lenses are served within the cluster, like the local lens server.
+	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to lens at %s: %w", endpoint, err)
+	}
+	client := NewLensV2Client(lensv2.NewLensClient(conn))
+	lensV2Clients.clients[endpoint] = client
+	return client, nil
+}
+
+// negotiate returns what the lens negotiated, negotiating again if it is
+// outdated.
+func (c *LensV2Client) negotiate(ctx context.Context) (*lensV2Info, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.negotiated != nil && c.now().Before(c.expires) {
+		return c.negotiated, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, lensV2NegotiationTimeout)
+	defer cancel()
+	negotiated, err := c.doNegotiate(ctx)
+	if err != nil {
+		if c.negotiated == nil {
+			return nil, err
+		}
+		logrus.WithError(err).Warn("Failed to negotiate with lens, using what it negotiated before.")
+		c.expires = c.now().Add(lensV2NegotiationRetry)
+		return c.negotiated, nil
+	}
+	c.negotiated = negotiated
+	c.expires = c.now().Add(lensV2NegotiationTTL)
+	return negotiated, nil
+}
+
+func (c *LensV2Client) doNegotiate(ctx context.Context) (*lensV2Info, error) {
+	info, err := c.client.Negotiate(ctx, &lensv2.NegotiateRequest{ProtocolVersions: []string{lensV2ProtocolVersion}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to negotiate with lens: %w", err)
+	}
+	if info.ProtocolVersion != lensV2ProtocolVersion {
+		return nil, fmt.Errorf("lens speaks version %q of the lens protocol, expected %q", info.ProtocolVersion, lensV2ProtocolVersion)
+	}
+	negotiated := &lensV2Info{LensInfo: info, capabilities: sets.New(info.Capabilities...)}
+	for _, files := range []struct {
+		regexes  []string
+		compiled *[]*regexp.Regexp
+	}{
+		{info.RequiredFiles, &negotiated.requiredFiles},
+		{info.OptionalFiles, &negotiated.optionalFiles},
+	} {
+		for _, expr := range files.regexes {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("lens declared an invalid file regex: %w", err)
+			}
+			*files.compiled = append(*files.compiled, re)
+		}
+	}
+	return negotiated, nil
+}
+
+// MatchArtifacts returns the artifacts of a build that match the files the
+// lens declared, and whether the lens applies to the build at all, i.e.
+// whether it declared files and all the required ones are there.
+func (c *LensV2Client) MatchArtifacts(ctx context.Context, artifactNames []string) ([]string, bool) {
+	negotiated, err := c.negotiate(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to negotiate with lens, not showing it.")
+		return nil, false
+	}
+	if len(negotiated.requiredFiles) == 0 && len(negotiated.optionalFiles) == 0 {
+		return nil, false
+	}
+	matches := sets.New[string]()
+	for _, re := range negotiated.requiredFiles {
+		found := false
+		for _, name := range artifactNames {
+			if re.MatchString(name) {
+				matches.Insert(name)
+				found = true
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	for _, re := range negotiated.optionalFiles {
+		for _, name := range artifactNames {
+			if re.MatchString(name) {
+				matches.Insert(name)
+			}
+		}
+	}
+	if matches.Len() == 0 {
+		return nil, false
+	}
+	return sets.List(matches), true
+}
+
+func newLensV2Handler(client *LensV2Client, opts lensHandlerOpts) http.HandlerFunc {
+	return limitedLensHandler(opts, func(ctx context.Context, w http.ResponseWriter, request *api.LensRequest) {
+		serveLensV2Request(ctx, w, client, opts, request)
+	})
+}
+
+// serveLensV2Request serves a request of a lens with a lens that speaks
+// version 2 of the lens protocol.
+func serveLensV2Request(ctx context.Context, w http.ResponseWriter, client *LensV2Client, opts lensHandlerOpts, request *api.LensRequest) {
+	negotiated, err := client.negotiate(ctx)
+	if err != nil {
+		writeHTTPError(w, err, http.StatusBadGateway)
+		return
+	}
+	switch request.Action {
+	case api.RequestActionCallBack:
+		if !negotiated.capabilities.Has(lensv2.Capability_CAPABILITY_CALLBACK) {
+			writeHTTPError(w, fmt.Errorf("lens %s does not support callbacks", opts.LensName), http.StatusBadRequest)
+			return
+		}
+	case api.RequestActionStream:
+		if !negotiated.capabilities.Has(lensv2.Capability_CAPABILITY_STREAM) {
+			writeHTTPError(w, fmt.Errorf("lens %s does not support streaming", opts.LensName), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if request.Decrypt {
+		ctx = pkgio.WithDecryption(ctx)
+	}
+	artifacts, failures, ok := fetchRequestArtifacts(ctx, w, opts, request)
+	if !ok {
+		return
+	}
+	sizeLimit := opts.ConfigGetter().Deck.Spyglass.SizeLimit
+	if negotiated.MaxArtifactSize > 0 && negotiated.MaxArtifactSize < sizeLimit {
+		sizeLimit = negotiated.MaxArtifactSize
+	}
+	v2Artifacts, readFailures := toV2Artifacts(artifacts, sizeLimit)
+	failures = append(failures, readFailures...)
+	lensConfig := string(opts.ConfigGetter().Deck.Spyglass.Lenses[request.LensIndex].Lens.Config)
+
+	switch request.Action {
+	case api.RequestActionInitial, api.RequestActionRerender:
+		renderRequest := &lensv2.RenderRequest{
+			Config:       lensConfig,
+			ResourceRoot: request.ResourceRoot,
+			Data:         request.Data,
+			Artifacts:    v2Artifacts,
+			Initial:      request.Action == api.RequestActionInitial,
+		}
+		if request.BaseArtifactSource != "" && negotiated.capabilities.Has(lensv2.Capability_CAPABILITY_COMPARE) {
+			// Artifacts missing from the base build are for the lens to report.
+			baseArtifacts, baseFailures, err := fetchLensArtifacts(ctx, opts, request, request.BaseArtifactSource)
+			if err != nil {
+				writeHTTPError(w, fmt.Errorf("failed to retrieve base artifacts: %w", err), http.StatusInternalServerError)
+				return
+			}
+			var readFailures []*artifactFailure
+			renderRequest.BaseArtifacts, readFailures = toV2Artifacts(baseArtifacts, sizeLimit)
+			for _, failure := range append(baseFailures, readFailures...) {
+				failure.Base = true
+				failures = append(failures, failure)
+			}
+		}
+		ctx, done := startRender(ctx, opts, request)
+		defer done()
+		renderV2(ctx, w, client, opts, renderRequest, failures, len(artifacts) > 0)
+
+	case api.RequestActionCallBack:
+		ctx, done := startRender(ctx, opts, request)
+		defer done()
+		response, err := client.client.Callback(ctx, &lensv2.CallbackRequest{
+			Config:       lensConfig,
+			ResourceRoot: request.ResourceRoot,
+			Data:         request.Data,
+			Artifacts:    v2Artifacts,
+		})
+		if err != nil {
+			writeHTTPError(w, fmt.Errorf("lens %s failed to answer the callback: %w", opts.LensName, err), http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(response.Data))
+
+	case api.RequestActionStream:
+		ctx, done := startRender(ctx, opts, request)
+		defer done()
+		stream, err := client.client.Stream(ctx, &lensv2.CallbackRequest{
+			Config:       lensConfig,
+			ResourceRoot: request.ResourceRoot,
+			Data:         request.Data,
+			Artifacts:    v2Artifacts,
+		})
+		if err != nil {
+			writeHTTPError(w, fmt.Errorf("lens %s failed to stream: %w", opts.LensName, err), http.StatusBadGateway)
+			return
+		}
+		serveStream(ctx, w, func(send func(string) error) error {
+			for {
+				response, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if err := send(response.Data); err != nil {
+					return err
+				}
+			}
+		})
+
+	default:
+		writeHTTPError(w, fmt.Errorf("invalid action %q", request.Action), http.StatusBadRequest)
+	}
+}
+
+// lensV2BodyMarker stands for the body of a lens in its page, which is
+// written as the lens renders it.
+const lensV2BodyMarker = "<!-- lens body -->"
+
+// renderV2 renders a lens, writing what the lens rendered as soon as it
+// sends it. The page is only rendered with the head and errors once the lens
+// sent its first response, so that a failing lens can still fail the request.
+func renderV2(ctx context.Context, w http.ResponseWriter, client *LensV2Client, opts lensHandlerOpts, request *lensv2.RenderRequest, failures []*artifactFailure, render bool) {
+	var pageEnd string
+	started := false
+	start := func(head string) error {
+		started = true
+		w.Header().Set("Content-Type", "text/html; encoding=utf-8")
+		if !request.Initial {
+			return lensTemplate.ExecuteTemplate(w, "errors", failures)
+		}
+		var page strings.Builder
+		if err := lensTemplate.Execute(&page, struct {
+			Title   string
+			BaseURL string
+			Head    template.HTML
+			Errors  []*artifactFailure
+			Body    template.HTML
+		}{
+			opts.LensTitle,
+			request.ResourceRoot,
+			template.HTML(head),
+			failures,
+			lensV2BodyMarker,
+		}); err != nil {
+			return err
+		}
+		pageStart, end, _ := strings.Cut(page.String(), lensV2BodyMarker)
+		pageEnd = end
+		_, err := io.WriteString(w, pageStart)
+		return err
+	}
+	flush := func() {
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	err := func() error {
+		if !render {
+			// Only the artifacts that could not be fetched are listed.
+			return start("")
+		}
+		stream, err := client.client.Render(ctx, request)
+		if err != nil {
+			return err
+		}
+		for {
+			response, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				if !started {
+					return start("")
+				}
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !started {
+				if err := start(response.Head); err != nil {
+					return err
+				}
+			}
+			if _, err := io.WriteString(w, response.Body); err != nil {
+				return err
+			}
+			flush()
+		}
+	}()
+	if err != nil {
+		if !started {
+			writeHTTPError(w, fmt.Errorf("lens %s failed to render: %w", opts.LensName, err), http.StatusBadGateway)
+			return
+		}
+		logrus.WithError(err).WithField("lens", opts.LensName).Warn("Lens failed to render")
+		fmt.Fprintf(w, "<p>Lens failed to render: %s</p>", template.HTMLEscapeString(err.Error()))
+	}
+	io.WriteString(w, pageEnd)
+}
+
+// toV2Artifacts returns the artifacts to send to a lens, read up to
+// sizeLimit, and those that could not be read.
+func toV2Artifacts(artifacts []api.Artifact, sizeLimit int64) ([]*lensv2.Artifact, []*artifactFailure) {
+	var v2Artifacts []*lensv2.Artifact
+	var failures []*artifactFailure
+	for _, artifact := range artifacts {
+		size, err := artifact.Size()
+		if err != nil {
+			failures = append(failures, &artifactFailure{Name: artifact.JobPath(), Err: err})
+			continue
+		}
+		v2Artifact := &lensv2.Artifact{
+			Name: artifact.JobPath(),
+			Size: size,
+			Link: artifact.CanonicalLink(),
+		}
+		if read := min(size, sizeLimit); read > 0 {
+			content, err := artifact.ReadAtMost(read)
+			if err != nil && !errors.Is(err, io.EOF) {
+				failures = append(failures, &artifactFailure{Name: artifact.JobPath(), Err: err})
+				continue
+			}
+			v2Artifact.Content = content
+		}
+		v2Artifact.Truncated = size > sizeLimit
+		v2Artifacts = append(v2Artifacts, v2Artifact)
+	}
+	return v2Artifacts, failures
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	lensv2 "sigs.k8s.io/prow/pkg/spyglass/api/v2"
+)
+
+// fakeLensV2 renders the artifacts it is given in as many responses.
+type fakeLensV2 struct {
+	lensv2.UnimplementedLensServer
+	info        *lensv2.LensInfo
+	negotiated  int
+	renderErr   error
+	lastRequest *lensv2.RenderRequest
+}
+
+func (l *fakeLensV2) Negotiate(context.Context, *lensv2.NegotiateRequest) (*lensv2.LensInfo, error) {
+	l.negotiated++
+	if l.info == nil {
+		return nil, errors.New("injected negotiation error")
+	}
+	return l.info, nil
+}
+
+func (l *fakeLensV2) Render(request *lensv2.RenderRequest, stream lensv2.Lens_RenderServer) error {
+	l.lastRequest = request
+	if l.renderErr != nil {
+		return l.renderErr
+	}
+	for i, artifact := range request.Artifacts {
+		response := &lensv2.RenderResponse{Body: fmt.Sprintf("<p>%s: %s</p>", artifact.Name, artifact.Content)}
+		if i == 0 {
+			response.Head = "<style>p{}</style>"
+		}
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *fakeLensV2) Callback(_ context.Context, request *lensv2.CallbackRequest) (*lensv2.CallbackResponse, error) {
+	return &lensv2.CallbackResponse{Data: "callback: " + request.Data}, nil
+}
+
+func newFakeLensV2Client(t *testing.T, lens *fakeLensV2) *LensV2Client {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	lensv2.RegisterLensServer(server, lens)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial fake lens: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewLensV2Client(lensv2.NewLensClient(conn))
+}
+
+func TestLensV2Negotiate(t *testing.T) {
+	lens := &fakeLensV2{info: &lensv2.LensInfo{ProtocolVersion: "v2"}}
+	client := newFakeLensV2Client(t, lens)
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	if _, err := client.negotiate(context.Background()); err != nil {
+		t.Fatalf("failed to negotiate: %v", err)
+	}
+	if _, err := client.negotiate(context.Background()); err != nil {
+		t.Fatalf("failed to negotiate: %v", err)
+	}
+	if lens.negotiated != 1 {
+		t.Errorf("expected negotiation to be cached, lens negotiated %d times", lens.negotiated)
+	}
+
+	// A lens that fails to negotiate again is served as negotiated before.
+	lens.info = nil
+	now = now.Add(lensV2NegotiationTTL)
+	if _, err := client.negotiate(context.Background()); err != nil {
+		t.Errorf("expected what the lens negotiated before to be used, got: %v", err)
+	}
+	if lens.negotiated != 2 {
+		t.Errorf("expected the lens to negotiate again once outdated, lens negotiated %d times", lens.negotiated)
+	}
+
+	otherVersion := newFakeLensV2Client(t, &fakeLensV2{info: &lensv2.LensInfo{ProtocolVersion: "v3"}})
+	if _, err := otherVersion.negotiate(context.Background()); err == nil {
+		t.Error("expected negotiating with a lens speaking another protocol version to fail")
+	}
+}
+
+func TestLensV2MatchArtifacts(t *testing.T) {
+	artifactNames := []string{"build-log.txt", "artifacts/junit_01.xml", "artifacts/junit_02.xml", "started.json"}
+	cases := []struct {
+		name        string
+		info        *lensv2.LensInfo
+		wantMatches []string
+		wantOK      bool
+	}{
+		{
+			name: "required and optional files are matched",
+			info: &lensv2.LensInfo{
+				RequiredFiles: []string{`^artifacts/junit.*\.xml$`},
+				OptionalFiles: []string{`^started\.json$`, `^finished\.json$`},
+			},
+			wantMatches: []string{"artifacts/junit_01.xml", "artifacts/junit_02.xml", "started.json"},
+			wantOK:      true,
+		},
+		{
+			name: "missing required file",
+			info: &lensv2.LensInfo{RequiredFiles: []string{`^build-log\.txt$`, `^finished\.json$`}},
+		},
+		{
+			name: "no declared files",
+			info: &lensv2.LensInfo{},
+		},
+		{
+			name: "no optional file present",
+			info: &lensv2.LensInfo{OptionalFiles: []string{`^finished\.json$`}},
+		},
+		{
+			name: "lens fails to negotiate",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.info != nil {
+				tc.info.ProtocolVersion = "v2"
+			}
+			client := newFakeLensV2Client(t, &fakeLensV2{info: tc.info})
+			matches, ok := client.MatchArtifacts(context.Background(), artifactNames)
+			if ok != tc.wantOK {
+				t.Errorf("expected ok to be %t, got %t", tc.wantOK, ok)
+			}
+			if diff := cmp.Diff(tc.wantMatches, matches); diff != "" {
+				t.Errorf("unexpected matches (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLensV2Handler(t *testing.T) {
+	cases := []struct {
+		name           string
+		lens           *fakeLensV2
+		action         api.RequestAction
+		artifacts      []string
+		data           string
+		wantStatus     int
+		wantContains   []string
+		wantNoContains []string
+		wantArtifacts  []*lensv2.Artifact
+	}{
+		{
+			name:       "page is rendered with what the lens sends",
+			lens:       &fakeLensV2{info: &lensv2.LensInfo{}},
+			action:     api.RequestActionInitial,
+			artifacts:  []string{"started.json", "build-log.txt"},
+			wantStatus: http.StatusOK,
+			wantContains: []string{
+				"<style>p{}</style>",
+				"<p>started.json: {}</p><p>build-log.txt: 0123456789</p>",
+				"</html>",
+			},
+			wantNoContains: []string{lensV2BodyMarker},
+			wantArtifacts: []*lensv2.Artifact{
+				{Name: "started.json", Size: 2, Content: []byte("{}")},
+				{Name: "build-log.txt", Size: 10, Content: []byte("0123456789")},
+			},
+		},
+		{
+			name:       "artifacts are truncated to the size the lens accepts",
+			lens:       &fakeLensV2{info: &lensv2.LensInfo{MaxArtifactSize: 4}},
+			action:     api.RequestActionInitial,
+			artifacts:  []string{"build-log.txt"},
+			wantStatus: http.StatusOK,
+			wantArtifacts: []*lensv2.Artifact{
+				{Name: "build-log.txt", Size: 10, Content: []byte("0123"), Truncated: true},
+			},
+		},
+		{
+			name:       "artifacts that could not be fetched are listed",
+			lens:       &fakeLensV2{info: &lensv2.LensInfo{}},
+			action:     api.RequestActionRerender,
+			artifacts:  []string{"started.json", "missing.json"},
+			wantStatus: http.StatusOK,
+			wantContains: []string{
+				"<code>missing.json</code>",
+				"<p>started.json: {}</p>",
+			},
+			wantNoContains: []string{"</html>"},
+		},
+		{
+			name:         "lens failing to render fails the request",
+			lens:         &fakeLensV2{info: &lensv2.LensInfo{}, renderErr: errors.New("injected render error")},
+			action:       api.RequestActionInitial,
+			artifacts:    []string{"started.json"},
+			wantStatus:   http.StatusBadGateway,
+			wantContains: []string{"injected render error"},
+		},
+		{
+			name:         "lens failing to negotiate fails the request",
+			lens:         &fakeLensV2{},
+			action:       api.RequestActionInitial,
+			artifacts:    []string{"started.json"},
+			wantStatus:   http.StatusBadGateway,
+			wantContains: []string{"injected negotiation error"},
+		},
+		{
+			name:         "callback is answered by the lens",
+			lens:         &fakeLensV2{info: &lensv2.LensInfo{Capabilities: []lensv2.Capability{lensv2.Capability_CAPABILITY_CALLBACK}}},
+			action:       api.RequestActionCallBack,
+			artifacts:    []string{"started.json"},
+			data:         "data",
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"callback: data"},
+		},
+		{
+			name:         "callback is refused unless the lens supports it",
+			lens:         &fakeLensV2{info: &lensv2.LensInfo{}},
+			action:       api.RequestActionCallBack,
+			artifacts:    []string{"started.json"},
+			wantStatus:   http.StatusBadRequest,
+			wantContains: []string{"does not support callbacks"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.lens.info != nil {
+				tc.lens.info.ProtocolVersion = "v2"
+			}
+			cfg := &config.Config{ProwConfig: config.ProwConfig{Deck: config.Deck{Spyglass: config.Spyglass{
+				SizeLimit: 100,
+				Lenses:    []config.LensFileConfig{{Lens: config.LensConfig{Name: "v2"}}},
+			}}}}
+			fetcher := fakeArtifactFetcher{"started.json": "{}", "build-log.txt": "0123456789"}
+			handler := newLensV2Handler(newFakeLensV2Client(t, tc.lens), lensHandlerOpts{
+				StorageArtifactFetcher: fetcher,
+				PodLogArtifactFetcher:  fetcher,
+				ConfigGetter:           func() *config.Config { return cfg },
+				LensOpt:                LensOpt{LensName: "v2"},
+			})
+			body, err := json.Marshal(api.LensRequest{
+				Action:         tc.action,
+				Artifacts:      tc.artifacts,
+				ArtifactSource: "gs/bucket/logs/job/1",
+				Data:           tc.data,
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal request: %v", err)
+			}
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, w.Code)
+			}
+			got := w.Body.String()
+			for _, want := range tc.wantContains {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected the response to contain %q, got:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tc.wantNoContains {
+				if strings.Contains(got, notWant) {
+					t.Errorf("expected the response not to contain %q, got:\n%s", notWant, got)
+				}
+			}
+			if tc.wantArtifacts != nil {
+				if tc.lens.lastRequest == nil {
+					t.Fatal("expected the lens to render")
+				}
+				for _, artifact := range tc.lens.lastRequest.Artifacts {
+					artifact.Link = ""
+				}
+				if diff := cmp.Diff(tc.wantArtifacts, tc.lens.lastRequest.Artifacts, protocmp.Transform()); diff != "" {
+					t.Errorf("unexpected artifacts (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
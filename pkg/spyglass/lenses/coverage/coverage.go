@@ -22,6 +22,7 @@ import (
 	"compress/gzip"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"path/filepath"
@@ -76,16 +77,22 @@ func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data str
 
 // Body renders the <body>
 func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
-	if len(artifacts) == 0 {
-		logrus.Error("coverage Body() called with no artifacts, which should never happen.")
-		return "Why am I here? There is no coverage file."
-	}
+	return lens.render(artifacts, nil, false, resourceDir)
+}
+
+// Compare renders the <body> along with how the coverage of each package
+// changed since the base build, e.g. a build of the base branch.
+func (lens Lens) Compare(artifacts, baseArtifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return lens.render(artifacts, baseArtifacts, true, resourceDir)
+}
 
-	profileArtifact := artifacts[0]
-	var htmlArtifact api.Artifact
+// splitArtifacts returns the coverage file and the optional HTML rendering of
+// it among the artifacts.
+func splitArtifacts(artifacts []api.Artifact) (profileArtifact, htmlArtifact api.Artifact, err error) {
+	profileArtifact = artifacts[0]
 	if len(artifacts) > 1 {
 		if len(artifacts) > 2 {
-			return "Too many files - expected one coverage file and one optional HTML file"
+			return nil, nil, errors.New("Too many files - expected one coverage file and one optional HTML file")
 		}
 		if strings.HasSuffix(artifacts[0].JobPath(), ".html") {
 			htmlArtifact = artifacts[0]
@@ -94,15 +101,33 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 			htmlArtifact = artifacts[1]
 			profileArtifact = artifacts[0]
 		} else {
-			return "Multiple input files, but none had a .html extension."
+			return nil, nil, errors.New("Multiple input files, but none had a .html extension.")
 		}
 	}
+	return profileArtifact, htmlArtifact, nil
+}
+
+func (lens Lens) render(artifacts, baseArtifacts []api.Artifact, comparing bool, resourceDir string) string {
+	if len(artifacts) == 0 {
+		logrus.Error("coverage Body() called with no artifacts, which should never happen.")
+		return "Why am I here? There is no coverage file."
+	}
+
+	profileArtifact, htmlArtifact, err := splitArtifacts(artifacts)
+	if err != nil {
+		return err.Error()
+	}
 
 	content, err := profileArtifact.ReadAll()
 	if err != nil {
 		logrus.WithError(err).Warn("Couldn't read a coverage file that should exist.")
 		return fmt.Sprintf("Faiiled to read the coverage file: %v", err)
 	}
+	profile, err := parseProfile(content)
+	if err != nil {
+		logrus.WithError(err).Info("Failed to parse coverage file")
+		return fmt.Sprintf("Failed to parse the coverage file: %v", err)
+	}
 
 	coverageTemplate, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
 	if err != nil {
@@ -110,9 +135,11 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 		return fmt.Sprintf("Failed to load template file: %v", err)
 	}
 
+	// The front-end only understands Go coverprofiles, so other formats are
+	// converted to it.
 	w := &bytes.Buffer{}
 	g := gzip.NewWriter(w)
-	_, err = g.Write(content)
+	_, err = g.Write(profile.coverprofile())
 	if err != nil {
 		logrus.WithError(err).Warn("Failed to compress coverage file")
 		return fmt.Sprintf("Failed to compress coverage file: %v", err)
@@ -127,12 +154,31 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 	if htmlArtifact != nil {
 		renderedCoverageURL = htmlArtifact.CanonicalLink()
 	}
+	packages := profile.packages()
 	t := struct {
 		CoverageContent  string
 		RenderedCoverage string
+		Comparing        bool
+		// BaseError is why the coverage of the base build is unknown, if it is.
+		BaseError string
+		Total     packageDelta
+		Packages  []packageDelta
 	}{
 		CoverageContent:  result,
 		RenderedCoverage: renderedCoverageURL,
+		Comparing:        comparing,
+		Total:            packageDelta{packageCoverage: total(packages)},
+		Packages:         packageDeltas(packages, nil),
+	}
+	if comparing {
+		basePackages, err := baseCoverage(profileArtifact, baseArtifacts)
+		if err != nil {
+			t.BaseError = err.Error()
+		} else {
+			baseTotal := total(basePackages)
+			t.Total.Base = &baseTotal
+			t.Packages = packageDeltas(packages, basePackages)
+		}
 	}
 	var buf bytes.Buffer
 	if err := coverageTemplate.ExecuteTemplate(&buf, "body", t); err != nil {
@@ -141,3 +187,23 @@ func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string,
 
 	return buf.String()
 }
+
+// baseCoverage returns the coverage of the packages of the base build, read
+// from its coverage file of the same name.
+func baseCoverage(profileArtifact api.Artifact, baseArtifacts []api.Artifact) ([]packageCoverage, error) {
+	for _, artifact := range baseArtifacts {
+		if artifact.JobPath() != profileArtifact.JobPath() {
+			continue
+		}
+		content, err := artifact.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the coverage file of the base build: %w", err)
+		}
+		profile, err := parseProfile(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the coverage file of the base build: %w", err)
+		}
+		return profile.packages(), nil
+	}
+	return nil, fmt.Errorf("the base build has no %s", profileArtifact.JobPath())
+}
@@ -16,6 +16,7 @@ limitations under the License.
 
 import Color from "color";
 import {inflate} from "pako/lib/inflate";
+import {parseQuery} from "../../../../cmd/deck/static/common/urls";
 import {Coverage, parseCoverage} from './parser';
 
 declare const COVERAGE_FILE: string;
@@ -66,7 +67,53 @@ function renderChildren(parent: Node, coverage: Coverage, horizontal: boolean):
   }
 }
 
+// The base build is chosen with the base parameter of the Spyglass page,
+// which passes it on to the requests of all of its lenses.
+function topURL(): URL {
+  return new URL(parseQuery(location.search.substr(1)).topURL!);
+}
+
+// A build ID refers to a build of the same job, whose builds are stored next
+// to each other. Builds of other jobs, e.g. of the base branch, are referred
+// to by their path.
+function baseSource(build: string): string {
+  if (build.includes('/')) {
+    return build.replace(/^\/+/, '');
+  }
+  const req = JSON.parse(parseQuery(location.search.substr(1)).req!) as {src: string};
+  const parts = req.src.replace(/\/+$/, '').split('/');
+  parts[parts.length - 1] = build;
+  return parts.join('/');
+}
+
+function setupCompare(): void {
+  const form = document.querySelector<HTMLFormElement>('#coverage-choose');
+  if (form) {
+    form.addEventListener('submit', (e) => {
+      e.preventDefault();
+      const build = document.querySelector<HTMLInputElement>('#coverage-base')!.value.trim();
+      if (build === '') {
+        return;
+      }
+      const url = topURL();
+      url.searchParams.set('base', baseSource(build));
+      window.top!.location.href = url.toString();
+    });
+  }
+  const base = document.querySelector<HTMLSpanElement>('#coverage-base-build');
+  if (base) {
+    base.innerText = topURL().searchParams.get('base') || '';
+  }
+  const clear = document.querySelector<HTMLAnchorElement>('#coverage-clear');
+  if (clear) {
+    const url = topURL();
+    url.searchParams.delete('base');
+    clear.href = url.toString();
+  }
+}
+
 window.onload = () => {
+  setupCompare();
   // Because the coverage files are a) huge, and b) compress excellently, we send it as
   // gzipped base64. This is faster unless your internet connection is faster than
   // about 300 Mb/s.
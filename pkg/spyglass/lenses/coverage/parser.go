@@ -0,0 +1,295 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// block is a block of statements of a file, e.g. a line for Cobertura.
+type block struct {
+	startLine, startCol int
+	endLine, endCol     int
+	statements          int
+	hits                int64
+}
+
+func (b block) key() string {
+	return fmt.Sprintf("%d.%d,%d.%d", b.startLine, b.startCol, b.endLine, b.endCol)
+}
+
+type fileProfile struct {
+	pkg    string
+	blocks map[string]*block
+	// order is the order of the keys of the blocks as they were parsed.
+	order []string
+}
+
+// profile is the coverage of the files of a build, whatever the format it was
+// reported in.
+type profile struct {
+	mode  string
+	files map[string]*fileProfile
+	// order is the order of the files as they were parsed.
+	order []string
+}
+
+func newProfile(mode string) *profile {
+	return &profile{mode: mode, files: map[string]*fileProfile{}}
+}
+
+// addBlock adds a block of a file of a package, merging it with a block
+// that covers the same statements, e.g. reported by another test binary.
+func (p *profile) addBlock(pkg, filename string, b block) {
+	f, ok := p.files[filename]
+	if !ok {
+		f = &fileProfile{pkg: pkg, blocks: map[string]*block{}}
+		p.files[filename] = f
+		p.order = append(p.order, filename)
+	}
+	k := b.key()
+	if existing, ok := f.blocks[k]; ok {
+		existing.hits += b.hits
+		return
+	}
+	f.blocks[k] = &b
+	f.order = append(f.order, k)
+}
+
+// packageCoverage is the coverage of the statements of a package.
+type packageCoverage struct {
+	Name    string
+	Covered int
+	Total   int
+}
+
+// Percent is the share of the statements of the package that are covered.
+func (c packageCoverage) Percent() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return 100 * float64(c.Covered) / float64(c.Total)
+}
+
+// packages returns the coverage of each package of the profile, sorted by
+// name.
+func (p *profile) packages() []packageCoverage {
+	byName := map[string]*packageCoverage{}
+	for _, f := range p.files {
+		c, ok := byName[f.pkg]
+		if !ok {
+			c = &packageCoverage{Name: f.pkg}
+			byName[f.pkg] = c
+		}
+		for _, b := range f.blocks {
+			c.Total += b.statements
+			if b.hits > 0 {
+				c.Covered += b.statements
+			}
+		}
+	}
+	var packages []packageCoverage
+	for _, c := range byName {
+		packages = append(packages, *c)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+	return packages
+}
+
+// coverprofile returns the profile in the format of Go coverprofiles, which
+// is the format the front-end of the lens renders.
+func (p *profile) coverprofile() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "mode: %s\n", p.mode)
+	for _, filename := range p.order {
+		f := p.files[filename]
+		for _, k := range f.order {
+			b := f.blocks[k]
+			fmt.Fprintf(&buf, "%s:%s %d %d\n", filename, k, b.statements, b.hits)
+		}
+	}
+	return buf.Bytes()
+}
+
+// parseProfile parses a coverage file, either a Go coverprofile or a
+// Cobertura XML report.
+func parseProfile(content []byte) (*profile, error) {
+	trimmed := bytes.TrimSpace(content)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		return parseCoverprofile(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return parseCobertura(trimmed)
+	default:
+		return nil, errors.New("unknown coverage format: expected a Go coverprofile or a Cobertura XML report")
+	}
+}
+
+// parseCoverprofile parses the output of `go test -coverprofile`, whose lines
+// are like `name.go:line.column,line.column numberOfStatements count`.
+func parseCoverprofile(content []byte) (*profile, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	scanner.Scan()
+	p := newProfile(strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "mode:")))
+	for lineNumber := 2; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			// Concatenated profiles repeat the mode.
+			continue
+		}
+		filename, b, err := parseCoverprofileLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		p.addBlock(path.Dir(filename), filename, b)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func parseCoverprofileLine(line string) (string, block, error) {
+	var b block
+	colon := strings.LastIndex(line, ":")
+	if colon == -1 {
+		return "", b, fmt.Errorf("invalid block %q", line)
+	}
+	filename := line[:colon]
+	fields := strings.Fields(line[colon+1:])
+	if len(fields) != 3 {
+		return "", b, fmt.Errorf("invalid block %q", line)
+	}
+	if _, err := fmt.Sscanf(fields[0], "%d.%d,%d.%d", &b.startLine, &b.startCol, &b.endLine, &b.endCol); err != nil {
+		return "", b, fmt.Errorf("invalid position in block %q: %w", line, err)
+	}
+	var err error
+	if b.statements, err = strconv.Atoi(fields[1]); err != nil {
+		return "", b, fmt.Errorf("invalid number of statements in block %q: %w", line, err)
+	}
+	if b.hits, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+		return "", b, fmt.Errorf("invalid count in block %q: %w", line, err)
+	}
+	return filename, b, nil
+}
+
+type coberturaReport struct {
+	XMLName  xml.Name `xml:"coverage"`
+	Packages []struct {
+		Name    string `xml:"name,attr"`
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number int   `xml:"number,attr"`
+				Hits   int64 `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// parseCobertura parses a Cobertura XML report. Each line is a block of one
+// statement, and the files are grouped by the packages of the report.
+func parseCobertura(content []byte) (*profile, error) {
+	var report coberturaReport
+	if err := xml.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("invalid Cobertura report: %w", err)
+	}
+	p := newProfile("count")
+	for _, pkg := range report.Packages {
+		for _, class := range pkg.Classes {
+			name := pkg.Name
+			if name == "" {
+				name = path.Dir(class.Filename)
+			}
+			for _, line := range class.Lines {
+				p.addBlock(name, class.Filename, block{
+					startLine:  line.Number,
+					endLine:    line.Number,
+					statements: 1,
+					hits:       line.Hits,
+				})
+			}
+		}
+	}
+	return p, nil
+}
+
+// packageDelta is how the coverage of a package changed since the base build.
+type packageDelta struct {
+	packageCoverage
+	// Base is the coverage of the package in the base build, if it has it.
+	Base *packageCoverage
+}
+
+// Removed is whether the package is only in the base build.
+func (d packageDelta) Removed() bool {
+	return d.Base != nil && d.Total == 0
+}
+
+// Delta is the difference in percentage points with the base build.
+func (d packageDelta) Delta() float64 {
+	if d.Base == nil || d.Removed() {
+		return 0
+	}
+	return d.Percent() - d.Base.Percent()
+}
+
+// total returns the coverage of all the packages.
+func total(packages []packageCoverage) packageCoverage {
+	var t packageCoverage
+	for _, c := range packages {
+		t.Covered += c.Covered
+		t.Total += c.Total
+	}
+	return t
+}
+
+// packageDeltas returns the coverage of the packages of a profile along with
+// their coverage in the base profile, if any. Packages only in the base
+// profile are listed with no statements.
+func packageDeltas(packages, basePackages []packageCoverage) []packageDelta {
+	base := map[string]packageCoverage{}
+	for _, c := range basePackages {
+		base[c.Name] = c
+	}
+	var deltas []packageDelta
+	for _, c := range packages {
+		d := packageDelta{packageCoverage: c}
+		if b, ok := base[c.Name]; ok {
+			d.Base = &b
+			delete(base, c.Name)
+		}
+		deltas = append(deltas, d)
+	}
+	for _, b := range basePackages {
+		if _, removed := base[b.Name]; removed {
+			b := b
+			deltas = append(deltas, packageDelta{packageCoverage: packageCoverage{Name: b.Name}, Base: &b})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Name < deltas[j].Name })
+	return deltas
+}
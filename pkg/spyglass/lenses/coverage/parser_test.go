@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseProfile(t *testing.T) {
+	testCases := []struct {
+		name                string
+		content             string
+		expectedPackages    []packageCoverage
+		expectedProfile     string
+		expectedErrContains string
+	}{
+		{
+			name: "go coverprofile",
+			content: `mode: set
+example.com/a/a.go:1.1,3.2 2 1
+example.com/a/a.go:5.1,6.2 1 0
+example.com/b/b.go:1.1,2.2 4 0
+mode: set
+example.com/a/a.go:5.1,6.2 1 1
+`,
+			expectedPackages: []packageCoverage{
+				{Name: "example.com/a", Covered: 3, Total: 3},
+				{Name: "example.com/b", Covered: 0, Total: 4},
+			},
+			expectedProfile: `mode: set
+example.com/a/a.go:1.1,3.2 2 1
+example.com/a/a.go:5.1,6.2 1 1
+example.com/b/b.go:1.1,2.2 4 0
+`,
+		},
+		{
+			name: "cobertura",
+			content: `<?xml version="1.0" ?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage line-rate="0.5" version="5.5">
+  <sources><source>/src</source></sources>
+  <packages>
+    <package name="app" line-rate="0.5">
+      <classes>
+        <class name="main.py" filename="app/main.py" line-rate="0.5">
+          <methods/>
+          <lines>
+            <line number="1" hits="1"/>
+            <line number="2" hits="0"/>
+          </lines>
+        </class>
+      </classes>
+    </package>
+    <package name="">
+      <classes>
+        <class name="util.py" filename="lib/util.py">
+          <lines>
+            <line number="3" hits="5"/>
+          </lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>`,
+			expectedPackages: []packageCoverage{
+				{Name: "app", Covered: 1, Total: 2},
+				{Name: "lib", Covered: 1, Total: 1},
+			},
+			expectedProfile: `mode: count
+app/main.py:1.0,1.0 1 1
+app/main.py:2.0,2.0 1 0
+lib/util.py:3.0,3.0 1 5
+`,
+		},
+		{
+			name:                "unknown format",
+			content:             `{"coverage": 1}`,
+			expectedErrContains: "unknown coverage format",
+		},
+		{
+			name: "invalid coverprofile",
+			content: `mode: set
+example.com/a/a.go:1.1,3.2 two 1
+`,
+			expectedErrContains: "line 2: invalid number of statements",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := parseProfile([]byte(tc.content))
+			if tc.expectedErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErrContains) {
+					t.Fatalf("expected an error containing %q, got %v", tc.expectedErrContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedPackages, p.packages()); diff != "" {
+				t.Errorf("unexpected packages (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedProfile, string(p.coverprofile())); diff != "" {
+				t.Errorf("unexpected coverprofile (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPackageDeltas(t *testing.T) {
+	packages := []packageCoverage{
+		{Name: "kept", Covered: 1, Total: 4},
+		{Name: "new", Covered: 1, Total: 1},
+	}
+	basePackages := []packageCoverage{
+		{Name: "kept", Covered: 2, Total: 4},
+		{Name: "gone", Covered: 1, Total: 2},
+	}
+	deltas := packageDeltas(packages, basePackages)
+
+	var names []string
+	actual := map[string]float64{}
+	for _, d := range deltas {
+		names = append(names, d.Name)
+		actual[d.Name] = d.Delta()
+	}
+	if diff := cmp.Diff([]string{"gone", "kept", "new"}, names); diff != "" {
+		t.Errorf("unexpected packages (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]float64{"gone": 0, "kept": -25, "new": 0}, actual); diff != "" {
+		t.Errorf("unexpected deltas (-want +got):\n%s", diff)
+	}
+	if !deltas[0].Removed() || deltas[1].Removed() || deltas[2].Base != nil {
+		t.Errorf("expected gone to be removed and new to have no base, got %+v", deltas)
+	}
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diff provides a lens that compares the artifacts of a build with
+// the artifacts of the same names of another build.
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "diff"
+	title    = "Diff"
+	priority = 5
+
+	defaultContextLines = 3
+	defaultMaxSize      = 1 << 20
+
+	// splitView shows the artifacts of both builds side by side instead of
+	// as a unified diff.
+	splitView = "split"
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens compares the artifacts of a build with those of a base build.
+type Lens struct{}
+
+type lensConfig struct {
+	// ContextLines is the number of unchanged lines shown around changes.
+	// Defaults to 3.
+	ContextLines *int `json:"context_lines,omitempty"`
+	// MaxSize is the size in bytes above which artifacts are not compared.
+	// Defaults to 1MiB.
+	MaxSize int64 `json:"max_size,omitempty"`
+}
+
+func parseConfig(raw json.RawMessage) lensConfig {
+	var c lensConfig
+	if len(raw) != 0 {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			logrus.WithError(err).Warn("Failed to parse the config of the diff lens, using the defaults.")
+		}
+	}
+	if c.ContextLines == nil || *c.ContextLines < 0 {
+		contextLines := defaultContextLines
+		c.ContextLines = &contextLines
+	}
+	if c.MaxSize <= 0 {
+		c.MaxSize = defaultMaxSize
+	}
+	return c
+}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "header", nil)
+	if err != nil {
+		logrus.Warnf("Failed to render header: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Body renders the form to choose the build to compare with, as there is no
+// base build yet.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	var names []string
+	for _, artifact := range artifacts {
+		names = append(names, artifact.JobPath())
+	}
+	output, err := renderTemplate(resourceDir, "choose", names)
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Callback does nothing.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return ""
+}
+
+// Compare renders the diffs of the artifacts with those of the base build,
+// unified or side by side if data is "split".
+func (lens Lens) Compare(artifacts, baseArtifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	c := parseConfig(rawConfig)
+	base := map[string]api.Artifact{}
+	for _, artifact := range baseArtifacts {
+		base[artifact.JobPath()] = artifact
+	}
+	params := struct {
+		Split bool
+		Diffs []artifactDiff
+	}{Split: data == splitView}
+	for _, artifact := range artifacts {
+		params.Diffs = append(params.Diffs, diffArtifact(artifact, base[artifact.JobPath()], c))
+	}
+	output, err := renderTemplate(resourceDir, "compare", params)
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+func renderTemplate(resourceDir, block string, params interface{}) (string, error) {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, block, params); err != nil {
+		return "", fmt.Errorf("Failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Kinds of lines of a diff.
+const (
+	lineContext = "context"
+	lineAdded   = "added"
+	lineRemoved = "removed"
+	lineEmpty   = "empty"
+)
+
+// line is a line of a diff. Old and New are its line numbers in the base and
+// the current artifact, or 0 if it is not in them.
+type line struct {
+	Kind string
+	Old  int
+	New  int
+	Text string
+}
+
+// row is a line of the base and of the current artifact, side by side.
+type row struct {
+	Left  line
+	Right line
+}
+
+type hunk struct {
+	Header string
+	// Lines are the lines of the unified diff.
+	Lines []line
+	// Rows are the lines of the side by side diff.
+	Rows []row
+}
+
+// Statuses of the artifacts.
+const (
+	statusChanged   = "changed"
+	statusUnchanged = "unchanged"
+	statusAdded     = "not in the base build"
+	statusTooLarge  = "too large to compare"
+	statusError     = "failed to read"
+)
+
+type artifactDiff struct {
+	Name   string
+	Status string
+	Hunks  []hunk
+}
+
+func diffArtifact(artifact, baseArtifact api.Artifact, c lensConfig) artifactDiff {
+	diff := artifactDiff{Name: artifact.JobPath()}
+	if baseArtifact == nil {
+		diff.Status = statusAdded
+		return diff
+	}
+	content, status := read(artifact, c.MaxSize)
+	if status != "" {
+		diff.Status = status
+		return diff
+	}
+	baseContent, status := read(baseArtifact, c.MaxSize)
+	if status != "" {
+		diff.Status = status
+		return diff
+	}
+	if bytes.Equal(content, baseContent) {
+		diff.Status = statusUnchanged
+		return diff
+	}
+	diff.Status = statusChanged
+	diff.Hunks = diffLines(splitLines(baseContent), splitLines(content), *c.ContextLines)
+	return diff
+}
+
+func read(artifact api.Artifact, maxSize int64) ([]byte, string) {
+	size, err := artifact.Size()
+	if err != nil {
+		logrus.WithError(err).WithField("artifact", artifact.JobPath()).Info("Failed to get the size of the artifact.")
+		return nil, statusError
+	}
+	if size > maxSize {
+		return nil, statusTooLarge
+	}
+	content, err := artifact.ReadAll()
+	if err != nil {
+		logrus.WithError(err).WithField("artifact", artifact.JobPath()).Info("Failed to read the artifact.")
+		return nil, statusError
+	}
+	return content, ""
+}
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+// diffLines returns the hunks of changes from old to new lines, with up to
+// contextLines unchanged lines around each change.
+func diffLines(old, new []string, contextLines int) []hunk {
+	var hunks []hunk
+	matcher := difflib.NewMatcher(old, new)
+	for _, group := range matcher.GetGroupedOpCodes(contextLines) {
+		first, last := group[0], group[len(group)-1]
+		h := hunk{Header: fmt.Sprintf("@@ -%d,%d +%d,%d @@", first.I1+1, last.I2-first.I1, first.J1+1, last.J2-first.J1)}
+		for _, op := range group {
+			if op.Tag == 'e' {
+				for i, j := op.I1, op.J1; i < op.I2; i, j = i+1, j+1 {
+					l := line{Kind: lineContext, Old: i + 1, New: j + 1, Text: old[i]}
+					h.Lines = append(h.Lines, l)
+					h.Rows = append(h.Rows, row{Left: l, Right: l})
+				}
+				continue
+			}
+			var removed, added []line
+			for i := op.I1; i < op.I2; i++ {
+				removed = append(removed, line{Kind: lineRemoved, Old: i + 1, Text: old[i]})
+			}
+			for j := op.J1; j < op.J2; j++ {
+				added = append(added, line{Kind: lineAdded, New: j + 1, Text: new[j]})
+			}
+			h.Lines = append(append(h.Lines, removed...), added...)
+			for k := 0; k < len(removed) || k < len(added); k++ {
+				r := row{Left: line{Kind: lineEmpty}, Right: line{Kind: lineEmpty}}
+				if k < len(removed) {
+					r.Left = removed[k]
+				}
+				if k < len(added) {
+					r.Right = added[k]
+				}
+				h.Rows = append(h.Rows, r)
+			}
+		}
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
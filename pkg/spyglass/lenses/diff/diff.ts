@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import {parseQuery} from "../../../../cmd/deck/static/common/urls";
+
+// The base build is chosen with the base parameter of the Spyglass page,
+// which passes it on to the requests of all of its lenses.
+function topURL(): URL {
+  return new URL(parseQuery(location.search.substr(1)).topURL!);
+}
+
+function source(): string {
+  const req = JSON.parse(parseQuery(location.search.substr(1)).req!) as {src: string};
+  return req.src;
+}
+
+// The builds of a job are stored next to each other, so the base build is
+// found by replacing the build ID at the end of the source of this one.
+function baseSource(build: string): string {
+  const parts = source().replace(/\/+$/, '').split('/');
+  parts[parts.length - 1] = build;
+  return parts.join('/');
+}
+
+function navigateTop(url: URL): void {
+  window.top!.location.href = url.toString();
+}
+
+function setup(): void {
+  const form = document.querySelector<HTMLFormElement>('#diff-choose');
+  if (form) {
+    form.addEventListener('submit', (e) => {
+      e.preventDefault();
+      const build = document.querySelector<HTMLInputElement>('#diff-base')!.value.trim();
+      if (build === '') {
+        return;
+      }
+      const url = topURL();
+      url.searchParams.set('base', baseSource(build));
+      navigateTop(url);
+    });
+  }
+  updateControls();
+}
+
+function updateControls(): void {
+  const base = document.querySelector<HTMLSpanElement>('#diff-base-build');
+  if (base) {
+    const src = topURL().searchParams.get('base') || '';
+    base.innerText = src.split('/').filter((part) => part !== '').pop() || src;
+  }
+  const clear = document.querySelector<HTMLAnchorElement>('#diff-clear');
+  if (clear) {
+    const url = topURL();
+    url.searchParams.delete('base');
+    clear.href = url.toString();
+  }
+}
+
+// The view buttons are handled by delegation, as switching views replaces them.
+document.addEventListener('click', async (e) => {
+  const button = (e.target as HTMLElement).closest<HTMLButtonElement>('button.diff-view');
+  if (!button) {
+    return;
+  }
+  await spyglass.updatePage(button.dataset.view || '');
+  updateControls();
+});
+
+window.addEventListener('load', setup);
@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name         string
+		old          []string
+		new          []string
+		contextLines int
+		expected     []hunk
+	}{
+		{
+			name: "identical lines have no hunks",
+			old:  []string{"a", "b"},
+			new:  []string{"a", "b"},
+		},
+		{
+			name:         "replaced line",
+			old:          []string{"a", "b", "c"},
+			new:          []string{"a", "x", "c"},
+			contextLines: 1,
+			expected: []hunk{{
+				Header: "@@ -1,3 +1,3 @@",
+				Lines: []line{
+					{Kind: lineContext, Old: 1, New: 1, Text: "a"},
+					{Kind: lineRemoved, Old: 2, Text: "b"},
+					{Kind: lineAdded, New: 2, Text: "x"},
+					{Kind: lineContext, Old: 3, New: 3, Text: "c"},
+				},
+				Rows: []row{
+					{Left: line{Kind: lineContext, Old: 1, New: 1, Text: "a"}, Right: line{Kind: lineContext, Old: 1, New: 1, Text: "a"}},
+					{Left: line{Kind: lineRemoved, Old: 2, Text: "b"}, Right: line{Kind: lineAdded, New: 2, Text: "x"}},
+					{Left: line{Kind: lineContext, Old: 3, New: 3, Text: "c"}, Right: line{Kind: lineContext, Old: 3, New: 3, Text: "c"}},
+				},
+			}},
+		},
+		{
+			name: "inserted lines are padded on the left",
+			old:  []string{"a"},
+			new:  []string{"a", "b", "c"},
+			expected: []hunk{{
+				Header: "@@ -2,0 +2,2 @@",
+				Lines: []line{
+					{Kind: lineAdded, New: 2, Text: "b"},
+					{Kind: lineAdded, New: 3, Text: "c"},
+				},
+				Rows: []row{
+					{Left: line{Kind: lineEmpty}, Right: line{Kind: lineAdded, New: 2, Text: "b"}},
+					{Left: line{Kind: lineEmpty}, Right: line{Kind: lineAdded, New: 3, Text: "c"}},
+				},
+			}},
+		},
+		{
+			name:         "distant changes are separate hunks",
+			old:          []string{"a", "b", "c", "d", "e", "f"},
+			new:          []string{"x", "b", "c", "d", "e", "y"},
+			contextLines: 1,
+			expected: []hunk{
+				{
+					Header: "@@ -1,2 +1,2 @@",
+					Lines: []line{
+						{Kind: lineRemoved, Old: 1, Text: "a"},
+						{Kind: lineAdded, New: 1, Text: "x"},
+						{Kind: lineContext, Old: 2, New: 2, Text: "b"},
+					},
+					Rows: []row{
+						{Left: line{Kind: lineRemoved, Old: 1, Text: "a"}, Right: line{Kind: lineAdded, New: 1, Text: "x"}},
+						{Left: line{Kind: lineContext, Old: 2, New: 2, Text: "b"}, Right: line{Kind: lineContext, Old: 2, New: 2, Text: "b"}},
+					},
+				},
+				{
+					Header: "@@ -5,2 +5,2 @@",
+					Lines: []line{
+						{Kind: lineContext, Old: 5, New: 5, Text: "e"},
+						{Kind: lineRemoved, Old: 6, Text: "f"},
+						{Kind: lineAdded, New: 6, Text: "y"},
+					},
+					Rows: []row{
+						{Left: line{Kind: lineContext, Old: 5, New: 5, Text: "e"}, Right: line{Kind: lineContext, Old: 5, New: 5, Text: "e"}},
+						{Left: line{Kind: lineRemoved, Old: 6, Text: "f"}, Right: line{Kind: lineAdded, New: 6, Text: "y"}},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, diffLines(tc.old, tc.new, tc.contextLines)); diff != "" {
+				t.Errorf("unexpected hunks (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffArtifact(t *testing.T) {
+	contextLines := 0
+	tests := []struct {
+		name           string
+		artifact       *fake.Artifact
+		baseArtifact   *fake.Artifact
+		expectedStatus string
+		expectedHunks  int
+	}{
+		{
+			name:           "not in the base build",
+			artifact:       &fake.Artifact{Path: "build-log.txt", Content: []byte("a\n")},
+			expectedStatus: statusAdded,
+		},
+		{
+			name:           "unchanged",
+			artifact:       &fake.Artifact{Path: "build-log.txt", Content: []byte("a\n")},
+			baseArtifact:   &fake.Artifact{Path: "build-log.txt", Content: []byte("a\n")},
+			expectedStatus: statusUnchanged,
+		},
+		{
+			name:           "changed",
+			artifact:       &fake.Artifact{Path: "build-log.txt", Content: []byte("a\nb\n")},
+			baseArtifact:   &fake.Artifact{Path: "build-log.txt", Content: []byte("a\nc\n")},
+			expectedStatus: statusChanged,
+			expectedHunks:  1,
+		},
+		{
+			name:           "too large",
+			artifact:       &fake.Artifact{Path: "build-log.txt", Content: []byte("a\nb\nc\n")},
+			baseArtifact:   &fake.Artifact{Path: "build-log.txt", Content: []byte("a\n")},
+			expectedStatus: statusTooLarge,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var baseArtifact api.Artifact
+			if tc.baseArtifact != nil {
+				baseArtifact = tc.baseArtifact
+			}
+			diff := diffArtifact(tc.artifact, baseArtifact, lensConfig{ContextLines: &contextLines, MaxSize: 4})
+			if diff.Status != tc.expectedStatus {
+				t.Errorf("expected status %q, got %q", tc.expectedStatus, diff.Status)
+			}
+			if len(diff.Hunks) != tc.expectedHunks {
+				t.Errorf("expected %d hunks, got %d", tc.expectedHunks, len(diff.Hunks))
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	artifacts := []api.Artifact{&fake.Artifact{Path: "build-log.txt", Content: []byte("a\nnew line\n")}}
+	baseArtifacts := []api.Artifact{&fake.Artifact{Path: "build-log.txt", Content: []byte("a\nold line\n")}}
+
+	tests := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name:     "unified",
+			expected: []string{"@@ -2,1 &#43;2,1 @@", "-old line", "+new line"},
+		},
+		{
+			name:     "side by side",
+			data:     splitView,
+			expected: []string{"@@ -2,1 &#43;2,1 @@", `colspan="6"`, ">old line<", ">new line<"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body := Lens{}.Compare(artifacts, baseArtifacts, ".", tc.data, []byte(`{"context_lines":0}`), config.Spyglass{})
+			for _, expected := range tc.expected {
+				if !strings.Contains(body, expected) {
+					t.Errorf("expected the body to contain %q, got:\n%s", expected, body)
+				}
+			}
+		})
+	}
+}
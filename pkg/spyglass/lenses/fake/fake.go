@@ -84,3 +84,14 @@ func (fa *Artifact) ReadAtMost(n int64) ([]byte, error) {
 	_, err := fa.ReadAt(buf, 0)
 	return buf, err
 }
+
+func (fa *Artifact) NewRangeReader(off, length int64) (io.ReadCloser, error) {
+	if off < 0 || off > int64(len(fa.Content)) {
+		return nil, io.EOF
+	}
+	content := fa.Content[off:]
+	if length >= 0 && length < int64(len(content)) {
+		content = content[:length]
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// heightMessage must match spyglass.SandboxHeightMessage.
+const heightMessage = 'spyglass-sandbox-height';
+
+// resizeFrame resizes the frame of the report that posted its height, reports
+// cannot be identified otherwise as they live in an opaque origin.
+const resizeFrame = (e: MessageEvent): void => {
+  if (!e.data || e.data.type !== heightMessage || typeof e.data.height !== 'number') {
+    return;
+  }
+  const frames = document.querySelectorAll<HTMLIFrameElement>('iframe.htmlreport-frame');
+  for (const frame of Array.from(frames)) {
+    if (frame.contentWindow !== e.source) {
+      continue;
+    }
+    // Reports sized to their frame report its height back, so only resize
+    // on changes to not loop.
+    const height = `${Math.ceil(e.data.height)}px`;
+    if (frame.style.height !== height) {
+      frame.style.height = height;
+      spyglass.contentUpdated();
+    }
+    return;
+  }
+};
+
+window.addEventListener('message', resizeFrame);
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package htmlreport provides a lens that embeds self-contained HTML reports,
+// such as those of Ginkgo or pytest-html, in sandboxed frames.
+package htmlreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "htmlreport"
+	title    = "HTML Report"
+	priority = 15
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens embeds HTML reports served sandboxed by Deck.
+type Lens struct{}
+
+// report is an HTML report and the link Deck serves it sandboxed at.
+type report struct {
+	Name string
+	Link string
+}
+
+type view struct {
+	Reports []report
+	// Errors are the reports that cannot be sandboxed.
+	Errors []string
+}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "header", nil)
+	if err != nil {
+		logrus.Warnf("Failed to render header: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Body renders a sandboxed frame for each report. The reports are not read
+// by the lens, the frames load them from Deck, which rewrites their relative
+// URLs so assets next to them load from the same bucket.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "body", newView(artifacts))
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Callback does nothing.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return ""
+}
+
+func newView(artifacts []api.Artifact) view {
+	var v view
+	for _, artifact := range artifacts {
+		link := lenses.SandboxedArtifactLink(artifact)
+		if link == "" {
+			v.Errors = append(v.Errors, fmt.Sprintf("%s is not stored in a storage bucket and cannot be sandboxed.", artifact.JobPath()))
+			continue
+		}
+		v.Reports = append(v.Reports, report{Name: artifact.JobPath(), Link: link})
+	}
+	return v
+}
+
+func renderTemplate(resourceDir, block string, params interface{}) (string, error) {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, block, params); err != nil {
+		return "", fmt.Errorf("Failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
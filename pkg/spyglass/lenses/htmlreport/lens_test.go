@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package htmlreport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+// storedArtifact is an artifact stored in a storage bucket.
+type storedArtifact struct {
+	*fake.Artifact
+	storagePath string
+}
+
+func (a storedArtifact) StoragePath() string {
+	return a.storagePath
+}
+
+func TestNewView(t *testing.T) {
+	artifacts := []api.Artifact{
+		storedArtifact{Artifact: &fake.Artifact{Path: "artifacts/report/index.html"}, storagePath: "gs://bucket/logs/job/1/artifacts/report/index.html"},
+		&fake.Artifact{Path: "artifacts/pod-report.html"},
+	}
+	expected := view{
+		Reports: []report{{Name: "artifacts/report/index.html", Link: "/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/index.html"}},
+		Errors:  []string{"artifacts/pod-report.html is not stored in a storage bucket and cannot be sandboxed."},
+	}
+	if diff := cmp.Diff(expected, newView(artifacts)); diff != "" {
+		t.Errorf("view differs from expected (-want +got):\n%s", diff)
+	}
+}
+
+func TestBody(t *testing.T) {
+	artifacts := []api.Artifact{
+		storedArtifact{Artifact: &fake.Artifact{Path: "artifacts/ginkgo.html"}, storagePath: "gs://bucket/logs/job/1/artifacts/ginkgo.html"},
+	}
+	body := Lens{}.Body(artifacts, ".", "", nil, config.Spyglass{})
+	for _, expected := range []string{
+		`src="/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/ginkgo.html"`,
+		`sandbox="allow-scripts allow-popups"`,
+		`data-spyglass-artifact="artifacts/ginkgo.html"`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected the body to contain %q, got:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(body, "allow-same-origin") {
+		t.Errorf("expected reports to be sandboxed in an opaque origin, got:\n%s", body)
+	}
+}
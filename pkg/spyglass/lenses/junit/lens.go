@@ -22,8 +22,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
@@ -41,8 +44,16 @@ const (
 	passedStatus  testStatus = "Passed"
 	failedStatus  testStatus = "Failed"
 	skippedStatus testStatus = "Skipped"
+
+	// containerArtifactsDir is where the test container writes the artifacts
+	// that are uploaded to the artifacts directory of the job.
+	containerArtifactsDir = "/logs/artifacts/"
 )
 
+// attachmentRe matches the attachments that tests print to their output, as
+// understood by the Jenkins and GitLab junit reports.
+var attachmentRe = regexp.MustCompile(`\[\[ATTACHMENT\|([^\]]+)\]\]`)
+
 func init() {
 	lenses.RegisterLens(Lens{})
 }
@@ -89,6 +100,20 @@ func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data str
 
 type JunitResult struct {
 	junit.Result
+	Attachments []Attachment
+}
+
+// Attachment is a file that a test attached to its result.
+type Attachment struct {
+	// Path is the path to the file as the test recorded it.
+	Path string
+	// Link points to the file in the artifacts of the job, if it was uploaded.
+	Link string
+}
+
+// HasDetails returns whether the result has output or attachments to show.
+func (jr JunitResult) HasDetails() bool {
+	return jr.Output != nil || jr.Error != nil || len(jr.Attachments) > 0
 }
 
 func (jr JunitResult) Duration() time.Duration {
@@ -186,7 +211,7 @@ func (lens Lens) getJvd(artifacts []api.Artifact) JVD {
 					// Deduplicate them here in this case, and classify a test as being
 					// flaky if it both succeeded and failed
 					k := testIdentifier{suite.Name, test.ClassName, test.Name}
-					groups[k] = append(groups[k], JunitResult{Result: test})
+					groups[k] = append(groups[k], JunitResult{Result: test, Attachments: attachments(test, artifact)})
 					if len(groups[k]) == 1 {
 						testsSequence = append(testsSequence, k)
 					}
@@ -280,3 +305,61 @@ func (lens Lens) getJvd(artifacts []api.Artifact) JVD {
 	jvd.NumTests = len(jvd.Passed) + len(jvd.Failed) + len(jvd.Flaky) + len(jvd.Skipped) - duplicates
 	return jvd
 }
+
+// attachments returns the files attached to the result, either printed to its
+// output or recorded as properties named attachment, as some pytest and
+// surefire plugins do.
+func attachments(result junit.Result, artifact api.Artifact) []Attachment {
+	var paths []string
+	for _, output := range []*string{result.Output, result.Error} {
+		if output == nil {
+			continue
+		}
+		for _, match := range attachmentRe.FindAllStringSubmatch(*output, -1) {
+			paths = append(paths, strings.TrimSpace(match[1]))
+		}
+	}
+	if result.Properties != nil {
+		for _, property := range result.Properties.PropertyList {
+			if strings.HasPrefix(property.Name, "attachment") && property.Value != "" {
+				paths = append(paths, property.Value)
+			}
+		}
+	}
+
+	var attachments []Attachment
+	seen := map[string]bool{}
+	for _, p := range paths {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		attachments = append(attachments, Attachment{Path: p, Link: attachmentLink(artifact, p)})
+	}
+	return attachments
+}
+
+// attachmentLink returns the link to the attachment in the artifacts of the
+// job, or nothing if it is not among them. Attachments are uploaded if they
+// were written to the artifacts directory of the test container, or if their
+// path is relative to the junit file.
+func attachmentLink(artifact api.Artifact, attachment string) string {
+	prefix, ok := strings.CutSuffix(artifact.CanonicalLink(), artifact.JobPath())
+	if !ok {
+		// The link is not a plain path to the artifact, e.g. it is signed.
+		return ""
+	}
+	p := strings.ReplaceAll(attachment, `\`, "/")
+	var jobPath string
+	if i := strings.Index(p, containerArtifactsDir); i >= 0 {
+		jobPath = path.Join("artifacts", p[i+len(containerArtifactsDir):])
+	} else if path.IsAbs(p) || strings.Contains(p, ":") {
+		return ""
+	} else {
+		jobPath = path.Join(path.Dir(artifact.JobPath()), p)
+	}
+	if jobPath == ".." || strings.HasPrefix(jobPath, "../") {
+		return ""
+	}
+	return prefix + jobPath
+}
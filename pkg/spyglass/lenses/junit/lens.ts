@@ -16,24 +16,59 @@ const addSectionExpanders = (): void => {
   }
 };
 
+const setTestExpanded = (row: Element, expanded: boolean): void => {
+  const sibling = row.nextElementSibling;
+  const icon = row.querySelector<HTMLElement>('i')!;
+  if (expanded) {
+    sibling.classList.remove('hidden');
+    icon.innerText = 'expand_less';
+  } else {
+    sibling.classList.add('hidden');
+    icon.innerText = 'expand_more';
+  }
+};
+
 const addTestExpanders = (): void => {
-  const rows = document.querySelectorAll<HTMLTableRowElement>('.failure-name,.flaky-name');
+  const rows = document.querySelectorAll<HTMLTableRowElement>('.failure-name,.flaky-name,.passed-name');
   for (const row of Array.from(rows)) {
     row.onclick = () => {
-      const sibling = row.nextElementSibling;
-      const icon = row.querySelector('i')!;
-      if (sibling.classList.contains('hidden')) {
-        sibling.classList.remove('hidden');
-        icon.innerText = 'expand_less';
-      } else {
-        sibling.classList.add('hidden');
-        icon.innerText = 'expand_more';
-      }
+      setTestExpanded(row, row.nextElementSibling.classList.contains('hidden'));
       spyglass.contentUpdated();
     };
   }
 };
 
+// Failures are the failed tests, which the template marks. Expanding all
+// shows the output of the failed and flaky tests, as there may be very many
+// passed ones.
+const registerNavigation = (): void => {
+  spyglass.registerNavigation({
+    reveal: (failure: Element) => {
+      const tbody = failure.closest('tbody.hidden-tests');
+      if (tbody) {
+        tbody.classList.remove('hidden-tests');
+        // The expander of the section is in the tbody before its tests.
+        const header = tbody.previousElementSibling;
+        const icon = header ? header.querySelector<HTMLElement>('.section-expander i') : null;
+        if (icon) {
+          icon.innerText = 'expand_less';
+        }
+      }
+      setTestExpanded(failure, true);
+    },
+    expandAll: () => {
+      for (const row of Array.from(document.querySelectorAll('.failure-name,.flaky-name'))) {
+        setTestExpanded(row, true);
+      }
+    },
+    collapseAll: () => {
+      for (const row of Array.from(document.querySelectorAll('.failure-name,.flaky-name,.passed-name'))) {
+        setTestExpanded(row, false);
+      }
+    },
+  });
+};
+
 const addStdoutStderrOpeners = (): void => {
   const links = document.querySelectorAll<HTMLAnchorElement>('a.open-stdout-stderr');
   for (const link of Array.from(links)) {
@@ -51,10 +86,19 @@ const addStdoutStderrOpeners = (): void => {
   }
 };
 
+const addOutputToggles = (): void => {
+  const outputs = document.querySelectorAll<HTMLDetailsElement>('details.test-output');
+  for (const output of Array.from(outputs)) {
+    output.addEventListener('toggle', () => spyglass.contentUpdated());
+  }
+};
+
 const loaded = (): void => {
   addTestExpanders();
   addStdoutStderrOpeners();
+  addOutputToggles();
   addSectionExpanders();
+  registerNavigation();
 };
 
 window.addEventListener('DOMContentLoaded', loaded);
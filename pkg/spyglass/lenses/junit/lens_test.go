@@ -148,7 +148,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
@@ -181,7 +181,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Errored:   &errors[0],
@@ -211,7 +211,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -246,7 +246,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -282,14 +282,14 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
 								},
 							},
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -304,14 +304,14 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
 								},
 							},
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -344,7 +344,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_1",
 									Failure:   nil,
@@ -358,7 +358,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
@@ -397,7 +397,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_1",
 									Failure:   nil,
@@ -411,7 +411,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
@@ -449,14 +449,14 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
 								},
 							},
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[1],
@@ -489,14 +489,14 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
 								},
 							},
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -529,14 +529,14 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
 								},
 							},
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -575,14 +575,14 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
 								},
 							},
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -618,14 +618,14 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
 								},
 							},
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
@@ -666,21 +666,21 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
 								},
 							},
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[1],
 								},
 							},
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -717,7 +717,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -731,7 +731,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   &failures[0],
@@ -773,7 +773,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_0",
 									ClassName: "fake_class_0",
 									Failure:   nil,
@@ -785,7 +785,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_1",
 									ClassName: "fake_class_1",
 									Failure:   nil,
@@ -797,7 +797,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_2",
 									ClassName: "fake_class_2",
 									Failure:   nil,
@@ -809,7 +809,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_3",
 									ClassName: "fake_class_3",
 									Failure:   nil,
@@ -821,7 +821,7 @@ func TestGetJvd(t *testing.T) {
 					{
 						Junit: []JunitResult{
 							{
-								junit.Result{
+								Result: junit.Result{
 									Name:      "fake_test_4",
 									ClassName: "fake_class_4",
 									Failure:   nil,
@@ -925,6 +925,34 @@ func TestTemplate(t *testing.T) {
 				`error`,
 			},
 		},
+		{
+			name: "Passed tests with output can be expanded",
+			input: JVD{NumTests: 1, Passed: []TestResult{{
+				Junit: []JunitResult{{
+					Result: junit.Result{ClassName: "class", Name: "test", Output: utilpointer.String("output")},
+				}},
+			}}},
+			expectedSubstrings: []string{
+				`<tr class="passed-name">`,
+				`<summary>system-out</summary>`,
+				`<pre>output</pre>`,
+			},
+		},
+		{
+			name: "Attachments link to uploaded artifacts",
+			input: JVD{NumTests: 1, Failed: []TestResult{{
+				Junit: []JunitResult{{
+					Attachments: []Attachment{
+						{Path: "/logs/artifacts/screenshot.png", Link: "https://storage.example.com/bucket/artifacts/screenshot.png"},
+						{Path: "/tmp/trace.zip"},
+					},
+				}},
+			}}},
+			expectedSubstrings: []string{
+				`<li><a href="https://storage.example.com/bucket/artifacts/screenshot.png" target="_blank">/logs/artifacts/screenshot.png</a></li>`,
+				`<li>/tmp/trace.zip</li>`,
+			},
+		},
 	}
 
 	tmpl, err := template.ParseFiles("template.html")
@@ -947,3 +975,77 @@ func TestTemplate(t *testing.T) {
 		})
 	}
 }
+
+type linkedArtifact struct {
+	FakeArtifact
+	link string
+}
+
+func (a *linkedArtifact) CanonicalLink() string {
+	return a.link
+}
+
+func TestAttachments(t *testing.T) {
+	t.Parallel()
+	artifact := &linkedArtifact{
+		FakeArtifact: FakeArtifact{path: "artifacts/junit/report.xml"},
+		link:         "https://storage.example.com/bucket/logs/job/1/artifacts/junit/report.xml",
+	}
+	testCases := []struct {
+		name     string
+		artifact api.Artifact
+		result   junit.Result
+		expected []Attachment
+	}{
+		{
+			name:   "no attachments",
+			result: junit.Result{Output: utilpointer.String("output")},
+		},
+		{
+			name: "attachments in the output and properties",
+			result: junit.Result{
+				Output: utilpointer.String("[[ATTACHMENT|/logs/artifacts/screenshots/login.png]]\nmore output [[ATTACHMENT|trace.zip]]"),
+				Error:  utilpointer.String("[[ATTACHMENT|/tmp/core]]\n[[ATTACHMENT|trace.zip]]"),
+				Properties: &junit.Properties{PropertyList: []junit.Property{
+					{Name: "attachment", Value: `C:\logs\artifacts\video.mp4`},
+					{Name: "owner", Value: "someone"},
+				}},
+			},
+			expected: []Attachment{
+				{Path: "/logs/artifacts/screenshots/login.png", Link: "https://storage.example.com/bucket/logs/job/1/artifacts/screenshots/login.png"},
+				{Path: "trace.zip", Link: "https://storage.example.com/bucket/logs/job/1/artifacts/junit/trace.zip"},
+				{Path: "/tmp/core"},
+				{Path: `C:\logs\artifacts\video.mp4`, Link: "https://storage.example.com/bucket/logs/job/1/artifacts/video.mp4"},
+			},
+		},
+		{
+			name:   "paths outside of the job are not linked",
+			result: junit.Result{Output: utilpointer.String("[[ATTACHMENT|../../../secret]]")},
+			expected: []Attachment{
+				{Path: "../../../secret"},
+			},
+		},
+		{
+			name: "signed links are not linked",
+			artifact: &linkedArtifact{
+				FakeArtifact: FakeArtifact{path: "artifacts/junit/report.xml"},
+				link:         "https://storage.example.com/bucket/logs/job/1/artifacts/junit/report.xml?signature=abc",
+			},
+			result: junit.Result{Output: utilpointer.String("[[ATTACHMENT|trace.zip]]")},
+			expected: []Attachment{
+				{Path: "trace.zip"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := tc.artifact
+			if a == nil {
+				a = artifact
+			}
+			if diff := cmp.Diff(tc.expected, attachments(tc.result, a)); diff != "" {
+				t.Errorf("unexpected attachments (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package junithistory provides a lens that renders the pass/fail history of
+// the tests of a job from the junit files of its prior builds.
+package junithistory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "junithistory"
+	title    = "JUnit History"
+	priority = 5
+
+	defaultMaxTests = 100
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens renders the history of the tests of a job.
+type Lens struct{}
+
+type lensConfig struct {
+	// MaxTests is the number of tests whose history is shown, the most
+	// failing first. Defaults to 100.
+	MaxTests int `json:"max_tests,omitempty"`
+}
+
+func parseConfig(raw json.RawMessage) lensConfig {
+	var c lensConfig
+	if len(raw) != 0 {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			logrus.WithError(err).Warn("Failed to parse the config of the junithistory lens, using the defaults.")
+		}
+	}
+	if c.MaxTests <= 0 {
+		c.MaxTests = defaultMaxTests
+	}
+	return c
+}
+
+type status string
+
+const (
+	passed  status = "passed"
+	failed  status = "failed"
+	skipped status = "skipped"
+	// absent is the status of tests that did not run in a build.
+	absent status = "absent"
+)
+
+// run is the status of a test in a build.
+type run struct {
+	BuildID string
+	Status  status
+}
+
+// testHistory is the status of a test in each build.
+type testHistory struct {
+	Name string
+	// Runs are the runs of the test, oldest first.
+	Runs   []run
+	Passed int
+	Failed int
+	// FirstFailed is the first build of the failures the test has had
+	// since it last passed, if it fails in the latest build.
+	FirstFailed string
+}
+
+// Failing is whether the test fails in the latest build.
+func (t testHistory) Failing() bool {
+	return len(t.Runs) > 0 && t.Runs[len(t.Runs)-1].Status == failed
+}
+
+// Flaky is whether the test both passed and failed.
+func (t testHistory) Flaky() bool {
+	return t.Passed > 0 && t.Failed > 0
+}
+
+// FlakeRate is the share of the runs of a flaky test that failed.
+func (t testHistory) FlakeRate() string {
+	if !t.Flaky() {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(t.Failed)/float64(t.Passed+t.Failed))
+}
+
+type historyView struct {
+	// Builds are the IDs of the builds, oldest first. The ID of the
+	// latest build is unknown to the lens, so it is empty.
+	Builds []string
+	// NumTests is the number of tests that ran in any of the builds.
+	NumTests int
+	// NumFailed is the number of tests that failed in any of the builds.
+	NumFailed int
+	// Tests are the tests that failed in any of the builds.
+	Tests []testHistory
+	// Hidden is the number of tests that failed but are not shown.
+	Hidden int
+}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "header", nil)
+	if err != nil {
+		logrus.Warnf("Failed to render header: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Body renders the history of the latest build only, as the request names
+// no prior builds.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return lens.History(artifacts, nil, resourceDir, data, config, spyglassConfig)
+}
+
+// Callback does nothing.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return ""
+}
+
+// History renders the status of the tests in the prior builds and in the
+// latest one.
+func (lens Lens) History(artifacts []api.Artifact, history []api.BuildArtifacts, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	builds := make([]api.BuildArtifacts, 0, len(history)+1)
+	for i := len(history) - 1; i >= 0; i-- {
+		builds = append(builds, history[i])
+	}
+	builds = append(builds, api.BuildArtifacts{Artifacts: artifacts})
+
+	output, err := renderTemplate(resourceDir, "body", newHistoryView(builds, parseConfig(rawConfig).MaxTests))
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// newHistoryView returns the history of the tests of builds, which are
+// ordered oldest first.
+func newHistoryView(builds []api.BuildArtifacts, maxTests int) historyView {
+	var view historyView
+	statuses := make([]map[string]status, len(builds))
+	names := map[string]bool{}
+	for i, build := range builds {
+		view.Builds = append(view.Builds, build.BuildID)
+		statuses[i] = testStatuses(build.Artifacts)
+		for name := range statuses[i] {
+			names[name] = true
+		}
+	}
+	view.NumTests = len(names)
+
+	for name := range names {
+		t := testHistory{Name: name}
+		for i, build := range builds {
+			s, ok := statuses[i][name]
+			if !ok {
+				s = absent
+			}
+			t.Runs = append(t.Runs, run{BuildID: build.BuildID, Status: s})
+			switch s {
+			case passed:
+				t.Passed++
+				t.FirstFailed = ""
+			case failed:
+				t.Failed++
+				if t.FirstFailed == "" {
+					t.FirstFailed = build.BuildID
+				}
+			}
+		}
+		if t.Failed == 0 {
+			continue
+		}
+		if !t.Failing() {
+			t.FirstFailed = ""
+		}
+		view.Tests = append(view.Tests, t)
+	}
+
+	// Tests failing in the latest build come first, then the most failing.
+	sort.Slice(view.Tests, func(i, j int) bool {
+		a, b := view.Tests[i], view.Tests[j]
+		if a.Failing() != b.Failing() {
+			return a.Failing()
+		}
+		if a.Failed != b.Failed {
+			return a.Failed > b.Failed
+		}
+		return a.Name < b.Name
+	})
+	view.NumFailed = len(view.Tests)
+	if len(view.Tests) > maxTests {
+		view.Hidden = len(view.Tests) - maxTests
+		view.Tests = view.Tests[:maxTests]
+	}
+	return view
+}
+
+// testStatuses returns the status of each test of the junit artifacts of a
+// build. Tests that ran several times failed if any of their runs failed, so
+// that tests passing on retry count as flaky.
+func testStatuses(artifacts []api.Artifact) map[string]status {
+	statuses := map[string]status{}
+	record := func(name string, s status) {
+		if current, ok := statuses[name]; ok && (current == failed || s == skipped) {
+			return
+		}
+		statuses[name] = s
+	}
+	var walk func(suite junit.Suite)
+	walk = func(suite junit.Suite) {
+		for _, subSuite := range suite.Suites {
+			walk(subSuite)
+		}
+		for _, result := range suite.Results {
+			testName := result.Name
+			if result.ClassName != "" {
+				testName = result.ClassName + "." + result.Name
+			}
+			if suite.Name != "" {
+				testName = suite.Name + " " + testName
+			}
+			switch {
+			case result.Skipped != nil:
+				record(testName, skipped)
+			case result.Failure != nil || result.Errored != nil:
+				record(testName, failed)
+			default:
+				record(testName, passed)
+			}
+		}
+	}
+
+	for _, artifact := range artifacts {
+		contents, err := artifact.ReadAll()
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Warn("Error reading artifact")
+			continue
+		}
+		suites, err := junit.Parse(contents)
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Info("Error parsing junit file.")
+			continue
+		}
+		for _, suite := range suites.Suites {
+			walk(suite)
+		}
+	}
+	return statuses
+}
+
+func renderTemplate(resourceDir, block string, params interface{}) (string, error) {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, block, params); err != nil {
+		return "", fmt.Errorf("Failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
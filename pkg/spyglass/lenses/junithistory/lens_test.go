@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package junithistory
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+func junitBuild(id string, results map[string]string) api.BuildArtifacts {
+	var cases []string
+	for name, result := range results {
+		switch result {
+		case "failed":
+			cases = append(cases, fmt.Sprintf(`<testcase name=%q><failure>boom</failure></testcase>`, name))
+		case "skipped":
+			cases = append(cases, fmt.Sprintf(`<testcase name=%q><skipped/></testcase>`, name))
+		default:
+			cases = append(cases, fmt.Sprintf(`<testcase name=%q></testcase>`, name))
+		}
+	}
+	content := `<testsuites><testsuite name="suite">` + strings.Join(cases, "") + `</testsuite></testsuites>`
+	return api.BuildArtifacts{
+		BuildID:   id,
+		Artifacts: []api.Artifact{&fake.Artifact{Path: "artifacts/junit.xml", Content: []byte(content)}},
+	}
+}
+
+func TestNewHistoryView(t *testing.T) {
+	builds := []api.BuildArtifacts{
+		junitBuild("1", map[string]string{"stable": "passed", "flaky": "failed", "broken": "passed", "new": ""}),
+		junitBuild("2", map[string]string{"stable": "passed", "flaky": "passed", "broken": "failed"}),
+		junitBuild("3", map[string]string{"stable": "passed", "flaky": "failed", "broken": "failed"}),
+		junitBuild("", map[string]string{"stable": "passed", "flaky": "passed", "broken": "failed", "skipped": "skipped"}),
+	}
+	runs := func(statuses ...status) []run {
+		var r []run
+		for i, s := range statuses {
+			r = append(r, run{BuildID: builds[i].BuildID, Status: s})
+		}
+		return r
+	}
+
+	expected := historyView{
+		Builds:    []string{"1", "2", "3", ""},
+		NumTests:  5,
+		NumFailed: 2,
+		Tests: []testHistory{
+			{
+				Name:        "suite broken",
+				Runs:        runs(passed, failed, failed, failed),
+				Passed:      1,
+				Failed:      3,
+				FirstFailed: "2",
+			},
+			{
+				Name:   "suite flaky",
+				Runs:   runs(failed, passed, failed, passed),
+				Passed: 2,
+				Failed: 2,
+			},
+		},
+	}
+	actual := newHistoryView(builds, defaultMaxTests)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected history (-want +got):\n%s", diff)
+	}
+	if rate := actual.Tests[1].FlakeRate(); rate != "50%" {
+		t.Errorf("expected the flake rate of the flaky test to be 50%%, got %q", rate)
+	}
+	if rate := actual.Tests[0].FlakeRate(); rate != "75%" {
+		t.Errorf("expected the flake rate of the broken test to be 75%%, got %q", rate)
+	}
+
+	limited := newHistoryView(builds, 1)
+	if len(limited.Tests) != 1 || limited.Tests[0].Name != "suite broken" || limited.Hidden != 1 {
+		t.Errorf("expected only the broken test to be shown, got %+v", limited)
+	}
+}
+
+func TestTestStatusesRetries(t *testing.T) {
+	content := `<testsuites><testsuite name="suite">
+<testcase name="retried"><failure>boom</failure></testcase>
+<testcase name="retried"></testcase>
+</testsuite></testsuites>`
+	statuses := testStatuses([]api.Artifact{&fake.Artifact{Path: "junit.xml", Content: []byte(content)}})
+	if s := statuses["suite retried"]; s != failed {
+		t.Errorf("expected a test that passed on retry to have failed, got %q", s)
+	}
+}
@@ -0,0 +1,470 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package k8sevents provides a lens that renders the Kubernetes events and
+// pod dumps uploaded by jobs, e.g. by e2e tests tearing down a cluster.
+package k8sevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "k8sevents"
+	title    = "Kubernetes Events"
+	priority = 25
+
+	defaultMaxEventsPerObject = 50
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens renders Kubernetes events grouped by the object they involve, and the
+// pods of pod dumps.
+type Lens struct{}
+
+type lensConfig struct {
+	// MaxEventsPerObject is the number of events shown for each object, the
+	// latest first. Defaults to 50.
+	MaxEventsPerObject int `json:"max_events_per_object,omitempty"`
+}
+
+func parseConfig(raw json.RawMessage) lensConfig {
+	var c lensConfig
+	if len(raw) != 0 {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			logrus.WithError(err).Warn("Failed to parse the config of the k8sevents lens, using the defaults.")
+		}
+	}
+	if c.MaxEventsPerObject <= 0 {
+		c.MaxEventsPerObject = defaultMaxEventsPerObject
+	}
+	return c
+}
+
+// event is an event of an object.
+type event struct {
+	Type    string
+	Reason  string
+	Message string
+	Count   int32
+	Source  string
+	Time    time.Time
+}
+
+// Warning is whether the event is a warning.
+func (e event) Warning() bool {
+	return e.Type == v1.EventTypeWarning
+}
+
+// objectEvents are the events of an object.
+type objectEvents struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Events are the latest first.
+	Events   []event
+	Warnings int
+	// Hidden is the number of events that are not shown.
+	Hidden int
+	// PodAnchor is the anchor of the pod, if the object is a dumped pod.
+	PodAnchor string
+}
+
+// container is the status of a container of a pod.
+type container struct {
+	Name     string
+	Ready    bool
+	Restarts int32
+	// State is e.g. "running" or "terminated: OOMKilled (exit code 137)".
+	State string
+	// Failed is whether the container is waiting or terminated abnormally.
+	Failed bool
+}
+
+// logLink is a link to a log artifact of a pod.
+type logLink struct {
+	Name string
+	Link string
+}
+
+// pod is a dumped pod.
+type pod struct {
+	Anchor     string
+	Namespace  string
+	Name       string
+	Phase      string
+	Node       string
+	Containers []container
+	Warnings   int
+	Logs       []logLink
+}
+
+type eventsView struct {
+	NumEvents   int
+	NumWarnings int
+	// Objects are the objects with warnings first.
+	Objects []objectEvents
+	Pods    []pod
+	// Errors are the artifacts that could not be read.
+	Errors []string
+}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "header", nil)
+	if err != nil {
+		logrus.Warnf("Failed to render header: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Body renders the events and pods of the artifacts.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	view := newEventsView(artifacts, parseConfig(rawConfig).MaxEventsPerObject, func(a api.Artifact) string {
+		return lenses.RawArtifactLink(a, spyglassConfig)
+	})
+	output, err := renderTemplate(resourceDir, "body", view)
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Callback does nothing.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return ""
+}
+
+// newEventsView reads the events and pods of the artifacts. Artifacts that
+// are neither, such as logs, are linked from the pods they belong to.
+func newEventsView(artifacts []api.Artifact, maxEvents int, link func(api.Artifact) string) eventsView {
+	var view eventsView
+	var events []v1.Event
+	var pods []v1.Pod
+	var logs []api.Artifact
+	for _, artifact := range artifacts {
+		if isLog(artifact.JobPath()) {
+			logs = append(logs, artifact)
+			continue
+		}
+		content, err := artifact.ReadAll()
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Warn("Error reading artifact")
+			view.Errors = append(view.Errors, fmt.Sprintf("%s: %v", artifact.JobPath(), err))
+			continue
+		}
+		e, p, err := parseObjects(content)
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Info("Error parsing Kubernetes objects.")
+			view.Errors = append(view.Errors, fmt.Sprintf("%s: %v", artifact.JobPath(), err))
+			continue
+		}
+		events = append(events, e...)
+		pods = append(pods, p...)
+	}
+
+	objects := map[string]*objectEvents{}
+	for _, e := range events {
+		involved := e.InvolvedObject
+		key := objectKey(involved.Kind, involved.Namespace, involved.Name)
+		o, ok := objects[key]
+		if !ok {
+			o = &objectEvents{Kind: involved.Kind, Namespace: involved.Namespace, Name: involved.Name}
+			objects[key] = o
+		}
+		ev := newEvent(e)
+		o.Events = append(o.Events, ev)
+		view.NumEvents++
+		if ev.Warning() {
+			o.Warnings++
+			view.NumWarnings++
+		}
+	}
+
+	for _, p := range pods {
+		key := objectKey("Pod", p.Namespace, p.Name)
+		dumped := newPod(p, logs, link)
+		if o, ok := objects[key]; ok {
+			o.PodAnchor = dumped.Anchor
+			dumped.Warnings = o.Warnings
+		}
+		view.Pods = append(view.Pods, dumped)
+	}
+	sort.Slice(view.Pods, func(i, j int) bool {
+		a, b := view.Pods[i], view.Pods[j]
+		if (a.Warnings > 0) != (b.Warnings > 0) {
+			return a.Warnings > 0
+		}
+		return objectKey("", a.Namespace, a.Name) < objectKey("", b.Namespace, b.Name)
+	})
+
+	for _, o := range objects {
+		sort.SliceStable(o.Events, func(i, j int) bool {
+			return o.Events[i].Time.After(o.Events[j].Time)
+		})
+		if len(o.Events) > maxEvents {
+			o.Hidden = len(o.Events) - maxEvents
+			o.Events = o.Events[:maxEvents]
+		}
+		view.Objects = append(view.Objects, *o)
+	}
+	sort.Slice(view.Objects, func(i, j int) bool {
+		a, b := view.Objects[i], view.Objects[j]
+		if a.Warnings != b.Warnings {
+			return a.Warnings > b.Warnings
+		}
+		return objectKey(a.Kind, a.Namespace, a.Name) < objectKey(b.Kind, b.Namespace, b.Name)
+	})
+	return view
+}
+
+func objectKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// isLog is whether an artifact is a log, rather than a dump of objects.
+func isLog(p string) bool {
+	return strings.HasSuffix(p, ".log") || strings.HasSuffix(p, ".log.txt")
+}
+
+func newEvent(e v1.Event) event {
+	ev := event{
+		Type:    e.Type,
+		Reason:  e.Reason,
+		Message: e.Message,
+		Count:   e.Count,
+		Source:  e.Source.Component,
+	}
+	if e.Series != nil && e.Series.Count > ev.Count {
+		ev.Count = e.Series.Count
+	}
+	if ev.Source == "" {
+		ev.Source = e.ReportingController
+	}
+	switch {
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		ev.Time = e.Series.LastObservedTime.Time
+	case !e.LastTimestamp.IsZero():
+		ev.Time = e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		ev.Time = e.EventTime.Time
+	case !e.FirstTimestamp.IsZero():
+		ev.Time = e.FirstTimestamp.Time
+	default:
+		ev.Time = e.CreationTimestamp.Time
+	}
+	return ev
+}
+
+func newPod(p v1.Pod, logs []api.Artifact, link func(api.Artifact) string) pod {
+	dumped := pod{
+		Anchor:    "pod-" + p.Namespace + "-" + p.Name,
+		Namespace: p.Namespace,
+		Name:      p.Name,
+		Phase:     string(p.Status.Phase),
+		Node:      p.Spec.NodeName,
+	}
+	statuses := append(append([]v1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		c := container{Name: status.Name, Ready: status.Ready, Restarts: status.RestartCount}
+		switch {
+		case status.State.Waiting != nil:
+			c.State = "waiting: " + status.State.Waiting.Reason
+			c.Failed = status.State.Waiting.Reason != "" && status.State.Waiting.Reason != "PodInitializing" && status.State.Waiting.Reason != "ContainerCreating"
+		case status.State.Terminated != nil:
+			terminated := status.State.Terminated
+			c.State = fmt.Sprintf("terminated: %s (exit code %d)", terminated.Reason, terminated.ExitCode)
+			c.Failed = terminated.ExitCode != 0
+		case status.State.Running != nil:
+			c.State = "running"
+		}
+		dumped.Containers = append(dumped.Containers, c)
+	}
+	// Logs are usually dumped to a directory or file named after the pod.
+	for _, log := range logs {
+		if !containsPathPart(log.JobPath(), p.Name) {
+			continue
+		}
+		dumped.Logs = append(dumped.Logs, logLink{Name: log.JobPath(), Link: link(log)})
+	}
+	return dumped
+}
+
+// containsPathPart is whether p has a directory or file name part that is
+// name, e.g. pod/container.log, namespace_pod_container.log or pod-container.log.
+func containsPathPart(p, name string) bool {
+	parts := strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '_' })
+	for _, part := range parts {
+		rest, ok := strings.CutPrefix(part, name)
+		if !ok {
+			continue
+		}
+		if rest == "" || rest[0] == '.' {
+			return true
+		}
+		// The logs of pod-10 are not those of pod-1.
+		if rest[0] == '-' && len(rest) > 1 && (rest[1] < '0' || rest[1] > '9') {
+			return true
+		}
+	}
+	return false
+}
+
+// parseObjects reads the events and pods of a JSON or YAML document, which
+// may be a single object, a list, a JSON array, or several YAML documents.
+func parseObjects(content []byte) ([]v1.Event, []v1.Pod, error) {
+	var events []v1.Event
+	var pods []v1.Pod
+	trimmed := bytes.TrimSpace(content)
+	var docs [][]byte
+	switch {
+	case len(trimmed) == 0:
+		return nil, nil, nil
+	case trimmed[0] == '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, nil, err
+		}
+		for _, item := range items {
+			docs = append(docs, item)
+		}
+	case trimmed[0] == '{':
+		// JSON may be a stream of objects, e.g. from kubectl get -w -o json.
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		for decoder.More() {
+			var item json.RawMessage
+			if err := decoder.Decode(&item); err != nil {
+				return nil, nil, err
+			}
+			docs = append(docs, item)
+		}
+	default:
+		for _, doc := range strings.Split("\n"+string(trimmed), "\n---") {
+			if strings.TrimSpace(doc) != "" {
+				docs = append(docs, []byte(doc))
+			}
+		}
+	}
+
+	for _, doc := range docs {
+		e, p, err := parseObject(doc, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, e...)
+		pods = append(pods, p...)
+	}
+	if len(events) == 0 && len(pods) == 0 {
+		return nil, nil, fmt.Errorf("found no events or pods")
+	}
+	return events, pods, nil
+}
+
+// parseObject reads an event, a pod, or a list of them. The items of typed
+// lists may have no kind, so they get the kind of the list.
+func parseObject(doc []byte, defaultKind string) ([]v1.Event, []v1.Pod, error) {
+	var meta struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := yaml.Unmarshal(doc, &meta); err != nil {
+		return nil, nil, err
+	}
+	kind := meta.Kind
+	if kind == "" {
+		kind = defaultKind
+	}
+	switch kind {
+	case "Event":
+		var e v1.Event
+		if err := yaml.Unmarshal(doc, &e); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse event: %w", err)
+		}
+		return []v1.Event{e}, nil, nil
+	case "Pod":
+		var p v1.Pod
+		if err := yaml.Unmarshal(doc, &p); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse pod: %w", err)
+		}
+		return nil, []v1.Pod{p}, nil
+	}
+	if !strings.HasSuffix(kind, "List") {
+		// Other objects, e.g. services in the same dump, are ignored.
+		return nil, nil, nil
+	}
+	var events []v1.Event
+	var pods []v1.Pod
+	for _, item := range meta.Items {
+		e, p, err := parseObject(item, strings.TrimSuffix(kind, "List"))
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, e...)
+		pods = append(pods, p...)
+	}
+	return events, pods, nil
+}
+
+func renderTemplate(resourceDir, block string, params interface{}) (string, error) {
+	t, err := template.New("template.html").Funcs(template.FuncMap{
+		"base": path.Base,
+		"timestamp": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.UTC().Format(time.RFC3339)
+		},
+	}).ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, block, params); err != nil {
+		return "", fmt.Errorf("Failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
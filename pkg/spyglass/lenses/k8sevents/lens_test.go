@@ -0,0 +1,238 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sevents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+const eventList = `{
+  "kind": "EventList",
+  "apiVersion": "v1",
+  "items": [
+    {
+      "metadata": {"name": "web-1.a", "namespace": "test"},
+      "involvedObject": {"kind": "Pod", "namespace": "test", "name": "web-1"},
+      "type": "Normal",
+      "reason": "Pulled",
+      "message": "Container image pulled",
+      "count": 1,
+      "source": {"component": "kubelet"},
+      "lastTimestamp": "2024-01-01T00:00:01Z"
+    },
+    {
+      "metadata": {"name": "web-1.b", "namespace": "test"},
+      "involvedObject": {"kind": "Pod", "namespace": "test", "name": "web-1"},
+      "type": "Warning",
+      "reason": "BackOff",
+      "message": "Back-off restarting failed container",
+      "count": 5,
+      "source": {"component": "kubelet"},
+      "lastTimestamp": "2024-01-01T00:00:05Z"
+    },
+    {
+      "metadata": {"name": "node.a"},
+      "involvedObject": {"kind": "Node", "name": "node-a"},
+      "type": "Normal",
+      "reason": "NodeReady",
+      "eventTime": "2024-01-01T00:00:00.000000Z",
+      "reportingComponent": "node-controller"
+    }
+  ]
+}`
+
+const podDump = `apiVersion: v1
+kind: Pod
+metadata:
+  name: web-1
+  namespace: test
+spec:
+  nodeName: node-a
+status:
+  phase: Running
+  containerStatuses:
+  - name: app
+    ready: false
+    restartCount: 3
+    state:
+      waiting:
+        reason: CrashLoopBackOff
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-10
+  namespace: test
+status:
+  phase: Succeeded
+  containerStatuses:
+  - name: app
+    ready: false
+    state:
+      terminated:
+        reason: Completed
+        exitCode: 0
+`
+
+func TestNewEventsView(t *testing.T) {
+	artifacts := []api.Artifact{
+		&fake.Artifact{Path: "artifacts/events.json", Content: []byte(eventList)},
+		&fake.Artifact{Path: "artifacts/pods.yaml", Content: []byte(podDump)},
+		&fake.Artifact{Path: "artifacts/pods/test_web-1_app.log"},
+		&fake.Artifact{Path: "artifacts/pods/web-10/app.log"},
+		&fake.Artifact{Path: "artifacts/broken.json", Content: []byte(`{"kind": "ConfigMap"}`)},
+	}
+	link := func(a api.Artifact) string { return "/raw/" + a.JobPath() }
+	at := func(s string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatalf("bad time %q: %v", s, err)
+		}
+		return parsed
+	}
+
+	expected := eventsView{
+		NumEvents:   3,
+		NumWarnings: 1,
+		Objects: []objectEvents{
+			{
+				Kind:      "Pod",
+				Namespace: "test",
+				Name:      "web-1",
+				Events: []event{
+					{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container", Count: 5, Source: "kubelet", Time: at("2024-01-01T00:00:05Z")},
+					{Type: "Normal", Reason: "Pulled", Message: "Container image pulled", Count: 1, Source: "kubelet", Time: at("2024-01-01T00:00:01Z")},
+				},
+				Warnings:  1,
+				PodAnchor: "pod-test-web-1",
+			},
+			{
+				Kind: "Node",
+				Name: "node-a",
+				Events: []event{
+					{Type: "Normal", Reason: "NodeReady", Source: "node-controller", Time: at("2024-01-01T00:00:00Z")},
+				},
+			},
+		},
+		Pods: []pod{
+			{
+				Anchor:     "pod-test-web-1",
+				Namespace:  "test",
+				Name:       "web-1",
+				Phase:      "Running",
+				Node:       "node-a",
+				Containers: []container{{Name: "app", Restarts: 3, State: "waiting: CrashLoopBackOff", Failed: true}},
+				Warnings:   1,
+				Logs:       []logLink{{Name: "artifacts/pods/test_web-1_app.log", Link: "/raw/artifacts/pods/test_web-1_app.log"}},
+			},
+			{
+				Anchor:     "pod-test-web-10",
+				Namespace:  "test",
+				Name:       "web-10",
+				Phase:      "Succeeded",
+				Containers: []container{{Name: "app", State: "terminated: Completed (exit code 0)"}},
+				Logs:       []logLink{{Name: "artifacts/pods/web-10/app.log", Link: "/raw/artifacts/pods/web-10/app.log"}},
+			},
+		},
+		Errors: []string{"artifacts/broken.json: found no events or pods"},
+	}
+	actual := newEventsView(artifacts, defaultMaxEventsPerObject, link)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected view (-want +got):\n%s", diff)
+	}
+
+	limited := newEventsView(artifacts, 1, link)
+	if events := limited.Objects[0].Events; len(events) != 1 || events[0].Reason != "BackOff" || limited.Objects[0].Hidden != 1 {
+		t.Errorf("expected only the latest event of web-1 to be shown, got %+v", limited.Objects[0])
+	}
+}
+
+func TestParseObjects(t *testing.T) {
+	testCases := []struct {
+		name           string
+		content        string
+		expectedEvents []string
+		expectedPods   []string
+		expectedErr    bool
+	}{
+		{
+			name:           "JSON array of events",
+			content:        `[{"kind": "Event", "reason": "a"}, {"kind": "Event", "reason": "b"}]`,
+			expectedEvents: []string{"a", "b"},
+		},
+		{
+			name:           "stream of JSON events",
+			content:        "{\"kind\": \"Event\", \"reason\": \"a\"}\n{\"kind\": \"Event\", \"reason\": \"b\"}\n",
+			expectedEvents: []string{"a", "b"},
+		},
+		{
+			name:           "generic list of pods and events",
+			content:        `{"kind": "List", "items": [{"kind": "Pod", "metadata": {"name": "p"}}, {"kind": "Event", "reason": "a"}, {"kind": "Service"}]}`,
+			expectedEvents: []string{"a"},
+			expectedPods:   []string{"p"},
+		},
+		{
+			name:         "YAML pod list without item kinds",
+			content:      "kind: PodList\nitems:\n- metadata:\n    name: p\n- metadata:\n    name: q\n",
+			expectedPods: []string{"p", "q"},
+		},
+		{
+			name:        "not Kubernetes objects",
+			content:     "just some text",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			events, pods, err := parseObjects([]byte(tc.content))
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			var reasons, names []string
+			for _, e := range events {
+				reasons = append(reasons, e.Reason)
+			}
+			for _, p := range pods {
+				names = append(names, p.Name)
+			}
+			if diff := cmp.Diff(tc.expectedEvents, reasons); diff != "" {
+				t.Errorf("unexpected events (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedPods, names); diff != "" {
+				t.Errorf("unexpected pods (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBody(t *testing.T) {
+	artifacts := []api.Artifact{&fake.Artifact{Path: "artifacts/events.json", Content: []byte(eventList)}}
+	body := Lens{}.Body(artifacts, ".", "", nil, config.Spyglass{})
+	for _, expected := range []string{"3 events of 2 objects, 1 of them warnings.", `class="events-warning"`, "Back-off restarting failed container"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected the body to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
@@ -33,7 +34,7 @@ import (
 )
 
 var (
-	lensReg = map[string]Lens{}
+	lensReg = map[string]LensV2{}
 
 	// ErrGzipOffsetRead will be thrown when an offset read is attempted on a gzip-compressed object
 	ErrGzipOffsetRead = errors.New("offset read on gzipped files unsupported")
@@ -76,6 +77,24 @@ type Lens interface {
 	Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string
 }
 
+// LensV2 defines the interface that version 2 lenses are required to implement
+// in order to be used by Spyglass.
+type LensV2 interface {
+	// Config returns a LensConfig that describes the lens.
+	Config() LensConfig
+	api.LensV2
+}
+
+// adaptedLens serves a Lens as a LensV2.
+type adaptedLens struct {
+	*api.LensAdapter
+	lens Lens
+}
+
+func (l adaptedLens) Config() LensConfig {
+	return l.lens.Config()
+}
+
 // ResourceDirForLens returns the path to a lens's public resource directory.
 func ResourceDirForLens(baseDir, name string) string {
 	return filepath.Join(baseDir, name)
@@ -83,6 +102,11 @@ func ResourceDirForLens(baseDir, name string) string {
 
 // RegisterLens registers new viewers
 func RegisterLens(lens Lens) error {
+	return RegisterLensV2(adaptedLens{LensAdapter: api.AdaptLens(lens), lens: lens})
+}
+
+// RegisterLensV2 registers new version 2 viewers
+func RegisterLensV2(lens LensV2) error {
 	config := lens.Config()
 	_, ok := lensReg[config.Name]
 	if ok {
@@ -98,7 +122,22 @@ func RegisterLens(lens Lens) error {
 }
 
 // GetLens returns a Lens or a remoteLens  by name, if it exists; otherwise it returns an error.
+// Version 2 lenses are only returned by GetLensV2.
 func GetLens(name string) (Lens, error) {
+	lens, err := GetLensV2(name)
+	if err != nil {
+		return nil, err
+	}
+	adapted, ok := lens.(adaptedLens)
+	if !ok {
+		return nil, fmt.Errorf("lens %s is a version 2 lens", name)
+	}
+	return adapted.lens, nil
+}
+
+// GetLensV2 returns a lens by name, if it exists; otherwise it returns an error. Lenses
+// registered with RegisterLens are served as version 2 lenses.
+func GetLensV2(name string) (LensV2, error) {
 	lens, ok := lensReg[name]
 	if !ok {
 		return nil, ErrInvalidLensName
@@ -112,6 +151,34 @@ func UnregisterLens(viewerName string) {
 	logrus.Infof("Spyglass unregistered viewer %s.", viewerName)
 }
 
+// RawArtifactViewerPath is the path under which Deck serves the raw artifact viewer.
+const RawArtifactViewerPath = "/spyglass/raw/"
+
+// RawArtifactLink returns a link to view the artifact in Deck's raw artifact
+// viewer. It falls back to the artifact's canonical link if the viewer is not
+// configured or the artifact is not stored in a storage bucket.
+func RawArtifactLink(a api.Artifact, spyglassConfig config.Spyglass) string {
+	stored, ok := a.(interface{ StoragePath() string })
+	if spyglassConfig.RawArtifactViewer == nil || !ok || stored.StoragePath() == "" {
+		return a.CanonicalLink()
+	}
+	return RawArtifactViewerPath + strings.Replace(stored.StoragePath(), "://", "/", 1)
+}
+
+// SandboxedArtifactPath is the path under which Deck serves artifacts with a
+// sandboxing Content-Security-Policy, so HTML reports can be embedded.
+const SandboxedArtifactPath = "/spyglass/sandbox/"
+
+// SandboxedArtifactLink returns a link to the artifact served sandboxed by
+// Deck, or an empty string if the artifact is not stored in a storage bucket.
+func SandboxedArtifactLink(a api.Artifact) string {
+	stored, ok := a.(interface{ StoragePath() string })
+	if !ok || stored.StoragePath() == "" {
+		return ""
+	}
+	return SandboxedArtifactPath + strings.Replace(stored.StoragePath(), "://", "/", 1)
+}
+
 // LastNLines reads the last n lines from an artifact.
 func LastNLines(a api.Artifact, n int64) ([]string, error) {
 	// 300B, a reasonable log line length, probably a bit more scalable than a hard-coded value
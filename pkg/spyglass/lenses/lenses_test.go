@@ -17,7 +17,9 @@ limitations under the License.
 package lenses
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -119,6 +121,89 @@ crazy`,
 
 }
 
+// adaptedDumpLens is a version 1 lens to be served as a version 2 lens.
+type adaptedDumpLens struct {
+	dumpLens
+}
+
+func (adaptedDumpLens) Config() LensConfig {
+	return LensConfig{
+		Name:  "adapted-dump",
+		Title: "Adapted Dump Lens",
+	}
+}
+
+// echoLens is a version 2 lens echoing the data it is sent.
+type echoLens struct{}
+
+func (echoLens) Config() LensConfig {
+	return LensConfig{
+		Name:  "echo",
+		Title: "Echo Lens",
+	}
+}
+
+func (echoLens) Header(ctx context.Context, artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error) {
+	return "", nil
+}
+
+func (echoLens) Body(ctx context.Context, artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error) {
+	return data, nil
+}
+
+func (echoLens) Callback(ctx context.Context, artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) (string, error) {
+	return data, nil
+}
+
+func TestLensV2(t *testing.T) {
+	if err := RegisterLens(adaptedDumpLens{}); err != nil {
+		t.Fatalf("Failed to register version 1 lens: %v", err)
+	}
+	defer UnregisterLens("adapted-dump")
+	if err := RegisterLensV2(echoLens{}); err != nil {
+		t.Fatalf("Failed to register version 2 lens: %v", err)
+	}
+	defer UnregisterLens("echo")
+	if err := RegisterLensV2(echoLens{}); err == nil {
+		t.Error("Expected registering a lens twice to fail")
+	}
+
+	artifacts := []api.Artifact{&FakeArtifact{Path: "log.txt", Content: []byte("some logs")}}
+
+	echo, err := GetLensV2("echo")
+	if err != nil {
+		t.Fatalf("Failed to get version 2 lens: %v", err)
+	}
+	if body, err := echo.Body(context.Background(), artifacts, "", "data", nil, config.Spyglass{}); err != nil || body != "data" {
+		t.Errorf("Expected version 2 lens to render %q, got %q and error %v", "data", body, err)
+	}
+	if _, err := GetLens("echo"); err == nil {
+		t.Error("Expected getting a version 2 lens as a version 1 lens to fail")
+	}
+
+	dump, err := GetLensV2("adapted-dump")
+	if err != nil {
+		t.Fatalf("Failed to get adapted version 1 lens: %v", err)
+	}
+	if dump.Config().Title != "Adapted Dump Lens" {
+		t.Errorf("Expected the config of the version 1 lens, got %v", dump.Config())
+	}
+	if body, err := dump.Body(context.Background(), artifacts, "", "", nil, config.Spyglass{}); err != nil || body != "some logs" {
+		t.Errorf("Expected adapted lens to render %q, got %q and error %v", "some logs", body, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := dump.Body(ctx, artifacts, "", "", nil, config.Spyglass{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected adapted lens to fail for a cancelled request, got %v", err)
+	}
+	if v1, ok := api.UnadaptLens(dump); !ok || v1 != (adaptedDumpLens{}) {
+		t.Errorf("Expected the adapted lens to be the version 1 lens, got %v", v1)
+	}
+	if _, ok := api.UnadaptLens(echo); ok {
+		t.Error("Expected a version 2 lens not to be adapted")
+	}
+}
+
 // Tests reading last N Lines from files in GCS
 func TestLastNLines_GCS(t *testing.T) {
 	fakeGCSServerChunkSize := int64(3500)
@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pprof provides a lens that renders Go pprof profiles as a top
+// table and a flame graph.
+package pprof
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+
+	"github.com/google/pprof/profile"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "pprof"
+	title    = "Profiles"
+	priority = 30
+
+	defaultMaxTop = 50
+	// defaultMinFlameFraction is the share of the total below which flame
+	// graph frames are dropped, as they are too narrow to be seen.
+	defaultMinFlameFraction = 0.001
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens renders pprof profiles.
+type Lens struct{}
+
+type lensConfig struct {
+	// MaxTop is the number of functions of the top table. Defaults to 50.
+	MaxTop int `json:"max_top,omitempty"`
+	// MinFlameFraction is the share of the total below which frames are left
+	// out of the flame graph. Defaults to 0.001.
+	MinFlameFraction float64 `json:"min_flame_fraction,omitempty"`
+}
+
+func parseConfig(raw json.RawMessage) lensConfig {
+	var c lensConfig
+	if len(raw) != 0 {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			logrus.WithError(err).Warn("Failed to parse the config of the pprof lens, using the defaults.")
+		}
+	}
+	if c.MaxTop <= 0 {
+		c.MaxTop = defaultMaxTop
+	}
+	if c.MinFlameFraction <= 0 {
+		c.MinFlameFraction = defaultMinFlameFraction
+	}
+	return c
+}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "header", nil)
+	if err != nil {
+		logrus.Warnf("Failed to render header: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+type profileLink struct {
+	Name string
+	Link string
+}
+
+// Body renders a placeholder for each profile. The profiles are only read
+// once the page asks for their views through callbacks, so that large
+// profiles do not delay the page.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	var profiles []profileLink
+	for _, artifact := range artifacts {
+		profiles = append(profiles, profileLink{Name: artifact.JobPath(), Link: lenses.RawArtifactLink(artifact, spyglassConfig)})
+	}
+	output, err := renderTemplate(resourceDir, "body", profiles)
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+type viewName string
+
+const (
+	viewTop   viewName = "top"
+	viewFlame viewName = "flame"
+)
+
+// callbackRequest asks for a view of a profile.
+type callbackRequest struct {
+	Artifact string   `json:"artifact"`
+	View     viewName `json:"view"`
+	// SampleIndex is the index of the sample type to show, or -1 for the
+	// default one of the profile.
+	SampleIndex int `json:"sampleIndex"`
+}
+
+// callbackResponse is a view of a profile.
+type callbackResponse struct {
+	Error string `json:"error,omitempty"`
+	// SampleTypes are the sample types of the profile, e.g. "cpu (nanoseconds)".
+	SampleTypes []string `json:"sampleTypes,omitempty"`
+	SampleIndex int      `json:"sampleIndex"`
+	Total       string   `json:"total,omitempty"`
+	Top         []topRow `json:"top,omitempty"`
+	Flame       *frame   `json:"flame,omitempty"`
+}
+
+// Callback computes the top table or the flame graph of a profile.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	resp := callback(artifacts, data, parseConfig(rawConfig))
+	b, err := json.Marshal(resp)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to marshal pprof view.")
+		return fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	return string(b)
+}
+
+func callback(artifacts []api.Artifact, data string, c lensConfig) *callbackResponse {
+	var request callbackRequest
+	if err := json.Unmarshal([]byte(data), &request); err != nil {
+		return &callbackResponse{Error: "Failed to unmarshal request"}
+	}
+	var artifact api.Artifact
+	for _, a := range artifacts {
+		if a.JobPath() == request.Artifact {
+			artifact = a
+			break
+		}
+	}
+	if artifact == nil {
+		return &callbackResponse{Error: fmt.Sprintf("No artifact named %s", request.Artifact)}
+	}
+
+	content, err := artifact.ReadAll()
+	if err != nil {
+		logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Warn("Error reading artifact")
+		return &callbackResponse{Error: fmt.Sprintf("Failed to read the profile: %v", err)}
+	}
+	p, err := profile.ParseData(content)
+	if err != nil {
+		return &callbackResponse{Error: fmt.Sprintf("Failed to parse the profile: %v", err)}
+	}
+	if len(p.SampleType) == 0 {
+		return &callbackResponse{Error: "The profile has no samples."}
+	}
+
+	resp := &callbackResponse{SampleIndex: request.SampleIndex}
+	for _, st := range p.SampleType {
+		resp.SampleTypes = append(resp.SampleTypes, fmt.Sprintf("%s (%s)", st.Type, st.Unit))
+	}
+	if resp.SampleIndex < 0 || resp.SampleIndex >= len(p.SampleType) {
+		resp.SampleIndex = defaultSampleIndex(p)
+	}
+	unit := p.SampleType[resp.SampleIndex].Unit
+	var total int64
+	for _, s := range p.Sample {
+		total += s.Value[resp.SampleIndex]
+	}
+	resp.Total = formatValue(total, unit)
+
+	switch request.View {
+	case viewTop:
+		resp.Top = top(p, resp.SampleIndex, c.MaxTop)
+	case viewFlame:
+		resp.Flame = flameGraph(p, resp.SampleIndex, c.MinFlameFraction)
+	default:
+		return &callbackResponse{Error: fmt.Sprintf("Unknown view %q", request.View)}
+	}
+	return resp
+}
+
+// defaultSampleIndex returns the index of the sample type pprof shows by
+// default: the one named by the profile, or else the last one.
+func defaultSampleIndex(p *profile.Profile) int {
+	for i, st := range p.SampleType {
+		if st.Type == p.DefaultSampleType {
+			return i
+		}
+	}
+	return len(p.SampleType) - 1
+}
+
+func renderTemplate(resourceDir, block string, params interface{}) (string, error) {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, block, params); err != nil {
+		return "", fmt.Errorf("Failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pprof
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/pprof/profile"
+
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+// testProfile has main calling work, which calls itself and hash, and
+// main calling idle; its samples are counts and CPU time.
+func testProfile(t *testing.T) []byte {
+	functions := map[string]*profile.Function{}
+	var locations []*profile.Location
+	location := func(names ...string) *profile.Location {
+		loc := &profile.Location{ID: uint64(len(locations) + 1), Address: 0x1000 + uint64(len(locations))}
+		for _, name := range names {
+			f, ok := functions[name]
+			if !ok {
+				f = &profile.Function{ID: uint64(len(functions) + 1), Name: name}
+				functions[name] = f
+			}
+			loc.Line = append(loc.Line, profile.Line{Function: f})
+		}
+		locations = append(locations, loc)
+		return loc
+	}
+	mainLoc := location("main")
+	workLoc := location("work")
+	// hash is inlined into work.
+	hashLoc := location("hash", "work")
+	idleLoc := location("idle")
+
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		Sample: []*profile.Sample{
+			{Location: []*profile.Location{hashLoc, mainLoc}, Value: []int64{6, 6000000000}},
+			{Location: []*profile.Location{workLoc, workLoc, mainLoc}, Value: []int64{3, 3000000000}},
+			{Location: []*profile.Location{idleLoc, mainLoc}, Value: []int64{1, 1000000000}},
+		},
+		Location: locations,
+	}
+	for _, f := range functions {
+		p.Function = append(p.Function, f)
+	}
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatalf("failed to write profile: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCallback(t *testing.T) {
+	artifacts := []api.Artifact{&fake.Artifact{Path: "artifacts/cpu.pb.gz", Content: testProfile(t)}}
+	request := func(view viewName, sampleIndex int) string {
+		b, err := json.Marshal(callbackRequest{Artifact: "artifacts/cpu.pb.gz", View: view, SampleIndex: sampleIndex})
+		if err != nil {
+			t.Fatalf("failed to marshal request: %v", err)
+		}
+		return string(b)
+	}
+	c := parseConfig(nil)
+
+	t.Run("top of the default sample type", func(t *testing.T) {
+		expected := &callbackResponse{
+			SampleTypes: []string{"samples (count)", "cpu (nanoseconds)"},
+			SampleIndex: 1,
+			Total:       "10s",
+			Top: []topRow{
+				{Name: "hash", Flat: "6s", FlatPercent: 60, Cum: "6s", CumPercent: 60},
+				{Name: "work", Flat: "3s", FlatPercent: 30, Cum: "9s", CumPercent: 90},
+				{Name: "idle", Flat: "1s", FlatPercent: 10, Cum: "1s", CumPercent: 10},
+				{Name: "main", Flat: "0s", FlatPercent: 0, Cum: "10s", CumPercent: 100},
+			},
+		}
+		if diff := cmp.Diff(expected, callback(artifacts, request(viewTop, -1), c)); diff != "" {
+			t.Errorf("unexpected response (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("top is limited", func(t *testing.T) {
+		resp := callback(artifacts, request(viewTop, 0), lensConfig{MaxTop: 1})
+		if len(resp.Top) != 1 || resp.Top[0].Name != "hash" || resp.Top[0].Flat != "6" || resp.Total != "10" {
+			t.Errorf("expected only hash with 6 samples, got %+v", resp)
+		}
+	})
+
+	t.Run("flame graph", func(t *testing.T) {
+		expected := &frame{Name: "root", Value: 10, Label: "10", Children: []*frame{
+			{Name: "main", Value: 10, Label: "10", Children: []*frame{
+				{Name: "idle", Value: 1, Label: "1"},
+				{Name: "work", Value: 9, Label: "9", Children: []*frame{
+					{Name: "hash", Value: 6, Label: "6"},
+					{Name: "work", Value: 3, Label: "3"},
+				}},
+			}},
+		}}
+		resp := callback(artifacts, request(viewFlame, 0), c)
+		if diff := cmp.Diff(expected, resp.Flame); diff != "" {
+			t.Errorf("unexpected flame graph (-want +got):\n%s", diff)
+		}
+
+		pruned := callback(artifacts, request(viewFlame, 0), lensConfig{MinFlameFraction: 0.2})
+		if children := pruned.Flame.Children[0].Children; len(children) != 1 || children[0].Name != "work" {
+			t.Errorf("expected idle to be left out, got %+v", children)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			name      string
+			artifacts []api.Artifact
+			data      string
+			expected  string
+		}{
+			{name: "bad request", artifacts: artifacts, data: "{", expected: "Failed to unmarshal request"},
+			{name: "unknown artifact", artifacts: artifacts, data: `{"artifact": "other"}`, expected: "No artifact named other"},
+			{name: "unknown view", artifacts: artifacts, data: request("graph", 0), expected: `Unknown view "graph"`},
+			{
+				name:      "not a profile",
+				artifacts: []api.Artifact{&fake.Artifact{Path: "artifacts/cpu.pb.gz", Content: []byte("nope")}},
+				data:      request(viewTop, 0),
+				expected:  "Failed to parse the profile: parsing profile: unrecognized profile format",
+			},
+		} {
+			if resp := callback(tc.artifacts, tc.data, c); resp.Error != tc.expected {
+				t.Errorf("%s: expected error %q, got %q", tc.name, tc.expected, resp.Error)
+			}
+		}
+	})
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, tc := range []struct {
+		value    int64
+		unit     string
+		expected string
+	}{
+		{1500000, "nanoseconds", "1.5ms"},
+		{512, "bytes", "512B"},
+		{3 * 1024 * 1024, "bytes", "3.00MiB"},
+		{42, "count", "42"},
+	} {
+		if actual := formatValue(tc.value, tc.unit); actual != tc.expected {
+			t.Errorf("formatValue(%d, %q) = %q, expected %q", tc.value, tc.unit, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+type View = 'top' | 'flame';
+
+interface TopRow {
+  name: string;
+  flat: string;
+  flatPercent: number;
+  cum: string;
+  cumPercent: number;
+}
+
+interface Frame {
+  name: string;
+  value: number;
+  label: string;
+  children?: Frame[];
+}
+
+interface ViewResponse {
+  error?: string;
+  sampleTypes?: string[];
+  sampleIndex: number;
+  total?: string;
+  top?: TopRow[];
+  flame?: Frame;
+}
+
+// Frames narrower than this share of the flame graph are not drawn.
+const minFramePercent = 0.2;
+
+function frameColor(name: string): string {
+  // Hash the name so that a function has the same color everywhere.
+  let hash = 0;
+  for (let i = 0; i < name.length; i++) {
+    hash = (hash * 31 + name.charCodeAt(i)) | 0;
+  }
+  const hue = 20 + Math.abs(hash) % 40;
+  return `hsl(${hue}, 90%, ${60 + Math.abs(hash >> 8) % 20}%)`;
+}
+
+function percent(v: number): string {
+  return `${v.toFixed(2)}%`;
+}
+
+function renderTop(rows: TopRow[]): HTMLElement {
+  const table = document.createElement('table');
+  table.className = 'pprof-top';
+  const head = table.createTHead().insertRow();
+  for (const title of ['Flat', 'Flat%', 'Cum', 'Cum%', 'Function']) {
+    const th = document.createElement('th');
+    th.textContent = title;
+    head.appendChild(th);
+  }
+  const body = table.createTBody();
+  for (const row of rows) {
+    const tr = body.insertRow();
+    for (const value of [row.flat, percent(row.flatPercent), row.cum, percent(row.cumPercent), row.name]) {
+      tr.insertCell().textContent = value;
+    }
+  }
+  return table;
+}
+
+// renderFrames draws a frame and its callees below it, each as wide as its
+// share of the root.
+function renderFrames(f: Frame, root: Frame, zoom: (f: Frame) => void): HTMLElement {
+  const share = root.value > 0 ? 100 * f.value / root.value : 0;
+  const elem = document.createElement('div');
+  elem.className = 'pprof-frame';
+  const label = document.createElement('div');
+  label.className = 'pprof-frame-label';
+  label.textContent = f.name;
+  label.title = `${f.name}: ${f.label} (${percent(share)})`;
+  label.style.backgroundColor = frameColor(f.name);
+  label.onclick = () => zoom(f);
+  elem.appendChild(label);
+
+  const children = (f.children || []).filter((c) => 100 * c.value / root.value >= minFramePercent);
+  if (children.length > 0) {
+    const row = document.createElement('div');
+    row.className = 'pprof-frame-row';
+    for (const child of children) {
+      const childElem = renderFrames(child, root, zoom);
+      childElem.style.width = `${100 * child.value / f.value}%`;
+      row.appendChild(childElem);
+    }
+    elem.appendChild(row);
+  }
+  return elem;
+}
+
+function renderFlame(root: Frame): HTMLElement {
+  const container = document.createElement('div');
+  container.className = 'pprof-flame';
+  const draw = (f: Frame) => {
+    container.innerHTML = '';
+    if (f !== root) {
+      const reset = document.createElement('div');
+      reset.className = 'pprof-flame-reset';
+      reset.textContent = 'Reset zoom';
+      reset.onclick = () => draw(root);
+      container.appendChild(reset);
+    }
+    container.appendChild(renderFrames(f, f, draw));
+    spyglass.contentUpdated();
+  };
+  draw(root);
+  return container;
+}
+
+async function loadView(profile: HTMLElement, view: View, sampleIndex: number): Promise<void> {
+  const content = profile.querySelector<HTMLDivElement>('.pprof-content')!;
+  const select = profile.querySelector<HTMLSelectElement>('.pprof-sample')!;
+  const total = profile.querySelector<HTMLSpanElement>('.pprof-total')!;
+  content.textContent = 'Loading...';
+  for (const button of Array.from(profile.querySelectorAll<HTMLButtonElement>('.pprof-view'))) {
+    button.classList.toggle('pprof-selected', button.dataset.view === view);
+  }
+
+  const resp = JSON.parse(await spyglass.request(JSON.stringify({
+    artifact: profile.dataset.artifact,
+    sampleIndex,
+    view,
+  }))) as ViewResponse;
+  content.innerHTML = '';
+  if (resp.error) {
+    content.className = 'pprof-content pprof-error';
+    content.textContent = resp.error;
+    spyglass.contentUpdated();
+    return;
+  }
+  content.className = 'pprof-content';
+
+  if (select.options.length === 0) {
+    (resp.sampleTypes || []).forEach((sampleType, i) => {
+      select.add(new Option(sampleType, String(i)));
+    });
+  }
+  select.value = String(resp.sampleIndex);
+  profile.dataset.view = view;
+  total.textContent = resp.total ? `Total: ${resp.total}` : '';
+
+  if (view === 'top') {
+    content.appendChild(renderTop(resp.top || []));
+  } else if (resp.flame) {
+    content.appendChild(renderFlame(resp.flame));
+  }
+  spyglass.contentUpdated();
+}
+
+window.addEventListener('load', () => {
+  for (const profile of Array.from(document.querySelectorAll<HTMLDivElement>('.pprof-profile'))) {
+    const select = profile.querySelector<HTMLSelectElement>('.pprof-sample')!;
+    select.onchange = () => loadView(profile, (profile.dataset.view || 'top') as View, Number(select.value));
+    for (const button of Array.from(profile.querySelectorAll<HTMLButtonElement>('.pprof-view'))) {
+      button.onclick = () => loadView(profile, button.dataset.view as View, select.options.length > 0 ? Number(select.value) : -1);
+    }
+    loadView(profile, 'top', -1);
+  }
+});
@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pprof
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// topRow is a function of the top table.
+type topRow struct {
+	Name string `json:"name"`
+	// Flat is the value of the samples in the function itself.
+	Flat        string  `json:"flat"`
+	FlatPercent float64 `json:"flatPercent"`
+	// Cum is the value of the samples in the function and its callees.
+	Cum        string  `json:"cum"`
+	CumPercent float64 `json:"cumPercent"`
+}
+
+// frame is a frame of the flame graph, with the frames it calls.
+type frame struct {
+	Name     string   `json:"name"`
+	Value    int64    `json:"value"`
+	Label    string   `json:"label"`
+	Children []*frame `json:"children,omitempty"`
+}
+
+// stack returns the functions of a sample, the outermost caller first.
+func stack(s *profile.Sample) []string {
+	var names []string
+	for i := len(s.Location) - 1; i >= 0; i-- {
+		loc := s.Location[i]
+		if len(loc.Line) == 0 {
+			names = append(names, fmt.Sprintf("0x%x", loc.Address))
+			continue
+		}
+		// The lines of a location are the inlined functions, the callee
+		// first.
+		for j := len(loc.Line) - 1; j >= 0; j-- {
+			name := "?"
+			if loc.Line[j].Function != nil {
+				name = loc.Line[j].Function.Name
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// top returns the functions with the most flat value, at most maxRows.
+func top(p *profile.Profile, sampleIndex, maxRows int) []topRow {
+	unit := p.SampleType[sampleIndex].Unit
+	flat := map[string]int64{}
+	cum := map[string]int64{}
+	var total int64
+	for _, s := range p.Sample {
+		v := s.Value[sampleIndex]
+		total += v
+		names := stack(s)
+		if len(names) == 0 {
+			continue
+		}
+		flat[names[len(names)-1]] += v
+		// Recursive functions count once in the cumulative value.
+		seen := map[string]bool{}
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				cum[name] += v
+			}
+		}
+	}
+
+	names := make([]string, 0, len(cum))
+	for name := range cum {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		if flat[a] != flat[b] {
+			return flat[a] > flat[b]
+		}
+		if cum[a] != cum[b] {
+			return cum[a] > cum[b]
+		}
+		return a < b
+	})
+	if len(names) > maxRows {
+		names = names[:maxRows]
+	}
+
+	percent := func(v int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return 100 * float64(v) / float64(total)
+	}
+	rows := make([]topRow, 0, len(names))
+	for _, name := range names {
+		rows = append(rows, topRow{
+			Name:        name,
+			Flat:        formatValue(flat[name], unit),
+			FlatPercent: percent(flat[name]),
+			Cum:         formatValue(cum[name], unit),
+			CumPercent:  percent(cum[name]),
+		})
+	}
+	return rows
+}
+
+// flameGraph returns the root frame of the flame graph of the profile, with
+// the frames under minFraction of the total left out.
+func flameGraph(p *profile.Profile, sampleIndex int, minFraction float64) *frame {
+	unit := p.SampleType[sampleIndex].Unit
+	root := &frame{Name: "root"}
+	children := map[*frame]map[string]*frame{}
+	for _, s := range p.Sample {
+		v := s.Value[sampleIndex]
+		node := root
+		node.Value += v
+		for _, name := range stack(s) {
+			if children[node] == nil {
+				children[node] = map[string]*frame{}
+			}
+			child, ok := children[node][name]
+			if !ok {
+				child = &frame{Name: name}
+				children[node][name] = child
+			}
+			child.Value += v
+			node = child
+		}
+	}
+
+	minValue := int64(minFraction * float64(root.Value))
+	var build func(f *frame)
+	build = func(f *frame) {
+		f.Label = formatValue(f.Value, unit)
+		for _, child := range children[f] {
+			if child.Value <= 0 || child.Value < minValue {
+				continue
+			}
+			build(child)
+			f.Children = append(f.Children, child)
+		}
+		sort.Slice(f.Children, func(i, j int) bool { return f.Children[i].Name < f.Children[j].Name })
+	}
+	build(root)
+	return root
+}
+
+// formatValue formats a sample value of the given unit for humans.
+func formatValue(v int64, unit string) string {
+	switch unit {
+	case "nanoseconds":
+		return time.Duration(v).String()
+	case "microseconds":
+		return (time.Duration(v) * time.Microsecond).String()
+	case "milliseconds":
+		return (time.Duration(v) * time.Millisecond).String()
+	case "seconds":
+		return (time.Duration(v) * time.Second).String()
+	case "bytes":
+		const k = 1024
+		f := float64(v)
+		for _, suffix := range []string{"B", "KiB", "MiB", "GiB"} {
+			if f < k && f > -k {
+				if suffix == "B" {
+					return fmt.Sprintf("%d%s", v, suffix)
+				}
+				return fmt.Sprintf("%.2f%s", f, suffix)
+			}
+			f /= k
+		}
+		return fmt.Sprintf("%.2fTiB", f)
+	}
+	return fmt.Sprintf("%d", v)
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sarif provides a lens that renders the findings of SARIF reports
+// written by linters and scanners.
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "sarif"
+	title    = "Static Analysis"
+	priority = 12
+
+	defaultMaxFindingsPerRule = 100
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens renders SARIF reports.
+type Lens struct{}
+
+type lensConfig struct {
+	// MaxFindingsPerRule is the number of findings shown for each rule.
+	// Defaults to 100.
+	MaxFindingsPerRule int `json:"max_findings_per_rule,omitempty"`
+}
+
+func parseConfig(raw json.RawMessage) lensConfig {
+	var c lensConfig
+	if len(raw) != 0 {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			logrus.WithError(err).Warn("Failed to parse the config of the sarif lens, using the defaults.")
+		}
+	}
+	if c.MaxFindingsPerRule <= 0 {
+		c.MaxFindingsPerRule = defaultMaxFindingsPerRule
+	}
+	return c
+}
+
+// finding is a result of a rule.
+type finding struct {
+	Message string
+	Path    string
+	Line    int
+	// Link links to the line in the source of the tested commit, if known.
+	Link string
+}
+
+// ruleFindings are the findings of a rule.
+type ruleFindings struct {
+	Tool        string
+	RuleID      string
+	Name        string
+	Description string
+	HelpURI     string
+	Level       level
+	Findings    []finding
+	// Hidden is the number of findings that are not shown.
+	Hidden int
+}
+
+type reportView struct {
+	// Counts is the number of findings of each level.
+	Counts map[string]int
+	Total  int
+	// Rules are the most severe first.
+	Rules []ruleFindings
+	// Commit is the commit the findings link to.
+	Commit string
+	// Errors are the reports that could not be read.
+	Errors []string
+}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "header", nil)
+	if err != nil {
+		logrus.Warnf("Failed to render header: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Body renders the findings of the SARIF reports, linking them to the source
+// when the prowjob.json of the job is among the artifacts.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "body", newReportView(artifacts, parseConfig(rawConfig).MaxFindingsPerRule))
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Callback does nothing.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return ""
+}
+
+func newReportView(artifacts []api.Artifact, maxFindings int) reportView {
+	view := reportView{Counts: map[string]int{}}
+	var src *sourceLinker
+	var reports []api.Artifact
+	for _, artifact := range artifacts {
+		if artifact.JobPath() != "prowjob.json" {
+			reports = append(reports, artifact)
+			continue
+		}
+		content, err := artifact.ReadAll()
+		if err != nil {
+			logrus.WithError(err).Warn("Couldn't read a prowjob file that should exist.")
+			continue
+		}
+		var pj prowapi.ProwJob
+		if err := json.Unmarshal(content, &pj); err != nil {
+			logrus.WithError(err).Info("Error unmarshalling prowjob")
+			continue
+		}
+		src = newSourceLinker(pj.Spec.Refs)
+	}
+	if src != nil {
+		view.Commit = src.sha
+	}
+
+	rules := map[string]*ruleFindings{}
+	for _, artifact := range reports {
+		content, err := artifact.ReadAll()
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Warn("Error reading artifact")
+			view.Errors = append(view.Errors, fmt.Sprintf("%s: %v", artifact.JobPath(), err))
+			continue
+		}
+		log, err := parseLog(content)
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Info("Error parsing SARIF report.")
+			view.Errors = append(view.Errors, fmt.Sprintf("%s: %v", artifact.JobPath(), err))
+			continue
+		}
+		for _, run := range log.Runs {
+			tool := run.Tool.Driver.Name
+			for _, result := range run.Results {
+				rule := run.rule(result)
+				ruleID := result.ruleID(rule)
+				key := tool + "/" + ruleID
+				group, ok := rules[key]
+				if !ok {
+					group = &ruleFindings{Tool: tool, RuleID: ruleID, Level: levelWarning}
+					if rule != nil {
+						group.Name = rule.Name
+						group.Description = rule.ShortDescription.String()
+						group.HelpURI = rule.HelpURI
+					}
+					rules[key] = group
+				}
+				lvl := result.level(rule)
+				// A rule is as severe as its most severe finding.
+				if lvl.severity() > group.Level.severity() || len(group.Findings)+group.Hidden == 0 {
+					group.Level = lvl
+				}
+				view.Counts[string(lvl)]++
+				view.Total++
+				f := finding{Message: result.Message.String()}
+				if loc := result.physicalLocation(); loc != nil {
+					var inRepo bool
+					f.Path, inRepo = run.path(loc.ArtifactLocation, src)
+					if loc.Region != nil {
+						f.Line = loc.Region.StartLine
+					}
+					if src != nil && inRepo && f.Path != "" {
+						f.Link = src.link(f.Path, f.Line)
+					}
+				}
+				if len(group.Findings) < maxFindings {
+					group.Findings = append(group.Findings, f)
+				} else {
+					group.Hidden++
+				}
+			}
+		}
+	}
+
+	for _, group := range rules {
+		view.Rules = append(view.Rules, *group)
+	}
+	sort.Slice(view.Rules, func(i, j int) bool {
+		a, b := view.Rules[i], view.Rules[j]
+		if a.Level.severity() != b.Level.severity() {
+			return a.Level.severity() > b.Level.severity()
+		}
+		if na, nb := len(a.Findings)+a.Hidden, len(b.Findings)+b.Hidden; na != nb {
+			return na > nb
+		}
+		return a.Tool+"/"+a.RuleID < b.Tool+"/"+b.RuleID
+	})
+	return view
+}
+
+func renderTemplate(resourceDir, block string, params interface{}) (string, error) {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, block, params); err != nil {
+		return "", fmt.Errorf("Failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
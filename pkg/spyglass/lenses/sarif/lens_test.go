@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sarif
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+const report = `{
+  "version": "2.1.0",
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "golangci-lint",
+          "rules": [
+            {"id": "errcheck", "shortDescription": {"text": "Unchecked errors"}, "helpUri": "https://example.com/errcheck"},
+            {"id": "G101", "name": "HardcodedCredentials", "defaultConfiguration": {"level": "error"}}
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "errcheck",
+          "message": {"text": "Error return value is not checked"},
+          "locations": [{"physicalLocation": {"artifactLocation": {"uri": "pkg/a.go", "uriBaseId": "%SRCROOT%"}, "region": {"startLine": 12}}}]
+        },
+        {
+          "ruleId": "errcheck",
+          "level": "note",
+          "message": {"text": "Another one"},
+          "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///home/prow/go/src/github.com/org/repo/pkg/b%20c.go"}}}]
+        },
+        {
+          "ruleIndex": 1,
+          "message": {"markdown": "Potential **hardcoded** credentials"},
+          "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///tmp/generated.go"}, "region": {"startLine": 3}}}]
+        },
+        {
+          "ruleId": "G101",
+          "kind": "pass",
+          "message": {"text": "Nothing to see"}
+        }
+      ]
+    }
+  ]
+}`
+
+const prowJob = `{
+  "spec": {
+    "type": "presubmit",
+    "refs": {
+      "org": "org",
+      "repo": "repo",
+      "repo_link": "https://github.com/org/repo",
+      "base_sha": "base",
+      "pulls": [{"number": 1, "sha": "head"}]
+    }
+  }
+}`
+
+func TestNewReportView(t *testing.T) {
+	artifacts := []api.Artifact{
+		&fake.Artifact{Path: "artifacts/lint.sarif", Content: []byte(report)},
+		&fake.Artifact{Path: "artifacts/old.sarif", Content: []byte(`{"version": "1.0.0"}`)},
+		&fake.Artifact{Path: "prowjob.json", Content: []byte(prowJob)},
+	}
+	expected := reportView{
+		Counts: map[string]int{"error": 1, "warning": 1, "note": 1},
+		Total:  3,
+		Rules: []ruleFindings{
+			{
+				Tool:   "golangci-lint",
+				RuleID: "G101",
+				Name:   "HardcodedCredentials",
+				Level:  levelError,
+				Findings: []finding{
+					{Message: "Potential **hardcoded** credentials", Path: "/tmp/generated.go", Line: 3},
+				},
+			},
+			{
+				Tool:        "golangci-lint",
+				RuleID:      "errcheck",
+				Description: "Unchecked errors",
+				HelpURI:     "https://example.com/errcheck",
+				Level:       levelWarning,
+				Findings: []finding{
+					{Message: "Error return value is not checked", Path: "pkg/a.go", Line: 12, Link: "https://github.com/org/repo/blob/head/pkg/a.go#L12"},
+					{Message: "Another one", Path: "pkg/b c.go", Link: "https://github.com/org/repo/blob/head/pkg/b c.go"},
+				},
+			},
+		},
+		Commit: "head",
+		Errors: []string{`artifacts/old.sarif: unsupported SARIF version "1.0.0"`},
+	}
+	actual := newReportView(artifacts, defaultMaxFindingsPerRule)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected view (-want +got):\n%s", diff)
+	}
+
+	limited := newReportView(artifacts[:1], 1)
+	if errcheck := limited.Rules[1]; len(errcheck.Findings) != 1 || errcheck.Hidden != 1 {
+		t.Errorf("expected one errcheck finding to be hidden, got %+v", errcheck)
+	}
+	if limited.Rules[1].Findings[0].Link != "" || limited.Commit != "" {
+		t.Errorf("expected no links without a prowjob, got %+v", limited)
+	}
+}
+
+func TestNewSourceLinker(t *testing.T) {
+	testCases := []struct {
+		name         string
+		job          string
+		path         string
+		expectedLink string
+	}{
+		{
+			name:         "postsubmits link to their base",
+			job:          `{"org": "org", "repo": "repo", "base_sha": "base"}`,
+			path:         "/go/src/github.com/org/repo/main.go",
+			expectedLink: "https://github.com/org/repo/blob/base/main.go#L1",
+		},
+		{
+			name:         "path aliases are checkout roots",
+			job:          `{"org": "kubernetes", "repo": "kubernetes", "path_alias": "k8s.io/kubernetes", "repo_link": "https://ghe.example.com/kubernetes/kubernetes/", "base_sha": "base"}`,
+			path:         "/go/src/k8s.io/kubernetes/cmd/main.go",
+			expectedLink: "https://ghe.example.com/kubernetes/kubernetes/blob/base/cmd/main.go#L1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content := `{"spec": {"refs": ` + tc.job + `}}`
+			sarif := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "t"}}, "results": [{"ruleId": "r", "message": {"text": "m"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file://` + tc.path + `"}, "region": {"startLine": 1}}}]}]}]}`
+			view := newReportView([]api.Artifact{
+				&fake.Artifact{Path: "prowjob.json", Content: []byte(content)},
+				&fake.Artifact{Path: "report.sarif", Content: []byte(sarif)},
+			}, defaultMaxFindingsPerRule)
+			if link := view.Rules[0].Findings[0].Link; link != tc.expectedLink {
+				t.Errorf("expected link %q, got %q", tc.expectedLink, link)
+			}
+		})
+	}
+}
+
+func TestBody(t *testing.T) {
+	artifacts := []api.Artifact{&fake.Artifact{Path: "artifacts/lint.sarif", Content: []byte(report)}}
+	body := Lens{}.Body(artifacts, ".", "", nil, config.Spyglass{})
+	for _, expected := range []string{"3 findings: 1 errors, 1 warnings, 1 notes.", `<details class="sarif-rule" open data-spyglass-failure>`, "Unchecked errors"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected the body to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
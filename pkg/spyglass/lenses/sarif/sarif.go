@@ -0,0 +1,271 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// The types below are the parts of SARIF 2.1 the lens reads, see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+type sarifLog struct {
+	Version string `json:"version"`
+	Runs    []run  `json:"runs"`
+}
+
+type run struct {
+	Tool struct {
+		Driver struct {
+			Name  string `json:"name"`
+			Rules []rule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []result `json:"results"`
+}
+
+type rule struct {
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	ShortDescription     *message `json:"shortDescription"`
+	HelpURI              string   `json:"helpUri"`
+	DefaultConfiguration *struct {
+		Level level `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type result struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex *int   `json:"ruleIndex"`
+	Rule      *struct {
+		ID    string `json:"id"`
+		Index *int   `json:"index"`
+	} `json:"rule"`
+	Kind      string     `json:"kind"`
+	Level     level      `json:"level"`
+	Message   message    `json:"message"`
+	Locations []location `json:"locations"`
+}
+
+type message struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown"`
+}
+
+type location struct {
+	PhysicalLocation *physicalLocation `json:"physicalLocation"`
+}
+
+type physicalLocation struct {
+	ArtifactLocation artifactLocation `json:"artifactLocation"`
+	Region           *struct {
+		StartLine int `json:"startLine"`
+	} `json:"region"`
+}
+
+type artifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// level is the severity of a finding.
+type level string
+
+const (
+	levelError   level = "error"
+	levelWarning level = "warning"
+	levelNote    level = "note"
+)
+
+func (l level) severity() int {
+	switch l {
+	case levelError:
+		return 3
+	case levelWarning:
+		return 2
+	case levelNote:
+		return 1
+	}
+	return 0
+}
+
+func parseLog(content []byte) (*sarifLog, error) {
+	var log sarifLog
+	if err := json.Unmarshal(content, &log); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(log.Version, "2.") {
+		return nil, fmt.Errorf("unsupported SARIF version %q", log.Version)
+	}
+	for i := range log.Runs {
+		log.Runs[i].Results = dropPassing(log.Runs[i].Results)
+	}
+	return &log, nil
+}
+
+// dropPassing drops the results that are not findings, e.g. the rules a
+// scanner checked and found nothing for.
+func dropPassing(results []result) []result {
+	var findings []result
+	for _, r := range results {
+		if r.Kind == "pass" || r.Kind == "notApplicable" {
+			continue
+		}
+		findings = append(findings, r)
+	}
+	return findings
+}
+
+func (m *message) String() string {
+	if m == nil {
+		return ""
+	}
+	if m.Text != "" {
+		return m.Text
+	}
+	return m.Markdown
+}
+
+// rule returns the rule of a result, if the run describes it.
+func (r *run) rule(res result) *rule {
+	rules := r.Tool.Driver.Rules
+	index := res.RuleIndex
+	if index == nil && res.Rule != nil {
+		index = res.Rule.Index
+	}
+	if index != nil && *index >= 0 && *index < len(rules) {
+		return &rules[*index]
+	}
+	id := res.RuleID
+	if id == "" && res.Rule != nil {
+		id = res.Rule.ID
+	}
+	for i := range rules {
+		if rules[i].ID == id {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func (res *result) ruleID(rule *rule) string {
+	switch {
+	case res.RuleID != "":
+		return res.RuleID
+	case res.Rule != nil && res.Rule.ID != "":
+		return res.Rule.ID
+	case rule != nil:
+		return rule.ID
+	}
+	return "(no rule)"
+}
+
+// level returns the level of a result, which defaults to the one of its rule
+// and otherwise to warning.
+func (res *result) level(rule *rule) level {
+	if res.Level != "" {
+		return res.Level
+	}
+	if rule != nil && rule.DefaultConfiguration != nil && rule.DefaultConfiguration.Level != "" {
+		return rule.DefaultConfiguration.Level
+	}
+	return levelWarning
+}
+
+func (res *result) physicalLocation() *physicalLocation {
+	for _, loc := range res.Locations {
+		if loc.PhysicalLocation != nil {
+			return loc.PhysicalLocation
+		}
+	}
+	return nil
+}
+
+// path returns the path of an artifact relative to the root of the repo, and
+// whether it is one; other paths are returned as they are in the report.
+func (r *run) path(loc artifactLocation, src *sourceLinker) (string, bool) {
+	uri := loc.URI
+	if unescaped, err := url.PathUnescape(uri); err == nil {
+		uri = unescaped
+	}
+	if !strings.HasPrefix(uri, "file:") && !strings.HasPrefix(uri, "/") {
+		// Relative locations are relative to the root of the repo, which
+		// is what scanners set their base, e.g. %SRCROOT%, to.
+		return strings.TrimPrefix(uri, "./"), true
+	}
+	p := strings.TrimPrefix(strings.TrimPrefix(uri, "file://"), "file:")
+	if src != nil {
+		if rel, ok := src.relative(p); ok {
+			return rel, true
+		}
+	}
+	return p, false
+}
+
+// sourceLinker links to lines of the source of the commit a job tested.
+type sourceLinker struct {
+	repoLink string
+	sha      string
+	// roots are the directory names the repo may be checked out in.
+	roots []string
+}
+
+func newSourceLinker(refs *prowapi.Refs) *sourceLinker {
+	if refs == nil {
+		return nil
+	}
+	// Presubmits test the head of their PR, the others their base.
+	sha := refs.BaseSHA
+	if len(refs.Pulls) > 0 {
+		sha = refs.Pulls[0].SHA
+	}
+	if sha == "" {
+		return nil
+	}
+	repoLink := refs.RepoLink
+	if repoLink == "" {
+		repoLink = fmt.Sprintf("https://github.com/%s/%s", refs.Org, refs.Repo)
+	}
+	roots := []string{"/" + refs.Org + "/" + refs.Repo + "/"}
+	if refs.PathAlias != "" {
+		roots = append([]string{"/" + strings.Trim(refs.PathAlias, "/") + "/"}, roots...)
+	}
+	return &sourceLinker{repoLink: strings.TrimSuffix(repoLink, "/"), sha: sha, roots: roots}
+}
+
+// relative returns the path of a file of the repo relative to its root, from
+// the absolute path it was checked out at, e.g. by clonerefs.
+func (s *sourceLinker) relative(p string) (string, bool) {
+	for _, root := range s.roots {
+		if i := strings.LastIndex(p, root); i >= 0 {
+			return p[i+len(root):], true
+		}
+	}
+	return "", false
+}
+
+func (s *sourceLinker) link(p string, line int) string {
+	link := fmt.Sprintf("%s/blob/%s/%s", s.repoLink, s.sha, p)
+	if line > 0 {
+		link += fmt.Sprintf("#L%d", line)
+	}
+	return link
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tfplan provides a lens that renders the resource changes of
+// Terraform and OpenTofu plans.
+package tfplan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name     = "tfplan"
+	title    = "Terraform Plan"
+	priority = 8
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens renders Terraform plans.
+type Lens struct{}
+
+// moduleNode is a module of a plan with the resources it changes.
+type moduleNode struct {
+	// Address is empty for the root module.
+	Address   string
+	Resources []resourceChange
+	Modules   []*moduleNode
+	// Changes and Destructive are the number of changes and destructive
+	// changes of the module and the modules it calls.
+	Changes     int
+	Destructive int
+}
+
+type planView struct {
+	Path string
+	// Add, Change and Destroy count replaced resources as one addition and
+	// one destruction, as Terraform does.
+	Add, Change, Destroy                int
+	Replace, Import, Move, Forget, Read int
+	// Destructive are the resources that are deleted or replaced.
+	Destructive []resourceChange
+	Root        *moduleNode
+	Drift       []resourceChange
+	Diagnostics []string
+}
+
+type view struct {
+	Plans []planView
+	// Errors are the plans that could not be read.
+	Errors []string
+}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "header", nil)
+	if err != nil {
+		logrus.Warnf("Failed to render header: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Body renders the changes of the plans, destructive ones first.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "body", newView(artifacts))
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Callback does nothing.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return ""
+}
+
+func newView(artifacts []api.Artifact) view {
+	var v view
+	for _, artifact := range artifacts {
+		content, err := artifact.ReadAll()
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Warn("Error reading artifact")
+			v.Errors = append(v.Errors, fmt.Sprintf("%s: %v", artifact.JobPath(), err))
+			continue
+		}
+		p, err := parsePlan(content)
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", artifact.CanonicalLink()).Info("Error parsing Terraform plan.")
+			v.Errors = append(v.Errors, fmt.Sprintf("%s: %v", artifact.JobPath(), err))
+			continue
+		}
+		v.Plans = append(v.Plans, newPlanView(artifact.JobPath(), p))
+	}
+	return v
+}
+
+func newPlanView(path string, p *plan) planView {
+	pv := planView{Path: path, Root: &moduleNode{}, Drift: p.Drift, Diagnostics: p.Diagnostics}
+	for _, c := range p.Changes {
+		switch c.Action {
+		case actionCreate:
+			pv.Add++
+		case actionUpdate:
+			pv.Change++
+		case actionDelete:
+			pv.Destroy++
+		case actionReplace:
+			pv.Add++
+			pv.Destroy++
+			pv.Replace++
+		case actionImport:
+			pv.Import++
+		case actionMove:
+			pv.Move++
+		case actionForget:
+			pv.Forget++
+		case actionRead:
+			pv.Read++
+		}
+		if c.Action.destructive() {
+			pv.Destructive = append(pv.Destructive, c)
+		}
+		pv.Root.add(c)
+	}
+	sort.SliceStable(pv.Destructive, func(i, j int) bool {
+		return pv.Destructive[i].Address < pv.Destructive[j].Address
+	})
+	pv.Root.sort()
+	return pv
+}
+
+// add adds a change to the module of its resource, adding the modules
+// between it and the root.
+func (m *moduleNode) add(c resourceChange) {
+	node := m
+	path := []*moduleNode{m}
+	for _, step := range moduleSteps(c.Module) {
+		address := step
+		if node.Address != "" {
+			address = node.Address + "." + step
+		}
+		var child *moduleNode
+		for _, candidate := range node.Modules {
+			if candidate.Address == address {
+				child = candidate
+				break
+			}
+		}
+		if child == nil {
+			child = &moduleNode{Address: address}
+			node.Modules = append(node.Modules, child)
+		}
+		node = child
+		path = append(path, node)
+	}
+	node.Resources = append(node.Resources, c)
+	for _, n := range path {
+		n.Changes++
+		if c.Action.destructive() {
+			n.Destructive++
+		}
+	}
+}
+
+func (m *moduleNode) sort() {
+	sort.SliceStable(m.Resources, func(i, j int) bool {
+		return m.Resources[i].Address < m.Resources[j].Address
+	})
+	sort.SliceStable(m.Modules, func(i, j int) bool {
+		return m.Modules[i].Address < m.Modules[j].Address
+	})
+	for _, child := range m.Modules {
+		child.sort()
+	}
+}
+
+// Name is the address of a resource relative to its module.
+func (c resourceChange) Name() string {
+	if c.Module == "" {
+		return c.Address
+	}
+	return strings.TrimPrefix(c.Address, c.Module+".")
+}
+
+func renderTemplate(resourceDir, block string, params interface{}) (string, error) {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, block, params); err != nil {
+		return "", fmt.Errorf("Failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tfplan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+const showPlan = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_s3_bucket.logs", "change": {"actions": ["create"]}},
+    {"address": "aws_instance.web", "change": {"actions": ["no-op"]}},
+    {"address": "module.db.aws_db_instance.main", "module_address": "module.db", "change": {"actions": ["delete", "create"]}, "action_reason": "replace_because_cannot_update"},
+    {"address": "module.db.module.net[\"a.b\"].aws_subnet.x", "module_address": "module.db.module.net[\"a.b\"]", "change": {"actions": ["update"]}},
+    {"address": "aws_iam_role.old", "change": {"actions": ["delete"]}, "action_reason": "delete_because_no_resource_config"},
+    {"address": "aws_iam_role.new", "previous_address": "aws_iam_role.renamed", "change": {"actions": ["no-op"]}}
+  ],
+  "resource_drift": [
+    {"address": "aws_instance.web", "change": {"actions": ["update"]}}
+  ]
+}`
+
+const uiPlan = `{"@level":"info","@message":"Terraform 1.6.0","type":"version"}
+{"@level":"info","@message":"aws_s3_bucket.logs: Plan to create","change":{"resource":{"addr":"aws_s3_bucket.logs","module":""},"action":"create"},"type":"planned_change"}
+{"@level":"info","@message":"module.db.aws_db_instance.main: Plan to replace","change":{"resource":{"addr":"module.db.aws_db_instance.main","module":"module.db"},"action":"replace","reason":"tainted"},"type":"planned_change"}
+{"@level":"info","@message":"Plan: 2 to add, 0 to change, 1 to destroy.","changes":{"add":2,"change":0,"remove":1,"operation":"plan"},"type":"change_summary"}
+{"@level":"error","@message":"Error: Invalid reference","diagnostic":{"severity":"error","summary":"Invalid reference","detail":"A reference must be followed by an attribute."},"type":"diagnostic"}
+`
+
+func TestParsePlan(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    *plan
+		expectedErr string
+	}{
+		{
+			name:    "terraform show -json",
+			content: showPlan,
+			expected: &plan{
+				Changes: []resourceChange{
+					{Address: "aws_s3_bucket.logs", Action: actionCreate},
+					{Address: "module.db.aws_db_instance.main", Module: "module.db", Action: actionReplace, Reason: "cannot update"},
+					{Address: `module.db.module.net["a.b"].aws_subnet.x`, Module: `module.db.module.net["a.b"]`, Action: actionUpdate},
+					{Address: "aws_iam_role.old", Action: actionDelete, Reason: "no resource config"},
+					{Address: "aws_iam_role.new", Action: actionMove, Reason: "from aws_iam_role.renamed"},
+				},
+				Drift: []resourceChange{{Address: "aws_instance.web", Action: actionUpdate}},
+			},
+		},
+		{
+			name:    "terraform plan -json",
+			content: uiPlan,
+			expected: &plan{
+				Changes: []resourceChange{
+					{Address: "aws_s3_bucket.logs", Action: actionCreate},
+					{Address: "module.db.aws_db_instance.main", Module: "module.db", Action: actionReplace, Reason: "tainted"},
+				},
+				Diagnostics: []string{"Invalid reference: A reference must be followed by an attribute."},
+			},
+		},
+		{
+			name:        "not a plan",
+			content:     `{"foo": "bar"}`,
+			expectedErr: "not a Terraform plan",
+		},
+		{
+			name:        "not JSON",
+			content:     "Plan: 1 to add",
+			expectedErr: "line 1: ",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parsePlan([]byte(tc.content))
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected plan (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModuleSteps(t *testing.T) {
+	testCases := map[string][]string{
+		"":                                nil,
+		"module.a":                        {"module.a"},
+		`module.a["x.y"].module.b[0]`:     {`module.a["x.y"]`, "module.b[0]"},
+		`module.a["quote \" ."].module.b`: {`module.a["quote \" ."]`, "module.b"},
+	}
+	for address, expected := range testCases {
+		if diff := cmp.Diff(expected, moduleSteps(address)); diff != "" {
+			t.Errorf("unexpected steps of %q (-want +got):\n%s", address, diff)
+		}
+	}
+}
+
+func TestNewView(t *testing.T) {
+	artifacts := []api.Artifact{
+		&fake.Artifact{Path: "artifacts/plan.json", Content: []byte(showPlan)},
+		&fake.Artifact{Path: "artifacts/broken.json", Content: []byte("{")},
+	}
+	v := newView(artifacts)
+	if diff := cmp.Diff([]string{"artifacts/broken.json: line 1: unexpected end of JSON input"}, v.Errors); diff != "" {
+		t.Errorf("unexpected errors (-want +got):\n%s", diff)
+	}
+	if len(v.Plans) != 1 {
+		t.Fatalf("expected one plan, got %d", len(v.Plans))
+	}
+	pv := v.Plans[0]
+	counts := []int{pv.Add, pv.Change, pv.Destroy, pv.Replace, pv.Move}
+	if diff := cmp.Diff([]int{2, 1, 2, 1, 1}, counts); diff != "" {
+		t.Errorf("unexpected add, change, destroy, replace and move counts (-want +got):\n%s", diff)
+	}
+	var destructive []string
+	for _, c := range pv.Destructive {
+		destructive = append(destructive, c.Address)
+	}
+	if diff := cmp.Diff([]string{"aws_iam_role.old", "module.db.aws_db_instance.main"}, destructive); diff != "" {
+		t.Errorf("unexpected destructive changes (-want +got):\n%s", diff)
+	}
+
+	root := pv.Root
+	if root.Changes != 5 || root.Destructive != 2 || len(root.Resources) != 3 || len(root.Modules) != 1 {
+		t.Fatalf("unexpected root module: %+v", root)
+	}
+	db := root.Modules[0]
+	if db.Address != "module.db" || db.Changes != 2 || db.Destructive != 1 || len(db.Modules) != 1 {
+		t.Fatalf("unexpected module.db: %+v", db)
+	}
+	net := db.Modules[0]
+	if net.Address != `module.db.module.net["a.b"]` || net.Destructive != 0 || net.Resources[0].Name() != "aws_subnet.x" {
+		t.Errorf("unexpected nested module: %+v", net)
+	}
+}
+
+func TestBody(t *testing.T) {
+	artifacts := []api.Artifact{&fake.Artifact{Path: "artifacts/plan.log", Content: []byte(uiPlan)}}
+	body := Lens{}.Body(artifacts, ".", "", nil, config.Spyglass{})
+	for _, expected := range []string{
+		"1 resources will be destroyed, 1 of them to be replaced",
+		"Plan: 2 to add, 0 to change, 1 to destroy.",
+		`<details class="tfplan-module" open>`,
+		"Error: Invalid reference",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected the body to contain %q, got:\n%s", expected, body)
+		}
+	}
+
+	empty := Lens{}.Body([]api.Artifact{&fake.Artifact{Path: "plan.json", Content: []byte(`{"format_version": "1.2"}`)}}, ".", "", nil, config.Spyglass{})
+	if !strings.Contains(empty, "No changes.") || strings.Contains(empty, "will be destroyed") {
+		t.Errorf("expected an empty plan to have no changes, got:\n%s", empty)
+	}
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tfplan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// action is what a plan does to a resource.
+type action string
+
+const (
+	actionCreate  action = "create"
+	actionUpdate  action = "update"
+	actionReplace action = "replace"
+	actionDelete  action = "delete"
+	actionRead    action = "read"
+	actionImport  action = "import"
+	actionMove    action = "move"
+	actionForget  action = "forget"
+	actionNoop    action = "no-op"
+)
+
+// destructive is whether the action destroys the resource.
+func (a action) destructive() bool {
+	return a == actionDelete || a == actionReplace
+}
+
+// resourceChange is the planned change of a resource.
+type resourceChange struct {
+	Address string
+	// Module is the address of the module of the resource, empty for the
+	// root module.
+	Module string
+	Action action
+	// Reason is why the resource is replaced or deleted, if Terraform says.
+	Reason string
+}
+
+// plan is what the lens reads from either format of plans.
+type plan struct {
+	Changes []resourceChange
+	// Drift are the changes made outside of Terraform since the last apply.
+	Drift []resourceChange
+	// Diagnostics are the errors Terraform reported while planning.
+	Diagnostics []string
+}
+
+// The types below are the parts of the JSON plan representation, the output
+// of `terraform show -json`, the lens reads, see
+// https://developer.hashicorp.com/terraform/internals/json-format
+
+type jsonPlan struct {
+	FormatVersion   string               `json:"format_version"`
+	ResourceChanges []jsonResourceChange `json:"resource_changes"`
+	ResourceDrift   []jsonResourceChange `json:"resource_drift"`
+}
+
+type jsonResourceChange struct {
+	Address         string `json:"address"`
+	ModuleAddress   string `json:"module_address"`
+	PreviousAddress string `json:"previous_address"`
+	Change          struct {
+		Actions   []string  `json:"actions"`
+		Importing *struct{} `json:"importing"`
+	} `json:"change"`
+	ActionReason string `json:"action_reason"`
+}
+
+// The types below are the parts of the machine readable UI, the output of
+// `terraform plan -json`, the lens reads, see
+// https://developer.hashicorp.com/terraform/internals/machine-readable-ui
+
+type uiMessage struct {
+	Type   string `json:"type"`
+	Change *struct {
+		Resource struct {
+			Addr   string `json:"addr"`
+			Module string `json:"module"`
+		} `json:"resource"`
+		Action string `json:"action"`
+		Reason string `json:"reason"`
+	} `json:"change"`
+	Diagnostic *struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+		Detail   string `json:"detail"`
+	} `json:"diagnostic"`
+}
+
+// parsePlan parses a JSON plan or the lines of `terraform plan -json`.
+func parsePlan(content []byte) (*plan, error) {
+	var jp jsonPlan
+	if err := json.Unmarshal(content, &jp); err == nil && jp.FormatVersion != "" {
+		return fromJSONPlan(jp), nil
+	}
+	return fromUI(content)
+}
+
+func fromJSONPlan(jp jsonPlan) *plan {
+	p := &plan{}
+	for _, rc := range jp.ResourceChanges {
+		if c, ok := rc.change(); ok {
+			p.Changes = append(p.Changes, c)
+		}
+	}
+	for _, rc := range jp.ResourceDrift {
+		if c, ok := rc.change(); ok {
+			p.Drift = append(p.Drift, c)
+		}
+	}
+	return p
+}
+
+// change returns the change of a resource, and false for the resources the
+// plan leaves as they are.
+func (rc *jsonResourceChange) change() (resourceChange, bool) {
+	c := resourceChange{Address: rc.Address, Module: rc.ModuleAddress, Reason: reason(rc.ActionReason)}
+	actions := rc.Change.Actions
+	switch {
+	case len(actions) == 2:
+		// Either delete-then-create or create-then-delete.
+		c.Action = actionReplace
+	case len(actions) == 1:
+		c.Action = action(actions[0])
+	default:
+		c.Action = actionNoop
+	}
+	if c.Action == actionNoop {
+		switch {
+		case rc.Change.Importing != nil:
+			c.Action = actionImport
+		case rc.PreviousAddress != "" && rc.PreviousAddress != rc.Address:
+			c.Action = actionMove
+			c.Reason = "from " + rc.PreviousAddress
+		default:
+			return c, false
+		}
+	}
+	return c, true
+}
+
+func fromUI(content []byte) (*plan, error) {
+	p := &plan{}
+	var messages int
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var msg uiMessage
+		if err := json.Unmarshal(text, &msg); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if msg.Type == "" {
+			continue
+		}
+		messages++
+		switch {
+		case msg.Type == "planned_change" && msg.Change != nil:
+			c := resourceChange{
+				Address: msg.Change.Resource.Addr,
+				Module:  msg.Change.Resource.Module,
+				Action:  uiAction(msg.Change.Action),
+				Reason:  reason(msg.Change.Reason),
+			}
+			if c.Action != actionNoop {
+				p.Changes = append(p.Changes, c)
+			}
+		case msg.Type == "resource_drift" && msg.Change != nil:
+			p.Drift = append(p.Drift, resourceChange{
+				Address: msg.Change.Resource.Addr,
+				Module:  msg.Change.Resource.Module,
+				Action:  uiAction(msg.Change.Action),
+			})
+		case msg.Type == "diagnostic" && msg.Diagnostic != nil && msg.Diagnostic.Severity == "error":
+			d := msg.Diagnostic.Summary
+			if msg.Diagnostic.Detail != "" {
+				d += ": " + msg.Diagnostic.Detail
+			}
+			p.Diagnostics = append(p.Diagnostics, d)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if messages == 0 {
+		return nil, errors.New("not a Terraform plan")
+	}
+	return p, nil
+}
+
+// uiAction maps the actions of the machine readable UI to the ones of the
+// JSON plan.
+func uiAction(a string) action {
+	switch a {
+	case "noop":
+		return actionNoop
+	case "remove":
+		return actionForget
+	}
+	return action(a)
+}
+
+// reason turns e.g. replace_because_tainted into "tainted".
+func reason(r string) string {
+	for _, prefix := range []string{"replace_because_", "delete_because_", "read_because_"} {
+		r = strings.TrimPrefix(r, prefix)
+	}
+	return strings.ReplaceAll(r, "_", " ")
+}
+
+// moduleSteps splits a module address, e.g. module.a["x.y"].module.b, into
+// the addresses of its steps, e.g. module.a["x.y"] and module.b.
+func moduleSteps(address string) []string {
+	var parts []string
+	var start, depth int
+	var quoted bool
+	for i := 0; i < len(address); i++ {
+		switch c := address[i]; {
+		case c == '"' && (i == 0 || address[i-1] != '\\'):
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '.' && depth == 0:
+			parts = append(parts, address[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(address) {
+		parts = append(parts, address[start:])
+	}
+	var steps []string
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "module" && i+1 < len(parts) {
+			steps = append(steps, "module."+parts[i+1])
+			i++
+			continue
+		}
+		steps = append(steps, parts[i])
+	}
+	return steps
+}
@@ -0,0 +1,6 @@
+;; Traps if it cannot grow its memory to 128MiB.
+(module
+  (memory (export "memory") 1)
+  (func (export "_start")
+    (if (i32.lt_s (memory.grow (i32.const 2048)) (i32.const 0))
+      (then unreachable))))
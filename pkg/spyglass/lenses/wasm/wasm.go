@@ -0,0 +1,277 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package wasm provides an experimental lens that renders artifacts with a
+// user-provided WebAssembly module. The module is run as a WASI command: it
+// reads the artifacts as JSON from stdin and writes HTML to stdout. It runs
+// without network, filesystem, environment or real clock access, with
+// limited memory, time and output.
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	name  = "wasm"
+	title = "Custom"
+
+	defaultTimeout       = 10 * time.Second
+	defaultMemoryLimitMB = 64
+	defaultMaxOutputSize = 1 << 20
+	// maxStderrSize is how much of the stderr of a failed module is shown.
+	maxStderrSize = 4 << 10
+	// wasmPageSize is the size of a WebAssembly memory page.
+	wasmPageSize = 64 << 10
+)
+
+// compilationCache is shared by the runs of the modules configured by the
+// operator, so they are only compiled once. It never evicts a module, so the
+// modules read from job artifacts, which any job can vary, are not cached.
+var compilationCache = wazero.NewCompilationCache()
+
+func init() {
+	lenses.RegisterLensV2(Lens{})
+}
+
+// Lens is the implementation of the WASM lens.
+type Lens struct{}
+
+type config struct {
+	// Module is the path to the module on the Deck filesystem, e.g. in a
+	// mounted ConfigMap.
+	Module string `json:"module,omitempty"`
+	// ModuleArtifact is the path of the module within the job's artifacts,
+	// which allows repos to ship the module from their own jobs. It must be
+	// matched by the required or optional files of the lens.
+	ModuleArtifact string `json:"module_artifact,omitempty"`
+	// Timeout is how long the module may run. Defaults to 10s.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// MemoryLimitMB is the maximum memory of the module. Defaults to 64.
+	MemoryLimitMB int `json:"memory_limit_mb,omitempty"`
+	// MaxOutputSize is the maximum size of the HTML the module may write in
+	// bytes. Defaults to 1MiB.
+	MaxOutputSize int `json:"max_output_size,omitempty"`
+}
+
+func parseConfig(raw json.RawMessage) (config, error) {
+	var c config
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return c, fmt.Errorf("failed to parse the lens config: %w", err)
+		}
+	}
+	if (c.Module == "") == (c.ModuleArtifact == "") {
+		return c, errors.New("exactly one of module and module_artifact must be set")
+	}
+	if c.Timeout == nil {
+		c.Timeout = &metav1.Duration{Duration: defaultTimeout}
+	}
+	if c.MemoryLimitMB <= 0 {
+		c.MemoryLimitMB = defaultMemoryLimitMB
+	}
+	if c.MaxOutputSize <= 0 {
+		c.MaxOutputSize = defaultMaxOutputSize
+	}
+	return c, nil
+}
+
+// input is written to the stdin of the module.
+type input struct {
+	Artifacts []inputArtifact `json:"artifacts"`
+}
+
+type inputArtifact struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+type bodyData struct {
+	Output string
+	Error  string
+}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: 1,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(_ context.Context, artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig prowconfig.Spyglass) (string, error) {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("failed to load template file: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "header", nil); err != nil {
+		return "", fmt.Errorf("failed to execute header template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Callback does nothing.
+func (lens Lens) Callback(_ context.Context, artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig prowconfig.Spyglass) (string, error) {
+	return "", nil
+}
+
+// Body runs the module on the artifacts and renders its output in a sandboxed
+// iframe that does not allow scripts. The module is stopped when the request
+// is done.
+func (lens Lens) Body(ctx context.Context, artifacts []api.Artifact, resourceDir string, data string, rawConfig json.RawMessage, spyglassConfig prowconfig.Spyglass) (string, error) {
+	var bd bodyData
+	output, err := render(ctx, artifacts, rawConfig)
+	if err != nil {
+		logrus.WithError(err).Info("WASM lens failed to render the artifacts.")
+		bd.Error = err.Error()
+	}
+	bd.Output = output
+
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("failed to load template file: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "body", bd); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// render runs the module on the artifacts and returns the HTML it wrote.
+func render(ctx context.Context, artifacts []api.Artifact, rawConfig json.RawMessage) (string, error) {
+	c, err := parseConfig(rawConfig)
+	if err != nil {
+		return "", err
+	}
+	var module []byte
+	var cache wazero.CompilationCache
+	in := input{Artifacts: []inputArtifact{}}
+	for _, artifact := range artifacts {
+		content, err := artifact.ReadAll()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", artifact.JobPath(), err)
+		}
+		if artifact.JobPath() == c.ModuleArtifact {
+			module = content
+			continue
+		}
+		in.Artifacts = append(in.Artifacts, inputArtifact{Path: artifact.JobPath(), Content: string(content)})
+	}
+	if c.Module != "" {
+		if module, err = os.ReadFile(c.Module); err != nil {
+			return "", fmt.Errorf("failed to read the module: %w", err)
+		}
+		cache = compilationCache
+	} else if module == nil {
+		return "", fmt.Errorf("the module artifact %s was not found", c.ModuleArtifact)
+	}
+	var stdin bytes.Buffer
+	enc := json.NewEncoder(&stdin)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(in); err != nil {
+		return "", fmt.Errorf("failed to marshal the input: %w", err)
+	}
+	return run(ctx, module, cache, stdin.Bytes(), c)
+}
+
+// run runs the module as a WASI command in a sandbox and returns its stdout.
+// The module is compiled into cache unless it is nil, in which case its
+// compilation is released with the runtime.
+func run(ctx context.Context, module []byte, cache wazero.CompilationCache, stdin []byte, c config) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout.Duration)
+	defer cancel()
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(c.MemoryLimitMB * (1 << 20) / wasmPageSize)).
+		WithCloseOnContextDone(true)
+	if cache != nil {
+		runtimeConfig = runtimeConfig.WithCompilationCache(cache)
+	}
+	runtime := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	defer runtime.Close(context.Background())
+	// Only WASI is provided. It does not support networking and no directories
+	// are mounted, so the module can only read stdin and write stdout and stderr.
+	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
+
+	compiled, err := runtime.CompileModule(ctx, module)
+	if err != nil {
+		return "", fmt.Errorf("failed to compile the module: %w", err)
+	}
+	stdout := &limitedBuffer{limit: c.MaxOutputSize}
+	stderr := &limitedBuffer{limit: maxStderrSize}
+	_, err = runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStdin(bytes.NewReader(stdin)).
+		WithStdout(stdout).
+		WithStderr(stderr))
+	var exitErr *sys.ExitError
+	switch {
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == sys.ExitCodeDeadlineExceeded:
+		return "", fmt.Errorf("the module did not finish within %s", c.Timeout.Duration)
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 0:
+	case err != nil:
+		return "", fmt.Errorf("the module failed: %w%s", err, stderr.suffix())
+	}
+	if stdout.truncated {
+		return "", fmt.Errorf("the module wrote more than %d bytes", c.MaxOutputSize)
+	}
+	return stdout.String(), nil
+}
+
+// limitedBuffer keeps at most limit bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); len(p) > remaining {
+		b.truncated = true
+		b.Buffer.Write(p[:remaining])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// suffix formats the buffer to be appended to an error.
+func (b *limitedBuffer) suffix() string {
+	if b.Len() == 0 {
+		return ""
+	}
+	return ": " + b.String()
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+// The test modules are assembled from the .wat files in testdata.
+
+func TestRender(t *testing.T) {
+	echo, err := os.ReadFile("testdata/echo.wasm")
+	if err != nil {
+		t.Fatalf("failed to read the test module: %v", err)
+	}
+	testCases := []struct {
+		name          string
+		config        string
+		artifacts     []api.Artifact
+		expected      string
+		expectedError string
+	}{
+		{
+			name:   "module from the Deck filesystem",
+			config: `{"module": "testdata/echo.wasm"}`,
+			artifacts: []api.Artifact{
+				&fake.Artifact{Path: "artifacts/results.txt", Content: []byte("<b>passed</b>")},
+			},
+			expected: `{"artifacts":[{"path":"artifacts/results.txt","content":"<b>passed</b>"}]}
+`,
+		},
+		{
+			name:   "module from the artifacts is not passed to itself",
+			config: `{"module_artifact": "artifacts/lens.wasm"}`,
+			artifacts: []api.Artifact{
+				&fake.Artifact{Path: "artifacts/lens.wasm", Content: echo},
+				&fake.Artifact{Path: "artifacts/results.txt", Content: []byte("ok")},
+			},
+			expected: `{"artifacts":[{"path":"artifacts/results.txt","content":"ok"}]}
+`,
+		},
+		{
+			name:          "missing module artifact",
+			config:        `{"module_artifact": "artifacts/lens.wasm"}`,
+			expectedError: "the module artifact artifacts/lens.wasm was not found",
+		},
+		{
+			name:          "no module",
+			config:        `{}`,
+			expectedError: "exactly one of module and module_artifact must be set",
+		},
+		{
+			name:          "module that does not terminate",
+			config:        `{"module": "testdata/loop.wasm", "timeout": "100ms"}`,
+			expectedError: "the module did not finish within 100ms",
+		},
+		{
+			name:          "module exceeding the memory limit",
+			config:        `{"module": "testdata/grow.wasm", "memory_limit_mb": 64}`,
+			expectedError: "the module failed",
+		},
+		{
+			name:     "module within the memory limit",
+			config:   `{"module": "testdata/grow.wasm", "memory_limit_mb": 256}`,
+			expected: "",
+		},
+		{
+			name:   "output exceeding the limit",
+			config: `{"module": "testdata/echo.wasm", "max_output_size": 10}`,
+			artifacts: []api.Artifact{
+				&fake.Artifact{Path: "artifacts/results.txt", Content: []byte("ok")},
+			},
+			expectedError: "the module wrote more than 10 bytes",
+		},
+		{
+			name:          "invalid module",
+			config:        `{"module": "testdata/echo.wat"}`,
+			expectedError: "failed to compile the module",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := render(context.Background(), tc.artifacts, json.RawMessage(tc.config))
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected output %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBody(t *testing.T) {
+	ctx := context.Background()
+	artifacts := []api.Artifact{&fake.Artifact{Path: "artifacts/results.txt", Content: []byte(`"><script>alert(1)</script>`)}}
+	body, err := Lens{}.Body(ctx, artifacts, ".", "", json.RawMessage(`{"module": "testdata/echo.wasm"}`), prowconfig.Spyglass{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(body, `sandbox=""`) {
+		t.Errorf("expected the output to be rendered in a sandbox, got %s", body)
+	}
+	if strings.Contains(body, "<script>") {
+		t.Errorf("expected the output to be escaped, got %s", body)
+	}
+
+	body, err = Lens{}.Body(ctx, artifacts, ".", "", json.RawMessage(`{}`), prowconfig.Spyglass{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(body, "exactly one of module and module_artifact must be set") {
+		t.Errorf("expected the error to be rendered, got %s", body)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	start := time.Now()
+	body, err = Lens{}.Body(cancelled, nil, ".", "", json.RawMessage(`{"module": "testdata/loop.wasm", "timeout": "1m"}`), prowconfig.Spyglass{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the module to stop with the request, ran for %s", elapsed)
+	}
+	if !strings.Contains(body, "the module failed") {
+		t.Errorf("expected the error to be rendered, got %s", body)
+	}
+}
@@ -189,6 +189,12 @@ func (a *PodLogArtifact) Size() (int64, error) {
 
 }
 
+// Live returns true, as the pod keeps logging until the job finishes. Once it
+// does, the log is uploaded as an artifact and no longer read from the pod.
+func (a *PodLogArtifact) Live() bool {
+	return true
+}
+
 func (a *PodLogArtifact) Metadata() (map[string]string, error) {
 	return nil, nil
 }
@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"html/template"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ArtifactKind describes how the raw artifact viewer presents an artifact.
+type ArtifactKind string
+
+const (
+	// ArtifactKindText is plain text, rendered inline.
+	ArtifactKindText ArtifactKind = "text"
+	// ArtifactKindJSON is JSON, pretty-printed and highlighted inline.
+	ArtifactKindJSON ArtifactKind = "json"
+	// ArtifactKindYAML is YAML, highlighted inline.
+	ArtifactKindYAML ArtifactKind = "yaml"
+	// ArtifactKindBinary is anything else, which is only ever downloaded.
+	ArtifactKindBinary ArtifactKind = "binary"
+)
+
+// SniffLen is the number of leading bytes of an artifact DetectContentType
+// looks at.
+const SniffLen = 512
+
+// textExtensions are extensions of artifacts commonly uploaded by jobs that
+// are plain text but that neither mime nor http.DetectContentType recognize.
+var textExtensions = map[string]bool{
+	".log":  true,
+	".txt":  true,
+	".out":  true,
+	".err":  true,
+	".diff": true,
+	".go":   true,
+	".sh":   true,
+	".md":   true,
+	".csv":  true,
+	".tf":   true,
+	".toml": true,
+	".ini":  true,
+}
+
+// DetectContentType returns the content type of an artifact and how the raw
+// artifact viewer should present it. It looks at the artifact name and the
+// first SniffLen bytes of its content. The content type reported by the
+// storage provider is ignored since it is frequently missing or generic.
+//
+// HTML, SVG and other active content are never reported as renderable: Deck
+// serves the viewer from its own origin, so those are always downloaded.
+func DetectContentType(name string, head []byte) (string, ArtifactKind) {
+	if len(head) > SniffLen {
+		head = head[:SniffLen]
+	}
+	ext := strings.ToLower(path.Ext(strings.TrimSuffix(name, ".gz")))
+	switch ext {
+	case ".json":
+		if looksLikeText(head) {
+			return "application/json", ArtifactKindJSON
+		}
+	case ".yaml", ".yml":
+		if looksLikeText(head) {
+			return "application/yaml", ArtifactKindYAML
+		}
+	}
+
+	sniffed := http.DetectContentType(head)
+	mediaType, _, _ := mime.ParseMediaType(sniffed)
+	if mediaType != "text/plain" {
+		if mediaType == "application/octet-stream" {
+			if byExt := mime.TypeByExtension(ext); byExt != "" {
+				return byExt, ArtifactKindBinary
+			}
+		}
+		return sniffed, ArtifactKindBinary
+	}
+	if ext == "" || textExtensions[ext] || strings.HasPrefix(mime.TypeByExtension(ext), "text/plain") {
+		return "text/plain; charset=utf-8", ArtifactKindText
+	}
+	// Files like foo.xml or foo.html sniff as text but must not be rendered
+	// inline, report their own type so they are downloaded as such.
+	if byExt := mime.TypeByExtension(ext); byExt != "" {
+		return byExt, ArtifactKindBinary
+	}
+	return "text/plain; charset=utf-8", ArtifactKindText
+}
+
+// looksLikeText returns whether head is valid UTF-8 without control
+// characters other than whitespace. The last rune may be truncated.
+func looksLikeText(head []byte) bool {
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		if r == utf8.RuneError && size <= 1 {
+			return len(head) < utf8.UTFMax && !utf8.FullRune(head)
+		}
+		if r < 0x20 && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+		head = head[size:]
+	}
+	return true
+}
+
+// PrettyPrint returns content reformatted for display. JSON is indented, all
+// other kinds and content that fails to parse are returned unchanged.
+func PrettyPrint(kind ArtifactKind, content []byte) []byte {
+	if kind != ArtifactKindJSON {
+		return content
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, content, "", "  "); err != nil {
+		return content
+	}
+	return buf.Bytes()
+}
+
+var (
+	jsonTokenRe = regexp.MustCompile(`"(?:[^"\\]|\\.)*"(\s*:)?|-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?|\btrue\b|\bfalse\b|\bnull\b`)
+	yamlLineRe  = regexp.MustCompile(`^(\s*(?:- )?)([^\s#:][^#:]*?)(:)(\s.*|$)`)
+)
+
+// Highlight returns content as HTML with syntax highlighting applied. The
+// returned HTML only contains escaped content wrapped in <span> elements
+// with a class of hl-key, hl-string, hl-number, hl-literal or hl-comment.
+func Highlight(kind ArtifactKind, content []byte) template.HTML {
+	switch kind {
+	case ArtifactKindJSON:
+		return highlightJSON(string(content))
+	case ArtifactKindYAML:
+		return highlightYAML(string(content))
+	default:
+		return template.HTML(html.EscapeString(string(content)))
+	}
+}
+
+func span(class, text string) string {
+	return `<span class="` + class + `">` + html.EscapeString(text) + `</span>`
+}
+
+func highlightJSON(content string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range jsonTokenRe.FindAllStringSubmatchIndex(content, -1) {
+		b.WriteString(html.EscapeString(content[last:m[0]]))
+		token := content[m[0]:m[1]]
+		switch {
+		case m[2] >= 0:
+			b.WriteString(span("hl-key", content[m[0]:m[2]]))
+			b.WriteString(html.EscapeString(content[m[2]:m[1]]))
+		case token[0] == '"':
+			b.WriteString(span("hl-string", token))
+		case token == "true" || token == "false" || token == "null":
+			b.WriteString(span("hl-literal", token))
+		default:
+			b.WriteString(span("hl-number", token))
+		}
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(content[last:]))
+	return template.HTML(b.String())
+}
+
+func highlightYAML(content string) template.HTML {
+	var b strings.Builder
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			b.WriteString(span("hl-comment", line))
+		case trimmed == "---" || trimmed == "...":
+			b.WriteString(span("hl-literal", line))
+		default:
+			m := yamlLineRe.FindStringSubmatch(line)
+			if m == nil {
+				b.WriteString(html.EscapeString(line))
+				continue
+			}
+			b.WriteString(html.EscapeString(m[1]))
+			b.WriteString(span("hl-key", m[2]))
+			b.WriteString(html.EscapeString(m[3]))
+			if value := strings.TrimSpace(m[4]); value != "" && !strings.HasPrefix(value, "#") {
+				b.WriteString(html.EscapeString(m[4][:strings.Index(m[4], value)]))
+				b.WriteString(span("hl-string", value))
+			} else {
+				b.WriteString(html.EscapeString(m[4]))
+			}
+		}
+	}
+	return template.HTML(b.String())
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestDetectContentType(t *testing.T) {
+	testCases := []struct {
+		name                string
+		artifact            string
+		head                string
+		expectedContentType string
+		expectedKind        ArtifactKind
+	}{
+		{
+			name:                "build log",
+			artifact:            "build-log.txt",
+			head:                "I0101 00:00:00.000000 starting",
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedKind:        ArtifactKindText,
+		},
+		{
+			name:                "text without an extension",
+			artifact:            "latest-build",
+			head:                "1234",
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedKind:        ArtifactKindText,
+		},
+		{
+			name:                "json",
+			artifact:            "finished.json",
+			head:                `{"passed":true}`,
+			expectedContentType: "application/json",
+			expectedKind:        ArtifactKindJSON,
+		},
+		{
+			name:                "yaml",
+			artifact:            "prowjob.yml",
+			head:                "kind: ProwJob\n",
+			expectedContentType: "application/yaml",
+			expectedKind:        ArtifactKindYAML,
+		},
+		{
+			name:                "json extension with binary content",
+			artifact:            "finished.json",
+			head:                "\x00\x01\x02\x03",
+			expectedContentType: "application/json",
+			expectedKind:        ArtifactKindBinary,
+		},
+		{
+			name:                "html is not renderable",
+			artifact:            "report.html",
+			head:                "<html><body>hi</body></html>",
+			expectedContentType: "text/html; charset=utf-8",
+			expectedKind:        ArtifactKindBinary,
+		},
+		{
+			name:                "svg that sniffs as text is not renderable",
+			artifact:            "graph.svg",
+			head:                "hello <svg></svg>",
+			expectedContentType: "image/svg+xml",
+			expectedKind:        ArtifactKindBinary,
+		},
+		{
+			name:                "gzip archive",
+			artifact:            "artifacts.tar.gz",
+			head:                "\x1f\x8b\x08\x00\x00\x00\x00\x00",
+			expectedContentType: "application/x-gzip",
+			expectedKind:        ArtifactKindBinary,
+		},
+		{
+			name:                "truncated multi-byte rune in json",
+			artifact:            "events.json",
+			head:                "[\"\xe2\x9c",
+			expectedContentType: "application/json",
+			expectedKind:        ArtifactKindJSON,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType, kind := DetectContentType(tc.artifact, []byte(tc.head))
+			if contentType != tc.expectedContentType {
+				t.Errorf("expected content type %q, got %q", tc.expectedContentType, contentType)
+			}
+			if kind != tc.expectedKind {
+				t.Errorf("expected kind %q, got %q", tc.expectedKind, kind)
+			}
+		})
+	}
+}
+
+func TestPrettyPrint(t *testing.T) {
+	testCases := []struct {
+		name     string
+		kind     ArtifactKind
+		content  string
+		expected string
+	}{
+		{
+			name:     "json is indented",
+			kind:     ArtifactKindJSON,
+			content:  `{"a":[1,2]}`,
+			expected: "{\n  \"a\": [\n    1,\n    2\n  ]\n}",
+		},
+		{
+			name:     "invalid json is unchanged",
+			kind:     ArtifactKindJSON,
+			content:  `{"a":`,
+			expected: `{"a":`,
+		},
+		{
+			name:     "text is unchanged",
+			kind:     ArtifactKindText,
+			content:  `{"a":1}`,
+			expected: `{"a":1}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := string(PrettyPrint(tc.kind, []byte(tc.content))); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	testCases := []struct {
+		name     string
+		kind     ArtifactKind
+		content  string
+		expected template.HTML
+	}{
+		{
+			name:     "text is escaped",
+			kind:     ArtifactKindText,
+			content:  `<script>alert("hi")</script>`,
+			expected: `&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;`,
+		},
+		{
+			name:     "json",
+			kind:     ArtifactKindJSON,
+			content:  `{"a": "<b>", "c": -1.5e3, "d": null}`,
+			expected: `{<span class="hl-key">&#34;a&#34;</span>: <span class="hl-string">&#34;&lt;b&gt;&#34;</span>, <span class="hl-key">&#34;c&#34;</span>: <span class="hl-number">-1.5e3</span>, <span class="hl-key">&#34;d&#34;</span>: <span class="hl-literal">null</span>}`,
+		},
+		{
+			name:     "json string with escaped quote",
+			kind:     ArtifactKindJSON,
+			content:  `["a\"b"]`,
+			expected: `[<span class="hl-string">&#34;a\&#34;b&#34;</span>]`,
+		},
+		{
+			name:    "yaml",
+			kind:    ArtifactKindYAML,
+			content: "---\n# comment\nmetadata:\n  name: <job>\n  - url: http://example.com",
+			expected: `<span class="hl-literal">---</span>` + "\n" +
+				`<span class="hl-comment"># comment</span>` + "\n" +
+				`<span class="hl-key">metadata</span>:` + "\n" +
+				`  <span class="hl-key">name</span>: <span class="hl-string">&lt;job&gt;</span>` + "\n" +
+				`  - <span class="hl-key">url</span>: <span class="hl-string">http://example.com</span>`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Highlight(tc.kind, []byte(tc.content)); actual != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, actual)
+			}
+		})
+	}
+}
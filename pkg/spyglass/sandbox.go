@@ -0,0 +1,237 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"bytes"
+	"mime"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// SandboxPolicy is the Content-Security-Policy Deck serves sandboxed
+// artifacts with. The sandbox directive gives documents an opaque origin even
+// when they are opened outside of a lens, so their scripts cannot act as
+// Deck. Subresources may only be loaded from Deck itself, which the rewriter
+// points at the sandboxed artifacts of the same bucket, and nothing may be
+// fetched or submitted.
+const SandboxPolicy = "sandbox allow-scripts allow-popups; " +
+	"default-src 'none'; " +
+	"script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; " +
+	"font-src 'self' data:; " +
+	"media-src 'self'; " +
+	"frame-src 'self'; " +
+	"connect-src 'none'; " +
+	"form-action 'none'; " +
+	"base-uri 'none'; " +
+	"frame-ancestors 'self'"
+
+// SandboxHeightMessage is the type of the message sandboxed HTML documents
+// post to the frame embedding them whenever their height changes.
+const SandboxHeightMessage = "spyglass-sandbox-height"
+
+// blockedURL replaces URLs that would leave the sandboxed bucket.
+const blockedURL = "about:invalid"
+
+// heightNotifier is appended to sandboxed HTML documents.
+const heightNotifier = `<script>
+(function () {
+  if (window.parent === window) {
+    return;
+  }
+  var notify = function () {
+    window.parent.postMessage({type: "` + SandboxHeightMessage + `", height: document.documentElement.offsetHeight}, "*");
+  };
+  window.addEventListener("load", notify);
+  window.addEventListener("resize", notify);
+  new MutationObserver(notify).observe(document, {attributes: true, childList: true, characterData: true, subtree: true});
+})();
+</script>
+`
+
+// urlAttributes are the attributes of HTML and SVG elements holding a single
+// URL.
+var urlAttributes = map[string]bool{
+	"src":        true,
+	"href":       true,
+	"poster":     true,
+	"data":       true,
+	"action":     true,
+	"formaction": true,
+	"background": true,
+	"xlink:href": true,
+}
+
+var (
+	cssURLRe    = regexp.MustCompile(`(?i)(url\(\s*['"]?)([^'")]*)(['"]?\s*\))`)
+	cssImportRe = regexp.MustCompile(`(?i)(@import\s+['"])([^'"]*)(['"])`)
+)
+
+// SandboxContentType returns the content type a sandboxed artifact is served
+// with. Unlike DetectContentType it trusts the extension first: browsers
+// refuse stylesheets and scripts served as text/plain with nosniff.
+func SandboxContentType(name string, head []byte) string {
+	ext := strings.ToLower(path.Ext(strings.TrimSuffix(name, ".gz")))
+	if byExt := mime.TypeByExtension(ext); byExt != "" {
+		return byExt
+	}
+	contentType, _ := DetectContentType(name, head)
+	return contentType
+}
+
+// IsHTMLContentType returns whether contentType is an HTML document.
+func IsHTMLContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// sandboxRewriter resolves the URLs of a sandboxed document.
+type sandboxRewriter struct {
+	base *url.URL
+	root *url.URL
+}
+
+// RewriteSandboxedHTML rewrites the relative URLs of the HTML document served
+// at docPath to absolute paths under rootPath, which is where Deck serves the
+// sandboxed artifacts of the document's bucket. Root-relative URLs resolve
+// against rootPath and URLs escaping it are blocked. URLs with a scheme or a
+// host are kept, the sandbox policy decides whether they load. <base>
+// elements are dropped and a script reporting the document height to the
+// embedding frame is appended.
+func RewriteSandboxedHTML(content []byte, docPath, rootPath string) []byte {
+	r := sandboxRewriter{
+		base: &url.URL{Path: docPath},
+		root: &url.URL{Path: strings.TrimSuffix(rootPath, "/") + "/"},
+	}
+	var out bytes.Buffer
+	z := html.NewTokenizer(bytes.NewReader(content))
+	inStyle := false
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		// Reading the tag lowercases the raw token in place.
+		raw := append([]byte(nil), z.Raw()...)
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			switch token.DataAtom {
+			case atom.Base:
+				continue
+			case atom.Style:
+				inStyle = tt == html.StartTagToken
+			}
+			if r.rewriteAttributes(token.Attr) {
+				out.WriteString(token.String())
+				continue
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); atom.Lookup(name) == atom.Style {
+				inStyle = false
+			}
+		case html.TextToken:
+			if inStyle {
+				out.WriteString(r.rewriteCSS(string(raw)))
+				continue
+			}
+		}
+		out.Write(raw)
+	}
+	out.WriteString(heightNotifier)
+	return out.Bytes()
+}
+
+// rewriteAttributes rewrites the URLs in attrs in place and returns whether
+// any changed.
+func (r sandboxRewriter) rewriteAttributes(attrs []html.Attribute) bool {
+	changed := false
+	for i, attr := range attrs {
+		value := attr.Val
+		switch {
+		case urlAttributes[attr.Key]:
+			value = r.rewriteURL(attr.Val)
+		case attr.Key == "srcset":
+			value = r.rewriteSrcset(attr.Val)
+		case attr.Key == "style":
+			value = r.rewriteCSS(attr.Val)
+		}
+		if value != attr.Val {
+			attrs[i].Val = value
+			changed = true
+		}
+	}
+	return changed
+}
+
+// rewriteURL resolves a relative reference against the document.
+func (r sandboxRewriter) rewriteURL(ref string) string {
+	trimmed := strings.TrimSpace(ref)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return ref
+	}
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return blockedURL
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return ref
+	}
+	var resolved *url.URL
+	if strings.HasPrefix(u.Path, "/") {
+		resolved = r.root.ResolveReference(&url.URL{Path: strings.TrimLeft(u.Path, "/"), RawQuery: u.RawQuery, Fragment: u.Fragment})
+	} else {
+		resolved = r.base.ResolveReference(u)
+	}
+	if !strings.HasPrefix(resolved.Path, r.root.Path) {
+		return blockedURL
+	}
+	return resolved.String()
+}
+
+// rewriteSrcset rewrites the URLs of the image candidates of a srcset.
+func (r sandboxRewriter) rewriteSrcset(srcset string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		if rewritten := r.rewriteURL(fields[0]); rewritten != fields[0] {
+			candidates[i] = strings.Replace(candidate, fields[0], rewritten, 1)
+		}
+	}
+	return strings.Join(candidates, ",")
+}
+
+// rewriteCSS rewrites the url() and @import references of a stylesheet.
+func (r sandboxRewriter) rewriteCSS(css string) string {
+	for _, re := range []*regexp.Regexp{cssURLRe, cssImportRe} {
+		css = re.ReplaceAllStringFunc(css, func(match string) string {
+			parts := re.FindStringSubmatch(match)
+			return parts[1] + r.rewriteURL(parts[2]) + parts[3]
+		})
+	}
+	return css
+}
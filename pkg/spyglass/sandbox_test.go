@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSandboxContentType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		artifact string
+		head     string
+		expected string
+	}{
+		{
+			name:     "stylesheets are served by extension",
+			artifact: "report/style.css",
+			head:     "body { color: red; }",
+			expected: "text/css; charset=utf-8",
+		},
+		{
+			name:     "scripts are served by extension",
+			artifact: "report/app.js",
+			head:     "console.log(1);",
+			expected: "text/javascript; charset=utf-8",
+		},
+		{
+			name:     "html",
+			artifact: "report/index.html",
+			head:     "<!DOCTYPE html><html></html>",
+			expected: "text/html; charset=utf-8",
+		},
+		{
+			name:     "unknown extensions are sniffed",
+			artifact: "report/logo",
+			head:     "\x89PNG\r\n\x1a\n",
+			expected: "image/png",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := SandboxContentType(tc.artifact, []byte(tc.head)); actual != tc.expected {
+				t.Errorf("expected content type %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRewriteSandboxedHTML(t *testing.T) {
+	const (
+		docPath  = "/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/index.html"
+		rootPath = "/spyglass/sandbox/gs/bucket/"
+	)
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "relative urls resolve against the document",
+			content:  `<link rel="stylesheet" href="style.css"><img src="../img/a.png?x=1#top">`,
+			expected: `<link rel="stylesheet" href="/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/style.css"><img src="/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/img/a.png?x=1#top">`,
+		},
+		{
+			name:     "root-relative urls resolve against the bucket",
+			content:  `<script src="/static/app.js"></script>`,
+			expected: `<script src="/spyglass/sandbox/gs/bucket/static/app.js"></script>`,
+		},
+		{
+			name:     "urls escaping the bucket are blocked",
+			content:  `<img src="../../../../../../../../other/a.png">`,
+			expected: `<img src="about:invalid">`,
+		},
+		{
+			name:     "absolute urls and fragments are kept",
+			content:  `<a href="https://example.com/x">x</a><a href="#failures">f</a><img src="data:image/png;base64,AAAA">`,
+			expected: `<a href="https://example.com/x">x</a><a href="#failures">f</a><img src="data:image/png;base64,AAAA">`,
+		},
+		{
+			name:     "srcset candidates are rewritten",
+			content:  `<img srcset="a.png 1x, b.png 2x">`,
+			expected: `<img srcset="/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/a.png 1x, /spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/b.png 2x">`,
+		},
+		{
+			name:     "stylesheets and style attributes are rewritten",
+			content:  `<style>@import "theme.css"; body { background: url('bg.png'); }</style><div style="background: url(/bg.png)"></div>`,
+			expected: `<style>@import "/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/theme.css"; body { background: url('/spyglass/sandbox/gs/bucket/logs/job/1/artifacts/report/bg.png'); }</style><div style="background: url(/spyglass/sandbox/gs/bucket/bg.png)"></div>`,
+		},
+		{
+			name:     "scripts are kept as is",
+			content:  `<script>if (a < b && c) { document.write("<img src='x.png'>"); }</script>`,
+			expected: `<script>if (a < b && c) { document.write("<img src='x.png'>"); }</script>`,
+		},
+		{
+			name:     "base elements are dropped",
+			content:  `<head><base href="https://evil.example.com/"></head>`,
+			expected: `<head></head>`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := string(RewriteSandboxedHTML([]byte(tc.content), docPath, rootPath))
+			if !strings.HasSuffix(actual, heightNotifier) {
+				t.Errorf("expected the height notifier to be appended, got:\n%s", actual)
+			}
+			if actual = strings.TrimSuffix(actual, heightNotifier); actual != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+)
+
+const (
+	// MaxSearchQueryLength is the length of the longest search query.
+	MaxSearchQueryLength = 200
+	// maxSearchMatches is the number of matches a search returns.
+	maxSearchMatches = 500
+	// maxSearchLineLength is the length the lines of matches are cut to.
+	maxSearchLineLength = 300
+	// binarySniffLength is the length of the start of an artifact that is
+	// checked for NUL bytes to tell binary artifacts apart.
+	binarySniffLength = 8000
+)
+
+// SearchMatch is a line of an artifact that contains the searched text.
+type SearchMatch struct {
+	Artifact string `json:"artifact"`
+	// Offset is the byte offset of the match in the artifact.
+	Offset int64 `json:"offset"`
+	// Length is the length of the match in bytes.
+	Length int `json:"length"`
+	// Line is the number of the line of the match, starting at 1.
+	Line int `json:"line"`
+	// Text is the line of the match, cut around the match if it is long.
+	Text string `json:"text"`
+}
+
+// SearchResult are the matches of a search across the artifacts of a run.
+type SearchResult struct {
+	Matches []SearchMatch `json:"matches"`
+	// Links are the links to the raw artifacts with matches.
+	Links map[string]string `json:"links,omitempty"`
+	// Searched is the number of artifacts searched.
+	Searched int `json:"searched"`
+	// Skipped are the artifacts that were not searched, because they are
+	// binary, could not be read or exceeded the size limit.
+	Skipped []string `json:"skipped,omitempty"`
+	// Truncated is true if there were more matches than returned.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// SearchArtifacts searches the artifacts of the run specified by src for
+// query, ignoring case. At most Deck.Spyglass.SizeLimit bytes are read in
+// total, build logs first.
+func (s *Spyglass) SearchArtifacts(ctx context.Context, src, query string) (*SearchResult, error) {
+	if query == "" {
+		return nil, errors.New("the query must not be empty")
+	}
+	if len(query) > MaxSearchQueryLength {
+		return nil, fmt.Errorf("the query must not be longer than %d bytes", MaxSearchQueryLength)
+	}
+	names, err := s.ListArtifacts(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("error listing artifacts: %w", err)
+	}
+	// Build logs are what users mostly search, so they come first in case
+	// the size limit is reached.
+	sort.SliceStable(names, func(i, j int) bool {
+		return strings.HasSuffix(names[i], singleLogName) && !strings.HasSuffix(names[j], singleLogName)
+	})
+	sizeLimit := s.config().Deck.Spyglass.SizeLimit
+	artifacts, err := s.FetchArtifacts(ctx, src, "", sizeLimit, names)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching artifacts: %w", err)
+	}
+	link := func(a api.Artifact) string {
+		return lenses.RawArtifactLink(a, s.config().Deck.Spyglass)
+	}
+	return searchArtifacts(artifacts, query, sizeLimit, link), nil
+}
+
+// searchArtifacts searches artifacts for query, reading at most budget bytes
+// in total.
+func searchArtifacts(artifacts []api.Artifact, query string, budget int64, link func(api.Artifact) string) *SearchResult {
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+	result := &SearchResult{Matches: []SearchMatch{}, Links: map[string]string{}}
+	for _, artifact := range artifacts {
+		if budget <= 0 {
+			result.Skipped = append(result.Skipped, artifact.JobPath())
+			continue
+		}
+		content, err := readForSearch(artifact, budget)
+		if err != nil {
+			logrus.WithError(err).WithField("artifact", artifact.JobPath()).Debug("Failed to read artifact to search.")
+			result.Skipped = append(result.Skipped, artifact.JobPath())
+			continue
+		}
+		budget -= int64(len(content))
+		if bytes.IndexByte(content[:min(len(content), binarySniffLength)], 0) >= 0 {
+			result.Skipped = append(result.Skipped, artifact.JobPath())
+			continue
+		}
+		result.Searched++
+		matches, more := searchContent(artifact.JobPath(), content, re, maxSearchMatches-len(result.Matches))
+		if len(matches) > 0 {
+			result.Matches = append(result.Matches, matches...)
+			result.Links[artifact.JobPath()] = link(artifact)
+		}
+		if more {
+			result.Truncated = true
+			break
+		}
+	}
+	return result
+}
+
+// readForSearch reads an artifact, or its first budget bytes if it is larger.
+func readForSearch(artifact api.Artifact, budget int64) ([]byte, error) {
+	size, err := artifact.Size()
+	if err != nil {
+		return nil, err
+	}
+	if size <= budget {
+		return artifact.ReadAll()
+	}
+	content, err := artifact.ReadAtMost(budget)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if int64(len(content)) > budget {
+		content = content[:budget]
+	}
+	return content, nil
+}
+
+// searchContent returns the first maxMatches lines of content that match re,
+// and whether there are more.
+func searchContent(name string, content []byte, re *regexp.Regexp, maxMatches int) ([]SearchMatch, bool) {
+	var matches []SearchMatch
+	line := 1
+	// lineStart is the offset of the start of the line of pos.
+	pos, lineStart := 0, 0
+	for pos < len(content) {
+		loc := re.FindIndex(content[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		if len(matches) == maxMatches {
+			return matches, true
+		}
+		line += bytes.Count(content[lineStart:start], []byte{'\n'})
+		if i := bytes.LastIndexByte(content[:start], '\n'); i >= 0 {
+			lineStart = i + 1
+		} else {
+			lineStart = 0
+		}
+		lineEnd := len(content)
+		if i := bytes.IndexByte(content[start:], '\n'); i >= 0 {
+			lineEnd = start + i
+		}
+		matches = append(matches, SearchMatch{
+			Artifact: name,
+			Offset:   int64(start),
+			Length:   end - start,
+			Line:     line,
+			Text:     lineAround(content[lineStart:lineEnd], start-lineStart),
+		})
+		// Lines are only returned once, however many matches they have.
+		pos = lineEnd + 1
+		line++
+		lineStart = pos
+	}
+	return matches, false
+}
+
+// lineAround returns a line cut to maxSearchLineLength around the match at
+// offset.
+func lineAround(line []byte, offset int) string {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) > maxSearchLineLength {
+		start := max(0, min(offset-maxSearchLineLength/4, len(line)-maxSearchLineLength))
+		line = line[start : start+maxSearchLineLength]
+	}
+	return strings.ToValidUTF8(string(line), "")
+}
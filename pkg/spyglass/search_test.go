@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/spyglass/api"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/fake"
+)
+
+func TestSearchArtifacts(t *testing.T) {
+	link := func(a api.Artifact) string { return "/raw/" + a.JobPath() }
+	testCases := []struct {
+		name      string
+		artifacts []api.Artifact
+		query     string
+		budget    int64
+		expected  *SearchResult
+	}{
+		{
+			name: "matches are found in all artifacts, ignoring case",
+			artifacts: []api.Artifact{
+				&fake.Artifact{Path: "build-log.txt", Content: []byte("starting\nFAIL: TestFoo\nok\nfail again\n")},
+				&fake.Artifact{Path: "artifacts/junit.xml", Content: []byte(`<testcase name="TestFoo"><failure>fail</failure></testcase>`)},
+			},
+			query:  "fail",
+			budget: 1000,
+			expected: &SearchResult{
+				Matches: []SearchMatch{
+					{Artifact: "build-log.txt", Offset: 9, Length: 4, Line: 2, Text: "FAIL: TestFoo"},
+					{Artifact: "build-log.txt", Offset: 26, Length: 4, Line: 4, Text: "fail again"},
+					{Artifact: "artifacts/junit.xml", Offset: 26, Length: 4, Line: 1, Text: `<testcase name="TestFoo"><failure>fail</failure></testcase>`},
+				},
+				Links: map[string]string{
+					"build-log.txt":       "/raw/build-log.txt",
+					"artifacts/junit.xml": "/raw/artifacts/junit.xml",
+				},
+				Searched: 2,
+			},
+		},
+		{
+			name: "lines with several matches are returned once",
+			artifacts: []api.Artifact{
+				&fake.Artifact{Path: "build-log.txt", Content: []byte("error error\r\nerror\n")},
+			},
+			query:  "error",
+			budget: 1000,
+			expected: &SearchResult{
+				Matches: []SearchMatch{
+					{Artifact: "build-log.txt", Offset: 0, Length: 5, Line: 1, Text: "error error"},
+					{Artifact: "build-log.txt", Offset: 13, Length: 5, Line: 2, Text: "error"},
+				},
+				Links:    map[string]string{"build-log.txt": "/raw/build-log.txt"},
+				Searched: 1,
+			},
+		},
+		{
+			name: "queries are literal",
+			artifacts: []api.Artifact{
+				&fake.Artifact{Path: "build-log.txt", Content: []byte("a.b\naxb\n")},
+			},
+			query:  "a.b",
+			budget: 1000,
+			expected: &SearchResult{
+				Matches:  []SearchMatch{{Artifact: "build-log.txt", Offset: 0, Length: 3, Line: 1, Text: "a.b"}},
+				Links:    map[string]string{"build-log.txt": "/raw/build-log.txt"},
+				Searched: 1,
+			},
+		},
+		{
+			name: "binary artifacts are skipped",
+			artifacts: []api.Artifact{
+				&fake.Artifact{Path: "artifacts/tool", Content: []byte("fail\x00\x01")},
+			},
+			query:  "fail",
+			budget: 1000,
+			expected: &SearchResult{
+				Matches: []SearchMatch{},
+				Links:   map[string]string{},
+				Skipped: []string{"artifacts/tool"},
+			},
+		},
+		{
+			name: "artifacts are only read up to the budget",
+			artifacts: []api.Artifact{
+				&fake.Artifact{Path: "build-log.txt", Content: []byte("fail\nfail\n")},
+				&fake.Artifact{Path: "artifacts/other.log", Content: []byte("fail\n")},
+			},
+			query:  "fail",
+			budget: 7,
+			expected: &SearchResult{
+				Matches: []SearchMatch{
+					{Artifact: "build-log.txt", Offset: 0, Length: 4, Line: 1, Text: "fail"},
+				},
+				Links:    map[string]string{"build-log.txt": "/raw/build-log.txt"},
+				Searched: 1,
+				Skipped:  []string{"artifacts/other.log"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := searchArtifacts(tc.artifacts, tc.query, tc.budget, link)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSearchArtifactsTruncated(t *testing.T) {
+	content := strings.Repeat("fail\n", maxSearchMatches+1)
+	artifacts := []api.Artifact{
+		&fake.Artifact{Path: "build-log.txt", Content: []byte(content)},
+		&fake.Artifact{Path: "artifacts/other.log", Content: []byte("fail\n")},
+	}
+	result := searchArtifacts(artifacts, "fail", int64(len(content)+10), func(api.Artifact) string { return "" })
+	if !result.Truncated {
+		t.Error("expected the result to be truncated")
+	}
+	if len(result.Matches) != maxSearchMatches {
+		t.Errorf("expected %d matches, got %d", maxSearchMatches, len(result.Matches))
+	}
+	if last := result.Matches[len(result.Matches)-1]; last.Line != maxSearchMatches {
+		t.Errorf("expected the last match on line %d, got %d", maxSearchMatches, last.Line)
+	}
+}
+
+func TestLineAround(t *testing.T) {
+	long := strings.Repeat("a", 1000) + "needle" + strings.Repeat("b", 1000)
+	actual := lineAround([]byte(long), 1000)
+	if len(actual) != maxSearchLineLength {
+		t.Fatalf("expected a line of %d bytes, got %d", maxSearchLineLength, len(actual))
+	}
+	if !strings.Contains(actual, "needle") {
+		t.Errorf("expected the line to contain the match, got %q", actual)
+	}
+	if actual := lineAround([]byte("short line\r"), 0); actual != "short line" {
+		t.Errorf("expected %q, got %q", "short line", actual)
+	}
+}
@@ -40,6 +40,7 @@ import (
 	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
 	"sigs.k8s.io/prow/pkg/spyglass/api"
 	"sigs.k8s.io/prow/pkg/spyglass/lenses"
+	"sigs.k8s.io/prow/pkg/spyglass/lenses/common"
 )
 
 // Key types specify the way Spyglass will fetch artifact handles
@@ -63,6 +64,9 @@ type Spyglass struct {
 
 	*StorageArtifactFetcher
 	*PodLogArtifactFetcher
+	// StorageArtifactFetchers fetches artifacts from the storage providers
+	// they were uploaded to.
+	StorageArtifactFetchers common.ArtifactFetchers
 }
 
 // LensRequest holds data sent by a view
@@ -70,6 +74,8 @@ type LensRequest struct {
 	Source    string   `json:"src"`
 	Index     int      `json:"index"`
 	Artifacts []string `json:"artifacts"`
+	// BaseSource is the source of the build to compare with, if any.
+	BaseSource string `json:"baseSrc,omitempty"`
 }
 
 // ExtraLink represents an extra link to be added to the Spyglass page.
@@ -81,11 +87,13 @@ type ExtraLink struct {
 
 // New constructs a Spyglass object from a JobAgent, a config.Agent, and a storage Client.
 func New(ctx context.Context, ja *jobs.JobAgent, cfg config.Getter, opener pkgio.Opener, useCookieAuth bool) *Spyglass {
+	storageArtifactFetcher := NewStorageArtifactFetcher(opener, cfg, useCookieAuth)
 	return &Spyglass{
-		JobAgent:               ja,
-		config:                 cfg,
-		PodLogArtifactFetcher:  NewPodLogArtifactFetcher(ja),
-		StorageArtifactFetcher: NewStorageArtifactFetcher(opener, cfg, useCookieAuth),
+		JobAgent:                ja,
+		config:                  cfg,
+		PodLogArtifactFetcher:   NewPodLogArtifactFetcher(ja),
+		StorageArtifactFetcher:  storageArtifactFetcher,
+		StorageArtifactFetchers: common.NewArtifactFetchers(storageArtifactFetcher),
 		testgrid: &TestGrid{
 			conf:   cfg,
 			opener: opener,
@@ -150,7 +158,7 @@ func (l lensConfigWrapper) Config() lenses.LensConfig {
 }
 
 func getLensConfig(lensFileConfig config.LensFileConfig) (LensConfig, error) {
-	lens, err := lenses.GetLens(lensFileConfig.Lens.Name)
+	lens, err := lenses.GetLensV2(lensFileConfig.Lens.Name)
 	if err != nil && err != lenses.ErrInvalidLensName {
 		return nil, err
 	}
@@ -405,36 +413,69 @@ func (sg *Spyglass) RunToPR(src string) (string, string, int, error) {
 	}
 }
 
-// ExtraLinks fetches started.json and extracts links from metadata.links.
+// ExtraLinks fetches started.json and finished.json and extracts links from
+// metadata.links. Links in finished.json, which include the links posted to
+// sidecar during the run, take precedence over links with the same name in
+// started.json.
 func (sg *Spyglass) ExtraLinks(ctx context.Context, src string) ([]ExtraLink, error) {
-	artifacts, err := sg.FetchArtifacts(ctx, src, "", 1000000, []string{prowapi.StartedStatusFile})
+	artifacts, err := sg.FetchArtifacts(ctx, src, "", 1000000, []string{prowapi.StartedStatusFile, prowapi.FinishedStatusFile})
 	// Failing to parse src, that's an error.
 	if err != nil {
 		return nil, err
 	}
 
-	// Failing to find started.json is okay, just return nothing quietly.
+	// Failing to find started.json and finished.json is okay, just return nothing quietly.
 	if len(artifacts) == 0 {
-		logrus.Debug("Failed to find started.json while looking for extra links.")
+		logrus.Debug("Failed to find started.json or finished.json while looking for extra links.")
 		return nil, nil
 	}
+	var extraLinks []ExtraLink
+	indices := map[string]int{}
+	for _, artifact := range artifacts {
+		links, err := metadataLinks(artifact)
+		if err != nil {
+			return nil, err
+		}
+		// Not having any links is fine.
+		if links == nil {
+			continue
+		}
+		if extraLinks == nil {
+			extraLinks = []ExtraLink{}
+		}
+		for _, link := range links {
+			if i, ok := indices[link.Name]; ok {
+				extraLinks[i] = link
+				continue
+			}
+			indices[link.Name] = len(extraLinks)
+			extraLinks = append(extraLinks, link)
+		}
+	}
+	return extraLinks, nil
+}
+
+// metadataLinks extracts the links from metadata.links of started.json or
+// finished.json. It returns nil if the artifact has no links.
+func metadataLinks(artifact api.Artifact) ([]ExtraLink, error) {
 	// Failing to read an artifact we already know to exist shouldn't happen, so that's an error.
-	content, err := artifacts[0].ReadAll()
+	content, err := artifact.ReadAll()
 	if err != nil {
 		// Swallow the error if this file is empty
-		if size, sizeErr := artifacts[0].Size(); sizeErr != nil && size == 0 {
-			logrus.Debug("Started.json is empty.")
+		if size, sizeErr := artifact.Size(); sizeErr != nil && size == 0 {
+			logrus.Debugf("%s is empty.", artifact.JobPath())
 			err = nil
 		}
 		return nil, err
 	}
-	// Being unable to parse a successfully fetched started.json correctly is also an error.
-	started := metadata.Started{}
-	if err := json.Unmarshal(content, &started); err != nil {
+	// Being unable to parse a successfully fetched file correctly is also an error.
+	var parsed struct {
+		Metadata metadata.Metadata `json:"metadata,omitempty"`
+	}
+	if err := json.Unmarshal(content, &parsed); err != nil {
 		return nil, err
 	}
-	// Not having any links is fine.
-	links, ok := started.Metadata.Meta("links")
+	links, ok := parsed.Metadata.Meta("links")
 	if !ok {
 		return nil, nil
 	}
@@ -443,7 +484,7 @@ func (sg *Spyglass) ExtraLinks(ctx context.Context, src string) ([]ExtraLink, er
 		m, ok := links.Meta(name)
 		if !ok {
 			// This should never happen, because Keys() should only return valid Metas.
-			logrus.Debugf("Got bad link key %q from %s, but that should be impossible.", name, artifacts[0].CanonicalLink())
+			logrus.Debugf("Got bad link key %q from %s, but that should be impossible.", name, artifact.CanonicalLink())
 			continue
 		}
 		s := m.Strings()
@@ -1541,6 +1541,7 @@ func TestExtraLinks(t *testing.T) {
 	testCases := []struct {
 		name      string
 		content   string
+		finished  string
 		links     []ExtraLink
 		expectErr bool
 	}{
@@ -1588,18 +1589,40 @@ func TestExtraLinks(t *testing.T) {
 			content: `{"metadata": {"links": {"A": {"url": "http://a", "description": "A!"}, "B": {"url": "http://b"}}}}`,
 			links:   []ExtraLink{{Name: "A", URL: "http://a", Description: "A!"}, {Name: "B", URL: "http://b"}},
 		},
+		{
+			name:     "returns links from finished.json without started.json",
+			finished: `{"metadata": {"links": {"Report": {"url": "http://report"}}}}`,
+			links:    []ExtraLink{{Name: "Report", URL: "http://report"}},
+		},
+		{
+			name:     "merges links from started.json and finished.json",
+			content:  `{"metadata": {"links": {"A": {"url": "http://a", "description": "A!"}, "B": {"url": "http://b"}}}}`,
+			finished: `{"metadata": {"links": {"B": {"url": "http://b2", "description": "B!"}, "C": {"url": "http://c"}}}}`,
+			links:    []ExtraLink{{Name: "A", URL: "http://a", Description: "A!"}, {Name: "B", URL: "http://b2", Description: "B!"}, {Name: "C", URL: "http://c"}},
+		},
+		{
+			name:      "errors given a malformed finished.json",
+			content:   `{"metadata": {"links": {"A": {"url": "http://a"}}}}`,
+			finished:  "this isn't json",
+			expectErr: true,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			var objects []fakestorage.Object
 			if tc.content != "" {
-				objects = []fakestorage.Object{
-					{
-						BucketName: "test-bucket",
-						Name:       "logs/some-job/42/started.json",
-						Content:    []byte(tc.content),
-					},
-				}
+				objects = append(objects, fakestorage.Object{
+					BucketName: "test-bucket",
+					Name:       "logs/some-job/42/started.json",
+					Content:    []byte(tc.content),
+				})
+			}
+			if tc.finished != "" {
+				objects = append(objects, fakestorage.Object{
+					BucketName: "test-bucket",
+					Name:       "logs/some-job/42/finished.json",
+					Content:    []byte(tc.finished),
+				})
 			}
 			gcsServer := fakestorage.NewServer(objects)
 			defer gcsServer.Stop()
@@ -129,6 +129,18 @@ func (a *StorageArtifact) CanonicalLink() string {
 	return a.link
 }
 
+// StoragePath returns the full storage path of the artifact, such as
+// gs://bucket/path/to/artifact, or an empty string if it is not known.
+func (a *StorageArtifact) StoragePath() string {
+	switch h := a.handle.(type) {
+	case *storageArtifactHandle:
+		return h.Name
+	case *cachingArtifactHandle:
+		return h.Name
+	}
+	return ""
+}
+
 // ReadAt reads len(p) bytes from a file in GCS at offset off
 func (a *StorageArtifact) ReadAt(p []byte, off int64) (n int, err error) {
 	if int64(len(p)) > a.sizeLimit {
@@ -289,6 +301,35 @@ func (a *StorageArtifact) ReadTail(n int64) ([]byte, error) {
 	return read, nil
 }
 
+// NewRangeReader returns a reader of length bytes from offset off of a file in
+// GCS, or of the rest of the file if length is negative. Unlike ReadAt, the
+// range is streamed and not limited by the size limit.
+func (a *StorageArtifact) NewRangeReader(off, length int64) (io.ReadCloser, error) {
+	gzipped, err := a.gzipped()
+	if err != nil {
+		return nil, fmt.Errorf("error checking artifact for gzip compression: %w", err)
+	}
+	if gzipped {
+		// Only the whole file can be read, decompressed by the storage provider.
+		if off != 0 || length >= 0 {
+			return nil, lenses.ErrGzipOffsetRead
+		}
+		reader, err := a.handle.NewReader(a.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting artifact reader: %w", err)
+		}
+		return reader, nil
+	}
+	if off < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+	reader, err := a.handle.NewRangeReader(a.ctx, off, length)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error getting artifact reader: %w", err)
+	}
+	return reader, nil
+}
+
 // gzipped returns whether the file is gzip-encoded in GCS
 func (a *StorageArtifact) gzipped() (bool, error) {
 	attrs, err := a.handle.Attrs(a.ctx)
@@ -44,6 +44,8 @@ type StorageArtifactFetcher struct {
 	opener        pkgio.Opener
 	cfg           config.Getter
 	useCookieAuth bool
+	cache         *artifactCache
+	manifests     *contentAddressedManifests
 }
 
 // storageJobSource is a location in GCS where Prow job-specific artifacts are stored. This implementation assumes
@@ -64,6 +66,8 @@ func NewStorageArtifactFetcher(opener pkgio.Opener, cfg config.Getter, useCookie
 		opener:        opener,
 		cfg:           cfg,
 		useCookieAuth: useCookieAuth,
+		cache:         newArtifactCache(cfg),
+		manifests:     newContentAddressedManifests(),
 	}
 }
 
@@ -167,6 +171,7 @@ func (af *StorageArtifactFetcher) artifacts(ctx context.Context, key string) ([]
 		artifacts = append(artifacts, strings.TrimPrefix(oAttrs.Name, prefix))
 		i = 0
 	}
+	artifacts = af.withContentAddressedArtifacts(ctx, src, artifacts)
 	logrus.WithField("duration", time.Since(listStart).String()).Infof("Listed %d artifacts.", len(artifacts))
 	return artifacts, nil
 }
@@ -212,12 +217,15 @@ func (af *StorageArtifactFetcher) Artifact(ctx context.Context, key string, arti
 
 	_, prefix := extractBucketPrefixPair(src.jobPath())
 	objName := path.Join(prefix, artifactName)
+	if contentPath, ok := af.contentAddressedObject(ctx, src, artifactName); ok {
+		objName = contentPath
+	}
 	obj := &storageArtifactHandle{Opener: af.opener, Name: fmt.Sprintf("%s%s/%s", src.linkPrefix, src.bucket, objName)}
 	signedURL, err := af.signURL(ctx, fmt.Sprintf("%s%s/%s", src.linkPrefix, src.bucket, objName))
 	if err != nil {
 		return nil, err
 	}
-	return NewStorageArtifact(context.Background(), obj, signedURL, artifactName, sizeLimit), nil
+	return NewStorageArtifact(ctx, af.cache.wrap(obj), signedURL, artifactName, sizeLimit), nil
 }
 
 func extractBucketPrefixPair(storagePath string) (string, string) {
@@ -365,6 +365,79 @@ func TestReadAt(t *testing.T) {
 
 }
 
+func TestNewRangeReader(t *testing.T) {
+	contents := []byte("Oh wow\nlogs\nthis is\ncrazy")
+	testCases := []struct {
+		name      string
+		offset    int64
+		length    int64
+		encoding  string
+		expected  []byte
+		expectErr bool
+	}{
+		{
+			name:     "range in the middle",
+			offset:   7,
+			length:   4,
+			expected: []byte("logs"),
+		},
+		{
+			name:     "rest of the artifact",
+			offset:   12,
+			length:   -1,
+			expected: []byte("this is\ncrazy"),
+		},
+		{
+			name:     "whole gzipped artifact",
+			length:   -1,
+			encoding: "gzip",
+			expected: contents,
+		},
+		{
+			name:      "range of a gzipped artifact",
+			offset:    7,
+			length:    4,
+			encoding:  "gzip",
+			expectErr: true,
+		},
+		{
+			name:      "negative offset",
+			offset:    -3,
+			length:    4,
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			artifact := NewStorageArtifact(context.Background(), &fakeArtifactHandle{
+				contents: contents,
+				oAttrs: pkgio.Attributes{
+					Size:            int64(len(contents)),
+					ContentEncoding: tc.encoding,
+				},
+			}, "", "build-log.txt", 1)
+			reader, err := artifact.NewRangeReader(tc.offset, tc.length)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer reader.Close()
+			actual, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("unexpected error reading: %v", err)
+			}
+			if !bytes.Equal(actual, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
 // Tests reading all data from files in GCS
 func TestReadAll(t *testing.T) {
 	testCases := []struct {
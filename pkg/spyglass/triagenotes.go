@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	pkgio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+)
+
+// TriageNotesName is the name of the file next to the artifacts of a run that
+// holds the triage notes users attached to the run.
+const TriageNotesName = "triage-notes.json"
+
+// TriageNotes are the notes users attached to a run, as stored in
+// TriageNotesName.
+type TriageNotes struct {
+	Notes []TriageNote `json:"notes"`
+}
+
+// TriageNote is a short note attached to a run, e.g. "known infra issue,
+// ignore".
+type TriageNote struct {
+	// Author is the GitHub login of the user who added the note.
+	Author  string    `json:"author"`
+	Text    string    `json:"text"`
+	Created time.Time `json:"created"`
+}
+
+// triageNotesLock serializes the additions of notes within this Deck, as
+// adding a note rewrites the whole file.
+var triageNotesLock sync.Mutex
+
+// triageNotesPath returns the path of the triage notes of the run specified
+// by src.
+func (s *Spyglass) triageNotesPath(src string) (string, error) {
+	keyType, key, err := splitSrc(strings.TrimSuffix(src, "/"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing src: %w", err)
+	}
+	switch keyType {
+	case prowKeyType:
+		keyType, key, err = s.prowToGCS(key)
+		if err != nil {
+			return "", err
+		}
+	case gcsKeyType:
+		keyType = providers.GS
+	}
+	jobSource, err := s.StorageArtifactFetcher.newStorageJobSource(fmt.Sprintf("%s://%s", keyType, key))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s/%s", jobSource.linkPrefix, jobSource.bucket, path.Join(jobSource.jobPrefix, TriageNotesName)), nil
+}
+
+// TriageNotes returns the triage notes attached to the run specified by src,
+// oldest first.
+func (s *Spyglass) TriageNotes(ctx context.Context, src string) ([]TriageNote, error) {
+	notesPath, err := s.triageNotesPath(src)
+	if err != nil {
+		return nil, err
+	}
+	return s.readTriageNotes(ctx, notesPath)
+}
+
+// AddTriageNote attaches a note to the run specified by src and returns all
+// of its notes.
+func (s *Spyglass) AddTriageNote(ctx context.Context, src string, note TriageNote) ([]TriageNote, error) {
+	notesPath, err := s.triageNotesPath(src)
+	if err != nil {
+		return nil, err
+	}
+	triageNotesLock.Lock()
+	defer triageNotesLock.Unlock()
+	notes, err := s.readTriageNotes(ctx, notesPath)
+	if err != nil {
+		return nil, err
+	}
+	notes = append(notes, note)
+	raw, err := json.Marshal(TriageNotes{Notes: notes})
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	if err := pkgio.WriteContent(ctx, logrus.WithField("src", src), s.opener, notesPath, raw, pkgio.WriterOptions{ContentType: &contentType}); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", notesPath, err)
+	}
+	return notes, nil
+}
+
+func (s *Spyglass) readTriageNotes(ctx context.Context, notesPath string) ([]TriageNote, error) {
+	reader, err := s.opener.Reader(ctx, notesPath)
+	if err != nil {
+		if pkgio.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var notes TriageNotes
+	if err := json.Unmarshal(raw, &notes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", notesPath, err)
+	}
+	return notes.Notes, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spyglass
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/io"
+)
+
+func TestTriageNotes(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	existing := TriageNote{Author: "alice", Text: "known infra issue, ignore", Created: created}
+
+	testCases := []struct {
+		name          string
+		src           string
+		add           *TriageNote
+		expectedNotes []TriageNote
+		expectedErr   bool
+	}{
+		{
+			name:          "notes are read",
+			src:           "gs/test-bucket/logs/job/1",
+			expectedNotes: []TriageNote{existing},
+		},
+		{
+			name: "run without notes has none",
+			src:  "gs/test-bucket/logs/job/2",
+		},
+		{
+			name:          "note is appended to the existing ones",
+			src:           "gcs/test-bucket/logs/job/1/",
+			add:           &TriageNote{Author: "bob", Text: "fixed by #123", Created: created.Add(time.Hour)},
+			expectedNotes: []TriageNote{existing, {Author: "bob", Text: "fixed by #123", Created: created.Add(time.Hour)}},
+		},
+		{
+			name:          "first note of a run is stored",
+			src:           "gs/test-bucket/logs/job/2",
+			add:           &TriageNote{Author: "bob", Text: "flake", Created: created},
+			expectedNotes: []TriageNote{{Author: "bob", Text: "flake", Created: created}},
+		},
+		{
+			name:        "notes can't be added in buckets that aren't allowed",
+			src:         "gs/other-bucket/logs/job/1",
+			add:         &TriageNote{Author: "bob", Text: "flake", Created: created},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := fakestorage.NewServer([]fakestorage.Object{
+				{
+					BucketName: "test-bucket",
+					Name:       "logs/job/1/" + TriageNotesName,
+					Content:    []byte(`{"notes":[{"author":"alice","text":"known infra issue, ignore","created":"2024-05-01T12:00:00Z"}]}`),
+				},
+				{
+					BucketName: "test-bucket",
+					Name:       "logs/job/2/build-log.txt",
+					Content:    []byte("no notes"),
+				},
+				{
+					BucketName: "other-bucket",
+					Name:       "logs/job/1/build-log.txt",
+					Content:    []byte("not allowed"),
+				},
+			})
+			defer server.Stop()
+			skipValidation := false
+			ca := config.Agent{}
+			ca.Set(&config.Config{
+				ProwConfig: config.ProwConfig{
+					Deck: config.Deck{
+						SkipStoragePathValidation: &skipValidation,
+						AllKnownStorageBuckets:    sets.New[string]("test-bucket"),
+					},
+				},
+			})
+			cfg := ca.Config
+			sg := &Spyglass{config: cfg, StorageArtifactFetcher: NewStorageArtifactFetcher(io.NewGCSOpener(server.Client()), cfg, false)}
+
+			var notes []TriageNote
+			var err error
+			if tc.add != nil {
+				notes, err = sg.AddTriageNote(context.Background(), tc.src, *tc.add)
+			} else {
+				notes, err = sg.TriageNotes(context.Background(), tc.src)
+			}
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedNotes, notes); diff != "" {
+				t.Errorf("unexpected notes (-want +got):\n%s", diff)
+			}
+			if tc.add == nil {
+				return
+			}
+			stored, err := sg.TriageNotes(context.Background(), tc.src)
+			if err != nil {
+				t.Fatalf("failed to read the stored notes: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedNotes, stored); diff != "" {
+				t.Errorf("unexpected stored notes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
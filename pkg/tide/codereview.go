@@ -226,6 +226,12 @@ type provider interface {
 	// "heads/master", which tide will use for making decision on whether a
 	// prowjob was tested against latest HEAD, it has to be from remote server.
 	GetRef(org, repo, ref string) (string, error)
+	// baseDrift returns the number of commits by which the branch of the
+	// subpool moved away from the SHA its PRs were tested against.
+	baseDrift(sp subpool) (int, error)
+	// unapprovedOwners returns the OWNERS files whose approval the changes
+	// of the PR still lack.
+	unapprovedOwners(pr *CodeReviewCommon) ([]string, error)
 	// headContexts returns Contexts from all presubmit requirements.
 	// Tide needs to know whether a PR passed all tests or not, this includes
 	// prow jobs, but also any external tests that are required by GitHub branch
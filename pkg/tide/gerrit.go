@@ -180,7 +180,9 @@ func newGerritProvider(
 	orgRepoConfigGetter := func() *config.GerritOrgRepoConfigs {
 		return &cfg().Tide.Gerrit.Queries
 	}
-	gerritClient.ApplyGlobalConfig(orgRepoConfigGetter, nil, cookiefilePath, tokenPathOverride, nil)
+	gerritClient.ApplyGlobalConfig(orgRepoConfigGetter, nil, cookiefilePath, tokenPathOverride, func() {
+		gerritClient.SetQueryParallelism(cfg().Gerrit.QueryParallelism)
+	})
 
 	return &GerritProvider{
 		logger:             logger,
@@ -274,6 +276,18 @@ func (p *GerritProvider) GetRef(org, repo, ref string) (string, error) {
 	return p.gc.GetBranchRevision(org, repo, ref)
 }
 
+// baseDrift is not supported for Gerrit, which rebases or rejects changes
+// that are not based on the latest revision itself on submit.
+func (p *GerritProvider) baseDrift(sp subpool) (int, error) {
+	return 0, nil
+}
+
+// unapprovedOwners is not supported for Gerrit, where the OWNERS of the
+// files are enforced by submit requirements.
+func (p *GerritProvider) unapprovedOwners(pr *CodeReviewCommon) ([]string, error) {
+	return nil, nil
+}
+
 // headContexts gets the status contexts for the commit with OID ==
 // pr.HeadRefOID
 //
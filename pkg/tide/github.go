@@ -32,6 +32,10 @@ import (
 	"sigs.k8s.io/prow/pkg/git/types"
 	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/plugins"
+	"sigs.k8s.io/prow/pkg/plugins/approve"
+	"sigs.k8s.io/prow/pkg/plugins/ownersconfig"
+	"sigs.k8s.io/prow/pkg/repoowners"
 	"sigs.k8s.io/prow/pkg/tide/blockers"
 
 	githubql "github.com/shurcooL/githubv4"
@@ -56,11 +60,52 @@ type GitHubProvider struct {
 	ghc                githubClient
 	gc                 git.ClientFactory
 	usesGitHubAppsAuth bool
+	// ownersApproval computes the approvals of PRs to repos that require
+	// them, it is nil if Tide cannot compute them.
+	ownersApproval *ownersApproval
 
 	*mergeChecker
 	logger *logrus.Entry
 }
 
+// ownersApproval computes which OWNERS still need to approve the changes of
+// a PR, like the approve plugin does.
+type ownersApproval struct {
+	ghc          approve.ApprovalClient
+	ownersClient repoowners.Interface
+	// pluginConfig returns the config to take the approve options from.
+	pluginConfig func() *plugins.Configuration
+}
+
+// newOwnersApproval returns an ownersApproval that loads the OWNERS files
+// and takes the approve options from the plugin config of the agent, or uses
+// the defaults of the plugin if the agent is nil.
+func newOwnersApproval(ghc github.Client, gc git.ClientFactory, cfg config.Getter, pluginAgent *plugins.ConfigAgent) *ownersApproval {
+	pluginConfig := func() *plugins.Configuration {
+		if pluginAgent == nil {
+			return &plugins.Configuration{}
+		}
+		return pluginAgent.Config()
+	}
+	ownersDirDenylist := func() *config.OwnersDirDenylist {
+		// OwnersDirDenylist struct contains some defaults that's required by all
+		// repos, so this function cannot return nil
+		if l := cfg().OwnersDirDenylist; l != nil {
+			return l
+		}
+		return &config.OwnersDirDenylist{}
+	}
+	ownersClient := repoowners.NewClient(
+		gc,
+		ghc,
+		func(org, repo string) bool { return pluginConfig().MDYAMLEnabled(org, repo) },
+		func(org, repo string) bool { return pluginConfig().SkipCollaborators(org, repo) },
+		ownersDirDenylist,
+		func(org, repo string) ownersconfig.Filenames { return pluginConfig().OwnersFilenames(org, repo) },
+	)
+	return &ownersApproval{ghc: ghc, ownersClient: ownersClient, pluginConfig: pluginConfig}
+}
+
 func newGitHubProvider(
 	logger *logrus.Entry,
 	ghc githubClient,
@@ -151,6 +196,48 @@ func (gi *GitHubProvider) GetRef(org, repo, ref string) (string, error) {
 	return gi.ghc.GetRef(org, repo, ref)
 }
 
+func (gi *GitHubProvider) baseDrift(sp subpool) (int, error) {
+	sha, err := gi.ghc.GetRef(sp.org, sp.repo, "heads/"+sp.branch)
+	if err != nil {
+		return 0, err
+	}
+	if sha == sp.sha {
+		return 0, nil
+	}
+	comparison, err := gi.ghc.CompareCommits(sp.org, sp.repo, sp.sha, sha)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compare %s with %s: %w", sp.sha, sha, err)
+	}
+	// The commits the branch lost count as well, e.g. after a force push.
+	return comparison.AheadBy + comparison.BehindBy, nil
+}
+
+func (gi *GitHubProvider) unapprovedOwners(pr *CodeReviewCommon) ([]string, error) {
+	if gi.ownersApproval == nil {
+		return nil, errors.New("the approvals of PRs cannot be computed without an OWNERS client")
+	}
+	opts := gi.ownersApproval.pluginConfig().ApproveFor(pr.Org, pr.Repo)
+	owners, err := gi.ownersApproval.ownersClient.LoadRepoOwners(pr.Org, pr.Repo, pr.BaseRefName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the OWNERS of %s/%s: %w", pr.Org, pr.Repo, err)
+	}
+	log := gi.logger.WithFields(pr.logFields())
+	return approve.UnapprovedFiles(log, gi.ownersApproval.ghc, owners, opts, pr.Org, pr.Repo, pr.Number, pr.AuthorLogin)
+}
+
+func (gi *GitHubProvider) currentApprovers(pr *CodeReviewCommon) ([]string, error) {
+	if gi.ownersApproval == nil {
+		return nil, errors.New("the approvers of PRs cannot be computed without an OWNERS client")
+	}
+	opts := gi.ownersApproval.pluginConfig().ApproveFor(pr.Org, pr.Repo)
+	owners, err := gi.ownersApproval.ownersClient.LoadRepoOwners(pr.Org, pr.Repo, pr.BaseRefName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the OWNERS of %s/%s: %w", pr.Org, pr.Repo, err)
+	}
+	log := gi.logger.WithFields(pr.logFields())
+	return approve.CurrentApprovers(log, gi.ownersApproval.ghc, owners, opts, pr.Org, pr.Repo, pr.Number, pr.AuthorLogin)
+}
+
 func (gi *GitHubProvider) GetTideContextPolicy(org, repo, branch string, baseSHAGetter config.RefGetter, pr *CodeReviewCommon) (contextChecker, error) {
 	return gi.cfg().GetTideContextPolicy(gi.gc, org, repo, branch, baseSHAGetter, pr.HeadRefOID)
 }
@@ -214,10 +301,12 @@ func (gi *GitHubProvider) prepareMergeDetails(commitTemplates config.TideMergeCo
 		MergeMethod: string(mergeMethod),
 	}
 
+	commitTemplates = commitTemplates.ForMergeMethod(mergeMethod)
+	data := &MergeCommitData{CodeReviewCommon: pr, MergeMethod: mergeMethod, provider: gi}
 	if commitTemplates.Title != nil {
 		var b bytes.Buffer
 
-		if err := commitTemplates.Title.Execute(&b, pr); err != nil {
+		if err := commitTemplates.Title.Execute(&b, data); err != nil {
 			gi.logger.Errorf("error executing commit title template: %v", err)
 		} else {
 			ghMergeDetails.CommitTitle = b.String()
@@ -227,7 +316,7 @@ func (gi *GitHubProvider) prepareMergeDetails(commitTemplates config.TideMergeCo
 	if commitTemplates.Body != nil {
 		var b bytes.Buffer
 
-		if err := commitTemplates.Body.Execute(&b, pr); err != nil {
+		if err := commitTemplates.Body.Execute(&b, data); err != nil {
 			gi.logger.Errorf("error executing commit body template: %v", err)
 		} else {
 			ghMergeDetails.CommitMessage = b.String()
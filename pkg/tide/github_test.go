@@ -240,6 +240,42 @@ func TestPrepareMergeDetails(t *testing.T) {
 			CommitTitle:   "1: my commit title",
 			CommitMessage: "SHA - my commit body",
 		},
+	}, {
+		name: "Commit template uses co-authors, approvers and linked issues",
+		tpl: config.TideMergeCommitTemplate{
+			Title: getTemplate("CommitTitle", "{{ .Title }} ({{ .MergeMethod }})"),
+			Body:  getTemplate("CommitBody", "{{ range .LinkedIssues }}Fixes {{ . }}\n{{ end }}{{ range .Approvers }}Approved-by: {{ . }}\n{{ end }}{{ range .CoAuthors }}Co-authored-by: {{ . }}\n{{ end }}"),
+		},
+		pr: func() PullRequest {
+			pr := pr
+			pr.Author.Login = "alice"
+			pr.Body = "Fixes #2 and closes org/other#3."
+			return pr
+		}(),
+		mergeMethod: "squash",
+		expected: github.MergeDetails{
+			SHA:           "SHA",
+			MergeMethod:   "squash",
+			CommitTitle:   "my commit title (squash)",
+			CommitMessage: "Fixes #2\nFixes org/other#3\nApproved-by: alice\nApproved-by: approver\nCo-authored-by: Bob <bob@example.com>\n",
+		},
+	}, {
+		name: "Commit template for the merge method takes precedence",
+		tpl: config.TideMergeCommitTemplate{
+			Title: getTemplate("CommitTitle", "{{ .Title }}"),
+			Body:  getTemplate("CommitBody", "{{ .Body }}"),
+			MergeMethods: map[types.PullRequestMergeType]config.TideCommitTemplate{
+				types.MergeSquash: {Title: getTemplate("CommitTitle", "{{ .Title }} (#{{ .Number }})")},
+			},
+		},
+		pr:          pr,
+		mergeMethod: "squash",
+		expected: github.MergeDetails{
+			SHA:           "SHA",
+			MergeMethod:   "squash",
+			CommitTitle:   "my commit title (#1)",
+			CommitMessage: "my commit body",
+		},
 	}, {
 		name: "Commit template uses nonexistent fields",
 		tpl: config.TideMergeCommitTemplate{
@@ -260,9 +296,13 @@ func TestPrepareMergeDetails(t *testing.T) {
 			cfgAgent := &config.Agent{}
 			cfgAgent.Set(cfg)
 			provider := &GitHubProvider{
-				cfg:    cfgAgent.Config,
-				ghc:    &fgc{},
-				logger: logrus.WithContext(context.Background()),
+				cfg: cfgAgent.Config,
+				ghc: &fgc{prCommits: map[int][]github.RepositoryCommit{1: {
+					{Author: github.User{Login: "alice"}, Commit: github.GitCommit{Author: github.CommitAuthor{Name: "Alice", Email: "alice@example.com"}}},
+					{Author: github.User{Login: "bob"}, Commit: github.GitCommit{Author: github.CommitAuthor{Name: "Bob", Email: "bob@example.com"}}},
+				}}},
+				ownersApproval: newFakeOwnersApproval([]int{1}, nil),
+				logger:         logrus.WithContext(context.Background()),
 			}
 
 			actual := provider.prepareMergeDetails(test.tpl, *CodeReviewCommonFromPullRequest(&test.pr), test.mergeMethod)
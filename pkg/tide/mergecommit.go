@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tide
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/prow/pkg/git/types"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// MergeCommitData is passed to the templates of merge commits. Next to the
+// fields of the PR, it offers the co-authors, approvers and linked issues of
+// the PR, which are only looked up if a template uses them.
+type MergeCommitData struct {
+	CodeReviewCommon
+	// MergeMethod is the method the PR is merged with.
+	MergeMethod types.PullRequestMergeType
+
+	provider  *GitHubProvider
+	coAuthors []string
+	approvers []string
+}
+
+// CoAuthors returns the authors of the commits of the PR other than the PR
+// author, as well as the co-authors named in the commit messages, formatted
+// as "Name <email>" for "Co-authored-by:" trailers.
+func (d *MergeCommitData) CoAuthors() ([]string, error) {
+	if d.coAuthors != nil {
+		return d.coAuthors, nil
+	}
+	commits, err := d.provider.ghc.ListPullRequestCommits(d.Org, d.Repo, d.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the commits of %s/%s#%d: %w", d.Org, d.Repo, d.Number, err)
+	}
+	d.coAuthors = coAuthors(commits, d.AuthorLogin)
+	return d.coAuthors, nil
+}
+
+// Approvers returns the logins of the approvers of the PR, as the approve
+// plugin determines them.
+func (d *MergeCommitData) Approvers() ([]string, error) {
+	if d.approvers != nil {
+		return d.approvers, nil
+	}
+	approvers, err := d.provider.currentApprovers(&d.CodeReviewCommon)
+	if err != nil {
+		return nil, err
+	}
+	d.approvers = approvers
+	return d.approvers, nil
+}
+
+// LinkedIssues returns the issues the PR closes according to the closing
+// keywords in its body, e.g. "Fixes #123", as "#123" for issues of the same
+// repo and as "org/repo#123" for issues of other repos.
+func (d *MergeCommitData) LinkedIssues() []string {
+	return linkedIssues(d.Body, d.Org, d.Repo)
+}
+
+var coAuthoredByRe = regexp.MustCompile(`(?mi)^co-authored-by:\s*(.+?)\s*$`)
+
+func coAuthors(commits []github.RepositoryCommit, author string) []string {
+	authorEmails := sets.New[string]()
+	for _, commit := range commits {
+		if strings.EqualFold(commit.Author.Login, author) {
+			authorEmails.Insert(strings.ToLower(commit.Commit.Author.Email))
+		}
+	}
+	seen := sets.New[string]()
+	found := []string{}
+	add := func(name, email string) {
+		key := strings.ToLower(email)
+		if email == "" || authorEmails.Has(key) || seen.Has(key) {
+			return
+		}
+		seen.Insert(key)
+		found = append(found, fmt.Sprintf("%s <%s>", name, email))
+	}
+	for _, commit := range commits {
+		if !strings.EqualFold(commit.Author.Login, author) {
+			add(commit.Commit.Author.Name, commit.Commit.Author.Email)
+		}
+		for _, match := range coAuthoredByRe.FindAllStringSubmatch(commit.Commit.Message, -1) {
+			if address, err := mail.ParseAddress(match[1]); err == nil {
+				add(address.Name, address.Address)
+			}
+		}
+	}
+	return found
+}
+
+// closingKeywordRe matches the references to the issues a PR closes, see
+// https://docs.github.com/en/issues/tracking-your-work-with-issues/linking-a-pull-request-to-an-issue
+var closingKeywordRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+(?:([\w.-]+/[\w.-]+)#|https?://[^/\s]+/([\w.-]+/[\w.-]+)/issues/|#)(\d+)\b`)
+
+func linkedIssues(body, org, repo string) []string {
+	seen := sets.New[string]()
+	found := []string{}
+	for _, match := range closingKeywordRe.FindAllStringSubmatch(body, -1) {
+		issue := "#" + match[3]
+		if issueRepo := match[1] + match[2]; issueRepo != "" && !strings.EqualFold(issueRepo, org+"/"+repo) {
+			issue = issueRepo + issue
+		}
+		if !seen.Has(issue) {
+			seen.Insert(issue)
+			found = append(found, issue)
+		}
+	}
+	return found
+}
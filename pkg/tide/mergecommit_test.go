@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tide
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+func TestCoAuthors(t *testing.T) {
+	commit := func(login, name, email, message string) github.RepositoryCommit {
+		return github.RepositoryCommit{
+			Author: github.User{Login: login},
+			Commit: github.GitCommit{Author: github.CommitAuthor{Name: name, Email: email}, Message: message},
+		}
+	}
+	testCases := []struct {
+		name     string
+		commits  []github.RepositoryCommit
+		expected []string
+	}{
+		{
+			name:     "only commits of the author",
+			commits:  []github.RepositoryCommit{commit("alice", "Alice", "alice@example.com", "Fix it")},
+			expected: []string{},
+		},
+		{
+			name: "commits of other authors",
+			commits: []github.RepositoryCommit{
+				commit("alice", "Alice", "alice@example.com", "Fix it"),
+				commit("bob", "Bob", "bob@example.com", "Fix it better"),
+				commit("", "Carol", "carol@example.com", "Fix it once more"),
+				commit("bob", "Bob", "Bob@example.com", "Fix it for good"),
+			},
+			expected: []string{"Bob <bob@example.com>", "Carol <carol@example.com>"},
+		},
+		{
+			name: "co-authors named in commit messages",
+			commits: []github.RepositoryCommit{
+				commit("alice", "Alice", "alice@example.com", "Fix it\n\nCo-authored-by: Dave <dave@example.com>\nco-authored-by: Alice <alice@example.com>\nCo-authored-by: nobody"),
+			},
+			expected: []string{"Dave <dave@example.com>"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, coAuthors(tc.commits, "Alice")); diff != "" {
+				t.Errorf("co-authors differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLinkedIssues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{
+			name:     "no closing keywords",
+			body:     "Related to #1, see also org/repo#2.",
+			expected: []string{},
+		},
+		{
+			name:     "issues of the same repo",
+			body:     "Fixes #1\nThis also resolves: org/repo#2 and closes https://github.com/Org/Repo/issues/3.",
+			expected: []string{"#1", "#2", "#3"},
+		},
+		{
+			name:     "issues of other repos",
+			body:     "Fixed org/other#1, closed https://github.com/other/repo/issues/2",
+			expected: []string{"org/other#1", "other/repo#2"},
+		},
+		{
+			name:     "issues are listed once",
+			body:     "Fixes #1\n\nFIXES #1",
+			expected: []string{"#1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, linkedIssues(tc.body, "org", "repo")); diff != "" {
+				t.Errorf("linked issues differ from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tide
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// shareWindow is the number of most recent triggers the share of each repo is
+// reported for.
+const shareWindow = 100
+
+// triggerRequest holds the tests a pool asked to trigger during a sync with
+// fair scheduling.
+type triggerRequest struct {
+	sp         subpool
+	action     Action
+	presubmits []config.Presubmit
+	prs        []CodeReviewCommon
+	// queueAge is how long the oldest PR of the pool has been waiting.
+	queueAge time.Duration
+}
+
+func (r triggerRequest) repo() config.OrgRepo {
+	return config.OrgRepo{Org: r.sp.org, Repo: r.sp.repo}
+}
+
+// fairScheduler queues the tests pools want to trigger during a sync, so that
+// they can be triggered in the order of fairSchedule once all pools synced.
+type fairScheduler struct {
+	sync.Mutex
+	// active is whether fair scheduling is enabled for the current sync.
+	active   bool
+	requests []triggerRequest
+	// lastTriggered is when tests were last triggered for each repo.
+	lastTriggered map[config.OrgRepo]time.Time
+	// started stands in for the last trigger of repos tests were never
+	// triggered for by the scheduler.
+	started time.Time
+	// recent are the repos of the last triggers, the oldest first.
+	recent []config.OrgRepo
+}
+
+// begin starts a sync, dropping the requests of the last one.
+func (s *fairScheduler) begin(active bool, now time.Time) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.active = active
+	s.requests = nil
+	if s.lastTriggered == nil {
+		s.lastTriggered = map[config.OrgRepo]time.Time{}
+	}
+	if s.started.IsZero() {
+		s.started = now
+	}
+}
+
+// queue records the request if fair scheduling is enabled for the sync, and
+// returns whether it did.
+func (s *fairScheduler) queue(r triggerRequest) bool {
+	if s == nil {
+		return false
+	}
+	s.Lock()
+	defer s.Unlock()
+	if !s.active {
+		return false
+	}
+	s.requests = append(s.requests, r)
+	return true
+}
+
+// queues tells whether triggers are queued during the current sync.
+func (s *fairScheduler) queues() bool {
+	if s == nil {
+		return false
+	}
+	s.Lock()
+	defer s.Unlock()
+	return s.active
+}
+
+// schedule returns the queued requests in the order they should be triggered.
+func (s *fairScheduler) schedule(weight float64, now time.Time) []triggerRequest {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	requests := s.requests
+	s.requests = nil
+	return fairSchedule(requests, s.lastTriggered, s.started, weight, now)
+}
+
+// fairSchedule orders requests by the time since tests were last triggered
+// for their repo plus the weighted age of their queue, the highest first.
+// Repos are rotated: once a request of a repo is picked, the other requests
+// of the repo rank as if its tests were just triggered.
+func fairSchedule(requests []triggerRequest, lastTriggered map[config.OrgRepo]time.Time, started time.Time, weight float64, now time.Time) []triggerRequest {
+	remaining := append([]triggerRequest(nil), requests...)
+	// Sort first so that ties are broken the same way in every sync.
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return poolKey(remaining[i].sp.org, remaining[i].sp.repo, remaining[i].sp.branch) < poolKey(remaining[j].sp.org, remaining[j].sp.repo, remaining[j].sp.branch)
+	})
+	picked := map[config.OrgRepo]bool{}
+	score := func(r triggerRequest) float64 {
+		var waited time.Duration
+		if !picked[r.repo()] {
+			last, ok := lastTriggered[r.repo()]
+			if !ok {
+				last = started
+			}
+			waited = now.Sub(last)
+		}
+		return waited.Seconds() + weight*r.queueAge.Seconds()
+	}
+	ordered := make([]triggerRequest, 0, len(remaining))
+	for len(remaining) > 0 {
+		best := 0
+		for i := range remaining {
+			if score(remaining[i]) > score(remaining[best]) {
+				best = i
+			}
+		}
+		ordered = append(ordered, remaining[best])
+		picked[remaining[best].repo()] = true
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return ordered
+}
+
+// triggered records that tests were triggered for the repo and returns the
+// share of each repo of the most recent triggers.
+func (s *fairScheduler) triggered(repo config.OrgRepo, now time.Time) map[config.OrgRepo]float64 {
+	s.Lock()
+	defer s.Unlock()
+	s.lastTriggered[repo] = now
+	s.recent = append(s.recent, repo)
+	if len(s.recent) > shareWindow {
+		s.recent = s.recent[len(s.recent)-shareWindow:]
+	}
+	shares := map[config.OrgRepo]float64{}
+	for _, r := range s.recent {
+		shares[r] += 1 / float64(len(s.recent))
+	}
+	return shares
+}
+
+// requestTrigger triggers the tests of a pool, or queues them to be triggered
+// by scheduleTriggers once all pools synced if fair scheduling is enabled.
+func (c *syncController) requestTrigger(sp subpool, action Action, presubmits []config.Presubmit, prs []CodeReviewCommon) error {
+	now := time.Now()
+	var queueAge time.Duration
+	for _, pr := range sp.prs {
+		if waited, ok := c.poolEntries.timeInPool(&pr, now); ok && waited > queueAge {
+			queueAge = waited
+		}
+	}
+	if c.fairScheduler.queue(triggerRequest{sp: sp, action: action, presubmits: presubmits, prs: prs, queueAge: queueAge}) {
+		return nil
+	}
+	return c.trigger(sp, presubmits, prs)
+}
+
+// scheduleTriggers triggers the tests of the first max_triggers_per_sync
+// pools in the fair schedule and defers the tests of the others to a later
+// sync, updating the pools accordingly.
+func (c *syncController) scheduleTriggers(pools []Pool, fair *config.TideFairScheduling) {
+	byKey := make(map[string]*Pool, len(pools))
+	for i := range pools {
+		byKey[poolKey(pools[i].Org, pools[i].Repo, pools[i].Branch)] = &pools[i]
+	}
+	now := time.Now()
+	for i, r := range c.fairScheduler.schedule(fair.GetQueueAgeWeight(), now) {
+		key := poolKey(r.sp.org, r.sp.repo, r.sp.branch)
+		pool := byKey[key]
+		if i >= fair.MaxTriggersPerSync {
+			r.sp.log.WithField("queue-age", r.queueAge.String()).Info("Deferring tests to let other repos trigger theirs first.")
+			tideMetrics.fairTriggers.WithLabelValues(r.sp.org, r.sp.repo, "deferred").Inc()
+			if pool != nil {
+				pool.Action = Deferred
+			}
+			continue
+		}
+		var errorString string
+		if err := c.trigger(r.sp, r.presubmits, r.prs); err != nil {
+			r.sp.log.WithError(err).Error("Error triggering tests.")
+			tideMetrics.poolErrors.WithLabelValues(r.sp.org, r.sp.repo, r.sp.branch).Inc()
+			errorString = err.Error()
+			if pool != nil {
+				pool.Error = errorString
+			}
+		} else {
+			tideMetrics.fairTriggers.WithLabelValues(r.sp.org, r.sp.repo, "triggered").Inc()
+			tideMetrics.fairTriggerShare.Reset()
+			for repo, share := range c.fairScheduler.triggered(r.repo(), now) {
+				tideMetrics.fairTriggerShare.WithLabelValues(repo.Org, repo.Repo).Set(share)
+			}
+		}
+		c.History.Record(key, string(r.action), r.sp.sha, errorString, prMeta(r.prs...), r.sp.TenantIDs())
+	}
+}
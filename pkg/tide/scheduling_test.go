@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tide
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/tide/history"
+)
+
+func TestFairSchedule(t *testing.T) {
+	now := time.Now()
+	started := now.Add(-time.Hour)
+	request := func(org, repo, branch string, queueAge time.Duration) triggerRequest {
+		return triggerRequest{sp: subpool{org: org, repo: repo, branch: branch}, queueAge: queueAge}
+	}
+	testCases := []struct {
+		name          string
+		requests      []triggerRequest
+		lastTriggered map[config.OrgRepo]time.Time
+		weight        float64
+		expected      []string
+	}{
+		{
+			name: "repos that triggered tests recently go last",
+			requests: []triggerRequest{
+				request("o", "busy", "main", 0),
+				request("o", "quiet", "main", 0),
+			},
+			lastTriggered: map[config.OrgRepo]time.Time{{Org: "o", Repo: "busy"}: now.Add(-time.Minute)},
+			weight:        1,
+			expected:      []string{"o/quiet:main", "o/busy:main"},
+		},
+		{
+			name: "old queues go first",
+			requests: []triggerRequest{
+				request("o", "busy", "main", 2*time.Hour),
+				request("o", "quiet", "main", 0),
+			},
+			lastTriggered: map[config.OrgRepo]time.Time{{Org: "o", Repo: "busy"}: now.Add(-time.Minute)},
+			weight:        1,
+			expected:      []string{"o/busy:main", "o/quiet:main"},
+		},
+		{
+			name: "queue age is ignored without weight",
+			requests: []triggerRequest{
+				request("o", "busy", "main", 2*time.Hour),
+				request("o", "quiet", "main", 0),
+			},
+			lastTriggered: map[config.OrgRepo]time.Time{{Org: "o", Repo: "busy"}: now.Add(-time.Minute)},
+			weight:        0,
+			expected:      []string{"o/quiet:main", "o/busy:main"},
+		},
+		{
+			name: "repos are rotated within a sync",
+			requests: []triggerRequest{
+				request("o", "a", "main", time.Minute),
+				request("o", "a", "release", time.Minute),
+				request("o", "b", "main", 0),
+			},
+			lastTriggered: map[config.OrgRepo]time.Time{{Org: "o", Repo: "b"}: now.Add(-30 * time.Minute)},
+			weight:        1,
+			expected:      []string{"o/a:main", "o/b:main", "o/a:release"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual []string
+			for _, r := range fairSchedule(tc.requests, tc.lastTriggered, started, tc.weight, now) {
+				actual = append(actual, poolKey(r.sp.org, r.sp.repo, r.sp.branch))
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("schedule differs from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScheduleTriggers(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.WithField("controller", "tide")
+	hist, err := history.New(100, nil, "")
+	if err != nil {
+		t.Fatalf("Failed to create history client: %v", err)
+	}
+	fair := &config.TideFairScheduling{MaxTriggersPerSync: 2}
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{
+			ProwJobNamespace: "default",
+			Tide:             config.Tide{FairScheduling: fair},
+		}}
+	}
+	c := &syncController{
+		ctx:           ctx,
+		logger:        log,
+		config:        cfg,
+		prowJobClient: newFakeManager(t, ctx).GetClient(),
+		provider:      &GitHubProvider{logger: log},
+		poolEntries:   &poolEntryTracker{nextEntered: map[string]time.Time{}},
+		fairScheduler: &fairScheduler{},
+		History:       hist,
+	}
+	now := time.Now()
+	c.fairScheduler.begin(true, now)
+	// Repo c triggered longest ago, the tie between a and b is broken by name.
+	c.fairScheduler.lastTriggered = map[config.OrgRepo]time.Time{
+		{Org: "o", Repo: "a"}: now.Add(-time.Minute),
+		{Org: "o", Repo: "b"}: now.Add(-time.Minute),
+		{Org: "o", Repo: "c"}: now.Add(-time.Hour),
+	}
+
+	var pools []Pool
+	for _, repo := range []string{"a", "b", "c"} {
+		sp := subpool{log: log, org: "o", repo: repo, branch: "main", sha: "base"}
+		var pr PullRequest
+		pr.Number = githubql.Int(1)
+		pr.HeadRefOID = githubql.String("head")
+		prs := []CodeReviewCommon{*CodeReviewCommonFromPullRequest(&pr)}
+		presubmits := []config.Presubmit{{JobBase: config.JobBase{Name: "test-" + repo}, Reporter: config.Reporter{Context: "test"}}}
+		if err := c.requestTrigger(sp, Trigger, presubmits, prs); err != nil {
+			t.Fatalf("Failed to request a trigger: %v", err)
+		}
+		pools = append(pools, Pool{Org: "o", Repo: repo, Branch: "main", Action: Trigger})
+	}
+	prowJobs := &prowapi.ProwJobList{}
+	if err := c.prowJobClient.List(ctx, prowJobs); err != nil {
+		t.Fatalf("Failed to list ProwJobs: %v", err)
+	}
+	if len(prowJobs.Items) != 0 {
+		t.Fatalf("Expected queued triggers not to create ProwJobs, got %d", len(prowJobs.Items))
+	}
+
+	c.scheduleTriggers(pools, fair)
+
+	if err := c.prowJobClient.List(ctx, prowJobs); err != nil {
+		t.Fatalf("Failed to list ProwJobs: %v", err)
+	}
+	var jobs []string
+	for _, pj := range prowJobs.Items {
+		jobs = append(jobs, pj.Spec.Job)
+	}
+	sort.Strings(jobs)
+	if diff := cmp.Diff([]string{"test-a", "test-c"}, jobs); diff != "" {
+		t.Errorf("Triggered jobs differ from expected (-want +got):\n%s", diff)
+	}
+	var actions []Action
+	for _, pool := range pools {
+		actions = append(actions, pool.Action)
+	}
+	if diff := cmp.Diff([]Action{Trigger, Deferred, Trigger}, actions); diff != "" {
+		t.Errorf("Pool actions differ from expected (-want +got):\n%s", diff)
+	}
+	records := hist.AllRecords()
+	if len(records["o/a:main"]) != 1 || len(records["o/b:main"]) != 0 || len(records["o/c:main"]) != 1 {
+		t.Errorf("Expected the triggers of o/a and o/c to be recorded, got %v", records)
+	}
+}
@@ -55,6 +55,22 @@ const (
 	maxStatusDescriptionLength = 140
 )
 
+// Reasons for a PR not being mergeable, used as the reason label of the
+// tideblockedprs metric.
+const (
+	blockedReasonMergeConflict   = "merge_conflict"
+	blockedReasonMergeMethod     = "merge_method"
+	blockedReasonBlockingIssue   = "blocking_issue"
+	blockedReasonForbiddenBranch = "forbidden_branch"
+	blockedReasonAuthor          = "author"
+	blockedReasonMilestone       = "milestone"
+	blockedReasonMissingLabel    = "missing_label"
+	blockedReasonForbiddenLabel  = "forbidden_label"
+	blockedReasonFailingContext  = "failing_context"
+	blockedReasonMissingReview   = "missing_review"
+	blockedReasonUnknown         = "unknown"
+)
+
 type storedState struct {
 	// LatestPR is the update time of the most recent result
 	LatestPR metav1.Time
@@ -126,8 +142,15 @@ func (sc *statusController) shutdown() {
 // the TideQuery is unknown. This can happen if this function's logic
 // does not match GitHub's and does not indicate that the PR matches the query.
 func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (string, int) {
+	desc, _, diff := requirementDiffWithReason(pr, q, cc)
+	return desc, diff
+}
+
+// requirementDiffWithReason is requirementDiff that also returns the reason
+// matching the description.
+func requirementDiffWithReason(pr *PullRequest, q *config.TideQuery, cc contextChecker) (string, string, int) {
 	const maxLabelChars = 50
-	var desc string
+	var desc, reason string
 	var diff int
 	// Drops labels if needed to fit the description text area, but keep at least 1.
 	truncate := func(labels []string) []string {
@@ -163,6 +186,7 @@ func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (s
 		diff += 2000
 		if desc == "" {
 			desc = fmt.Sprintf(" Merging to branch %s is forbidden.", pr.BaseRef.Name)
+			reason = blockedReasonForbiddenBranch
 		}
 	}
 
@@ -175,6 +199,7 @@ func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (s
 		diff += 1000
 		if desc == "" {
 			desc = fmt.Sprintf(" Must be by author %s.", qAuthor)
+			reason = blockedReasonAuthor
 		}
 	}
 
@@ -184,6 +209,7 @@ func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (s
 		diff += 100
 		if desc == "" {
 			desc = fmt.Sprintf(" Must be in milestone %s.", q.Milestone)
+			reason = blockedReasonMilestone
 		}
 	}
 
@@ -211,6 +237,7 @@ func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (s
 		} else {
 			desc = fmt.Sprintf(" Needs %s labels.", strings.Join(trunced, ", "))
 		}
+		reason = blockedReasonMissingLabel
 	}
 
 	var presentLabels []string
@@ -231,6 +258,7 @@ func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (s
 		} else {
 			desc = fmt.Sprintf(" Should not have %s labels.", strings.Join(trunced, ", "))
 		}
+		reason = blockedReasonForbiddenLabel
 	}
 
 	// fixing label issues takes precedence over status contexts
@@ -252,43 +280,50 @@ func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (s
 		} else {
 			desc = fmt.Sprintf(" Jobs %s have not succeeded.", strings.Join(trunced, ", "))
 		}
+		reason = blockedReasonFailingContext
 	}
 
 	if q.ReviewApprovedRequired && pr.ReviewDecision != githubql.PullRequestReviewDecisionApproved {
 		diff += 50
 		if desc == "" {
 			desc = " PullRequest is missing sufficient approving GitHub review(s)"
+			reason = blockedReasonMissingReview
 		}
 	}
-	return desc, diff
+	return desc, reason, diff
 }
 
-// expectedStatus returns expected GitHub status state and description.
+// expectedStatus returns expected GitHub status state and description, and
+// the reason if the PR is not mergeable.
 // If a PR is not mergeable, we have to select a TideQuery to compare it against
 // in order to generate a diff for the status description. We choose the query
 // for the repo that the PR is closest to meeting (as determined by the number
 // of unmet/violated requirements).
-func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.QueryMap, crc *CodeReviewCommon, pool map[string]CodeReviewCommon, ccg contextCheckerGetter, blocks blockers.Blockers, baseSHA string) (string, string, error) {
+func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.QueryMap, crc *CodeReviewCommon, pool map[string]CodeReviewCommon, ccg contextCheckerGetter, blocks blockers.Blockers, baseSHA string) (string, string, string, error) {
 	// Get PullRequest struct for GitHub specific logic
 	pr := crc.GitHub
 	if pr == nil {
 		// This should not happen, as this mergeChecker is meant to be used by
 		// GitHub repos only
-		return "", "", errors.New("unexpected error: CodeReviewCommon should carry PullRequest struct")
+		return "", "", "", errors.New("unexpected error: CodeReviewCommon should carry PullRequest struct")
 	}
 
 	repo := config.OrgRepo{Org: crc.Org, Repo: crc.Repo}
 
 	if reason, err := sc.ghProvider.isAllowedToMerge(crc); err != nil {
-		return "", "", fmt.Errorf("error checking if merge is allowed: %w", err)
+		return "", "", "", fmt.Errorf("error checking if merge is allowed: %w", err)
 	} else if reason != "" {
 		log.WithField("reason", reason).Debug("The PR is not mergeable")
-		return github.StatusError, fmt.Sprintf(statusNotInPool, " "+reason), nil
+		blockedReason := blockedReasonMergeMethod
+		if pr.Mergeable == githubql.MergeableStateConflicting {
+			blockedReason = blockedReasonMergeConflict
+		}
+		return github.StatusError, fmt.Sprintf(statusNotInPool, " "+reason), blockedReason, nil
 	}
 
 	cc, err := ccg()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to set up context register: %w", err)
+		return "", "", "", fmt.Errorf("failed to set up context register: %w", err)
 	}
 
 	if _, ok := pool[prKey(crc)]; !ok {
@@ -303,7 +338,7 @@ func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.Q
 			if len(numbers) > 1 {
 				s = "s"
 			}
-			return github.StatusError, fmt.Sprintf(statusNotInPool, fmt.Sprintf(" Merging is blocked by issue%s %s.", s, strings.Join(numbers, ", "))), nil
+			return github.StatusError, fmt.Sprintf(statusNotInPool, fmt.Sprintf(" Merging is blocked by issue%s %s.", s, strings.Join(numbers, ", "))), blockedReasonBlockingIssue, nil
 		}
 
 		// hasFulfilledQuery is a weird state, it means that the PR is not in the pool but should be. It happens when all requirements were fulfilled
@@ -314,8 +349,14 @@ func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.Q
 
 		minDiffCount := -1
 		var minDiff string
+		minReasonCount := -1
+		blockedReason := blockedReasonUnknown
 		for _, q := range queryMap.ForRepo(repo) {
-			diff, diffCount := requirementDiff(pr, &q, cc)
+			diff, reason, diffCount := requirementDiffWithReason(pr, &q, cc)
+			if diffCount > 0 && reason != "" && (minReasonCount == -1 || diffCount < minReasonCount) {
+				minReasonCount = diffCount
+				blockedReason = reason
+			}
 			if diffCount == 0 {
 				hasFulfilledQuery = true
 				break
@@ -338,7 +379,7 @@ func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.Q
 		}
 
 		if !hasFulfilledQuery {
-			return github.StatusPending, fmt.Sprintf(statusNotInPool, minDiff), nil
+			return github.StatusPending, fmt.Sprintf(statusNotInPool, minDiff), blockedReason, nil
 		}
 	}
 
@@ -347,7 +388,7 @@ func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.Q
 	if err := sc.pjClient.List(context.Background(), passingUpToDatePJs, ctrlruntimeclient.MatchingFields{indexNamePassingJobs: indexKey}); err != nil {
 		// Just log the error and return success, as the PR is in the merge pool
 		log.WithError(err).Error("Failed to list ProwJobs.")
-		return github.StatusSuccess, statusInPool, nil
+		return github.StatusSuccess, statusInPool, "", nil
 	}
 
 	var passingUpToDateContexts []string
@@ -355,9 +396,9 @@ func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.Q
 		passingUpToDateContexts = append(passingUpToDateContexts, pj.Spec.Context)
 	}
 	if diff := cc.MissingRequiredContexts(passingUpToDateContexts); len(diff) > 0 {
-		return github.StatePending, retestingStatus(diff), nil
+		return github.StatePending, retestingStatus(diff), "", nil
 	}
-	return github.StatusSuccess, statusInPool, nil
+	return github.StatusSuccess, statusInPool, "", nil
 }
 
 func retestingStatus(retested []string) string {
@@ -431,7 +472,7 @@ func (sc *statusController) setStatuses(all []CodeReviewCommon, pool map[string]
 
 		cr := contextCheckerGetterFactory(c, sc.gc, org, repo, branch, baseSHAGetter, headSHA, requiredContexts[prKey(pr)])
 
-		wantState, wantDesc, err := sc.expectedStatus(log, queryMap, pr, pool, cr, blocks, baseSHA)
+		wantState, wantDesc, blockedReason, err := sc.expectedStatus(log, queryMap, pr, pool, cr, blocks, baseSHA)
 		if err != nil {
 			log.WithError(err).Error("getting expected status")
 			return
@@ -451,6 +492,9 @@ func (sc *statusController) setStatuses(all []CodeReviewCommon, pool map[string]
 		}
 		actualState = githubql.StatusState(strings.ToLower(string(actualState)))
 		if !sc.dontUpdateStatus.has(pr.Org, pr.Repo, pr.Number) && (wantState != string(actualState) || wantDesc != actualDesc) {
+			if blockedReason != "" {
+				tideMetrics.blockedPRs.WithLabelValues(org, repo, branch, blockedReason).Inc()
+			}
 			if err := sc.ghc.CreateStatus(
 				org,
 				repo,
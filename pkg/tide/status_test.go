@@ -69,8 +69,9 @@ func TestExpectedStatus(t *testing.T) {
 		hasApprovingReview    bool
 		singleQuery           bool
 
-		state string
-		desc  string
+		state  string
+		desc   string
+		reason string
 	}{
 		{
 			name:   "in pool",
@@ -87,8 +88,9 @@ func TestExpectedStatus(t *testing.T) {
 			milestone:         "v1.0",
 			inPool:            false,
 
-			state: github.StatusPending,
-			desc:  fmt.Sprintf(statusNotInPool, " Needs need-1, need-2 labels."),
+			state:  github.StatusPending,
+			desc:   fmt.Sprintf(statusNotInPool, " Needs need-1, need-2 labels."),
+			reason: blockedReasonMissingLabel,
 		},
 		{
 			name:              "check truncation of label list is not excessive",
@@ -107,6 +109,7 @@ func TestExpectedStatus(t *testing.T) {
 			labels: append(append([]string{}, neededLabels...), mergeLabel, squashLabel),
 			state:  github.StatusError,
 			desc:   fmt.Sprintf(statusNotInPool, " PR has conflicting merge method override labels"),
+			reason: blockedReasonMergeMethod,
 		},
 		{
 			name:              "has forbidden labels",
@@ -129,8 +132,9 @@ func TestExpectedStatus(t *testing.T) {
 			milestone:         "v1.0",
 			inPool:            false,
 
-			state: github.StatusPending,
-			desc:  fmt.Sprintf(statusNotInPool, " Should not have forbidden-1 label."),
+			state:  github.StatusPending,
+			desc:   fmt.Sprintf(statusNotInPool, " Should not have forbidden-1 label."),
+			reason: blockedReasonForbiddenLabel,
 		},
 		{
 			name:              "only mention one requirement class",
@@ -184,8 +188,9 @@ func TestExpectedStatus(t *testing.T) {
 			inPool:                false,
 			displayAllTideQueries: true,
 
-			state: github.StatusPending,
-			desc:  fmt.Sprintf(statusNotInPool, " No Tide query for branch bad found."),
+			state:  github.StatusPending,
+			desc:   fmt.Sprintf(statusNotInPool, " No Tide query for branch bad found."),
+			reason: blockedReasonForbiddenBranch,
 		},
 		{
 			name:           "displayAllTideQueries shows only queries matching the branch",
@@ -215,8 +220,9 @@ func TestExpectedStatus(t *testing.T) {
 			labels:         neededLabels,
 			inPool:         false,
 
-			state: github.StatusPending,
-			desc:  fmt.Sprintf(statusNotInPool, " Merging to branch bad is forbidden."),
+			state:  github.StatusPending,
+			desc:   fmt.Sprintf(statusNotInPool, " Merging to branch bad is forbidden."),
+			reason: blockedReasonForbiddenBranch,
 		},
 		{
 			name:            "not against included branch",
@@ -266,8 +272,9 @@ func TestExpectedStatus(t *testing.T) {
 			milestone:         "v1.0",
 			inPool:            false,
 
-			state: github.StatusPending,
-			desc:  fmt.Sprintf(statusNotInPool, " Job job-name has not succeeded."),
+			state:  github.StatusPending,
+			desc:   fmt.Sprintf(statusNotInPool, " Job job-name has not succeeded."),
+			reason: blockedReasonFailingContext,
 		},
 		{
 			name:              "single bad checkrun",
@@ -713,14 +720,16 @@ func TestExpectedStatus(t *testing.T) {
 			mergeConflicts: true,
 			state:          github.StatusError,
 			desc:           "Not mergeable. PR has a merge conflict.",
+			reason:         blockedReasonMergeConflict,
 		},
 		{
 			name:                  "Missing approving review",
 			additionalTideQueries: []config.TideQuery{{Orgs: []string{""}, ReviewApprovedRequired: true}},
 			inPool:                false,
 
-			state: github.StatusPending,
-			desc:  "Not mergeable. PullRequest is missing sufficient approving GitHub review(s)",
+			state:  github.StatusPending,
+			desc:   "Not mergeable. PullRequest is missing sufficient approving GitHub review(s)",
+			reason: blockedReasonMissingReview,
 		},
 		{
 			name:                  "Required approving review is present",
@@ -855,7 +864,7 @@ func TestExpectedStatus(t *testing.T) {
 			ccg := func() (contextChecker, error) {
 				return &config.TideContextPolicy{RequiredContexts: tc.requiredContexts}, nil
 			}
-			state, desc, err := sc.expectedStatus(sc.logger, queriesByRepo, CodeReviewCommonFromPullRequest(&pr), pool, ccg, blocks, tc.baseref)
+			state, desc, reason, err := sc.expectedStatus(sc.logger, queriesByRepo, CodeReviewCommonFromPullRequest(&pr), pool, ccg, blocks, tc.baseref)
 			if err != nil {
 				t.Fatalf("error calling expectedStatus(): %v", err)
 			}
@@ -865,6 +874,9 @@ func TestExpectedStatus(t *testing.T) {
 			if desc != tc.desc {
 				t.Errorf("Expected status description %q, but got %q.", tc.desc, desc)
 			}
+			if tc.reason != "" && reason != tc.reason {
+				t.Errorf("Expected blocked reason %q, but got %q.", tc.reason, reason)
+			}
 		})
 	}
 }
@@ -46,6 +46,7 @@ import (
 	"sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/kube"
 	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/plugins"
 	"sigs.k8s.io/prow/pkg/tide/blockers"
 	"sigs.k8s.io/prow/pkg/tide/history"
 	_ "sigs.k8s.io/prow/pkg/version"
@@ -60,8 +61,10 @@ type githubClient interface {
 	ListCheckRuns(org, repo, ref string) (*github.CheckRunList, error)
 	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
 	GetRef(string, string, string) (string, error)
+	CompareCommits(org, repo, base, head string) (*github.CommitComparison, error)
 	GetRepo(owner, name string) (github.FullRepo, error)
 	Merge(string, string, int, github.MergeDetails) error
+	ListPullRequestCommits(org, repo string, number int) ([]github.RepositoryCommit, error)
 	QueryWithGitHubAppsSupport(ctx context.Context, q interface{}, vars map[string]interface{}, org string) error
 }
 
@@ -87,6 +90,11 @@ type syncController struct {
 	// changedFiles caches the names of files changed by PRs.
 	// Cache entries expire if they are not used during a sync loop.
 	changedFiles *changedFilesAgent
+	// poolEntries records when PRs entered the pool.
+	poolEntries *poolEntryTracker
+	// fairScheduler queues the tests pools trigger when fair scheduling
+	// is enabled.
+	fairScheduler *fairScheduler
 
 	History *history.History
 
@@ -106,15 +114,22 @@ const (
 	Merge        Action = "MERGE"
 	MergeBatch   Action = "MERGE_BATCH"
 	PoolBlocked  Action = "BLOCKED"
+	// InvalidateStale skips merging PRs whose results went stale because the
+	// base branch moved too far past the SHA they were tested against.
+	InvalidateStale Action = "INVALIDATE_STALE"
+	// Deferred waits with triggering tests because fair scheduling let the
+	// pools of other repos trigger theirs first.
+	Deferred Action = "DEFERRED"
 )
 
 // recordableActions is the subset of actions that we keep historical record of.
 // Ignore idle actions to avoid flooding the records with useless data.
 var recordableActions = map[Action]bool{
-	Trigger:      true,
-	TriggerBatch: true,
-	Merge:        true,
-	MergeBatch:   true,
+	Trigger:         true,
+	TriggerBatch:    true,
+	Merge:           true,
+	MergeBatch:      true,
+	InvalidateStale: true,
 }
 
 // Pool represents information about a tide pool. There is one for every
@@ -142,6 +157,13 @@ type Pool struct {
 
 	// All of the TenantIDs associated with PRs in the pool.
 	TenantIDs []string
+
+	// BlockingContexts are the contexts of the required presubmits that are
+	// missing or failing for each PR, by PR number.
+	BlockingContexts map[int][]string
+	// MergeOrder are the numbers of the PRs in the order they are projected
+	// to merge in.
+	MergeOrder []int
 }
 
 // PoolForDeck contains the same data as Pool, the only exception is that it has
@@ -170,6 +192,9 @@ type PoolForDeck struct {
 
 	// All of the TenantIDs associated with PRs in the pool.
 	TenantIDs []string
+
+	BlockingContexts map[int][]string `json:",omitempty"`
+	MergeOrder       []int            `json:",omitempty"`
 }
 
 func PoolToPoolForDeck(p *Pool) *PoolForDeck {
@@ -193,6 +218,9 @@ func PoolToPoolForDeck(p *Pool) *PoolForDeck {
 		Blockers:     p.Blockers,
 		Error:        p.Error,
 		TenantIDs:    p.TenantIDs,
+
+		BlockingContexts: p.BlockingContexts,
+		MergeOrder:       p.MergeOrder,
 	}
 	return pfd
 }
@@ -201,11 +229,20 @@ func PoolToPoolForDeck(p *Pool) *PoolForDeck {
 var (
 	tideMetrics = struct {
 		// Per pool
-		pooledPRs    *prometheus.GaugeVec
-		updateTime   *prometheus.GaugeVec
-		merges       *prometheus.HistogramVec
-		poolErrors   *prometheus.CounterVec
-		queryResults *prometheus.CounterVec
+		pooledPRs        *prometheus.GaugeVec
+		updateTime       *prometheus.GaugeVec
+		merges           *prometheus.HistogramVec
+		poolErrors       *prometheus.CounterVec
+		queryResults     *prometheus.CounterVec
+		queueDepth       *prometheus.GaugeVec
+		timeToMerge      *prometheus.HistogramVec
+		blockedPRs       *prometheus.CounterVec
+		staleResults     *prometheus.CounterVec
+		ownersUnapproved *prometheus.CounterVec
+
+		// Per repo
+		fairTriggers     *prometheus.CounterVec
+		fairTriggerShare *prometheus.GaugeVec
 
 		// Singleton
 		syncDuration         prometheus.Gauge
@@ -241,6 +278,71 @@ var (
 			"branch",
 		}),
 
+		queueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tidepoolqueuedepth",
+			Help: "Number of PRs in each Tide pool by state (success, pending, missing, batch_pending).",
+		}, []string{
+			"org",
+			"repo",
+			"branch",
+			"state",
+		}),
+
+		timeToMerge: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "tidetimetomerge",
+			Help:    "Histogram of seconds from a PR entering the Tide pool, which requires the merge labels such as lgtm, until Tide merged it.",
+			Buckets: []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, 86400, 172800, 604800},
+		}, []string{
+			"org",
+			"repo",
+			"branch",
+		}),
+
+		blockedPRs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tideblockedprs",
+			Help: "Count of Tide status updates marking a PR as not mergeable, by blocking reason.",
+		}, []string{
+			"org",
+			"repo",
+			"branch",
+			"reason",
+		}),
+
+		staleResults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tidestaleresults",
+			Help: "Count of merges skipped because the base branch moved past the tested SHA by more than max_base_drift.",
+		}, []string{
+			"org",
+			"repo",
+			"branch",
+		}),
+
+		ownersUnapproved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tideownersunapproved",
+			Help: "Count of merges skipped because the changes of a PR lacked the approval of their OWNERS.",
+		}, []string{
+			"org",
+			"repo",
+			"branch",
+		}),
+
+		fairTriggers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tidefairtriggers",
+			Help: "Count of pools that wanted to trigger tests with fair scheduling, by outcome (triggered, deferred).",
+		}, []string{
+			"org",
+			"repo",
+			"outcome",
+		}),
+
+		fairTriggerShare: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tidefairtriggershare",
+			Help: "Share of each repo of the last 100 pools fair scheduling triggered tests for.",
+		}, []string{
+			"org",
+			"repo",
+		}),
+
 		poolErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "tidepoolerrors",
 			Help: "Count of Tide pool sync errors.",
@@ -289,6 +391,13 @@ func init() {
 	prometheus.MustRegister(tideMetrics.syncHeartbeat)
 	prometheus.MustRegister(tideMetrics.poolErrors)
 	prometheus.MustRegister(tideMetrics.queryResults)
+	prometheus.MustRegister(tideMetrics.queueDepth)
+	prometheus.MustRegister(tideMetrics.timeToMerge)
+	prometheus.MustRegister(tideMetrics.blockedPRs)
+	prometheus.MustRegister(tideMetrics.staleResults)
+	prometheus.MustRegister(tideMetrics.ownersUnapproved)
+	prometheus.MustRegister(tideMetrics.fairTriggers)
+	prometheus.MustRegister(tideMetrics.fairTriggerShare)
 }
 
 type manager interface {
@@ -334,6 +443,7 @@ func NewController(
 	statusURI string,
 	logger *logrus.Entry,
 	usesGitHubAppsAuth bool,
+	pluginAgent *plugins.ConfigAgent,
 ) (*Controller, error) {
 	if logger == nil {
 		logger = logrus.NewEntry(logrus.StandardLogger())
@@ -359,6 +469,7 @@ func NewController(
 	go sc.run()
 
 	provider := newGitHubProvider(logger, ghcSync, gc, cfg, mergeChecker, usesGitHubAppsAuth)
+	provider.ownersApproval = newOwnersApproval(ghcSync, gc, cfg, pluginAgent)
 	syncCtrl, err := newSyncController(ctx, logger, mgr, provider, cfg, gc, hist, usesGitHubAppsAuth, statusUpdate)
 	if err != nil {
 		return nil, err
@@ -432,8 +543,10 @@ func newSyncController(
 			provider:        provider,
 			nextChangeCache: make(map[changeCacheKey][]string),
 		},
-		History:      hist,
-		statusUpdate: statusUpdate,
+		poolEntries:   &poolEntryTracker{nextEntered: map[string]time.Time{}},
+		fairScheduler: &fairScheduler{},
+		History:       hist,
+		statusUpdate:  statusUpdate,
 	}, nil
 }
 
@@ -481,6 +594,7 @@ func (c *syncController) Sync() error {
 		tideMetrics.syncHeartbeat.WithLabelValues("sync").Inc()
 	}()
 	defer c.changedFiles.prune()
+	defer c.poolEntries.prune()
 	c.config().BranchProtectionWarnings(c.logger, c.config().PresubmitsStatic)
 
 	c.logger.Debug("Building tide pool.")
@@ -508,6 +622,8 @@ func (c *syncController) Sync() error {
 		return err
 	}
 	filteredPools := c.filterSubpools(c.provider.isAllowedToMerge, rawPools)
+	fair := c.config().Tide.FairScheduling
+	c.fairScheduler.begin(fair != nil, time.Now())
 
 	// Notify statusController about the new pool.
 	c.statusUpdate.Lock()
@@ -546,6 +662,9 @@ func (c *syncController) Sync() error {
 	for pool := range poolChan {
 		pools = append(pools, pool)
 	}
+	if fair != nil {
+		c.scheduleTriggers(pools, fair)
+	}
 	sortPools(pools)
 	c.m.Lock()
 	c.pools = pools
@@ -867,6 +986,40 @@ func pickHighestPriorityPR(log *logrus.Entry, prs []CodeReviewCommon, cc map[int
 	return false, smallestPR
 }
 
+// projectMergeOrder returns the numbers of the PRs in the order they are
+// projected to merge in: the batch that passed or is being tested first, then
+// the passing PRs and then the pending ones, each in the order that
+// pickHighestPriorityPR picks them in. PRs that are missing tests are left
+// out, as they need to be tested again first.
+func projectMergeOrder(batch, successes, pendings []CodeReviewCommon, priorities []config.TidePriority) []int {
+	rank := func(pr CodeReviewCommon) int {
+		for i, p := range priorities {
+			if hasAllLabels(pr, p.Labels) {
+				return i
+			}
+		}
+		return len(priorities)
+	}
+	var order []int
+	seen := sets.New[int]()
+	for _, prs := range [][]CodeReviewCommon{batch, successes, pendings} {
+		prs = append([]CodeReviewCommon(nil), prs...)
+		sort.SliceStable(prs, func(i, j int) bool {
+			if ri, rj := rank(prs[i]), rank(prs[j]); ri != rj {
+				return ri < rj
+			}
+			return prs[i].Number < prs[j].Number
+		})
+		for _, pr := range prs {
+			if !seen.Has(pr.Number) {
+				seen.Insert(pr.Number)
+				order = append(order, pr.Number)
+			}
+		}
+	}
+	return order
+}
+
 // accumulateBatch looks at existing batch ProwJobs and, if applicable, returns:
 // * A list of PRs that are part of a batch test that finished successfully
 // * A list of PRs that are part of a batch test that hasn't finished yet but
@@ -1416,27 +1569,63 @@ func (c *syncController) nonFailedBatchForJobAndRefsExists(jobName string, refs
 func (c *syncController) takeAction(sp subpool, batchPending, successes, pendings, missings, batchMerges []CodeReviewCommon, missingSerialTests map[int][]config.Presubmit) (Action, []CodeReviewCommon, error) {
 	var merged []CodeReviewCommon
 	var err error
+	var batchBlocked bool
 	defer func() {
 		if len(merged) > 0 {
 			tideMetrics.merges.WithLabelValues(sp.org, sp.repo, sp.branch).Observe(float64(len(merged)))
 		}
+		now := time.Now()
+		for _, pr := range merged {
+			if waited, ok := c.poolEntries.timeInPool(&pr, now); ok {
+				tideMetrics.timeToMerge.WithLabelValues(sp.org, sp.repo, sp.branch).Observe(waited.Seconds())
+			}
+		}
 	}()
 
 	// Merge the batch!
 	if len(batchMerges) > 0 {
-		merged, err = c.provider.mergePRs(sp, batchMerges, c.statusUpdate.dontUpdateStatus)
-		return MergeBatch, batchMerges, err
+		unapproved, err := c.unapprovedByOwners(sp, batchMerges)
+		if err != nil {
+			return Wait, nil, err
+		}
+		if len(unapproved) == 0 {
+			if stale, err := c.baseIsStale(sp); err != nil {
+				return Wait, nil, err
+			} else if stale {
+				return InvalidateStale, batchMerges, nil
+			}
+			merged, err = c.provider.mergePRs(sp, batchMerges, c.statusUpdate.dontUpdateStatus)
+			return MergeBatch, batchMerges, err
+		}
+		// The batch waits for the approvals, the other PRs of the batch can
+		// still be merged on their own.
+		successes = withoutPRs(successes, unapproved)
+		batchBlocked = true
 	}
 	// Do not merge PRs while waiting for a batch to complete. We don't want to
 	// invalidate the old batch result.
-	if len(successes) > 0 && len(batchPending) == 0 {
-		if ok, pr := pickHighestPriorityPR(sp.log, successes, sp.cc, c.isPassingTests, c.config().Tide.Priority); ok {
-			merged, err = c.provider.mergePRs(sp, []CodeReviewCommon{pr}, c.statusUpdate.dontUpdateStatus)
-			return Merge, []CodeReviewCommon{pr}, err
+	for len(successes) > 0 && len(batchPending) == 0 {
+		ok, pr := pickHighestPriorityPR(sp.log, successes, sp.cc, c.isPassingTests, c.config().Tide.Priority)
+		if !ok {
+			break
 		}
+		if unapproved, err := c.unapprovedByOwners(sp, []CodeReviewCommon{pr}); err != nil {
+			return Wait, nil, err
+		} else if len(unapproved) > 0 {
+			successes = withoutPRs(successes, unapproved)
+			continue
+		}
+		if stale, err := c.baseIsStale(sp); err != nil {
+			return Wait, nil, err
+		} else if stale {
+			return InvalidateStale, []CodeReviewCommon{pr}, nil
+		}
+		merged, err = c.provider.mergePRs(sp, []CodeReviewCommon{pr}, c.statusUpdate.dontUpdateStatus)
+		return Merge, []CodeReviewCommon{pr}, err
 	}
-	// If no presubmits are configured, just wait.
-	if len(sp.presubmits) == 0 {
+	// If no presubmits are configured or the successful batch waits for
+	// approvals, just wait.
+	if len(sp.presubmits) == 0 || batchBlocked {
 		return Wait, nil, nil
 	}
 	// If we have no batch, trigger one.
@@ -1446,18 +1635,81 @@ func (c *syncController) takeAction(sp subpool, batchPending, successes, pending
 			return Wait, nil, err
 		}
 		if len(batch) > 1 {
-			return TriggerBatch, batch, c.trigger(sp, presubmits, batch)
+			return TriggerBatch, batch, c.requestTrigger(sp, TriggerBatch, presubmits, batch)
 		}
 	}
 	// If we have no serial jobs pending or successful, trigger one.
 	if len(missings) > 0 && len(pendings) == 0 && len(successes) == 0 {
 		if ok, pr := pickHighestPriorityPR(sp.log, missings, sp.cc, c.isRetestEligible, c.config().Tide.Priority); ok {
-			return Trigger, []CodeReviewCommon{pr}, c.trigger(sp, missingSerialTests[pr.Number], []CodeReviewCommon{pr})
+			return Trigger, []CodeReviewCommon{pr}, c.requestTrigger(sp, Trigger, missingSerialTests[pr.Number], []CodeReviewCommon{pr})
 		}
 	}
 	return Wait, nil, nil
 }
 
+// unapprovedByOwners returns the PRs whose changes lack the approval of their
+// OWNERS, if the repo of the subpool requires it. The approvals are computed
+// right before merging, so that the pushes since the approved label was added
+// are taken into account.
+func (c *syncController) unapprovedByOwners(sp subpool, prs []CodeReviewCommon) ([]CodeReviewCommon, error) {
+	if !c.config().Tide.RequireOwnersApproval(config.OrgRepo{Org: sp.org, Repo: sp.repo}) {
+		return nil, nil
+	}
+	var unapproved []CodeReviewCommon
+	for _, pr := range prs {
+		files, err := c.provider.unapprovedOwners(&pr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute the OWNERS approvals of PR #%d: %w", pr.Number, err)
+		}
+		if len(files) == 0 {
+			continue
+		}
+		sp.log.WithFields(pr.logFields()).WithField("unapproved-owners", files).Info("The changes of the PR lack the approval of their OWNERS, not merging it.")
+		tideMetrics.ownersUnapproved.WithLabelValues(sp.org, sp.repo, sp.branch).Inc()
+		unapproved = append(unapproved, pr)
+	}
+	return unapproved, nil
+}
+
+// withoutPRs returns the PRs that are not excluded.
+func withoutPRs(prs, excluded []CodeReviewCommon) []CodeReviewCommon {
+	skip := sets.New[string]()
+	for _, pr := range excluded {
+		skip.Insert(prKey(&pr))
+	}
+	var kept []CodeReviewCommon
+	for _, pr := range prs {
+		if !skip.Has(prKey(&pr)) {
+			kept = append(kept, pr)
+		}
+	}
+	return kept
+}
+
+// baseIsStale tells whether the branch of the subpool moved away from the SHA
+// its PRs were tested against by more than the max_base_drift of the repo since
+// the sync started. The results of stale subpools are not merged: the next sync
+// picks up the new base SHA, for which the results are missing, and retests.
+func (c *syncController) baseIsStale(sp subpool) (bool, error) {
+	maxDrift, ok := c.config().Tide.MaxBaseDrift(config.OrgRepo{Org: sp.org, Repo: sp.repo})
+	if !ok {
+		return false, nil
+	}
+	drift, err := c.provider.baseDrift(sp)
+	if err != nil {
+		return false, fmt.Errorf("failed to check whether the base branch moved: %w", err)
+	}
+	if drift <= maxDrift {
+		return false, nil
+	}
+	sp.log.WithFields(logrus.Fields{
+		"base-drift":     drift,
+		"max-base-drift": maxDrift,
+	}).Info("The base branch moved past the tested SHA, not merging the stale results.")
+	tideMetrics.staleResults.WithLabelValues(sp.org, sp.repo, sp.branch).Inc()
+	return true, nil
+}
+
 // changedFilesAgent queries and caches the names of files changed by PRs.
 // Cache entries expire if they are not used during a sync loop.
 type changedFilesAgent struct {
@@ -1469,6 +1721,65 @@ type changedFilesAgent struct {
 	sync.RWMutex
 }
 
+// poolEntryTracker records when PRs entered the pool, to measure how long
+// they waited to be merged. Entries expire once the PRs leave the pool.
+type poolEntryTracker struct {
+	entered map[string]time.Time
+	// nextEntered holds the entries of the PRs in the pool this sync.
+	// This becomes the new entered when prune() is called at the end of each sync.
+	nextEntered map[string]time.Time
+	// initialized is false until the first sync finished. PRs that were
+	// already in the pool when Tide started have an unknown entry time.
+	initialized bool
+	sync.Mutex
+}
+
+// enter records the PRs as being in the pool this sync.
+func (t *poolEntryTracker) enter(prs []CodeReviewCommon, now time.Time) {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	if t.nextEntered == nil {
+		t.nextEntered = map[string]time.Time{}
+	}
+	for _, pr := range prs {
+		key := prKey(&pr)
+		entered, ok := t.entered[key]
+		if !ok && t.initialized {
+			entered = now
+		}
+		t.nextEntered[key] = entered
+	}
+}
+
+// timeInPool returns how long the PR has been in the pool, if known.
+func (t *poolEntryTracker) timeInPool(pr *CodeReviewCommon, now time.Time) (time.Duration, bool) {
+	if t == nil {
+		return 0, false
+	}
+	t.Lock()
+	defer t.Unlock()
+	entered, ok := t.nextEntered[prKey(pr)]
+	if !ok || entered.IsZero() {
+		return 0, false
+	}
+	return now.Sub(entered), true
+}
+
+// prune forgets the PRs that were not in the pool this sync.
+func (t *poolEntryTracker) prune() {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.entered = t.nextEntered
+	t.nextEntered = make(map[string]time.Time, len(t.entered))
+	t.initialized = true
+}
+
 type changeCacheKey struct {
 	org, repo string
 	number    int
@@ -1653,6 +1964,7 @@ func (c *syncController) presubmitsForBatch(prs []CodeReviewCommon, org, repo, b
 
 func (c *syncController) syncSubpool(sp subpool, blocks []blockers.Blocker) (Pool, error) {
 	sp.log.WithField("num_prs", len(sp.prs)).WithField("num_prowjobs", len(sp.pjs)).Info("Syncing subpool")
+	c.poolEntries.enter(sp.prs, time.Now())
 	successes, pendings, missings, missingSerialTests := c.accumulate(sp.presubmits, sp.prs, sp.pjs, sp.sha)
 	batchMerge, batchPending := c.accumulateBatch(sp)
 	sp.log.WithFields(logrus.Fields{
@@ -1675,7 +1987,9 @@ func (c *syncController) syncSubpool(sp subpool, blocks []blockers.Blocker) (Poo
 		if err != nil {
 			errorString = err.Error()
 		}
-		if recordableActions[act] {
+		// Queued triggers are recorded once they are scheduled.
+		queued := (act == Trigger || act == TriggerBatch) && c.fairScheduler.queues()
+		if recordableActions[act] && !queued {
 			c.History.Record(
 				poolKey(sp.org, sp.repo, sp.branch),
 				string(act),
@@ -1691,8 +2005,36 @@ func (c *syncController) syncSubpool(sp subpool, blocks []blockers.Blocker) (Poo
 		"action":  string(act),
 		"targets": prNumbers(targets),
 	}).Info("Subpool synced.")
+
+	var blockingContexts map[int][]string
+	for number, presubmits := range missingSerialTests {
+		if len(presubmits) == 0 {
+			continue
+		}
+		if blockingContexts == nil {
+			blockingContexts = map[int][]string{}
+		}
+		for _, ps := range presubmits {
+			blockingContexts[number] = append(blockingContexts[number], ps.Context)
+		}
+		sort.Strings(blockingContexts[number])
+	}
+	batch := batchMerge
+	if len(batch) == 0 {
+		batch = batchPending
+	}
+	mergeOrder := projectMergeOrder(batch, successes, pendings, c.config().Tide.Priority)
+
 	tideMetrics.pooledPRs.WithLabelValues(sp.org, sp.repo, sp.branch).Set(float64(len(sp.prs)))
 	tideMetrics.updateTime.WithLabelValues(sp.org, sp.repo, sp.branch).Set(float64(time.Now().Unix()))
+	for state, prs := range map[string][]CodeReviewCommon{
+		"success":       successes,
+		"pending":       pendings,
+		"missing":       missings,
+		"batch_pending": batchPending,
+	} {
+		tideMetrics.queueDepth.WithLabelValues(sp.org, sp.repo, sp.branch, state).Set(float64(len(prs)))
+	}
 	return Pool{
 			Org:    sp.org,
 			Repo:   sp.repo,
@@ -1710,6 +2052,9 @@ func (c *syncController) syncSubpool(sp subpool, blocks []blockers.Blocker) (Poo
 			Error:    errorString,
 
 			TenantIDs: tenantIDs,
+
+			BlockingContexts: blockingContexts,
+			MergeOrder:       mergeOrder,
 		},
 		err
 }
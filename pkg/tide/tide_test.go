@@ -55,7 +55,12 @@ import (
 	"sigs.k8s.io/prow/pkg/git/types"
 	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
 	"sigs.k8s.io/prow/pkg/kube"
+	"sigs.k8s.io/prow/pkg/layeredsets"
+	"sigs.k8s.io/prow/pkg/plugins"
+	"sigs.k8s.io/prow/pkg/plugins/ownersconfig"
+	"sigs.k8s.io/prow/pkg/repoowners"
 	"sigs.k8s.io/prow/pkg/testutil"
 	"sigs.k8s.io/prow/pkg/tide/history"
 )
@@ -708,10 +713,15 @@ type fgc struct {
 	mergeErrs  map[int]error
 	queryCalls int
 
+	// baseDrift is the number of commits branches moved past the SHAs they
+	// are compared with.
+	baseDrift int
+
 	expectedSHA          string
 	skipExpectedShaCheck bool
 	combinedStatus       map[string]string
 	checkRuns            *github.CheckRunList
+	prCommits            map[int][]github.RepositoryCommit
 }
 
 func (f *fgc) GetRepo(o, r string) (github.FullRepo, error) {
@@ -732,6 +742,10 @@ func (f *fgc) GetRef(o, r, ref string) (string, error) {
 	return f.refs[o+"/"+r+" "+ref], f.err
 }
 
+func (f *fgc) CompareCommits(o, r, base, head string) (*github.CommitComparison, error) {
+	return &github.CommitComparison{Status: "ahead", AheadBy: f.baseDrift}, f.err
+}
+
 func (f *fgc) QueryWithGitHubAppsSupport(ctx context.Context, q interface{}, vars map[string]interface{}, org string) error {
 	sq, ok := q.(*searchQuery)
 	if !ok {
@@ -761,6 +775,13 @@ func (f *fgc) Merge(org, repo string, number int, details github.MergeDetails) e
 	return nil
 }
 
+func (f *fgc) ListPullRequestCommits(org, repo string, number int) ([]github.RepositoryCommit, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.prCommits[number], nil
+}
+
 func (f *fgc) CreateStatus(org, repo, ref string, s github.Status) error {
 	f.lock.Lock()
 	defer f.lock.Unlock()
@@ -812,6 +833,63 @@ func (f *fgc) GetPullRequestChanges(org, repo string, number int) ([]github.Pull
 		nil
 }
 
+// fakeOwnersClient loads OWNERS that require the approval of "approver" for
+// every file.
+type fakeOwnersClient struct {
+	repoowners.Interface
+}
+
+func (fakeOwnersClient) LoadRepoOwners(org, repo, base string) (repoowners.RepoOwner, error) {
+	return fakeRepoOwners{}, nil
+}
+
+type fakeRepoOwners struct {
+	repoowners.RepoOwner
+}
+
+func (fakeRepoOwners) Approvers(path string) layeredsets.String {
+	return layeredsets.NewString("approver")
+}
+
+func (fakeRepoOwners) LeafApprovers(path string) sets.Set[string] {
+	return sets.New[string]("approver")
+}
+
+func (fakeRepoOwners) FindApproverOwnersForFile(path string) string {
+	return "a"
+}
+
+func (fakeRepoOwners) IsNoParentOwners(path string) bool {
+	return false
+}
+
+func (fakeRepoOwners) IsAutoApproveUnownedSubfolders(directory string) bool {
+	return false
+}
+
+func (fakeRepoOwners) Filenames() ownersconfig.Filenames {
+	return ownersconfig.FakeFilenames
+}
+
+// newFakeOwnersApproval changes a file of every PR, approved by its OWNERS
+// unless the PR is unapproved.
+func newFakeOwnersApproval(prs []int, unapproved sets.Set[int]) *ownersApproval {
+	ghc := fakegithub.NewFakeClient()
+	ghc.PullRequestChanges = map[int][]github.PullRequestChange{}
+	ghc.IssueComments = map[int][]github.IssueComment{}
+	for _, number := range prs {
+		ghc.PullRequestChanges[number] = []github.PullRequestChange{{Filename: "a/a.go"}}
+		if !unapproved.Has(number) {
+			ghc.IssueComments[number] = []github.IssueComment{{User: github.User{Login: "approver"}, Body: "/approve"}}
+		}
+	}
+	return &ownersApproval{
+		ghc:          ghc,
+		ownersClient: fakeOwnersClient{},
+		pluginConfig: func() *plugins.Configuration { return &plugins.Configuration{} },
+	}
+}
+
 // TestDividePool ensures that subpools returned by dividePool satisfy a few
 // important invariants.
 func TestDividePool(t *testing.T) {
@@ -1428,6 +1506,10 @@ func testTakeAction(clients localgit.Clients, t *testing.T) {
 		preExistingJobs  []runtime.Object
 		mergeErrs        map[int]error
 		enableScheduling bool
+		maxBaseDrift     map[string]int
+		baseDrift        int
+		requireApproval  map[string]bool
+		unapproved       []int
 
 		merged           int
 		triggered        int
@@ -1843,6 +1925,84 @@ func testTakeAction(clients localgit.Clients, t *testing.T) {
 			action:           Trigger,
 			enableScheduling: true,
 		},
+		{
+			name: "batch merges when the base branch moved less than max_base_drift",
+
+			batchMerges:  []int{1, 2, 3},
+			maxBaseDrift: map[string]int{"o/r": 2},
+			baseDrift:    2,
+			merged:       3,
+			triggered:    0,
+			action:       MergeBatch,
+		},
+		{
+			name: "batch is not merged when the base branch moved more than max_base_drift",
+
+			batchMerges:  []int{1, 2, 3},
+			maxBaseDrift: map[string]int{"o/r": 2},
+			baseDrift:    3,
+			merged:       0,
+			triggered:    0,
+			action:       InvalidateStale,
+		},
+		{
+			name: "passing PR is not merged when the base branch moved more than the global max_base_drift",
+
+			successes:    []int{1},
+			pendings:     []int{},
+			nones:        []int{},
+			batchMerges:  []int{},
+			maxBaseDrift: map[string]int{"*": 0},
+			baseDrift:    1,
+			merged:       0,
+			triggered:    0,
+			action:       InvalidateStale,
+		},
+		{
+			name: "batch lacking OWNERS approval is merged when it is not required",
+
+			batchMerges: []int{1, 2, 3},
+			unapproved:  []int{2},
+			merged:      3,
+			triggered:   0,
+			action:      MergeBatch,
+		},
+		{
+			name: "batch with a PR lacking OWNERS approval waits",
+
+			batchMerges:     []int{1, 2, 3},
+			requireApproval: map[string]bool{"o/r": true},
+			unapproved:      []int{2},
+			merged:          0,
+			triggered:       0,
+			action:          Wait,
+		},
+		{
+			name: "passing PR lacking OWNERS approval is skipped for the next one",
+
+			successes:       []int{1, 2},
+			pendings:        []int{},
+			nones:           []int{},
+			batchMerges:     []int{},
+			requireApproval: map[string]bool{"*": true},
+			unapproved:      []int{1},
+			merged:          1,
+			triggered:       0,
+			action:          Merge,
+		},
+		{
+			name: "no passing PR has the approval of its OWNERS, should wait",
+
+			successes:       []int{1},
+			pendings:        []int{},
+			nones:           []int{},
+			batchMerges:     []int{},
+			requireApproval: map[string]bool{"o": true},
+			unapproved:      []int{1},
+			merged:          0,
+			triggered:       0,
+			action:          Wait,
+		},
 	}
 
 	for _, tc := range testcases {
@@ -1855,6 +2015,8 @@ func testTakeAction(clients localgit.Clients, t *testing.T) {
 					Scheduler:        config.Scheduler{Enabled: tc.enableScheduling},
 				},
 			}
+			cfg.Tide.MaxBaseDriftMap = tc.maxBaseDrift
+			cfg.Tide.RequireOwnersApprovalMap = tc.requireApproval
 			if err := cfg.SetPresubmits(
 				map[string][]config.Presubmit{
 					"o/r": {
@@ -1957,9 +2119,10 @@ func testTakeAction(clients localgit.Clients, t *testing.T) {
 				}
 				return prs
 			}
-			fgc := fgc{mergeErrs: tc.mergeErrs}
+			fgc := fgc{mergeErrs: tc.mergeErrs, baseDrift: tc.baseDrift}
 			log := logrus.WithField("controller", "tide")
 			ghProvider := newGitHubProvider(log, &fgc, gc, ca.Config, nil, false)
+			ghProvider.ownersApproval = newFakeOwnersApproval(append(tc.successes, tc.batchMerges...), sets.New[int](tc.unapproved...))
 			ctx := context.Background()
 			mgr := newFakeManager(t, ctx, tc.preExistingJobs...)
 			c, err := newSyncController(
@@ -2158,6 +2321,7 @@ func TestSync(t *testing.T) {
 				Action:     Merge,
 				Target:     []CodeReviewCommon{*CodeReviewCommonFromPullRequest(&mergeableA)},
 				TenantIDs:  []string{},
+				MergeOrder: []int{int(mergeableA.Number)},
 			}},
 		},
 		{
@@ -2176,6 +2340,7 @@ func TestSync(t *testing.T) {
 				Action:     Merge,
 				Target:     []CodeReviewCommon{*CodeReviewCommonFromPullRequest(&unknownA)},
 				TenantIDs:  []string{},
+				MergeOrder: []int{int(unknownA.Number)},
 			}},
 		},
 		{
@@ -2189,6 +2354,7 @@ func TestSync(t *testing.T) {
 				Action:     Merge,
 				Target:     []CodeReviewCommon{*CodeReviewCommonFromPullRequest(&mergeableA)},
 				TenantIDs:  []string{},
+				MergeOrder: []int{int(mergeableA.Number)},
 			}},
 		},
 		{
@@ -2202,6 +2368,7 @@ func TestSync(t *testing.T) {
 				Action:     Merge,
 				Target:     []CodeReviewCommon{*CodeReviewCommonFromPullRequest(&mergeableA)},
 				TenantIDs:  []string{},
+				MergeOrder: []int{int(mergeableA.Number)},
 			}},
 		},
 		{
@@ -2215,6 +2382,7 @@ func TestSync(t *testing.T) {
 				Action:     Merge,
 				Target:     []CodeReviewCommon{*CodeReviewCommonFromPullRequest(&mergeableA)},
 				TenantIDs:  []string{},
+				MergeOrder: []int{int(mergeableA.Number)},
 			}},
 		},
 	}
@@ -4400,6 +4568,58 @@ func TestPickSmallestPassingNumber(t *testing.T) {
 	}
 }
 
+func TestProjectMergeOrder(t *testing.T) {
+	priorities := []config.TidePriority{
+		{Labels: []string{"kind/failing-test"}},
+	}
+	pr := func(num int, labels ...string) CodeReviewCommon {
+		return *CodeReviewCommonFromPullRequest(testPRWithLabels("org", "repo", "A", num, githubql.MergeableStateMergeable, labels))
+	}
+	testCases := []struct {
+		name      string
+		batch     []CodeReviewCommon
+		successes []CodeReviewCommon
+		pendings  []CodeReviewCommon
+		expected  []int
+	}{
+		{
+			name: "nothing to merge",
+		},
+		{
+			name:      "successes ordered by number",
+			successes: []CodeReviewCommon{pr(5), pr(3), pr(4)},
+			expected:  []int{3, 4, 5},
+		},
+		{
+			name:      "priority labels come first",
+			successes: []CodeReviewCommon{pr(5), pr(3), pr(7, "kind/failing-test")},
+			expected:  []int{7, 3, 5},
+		},
+		{
+			name:      "batch before successes before pendings",
+			batch:     []CodeReviewCommon{pr(9), pr(8)},
+			successes: []CodeReviewCommon{pr(2)},
+			pendings:  []CodeReviewCommon{pr(1)},
+			expected:  []int{8, 9, 2, 1},
+		},
+		{
+			name:      "PRs are listed once",
+			batch:     []CodeReviewCommon{pr(3), pr(4)},
+			successes: []CodeReviewCommon{pr(4), pr(1)},
+			pendings:  []CodeReviewCommon{pr(3), pr(2)},
+			expected:  []int{3, 4, 1, 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := projectMergeOrder(tc.batch, tc.successes, tc.pendings, priorities)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("merge order differs from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestQueryShardsByOrgWhenAppsAuthIsEnabledOnly(t *testing.T) {
 	t.Parallel()
 
@@ -5310,3 +5530,39 @@ func TestSerialRetestingConsidersPRThatIsCurrentlyBeingSRetested(t *testing.T) {
 	}
 
 }
+
+func TestPoolEntryTracker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pr := func(number int) *CodeReviewCommon {
+		return &CodeReviewCommon{NameWithOwner: "org/repo", Org: "org", Repo: "repo", Number: number}
+	}
+	tracker := &poolEntryTracker{}
+
+	// PRs already in the pool when Tide starts have an unknown entry time.
+	tracker.enter([]CodeReviewCommon{*pr(1)}, start)
+	if _, ok := tracker.timeInPool(pr(1), start.Add(time.Minute)); ok {
+		t.Error("expected unknown time in pool for PR that was in the pool at startup")
+	}
+	tracker.prune()
+
+	tracker.enter([]CodeReviewCommon{*pr(1), *pr(2)}, start.Add(time.Minute))
+	tracker.prune()
+	tracker.enter([]CodeReviewCommon{*pr(2)}, start.Add(2*time.Minute))
+	if waited, ok := tracker.timeInPool(pr(2), start.Add(5*time.Minute)); !ok || waited != 4*time.Minute {
+		t.Errorf("expected PR to be in the pool for 4m, got %v (known: %t)", waited, ok)
+	}
+	tracker.prune()
+
+	// PRs leaving the pool are forgotten and get a new entry time when they return.
+	tracker.enter([]CodeReviewCommon{*pr(1)}, start.Add(10*time.Minute))
+	if waited, ok := tracker.timeInPool(pr(1), start.Add(11*time.Minute)); !ok || waited != time.Minute {
+		t.Errorf("expected PR to be in the pool for 1m, got %v (known: %t)", waited, ok)
+	}
+
+	var nilTracker *poolEntryTracker
+	nilTracker.enter([]CodeReviewCommon{*pr(1)}, start)
+	if _, ok := nilTracker.timeInPool(pr(1), start); ok {
+		t.Error("expected nil tracker to know no entry times")
+	}
+	nilTracker.prune()
+}
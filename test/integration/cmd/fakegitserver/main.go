@@ -39,6 +39,7 @@ type options struct {
 	portHttps         int
 	gitBinary         string
 	gitReposParentDir string
+	repoBaseURL       string
 	cert              string
 	key               string
 }
@@ -54,6 +55,7 @@ func flagOptions() *options {
 	flag.IntVar(&o.portHttps, "port-https", 4443, "Port to listen on for HTTPS traffic.")
 	flag.StringVar(&o.gitBinary, "git-binary", "/usr/bin/git", "Path to the `git` binary.")
 	flag.StringVar(&o.gitReposParentDir, "git-repos-parent-dir", "/git-repo", "Path to the parent folder containing all Git repos to serve over HTTP.")
+	flag.StringVar(&o.repoBaseURL, "repo-base-url", "http://fakegitserver.default/repo", "URL the repos are served under in the cluster, which submodules point to by default.")
 	flag.StringVar(&o.cert, "cert", "", "Path to the server cert file for HTTPS.")
 	flag.StringVar(&o.key, "key", "", "Path to the server key file for HTTPS.")
 	return o
@@ -78,11 +80,14 @@ func main() {
 	// we can have other paths (if necessary) to take in custom commands from
 	// integration tests (e.g., "/admin/reset" to reset all repos back to their
 	// original state).
+	// Git LFS derives the URL of its API from the URL of the repo, so route
+	// the requests to it before they reach git-http-backend.
+	r.PathPrefix("/repo").MatcherFunc(fakegitserver.IsLFSRequest).Handler(fakegitserver.LFSHandler(o.gitReposParentDir))
 	r.PathPrefix("/repo").Handler(fakegitserver.GitCGIHandler(o.gitBinary, o.gitReposParentDir))
 	// Set up repo might modify global git config, need to lock it to avoid
 	// errors caused by concurrent modifications.
 	var lock sync.Mutex
-	r.PathPrefix("/setup-repo").Handler(fakegitserver.SetupRepoHandler(o.gitReposParentDir, &lock))
+	r.PathPrefix("/setup-repo").Handler(fakegitserver.SetupRepoHandler(o.gitReposParentDir, o.repoBaseURL, &lock))
 
 	if err := os.MkdirAll(o.gitReposParentDir, os.ModePerm); err != nil {
 		logrus.Fatalf("could not create directory %q", o.gitReposParentDir)
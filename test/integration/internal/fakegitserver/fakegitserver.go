@@ -27,6 +27,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -52,8 +54,38 @@ type RepoSetup struct {
 	// ".git") even if a file (directory) exists there already. This basically
 	// does a 'rm -rf' of the folder first.
 	Overwrite bool `json:"overwrite"`
+	// Submodules to add to the repo after running Script. The repos they
+	// point to must be set up first.
+	Submodules []Submodule `json:"submodules,omitempty"`
+	// LFSFiles maps the paths of files to add to the repo with Git LFS after
+	// running Script to their contents. Their objects are served by the Git
+	// LFS API of the repo.
+	LFSFiles map[string]string `json:"lfs_files,omitempty"`
+	// RequiredCookie, e.g. "session=secret", is a cookie that requests for
+	// the repo must send, as clients authenticating with an http.cookiefile
+	// do. The repo can be read by anyone if it is empty.
+	RequiredCookie string `json:"required_cookie,omitempty"`
 }
 
+// Submodule is a submodule of a repo that points to another repo of the
+// server.
+type Submodule struct {
+	// Repo is the name of the repo of the submodule.
+	Repo string `json:"repo"`
+	// Path is where the submodule is checked out. Defaults to Repo.
+	Path string `json:"path,omitempty"`
+	// URL the submodule is cloned from. Defaults to the URL of Repo on the
+	// server.
+	URL string `json:"url,omitempty"`
+}
+
+// requiredCookieFile is the file of a bare repo that holds its RequiredCookie.
+const requiredCookieFile = "fakegitserver-required-cookie"
+
+// gitRepoPathRe matches the paths git-http-backend serves, capturing the name
+// of their repo.
+var gitRepoPathRe = regexp.MustCompile(`^/(.+?)(?:\.git)?/(?:info/|objects/|git-upload-pack$|git-receive-pack$|HEAD$)`)
+
 func NewClient(host string, timeout time.Duration) *Client {
 	return &Client{
 		host: host,
@@ -78,6 +110,17 @@ func (c *Client) do(method, endpoint string, payload []byte, params map[string]s
 	return c.httpClient.Do(req)
 }
 
+// SetupRepos sets up repos in order, so that repos can have the ones before
+// them as submodules.
+func (c *Client) SetupRepos(repoSetups ...RepoSetup) error {
+	for _, repoSetup := range repoSetups {
+		if err := c.SetupRepo(repoSetup); err != nil {
+			return fmt.Errorf("failed to set up repo %s: %w", repoSetup.Name, err)
+		}
+	}
+	return nil
+}
+
 // SetupRepo sends a POST request with the RepoSetup contents.
 func (c *Client) SetupRepo(repoSetup RepoSetup) error {
 	buf, err := json.Marshal(repoSetup)
@@ -117,13 +160,30 @@ func GitCGIHandler(gitBinary, gitReposParentDir string) http.Handler {
 		// It appears that this RequestURI field is not used; but for
 		// completeness trim the prefix here as well.
 		req.RequestURI = strings.TrimPrefix(req.RequestURI, "/repo")
+		if match := gitRepoPathRe.FindStringSubmatch(req.URL.Path); match != nil && !authorized(gitReposParentDir, match[1], req) {
+			http.Error(w, "missing or wrong cookie", http.StatusUnauthorized)
+			return
+		}
 		h.ServeHTTP(w, req)
 	})
 }
 
+// authorized returns whether a request sends the cookie a repo requires, if
+// any.
+func authorized(gitReposParentDir, repo string, req *http.Request) bool {
+	required, err := os.ReadFile(filepath.Join(gitReposParentDir, repo+".git", requiredCookieFile))
+	if err != nil {
+		return true
+	}
+	name, value, _ := strings.Cut(string(required), "=")
+	cookie, err := req.Cookie(name)
+	return err == nil && cookie.Value == value
+}
+
 // SetupRepoHandler executes a JSON payload of instructions to set up a Git
-// repo.
-func SetupRepoHandler(gitReposParentDir string, mux *sync.Mutex) http.Handler {
+// repo. Submodules without a URL point to repoBaseURL followed by the name of
+// their repo.
+func SetupRepoHandler(gitReposParentDir, repoBaseURL string, mux *sync.Mutex) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		buf, err := io.ReadAll(req.Body)
 		defer req.Body.Close()
@@ -146,7 +206,7 @@ func SetupRepoHandler(gitReposParentDir string, mux *sync.Mutex) http.Handler {
 		// modifications of which could result in error like "exit status 255
 		// error: could not lock config file /root/.gitconfig". Use a mux to
 		// avoid this
-		repo, err := setupRepo(gitReposParentDir, &repoSetup, mux)
+		repo, err := setupRepo(gitReposParentDir, repoBaseURL, &repoSetup, mux)
 		if err != nil {
 			// Just log the error if the setup fails so that the developer can
 			// fix their error and retry without having to restart this server.
@@ -165,7 +225,7 @@ func SetupRepoHandler(gitReposParentDir string, mux *sync.Mutex) http.Handler {
 	})
 }
 
-func setupRepo(gitReposParentDir string, repoSetup *RepoSetup, mux *sync.Mutex) (*git.Repository, error) {
+func setupRepo(gitReposParentDir, repoBaseURL string, repoSetup *RepoSetup, mux *sync.Mutex) (*git.Repository, error) {
 	dir := filepath.Join(gitReposParentDir, repoSetup.Name+".git")
 	logger := logrus.WithField("directory", dir)
 
@@ -206,11 +266,23 @@ func setupRepo(gitReposParentDir string, repoSetup *RepoSetup, mux *sync.Mutex)
 
 	logger.Infof("successfully ran setup script in %s", dir)
 
+	if err := addFixtures(gitReposParentDir, repoBaseURL, dir, repoSetup); err != nil {
+		logger.Error("adding submodules and LFS files failed")
+		return nil, err
+	}
+
 	if err := convertToBareRepo(repo, dir); err != nil {
 		logger.Error("conversion to bare repo failed")
 		return nil, err
 	}
 
+	if repoSetup.RequiredCookie != "" {
+		if err := os.WriteFile(filepath.Join(dir, requiredCookieFile), []byte(repoSetup.RequiredCookie), 0644); err != nil {
+			logger.Error("could not write the required cookie")
+			return nil, err
+		}
+	}
+
 	repo, err = git.PlainOpen(dir)
 	if err != nil {
 		logger.Error("could not reopen repo")
@@ -275,6 +347,103 @@ func convertToBareRepo(repo *git.Repository, repoPath string) error {
 	return nil
 }
 
+// addFixtures adds the submodules and LFS files of a repo and commits them.
+// Submodules are added without cloning them, so that repos requiring a cookie
+// can be submodules too.
+func addFixtures(gitReposParentDir, repoBaseURL, repoPath string, repoSetup *RepoSetup) error {
+	if len(repoSetup.Submodules) == 0 && len(repoSetup.LFSFiles) == 0 {
+		return nil
+	}
+	var paths []string
+
+	var attributes []string
+	for path, content := range repoSetup.LFSFiles {
+		pointer, err := lfsPointer(filepath.Join(repoPath, ".git"), content)
+		if err != nil {
+			return fmt.Errorf("could not store the LFS object of %s: %w", path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(repoPath, path)), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(repoPath, path), []byte(pointer), 0644); err != nil {
+			return err
+		}
+		attributes = append(attributes, "/"+path+" filter=lfs diff=lfs merge=lfs -text\n")
+		paths = append(paths, path)
+	}
+	if len(attributes) > 0 {
+		sort.Strings(attributes)
+		f, err := os.OpenFile(filepath.Join(repoPath, ".gitattributes"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(strings.Join(attributes, ""))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		paths = append(paths, ".gitattributes")
+	}
+
+	for _, submodule := range repoSetup.Submodules {
+		path, url := submodule.Path, submodule.URL
+		if path == "" {
+			path = submodule.Repo
+		}
+		if url == "" {
+			url = strings.TrimSuffix(repoBaseURL, "/") + "/" + submodule.Repo
+		}
+		sub, err := git.PlainOpen(filepath.Join(gitReposParentDir, submodule.Repo+".git"))
+		if err != nil {
+			return fmt.Errorf("could not open the repo of submodule %s: %w", path, err)
+		}
+		head, err := sub.Head()
+		if err != nil {
+			return fmt.Errorf("could not get the HEAD of submodule %s: %w", path, err)
+		}
+		for _, args := range [][]string{
+			{"config", "-f", ".gitmodules", "submodule." + path + ".path", path},
+			{"config", "-f", ".gitmodules", "submodule." + path + ".url", url},
+			{"update-index", "--add", "--cacheinfo", "160000," + head.Hash().String() + "," + path},
+		} {
+			if err := runGit(repoPath, args...); err != nil {
+				return err
+			}
+		}
+	}
+	if len(repoSetup.Submodules) > 0 {
+		paths = append(paths, ".gitmodules")
+	}
+
+	sort.Strings(paths)
+	if err := runGit(repoPath, append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	return runGit(repoPath, "commit", "-m", "add submodules and LFS files")
+}
+
+// runGit runs git in a repo with the environment of the setup script.
+func runGit(repoPath string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	cmd.Env = gitEnv
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, out)
+	}
+	return nil
+}
+
+// gitEnv makes the git commands of the setup result in reproducible commits.
+var gitEnv = []string{
+	"GIT_AUTHOR_NAME=abc",
+	"GIT_AUTHOR_EMAIL=d@e.f",
+	"GIT_AUTHOR_DATE='Thu May 19 12:34:56 2022 +0000'",
+	"GIT_COMMITTER_NAME=abc",
+	"GIT_COMMITTER_EMAIL=d@e.f",
+	"GIT_COMMITTER_DATE='Thu May 19 12:34:56 2022 +0000'"}
+
 func runSetupScript(repoPath, script string) error {
 	// Catch errors in the script.
 	script = "set -eu;" + script
@@ -290,13 +459,7 @@ func runSetupScript(repoPath, script string) error {
 	// By default, make it so that the git commands contained in the script
 	// result in reproducible commits. This can be overridden by the script
 	// itself if it chooses to (re-)export the same environment variables.
-	cmd.Env = []string{
-		"GIT_AUTHOR_NAME=abc",
-		"GIT_AUTHOR_EMAIL=d@e.f",
-		"GIT_AUTHOR_DATE='Thu May 19 12:34:56 2022 +0000'",
-		"GIT_COMMITTER_NAME=abc",
-		"GIT_COMMITTER_EMAIL=d@e.f",
-		"GIT_COMMITTER_DATE='Thu May 19 12:34:56 2022 +0000'"}
+	cmd.Env = gitEnv
 
 	return cmd.Run()
 }
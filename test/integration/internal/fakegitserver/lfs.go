@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegitserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// lfsContentType is the media type of the requests and responses of the Git
+// LFS API.
+const lfsContentType = "application/vnd.git-lfs+json"
+
+// lfsPathRe matches the paths of the Git LFS API of a repo, which Git LFS
+// derives from its remote URL, e.g. /repo/foo.git/info/lfs/objects/batch for
+// http://fakegitserver.default/repo/foo.
+var lfsPathRe = regexp.MustCompile(`^/repo/(.+?)(?:\.git)?/info/lfs/objects/(batch|[0-9a-f]{64})$`)
+
+var oidRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type lfsObject struct {
+	OID     string               `json:"oid"`
+	Size    int64                `json:"size"`
+	Actions map[string]lfsAction `json:"actions,omitempty"`
+	Error   *lfsError            `json:"error,omitempty"`
+}
+
+type lfsAction struct {
+	Href string `json:"href"`
+}
+
+type lfsError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lfsBatchRequest struct {
+	Operation string      `json:"operation"`
+	Objects   []lfsObject `json:"objects"`
+}
+
+type lfsBatchResponse struct {
+	Transfer string      `json:"transfer"`
+	Objects  []lfsObject `json:"objects"`
+}
+
+// IsLFSRequest matches the requests to the Git LFS API of the repos, so that
+// they can be routed to the LFSHandler instead of git-http-backend.
+func IsLFSRequest(req *http.Request, _ *mux.RouteMatch) bool {
+	return lfsPathRe.MatchString(req.URL.Path)
+}
+
+// LFSHandler serves the objects of the repos that are stored with Git LFS,
+// using the basic transfer adapter of the batch API. See
+// https://github.com/git-lfs/git-lfs/blob/main/docs/api/batch.md.
+func LFSHandler(gitReposParentDir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		match := lfsPathRe.FindStringSubmatch(req.URL.Path)
+		if match == nil {
+			http.NotFound(w, req)
+			return
+		}
+		repo := match[1]
+		if !authorized(gitReposParentDir, repo, req) {
+			http.Error(w, "missing or wrong cookie", http.StatusUnauthorized)
+			return
+		}
+		dir := filepath.Join(gitReposParentDir, repo+".git")
+		if match[2] == "batch" {
+			serveLFSBatch(w, req, dir)
+			return
+		}
+		path := lfsObjectPath(dir, match[2])
+		switch req.Method {
+		case http.MethodGet:
+			http.ServeFile(w, req, path)
+		case http.MethodPut:
+			if err := storeLFSObject(path, match[2], req.Body); err != nil {
+				logrus.WithError(err).Error("failed to store LFS object")
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func serveLFSBatch(w http.ResponseWriter, req *http.Request, dir string) {
+	var batch lfsBatchRequest
+	if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if batch.Operation != "download" && batch.Operation != "upload" {
+		http.Error(w, fmt.Sprintf("unknown operation %q", batch.Operation), http.StatusUnprocessableEntity)
+		return
+	}
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	objects := strings.TrimSuffix(req.URL.Path, "batch")
+	resp := lfsBatchResponse{Transfer: "basic"}
+	for _, obj := range batch.Objects {
+		result := lfsObject{OID: obj.OID, Size: obj.Size}
+		if !oidRe.MatchString(obj.OID) {
+			result.Error = &lfsError{Code: http.StatusUnprocessableEntity, Message: "invalid oid"}
+			resp.Objects = append(resp.Objects, result)
+			continue
+		}
+		action := lfsAction{Href: fmt.Sprintf("%s://%s%s%s", scheme, req.Host, objects, obj.OID)}
+		info, err := os.Stat(lfsObjectPath(dir, obj.OID))
+		exists := err == nil && info.Size() == obj.Size
+		switch {
+		case batch.Operation == "download" && !exists:
+			result.Error = &lfsError{Code: http.StatusNotFound, Message: "object does not exist"}
+		case batch.Operation == "download":
+			result.Actions = map[string]lfsAction{"download": action}
+		case !exists:
+			// Objects that are already stored need not be uploaded.
+			result.Actions = map[string]lfsAction{"upload": action}
+		}
+		resp.Objects = append(resp.Objects, result)
+	}
+	w.Header().Set("Content-Type", lfsContentType)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.WithError(err).Error("failed to write LFS batch response")
+	}
+}
+
+// lfsObjectPath returns where an object is stored in a bare repo, which is
+// the layout Git LFS uses itself.
+func lfsObjectPath(repoDir, oid string) string {
+	return filepath.Join(repoDir, "lfs", "objects", oid[0:2], oid[2:4], oid)
+}
+
+// storeLFSObject stores the content of an object if it matches its oid.
+func storeLFSObject(path, oid string, content io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "upload")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != oid {
+		return fmt.Errorf("content has oid %s, not %s", got, oid)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lfsPointer stores content as an object of a repo and returns the pointer
+// file that is committed in its place.
+func lfsPointer(gitDir, content string) (string, error) {
+	sum := sha256.Sum256([]byte(content))
+	oid := hex.EncodeToString(sum[:])
+	if err := storeLFSObject(lfsObjectPath(gitDir, oid), oid, strings.NewReader(content)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, len(content)), nil
+}
@@ -42,6 +42,8 @@ const (
 	barHEADsha = "c2740e40972392a7c8954b389c71733e1f900561"
 	barPR2sha  = "62d5ed7cbccce940421b7e234de28591472b6955"
 	barPR3sha  = "f8387fe902fcb0c50d6d9e67f68b9802d591fd45"
+
+	bazHEADsha = "28d5ee132ecfcaf628525f04826484b1d550b1af"
 )
 
 // TestClonerefs tests the "clonerefs" binary by creating a ProwJob with
@@ -419,6 +421,83 @@ ls-tree:
 100644 blob a0423896973644771497bdc03eb99d5281615b51	bar.txt
 160000 commit e7d762376b714fdc2d6493ecd798a9d128e4b88f	foo4
 
+ls-tree (submodule):
+100644 blob a0423896973644771497bdc03eb99d5281615b51	README.txt
+`,
+		},
+		{
+			// Check that submodules and LFS files defined declaratively are
+			// cloned.
+			name: "postsubmit-submodule-lfs-fixtures",
+			repoSetups: []fakegitserver.RepoSetup{
+				{
+					Name:      "foo5",
+					Script:    createRepoFoo,
+					Overwrite: true,
+				},
+				{
+					Name: "baz",
+					Script: `
+echo baz > baz.txt
+git add baz.txt
+git commit -m "commit 1"
+`,
+					Overwrite:  true,
+					Submodules: []fakegitserver.Submodule{{Repo: "foo5"}},
+					LFSFiles:   map[string]string{"data/blob.bin": "stored with Git LFS\n"},
+				},
+			},
+			prowjob: prowjobv1.ProwJob{
+				ObjectMeta: v1.ObjectMeta{
+					Annotations: map[string]string{
+						"prow.k8s.io/type": "postsubmit",
+					},
+					Labels: map[string]string{
+						"prow.k8s.io/type": "postsubmit",
+					},
+				},
+				Spec: prowjobv1.ProwJobSpec{
+					Type: prowjobv1.PostsubmitJob,
+					Refs: &prowjobv1.Refs{
+						Repo:     "baz",
+						BaseSHA:  bazHEADsha,
+						CloneURI: "http://fakegitserver.default/repo/baz",
+					},
+					PodSpec: &coreapi.PodSpec{
+						Containers: []coreapi.Container{
+							{
+								Args: []string{
+									"sh",
+									"-c",
+									`
+cat <<EOF
+
+HEAD: $(git rev-parse HEAD)
+
+ls-tree:
+$(git ls-tree -r HEAD)
+
+ls-tree (submodule):
+$(cd foo5 && git ls-tree HEAD)
+EOF
+`,
+								},
+							},
+						},
+					},
+				},
+			},
+			// The blob of data/blob.bin is the one of its LFS pointer file.
+			expected: `
+HEAD: 28d5ee132ecfcaf628525f04826484b1d550b1af
+
+ls-tree:
+100644 blob d1ea6b69d5994a596c008ed36c3b0289ddf3e7bc	.gitattributes
+100644 blob e25e66185fd28d5e1bf3f39c7acbc186e2a1c8b5	.gitmodules
+100644 blob 76018072e09c5d31c8c6e3113b8aa0fe625195ca	baz.txt
+100644 blob e0914a98bd3653863adc52984ab82db89bff9103	data/blob.bin
+160000 commit e7d762376b714fdc2d6493ecd798a9d128e4b88f	foo5
+
 ls-tree (submodule):
 100644 blob a0423896973644771497bdc03eb99d5281615b51	README.txt
 `,
@@ -498,11 +577,8 @@ ls-tree (submodule):
 			}
 
 			// Set up repos on FGS for just this test case.
-			for _, repoSetup := range tt.repoSetups {
-				err := fgsClient.SetupRepo(repoSetup)
-				if err != nil {
-					t.Fatalf("FGS repo setup failed: %v", err)
-				}
+			if err := fgsClient.SetupRepos(tt.repoSetups...); err != nil {
+				t.Fatalf("FGS repo setup failed: %v", err)
 			}
 
 			t.Logf("Creating prowjob: %s", podName)